@@ -0,0 +1,23 @@
+// Command agentgo runs, chats with and serves an agent defined in a YAML or
+// TOML file, without writing a main.go.
+//
+//	agentgo run -f agent.yaml "What is 2+2?"
+//	agentgo chat -f agent.yaml
+//	agentgo serve -f agent.yaml -addr :8080
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jholhewres/agent-go/internal/cli"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := cli.Main(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 
 	"github.com/jholhewres/agent-go/pkg/agentgo/agent"
+	openaiembed "github.com/jholhewres/agent-go/pkg/agentgo/embeddings/openai"
 	"github.com/jholhewres/agent-go/pkg/agentgo/learning"
 	"github.com/jholhewres/agent-go/pkg/agentgo/learning/sqlite"
 	"github.com/jholhewres/agent-go/pkg/agentgo/models/openai"
@@ -36,6 +37,16 @@ func main() {
 		log.Fatalf("Failed to create learning machine: %v", err)
 	}
 
+	// Search the user's memories by meaning, so each run gets the relevant ones
+	embedder, err := openaiembed.New(openaiembed.Config{APIKey: os.Getenv("OPENAI_API_KEY")})
+	if err != nil {
+		log.Fatalf("Failed to create embedder: %v", err)
+	}
+	memorySearcher, err := learning.NewMemorySearcher(learningStorage, embedder, learning.MemorySearchConfig{})
+	if err != nil {
+		log.Fatalf("Failed to create memory searcher: %v", err)
+	}
+
 	// Create agent with learning enabled
 	ag, err := agent.New(agent.Config{
 		Name:            "Learning Assistant",
@@ -45,6 +56,7 @@ func main() {
 		UserID:          "user-123", // Required for learning
 		Learning:        true,       // Enable learning
 		LearningMachine: learningMachine,
+		UserMemories:    memorySearcher, // Inject the memories relevant to each message
 	})
 	if err != nil {
 		log.Fatalf("Failed to create agent: %v", err)
@@ -60,7 +72,7 @@ func main() {
 	}
 	fmt.Printf("Agent: %s\n\n", output1.Content)
 
-	// Second interaction - agent should remember
+	// Second interaction - the relevant memories are in the system prompt
 	fmt.Println("=== Second Interaction ===")
 	output2, err := ag.Run(ctx, "What's my name?")
 	if err != nil {
@@ -77,13 +89,13 @@ func main() {
 		fmt.Printf("User Profile: %+v\n", profile)
 	}
 
-	memories, err := learningMachine.GetUserMemories(ctx, "user-123", 10)
+	memories, err := memorySearcher.SearchUserMemories(ctx, "user-123", "answer style", 5)
 	if err != nil {
-		log.Printf("No memories found: %v", err)
+		log.Printf("Memory search failed: %v", err)
 	} else {
-		fmt.Printf("\nUser Memories (%d):\n", len(memories))
+		fmt.Printf("\nMemories about answer style (%d):\n", len(memories))
 		for _, mem := range memories {
-			fmt.Printf("  - [%s] %s\n", mem.Type, mem.Content)
+			fmt.Printf("  - [%s %.2f] %s\n", mem.Type, mem.Score, mem.Content)
 		}
 	}
 }
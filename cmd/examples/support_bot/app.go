@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/agent"
+	"github.com/jholhewres/agent-go/pkg/agentgo/embeddings"
+	"github.com/jholhewres/agent-go/pkg/agentgo/guardrails"
+	"github.com/jholhewres/agent-go/pkg/agentgo/hooks"
+	"github.com/jholhewres/agent-go/pkg/agentgo/knowledge"
+	"github.com/jholhewres/agent-go/pkg/agentgo/learning"
+	learningpg "github.com/jholhewres/agent-go/pkg/agentgo/learning/postgres"
+	learningsqlite "github.com/jholhewres/agent-go/pkg/agentgo/learning/sqlite"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/storage"
+	storagepg "github.com/jholhewres/agent-go/pkg/agentgo/storage/postgres"
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb/memvec"
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb/pgvector"
+)
+
+const supportInstructions = `You are the customer support assistant of Acme Cloud.
+Answer from the knowledge base excerpts you are given and cite the document you used.
+If the excerpts do not answer the question, say so and offer to open a ticket.
+Keep answers short and never ask customers for passwords or full card numbers.`
+
+const refusalMessage = "I couldn't find that in our help center. Reply \"ticket\" and a support engineer will follow up."
+
+// Config holds the settings of the support bot
+type Config struct {
+	// DatabaseURL selects Postgres for sessions, learning and the knowledge
+	// base. When empty, everything runs in-process and is kept under DataDir.
+	DatabaseURL string
+
+	// DataDir holds the local stores used without a database (default: ./data)
+	DataDir string
+
+	// DocsDir is ingested into the knowledge base (markdown files)
+	DocsDir string
+
+	// SitemapURL, when set, adds the pages of a public help center
+	SitemapURL string
+
+	// PolicyPath is the guardrail policy file; it is reloaded when it changes
+	PolicyPath string
+
+	// HistoryRuns is the number of previous runs restored into a conversation (default: 10)
+	HistoryRuns int
+
+	// ConfidenceThreshold is the score the best retrieved chunk must reach for
+	// the bot to answer; below it the bot refuses instead of guessing (default: 0.35)
+	ConfidenceThreshold float64
+}
+
+// App holds the long-lived dependencies shared by every conversation. Agents
+// are cheap and built per request by NewAgent: all conversation state lives
+// in the stores, so any replica can serve any session.
+type App struct {
+	cfg       Config
+	model     models.Model
+	logger    *slog.Logger
+	db        *sql.DB // nil without DatabaseURL
+	vectors   vectordb.VectorDB
+	kb        *knowledge.KnowledgeBase
+	runs      storage.SessionStorage
+	learning  *learning.Machine
+	guardrail *guardrails.PolicyGuardrail
+	closers   []func() error
+}
+
+// NewApp connects the stores, creates the knowledge base and loads the
+// guardrail policy. dimension is the size of the embedder's vectors.
+func NewApp(ctx context.Context, cfg Config, model models.Model, embedder embeddings.Embedder, dimension int, logger *slog.Logger) (*App, error) {
+	if model == nil {
+		return nil, fmt.Errorf("model is required")
+	}
+	if embedder == nil {
+		return nil, fmt.Errorf("embedder is required")
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if cfg.DataDir == "" {
+		cfg.DataDir = "./data"
+	}
+	if cfg.HistoryRuns <= 0 {
+		cfg.HistoryRuns = 10
+	}
+	if cfg.ConfidenceThreshold == 0 {
+		cfg.ConfidenceThreshold = 0.35
+	}
+
+	app := &App{cfg: cfg, model: model, logger: logger}
+
+	var err error
+	if cfg.DatabaseURL != "" {
+		err = app.openPostgres(ctx, embedder, dimension)
+	} else {
+		logger.Warn("DATABASE_URL not set, using in-process stores", "data_dir", cfg.DataDir)
+		err = app.openLocal(embedder, dimension)
+	}
+	if err != nil {
+		app.Close()
+		return nil, err
+	}
+
+	var stateStore knowledge.StateStore = knowledge.NewFileStateStore(filepath.Join(cfg.DataDir, "kb-state.json"))
+	if app.db != nil {
+		stateStore = &pgStateStore{db: app.db, key: "support_bot"}
+	}
+	app.kb, err = knowledge.NewKnowledgeBase(knowledge.KnowledgeBaseConfig{
+		VectorDB:   app.vectors,
+		Chunker:    knowledge.NewMarkdownChunker(800, 100),
+		Embedder:   embedder,
+		StateStore: stateStore,
+	})
+	if err != nil {
+		app.Close()
+		return nil, fmt.Errorf("create knowledge base: %w", err)
+	}
+	if cfg.DocsDir != "" {
+		app.kb.AddSource(knowledge.NewDirectoryLoader(cfg.DocsDir, "*.md", true))
+	}
+	if cfg.SitemapURL != "" {
+		sitemap := knowledge.NewSitemapLoader(cfg.SitemapURL)
+		sitemap.RequestsPerSecond = 2
+		app.kb.AddSource(sitemap)
+	}
+
+	policy := defaultPolicy()
+	if cfg.PolicyPath != "" {
+		if policy, err = guardrails.LoadPolicy(cfg.PolicyPath); err != nil {
+			app.Close()
+			return nil, fmt.Errorf("load guardrail policy: %w", err)
+		}
+	}
+	if app.guardrail, err = guardrails.NewPolicyGuardrail(policy); err != nil {
+		app.Close()
+		return nil, fmt.Errorf("create guardrails: %w", err)
+	}
+
+	return app, nil
+}
+
+// openPostgres uses one connection pool for pgvector, run storage, learning
+// and the knowledge base sync state. Every store creates its tables.
+func (a *App) openPostgres(ctx context.Context, embedder embeddings.Embedder, dimension int) error {
+	db, err := sql.Open("postgres", a.cfg.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+	db.SetMaxOpenConns(20)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(30 * time.Minute)
+	a.db = db
+	a.closers = append(a.closers, db.Close)
+
+	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := db.PingContext(pingCtx); err != nil {
+		return fmt.Errorf("connect to database: %w", err)
+	}
+
+	if a.vectors, err = pgvector.New(pgvector.Config{
+		DB:             db,
+		TableName:      "support_bot_chunks",
+		CollectionName: "help_center",
+		Dimension:      dimension,
+		EmbeddingFunc:  embedder,
+	}); err != nil {
+		return fmt.Errorf("create pgvector store: %w", err)
+	}
+	if a.runs, err = storagepg.New(ctx, db, storagepg.Config{Table: "support_bot_runs"}); err != nil {
+		return fmt.Errorf("create run storage: %w", err)
+	}
+	learningStore, err := learningpg.New(db, "public")
+	if err != nil {
+		return fmt.Errorf("create learning storage: %w", err)
+	}
+	if a.learning, err = learning.NewMachine(learningStore); err != nil {
+		return fmt.Errorf("create learning machine: %w", err)
+	}
+	if err := migrateStateStore(ctx, db); err != nil {
+		return fmt.Errorf("create sync state table: %w", err)
+	}
+	return nil
+}
+
+// openLocal keeps vectors in a memvec file, runs in memory and learning in
+// SQLite, for development and tests
+func (a *App) openLocal(embedder embeddings.Embedder, dimension int) error {
+	if err := os.MkdirAll(a.cfg.DataDir, 0o755); err != nil {
+		return fmt.Errorf("create data directory: %w", err)
+	}
+
+	vectors, err := memvec.New(memvec.Config{
+		CollectionName:    "help_center",
+		Dimension:         dimension,
+		EmbeddingFunction: embedder,
+		Path:              filepath.Join(a.cfg.DataDir, "vectors.gob"),
+	})
+	if err != nil {
+		return fmt.Errorf("create vector store: %w", err)
+	}
+	a.vectors = vectors
+	a.closers = append(a.closers, vectors.Close)
+
+	a.runs = storage.NewMemoryStorage()
+
+	learningStore, err := learningsqlite.New(filepath.Join(a.cfg.DataDir, "learning.db"))
+	if err != nil {
+		return fmt.Errorf("create learning storage: %w", err)
+	}
+	a.closers = append(a.closers, learningStore.Close)
+	if a.learning, err = learning.NewMachine(learningStore); err != nil {
+		return fmt.Errorf("create learning machine: %w", err)
+	}
+	return nil
+}
+
+// NewAgent builds the agent serving one conversation. The session's previous
+// runs are restored from storage on its first run.
+func (a *App) NewAgent(userID, sessionID string) (*agent.Agent, error) {
+	return agent.New(agent.Config{
+		ID:                           "acme-support",
+		Name:                         "Acme Support",
+		Model:                        a.model,
+		Instructions:                 supportInstructions,
+		UserID:                       userID,
+		Logger:                       a.logger,
+		Knowledge:                    a.kb,
+		KnowledgeLimit:               4,
+		KnowledgeStrict:              true,
+		KnowledgeConfidenceThreshold: a.cfg.ConfidenceThreshold,
+		KnowledgeRefusalMessage:      refusalMessage,
+		Learning:                     true,
+		LearningMachine:              a.learning,
+		PreHooks:                     []hooks.Hook{a.guardrail},
+		SessionID:                    sessionID,
+		SessionStorage:               a.runs,
+		HistoryMaxRuns:               a.cfg.HistoryRuns,
+		StreamOptions:                agent.StreamOptions{Overflow: agent.StreamOverflowCoalesce},
+	})
+}
+
+// Sync ingests new and changed help center documents
+func (a *App) Sync(ctx context.Context) (*knowledge.SyncStats, error) {
+	return a.kb.Sync(ctx)
+}
+
+// Ping reports whether the database is reachable
+func (a *App) Ping(ctx context.Context) error {
+	if a.db == nil {
+		return nil
+	}
+	return a.db.PingContext(ctx)
+}
+
+// Guardrail returns the policy guardrail, for hot reloading
+func (a *App) Guardrail() *guardrails.PolicyGuardrail {
+	return a.guardrail
+}
+
+// Close releases the stores in reverse order of creation
+func (a *App) Close() error {
+	var firstErr error
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		if err := a.closers[i](); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	a.closers = nil
+	return firstErr
+}
+
+// defaultPolicy blocks prompt injection and payment card numbers when no
+// policy file is configured
+func defaultPolicy() *guardrails.Policy {
+	return &guardrails.Policy{
+		PromptInjection: &guardrails.PromptInjectionPolicy{UseDefaults: true},
+		PII: &guardrails.PIIPolicy{
+			Types:  []guardrails.PIIType{guardrails.PIITypeCreditCard, guardrails.PIITypeSSN},
+			Action: "block",
+		},
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/eval"
+	"github.com/jholhewres/agent-go/pkg/agentgo/ids"
+)
+
+// evalCase is one entry of the eval cases file
+type evalCase struct {
+	Name     string   `json:"name"`
+	Input    string   `json:"input"`
+	Expected string   `json:"expected"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// loadEvalCases reads a JSON array of cases
+func loadEvalCases(path string) ([]evalCase, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read eval cases: %w", err)
+	}
+	var cases []evalCase
+	if err := json.Unmarshal(data, &cases); err != nil {
+		return nil, fmt.Errorf("decode eval cases %s: %w", path, err)
+	}
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("no eval cases in %s", path)
+	}
+	return cases, nil
+}
+
+// RunEvals answers every case in a fresh conversation, exactly like the chat
+// endpoint does, and scores the answers. Expected values are substrings of
+// the answer (case-insensitive).
+func (a *App) RunEvals(ctx context.Context, cases []evalCase, maxLatency time.Duration) (map[string]*eval.Report, error) {
+	runs := make([]*eval.EvalRun, 0, len(cases))
+	for _, tc := range cases {
+		ag, err := a.NewAgent("eval", ids.Prefixed("eval"))
+		if err != nil {
+			return nil, err
+		}
+
+		start := time.Now()
+		out, err := ag.Run(ctx, tc.Input)
+		runs = append(runs, &eval.EvalRun{
+			Input:          tc.Input,
+			ExpectedOutput: tc.Expected,
+			Output:         out,
+			Duration:       time.Since(start),
+			Err:            err,
+			Metadata:       map[string]any{"case_name": tc.Name, "tags": tc.Tags},
+		})
+	}
+
+	evaluators := []eval.Evaluator{
+		eval.NewAccuracyEvaluator(eval.MatchContains),
+		eval.NewReliabilityEvaluator(),
+		eval.NewPerformanceEvaluator(eval.PerformanceConfig{MaxLatencyMs: float64(maxLatency.Milliseconds())}),
+	}
+	reports := make(map[string]*eval.Report, len(evaluators))
+	for _, ev := range evaluators {
+		report, err := ev.Evaluate(ctx, runs)
+		if err != nil {
+			return nil, fmt.Errorf("evaluator %q failed: %w", ev.Name(), err)
+		}
+		reports[ev.Name()] = report
+	}
+	return reports, nil
+}
@@ -0,0 +1,223 @@
+// Package main is a reference customer support bot that wires the whole
+// package together: Postgres storage for conversations and learning, a
+// pgvector knowledge base with incremental ingestion, guardrails loaded from a
+// hot-reloaded policy file, an HTTP API with SSE streaming, and evals that can
+// gate deployments in CI.
+//
+// Usage:
+//
+//	support_bot serve   [flags]  ingest the docs, then serve the HTTP API
+//	support_bot ingest  [flags]  sync the knowledge base and exit
+//	support_bot eval    [flags]  run the eval cases and exit non-zero below -min-pass
+//
+// Environment:
+//
+//	OPENAI_API_KEY          required (chat model and embeddings)
+//	DATABASE_URL            Postgres with the pgvector extension; unset = in-process stores under -data
+//	SUPPORT_BOT_MODEL       chat model (default: gpt-4o-mini)
+//	SUPPORT_BOT_ADMIN_TOKEN bearer token for POST /v1/ingest
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/embeddings"
+	openaiembed "github.com/jholhewres/agent-go/pkg/agentgo/embeddings/openai"
+	"github.com/jholhewres/agent-go/pkg/agentgo/eval"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models/openai"
+	"github.com/jholhewres/agent-go/pkg/agentgo/reload"
+)
+
+func main() {
+	command := "serve"
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		command, args = args[0], args[1:]
+	}
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	addr := flags.String("addr", ":8080", "HTTP listen address")
+	docsDir := flags.String("docs", "cmd/examples/support_bot/docs", "markdown help center to ingest")
+	sitemapURL := flags.String("sitemap", "", "sitemap.xml of a public help center to ingest")
+	policyPath := flags.String("policy", "cmd/examples/support_bot/policy.yaml", "guardrail policy file (reloaded on change)")
+	dataDir := flags.String("data", "./data", "local stores used when DATABASE_URL is unset")
+	casesPath := flags.String("cases", "cmd/examples/support_bot/evals/cases.json", "eval cases")
+	junitPath := flags.String("junit", "", "write the eval results as JUnit XML to this file")
+	minPass := flags.Float64("min-pass", 0.8, "minimum pass rate of every evaluator")
+	maxLatency := flags.Duration("max-latency", 20*time.Second, "p99 latency budget checked by the evals")
+	flags.Parse(args)
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if apiKey == "" {
+		log.Fatal("OPENAI_API_KEY environment variable is required")
+	}
+	modelID := os.Getenv("SUPPORT_BOT_MODEL")
+	if modelID == "" {
+		modelID = "gpt-4o-mini"
+	}
+	model, err := openai.New(modelID, openai.Config{APIKey: apiKey})
+	if err != nil {
+		log.Fatalf("create model: %v", err)
+	}
+	embedder, dimension, err := newEmbedder(apiKey)
+	if err != nil {
+		log.Fatalf("create embedder: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	app, err := NewApp(ctx, Config{
+		DatabaseURL: os.Getenv("DATABASE_URL"),
+		DataDir:     *dataDir,
+		DocsDir:     *docsDir,
+		SitemapURL:  *sitemapURL,
+		PolicyPath:  *policyPath,
+	}, model, embedder, dimension, logger)
+	if err != nil {
+		log.Fatalf("start support bot: %v", err)
+	}
+	defer app.Close()
+
+	switch command {
+	case "ingest":
+		if err := ingest(ctx, app, logger); err != nil {
+			app.Close()
+			log.Fatal(err)
+		}
+	case "eval":
+		if err := runEvals(ctx, app, *casesPath, *junitPath, *minPass, *maxLatency); err != nil {
+			app.Close()
+			log.Fatal(err)
+		}
+	case "serve":
+		if err := ingest(ctx, app, logger); err != nil {
+			// Serve what was ingested before; POST /v1/ingest retries later
+			logger.Error("initial ingestion failed", "error", err)
+		}
+		if err := serve(ctx, app, *addr, *policyPath, logger); err != nil {
+			app.Close()
+			log.Fatal(err)
+		}
+	default:
+		log.Fatalf("unknown command %q (want serve, ingest or eval)", command)
+	}
+}
+
+// newEmbedder batches and caches OpenAI embeddings, so re-ingesting unchanged
+// chunks and repeated questions do not call the API again
+func newEmbedder(apiKey string) (embeddings.Embedder, int, error) {
+	provider, err := openaiembed.New(openaiembed.Config{APIKey: apiKey, Model: "text-embedding-3-small"})
+	if err != nil {
+		return nil, 0, err
+	}
+	client, err := embeddings.New(embeddings.Config{
+		Embedder:          provider,
+		BatchSize:         96,
+		MaxConcurrency:    2,
+		Cache:             embeddings.NewMemoryCache(10000, time.Hour),
+		RequestsPerMinute: 3000,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return client, provider.GetDimensions(), nil
+}
+
+func ingest(ctx context.Context, app *App, logger *slog.Logger) error {
+	start := time.Now()
+	stats, err := app.Sync(ctx)
+	if err != nil {
+		return fmt.Errorf("ingest knowledge: %w", err)
+	}
+	logger.Info("knowledge base synced",
+		"documents", stats.Documents,
+		"added", stats.Added,
+		"updated", stats.Updated,
+		"removed", stats.Removed,
+		"chunks", stats.Chunks,
+		"duration", time.Since(start).String())
+	return nil
+}
+
+func serve(ctx context.Context, app *App, addr, policyPath string, logger *slog.Logger) error {
+	// Policy edits apply without a restart; an invalid file keeps the previous policy
+	if policyPath != "" {
+		watcher := reload.NewWatcher(reload.Config{Logger: logger})
+		if err := watcher.Watch(policyPath, reload.GuardrailPolicy(app.Guardrail())); err != nil {
+			return err
+		}
+		go watcher.Run(ctx)
+	}
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           NewServer(app, os.Getenv("SUPPORT_BOT_ADMIN_TOKEN"), logger).Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		logger.Info("support bot listening", "addr", addr)
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Info("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func runEvals(ctx context.Context, app *App, casesPath, junitPath string, minPass float64, maxLatency time.Duration) error {
+	cases, err := loadEvalCases(casesPath)
+	if err != nil {
+		return err
+	}
+	reports, err := app.RunEvals(ctx, cases, maxLatency)
+	if err != nil {
+		return err
+	}
+
+	if err := eval.WriteJSON(os.Stdout, reports); err != nil {
+		return err
+	}
+	if junitPath != "" {
+		var buf bytes.Buffer
+		if err := eval.WriteJUnit(&buf, reports, "support_bot"); err != nil {
+			return err
+		}
+		if err := os.WriteFile(junitPath, buf.Bytes(), 0o644); err != nil {
+			return fmt.Errorf("write JUnit report: %w", err)
+		}
+	}
+
+	for name, report := range reports {
+		if report.PassRate < minPass {
+			return fmt.Errorf("eval %s: pass rate %.2f is below %.2f", name, report.PassRate, minPass)
+		}
+	}
+	return nil
+}
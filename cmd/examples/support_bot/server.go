@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/agent"
+	"github.com/jholhewres/agent-go/pkg/agentgo/ids"
+	"github.com/jholhewres/agent-go/pkg/agentgo/run"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// userHeader carries the authenticated customer. The bot is meant to run
+// behind the gateway that authenticates customers and sets it.
+const userHeader = "X-User-ID"
+
+type chatRequest struct {
+	SessionID string `json:"session_id,omitempty"`
+	Message   string `json:"message"`
+}
+
+type chatResponse struct {
+	SessionID string             `json:"session_id"`
+	RunID     string             `json:"run_id"`
+	Content   string             `json:"content"`
+	Refused   bool               `json:"refused,omitempty"`
+	Sources   []string           `json:"sources,omitempty"`
+	Warnings  []agent.RunWarning `json:"warnings,omitempty"`
+	Usage     types.Usage        `json:"usage"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
+// Server exposes the support bot over HTTP:
+//
+//	POST /v1/chat    answer a message; streams SSE when the client accepts text/event-stream
+//	POST /v1/ingest  sync the knowledge base (requires the admin token)
+//	GET  /healthz    liveness and database reachability
+type Server struct {
+	app         *App
+	logger      *slog.Logger
+	adminToken  string
+	sendTimeout time.Duration
+	runTimeout  time.Duration
+}
+
+// NewServer creates the HTTP handler of the bot
+func NewServer(app *App, adminToken string, logger *slog.Logger) *Server {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Server{
+		app:         app,
+		logger:      logger,
+		adminToken:  adminToken,
+		sendTimeout: 15 * time.Second,
+		runTimeout:  2 * time.Minute,
+	}
+}
+
+// Handler returns the routes of the bot
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/chat", s.handleChat)
+	mux.HandleFunc("POST /v1/ingest", s.handleIngest)
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+	return mux
+}
+
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request) {
+	userID := strings.TrimSpace(r.Header.Get(userHeader))
+	if userID == "" {
+		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "missing " + userHeader + " header", Code: "UNAUTHENTICATED"})
+		return
+	}
+
+	var req chatRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request body", Code: "INVALID_REQUEST"})
+		return
+	}
+	if strings.TrimSpace(req.Message) == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "message is required", Code: "INVALID_REQUEST"})
+		return
+	}
+	if req.SessionID == "" {
+		req.SessionID = ids.Prefixed("sess")
+	}
+
+	// Sessions are stored per customer, so a leaked session ID never exposes
+	// another customer's conversation
+	ag, err := s.app.NewAgent(userID, userID+"/"+req.SessionID)
+	if err != nil {
+		s.logger.Error("create agent", "error", err)
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal error", Code: "INTERNAL"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.runTimeout)
+	defer cancel()
+
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		s.streamChat(ctx, cancel, w, ag, req)
+		return
+	}
+
+	output, err := ag.Run(ctx, req.Message)
+	if err != nil {
+		status, body := runError(err)
+		writeJSON(w, status, body)
+		return
+	}
+	writeJSON(w, http.StatusOK, newChatResponse(req.SessionID, output))
+}
+
+// streamChat sends the answer as SSE: "token" events while the model streams,
+// then one "done" event with the complete answer, or an "error" event.
+// Deltas are coalesced for clients that read slowly, and a client that stops
+// reading altogether is dropped after sendTimeout, cancelling its run.
+func (s *Server) streamChat(ctx context.Context, cancel context.CancelFunc, w http.ResponseWriter, ag *agent.Agent, req chatRequest) {
+	result, err := ag.RunStream(ctx, req.Message)
+	if err != nil {
+		status, body := runError(err)
+		writeJSON(w, status, body)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	send := func(event string, data any) bool {
+		payload, err := json.Marshal(data)
+		if err != nil {
+			return false
+		}
+		if err := rc.SetWriteDeadline(time.Now().Add(s.sendTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			cancel()
+			return false
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+			s.logger.Warn("dropping stream client", "session_id", req.SessionID, "error", err)
+			cancel()
+			return false
+		}
+		return rc.Flush() == nil
+	}
+
+	// Drain every event, even after the client is gone, so the run can finish
+	// and persist the conversation
+	clientGone := false
+	for evt := range result.Events {
+		if content, ok := evt.(*run.RunContentEvent); ok && content.Content != "" && !clientGone {
+			clientGone = !send("token", map[string]string{"text": content.Content})
+		}
+	}
+
+	done := <-result.Done
+	if clientGone {
+		return
+	}
+	if done.Err != nil {
+		_, body := runError(done.Err)
+		send("error", body)
+		return
+	}
+	send("done", newChatResponse(req.SessionID, done.Output))
+}
+
+func (s *Server) handleIngest(w http.ResponseWriter, r *http.Request) {
+	if s.adminToken == "" || r.Header.Get("Authorization") != "Bearer "+s.adminToken {
+		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "admin token required", Code: "UNAUTHENTICATED"})
+		return
+	}
+
+	stats, err := s.app.Sync(r.Context())
+	if err != nil {
+		s.logger.Error("knowledge sync failed", "error", err)
+		writeJSON(w, http.StatusBadGateway, errorResponse{Error: err.Error(), Code: "SYNC_FAILED"})
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+	if err := s.app.Ping(ctx); err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "degraded", "database": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func newChatResponse(sessionID string, output *agent.RunOutput) chatResponse {
+	resp := chatResponse{
+		SessionID: sessionID,
+		RunID:     output.RunID,
+		Content:   output.Content,
+		Warnings:  output.Warnings,
+		Usage:     output.Usage,
+	}
+	if output.Grounding != nil {
+		resp.Refused = output.Grounding.Refused
+		resp.Sources = output.Grounding.Sources
+	}
+	return resp
+}
+
+// runError maps agent errors to HTTP responses; guardrail rejections keep
+// their specific code (PROMPT_INJECTION, PII_DETECTED, ...)
+func runError(err error) (int, errorResponse) {
+	code := ""
+	for cause := err; cause != nil; {
+		var agnoErr *types.AgnoError
+		if !errors.As(cause, &agnoErr) {
+			break
+		}
+		code = string(agnoErr.Code)
+		cause = agnoErr.Cause
+	}
+
+	switch types.ErrorCode(code) {
+	case "":
+		if errors.Is(err, context.DeadlineExceeded) {
+			return http.StatusGatewayTimeout, errorResponse{Error: "the assistant took too long to answer", Code: "TIMEOUT"}
+		}
+		return http.StatusInternalServerError, errorResponse{Error: "internal error", Code: "INTERNAL"}
+	case types.ErrCodeInvalidInput, types.ErrCodeInputCheck, types.ErrCodePromptInjection,
+		types.ErrCodePIIDetected, types.ErrCodeContentModeration:
+		return http.StatusUnprocessableEntity, errorResponse{Error: "the message was rejected by the content policy", Code: code}
+	default:
+		return http.StatusBadGateway, errorResponse{Error: "the assistant is unavailable, please retry", Code: code}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/knowledge"
+)
+
+// pgStateStore keeps the knowledge base sync state next to the vectors, so
+// every replica (and the ingest job) agrees on what was already embedded
+type pgStateStore struct {
+	db  *sql.DB
+	key string
+}
+
+var _ knowledge.StateStore = (*pgStateStore)(nil)
+
+func migrateStateStore(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS support_bot_sync_state (
+	key TEXT PRIMARY KEY,
+	state JSONB NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+)`)
+	return err
+}
+
+// LoadState returns nil before the first sync
+func (s *pgStateStore) LoadState(ctx context.Context) (*knowledge.SyncState, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, `SELECT state FROM support_bot_sync_state WHERE key = $1`, s.key).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load sync state: %w", err)
+	}
+
+	state := knowledge.NewSyncState()
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("decode sync state: %w", err)
+	}
+	if state.Sources == nil {
+		state.Sources = make(map[string]knowledge.SourceState)
+	}
+	if state.Documents == nil {
+		state.Documents = make(map[string]knowledge.DocumentState)
+	}
+	return state, nil
+}
+
+// SaveState replaces the stored state
+func (s *pgStateStore) SaveState(ctx context.Context, state *knowledge.SyncState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("encode sync state: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, `INSERT INTO support_bot_sync_state (key, state, updated_at) VALUES ($1, $2, NOW())
+ON CONFLICT (key) DO UPDATE SET state = EXCLUDED.state, updated_at = EXCLUDED.updated_at`, s.key, data)
+	if err != nil {
+		return fmt.Errorf("save sync state: %w", err)
+	}
+	return nil
+}
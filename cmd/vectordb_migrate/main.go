@@ -12,14 +12,21 @@ import (
 
 func main() {
 	var (
-		action       = flag.String("action", "up", "Migration action: up|down")
-		provider     = flag.String("provider", "chroma", "VectorDB provider: chroma")
+		action       = flag.String("action", "up", "Migration action: up|down|reindex|vacuum|copy")
+		provider     = flag.String("provider", "chroma", "VectorDB provider: chroma|pgvector|redis")
 		collection   = flag.String("collection", "", "Collection name")
 		chromaURL    = flag.String("chroma-url", os.Getenv("CHROMA_URL"), "Chroma base URL (default from CHROMA_URL)")
 		chromaTenant = flag.String("chroma-tenant", os.Getenv("CHROMA_TENANT"), "Chroma tenant")
 		chromaDB     = flag.String("chroma-db", os.Getenv("CHROMA_DB"), "Chroma database")
+		pgDSN        = flag.String("pg-dsn", os.Getenv("DATABASE_URL"), "PostgreSQL DSN for pgvector (default from DATABASE_URL)")
+		pgTable      = flag.String("pg-table", "", "pgvector table (default vector_documents)")
+		dimension    = flag.Int("dimension", 0, "Embedding dimension; required to create a pgvector table, inferred by copy")
 		distance     = flag.String("distance", os.Getenv("VECTOR_DISTANCE"), "Distance function: l2|cosine|ip")
-		timeout      = flag.Duration("timeout", 30*time.Second, "Operation timeout")
+		from         = flag.String("from", "", "copy: source provider")
+		to           = flag.String("to", "", "copy: destination provider")
+		toCollection = flag.String("to-collection", "", "copy: destination collection (default --collection)")
+		batchSize    = flag.Int("batch-size", 256, "copy: documents per batch")
+		timeout      = flag.Duration("timeout", 30*time.Second, "Operation timeout (0 for none)")
 	)
 	flag.Parse()
 
@@ -35,10 +42,17 @@ func main() {
 		ChromaTenant:   *chromaTenant,
 		ChromaDatabase: *chromaDB,
 		Distance:       *distance,
+		PostgresDSN:    *pgDSN,
+		PostgresTable:  *pgTable,
+		Dimension:      *dimension,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
-	defer cancel()
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
 
 	var err error
 	switch *action {
@@ -46,8 +60,34 @@ func main() {
 		err = migrate.Up(ctx, opts)
 	case "down":
 		err = migrate.Down(ctx, opts)
+	case "reindex":
+		err = migrate.Reindex(ctx, opts)
+	case "vacuum":
+		err = migrate.Vacuum(ctx, opts)
+	case "copy":
+		if *from == "" || *to == "" {
+			fmt.Fprintln(os.Stderr, "copy requires --from and --to")
+			os.Exit(2)
+		}
+		src, dst := opts, opts
+		src.Provider, dst.Provider = *from, *to
+		if *toCollection != "" {
+			dst.Collection = *toCollection
+		}
+		var progress migrate.CopyProgress
+		progress, err = migrate.Copy(ctx, migrate.CopyOptions{
+			From:      src,
+			To:        dst,
+			BatchSize: *batchSize,
+			OnProgress: func(p migrate.CopyProgress) {
+				fmt.Fprintf(os.Stderr, "copied %d/%d\n", p.Copied, p.Total)
+			},
+		})
+		if err == nil {
+			fmt.Printf("copied %d documents\n", progress.Copied)
+		}
 	default:
-		fmt.Fprintln(os.Stderr, "invalid --action, expected up|down")
+		fmt.Fprintln(os.Stderr, "invalid --action, expected up|down|reindex|vacuum|copy")
 		os.Exit(2)
 	}
 	if err != nil {
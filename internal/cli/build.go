@@ -0,0 +1,268 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/agent"
+	"github.com/jholhewres/agent-go/pkg/agentgo/embeddings/openai"
+	"github.com/jholhewres/agent-go/pkg/agentgo/events"
+	"github.com/jholhewres/agent-go/pkg/agentgo/knowledge"
+	"github.com/jholhewres/agent-go/pkg/agentgo/memory"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models/anthropic"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models/deepseek"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models/gemini"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models/groq"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models/keypool"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models/mistral"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models/ollama"
+	openaimodel "github.com/jholhewres/agent-go/pkg/agentgo/models/openai"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models/openrouter"
+	"github.com/jholhewres/agent-go/pkg/agentgo/storage"
+	"github.com/jholhewres/agent-go/pkg/agentgo/storage/sqlite"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb/memvec"
+
+	// Built-in toolkits register themselves in toolkit.DefaultRegistry
+	_ "github.com/jholhewres/agent-go/pkg/agentgo/tools/arxiv"
+	_ "github.com/jholhewres/agent-go/pkg/agentgo/tools/calculator"
+	_ "github.com/jholhewres/agent-go/pkg/agentgo/tools/csv"
+	_ "github.com/jholhewres/agent-go/pkg/agentgo/tools/file"
+	_ "github.com/jholhewres/agent-go/pkg/agentgo/tools/hackernews"
+	_ "github.com/jholhewres/agent-go/pkg/agentgo/tools/httptool"
+	_ "github.com/jholhewres/agent-go/pkg/agentgo/tools/pubmed"
+	_ "github.com/jholhewres/agent-go/pkg/agentgo/tools/search"
+	_ "github.com/jholhewres/agent-go/pkg/agentgo/tools/websearch"
+)
+
+// ModelFactory creates the model of a definition
+type ModelFactory func(spec ModelSpec) (models.Model, error)
+
+// ModelProviders maps model.provider values to factories; tests and custom
+// builds may add their own
+var ModelProviders = map[string]ModelFactory{
+	"openai": func(s ModelSpec) (models.Model, error) {
+		return openaimodel.New(s.ID, openaimodel.Config{APIKey: apiKey(s, "OPENAI_API_KEY"), BaseURL: s.BaseURL, Temperature: s.Temperature, MaxTokens: s.MaxTokens})
+	},
+	"anthropic": func(s ModelSpec) (models.Model, error) {
+		return anthropic.New(s.ID, anthropic.Config{APIKey: apiKey(s, "ANTHROPIC_API_KEY"), BaseURL: s.BaseURL, Temperature: s.Temperature, MaxTokens: s.MaxTokens})
+	},
+	"gemini": func(s ModelSpec) (models.Model, error) {
+		return gemini.New(s.ID, gemini.Config{APIKey: apiKey(s, "GEMINI_API_KEY"), BaseURL: s.BaseURL, Temperature: s.Temperature, MaxTokens: s.MaxTokens})
+	},
+	"groq": func(s ModelSpec) (models.Model, error) {
+		return groq.New(s.ID, groq.Config{APIKey: apiKey(s, "GROQ_API_KEY"), BaseURL: s.BaseURL, Temperature: s.Temperature, MaxTokens: s.MaxTokens})
+	},
+	"deepseek": func(s ModelSpec) (models.Model, error) {
+		return deepseek.New(s.ID, deepseek.Config{APIKey: apiKey(s, "DEEPSEEK_API_KEY"), BaseURL: s.BaseURL, Temperature: s.Temperature, MaxTokens: s.MaxTokens})
+	},
+	"mistral": func(s ModelSpec) (models.Model, error) {
+		return mistral.New(s.ID, mistral.Config{APIKey: apiKey(s, "MISTRAL_API_KEY"), BaseURL: s.BaseURL, Temperature: s.Temperature, MaxTokens: s.MaxTokens})
+	},
+	"openrouter": func(s ModelSpec) (models.Model, error) {
+		return openrouter.New(s.ID, openrouter.Config{APIKey: apiKey(s, "OPENROUTER_API_KEY"), BaseURL: s.BaseURL, Temperature: s.Temperature, MaxTokens: s.MaxTokens})
+	},
+	"ollama": func(s ModelSpec) (models.Model, error) {
+		return ollama.New(s.ID, ollama.Config{BaseURL: s.BaseURL, Temperature: s.Temperature, MaxTokens: s.MaxTokens})
+	},
+}
+
+// apiKey returns the key of the spec, or the provider's environment variable
+func apiKey(s ModelSpec, env string) string {
+	if s.APIKey != "" {
+		return s.APIKey
+	}
+	return os.Getenv(env)
+}
+
+// buildModel creates the model of a spec, pooling its keys when it has several
+func buildModel(s ModelSpec) (models.Model, error) {
+	factory := ModelProviders[s.Provider]
+	if len(s.APIKeys) == 0 {
+		return factory(s)
+	}
+	return keypool.New(keypool.Config{
+		Keys: s.APIKeys,
+		New: func(key string) (models.Model, error) {
+			spec := s
+			spec.APIKey, spec.APIKeys = key, nil
+			return factory(spec)
+		},
+	})
+}
+
+// defaultSessionID is used by the sqlite backend when the definition has none
+const defaultSessionID = "default"
+
+// Instance is an agent built from a definition, with the resources it owns
+type Instance struct {
+	Agent *agent.Agent
+	// Events receives the run, model and tool events of the agent
+	Events  *events.Bus
+	closers []func() error
+}
+
+// Close releases the resources of the agent, saving the knowledge index
+func (in *Instance) Close() error {
+	var errs []error
+	for i := len(in.closers) - 1; i >= 0; i-- {
+		errs = append(errs, in.closers[i]())
+	}
+	in.closers = nil
+	return errors.Join(errs...)
+}
+
+// Build creates the agent of a definition. When the definition has
+// knowledge sources they are synced before Build returns.
+func Build(ctx context.Context, def *Definition, logger *slog.Logger) (_ *Instance, err error) {
+	if err := def.Validate(); err != nil {
+		return nil, err
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	in := &Instance{Events: events.NewBus()}
+	defer func() {
+		if err != nil {
+			in.Close()
+		}
+	}()
+
+	model, err := buildModel(def.Model)
+	if err != nil {
+		return nil, fmt.Errorf("create %s model: %w", def.Model.Provider, err)
+	}
+
+	cfg := agent.Config{
+		ID:           def.ID,
+		Name:         def.Name,
+		Model:        model,
+		Instructions: def.Instructions,
+		MaxLoops:     def.MaxLoops,
+		Memory:       memory.NewInMemory(def.Memory.MaxMessages),
+		Logger:       logger,
+		Events:       in.Events,
+	}
+	for _, name := range def.Toolkits {
+		tk, err := toolkit.DefaultRegistry.New(name, def.ToolkitOptions[name])
+		if err != nil {
+			return nil, err
+		}
+		cfg.Toolkits = append(cfg.Toolkits, tk)
+	}
+
+	if def.Memory.Backend == "sqlite" {
+		cfg.SessionStorage, err = in.openSQLite(def.Memory.Path)
+		if err != nil {
+			return nil, err
+		}
+		cfg.SessionID = def.Memory.SessionID
+		if cfg.SessionID == "" {
+			cfg.SessionID = defaultSessionID
+		}
+	}
+
+	if def.Knowledge != nil {
+		kb, err := in.openKnowledge(ctx, def.Knowledge, logger)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Knowledge = kb
+		cfg.KnowledgeLimit = def.Knowledge.Limit
+	}
+
+	if in.Agent, err = agent.New(cfg); err != nil {
+		return nil, fmt.Errorf("create agent: %w", err)
+	}
+	return in, nil
+}
+
+// openSQLite opens the session storage of the sqlite memory backend
+func (in *Instance) openSQLite(path string) (storage.SessionStorage, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create memory directory: %w", err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("open memory database: %w", err)
+	}
+	in.closers = append(in.closers, db.Close)
+	store, err := sqlite.New(db, sqlite.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("create session storage: %w", err)
+	}
+	return store, nil
+}
+
+// openKnowledge creates the knowledge base and syncs its sources
+func (in *Instance) openKnowledge(ctx context.Context, spec *KnowledgeSpec, logger *slog.Logger) (*knowledge.KnowledgeBase, error) {
+	key := spec.Embedder.APIKey
+	if key == "" {
+		key = os.Getenv("OPENAI_API_KEY")
+	}
+	embedder, err := openai.New(openai.Config{APIKey: key, Model: spec.Embedder.Model, BaseURL: spec.Embedder.BaseURL})
+	if err != nil {
+		return nil, fmt.Errorf("create embedder: %w", err)
+	}
+
+	var store knowledge.StateStore
+	if spec.Index != "" {
+		if err := os.MkdirAll(filepath.Dir(spec.Index), 0o755); err != nil {
+			return nil, fmt.Errorf("create knowledge index directory: %w", err)
+		}
+		store = knowledge.NewFileStateStore(spec.Index + ".state.json")
+	}
+	vectors, err := memvec.New(memvec.Config{EmbeddingFunction: embedder, Path: spec.Index})
+	if err != nil {
+		return nil, fmt.Errorf("create knowledge index: %w", err)
+	}
+	in.closers = append(in.closers, vectors.Close)
+
+	kb, err := knowledge.NewKnowledgeBase(knowledge.KnowledgeBaseConfig{
+		VectorDB:   vectors,
+		Embedder:   embedder,
+		StateStore: store,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create knowledge base: %w", err)
+	}
+	for _, src := range spec.Sources {
+		loader, err := sourceLoader(src)
+		if err != nil {
+			return nil, err
+		}
+		kb.AddSource(loader)
+	}
+
+	stats, err := kb.Sync(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("sync knowledge: %w", err)
+	}
+	logger.Info("knowledge synced", "documents", stats.Documents, "added", stats.Added,
+		"updated", stats.Updated, "removed", stats.Removed)
+	return kb, nil
+}
+
+// sourceLoader returns the loader of a knowledge source
+func sourceLoader(src SourceSpec) (knowledge.Loader, error) {
+	if src.URL != "" {
+		return knowledge.NewURLLoader(src.URL), nil
+	}
+	info, err := os.Stat(src.Path)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge source: %w", err)
+	}
+	if info.IsDir() {
+		return knowledge.NewDirectoryLoader(src.Path, src.Pattern, src.Recursive), nil
+	}
+	return knowledge.NewTextLoader(src.Path), nil
+}
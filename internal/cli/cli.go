@@ -0,0 +1,405 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/agent"
+	"github.com/jholhewres/agent-go/pkg/agentgo/events"
+	"github.com/jholhewres/agent-go/pkg/agentgo/run"
+	"github.com/jholhewres/agent-go/pkg/agentos"
+)
+
+const usage = `Usage: agentgo <command> [flags]
+
+Commands:
+  run    Run the agent once on an input and print the answer
+  chat   Chat with the agent in the terminal
+  serve  Serve the agent over HTTP with AgentOS
+
+Run "agentgo <command> -h" for the flags of a command.
+`
+
+// Main runs the agentgo command with args (without the program name) and
+// returns the process exit code: 0 on success, 1 on failure and 2 on a usage
+// error. Logs go to stderr, answers to stdout.
+func Main(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Fprint(stderr, usage)
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+
+	cmd := &command{stdin: stdin, stdout: stdout, stderr: stderr}
+	var err error
+	switch args[0] {
+	case "run":
+		err = cmd.run(ctx, args[1:])
+	case "chat":
+		err = cmd.chat(ctx, args[1:])
+	case "serve":
+		err = cmd.serve(ctx, args[1:])
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	var uerr usageError
+	switch {
+	case err == nil || errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &uerr):
+		fmt.Fprintln(stderr, err)
+		return 2
+	default:
+		fmt.Fprintln(stderr, "agentgo:", err)
+		return 1
+	}
+}
+
+// usageError is an error in the command line
+type usageError string
+
+func (e usageError) Error() string { return string(e) }
+
+// command holds the streams shared by the subcommands
+type command struct {
+	stdin          io.Reader
+	stdout, stderr io.Writer
+}
+
+// commonFlags are the flags of every subcommand
+type commonFlags struct {
+	file    string
+	session string
+	verbose bool
+}
+
+func (c *command) flagSet(name string, common *commonFlags) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.StringVar(&common.file, "f", "agent.yaml", "Agent definition file (.yaml, .yml or .toml)")
+	fs.StringVar(&common.file, "config", "agent.yaml", "Same as -f")
+	fs.StringVar(&common.session, "session", "", "Session ID, overriding memory.session_id")
+	fs.BoolVar(&common.verbose, "v", false, "Log agent activity to stderr")
+	return fs
+}
+
+// load builds the agent of the definition file
+func (c *command) load(ctx context.Context, common *commonFlags) (*Instance, error) {
+	def, err := LoadDefinition(common.file)
+	if err != nil {
+		return nil, err
+	}
+	if common.session != "" {
+		def.Memory.SessionID = common.session
+	}
+	level := slog.LevelWarn
+	if common.verbose {
+		level = slog.LevelInfo
+	}
+	logger := slog.New(slog.NewTextHandler(c.stderr, &slog.HandlerOptions{Level: level}))
+	return Build(ctx, def, logger)
+}
+
+// run answers one input given as arguments or, without arguments, read from stdin
+func (c *command) run(ctx context.Context, args []string) (err error) {
+	var common commonFlags
+	fs := c.flagSet("run", &common)
+	stream := fs.Bool("stream", false, "Print the answer as it is generated")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	input := strings.Join(fs.Args(), " ")
+	if input == "" || input == "-" {
+		data, err := io.ReadAll(c.stdin)
+		if err != nil {
+			return fmt.Errorf("read input: %w", err)
+		}
+		input = string(data)
+	}
+	if strings.TrimSpace(input) == "" {
+		return usageError("run needs an input, as arguments or on stdin")
+	}
+
+	in, err := c.load(ctx, &common)
+	if err != nil {
+		return err
+	}
+	defer closeInstance(in, &err)
+	return c.respond(ctx, in.Agent, input, *stream)
+}
+
+// chat is an interactive chat: answers stream as they are generated, with
+// the model's reasoning dimmed before them, tool calls are shown as they run
+// and every answer reports the tokens it used.
+// /help lists the slash commands.
+func (c *command) chat(ctx context.Context, args []string) (err error) {
+	var common commonFlags
+	fs := c.flagSet("chat", &common)
+	stream := fs.Bool("stream", true, "Print answers as they are generated")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError("chat takes no arguments")
+	}
+
+	in, err := c.load(ctx, &common)
+	if err != nil {
+		return err
+	}
+	defer closeInstance(in, &err)
+
+	ui := newChatUI(c.stdout)
+	unsubscribe := events.On(in.Events, func(_ context.Context, ev *events.ToolExecuted) {
+		ui.toolCall(ev)
+	})
+	defer unsubscribe()
+
+	// Lines are read in the background so an interrupt ends the chat while
+	// it waits for input
+	lines := make(chan string)
+	scanErr := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(c.stdin)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+		scanErr <- scanner.Err()
+	}()
+
+	tools := 0
+	for _, tk := range in.Agent.Toolkits {
+		tools += len(tk.Functions())
+	}
+	ui.banner(in.Agent.Name, tools)
+	for {
+		ui.prompt()
+		var line string
+		var ok bool
+		select {
+		case line, ok = <-lines:
+		case <-ctx.Done():
+			ui.println("")
+			return nil
+		}
+		if !ok {
+			ui.println("")
+			return <-scanErr
+		}
+
+		input := strings.TrimSpace(line)
+		if input == "" {
+			continue
+		}
+		if strings.HasPrefix(input, "/") {
+			if quit := chatCommand(ui, in, input); quit {
+				return nil
+			}
+			continue
+		}
+		if err := chatTurn(ctx, ui, in.Agent, input, *stream); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			// A failed run does not end the chat
+			ui.errorf("%v", err)
+		}
+	}
+}
+
+// chatCommand runs a slash command, reporting whether the chat should end
+func chatCommand(ui *chatUI, in *Instance, input string) (quit bool) {
+	switch input {
+	case "/exit", "/quit":
+		return true
+	case "/reset":
+		in.Agent.ClearMemory()
+		ui.println("Conversation cleared.")
+	case "/memory":
+		ui.memory(in.Agent.Memory.GetMessages(in.Agent.UserID))
+	case "/tools":
+		ui.tools(in.Agent.Toolkits)
+	case "/usage":
+		ui.usage()
+	case "/help":
+		ui.println(chatHelp)
+	default:
+		ui.errorf("unknown command %s, /help lists the commands", input)
+	}
+	return false
+}
+
+// chatTurn answers one input of the chat
+func chatTurn(ctx context.Context, ui *chatUI, ag *agent.Agent, input string, stream bool) error {
+	if !stream {
+		out, err := ag.Run(ctx, input)
+		if err != nil {
+			return err
+		}
+		ui.content(out.Content)
+		ui.endAnswer(out.Usage)
+		return nil
+	}
+
+	result, err := ag.RunStream(ctx, input)
+	if err != nil {
+		return err
+	}
+	streamed := false
+	for evt := range result.Events {
+		switch e := evt.(type) {
+		case *run.RunReasoningEvent:
+			ui.reasoning(e.Content)
+		case *run.RunContentEvent:
+			if e.Content != "" {
+				ui.content(e.Content)
+				streamed = true
+			}
+		}
+	}
+	done := <-result.Done
+	if done.Err != nil {
+		return done.Err
+	}
+	if !streamed {
+		ui.content(done.Output.Content)
+	}
+	ui.endAnswer(done.Output.Usage)
+	return nil
+}
+
+// closeInstance closes in, reporting its error unless the command already failed
+func closeInstance(in *Instance, err *error) {
+	if cerr := in.Close(); *err == nil {
+		*err = cerr
+	}
+}
+
+// respond runs the agent on input and prints the answer
+func (c *command) respond(ctx context.Context, ag *agent.Agent, input string, stream bool) error {
+	if !stream {
+		out, err := ag.Run(ctx, input)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.stdout, out.Content)
+		return nil
+	}
+
+	result, err := ag.RunStream(ctx, input)
+	if err != nil {
+		return err
+	}
+	streamed := false
+	for evt := range result.Events {
+		if content, ok := evt.(*run.RunContentEvent); ok && content.Content != "" {
+			fmt.Fprint(c.stdout, content.Content)
+			streamed = true
+		}
+	}
+	done := <-result.Done
+	if done.Err != nil {
+		if streamed {
+			fmt.Fprintln(c.stdout)
+		}
+		return done.Err
+	}
+	if !streamed && done.Output != nil {
+		fmt.Fprint(c.stdout, done.Output.Content)
+	}
+	fmt.Fprintln(c.stdout)
+	return nil
+}
+
+// serve serves the agent with AgentOS until ctx is cancelled
+func (c *command) serve(ctx context.Context, args []string) (err error) {
+	var common commonFlags
+	fs := c.flagSet("serve", &common)
+	addr := fs.String("addr", "127.0.0.1:8080", "Address to listen on")
+	auth := fs.Bool("auth", false, "Require an API key, printed at startup")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError("serve takes no arguments")
+	}
+
+	in, err := c.load(ctx, &common)
+	if err != nil {
+		return err
+	}
+	defer closeInstance(in, &err)
+
+	config := &agentos.Config{Address: *addr, Logger: slog.New(slog.NewTextHandler(c.stderr, nil))}
+	var secret string
+	if *auth {
+		config.APIKeys = agentos.NewAPIKeyManager(nil)
+		secret, _, err = config.APIKeys.Create(ctx, agentos.CreateAPIKeyRequest{
+			Name:   "agentgo serve",
+			Scopes: []agentos.APIKeyScope{agentos.ScopeAgentsRead, agentos.ScopeAgentsRun, agentos.ScopeSessions},
+			Agents: []string{in.Agent.ID},
+		})
+		if err != nil {
+			return fmt.Errorf("create api key: %w", err)
+		}
+	} else if !isLoopback(*addr) {
+		fmt.Fprintf(c.stderr, "WARNING: serving on %s without -auth; anyone who can reach it can run the agent and its tools\n", *addr)
+	}
+
+	server, err := agentos.NewServer(config)
+	if err != nil {
+		return fmt.Errorf("create server: %w", err)
+	}
+	if err := server.RegisterAgent(in.Agent.ID, in.Agent); err != nil {
+		return fmt.Errorf("register agent: %w", err)
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.Start() }()
+	fmt.Fprintf(c.stderr, "Serving agent %q on %s\n", in.Agent.ID, *addr)
+	if secret != "" {
+		fmt.Fprintf(c.stderr, "API key (send as Authorization: Bearer <key>): %s\n", secret)
+	}
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
+
+// isLoopback reports whether addr only listens on the loopback interface. An
+// empty host listens on every interface.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
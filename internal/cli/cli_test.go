@@ -0,0 +1,374 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models/keypool"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// echoModel answers with the last user message and the number of messages it
+// saw. An input "add A B" first calls the calculator's add tool.
+type echoModel struct {
+	models.BaseModel
+}
+
+var echoUsage = types.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}
+
+func (m *echoModel) respond(req *models.InvokeRequest) *types.ModelResponse {
+	last := req.Messages[len(req.Messages)-1]
+	if last.Role == types.RoleTool {
+		return &types.ModelResponse{Content: "the sum is " + last.Content, Model: m.ID, Usage: echoUsage}
+	}
+	var a, b float64
+	if _, err := fmt.Sscanf(last.Content, "add %g %g", &a, &b); err == nil {
+		call := types.ToolCall{ID: "call-1", Type: "function", Function: types.ToolCallFunction{
+			Name: "add", Arguments: fmt.Sprintf(`{"a":%g,"b":%g}`, a, b),
+		}}
+		return &types.ModelResponse{ToolCalls: []types.ToolCall{call}, Model: m.ID, Usage: echoUsage}
+	}
+	content := fmt.Sprintf("echo %q (%d messages)", last.Content, len(req.Messages))
+	return &types.ModelResponse{Content: content, Model: m.ID, Usage: echoUsage}
+}
+
+func (m *echoModel) Invoke(_ context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+	return m.respond(req), nil
+}
+
+func (m *echoModel) InvokeStream(_ context.Context, req *models.InvokeRequest) (<-chan types.ResponseChunk, error) {
+	resp := m.respond(req)
+	ch := make(chan types.ResponseChunk, 2)
+	ch <- types.ResponseChunk{Content: resp.Content, ToolCalls: resp.ToolCalls}
+	ch <- types.ResponseChunk{Done: true, Usage: &resp.Usage}
+	close(ch)
+	return ch, nil
+}
+
+func init() {
+	ModelProviders["echo"] = func(s ModelSpec) (models.Model, error) {
+		return &echoModel{BaseModel: models.BaseModel{ID: s.ID, Provider: "echo"}}, nil
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDefinition(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CLI_TEST_KEY", "secret")
+
+	yamlPath := writeFile(t, dir, "agent.yaml", `
+name: Helper
+instructions: Be brief.
+model:
+  provider: openai
+  id: gpt-4o-mini
+  api_key: ${CLI_TEST_KEY}
+toolkits: [calculator, http]
+knowledge:
+  sources:
+    - path: docs
+      pattern: "*.md"
+    - url: https://example.com/faq
+  index: data/index.gob
+memory:
+  backend: sqlite
+  path: data/memory.db
+  session_id: s1
+`)
+	tomlPath := writeFile(t, dir, "agent.toml", `
+name = "Helper"
+instructions = "Be brief."
+toolkits = ["calculator", "http"]
+
+[model]
+provider = "openai"
+id = "gpt-4o-mini"
+api_key = "${CLI_TEST_KEY}"
+
+[knowledge]
+index = "data/index.gob"
+
+[[knowledge.sources]]
+path = "docs"
+pattern = "*.md"
+
+[[knowledge.sources]]
+url = "https://example.com/faq"
+
+[memory]
+backend = "sqlite"
+path = "data/memory.db"
+session_id = "s1"
+`)
+
+	for _, path := range []string{yamlPath, tomlPath} {
+		def, err := LoadDefinition(path)
+		if err != nil {
+			t.Fatalf("LoadDefinition(%s): %v", filepath.Base(path), err)
+		}
+		if def.Name != "Helper" || def.Model.APIKey != "secret" || len(def.Toolkits) != 2 {
+			t.Fatalf("%s: unexpected definition %+v", filepath.Base(path), def)
+		}
+		if got := def.Knowledge.Sources[0].Path; got != filepath.Join(dir, "docs") {
+			t.Fatalf("%s: source path = %q", filepath.Base(path), got)
+		}
+		if def.Knowledge.Sources[1].URL != "https://example.com/faq" || def.Knowledge.Index != filepath.Join(dir, "data/index.gob") {
+			t.Fatalf("%s: knowledge = %+v", filepath.Base(path), def.Knowledge)
+		}
+		if def.Memory.Path != filepath.Join(dir, "data/memory.db") || def.Memory.SessionID != "s1" {
+			t.Fatalf("%s: memory = %+v", filepath.Base(path), def.Memory)
+		}
+	}
+}
+
+func TestLoadDefinition_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		"no-provider.yaml":     "model: {id: x}",
+		"bad-provider.yaml":    "model: {provider: nope, id: x}",
+		"no-model-id.yaml":     "model: {provider: echo}",
+		"bad-toolkit.yaml":     "model: {provider: echo, id: x}\ntoolkits: [nope]",
+		"unknown-field.yaml":   "model: {provider: echo, id: x}\ntemperature: 1",
+		"unknown-field.toml":   "colour = \"red\"\n[model]\nprovider = \"echo\"\nid = \"x\"",
+		"bad-source.yaml":      "model: {provider: echo, id: x}\nknowledge: {sources: [{path: a, url: b}]}",
+		"bad-memory.yaml":      "model: {provider: echo, id: x}\nmemory: {backend: redis}",
+		"sqlite-no-path.yaml":  "model: {provider: echo, id: x}\nmemory: {backend: sqlite}",
+		"unsupported.json":     "{}",
+		"bad-embedder.yaml":    "model: {provider: echo, id: x}\nknowledge: {sources: [{path: a}], embedder: {provider: cohere}}",
+		"empty-knowledge.yaml": "model: {provider: echo, id: x}\nknowledge: {index: x}",
+		"bad-syntax.yaml":      "model: [",
+		"bad-toolkits.toml":    "toolkits = 1\n[model]\nprovider = \"echo\"\nid = \"x\"",
+		"two-key-fields.yaml":  "model: {provider: echo, id: x, api_key: a, api_keys: [b, c]}",
+	}
+	for name, content := range tests {
+		if _, err := LoadDefinition(writeFile(t, dir, name, content)); err == nil {
+			t.Errorf("LoadDefinition(%s): expected an error", name)
+		}
+	}
+	if _, err := LoadDefinition(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestMain_Run(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "agent.yaml", `
+name: Echo
+model: {provider: echo, id: echo-1}
+toolkits: [calculator]
+memory: {backend: sqlite, path: data/memory.db}
+`)
+
+	run := func(stdin string, args ...string) (string, string, int) {
+		var stdout, stderr bytes.Buffer
+		code := Main(context.Background(), append([]string{"run", "-f", path}, args...), strings.NewReader(stdin), &stdout, &stderr)
+		return stdout.String(), stderr.String(), code
+	}
+
+	out, errOut, code := run("", "hello", "there")
+	if code != 0 || out != "echo \"hello there\" (1 messages)\n" {
+		t.Fatalf("run = %d %q, stderr %q", code, out, errOut)
+	}
+
+	// A new process restores the conversation from the sqlite backend
+	out, errOut, code = run("again\n", "-stream")
+	if code != 0 || !strings.HasPrefix(out, "echo \"again\\n\" (3 messages)") {
+		t.Fatalf("second run = %d %q, stderr %q", code, out, errOut)
+	}
+
+	// Another session starts empty
+	out, _, code = run("", "-session", "other", "hi")
+	if code != 0 || out != "echo \"hi\" (1 messages)\n" {
+		t.Fatalf("other session = %d %q", code, out)
+	}
+
+	if _, _, code := run(""); code != 2 {
+		t.Fatalf("run without input = %d, want 2", code)
+	}
+}
+
+func TestBuild_ToolkitOptions(t *testing.T) {
+	dir := t.TempDir()
+	def := &Definition{
+		Model:          ModelSpec{Provider: "echo", ID: "echo-1"},
+		Toolkits:       []string{"calculator", "file"},
+		ToolkitOptions: map[string]map[string]interface{}{"file": {"root": dir, "read_only": true}},
+	}
+	in, err := Build(context.Background(), def, nil)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	defer in.Close()
+
+	var names []string
+	for _, tk := range in.Agent.Toolkits {
+		names = append(names, tk.Name())
+		if tk.Name() == "file_operations" && tk.Functions()["write_file"] != nil {
+			t.Error("read_only file toolkit has write_file")
+		}
+	}
+	if strings.Join(names, ",") != "calculator,file_operations" {
+		t.Fatalf("toolkits = %v", names)
+	}
+
+	def.ToolkitOptions["file"]["colour"] = "red"
+	if _, err := Build(context.Background(), def, nil); err == nil {
+		t.Fatal("expected an error for an unknown toolkit option")
+	}
+	def.ToolkitOptions = map[string]map[string]interface{}{"http": {}}
+	if err := def.Validate(); err == nil {
+		t.Fatal("expected an error for options of a toolkit that is not used")
+	}
+}
+
+func TestBuild_APIKeys(t *testing.T) {
+	var keys []string
+	ModelProviders["keyed"] = func(s ModelSpec) (models.Model, error) {
+		keys = append(keys, s.APIKey)
+		return &echoModel{BaseModel: models.BaseModel{ID: s.ID, Provider: "keyed"}}, nil
+	}
+	defer delete(ModelProviders, "keyed")
+
+	def := &Definition{Model: ModelSpec{Provider: "keyed", ID: "echo-1", APIKeys: []string{"key-1", "key-2"}}}
+	in, err := Build(context.Background(), def, nil)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	defer in.Close()
+
+	if _, ok := in.Agent.Model.(*keypool.Pool); !ok {
+		t.Fatalf("model = %T, want a key pool", in.Agent.Model)
+	}
+	if strings.Join(keys, ",") != "key-1,key-2" {
+		t.Fatalf("models created with keys %v", keys)
+	}
+	out, err := in.Agent.Run(context.Background(), "hi")
+	if err != nil || out.Content != `echo "hi" (1 messages)` {
+		t.Fatalf("Run = %v, %v", out, err)
+	}
+}
+
+func TestMain_Chat(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "agent.toml", `
+name = "Echo"
+toolkits = ["calculator"]
+[model]
+provider = "echo"
+id = "echo-1"
+`)
+	for _, stream := range []string{"-stream=true", "-stream=false"} {
+		t.Run(stream, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			stdin := strings.NewReader("first\n\nsecond\nadd 2 3\n/tools\n/memory\n/usage\n/nope\n/reset\n/memory\nthird\n/exit\nignored\n")
+			if code := Main(context.Background(), []string{"chat", "--config", path, stream}, stdin, &stdout, &stderr); code != 0 {
+				t.Fatalf("chat = %d, stderr %q", code, stderr.String())
+			}
+
+			out := stdout.String()
+			for _, want := range []string{
+				"agentgo chat · Echo",
+				`echo "first" (1 messages)`,
+				`echo "second" (3 messages)`,
+				"╭─ tool add\n│ {\"a\":2,\"b\":3}\n╰─ success in ",
+				"the sum is 5\ntokens: 20 in · 10 out · 60 total this chat\n> ",
+				"calculator\n  add ",
+				"  8 assistant the sum is 5",
+				"60 tokens (40 in, 20 out)",
+				"error: unknown command /nope",
+				"Conversation cleared.\n> Memory is empty.",
+				`echo "third" (1 messages)`,
+			} {
+				if !strings.Contains(out, want) {
+					t.Errorf("chat output missing %q:\n%s", want, out)
+				}
+			}
+			if strings.Contains(out, "ignored") || strings.Contains(out, "\033[") {
+				t.Errorf("unexpected chat output:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestMain_Usage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := Main(context.Background(), nil, strings.NewReader(""), &stdout, &stderr); code != 2 {
+		t.Fatalf("no command = %d, want 2", code)
+	}
+	if code := Main(context.Background(), []string{"deploy"}, strings.NewReader(""), &stdout, &stderr); code != 2 {
+		t.Fatalf("unknown command = %d, want 2", code)
+	}
+	if code := Main(context.Background(), []string{"run", "-f", "missing.yaml", "hi"}, strings.NewReader(""), &stdout, &stderr); code != 1 {
+		t.Fatalf("missing definition = %d, want 1", code)
+	}
+	if code := Main(context.Background(), []string{"serve", "-h"}, strings.NewReader(""), &stdout, &stderr); code != 0 {
+		t.Fatalf("serve -h = %d, want 0", code)
+	}
+}
+
+func TestMain_Serve(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "agent.yaml", "name: Echo\nmodel: {provider: echo, id: echo-1}\n")
+	serve := func(args ...string) string {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		var stdout, stderr bytes.Buffer
+		if code := Main(ctx, append([]string{"serve", "-f", path}, args...), strings.NewReader(""), &stdout, &stderr); code != 0 {
+			t.Fatalf("serve %v = %d, stderr:\n%s", args, code, stderr.String())
+		}
+		return stderr.String()
+	}
+
+	if out := serve("-addr", "0.0.0.0:0"); !strings.Contains(out, "WARNING") {
+		t.Errorf("expected a warning when serving on every interface without -auth:\n%s", out)
+	}
+	if out := serve("-addr", "127.0.0.1:0"); strings.Contains(out, "WARNING") {
+		t.Errorf("unexpected warning on loopback:\n%s", out)
+	}
+	out := serve("-addr", "0.0.0.0:0", "-auth")
+	if strings.Contains(out, "WARNING") || !strings.Contains(out, "API key (send as Authorization: Bearer <key>): ago_") {
+		t.Errorf("expected an API key and no warning with -auth:\n%s", out)
+	}
+}
+
+func TestIsLoopback(t *testing.T) {
+	tests := map[string]bool{
+		"127.0.0.1:8080": true,
+		"[::1]:8080":     true,
+		"localhost:8080": true,
+		":8080":          false,
+		"0.0.0.0:8080":   false,
+		"10.0.0.5:8080":  false,
+		"8080":           false,
+	}
+	for addr, want := range tests {
+		if got := isLoopback(addr); got != want {
+			t.Errorf("isLoopback(%q) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestChatUI_Reasoning(t *testing.T) {
+	var out bytes.Buffer
+	ui := newChatUI(&out)
+	ui.reasoning("adding ")
+	ui.reasoning("2 and 3")
+	ui.content("5")
+	ui.endAnswer(types.Usage{})
+	if got := out.String(); got != "adding 2 and 3\n5\n" {
+		t.Fatalf("output = %q", got)
+	}
+}
@@ -0,0 +1,191 @@
+// Package cli implements the agentgo command: it loads an agent definition
+// from a YAML or TOML file and runs the agent once, chats with it in a REPL
+// or serves it over HTTP with AgentOS.
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+)
+
+// Definition describes an agent. String values may reference environment
+// variables as $VAR or ${VAR}; they are expanded when the file is loaded.
+type Definition struct {
+	// ID registers the agent with AgentOS (default: derived from the model)
+	ID           string `yaml:"id" toml:"id"`
+	Name         string `yaml:"name" toml:"name"`
+	Instructions string `yaml:"instructions" toml:"instructions"`
+	// MaxLoops bounds the tool calling loops of a run (default: 10)
+	MaxLoops int `yaml:"max_loops" toml:"max_loops"`
+
+	Model ModelSpec `yaml:"model" toml:"model"`
+	// Toolkits are names registered in toolkit.DefaultRegistry
+	Toolkits []string `yaml:"toolkits" toml:"toolkits"`
+	// ToolkitOptions are the options of the toolkits, keyed by toolkit name
+	ToolkitOptions map[string]map[string]interface{} `yaml:"toolkit_options" toml:"toolkit_options"`
+
+	Knowledge *KnowledgeSpec `yaml:"knowledge" toml:"knowledge"`
+	Memory    MemorySpec     `yaml:"memory" toml:"memory"`
+}
+
+// ModelSpec selects the model of the agent
+type ModelSpec struct {
+	// Provider is a key of ModelProviders, e.g. openai, anthropic or ollama
+	Provider string `yaml:"provider" toml:"provider"`
+	ID       string `yaml:"id" toml:"id"`
+	// APIKey defaults to the provider's environment variable, e.g. OPENAI_API_KEY
+	APIKey string `yaml:"api_key" toml:"api_key"`
+	// APIKeys spreads calls across several keys with keypool, pausing a key
+	// when the provider rate limits it. It replaces api_key.
+	APIKeys     []string `yaml:"api_keys" toml:"api_keys"`
+	BaseURL     string   `yaml:"base_url" toml:"base_url"`
+	Temperature float64  `yaml:"temperature" toml:"temperature"`
+	MaxTokens   int      `yaml:"max_tokens" toml:"max_tokens"`
+}
+
+// KnowledgeSpec configures a knowledge base searched with the input of every run
+type KnowledgeSpec struct {
+	Sources  []SourceSpec `yaml:"sources" toml:"sources"`
+	Embedder EmbedderSpec `yaml:"embedder" toml:"embedder"`
+	// Index is a file the embedded chunks are kept in between runs, so only
+	// changed documents are embedded again (default: in memory only)
+	Index string `yaml:"index" toml:"index"`
+	// Limit is the number of chunks retrieved per run (default: 5)
+	Limit int `yaml:"limit" toml:"limit"`
+}
+
+// SourceSpec is one knowledge source: a file, a directory or a URL
+type SourceSpec struct {
+	Path string `yaml:"path" toml:"path"`
+	URL  string `yaml:"url" toml:"url"`
+	// Pattern filters the files of a directory by extension, e.g. "*.md"
+	// (default: all files)
+	Pattern   string `yaml:"pattern" toml:"pattern"`
+	Recursive bool   `yaml:"recursive" toml:"recursive"`
+}
+
+// EmbedderSpec selects the embedding model of the knowledge base. Only the
+// openai provider is supported; BaseURL points it at compatible servers.
+type EmbedderSpec struct {
+	Provider string `yaml:"provider" toml:"provider"`
+	Model    string `yaml:"model" toml:"model"`
+	APIKey   string `yaml:"api_key" toml:"api_key"`
+	BaseURL  string `yaml:"base_url" toml:"base_url"`
+}
+
+// MemorySpec selects where the conversation is kept
+type MemorySpec struct {
+	// Backend is "memory" (default), which forgets the conversation when the
+	// process exits, or "sqlite", which persists the runs of SessionID in Path
+	Backend   string `yaml:"backend" toml:"backend"`
+	Path      string `yaml:"path" toml:"path"`
+	SessionID string `yaml:"session_id" toml:"session_id"`
+	// MaxMessages bounds the messages kept in memory (default: 100)
+	MaxMessages int `yaml:"max_messages" toml:"max_messages"`
+}
+
+// LoadDefinition reads a definition from a .yaml, .yml or .toml file.
+// Relative paths in the definition are resolved against the file's directory.
+func LoadDefinition(path string) (*Definition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read definition: %w", err)
+	}
+	data = []byte(os.ExpandEnv(string(data)))
+
+	def := &Definition{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(def)
+	case ".toml":
+		err = toml.NewDecoder(bytes.NewReader(data)).DisallowUnknownFields().Decode(def)
+	default:
+		return nil, fmt.Errorf("unsupported definition format %q (use .yaml, .yml or .toml)", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	def.resolvePaths(filepath.Dir(path))
+	if err := def.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid definition %s: %w", path, err)
+	}
+	return def, nil
+}
+
+// Validate checks that the definition can be built
+func (d *Definition) Validate() error {
+	if d.Model.Provider == "" {
+		return fmt.Errorf("model.provider is required")
+	}
+	if _, ok := ModelProviders[d.Model.Provider]; !ok {
+		return fmt.Errorf("unknown model provider %q", d.Model.Provider)
+	}
+	if d.Model.ID == "" {
+		return fmt.Errorf("model.id is required")
+	}
+	if d.Model.APIKey != "" && len(d.Model.APIKeys) > 0 {
+		return fmt.Errorf("model.api_key and model.api_keys cannot both be set")
+	}
+	for _, name := range d.Toolkits {
+		if !toolkit.DefaultRegistry.Has(name) {
+			return fmt.Errorf("unknown toolkit %q", name)
+		}
+	}
+	for name := range d.ToolkitOptions {
+		if !slices.Contains(d.Toolkits, name) {
+			return fmt.Errorf("toolkit_options has options for %q, which is not in toolkits", name)
+		}
+	}
+	if k := d.Knowledge; k != nil {
+		if len(k.Sources) == 0 {
+			return fmt.Errorf("knowledge.sources is empty")
+		}
+		for i, src := range k.Sources {
+			if (src.Path == "") == (src.URL == "") {
+				return fmt.Errorf("knowledge.sources[%d] needs exactly one of path and url", i)
+			}
+		}
+		if p := k.Embedder.Provider; p != "" && p != "openai" {
+			return fmt.Errorf("unknown embedder provider %q", p)
+		}
+	}
+	switch d.Memory.Backend {
+	case "", "memory":
+	case "sqlite":
+		if d.Memory.Path == "" {
+			return fmt.Errorf("memory.path is required for the sqlite backend")
+		}
+	default:
+		return fmt.Errorf("unknown memory backend %q", d.Memory.Backend)
+	}
+	return nil
+}
+
+// resolvePaths makes the file paths of the definition relative to dir
+func (d *Definition) resolvePaths(dir string) {
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+	if d.Knowledge != nil {
+		d.Knowledge.Index = resolve(d.Knowledge.Index)
+		for i := range d.Knowledge.Sources {
+			d.Knowledge.Sources[i].Path = resolve(d.Knowledge.Sources[i].Path)
+		}
+	}
+	d.Memory.Path = resolve(d.Memory.Path)
+}
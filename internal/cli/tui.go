@@ -0,0 +1,233 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-isatty"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/events"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// ANSI styles used by the chat UI
+const (
+	styleReset = "\033[0m"
+	styleBold  = "\033[1m"
+	styleDim   = "\033[2m"
+	styleRed   = "\033[31m"
+	styleGreen = "\033[32m"
+	styleYell  = "\033[33m"
+	styleCyan  = "\033[36m"
+)
+
+// maxLineWidth truncates tool arguments, messages and descriptions
+const maxLineWidth = 120
+
+// chatHelp lists the slash commands of the chat
+const chatHelp = `/reset   clear the conversation
+/memory  show the messages in memory
+/tools   list the tools of the agent
+/usage   show the tokens used in this chat
+/help    show this help
+/exit    quit`
+
+// chatUI renders the chat in a terminal: streamed answers, a pane per tool
+// call and the tokens used by each answer. Writes are serialized because tool
+// calls are reported from the run's goroutine while answers stream.
+type chatUI struct {
+	out   io.Writer
+	color bool
+
+	mu       sync.Mutex
+	midLine  bool        // the last write did not end a line
+	thinking bool        // the last write was streamed reasoning
+	total    types.Usage // tokens used since the chat started
+}
+
+// newChatUI creates the UI; colors are used when out is a terminal and
+// NO_COLOR is not set
+func newChatUI(out io.Writer) *chatUI {
+	color := false
+	if f, ok := out.(*os.File); ok && os.Getenv("NO_COLOR") == "" {
+		color = isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+	}
+	return &chatUI{out: out, color: color}
+}
+
+// style wraps s in the given ANSI styles when colors are enabled
+func (ui *chatUI) style(s string, styles ...string) string {
+	if !ui.color || len(styles) == 0 {
+		return s
+	}
+	return strings.Join(styles, "") + s + styleReset
+}
+
+// println writes a full line, ending the streamed line first if needed
+func (ui *chatUI) println(s string) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	ui.endLineLocked()
+	fmt.Fprintln(ui.out, s)
+}
+
+func (ui *chatUI) endLineLocked() {
+	ui.thinking = false
+	if ui.midLine {
+		fmt.Fprintln(ui.out)
+		ui.midLine = false
+	}
+}
+
+func (ui *chatUI) banner(name string, tools int) {
+	ui.println(ui.style("agentgo chat", styleBold) + " · " + ui.style(name, styleCyan) +
+		ui.style(fmt.Sprintf(" · %d tools · /help for commands", tools), styleDim))
+}
+
+func (ui *chatUI) prompt() {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	ui.endLineLocked()
+	fmt.Fprint(ui.out, ui.style("> ", styleBold, styleGreen))
+}
+
+// reasoning writes a streamed part of the model's reasoning, dimmed so it
+// stands apart from the answer
+func (ui *chatUI) reasoning(s string) {
+	if s == "" {
+		return
+	}
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	fmt.Fprint(ui.out, ui.style(s, styleDim))
+	ui.midLine = !strings.HasSuffix(s, "\n")
+	ui.thinking = true
+}
+
+// content writes a streamed part of the answer
+func (ui *chatUI) content(s string) {
+	if s == "" {
+		return
+	}
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	if ui.thinking {
+		ui.endLineLocked()
+		ui.thinking = false
+	}
+	fmt.Fprint(ui.out, s)
+	ui.midLine = !strings.HasSuffix(s, "\n")
+}
+
+// endAnswer finishes an answer with the tokens it used
+func (ui *chatUI) endAnswer(usage types.Usage) {
+	ui.mu.Lock()
+	ui.total = ui.total.Add(usage)
+	total := ui.total
+	ui.mu.Unlock()
+	if usage.TotalTokens == 0 {
+		ui.mu.Lock()
+		ui.endLineLocked()
+		ui.mu.Unlock()
+		return
+	}
+	line := fmt.Sprintf("tokens: %d in · %d out · %d total this chat", usage.PromptTokens, usage.CompletionTokens, total.TotalTokens)
+	if total.EstimatedCost > 0 {
+		line += fmt.Sprintf(" · $%.4f", total.EstimatedCost)
+	}
+	ui.println(ui.style(line, styleDim))
+}
+
+// toolCall renders the pane of one tool call
+func (ui *chatUI) toolCall(ev *events.ToolExecuted) {
+	args, _ := json.Marshal(ev.Arguments)
+	if ev.Arguments == nil {
+		args = []byte("{}")
+	}
+	status := ui.style(ev.Status, styleGreen)
+	if ev.Status != "success" {
+		status = ui.style(ev.Status, styleRed)
+	}
+	if ev.Error != "" {
+		status += ": " + ev.Error
+	}
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	ui.endLineLocked()
+	fmt.Fprintln(ui.out, ui.style("╭─ tool ", styleYell)+ui.style(ev.Tool, styleBold))
+	fmt.Fprintln(ui.out, ui.style("│ ", styleYell)+ui.style(truncate(string(args), maxLineWidth), styleDim))
+	fmt.Fprintln(ui.out, ui.style("╰─ ", styleYell)+status+ui.style(" in "+ev.Duration.Round(time.Millisecond).String(), styleDim))
+}
+
+// errorf reports an error without ending the chat
+func (ui *chatUI) errorf(format string, args ...interface{}) {
+	ui.println(ui.style("error: "+fmt.Sprintf(format, args...), styleRed))
+}
+
+// memory lists the messages of the conversation
+func (ui *chatUI) memory(messages []*types.Message) {
+	if len(messages) == 0 {
+		ui.println(ui.style("Memory is empty.", styleDim))
+		return
+	}
+	for i, msg := range messages {
+		text := msg.Content
+		if len(msg.ToolCalls) > 0 {
+			names := make([]string, len(msg.ToolCalls))
+			for j, call := range msg.ToolCalls {
+				names[j] = call.Function.Name
+			}
+			text = "calls " + strings.Join(names, ", ")
+		}
+		ui.println(fmt.Sprintf("%s %s %s", ui.style(fmt.Sprintf("%3d", i+1), styleDim),
+			ui.style(fmt.Sprintf("%-9s", msg.Role), styleCyan), truncate(text, maxLineWidth)))
+	}
+}
+
+// tools lists the functions of every toolkit
+func (ui *chatUI) tools(toolkits []toolkit.Toolkit) {
+	if len(toolkits) == 0 {
+		ui.println(ui.style("The agent has no tools.", styleDim))
+		return
+	}
+	for _, tk := range toolkits {
+		ui.println(ui.style(tk.Name(), styleBold))
+		fns := tk.Functions()
+		names := make([]string, 0, len(fns))
+		for name := range fns {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			ui.println("  " + ui.style(name, styleCyan) + ui.style(" "+truncate(fns[name].Description, maxLineWidth), styleDim))
+		}
+	}
+}
+
+// usage shows the tokens used since the chat started
+func (ui *chatUI) usage() {
+	ui.mu.Lock()
+	total := ui.total
+	ui.mu.Unlock()
+	line := fmt.Sprintf("%d tokens (%d in, %d out)", total.TotalTokens, total.PromptTokens, total.CompletionTokens)
+	if total.EstimatedCost > 0 {
+		line += fmt.Sprintf(", $%.4f", total.EstimatedCost)
+	}
+	ui.println(line)
+}
+
+// truncate shortens s to n runes on one line
+func truncate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
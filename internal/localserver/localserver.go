@@ -0,0 +1,201 @@
+// Package localserver manages a model server process on this machine, such as
+// llama.cpp's llama-server or Hugging Face's text-embeddings-router. The
+// server is started on first use, polled on /health until it is ready,
+// restarted if it dies, and stopped on Close. A server given by URL is used
+// as is and never started or stopped.
+package localserver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config holds configuration for a local server
+type Config struct {
+	// Name describes the server in errors, e.g. "embedding server"
+	Name string
+
+	// URL of a running server; when set no process is started
+	URL string
+
+	// Command is the server binary
+	Command string
+
+	// Args returns the server arguments for the port it must listen on
+	Args func(port int) []string
+
+	// Port for the started server (default: a free port)
+	Port int
+
+	// StartTimeout bounds server start-up, including model loading (default: 2m)
+	StartTimeout time.Duration
+
+	// HTTPClient polls the health endpoint (default: http.DefaultClient)
+	HTTPClient *http.Client
+}
+
+// Server is a model server process started on demand
+type Server struct {
+	config Config
+
+	mu      sync.Mutex
+	baseURL string
+	cmd     *exec.Cmd
+	exited  chan struct{}
+}
+
+// New creates a server. The process is started lazily by URL.
+func New(config Config) *Server {
+	if config.StartTimeout <= 0 {
+		config.StartTimeout = 2 * time.Minute
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	return &Server{
+		config:  config,
+		baseURL: strings.TrimSuffix(config.URL, "/"),
+	}
+}
+
+// URL returns the server's base URL, starting the server if needed
+func (s *Server) URL(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.config.URL != "" {
+		return s.baseURL, nil
+	}
+	if s.cmd != nil {
+		select {
+		case <-s.exited:
+			// The server died; start a new one below.
+			s.cmd, s.exited, s.baseURL = nil, nil, ""
+		default:
+			return s.baseURL, nil
+		}
+	}
+
+	port := s.config.Port
+	if port == 0 {
+		var err error
+		if port, err = freePort(); err != nil {
+			return "", err
+		}
+	}
+
+	var args []string
+	if s.config.Args != nil {
+		args = s.config.Args(port)
+	}
+	cmd := exec.Command(s.config.Command, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("failed to start %s: %w", s.config.Command, err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+	s.cmd, s.exited = cmd, exited
+	s.baseURL = "http://127.0.0.1:" + strconv.Itoa(port)
+
+	if err := s.waitHealthy(ctx, exited); err != nil {
+		_ = s.stop()
+		if stderr.Len() > 0 {
+			return "", fmt.Errorf("%w: %s", err, lastLine(stderr.String()))
+		}
+		return "", err
+	}
+	return s.baseURL, nil
+}
+
+// Running reports whether a started process is being tracked
+func (s *Server) Running() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cmd != nil
+}
+
+// Close stops the started server. Servers given by URL are left running.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stop()
+}
+
+// stop must be called with mu held
+func (s *Server) stop() error {
+	if s.cmd == nil {
+		return nil
+	}
+	cmd, exited := s.cmd, s.exited
+	s.cmd, s.exited, s.baseURL = nil, nil, ""
+	select {
+	case <-exited:
+		return nil
+	default:
+	}
+	if err := cmd.Process.Kill(); err != nil {
+		return fmt.Errorf("failed to stop %s: %w", s.config.Name, err)
+	}
+	<-exited
+	return nil
+}
+
+func (s *Server) waitHealthy(ctx context.Context, exited <-chan struct{}) error {
+	deadline := time.NewTimer(s.config.StartTimeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/health", nil)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		if resp, err := s.config.HTTPClient.Do(req); err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-exited:
+			return fmt.Errorf("%s exited during start-up", s.config.Name)
+		case <-deadline.C:
+			return fmt.Errorf("%s not ready after %s", s.config.Name, s.config.StartTimeout)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, fmt.Errorf("failed to find a free port: %w", err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+func lastLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
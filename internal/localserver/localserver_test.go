@@ -0,0 +1,108 @@
+package localserver
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// When this variable is set the test binary acts as a fake model server, so
+// process management can be tested without llama.cpp or TEI installed.
+const helperEnv = "AGENTGO_LOCALSERVER_HELPER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(helperEnv) == "1" {
+		serveHelper()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+func serveHelper() {
+	port := ""
+	for i, arg := range os.Args {
+		if arg == "--port" && i+1 < len(os.Args) {
+			port = os.Args[i+1]
+		}
+		if arg == "--fail" {
+			os.Stderr.WriteString("loading model\nmodel file is corrupt\n")
+			os.Exit(1)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {})
+	_ = http.ListenAndServe("127.0.0.1:"+port, mux)
+}
+
+func helperServer(t *testing.T, extra ...string) *Server {
+	t.Helper()
+	t.Setenv(helperEnv, "1")
+	return New(Config{
+		Name:    "test server",
+		Command: os.Args[0],
+		Args: func(port int) []string {
+			return append([]string{"--port", strconv.Itoa(port)}, extra...)
+		},
+		StartTimeout: 10 * time.Second,
+	})
+}
+
+func TestServer_StartsAndStops(t *testing.T) {
+	s := helperServer(t)
+	defer s.Close()
+
+	url, err := s.URL(context.Background())
+	if err != nil {
+		t.Fatalf("URL() error = %v", err)
+	}
+	if !strings.HasPrefix(url, "http://127.0.0.1:") || !s.Running() {
+		t.Fatalf("URL() = %q, running = %v", url, s.Running())
+	}
+	if again, err := s.URL(context.Background()); err != nil || again != url {
+		t.Errorf("second URL() = %q, %v, want the running server", again, err)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if s.Running() {
+		t.Error("server still tracked after Close")
+	}
+	if _, err := http.Get(url + "/health"); err == nil {
+		t.Error("server still answers after Close")
+	}
+}
+
+func TestServer_ExitDuringStartup(t *testing.T) {
+	s := helperServer(t, "--fail")
+	_, err := s.URL(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "test server exited during start-up: model file is corrupt") {
+		t.Fatalf("URL() error = %v", err)
+	}
+	if s.Running() {
+		t.Error("failed server still tracked")
+	}
+}
+
+func TestServer_MissingCommand(t *testing.T) {
+	s := New(Config{Name: "test server", Command: filepath.Join(t.TempDir(), "no-such-binary")})
+	if _, err := s.URL(context.Background()); err == nil {
+		t.Fatal("expected start error")
+	}
+}
+
+func TestServer_GivenURL(t *testing.T) {
+	s := New(Config{URL: "http://localhost:9000/", Command: "never-run"})
+	url, err := s.URL(context.Background())
+	if err != nil || url != "http://localhost:9000" {
+		t.Fatalf("URL() = %q, %v", url, err)
+	}
+	if s.Running() || s.Close() != nil {
+		t.Error("a server given by URL must not be managed")
+	}
+}
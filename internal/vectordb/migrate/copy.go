@@ -0,0 +1,148 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+)
+
+// CopyOptions controls copying a collection between providers
+type CopyOptions struct {
+	From Options // source; its provider must implement vectordb.Scanner
+	To   Options // destination; Collection defaults to From.Collection
+
+	// BatchSize is the number of documents read and written at a time
+	// (default: 256)
+	BatchSize int
+
+	// OnProgress is called after each batch is written
+	OnProgress func(CopyProgress)
+}
+
+// CopyProgress reports how far a copy has got
+type CopyProgress struct {
+	Total  int // documents in the source when the copy started
+	Copied int // documents written to the destination
+}
+
+// Copy streams the documents of a collection, embeddings included, from one
+// provider to another. Embeddings must all have the same dimension, which
+// must match To.Dimension when it is set; otherwise To.Dimension is taken from
+// the source. The destination collection is created with To.Distance, or the
+// source's distance when unset, and only once there is a document to write.
+// Documents with IDs already in the destination are replaced.
+func Copy(ctx context.Context, opts CopyOptions) (CopyProgress, error) {
+	var progress CopyProgress
+	if opts.From.Collection == "" {
+		return progress, fmt.Errorf("source collection is required")
+	}
+	if opts.To.Collection == "" {
+		opts.To.Collection = opts.From.Collection
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 256
+	}
+
+	src, err := ProviderFactory(opts.From)
+	if err != nil {
+		return progress, fmt.Errorf("open source: %w", err)
+	}
+	defer src.Close()
+
+	scanner, ok := src.(vectordb.Scanner)
+	if !ok {
+		return progress, fmt.Errorf("provider %s does not support scanning documents", opts.From.Provider)
+	}
+
+	// Stats come first so that connecting below cannot create a missing source
+	stats, err := src.CollectionStats(ctx, opts.From.Collection)
+	if err != nil {
+		return progress, fmt.Errorf("source stats: %w", err)
+	}
+	if err := src.CreateCollection(ctx, opts.From.Collection, nil); err != nil {
+		return progress, fmt.Errorf("open source collection: %w", err)
+	}
+	progress.Total = stats.Count
+
+	dimension := opts.To.Dimension
+	if stats.Dimension > 0 {
+		if dimension > 0 && dimension != stats.Dimension {
+			return progress, fmt.Errorf("dimension mismatch: source has %d, destination expects %d", stats.Dimension, dimension)
+		}
+		dimension = stats.Dimension
+	}
+	if opts.To.Distance == "" {
+		opts.To.Distance = string(stats.DistanceFunction)
+	}
+
+	var dst vectordb.VectorDB
+	defer func() {
+		if dst != nil {
+			dst.Close()
+		}
+	}()
+
+	cursor := ""
+	for {
+		docs, next, err := scanner.Scan(ctx, cursor, opts.BatchSize)
+		if err != nil {
+			return progress, fmt.Errorf("scan source: %w", err)
+		}
+
+		for _, doc := range docs {
+			if len(doc.Embedding) == 0 {
+				return progress, fmt.Errorf("document %s has no embedding", doc.ID)
+			}
+			if dimension == 0 {
+				dimension = len(doc.Embedding)
+			}
+			if len(doc.Embedding) != dimension {
+				return progress, fmt.Errorf("dimension mismatch: document %s has %d, expected %d", doc.ID, len(doc.Embedding), dimension)
+			}
+		}
+
+		if len(docs) > 0 {
+			if dst == nil {
+				opts.To.Dimension = dimension
+				if dst, err = openDestination(ctx, opts.To); err != nil {
+					return progress, err
+				}
+			}
+			if err := dst.Add(ctx, docs); err != nil {
+				return progress, fmt.Errorf("write destination: %w", err)
+			}
+			progress.Copied += len(docs)
+			if opts.OnProgress != nil {
+				opts.OnProgress(progress)
+			}
+		}
+
+		if next == "" {
+			return progress, nil
+		}
+		cursor = next
+	}
+}
+
+// openDestination creates the destination collection and checks that it
+// holds embeddings of opts.Dimension
+func openDestination(ctx context.Context, opts Options) (vectordb.VectorDB, error) {
+	meta, err := collectionMetadata(opts.Distance)
+	if err != nil {
+		return nil, err
+	}
+	db, err := ProviderFactory(opts)
+	if err != nil {
+		return nil, fmt.Errorf("open destination: %w", err)
+	}
+	if err := db.CreateCollection(ctx, opts.Collection, meta); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create destination collection: %w", err)
+	}
+	if stats, err := db.CollectionStats(ctx, opts.Collection); err == nil && stats.Dimension > 0 && stats.Dimension != opts.Dimension {
+		db.Close()
+		return nil, fmt.Errorf("dimension mismatch: destination has %d, source has %d", stats.Dimension, opts.Dimension)
+	}
+	return db, nil
+}
@@ -16,6 +16,20 @@ type Options struct {
 	ChromaTenant   string
 	ChromaDatabase string
 	Distance       string // l2|cosine|ip
+	// pgvector-specific
+	PostgresDSN   string
+	PostgresTable string
+	Dimension     int // embedding dimension, required to create a pgvector table
+}
+
+// Reindexer is implemented by providers that can rebuild their indexes
+type Reindexer interface {
+	Reindex(ctx context.Context) error
+}
+
+// Vacuumer is implemented by providers that can reclaim deleted space
+type Vacuumer interface {
+	Vacuum(ctx context.Context) error
 }
 
 // Factory creates a VectorDB instance from options
@@ -35,8 +49,17 @@ func Up(ctx context.Context, opts Options) error {
 	}
 	defer db.Close()
 
+	meta, err := collectionMetadata(opts.Distance)
+	if err != nil {
+		return err
+	}
+	return db.CreateCollection(ctx, opts.Collection, meta)
+}
+
+// collectionMetadata maps a distance name to CreateCollection metadata
+func collectionMetadata(distance string) (map[string]interface{}, error) {
 	meta := map[string]interface{}{}
-	switch opts.Distance {
+	switch distance {
 	case "cosine":
 		meta["distance_function"] = vectordb.Cosine
 	case "ip", "inner", "inner_product":
@@ -44,9 +67,9 @@ func Up(ctx context.Context, opts Options) error {
 	case "", "l2":
 		meta["distance_function"] = vectordb.L2
 	default:
-		return fmt.Errorf("invalid distance: %s", opts.Distance)
+		return nil, fmt.Errorf("invalid distance: %s", distance)
 	}
-	return db.CreateCollection(ctx, opts.Collection, meta)
+	return meta, nil
 }
 
 // Down drops the collection
@@ -61,3 +84,41 @@ func Down(ctx context.Context, opts Options) error {
 	defer db.Close()
 	return db.DeleteCollection(ctx, opts.Collection)
 }
+
+// Reindex rebuilds the indexes of the collection, for providers that
+// implement Reindexer
+func Reindex(ctx context.Context, opts Options) error {
+	if opts.Collection == "" {
+		return fmt.Errorf("collection is required")
+	}
+	db, err := ProviderFactory(opts)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	r, ok := db.(Reindexer)
+	if !ok {
+		return fmt.Errorf("provider %s does not support reindex", opts.Provider)
+	}
+	return r.Reindex(ctx)
+}
+
+// Vacuum reclaims the space of deleted documents, for providers that
+// implement Vacuumer
+func Vacuum(ctx context.Context, opts Options) error {
+	if opts.Collection == "" {
+		return fmt.Errorf("collection is required")
+	}
+	db, err := ProviderFactory(opts)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	v, ok := db.(Vacuumer)
+	if !ok {
+		return fmt.Errorf("provider %s does not support vacuum", opts.Provider)
+	}
+	return v.Vacuum(ctx)
+}
@@ -0,0 +1,26 @@
+package migrate
+
+import (
+	"fmt"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb/chromadb"
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb/pgvector"
+)
+
+// defaultFactory provides the Chroma and pgvector providers; Redis depends on
+// the redis build tag.
+func defaultFactory(opts Options) (vectordb.VectorDB, error) {
+	switch opts.Provider {
+	case "chroma", "chromadb":
+		cfg := chromadb.Config{BaseURL: opts.ChromaBaseURL, CollectionName: opts.Collection, Database: opts.ChromaDatabase, Tenant: opts.ChromaTenant}
+		return chromadb.New(cfg)
+	case "pgvector", "postgres":
+		cfg := pgvector.Config{DSN: opts.PostgresDSN, TableName: opts.PostgresTable, CollectionName: opts.Collection, Dimension: opts.Dimension}
+		return pgvector.New(cfg)
+	case "redis":
+		return newRedis(opts)
+	default:
+		return nil, fmt.Errorf("unsupported provider: %s", opts.Provider)
+	}
+}
@@ -3,9 +3,12 @@ package migrate
 import (
 	"context"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb/memvec"
 )
 
 type fakeDB struct {
@@ -24,6 +27,9 @@ func (f *fakeDB) DeleteCollection(ctx context.Context, name string) error {
 func (f *fakeDB) Add(ctx context.Context, documents []vectordb.Document) error    { return nil }
 func (f *fakeDB) Update(ctx context.Context, documents []vectordb.Document) error { return nil }
 func (f *fakeDB) Delete(ctx context.Context, ids []string) error                  { return nil }
+func (f *fakeDB) DeleteByFilter(ctx context.Context, filter map[string]interface{}) error {
+	return nil
+}
 func (f *fakeDB) Query(ctx context.Context, query string, limit int, filter map[string]interface{}) ([]vectordb.SearchResult, error) {
 	return nil, nil
 }
@@ -32,7 +38,11 @@ func (f *fakeDB) QueryWithEmbedding(ctx context.Context, embedding []float32, li
 }
 func (f *fakeDB) Get(ctx context.Context, ids []string) ([]vectordb.Document, error) { return nil, nil }
 func (f *fakeDB) Count(ctx context.Context) (int, error)                             { return 0, nil }
-func (f *fakeDB) Close() error                                                       { return nil }
+func (f *fakeDB) ListCollections(ctx context.Context) ([]string, error)              { return nil, nil }
+func (f *fakeDB) CollectionStats(ctx context.Context, name string) (vectordb.CollectionStats, error) {
+	return vectordb.CollectionStats{Name: name, DiskSize: -1}, nil
+}
+func (f *fakeDB) Close() error { return nil }
 
 func TestUpDown_UsesFactoryAndCallsProvider(t *testing.T) {
 	old := ProviderFactory
@@ -77,3 +87,139 @@ func TestRedisProvider_Gated(t *testing.T) {
 		t.Fatalf("Down error: %v", err)
 	}
 }
+
+type maintainedDB struct {
+	fakeDB
+	reindexed bool
+	vacuumed  bool
+}
+
+func (m *maintainedDB) Reindex(ctx context.Context) error {
+	m.reindexed = true
+	return nil
+}
+
+func (m *maintainedDB) Vacuum(ctx context.Context) error {
+	m.vacuumed = true
+	return nil
+}
+
+func TestReindexVacuum(t *testing.T) {
+	old := ProviderFactory
+	defer func() { ProviderFactory = old }()
+
+	m := &maintainedDB{}
+	ProviderFactory = func(opts Options) (vectordb.VectorDB, error) { return m, nil }
+	opts := Options{Provider: "pgvector", Collection: "docs"}
+	if err := Reindex(context.Background(), opts); err != nil || !m.reindexed {
+		t.Fatalf("Reindex() error = %v, reindexed = %v", err, m.reindexed)
+	}
+	if err := Vacuum(context.Background(), opts); err != nil || !m.vacuumed {
+		t.Fatalf("Vacuum() error = %v, vacuumed = %v", err, m.vacuumed)
+	}
+
+	ProviderFactory = func(opts Options) (vectordb.VectorDB, error) { return &fakeDB{}, nil }
+	if err := Reindex(context.Background(), opts); err == nil || !strings.Contains(err.Error(), "does not support reindex") {
+		t.Fatalf("Reindex() on unsupported provider error = %v", err)
+	}
+	if err := Vacuum(context.Background(), opts); err == nil || !strings.Contains(err.Error(), "does not support vacuum") {
+		t.Fatalf("Vacuum() on unsupported provider error = %v", err)
+	}
+}
+
+// copyFixture routes the "src" and "dst" providers to in-memory stores
+func copyFixture(t *testing.T, docs []vectordb.Document) (src, dst *memvec.MemVec, opened map[string]int) {
+	t.Helper()
+	ctx := context.Background()
+
+	var err error
+	if src, err = memvec.New(memvec.Config{CollectionName: "docs"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.Add(ctx, docs); err != nil {
+		t.Fatal(err)
+	}
+	if dst, err = memvec.New(memvec.Config{}); err != nil {
+		t.Fatal(err)
+	}
+
+	opened = map[string]int{}
+	old := ProviderFactory
+	t.Cleanup(func() { ProviderFactory = old })
+	ProviderFactory = func(opts Options) (vectordb.VectorDB, error) {
+		opened[opts.Provider]++
+		if opts.Provider == "src" {
+			return src, nil
+		}
+		return dst, nil
+	}
+	return src, dst, opened
+}
+
+func TestCopy(t *testing.T) {
+	var docs []vectordb.Document
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		docs = append(docs, vectordb.Document{ID: id, Content: "doc " + id, Embedding: []float32{1, 2, 3}, Metadata: map[string]interface{}{"id": id}})
+	}
+	_, dst, _ := copyFixture(t, docs)
+	ctx := context.Background()
+
+	var reports []CopyProgress
+	progress, err := Copy(ctx, CopyOptions{
+		From:       Options{Provider: "src", Collection: "docs"},
+		To:         Options{Provider: "dst", Collection: "docs-copy"},
+		BatchSize:  2,
+		OnProgress: func(p CopyProgress) { reports = append(reports, p) },
+	})
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if progress != (CopyProgress{Total: 5, Copied: 5}) {
+		t.Errorf("Copy() = %+v, want 5 of 5 copied", progress)
+	}
+	want := []CopyProgress{{5, 2}, {5, 4}, {5, 5}}
+	if !reflect.DeepEqual(reports, want) {
+		t.Errorf("progress reports = %v, want %v", reports, want)
+	}
+
+	if err := dst.CreateCollection(ctx, "docs-copy", nil); err != nil {
+		t.Fatal(err)
+	}
+	got, err := dst.Get(ctx, []string{"c"})
+	if err != nil || len(got) != 1 || got[0].Content != "doc c" || len(got[0].Embedding) != 3 || got[0].Metadata["id"] != "c" {
+		t.Errorf("copied document = %+v, %v", got, err)
+	}
+}
+
+func TestCopy_DimensionValidation(t *testing.T) {
+	docs := []vectordb.Document{
+		{ID: "a", Content: "a", Embedding: []float32{1, 2, 3}},
+		{ID: "b", Content: "b", Embedding: []float32{1, 2, 3}},
+	}
+	_, _, opened := copyFixture(t, docs)
+
+	_, err := Copy(context.Background(), CopyOptions{
+		From: Options{Provider: "src", Collection: "docs"},
+		To:   Options{Provider: "dst", Dimension: 4},
+	})
+	if err == nil || !strings.Contains(err.Error(), "dimension mismatch") {
+		t.Fatalf("Copy() error = %v, want dimension mismatch", err)
+	}
+	if opened["dst"] != 0 {
+		t.Errorf("destination opened %d times before validation failed", opened["dst"])
+	}
+}
+
+func TestCopy_RequiresScanner(t *testing.T) {
+	old := ProviderFactory
+	defer func() { ProviderFactory = old }()
+	ProviderFactory = func(opts Options) (vectordb.VectorDB, error) { return &fakeDB{}, nil }
+
+	_, err := Copy(context.Background(), CopyOptions{
+		From: Options{Provider: "chroma", Collection: "docs"},
+		To:   Options{Provider: "pgvector"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "does not support scanning") {
+		t.Fatalf("Copy() error = %v, want scanner error", err)
+	}
+}
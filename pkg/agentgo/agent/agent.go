@@ -2,8 +2,6 @@ package agent
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,14 +12,23 @@ import (
 	"time"
 
 	"github.com/jholhewres/agent-go/pkg/agentgo/cache"
+	"github.com/jholhewres/agent-go/pkg/agentgo/events"
+	"github.com/jholhewres/agent-go/pkg/agentgo/flags"
+	"github.com/jholhewres/agent-go/pkg/agentgo/format"
+	"github.com/jholhewres/agent-go/pkg/agentgo/guardrails"
 	"github.com/jholhewres/agent-go/pkg/agentgo/hooks"
 	"github.com/jholhewres/agent-go/pkg/agentgo/learning"
 	"github.com/jholhewres/agent-go/pkg/agentgo/memory"
 	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/observability/metrics"
 	"github.com/jholhewres/agent-go/pkg/agentgo/prompts"
+	"github.com/jholhewres/agent-go/pkg/agentgo/ratelimit"
 	"github.com/jholhewres/agent-go/pkg/agentgo/reasoning"
+	"github.com/jholhewres/agent-go/pkg/agentgo/resources"
 	"github.com/jholhewres/agent-go/pkg/agentgo/run"
 	"github.com/jholhewres/agent-go/pkg/agentgo/skills"
+	"github.com/jholhewres/agent-go/pkg/agentgo/speech"
+	"github.com/jholhewres/agent-go/pkg/agentgo/storage"
 	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
 	"github.com/jholhewres/agent-go/pkg/agentgo/types"
 )
@@ -36,6 +43,7 @@ const (
 	RunStatusCompleted RunStatus = "completed"
 	RunStatusCancelled RunStatus = "cancelled"
 	RunStatusError     RunStatus = "error"
+	RunStatusPaused    RunStatus = "paused"
 )
 
 // SessionPersister is the interface for persisting run outputs to session storage.
@@ -73,9 +81,20 @@ type Agent struct {
 	cacheTTL     time.Duration
 	cacheEnabled bool
 
+	// Guardrails / 防护栏
+	inputGuardrails  []GuardrailConfig // Checked before the model is called / 调用模型之前检查
+	outputGuardrails []GuardrailConfig // Checked before the answer is returned / 返回回答之前检查
+
+	// Tool execution / 工具执行
+	maxParallelToolCalls int           // Tool calls of a turn run at once / 同一轮同时执行的工具调用数
+	defaultToolTimeout   time.Duration // Default limit per tool call / 每次工具调用的默认时限
+
 	// Learning system / 学习系统
 	learning        bool
 	learningMachine learning.LearningMachine
+	userMemories    learning.MemoryRetriever // Relevant memories per run / 每次运行的相关记忆
+	userMemoryLimit int                      // Memories per run (default: 5) / 每次运行的记忆数
+	userMemoryMin   float64                  // Minimum memory score / 记忆最低得分
 
 	// Storage control / 存储控制
 	storeToolMessages    bool // Whether to store tool messages in RunOutput / 是否在 RunOutput 中存储工具消息
@@ -83,12 +102,16 @@ type Agent struct {
 
 	// Temporary instructions support for workflow history injection
 	// 临时 instructions 支持,用于工作流历史注入
-	tempInstructions string       // Temporary instructions (single execution only) / 临时指令（仅单次执行）
-	instructionsMu   sync.RWMutex // Protects instructions modification / 保护指令修改
+	tempInstructions   string       // Temporary instructions (single execution only) / 临时指令（仅单次执行）
+	systemInstructions string       // Instructions the system message in memory was built from / 内存中系统消息所基于的指令
+	instructionsMu     sync.RWMutex // Protects instructions modification / 保护指令修改
 
 	// Prompt composition / Prompt 组合
 	promptComposer     *prompts.PromptComposer // Optional modular prompt composer / 可选的模块化提示组合器
+	promptVars         map[string]interface{}  // Variables for prompt composition / 提示组合变量
 	enableMemorySearch bool                    // Enable automatic memory search before runs / 启用运行前自动内存搜索
+	memorySearchLimit  int                     // Messages per memory search (default: 5) / 每次内存搜索的消息数
+	memorySearchMin    float64                 // Minimum memory search score / 内存搜索最低得分
 
 	// Structured output / 结构化输出
 	responseFormat *models.ResponseFormat // Optional: constrain model output to JSON schema / 约束模型输出为 JSON schema
@@ -96,11 +119,80 @@ type Agent struct {
 	// Session persistence / 会话持久化
 	sessionPersister SessionPersister // Persists runs to session storage / 将运行持久化到会话存储
 	sessionID        string           // Session identifier / 会话标识符
+	sessionState     *SessionState    // Mutable session state / 可变的会话状态
+
+	// Dependency injection / 依赖注入
+	dependencies map[string]interface{} // Values and resolvers per name / 按名称的值和解析器
 
 	// History injection / 历史注入
 	historyProvider HistoryProvider // Provides previous run history / 提供历史运行记录
 	historyMaxRuns  int             // Max runs to inject (default: 5) / 最大注入运行数
 
+	// Knowledge injection / 知识注入
+	knowledge           KnowledgeSearcher // Searched with each run's input / 按每次运行的输入搜索
+	knowledgeLimit      int               // Chunks per run (default: 5) / 每次运行的片段数
+	knowledgeMinScore   float64           // Minimum chunk score / 片段最低得分
+	knowledgeStrict     bool              // Answer only from retrieved chunks / 仅基于检索片段回答
+	knowledgeConfidence float64           // Best score required to answer / 回答所需的最佳得分
+	knowledgeRefusal    string            // Answer when refusing / 拒绝时的回复
+	knowledgeCitations  bool              // Ask the model to cite chunks / 要求模型引用片段
+
+	// Run storage / 运行存储
+	sessionStorage   storage.SessionStorage // Persists runs and restores conversations / 持久化运行并恢复对话
+	sessionRestoreMu sync.Mutex             // Guards sessionRestored / 保护 sessionRestored
+	sessionRestored  bool                   // Whether stored messages were loaded into Memory / 是否已将存储的消息加载到 Memory
+
+	// Shared resources / 共享资源
+	resources *resources.Resources // Application-owned connections; never closed by the agent / 应用拥有的连接，代理不会关闭
+
+	// Feature flags / 功能开关
+	flagProvider flags.FlagProvider // Evaluates feature flags per run / 每次运行时评估功能开关
+	toolFlags    map[string]string  // Tool or toolkit name -> flag key / 工具或工具包名称 -> 开关键
+
+	// Usage accounting / 用量统计
+	pricing models.PricingTable // Per-model prices / 按模型定价
+	usage   usageTracker        // Aggregate usage across runs / 跨运行的累计用量
+	limits  *ratelimit.Enforcer // Per-user rate limits / 按用户的限流
+	metrics *metrics.Registry   // Built-in Prometheus metrics / 内置 Prometheus 指标
+	events  *events.Bus         // Run lifecycle events / 运行生命周期事件
+
+	// Run traces / 运行追踪
+	captureTraces bool // Record a RunTrace for every run / 为每次运行记录 RunTrace
+
+	// Reproducibility / 可复现性
+	seed         *int // Sampling seed sent with every request / 随每个请求发送的采样种子
+	hasFallbacks bool // Whether a fallback model may answer / 是否可能由备用模型回答
+
+	// Reasoning / 推理
+	reasoningEffort    string // Effort asked of reasoning models / 要求推理模型的思考程度
+	maxReasoningTokens int    // Thinking budget per call / 每次调用的思考预算
+
+	// Output formatting / 输出格式
+	formatPolicy          *format.Policy
+	channelFormatPolicies map[string]*format.Policy
+
+	// Context window / 上下文窗口
+	contextWindow *memory.ContextWindow // Trims requests to the token budget / 将请求裁剪到令牌预算内
+
+	// Stateless turns / 无状态轮次
+	runStateKey     []byte                                         // Seals RunTurn/ResumeTurn state blobs / 加密 RunTurn/ResumeTurn 状态数据
+	runStateTTL     time.Duration                                  // How long a blob can be resumed / 状态数据可恢复的时长
+	consumeRunState func(ctx context.Context, turnID string) error // Rejects replayed blobs / 拒绝重放的状态数据
+
+	// Attachments / 附件
+	attachments AttachmentConfig // Indexing of RunWithFiles attachments / RunWithFiles 附件的索引
+
+	// Voice / 语音
+	transcriber speech.Transcriber // Speech-to-text for RunVoice / RunVoice 的语音转文本
+	synthesizer speech.Synthesizer // Text-to-speech for RunVoice / RunVoice 的文本转语音
+
+	// Streaming / 流式输出
+	streamOpts StreamOptions // Delivery to slow RunStream consumers / 向慢速 RunStream 消费者投递事件
+
+	// Tool approvals / 工具审批
+	approvalsMu sync.Mutex            // Guards approvals / 保护 approvals
+	approvals   map[string]*pausedRun // Paused runs by approval ID / 按审批 ID 存放的暂停运行
+
 	// learningSem limits concurrent learning goroutines to prevent unbounded growth.
 	learningSem chan struct{}
 }
@@ -123,10 +215,49 @@ type Config struct {
 	CacheProvider cache.Provider
 	CacheTTL      time.Duration
 
+	// InputGuardrails check the input, after PreHooks, before the model is called;
+	// OutputGuardrails check the answer before it is returned. Each check is listed in
+	// RunOutput.GuardrailReports. RunStream has already streamed the answer when output
+	// guardrails run, so a redaction there only changes RunOutput.Content.
+	// InputGuardrails 在 PreHooks 之后、调用模型之前检查输入；OutputGuardrails 在返回之前检查回答。
+	// 每次检查都列在 RunOutput.GuardrailReports 中。RunStream 运行输出防护栏时回答已流式发送，
+	// 因此替换只会修改 RunOutput.Content。
+	InputGuardrails  []GuardrailConfig
+	OutputGuardrails []GuardrailConfig
+
+	// MaxParallelToolCalls is how many of the tool calls the model requests in
+	// one turn run at the same time (0 or 1 = one after another). Results keep
+	// the order of the calls. Tools and tool hooks must then be safe for
+	// concurrent use; each call keeps its own hooks.ToolHookInput.
+	// MaxParallelToolCalls 是模型在同一轮中请求的工具调用可同时执行的数量（0 或 1 = 依次执行）。
+	// 结果保持调用顺序。此时工具和工具钩子必须可安全并发使用；每个调用都有自己的 hooks.ToolHookInput。
+	MaxParallelToolCalls int
+	// DefaultToolTimeout bounds each tool call (0 = no limit); toolkit.Function.Timeout
+	// overrides it per function. The tool's context is cancelled when it expires, the
+	// call is reported with ToolExecutionStatusTimeout and the run continues.
+	// DefaultToolTimeout 限制每次工具调用的时长（0 = 不限制）；toolkit.Function.Timeout 可按函数覆盖。
+	// 超时后工具的上下文会被取消，调用以 ToolExecutionStatusTimeout 报告，运行继续。
+	DefaultToolTimeout time.Duration
+
 	// Learning system / 学习系统
 	Learning        bool                     // Enable learning system / 启用学习系统
 	LearningMachine learning.LearningMachine // Learning machine instance / 学习机器实例
 
+	// UserMemories puts the user's learned memories most relevant to each run's
+	// input into the system prompt, e.g. a learning.MemorySearcher. When set,
+	// Learning no longer injects the latest memories.
+	// UserMemories 将与每次运行输入最相关的用户已学习记忆放入系统提示，例如 learning.MemorySearcher。
+	// 设置后，Learning 不再注入最新的记忆。
+	UserMemories learning.MemoryRetriever
+
+	// UserMemoryLimit is the number of memories injected per run (default: 5)
+	// UserMemoryLimit 是每次运行注入的记忆数（默认值：5）
+	UserMemoryLimit int
+
+	// UserMemoryMinScore drops memories scoring below it (0-1)
+	// UserMemoryMinScore 丢弃得分低于它的记忆 (0-1)
+	UserMemoryMinScore float64
+
 	// Skills system / 技能系统
 	Skills interface{} // Skills orchestrator (will be *skills.Skills to avoid import cycle) / 技能编排器
 
@@ -153,19 +284,70 @@ type Config struct {
 	PromptVars map[string]interface{}
 
 	// EnableMemorySearch enables automatic memory search before each Run
-	// If enabled, the agent will search memory for relevant context before processing input
+	// If enabled, the agent will search memory for relevant context before processing input.
+	// Requires a memory.SearchableMemory such as memory.HybridMemory; found messages are
+	// listed in RunOutput.Citations.
 	// EnableMemorySearch 启用每次运行前的自动内存搜索
 	// 如果启用，代理将在处理输入之前搜索相关上下文的内存
 	EnableMemorySearch bool
 
-	// MemorySearchLimit controls how many results to retrieve when searching memory
-	// MemorySearchLimit 控制搜索内存时检索的结果数
+	// MemorySearchLimit controls how many results to retrieve when searching memory (default: 5)
+	// MemorySearchLimit 控制搜索内存时检索的结果数（默认值：5）
 	MemorySearchLimit int
 
 	// MemorySearchMinScore is the minimum relevance score for memory search results (0-1)
 	// MemorySearchMinScore 是内存搜索结果的最小相关性分数 (0-1)
 	MemorySearchMinScore float64
 
+	// Knowledge is searched with the input of every run; the retrieved chunks are added
+	// to the system prompt. Use knowledge.NewKnowledgeBase to create one.
+	// Knowledge 在每次运行时按输入进行搜索，检索到的片段会加入系统提示。
+	// 使用 knowledge.NewKnowledgeBase 创建。
+	Knowledge KnowledgeSearcher
+
+	// KnowledgeLimit is the number of chunks retrieved per run (default: 5).
+	// KnowledgeLimit 是每次运行检索的片段数（默认值：5）。
+	KnowledgeLimit int
+
+	// KnowledgeMinScore drops retrieved chunks scoring below it.
+	// KnowledgeMinScore 丢弃得分低于该值的检索片段。
+	KnowledgeMinScore float64
+
+	// KnowledgeStrict makes the agent answer only from retrieved chunks. When nothing
+	// relevant is retrieved, or the best chunk scores below KnowledgeConfidenceThreshold,
+	// the run answers KnowledgeRefusalMessage without calling the model. The decision
+	// is recorded in RunOutput.Grounding. Requires Knowledge.
+	// KnowledgeStrict 使代理仅基于检索到的片段回答。没有检索到相关内容或最佳片段得分低于
+	// KnowledgeConfidenceThreshold 时，运行直接以 KnowledgeRefusalMessage 回复而不调用模型。
+	// 决策记录在 RunOutput.Grounding 中。需要配置 Knowledge。
+	KnowledgeStrict bool
+
+	// KnowledgeConfidenceThreshold is the score the best chunk must reach for a
+	// strict agent to answer.
+	// KnowledgeConfidenceThreshold 是严格模式下最佳片段回答所需达到的得分。
+	KnowledgeConfidenceThreshold float64
+
+	// KnowledgeRefusalMessage is the strict agent's answer when it refuses
+	// (default: DefaultKnowledgeRefusalMessage).
+	// KnowledgeRefusalMessage 是严格模式下拒绝时的回复（默认值：DefaultKnowledgeRefusalMessage）。
+	KnowledgeRefusalMessage string
+
+	// KnowledgeCitations asks the model to cite the retrieved chunks by number. The
+	// chunks are listed in RunOutput.Citations either way. Requires Knowledge or
+	// Attachments.
+	// KnowledgeCitations 要求模型按编号引用检索到的片段。无论是否启用，片段都会列在
+	// RunOutput.Citations 中。需要配置 Knowledge 或 Attachments。
+	KnowledgeCitations bool
+
+	// NumDocuments, SimilarityThreshold and CitationsEnabled are aliases of
+	// KnowledgeLimit, KnowledgeMinScore and KnowledgeCitations. Set either name;
+	// setting both to different values is an error.
+	// NumDocuments、SimilarityThreshold 和 CitationsEnabled 分别是 KnowledgeLimit、
+	// KnowledgeMinScore 和 KnowledgeCitations 的别名。任选其一设置；两者设置为不同的值会报错。
+	NumDocuments        int
+	SimilarityThreshold float64
+	CitationsEnabled    bool
+
 	// ResponseFormat constrains the model output to structured JSON.
 	// When set, the model is instructed to produce JSON matching the given schema.
 	// ResponseFormat 约束模型输出为结构化 JSON。
@@ -189,6 +371,190 @@ type Config struct {
 	// HistoryMaxRuns limits the number of previous runs to include in context (default: 5).
 	// HistoryMaxRuns 限制上下文中包含的先前运行的最大数量（默认值：5）。
 	HistoryMaxRuns int
+
+	// SessionStorage persists every completed run (messages and metadata) keyed by SessionID
+	// and restores the last HistoryMaxRuns runs into Memory the first time the session is
+	// used, so conversations survive process restarts. Requires SessionID.
+	// SessionStorage 按 SessionID 持久化每次完成的运行（消息和元数据），并在首次使用会话时
+	// 将最近 HistoryMaxRuns 次运行恢复到 Memory 中，使对话在进程重启后保留。需要 SessionID。
+	SessionStorage storage.SessionStorage
+
+	// SessionState is the initial state of the session, e.g. {"cart": []}. The state is
+	// rendered into the instructions ({{.state.cart}}), read and updated by tools through
+	// SessionStateFromContext, returned in RunOutput.SessionState and, with SessionStorage,
+	// restored from the last stored run.
+	// SessionState 是会话的初始状态，例如 {"cart": []}。状态会渲染到指令中（{{.state.cart}}），
+	// 工具通过 SessionStateFromContext 读取和更新，在 RunOutput.SessionState 中返回，
+	// 配置 SessionStorage 时从最近存储的运行中恢复。
+	SessionState map[string]interface{}
+
+	// Dependencies are values resolved when a run starts, rendered into the instructions
+	// ({{.dependencies.name}}) and read by tools and hooks with Dependency. A value that is
+	// a DependencyFunc is called once per run; WithDependencies adds or overrides values
+	// for one run.
+	// Dependencies 是在运行开始时解析的值，渲染到指令中（{{.dependencies.name}}），工具和钩子通过
+	// Dependency 读取。值为 DependencyFunc 时每次运行调用一次；WithDependencies 可为单次运行添加或覆盖值。
+	Dependencies map[string]interface{}
+
+	// Resources exposes application-owned connections (DB pools, HTTP clients, vector and
+	// embedder clients) to tools and hooks. The agent never closes them; the application
+	// calls Resources.Close on shutdown.
+	// Resources 向工具和钩子提供应用程序拥有的连接（数据库连接池、HTTP 客户端、向量和嵌入客户端）。
+	// 代理不会关闭它们，应用程序应在关闭时调用 Resources.Close。
+	Resources *resources.Resources
+
+	// ModelRetry, when set, wraps Model and every fallback model with models.WithRetry so
+	// rate limits and 5xx errors are retried with exponential backoff.
+	// ModelRetry 设置后，使用 models.WithRetry 包装 Model 和每个备用模型，
+	// 以指数退避方式重试限流和 5xx 错误。
+	ModelRetry *models.RetryConfig
+
+	// FallbackModels are tried in order when Model keeps failing with a retryable error.
+	// FallbackModels 在 Model 持续出现可重试错误时按顺序尝试。
+	FallbackModels []models.Model
+
+	// ModelHedge, when set, wraps Model with models.WithHedging: a call still running after
+	// ModelHedge.Delay is duplicated to the same or a secondary model and the first response wins.
+	// ModelHedge 设置后，使用 models.WithHedging 包装 Model：调用超过 ModelHedge.Delay 仍未完成时，
+	// 向同一模型或备用模型发送重复请求，并采用最先返回的响应。
+	ModelHedge *models.HedgeConfig
+
+	// ModelMiddleware wraps Model and every fallback model with models.WithMiddleware, inside
+	// ModelRetry, so each attempt runs through it: logging, headers (models.SetHeaders), key
+	// rotation (models.RoundRobin) or response rewriting. The first middleware is the outermost.
+	// ModelMiddleware 使用 models.WithMiddleware 包装 Model 和每个备用模型（位于 ModelRetry 之内），
+	// 每次尝试都会经过它：日志、请求头（models.SetHeaders）、密钥轮换（models.RoundRobin）或响应改写。
+	// 第一个中间件位于最外层。
+	ModelMiddleware []models.Middleware
+
+	// Seed is sent with every model request to providers that support deterministic
+	// sampling, and recorded in RunOutput.Reproducibility.
+	// Seed 随每个模型请求发送给支持确定性采样的提供者，并记录在 RunOutput.Reproducibility 中。
+	Seed *int
+
+	// ReasoningEffort asks reasoning models to think less or more: models.ReasoningEffortLow,
+	// Medium or High. Providers that take a thinking budget (Anthropic, Gemini) map it to one.
+	// ReasoningEffort 要求推理模型减少或增加思考：models.ReasoningEffortLow、Medium 或 High。
+	// 使用思考预算的提供者（Anthropic、Gemini）会将其映射为预算。
+	ReasoningEffort string
+
+	// MaxReasoningTokens caps the tokens a reasoning model may spend thinking per call,
+	// taking precedence over ReasoningEffort for providers that take a budget.
+	// MaxReasoningTokens 限制推理模型每次调用用于思考的令牌数，对使用预算的提供者优先于 ReasoningEffort。
+	MaxReasoningTokens int
+
+	// FormatPolicy enforces response formatting (markdown level, max length, code-block or
+	// JSON only) on the final answer, with optional repair calls for violations that cannot
+	// be fixed locally.
+	// FormatPolicy 对最终回答强制执行格式策略（markdown 级别、最大长度、仅代码块或仅 JSON），
+	// 对无法本地修复的违规可选择请求模型修复。
+	FormatPolicy *format.Policy
+
+	// ChannelFormatPolicies override FormatPolicy for the channel attached with
+	// format.WithChannel on the run context.
+	// ChannelFormatPolicies 为运行上下文中通过 format.WithChannel 附加的渠道覆盖 FormatPolicy。
+	ChannelFormatPolicies map[string]*format.Policy
+
+	// StreamOptions controls how RunStream delivers events to a consumer that reads slower
+	// than the model streams: blocking (default), dropping the oldest deltas or coalescing
+	// them, with an optional per-event send timeout. Override per call with WithStreamOptions.
+	// StreamOptions 控制 RunStream 如何向读取速度慢于模型输出的消费者投递事件：阻塞（默认）、
+	// 丢弃最旧的增量或合并增量，并可设置单个事件的发送超时。可通过 WithStreamOptions 按调用覆盖。
+	StreamOptions StreamOptions
+
+	// FlagProvider evaluates feature flags for every run. It gates prompt sections with a
+	// FlagKey and the tools listed in ToolFlags. The evaluation context is taken from
+	// flags.WithEvalContext on the run context, with UserID defaulting to the agent's user.
+	// FlagProvider 在每次运行时评估功能开关，控制带 FlagKey 的提示部分和 ToolFlags 中列出的工具。
+	// 评估上下文取自运行上下文中的 flags.WithEvalContext，UserID 默认为代理的用户。
+	FlagProvider flags.FlagProvider
+
+	// ToolFlags maps a function or toolkit name to a boolean flag key; the tool is only
+	// offered to the model and executable when the flag is on. Requires FlagProvider.
+	// ToolFlags 将函数或工具包名称映射到布尔开关键；仅当开关开启时工具才会提供给模型并可执行。
+	ToolFlags map[string]string
+
+	// Pricing prices model calls by model ID so RunOutput.Usage and UsageStats carry an
+	// estimated cost. Models missing from the table are counted with zero cost.
+	// Pricing 按模型 ID 为模型调用定价，使 RunOutput.Usage 和 UsageStats 包含估算成本。
+	// 表中缺失的模型按零成本计算。
+	Pricing models.PricingTable
+
+	// RateLimit caps the runs per minute and tokens per day of each user (the run
+	// context's UserID, else UserID). Runs over the budget are queued up to MaxWait or
+	// rejected with a rate-limit error; consumption is reported by UsageStats.
+	// RateLimit 限制每个用户（运行上下文的 UserID，否则为 UserID）每分钟的运行数和每天的令牌数。
+	// 超出预算的运行最多排队 MaxWait 或以限流错误拒绝；用量由 UsageStats 报告。
+	RateLimit *ratelimit.Config
+
+	// Metrics records runs, tokens, tool calls and guardrail blocks in a metrics registry
+	// that can be scraped by Prometheus. Registries may be shared by several agents.
+	// Metrics 将运行、令牌、工具调用和护栏拦截记录到可供 Prometheus 抓取的指标注册表中，
+	// 注册表可由多个代理共享。
+	Metrics *metrics.Registry
+
+	// Events receives typed events as runs start and finish, around model calls, for
+	// tool calls and when guardrails fail. Buses may be shared by several agents.
+	// Events 在运行开始和结束、模型调用前后、工具调用以及防护栏失败时接收类型化事件，
+	// 事件总线可由多个代理共享。
+	Events *events.Bus
+
+	// CaptureTraces records the full trace of every run (messages sent to the model,
+	// raw responses, tool inputs and outputs, timings) in RunOutput.Trace and, with
+	// SessionStorage, in the stored run. Traces can be re-executed with Agent.Replay.
+	// CaptureTraces 记录每次运行的完整追踪（发送给模型的消息、原始响应、工具输入输出和耗时），
+	// 保存在 RunOutput.Trace 中，配置 SessionStorage 时也保存在存储的运行中。可使用 Agent.Replay 重新执行追踪。
+	CaptureTraces bool
+
+	// MaxContextTokens trims the oldest messages of each model request so it fits in
+	// this many tokens. Tokens are counted with the model's tokenizer when it implements
+	// models.TokenCounter, or estimated otherwise; no built-in provider implements it
+	// yet, so pass a ContextWindow with an exact Counter when the estimate is too
+	// coarse. Stored memory is not modified.
+	// MaxContextTokens 裁剪每次模型请求中最早的消息以适应该令牌数。模型实现
+	// models.TokenCounter 时使用其分词器计数，否则进行估算；目前内置提供商均未实现该接口，
+	// 估算不够精确时请传入带精确 Counter 的 ContextWindow。存储的记忆不会被修改。
+	MaxContextTokens int
+
+	// ContextWindow gives full control over trimming (reserve, summarizer, counter) and
+	// takes precedence over MaxContextTokens.
+	// ContextWindow 提供对裁剪的完全控制（预留、摘要器、计数器），优先于 MaxContextTokens。
+	ContextWindow *memory.ContextWindow
+
+	// RunStateKey encrypts and authenticates the state blobs returned by RunTurn with
+	// AES-256-GCM; ResumeTurn rejects blobs sealed with another key or changed. It is
+	// required by RunTurn and ResumeTurn, since resuming executes the pending tool calls
+	// a blob contains and the blob holds the values behind PII placeholders.
+	// RunStateKey 使用 AES-256-GCM 加密并认证 RunTurn 返回的状态数据；ResumeTurn 拒绝使用其他密钥
+	// 加密或被修改的数据。RunTurn 和 ResumeTurn 必须设置它，因为恢复时会执行其中待处理的工具调用，
+	// 且数据中包含 PII 占位符对应的值。
+	RunStateKey []byte
+
+	// RunStateTTL is how long a state blob returned by RunTurn or ResumeTurn can be
+	// resumed (default: 24h); older blobs are rejected.
+	// RunStateTTL 是 RunTurn 或 ResumeTurn 返回的状态数据可被恢复的时长（默认：24 小时）；过期数据会被拒绝。
+	RunStateTTL time.Duration
+
+	// ConsumeRunState is called with the turn ID of each blob before ResumeTurn or
+	// ResumeTurnApproval uses it, and rejects the blob when it returns an error. Record
+	// the ID and fail when it was already consumed to make every blob single-use;
+	// without it a blob can be resumed again until it expires.
+	// ConsumeRunState 在 ResumeTurn 或 ResumeTurnApproval 使用状态数据之前以其轮次 ID 调用，返回错误时拒绝该数据。
+	// 记录 ID 并在已被使用时返回错误，可使每份数据只能使用一次；未设置时数据在过期前可以再次恢复。
+	ConsumeRunState func(ctx context.Context, turnID string) error
+
+	// Attachments configures how RunWithFiles indexes attached files; its Embedder is required
+	// to use RunWithFiles.
+	// Attachments 配置 RunWithFiles 如何索引附加的文件；使用 RunWithFiles 需要设置其 Embedder。
+	Attachments AttachmentConfig
+
+	// Transcriber turns the audio passed to RunVoice into the run's input.
+	// Transcriber 将传给 RunVoice 的音频转换为运行输入。
+	Transcriber speech.Transcriber
+
+	// Synthesizer speaks the replies of RunVoice. Without it RunVoice returns text only.
+	// Synthesizer 朗读 RunVoice 的回答。未设置时 RunVoice 仅返回文本。
+	Synthesizer speech.Synthesizer
 }
 
 // New creates a new agent
@@ -213,10 +579,65 @@ func New(config Config) (*Agent, error) {
 		config.MaxLoops = 10
 	}
 
+	if config.SessionStorage != nil && config.SessionID == "" {
+		return nil, types.NewInvalidConfigError("session storage requires a session ID", nil)
+	}
+
 	if config.Logger == nil {
 		config.Logger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
 	}
 
+	if !models.ValidReasoningEffort(config.ReasoningEffort) {
+		return nil, types.NewInvalidConfigError(fmt.Sprintf("unknown reasoning effort %q", config.ReasoningEffort), nil)
+	}
+	if config.MaxReasoningTokens < 0 {
+		return nil, types.NewInvalidConfigError("max reasoning tokens cannot be negative", nil)
+	}
+
+	var limits *ratelimit.Enforcer
+	if config.RateLimit != nil {
+		var err error
+		if limits, err = ratelimit.New(*config.RateLimit); err != nil {
+			return nil, types.NewInvalidConfigError(err.Error(), err)
+		}
+	}
+
+	if config.FormatPolicy != nil {
+		if err := config.FormatPolicy.Validate(); err != nil {
+			return nil, types.NewInvalidConfigError("invalid format policy", err)
+		}
+	}
+	switch config.StreamOptions.Overflow {
+	case "", StreamOverflowBlock, StreamOverflowDropOldest, StreamOverflowCoalesce:
+	default:
+		return nil, types.NewInvalidConfigError(fmt.Sprintf("unknown stream overflow policy %q", config.StreamOptions.Overflow), nil)
+	}
+	for channel, policy := range config.ChannelFormatPolicies {
+		if policy == nil {
+			continue
+		}
+		if err := policy.Validate(); err != nil {
+			return nil, types.NewInvalidConfigError(fmt.Sprintf("invalid format policy for channel %s", channel), err)
+		}
+	}
+
+	if config.ContextWindow == nil && config.MaxContextTokens > 0 {
+		window, err := memory.NewContextWindow(memory.ContextWindowConfig{
+			MaxTokens: config.MaxContextTokens,
+			Counter:   models.TokenCounterFor(config.Model),
+		})
+		if err != nil {
+			return nil, types.NewInvalidConfigError("invalid context window", err)
+		}
+		config.ContextWindow = window
+	}
+
+	model, err := buildModelChain(config)
+	if err != nil {
+		return nil, err
+	}
+	config.Model = model
+
 	var cacheProvider cache.Provider
 	if config.EnableCache {
 		cacheProvider = config.CacheProvider
@@ -307,26 +728,78 @@ func New(config Config) (*Agent, error) {
 		historyMaxRuns = 5
 	}
 
+	memorySearchLimit := config.MemorySearchLimit
+	if memorySearchLimit <= 0 {
+		memorySearchLimit = 5
+	}
+	userMemoryLimit := config.UserMemoryLimit
+	if userMemoryLimit <= 0 {
+		userMemoryLimit = 5
+	}
+	if err := resolveKnowledgeAliases(&config); err != nil {
+		return nil, err
+	}
+	knowledgeLimit := config.KnowledgeLimit
+	if knowledgeLimit <= 0 {
+		knowledgeLimit = 5
+	}
+	if config.KnowledgeStrict && config.Knowledge == nil {
+		return nil, types.NewInvalidConfigError("KnowledgeStrict requires Knowledge", nil)
+	}
+	if config.KnowledgeCitations && config.Knowledge == nil && config.Attachments.Embedder == nil {
+		return nil, types.NewInvalidConfigError("KnowledgeCitations requires Knowledge or Attachments", nil)
+	}
+
+	inputGuardrails, err := validateGuardrails(config.InputGuardrails)
+	if err != nil {
+		return nil, err
+	}
+	outputGuardrails, err := validateGuardrails(config.OutputGuardrails)
+	if err != nil {
+		return nil, err
+	}
+
+	knowledgeRefusal := config.KnowledgeRefusalMessage
+	if knowledgeRefusal == "" {
+		knowledgeRefusal = DefaultKnowledgeRefusalMessage
+	}
+	runStateTTL := config.RunStateTTL
+	if runStateTTL <= 0 {
+		runStateTTL = 24 * time.Hour
+	}
+
 	agent := &Agent{
-		ID:           config.ID,
-		Name:         config.Name,
-		Model:        config.Model,
-		Toolkits:     finalToolkits,
-		Memory:       config.Memory,
-		Instructions: finalInstructions, // Keep original for reference / 保留原始值供参考
-		MaxLoops:     config.MaxLoops,
-		UserID:       config.UserID,
-		PreHooks:     config.PreHooks,
-		PostHooks:    config.PostHooks,
-		ToolHooks:    config.ToolHooks,
-		logger:       config.Logger,
-		cache:        cacheProvider,
-		cacheTTL:     cacheTTL,
-		cacheEnabled: config.EnableCache && cacheProvider != nil,
+		ID:                 config.ID,
+		Name:               config.Name,
+		Model:              config.Model,
+		Toolkits:           finalToolkits,
+		Memory:             config.Memory,
+		Instructions:       finalInstructions, // Keep original for reference / 保留原始值供参考
+		systemInstructions: finalInstructions,
+		MaxLoops:           config.MaxLoops,
+		UserID:             config.UserID,
+		PreHooks:           config.PreHooks,
+		PostHooks:          config.PostHooks,
+		ToolHooks:          config.ToolHooks,
+		logger:             config.Logger,
+		cache:              cacheProvider,
+		cacheTTL:           cacheTTL,
+		cacheEnabled:       config.EnableCache && cacheProvider != nil,
+
+		// Guardrails / 防护栏
+		inputGuardrails:  inputGuardrails,
+		outputGuardrails: outputGuardrails,
+
+		// Tool execution / 工具执行
+		maxParallelToolCalls: config.MaxParallelToolCalls,
+		defaultToolTimeout:   config.DefaultToolTimeout,
 
 		// Learning system / 学习系统
 		learning:        config.Learning,
 		learningMachine: config.LearningMachine,
+		userMemories:    config.UserMemories,
+		userMemoryLimit: userMemoryLimit,
+		userMemoryMin:   config.UserMemoryMinScore,
 
 		// Storage control (default to true for backward compatibility) / 存储控制 (默认为 true 以保持向后兼容)
 		storeToolMessages:    boolOrDefault(config.StoreToolMessages, true),
@@ -334,7 +807,10 @@ func New(config Config) (*Agent, error) {
 
 		// Prompt composition / Prompt 组合
 		promptComposer:     composer,
+		promptVars:         config.PromptVars,
 		enableMemorySearch: config.EnableMemorySearch,
+		memorySearchLimit:  memorySearchLimit,
+		memorySearchMin:    config.MemorySearchMinScore,
 
 		// Structured output / 结构化输出
 		responseFormat: config.ResponseFormat,
@@ -342,11 +818,72 @@ func New(config Config) (*Agent, error) {
 		// Session persistence / 会话持久化
 		sessionPersister: config.SessionPersister,
 		sessionID:        config.SessionID,
+		sessionState:     NewSessionState(config.SessionState),
+
+		// Dependency injection / 依赖注入
+		dependencies: config.Dependencies,
 
 		// History injection / 历史注入
 		historyProvider: config.HistoryProvider,
 		historyMaxRuns:  historyMaxRuns,
 
+		// Knowledge injection / 知识注入
+		knowledge:           config.Knowledge,
+		knowledgeLimit:      knowledgeLimit,
+		knowledgeMinScore:   config.KnowledgeMinScore,
+		knowledgeStrict:     config.KnowledgeStrict,
+		knowledgeConfidence: config.KnowledgeConfidenceThreshold,
+		knowledgeRefusal:    knowledgeRefusal,
+		knowledgeCitations:  config.KnowledgeCitations,
+
+		// Run storage / 运行存储
+		sessionStorage: config.SessionStorage,
+
+		// Shared resources / 共享资源
+		resources: config.Resources,
+
+		// Feature flags / 功能开关
+		flagProvider: config.FlagProvider,
+		toolFlags:    config.ToolFlags,
+
+		// Usage accounting / 用量统计
+		pricing: config.Pricing,
+		limits:  limits,
+		metrics: config.Metrics,
+		events:  config.Events,
+
+		captureTraces: config.CaptureTraces,
+
+		// Context window / 上下文窗口
+		contextWindow: config.ContextWindow,
+
+		// Stateless turns / 无状态轮次
+		runStateKey:     config.RunStateKey,
+		runStateTTL:     runStateTTL,
+		consumeRunState: config.ConsumeRunState,
+
+		// Attachments / 附件
+		attachments: config.Attachments,
+
+		// Voice / 语音
+		transcriber: config.Transcriber,
+		synthesizer: config.Synthesizer,
+
+		// Streaming / 流式输出
+		streamOpts: config.StreamOptions,
+
+		// Reproducibility / 可复现性
+		seed:         config.Seed,
+		hasFallbacks: len(config.FallbackModels) > 0,
+
+		// Reasoning / 推理
+		reasoningEffort:    config.ReasoningEffort,
+		maxReasoningTokens: config.MaxReasoningTokens,
+
+		// Output formatting / 输出格式
+		formatPolicy:          config.FormatPolicy,
+		channelFormatPolicies: config.ChannelFormatPolicies,
+
 		// Bound concurrent learning goroutines.
 		learningSem: make(chan struct{}, 3),
 	}
@@ -362,16 +899,26 @@ func New(config Config) (*Agent, error) {
 
 // RunOutput contains the result of agent execution
 type RunOutput struct {
-	RunID              string                  `json:"run_id,omitempty"`
-	Status             RunStatus               `json:"status"`
-	StartedAt          time.Time               `json:"started_at"`
-	CompletedAt        time.Time               `json:"completed_at"`
-	CancellationReason string                  `json:"cancellation_reason,omitempty"`
-	Content            string                  `json:"content"`
-	Messages           []*types.Message        `json:"messages"`
-	Metadata           map[string]interface{}  `json:"metadata,omitempty"`
-	Events             run.Events              `json:"events,omitempty"`
-	ToolsExecuted      []*ToolExecutionSummary `json:"tools_executed,omitempty"` // Tool execution summaries / 工具执行摘要
+	RunID              string                      `json:"run_id,omitempty"`
+	Status             RunStatus                   `json:"status"`
+	StartedAt          time.Time                   `json:"started_at"`
+	CompletedAt        time.Time                   `json:"completed_at"`
+	CancellationReason string                      `json:"cancellation_reason,omitempty"`
+	Content            string                      `json:"content"`
+	Messages           []*types.Message            `json:"messages"`
+	Metadata           map[string]interface{}      `json:"metadata,omitempty"`
+	Events             run.Events                  `json:"events,omitempty"`
+	ToolsExecuted      []*ToolExecutionSummary     `json:"tools_executed,omitempty"`    // Tool execution summaries / 工具执行摘要
+	Usage              types.Usage                 `json:"usage"`                       // Tokens and estimated cost across all model calls / 所有模型调用的令牌和估算成本
+	Reproducibility    *Reproducibility            `json:"reproducibility,omitempty"`   // Inputs that determine the run / 决定运行结果的输入
+	GuardrailReport    *guardrails.GuardrailReport `json:"guardrail_report,omitempty"`  // Non-blocking guardrail findings / 非阻断的防护栏问题
+	GuardrailReports   []GuardrailResult           `json:"guardrail_reports,omitempty"` // Input and output guardrail checks / 输入和输出防护栏检查
+	Grounding          *GroundingDecision          `json:"grounding,omitempty"`         // Strict knowledge decision / 严格知识模式的决策
+	Citations          []Citation                  `json:"citations,omitempty"`         // Knowledge, attachments and memory given to the model / 提供给模型的知识、附件和记忆
+	Warnings           []RunWarning                `json:"warnings,omitempty"`          // Optional subsystems that failed / 失败的可选子系统
+	PendingApproval    *PendingApproval            `json:"pending_approval,omitempty"`  // Set when the run is paused / 运行暂停时设置
+	SessionState       map[string]interface{}      `json:"session_state,omitempty"`     // Session state at the end of the run / 运行结束时的会话状态
+	Trace              *RunTrace                   `json:"trace,omitempty"`             // Set with Config.CaptureTraces / 设置 Config.CaptureTraces 时提供
 }
 
 // RunStreamDone represents the terminal result of a streaming run.
@@ -390,7 +937,7 @@ type RunStreamResult struct {
 }
 
 // Run executes the agent with the given input
-func (a *Agent) Run(ctx context.Context, input string) (*RunOutput, error) {
+func (a *Agent) Run(ctx context.Context, input string) (runOutput *RunOutput, runErr error) {
 	defer a.ClearTempInstructions()
 
 	if input == "" {
@@ -403,49 +950,74 @@ func (a *Agent) Run(ctx context.Context, input string) (*RunOutput, error) {
 		runCtx.UserID = a.UserID
 	}
 	runID := runCtx.RunID
+	ctx = a.withFlagContext(ctx, runCtx)
+	if err := a.admitRun(ctx); err != nil {
+		return nil, err
+	}
 
-	currentInstructions := a.GetInstructions()
+	a.restoreSession(ctx)
+	ctx = a.withSessionState(ctx)
+	ctx, err := a.resolveDependencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	currentInstructions := a.instructionsForRun(ctx)
 	a.logger.Info("agent run started", "agent_id", a.ID, "input", input)
 
 	initialMessageCount := len(a.Memory.GetMessages(a.UserID))
 
+	ctx, endRun := a.beginRun(ctx, input, initialMessageCount)
+	defer func() {
+		endRun(runOutput, runOutput != nil && runOutput.Status == RunStatusPaused, runErr)
+	}()
+
+	report := guardrails.NewGuardrailReport()
+	pii := guardrails.PIIMapping{}
+	ctx = withPIIMapping(ctx, pii)
 	if len(a.PreHooks) > 0 {
 		a.logger.Debug("executing pre-hooks", "count", len(a.PreHooks))
 		hookInput := hooks.NewHookInput(input).
 			WithAgentID(a.ID).
-			WithMessages([]interface{}{})
+			WithMessages([]interface{}{}).
+			WithReport(report)
+		hookInput.Metadata[guardrails.PIIMappingKey] = pii
+		// Tell the pre-hooks how the run ended, e.g. to close a tracing span.
+		// 告知前置钩子运行如何结束，例如用于关闭追踪 span。
+		defer func() { hookInput.RunEnded(runErr) }()
 
 		if err := hooks.ExecuteHooks(ctx, a.PreHooks, hookInput); err != nil {
 			a.logger.Error("pre-hook failed", "error", err)
 			return nil, types.NewInputCheckError("pre-hook validation failed", err)
 		}
+		input = hookInput.Input
+		if hookCtx := hookInput.Context(); hookCtx != nil {
+			ctx = hookCtx
+		}
+	}
+
+	input, guardrailResults, err := a.checkGuardrails(ctx, GuardrailStageInput, a.inputGuardrails, input, []interface{}{}, report, pii, nil)
+	if err != nil {
+		return nil, err
 	}
 
 	userMsg := types.NewUserMessage(input)
 	a.Memory.Add(userMsg, a.UserID)
 
 	output := &RunOutput{
-		RunID:     runID,
-		Status:    RunStatusRunning,
-		StartedAt: time.Now().UTC(),
-		Metadata:  map[string]interface{}{},
-	}
-
-	// Inject session history if configured.
-	if a.historyProvider != nil && a.sessionID != "" {
-		if histCtx, err := a.historyProvider.GetHistory(ctx, a.sessionID, a.historyMaxRuns); err == nil && histCtx != "" {
-			currentInstructions += "\n\n" + histCtx
-		}
+		RunID:            runID,
+		Status:           RunStatusRunning,
+		StartedAt:        time.Now().UTC(),
+		Metadata:         map[string]interface{}{},
+		GuardrailReports: guardrailResults,
 	}
 
-	// Inject learned context if learning is enabled.
-	if a.learning && a.learningMachine != nil && a.UserID != "" {
-		if learnedCtx := a.buildLearnedContext(ctx); learnedCtx != "" {
-			currentInstructions += "\n\n" + learnedCtx
-		}
-	}
+	rc := a.withRunContextInstructions(ctx, currentInstructions, input)
+	currentInstructions, output.Grounding, output.Warnings = rc.instructions, rc.grounding, rc.warnings
+	output.Citations = rc.citations
 
-	instructionsModified := currentInstructions != a.Instructions && currentInstructions != ""
+	instructionsModified := a.instructionsChanged(currentInstructions)
+	output.Reproducibility = a.reproducibility(ctx, currentInstructions)
 
 	var finalResponse *types.ModelResponse
 	loopCount := 0
@@ -464,13 +1036,17 @@ func (a *Agent) Run(ctx context.Context, input string) (*RunOutput, error) {
 			messages = a.updateSystemMessage(messages, currentInstructions)
 		}
 
+		messages = a.fitContext(ctx, messages)
+
 		req := &models.InvokeRequest{Messages: messages}
 		if len(a.Toolkits) > 0 {
-			req.Tools = toolkit.ToModelToolDefinitions(a.Toolkits)
+			req.Tools = a.toolDefinitions(ctx)
 		}
 		if a.responseFormat != nil {
 			req.ResponseFormat = a.responseFormat
 		}
+		req.Seed = a.seed
+		req.ReasoningEffort, req.MaxReasoningTokens = a.reasoningEffort, a.maxReasoningTokens
 		attachRunContextToRequest(ctx, req)
 
 		var (
@@ -480,7 +1056,10 @@ func (a *Agent) Run(ctx context.Context, input string) (*RunOutput, error) {
 			cacheKey  string
 		)
 
-		if a.cacheEnabled {
+		if output.Grounding.refused() {
+			// Retrieval did not support an answer, so the model is not called.
+			resp, fromCache = a.refusalResponse(), true
+		} else if a.cacheEnabled {
 			cachedResp, key, ok, cacheErr := a.tryCacheGet(ctx, req)
 			cacheKey = key
 			if cacheErr != nil {
@@ -493,7 +1072,7 @@ func (a *Agent) Run(ctx context.Context, input string) (*RunOutput, error) {
 		}
 
 		if !fromCache {
-			resp, invokeErr = a.Model.Invoke(ctx, req)
+			resp, invokeErr = a.invokeModel(ctx, req)
 			if invokeErr != nil {
 				if errors.Is(invokeErr, context.Canceled) || errors.Is(invokeErr, context.DeadlineExceeded) || ctx.Err() != nil {
 					cancelled := a.markRunCancelled(output, loopCount, cacheHit, invokeErr, initialMessageCount)
@@ -504,9 +1083,10 @@ func (a *Agent) Run(ctx context.Context, input string) (*RunOutput, error) {
 			}
 			if a.cacheEnabled {
 				if cacheKey == "" {
-					cacheKey = a.buildCacheKey(req)
+					cacheKey = a.cacheKey(req)
 				}
 			}
+			a.recordUsage(ctx, output, resp)
 		}
 
 		reasoningContent := a.extractReasoning(ctx, resp)
@@ -529,6 +1109,29 @@ func (a *Agent) Run(ctx context.Context, input string) (*RunOutput, error) {
 		a.logger.Info("executing tool calls", "count", len(resp.ToolCalls))
 		summaries := a.executeToolCalls(ctx, resp.ToolCalls)
 		output.ToolsExecuted = append(output.ToolsExecuted, summaries...)
+
+		if hasPendingApprovals(summaries) {
+			state := &RunState{
+				Version:             runStateVersion,
+				RunID:               runID,
+				AgentID:             a.ID,
+				UserID:              a.UserID,
+				Input:               input,
+				Instructions:        currentInstructions,
+				InitialMessageCount: initialMessageCount,
+				Loops:               loopCount,
+				MaxLoops:            a.MaxLoops,
+				Findings:            report.Findings(),
+				GuardrailResults:    output.GuardrailReports,
+				PIIMapping:          pii,
+				Reproducibility:     output.Reproducibility,
+				Grounding:           output.Grounding,
+				Citations:           output.Citations,
+				Warnings:            output.Warnings,
+				StartedAt:           output.StartedAt,
+			}
+			return a.pauseForApproval(output, state, resp.ToolCalls), nil
+		}
 	}
 
 	if finalResponse == nil {
@@ -539,19 +1142,34 @@ func (a *Agent) Run(ctx context.Context, input string) (*RunOutput, error) {
 		return nil, types.NewError(types.ErrCodeUnknown, "no response from model", nil)
 	}
 
+	finalContent, err := a.enforceFormat(ctx, output, finalResponse.Content, currentInstructions, true)
+	if err != nil {
+		return nil, err
+	}
+	a.recordModelRefusal(output.Grounding, finalContent)
+
 	if len(a.PostHooks) > 0 {
 		a.logger.Debug("executing post-hooks", "count", len(a.PostHooks))
 		hookInput := hooks.NewHookInput(input).
-			WithOutput(finalResponse.Content).
+			WithOutput(finalContent).
 			WithAgentID(a.ID).
-			WithMessages([]interface{}{})
+			WithMessages(hookMessages(a.Memory.GetMessages(a.UserID))).
+			WithReport(report)
+		hookInput.Metadata[guardrails.PIIMappingKey] = pii
 
 		if err := hooks.ExecuteHooks(ctx, a.PostHooks, hookInput); err != nil {
 			a.logger.Error("post-hook failed", "error", err)
 			return nil, types.NewOutputCheckError("post-hook validation failed", err)
 		}
+		finalContent = hookInput.Output
 	}
 
+	finalContent, guardrailResults, err = a.checkGuardrails(ctx, GuardrailStageOutput, a.outputGuardrails, finalContent, hookMessages(a.Memory.GetMessages(a.UserID)), report, pii, a.outputRetry(ctx, output, currentInstructions))
+	if err != nil {
+		return nil, err
+	}
+	output.GuardrailReports = append(output.GuardrailReports, guardrailResults...)
+
 	// Trigger async learning if enabled (bounded by semaphore).
 	if a.learning && a.learningMachine != nil && a.UserID != "" {
 		msgs := a.Memory.GetMessages(a.UserID)
@@ -578,10 +1196,14 @@ func (a *Agent) Run(ctx context.Context, input string) (*RunOutput, error) {
 
 	output.Status = RunStatusCompleted
 	output.CompletedAt = time.Now().UTC()
-	output.Content = finalResponse.Content
+	a.usage.addRun()
+	output.Content = finalContent
+	if report.HasFindings() {
+		output.GuardrailReport = report
+	}
 	output.Messages = a.Memory.GetMessages(a.UserID)
 	output.Metadata["loops"] = loopCount
-	output.Metadata["usage"] = finalResponse.Usage
+	output.Metadata["usage"] = output.Usage
 	output.Metadata["cache_hit"] = cacheHit
 	// Propagate model-level extra metadata (e.g., fallback_model, fallback_index).
 	for k, v := range finalResponse.Metadata.Extra {
@@ -595,10 +1217,13 @@ func (a *Agent) Run(ctx context.Context, input string) (*RunOutput, error) {
 	}
 	output.appendEvent(run.NewRunCompletedEvent(runID, a.ID, "", string(output.Status), finalResponse.Content))
 
+	runMessages := a.messagesSince(initialMessageCount)
 	a.scrubRunOutputWithContext(output, initialMessageCount)
 
 	// Persist run to session storage if configured.
+	output.SessionState = SessionStateFromContext(ctx).GetAll()
 	a.persistRunToSession(ctx, output)
+	a.saveRunRecord(ctx, input, output, runMessages)
 
 	return output, nil
 }
@@ -618,6 +1243,81 @@ func RunTyped[T any](ctx context.Context, a *Agent, input string) (*T, *RunOutpu
 	return &result, output, nil
 }
 
+// withRunContextInstructions appends session history, learned context, knowledge,
+// attachment excerpts and memory retrieved for input to the run's instructions
+// when configured. The grounding decision is non-nil for strict knowledge agents. These subsystems
+// are optional: when one fails the run continues without its context and the
+// failure is reported as a RunWarning.
+func (a *Agent) withRunContextInstructions(ctx context.Context, instructions, input string) runContext {
+	rc := runContext{instructions: instructions}
+
+	// Inject session history if configured.
+	if a.historyProvider != nil && a.sessionID != "" {
+		histCtx, err := a.historyProvider.GetHistory(ctx, a.sessionID, a.historyMaxRuns)
+		if err != nil {
+			a.degrade(ctx, &rc, SubsystemHistory, err)
+		} else if histCtx != "" {
+			rc.instructions += "\n\n" + histCtx
+		}
+	}
+
+	// Inject learned context if learning is enabled.
+	if a.learning && a.learningMachine != nil && a.UserID != "" {
+		learnedCtx, err := a.buildLearnedContext(ctx)
+		if err != nil {
+			a.degrade(ctx, &rc, SubsystemLearning, err)
+		}
+		if learnedCtx != "" {
+			rc.instructions += "\n\n" + learnedCtx
+		}
+	}
+
+	// Inject the user's memories relevant to the input.
+	if a.userMemories != nil {
+		userMemoryCtx, err := a.buildUserMemoryContext(ctx, input)
+		if err != nil {
+			a.degrade(ctx, &rc, SubsystemLearning, err)
+		}
+		if userMemoryCtx != "" {
+			rc.instructions += "\n\n" + userMemoryCtx
+		}
+	}
+
+	// Inject knowledge retrieved for the input.
+	knowledgeCtx, grounding, citations, err := a.buildKnowledgeContext(ctx, input)
+	if err != nil {
+		a.degrade(ctx, &rc, SubsystemKnowledge, err)
+	}
+	if knowledgeCtx != "" {
+		rc.instructions += "\n\n" + knowledgeCtx
+	}
+	rc.grounding, rc.citations = grounding, citations
+
+	// Inject excerpts of the files attached with RunWithFiles, numbered after the knowledge.
+	attachmentCtx, attachmentCitations, err := a.buildAttachmentContext(ctx, input, len(rc.citations)+1)
+	if err != nil {
+		a.degrade(ctx, &rc, SubsystemAttachments, err)
+	}
+	if attachmentCtx != "" {
+		rc.instructions += "\n\n" + attachmentCtx
+		rc.citations = append(rc.citations, attachmentCitations...)
+	}
+
+	// Inject earlier messages found by memory search, numbered after the excerpts above.
+	if a.enableMemorySearch && !grounding.refused() {
+		memoryCtx, memoryCitations, err := a.buildMemoryContext(ctx, input, len(rc.citations)+1)
+		if err != nil {
+			a.degrade(ctx, &rc, SubsystemMemorySearch, err)
+		}
+		if memoryCtx != "" {
+			rc.instructions += "\n\n" + memoryCtx
+			rc.citations = append(rc.citations, memoryCitations...)
+		}
+	}
+
+	return rc
+}
+
 // persistRunToSession saves the run output to session storage if configured.
 // Errors are logged but do not fail the run.
 func (a *Agent) persistRunToSession(ctx context.Context, output *RunOutput) {
@@ -629,32 +1329,149 @@ func (a *Agent) persistRunToSession(ctx context.Context, output *RunOutput) {
 	}
 }
 
+// restoreSession loads the stored conversation of the configured session into
+// Memory, and the session state of its last run. It runs once per agent;
+// failures are logged and retried on the next run.
+func (a *Agent) restoreSession(ctx context.Context) {
+	if a.sessionStorage == nil || a.sessionID == "" {
+		return
+	}
+
+	a.sessionRestoreMu.Lock()
+	defer a.sessionRestoreMu.Unlock()
+	if a.sessionRestored {
+		return
+	}
+
+	runs, err := a.sessionStorage.GetRuns(ctx, a.sessionID, a.historyMaxRuns)
+	if err != nil {
+		a.logger.Warn("failed to restore session", "session_id", a.sessionID, "error", err)
+		return
+	}
+	a.sessionRestored = true
+
+	restored := 0
+	for _, r := range runs {
+		if state, ok := r.Metadata[SessionStateMetadataKey].(map[string]interface{}); ok {
+			a.sessionState.replace(state)
+		}
+		for _, msg := range r.Messages {
+			if msg == nil || msg.Role == types.RoleSystem {
+				continue
+			}
+			a.Memory.Add(msg, a.UserID)
+			restored++
+		}
+	}
+	if restored > 0 {
+		a.logger.Debug("restored session messages", "session_id", a.sessionID, "messages", restored)
+	}
+}
+
+// fitContext trims messages to the configured context window, if any.
+// fitContext 将消息裁剪到配置的上下文窗口内（如有）。
+func (a *Agent) fitContext(ctx context.Context, messages []*types.Message) []*types.Message {
+	if a.contextWindow == nil {
+		return messages
+	}
+	fitted, result := a.contextWindow.Fit(ctx, messages)
+	if result.Dropped > 0 {
+		a.logger.Debug("trimmed request to context window",
+			"agent_id", a.ID,
+			"dropped", result.Dropped,
+			"summarized", result.Summarized,
+			"tokens_before", result.OriginalTokens,
+			"tokens_after", result.FinalTokens)
+	}
+	return fitted
+}
+
+// messagesSince returns the messages added to Memory after the first count messages.
+func (a *Agent) messagesSince(count int) []*types.Message {
+	msgs := a.Memory.GetMessages(a.UserID)
+	if count < 0 || count > len(msgs) {
+		return msgs
+	}
+	result := make([]*types.Message, len(msgs)-count)
+	copy(result, msgs[count:])
+	return result
+}
+
+// saveRunRecord appends the completed run to SessionStorage if configured.
+// Errors are logged but do not fail the run.
+func (a *Agent) saveRunRecord(ctx context.Context, input string, output *RunOutput, messages []*types.Message) {
+	if a.sessionStorage == nil || a.sessionID == "" || output == nil {
+		return
+	}
+
+	record := &storage.RunRecord{
+		RunID:       output.RunID,
+		SessionID:   a.sessionID,
+		AgentID:     a.ID,
+		UserID:      a.UserID,
+		Status:      string(output.Status),
+		Input:       input,
+		Content:     output.Content,
+		Messages:    messages,
+		Metadata:    output.Metadata,
+		StartedAt:   output.StartedAt,
+		CompletedAt: output.CompletedAt,
+	}
+	recorder := traceRecorderFrom(ctx)
+	// An empty state is saved too, so restoring does not bring back a cleared one
+	// 空状态也会保存，这样恢复时不会带回已清空的状态
+	if recorder != nil || output.SessionState != nil {
+		record.Metadata = make(map[string]interface{}, len(output.Metadata)+2)
+		for k, v := range output.Metadata {
+			record.Metadata[k] = v
+		}
+		if recorder != nil {
+			record.Metadata[TraceMetadataKey] = recorder.build(output, nil)
+		}
+		if output.SessionState != nil {
+			record.Metadata[SessionStateMetadataKey] = output.SessionState
+		}
+	}
+	if err := a.sessionStorage.SaveRun(ctx, record); err != nil {
+		a.logger.Warn("failed to save run to session storage", "session_id", a.sessionID, "error", err)
+	}
+}
+
 // buildLearnedContext retrieves user profile and memories from the learning system
 // and formats them as a context string to inject into the system prompt.
-func (a *Agent) buildLearnedContext(ctx context.Context) string {
+func (a *Agent) buildLearnedContext(ctx context.Context) (string, error) {
 	if a.learningMachine == nil || a.UserID == "" {
-		return ""
+		return "", nil
 	}
 
 	var parts []string
+	var firstErr error
 
 	profile, err := a.learningMachine.GetUserProfile(ctx, a.UserID)
-	if err == nil && profile != nil && profile.Name != "" {
+	if err != nil {
+		firstErr = err
+	} else if profile != nil && profile.Name != "" {
 		parts = append(parts, fmt.Sprintf("User: %s", profile.Name))
 	}
 
-	memories, err := a.learningMachine.GetUserMemories(ctx, a.UserID, 10)
-	if err == nil && len(memories) > 0 {
-		parts = append(parts, "Learned context:")
-		for _, mem := range memories {
-			parts = append(parts, fmt.Sprintf("- %s", mem.Content))
+	// UserMemories injects the relevant memories instead
+	if a.userMemories == nil {
+		memories, err := a.learningMachine.GetUserMemories(ctx, a.UserID, 10)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if err == nil && len(memories) > 0 {
+			parts = append(parts, "Learned context:")
+			for _, mem := range memories {
+				parts = append(parts, fmt.Sprintf("- %s", mem.Content))
+			}
 		}
 	}
 
 	if len(parts) == 0 {
-		return ""
+		return "", firstErr
 	}
-	return "[Learned Context]\n" + strings.Join(parts, "\n")
+	return "[Learned Context]\n" + strings.Join(parts, "\n"), firstErr
 }
 
 func (a *Agent) tryCacheGet(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, string, bool, error) {
@@ -662,7 +1479,15 @@ func (a *Agent) tryCacheGet(ctx context.Context, req *models.InvokeRequest) (*ty
 		return nil, "", false, nil
 	}
 
-	key := a.buildCacheKey(req)
+	key := a.cacheKey(req)
+	if key == "" {
+		return nil, "", false, nil
+	}
+	// cache.WithBypass skips the lookup; the fresh response still replaces the entry
+	// cache.WithBypass 跳过查找；新的响应仍会替换缓存项
+	if cache.IsBypassed(ctx) {
+		return nil, key, false, nil
+	}
 	resp, ok, err := a.cache.Get(ctx, key)
 	return resp, key, ok, err
 }
@@ -681,38 +1506,11 @@ func (a *Agent) tryCacheSet(ctx context.Context, key string, resp *types.ModelRe
 	}
 }
 
-func (a *Agent) buildCacheKey(req *models.InvokeRequest) string {
-	if req == nil {
-		return ""
-	}
-
-	var builder strings.Builder
-	builder.Grow(256)
-
-	builder.WriteString(a.Model.GetProvider())
-	builder.WriteString(":")
-	builder.WriteString(a.Model.GetID())
-
-	for _, msg := range req.Messages {
-		builder.WriteString("|")
-		builder.WriteString(string(msg.Role))
-		builder.WriteString(":")
-		builder.WriteString(msg.Content)
-		if len(msg.ToolCalls) > 0 {
-			builder.WriteString("#toolcalls")
-		}
-	}
-
-	if len(req.Tools) > 0 {
-		builder.WriteString("|tools:")
-		for _, tool := range req.Tools {
-			builder.WriteString(tool.Function.Name)
-			builder.WriteString(",")
-		}
-	}
-
-	sum := sha256.Sum256([]byte(builder.String()))
-	return hex.EncodeToString(sum[:])
+// cacheKey keys a model request with cache.ModelKey; it is empty when the
+// request cannot be keyed and must not be cached
+func (a *Agent) cacheKey(req *models.InvokeRequest) string {
+	key, _ := cache.ModelKey(a.Model.GetProvider(), a.Model.GetID(), req)
+	return key
 }
 
 func (output *RunOutput) appendEvent(evt run.BaseRunOutputEvent) {
@@ -733,7 +1531,7 @@ func (output *RunOutput) appendEvent(evt run.BaseRunOutputEvent) {
 // - After tool execution, a new streaming invocation is started with updated messages.
 // - The loop continues until no tool calls remain or MaxLoops is reached.
 // - Cache is bypassed for streaming runs.
-func (a *Agent) RunStream(ctx context.Context, input string) (*RunStreamResult, error) {
+func (a *Agent) RunStream(ctx context.Context, input string) (_ *RunStreamResult, runErr error) {
 	defer a.ClearTempInstructions()
 
 	if strings.TrimSpace(input) == "" {
@@ -745,71 +1543,129 @@ func (a *Agent) RunStream(ctx context.Context, input string) (*RunStreamResult,
 		runCtx.UserID = a.UserID
 	}
 	runID := runCtx.RunID
+	ctx = a.withFlagContext(ctx, runCtx)
+	if err := a.admitRun(ctx); err != nil {
+		return nil, err
+	}
 
-	currentInstructions := a.GetInstructions()
+	a.restoreSession(ctx)
+	ctx = a.withSessionState(ctx)
+	ctx, err := a.resolveDependencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	currentInstructions := a.instructionsForRun(ctx)
 	a.logger.Info("agent run (stream) started", "agent_id", a.ID, "input", input)
 
 	initialMessageCount := len(a.Memory.GetMessages(a.UserID))
 
+	// The run is recorded when the stream finishes, or here if it never starts.
+	// 运行在流结束时记录；若流未能开始则在此记录。
+	ctx, endRun := a.beginRun(ctx, input, initialMessageCount)
+	defer func() {
+		if runErr != nil {
+			endRun(nil, false, runErr)
+		}
+	}()
+
+	report := guardrails.NewGuardrailReport()
+	pii := guardrails.PIIMapping{}
+	ctx = withPIIMapping(ctx, pii)
+	// preHookInput is told how the run ended once the stream finishes.
+	// preHookInput 在流结束后获知运行如何结束。
+	var preHookInput *hooks.HookInput
+	defer func() {
+		if runErr != nil {
+			preHookInput.RunEnded(runErr)
+		}
+	}()
 	if len(a.PreHooks) > 0 {
 		a.logger.Debug("executing pre-hooks (stream)", "count", len(a.PreHooks))
-		hookInput := hooks.NewHookInput(input).
+		preHookInput = hooks.NewHookInput(input).
 			WithAgentID(a.ID).
-			WithMessages([]interface{}{})
+			WithMessages([]interface{}{}).
+			WithReport(report)
+		preHookInput.Metadata[guardrails.PIIMappingKey] = pii
 
-		if err := hooks.ExecuteHooks(ctx, a.PreHooks, hookInput); err != nil {
+		if err := hooks.ExecuteHooks(ctx, a.PreHooks, preHookInput); err != nil {
 			a.logger.Error("pre-hook failed (stream)", "error", err)
 			return nil, types.NewInputCheckError("pre-hook validation failed", err)
 		}
+		input = preHookInput.Input
+		if hookCtx := preHookInput.Context(); hookCtx != nil {
+			ctx = hookCtx
+		}
+	}
+
+	input, guardrailResults, err := a.checkGuardrails(ctx, GuardrailStageInput, a.inputGuardrails, input, []interface{}{}, report, pii, nil)
+	if err != nil {
+		return nil, err
 	}
 
 	userMsg := types.NewUserMessage(input)
 	a.Memory.Add(userMsg, a.UserID)
 
-	// Inject session history if configured.
-	if a.historyProvider != nil && a.sessionID != "" {
-		if histCtx, err := a.historyProvider.GetHistory(ctx, a.sessionID, a.historyMaxRuns); err == nil && histCtx != "" {
-			currentInstructions += "\n\n" + histCtx
-		}
-	}
-
-	// Inject learned context if learning is enabled.
-	if a.learning && a.learningMachine != nil && a.UserID != "" {
-		if learnedCtx := a.buildLearnedContext(ctx); learnedCtx != "" {
-			currentInstructions += "\n\n" + learnedCtx
-		}
-	}
+	rc := a.withRunContextInstructions(ctx, currentInstructions, input)
+	currentInstructions, grounding := rc.instructions, rc.grounding
 
 	output := &RunOutput{
 		RunID:     runID,
 		Status:    RunStatusRunning,
 		StartedAt: time.Now().UTC(),
 		Metadata:  map[string]interface{}{},
+		Grounding: grounding,
+		Citations: rc.citations,
+		Warnings:  rc.warnings,
+
+		GuardrailReports: guardrailResults,
 	}
 
-	eventsCh := make(chan run.BaseRunOutputEvent)
+	sender := newStreamSender(ctx, a.streamOptions(ctx))
 	doneCh := make(chan RunStreamDone, 1)
 
-	instructionsModified := currentInstructions != a.Instructions && currentInstructions != ""
+	instructionsModified := a.instructionsChanged(currentInstructions)
+	output.Reproducibility = a.reproducibility(ctx, currentInstructions)
 
 	go func() {
-		defer close(eventsCh)
+		defer sender.close()
 		sequence := 0
 		loopCount := 0
+		// endModelCall publishes the end of the model call in progress, if any.
+		// endModelCall 发布进行中的模型调用的结束事件（如有）。
+		var endModelCall func(*types.ModelResponse, error)
+
+		// finish delivers the events still buffered for the consumer, then the result.
+		finish := func(done RunStreamDone) {
+			if endModelCall != nil {
+				endModelCall(nil, done.Err)
+			}
+			sender.close()
+			if dropped, coalesced := sender.stats(); done.Output != nil && (dropped > 0 || coalesced > 0) {
+				if done.Output.Metadata == nil {
+					done.Output.Metadata = map[string]interface{}{}
+				}
+				done.Output.Metadata["stream_dropped"] = dropped
+				done.Output.Metadata["stream_coalesced"] = coalesced
+			}
+			preHookInput.RunEnded(done.Err)
+			endRun(done.Output, false, done.Err)
+			doneCh <- done
+		}
 
 		finishCancelled := func(reason error) {
 			cancelled := a.markRunCancelled(output, loopCount, false, reason, initialMessageCount)
-			doneCh <- RunStreamDone{
+			finish(RunStreamDone{
 				Output: cancelled,
 				Err:    types.NewCancellationError("agent run cancelled", reason),
-			}
+			})
 		}
 
 		finishError := func(err error) {
-			doneCh <- RunStreamDone{
+			finish(RunStreamDone{
 				Output: nil,
 				Err:    err,
-			}
+			})
 		}
 
 		for loopCount < a.MaxLoops {
@@ -826,16 +1682,30 @@ func (a *Agent) RunStream(ctx context.Context, input string) (*RunStreamResult,
 				messages = a.updateSystemMessage(messages, currentInstructions)
 			}
 
+			messages = a.fitContext(ctx, messages)
+
 			req := &models.InvokeRequest{Messages: messages}
 			if len(a.Toolkits) > 0 {
-				req.Tools = toolkit.ToModelToolDefinitions(a.Toolkits)
+				req.Tools = a.toolDefinitions(ctx)
 			}
 			if a.responseFormat != nil {
 				req.ResponseFormat = a.responseFormat
 			}
+			req.Seed = a.seed
+			req.ReasoningEffort, req.MaxReasoningTokens = a.reasoningEffort, a.maxReasoningTokens
 			attachRunContextToRequest(ctx, req)
 
-			stream, err := a.Model.InvokeStream(ctx, req)
+			var (
+				stream <-chan types.ResponseChunk
+				err    error
+			)
+			if grounding.refused() {
+				// Retrieval did not support an answer, so the model is not called.
+				stream = singleChunkStream(types.ResponseChunk{Content: a.knowledgeRefusal, Done: true})
+			} else {
+				endModelCall = a.startModelCall(ctx, req, true)
+				stream, err = a.Model.InvokeStream(ctx, req)
+			}
 			if err != nil {
 				if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil {
 					finishCancelled(err)
@@ -903,14 +1773,25 @@ func (a *Agent) RunStream(ctx context.Context, input string) (*RunStreamResult,
 						break streamLoop
 					}
 
+					if chunk.ReasoningContent != "" {
+						evt := run.NewRunReasoningEvent(runID, a.ID, chunk.ReasoningContent, sequence)
+						sequence++
+						output.appendEvent(evt)
+
+						if !sender.send(ctx, evt) {
+							closeAggregator()
+							<-doneAgg
+							finishCancelled(ctx.Err())
+							return
+						}
+					}
+
 					if chunk.Content != "" {
 						evt := run.NewRunContentEvent(runID, a.ID, string(types.RoleAssistant), chunk.Content, sequence)
 						sequence++
 						output.appendEvent(evt)
 
-						select {
-						case eventsCh <- evt:
-						case <-ctx.Done():
+						if !sender.send(ctx, evt) {
 							closeAggregator()
 							<-doneAgg
 							finishCancelled(ctx.Err())
@@ -950,6 +1831,11 @@ func (a *Agent) RunStream(ctx context.Context, input string) (*RunStreamResult,
 			if resp == nil {
 				resp = &types.ModelResponse{}
 			}
+			a.recordUsage(ctx, output, resp)
+			if endModelCall != nil {
+				endModelCall(resp, nil)
+				endModelCall = nil
+			}
 
 			// Store assistant message.
 			reasoningContent := a.extractReasoning(ctx, resp)
@@ -963,52 +1849,82 @@ func (a *Agent) RunStream(ctx context.Context, input string) (*RunStreamResult,
 
 			// If no tool calls, finalize.
 			if !resp.HasToolCalls() {
+				// Content has already been streamed, so only local fixes apply.
+				finalContent, err := a.enforceFormat(ctx, output, resp.Content, currentInstructions, false)
+				if err != nil {
+					finishError(err)
+					return
+				}
+				a.recordModelRefusal(grounding, finalContent)
+
 				if len(a.PostHooks) > 0 {
 					a.logger.Debug("executing post-hooks (stream)", "count", len(a.PostHooks))
 					hookInput := hooks.NewHookInput(input).
-						WithOutput(resp.Content).
+						WithOutput(finalContent).
 						WithAgentID(a.ID).
-						WithMessages([]interface{}{})
+						WithMessages(hookMessages(a.Memory.GetMessages(a.UserID))).
+						WithReport(report)
+					hookInput.Metadata[guardrails.PIIMappingKey] = pii
 
 					if err := hooks.ExecuteHooks(ctx, a.PostHooks, hookInput); err != nil {
 						a.logger.Error("post-hook failed (stream)", "error", err)
-						doneCh <- RunStreamDone{
-							Output: nil,
-							Err:    types.NewOutputCheckError("post-hook validation failed", err),
-						}
+						finishError(types.NewOutputCheckError("post-hook validation failed", err))
 						return
 					}
+					finalContent = hookInput.Output
+				}
+
+				finalContent, guardrailResults, err := a.checkGuardrails(ctx, GuardrailStageOutput, a.outputGuardrails, finalContent, hookMessages(a.Memory.GetMessages(a.UserID)), report, pii, nil)
+				if err != nil {
+					finishError(err)
+					return
 				}
+				output.GuardrailReports = append(output.GuardrailReports, guardrailResults...)
 
 				a.logger.Info("agent run (stream) completed", "agent_id", a.ID)
 
 				output.Status = RunStatusCompleted
 				output.CompletedAt = time.Now().UTC()
-				output.Content = resp.Content
+				a.usage.addRun()
+				output.Content = finalContent
+				if report.HasFindings() {
+					output.GuardrailReport = report
+				}
 				output.Messages = a.Memory.GetMessages(a.UserID)
 				output.Metadata["loops"] = loopCount
-				output.Metadata["usage"] = resp.Usage
+				output.Metadata["usage"] = output.Usage
 				output.Metadata["cache_hit"] = false
 				addRunContextMetadata(output, runCtx)
 
 				completed := run.NewRunCompletedEvent(runID, a.ID, "", string(output.Status), output.Content)
 				output.appendEvent(completed)
 
+				runMessages := a.messagesSince(initialMessageCount)
 				a.scrubRunOutputWithContext(output, initialMessageCount)
 
 				// Persist run to session storage if configured.
+				output.SessionState = SessionStateFromContext(ctx).GetAll()
 				a.persistRunToSession(ctx, output)
+				a.saveRunRecord(ctx, input, output, runMessages)
 
-				doneCh <- RunStreamDone{
+				finish(RunStreamDone{
 					Output: output,
 					Err:    nil,
-				}
+				})
 				return
 			}
 
 			// Execute tool calls and loop for next streaming pass.
 			a.logger.Info("executing tool calls (stream)", "count", len(resp.ToolCalls))
 			summaries := a.executeToolCalls(ctx, resp.ToolCalls)
+			for _, summary := range summaries {
+				// Streamed runs cannot pause, so calls that need approval are blocked.
+				// 流式运行无法暂停，因此需要审批的调用会被阻止。
+				if summary.IsPendingApproval() {
+					summary.Status = ToolExecutionStatusBlocked
+					summary.Error += " (streaming runs cannot wait for approval)"
+				}
+			}
 			output.ToolsExecuted = append(output.ToolsExecuted, summaries...)
 		}
 
@@ -1018,7 +1934,7 @@ func (a *Agent) RunStream(ctx context.Context, input string) (*RunStreamResult,
 	}()
 
 	return &RunStreamResult{
-		Events: eventsCh,
+		Events: sender.out,
 		Done:   doneCh,
 	}, nil
 }
@@ -1100,33 +2016,110 @@ func attachRunContextToRequest(ctx context.Context, req *models.InvokeRequest) {
 }
 
 // executeToolCalls executes all tool calls with hooks and returns execution summaries.
+// Up to MaxParallelToolCalls calls run at once; summaries and tool messages keep
+// the order of toolCalls.
 // executeToolCalls 使用钩子执行所有工具调用并返回执行摘要。
+// 最多同时执行 MaxParallelToolCalls 个调用；摘要和工具消息保持 toolCalls 的顺序。
 func (a *Agent) executeToolCalls(ctx context.Context, toolCalls []types.ToolCall) []*ToolExecutionSummary {
-	var summaries []*ToolExecutionSummary
-
-	for _, tc := range toolCalls {
-		summary := a.executeSingleToolCall(ctx, tc)
-		summaries = append(summaries, summary)
-
-		// If blocked by pre-hook, skip adding to memory
-		// 如果被前置钩子阻止，则跳过添加到内存
-		if summary.Status == ToolExecutionStatusBlocked {
-			continue
+	summaries := make([]*ToolExecutionSummary, len(toolCalls))
+	messages := make([]*types.Message, len(toolCalls))
+
+	workers := min(a.maxParallelToolCalls, len(toolCalls))
+	if workers <= 1 {
+		for i, tc := range toolCalls {
+			summaries[i], messages[i] = a.executeSingleToolCall(ctx, tc)
+			a.recordToolCall(ctx, summaries[i], messages[i])
+			if messages[i] != nil {
+				a.Memory.Add(messages[i], a.UserID)
+			}
 		}
+		return summaries
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				summaries[i], messages[i] = a.executeSingleToolCall(ctx, toolCalls[i])
+				a.recordToolCall(ctx, summaries[i], messages[i])
+			}
+		}()
 	}
+	for i := range toolCalls {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
 
+	for _, msg := range messages {
+		if msg != nil {
+			a.Memory.Add(msg, a.UserID)
+		}
+	}
 	return summaries
 }
 
-// executeSingleToolCall executes one tool call with full hook lifecycle.
+// callTool runs a tool handler under the function's timeout, or the agent's
+// DefaultToolTimeout. The handler's context is cancelled when the timeout
+// expires; a handler that ignores it is abandoned.
+// callTool 在函数的超时（或代理的 DefaultToolTimeout）内运行工具处理函数。
+// 超时后处理函数的上下文会被取消；忽略上下文的处理函数会被放弃。
+func (a *Agent) callTool(ctx context.Context, fn *toolkit.Function, args map[string]interface{}) (interface{}, error) {
+	timeout := fn.Timeout
+	if timeout == 0 {
+		timeout = a.defaultToolTimeout
+	}
+	if timeout <= 0 {
+		return fn.Handler(ctx, args)
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		result interface{}
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := fn.Handler(callCtx, args)
+		done <- outcome{result, err}
+	}()
+
+	select {
+	case out := <-done:
+		// A handler that returns after its deadline still timed out.
+		// 在截止时间之后返回的处理函数仍视为超时。
+		if out.err == nil || ctx.Err() != nil || !errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+			return out.result, out.err
+		}
+	case <-callCtx.Done():
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+	a.logger.Warn("tool call timed out", "function", fn.Name, "timeout", timeout)
+	return nil, &ToolTimeoutError{FunctionName: fn.Name, Timeout: timeout}
+}
+
+// executeSingleToolCall executes one tool call with full hook lifecycle. It
+// returns the tool message for memory, or nil when the call did not run.
 // executeSingleToolCall 使用完整的钩子生命周期执行单个工具调用。
-func (a *Agent) executeSingleToolCall(ctx context.Context, tc types.ToolCall) *ToolExecutionSummary {
+// 返回要写入记忆的工具消息；调用未执行时返回 nil。
+func (a *Agent) executeSingleToolCall(ctx context.Context, tc types.ToolCall) (*ToolExecutionSummary, *types.Message) {
 	// Find the toolkit that has this function
 	// 找到包含此函数的工具包
 	var targetToolkit toolkit.Toolkit
 	for _, tk := range a.Toolkits {
 		if _, exists := tk.Functions()[tc.Function.Name]; exists {
-			targetToolkit = tk
+			// Tools disabled by a feature flag are treated as unknown.
+			// 被功能开关禁用的工具视为未知工具。
+			if a.toolEnabled(ctx, tk.Name(), tc.Function.Name) {
+				targetToolkit = tk
+			}
 			break
 		}
 	}
@@ -1134,7 +2127,6 @@ func (a *Agent) executeSingleToolCall(ctx context.Context, tc types.ToolCall) *T
 	if targetToolkit == nil {
 		errMsg := fmt.Sprintf("function %s not found in any toolkit", tc.Function.Name)
 		a.logger.Warn("tool not found", "function", tc.Function.Name)
-		a.Memory.Add(types.NewToolMessage(tc.ID, errMsg), a.UserID)
 		return &ToolExecutionSummary{
 			ToolCallID:   tc.ID,
 			FunctionName: tc.Function.Name,
@@ -1143,7 +2135,7 @@ func (a *Agent) executeSingleToolCall(ctx context.Context, tc types.ToolCall) *T
 			StartTime:    time.Now(),
 			EndTime:      time.Now(),
 			Metadata:     make(map[string]interface{}),
-		}
+		}, types.NewToolMessage(tc.ID, errMsg)
 	}
 
 	// Parse arguments
@@ -1152,7 +2144,6 @@ func (a *Agent) executeSingleToolCall(ctx context.Context, tc types.ToolCall) *T
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to parse arguments: %v", err)
 		a.logger.Error("argument parsing failed", "error", err)
-		a.Memory.Add(types.NewToolMessage(tc.ID, errMsg), a.UserID)
 		return &ToolExecutionSummary{
 			ToolCallID:   tc.ID,
 			FunctionName: tc.Function.Name,
@@ -1162,21 +2153,41 @@ func (a *Agent) executeSingleToolCall(ctx context.Context, tc types.ToolCall) *T
 			StartTime:    time.Now(),
 			EndTime:      time.Now(),
 			Metadata:     make(map[string]interface{}),
-		}
+		}, types.NewToolMessage(tc.ID, errMsg)
 	}
 
+	// Tools receive the values behind PII placeholders; results are anonymized again.
+	// 工具接收 PII 占位符对应的值；结果会再次匿名化。
+	pii := piiMappingFrom(ctx)
+	restorePII(pii, args)
+
 	// Create hook input for pre-execution
 	// 为 pre-execution 创建钩子输入
-	hookInput := hooks.NewToolHookInput(a.ID, tc.ID, tc.Function.Name, args)
+	hookInput := hooks.NewToolHookInput(a.ID, tc.ID, tc.Function.Name, args).WithUserID(a.UserID)
+	// Hooks and the tool share this input through the context.
+	// 钩子和工具通过上下文共享此输入。
+	ctx = hooks.WithToolHookInput(ctx, hookInput)
 
 	// Execute pre-hooks
 	// 执行前置钩子
 	if len(a.ToolHooks) > 0 {
 		if err := hooks.ExecuteToolPreHooks(ctx, a.ToolHooks, hookInput); err != nil {
+			var approval *hooks.ApprovalRequiredError
+			if errors.As(err, &approval) {
+				a.logger.Info("tool execution waits for approval", "function", tc.Function.Name)
+				summary := NewBlockedToolExecutionSummary(hookInput, err)
+				summary.Status = ToolExecutionStatusPendingApproval
+				return summary, nil
+			}
 			a.logger.Warn("tool execution blocked by pre-hook",
 				"function", tc.Function.Name,
 				"error", err)
-			return NewBlockedToolExecutionSummary(hookInput, err)
+			return NewBlockedToolExecutionSummary(hookInput, err), nil
+		}
+		// Pre-hooks may set the context the tool runs with, e.g. a tracing span.
+		// 前置钩子可以设置工具运行所用的上下文，例如追踪 span。
+		if hookCtx := hookInput.Context(); hookCtx != nil {
+			ctx = hookCtx
 		}
 	}
 
@@ -1188,13 +2199,20 @@ func (a *Agent) executeSingleToolCall(ctx context.Context, tc types.ToolCall) *T
 	if fn == nil {
 		errMsg := fmt.Sprintf("function %s not found", tc.Function.Name)
 		a.logger.Error("function not found", "function", tc.Function.Name)
-		a.Memory.Add(types.NewToolMessage(tc.ID, errMsg), a.UserID)
 		hookInput.WithResult(nil, fmt.Errorf("%s", errMsg))
-		return NewToolExecutionSummary(hookInput, nil, fmt.Errorf("%s", errMsg))
+		return NewToolExecutionSummary(hookInput, nil, fmt.Errorf("%s", errMsg)), types.NewToolMessage(tc.ID, errMsg)
 	}
 
 	startTime := time.Now()
-	result, execErr := fn.Handler(ctx, args)
+	result, skipped := hookInput.SkippedResult()
+	var execErr error
+	if skipped {
+		a.logger.Info("tool execution skipped by pre-hook", "function", tc.Function.Name)
+	} else if replay := traceReplayFrom(ctx); replay != nil {
+		result, execErr = replay.callTool(ctx, a, hookInput, fn, args)
+	} else {
+		result, execErr = a.callTool(ctx, fn, args)
+	}
 	hookInput.StartTime = startTime
 	hookInput.WithResult(result, execErr)
 
@@ -1211,10 +2229,9 @@ func (a *Agent) executeSingleToolCall(ctx context.Context, tc types.ToolCall) *T
 	// Handle execution result
 	// 处理执行结果
 	if execErr != nil {
-		errMsg := fmt.Sprintf("tool execution error: %v", execErr)
+		errMsg := pii.Anonymize(fmt.Sprintf("tool execution error: %v", execErr))
 		a.logger.Error("tool execution failed", "function", tc.Function.Name, "error", execErr)
-		a.Memory.Add(types.NewToolMessage(tc.ID, errMsg), a.UserID)
-		return NewToolExecutionSummary(hookInput, nil, execErr)
+		return NewToolExecutionSummary(hookInput, nil, execErr), types.NewToolMessage(tc.ID, errMsg)
 	}
 
 	// Format and store result
@@ -1225,19 +2242,21 @@ func (a *Agent) executeSingleToolCall(ctx context.Context, tc types.ToolCall) *T
 	}
 
 	a.logger.Info("tool executed successfully", "function", tc.Function.Name)
-	a.Memory.Add(types.NewToolMessage(tc.ID, resultStr), a.UserID)
-
-	return NewToolExecutionSummary(hookInput, result, nil)
+	return NewToolExecutionSummary(hookInput, result, nil), types.NewToolMessage(tc.ID, pii.Anonymize(resultStr))
 }
 
 // ClearMemory clears the agent's conversation history for this user
 // ClearMemory 清除此用户的Agent对话历史
 func (a *Agent) ClearMemory() {
 	a.Memory.Clear(a.UserID)
+	a.instructionsMu.Lock()
+	instructions := a.Instructions
+	a.systemInstructions = instructions
+	a.instructionsMu.Unlock()
 	// Re-add system message
 	// 重新添加系统消息
-	if a.Instructions != "" {
-		a.Memory.Add(types.NewSystemMessage(a.Instructions), a.UserID)
+	if instructions != "" {
+		a.Memory.Add(types.NewSystemMessage(instructions), a.UserID)
 	}
 }
 
@@ -1261,13 +2280,32 @@ func (a *Agent) GetInstructions() string {
 	return a.Instructions
 }
 
-// SetInstructions permanently sets the agent's instructions
-// SetInstructions 永久设置 agent 的指令
+// SetInstructions permanently sets the agent's instructions. The system
+// message in memory is left alone; runs replace it in their requests until
+// the memory is cleared.
+// SetInstructions 永久设置 agent 的指令。内存中的系统消息保持不变；
+// 在清除内存之前，运行会在请求中替换它。
 func (a *Agent) SetInstructions(instructions string) {
 	a.instructionsMu.Lock()
 	defer a.instructionsMu.Unlock()
 
 	a.Instructions = instructions
+	if a.promptComposer != nil {
+		if instructions != "" {
+			a.promptComposer.ReplaceSection(prompts.NewSection("instructions", instructions, 10))
+		} else {
+			a.promptComposer.RemoveSection("instructions")
+		}
+	}
+}
+
+// instructionsChanged reports whether instructions differ from the ones the
+// system message in memory was built from, so requests must replace it
+// instructionsChanged 报告指令是否与内存中系统消息所基于的指令不同，即请求需要替换它
+func (a *Agent) instructionsChanged(instructions string) bool {
+	a.instructionsMu.RLock()
+	defer a.instructionsMu.RUnlock()
+	return instructions != "" && instructions != a.systemInstructions
 }
 
 // SetTempInstructions temporarily sets instructions (only affects next Run)
@@ -1288,6 +2326,12 @@ func (a *Agent) ClearTempInstructions() {
 	a.tempInstructions = ""
 }
 
+// GetResources returns the shared resources container, or nil if none was configured
+// GetResources 返回共享资源容器，未配置时返回 nil
+func (a *Agent) GetResources() *resources.Resources {
+	return a.resources
+}
+
 // GetPromptComposer returns the prompt composer if available
 // GetPromptComposer 返回 prompt composer（如果可用）
 func (a *Agent) GetPromptComposer() *prompts.PromptComposer {
@@ -1323,11 +2367,7 @@ func (a *Agent) UpdatePromptSection(name string, content string) bool {
 		return false
 	}
 
-	// Remove old section and add updated one
-	// 删除旧部分并添加更新的部分
-	a.promptComposer.RemoveSection(name)
-	newSection := prompts.NewSection(name, content, section.Priority)
-	a.promptComposer.AddSection(newSection)
+	a.promptComposer.ReplaceSection(prompts.NewSection(name, content, section.Priority))
 
 	// Update system message in memory
 	// 更新内存中的系统消息
@@ -1455,8 +2495,12 @@ func (a *Agent) filterHistoryMessages(messages []*types.Message, initialCount in
 // extractReasoning 从响应中提取推理内容(优雅降级)
 // extractReasoning extracts reasoning from response (graceful degradation)
 func (a *Agent) extractReasoning(ctx context.Context, resp *types.ModelResponse) *types.ReasoningContent {
-	// 检查模型是否支持推理 / Check if model supports reasoning
+	// 检查模型是否支持推理；提供者单独返回的推理总是保留
+	// Check if model supports reasoning; reasoning the provider returned apart is always kept
 	if !reasoning.IsReasoningModel(a.Model) {
+		if resp != nil {
+			return resp.ReasoningContent
+		}
 		return nil
 	}
 
@@ -1549,3 +2593,14 @@ func (a *Agent) markRunCancelled(output *RunOutput, loopCount int, cacheHit bool
 	a.scrubRunOutputWithContext(output, initialMessageCount)
 	return output
 }
+
+// hookMessages converts conversation messages for hook inputs so output
+// guardrails can inspect tool results
+// hookMessages 将对话消息转换为钩子输入，以便输出防护栏检查工具结果
+func hookMessages(messages []*types.Message) []interface{} {
+	result := make([]interface{}, len(messages))
+	for i, msg := range messages {
+		result[i] = msg
+	}
+	return result
+}
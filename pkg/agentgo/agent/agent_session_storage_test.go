@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/storage"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+func TestNew_SessionStorageRequiresSessionID(t *testing.T) {
+	_, err := New(Config{
+		Model:          &MockModel{BaseModel: models.BaseModel{ID: "mock", Provider: "mock"}},
+		SessionStorage: storage.NewMemoryStorage(),
+	})
+	if err == nil {
+		t.Fatal("expected error when SessionStorage is set without SessionID")
+	}
+}
+
+func TestAgent_SessionStorage_SurvivesRestart(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	ctx := context.Background()
+
+	newAgent := func(seen *[]*types.Message) *Agent {
+		model := &MockModel{
+			BaseModel: models.BaseModel{ID: "mock", Provider: "mock"},
+			InvokeFunc: func(_ context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+				*seen = req.Messages
+				return &types.ModelResponse{Content: "reply"}, nil
+			},
+		}
+		ag, err := New(Config{
+			Model:          model,
+			Instructions:   "be helpful",
+			SessionID:      "sess-restart",
+			SessionStorage: store,
+		})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		return ag
+	}
+
+	var firstSeen []*types.Message
+	first := newAgent(&firstSeen)
+	if _, err := first.Run(ctx, "my name is Ada"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	runs, err := store.GetRuns(ctx, "sess-restart", 0)
+	if err != nil {
+		t.Fatalf("GetRuns() error = %v", err)
+	}
+	if len(runs) != 1 {
+		t.Fatalf("expected 1 stored run, got %d", len(runs))
+	}
+	if runs[0].Input != "my name is Ada" || runs[0].Content != "reply" || runs[0].Status != string(RunStatusCompleted) {
+		t.Fatalf("unexpected run record %+v", runs[0])
+	}
+	if len(runs[0].Messages) != 2 {
+		t.Fatalf("expected user and assistant messages to be stored, got %d", len(runs[0].Messages))
+	}
+
+	// A fresh agent (simulating a process restart) sees the previous turn.
+	var secondSeen []*types.Message
+	second := newAgent(&secondSeen)
+	if _, err := second.Run(ctx, "what is my name?"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	var contents []string
+	for _, msg := range secondSeen {
+		contents = append(contents, string(msg.Role)+":"+msg.Content)
+	}
+	want := []string{"system:be helpful", "user:my name is Ada", "assistant:reply", "user:what is my name?"}
+	if len(contents) != len(want) {
+		t.Fatalf("expected messages %v, got %v", want, contents)
+	}
+	for i := range want {
+		if contents[i] != want[i] {
+			t.Fatalf("expected messages %v, got %v", want, contents)
+		}
+	}
+
+	// Restoration happens only once per agent.
+	if _, err := second.Run(ctx, "again"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if n := len(secondSeen); n != 6 {
+		t.Fatalf("expected 6 messages on third turn, got %d", n)
+	}
+}
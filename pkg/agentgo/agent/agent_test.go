@@ -379,6 +379,59 @@ func TestAgent_Run_UsesCache(t *testing.T) {
 	if second.Status != RunStatusCompleted {
 		t.Fatalf("expected cached run to be completed")
 	}
+
+	agent.ClearMemory()
+	third, err := agent.Run(cache.WithBypass(context.Background()), "Hello")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if hit, _ := third.Metadata["cache_hit"].(bool); hit || callCount != 2 {
+		t.Fatalf("expected the bypassed run to call the provider, got %d calls", callCount)
+	}
+}
+
+func TestAgent_Run_CacheHitsAcrossRunIDs(t *testing.T) {
+	provider, err := cache.NewMemoryProvider(8, time.Minute)
+	if err != nil {
+		t.Fatalf("NewMemoryProvider error = %v", err)
+	}
+
+	callCount := 0
+	mockModel := &MockModel{
+		BaseModel: models.BaseModel{ID: "test", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			callCount++
+			return &types.ModelResponse{Content: "Cached result", Model: "test"}, nil
+		},
+	}
+
+	agent, err := New(Config{
+		Name:          "CacheAgent",
+		Model:         mockModel,
+		EnableCache:   true,
+		CacheProvider: provider,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	for i, runID := range []string{"run-a", "run-b"} {
+		agent.ClearMemory()
+		ctx := run.WithContext(context.Background(), &run.RunContext{RunID: runID})
+		output, err := agent.Run(ctx, "Hello")
+		if err != nil {
+			t.Fatalf("Run(%s) error = %v", runID, err)
+		}
+		if output.RunID != runID {
+			t.Fatalf("expected run ID %s, got %s", runID, output.RunID)
+		}
+		if hit, _ := output.Metadata["cache_hit"].(bool); hit != (i > 0) {
+			t.Fatalf("Run(%s) cache_hit = %v", runID, hit)
+		}
+	}
+	if callCount != 1 {
+		t.Fatalf("expected a single provider call across run IDs, got %d", callCount)
+	}
 }
 
 func TestAgent_Run_ContextCancelled(t *testing.T) {
@@ -1318,3 +1371,129 @@ func TestAgent_HistoryInjection_EmptyHistory(t *testing.T) {
 		}
 	}
 }
+
+func TestNew_FallbackModels(t *testing.T) {
+	primary := &MockModel{
+		BaseModel: models.BaseModel{ID: "primary", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			return nil, types.NewAPIError("API error (status 503): unavailable", nil)
+		},
+	}
+	secondary := &MockModel{
+		BaseModel: models.BaseModel{ID: "secondary", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			return &types.ModelResponse{Content: "from secondary"}, nil
+		},
+	}
+
+	var attempts int
+	ag, err := New(Config{
+		Model:          primary,
+		FallbackModels: []models.Model{secondary},
+		ModelRetry: &models.RetryConfig{
+			MaxRetries:     1,
+			InitialBackoff: time.Millisecond,
+			OnAttempt:      func(models.AttemptInfo) { attempts++ },
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if ag.ID != "agent-primary" {
+		t.Errorf("expected ID derived from primary model, got %s", ag.ID)
+	}
+
+	output, err := ag.Run(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if output.Content != "from secondary" {
+		t.Errorf("expected fallback response, got %q", output.Content)
+	}
+	// Two attempts on primary, one on secondary.
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestNew_ModelMiddleware(t *testing.T) {
+	var calls int
+	model := &MockModel{
+		BaseModel: models.BaseModel{ID: "primary", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			calls++
+			if calls == 1 {
+				return nil, types.NewAPIError("API error (status 503): unavailable", nil)
+			}
+			return &types.ModelResponse{Content: models.HeadersFromContext(ctx)["X-Tenant"]}, nil
+		},
+	}
+
+	var seen int
+	counting := func(next models.Handler) models.Handler {
+		return models.Handler{Invoke: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			seen++
+			return next.Invoke(ctx, req)
+		}}
+	}
+	ag, err := New(Config{
+		Model:           model,
+		ModelMiddleware: []models.Middleware{counting, models.SetHeaders(map[string]string{"X-Tenant": "acme"})},
+		ModelRetry:      &models.RetryConfig{InitialBackoff: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	output, err := ag.Run(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if output.Content != "acme" {
+		t.Errorf("expected the header set by middleware, got %q", output.Content)
+	}
+	// The middleware runs inside the retry, once per attempt.
+	if seen != 2 {
+		t.Errorf("expected middleware to see 2 attempts, got %d", seen)
+	}
+}
+
+func TestNew_ModelHedge(t *testing.T) {
+	primary := &MockModel{
+		BaseModel: models.BaseModel{ID: "primary", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+	secondary := &MockModel{
+		BaseModel: models.BaseModel{ID: "secondary", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			return &types.ModelResponse{Content: "from hedge"}, nil
+		},
+	}
+
+	hedges := make(chan models.HedgeInfo, 1)
+	ag, err := New(Config{
+		Model: primary,
+		ModelHedge: &models.HedgeConfig{
+			Delay:     5 * time.Millisecond,
+			Secondary: secondary,
+			OnHedge:   func(info models.HedgeInfo) { hedges <- info },
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	output, err := ag.Run(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if output.Content != "from hedge" {
+		t.Errorf("expected hedged response, got %q", output.Content)
+	}
+	if info := <-hedges; info.WinnerID != "secondary" {
+		t.Errorf("expected secondary to win, got %+v", info)
+	}
+}
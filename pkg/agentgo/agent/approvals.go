@@ -0,0 +1,208 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/hooks"
+	"github.com/jholhewres/agent-go/pkg/agentgo/ids"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// PendingApproval is a paused run waiting for a human to approve or reject
+// its tool calls
+// PendingApproval 是等待人工批准或拒绝其工具调用的暂停运行
+type PendingApproval struct {
+	ID        string           `json:"id"`
+	RunID     string           `json:"run_id"`
+	AgentID   string           `json:"agent_id"`
+	UserID    string           `json:"user_id,omitempty"`
+	ToolCalls []types.ToolCall `json:"tool_calls"` // Calls waiting for the decision / 等待决定的调用
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// pausedRun is a pending approval with the state needed to continue the run
+type pausedRun struct {
+	approval *PendingApproval
+	state    *RunState
+}
+
+// hasPendingApprovals reports whether any tool call waits for approval
+func hasPendingApprovals(summaries []*ToolExecutionSummary) bool {
+	for _, s := range summaries {
+		if s.IsPendingApproval() {
+			return true
+		}
+	}
+	return false
+}
+
+// pauseForApproval stores the run under a new approval ID and returns the
+// paused output. The calls in toolCalls that wait for approval become the
+// state's pending tool calls; the others have already run.
+func (a *Agent) pauseForApproval(output *RunOutput, state *RunState, toolCalls []types.ToolCall) *RunOutput {
+	approval := a.pausedApproval(output, state, toolCalls)
+	a.approvalsMu.Lock()
+	if a.approvals == nil {
+		a.approvals = make(map[string]*pausedRun)
+	}
+	a.approvals[approval.ID] = &pausedRun{approval: approval, state: state}
+	a.approvalsMu.Unlock()
+	return output
+}
+
+// pausedApproval moves the calls in toolCalls that wait for approval into the
+// state's pending tool calls and marks output as paused on the returned
+// approval. The caller keeps the state so the run can be resumed.
+func (a *Agent) pausedApproval(output *RunOutput, state *RunState, toolCalls []types.ToolCall) *PendingApproval {
+	waiting := make(map[string]bool)
+	executed := make([]*ToolExecutionSummary, 0, len(output.ToolsExecuted))
+	for _, s := range output.ToolsExecuted {
+		if s.IsPendingApproval() {
+			waiting[s.ToolCallID] = true
+			continue
+		}
+		executed = append(executed, s)
+	}
+	var pending []types.ToolCall
+	for _, tc := range toolCalls {
+		if waiting[tc.ID] {
+			pending = append(pending, tc)
+		}
+	}
+
+	state.PendingToolCalls = pending
+	state.Messages = a.Memory.GetMessages(a.UserID)
+	state.Usage = output.Usage
+	state.ToolsExecuted = executed
+
+	approval := &PendingApproval{
+		ID:        ids.Prefixed("approval"),
+		RunID:     state.RunID,
+		AgentID:   a.ID,
+		UserID:    a.UserID,
+		ToolCalls: pending,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	a.logger.Info("agent run paused for approval", "agent_id", a.ID, "run_id", state.RunID, "approval_id", approval.ID, "tool_calls", len(pending))
+
+	output.Status = RunStatusPaused
+	output.PendingApproval = approval
+	output.Messages = state.Messages
+	output.Metadata["loops"] = state.Loops
+	output.Metadata["usage"] = output.Usage
+	return approval
+}
+
+// PendingApprovals returns the runs waiting for a tool approval, oldest first
+// PendingApprovals 返回等待工具审批的运行，按时间从早到晚排列
+func (a *Agent) PendingApprovals() []*PendingApproval {
+	a.approvalsMu.Lock()
+	defer a.approvalsMu.Unlock()
+
+	out := make([]*PendingApproval, 0, len(a.approvals))
+	for _, p := range a.approvals {
+		out = append(out, p.approval)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+// Resume decides a pending approval and continues the paused run. When
+// approved, the tool calls run; otherwise they are cancelled and the model is
+// told the user rejected them. The run then continues until it completes or
+// pauses again. The conversation saved with the approval replaces what the
+// agent's Memory holds for its user.
+// Resume 对待处理的审批作出决定并继续暂停的运行。批准时执行工具调用；否则取消调用并告知模型用户已拒绝。
+// 之后运行继续，直到完成或再次暂停。审批时保存的对话会替换代理 Memory 中该用户的内容。
+func (a *Agent) Resume(ctx context.Context, approvalID string, approved bool) (runOutput *RunOutput, runErr error) {
+	a.approvalsMu.Lock()
+	paused, ok := a.approvals[approvalID]
+	if ok && paused.state.UserID == a.UserID {
+		delete(a.approvals, approvalID)
+	}
+	a.approvalsMu.Unlock()
+	if !ok {
+		return nil, types.NewInvalidInputError(fmt.Sprintf("unknown approval %q", approvalID), nil)
+	}
+	state := paused.state
+	if state.UserID != a.UserID {
+		return nil, types.NewInvalidInputError(fmt.Sprintf("approval belongs to user %q", state.UserID), nil)
+	}
+
+	ctx, runCtx := ensureRunContext(WithRunContext(ctx, state.RunID))
+	if runCtx != nil && runCtx.UserID == "" && a.UserID != "" {
+		runCtx.UserID = a.UserID
+	}
+	ctx = a.withFlagContext(ctx, runCtx)
+	ctx = a.withSessionState(ctx)
+	ctx, err := a.resolveDependencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	a.Memory.Clear(a.UserID)
+	for _, msg := range state.Messages {
+		a.Memory.Add(msg, a.UserID)
+	}
+	ctx = a.decideApproval(ctx, state, approved)
+
+	a.logger.Info("agent run resumed", "agent_id", a.ID, "run_id", state.RunID, "approval_id", approvalID, "approved", approved)
+	ctx, endRun := a.beginRun(ctx, state.Input, state.InitialMessageCount)
+	defer func() {
+		endRun(runOutput, runOutput != nil && runOutput.Status == RunStatusPaused, runErr)
+	}()
+	for {
+		res, err := a.turn(ctx, runCtx, state, false)
+		if err != nil {
+			if res != nil {
+				return res.Output, err
+			}
+			return nil, err
+		}
+		if res.Output != nil {
+			return res.Output, nil
+		}
+		// turn updated state in place; run the requested tools and continue.
+	}
+}
+
+// decideApproval applies the decision on the state's pending tool calls. The
+// conversation must already be in Memory. Approved calls run on the next turn
+// with the returned context; rejected ones are answered with a rejection.
+func (a *Agent) decideApproval(ctx context.Context, state *RunState, approved bool) context.Context {
+	if approved {
+		callIDs := make([]string, len(state.PendingToolCalls))
+		for i, tc := range state.PendingToolCalls {
+			callIDs[i] = tc.ID
+		}
+		return hooks.WithApprovedToolCalls(ctx, callIDs...)
+	}
+	for _, tc := range state.PendingToolCalls {
+		a.Memory.Add(types.NewToolMessage(tc.ID, "tool call rejected by the user"), a.UserID)
+		state.ToolsExecuted = append(state.ToolsExecuted, rejectedToolCall(tc))
+	}
+	state.PendingToolCalls = nil
+	return ctx
+}
+
+// rejectedToolCall summarizes a tool call the user rejected
+func rejectedToolCall(tc types.ToolCall) *ToolExecutionSummary {
+	var args map[string]interface{}
+	_ = json.Unmarshal([]byte(tc.Function.Arguments), &args)
+	now := time.Now()
+	return &ToolExecutionSummary{
+		ToolCallID:   tc.ID,
+		FunctionName: tc.Function.Name,
+		Arguments:    args,
+		Status:       ToolExecutionStatusBlocked,
+		Error:        "rejected by the user",
+		StartTime:    now,
+		EndTime:      now,
+		Metadata:     make(map[string]interface{}),
+	}
+}
@@ -0,0 +1,174 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/hooks"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+func approvalAgent(t *testing.T, deleted *[]string) (*Agent, *[][]*types.Message) {
+	t.Helper()
+	tk := toolkit.NewBaseToolkit("files")
+	tk.RegisterFunction(&toolkit.Function{
+		Name:       "delete_file",
+		Parameters: map[string]toolkit.Parameter{"path": {Type: "string", Required: true}},
+		Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			path, _ := args["path"].(string)
+			*deleted = append(*deleted, path)
+			return "deleted " + path, nil
+		},
+	})
+
+	var requests [][]*types.Message
+	model := &MockModel{
+		BaseModel: models.BaseModel{ID: "test", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			requests = append(requests, req.Messages)
+			last := req.Messages[len(req.Messages)-1]
+			if last.Role == types.RoleTool {
+				return &types.ModelResponse{Content: "Result: " + last.Content}, nil
+			}
+			return &types.ModelResponse{ToolCalls: []types.ToolCall{{
+				ID:       "call-1",
+				Type:     "function",
+				Function: types.ToolCallFunction{Name: "delete_file", Arguments: `{"path": "/tmp/report.txt"}`},
+			}}}, nil
+		},
+	}
+
+	ag, err := New(Config{
+		Model:       model,
+		Toolkits:    []toolkit.Toolkit{tk},
+		ToolHooks:   []hooks.ToolHook{hooks.NewDeferredApprovalHook("delete_file")},
+		RunStateKey: testRunStateKey,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return ag, &requests
+}
+
+func TestAgent_ApprovalPauseAndResume(t *testing.T) {
+	var deleted []string
+	ag, requests := approvalAgent(t, &deleted)
+
+	out, err := ag.Run(context.Background(), "Delete the report")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if out.Status != RunStatusPaused || out.PendingApproval == nil {
+		t.Fatalf("Run() status = %s, pending = %+v", out.Status, out.PendingApproval)
+	}
+	approval := out.PendingApproval
+	if len(approval.ToolCalls) != 1 || approval.ToolCalls[0].Function.Name != "delete_file" || approval.RunID != out.RunID {
+		t.Errorf("PendingApproval = %+v", approval)
+	}
+	if len(deleted) != 0 {
+		t.Fatal("tool ran before approval")
+	}
+	if pending := ag.PendingApprovals(); len(pending) != 1 || pending[0].ID != approval.ID {
+		t.Errorf("PendingApprovals() = %+v", pending)
+	}
+
+	out, err = ag.Resume(context.Background(), approval.ID, true)
+	if err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	if out.Status != RunStatusCompleted || !strings.Contains(out.Content, "deleted /tmp/report.txt") {
+		t.Errorf("Resume() status = %s, content = %q", out.Status, out.Content)
+	}
+	if len(deleted) != 1 {
+		t.Errorf("tool ran %d times, want once", len(deleted))
+	}
+	if len(out.ToolsExecuted) != 1 || !out.ToolsExecuted[0].IsSuccess() {
+		t.Errorf("ToolsExecuted = %+v", out.ToolsExecuted)
+	}
+	if len(*requests) != 2 {
+		t.Errorf("model called %d times, want 2", len(*requests))
+	}
+	if len(ag.PendingApprovals()) != 0 {
+		t.Error("approval still pending after Resume")
+	}
+
+	_, err = ag.Resume(context.Background(), approval.ID, true)
+	var agentErr *types.AgnoError
+	if !errors.As(err, &agentErr) || agentErr.Code != types.ErrCodeInvalidInput {
+		t.Errorf("second Resume() error = %v, want invalid input", err)
+	}
+}
+
+func TestAgent_ApprovalRejected(t *testing.T) {
+	var deleted []string
+	ag, _ := approvalAgent(t, &deleted)
+
+	out, err := ag.Run(context.Background(), "Delete the report")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	out, err = ag.Resume(context.Background(), out.PendingApproval.ID, false)
+	if err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	if len(deleted) != 0 {
+		t.Error("rejected tool ran")
+	}
+	if out.Status != RunStatusCompleted || !strings.Contains(out.Content, "rejected by the user") {
+		t.Errorf("Resume() status = %s, content = %q", out.Status, out.Content)
+	}
+	if len(out.ToolsExecuted) != 1 || !out.ToolsExecuted[0].IsBlocked() {
+		t.Errorf("ToolsExecuted = %+v", out.ToolsExecuted)
+	}
+}
+
+func TestAgent_ApprovalRunTurn(t *testing.T) {
+	for _, approved := range []bool{true, false} {
+		var deleted []string
+		ag, _ := approvalAgent(t, &deleted)
+
+		res, err := ag.RunTurn(context.Background(), "Delete the report")
+		if err != nil || res.Done() {
+			t.Fatalf("RunTurn() = %+v, %v; want pending tool calls", res, err)
+		}
+		res, err = ag.ResumeTurn(context.Background(), res.State)
+		if err != nil {
+			t.Fatalf("ResumeTurn: %v", err)
+		}
+		if res.Done() || res.Output == nil || res.Output.Status != RunStatusPaused || res.Output.PendingApproval == nil || len(res.State) == 0 {
+			t.Fatalf("ResumeTurn() = %+v, want a paused run with its state", res)
+		}
+		// The paused turn lives in the blob, not in the agent
+		if pending := ag.PendingApprovals(); len(pending) != 0 {
+			t.Errorf("PendingApprovals() = %+v, want none", pending)
+		}
+		if _, err := ag.ResumeTurn(context.Background(), res.State); err == nil {
+			t.Error("expected ResumeTurn() to refuse a turn waiting for approval")
+		}
+
+		// The decision is made by another instance, as in a later invocation
+		var elsewhere []string
+		other, _ := approvalAgent(t, &elsewhere)
+		res, err = other.ResumeTurnApproval(context.Background(), res.State, approved)
+		if err != nil {
+			t.Fatalf("ResumeTurnApproval: %v", err)
+		}
+		if !res.Done() || res.Output.Status != RunStatusCompleted {
+			t.Fatalf("ResumeTurnApproval() = %+v, want a completed run", res)
+		}
+		if len(deleted) != 0 {
+			t.Errorf("tool ran on the pausing instance: %v", deleted)
+		}
+		if approved && (len(elsewhere) != 1 || !strings.Contains(res.Output.Content, "deleted /tmp/report.txt")) {
+			t.Errorf("approved: deleted = %v, content = %q", elsewhere, res.Output.Content)
+		}
+		if !approved && (len(elsewhere) != 0 || !strings.Contains(res.Output.Content, "rejected by the user")) {
+			t.Errorf("rejected: deleted = %v, content = %q", elsewhere, res.Output.Content)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/embeddings"
+	"github.com/jholhewres/agent-go/pkg/agentgo/ids"
+	"github.com/jholhewres/agent-go/pkg/agentgo/knowledge"
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb/memvec"
+)
+
+const (
+	defaultAttachmentLimit       = 5
+	defaultAttachmentMaxFileSize = 20 << 20
+)
+
+// Attachment is a file passed to RunWithFiles. The extension of Name selects
+// the loader: PDF, CSV, DOCX, XLSX, HTML, JSON, or plain text.
+// Attachment 是传给 RunWithFiles 的文件，Name 的扩展名决定使用的加载器：
+// PDF、CSV、DOCX、XLSX、HTML、JSON 或纯文本。
+type Attachment struct {
+	Name string
+	Data []byte
+}
+
+// AttachmentConfig configures how RunWithFiles indexes attached files.
+// AttachmentConfig 配置 RunWithFiles 如何索引附加的文件。
+type AttachmentConfig struct {
+	// Embedder embeds the attachment chunks and the run's input (required for RunWithFiles)
+	// Embedder 嵌入附件片段和运行输入（RunWithFiles 必需）
+	Embedder embeddings.Embedder
+
+	// Chunker splits attachments (default: 1000-character chunks with 200 overlap)
+	// Chunker 切分附件（默认：1000 字符片段，重叠 200）
+	Chunker knowledge.Chunker
+
+	// Limit is the number of chunks given to the model (default: 5)
+	// Limit 是提供给模型的片段数（默认：5）
+	Limit int
+
+	// MinScore drops chunks scoring below it
+	// MinScore 丢弃得分低于该值的片段
+	MinScore float64
+
+	// MaxFileSize rejects larger files (default: 20MB)
+	// MaxFileSize 拒绝更大的文件（默认：20MB）
+	MaxFileSize int64
+}
+
+// runAttachments are the files indexed for one run
+type runAttachments struct {
+	names    []string
+	searcher KnowledgeSearcher
+}
+
+type attachmentsKey struct{}
+
+func withAttachments(ctx context.Context, attachments *runAttachments) context.Context {
+	return context.WithValue(ctx, attachmentsKey{}, attachments)
+}
+
+func attachmentsFromContext(ctx context.Context) *runAttachments {
+	attachments, _ := ctx.Value(attachmentsKey{}).(*runAttachments)
+	return attachments
+}
+
+// RunWithFiles runs the agent with files attached to the input. The files are
+// loaded, chunked and embedded into a temporary collection for the session,
+// and the chunks most relevant to the input are given to the model alongside
+// any knowledge, cited as CitationAttachment. The collection is discarded when
+// the run returns. Requires Config.Attachments.Embedder.
+// RunWithFiles 在输入附带文件的情况下运行代理。文件被加载、切分并嵌入到该会话的临时集合中，
+// 与输入最相关的片段会与知识一起提供给模型，并以 CitationAttachment 引用。运行返回后集合被丢弃。
+// 需要配置 Config.Attachments.Embedder。
+func (a *Agent) RunWithFiles(ctx context.Context, input string, files []Attachment) (*RunOutput, error) {
+	if len(files) == 0 {
+		return a.Run(ctx, input)
+	}
+	if a.attachments.Embedder == nil {
+		return nil, fmt.Errorf("attachments require an embedder: set Config.Attachments.Embedder")
+	}
+
+	collection := "attachments-" + ids.New()
+	if a.sessionID != "" {
+		collection = "attachments-" + a.sessionID + "-" + ids.New()
+	}
+	db, err := memvec.New(memvec.Config{CollectionName: collection})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create attachment collection: %w", err)
+	}
+	defer db.Close()
+
+	chunker := a.attachments.Chunker
+	if chunker == nil {
+		chunker = knowledge.NewCharacterChunker(1000, 200)
+	}
+	kb, err := knowledge.NewKnowledgeBase(knowledge.KnowledgeBaseConfig{
+		VectorDB: db,
+		Chunker:  chunker,
+		Embedder: a.attachments.Embedder,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create attachment collection: %w", err)
+	}
+
+	maxSize := a.attachments.MaxFileSize
+	if maxSize <= 0 {
+		maxSize = defaultAttachmentMaxFileSize
+	}
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		name := filepath.Base(file.Name)
+		if int64(len(file.Data)) > maxSize {
+			return nil, fmt.Errorf("attachment %s is %d bytes, above the %d byte limit", name, len(file.Data), maxSize)
+		}
+		loader, err := knowledge.LoaderForFile(name, file.Data, map[string]interface{}{"attachment": true})
+		if err != nil {
+			return nil, fmt.Errorf("attachment %s: %w", name, err)
+		}
+		kb.AddSource(loader)
+		names = append(names, name)
+	}
+	if _, err := kb.Sync(ctx); err != nil {
+		return nil, fmt.Errorf("failed to index attachments: %w", err)
+	}
+
+	return a.Run(withAttachments(ctx, &runAttachments{names: names, searcher: kb}), input)
+}
+
+// buildAttachmentContext searches the files attached to the run for the input
+// and formats the excerpts for the system prompt, numbered from first. The
+// attachment names are listed even when no excerpt matches.
+// buildAttachmentContext 按输入搜索运行附带的文件，并从 first 开始编号格式化后注入系统提示；
+// 即使没有匹配的片段也会列出附件名称。
+func (a *Agent) buildAttachmentContext(ctx context.Context, input string, first int) (string, []Citation, error) {
+	attachments := attachmentsFromContext(ctx)
+	if attachments == nil {
+		return "", nil, nil
+	}
+	header := "[Attachments]\nThe user attached these files: " + strings.Join(attachments.names, ", ") + "\n"
+	if strings.TrimSpace(input) == "" {
+		return header, nil, nil
+	}
+
+	limit := a.attachments.Limit
+	if limit <= 0 {
+		limit = defaultAttachmentLimit
+	}
+	results, err := attachments.searcher.Search(ctx, input, limit)
+	if err != nil {
+		return header, nil, fmt.Errorf("attachment search failed: %w", err)
+	}
+
+	var b strings.Builder
+	var citations []Citation
+	for _, result := range results {
+		if float64(result.Score) < a.attachments.MinScore || strings.TrimSpace(result.Content) == "" {
+			continue
+		}
+		index := first + len(citations)
+		citation := knowledgeCitation(index, result)
+		citation.Kind = CitationAttachment
+		fmt.Fprintf(&b, "\n[%d] (file: %s)\n%s\n", index, citation.Source, strings.TrimSpace(result.Content))
+		citations = append(citations, citation)
+	}
+	if len(citations) == 0 {
+		return header, nil, nil
+	}
+
+	cite := ""
+	if a.knowledgeCitations {
+		cite = "Cite the excerpts you use by their number, e.g. [1].\n"
+	}
+	return header + "Use these excerpts from them when they are relevant to the request:\n" + cite + b.String(), citations, nil
+}
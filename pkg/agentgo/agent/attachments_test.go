@@ -0,0 +1,116 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/knowledge"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// keywordEmbedder embeds text as counts of a few keywords
+type keywordEmbedder struct{}
+
+var attachmentKeywords = []string{"invoice", "refund", "penguin"}
+
+func (keywordEmbedder) EmbedSingle(_ context.Context, text string) ([]float32, error) {
+	text = strings.ToLower(text)
+	vector := make([]float32, len(attachmentKeywords)+1)
+	for i, word := range attachmentKeywords {
+		vector[i] = float32(strings.Count(text, word))
+	}
+	vector[len(attachmentKeywords)] = 0.01
+	return vector, nil
+}
+
+func (e keywordEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i], _ = e.EmbedSingle(ctx, text)
+	}
+	return vectors, nil
+}
+
+func TestAgent_RunWithFiles(t *testing.T) {
+	var capturedReq *models.InvokeRequest
+	model := &MockModel{
+		BaseModel: models.BaseModel{ID: "test", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			capturedReq = req
+			return &types.ModelResponse{Content: "Refunds take 5 days [1]."}, nil
+		},
+	}
+	ag, err := New(Config{
+		Model:              model,
+		Instructions:       "You are helpful.",
+		KnowledgeCitations: true,
+		Attachments: AttachmentConfig{
+			Embedder: keywordEmbedder{},
+			Chunker:  knowledge.NewParagraphChunker(50),
+			Limit:    1,
+		},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	files := []Attachment{
+		{Name: "docs/policy.txt", Data: []byte("Penguins live in Antarctica.\n\nA refund is paid within 5 days of the request.")},
+		{Name: "data.csv", Data: []byte("invoice,amount\nA-1,10\n")},
+	}
+	out, err := ag.RunWithFiles(context.Background(), "How long does a refund take?", files)
+	if err != nil {
+		t.Fatalf("RunWithFiles: %v", err)
+	}
+
+	prompt := systemPrompt(capturedReq)
+	if !strings.Contains(prompt, "[Attachments]") || !strings.Contains(prompt, "policy.txt, data.csv") {
+		t.Errorf("system prompt = %q, want the attachments listed", prompt)
+	}
+	if !strings.Contains(prompt, "[1] (file: policy.txt)") || !strings.Contains(prompt, "paid within 5 days") {
+		t.Errorf("system prompt = %q, want the refund excerpt", prompt)
+	}
+	if strings.Contains(prompt, "Antarctica") {
+		t.Errorf("system prompt = %q, want only the top excerpt", prompt)
+	}
+	if len(out.Citations) != 1 || out.Citations[0].Kind != CitationAttachment || out.Citations[0].Source != "policy.txt" {
+		t.Errorf("citations = %+v", out.Citations)
+	}
+
+	// The attachments only belong to that run
+	if _, err := ag.Run(context.Background(), "How long does a refund take?"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if strings.Contains(systemPrompt(capturedReq), "[Attachments]") {
+		t.Error("attachments leaked into a later run")
+	}
+}
+
+func TestAgent_RunWithFilesErrors(t *testing.T) {
+	model := &MockModel{BaseModel: models.BaseModel{ID: "test", Provider: "mock"}}
+	file := []Attachment{{Name: "notes.txt", Data: []byte("hello")}}
+
+	ag, err := New(Config{Model: model})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := ag.RunWithFiles(context.Background(), "hi", file); err == nil {
+		t.Error("expected an error without an embedder")
+	}
+
+	ag, err = New(Config{Model: model, Attachments: AttachmentConfig{Embedder: keywordEmbedder{}, MaxFileSize: 4}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := ag.RunWithFiles(context.Background(), "hi", file); err == nil || !strings.Contains(err.Error(), "limit") {
+		t.Errorf("err = %v, want the size limit", err)
+	}
+	ag.attachments.MaxFileSize = 0
+	binary := []Attachment{{Name: "image.png", Data: []byte{0x89, 'P', 'N', 'G', 0}}}
+	if _, err := ag.RunWithFiles(context.Background(), "hi", binary); !errors.Is(err, knowledge.ErrUnsupportedFileType) {
+		t.Errorf("err = %v, want ErrUnsupportedFileType", err)
+	}
+}
@@ -0,0 +1,68 @@
+package agent
+
+import "github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+
+// Citation kinds
+// 引用类型
+const (
+	CitationKnowledge  = "knowledge"  // Knowledge base chunk / 知识库片段
+	CitationMemory     = "memory"     // Message found by memory search / 内存搜索找到的消息
+	CitationAttachment = "attachment" // Chunk of a file attached with RunWithFiles / RunWithFiles 附带文件的片段
+)
+
+// Citation is an excerpt given to the model as run context, so UIs can show the
+// sources of an answer. Index is the [n] marker of the excerpt in the prompt.
+// StartChar and EndChar locate a knowledge chunk in its document when the
+// chunker recorded them; EndChar is 0 when the offsets are unknown.
+// Citation 是作为运行上下文提供给模型的摘录，便于界面展示回答的来源。Index 是摘录在提示中的 [n] 编号。
+// StartChar 和 EndChar 在分块器记录了偏移时定位知识片段在文档中的位置；偏移未知时 EndChar 为 0。
+type Citation struct {
+	Index      int                    `json:"index"`
+	Kind       string                 `json:"kind"`                  // One of the Citation kinds / Citation 类型之一
+	ID         string                 `json:"id,omitempty"`          // Chunk or message ID / 片段或消息 ID
+	DocumentID string                 `json:"document_id,omitempty"` // Document the chunk was cut from / 片段所属文档
+	Source     string                 `json:"source,omitempty"`      // Chunk source, or short_term/long_term for memory / 片段来源，或内存层级
+	StartChar  int                    `json:"start_char,omitempty"`  // Chunk start in the document / 片段在文档中的起始位置
+	EndChar    int                    `json:"end_char,omitempty"`    // Chunk end in the document / 片段在文档中的结束位置
+	Content    string                 `json:"content"`
+	Score      float64                `json:"score"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// knowledgeCitation records the chunk shown to the model as [index]
+func knowledgeCitation(index int, result vectordb.SearchResult) Citation {
+	c := Citation{
+		Index:    index,
+		Kind:     CitationKnowledge,
+		ID:       result.ID,
+		Content:  result.Content,
+		Score:    float64(result.Score),
+		Metadata: result.Metadata,
+	}
+	c.Source, _ = result.Metadata["source"].(string)
+	c.DocumentID, _ = result.Metadata["document_id"].(string)
+	start, okStart := metadataInt(result.Metadata, "start_char")
+	end, okEnd := metadataInt(result.Metadata, "end_char")
+	if okStart && okEnd && end > start {
+		c.StartChar, c.EndChar = start, end
+	}
+	return c
+}
+
+// metadataInt reads an integer stored in metadata, which vector databases may
+// return as any numeric type after a JSON round trip
+func metadataInt(metadata map[string]interface{}, key string) (int, bool) {
+	switch v := metadata[key].(type) {
+	case int:
+		return v, true
+	case int32:
+		return int(v), true
+	case int64:
+		return int(v), true
+	case float32:
+		return int(v), true
+	case float64:
+		return int(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,46 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+func TestAgent_MaxContextTokensTrimsRequest(t *testing.T) {
+	var lastRequest []*types.Message
+	model := &MockModel{
+		BaseModel: models.BaseModel{ID: "test", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			lastRequest = req.Messages
+			return &types.ModelResponse{Content: "ok"}, nil
+		},
+	}
+
+	ag, err := New(Config{
+		Model:            model,
+		Instructions:     "be brief",
+		MaxContextTokens: 60,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := ag.Run(context.Background(), strings.Repeat("x", 80)); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+	}
+
+	if got := models.TokenCounterFor(model).CountTokens(lastRequest); got > 60 {
+		t.Errorf("request has %d tokens, want <= 60", got)
+	}
+	if lastRequest[0].Role != types.RoleSystem {
+		t.Error("system instructions should be kept")
+	}
+	if len(ag.Memory.GetMessages(ag.UserID)) <= len(lastRequest) {
+		t.Error("stored memory should not be trimmed")
+	}
+}
@@ -0,0 +1,40 @@
+package agent
+
+import "context"
+
+// Subsystems that may degrade without failing a run
+// 可以降级而不会导致运行失败的子系统
+const (
+	SubsystemHistory      = "history"       // Session history provider / 会话历史提供者
+	SubsystemLearning     = "learning"      // Learned profile and memories / 学习到的用户档案和记忆
+	SubsystemKnowledge    = "knowledge"     // Knowledge search (vector DB, embedder) / 知识搜索（向量数据库、嵌入器）
+	SubsystemMemorySearch = "memory_search" // Memory search (EnableMemorySearch) / 内存搜索
+	SubsystemAttachments  = "attachments"   // Search of files attached with RunWithFiles / RunWithFiles 附带文件的搜索
+)
+
+// RunWarning reports an optional subsystem that failed during a run. The run
+// continued without the context that subsystem would have provided.
+// RunWarning 报告运行期间失败的可选子系统。运行会在缺少该子系统上下文的情况下继续。
+type RunWarning struct {
+	Subsystem string `json:"subsystem"` // One of the Subsystem constants / Subsystem 常量之一
+	Message   string `json:"message"`
+}
+
+// runContext is the context gathered before the first model call
+type runContext struct {
+	instructions string
+	grounding    *GroundingDecision
+	citations    []Citation
+	warnings     []RunWarning
+}
+
+// degrade records that subsystem failed with err and logs it; the run goes on
+// without that subsystem's context. Cancellation is not a degradation and is
+// left to the run loop.
+func (a *Agent) degrade(ctx context.Context, rc *runContext, subsystem string, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	a.logger.Warn("optional subsystem failed, continuing without it", "subsystem", subsystem, "error", err)
+	rc.warnings = append(rc.warnings, RunWarning{Subsystem: subsystem, Message: err.Error()})
+}
@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+func TestAgent_DegradesWhenOptionalSubsystemsFail(t *testing.T) {
+	var prompts []string
+	ag, err := New(Config{
+		Model: &MockModel{
+			BaseModel: models.BaseModel{ID: "mock", Provider: "mock"},
+			InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+				prompts = append(prompts, systemPrompt(req))
+				return &types.ModelResponse{Content: "ok"}, nil
+			},
+		},
+		Instructions:    "You are helpful.",
+		SessionID:       "s1",
+		HistoryProvider: &mockHistoryProvider{err: errors.New("session store unreachable")},
+		Knowledge:       &mockKnowledge{err: errors.New("embedder timeout")},
+		RunStateKey:     testRunStateKey,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+
+	output, err := ag.Run(ctx, "hello")
+	if err != nil {
+		t.Fatalf("Run should degrade instead of failing: %v", err)
+	}
+	if output.Content != "ok" || strings.Contains(prompts[0], "[Knowledge]") {
+		t.Fatalf("content = %q, prompt = %q", output.Content, prompts[0])
+	}
+	if len(output.Warnings) != 2 ||
+		output.Warnings[0].Subsystem != SubsystemHistory ||
+		output.Warnings[1].Subsystem != SubsystemKnowledge ||
+		!strings.Contains(output.Warnings[1].Message, "embedder timeout") {
+		t.Fatalf("warnings = %+v", output.Warnings)
+	}
+
+	turn, err := ag.RunTurn(ctx, "hello again")
+	if err != nil {
+		t.Fatalf("RunTurn failed: %v", err)
+	}
+	if !turn.Done() || len(turn.Output.Warnings) != 2 {
+		t.Fatalf("turn warnings = %+v", turn.Output.Warnings)
+	}
+}
+
+func TestAgent_NoWarningsWhenSubsystemsHealthy(t *testing.T) {
+	ag, _ := New(Config{
+		Model: &MockModel{
+			BaseModel: models.BaseModel{ID: "mock", Provider: "mock"},
+			InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+				return &types.ModelResponse{Content: "ok"}, nil
+			},
+		},
+		Knowledge: &mockKnowledge{},
+	})
+
+	output, err := ag.Run(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if output.Warnings != nil {
+		t.Errorf("expected no warnings, got %+v", output.Warnings)
+	}
+}
@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// DependencyFunc resolves a dependency when a run starts, e.g. the current
+// user's account or a request-scoped client
+// DependencyFunc 在运行开始时解析依赖，例如当前用户的账户或请求范围的客户端
+type DependencyFunc func(ctx context.Context) (interface{}, error)
+
+type dependenciesKey struct{}
+
+// WithDependencies returns a context whose runs get deps on top of (and
+// overriding) Config.Dependencies. Values may be DependencyFuncs.
+// WithDependencies 返回一个上下文，其中的运行在 Config.Dependencies 之上获得 deps（同名时覆盖）。值可以是 DependencyFunc。
+func WithDependencies(ctx context.Context, deps map[string]interface{}) context.Context {
+	merged := make(map[string]interface{}, len(deps))
+	if existing, ok := ctx.Value(dependenciesKey{}).(map[string]interface{}); ok {
+		for name, value := range existing {
+			merged[name] = value
+		}
+	}
+	for name, value := range deps {
+		merged[name] = value
+	}
+	return context.WithValue(ctx, dependenciesKey{}, merged)
+}
+
+// Dependency returns the resolved dependency name of the current run. Tool
+// handlers and hooks use it instead of closing over values when the toolkit is built.
+// Dependency 返回当前运行中已解析的依赖 name。工具处理器和钩子用它代替在构建工具包时捕获的闭包值。
+func Dependency(ctx context.Context, name string) (interface{}, bool) {
+	value, ok := DependenciesFromContext(ctx)[name]
+	return value, ok
+}
+
+// DependenciesFromContext returns the resolved dependencies of the current run
+// DependenciesFromContext 返回当前运行中已解析的依赖
+func DependenciesFromContext(ctx context.Context) map[string]interface{} {
+	resolved, _ := ctx.Value(resolvedDependenciesKey{}).(map[string]interface{})
+	return resolved
+}
+
+type resolvedDependenciesKey struct{}
+
+// resolveDependencies resolves Config.Dependencies and those set with
+// WithDependencies, calling every DependencyFunc once, and returns the
+// context carrying the values
+// resolveDependencies 解析 Config.Dependencies 和通过 WithDependencies 设置的依赖，每个 DependencyFunc
+// 调用一次，并返回带有这些值的上下文
+func (a *Agent) resolveDependencies(ctx context.Context) (context.Context, error) {
+	overrides, _ := ctx.Value(dependenciesKey{}).(map[string]interface{})
+	if len(a.dependencies) == 0 && len(overrides) == 0 {
+		return ctx, nil
+	}
+
+	deps := make(map[string]interface{}, len(a.dependencies)+len(overrides))
+	for name, value := range a.dependencies {
+		deps[name] = value
+	}
+	for name, value := range overrides {
+		deps[name] = value
+	}
+
+	// Resolve in name order so resolvers with side effects run predictably
+	names := make([]string, 0, len(deps))
+	for name := range deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resolved := make(map[string]interface{}, len(deps))
+	for _, name := range names {
+		value := deps[name]
+		var resolve DependencyFunc
+		switch fn := value.(type) {
+		case DependencyFunc:
+			resolve = fn
+		case func(context.Context) (interface{}, error):
+			resolve = fn
+		}
+		if resolve != nil {
+			var err error
+			if value, err = resolve(ctx); err != nil {
+				return ctx, fmt.Errorf("resolve dependency %q: %w", name, err)
+			}
+		}
+		resolved[name] = value
+	}
+	return context.WithValue(ctx, resolvedDependenciesKey{}, resolved), nil
+}
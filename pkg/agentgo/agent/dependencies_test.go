@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/run"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+type inventory map[string]int
+
+func TestAgent_Dependencies(t *testing.T) {
+	tk := toolkit.NewBaseToolkit("shop")
+	tk.RegisterFunction(&toolkit.Function{
+		Name: "stock",
+		Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			stock, ok := Dependency(ctx, "inventory")
+			if !ok {
+				return nil, errors.New("no inventory")
+			}
+			return stock.(inventory)["apple"], nil
+		},
+	})
+
+	var prompts, toolResults []string
+	model := &MockModel{
+		BaseModel: models.BaseModel{ID: "mock", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			last := req.Messages[len(req.Messages)-1]
+			if last.Role == types.RoleTool {
+				toolResults = append(toolResults, last.Content)
+				return &types.ModelResponse{Content: "done"}, nil
+			}
+			prompts = append(prompts, req.Messages[0].Content)
+			return &types.ModelResponse{ToolCalls: []types.ToolCall{{
+				ID: "call-1", Type: "function", Function: types.ToolCallFunction{Name: "stock", Arguments: `{}`},
+			}}}, nil
+		},
+	}
+
+	resolved := 0
+	ag, err := New(Config{
+		Model:        model,
+		Toolkits:     []toolkit.Toolkit{tk},
+		Instructions: "You work at {{.dependencies.shop}} serving {{.dependencies.customer}}.",
+		Dependencies: map[string]interface{}{
+			"shop":      "Acme",
+			"inventory": inventory{"apple": 3},
+			"customer": DependencyFunc(func(ctx context.Context) (interface{}, error) {
+				resolved++
+				rc, _ := run.FromContext(ctx)
+				return strings.ToUpper(rc.UserID), nil
+			}),
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if _, err := ag.Run(userContext("ada"), "how many apples?"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if prompts[0] != "You work at Acme serving ADA." || toolResults[0] != "3" || resolved != 1 {
+		t.Fatalf("prompt = %q, tool result = %q, resolved %d times", prompts[0], toolResults[0], resolved)
+	}
+
+	// Per-run values override the configured ones
+	ctx := WithDependencies(userContext("bob"), map[string]interface{}{"inventory": inventory{"apple": 7}})
+	if _, err := ag.Run(ctx, "and now?"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if prompts[1] != "You work at Acme serving BOB." || toolResults[1] != "7" {
+		t.Fatalf("prompt = %q, tool result = %q", prompts[1], toolResults[1])
+	}
+}
+
+func TestAgent_DependencyError(t *testing.T) {
+	ag, err := New(Config{
+		Model: &MockModel{},
+		Dependencies: map[string]interface{}{
+			"account": func(ctx context.Context) (interface{}, error) { return nil, errors.New("account service down") },
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := ag.Run(context.Background(), "hi"); err == nil || !strings.Contains(err.Error(), `resolve dependency "account": account service down`) {
+		t.Fatalf("expected the resolver error, got %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/guardrails"
+	"github.com/jholhewres/agent-go/pkg/agentgo/hooks"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+func TestAgent_FactCheckGuardrailReport(t *testing.T) {
+	model := &MockModel{
+		BaseModel: models.BaseModel{ID: "test", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			return &types.ModelResponse{Content: "Revenue was $4.2 million in 2023, up 12%."}, nil
+		},
+	}
+	factCheck, err := guardrails.NewFactCheckGuardrail(guardrails.FactCheckConfig{})
+	if err != nil {
+		t.Fatalf("NewFactCheckGuardrail: %v", err)
+	}
+
+	ag, err := New(Config{Model: model, PostHooks: []hooks.Hook{factCheck}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ag.Memory.Add(types.NewToolMessage("call-1", `{"revenue_usd": 4200000, "year": 2023}`), ag.UserID)
+
+	out, err := ag.Run(context.Background(), "How was 2023?")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if out.GuardrailReport == nil {
+		t.Fatal("expected a guardrail report")
+	}
+	findings := out.GuardrailReport.Findings()
+	if len(findings) != 1 || findings[0].Value != "12%" {
+		t.Errorf("unexpected findings: %+v", findings)
+	}
+}
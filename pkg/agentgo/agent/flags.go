@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/flags"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/run"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+)
+
+// withFlagContext attaches a flag evaluation context to ctx. An evaluation
+// context supplied by the caller is kept; a missing UserID is filled from the run.
+// withFlagContext 将功能开关评估上下文附加到 ctx。
+func (a *Agent) withFlagContext(ctx context.Context, rc *run.RunContext) context.Context {
+	if a.flagProvider == nil {
+		return ctx
+	}
+
+	evalCtx, _ := flags.EvalContextFromContext(ctx)
+	if evalCtx.UserID == "" {
+		if rc != nil && rc.UserID != "" {
+			evalCtx.UserID = rc.UserID
+		} else {
+			evalCtx.UserID = a.UserID
+		}
+	}
+	return flags.WithEvalContext(ctx, evalCtx)
+}
+
+// instructionsForRun returns the system instructions for this run, composing
+// the prompt sections (flag-gated ones for the current evaluation context) and
+// rendering the session state into them.
+// instructionsForRun 返回本次运行的系统指令，组合提示部分（受开关控制的部分按当前评估上下文），并渲染会话状态。
+func (a *Agent) instructionsForRun(ctx context.Context) string {
+	return a.renderInstructions(ctx, a.composeInstructions(ctx))
+}
+
+// composeInstructions composes the prompt sections around the current
+// instructions. It runs under the lock, so a concurrent SetInstructions swaps
+// the instructions and their prompt section together.
+// composeInstructions 组合当前指令所在的提示部分。在锁内执行，使并发的 SetInstructions 同时替换指令及其提示部分。
+func (a *Agent) composeInstructions(ctx context.Context) string {
+	a.instructionsMu.RLock()
+	defer a.instructionsMu.RUnlock()
+
+	if a.tempInstructions != "" {
+		return a.tempInstructions
+	}
+	instructions := a.Instructions
+	if a.promptComposer == nil {
+		return instructions
+	}
+
+	var composed string
+	var err error
+	if a.flagProvider != nil && a.promptComposer.HasFlaggedSections() {
+		evalCtx, _ := flags.EvalContextFromContext(ctx)
+		composed, err = a.promptComposer.ComposeWithFlags(ctx, a.flagProvider, evalCtx, a.promptVars)
+	} else {
+		composed, err = a.promptComposer.ComposeWithVars(a.promptVars)
+	}
+	if err != nil {
+		a.logger.Warn("failed to compose prompt, using default instructions", "error", err)
+		return instructions
+	}
+	return composed
+}
+
+// toolEnabled reports whether a toolkit function is available for this run.
+// A flag configured for the function name takes precedence over one
+// configured for the toolkit name; tools without a flag are always enabled.
+// toolEnabled 判断工具函数在本次运行中是否可用。
+func (a *Agent) toolEnabled(ctx context.Context, toolkitName, functionName string) bool {
+	if a.flagProvider == nil || len(a.toolFlags) == 0 {
+		return true
+	}
+
+	key, ok := a.toolFlags[functionName]
+	if !ok {
+		key, ok = a.toolFlags[toolkitName]
+	}
+	if !ok {
+		return true
+	}
+
+	evalCtx, _ := flags.EvalContextFromContext(ctx)
+	return a.flagProvider.BoolValue(ctx, key, false, evalCtx)
+}
+
+// toolDefinitions returns the tool definitions enabled for this run
+// toolDefinitions 返回本次运行启用的工具定义
+func (a *Agent) toolDefinitions(ctx context.Context) []models.ToolDefinition {
+	if a.flagProvider == nil || len(a.toolFlags) == 0 {
+		return toolkit.ToModelToolDefinitions(a.Toolkits)
+	}
+
+	var enabled []toolkit.Toolkit
+	for _, tk := range a.Toolkits {
+		filtered := toolkit.NewBaseToolkit(tk.Name())
+		for name, fn := range tk.Functions() {
+			if a.toolEnabled(ctx, tk.Name(), name) {
+				filtered.RegisterFunction(fn)
+			}
+		}
+		if len(filtered.Functions()) > 0 {
+			enabled = append(enabled, filtered)
+		}
+	}
+	if len(enabled) == 0 {
+		return nil
+	}
+	return toolkit.ToModelToolDefinitions(enabled)
+}
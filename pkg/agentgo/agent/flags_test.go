@@ -0,0 +1,109 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/flags"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/prompts"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/calculator"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+func TestAgent_FeatureFlags_GateToolsAndPrompt(t *testing.T) {
+	provider := flags.NewStaticProvider(map[string]interface{}{"math_tools": false})
+	provider.SetForUser("beta", "math_tools", true)
+	provider.SetForUser("beta", "beta_prompt", true)
+
+	var lastReq *models.InvokeRequest
+	model := &MockModel{
+		BaseModel: models.BaseModel{ID: "mock", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			lastReq = req
+			return &types.ModelResponse{Content: "ok"}, nil
+		},
+	}
+
+	betaSection := prompts.NewSection("beta", "You may use beta features.", 20)
+	betaSection.FlagKey = "beta_prompt"
+
+	ag, err := New(Config{
+		Model:          model,
+		Toolkits:       []toolkit.Toolkit{calculator.New()},
+		PromptComposer: prompts.NewPromptComposer(prompts.NewSection("base", "Be helpful.", 10), betaSection),
+		FlagProvider:   provider,
+		ToolFlags:      map[string]string{"calculator": "math_tools"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// Regular user: no tools, no beta section.
+	if _, err := ag.Run(context.Background(), "hi"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(lastReq.Tools) != 0 {
+		t.Errorf("expected tools to be hidden, got %d", len(lastReq.Tools))
+	}
+	if system := lastReq.Messages[0]; system.Role != types.RoleSystem || strings.Contains(system.Content, "beta") {
+		t.Errorf("expected system prompt without beta section, got %q", system.Content)
+	}
+
+	// Beta user via eval context: tools and beta section enabled.
+	ctx := flags.WithEvalContext(context.Background(), flags.EvalContext{UserID: "beta"})
+	if _, err := ag.Run(ctx, "hi again"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(lastReq.Tools) != 4 {
+		t.Errorf("expected calculator tools, got %d", len(lastReq.Tools))
+	}
+	if system := lastReq.Messages[0]; !strings.Contains(system.Content, "You may use beta features.") {
+		t.Errorf("expected beta section in system prompt, got %q", system.Content)
+	}
+}
+
+func TestAgent_FeatureFlags_DisabledToolNotExecuted(t *testing.T) {
+	provider := flags.NewStaticProvider(nil)
+
+	calls := 0
+	model := &MockModel{
+		BaseModel: models.BaseModel{ID: "mock", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			calls++
+			if calls == 1 {
+				return &types.ModelResponse{
+					ToolCalls: []types.ToolCall{{
+						ID:   "call-1",
+						Type: "function",
+						Function: types.ToolCallFunction{
+							Name:      "divide",
+							Arguments: `{"a": 4, "b": 2}`,
+						},
+					}},
+				}, nil
+			}
+			return &types.ModelResponse{Content: "done"}, nil
+		},
+	}
+
+	ag, err := New(Config{
+		Model:        model,
+		Toolkits:     []toolkit.Toolkit{calculator.New()},
+		FlagProvider: provider,
+		ToolFlags:    map[string]string{"divide": "division"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	output, err := ag.Run(context.Background(), "divide")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(output.ToolsExecuted) != 1 || output.ToolsExecuted[0].Status != ToolExecutionStatusFailed {
+		t.Fatalf("expected flagged-off tool call to fail, got %+v", output.ToolsExecuted)
+	}
+}
@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/format"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// formatPolicyFor returns the format policy for the channel on ctx, falling
+// back to the agent-wide policy.
+// formatPolicyFor 返回 ctx 中渠道对应的格式策略，否则使用代理级策略。
+func (a *Agent) formatPolicyFor(ctx context.Context) *format.Policy {
+	if channel := format.ChannelFromContext(ctx); channel != "" {
+		if policy, ok := a.channelFormatPolicies[channel]; ok {
+			return policy
+		}
+	}
+	return a.formatPolicy
+}
+
+// enforceFormat applies the run's format policy to content. When repair is
+// true, remaining violations are sent back to the model up to MaxRepairs
+// times; repair turns are not stored in memory. Violations left over are
+// recorded in output metadata, and fail the run when the policy is strict.
+// enforceFormat 对内容应用本次运行的格式策略，必要时请求模型修复。
+func (a *Agent) enforceFormat(ctx context.Context, output *RunOutput, content, instructions string, repair bool) (string, error) {
+	policy := a.formatPolicyFor(ctx)
+	if policy == nil {
+		return content, nil
+	}
+
+	formatted, violations := policy.Apply(content)
+
+	repairs := 0
+	var extra []*types.Message
+	for repair && len(violations) > 0 && repairs < policy.MaxRepairs {
+		repairs++
+
+		messages := a.Memory.GetMessages(a.UserID)
+		if a.instructionsChanged(instructions) {
+			messages = a.updateSystemMessage(messages, instructions)
+		}
+		extra = append(extra, types.NewUserMessage(policy.RepairPrompt(violations)))
+		messages = a.fitContext(ctx, append(messages, extra...))
+
+		req := &models.InvokeRequest{Messages: messages, Seed: a.seed}
+		if a.responseFormat != nil {
+			req.ResponseFormat = a.responseFormat
+		}
+		attachRunContextToRequest(ctx, req)
+
+		resp, err := a.invokeModel(ctx, req)
+		if err != nil {
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil {
+				return "", types.NewCancellationError("agent run cancelled", err)
+			}
+			a.logger.Warn("format repair failed, keeping previous answer", "error", err)
+			break
+		}
+		a.recordUsage(ctx, output, resp)
+		extra = append(extra, types.NewAssistantMessage(resp.Content))
+
+		formatted, violations = policy.Apply(resp.Content)
+	}
+
+	if repairs > 0 {
+		output.Metadata["format_repairs"] = repairs
+	}
+	if len(violations) > 0 {
+		output.Metadata["format_violations"] = violations
+		if policy.Strict {
+			return "", types.NewOutputCheckError("response violates format policy: "+violations[0].Message, nil)
+		}
+		a.logger.Warn("response violates format policy", "agent_id", a.ID, "violations", len(violations))
+	}
+	return formatted, nil
+}
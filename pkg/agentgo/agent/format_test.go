@@ -0,0 +1,106 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/format"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+func TestAgent_FormatPolicyRepair(t *testing.T) {
+	calls := 0
+	model := &MockModel{
+		BaseModel: models.BaseModel{ID: "test", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			calls++
+			if calls == 1 {
+				return &types.ModelResponse{Content: "Sure, here it is: {\"ok\": true}"}, nil
+			}
+			last := req.Messages[len(req.Messages)-1]
+			if !strings.Contains(last.Content, "not valid JSON") {
+				t.Errorf("expected repair prompt, got %q", last.Content)
+			}
+			return &types.ModelResponse{Content: "```json\n{\"ok\": true}\n```"}, nil
+		},
+	}
+
+	ag, err := New(Config{
+		Model:        model,
+		FormatPolicy: &format.Policy{JSONOnly: true, MaxRepairs: 2},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	out, err := ag.Run(context.Background(), "status?")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if out.Content != `{"ok": true}` {
+		t.Errorf("Content = %q", out.Content)
+	}
+	if calls != 2 || out.Metadata["format_repairs"] != 1 {
+		t.Errorf("expected one repair call, got %d calls, metadata %v", calls, out.Metadata["format_repairs"])
+	}
+	if _, ok := out.Metadata["format_violations"]; ok {
+		t.Error("no violations should remain")
+	}
+}
+
+func TestAgent_ChannelFormatPolicy(t *testing.T) {
+	model := &MockModel{
+		BaseModel: models.BaseModel{ID: "test", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			return &types.ModelResponse{Content: "**Hello** there, this is a long answer."}, nil
+		},
+	}
+	ag, err := New(Config{
+		Model: model,
+		ChannelFormatPolicies: map[string]*format.Policy{
+			"sms": {Markdown: format.MarkdownNone, MaxLength: 20},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	out, err := ag.Run(format.WithChannel(context.Background(), "sms"), "hi")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if out.Content != "Hello there, this…" {
+		t.Errorf("sms Content = %q", out.Content)
+	}
+
+	out, err = ag.Run(context.Background(), "hi")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !strings.HasPrefix(out.Content, "**Hello**") {
+		t.Errorf("default channel should be unformatted, got %q", out.Content)
+	}
+}
+
+func TestAgent_StrictFormatPolicy(t *testing.T) {
+	ag, err := New(Config{
+		Model:        &MockModel{BaseModel: models.BaseModel{ID: "test", Provider: "mock"}},
+		FormatPolicy: &format.Policy{JSONOnly: true, Strict: true},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if _, err := ag.Run(context.Background(), "hi"); err == nil {
+		t.Fatal("expected strict policy violation error")
+	}
+
+	if _, err := New(Config{
+		Model:        &MockModel{BaseModel: models.BaseModel{ID: "test", Provider: "mock"}},
+		FormatPolicy: &format.Policy{JSONOnly: true, CodeBlockOnly: true},
+	}); err == nil {
+		t.Error("expected invalid policy error")
+	}
+}
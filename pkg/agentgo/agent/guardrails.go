@@ -0,0 +1,276 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/events"
+	"github.com/jholhewres/agent-go/pkg/agentgo/guardrails"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// GuardrailAction is what the agent does when a guardrail rejects content
+// GuardrailAction 是防护栏拒绝内容时代理采取的操作
+type GuardrailAction string
+
+const (
+	// GuardrailBlock fails the run (default)
+	// GuardrailBlock 使运行失败（默认）
+	GuardrailBlock GuardrailAction = "block"
+	// GuardrailWarn records the result and continues
+	// GuardrailWarn 记录结果并继续
+	GuardrailWarn GuardrailAction = "warn"
+	// GuardrailRedact replaces the offending content and continues; the guardrail
+	// must implement guardrails.Redactor
+	// GuardrailRedact 替换违规内容并继续；防护栏必须实现 guardrails.Redactor
+	GuardrailRedact GuardrailAction = "redact"
+)
+
+// GuardrailStage is the point of the run where a guardrail is checked
+// GuardrailStage 是运行中检查防护栏的阶段
+type GuardrailStage string
+
+const (
+	// GuardrailStageInput checks the user input before the model is called
+	// GuardrailStageInput 在调用模型之前检查用户输入
+	GuardrailStageInput GuardrailStage = "input"
+	// GuardrailStageOutput checks the answer before it is returned
+	// GuardrailStageOutput 在返回之前检查回答
+	GuardrailStageOutput GuardrailStage = "output"
+)
+
+// GuardrailConfig pairs a guardrail with the action taken when it fails
+// GuardrailConfig 将防护栏与其失败时采取的操作配对
+type GuardrailConfig struct {
+	Guardrail guardrails.Guardrail
+	Action    GuardrailAction // Empty means GuardrailBlock / 为空表示 GuardrailBlock
+	// MaxRetries is how many times a blocking output guardrail sends a failed
+	// answer back to the model with a corrective system message before the
+	// run fails (Run and RunTurn only; RunStream has already streamed the answer)
+	// MaxRetries 是阻断型输出防护栏在运行失败前将失败回答连同纠正系统消息发回模型的次数
+	// （仅适用于 Run 和 RunTurn；RunStream 已经流式输出了回答）
+	MaxRetries int
+}
+
+// GuardrailResult is the outcome of one guardrail check
+// GuardrailResult 是一次防护栏检查的结果
+type GuardrailResult struct {
+	Guardrail string          `json:"guardrail"`
+	Stage     GuardrailStage  `json:"stage"`
+	Action    GuardrailAction `json:"action"`
+	Passed    bool            `json:"passed"`
+	Message   string          `json:"message,omitempty"`  // Why the check failed / 检查失败的原因
+	Redacted  bool            `json:"redacted,omitempty"` // Whether content was replaced / 内容是否被替换
+	Retries   int             `json:"retries,omitempty"`  // Answers sent back for correction / 发回修正的回答次数
+}
+
+// validateGuardrails fills in default actions and checks that redacting
+// guardrails can redact
+func validateGuardrails(configs []GuardrailConfig) ([]GuardrailConfig, error) {
+	out := make([]GuardrailConfig, 0, len(configs))
+	for _, gc := range configs {
+		if gc.Guardrail == nil {
+			return nil, types.NewInvalidConfigError("guardrail must not be nil", nil)
+		}
+		if gc.MaxRetries < 0 {
+			return nil, types.NewInvalidConfigError(fmt.Sprintf("guardrail %s: max retries must not be negative", gc.Guardrail.Name()), nil)
+		}
+		switch gc.Action {
+		case "":
+			gc.Action = GuardrailBlock
+		case GuardrailBlock, GuardrailWarn:
+		case GuardrailRedact:
+			if _, ok := gc.Guardrail.(guardrails.Redactor); !ok {
+				return nil, types.NewInvalidConfigError(fmt.Sprintf("guardrail %s cannot redact", gc.Guardrail.Name()), nil)
+			}
+		default:
+			return nil, types.NewInvalidConfigError(fmt.Sprintf("unknown guardrail action %q", gc.Action), nil)
+		}
+		out = append(out, gc)
+	}
+	return out, nil
+}
+
+// guardrailRetry asks the model for a new answer after a blocking guardrail
+// failed, passing instructions on how to fix the previous one
+type guardrailRetry func(correction string) (string, error)
+
+// checkGuardrails runs configs against text in order and returns the text,
+// redacted where a redacting guardrail failed or a guardrail rewrote it, with
+// one result per guardrail. A blocking failure returns an InputCheckError or
+// OutputCheckError, unless retry is set and the guardrail has retries left:
+// then the revised answer is checked by every guardrail again. Output
+// guardrails see the answer as both Input and Output, so guardrails that only
+// read Input, such as PII detection, check the answer too. pii is shared with
+// guardrails under guardrails.PIIMappingKey.
+func (a *Agent) checkGuardrails(ctx context.Context, stage GuardrailStage, configs []GuardrailConfig, text string, messages []interface{}, report *guardrails.GuardrailReport, pii guardrails.PIIMapping, retry guardrailRetry) (string, []GuardrailResult, error) {
+	if len(configs) == 0 {
+		return text, nil, nil
+	}
+
+	retries := make([]int, len(configs))
+	for {
+		checked, results, failed, err := a.runGuardrails(ctx, stage, configs, text, messages, report, pii)
+		if err == nil {
+			for i := range results {
+				results[i].Retries = retries[i]
+			}
+			return checked, results, nil
+		}
+
+		name := configs[failed].Guardrail.Name()
+		if retry != nil && retries[failed] < configs[failed].MaxRetries {
+			retries[failed]++
+			a.logger.Warn("guardrail failed, asking the model to correct the answer", "agent_id", a.ID, "guardrail", name, "retry", retries[failed], "error", err)
+			if text, err = retry(guardrailCorrection(name, err)); err != nil {
+				return "", nil, err
+			}
+			continue
+		}
+
+		a.logger.Error("guardrail blocked run", "agent_id", a.ID, "guardrail", name, "stage", stage, "error", err)
+		a.metrics.GuardrailBlocked(a.ID, string(stage), name)
+		msg := fmt.Sprintf("guardrail %s blocked the %s", name, stage)
+		if retries[failed] > 0 {
+			msg += fmt.Sprintf(" after %d retries", retries[failed])
+		}
+		if stage == GuardrailStageInput {
+			return "", nil, types.NewInputCheckError(msg, err)
+		}
+		return "", nil, types.NewOutputCheckError(msg, err)
+	}
+}
+
+// runGuardrails checks text once with every guardrail. On a blocking failure
+// it returns the index of the failed guardrail and its error.
+func (a *Agent) runGuardrails(ctx context.Context, stage GuardrailStage, configs []GuardrailConfig, text string, messages []interface{}, report *guardrails.GuardrailReport, pii guardrails.PIIMapping) (string, []GuardrailResult, int, error) {
+	results := make([]GuardrailResult, 0, len(configs))
+	for i, gc := range configs {
+		input := &guardrails.CheckInput{
+			Input:    text,
+			Messages: messages,
+			Metadata: map[string]interface{}{"stage": string(stage), guardrails.PIIMappingKey: pii},
+			Report:   report,
+		}
+		if stage == GuardrailStageOutput {
+			input.Output = text
+		}
+
+		result := GuardrailResult{Guardrail: gc.Guardrail.Name(), Stage: stage, Action: gc.Action, Passed: true}
+		if err := gc.Guardrail.Check(ctx, input); err != nil {
+			result.Passed = false
+			result.Message = err.Error()
+			if a.publishing(ctx) {
+				a.publish(ctx, &events.GuardrailTriggered{
+					Header:    a.eventHeader(ctx),
+					Guardrail: result.Guardrail,
+					Stage:     string(stage),
+					Action:    string(gc.Action),
+					Message:   result.Message,
+				})
+			}
+
+			switch gc.Action {
+			case GuardrailWarn:
+				a.logger.Warn("guardrail failed", "agent_id", a.ID, "guardrail", result.Guardrail, "stage", stage, "error", err)
+			case GuardrailRedact:
+				text = gc.Guardrail.(guardrails.Redactor).Redact(text)
+				result.Redacted = true
+			default:
+				return "", nil, i, err
+			}
+		} else if rewritten := checkedText(stage, input); rewritten != text {
+			text = rewritten
+			result.Redacted = true
+		}
+		results = append(results, result)
+	}
+	return text, results, -1, nil
+}
+
+// guardrailCorrection returns the corrective message for a failed check,
+// preferring the guardrail's own instructions
+func guardrailCorrection(name string, err error) string {
+	var corrector guardrails.Corrector
+	if errors.As(err, &corrector) {
+		return corrector.Correction()
+	}
+	return fmt.Sprintf("Your previous answer failed the %s check: %v. Answer again and fix the problem.", name, err)
+}
+
+// outputRetry returns a guardrailRetry that re-invokes the model with the
+// conversation so far plus the rejected answers and corrections. Like format
+// repairs, these turns are not stored in memory.
+func (a *Agent) outputRetry(ctx context.Context, output *RunOutput, instructions string) guardrailRetry {
+	var extra []*types.Message
+	return func(correction string) (string, error) {
+		messages := a.Memory.GetMessages(a.UserID)
+		if a.instructionsChanged(instructions) {
+			messages = a.updateSystemMessage(messages, instructions)
+		}
+		extra = append(extra, types.NewSystemMessage(correction))
+		messages = a.fitContext(ctx, append(messages, extra...))
+
+		req := &models.InvokeRequest{Messages: messages, Seed: a.seed}
+		if a.responseFormat != nil {
+			req.ResponseFormat = a.responseFormat
+		}
+		attachRunContextToRequest(ctx, req)
+
+		resp, err := a.invokeModel(ctx, req)
+		if err != nil {
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil {
+				return "", types.NewCancellationError("agent run cancelled", err)
+			}
+			return "", types.NewAPIError("guardrail retry failed", err)
+		}
+		a.recordUsage(ctx, output, resp)
+		extra = append(extra, types.NewAssistantMessage(resp.Content))
+		return resp.Content, nil
+	}
+}
+
+// checkedText returns the text a guardrail checked at stage, as it left it
+func checkedText(stage GuardrailStage, input *guardrails.CheckInput) string {
+	if stage == GuardrailStageOutput {
+		return input.Output
+	}
+	return input.Input
+}
+
+type piiMappingKey struct{}
+
+// withPIIMapping attaches the run's PII mapping to ctx, so tool calls can use
+// the values behind the placeholders the model sees
+func withPIIMapping(ctx context.Context, pii guardrails.PIIMapping) context.Context {
+	return context.WithValue(ctx, piiMappingKey{}, pii)
+}
+
+// piiMappingFrom returns the run's PII mapping, or nil
+func piiMappingFrom(ctx context.Context) guardrails.PIIMapping {
+	pii, _ := ctx.Value(piiMappingKey{}).(guardrails.PIIMapping)
+	return pii
+}
+
+// restorePII replaces PII placeholders in tool call arguments with the
+// original values
+func restorePII(pii guardrails.PIIMapping, value interface{}) interface{} {
+	if len(pii) == 0 {
+		return value
+	}
+	switch v := value.(type) {
+	case string:
+		return pii.Restore(v)
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = restorePII(pii, item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = restorePII(pii, item)
+		}
+	}
+	return value
+}
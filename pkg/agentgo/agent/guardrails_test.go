@@ -0,0 +1,345 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/guardrails"
+	"github.com/jholhewres/agent-go/pkg/agentgo/hooks"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/calculator"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+func guardrailModel(answer string, seen *string) *MockModel {
+	return &MockModel{
+		BaseModel: models.BaseModel{ID: "test", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			if seen != nil {
+				*seen = req.Messages[len(req.Messages)-1].Content
+			}
+			return &types.ModelResponse{Content: answer}, nil
+		},
+	}
+}
+
+func TestAgent_InputGuardrails(t *testing.T) {
+	pii := guardrails.NewPIIDetectionGuardrailWithTypes([]guardrails.PIIType{guardrails.PIITypeEmail}, "block")
+
+	t.Run("redact", func(t *testing.T) {
+		var seen string
+		ag, err := New(Config{
+			Model:           guardrailModel("Done.", &seen),
+			InputGuardrails: []GuardrailConfig{{Guardrail: pii, Action: GuardrailRedact}},
+		})
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+
+		out, err := ag.Run(context.Background(), "Email john@example.com the report")
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if seen != "Email [REDACTED:email] the report" {
+			t.Errorf("model saw %q, want the redacted input", seen)
+		}
+		if len(out.GuardrailReports) != 1 {
+			t.Fatalf("GuardrailReports = %+v", out.GuardrailReports)
+		}
+		r := out.GuardrailReports[0]
+		if r.Passed || !r.Redacted || r.Stage != GuardrailStageInput || r.Guardrail != "PIIDetectionGuardrail" {
+			t.Errorf("result = %+v", r)
+		}
+	})
+
+	t.Run("block", func(t *testing.T) {
+		called := false
+		model := guardrailModel("Done.", nil)
+		model.InvokeFunc = func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			called = true
+			return &types.ModelResponse{Content: "Done."}, nil
+		}
+		ag, err := New(Config{Model: model, InputGuardrails: []GuardrailConfig{{Guardrail: pii}}})
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+
+		_, err = ag.Run(context.Background(), "Email john@example.com the report")
+		var agentErr *types.AgnoError
+		if !errors.As(err, &agentErr) || agentErr.Code != types.ErrCodeInputCheck {
+			t.Fatalf("Run error = %v, want an input check error", err)
+		}
+		if called {
+			t.Error("model called for blocked input")
+		}
+		if len(ag.Memory.GetMessages(ag.UserID)) != 0 {
+			t.Error("blocked input added to memory")
+		}
+	})
+
+	t.Run("passed", func(t *testing.T) {
+		ag, err := New(Config{Model: guardrailModel("Done.", nil), InputGuardrails: []GuardrailConfig{{Guardrail: pii}}})
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		out, err := ag.Run(context.Background(), "Send the report")
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if len(out.GuardrailReports) != 1 || !out.GuardrailReports[0].Passed || out.GuardrailReports[0].Action != GuardrailBlock {
+			t.Errorf("GuardrailReports = %+v", out.GuardrailReports)
+		}
+	})
+}
+
+func TestAgent_OutputGuardrails(t *testing.T) {
+	urls := guardrails.NewURLValidationGuardrailWithBlockedDomains([]string{"evil.com"})
+	pii := guardrails.NewPIIDetectionGuardrailWithTypes([]guardrails.PIIType{guardrails.PIITypeEmail}, "block")
+	answer := "Write to jane@example.com or see https://evil.com/x"
+
+	ag, err := New(Config{
+		Model: guardrailModel(answer, nil),
+		OutputGuardrails: []GuardrailConfig{
+			{Guardrail: urls, Action: GuardrailWarn},
+			{Guardrail: pii, Action: GuardrailRedact},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	out, err := ag.Run(context.Background(), "Who do I contact?")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if out.Content != "Write to [REDACTED:email] or see https://evil.com/x" {
+		t.Errorf("Content = %q", out.Content)
+	}
+	if len(out.GuardrailReports) != 2 {
+		t.Fatalf("GuardrailReports = %+v", out.GuardrailReports)
+	}
+	warn, redact := out.GuardrailReports[0], out.GuardrailReports[1]
+	if warn.Passed || warn.Redacted || warn.Action != GuardrailWarn || !strings.Contains(warn.Message, "blocked domain") {
+		t.Errorf("warn result = %+v", warn)
+	}
+	if redact.Passed || !redact.Redacted || redact.Stage != GuardrailStageOutput {
+		t.Errorf("redact result = %+v", redact)
+	}
+
+	// Blocking output fails the run
+	ag, err = New(Config{Model: guardrailModel(answer, nil), OutputGuardrails: []GuardrailConfig{{Guardrail: urls}}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	_, err = ag.Run(context.Background(), "Who do I contact?")
+	var agentErr *types.AgnoError
+	if !errors.As(err, &agentErr) || agentErr.Code != types.ErrCodeOutputCheck {
+		t.Errorf("Run error = %v, want an output check error", err)
+	}
+}
+
+func TestAgent_GuardrailsRunTurn(t *testing.T) {
+	pii := guardrails.NewPIIDetectionGuardrailWithTypes([]guardrails.PIIType{guardrails.PIITypeEmail}, "block")
+	ag, err := New(Config{
+		Model:            guardrailModel("Sent to jane@example.com.", nil),
+		InputGuardrails:  []GuardrailConfig{{Guardrail: pii, Action: GuardrailRedact}},
+		OutputGuardrails: []GuardrailConfig{{Guardrail: pii, Action: GuardrailRedact}},
+		RunStateKey:      testRunStateKey,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	res, err := ag.RunTurn(context.Background(), "Email john@example.com")
+	if err != nil {
+		t.Fatalf("RunTurn: %v", err)
+	}
+	if !res.Done() {
+		t.Fatal("expected a completed turn")
+	}
+	if res.Output.Content != "Sent to [REDACTED:email]." {
+		t.Errorf("Content = %q", res.Output.Content)
+	}
+	if len(res.Output.GuardrailReports) != 2 || res.Output.GuardrailReports[0].Stage != GuardrailStageInput {
+		t.Errorf("GuardrailReports = %+v", res.Output.GuardrailReports)
+	}
+}
+
+func TestAgent_RunTurnStateHidesPII(t *testing.T) {
+	pii := guardrails.NewPIIDetectionGuardrailWithTypes([]guardrails.PIIType{guardrails.PIITypeEmail}, "redact")
+	model := &MockModel{
+		BaseModel: models.BaseModel{ID: "test", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			return &types.ModelResponse{ToolCalls: []types.ToolCall{{
+				ID:       "call-1",
+				Type:     "function",
+				Function: types.ToolCallFunction{Name: "add", Arguments: `{"a": 1, "b": 2}`},
+			}}}, nil
+		},
+	}
+	ag, err := New(Config{
+		Model:       model,
+		Toolkits:    []toolkit.Toolkit{calculator.New()},
+		PreHooks:    []hooks.Hook{pii},
+		RunStateKey: testRunStateKey,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	res, err := ag.RunTurn(context.Background(), "Mail the sum to ana@example.com")
+	if err != nil {
+		t.Fatalf("RunTurn: %v", err)
+	}
+	if len(res.State) == 0 {
+		t.Fatal("expected a state blob")
+	}
+	if bytes.Contains(res.State, []byte("ana@example.com")) {
+		t.Errorf("state blob leaks the redacted value: %s", res.State)
+	}
+
+	// The mapping still travels with the state
+	state, err := ag.DecodeRunState(res.State)
+	if err != nil {
+		t.Fatalf("DecodeRunState: %v", err)
+	}
+	if state.PIIMapping["<EMAIL_1>"] != "ana@example.com" {
+		t.Errorf("PIIMapping = %v", state.PIIMapping)
+	}
+}
+
+func TestNew_GuardrailValidation(t *testing.T) {
+	model := guardrailModel("", nil)
+	injection := guardrails.NewPromptInjectionGuardrail()
+
+	tests := []struct {
+		name   string
+		config GuardrailConfig
+	}{
+		{"nil guardrail", GuardrailConfig{Action: GuardrailWarn}},
+		{"unknown action", GuardrailConfig{Guardrail: injection, Action: "ignore"}},
+		{"redact without redactor", GuardrailConfig{Guardrail: injection, Action: GuardrailRedact}},
+	}
+	for _, tt := range tests {
+		if _, err := New(Config{Model: model, OutputGuardrails: []GuardrailConfig{tt.config}}); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestAgent_PIIRedactMode(t *testing.T) {
+	pii := guardrails.NewPIIDetectionGuardrailWithTypes([]guardrails.PIIType{guardrails.PIITypeEmail}, "redact")
+
+	var toolArg string
+	tk := toolkit.NewBaseToolkit("mail")
+	tk.RegisterFunction(&toolkit.Function{
+		Name:       "send_mail",
+		Parameters: map[string]toolkit.Parameter{"to": {Type: "string", Required: true}},
+		Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			toolArg, _ = args["to"].(string)
+			return "sent to " + toolArg, nil
+		},
+	})
+
+	var requests [][]*types.Message
+	model := &MockModel{
+		BaseModel: models.BaseModel{ID: "test", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			requests = append(requests, req.Messages)
+			if len(requests) == 1 {
+				return &types.ModelResponse{ToolCalls: []types.ToolCall{{
+					ID:       "call-1",
+					Type:     "function",
+					Function: types.ToolCallFunction{Name: "send_mail", Arguments: `{"to": "<EMAIL_1>"}`},
+				}}}, nil
+			}
+			return &types.ModelResponse{Content: "Mailed <EMAIL_1>; copy ops@example.com."}, nil
+		},
+	}
+
+	ag, err := New(Config{
+		Model:     model,
+		Toolkits:  []toolkit.Toolkit{tk},
+		PreHooks:  []hooks.Hook{pii},
+		PostHooks: []hooks.Hook{pii},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	out, err := ag.Run(context.Background(), "Mail the report to ana@example.com")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := requests[0][len(requests[0])-1].Content; got != "Mail the report to <EMAIL_1>" {
+		t.Errorf("model saw %q, want the redacted input", got)
+	}
+	if toolArg != "ana@example.com" {
+		t.Errorf("tool received %q, want the original value", toolArg)
+	}
+	if got := requests[1][len(requests[1])-1].Content; strings.Contains(got, "ana@example.com") {
+		t.Errorf("tool result %q was not anonymized", got)
+	}
+	if out.Content != "Mailed <EMAIL_1>; copy <EMAIL_2>." {
+		t.Errorf("Content = %q", out.Content)
+	}
+}
+
+func TestAgent_OutputGuardrailRetry(t *testing.T) {
+	schema, err := guardrails.NewSchemaGuardrail(guardrails.SchemaConfig{Schema: map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"city": map[string]interface{}{"type": "string"}},
+		"required":   []string{"city"},
+	}})
+	if err != nil {
+		t.Fatalf("NewSchemaGuardrail: %v", err)
+	}
+
+	answers := []string{"It is Lisbon.", `{"town": "Lisbon"}`, `{"city": "Lisbon"}`}
+	var requests [][]*types.Message
+	model := &MockModel{
+		BaseModel: models.BaseModel{ID: "test", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			requests = append(requests, req.Messages)
+			return &types.ModelResponse{Content: answers[len(requests)-1]}, nil
+		},
+	}
+
+	ag, err := New(Config{Model: model, OutputGuardrails: []GuardrailConfig{{Guardrail: schema, MaxRetries: 2}}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	out, err := ag.Run(context.Background(), "Capital of Portugal as JSON")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if out.Content != `{"city": "Lisbon"}` {
+		t.Errorf("Content = %q", out.Content)
+	}
+	if len(out.GuardrailReports) != 1 || !out.GuardrailReports[0].Passed || out.GuardrailReports[0].Retries != 2 {
+		t.Errorf("GuardrailReports = %+v", out.GuardrailReports)
+	}
+	last := requests[2][len(requests[2])-1]
+	if last.Role != types.RoleSystem || !strings.Contains(last.Content, `missing required property "city"`) {
+		t.Errorf("last retry message = %+v", last)
+	}
+	if n := len(ag.Memory.GetMessages(ag.UserID)); n != 2 {
+		t.Errorf("memory has %d messages, want retries kept out of memory", n)
+	}
+
+	// Out of retries
+	requests = nil
+	ag, err = New(Config{Model: model, OutputGuardrails: []GuardrailConfig{{Guardrail: schema, MaxRetries: 1}}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	_, err = ag.Run(context.Background(), "Capital of Portugal as JSON")
+	var agentErr *types.AgnoError
+	if !errors.As(err, &agentErr) || agentErr.Code != types.ErrCodeOutputCheck || !strings.Contains(err.Error(), "after 1 retries") {
+		t.Errorf("Run error = %v, want an output check error", err)
+	}
+}
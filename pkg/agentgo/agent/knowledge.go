@@ -0,0 +1,184 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/prompts"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+)
+
+// DefaultKnowledgeRefusalMessage is the answer of a strict knowledge agent when
+// retrieval does not support an answer.
+// DefaultKnowledgeRefusalMessage 是严格知识模式下检索不足以支持回答时的默认回复。
+const DefaultKnowledgeRefusalMessage = "I couldn't find the answer to that in the knowledge base."
+
+// Grounding decision reasons
+// 依据决策原因
+const (
+	GroundingReasonAnswered      = "answered"       // Answered from retrieved chunks / 基于检索片段回答
+	GroundingReasonNoResults     = "no_results"     // Nothing retrieved above KnowledgeMinScore / 没有检索到结果
+	GroundingReasonLowConfidence = "low_confidence" // Best chunk below the threshold / 最佳片段低于阈值
+	GroundingReasonSearchFailed  = "search_failed"  // Knowledge search returned an error / 知识搜索失败
+	GroundingReasonModelDeclined = "model_declined" // The model found no answer in the chunks / 模型在片段中未找到答案
+)
+
+// KnowledgeSearcher retrieves knowledge relevant to a run's input.
+// knowledge.KnowledgeBase implements it.
+// KnowledgeSearcher 检索与运行输入相关的知识，knowledge.KnowledgeBase 实现了该接口。
+type KnowledgeSearcher interface {
+	// Search returns the k chunks most relevant to query
+	Search(ctx context.Context, query string, k int) ([]vectordb.SearchResult, error)
+}
+
+// resolveKnowledgeAliases copies NumDocuments, SimilarityThreshold and
+// CitationsEnabled into the Knowledge options they alias
+func resolveKnowledgeAliases(config *Config) error {
+	if config.NumDocuments != 0 {
+		if config.KnowledgeLimit != 0 && config.KnowledgeLimit != config.NumDocuments {
+			return types.NewInvalidConfigError("NumDocuments and KnowledgeLimit are set to different values", nil)
+		}
+		config.KnowledgeLimit = config.NumDocuments
+	}
+	if config.SimilarityThreshold != 0 {
+		if config.KnowledgeMinScore != 0 && config.KnowledgeMinScore != config.SimilarityThreshold {
+			return types.NewInvalidConfigError("SimilarityThreshold and KnowledgeMinScore are set to different values", nil)
+		}
+		config.KnowledgeMinScore = config.SimilarityThreshold
+	}
+	config.KnowledgeCitations = config.KnowledgeCitations || config.CitationsEnabled
+	return nil
+}
+
+// GroundingDecision records whether a strict knowledge run answered or refused.
+// When Refused is set by retrieval, the model was not called.
+// GroundingDecision 记录严格知识模式下的运行是回答还是拒绝。由检索导致拒绝时不会调用模型。
+type GroundingDecision struct {
+	Refused   bool     `json:"refused"`
+	Reason    string   `json:"reason"`            // One of the GroundingReason constants / GroundingReason 常量之一
+	TopScore  float64  `json:"top_score"`         // Best retrieved score / 最佳检索得分
+	Threshold float64  `json:"threshold"`         // KnowledgeConfidenceThreshold / 置信度阈值
+	Chunks    int      `json:"chunks"`            // Chunks given to the model / 提供给模型的片段数
+	Sources   []string `json:"sources,omitempty"` // Sources of those chunks / 片段来源
+}
+
+// refused reports whether the run must answer with the refusal message
+func (g *GroundingDecision) refused() bool {
+	return g != nil && g.Refused
+}
+
+// refuse marks the decision as refused for reason; nil decisions are ignored
+func (g *GroundingDecision) refuse(reason string) {
+	if g != nil {
+		g.Refused = true
+		g.Reason = reason
+	}
+}
+
+// buildKnowledgeContext searches the knowledge base for the input and formats the
+// results for the system prompt, returning the chunks given to the model as
+// citations. A search error skips the knowledge stage and is returned for the
+// run's warnings. In strict mode it also returns the grounding decision for the
+// run. Agents with a PromptComposer receive the chunks as its memory section.
+// buildKnowledgeContext 按输入搜索知识库，将结果格式化后注入系统提示，并以引用形式返回提供给模型的片段；
+// 严格模式下同时返回依据决策。配置了 PromptComposer 的代理通过其 memory 部分接收片段。
+func (a *Agent) buildKnowledgeContext(ctx context.Context, input string) (string, *GroundingDecision, []Citation, error) {
+	if a.knowledge == nil {
+		return "", nil, nil, nil
+	}
+	var decision *GroundingDecision
+	if a.knowledgeStrict {
+		decision = &GroundingDecision{Threshold: a.knowledgeConfidence}
+	}
+	if strings.TrimSpace(input) == "" {
+		decision.refuse(GroundingReasonNoResults)
+		return "", decision, nil, nil
+	}
+
+	results, err := a.knowledge.Search(ctx, input, a.knowledgeLimit)
+	if err != nil {
+		decision.refuse(GroundingReasonSearchFailed)
+		return "", decision, nil, fmt.Errorf("knowledge search failed: %w", err)
+	}
+
+	var b strings.Builder
+	n := 0
+	topScore := 0.0
+	var sources []string
+	var citations []Citation
+	for _, result := range results {
+		if float64(result.Score) < a.knowledgeMinScore || strings.TrimSpace(result.Content) == "" {
+			continue
+		}
+		if n == 0 || float64(result.Score) > topScore {
+			topScore = float64(result.Score)
+		}
+		n++
+		fmt.Fprintf(&b, "\n[%d]", n)
+		source, _ := result.Metadata["source"].(string)
+		if source != "" {
+			fmt.Fprintf(&b, " (source: %s)", source)
+			sources = append(sources, source)
+		}
+		b.WriteString("\n")
+		b.WriteString(strings.TrimSpace(result.Content))
+		b.WriteString("\n")
+		citations = append(citations, knowledgeCitation(n, result))
+	}
+
+	cite := ""
+	if a.knowledgeCitations {
+		cite = "Cite the excerpts you use by their number, e.g. [1].\n"
+	}
+
+	if decision != nil {
+		decision.TopScore = topScore
+		switch {
+		case n == 0:
+			decision.refuse(GroundingReasonNoResults)
+			return "", decision, nil, nil
+		case topScore < a.knowledgeConfidence:
+			decision.refuse(GroundingReasonLowConfidence)
+			return "", decision, nil, nil
+		}
+		decision.Reason = GroundingReasonAnswered
+		decision.Chunks = n
+		decision.Sources = sources
+		return "[Knowledge]\nAnswer only from these excerpts of the knowledge base and do not use prior knowledge. " +
+			"If they do not contain the answer, reply exactly: " + a.knowledgeRefusal + "\n" + cite + b.String(), decision, citations, nil
+	}
+	if n == 0 {
+		return "", nil, nil, nil
+	}
+	if a.promptComposer != nil {
+		return prompts.MemorySection(cite + strings.TrimSpace(b.String())).Content, nil, citations, nil
+	}
+	return "[Knowledge]\nUse these excerpts from the knowledge base when they are relevant to the request:\n" + cite + b.String(), nil, citations, nil
+}
+
+// refusalResponse is the response used instead of calling the model when
+// retrieval refused the run
+func (a *Agent) refusalResponse() *types.ModelResponse {
+	return &types.ModelResponse{Content: a.knowledgeRefusal}
+}
+
+// singleChunkStream returns a closed stream holding chunk
+func singleChunkStream(chunk types.ResponseChunk) <-chan types.ResponseChunk {
+	ch := make(chan types.ResponseChunk, 1)
+	ch <- chunk
+	close(ch)
+	return ch
+}
+
+// recordModelRefusal marks a grounded answer as refused when the model replied
+// with the refusal message because the chunks did not contain the answer
+func (a *Agent) recordModelRefusal(decision *GroundingDecision, content string) {
+	if decision == nil || decision.Refused {
+		return
+	}
+	if strings.TrimSpace(content) == strings.TrimSpace(a.knowledgeRefusal) {
+		decision.refuse(GroundingReasonModelDeclined)
+	}
+}
@@ -0,0 +1,388 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/memory"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/prompts"
+	"github.com/jholhewres/agent-go/pkg/agentgo/run"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+)
+
+// mockKnowledge returns configured results and records queries.
+type mockKnowledge struct {
+	results []vectordb.SearchResult
+	err     error
+	query   string
+	k       int
+}
+
+func (m *mockKnowledge) Search(ctx context.Context, query string, k int) ([]vectordb.SearchResult, error) {
+	m.query, m.k = query, k
+	return m.results, m.err
+}
+
+func systemPrompt(req *models.InvokeRequest) string {
+	for _, msg := range req.Messages {
+		if msg.Role == types.RoleSystem {
+			return msg.Content
+		}
+	}
+	return ""
+}
+
+func TestAgent_KnowledgeInjection(t *testing.T) {
+	var capturedReq *models.InvokeRequest
+	kb := &mockKnowledge{results: []vectordb.SearchResult{
+		{Content: "Refunds are processed within 5 days.", Score: 0.9, Metadata: map[string]interface{}{"source": "policy.md"}},
+		{Content: "Unrelated text.", Score: 0.1},
+	}}
+
+	ag, err := New(Config{
+		Name: "kb-agent",
+		Model: &MockModel{
+			BaseModel: models.BaseModel{ID: "mock", Provider: "mock"},
+			InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+				capturedReq = req
+				return &types.ModelResponse{Content: "ok"}, nil
+			},
+		},
+		Instructions:      "You are helpful.",
+		Knowledge:         kb,
+		KnowledgeMinScore: 0.5,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if _, err := ag.Run(context.Background(), "How long do refunds take?"); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if kb.query != "How long do refunds take?" || kb.k != 5 {
+		t.Errorf("Search(%q, %d), want the input and the default limit", kb.query, kb.k)
+	}
+
+	prompt := systemPrompt(capturedReq)
+	if !strings.HasPrefix(prompt, "You are helpful.") || !strings.Contains(prompt, "[Knowledge]") {
+		t.Fatalf("system prompt = %q", prompt)
+	}
+	if !strings.Contains(prompt, "[1] (source: policy.md)\nRefunds are processed within 5 days.") {
+		t.Errorf("retrieved chunk missing from %q", prompt)
+	}
+	if strings.Contains(prompt, "Unrelated text.") {
+		t.Error("chunk below KnowledgeMinScore was injected")
+	}
+}
+
+func TestAgent_KnowledgeSearchError(t *testing.T) {
+	var capturedReq *models.InvokeRequest
+	ag, _ := New(Config{
+		Model: &MockModel{
+			BaseModel: models.BaseModel{ID: "mock", Provider: "mock"},
+			InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+				capturedReq = req
+				return &types.ModelResponse{Content: "ok"}, nil
+			},
+		},
+		Instructions:   "You are helpful.",
+		Knowledge:      &mockKnowledge{err: errors.New("db down")},
+		KnowledgeLimit: 3,
+	})
+
+	if _, err := ag.Run(context.Background(), "hello"); err != nil {
+		t.Fatalf("Run should not fail when knowledge search fails: %v", err)
+	}
+	if strings.Contains(systemPrompt(capturedReq), "[Knowledge]") {
+		t.Error("knowledge section injected despite search error")
+	}
+}
+
+func TestAgent_KnowledgeStrict(t *testing.T) {
+	calls := 0
+	var capturedReq *models.InvokeRequest
+	answer := "Refunds take 5 days."
+	model := &MockModel{
+		BaseModel: models.BaseModel{ID: "mock", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			calls++
+			capturedReq = req
+			return &types.ModelResponse{Content: answer}, nil
+		},
+	}
+	kb := &mockKnowledge{results: []vectordb.SearchResult{
+		{Content: "Refunds are processed within 5 days.", Score: 0.9, Metadata: map[string]interface{}{"source": "policy.md"}},
+	}}
+	ag, err := New(Config{
+		Model:                        model,
+		Instructions:                 "You are helpful.",
+		Knowledge:                    kb,
+		KnowledgeStrict:              true,
+		KnowledgeConfidenceThreshold: 0.5,
+		KnowledgeRefusalMessage:      "Not in the docs.",
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+
+	// Confident retrieval: the model answers from the chunks.
+	out, err := ag.Run(ctx, "How long do refunds take?")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	g := out.Grounding
+	if g == nil || g.Refused || g.Reason != GroundingReasonAnswered || g.Chunks != 1 || g.Sources[0] != "policy.md" {
+		t.Errorf("Grounding = %+v", g)
+	}
+	if prompt := systemPrompt(capturedReq); !strings.Contains(prompt, "reply exactly: Not in the docs.") {
+		t.Errorf("system prompt = %q", prompt)
+	}
+
+	// Low confidence: refused without calling the model.
+	kb.results[0].Score = 0.2
+	calls = 0
+	out, err = ag.Run(ctx, "What is the warranty?")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if calls != 0 || out.Content != "Not in the docs." {
+		t.Errorf("calls = %d, content = %q", calls, out.Content)
+	}
+	if g := out.Grounding; !g.Refused || g.Reason != GroundingReasonLowConfidence || g.TopScore != float64(float32(0.2)) {
+		t.Errorf("Grounding = %+v", g)
+	}
+
+	// Nothing retrieved.
+	kb.results = nil
+	if out, _ = ag.Run(ctx, "Anything?"); out.Grounding.Reason != GroundingReasonNoResults || calls != 0 {
+		t.Errorf("Grounding = %+v, calls = %d", out.Grounding, calls)
+	}
+
+	// The model declines when the chunks do not contain the answer.
+	kb.results = []vectordb.SearchResult{{Content: "Shipping is free.", Score: 0.8}}
+	answer = "Not in the docs."
+	if out, _ = ag.Run(ctx, "What is the warranty?"); !out.Grounding.Refused || out.Grounding.Reason != GroundingReasonModelDeclined {
+		t.Errorf("Grounding = %+v", out.Grounding)
+	}
+}
+
+func TestAgent_KnowledgeStrictStreamAndTurn(t *testing.T) {
+	model := &MockModel{
+		BaseModel: models.BaseModel{ID: "mock", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			t.Error("model called for a refused run")
+			return &types.ModelResponse{Content: "ok"}, nil
+		},
+		InvokeStreamFunc: func(ctx context.Context, req *models.InvokeRequest) (<-chan types.ResponseChunk, error) {
+			t.Error("model streamed for a refused run")
+			return nil, errors.New("unexpected")
+		},
+	}
+	ag, err := New(Config{
+		Model:           model,
+		Knowledge:       &mockKnowledge{err: errors.New("db down")},
+		KnowledgeStrict: true,
+		RunStateKey:     testRunStateKey,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+
+	result, err := ag.RunStream(ctx, "hello")
+	if err != nil {
+		t.Fatalf("RunStream failed: %v", err)
+	}
+	var streamed strings.Builder
+	for evt := range result.Events {
+		if content, ok := evt.(*run.RunContentEvent); ok {
+			streamed.WriteString(content.Content)
+		}
+	}
+	done := <-result.Done
+	if done.Err != nil {
+		t.Fatalf("stream error = %v", done.Err)
+	}
+	if streamed.String() != DefaultKnowledgeRefusalMessage || done.Output.Grounding.Reason != GroundingReasonSearchFailed {
+		t.Errorf("streamed %q, grounding = %+v", streamed.String(), done.Output.Grounding)
+	}
+
+	turn, err := ag.RunTurn(ctx, "hello again")
+	if err != nil {
+		t.Fatalf("RunTurn failed: %v", err)
+	}
+	if !turn.Done() || turn.Output.Content != DefaultKnowledgeRefusalMessage || !turn.Output.Grounding.Refused {
+		t.Errorf("turn = %+v", turn.Output)
+	}
+}
+
+func TestAgent_KnowledgeCitations(t *testing.T) {
+	var capturedReq *models.InvokeRequest
+	kb := &mockKnowledge{results: []vectordb.SearchResult{
+		{ID: "policy_chunk_1", Content: "Refunds are processed within 5 days.", Score: 0.9, Metadata: map[string]interface{}{
+			"source": "policy.md", "document_id": "policy", "start_char": float64(120), "end_char": float64(156),
+		}},
+		{ID: "faq-3", Content: "Refunds go to the original card.", Score: 0.7},
+		{ID: "misc", Content: "Unrelated text.", Score: 0.1},
+	}}
+	ag, err := New(Config{
+		Model: &MockModel{
+			BaseModel: models.BaseModel{ID: "mock", Provider: "mock"},
+			InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+				capturedReq = req
+				return &types.ModelResponse{Content: "Within 5 days [1]."}, nil
+			},
+		},
+		PromptComposer:     prompts.NewPromptComposer(prompts.IdentitySection("Support", "You answer refund questions.")),
+		Knowledge:          kb,
+		KnowledgeLimit:     3,
+		KnowledgeMinScore:  0.5,
+		KnowledgeCitations: true,
+		RunStateKey:        testRunStateKey,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	out, err := ag.Run(context.Background(), "How long do refunds take?")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	prompt := systemPrompt(capturedReq)
+	if !strings.HasPrefix(prompt, "You are Support.") || !strings.Contains(prompt, "## Relevant Context from Memory") {
+		t.Fatalf("system prompt = %q", prompt)
+	}
+	if !strings.Contains(prompt, "Cite the excerpts you use by their number") ||
+		!strings.Contains(prompt, "[1] (source: policy.md)\nRefunds are processed within 5 days.") ||
+		!strings.Contains(prompt, "[2]\nRefunds go to the original card.") {
+		t.Errorf("knowledge missing from %q", prompt)
+	}
+
+	if len(out.Citations) != 2 {
+		t.Fatalf("Citations = %+v, want the 2 chunks above KnowledgeMinScore", out.Citations)
+	}
+	want := Citation{Index: 1, Kind: CitationKnowledge, ID: "policy_chunk_1", DocumentID: "policy", Source: "policy.md", StartChar: 120, EndChar: 156}
+	if c := out.Citations[0]; c.Index != want.Index || c.Kind != want.Kind || c.ID != want.ID || c.DocumentID != want.DocumentID ||
+		c.Source != want.Source || c.StartChar != want.StartChar || c.EndChar != want.EndChar || c.Score != float64(float32(0.9)) {
+		t.Errorf("Citations[0] = %+v", c)
+	}
+	if c := out.Citations[1]; c.Index != 2 || c.ID != "faq-3" || c.Source != "" || c.EndChar != 0 {
+		t.Errorf("Citations[1] = %+v", c)
+	}
+
+	turn, err := ag.RunTurn(context.Background(), "And exchanges?")
+	if err != nil {
+		t.Fatalf("RunTurn failed: %v", err)
+	}
+	if !turn.Done() || len(turn.Output.Citations) != 2 {
+		t.Errorf("turn citations = %+v", turn.Output.Citations)
+	}
+}
+
+// searchableMemory is an InMemory whose searches return fixed results
+type searchableMemory struct {
+	*memory.InMemory
+	results []memory.SearchResult
+	err     error
+}
+
+func (m *searchableMemory) Search(ctx context.Context, query string, limit int, userID ...string) ([]memory.SearchResult, error) {
+	return m.results, m.err
+}
+
+func (m *searchableMemory) SearchWithOptions(ctx context.Context, query string, options memory.SearchOptions, userID ...string) ([]memory.SearchResult, error) {
+	return m.results, m.err
+}
+
+func TestAgent_MemorySearchCitations(t *testing.T) {
+	var capturedReq *models.InvokeRequest
+	mem := &searchableMemory{InMemory: memory.NewInMemory(100), results: []memory.SearchResult{
+		{Message: &types.Message{ID: "m-7", Role: types.RoleUser, Content: "My order number is 4411."}, Score: 0.8, Source: "long_term"},
+		{Message: &types.Message{ID: "m-9", Role: types.RoleUser, Content: "Where is my order?"}, Score: 0.9, Source: "short_term"},
+		{Message: &types.Message{ID: "m-2", Role: types.RoleAssistant, Content: "Hello!"}, Score: 0.1, Source: "long_term"},
+	}}
+	kb := &mockKnowledge{results: []vectordb.SearchResult{
+		{ID: "shipping_chunk_0", Content: "Orders ship within 2 days.", Score: 0.9},
+	}}
+	ag, err := New(Config{
+		Model: &MockModel{
+			BaseModel: models.BaseModel{ID: "mock", Provider: "mock"},
+			InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+				capturedReq = req
+				return &types.ModelResponse{Content: "ok"}, nil
+			},
+		},
+		Instructions:         "You are helpful.",
+		Memory:               mem,
+		Knowledge:            kb,
+		EnableMemorySearch:   true,
+		MemorySearchMinScore: 0.5,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	out, err := ag.Run(context.Background(), "Where is my order?")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	prompt := systemPrompt(capturedReq)
+	if !strings.Contains(prompt, "[Memory]") || !strings.Contains(prompt, "[2] (user)\nMy order number is 4411.") {
+		t.Fatalf("system prompt = %q", prompt)
+	}
+	if strings.Contains(prompt, "Hello!") {
+		t.Error("memory below MemorySearchMinScore was injected")
+	}
+
+	// The input itself is not cited; memory follows the knowledge chunk.
+	if len(out.Citations) != 2 {
+		t.Fatalf("Citations = %+v", out.Citations)
+	}
+	if c := out.Citations[1]; c.Index != 2 || c.Kind != CitationMemory || c.ID != "m-7" || c.Source != "long_term" {
+		t.Errorf("Citations[1] = %+v", c)
+	}
+
+	mem.err = errors.New("index offline")
+	out, err = ag.Run(context.Background(), "Where is my order?")
+	if err != nil {
+		t.Fatalf("Run should not fail when memory search fails: %v", err)
+	}
+	if len(out.Warnings) != 1 || out.Warnings[0].Subsystem != SubsystemMemorySearch || len(out.Citations) != 1 {
+		t.Errorf("warnings = %+v, citations = %+v", out.Warnings, out.Citations)
+	}
+}
+
+func TestNew_KnowledgeStrictRequiresKnowledge(t *testing.T) {
+	_, err := New(Config{Model: &MockModel{}, KnowledgeStrict: true})
+	if err == nil {
+		t.Fatal("expected error for KnowledgeStrict without Knowledge")
+	}
+	_, err = New(Config{Model: &MockModel{}, KnowledgeCitations: true})
+	if err == nil {
+		t.Fatal("expected error for KnowledgeCitations without Knowledge")
+	}
+}
+
+func TestNew_KnowledgeAliases(t *testing.T) {
+	kb := &mockKnowledge{}
+	ag, err := New(Config{Model: &MockModel{}, Knowledge: kb, NumDocuments: 3, SimilarityThreshold: 0.4, CitationsEnabled: true})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if ag.knowledgeLimit != 3 || ag.knowledgeMinScore != 0.4 || !ag.knowledgeCitations {
+		t.Errorf("aliases not applied: limit=%d minScore=%v citations=%v", ag.knowledgeLimit, ag.knowledgeMinScore, ag.knowledgeCitations)
+	}
+
+	if _, err := New(Config{Model: &MockModel{}, Knowledge: kb, NumDocuments: 3, KnowledgeLimit: 4}); err == nil {
+		t.Error("expected error for conflicting NumDocuments and KnowledgeLimit")
+	}
+	if _, err := New(Config{Model: &MockModel{}, CitationsEnabled: true}); err == nil {
+		t.Error("expected error for CitationsEnabled without Knowledge")
+	}
+}
@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/memory"
+	"github.com/jholhewres/agent-go/pkg/agentgo/prompts"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// buildMemoryContext searches the agent's memory for messages relevant to the
+// input and formats them for the system prompt, numbered from first. The
+// current input and system messages are skipped. Memories that cannot be
+// searched yield no context.
+// buildMemoryContext 在代理内存中搜索与输入相关的消息，并从 first 开始编号格式化后注入系统提示。
+func (a *Agent) buildMemoryContext(ctx context.Context, input string, first int) (string, []Citation, error) {
+	if _, ok := a.Memory.(memory.SearchableMemory); !ok || strings.TrimSpace(input) == "" {
+		return "", nil, nil
+	}
+	results, err := a.SearchMemory(ctx, input, a.memorySearchLimit)
+	if err != nil {
+		return "", nil, fmt.Errorf("memory search failed: %w", err)
+	}
+
+	var b strings.Builder
+	var citations []Citation
+	for _, result := range results {
+		msg := result.Message
+		if msg == nil || msg.Role == types.RoleSystem || result.Score < a.memorySearchMin {
+			continue
+		}
+		content := strings.TrimSpace(msg.Content)
+		if content == "" || content == strings.TrimSpace(input) {
+			continue
+		}
+		index := first + len(citations)
+		fmt.Fprintf(&b, "\n[%d] (%s)\n%s\n", index, msg.Role, content)
+		citations = append(citations, Citation{
+			Index:   index,
+			Kind:    CitationMemory,
+			ID:      msg.ID,
+			Source:  result.Source,
+			Content: msg.Content,
+			Score:   result.Score,
+		})
+	}
+
+	if len(citations) == 0 {
+		return "", nil, nil
+	}
+	if a.promptComposer != nil {
+		return prompts.MemorySection(strings.TrimSpace(b.String())).Content, citations, nil
+	}
+	return "[Memory]\nEarlier messages that may be relevant to the request:\n" + b.String(), citations, nil
+}
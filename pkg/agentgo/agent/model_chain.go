@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models/fallback"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// buildModelChain applies ModelMiddleware, ModelRetry, ModelHedge and FallbackModels to
+// config.Model. Without any of these options the model is returned unchanged.
+// buildModelChain 将 ModelMiddleware、ModelRetry、ModelHedge 和 FallbackModels 应用于 config.Model。
+func buildModelChain(config Config) (models.Model, error) {
+	if len(config.ModelMiddleware) == 0 && config.ModelRetry == nil && config.ModelHedge == nil && len(config.FallbackModels) == 0 {
+		return config.Model, nil
+	}
+
+	logger := config.Logger
+	wrap := func(m models.Model) models.Model {
+		if len(config.ModelMiddleware) > 0 {
+			m = models.WithMiddleware(m, config.ModelMiddleware...)
+		}
+		if config.ModelRetry == nil {
+			return m
+		}
+		retryConfig := *config.ModelRetry
+		userHook := retryConfig.OnAttempt
+		retryConfig.OnAttempt = func(info models.AttemptInfo) {
+			if info.Err != nil && info.NextDelay > 0 {
+				logger.Warn("model call failed, retrying",
+					"model", info.ModelID,
+					"attempt", info.Attempt,
+					"delay", info.NextDelay,
+					"error", info.Err)
+			}
+			if userHook != nil {
+				userHook(info)
+			}
+		}
+		return models.WithRetry(m, retryConfig)
+	}
+
+	primary := wrap(config.Model)
+	if config.ModelHedge != nil {
+		hedgeConfig := *config.ModelHedge
+		if hedgeConfig.Secondary != nil {
+			hedgeConfig.Secondary = wrap(hedgeConfig.Secondary)
+		}
+		userHook := hedgeConfig.OnHedge
+		hedgeConfig.OnHedge = func(info models.HedgeInfo) {
+			logger.Debug("model call hedged",
+				"model", info.ModelID,
+				"hedges", info.Hedges,
+				"winner", info.WinnerID,
+				"latency", info.Latency)
+			if userHook != nil {
+				userHook(info)
+			}
+		}
+		primary = models.WithHedging(primary, hedgeConfig)
+	}
+
+	if len(config.FallbackModels) == 0 {
+		return primary, nil
+	}
+
+	chain := make([]models.Model, 0, len(config.FallbackModels)+1)
+	chain = append(chain, primary)
+	for _, m := range config.FallbackModels {
+		if m == nil {
+			return nil, types.NewInvalidConfigError("fallback model cannot be nil", nil)
+		}
+		chain = append(chain, wrap(m))
+	}
+
+	opts := []fallback.Option{
+		fallback.WithOnFallback(func(from, to models.Model, err error) {
+			logger.Warn("model failed, falling back",
+				"from", from.GetID(),
+				"to", to.GetID(),
+				"error", err)
+		}),
+	}
+	// Retries already happen inside each wrapped model.
+	if config.ModelRetry != nil {
+		opts = append(opts, fallback.WithMaxRetries(0))
+	}
+
+	chained, err := fallback.New(chain, opts...)
+	if err != nil {
+		return nil, types.NewInvalidConfigError("invalid fallback models", err)
+	}
+	return chained, nil
+}
@@ -0,0 +1,189 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/events"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/run"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// runEndStatus classifies how a run ended, for the metrics registry and the
+// RunFinished event. paused is set when the run waits for an approval or, for
+// stateless turns, for the next turn.
+// runEndStatus 为指标注册表和 RunFinished 事件归类运行的结束方式。
+// 运行等待审批或（无状态轮次）等待下一轮时 paused 为 true。
+func runEndStatus(paused bool, err error) string {
+	var agentErr *types.AgnoError
+	switch {
+	case err == nil && paused:
+		return events.RunPaused
+	case err == nil:
+		return events.RunCompleted
+	case errors.As(err, &agentErr) && agentErr.Code == types.ErrCodeCancelled:
+		return events.RunCancelled
+	case errors.As(err, &agentErr) && (agentErr.Code == types.ErrCodeInputCheck || agentErr.Code == types.ErrCodeOutputCheck):
+		return events.RunBlocked
+	}
+	return events.RunError
+}
+
+type eventBusKey struct{}
+
+// WithEventBus makes the runs started with the returned context also publish
+// their events on bus, besides the agent's Config.Events, e.g. for one client
+// connection. Subscribers can tell runs apart by Header.RunID.
+// WithEventBus 使通过返回的 context 发起的运行除代理的 Config.Events 外也在 bus 上发布事件，例如按客户端连接；
+// 订阅者可通过 Header.RunID 区分运行
+func WithEventBus(ctx context.Context, bus *events.Bus) context.Context {
+	return context.WithValue(ctx, eventBusKey{}, bus)
+}
+
+// publishing reports whether the events of ctx's run go to any bus
+// publishing 报告 ctx 所属运行的事件是否有任何总线接收
+func (a *Agent) publishing(ctx context.Context) bool {
+	bus, _ := ctx.Value(eventBusKey{}).(*events.Bus)
+	return a.events != nil || bus != nil
+}
+
+// publish delivers event to the agent's bus and to the bus given with WithEventBus
+// publish 将事件发送到代理的总线以及通过 WithEventBus 指定的总线
+func (a *Agent) publish(ctx context.Context, event events.Event) {
+	a.events.Publish(ctx, event)
+	if bus, _ := ctx.Value(eventBusKey{}).(*events.Bus); bus != nil && bus != a.events {
+		bus.Publish(ctx, event)
+	}
+}
+
+// eventHeader identifies the run of ctx in published events
+// eventHeader 为发布的事件标识 ctx 所属的运行
+func (a *Agent) eventHeader(ctx context.Context) events.Header {
+	header := events.Header{Time: time.Now().UTC(), AgentID: a.ID, SessionID: a.sessionID, UserID: a.UserID}
+	if rc, ok := run.FromContext(ctx); ok && rc != nil {
+		header.RunID = rc.RunID
+		if rc.SessionID != "" {
+			header.SessionID = rc.SessionID
+		}
+		if rc.UserID != "" {
+			header.UserID = rc.UserID
+		}
+	}
+	return header
+}
+
+// beginRun records the start of a run in the metrics registry and on the
+// event bus, starts its trace when traces are captured, and returns the
+// context for the run with the function recording its end. initialMessages is
+// the number of messages in Memory from before the run. output may be nil.
+// beginRun 在指标注册表和事件总线上记录运行开始，在捕获追踪时开始记录运行追踪，
+// 并返回运行所用的上下文和记录运行结束的函数。initialMessages 是运行前 Memory 中的消息数；output 可以为 nil。
+func (a *Agent) beginRun(ctx context.Context, input string, initialMessages int) (context.Context, func(output *RunOutput, paused bool, err error)) {
+	ctx, trace := a.startTrace(ctx, input, initialMessages)
+	endMetrics := a.metrics.StartRun(a.ID)
+	start := time.Now()
+	if a.publishing(ctx) {
+		a.publish(ctx, &events.RunStarted{Header: a.eventHeader(ctx), Input: input})
+	}
+
+	return ctx, func(output *RunOutput, paused bool, err error) {
+		if trace != nil && output != nil {
+			output.Trace = trace.build(output, err)
+		}
+		status := runEndStatus(paused, err)
+		endMetrics(status)
+		if !a.publishing(ctx) {
+			return
+		}
+		finished := &events.RunFinished{Header: a.eventHeader(ctx), Status: status, Duration: time.Since(start), Err: err}
+		if output != nil {
+			finished.Output = output.Content
+			finished.Usage = output.Usage
+		}
+		a.publish(ctx, finished)
+	}
+}
+
+// invokeModel calls the model, publishing ModelCallStarted and ModelCallFinished
+// invokeModel 调用模型，并发布 ModelCallStarted 和 ModelCallFinished 事件
+func (a *Agent) invokeModel(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+	end := a.startModelCall(ctx, req, false)
+	resp, err := a.Model.Invoke(ctx, req)
+	end(resp, err)
+	return resp, err
+}
+
+// startModelCall publishes ModelCallStarted and returns the function
+// publishing ModelCallFinished; both calls are added to the run's trace
+// startModelCall 发布 ModelCallStarted 并返回发布 ModelCallFinished 的函数；两者都会加入运行追踪
+func (a *Agent) startModelCall(ctx context.Context, req *models.InvokeRequest, stream bool) func(resp *types.ModelResponse, err error) {
+	trace := traceRecorderFrom(ctx)
+	publishing := a.publishing(ctx)
+	if !publishing && trace == nil {
+		return func(*types.ModelResponse, error) {}
+	}
+	provider, model := a.Model.GetProvider(), a.Model.GetID()
+	if publishing {
+		a.publish(ctx, &events.ModelCallStarted{
+			Header:   a.eventHeader(ctx),
+			Provider: provider,
+			Model:    model,
+			Messages: len(req.Messages),
+			Tools:    len(req.Tools),
+			Stream:   stream,
+		})
+	}
+
+	start := time.Now()
+	return func(resp *types.ModelResponse, err error) {
+		duration := time.Since(start)
+		trace.addModelCall(provider, model, req, stream, resp, err, start, duration)
+		if !publishing {
+			return
+		}
+		finished := &events.ModelCallFinished{
+			Header:   a.eventHeader(ctx),
+			Provider: provider,
+			Model:    model,
+			Stream:   stream,
+			Duration: duration,
+			Err:      err,
+		}
+		if resp != nil {
+			if resp.Model != "" {
+				finished.Model = resp.Model
+			}
+			finished.Usage = resp.Usage
+			finished.ToolCalls = len(resp.ToolCalls)
+		}
+		a.publish(ctx, finished)
+	}
+}
+
+// recordToolCall records a tool call in the metrics registry and the run's
+// trace, and publishes ToolExecuted. msg is the tool message returned to the
+// model, nil when the call did not run.
+// recordToolCall 将工具调用记录到指标注册表和运行追踪，并发布 ToolExecuted 事件；
+// msg 是返回给模型的工具消息，调用未执行时为 nil。
+func (a *Agent) recordToolCall(ctx context.Context, summary *ToolExecutionSummary, msg *types.Message) {
+	if summary == nil {
+		return
+	}
+	duration := summary.EndTime.Sub(summary.StartTime)
+	a.metrics.ObserveToolCall(a.ID, summary.FunctionName, string(summary.Status), duration)
+	traceRecorderFrom(ctx).addToolCall(summary, msg)
+	if a.publishing(ctx) {
+		a.publish(ctx, &events.ToolExecuted{
+			Header:     a.eventHeader(ctx),
+			ToolCallID: summary.ToolCallID,
+			Tool:       summary.FunctionName,
+			Arguments:  summary.Arguments,
+			Status:     string(summary.Status),
+			Result:     summary.Result,
+			Error:      summary.Error,
+			Duration:   duration,
+		})
+	}
+}
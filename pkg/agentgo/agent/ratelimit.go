@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/run"
+)
+
+// runUserID returns the user a run belongs to: the run context's UserID, else
+// the agent's
+// runUserID 返回运行所属的用户：运行上下文的 UserID，否则为代理的 UserID
+func (a *Agent) runUserID(ctx context.Context) string {
+	if rc, ok := run.FromContext(ctx); ok && rc != nil && rc.UserID != "" {
+		return rc.UserID
+	}
+	return a.UserID
+}
+
+// rateLimitKey scopes a user's counts to this agent
+// rateLimitKey 将用户的计数限定在本代理内
+func (a *Agent) rateLimitKey(userID string) string {
+	return a.ID + ":" + userID
+}
+
+// admitRun counts a new run against the user's limits, waiting or returning
+// the rate-limit error when the user is over budget
+// admitRun 按用户的限制计入一次新运行；用户超出预算时等待或返回限流错误
+func (a *Agent) admitRun(ctx context.Context) error {
+	if a.limits == nil {
+		return nil
+	}
+	userID := a.runUserID(ctx)
+	a.usage.addLimitedUser(userID)
+	if err := a.limits.Admit(ctx, a.rateLimitKey(userID)); err != nil {
+		a.usage.addRejected()
+		a.logger.Warn("agent run rejected by rate limit", "agent_id", a.ID, "user_id", userID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// chargeTokens counts the tokens of a model response against the user's daily
+// budget. The tokens are spent, so they are charged even when the run has
+// been cancelled since.
+// chargeTokens 将模型响应的令牌计入用户的每日预算。令牌已被消耗，因此即使运行随后被取消也会计入。
+func (a *Agent) chargeTokens(ctx context.Context, tokens int) {
+	if a.limits == nil || tokens <= 0 {
+		return
+	}
+	userID := a.runUserID(ctx)
+	if err := a.limits.Charge(context.WithoutCancel(ctx), a.rateLimitKey(userID), tokens); err != nil {
+		a.logger.Warn("failed to charge tokens to rate limit", "agent_id", a.ID, "user_id", userID, "error", err)
+	}
+}
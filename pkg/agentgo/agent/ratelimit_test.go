@@ -0,0 +1,161 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/ratelimit"
+	"github.com/jholhewres/agent-go/pkg/agentgo/run"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+func userContext(userID string) context.Context {
+	rc := run.NewContext()
+	rc.UserID = userID
+	return run.WithContext(context.Background(), rc)
+}
+
+func TestAgent_RateLimit(t *testing.T) {
+	model := &MockModel{
+		BaseModel: models.BaseModel{ID: "mock", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			// alice asks long questions
+			tokens := 10
+			if rc, _ := run.FromContext(ctx); rc.UserID == "alice" {
+				tokens = 60
+			}
+			return &types.ModelResponse{Content: "ok", Usage: types.Usage{TotalTokens: tokens}}, nil
+		},
+	}
+	ag, err := New(Config{
+		ID:          "limited",
+		Model:       model,
+		RateLimit:   &ratelimit.Config{RequestsPerMinute: 2, TokensPerDay: 100},
+		RunStateKey: testRunStateKey,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := ag.Run(userContext("alice"), "hi"); err != nil {
+			t.Fatalf("Run() #%d error = %v", i, err)
+		}
+	}
+
+	// alice spent 120 of 100 tokens: the daily budget rejects her third run
+	_, err = ag.Run(userContext("alice"), "hi")
+	var agnoErr *types.AgnoError
+	var limitErr *ratelimit.LimitError
+	if !errors.As(err, &agnoErr) || agnoErr.Code != types.ErrCodeRateLimitError || !errors.As(err, &limitErr) {
+		t.Fatalf("expected a rate-limit error, got %v", err)
+	}
+	if limitErr.Reason != ratelimit.ReasonTokensPerDay || limitErr.Key != "limited:alice" {
+		t.Fatalf("limit error = %+v", limitErr)
+	}
+
+	// Users are limited separately
+	if _, err := ag.Run(userContext("bob"), "hi"); err != nil {
+		t.Fatalf("Run(bob) error = %v", err)
+	}
+	if _, err := ag.RunTurn(userContext("bob"), "hi"); err != nil {
+		t.Fatalf("RunTurn(bob) error = %v", err)
+	}
+	if _, err := ag.RunStream(userContext("bob"), "hi"); !errors.As(err, &limitErr) || limitErr.Reason != ratelimit.ReasonRequestsPerMinute {
+		t.Fatalf("expected bob's third run to be over the request rate, got %v", err)
+	}
+
+	stats := ag.UsageStats()
+	if stats.RateLimit == nil || stats.RateLimit.Rejected != 2 || len(stats.RateLimit.Users) != 2 {
+		t.Fatalf("rate limit stats = %+v", stats.RateLimit)
+	}
+	if alice := stats.RateLimit.Users["alice"]; alice.Tokens != 120 || alice.TokensRemaining != 0 || alice.TokensPerDay != 100 {
+		t.Fatalf("alice = %+v", alice)
+	}
+	if bob, _ := ag.RateLimitStatus(context.Background(), "bob"); bob.Requests != 2 || bob.Tokens != 20 || bob.TokensRemaining != 80 {
+		t.Fatalf("bob = %+v", bob)
+	}
+}
+
+func TestNew_InvalidRateLimit(t *testing.T) {
+	_, err := New(Config{Model: &MockModel{}, RateLimit: &ratelimit.Config{RequestsPerMinute: -1}})
+	if err == nil {
+		t.Fatal("expected an error for a negative rate limit")
+	}
+}
+
+func TestAgent_RateLimit_ChargesStreamedRuns(t *testing.T) {
+	model := &MockModel{
+		BaseModel: models.BaseModel{ID: "mock", Provider: "mock"},
+		InvokeStreamFunc: func(ctx context.Context, req *models.InvokeRequest) (<-chan types.ResponseChunk, error) {
+			ch := make(chan types.ResponseChunk, 2)
+			ch <- types.ResponseChunk{Content: "ok"}
+			ch <- types.ResponseChunk{Done: true, Usage: &types.Usage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120}}
+			close(ch)
+			return ch, nil
+		},
+	}
+	ag, err := New(Config{ID: "limited", Model: model, RateLimit: &ratelimit.Config{TokensPerDay: 100}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	result, err := ag.RunStream(userContext("alice"), "hi")
+	if err != nil {
+		t.Fatalf("RunStream() error = %v", err)
+	}
+	for range result.Events {
+	}
+	done := <-result.Done
+	if done.Err != nil || done.Output.Usage.TotalTokens != 120 {
+		t.Fatalf("streamed run = %+v, %v, want 120 tokens", done.Output, done.Err)
+	}
+
+	var limitErr *ratelimit.LimitError
+	if _, err := ag.RunStream(userContext("alice"), "hi"); !errors.As(err, &limitErr) || limitErr.Reason != ratelimit.ReasonTokensPerDay {
+		t.Fatalf("expected the streamed tokens to use up the budget, got %v", err)
+	}
+}
+
+func TestAgent_RateLimit_ChargesCancelledRuns(t *testing.T) {
+	ctx, cancel := context.WithCancel(userContext("alice"))
+	defer cancel()
+	model := &MockModel{
+		BaseModel: models.BaseModel{ID: "mock", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			// The client goes away right after the model answers
+			cancel()
+			return &types.ModelResponse{Content: "ok", Usage: types.Usage{TotalTokens: 120}}, nil
+		},
+	}
+	ag, err := New(Config{ID: "limited", Model: model, RateLimit: &ratelimit.Config{TokensPerDay: 100}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	_, _ = ag.Run(ctx, "hi")
+	if status, err := ag.RateLimitStatus(context.Background(), "alice"); err != nil || status.Tokens != 120 {
+		t.Fatalf("status = %+v, %v, want the cancelled run's tokens charged", status, err)
+	}
+}
+
+func TestUsageTracker_BoundsLimitedUsers(t *testing.T) {
+	var tracker usageTracker
+	tracker.limitedUsers = map[string]time.Time{"yesterday": time.Now().Add(-48 * time.Hour)}
+	for i := 0; i < maxLimitedUsers+1; i++ {
+		tracker.addLimitedUser(fmt.Sprintf("user-%d", i))
+	}
+	if _, ok := tracker.limitedUsers["yesterday"]; ok {
+		t.Fatal("expected the idle user to be evicted")
+	}
+	if len(tracker.limitedUsers) > maxLimitedUsers {
+		t.Fatalf("tracked %d users, want at most %d", len(tracker.limitedUsers), maxLimitedUsers)
+	}
+	if _, users := tracker.limitSnapshot(); len(users) != len(tracker.limitedUsers) {
+		t.Fatalf("snapshot has %d users, want %d", len(users), len(tracker.limitedUsers))
+	}
+}
@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/run"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+func TestAgent_ReasoningBudgetIsSent(t *testing.T) {
+	var sent *models.InvokeRequest
+	model := &MockModel{
+		BaseModel: models.BaseModel{ID: "test", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			sent = req
+			return &types.ModelResponse{Content: "ok"}, nil
+		},
+	}
+	ag, err := New(Config{Model: model, ReasoningEffort: models.ReasoningEffortHigh, MaxReasoningTokens: 2048})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := ag.Run(context.Background(), "hi"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if sent.ReasoningEffort != models.ReasoningEffortHigh || sent.MaxReasoningTokens != 2048 {
+		t.Errorf("reasoning settings not sent: effort %q, max %d", sent.ReasoningEffort, sent.MaxReasoningTokens)
+	}
+
+	if _, err := New(Config{Model: model, ReasoningEffort: "extreme"}); err == nil {
+		t.Error("expected an error for an unknown reasoning effort")
+	}
+	if _, err := New(Config{Model: model, MaxReasoningTokens: -1}); err == nil {
+		t.Error("expected an error for a negative reasoning budget")
+	}
+}
+
+func TestAgent_RunStreamSeparatesReasoning(t *testing.T) {
+	model := &MockModel{
+		BaseModel: models.BaseModel{ID: "test", Provider: "mock"},
+		InvokeStreamFunc: func(ctx context.Context, req *models.InvokeRequest) (<-chan types.ResponseChunk, error) {
+			ch := make(chan types.ResponseChunk, 4)
+			ch <- types.ResponseChunk{ReasoningContent: "2 plus 2 "}
+			ch <- types.ResponseChunk{ReasoningContent: "is 4."}
+			ch <- types.ResponseChunk{Content: "4"}
+			ch <- types.ResponseChunk{Done: true}
+			close(ch)
+			return ch, nil
+		},
+	}
+	ag, err := New(Config{Model: model})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	result, err := ag.RunStream(context.Background(), "2+2?")
+	if err != nil {
+		t.Fatalf("RunStream: %v", err)
+	}
+	var reasoning, content string
+	lastSequence := -1
+	for evt := range result.Events {
+		switch e := evt.(type) {
+		case *run.RunReasoningEvent:
+			if content != "" {
+				t.Error("reasoning streamed after the answer")
+			}
+			reasoning += e.Content
+			lastSequence = e.Sequence
+		case *run.RunContentEvent:
+			content += e.Content
+			if e.Sequence <= lastSequence {
+				t.Errorf("content sequence %d does not follow reasoning sequence %d", e.Sequence, lastSequence)
+			}
+		}
+	}
+	done := <-result.Done
+	if done.Err != nil {
+		t.Fatalf("run failed: %v", done.Err)
+	}
+
+	if reasoning != "2 plus 2 is 4." || content != "4" || done.Output.Content != "4" {
+		t.Fatalf("reasoning %q, content %q, output %q", reasoning, content, done.Output.Content)
+	}
+	last := done.Output.Messages[len(done.Output.Messages)-1]
+	if last.ReasoningContent == nil || last.ReasoningContent.Content != "2 plus 2 is 4." {
+		t.Errorf("assistant message reasoning = %+v", last.ReasoningContent)
+	}
+}
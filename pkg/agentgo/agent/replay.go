@@ -0,0 +1,215 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/hooks"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// replayedMetadataKey marks tool calls answered from a replayed trace
+const replayedMetadataKey = "replayed"
+
+// ReplayOptions configures Agent.Replay
+// ReplayOptions 配置 Agent.Replay
+type ReplayOptions struct {
+	// LiveTools runs tools the trace has no result for instead of failing the call
+	// LiveTools 对追踪中没有结果的工具调用真实执行工具，而不是使调用失败
+	LiveTools bool
+}
+
+// ReplayResult is the outcome of replaying a trace
+// ReplayResult 是重放追踪的结果
+type ReplayResult struct {
+	Original *RunTrace  `json:"original"`
+	Output   *RunOutput `json:"output"`
+	Trace    *RunTrace  `json:"trace"` // Trace of the replayed run / 重放运行的追踪
+	Diff     TraceDiff  `json:"diff"`
+}
+
+// TraceDiff compares a replayed run with the original
+// TraceDiff 比较重放运行与原始运行
+type TraceDiff struct {
+	OutputChanged       bool        `json:"output_changed"`
+	ToolCallsChanged    bool        `json:"tool_calls_changed"` // Different tools or order / 工具或顺序不同
+	OriginalToolCalls   []string    `json:"original_tool_calls,omitempty"`
+	ReplayedToolCalls   []string    `json:"replayed_tool_calls,omitempty"`
+	UnrecordedToolCalls []string    `json:"unrecorded_tool_calls,omitempty"` // Calls the original had no result for / 原始追踪中没有结果的调用
+	OriginalModelCalls  int         `json:"original_model_calls"`
+	ReplayedModelCalls  int         `json:"replayed_model_calls"`
+	OriginalUsage       types.Usage `json:"original_usage"`
+	ReplayedUsage       types.Usage `json:"replayed_usage"`
+}
+
+// Replay re-executes a trace for regression comparison: the conversation the
+// trace started from replaces the agent's memory for its user, the trace input
+// is run, and tools answer with the results recorded in the trace instead of
+// running. Build the agent with the new model or instructions to compare them
+// with the ones that produced the trace. Tool calls the trace has no result for
+// fail, unless opts.LiveTools is set.
+// Replay 重新执行追踪以进行回归比较：追踪开始时的对话替换代理中该用户的记忆，执行追踪的输入，
+// 工具使用追踪中记录的结果而不真实运行。使用新的模型或指令构建代理，即可与生成追踪的版本进行比较。
+// 追踪中没有结果的工具调用会失败，除非设置了 opts.LiveTools。
+func (a *Agent) Replay(ctx context.Context, trace *RunTrace, opts ReplayOptions) (*ReplayResult, error) {
+	if trace == nil {
+		return nil, types.NewInvalidInputError("trace must not be nil", nil)
+	}
+	if trace.Version > runTraceVersion {
+		return nil, types.NewInvalidInputError(fmt.Sprintf("unsupported trace version %d", trace.Version), nil)
+	}
+
+	a.ClearMemory()
+	for _, msg := range trace.History {
+		if msg != nil && msg.Role != types.RoleSystem {
+			a.Memory.Add(msg, a.UserID)
+		}
+	}
+
+	output, err := a.Run(withPendingReplay(ctx, newTraceReplay(trace, opts)), trace.Input)
+	if output == nil {
+		return nil, err
+	}
+	result := &ReplayResult{Original: trace, Output: output, Trace: output.Trace}
+	result.Diff = CompareTraces(trace, output.Trace)
+	return result, err
+}
+
+// CompareTraces summarizes how a replayed run differs from the original
+// CompareTraces 概括重放运行与原始运行的差异
+func CompareTraces(original, replayed *RunTrace) TraceDiff {
+	var diff TraceDiff
+	if original == nil {
+		original = &RunTrace{}
+	}
+	if replayed == nil {
+		replayed = &RunTrace{}
+	}
+
+	diff.OutputChanged = original.Output != replayed.Output
+	diff.OriginalToolCalls = toolCallNames(original.ToolCalls)
+	diff.ReplayedToolCalls = toolCallNames(replayed.ToolCalls)
+	diff.ToolCallsChanged = len(diff.OriginalToolCalls) != len(diff.ReplayedToolCalls)
+	for i := 0; !diff.ToolCallsChanged && i < len(diff.OriginalToolCalls); i++ {
+		diff.ToolCallsChanged = diff.OriginalToolCalls[i] != diff.ReplayedToolCalls[i]
+	}
+	for _, call := range replayed.ToolCalls {
+		if !call.Replayed && (call.Status == string(ToolExecutionStatusSuccess) || call.Status == string(ToolExecutionStatusFailed) || call.Status == string(ToolExecutionStatusTimeout)) {
+			diff.UnrecordedToolCalls = append(diff.UnrecordedToolCalls, call.Name)
+		}
+	}
+	diff.OriginalModelCalls = len(original.ModelCalls)
+	diff.ReplayedModelCalls = len(replayed.ModelCalls)
+	diff.OriginalUsage = original.Usage
+	diff.ReplayedUsage = replayed.Usage
+	return diff
+}
+
+func toolCallNames(calls []ToolCallTrace) []string {
+	names := make([]string, 0, len(calls))
+	for _, call := range calls {
+		names = append(names, call.Name)
+	}
+	return names
+}
+
+// traceReplay answers tool calls with the results recorded in a trace
+type traceReplay struct {
+	mu        sync.Mutex
+	results   map[string][]ToolCallTrace // Name and arguments -> recorded calls / 名称和参数 -> 记录的调用
+	used      map[string]int
+	liveTools bool
+}
+
+func newTraceReplay(trace *RunTrace, opts ReplayOptions) *traceReplay {
+	r := &traceReplay{
+		results:   map[string][]ToolCallTrace{},
+		used:      map[string]int{},
+		liveTools: opts.LiveTools,
+	}
+	for _, call := range trace.ToolCalls {
+		switch ToolExecutionStatus(call.Status) {
+		case ToolExecutionStatusSuccess, ToolExecutionStatusFailed, ToolExecutionStatusTimeout:
+			key := toolCallKey(call.Name, call.Arguments)
+			r.results[key] = append(r.results[key], call)
+		}
+	}
+	return r
+}
+
+// toolCallKey identifies a tool call by name and arguments
+func toolCallKey(name string, args map[string]interface{}) string {
+	data, _ := json.Marshal(args)
+	if len(args) == 0 {
+		data = nil
+	}
+	return name + "\x00" + string(data)
+}
+
+// lookup returns the next recorded call with the same name and arguments.
+// Calls repeated more often than recorded reuse the last result.
+func (r *traceReplay) lookup(name string, args map[string]interface{}) (ToolCallTrace, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := toolCallKey(name, args)
+	calls := r.results[key]
+	if len(calls) == 0 {
+		return ToolCallTrace{}, false
+	}
+	i := r.used[key]
+	if i >= len(calls) {
+		i = len(calls) - 1
+	}
+	r.used[key] = i + 1
+	return calls[i], true
+}
+
+// callTool returns the recorded result of a tool call, or runs the tool when
+// the trace has none and live tools are allowed
+func (r *traceReplay) callTool(ctx context.Context, a *Agent, hookInput *hooks.ToolHookInput, fn *toolkit.Function, args map[string]interface{}) (interface{}, error) {
+	call, ok := r.lookup(hookInput.FunctionName, args)
+	if !ok {
+		if r.liveTools {
+			return a.callTool(ctx, fn, args)
+		}
+		return nil, fmt.Errorf("no recorded result for %s in the replayed trace", hookInput.FunctionName)
+	}
+
+	hookInput.SetMetadata(replayedMetadataKey, true)
+	if call.Error != "" {
+		return nil, errors.New(call.Error)
+	}
+	// The recorded output is the formatted result; returning it as raw JSON
+	// gives the model the same tool message.
+	// 记录的输出是格式化后的结果；以原始 JSON 返回可使模型得到相同的工具消息。
+	if json.Valid([]byte(call.Output)) {
+		return json.RawMessage(call.Output), nil
+	}
+	return call.Output, nil
+}
+
+type pendingReplayKey struct{}
+
+// withPendingReplay makes the next run started with ctx replay a trace
+func withPendingReplay(ctx context.Context, replay *traceReplay) context.Context {
+	return context.WithValue(ctx, pendingReplayKey{}, replay)
+}
+
+// pendingReplayFrom returns the replay waiting for a run to start, or nil
+func pendingReplayFrom(ctx context.Context) *traceReplay {
+	replay, _ := ctx.Value(pendingReplayKey{}).(*traceReplay)
+	return replay
+}
+
+// traceReplayFrom returns the replay of the run of ctx, or nil
+func traceReplayFrom(ctx context.Context) *traceReplay {
+	if recorder := traceRecorderFrom(ctx); recorder != nil {
+		return recorder.replay
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+)
+
+// Reproducibility records the inputs that determine a run's result, so two
+// runs can be compared and a run can be labelled reproducible or not.
+// Reproducibility 记录决定运行结果的输入，用于比较两次运行并判断运行是否可复现。
+type Reproducibility struct {
+	ModelID      string                 `json:"model_id"`
+	Provider     string                 `json:"provider"`
+	Seed         *int                   `json:"seed,omitempty"`
+	Parameters   map[string]interface{} `json:"parameters,omitempty"`    // Request parameters other than messages / 消息以外的请求参数
+	PromptHash   string                 `json:"prompt_hash"`             // SHA-256 of the system instructions / 系统指令的 SHA-256
+	ToolVersions map[string]string      `json:"tool_versions,omitempty"` // Toolkit name to version ("" if unversioned) / 工具包名称到版本
+	ToolsHash    string                 `json:"tools_hash,omitempty"`    // SHA-256 of the tool definitions sent to the model / 发送给模型的工具定义的 SHA-256
+
+	// Reproducible is true when nothing known makes the run non-deterministic;
+	// Reasons lists what does otherwise.
+	// Reproducible 在没有已知的非确定性因素时为 true，否则 Reasons 列出原因。
+	Reproducible bool     `json:"reproducible"`
+	Reasons      []string `json:"reasons,omitempty"`
+}
+
+// RunFingerprint returns a stable hash of a run's reproducibility inputs. Runs
+// with equal fingerprints used the same model, parameters, seed, prompt and
+// tools. It returns "" when the output carries no reproducibility metadata.
+// RunFingerprint 返回运行可复现性输入的稳定哈希。指纹相同的运行使用了相同的模型、参数、种子、提示和工具。
+func RunFingerprint(output *RunOutput) string {
+	if output == nil || output.Reproducibility == nil {
+		return ""
+	}
+	r := output.Reproducibility
+	// Only inputs are hashed; the verdict fields are derived from them.
+	data, _ := json.Marshal(struct {
+		ModelID      string                 `json:"model_id"`
+		Provider     string                 `json:"provider"`
+		Seed         *int                   `json:"seed"`
+		Parameters   map[string]interface{} `json:"parameters"`
+		PromptHash   string                 `json:"prompt_hash"`
+		ToolVersions map[string]string      `json:"tool_versions"`
+		ToolsHash    string                 `json:"tools_hash"`
+	}{r.ModelID, r.Provider, r.Seed, r.Parameters, r.PromptHash, r.ToolVersions, r.ToolsHash})
+	return hashHex(data)
+}
+
+// reproducibility builds the metadata for a run with the given instructions
+func (a *Agent) reproducibility(ctx context.Context, instructions string) *Reproducibility {
+	if instructions == "" {
+		a.instructionsMu.RLock()
+		instructions = a.Instructions
+		a.instructionsMu.RUnlock()
+	}
+
+	r := &Reproducibility{
+		ModelID:    a.Model.GetID(),
+		Provider:   a.Model.GetProvider(),
+		PromptHash: hashHex([]byte(instructions)),
+	}
+	if a.seed != nil {
+		seed := *a.seed
+		r.Seed = &seed
+	} else {
+		r.Reasons = append(r.Reasons, "no seed configured")
+	}
+	if a.hasFallbacks {
+		r.Reasons = append(r.Reasons, "fallback models may answer")
+	}
+	if a.responseFormat != nil {
+		r.Parameters = map[string]interface{}{"response_format": a.responseFormat.Type}
+	}
+
+	if len(a.Toolkits) > 0 {
+		r.ToolVersions = make(map[string]string, len(a.Toolkits))
+		for _, tk := range a.Toolkits {
+			version := ""
+			if v, ok := tk.(toolkit.Versioned); ok {
+				version = v.Version()
+			}
+			r.ToolVersions[tk.Name()] = version
+		}
+
+		names := make([]string, 0, len(r.ToolVersions))
+		for name, version := range r.ToolVersions {
+			if version == "" {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			r.Reasons = append(r.Reasons, fmt.Sprintf("toolkit %s has no version", name))
+		}
+
+		defs := a.toolDefinitions(ctx)
+		sort.Slice(defs, func(i, j int) bool { return defs[i].Function.Name < defs[j].Function.Name })
+		if data, err := json.Marshal(defs); err == nil {
+			r.ToolsHash = hashHex(data)
+		}
+	}
+
+	r.Reproducible = len(r.Reasons) == 0
+	return r
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/calculator"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+type versionedToolkit struct {
+	*toolkit.BaseToolkit
+}
+
+func (versionedToolkit) Version() string { return "1.2.0" }
+
+func TestAgent_Reproducibility(t *testing.T) {
+	var sentSeed *int
+	model := &MockModel{
+		BaseModel: models.BaseModel{ID: "test", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			sentSeed = req.Seed
+			return &types.ModelResponse{Content: "ok"}, nil
+		},
+	}
+	seed := 7
+	newAgent := func(instructions string) *Agent {
+		ag, err := New(Config{
+			Model:        model,
+			Instructions: instructions,
+			Seed:         &seed,
+			Toolkits:     []toolkit.Toolkit{versionedToolkit{toolkit.NewBaseToolkit("search")}},
+		})
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		return ag
+	}
+
+	first, err := newAgent("be brief").Run(context.Background(), "hi")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	r := first.Reproducibility
+	if r == nil || !r.Reproducible || len(r.Reasons) != 0 {
+		t.Fatalf("expected reproducible run, got %+v", r)
+	}
+	if sentSeed == nil || *sentSeed != seed {
+		t.Errorf("seed not sent to model: %v", sentSeed)
+	}
+	if r.ModelID != "test" || r.Provider != "mock" || r.ToolVersions["search"] != "1.2.0" || r.PromptHash == "" {
+		t.Errorf("unexpected metadata: %+v", r)
+	}
+
+	second, _ := newAgent("be brief").Run(context.Background(), "something else")
+	if RunFingerprint(first) != RunFingerprint(second) {
+		t.Error("runs with the same inputs should share a fingerprint")
+	}
+	changed, _ := newAgent("be verbose").Run(context.Background(), "hi")
+	if RunFingerprint(first) == RunFingerprint(changed) {
+		t.Error("changing instructions should change the fingerprint")
+	}
+	if RunFingerprint(nil) != "" {
+		t.Error("nil output should have an empty fingerprint")
+	}
+}
+
+func TestAgent_ReproducibilityReasons(t *testing.T) {
+	ag, err := New(Config{
+		Model:    &MockModel{BaseModel: models.BaseModel{ID: "test", Provider: "mock"}},
+		Toolkits: []toolkit.Toolkit{calculator.New()},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	out, err := ag.Run(context.Background(), "hi")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	r := out.Reproducibility
+	if r.Reproducible {
+		t.Fatal("run without seed should not be reproducible")
+	}
+	want := []string{"no seed configured", "toolkit calculator has no version"}
+	if len(r.Reasons) != len(want) || r.Reasons[0] != want[0] || r.Reasons[1] != want[1] {
+		t.Errorf("Reasons = %v, want %v", r.Reasons, want)
+	}
+	if r.ToolsHash == "" {
+		t.Error("expected tools hash")
+	}
+}
@@ -0,0 +1,202 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+)
+
+// SessionStateMetadataKey is the RunRecord metadata key under which SessionStorage
+// keeps the session state at the end of a run
+// SessionStateMetadataKey 是 SessionStorage 在 RunRecord 元数据中保存运行结束时会话状态所用的键
+const SessionStateMetadataKey = "session_state"
+
+// SessionState is the mutable state of a session, e.g. a shopping cart. It is
+// persisted with the session, rendered into the instructions as {{.state.key}}
+// and read or updated by tools through SessionStateFromContext.
+// SessionState 是会话的可变状态（例如购物车）。它随会话持久化，以 {{.state.key}} 渲染到指令中，
+// 工具可通过 SessionStateFromContext 读取或更新。
+type SessionState struct {
+	mu   sync.RWMutex
+	data map[string]interface{}
+}
+
+// NewSessionState creates a session state holding a copy of values
+// NewSessionState 创建包含 values 副本的会话状态
+func NewSessionState(values map[string]interface{}) *SessionState {
+	data := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		data[k] = v
+	}
+	return &SessionState{data: data}
+}
+
+// Get retrieves a value from the session state
+// Get 从会话状态检索值
+func (s *SessionState) Get(key string) (interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.data[key]
+	return v, ok
+}
+
+// Set stores a value in the session state
+// Set 在会话状态中存储值
+func (s *SessionState) Set(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = value
+}
+
+// Update replaces the value of key with fn(old) atomically; old is nil when the
+// key is missing. Use it for read-modify-write changes such as adding to a cart.
+// Update 以原子方式将 key 的值替换为 fn(old)；键不存在时 old 为 nil。适用于读-改-写操作，例如向购物车添加商品。
+func (s *SessionState) Update(key string, fn func(old interface{}) interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = fn(s.data[key])
+}
+
+// Delete removes a key from the session state
+// Delete 从会话状态中删除键
+func (s *SessionState) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, key)
+}
+
+// GetAll returns a copy of all session state data; nil for a nil state
+// GetAll 返回所有会话状态数据的副本；state 为 nil 时返回 nil
+func (s *SessionState) GetAll() map[string]interface{} {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	values := make(map[string]interface{}, len(s.data))
+	for k, v := range s.data {
+		values[k] = v
+	}
+	return values
+}
+
+// replace swaps the whole state for a copy of values
+func (s *SessionState) replace(values map[string]interface{}) {
+	data := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		data[k] = v
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = data
+}
+
+type sessionStateKey struct{}
+
+// WithSessionState returns a context whose runs use state instead of the
+// agent's own session state, e.g. when one agent serves many sessions
+// WithSessionState 返回一个上下文，其中的运行使用 state 而非代理自身的会话状态，例如一个代理服务多个会话时
+func WithSessionState(ctx context.Context, state *SessionState) context.Context {
+	return context.WithValue(ctx, sessionStateKey{}, state)
+}
+
+// SessionStateFromContext returns the session state of the current run, or nil
+// outside a run. Tools use it to read and update the state.
+// SessionStateFromContext 返回当前运行的会话状态，运行之外返回 nil。工具用它读取和更新状态。
+func SessionStateFromContext(ctx context.Context) *SessionState {
+	state, _ := ctx.Value(sessionStateKey{}).(*SessionState)
+	return state
+}
+
+// withSessionState makes sure the run's context carries a session state: the
+// caller's if set with WithSessionState, else the agent's
+// withSessionState 确保运行的上下文带有会话状态：调用方通过 WithSessionState 设置的，否则为代理自身的
+func (a *Agent) withSessionState(ctx context.Context) context.Context {
+	if SessionStateFromContext(ctx) != nil {
+		return ctx
+	}
+	return WithSessionState(ctx, a.sessionState)
+}
+
+// SessionState returns the agent's own session state
+// SessionState 返回代理自身的会话状态
+func (a *Agent) SessionState() *SessionState {
+	return a.sessionState
+}
+
+// renderInstructions fills {{.state.key}} and {{.dependencies.name}} placeholders
+// with the run's session state and dependencies. Instructions that are not a
+// valid template are used as they are.
+// renderInstructions 用运行的会话状态和依赖填充 {{.state.key}} 和 {{.dependencies.name}} 占位符；
+// 不是有效模板的指令按原样使用。
+func (a *Agent) renderInstructions(ctx context.Context, instructions string) string {
+	if !strings.Contains(instructions, "{{") {
+		return instructions
+	}
+	tmpl, err := template.New("instructions").Funcs(template.FuncMap{"orEmpty": orEmpty}).Parse(instructions)
+	if err != nil {
+		a.logger.Debug("instructions are not a template, using them as they are", "error", err)
+		return instructions
+	}
+	for _, t := range tmpl.Templates() {
+		emptyMissing(t.Tree.Root)
+	}
+
+	state := SessionStateFromContext(ctx).GetAll()
+	if state == nil {
+		state = map[string]interface{}{}
+	}
+	var buf bytes.Buffer
+	deps := DependenciesFromContext(ctx)
+	if deps == nil {
+		deps = map[string]interface{}{}
+	}
+	data := map[string]interface{}{"state": state, "dependencies": deps}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		a.logger.Warn("failed to render instructions template", "error", err)
+		return instructions
+	}
+	return buf.String()
+}
+
+// emptyMissing pipes every printed action through orEmpty, so missing keys
+// render as "" instead of "<no value>"
+// emptyMissing 将每个输出的动作通过 orEmpty 处理，使缺失的键渲染为 "" 而不是 "<no value>"
+func emptyMissing(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			emptyMissing(child)
+		}
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) == 0 {
+			n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+				NodeType: parse.NodeCommand,
+				Pos:      n.Pos,
+				Args:     []parse.Node{parse.NewIdentifier("orEmpty").SetPos(n.Pos)},
+			})
+		}
+	case *parse.IfNode:
+		emptyMissing(n.List)
+		emptyMissing(n.ElseList)
+	case *parse.RangeNode:
+		emptyMissing(n.List)
+		emptyMissing(n.ElseList)
+	case *parse.WithNode:
+		emptyMissing(n.List)
+		emptyMissing(n.ElseList)
+	}
+}
+
+func orEmpty(v interface{}) interface{} {
+	if v == nil {
+		return ""
+	}
+	return v
+}
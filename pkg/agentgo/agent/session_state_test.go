@@ -0,0 +1,174 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/storage"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// cartAgent returns an agent whose model adds the input to the cart with a
+// tool, and the system prompts the model was given
+func cartAgent(t *testing.T, config Config) (*Agent, *[]string) {
+	t.Helper()
+	tk := toolkit.NewBaseToolkit("shop")
+	tk.RegisterFunction(&toolkit.Function{
+		Name:       "add_to_cart",
+		Parameters: map[string]toolkit.Parameter{"item": {Type: "string", Required: true}},
+		Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			state := SessionStateFromContext(ctx)
+			if state == nil {
+				return nil, fmt.Errorf("no session state")
+			}
+			state.Update("cart", func(old interface{}) interface{} {
+				cart, _ := old.([]interface{})
+				return append(cart, args["item"])
+			})
+			return "added", nil
+		},
+	})
+
+	var prompts []string
+	config.Model = &MockModel{
+		BaseModel: models.BaseModel{ID: "mock", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			prompts = append(prompts, req.Messages[0].Content)
+			last := req.Messages[len(req.Messages)-1]
+			if last.Role == types.RoleTool {
+				return &types.ModelResponse{Content: "done"}, nil
+			}
+			return &types.ModelResponse{ToolCalls: []types.ToolCall{{
+				ID:       "call-1",
+				Type:     "function",
+				Function: types.ToolCallFunction{Name: "add_to_cart", Arguments: fmt.Sprintf(`{"item": %q}`, last.Content)},
+			}}}, nil
+		},
+	}
+	config.Instructions = "Cart: {{.state.cart}} Coupon: {{.state.coupon}}"
+	config.Toolkits = []toolkit.Toolkit{tk}
+
+	ag, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return ag, &prompts
+}
+
+func TestAgent_SessionState(t *testing.T) {
+	ag, prompts := cartAgent(t, Config{SessionState: map[string]interface{}{"cart": []interface{}{}}})
+	ctx := context.Background()
+
+	output, err := ag.Run(ctx, "apple")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := fmt.Sprint(output.SessionState["cart"]); got != "[apple]" {
+		t.Fatalf("expected the tool to update the cart, got %s", got)
+	}
+	if _, err := ag.Run(ctx, "pear"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// Each run renders the state it starts with; missing keys render empty
+	if (*prompts)[0] != "Cart: [] Coupon: " || (*prompts)[2] != "Cart: [apple] Coupon: " {
+		t.Fatalf("unexpected system prompts %q", *prompts)
+	}
+	if cart, _ := ag.SessionState().Get("cart"); fmt.Sprint(cart) != "[apple pear]" {
+		t.Fatalf("agent state cart = %v", cart)
+	}
+}
+
+func TestAgent_SessionState_FromContext(t *testing.T) {
+	ag, _ := cartAgent(t, Config{})
+	state := NewSessionState(map[string]interface{}{"cart": []interface{}{"milk"}})
+
+	output, err := ag.Run(WithSessionState(context.Background(), state), "bread")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if cart, _ := state.Get("cart"); fmt.Sprint(cart) != "[milk bread]" || fmt.Sprint(output.SessionState["cart"]) != "[milk bread]" {
+		t.Fatalf("expected the caller's state to be updated, got %v", cart)
+	}
+	if _, ok := ag.SessionState().Get("cart"); ok {
+		t.Fatal("the agent's own state should be untouched")
+	}
+}
+
+func TestAgent_SessionState_Persisted(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	config := Config{SessionID: "shop-session", SessionStorage: store}
+
+	first, _ := cartAgent(t, config)
+	if _, err := first.Run(context.Background(), "apple"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// A new process restores the state of the last stored run
+	second, prompts := cartAgent(t, config)
+	if _, err := second.Run(context.Background(), "pear"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if (*prompts)[0] != "Cart: [apple] Coupon: " {
+		t.Fatalf("expected the restored cart in the prompt, got %q", (*prompts)[0])
+	}
+	if cart, _ := second.SessionState().Get("cart"); fmt.Sprint(cart) != "[apple pear]" {
+		t.Fatalf("restored cart = %v", cart)
+	}
+}
+
+func TestAgent_SessionState_InvalidTemplate(t *testing.T) {
+	ag, err := New(Config{Model: &MockModel{}, Instructions: "Reply with {{ JSON }}"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got := ag.renderInstructions(context.Background(), ag.GetInstructions()); got != "Reply with {{ JSON }}" {
+		t.Fatalf("expected the instructions unchanged, got %q", got)
+	}
+}
+
+func TestAgent_SessionState_ClearedStatePersisted(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	newAgent := func() *Agent {
+		ag, err := New(Config{
+			Model:          &MockModel{BaseModel: models.BaseModel{ID: "mock", Provider: "mock"}},
+			SessionID:      "shop-session",
+			SessionState:   map[string]interface{}{"cart": []interface{}{"apple"}},
+			SessionStorage: store,
+		})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		return ag
+	}
+
+	first := newAgent()
+	if _, err := first.Run(context.Background(), "hi"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	first.SessionState().Delete("cart")
+	if _, err := first.Run(context.Background(), "checkout"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// The emptied state is restored, not the cart of the run before
+	second := newAgent()
+	second.restoreSession(context.Background())
+	if cart, ok := second.SessionState().Get("cart"); ok {
+		t.Fatalf("expected the cleared cart to stay cleared, got %v", cart)
+	}
+}
+
+func TestAgent_SessionState_LiteralNoValue(t *testing.T) {
+	ag, err := New(Config{Model: &MockModel{}, Instructions: `Say "<no value>" when unsure.{{if .state.cart}} Cart: {{.state.cart}}{{end}} Coupon: {{.state.coupon}}`})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := WithSessionState(context.Background(), NewSessionState(map[string]interface{}{"cart": []interface{}{"apple"}}))
+	if got := ag.renderInstructions(ctx, ag.GetInstructions()); got != `Say "<no value>" when unsure. Cart: [apple] Coupon: ` {
+		t.Fatalf("renderInstructions() = %q", got)
+	}
+}
@@ -0,0 +1,212 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/prompts"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// snapshotVersion is bumped when Snapshot changes incompatibly
+const snapshotVersion = 1
+
+// ErrSnapshotConfigMismatch is returned by Restore when the snapshot was taken
+// from an agent with a different model, tools or loop budget
+// ErrSnapshotConfigMismatch 表示快照来自模型、工具或循环预算不同的代理
+var ErrSnapshotConfigMismatch = errors.New("snapshot config hash does not match agent")
+
+// Snapshot is the restorable state of an agent: conversation memory, prompt
+// composition, the learning profile it points to and a hash of the
+// configuration it was taken from. Learned data itself stays in the learning
+// storage; the snapshot only references it.
+// Snapshot 是代理的可恢复状态：对话内存、提示组合、所指向的学习档案以及生成快照时配置的哈希。
+// 学习数据本身保留在学习存储中，快照仅引用它。
+type Snapshot struct {
+	Version      int                    `json:"version"`
+	AgentID      string                 `json:"agent_id"`
+	Name         string                 `json:"name,omitempty"`
+	UserID       string                 `json:"user_id,omitempty"`
+	SessionID    string                 `json:"session_id,omitempty"`
+	ConfigHash   string                 `json:"config_hash"`             // Model, tools and loop budget / 模型、工具和循环预算
+	Instructions string                 `json:"instructions,omitempty"`  // Current instructions / 当前指令
+	Messages     []*types.Message       `json:"messages"`                // Memory for UserID / UserID 的内存
+	SessionState map[string]interface{} `json:"session_state,omitempty"` // Agent's session state / 代理的会话状态
+	Prompt       *PromptSnapshot        `json:"prompt,omitempty"`        // Prompt composer state / 提示组合器状态
+	Learning     *LearningRef           `json:"learning,omitempty"`      // Learning profile pointer / 学习档案指针
+	Metadata     map[string]interface{} `json:"metadata,omitempty"`      // Caller-defined data / 调用方自定义数据
+	CreatedAt    time.Time              `json:"created_at"`
+}
+
+// PromptSnapshot captures the prompt composer sections and variables
+// PromptSnapshot 记录提示组合器的部分和变量
+type PromptSnapshot struct {
+	Sections  []prompts.PromptSection `json:"sections"`
+	Variables map[string]interface{}  `json:"variables,omitempty"`
+}
+
+// LearningRef points at the learning profile an agent used
+// LearningRef 指向代理使用的学习档案
+type LearningRef struct {
+	UserID           string     `json:"user_id"`
+	ProfileUpdatedAt *time.Time `json:"profile_updated_at,omitempty"` // nil when no profile existed / 无档案时为 nil
+}
+
+// ConfigHash returns a stable hash of the configuration that determines how
+// restored state is interpreted: model, tool definitions, loop budget and
+// response format. Instructions and prompt sections are state, not config.
+// ConfigHash 返回决定如何解释恢复状态的配置的稳定哈希：模型、工具定义、循环预算和响应格式。
+func (a *Agent) ConfigHash() string {
+	defs := toolkit.ToModelToolDefinitions(a.Toolkits)
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Function.Name < defs[j].Function.Name })
+
+	cfg := struct {
+		ModelID        string      `json:"model_id"`
+		Provider       string      `json:"provider"`
+		MaxLoops       int         `json:"max_loops"`
+		Tools          interface{} `json:"tools"`
+		ResponseFormat string      `json:"response_format,omitempty"`
+	}{
+		MaxLoops: a.MaxLoops,
+		Tools:    defs,
+	}
+	if a.Model != nil {
+		cfg.ModelID = a.Model.GetID()
+		cfg.Provider = a.Model.GetProvider()
+	}
+	if a.responseFormat != nil {
+		cfg.ResponseFormat = a.responseFormat.Type
+	}
+	data, _ := json.Marshal(cfg)
+	return hashHex(data)
+}
+
+// Snapshot captures the agent's state for blue/green deploys and disaster
+// recovery. Persist it with json.Marshal and pass it to Restore on an agent
+// built with the same configuration.
+// Snapshot 捕获代理状态，用于蓝绿部署和灾难恢复。用 json.Marshal 持久化，并传给使用相同配置构建的代理的 Restore。
+func (a *Agent) Snapshot(ctx context.Context) (*Snapshot, error) {
+	// Make sure stored session messages are part of the snapshot.
+	a.restoreSession(ctx)
+
+	snap := &Snapshot{
+		Version:      snapshotVersion,
+		AgentID:      a.ID,
+		Name:         a.Name,
+		UserID:       a.UserID,
+		SessionID:    a.sessionID,
+		ConfigHash:   a.ConfigHash(),
+		Instructions: a.GetInstructions(),
+		SessionState: a.sessionState.GetAll(),
+		CreatedAt:    time.Now().UTC(),
+	}
+
+	messages := a.Memory.GetMessages(a.UserID)
+	snap.Messages = make([]*types.Message, 0, len(messages))
+	for _, msg := range messages {
+		if msg == nil {
+			continue
+		}
+		cp := *msg
+		snap.Messages = append(snap.Messages, &cp)
+	}
+
+	if a.promptComposer != nil {
+		ps := &PromptSnapshot{Variables: copyMap(a.promptVars)}
+		for _, name := range a.promptComposer.ListSections() {
+			if section, ok := a.promptComposer.GetSection(name); ok {
+				section.Variables = copyMap(section.Variables)
+				ps.Sections = append(ps.Sections, section)
+			}
+		}
+		snap.Prompt = ps
+	}
+
+	if a.learningMachine != nil && a.UserID != "" {
+		ref := &LearningRef{UserID: a.UserID}
+		profile, err := a.learningMachine.GetUserProfile(ctx, a.UserID)
+		if err != nil {
+			a.logger.Debug("no learning profile for snapshot", "user_id", a.UserID, "error", err)
+		} else if profile != nil {
+			updated := profile.UpdatedAt
+			ref.ProfileUpdatedAt = &updated
+		}
+		snap.Learning = ref
+	}
+
+	return snap, nil
+}
+
+// Restore replaces the agent's memory, instructions, session state and prompt
+// composition with the snapshot's. It fails with ErrSnapshotConfigMismatch when the
+// snapshot was taken from a different configuration; clear snap.ConfigHash to
+// restore into a deliberately changed agent (e.g. a new model version).
+// Restore 用快照替换代理的内存、指令、会话状态和提示组合。配置不同时返回 ErrSnapshotConfigMismatch；
+// 如需恢复到有意变更的代理（例如新模型版本），请清空 snap.ConfigHash。
+func (a *Agent) Restore(ctx context.Context, snap *Snapshot) error {
+	if snap == nil {
+		return fmt.Errorf("snapshot is nil")
+	}
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", snap.Version)
+	}
+	if snap.ConfigHash != "" && snap.ConfigHash != a.ConfigHash() {
+		return ErrSnapshotConfigMismatch
+	}
+
+	a.UserID = snap.UserID
+	if snap.SessionID != "" {
+		a.sessionID = snap.SessionID
+	}
+	a.SetInstructions(snap.Instructions)
+	a.sessionState.replace(snap.SessionState)
+
+	if snap.Prompt != nil {
+		if a.promptComposer == nil {
+			a.promptComposer = prompts.NewPromptComposer()
+		}
+		a.promptComposer.Clear()
+		for _, section := range snap.Prompt.Sections {
+			a.promptComposer.AddSection(section)
+		}
+		a.promptVars = copyMap(snap.Prompt.Variables)
+	}
+
+	a.Memory.Clear(a.UserID)
+	for _, msg := range snap.Messages {
+		if msg == nil {
+			continue
+		}
+		cp := *msg
+		a.Memory.Add(&cp, a.UserID)
+	}
+
+	// The snapshot already holds the session's messages; loading them again
+	// from storage would duplicate them.
+	a.sessionRestoreMu.Lock()
+	a.sessionRestored = true
+	a.sessionRestoreMu.Unlock()
+
+	if snap.Learning != nil && a.learningMachine == nil {
+		a.logger.Warn("snapshot references a learning profile but the agent has no learning machine", "user_id", snap.Learning.UserID)
+	}
+
+	a.logger.Info("agent state restored", "agent_id", a.ID, "messages", len(snap.Messages), "snapshot_created_at", snap.CreatedAt)
+	return nil
+}
+
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	cp := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		cp[k] = v
+	}
+	return cp
+}
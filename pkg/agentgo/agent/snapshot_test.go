@@ -0,0 +1,105 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/prompts"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/calculator"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+func newSnapshotAgent(t *testing.T, modelID string) *Agent {
+	t.Helper()
+	model := &MockModel{
+		BaseModel: models.BaseModel{ID: modelID, Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			return &types.ModelResponse{Content: "noted"}, nil
+		},
+	}
+	ag, err := New(Config{
+		Name:           "assistant",
+		Model:          model,
+		Toolkits:       []toolkit.Toolkit{calculator.New()},
+		Instructions:   "Be brief.",
+		UserID:         "u1",
+		PromptComposer: prompts.NewPromptComposer(prompts.NewSection("identity", "You are helpful.", 1)),
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return ag
+}
+
+func TestAgent_SnapshotRestore(t *testing.T) {
+	ctx := context.Background()
+	blue := newSnapshotAgent(t, "m1")
+	if _, err := blue.Run(ctx, "remember the code 4242"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	blue.SetInstructions("Be very brief.")
+	blue.UpdatePromptSection("identity", "You are a support bot.")
+
+	snap, err := blue.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	data, err := json.Marshal(snap)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var decoded Snapshot
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	green := newSnapshotAgent(t, "m1")
+	if err := green.Restore(ctx, &decoded); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	if green.GetInstructions() != "Be very brief." {
+		t.Errorf("instructions = %q", green.GetInstructions())
+	}
+	section, ok := green.GetPromptComposer().GetSection("identity")
+	if !ok || section.Content != "You are a support bot." {
+		t.Errorf("prompt section not restored: %+v", section)
+	}
+	want := blue.Memory.GetMessages("u1")
+	got := green.Memory.GetMessages("u1")
+	if len(got) != len(want) || len(got) == 0 {
+		t.Fatalf("messages = %d, want %d", len(got), len(want))
+	}
+	for i := range got {
+		if got[i].Role != want[i].Role || got[i].Content != want[i].Content {
+			t.Errorf("message %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestAgent_RestoreRejectsConfigMismatch(t *testing.T) {
+	ctx := context.Background()
+	snap, err := newSnapshotAgent(t, "m1").Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+
+	upgraded := newSnapshotAgent(t, "m2")
+	if err := upgraded.Restore(ctx, snap); !errors.Is(err, ErrSnapshotConfigMismatch) {
+		t.Fatalf("expected ErrSnapshotConfigMismatch, got %v", err)
+	}
+
+	snap.ConfigHash = ""
+	if err := upgraded.Restore(ctx, snap); err != nil {
+		t.Fatalf("Restore without hash: %v", err)
+	}
+
+	snap.Version = 99
+	if err := upgraded.Restore(ctx, snap); err == nil {
+		t.Fatal("expected error for unsupported version")
+	}
+}
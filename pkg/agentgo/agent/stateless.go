@@ -0,0 +1,629 @@
+package agent
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/guardrails"
+	"github.com/jholhewres/agent-go/pkg/agentgo/hooks"
+	"github.com/jholhewres/agent-go/pkg/agentgo/ids"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/run"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// runStateVersion is bumped when RunState changes incompatibly
+const runStateVersion = 2
+
+// RunState is the complete loop state of a run between two model turns.
+// RunTurn and ResumeTurn serialize it into an opaque blob so each turn can
+// execute in a separate process. The blob is encrypted, since the state holds
+// the conversation and the values behind PII placeholders.
+// RunState 是两次模型轮次之间运行的完整循环状态，由 RunTurn 和 ResumeTurn 序列化为不透明数据，
+// 使每个轮次可以在不同进程中执行。该数据经过加密，因为状态包含对话以及 PII 占位符对应的值。
+type RunState struct {
+	Version             int                     `json:"version"`
+	TurnID              string                  `json:"turn_id"`   // Unique per blob, passed to ConsumeRunState / 每份数据唯一，传给 ConsumeRunState
+	IssuedAt            time.Time               `json:"issued_at"` // When the blob was sealed / 数据加密的时间
+	RunID               string                  `json:"run_id"`
+	AgentID             string                  `json:"agent_id"`
+	UserID              string                  `json:"user_id,omitempty"`
+	Input               string                  `json:"input"`
+	Instructions        string                  `json:"instructions,omitempty"`       // Resolved instructions for the run / 本次运行解析后的指令
+	Messages            []*types.Message        `json:"messages"`                     // Conversation including history / 包含历史的对话
+	InitialMessageCount int                     `json:"initial_message_count"`        // Messages that predate the run / 运行之前已有的消息数
+	PendingToolCalls    []types.ToolCall        `json:"pending_tool_calls,omitempty"` // Executed at the start of the next turn / 在下一轮开始时执行
+	AwaitingApproval    bool                    `json:"awaiting_approval,omitempty"`  // PendingToolCalls wait for ResumeTurnApproval / PendingToolCalls 等待 ResumeTurnApproval
+	Loops               int                     `json:"loops"`                        // Model turns used so far / 已使用的模型轮次
+	MaxLoops            int                     `json:"max_loops"`                    // Turn budget / 轮次预算
+	Usage               types.Usage             `json:"usage"`                        // Tokens and cost so far / 到目前为止的令牌和成本
+	ToolsExecuted       []*ToolExecutionSummary `json:"tools_executed,omitempty"`     // Tool summaries so far / 到目前为止的工具摘要
+	Findings            []guardrails.Finding    `json:"findings,omitempty"`           // Pre-hook guardrail findings / 前置钩子防护栏问题
+	GuardrailResults    []GuardrailResult       `json:"guardrail_results,omitempty"`  // Input guardrail checks / 输入防护栏检查
+	PIIMapping          guardrails.PIIMapping   `json:"pii_mapping,omitempty"`        // Values behind PII placeholders / PII 占位符对应的值
+	Reproducibility     *Reproducibility        `json:"reproducibility,omitempty"`    // Run inputs / 运行输入
+	Grounding           *GroundingDecision      `json:"grounding,omitempty"`          // Strict knowledge decision / 严格知识模式的决策
+	Citations           []Citation              `json:"citations,omitempty"`          // Knowledge and memory given to the model / 提供给模型的知识和记忆
+	Warnings            []RunWarning            `json:"warnings,omitempty"`           // Optional subsystems that failed / 失败的可选子系统
+	SessionState        map[string]interface{}  `json:"session_state,omitempty"`      // Session state after the turn / 本轮之后的会话状态
+	StartedAt           time.Time               `json:"started_at"`
+}
+
+// TurnResult is the outcome of one stateless turn: either the final output or
+// the state blob to pass to the next ResumeTurn call. A turn paused for a tool
+// approval has both: the paused output and the blob to pass to
+// ResumeTurnApproval.
+// TurnResult 是一次无状态轮次的结果：最终输出，或传给下一次 ResumeTurn 的状态数据。
+// 等待工具审批而暂停的轮次两者都有：暂停的输出和传给 ResumeTurnApproval 的状态数据。
+type TurnResult struct {
+	Output *RunOutput
+	State  []byte
+}
+
+// Done reports whether the run completed
+// Done 判断运行是否已完成
+func (r *TurnResult) Done() bool {
+	return r != nil && r.Output != nil && r.Output.Status != RunStatusPaused
+}
+
+// finalOutput returns the output of a completed run, or nil
+func (r *TurnResult) finalOutput() *RunOutput {
+	if r == nil {
+		return nil
+	}
+	return r.Output
+}
+
+// runStateEnvelope carries a RunState sealed with AES-256-GCM, so the holder
+// of a blob can neither read nor change it
+type runStateEnvelope struct {
+	Nonce []byte `json:"nonce"`
+	State []byte `json:"state"`
+}
+
+// RunTurn starts a run but performs a single model turn. When the model asks
+// for tools, the tool calls are left pending and the serialized run state is
+// returned; pass it to ResumeTurn, possibly on another instance built with the
+// same configuration, to execute them and take the next turn. It suits
+// serverless deployments that run one turn per invocation.
+//
+// Stateless turns do not use the response cache and do not trigger
+// asynchronous learning, since the process may stop once a turn returns.
+//
+// The agent keeps no record of the blobs it returns. A blob can be resumed
+// until RunStateTTL has passed, and resuming it again executes its pending
+// tool calls again, or approves them again for ResumeTurnApproval. Set
+// ConsumeRunState to make each blob single-use.
+// RunTurn 开始一次运行但只执行一个模型轮次。模型请求工具时，工具调用保持待处理并返回序列化的运行状态；
+// 将其传给 ResumeTurn（可在使用相同配置构建的其他实例上）以执行工具并进行下一轮。适用于每次调用执行一轮的无服务器部署。
+// 代理不记录返回的状态数据：在 RunStateTTL 过期前都可以恢复，再次恢复会再次执行待处理的工具调用，
+// 或通过 ResumeTurnApproval 再次批准。设置 ConsumeRunState 可使每份数据只能使用一次。
+func (a *Agent) RunTurn(ctx context.Context, input string) (result *TurnResult, runErr error) {
+	defer a.ClearTempInstructions()
+
+	if input == "" {
+		return nil, types.NewInvalidInputError("input cannot be empty", nil)
+	}
+	if err := a.requireRunStateKey(); err != nil {
+		return nil, err
+	}
+
+	ctx, runCtx := ensureRunContext(ctx)
+	if runCtx != nil && runCtx.UserID == "" && a.UserID != "" {
+		runCtx.UserID = a.UserID
+	}
+	ctx = a.withFlagContext(ctx, runCtx)
+	if err := a.admitRun(ctx); err != nil {
+		return nil, err
+	}
+
+	a.restoreSession(ctx)
+	ctx = a.withSessionState(ctx)
+	ctx, err := a.resolveDependencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	instructions := a.instructionsForRun(ctx)
+	a.logger.Info("agent run (turn) started", "agent_id", a.ID, "input", input)
+
+	initialMessageCount := len(a.Memory.GetMessages(a.UserID))
+
+	ctx, endRun := a.beginRun(ctx, input, initialMessageCount)
+	defer func() { endRun(result.finalOutput(), runErr == nil && !result.Done(), runErr) }()
+
+	report := guardrails.NewGuardrailReport()
+	pii := guardrails.PIIMapping{}
+	if len(a.PreHooks) > 0 {
+		hookInput := hooks.NewHookInput(input).
+			WithAgentID(a.ID).
+			WithMessages([]interface{}{}).
+			WithReport(report)
+		hookInput.Metadata[guardrails.PIIMappingKey] = pii
+		defer func() { hookInput.RunEnded(runErr) }()
+
+		if err := hooks.ExecuteHooks(ctx, a.PreHooks, hookInput); err != nil {
+			a.logger.Error("pre-hook failed (turn)", "error", err)
+			return nil, types.NewInputCheckError("pre-hook validation failed", err)
+		}
+		input = hookInput.Input
+		if hookCtx := hookInput.Context(); hookCtx != nil {
+			ctx = hookCtx
+		}
+	}
+
+	input, guardrailResults, err := a.checkGuardrails(ctx, GuardrailStageInput, a.inputGuardrails, input, []interface{}{}, report, pii, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	a.Memory.Add(types.NewUserMessage(input), a.UserID)
+	rc := a.withRunContextInstructions(ctx, instructions, input)
+
+	state := &RunState{
+		Version:             runStateVersion,
+		RunID:               runCtx.RunID,
+		AgentID:             a.ID,
+		UserID:              a.UserID,
+		Input:               input,
+		Instructions:        rc.instructions,
+		InitialMessageCount: initialMessageCount,
+		MaxLoops:            a.MaxLoops,
+		Findings:            report.Findings(),
+		GuardrailResults:    guardrailResults,
+		PIIMapping:          pii,
+		Reproducibility:     a.reproducibility(ctx, rc.instructions),
+		Grounding:           rc.grounding,
+		Citations:           rc.citations,
+		Warnings:            rc.warnings,
+		StartedAt:           time.Now().UTC(),
+	}
+	return a.turn(ctx, runCtx, state, true)
+}
+
+// ResumeTurn continues a run from a state blob returned by RunTurn or a
+// previous ResumeTurn: it executes the pending tool calls, then performs one
+// model turn. The conversation in the blob replaces what the agent's Memory
+// holds for its user. Blobs of turns paused for a tool approval go to
+// ResumeTurnApproval instead.
+// ResumeTurn 从 RunTurn 或上一次 ResumeTurn 返回的状态数据继续运行：执行待处理的工具调用，然后执行一个模型轮次。
+// 状态数据中的对话会替换代理 Memory 中该用户的内容。等待工具审批的轮次的状态数据应交给 ResumeTurnApproval。
+func (a *Agent) ResumeTurn(ctx context.Context, blob []byte) (result *TurnResult, runErr error) {
+	state, err := a.decodeTurnState(blob)
+	if err != nil {
+		return nil, err
+	}
+	if state.AwaitingApproval {
+		return nil, types.NewInvalidInputError("run state waits for a tool approval; use ResumeTurnApproval", nil)
+	}
+	if err := a.consumeTurnState(ctx, state); err != nil {
+		return nil, err
+	}
+	return a.resumeTurn(ctx, state, nil)
+}
+
+// ResumeTurnApproval decides the tool calls of a stateless turn paused for
+// approval and continues the run like ResumeTurn. The paused state travels in
+// the blob, so the decision can be made in another process; Resume and
+// PendingApprovals do not know about it. When approved, the tool calls run;
+// otherwise they are cancelled and the model is told the user rejected them.
+// ResumeTurnApproval 对等待审批的无状态轮次的工具调用作出决定，并像 ResumeTurn 一样继续运行。
+// 暂停的状态保存在状态数据中，因此可以在其他进程中作出决定；Resume 和 PendingApprovals 不知道它。
+// 批准时执行工具调用；否则取消调用并告知模型用户已拒绝。
+func (a *Agent) ResumeTurnApproval(ctx context.Context, blob []byte, approved bool) (result *TurnResult, runErr error) {
+	state, err := a.decodeTurnState(blob)
+	if err != nil {
+		return nil, err
+	}
+	if !state.AwaitingApproval {
+		return nil, types.NewInvalidInputError("run state does not wait for a tool approval", nil)
+	}
+	if err := a.consumeTurnState(ctx, state); err != nil {
+		return nil, err
+	}
+	state.AwaitingApproval = false
+	return a.resumeTurn(ctx, state, &approved)
+}
+
+// decodeTurnState decodes an unexpired blob for this agent and user
+func (a *Agent) decodeTurnState(blob []byte) (*RunState, error) {
+	if err := a.requireRunStateKey(); err != nil {
+		return nil, err
+	}
+	state, err := a.DecodeRunState(blob)
+	if err != nil {
+		return nil, types.NewInvalidInputError("invalid run state", err)
+	}
+	if state.AgentID != a.ID || state.UserID != a.UserID {
+		return nil, types.NewInvalidInputError(
+			fmt.Sprintf("run state belongs to agent %q and user %q", state.AgentID, state.UserID), nil)
+	}
+	if time.Since(state.IssuedAt) > a.runStateTTL {
+		return nil, types.NewInvalidInputError("run state has expired", nil)
+	}
+	return state, nil
+}
+
+// consumeTurnState lets ConsumeRunState reject a blob that was already resumed
+func (a *Agent) consumeTurnState(ctx context.Context, state *RunState) error {
+	if a.consumeRunState == nil {
+		return nil
+	}
+	if err := a.consumeRunState(ctx, state.TurnID); err != nil {
+		return types.NewInvalidInputError("run state was rejected", err)
+	}
+	return nil
+}
+
+// resumeTurn restores the state's conversation, applies an approval decision
+// when approved is set, and takes the next turn
+func (a *Agent) resumeTurn(ctx context.Context, state *RunState, approved *bool) (result *TurnResult, runErr error) {
+	var err error
+
+	ctx, runCtx := ensureRunContext(WithRunContext(ctx, state.RunID))
+	if runCtx != nil && runCtx.UserID == "" && a.UserID != "" {
+		runCtx.UserID = a.UserID
+	}
+	ctx = a.withFlagContext(ctx, runCtx)
+
+	a.Memory.Clear(a.UserID)
+	for _, msg := range state.Messages {
+		a.Memory.Add(msg, a.UserID)
+	}
+	// The blob's session state replaces the agent's, like its conversation
+	if SessionStateFromContext(ctx) == nil && state.SessionState != nil {
+		a.sessionState.replace(state.SessionState)
+	}
+	ctx = a.withSessionState(ctx)
+	ctx, err = a.resolveDependencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if approved != nil {
+		ctx = a.decideApproval(ctx, state, *approved)
+	}
+
+	ctx, endRun := a.beginRun(ctx, state.Input, state.InitialMessageCount)
+	defer func() { endRun(result.finalOutput(), runErr == nil && !result.Done(), runErr) }()
+
+	a.logger.Info("agent run (turn) resumed", "agent_id", a.ID, "run_id", state.RunID, "loops", state.Loops)
+	return a.turn(ctx, runCtx, state, true)
+}
+
+// turn executes pending tool calls and one model turn. A stateless turn that
+// needs a tool approval returns the paused state in its blob; otherwise the
+// agent keeps it for Resume.
+func (a *Agent) turn(ctx context.Context, runCtx *run.RunContext, state *RunState, stateless bool) (*TurnResult, error) {
+	if state.PIIMapping == nil {
+		state.PIIMapping = guardrails.PIIMapping{}
+	}
+	ctx = withPIIMapping(ctx, state.PIIMapping)
+
+	output := &RunOutput{
+		RunID:           state.RunID,
+		Status:          RunStatusRunning,
+		StartedAt:       state.StartedAt,
+		Metadata:        map[string]interface{}{},
+		ToolsExecuted:   state.ToolsExecuted,
+		Usage:           state.Usage,
+		Reproducibility: state.Reproducibility,
+		Grounding:       state.Grounding,
+		Citations:       state.Citations,
+		Warnings:        state.Warnings,
+	}
+
+	if len(state.PendingToolCalls) > 0 {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			cancelled := a.markRunCancelled(output, state.Loops, false, ctxErr, state.InitialMessageCount)
+			return &TurnResult{Output: cancelled}, types.NewCancellationError("agent run cancelled", ctxErr)
+		}
+		a.logger.Info("executing tool calls", "count", len(state.PendingToolCalls))
+		summaries := a.executeToolCalls(ctx, state.PendingToolCalls)
+		output.ToolsExecuted = append(output.ToolsExecuted, summaries...)
+		if hasPendingApprovals(summaries) {
+			if stateless {
+				return a.pauseTurnForApproval(ctx, output, state)
+			}
+			return &TurnResult{Output: a.pauseForApproval(output, state, state.PendingToolCalls)}, nil
+		}
+		state.PendingToolCalls = nil
+	}
+
+	if state.Loops >= state.MaxLoops {
+		a.logger.Warn("max loops reached", "max_loops", state.MaxLoops)
+		return nil, types.NewError(types.ErrCodeUnknown, "max tool calling loops reached", nil)
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		cancelled := a.markRunCancelled(output, state.Loops, false, ctxErr, state.InitialMessageCount)
+		return &TurnResult{Output: cancelled}, types.NewCancellationError("agent run cancelled", ctxErr)
+	}
+	state.Loops++
+
+	messages := a.Memory.GetMessages(a.UserID)
+	if a.instructionsChanged(state.Instructions) {
+		messages = a.updateSystemMessage(messages, state.Instructions)
+	}
+	messages = a.fitContext(ctx, messages)
+
+	req := &models.InvokeRequest{Messages: messages}
+	if len(a.Toolkits) > 0 {
+		req.Tools = a.toolDefinitions(ctx)
+	}
+	if a.responseFormat != nil {
+		req.ResponseFormat = a.responseFormat
+	}
+	req.Seed = a.seed
+	req.ReasoningEffort, req.MaxReasoningTokens = a.reasoningEffort, a.maxReasoningTokens
+	attachRunContextToRequest(ctx, req)
+
+	var resp *types.ModelResponse
+	if state.Grounding.refused() {
+		// Retrieval did not support an answer, so the model is not called.
+		resp = a.refusalResponse()
+	} else {
+		var err error
+		resp, err = a.invokeModel(ctx, req)
+		if err != nil {
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil {
+				cancelled := a.markRunCancelled(output, state.Loops, false, err, state.InitialMessageCount)
+				return &TurnResult{Output: cancelled}, types.NewCancellationError("agent run cancelled", err)
+			}
+			a.logger.Error("model invocation failed", "error", err)
+			return nil, types.NewAPIError("model invocation failed", err)
+		}
+		a.recordUsage(ctx, output, resp)
+	}
+
+	a.Memory.Add(&types.Message{
+		Role:             types.RoleAssistant,
+		Content:          resp.Content,
+		ToolCalls:        resp.ToolCalls,
+		ReasoningContent: a.extractReasoning(ctx, resp),
+	}, a.UserID)
+
+	if resp.HasToolCalls() {
+		state.PendingToolCalls = resp.ToolCalls
+		state.Messages = a.Memory.GetMessages(a.UserID)
+		state.Usage = output.Usage
+		state.ToolsExecuted = output.ToolsExecuted
+		state.SessionState = SessionStateFromContext(ctx).GetAll()
+
+		blob, err := a.encodeRunState(state)
+		if err != nil {
+			return nil, types.NewError(types.ErrCodeUnknown, "failed to encode run state", err)
+		}
+		return &TurnResult{State: blob}, nil
+	}
+
+	return a.completeTurn(ctx, runCtx, state, output, resp)
+}
+
+// pauseTurnForApproval returns the paused output with the state blob to pass
+// to ResumeTurnApproval
+func (a *Agent) pauseTurnForApproval(ctx context.Context, output *RunOutput, state *RunState) (*TurnResult, error) {
+	a.pausedApproval(output, state, state.PendingToolCalls)
+	state.AwaitingApproval = true
+	state.SessionState = SessionStateFromContext(ctx).GetAll()
+
+	blob, err := a.encodeRunState(state)
+	if err != nil {
+		return nil, types.NewError(types.ErrCodeUnknown, "failed to encode run state", err)
+	}
+	return &TurnResult{Output: output, State: blob}, nil
+}
+
+// completeTurn finalizes a stateless run like Run does
+func (a *Agent) completeTurn(ctx context.Context, runCtx *run.RunContext, state *RunState, output *RunOutput, resp *types.ModelResponse) (*TurnResult, error) {
+	finalContent, err := a.enforceFormat(ctx, output, resp.Content, state.Instructions, true)
+	if err != nil {
+		return nil, err
+	}
+	a.recordModelRefusal(output.Grounding, finalContent)
+
+	pii := state.PIIMapping
+	report := guardrails.NewGuardrailReport()
+	for _, f := range state.Findings {
+		report.Add(f)
+	}
+	if len(a.PostHooks) > 0 {
+		hookInput := hooks.NewHookInput(state.Input).
+			WithOutput(finalContent).
+			WithAgentID(a.ID).
+			WithMessages(hookMessages(a.Memory.GetMessages(a.UserID))).
+			WithReport(report)
+		hookInput.Metadata[guardrails.PIIMappingKey] = pii
+
+		if err := hooks.ExecuteHooks(ctx, a.PostHooks, hookInput); err != nil {
+			a.logger.Error("post-hook failed (turn)", "error", err)
+			return nil, types.NewOutputCheckError("post-hook validation failed", err)
+		}
+		finalContent = hookInput.Output
+	}
+
+	finalContent, guardrailResults, err := a.checkGuardrails(ctx, GuardrailStageOutput, a.outputGuardrails, finalContent, hookMessages(a.Memory.GetMessages(a.UserID)), report, pii, a.outputRetry(ctx, output, state.Instructions))
+	if err != nil {
+		return nil, err
+	}
+	output.GuardrailReports = append(append([]GuardrailResult(nil), state.GuardrailResults...), guardrailResults...)
+
+	a.logger.Info("agent run (turn) completed", "agent_id", a.ID, "loops", state.Loops)
+
+	output.Status = RunStatusCompleted
+	output.CompletedAt = time.Now().UTC()
+	a.usage.addRun()
+	output.Content = finalContent
+	if report.HasFindings() {
+		output.GuardrailReport = report
+	}
+	output.Messages = a.Memory.GetMessages(a.UserID)
+	output.Metadata["loops"] = state.Loops
+	output.Metadata["usage"] = output.Usage
+	for k, v := range resp.Metadata.Extra {
+		output.Metadata[k] = v
+	}
+	addRunContextMetadata(output, runCtx)
+
+	sequence := len(output.Events)
+	if resp.Content != "" {
+		output.appendEvent(run.NewRunContentEvent(output.RunID, a.ID, string(types.RoleAssistant), resp.Content, sequence))
+	}
+	output.appendEvent(run.NewRunCompletedEvent(output.RunID, a.ID, "", string(output.Status), resp.Content))
+
+	runMessages := a.messagesSince(state.InitialMessageCount)
+	a.scrubRunOutputWithContext(output, state.InitialMessageCount)
+
+	output.SessionState = SessionStateFromContext(ctx).GetAll()
+	a.persistRunToSession(ctx, output)
+	a.saveRunRecord(ctx, state.Input, output, runMessages)
+
+	return &TurnResult{Output: output}, nil
+}
+
+// requireRunStateKey fails stateless turns on agents without RunStateKey: a
+// plain blob would expose the redacted PII to its holder and let them forge the
+// conversation, the turn budget and the tool calls ResumeTurn executes
+// requireRunStateKey 在未设置 RunStateKey 的代理上拒绝无状态轮次：未加密的数据
+// 会向持有者暴露已脱敏的 PII，并让其伪造对话、轮次预算以及 ResumeTurn 执行的工具调用
+func (a *Agent) requireRunStateKey() error {
+	if len(a.runStateKey) == 0 {
+		return types.NewInvalidConfigError("RunStateKey is required for RunTurn and ResumeTurn", nil)
+	}
+	return nil
+}
+
+// encodeRunState gives state a new turn ID and issue time, then serializes
+// and seals it
+func (a *Agent) encodeRunState(state *RunState) ([]byte, error) {
+	state.TurnID = ids.Prefixed("turn")
+	state.IssuedAt = time.Now().UTC()
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := a.runStateAEAD()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return json.Marshal(runStateEnvelope{Nonce: nonce, State: aead.Seal(nil, nonce, data, nil)})
+}
+
+// DecodeRunState verifies and decrypts a state blob returned by RunTurn or
+// ResumeTurn, e.g. to inspect pending tool calls before resuming
+// DecodeRunState 验证并解密 RunTurn 或 ResumeTurn 返回的状态数据，例如在恢复前检查待处理的工具调用
+func (a *Agent) DecodeRunState(blob []byte) (*RunState, error) {
+	var envelope runStateEnvelope
+	if err := json.Unmarshal(blob, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to decode run state: %w", err)
+	}
+	if len(envelope.State) == 0 {
+		return nil, fmt.Errorf("run state is empty")
+	}
+	if err := a.requireRunStateKey(); err != nil {
+		return nil, err
+	}
+	aead, err := a.runStateAEAD()
+	if err != nil {
+		return nil, err
+	}
+	if len(envelope.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("run state is not sealed or was sealed with another key")
+	}
+	data, err := aead.Open(nil, envelope.Nonce, envelope.State, nil)
+	if err != nil {
+		return nil, fmt.Errorf("run state is not sealed or was sealed with another key")
+	}
+
+	var state RunState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to decode run state: %w", err)
+	}
+	if state.Version != runStateVersion {
+		return nil, fmt.Errorf("unsupported run state version %d", state.Version)
+	}
+	if err := validatePendingToolCalls(&state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// runStateAEAD returns the AES-256-GCM cipher for run state, keyed by an
+// HMAC-SHA256 of RunStateKey so keys of any length can be used
+func (a *Agent) runStateAEAD() (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, a.runStateKey)
+	mac.Write([]byte("agent-go run state"))
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// validatePendingToolCalls checks that pending tool calls are the ones the
+// model requested in the last assistant message
+func validatePendingToolCalls(state *RunState) error {
+	if len(state.PendingToolCalls) == 0 {
+		if state.AwaitingApproval {
+			return fmt.Errorf("run state waits for an approval but has no pending tool calls")
+		}
+		return nil
+	}
+	if len(state.Messages) == 0 {
+		return fmt.Errorf("run state has pending tool calls but no messages")
+	}
+	if state.AwaitingApproval {
+		return validateApprovalToolCalls(state)
+	}
+	last := state.Messages[len(state.Messages)-1]
+	if last == nil || last.Role != types.RoleAssistant || len(last.ToolCalls) != len(state.PendingToolCalls) {
+		return fmt.Errorf("pending tool calls do not match the last assistant message")
+	}
+	for i, tc := range state.PendingToolCalls {
+		requested := last.ToolCalls[i]
+		if tc.ID != requested.ID || tc.Function.Name != requested.Function.Name || tc.Function.Arguments != requested.Function.Arguments {
+			return fmt.Errorf("pending tool call %s does not match the last assistant message", tc.ID)
+		}
+	}
+	return nil
+}
+
+// validateApprovalToolCalls checks that calls waiting for approval were
+// requested in the last assistant message and have not been answered: only
+// the results of the calls that already ran follow that message
+func validateApprovalToolCalls(state *RunState) error {
+	i := len(state.Messages) - 1
+	answered := make(map[string]bool)
+	for ; i >= 0; i-- {
+		msg := state.Messages[i]
+		if msg == nil || msg.Role != types.RoleTool {
+			break
+		}
+		answered[msg.ToolCallID] = true
+	}
+	if i < 0 || state.Messages[i] == nil || state.Messages[i].Role != types.RoleAssistant {
+		return fmt.Errorf("pending tool calls do not match the last assistant message")
+	}
+	requested := make(map[string]types.ToolCall, len(state.Messages[i].ToolCalls))
+	for _, tc := range state.Messages[i].ToolCalls {
+		requested[tc.ID] = tc
+	}
+	for _, tc := range state.PendingToolCalls {
+		req, ok := requested[tc.ID]
+		if !ok || answered[tc.ID] || tc.Function.Name != req.Function.Name || tc.Function.Arguments != req.Function.Arguments {
+			return fmt.Errorf("pending tool call %s does not match the last assistant message", tc.ID)
+		}
+		delete(requested, tc.ID)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/calculator"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// testRunStateKey seals run state in tests that take stateless turns
+var testRunStateKey = []byte("test-run-state-key")
+
+// newTurnAgent builds a fresh agent per turn, as a serverless handler would
+func newTurnAgent(t *testing.T, key []byte) *Agent {
+	t.Helper()
+	model := &MockModel{
+		BaseModel: models.BaseModel{ID: "test", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			last := req.Messages[len(req.Messages)-1]
+			if last.Role == types.RoleTool {
+				return &types.ModelResponse{Content: "The result is " + last.Content, Usage: types.Usage{TotalTokens: 5}}, nil
+			}
+			return &types.ModelResponse{
+				ToolCalls: []types.ToolCall{{
+					ID:       "call_1",
+					Type:     "function",
+					Function: types.ToolCallFunction{Name: "add", Arguments: `{"a": 10, "b": 5}`},
+				}},
+				Usage: types.Usage{TotalTokens: 7},
+			}, nil
+		},
+	}
+	ag, err := New(Config{
+		Name:        "turns",
+		Model:       model,
+		Toolkits:    []toolkit.Toolkit{calculator.New()},
+		RunStateKey: key,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return ag
+}
+
+func TestAgent_RunTurnResume(t *testing.T) {
+	key := []byte("secret")
+	ctx := context.Background()
+
+	first, err := newTurnAgent(t, key).RunTurn(ctx, "What is 10 + 5?")
+	if err != nil {
+		t.Fatalf("RunTurn: %v", err)
+	}
+	if first.Done() || len(first.State) == 0 {
+		t.Fatalf("expected pending state after the first turn, got %+v", first)
+	}
+
+	resumer := newTurnAgent(t, key)
+	state, err := resumer.DecodeRunState(first.State)
+	if err != nil {
+		t.Fatalf("DecodeRunState: %v", err)
+	}
+	if len(state.PendingToolCalls) != 1 || state.Loops != 1 || state.Usage.TotalTokens != 7 {
+		t.Errorf("unexpected state: %+v", state)
+	}
+
+	second, err := resumer.ResumeTurn(ctx, first.State)
+	if err != nil {
+		t.Fatalf("ResumeTurn: %v", err)
+	}
+	if !second.Done() {
+		t.Fatal("expected the run to complete on the second turn")
+	}
+	out := second.Output
+	if out.Status != RunStatusCompleted || !strings.HasPrefix(out.Content, "The result is 15") {
+		t.Errorf("unexpected output: %s %q", out.Status, out.Content)
+	}
+	if out.RunID != state.RunID || out.Usage.TotalTokens != 12 || len(out.ToolsExecuted) != 1 || out.Metadata["loops"] != 2 {
+		t.Errorf("run state not carried over: run_id=%s usage=%d tools=%d loops=%v",
+			out.RunID, out.Usage.TotalTokens, len(out.ToolsExecuted), out.Metadata["loops"])
+	}
+}
+
+func TestAgent_ResumeTurnRejectsTamperedState(t *testing.T) {
+	key := []byte("secret")
+	first, err := newTurnAgent(t, key).RunTurn(context.Background(), "What is 10 + 5?")
+	if err != nil {
+		t.Fatalf("RunTurn: %v", err)
+	}
+
+	var envelope runStateEnvelope
+	_ = json.Unmarshal(first.State, &envelope)
+	envelope.State[len(envelope.State)/2] ^= 1
+	tampered, _ := json.Marshal(envelope)
+
+	if _, err := newTurnAgent(t, key).ResumeTurn(context.Background(), tampered); err == nil {
+		t.Error("expected tampered state to be rejected")
+	}
+	if _, err := newTurnAgent(t, []byte("other")).ResumeTurn(context.Background(), first.State); err == nil {
+		t.Error("expected state sealed with another key to be rejected")
+	}
+}
+
+func TestAgent_ResumeTurnRejectsReplays(t *testing.T) {
+	first, err := newTurnAgent(t, testRunStateKey).RunTurn(context.Background(), "What is 10 + 5?")
+	if err != nil {
+		t.Fatalf("RunTurn: %v", err)
+	}
+
+	// Without ConsumeRunState a blob can be resumed again until it expires
+	expired := newTurnAgent(t, testRunStateKey)
+	expired.runStateTTL = time.Nanosecond
+	if _, err := expired.ResumeTurn(context.Background(), first.State); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("expected an expired blob to be rejected, got %v", err)
+	}
+
+	consumed := map[string]bool{}
+	consume := func(ctx context.Context, turnID string) error {
+		if turnID == "" || consumed[turnID] {
+			return fmt.Errorf("turn %q already consumed", turnID)
+		}
+		consumed[turnID] = true
+		return nil
+	}
+	resumer := newTurnAgent(t, testRunStateKey)
+	resumer.consumeRunState = consume
+	if _, err := resumer.ResumeTurn(context.Background(), first.State); err != nil {
+		t.Fatalf("ResumeTurn: %v", err)
+	}
+	replayer := newTurnAgent(t, testRunStateKey)
+	replayer.consumeRunState = consume
+	if _, err := replayer.ResumeTurn(context.Background(), first.State); err == nil || !strings.Contains(err.Error(), "already consumed") {
+		t.Errorf("expected a replayed blob to be rejected, got %v", err)
+	}
+}
+
+func TestAgent_ResumeTurnMaxLoops(t *testing.T) {
+	ag := newTurnAgent(t, testRunStateKey)
+	ag.MaxLoops = 1
+
+	first, err := ag.RunTurn(context.Background(), "What is 10 + 5?")
+	if err != nil {
+		t.Fatalf("RunTurn: %v", err)
+	}
+
+	resumer := newTurnAgent(t, testRunStateKey)
+	resumer.MaxLoops = 10 // the budget travels with the state
+	if _, err := resumer.ResumeTurn(context.Background(), first.State); err == nil ||
+		!strings.Contains(err.Error(), "max tool calling loops") {
+		t.Errorf("expected max loops error, got %v", err)
+	}
+}
+
+func TestDecodeRunState_PendingToolCallsMustMatch(t *testing.T) {
+	ag := newTurnAgent(t, testRunStateKey)
+	blob, _ := ag.encodeRunState(&RunState{
+		Version:          runStateVersion,
+		Messages:         []*types.Message{types.NewUserMessage("hi")},
+		PendingToolCalls: []types.ToolCall{{ID: "x", Function: types.ToolCallFunction{Name: "add"}}},
+	})
+	if _, err := ag.DecodeRunState(blob); err == nil {
+		t.Error("expected pending tool calls without a matching assistant message to be rejected")
+	}
+}
+
+func TestAgent_RunTurnRequiresKey(t *testing.T) {
+	ag := newTurnAgent(t, nil)
+	if _, err := ag.RunTurn(context.Background(), "What is 10 + 5?"); err == nil || !strings.Contains(err.Error(), "RunStateKey") {
+		t.Fatalf("RunTurn() without a key error = %v", err)
+	}
+
+	// An unsealed blob with forged tool calls is never executed
+	data, _ := json.Marshal(&RunState{
+		Version:          runStateVersion,
+		AgentID:          ag.ID,
+		Messages:         []*types.Message{types.NewUserMessage("hi"), {Role: types.RoleAssistant, ToolCalls: []types.ToolCall{{ID: "x", Function: types.ToolCallFunction{Name: "add"}}}}},
+		PendingToolCalls: []types.ToolCall{{ID: "x", Function: types.ToolCallFunction{Name: "add"}}},
+		MaxLoops:         1000,
+	})
+	blob, _ := json.Marshal(runStateEnvelope{State: data})
+	if _, err := ag.ResumeTurn(context.Background(), blob); err == nil {
+		t.Fatal("expected ResumeTurn() without a key to fail")
+	}
+	if _, err := newTurnAgent(t, testRunStateKey).ResumeTurn(context.Background(), blob); err == nil {
+		t.Fatal("expected an unsealed blob to be rejected")
+	}
+}
@@ -8,7 +8,8 @@ import (
 
 // AggregateResponseStream consumes a stream of ResponseChunk values and
 // reconstructs a single ModelResponse. It concatenates content in arrival
-// order and aggregates any tool calls. If a chunk carries a non-nil Error,
+// order and aggregates any tool calls. Streamed reasoning is concatenated
+// into ReasoningContent so it stays separate from the answer. If a chunk carries a non-nil Error,
 // aggregation stops and the error is returned.
 //
 // This helper is intended for future streaming Agent implementations so that
@@ -36,9 +37,18 @@ func AggregateResponseStream(ctx context.Context, ch <-chan types.ResponseChunk)
 			if chunk.Content != "" {
 				resp.Content += chunk.Content
 			}
+			if chunk.ReasoningContent != "" {
+				if resp.ReasoningContent == nil {
+					resp.ReasoningContent = types.NewReasoningContent("")
+				}
+				resp.ReasoningContent.Content += chunk.ReasoningContent
+			}
 			if len(chunk.ToolCalls) > 0 {
 				resp.ToolCalls = append(resp.ToolCalls, chunk.ToolCalls...)
 			}
+			if chunk.Usage != nil {
+				resp.Usage = *chunk.Usage
+			}
 		}
 	}
 }
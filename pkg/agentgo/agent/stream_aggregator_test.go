@@ -34,6 +34,28 @@ func TestAggregateResponseStream_ConcatenatesContent(t *testing.T) {
 	}
 }
 
+func TestAggregateResponseStream_KeepsReasoningSeparate(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	ch := make(chan types.ResponseChunk, 3)
+	ch <- types.ResponseChunk{ReasoningContent: "2+2 "}
+	ch <- types.ResponseChunk{ReasoningContent: "is 4"}
+	ch <- types.ResponseChunk{Content: "4"}
+	close(ch)
+
+	resp, err := AggregateResponseStream(ctx, ch)
+	if err != nil {
+		t.Fatalf("AggregateResponseStream returned error: %v", err)
+	}
+	if resp.Content != "4" {
+		t.Fatalf("unexpected content: %q", resp.Content)
+	}
+	if resp.ReasoningContent == nil || resp.ReasoningContent.Content != "2+2 is 4" {
+		t.Fatalf("unexpected reasoning: %+v", resp.ReasoningContent)
+	}
+}
+
 func TestAggregateResponseStream_AggregatesToolCalls(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
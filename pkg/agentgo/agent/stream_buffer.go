@@ -0,0 +1,249 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/run"
+)
+
+// StreamOverflow is what a streaming run does when its consumer reads events
+// slower than the model produces them
+// StreamOverflow 决定消费者读取事件慢于模型生成速度时流式运行的行为
+type StreamOverflow string
+
+const (
+	// StreamOverflowBlock waits for the consumer (default). A slow consumer
+	// slows the model stream down.
+	// StreamOverflowBlock 等待消费者（默认）。慢消费者会拖慢模型流。
+	StreamOverflowBlock StreamOverflow = "block"
+
+	// StreamOverflowDropOldest discards the oldest buffered delta when the
+	// buffer is full. Sequence numbers of the delivered events show the gaps.
+	// StreamOverflowDropOldest 在缓冲区满时丢弃最旧的增量，已投递事件的序号会体现缺口。
+	StreamOverflowDropOldest StreamOverflow = "drop_oldest"
+
+	// StreamOverflowCoalesce appends new deltas to the newest buffered one when
+	// the buffer is full, so no content is lost but events get larger.
+	// StreamOverflowCoalesce 在缓冲区满时将新增量合并到最新的缓冲事件中，内容不会丢失但事件会变大。
+	StreamOverflowCoalesce StreamOverflow = "coalesce"
+)
+
+// defaultStreamBuffer is the buffer size of the non-blocking policies
+const defaultStreamBuffer = 64
+
+// StreamOptions configures how RunStream delivers events to a slow consumer.
+// The complete output is always available on RunStreamDone, whatever was
+// dropped or coalesced along the way.
+// StreamOptions 配置 RunStream 如何向慢消费者投递事件。无论途中丢弃或合并了什么，
+// 完整输出始终可以在 RunStreamDone 中获取。
+type StreamOptions struct {
+	// Overflow is the policy applied when the consumer falls behind (default: StreamOverflowBlock)
+	// Overflow 是消费者落后时应用的策略（默认：StreamOverflowBlock）
+	Overflow StreamOverflow
+
+	// Buffer is the number of events queued ahead of the consumer
+	// (default: 0 for StreamOverflowBlock, 64 otherwise)
+	// Buffer 是在消费者之前排队的事件数（默认：StreamOverflowBlock 为 0，其他为 64）
+	Buffer int
+
+	// SendTimeout bounds the wait for the consumer to take one event; the event
+	// is dropped once it elapses (0 = wait as long as the run lasts)
+	// SendTimeout 限制等待消费者接收单个事件的时间，超时后丢弃该事件（0 = 在运行期间一直等待）
+	SendTimeout time.Duration
+}
+
+type streamOptionsKey struct{}
+
+// WithStreamOptions overrides the agent's StreamOptions for the RunStream
+// calls made with the returned context, e.g. per client connection
+// WithStreamOptions 为使用返回的 context 发起的 RunStream 调用覆盖代理的 StreamOptions，例如按客户端连接设置
+func WithStreamOptions(ctx context.Context, opts StreamOptions) context.Context {
+	return context.WithValue(ctx, streamOptionsKey{}, opts)
+}
+
+// streamOptions returns the options for a run: the context override, else the agent's
+func (a *Agent) streamOptions(ctx context.Context) StreamOptions {
+	if opts, ok := ctx.Value(streamOptionsKey{}).(StreamOptions); ok {
+		return opts
+	}
+	return a.streamOpts
+}
+
+// streamSender delivers the events of a streaming run to its consumer without
+// letting the consumer stall the model stream beyond what the options allow.
+// With a non-blocking policy a pump goroutine moves events from a bounded
+// queue to the output channel.
+type streamSender struct {
+	out  chan run.BaseRunOutputEvent
+	opts StreamOptions
+
+	mu        sync.Mutex
+	queue     []run.BaseRunOutputEvent
+	closing   bool
+	wake      chan struct{}
+	drained   chan struct{}
+	closeOnce sync.Once
+	dropped   int
+	coalesced int
+}
+
+func newStreamSender(ctx context.Context, opts StreamOptions) *streamSender {
+	if opts.Overflow == "" {
+		opts.Overflow = StreamOverflowBlock
+	}
+	if opts.Overflow != StreamOverflowBlock && opts.Buffer <= 0 {
+		opts.Buffer = defaultStreamBuffer
+	}
+
+	s := &streamSender{
+		out:     make(chan run.BaseRunOutputEvent),
+		opts:    opts,
+		wake:    make(chan struct{}, 1),
+		drained: make(chan struct{}),
+	}
+	if opts.Overflow == StreamOverflowBlock && opts.Buffer > 0 {
+		s.out = make(chan run.BaseRunOutputEvent, opts.Buffer)
+	}
+	if opts.Overflow != StreamOverflowBlock {
+		go s.pump(ctx)
+	}
+	return s
+}
+
+// send hands evt to the consumer. It returns false when ctx is done.
+func (s *streamSender) send(ctx context.Context, evt run.BaseRunOutputEvent) bool {
+	if s.opts.Overflow == StreamOverflowBlock {
+		return s.deliver(ctx, evt)
+	}
+
+	s.mu.Lock()
+	if len(s.queue) >= s.opts.Buffer {
+		switch s.opts.Overflow {
+		case StreamOverflowCoalesce:
+			if s.coalesce(evt) {
+				s.mu.Unlock()
+				return ctx.Err() == nil
+			}
+			fallthrough
+		default:
+			s.queue = s.queue[1:]
+			s.dropped++
+		}
+	}
+	s.queue = append(s.queue, evt)
+	s.mu.Unlock()
+	s.signal()
+	return ctx.Err() == nil
+}
+
+// coalesce appends a content or reasoning delta to the newest queued one of
+// the same kind; mu must be held
+func (s *streamSender) coalesce(evt run.BaseRunOutputEvent) bool {
+	if len(s.queue) == 0 {
+		return false
+	}
+	switch next := evt.(type) {
+	case *run.RunContentEvent:
+		last, ok := s.queue[len(s.queue)-1].(*run.RunContentEvent)
+		if !ok || last.Role != next.Role || last.AgentID != next.AgentID {
+			return false
+		}
+		merged := *last
+		merged.Content += next.Content
+		s.queue[len(s.queue)-1] = &merged
+	case *run.RunReasoningEvent:
+		last, ok := s.queue[len(s.queue)-1].(*run.RunReasoningEvent)
+		if !ok || last.AgentID != next.AgentID {
+			return false
+		}
+		merged := *last
+		merged.Content += next.Content
+		s.queue[len(s.queue)-1] = &merged
+	default:
+		return false
+	}
+	s.coalesced++
+	return true
+}
+
+// deliver sends evt on the output channel, dropping it after SendTimeout
+func (s *streamSender) deliver(ctx context.Context, evt run.BaseRunOutputEvent) bool {
+	var timeout <-chan time.Time
+	if s.opts.SendTimeout > 0 {
+		timer := time.NewTimer(s.opts.SendTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case s.out <- evt:
+		return true
+	case <-timeout:
+		s.mu.Lock()
+		s.dropped++
+		s.mu.Unlock()
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (s *streamSender) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// pump moves queued events to the consumer until the sender is closed and
+// the queue is empty, or ctx is done
+func (s *streamSender) pump(ctx context.Context) {
+	defer close(s.drained)
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			closing := s.closing
+			s.mu.Unlock()
+			if closing {
+				return
+			}
+			select {
+			case <-s.wake:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+		evt := s.queue[0]
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+
+		if !s.deliver(ctx, evt) {
+			return
+		}
+	}
+}
+
+// close waits for queued events to reach the consumer, then closes the
+// output channel. It must be called before the run's result is sent, so
+// consumers see every delivered event before RunStreamDone.
+func (s *streamSender) close() {
+	s.closeOnce.Do(func() {
+		if s.opts.Overflow != StreamOverflowBlock {
+			s.mu.Lock()
+			s.closing = true
+			s.mu.Unlock()
+			s.signal()
+			<-s.drained
+		}
+		close(s.out)
+	})
+}
+
+// stats returns the number of dropped and coalesced events
+func (s *streamSender) stats() (dropped, coalesced int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped, s.coalesced
+}
@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/run"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// newChattyAgent streams n one-character chunks and closes produced once the
+// model has handed over every chunk
+func newChattyAgent(t *testing.T, n int, opts StreamOptions) (*Agent, <-chan struct{}) {
+	t.Helper()
+	produced := make(chan struct{})
+	ag, err := New(Config{
+		Name: "chatty",
+		Model: &MockModel{
+			BaseModel: models.BaseModel{ID: "test", Provider: "mock"},
+			InvokeStreamFunc: func(ctx context.Context, req *models.InvokeRequest) (<-chan types.ResponseChunk, error) {
+				ch := make(chan types.ResponseChunk)
+				go func() {
+					defer close(ch)
+					for i := 0; i < n; i++ {
+						ch <- types.ResponseChunk{Content: "x"}
+					}
+					close(produced)
+				}()
+				return ch, nil
+			},
+		},
+		StreamOptions: opts,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return ag, produced
+}
+
+// streamWithoutReading starts a run and waits for the model to finish
+// streaming before the consumer reads a single event
+func streamWithoutReading(t *testing.T, ag *Agent, produced <-chan struct{}) (string, int, RunStreamDone) {
+	t.Helper()
+	result, err := ag.RunStream(context.Background(), "talk")
+	if err != nil {
+		t.Fatalf("RunStream() error = %v", err)
+	}
+
+	select {
+	case <-produced:
+	case <-time.After(2 * time.Second):
+		t.Fatal("slow consumer stalled the model stream")
+	}
+
+	var content strings.Builder
+	events := 0
+	for evt := range result.Events {
+		if contentEvt, ok := evt.(*run.RunContentEvent); ok {
+			content.WriteString(contentEvt.Content)
+			events++
+		}
+	}
+	done := <-result.Done
+	if done.Err != nil {
+		t.Fatalf("RunStream() Done error = %v", done.Err)
+	}
+	return content.String(), events, done
+}
+
+func TestRunStream_CoalescesForSlowConsumer(t *testing.T) {
+	ag, produced := newChattyAgent(t, 200, StreamOptions{Overflow: StreamOverflowCoalesce, Buffer: 4})
+
+	content, events, done := streamWithoutReading(t, ag, produced)
+
+	if want := strings.Repeat("x", 200); content != want || done.Output.Content != want {
+		t.Fatalf("coalescing lost content: streamed %d bytes, output %d bytes", len(content), len(done.Output.Content))
+	}
+	if events >= 200 {
+		t.Errorf("expected deltas to be merged, got %d events", events)
+	}
+	if coalesced, _ := done.Output.Metadata["stream_coalesced"].(int); coalesced == 0 {
+		t.Errorf("stream_coalesced not reported: %v", done.Output.Metadata)
+	}
+}
+
+func TestRunStream_DropOldestForSlowConsumer(t *testing.T) {
+	ag, produced := newChattyAgent(t, 200, StreamOptions{Overflow: StreamOverflowDropOldest, Buffer: 4})
+
+	content, events, done := streamWithoutReading(t, ag, produced)
+
+	if events > 6 {
+		t.Errorf("expected at most buffer + in-flight events, got %d", events)
+	}
+	if len(content) >= 200 {
+		t.Errorf("expected deltas to be dropped, streamed %d bytes", len(content))
+	}
+	if done.Output.Content != strings.Repeat("x", 200) {
+		t.Errorf("final output must stay complete, got %d bytes", len(done.Output.Content))
+	}
+	if dropped, _ := done.Output.Metadata["stream_dropped"].(int); dropped == 0 {
+		t.Errorf("stream_dropped not reported: %v", done.Output.Metadata)
+	}
+}
+
+func TestRunStream_SendTimeoutDropsEvents(t *testing.T) {
+	ag, produced := newChattyAgent(t, 5, StreamOptions{SendTimeout: 5 * time.Millisecond})
+
+	_, _, done := streamWithoutReading(t, ag, produced)
+
+	if dropped, _ := done.Output.Metadata["stream_dropped"].(int); dropped == 0 {
+		t.Errorf("stream_dropped not reported: %v", done.Output.Metadata)
+	}
+}
+
+func TestWithStreamOptions_OverridesAgentDefault(t *testing.T) {
+	ag, _ := newChattyAgent(t, 1, StreamOptions{Overflow: StreamOverflowCoalesce})
+
+	if got := ag.streamOptions(context.Background()).Overflow; got != StreamOverflowCoalesce {
+		t.Errorf("default overflow = %q", got)
+	}
+	ctx := WithStreamOptions(context.Background(), StreamOptions{Overflow: StreamOverflowDropOldest})
+	if got := ag.streamOptions(ctx).Overflow; got != StreamOverflowDropOldest {
+		t.Errorf("override overflow = %q", got)
+	}
+
+	if _, err := New(Config{Model: &MockModel{}, StreamOptions: StreamOptions{Overflow: "spill"}}); err == nil {
+		t.Error("expected an error for an unknown overflow policy")
+	}
+}
@@ -1,6 +1,8 @@
 package agent
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/jholhewres/agent-go/pkg/agentgo/hooks"
@@ -20,8 +22,25 @@ const (
 	// ToolExecutionStatusBlocked indicates execution was blocked by a pre-hook
 	// ToolExecutionStatusBlocked 表示执行被前置钩子阻止
 	ToolExecutionStatusBlocked ToolExecutionStatus = "blocked"
+	// ToolExecutionStatusPendingApproval indicates the call waits for a human decision
+	// ToolExecutionStatusPendingApproval 表示调用正在等待人工决定
+	ToolExecutionStatusPendingApproval ToolExecutionStatus = "pending_approval"
+	// ToolExecutionStatusTimeout indicates the tool was cancelled after its timeout
+	// ToolExecutionStatusTimeout 表示工具在超时后被取消
+	ToolExecutionStatusTimeout ToolExecutionStatus = "timeout"
 )
 
+// ToolTimeoutError is the error of a tool call cancelled after its timeout
+// ToolTimeoutError 是超时后被取消的工具调用的错误
+type ToolTimeoutError struct {
+	FunctionName string
+	Timeout      time.Duration
+}
+
+func (e *ToolTimeoutError) Error() string {
+	return fmt.Sprintf("tool %s timed out after %s", e.FunctionName, e.Timeout)
+}
+
 // ToolExecutionSummary captures details about a single tool execution.
 // ToolExecutionSummary 捕获单个工具执行的详细信息。
 type ToolExecutionSummary struct {
@@ -45,8 +64,8 @@ type ToolExecutionSummary struct {
 	// Error 包含执行失败时的错误消息
 	Error string `json:"error,omitempty"`
 
-	// Status indicates success, failure, or blocked
-	// Status 指示成功、失败或阻止
+	// Status indicates success, failure, timeout, or blocked
+	// Status 指示成功、失败、超时或阻止
 	Status ToolExecutionStatus `json:"status"`
 
 	// StartTime is when execution began
@@ -81,14 +100,19 @@ func NewToolExecutionSummary(input *hooks.ToolHookInput, result interface{}, err
 
 	// Copy metadata from hook input
 	// 从钩子输入复制元数据
-	for k, v := range input.Metadata {
+	for k, v := range input.MetadataSnapshot() {
 		summary.Metadata[k] = v
 	}
 
-	if err != nil {
+	var timeout *ToolTimeoutError
+	switch {
+	case errors.As(err, &timeout):
+		summary.Status = ToolExecutionStatusTimeout
+		summary.Error = err.Error()
+	case err != nil:
 		summary.Status = ToolExecutionStatusFailed
 		summary.Error = err.Error()
-	} else {
+	default:
 		summary.Status = ToolExecutionStatusSuccess
 		summary.Result = result
 	}
@@ -113,7 +137,7 @@ func NewBlockedToolExecutionSummary(input *hooks.ToolHookInput, blockErr error)
 
 	// Copy metadata from hook input
 	// 从钩子输入复制元数据
-	for k, v := range input.Metadata {
+	for k, v := range input.MetadataSnapshot() {
 		summary.Metadata[k] = v
 	}
 
@@ -132,6 +156,18 @@ func (s *ToolExecutionSummary) IsFailed() bool {
 	return s.Status == ToolExecutionStatusFailed
 }
 
+// IsTimedOut returns true if execution was cancelled after its timeout.
+// IsTimedOut 如果执行在超时后被取消，则返回 true。
+func (s *ToolExecutionSummary) IsTimedOut() bool {
+	return s.Status == ToolExecutionStatusTimeout
+}
+
+// IsPendingApproval returns true if execution waits for approval.
+// IsPendingApproval 如果执行正在等待审批，则返回 true。
+func (s *ToolExecutionSummary) IsPendingApproval() bool {
+	return s.Status == ToolExecutionStatusPendingApproval
+}
+
 // IsBlocked returns true if execution was blocked.
 // IsBlocked 如果执行被阻止，则返回 true。
 func (s *ToolExecutionSummary) IsBlocked() bool {
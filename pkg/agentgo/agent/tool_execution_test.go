@@ -0,0 +1,334 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/hooks"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// correlatingHook records the input passed to pre hooks and checks that post
+// hooks and tools see the same instance, as rate limiters and metrics rely on.
+type correlatingHook struct {
+	pre    sync.Map // ToolCallID -> *hooks.ToolHookInput
+	active int32
+}
+
+func (h *correlatingHook) OnToolPre(ctx context.Context, input *hooks.ToolHookInput) error {
+	h.pre.Store(input.ToolCallID, input)
+	input.SetMetadata("started", time.Now())
+	return nil
+}
+
+func (h *correlatingHook) OnToolPost(ctx context.Context, input *hooks.ToolHookInput) error {
+	pre, ok := h.pre.Load(input.ToolCallID)
+	if !ok || pre.(*hooks.ToolHookInput) != input {
+		return fmt.Errorf("post hook for %s got a different input", input.ToolCallID)
+	}
+	if _, ok := input.GetMetadata("started"); !ok {
+		return fmt.Errorf("metadata from the pre hook lost for %s", input.ToolCallID)
+	}
+	input.SetMetadata("correlated", true)
+	return nil
+}
+
+func TestAgent_ParallelToolCalls(t *testing.T) {
+	hook := &correlatingHook{}
+	release := make(chan struct{})
+	var seen sync.Map
+
+	tk := toolkit.NewBaseToolkit("slow")
+	tk.RegisterFunction(&toolkit.Function{
+		Name:       "wait",
+		Parameters: map[string]toolkit.Parameter{"n": {Type: "number", Required: true}},
+		Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			input := hooks.ToolHookInputFromContext(ctx)
+			if input == nil {
+				return nil, fmt.Errorf("no tool hook input on context")
+			}
+			seen.Store(input.ToolCallID, input)
+			if n := atomic.AddInt32(&hook.active, 1); n == 3 {
+				close(release)
+			}
+			select {
+			case <-release:
+			case <-time.After(2 * time.Second):
+				return nil, fmt.Errorf("tool calls did not run in parallel")
+			}
+			return args["n"], nil
+		},
+	})
+
+	calls := 0
+	model := &MockModel{
+		BaseModel: models.BaseModel{ID: "test", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			calls++
+			if calls > 1 {
+				return &types.ModelResponse{Content: "done"}, nil
+			}
+			var toolCalls []types.ToolCall
+			for i := 1; i <= 3; i++ {
+				toolCalls = append(toolCalls, types.ToolCall{
+					ID:       fmt.Sprintf("call-%d", i),
+					Type:     "function",
+					Function: types.ToolCallFunction{Name: "wait", Arguments: fmt.Sprintf(`{"n": %d}`, i)},
+				})
+			}
+			return &types.ModelResponse{ToolCalls: toolCalls}, nil
+		},
+	}
+
+	ag, err := New(Config{
+		Model:                model,
+		Toolkits:             []toolkit.Toolkit{tk},
+		ToolHooks:            []hooks.ToolHook{hook},
+		MaxParallelToolCalls: 3,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	out, err := ag.Run(context.Background(), "wait three times")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(out.ToolsExecuted) != 3 {
+		t.Fatalf("ToolsExecuted = %+v", out.ToolsExecuted)
+	}
+	for i, summary := range out.ToolsExecuted {
+		id := fmt.Sprintf("call-%d", i+1)
+		if summary.ToolCallID != id || !summary.IsSuccess() || summary.Metadata["correlated"] != true {
+			t.Errorf("summary %d = %+v", i, summary)
+		}
+		pre, _ := hook.pre.Load(id)
+		tool, _ := seen.Load(id)
+		if pre == nil || pre != tool {
+			t.Errorf("%s: tool saw a different input than the hooks", id)
+		}
+	}
+}
+
+// toolCallModel requests the given tool calls once, then answers "done".
+func toolCallModel(toolCalls ...types.ToolCall) *MockModel {
+	calls := 0
+	return &MockModel{
+		BaseModel: models.BaseModel{ID: "test", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			calls++
+			if calls > 1 {
+				return &types.ModelResponse{Content: "done"}, nil
+			}
+			return &types.ModelResponse{ToolCalls: toolCalls}, nil
+		},
+	}
+}
+
+func TestAgent_MaxParallelToolCallsLimitsWorkers(t *testing.T) {
+	var active, peak int32
+	tk := toolkit.NewBaseToolkit("slow")
+	tk.RegisterFunction(&toolkit.Function{
+		Name:       "echo",
+		Parameters: map[string]toolkit.Parameter{"n": {Type: "number", Required: true}},
+		Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			n := atomic.AddInt32(&active, 1)
+			defer atomic.AddInt32(&active, -1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			return args["n"], nil
+		},
+	})
+
+	var toolCalls []types.ToolCall
+	for i := 1; i <= 6; i++ {
+		toolCalls = append(toolCalls, types.ToolCall{
+			ID:       fmt.Sprintf("call-%d", i),
+			Type:     "function",
+			Function: types.ToolCallFunction{Name: "echo", Arguments: fmt.Sprintf(`{"n": %d}`, i)},
+		})
+	}
+
+	ag, err := New(Config{
+		Model:                toolCallModel(toolCalls...),
+		Toolkits:             []toolkit.Toolkit{tk},
+		MaxParallelToolCalls: 2,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	out, err := ag.Run(context.Background(), "echo six times")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if p := atomic.LoadInt32(&peak); p != 2 {
+		t.Errorf("peak concurrency = %d, want 2", p)
+	}
+	for i, summary := range out.ToolsExecuted {
+		if want := fmt.Sprintf("call-%d", i+1); summary.ToolCallID != want {
+			t.Errorf("summary %d is %s, want %s", i, summary.ToolCallID, want)
+		}
+	}
+
+	// Tool messages are stored in the order of the calls.
+	var ids []string
+	for _, msg := range ag.Memory.GetMessages(ag.UserID) {
+		if msg.Role == types.RoleTool {
+			ids = append(ids, msg.ToolCallID)
+		}
+	}
+	if len(ids) != 6 {
+		t.Fatalf("tool messages = %v", ids)
+	}
+	for i, id := range ids {
+		if want := fmt.Sprintf("call-%d", i+1); id != want {
+			t.Errorf("tool message %d is %s, want %s", i, id, want)
+		}
+	}
+}
+
+func TestAgent_ToolCallTimeout(t *testing.T) {
+	tk := toolkit.NewBaseToolkit("slow")
+	tk.RegisterFunction(&toolkit.Function{
+		Name: "hang",
+		Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	})
+	tk.RegisterFunction(&toolkit.Function{
+		Name: "ignore",
+		// Ignores its context; the per-function timeout still applies.
+		Timeout: 20 * time.Millisecond,
+		Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			time.Sleep(time.Second)
+			return "late", nil
+		},
+	})
+	tk.RegisterFunction(&toolkit.Function{
+		Name: "quick",
+		Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			return "ok", nil
+		},
+	})
+
+	ag, err := New(Config{
+		Model: toolCallModel(
+			types.ToolCall{ID: "call-1", Type: "function", Function: types.ToolCallFunction{Name: "hang", Arguments: "{}"}},
+			types.ToolCall{ID: "call-2", Type: "function", Function: types.ToolCallFunction{Name: "ignore", Arguments: "{}"}},
+			types.ToolCall{ID: "call-3", Type: "function", Function: types.ToolCallFunction{Name: "quick", Arguments: "{}"}},
+		),
+		Toolkits:             []toolkit.Toolkit{tk},
+		MaxParallelToolCalls: 3,
+		DefaultToolTimeout:   20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	start := time.Now()
+	out, err := ag.Run(context.Background(), "call the tools")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Run took %s, timeouts were not applied", elapsed)
+	}
+	if out.Content != "done" || len(out.ToolsExecuted) != 3 {
+		t.Fatalf("output = %+v", out)
+	}
+	for _, i := range []int{0, 1} {
+		summary := out.ToolsExecuted[i]
+		if !summary.IsTimedOut() || summary.Error != "tool "+summary.FunctionName+" timed out after 20ms" {
+			t.Errorf("%s: summary = %+v", summary.FunctionName, summary)
+		}
+	}
+	if !out.ToolsExecuted[2].IsSuccess() {
+		t.Errorf("quick: summary = %+v", out.ToolsExecuted[2])
+	}
+}
+
+func TestAgent_ToolCallCancelledWithRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	tk := toolkit.NewBaseToolkit("slow")
+	tk.RegisterFunction(&toolkit.Function{
+		Name: "hang",
+		Handler: func(toolCtx context.Context, args map[string]interface{}) (interface{}, error) {
+			cancel()
+			<-toolCtx.Done()
+			return nil, toolCtx.Err()
+		},
+	})
+
+	ag, err := New(Config{
+		Model:              toolCallModel(types.ToolCall{ID: "call-1", Type: "function", Function: types.ToolCallFunction{Name: "hang", Arguments: "{}"}}),
+		Toolkits:           []toolkit.Toolkit{tk},
+		DefaultToolTimeout: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	out, err := ag.Run(ctx, "call the tool")
+	if err == nil {
+		if len(out.ToolsExecuted) != 1 || !out.ToolsExecuted[0].IsFailed() {
+			t.Fatalf("ToolsExecuted = %+v", out.ToolsExecuted)
+		}
+	}
+}
+
+func TestAgent_PreHookSkipsExecution(t *testing.T) {
+	executed := 0
+	tk := toolkit.NewBaseToolkit("weather")
+	tk.RegisterFunction(&toolkit.Function{
+		Name: "weather",
+		Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			executed++
+			return "fresh", nil
+		},
+	})
+	var postResult interface{}
+	skip := hooks.ToolHookFunc(func(ctx context.Context, input *hooks.ToolHookInput) error {
+		if input.IsPre() {
+			input.SkipExecution("cached")
+		} else {
+			postResult = input.Result
+		}
+		return nil
+	})
+
+	ag, err := New(Config{
+		Model:     toolCallModel(types.ToolCall{ID: "call-1", Type: "function", Function: types.ToolCallFunction{Name: "weather", Arguments: "{}"}}),
+		Toolkits:  []toolkit.Toolkit{tk},
+		ToolHooks: []hooks.ToolHook{skip},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	out, err := ag.Run(context.Background(), "weather?")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if executed != 0 {
+		t.Errorf("tool executed %d times", executed)
+	}
+	if len(out.ToolsExecuted) != 1 || !out.ToolsExecuted[0].IsSuccess() || out.ToolsExecuted[0].Result != "cached" {
+		t.Errorf("ToolsExecuted = %+v", out.ToolsExecuted)
+	}
+	if postResult != "cached" {
+		t.Errorf("post hook saw %v", postResult)
+	}
+}
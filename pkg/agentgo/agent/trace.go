@@ -0,0 +1,238 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/storage"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// runTraceVersion is bumped when RunTrace changes incompatibly
+const runTraceVersion = 1
+
+// TraceMetadataKey is the RunRecord metadata key under which SessionStorage
+// keeps the trace of a run
+// TraceMetadataKey 是 SessionStorage 在 RunRecord 元数据中保存运行追踪所用的键
+const TraceMetadataKey = "trace"
+
+// ErrNoTrace is returned by TraceFromRecord when a run was stored without a trace
+// ErrNoTrace 表示运行存储时没有追踪
+var ErrNoTrace = errors.New("run record has no trace")
+
+// RunTrace is the complete record of one run: the conversation it started
+// from, every request sent to the model with its response, every tool call
+// with its input and output, and timings. Set Config.CaptureTraces to get it
+// in RunOutput.Trace and in SessionStorage; Replay re-executes it.
+// RunTrace 是一次运行的完整记录：运行开始时的对话、发送给模型的每个请求及其响应、
+// 每次工具调用的输入和输出以及耗时。设置 Config.CaptureTraces 后可在 RunOutput.Trace 和
+// SessionStorage 中获得；Replay 可重新执行它。
+type RunTrace struct {
+	Version         int              `json:"version"`
+	RunID           string           `json:"run_id"`
+	AgentID         string           `json:"agent_id"`
+	SessionID       string           `json:"session_id,omitempty"`
+	UserID          string           `json:"user_id,omitempty"`
+	Input           string           `json:"input"`
+	History         []*types.Message `json:"history,omitempty"`     // Conversation before the run, without system messages / 运行前的对话（不含系统消息）
+	ModelCalls      []ModelCallTrace `json:"model_calls,omitempty"` // In call order / 按调用顺序
+	ToolCalls       []ToolCallTrace  `json:"tool_calls,omitempty"`  // In completion order / 按完成顺序
+	Output          string           `json:"output"`
+	Status          string           `json:"status"`
+	Error           string           `json:"error,omitempty"`
+	Usage           types.Usage      `json:"usage"`
+	Reproducibility *Reproducibility `json:"reproducibility,omitempty"`
+	StartedAt       time.Time        `json:"started_at"`
+	CompletedAt     time.Time        `json:"completed_at"`
+}
+
+// ModelCallTrace is one model request and its response
+// ModelCallTrace 是一次模型请求及其响应
+type ModelCallTrace struct {
+	Provider  string               `json:"provider"`
+	Model     string               `json:"model"`
+	Messages  []*types.Message     `json:"messages"`        // Messages sent / 发送的消息
+	Tools     []string             `json:"tools,omitempty"` // Names of the tools offered / 提供的工具名称
+	Stream    bool                 `json:"stream,omitempty"`
+	Response  *types.ModelResponse `json:"response,omitempty"` // Aggregated for streams / 流式调用为聚合后的响应
+	Error     string               `json:"error,omitempty"`
+	StartedAt time.Time            `json:"started_at"`
+	Duration  time.Duration        `json:"duration"`
+}
+
+// ToolCallTrace is one tool call with what the tool returned to the model
+// ToolCallTrace 是一次工具调用及工具返回给模型的内容
+type ToolCallTrace struct {
+	ToolCallID string                 `json:"tool_call_id"`
+	Name       string                 `json:"name"`
+	Arguments  map[string]interface{} `json:"arguments,omitempty"`
+	Output     string                 `json:"output,omitempty"` // Tool message sent to the model / 发送给模型的工具消息
+	Error      string                 `json:"error,omitempty"`
+	Status     string                 `json:"status"`
+	Replayed   bool                   `json:"replayed,omitempty"` // Output taken from the replayed trace / 输出取自被重放的追踪
+	StartedAt  time.Time              `json:"started_at"`
+	Duration   time.Duration          `json:"duration"`
+}
+
+// traceRecorder collects the trace of a run; a nil recorder records nothing
+type traceRecorder struct {
+	mu     sync.Mutex
+	trace  RunTrace
+	replay *traceReplay // Set when the run replays a trace / 重放追踪时设置
+}
+
+type traceRecorderKey struct{}
+
+// traceRecorderFrom returns the recorder of the run of ctx, or nil
+func traceRecorderFrom(ctx context.Context) *traceRecorder {
+	recorder, _ := ctx.Value(traceRecorderKey{}).(*traceRecorder)
+	return recorder
+}
+
+// startTrace starts recording the trace of a run when traces are captured or
+// the run replays one. Runs nested in the run, such as agents used as tools,
+// do not record into its trace.
+// startTrace 在捕获追踪或运行重放追踪时开始记录运行追踪；嵌套在运行中的运行（例如作为工具的代理）不会记录到其追踪中。
+func (a *Agent) startTrace(ctx context.Context, input string, initialMessages int) (context.Context, *traceRecorder) {
+	replay := pendingReplayFrom(ctx)
+	if !a.captureTraces && replay == nil {
+		if traceRecorderFrom(ctx) != nil {
+			ctx = context.WithValue(ctx, traceRecorderKey{}, (*traceRecorder)(nil))
+		}
+		return ctx, nil
+	}
+
+	header := a.eventHeader(ctx)
+	recorder := &traceRecorder{
+		replay: replay,
+		trace: RunTrace{
+			Version:   runTraceVersion,
+			RunID:     header.RunID,
+			AgentID:   a.ID,
+			SessionID: header.SessionID,
+			UserID:    a.UserID,
+			Input:     input,
+			StartedAt: time.Now().UTC(),
+		},
+	}
+	messages := a.Memory.GetMessages(a.UserID)
+	if initialMessages >= 0 && initialMessages < len(messages) {
+		messages = messages[:initialMessages]
+	}
+	for _, msg := range messages {
+		if msg != nil && msg.Role != types.RoleSystem {
+			recorder.trace.History = append(recorder.trace.History, msg)
+		}
+	}
+
+	ctx = context.WithValue(ctx, traceRecorderKey{}, recorder)
+	if replay != nil {
+		ctx = withPendingReplay(ctx, nil)
+	}
+	return ctx, recorder
+}
+
+// addModelCall records a model request and its response
+func (r *traceRecorder) addModelCall(provider, model string, req *models.InvokeRequest, stream bool, resp *types.ModelResponse, err error, start time.Time, duration time.Duration) {
+	if r == nil {
+		return
+	}
+	call := ModelCallTrace{
+		Provider:  provider,
+		Model:     model,
+		Messages:  append([]*types.Message(nil), req.Messages...),
+		Stream:    stream,
+		Response:  resp,
+		StartedAt: start.UTC(),
+		Duration:  duration,
+	}
+	for _, tool := range req.Tools {
+		call.Tools = append(call.Tools, tool.Function.Name)
+	}
+	if err != nil {
+		call.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.trace.ModelCalls = append(r.trace.ModelCalls, call)
+}
+
+// addToolCall records a tool call and the message returned to the model
+func (r *traceRecorder) addToolCall(summary *ToolExecutionSummary, msg *types.Message) {
+	if r == nil {
+		return
+	}
+	call := ToolCallTrace{
+		ToolCallID: summary.ToolCallID,
+		Name:       summary.FunctionName,
+		Arguments:  summary.Arguments,
+		Error:      summary.Error,
+		Status:     string(summary.Status),
+		StartedAt:  summary.StartTime.UTC(),
+		Duration:   summary.EndTime.Sub(summary.StartTime),
+	}
+	if msg != nil {
+		call.Output = msg.Content
+	}
+	call.Replayed, _ = summary.Metadata[replayedMetadataKey].(bool)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.trace.ToolCalls = append(r.trace.ToolCalls, call)
+}
+
+// build returns the trace of the run that produced output
+func (r *traceRecorder) build(output *RunOutput, err error) *RunTrace {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	trace := r.trace
+	trace.History = append([]*types.Message(nil), r.trace.History...)
+	trace.ModelCalls = append([]ModelCallTrace(nil), r.trace.ModelCalls...)
+	trace.ToolCalls = append([]ToolCallTrace(nil), r.trace.ToolCalls...)
+	trace.Output = output.Content
+	trace.Status = string(output.Status)
+	trace.Usage = output.Usage
+	trace.Reproducibility = output.Reproducibility
+	trace.CompletedAt = output.CompletedAt
+	if trace.CompletedAt.IsZero() {
+		trace.CompletedAt = time.Now().UTC()
+	}
+	if err != nil {
+		trace.Error = err.Error()
+	}
+	return &trace
+}
+
+// TraceFromRecord returns the trace SessionStorage kept with a run. It returns
+// ErrNoTrace when the run was stored without one.
+// TraceFromRecord 返回 SessionStorage 随运行保存的追踪；运行存储时没有追踪则返回 ErrNoTrace。
+func TraceFromRecord(record *storage.RunRecord) (*RunTrace, error) {
+	if record == nil {
+		return nil, ErrNoTrace
+	}
+	switch v := record.Metadata[TraceMetadataKey].(type) {
+	case nil:
+		return nil, ErrNoTrace
+	case *RunTrace:
+		return v, nil
+	default:
+		// Storages that encode metadata as JSON return it decoded as a map.
+		// 将元数据编码为 JSON 的存储会以 map 形式返回。
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode stored trace: %w", err)
+		}
+		var trace RunTrace
+		if err := json.Unmarshal(data, &trace); err != nil {
+			return nil, fmt.Errorf("failed to decode stored trace: %w", err)
+		}
+		return &trace, nil
+	}
+}
@@ -0,0 +1,193 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/storage"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// lookupToolkit returns a toolkit with a "lookup" function counting its calls
+func lookupToolkit(calls *int) toolkit.Toolkit {
+	tk := toolkit.NewBaseToolkit("lookup")
+	tk.RegisterFunction(&toolkit.Function{
+		Name:        "lookup",
+		Description: "Look up a city",
+		Parameters: map[string]toolkit.Parameter{
+			"city": {Type: "string", Required: true},
+		},
+		Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			*calls++
+			return map[string]interface{}{"city": args["city"], "temp": 21}, nil
+		},
+	})
+	return tk
+}
+
+// lookupModel calls lookup for Paris, then answers with the tool output prefixed
+func lookupModel(id, prefix string) *MockModel {
+	return &MockModel{
+		BaseModel: models.BaseModel{ID: id, Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			last := req.Messages[len(req.Messages)-1]
+			if last.Role == types.RoleTool {
+				return &types.ModelResponse{Content: prefix + last.Content, Usage: types.Usage{TotalTokens: 3}}, nil
+			}
+			return &types.ModelResponse{ToolCalls: []types.ToolCall{{
+				ID:       "call-1",
+				Type:     "function",
+				Function: types.ToolCallFunction{Name: "lookup", Arguments: `{"city": "Paris"}`},
+			}}, Usage: types.Usage{TotalTokens: 5}}, nil
+		},
+	}
+}
+
+func TestAgent_CaptureTraces(t *testing.T) {
+	calls := 0
+	store := storage.NewMemoryStorage()
+	ag, err := New(Config{
+		ID:             "weather",
+		Model:          lookupModel("v1", "v1: "),
+		Instructions:   "You report the weather.",
+		Toolkits:       []toolkit.Toolkit{lookupToolkit(&calls)},
+		SessionStorage: store,
+		SessionID:      "s1",
+		CaptureTraces:  true,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	out, err := ag.Run(context.Background(), "weather in Paris?")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	trace := out.Trace
+	if trace == nil {
+		t.Fatal("expected a trace")
+	}
+	if trace.Input != "weather in Paris?" || trace.Output != out.Content || trace.Status != string(RunStatusCompleted) {
+		t.Fatalf("unexpected trace: %+v", trace)
+	}
+	if len(trace.ModelCalls) != 2 || trace.ModelCalls[0].Model != "v1" || len(trace.ModelCalls[0].Tools) != 1 {
+		t.Fatalf("model calls = %+v", trace.ModelCalls)
+	}
+	if trace.ModelCalls[0].Messages[0].Role != types.RoleSystem || trace.ModelCalls[1].Response.Content != out.Content {
+		t.Fatalf("model call contents not recorded: %+v", trace.ModelCalls)
+	}
+	if len(trace.ToolCalls) != 1 || trace.ToolCalls[0].Name != "lookup" || trace.ToolCalls[0].Arguments["city"] != "Paris" {
+		t.Fatalf("tool calls = %+v", trace.ToolCalls)
+	}
+	if !strings.Contains(trace.ToolCalls[0].Output, `"temp":21`) {
+		t.Fatalf("tool output = %q", trace.ToolCalls[0].Output)
+	}
+	if trace.Usage.TotalTokens != 8 {
+		t.Fatalf("usage = %+v", trace.Usage)
+	}
+
+	runs, err := store.GetRuns(context.Background(), "s1", 0)
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("GetRuns = %v, %v", runs, err)
+	}
+	// Storages encoding metadata as JSON return the trace decoded as a map.
+	data, err := json.Marshal(runs[0].Metadata)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	record := &storage.RunRecord{}
+	if err := json.Unmarshal(data, &record.Metadata); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	stored, err := TraceFromRecord(record)
+	if err != nil {
+		t.Fatalf("TraceFromRecord: %v", err)
+	}
+	if stored.Output != out.Content || len(stored.ToolCalls) != 1 || len(stored.ModelCalls) != 2 {
+		t.Fatalf("stored trace = %+v", stored)
+	}
+
+	if _, err := TraceFromRecord(&storage.RunRecord{}); !errors.Is(err, ErrNoTrace) {
+		t.Fatalf("err = %v, want ErrNoTrace", err)
+	}
+}
+
+func TestAgent_Replay(t *testing.T) {
+	calls := 0
+	original, err := New(Config{
+		ID:            "weather",
+		Model:         lookupModel("v1", "v1: "),
+		Toolkits:      []toolkit.Toolkit{lookupToolkit(&calls)},
+		CaptureTraces: true,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	out, err := original.Run(context.Background(), "weather in Paris?")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	candidate, err := New(Config{
+		ID:       "weather",
+		Model:    lookupModel("v2", "v2: "),
+		Toolkits: []toolkit.Toolkit{lookupToolkit(&calls)},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	result, err := candidate.Replay(context.Background(), out.Trace, ReplayOptions{})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+
+	if calls != 1 {
+		t.Fatalf("tool ran %d times, want only during the original run", calls)
+	}
+	if result.Trace == nil || len(result.Trace.ToolCalls) != 1 || !result.Trace.ToolCalls[0].Replayed {
+		t.Fatalf("replayed trace = %+v", result.Trace)
+	}
+	if want := "v2: " + out.Trace.ToolCalls[0].Output; result.Output.Content != want {
+		t.Fatalf("content = %q, want %q", result.Output.Content, want)
+	}
+	diff := result.Diff
+	if !diff.OutputChanged || diff.ToolCallsChanged || len(diff.UnrecordedToolCalls) != 0 {
+		t.Fatalf("diff = %+v", diff)
+	}
+	if diff.OriginalModelCalls != 2 || diff.ReplayedModelCalls != 2 {
+		t.Fatalf("diff = %+v", diff)
+	}
+}
+
+func TestAgent_Replay_UnrecordedToolCall(t *testing.T) {
+	calls := 0
+	trace := &RunTrace{Version: runTraceVersion, Input: "weather in Paris?"}
+
+	ag, err := New(Config{ID: "weather", Model: lookupModel("v2", ""), Toolkits: []toolkit.Toolkit{lookupToolkit(&calls)}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	result, err := ag.Replay(context.Background(), trace, ReplayOptions{})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if calls != 0 || !strings.Contains(result.Output.Content, "no recorded result for lookup") {
+		t.Fatalf("calls = %d, content = %q", calls, result.Output.Content)
+	}
+	if len(result.Diff.UnrecordedToolCalls) != 1 {
+		t.Fatalf("diff = %+v", result.Diff)
+	}
+
+	result, err = ag.Replay(context.Background(), trace, ReplayOptions{LiveTools: true})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if calls != 1 || len(result.Diff.UnrecordedToolCalls) != 1 {
+		t.Fatalf("calls = %d, diff = %+v", calls, result.Diff)
+	}
+}
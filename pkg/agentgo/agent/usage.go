@@ -0,0 +1,204 @@
+package agent
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/ratelimit"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// UsageStats aggregates token usage and estimated cost across runs
+// UsageStats 汇总跨运行的令牌用量和估算成本
+type UsageStats struct {
+	Runs       int                    `json:"runs"`        // Completed runs / 已完成的运行数
+	ModelCalls int                    `json:"model_calls"` // Model invocations (cache hits excluded) / 模型调用次数（不含缓存命中）
+	Total      types.Usage            `json:"total"`       // Totals across all models / 所有模型的总计
+	ByModel    map[string]types.Usage `json:"by_model"`    // Totals per model ID / 按模型 ID 统计
+
+	// RateLimit is set when Config.RateLimit is / 仅在设置 Config.RateLimit 时存在
+	RateLimit *RateLimitStats `json:"rate_limit,omitempty"`
+}
+
+// RateLimitStats reports the runs rejected by Config.RateLimit and the budget
+// consumed by the users the agent has served today, up to the 1000 most recent
+// RateLimitStats 报告被 Config.RateLimit 拒绝的运行数以及代理今天服务过的用户（最多最近 1000 个）的预算用量
+type RateLimitStats struct {
+	Rejected int                         `json:"rejected"` // Runs rejected / 被拒绝的运行数
+	Users    map[string]ratelimit.Status `json:"users"`    // Consumption per user ID / 按用户 ID 的用量
+}
+
+type usageTracker struct {
+	mu    sync.Mutex
+	stats UsageStats
+
+	rejected     int
+	limitedUsers map[string]time.Time // User ID -> last run admitted or rejected / 用户 ID -> 最近一次运行时间
+}
+
+// maxLimitedUsers bounds the users UsageStats reports rate-limit usage for
+// maxLimitedUsers 限制 UsageStats 报告限流用量的用户数
+const maxLimitedUsers = 1000
+
+func (t *usageTracker) add(modelID string, usage types.Usage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.stats.ModelCalls++
+	t.stats.Total = t.stats.Total.Add(usage)
+	if t.stats.ByModel == nil {
+		t.stats.ByModel = make(map[string]types.Usage)
+	}
+	t.stats.ByModel[modelID] = t.stats.ByModel[modelID].Add(usage)
+}
+
+func (t *usageTracker) addRun() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stats.Runs++
+}
+
+func (t *usageTracker) addLimitedUser(userID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.limitedUsers == nil {
+		t.limitedUsers = make(map[string]time.Time)
+	}
+	now := time.Now()
+	t.limitedUsers[userID] = now
+	if len(t.limitedUsers) <= maxLimitedUsers {
+		return
+	}
+
+	// Users idle since before today have nothing left in their windows
+	// 今天之前就不活跃的用户在其窗口中已没有用量
+	today := now.UTC().Truncate(24 * time.Hour)
+	for id, seen := range t.limitedUsers {
+		if seen.Before(today) {
+			delete(t.limitedUsers, id)
+		}
+	}
+	if len(t.limitedUsers) <= maxLimitedUsers {
+		return
+	}
+
+	// Still too many: forget the least recently seen quarter
+	// 仍然过多：移除最久未活跃的四分之一
+	users := make([]string, 0, len(t.limitedUsers))
+	for id := range t.limitedUsers {
+		users = append(users, id)
+	}
+	sort.Slice(users, func(i, j int) bool { return t.limitedUsers[users[i]].Before(t.limitedUsers[users[j]]) })
+	for _, id := range users[:len(users)-maxLimitedUsers*3/4] {
+		delete(t.limitedUsers, id)
+	}
+}
+
+func (t *usageTracker) addRejected() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rejected++
+}
+
+// limitSnapshot returns the rejected runs and the sorted users seen by the
+// limiter today
+func (t *usageTracker) limitSnapshot() (int, []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	users := make([]string, 0, len(t.limitedUsers))
+	for userID, seen := range t.limitedUsers {
+		if !seen.Before(today) {
+			users = append(users, userID)
+		}
+	}
+	sort.Strings(users)
+	return t.rejected, users
+}
+
+func (t *usageTracker) snapshot() UsageStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := t.stats
+	stats.ByModel = make(map[string]types.Usage, len(t.stats.ByModel))
+	for id, usage := range t.stats.ByModel {
+		stats.ByModel[id] = usage
+	}
+	return stats
+}
+
+func (t *usageTracker) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stats = UsageStats{}
+	t.rejected = 0
+}
+
+// recordUsage prices a model response and adds it to the run and agent totals.
+// The response's own Usage gets the estimated cost so callers see it too, and its
+// tokens are charged to the user's daily budget when Config.RateLimit is set.
+// recordUsage 为模型响应定价，并将其计入运行和代理的总计；设置 Config.RateLimit 时其令牌计入用户的每日预算。
+func (a *Agent) recordUsage(ctx context.Context, output *RunOutput, resp *types.ModelResponse) {
+	if resp == nil {
+		return
+	}
+
+	modelID := resp.Model
+	if modelID == "" {
+		modelID = a.Model.GetID()
+	}
+
+	if a.pricing != nil && resp.Usage.EstimatedCost == 0 {
+		if cost, ok := a.pricing.EstimateCost(modelID, resp.Usage); ok {
+			resp.Usage.EstimatedCost = cost
+		}
+	}
+
+	output.Usage = output.Usage.Add(resp.Usage)
+	a.usage.add(modelID, resp.Usage)
+	a.metrics.AddTokens(a.ID, modelID, resp.Usage)
+	a.chargeTokens(ctx, resp.Usage.TotalTokens)
+}
+
+// UsageStats returns token usage and estimated cost accumulated since the
+// agent was created or ResetUsageStats was last called. With Config.RateLimit it
+// also carries each user's consumption in the current windows.
+// UsageStats 返回自代理创建或上次调用 ResetUsageStats 以来累计的令牌用量和估算成本。
+// 设置 Config.RateLimit 时还包含每个用户在当前窗口中的用量。
+func (a *Agent) UsageStats() UsageStats {
+	stats := a.usage.snapshot()
+	if a.limits == nil {
+		return stats
+	}
+
+	rejected, users := a.usage.limitSnapshot()
+	stats.RateLimit = &RateLimitStats{Rejected: rejected, Users: make(map[string]ratelimit.Status, len(users))}
+	for _, userID := range users {
+		status, err := a.RateLimitStatus(context.Background(), userID)
+		if err != nil {
+			a.logger.Warn("failed to read rate limit usage", "agent_id", a.ID, "user_id", userID, "error", err)
+			continue
+		}
+		stats.RateLimit.Users[userID] = status
+	}
+	return stats
+}
+
+// RateLimitStatus returns a user's consumption against Config.RateLimit, or a
+// zero Status when the agent has no rate limit
+// RateLimitStatus 返回用户相对于 Config.RateLimit 的用量；代理未设置限流时返回零值 Status
+func (a *Agent) RateLimitStatus(ctx context.Context, userID string) (ratelimit.Status, error) {
+	if a.limits == nil {
+		return ratelimit.Status{}, nil
+	}
+	return a.limits.Status(ctx, a.rateLimitKey(userID))
+}
+
+// ResetUsageStats clears the accumulated usage statistics
+// ResetUsageStats 清除累计的用量统计
+func (a *Agent) ResetUsageStats() {
+	a.usage.reset()
+}
@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/calculator"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+func TestAgent_UsageAccounting(t *testing.T) {
+	calls := 0
+	model := &MockModel{
+		BaseModel: models.BaseModel{ID: "priced-model", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			calls++
+			usage := types.Usage{PromptTokens: 1000, CompletionTokens: 100, TotalTokens: 1100}
+			if calls%2 == 1 {
+				return &types.ModelResponse{
+					Usage: usage,
+					ToolCalls: []types.ToolCall{{
+						ID:       "call",
+						Type:     "function",
+						Function: types.ToolCallFunction{Name: "add", Arguments: `{"a": 1, "b": 2}`},
+					}},
+				}, nil
+			}
+			return &types.ModelResponse{Content: "3", Usage: usage}, nil
+		},
+	}
+
+	ag, err := New(Config{
+		Model:    model,
+		Toolkits: []toolkit.Toolkit{calculator.New()},
+		Pricing: models.PricingTable{
+			"priced": {PromptPerMillion: 1, CompletionPerMillion: 10},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	output, err := ag.Run(context.Background(), "1+2")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// Two model calls: 2000 prompt, 200 completion tokens.
+	if output.Usage.PromptTokens != 2000 || output.Usage.CompletionTokens != 200 || output.Usage.TotalTokens != 2200 {
+		t.Fatalf("unexpected run usage %+v", output.Usage)
+	}
+	wantCost := 2000*1.0/1e6 + 200*10.0/1e6
+	if math.Abs(output.Usage.EstimatedCost-wantCost) > 1e-12 {
+		t.Fatalf("expected cost %v, got %v", wantCost, output.Usage.EstimatedCost)
+	}
+	if usage, ok := output.Metadata["usage"].(types.Usage); !ok || usage != output.Usage {
+		t.Fatalf("expected metadata usage to match run usage, got %v", output.Metadata["usage"])
+	}
+
+	if _, err := ag.Run(context.Background(), "1+2 again"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	stats := ag.UsageStats()
+	if stats.Runs != 2 || stats.ModelCalls != 4 || stats.Total.TotalTokens != 4400 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	if math.Abs(stats.ByModel["priced-model"].EstimatedCost-2*wantCost) > 1e-12 {
+		t.Fatalf("unexpected per-model stats %+v", stats.ByModel)
+	}
+
+	ag.ResetUsageStats()
+	if stats := ag.UsageStats(); stats.Runs != 0 || len(stats.ByModel) != 0 {
+		t.Fatalf("expected reset stats, got %+v", stats)
+	}
+}
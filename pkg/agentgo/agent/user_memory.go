@@ -0,0 +1,33 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/prompts"
+)
+
+// buildUserMemoryContext retrieves the run user's memories most relevant to
+// the input and formats them with prompts.UserMemorySection
+// buildUserMemoryContext 检索与输入最相关的运行用户记忆，并用 prompts.UserMemorySection 格式化
+func (a *Agent) buildUserMemoryContext(ctx context.Context, input string) (string, error) {
+	userID := a.runUserID(ctx)
+	if userID == "" || strings.TrimSpace(input) == "" {
+		return "", nil
+	}
+
+	results, err := a.userMemories.SearchUserMemories(ctx, userID, input, a.userMemoryLimit)
+	if err != nil {
+		return "", fmt.Errorf("user memory search failed: %w", err)
+	}
+
+	var memories []string
+	for _, result := range results {
+		if result.Score < a.userMemoryMin {
+			continue
+		}
+		memories = append(memories, result.Content)
+	}
+	return prompts.UserMemorySection(memories).Content, nil
+}
@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/learning"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// fakeRetriever returns fixed memories and records the last search
+type fakeRetriever struct {
+	results []learning.ScoredMemory
+	err     error
+	userID  string
+	query   string
+	limit   int
+}
+
+func (f *fakeRetriever) SearchUserMemories(ctx context.Context, userID, query string, limit int) ([]learning.ScoredMemory, error) {
+	f.userID, f.query, f.limit = userID, query, limit
+	return f.results, f.err
+}
+
+func TestAgent_UserMemories(t *testing.T) {
+	retriever := &fakeRetriever{results: []learning.ScoredMemory{
+		{UserMemory: learning.UserMemory{Content: "Is allergic to peanuts"}, Score: 0.9},
+		{UserMemory: learning.UserMemory{Content: "Likes jazz"}, Score: 0.1},
+	}}
+	lm := &mockLearningMachine{
+		profile:  &learning.UserProfile{UserID: "ada", Name: "Ada"},
+		memories: []learning.UserMemory{{Content: "Latest memory"}},
+	}
+
+	var prompt string
+	ag, err := New(Config{
+		Model: &MockModel{
+			BaseModel: models.BaseModel{ID: "mock", Provider: "mock"},
+			InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+				prompt = req.Messages[0].Content
+				return &types.ModelResponse{Content: "ok"}, nil
+			},
+		},
+		Instructions:       "You are a cooking assistant.",
+		UserID:             "default-user",
+		Learning:           true,
+		LearningMachine:    lm,
+		UserMemories:       retriever,
+		UserMemoryLimit:    3,
+		UserMemoryMinScore: 0.5,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if _, err := ag.Run(userContext("ada"), "suggest a satay recipe"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if retriever.userID != "ada" || retriever.query != "suggest a satay recipe" || retriever.limit != 3 {
+		t.Fatalf("SearchUserMemories(%q, %q, %d)", retriever.userID, retriever.query, retriever.limit)
+	}
+	if !strings.Contains(prompt, "## What You Know About the User\n\n- Is allergic to peanuts\n") {
+		t.Fatalf("expected the relevant memory in the prompt, got %q", prompt)
+	}
+	// The low-scoring memory and the latest memories are left out; the profile stays
+	if strings.Contains(prompt, "Likes jazz") || strings.Contains(prompt, "Latest memory") || !strings.Contains(prompt, "User: Ada") {
+		t.Fatalf("unexpected prompt %q", prompt)
+	}
+}
+
+func TestAgent_UserMemories_Degrades(t *testing.T) {
+	ag, err := New(Config{
+		Model:        &MockModel{BaseModel: models.BaseModel{ID: "mock", Provider: "mock"}},
+		UserID:       "ada",
+		UserMemories: &fakeRetriever{err: errors.New("embedder down")},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	output, err := ag.Run(context.Background(), "hi")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(output.Warnings) != 1 || output.Warnings[0].Subsystem != SubsystemLearning {
+		t.Fatalf("expected a learning warning, got %+v", output.Warnings)
+	}
+}
@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/format"
+	"github.com/jholhewres/agent-go/pkg/agentgo/speech"
+)
+
+// VoiceChannel is the format channel RunVoice attaches when the context has
+// none, so ChannelFormatPolicies can keep spoken replies free of markdown
+// VoiceChannel 是 RunVoice 在上下文未指定渠道时附加的格式渠道，
+// 使 ChannelFormatPolicies 可以让语音回答不含 markdown
+const VoiceChannel = "voice"
+
+// ErrNoSpeech is returned by RunVoice when the audio contains no speech
+// ErrNoSpeech 在音频不包含语音时由 RunVoice 返回
+var ErrNoSpeech = errors.New("no speech in audio")
+
+// VoiceOutput is the result of RunVoice
+// VoiceOutput 是 RunVoice 的结果
+type VoiceOutput struct {
+	Transcript *speech.Transcript `json:"transcript"`       // What the user said / 用户所说的内容
+	Output     *RunOutput         `json:"output,omitempty"` // The run on the transcript / 基于转写文本的运行
+	Audio      *speech.Audio      `json:"-"`                // The spoken reply, nil without a Synthesizer / 语音回答，未配置 Synthesizer 时为 nil
+}
+
+// RunVoice transcribes audio with the configured Transcriber, runs the agent
+// on the transcript and speaks the reply with the Synthesizer. Runs that end
+// without content, such as runs paused for approval, return no audio. When
+// the run or synthesis fails the output so far is returned with the error.
+// RunVoice 使用配置的 Transcriber 转写音频，基于转写文本运行代理，并用 Synthesizer 朗读回答。
+// 没有内容的运行（例如等待审批而暂停的运行）不返回音频。运行或合成失败时，返回已有的输出和错误。
+func (a *Agent) RunVoice(ctx context.Context, audio *speech.Audio) (*VoiceOutput, error) {
+	if a.transcriber == nil {
+		return nil, fmt.Errorf("agent has no transcriber configured")
+	}
+
+	transcript, err := a.transcriber.Transcribe(ctx, audio)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transcribe audio: %w", err)
+	}
+	result := &VoiceOutput{Transcript: transcript}
+	if strings.TrimSpace(transcript.Text) == "" {
+		return result, ErrNoSpeech
+	}
+
+	if format.ChannelFromContext(ctx) == "" {
+		ctx = format.WithChannel(ctx, VoiceChannel)
+	}
+	result.Output, err = a.Run(ctx, transcript.Text)
+	if err != nil {
+		return result, err
+	}
+
+	if a.synthesizer == nil || strings.TrimSpace(result.Output.Content) == "" {
+		return result, nil
+	}
+	result.Audio, err = a.synthesizer.Synthesize(ctx, result.Output.Content)
+	if err != nil {
+		return result, fmt.Errorf("failed to synthesize reply: %w", err)
+	}
+	return result, nil
+}
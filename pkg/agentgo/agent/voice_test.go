@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/format"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/speech"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+func TestAgent_RunVoice(t *testing.T) {
+	model := &MockModel{
+		BaseModel: models.BaseModel{ID: "test", Provider: "mock"},
+		InvokeFunc: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			last := req.Messages[len(req.Messages)-1]
+			if last.Content != "What time is it?" {
+				t.Errorf("input = %q, want the transcript", last.Content)
+			}
+			return &types.ModelResponse{Content: "It is **noon**."}, nil
+		},
+	}
+	var spoken string
+	ag, err := New(Config{
+		Model: model,
+		Transcriber: speech.TranscriberFunc(func(_ context.Context, audio *speech.Audio) (*speech.Transcript, error) {
+			if string(audio.Data) != "question" {
+				t.Errorf("audio = %q", audio.Data)
+			}
+			return &speech.Transcript{Text: "What time is it?", Language: "en"}, nil
+		}),
+		Synthesizer: speech.SynthesizerFunc(func(_ context.Context, text string) (*speech.Audio, error) {
+			spoken = text
+			return &speech.Audio{Data: []byte("answer"), Format: "mp3"}, nil
+		}),
+		ChannelFormatPolicies: map[string]*format.Policy{
+			VoiceChannel: {Markdown: format.MarkdownNone},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	out, err := ag.RunVoice(context.Background(), &speech.Audio{Data: []byte("question"), Format: "wav"})
+	if err != nil {
+		t.Fatalf("RunVoice: %v", err)
+	}
+	if out.Transcript.Text != "What time is it?" || out.Output.Content != "It is noon." {
+		t.Errorf("output = %+v, content %q", out, out.Output.Content)
+	}
+	if spoken != "It is noon." || string(out.Audio.Data) != "answer" {
+		t.Errorf("spoke %q, audio %v; want the voice channel policy applied first", spoken, out.Audio)
+	}
+}
+
+func TestAgent_RunVoiceErrors(t *testing.T) {
+	model := &MockModel{BaseModel: models.BaseModel{ID: "test", Provider: "mock"}}
+	audio := &speech.Audio{Data: []byte("..."), Format: "wav"}
+
+	ag, _ := New(Config{Model: model})
+	if _, err := ag.RunVoice(context.Background(), audio); err == nil {
+		t.Error("expected an error without a transcriber")
+	}
+
+	silent := speech.TranscriberFunc(func(context.Context, *speech.Audio) (*speech.Transcript, error) {
+		return &speech.Transcript{Text: "  "}, nil
+	})
+	ag, _ = New(Config{Model: model, Transcriber: silent})
+	if _, err := ag.RunVoice(context.Background(), audio); !errors.Is(err, ErrNoSpeech) {
+		t.Errorf("err = %v, want ErrNoSpeech", err)
+	}
+
+	// Without a synthesizer the reply is text only.
+	heard := speech.TranscriberFunc(func(context.Context, *speech.Audio) (*speech.Transcript, error) {
+		return &speech.Transcript{Text: "hello"}, nil
+	})
+	ag, _ = New(Config{Model: model, Transcriber: heard})
+	out, err := ag.RunVoice(context.Background(), audio)
+	if err != nil || out.Output == nil || out.Audio != nil {
+		t.Errorf("text-only output = %+v, %v", out, err)
+	}
+
+	failing := speech.SynthesizerFunc(func(context.Context, string) (*speech.Audio, error) {
+		return nil, errors.New("quota exceeded")
+	})
+	ag, _ = New(Config{Model: model, Transcriber: heard, Synthesizer: failing})
+	out, err = ag.RunVoice(context.Background(), audio)
+	if err == nil || out == nil || out.Output == nil {
+		t.Errorf("synthesis failure = %+v, %v; want the run output with the error", out, err)
+	}
+}
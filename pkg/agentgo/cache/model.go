@@ -0,0 +1,211 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// defaultModelTTL 是模型响应缓存的默认有效期。
+const defaultModelTTL = time.Hour
+
+// ModelConfig 配置模型响应缓存。
+type ModelConfig struct {
+	// Provider 保存响应，为空时创建有效期为 TTL 的内存缓存。
+	Provider Provider
+	// TTL 为缓存有效期，默认 1 小时。
+	TTL time.Duration
+}
+
+// ModelStats 统计缓存命中情况。
+type ModelStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+// Model 为模型加上响应缓存：模型和请求（消息、工具、采样参数）都相同时直接返回缓存的响应，
+// 包括工具调用，因此重放相同的提示和工具循环不会再消耗令牌。流式调用不经过缓存。
+type Model struct {
+	model    models.Model
+	provider Provider
+	ttl      time.Duration
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+var _ models.Model = (*Model)(nil)
+
+// NewModel 为 model 创建响应缓存。
+func NewModel(model models.Model, config ModelConfig) (*Model, error) {
+	ttl := config.TTL
+	if ttl <= 0 {
+		ttl = defaultModelTTL
+	}
+	provider := config.Provider
+	if provider == nil {
+		memory, err := NewMemoryProvider(0, ttl)
+		if err != nil {
+			return nil, err
+		}
+		provider = memory
+	}
+	return &Model{model: model, provider: provider, ttl: ttl}, nil
+}
+
+type bypassKey struct{}
+
+// WithBypass 返回跳过缓存读取的上下文：调用总是请求模型，新的响应仍会写入缓存。
+func WithBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+// IsBypassed 报告 ctx 是否由 WithBypass 创建。
+func IsBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassKey{}).(bool)
+	return bypass
+}
+
+// Invoke 实现 models.Model，命中时返回缓存的响应并在 Metadata.Extra 中标记 cache_hit。
+func (m *Model) Invoke(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+	key, ok := ModelKey(m.model.GetProvider(), m.model.GetID(), req)
+	if !ok {
+		return m.model.Invoke(ctx, req)
+	}
+
+	if !IsBypassed(ctx) {
+		// 读取失败时按未命中处理
+		if cached, hit, err := m.provider.Get(ctx, key); err == nil && hit {
+			m.hits.Add(1)
+			if cached.Metadata.Extra == nil {
+				cached.Metadata.Extra = make(map[string]interface{})
+			}
+			cached.Metadata.Extra["cache_hit"] = true
+			return cached, nil
+		}
+	}
+
+	m.misses.Add(1)
+	resp, err := m.model.Invoke(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// 写入失败不影响本次调用
+	_ = m.provider.Set(ctx, key, resp, m.ttl)
+	return resp, nil
+}
+
+// InvokeStream 实现 models.Model，流式调用直接交给模型。
+func (m *Model) InvokeStream(ctx context.Context, req *models.InvokeRequest) (<-chan types.ResponseChunk, error) {
+	return m.model.InvokeStream(ctx, req)
+}
+
+// GetProvider 返回被缓存模型的提供商。
+func (m *Model) GetProvider() string {
+	return m.model.GetProvider()
+}
+
+// GetID 返回被缓存模型的 ID。
+func (m *Model) GetID() string {
+	return m.model.GetID()
+}
+
+// GetName 返回被缓存模型的名称。
+func (m *Model) GetName() string {
+	return m.model.GetName()
+}
+
+// Unwrap 返回被缓存的模型。
+func (m *Model) Unwrap() models.Model {
+	return m.model
+}
+
+// Stats 返回缓存命中统计。
+func (m *Model) Stats() ModelStats {
+	return ModelStats{Hits: m.hits.Load(), Misses: m.misses.Load()}
+}
+
+// keyMessage 是消息中影响模型输出的部分，不含每次生成的消息 ID 和各类元数据。
+type keyMessage struct {
+	Role       types.Role       `json:"role"`
+	Content    string           `json:"content"`
+	Name       string           `json:"name,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+	ToolCalls  []types.ToolCall `json:"tool_calls,omitempty"`
+}
+
+// keyRequest 是参与计算缓存键的请求内容。
+type keyRequest struct {
+	Provider           string                  `json:"provider"`
+	Model              string                  `json:"model"`
+	Messages           []keyMessage            `json:"messages"`
+	Tools              []models.ToolDefinition `json:"tools,omitempty"`
+	Temperature        float64                 `json:"temperature,omitempty"`
+	MaxTokens          int                     `json:"max_tokens,omitempty"`
+	ResponseFormat     *models.ResponseFormat  `json:"response_format,omitempty"`
+	Seed               *int                    `json:"seed,omitempty"`
+	ReasoningEffort    string                  `json:"reasoning_effort,omitempty"`
+	MaxReasoningTokens int                     `json:"max_reasoning_tokens,omitempty"`
+	Extra              map[string]interface{}  `json:"extra,omitempty"`
+}
+
+// runContextExtra 是 agent 写入 InvokeRequest.Extra 的运行上下文（run_id 等追踪信息），每次运行都不同，不参与缓存键。
+const runContextExtra = "run_context"
+
+// keyExtra 返回参与缓存键的 Extra，去掉运行上下文。
+func keyExtra(extra map[string]interface{}) map[string]interface{} {
+	if _, ok := extra[runContextExtra]; !ok {
+		return extra
+	}
+	out := make(map[string]interface{}, len(extra)-1)
+	for k, v := range extra {
+		if k != runContextExtra {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// ModelKey 返回模型和请求的缓存键（SHA-256 十六进制）。请求无法序列化时 ok 为 false，此时不应缓存。
+func ModelKey(provider, modelID string, req *models.InvokeRequest) (key string, ok bool) {
+	if req == nil {
+		return "", false
+	}
+	kr := keyRequest{
+		Provider:           provider,
+		Model:              modelID,
+		Messages:           make([]keyMessage, 0, len(req.Messages)),
+		Tools:              req.Tools,
+		Temperature:        req.Temperature,
+		MaxTokens:          req.MaxTokens,
+		ResponseFormat:     req.ResponseFormat,
+		Seed:               req.Seed,
+		ReasoningEffort:    req.ReasoningEffort,
+		MaxReasoningTokens: req.MaxReasoningTokens,
+		Extra:              keyExtra(req.Extra),
+	}
+	for _, msg := range req.Messages {
+		if msg == nil {
+			continue
+		}
+		km := keyMessage{Role: msg.Role, Content: msg.Content, Name: msg.Name, ToolCallID: msg.ToolCallID}
+		for _, tc := range msg.ToolCalls {
+			tc.Metadata = nil
+			km.ToolCalls = append(km.ToolCalls, tc)
+		}
+		kr.Messages = append(kr.Messages, km)
+	}
+
+	data, err := json.Marshal(kr)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), true
+}
@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+type countingModel struct {
+	models.BaseModel
+	calls int
+	err   error
+}
+
+func (m *countingModel) Invoke(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &types.ModelResponse{
+		Content:   "answer",
+		ToolCalls: []types.ToolCall{{ID: "call_1", Type: "function", Function: types.ToolCallFunction{Name: "search"}}},
+		Usage:     types.Usage{TotalTokens: 10},
+	}, nil
+}
+
+func (m *countingModel) InvokeStream(ctx context.Context, req *models.InvokeRequest) (<-chan types.ResponseChunk, error) {
+	m.calls++
+	ch := make(chan types.ResponseChunk)
+	close(ch)
+	return ch, nil
+}
+
+func request(content string) *models.InvokeRequest {
+	return &models.InvokeRequest{Messages: []*types.Message{types.NewUserMessage(content)}}
+}
+
+func TestModel_CachesResponses(t *testing.T) {
+	inner := &countingModel{BaseModel: models.BaseModel{ID: "gpt-test", Provider: "test"}}
+	model, err := NewModel(inner, ModelConfig{})
+	if err != nil {
+		t.Fatalf("NewModel error = %v", err)
+	}
+	ctx := context.Background()
+
+	first, err := model.Invoke(ctx, request("hello"))
+	if err != nil {
+		t.Fatalf("Invoke error = %v", err)
+	}
+	if hit, _ := first.Metadata.Extra["cache_hit"].(bool); hit {
+		t.Fatalf("first call should miss")
+	}
+
+	// New messages get new IDs; the key only depends on their content
+	second, err := model.Invoke(ctx, request("hello"))
+	if err != nil {
+		t.Fatalf("Invoke error = %v", err)
+	}
+	if inner.calls != 1 {
+		t.Fatalf("expected 1 model call, got %d", inner.calls)
+	}
+	if hit, _ := second.Metadata.Extra["cache_hit"].(bool); !hit || len(second.ToolCalls) != 1 {
+		t.Fatalf("expected the cached tool call, got %+v", second)
+	}
+
+	if _, err := model.Invoke(ctx, request("other")); err != nil || inner.calls != 2 {
+		t.Fatalf("expected a different prompt to call the model, calls = %d, err = %v", inner.calls, err)
+	}
+
+	if _, err := model.Invoke(WithBypass(ctx), request("hello")); err != nil || inner.calls != 3 {
+		t.Fatalf("expected the bypass to call the model, calls = %d, err = %v", inner.calls, err)
+	}
+
+	if stats := model.Stats(); stats.Hits != 1 || stats.Misses != 3 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestModel_DoesNotCacheErrorsOrStreams(t *testing.T) {
+	inner := &countingModel{BaseModel: models.BaseModel{ID: "gpt-test", Provider: "test"}, err: errors.New("boom")}
+	model, err := NewModel(inner, ModelConfig{})
+	if err != nil {
+		t.Fatalf("NewModel error = %v", err)
+	}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := model.Invoke(ctx, request("hello")); err == nil {
+			t.Fatalf("expected the model error")
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := model.InvokeStream(ctx, request("hello")); err != nil {
+			t.Fatalf("InvokeStream error = %v", err)
+		}
+	}
+	if inner.calls != 4 {
+		t.Fatalf("expected every call to reach the model, got %d", inner.calls)
+	}
+}
+
+func TestModelKey(t *testing.T) {
+	base := request("hello")
+	key, ok := ModelKey("test", "gpt-test", base)
+	if !ok {
+		t.Fatalf("expected a key")
+	}
+
+	variants := map[string]func() (string, bool){
+		"model": func() (string, bool) { return ModelKey("test", "gpt-other", base) },
+		"temperature": func() (string, bool) {
+			req := request("hello")
+			req.Temperature = 0.5
+			return ModelKey("test", "gpt-test", req)
+		},
+		"tools": func() (string, bool) {
+			req := request("hello")
+			req.Tools = []models.ToolDefinition{{Type: "function", Function: models.FunctionSchema{Name: "search"}}}
+			return ModelKey("test", "gpt-test", req)
+		},
+	}
+	for name, variant := range variants {
+		if other, _ := variant(); other == key {
+			t.Errorf("%s: expected a different key", name)
+		}
+	}
+
+	traced := request("hello")
+	traced.Extra = map[string]interface{}{"run_context": map[string]interface{}{"run_id": "run-1"}}
+	if other, _ := ModelKey("test", "gpt-test", traced); other != key {
+		t.Errorf("run_context: expected the same key")
+	}
+
+	unserializable := request("hello")
+	unserializable.Extra = map[string]interface{}{"fn": func() {}}
+	if _, ok := ModelKey("test", "gpt-test", unserializable); ok {
+		t.Fatalf("expected no key for an unserializable request")
+	}
+}
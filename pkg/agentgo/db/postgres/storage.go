@@ -12,6 +12,7 @@ import (
 
 	"github.com/jholhewres/agent-go/pkg/agentgo/agent"
 	"github.com/jholhewres/agent-go/pkg/agentgo/db/batch"
+	"github.com/jholhewres/agent-go/pkg/agentgo/pagination"
 	"github.com/jholhewres/agent-go/pkg/agentgo/session"
 )
 
@@ -214,6 +215,44 @@ func (p *PostgresStorage) List(ctx context.Context, filters map[string]interface
 	return sessions, nil
 }
 
+// ListPage 按 created_at 游标分页查询 Session，支持与 List 相同的过滤条件。
+// ListPage pages through sessions by created_at with the same filters as List.
+func (p *PostgresStorage) ListPage(ctx context.Context, opts pagination.ListOptions) (pagination.Page[*session.Session], error) {
+	opts, cursor, err := opts.Prepare()
+	if err != nil {
+		return pagination.Page[*session.Session]{}, err
+	}
+
+	b := pagination.NewSQLBuilder(pagination.Dollar)
+	for _, column := range []string{"agent_id", "user_id", "team_id", "workflow_id"} {
+		if v, ok := opts.Filter(column); ok {
+			b.Where(column+" = ?", v)
+		}
+	}
+	query := fmt.Sprintf(`SELECT %s FROM %s`, p.columnNames, p.tableName) + b.Clause(opts, cursor, "created_at", "session_id")
+
+	rows, err := p.db.QueryContext(ctx, query, b.Args()...)
+	if err != nil {
+		return pagination.Page[*session.Session]{}, err
+	}
+	defer rows.Close()
+
+	var sessions []*session.Session
+	for rows.Next() {
+		sess, err := scanSession(rows)
+		if err != nil {
+			return pagination.Page[*session.Session]{}, err
+		}
+		sessions = append(sessions, sess)
+	}
+	if err := rows.Err(); err != nil {
+		return pagination.Page[*session.Session]{}, err
+	}
+	return pagination.Trim(sessions, opts.Limit, func(s *session.Session) (time.Time, string) {
+		return s.CreatedAt, s.SessionID
+	}), nil
+}
+
 // ListByAgent 查询代理下的全部 Session。
 func (p *PostgresStorage) ListByAgent(ctx context.Context, agentID string) ([]*session.Session, error) {
 	return p.List(ctx, map[string]interface{}{
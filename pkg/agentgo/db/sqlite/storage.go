@@ -237,12 +237,10 @@ func (s *Storage) ListByUser(ctx context.Context, userID string) ([]*session.Ses
 	return s.List(ctx, map[string]interface{}{"user_id": userID})
 }
 
-// Close closes the underlying database connection.
+// Close releases storage resources. The *sql.DB is owned by the caller and
+// is left open; close it directly or through a resources.Resources container.
 func (s *Storage) Close() error {
-	if s.db == nil {
-		return nil
-	}
-	return s.db.Close()
+	return nil
 }
 
 func (s *Storage) upsert(ctx context.Context, sess *session.Session) error {
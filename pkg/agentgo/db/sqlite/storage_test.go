@@ -142,3 +142,16 @@ func mustNewStorage(t *testing.T, db *sql.DB) *Storage {
 	}
 	return store
 }
+
+func TestStorage_CloseLeavesDBOpen(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	store := mustNewStorage(t, db)
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := db.Ping(); err != nil {
+		t.Fatalf("expected caller-owned db to remain open, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+)
+
+const (
+	defaultCacheCapacity = 10000
+	defaultCacheTTL      = 24 * time.Hour
+)
+
+// Cache stores embeddings by key. Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the cached embedding for key
+	Get(ctx context.Context, key string) ([]float32, bool, error)
+	// Set stores an embedding under key
+	Set(ctx context.Context, key string, embedding []float32) error
+}
+
+// MemoryCache is an in-process LRU cache with expiry
+type MemoryCache struct {
+	lru *expirable.LRU[string, []float32]
+}
+
+// NewMemoryCache creates a memory cache holding up to capacity embeddings for
+// ttl (defaults: 10000 embeddings, 24h)
+func NewMemoryCache(capacity int, ttl time.Duration) *MemoryCache {
+	if capacity <= 0 {
+		capacity = defaultCacheCapacity
+	}
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	return &MemoryCache{lru: expirable.NewLRU[string, []float32](capacity, nil, ttl)}
+}
+
+// Get implements Cache
+func (m *MemoryCache) Get(ctx context.Context, key string) ([]float32, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	embedding, ok := m.lru.Get(key)
+	if !ok {
+		return nil, false, nil
+	}
+	return append([]float32(nil), embedding...), true, nil
+}
+
+// Set implements Cache
+func (m *MemoryCache) Set(ctx context.Context, key string, embedding []float32) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.lru.Add(key, append([]float32(nil), embedding...))
+	return nil
+}
+
+// Len returns the number of cached embeddings
+func (m *MemoryCache) Len() int {
+	return m.lru.Len()
+}
+
+// EncodeEmbedding serializes an embedding as little-endian float32s, the
+// format used by the SQLite and Redis caches
+func EncodeEmbedding(embedding []float32) []byte {
+	buf := make([]byte, 4*len(embedding))
+	for i, v := range embedding {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return buf
+}
+
+// DecodeEmbedding parses the output of EncodeEmbedding
+func DecodeEmbedding(data []byte) ([]float32, error) {
+	if len(data)%4 != 0 {
+		return nil, fmt.Errorf("invalid embedding encoding: %d bytes", len(data))
+	}
+	embedding := make([]float32, len(data)/4)
+	for i := range embedding {
+		embedding[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+	}
+	return embedding, nil
+}
@@ -0,0 +1,139 @@
+package embeddings
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+var _ Embedder = (*CachedEmbedder)(nil)
+
+// CachedEmbedder memoizes another Embedder's results in a Cache, keyed by the
+// SHA-256 of namespace and text. Use it where the same strings are embedded
+// again and again, such as HybridMemory and knowledge ingestion. Client offers
+// the same caching together with batching and rate limiting.
+type CachedEmbedder struct {
+	embedder  Embedder
+	cache     Cache
+	namespace string
+}
+
+// NewCachedEmbedder wraps embedder with cache. namespace separates entries of
+// different models sharing a cache and defaults to the embedder's model when
+// it implements ModelInfo.
+func NewCachedEmbedder(embedder Embedder, cache Cache, namespace string) (*CachedEmbedder, error) {
+	if embedder == nil {
+		return nil, fmt.Errorf("embedder is required")
+	}
+	if cache == nil {
+		return nil, fmt.Errorf("cache is required")
+	}
+	if namespace == "" {
+		if info, ok := embedder.(ModelInfo); ok {
+			namespace = info.GetModel()
+		}
+	}
+	return &CachedEmbedder{embedder: embedder, cache: cache, namespace: namespace}, nil
+}
+
+// Unwrap returns the wrapped embedder
+func (e *CachedEmbedder) Unwrap() Embedder {
+	return e.embedder
+}
+
+// GetModel returns the wrapped embedder's model, if it reports one
+func (e *CachedEmbedder) GetModel() string {
+	if info, ok := e.embedder.(ModelInfo); ok {
+		return info.GetModel()
+	}
+	return ""
+}
+
+// GetDimensions returns the wrapped embedder's vector size, if it reports one
+func (e *CachedEmbedder) GetDimensions() int {
+	if info, ok := e.embedder.(ModelInfo); ok {
+		return info.GetDimensions()
+	}
+	return 0
+}
+
+// Embed returns one embedding per text. Cached texts are served from the
+// cache; the rest are embedded in one call to the wrapped embedder and cached.
+func (e *CachedEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return embedCached(ctx, texts, e.cache, e.namespace, func(ctx context.Context, missing []string) ([][]float32, error) {
+		embeddings, err := e.embedder.Embed(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		if len(embeddings) != len(missing) {
+			return nil, fmt.Errorf("embedder returned %d embeddings for %d texts", len(embeddings), len(missing))
+		}
+		return embeddings, nil
+	})
+}
+
+// EmbedSingle returns the embedding of one text
+func (e *CachedEmbedder) EmbedSingle(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := e.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// CacheKey returns the cache key of text: the hex SHA-256 of namespace and text
+func CacheKey(namespace, text string) string {
+	sum := sha256.Sum256([]byte(namespace + "\x00" + text))
+	return hex.EncodeToString(sum[:])
+}
+
+// embedCached embeds each distinct text once, serving what it can from cache
+// (which may be nil) and storing the rest after calling embed
+func embedCached(ctx context.Context, texts []string, cache Cache, namespace string,
+	embed func(context.Context, []string) ([][]float32, error)) ([][]float32, error) {
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+
+	out := make([][]float32, len(texts))
+	var unique []string
+	positions := make(map[string][]int) // text -> indexes in texts
+	for i, text := range texts {
+		if _, seen := positions[text]; !seen {
+			unique = append(unique, text)
+		}
+		positions[text] = append(positions[text], i)
+	}
+
+	var missing []string
+	for _, text := range unique {
+		if cache != nil {
+			if embedding, ok, err := cache.Get(ctx, CacheKey(namespace, text)); err == nil && ok {
+				for _, pos := range positions[text] {
+					out[pos] = embedding
+				}
+				continue
+			}
+		}
+		missing = append(missing, text)
+	}
+	if len(missing) == 0 {
+		return out, nil
+	}
+
+	embedded, err := embed(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for i, text := range missing {
+		for _, pos := range positions[text] {
+			out[pos] = embedded[i]
+		}
+		if cache != nil {
+			// A failed cache write only costs a future provider call.
+			_ = cache.Set(ctx, CacheKey(namespace, text), embedded[i])
+		}
+	}
+	return out, nil
+}
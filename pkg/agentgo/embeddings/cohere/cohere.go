@@ -0,0 +1,199 @@
+package cohere
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+)
+
+// Input types accepted by Cohere embedding models
+const (
+	InputSearchDocument = "search_document"
+	InputSearchQuery    = "search_query"
+	InputClassification = "classification"
+	InputClustering     = "clustering"
+)
+
+// maxBatchSize is the number of texts Cohere accepts per request
+const maxBatchSize = 96
+
+// Embedding implements vectordb.EmbeddingFunction using Cohere's embed API
+type Embedding struct {
+	apiKey     string
+	model      string
+	inputType  string
+	dimensions int
+	baseURL    string
+	httpClient *http.Client
+}
+
+// Config holds configuration for Cohere embeddings
+type Config struct {
+	// APIKey for Cohere API
+	APIKey string
+
+	// Model to use (default: embed-v4.0)
+	// Options: embed-v4.0, embed-english-v3.0, embed-multilingual-v3.0, ...
+	Model string
+
+	// InputType tells the model how the vectors will be used (default: search_document).
+	// Use a second embedder with InputSearchQuery for queries.
+	InputType string
+
+	// Dimensions requests a smaller output size on models that support it (optional)
+	Dimensions int
+
+	// BaseURL for Cohere API (default: https://api.cohere.com)
+	BaseURL string
+
+	// HTTPClient to use for requests (optional)
+	HTTPClient *http.Client
+}
+
+type embedRequest struct {
+	Model           string   `json:"model"`
+	Texts           []string `json:"texts"`
+	InputType       string   `json:"input_type"`
+	EmbeddingTypes  []string `json:"embedding_types"`
+	OutputDimension int      `json:"output_dimension,omitempty"`
+}
+
+type embedResponse struct {
+	Embeddings struct {
+		Float [][]float32 `json:"float"`
+	} `json:"embeddings"`
+}
+
+type errorResponse struct {
+	Message string `json:"message"`
+}
+
+var _ vectordb.EmbeddingFunction = (*Embedding)(nil)
+
+// New creates a new Cohere embedding function
+func New(config Config) (*Embedding, error) {
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("API key is required")
+	}
+	if config.Model == "" {
+		config.Model = "embed-v4.0"
+	}
+	if config.InputType == "" {
+		config.InputType = InputSearchDocument
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = "https://api.cohere.com"
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return &Embedding{
+		apiKey:     config.APIKey,
+		model:      config.Model,
+		inputType:  config.InputType,
+		dimensions: config.Dimensions,
+		baseURL:    config.BaseURL,
+		httpClient: config.HTTPClient,
+	}, nil
+}
+
+// Embed generates embeddings for multiple texts
+func (e *Embedding) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+
+	embeddings := make([][]float32, 0, len(texts))
+	for i := 0; i < len(texts); i += maxBatchSize {
+		end := min(i+maxBatchSize, len(texts))
+		batch, err := e.embed(ctx, texts[i:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed batch %d-%d: %w", i, end, err)
+		}
+		embeddings = append(embeddings, batch...)
+	}
+	return embeddings, nil
+}
+
+func (e *Embedding) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	data, err := json.Marshal(embedRequest{
+		Model:           e.model,
+		Texts:           texts,
+		InputType:       e.inputType,
+		EmbeddingTypes:  []string{"float"},
+		OutputDimension: e.dimensions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/v2/embed", bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+e.apiKey)
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var errResp errorResponse
+		if err := json.Unmarshal(body, &errResp); err != nil || errResp.Message == "" {
+			return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		}
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, errResp.Message)
+	}
+
+	var embResp embedResponse
+	if err := json.Unmarshal(body, &embResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(embResp.Embeddings.Float) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(embResp.Embeddings.Float))
+	}
+	return embResp.Embeddings.Float, nil
+}
+
+// EmbedSingle generates embedding for a single text
+func (e *Embedding) EmbedSingle(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := e.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// GetModel returns the model name being used
+func (e *Embedding) GetModel() string {
+	return e.model
+}
+
+// GetDimensions returns the expected embedding dimensions for the model
+func (e *Embedding) GetDimensions() int {
+	if e.dimensions > 0 {
+		return e.dimensions
+	}
+	switch e.model {
+	case "embed-v4.0":
+		return 1536
+	case "embed-english-light-v3.0", "embed-multilingual-light-v3.0":
+		return 384
+	default:
+		return 1024
+	}
+}
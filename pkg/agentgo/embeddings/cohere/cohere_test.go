@@ -0,0 +1,97 @@
+package cohere
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Config{}); err == nil {
+		t.Error("expected error without API key")
+	}
+	e, err := New(Config{APIKey: "key"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if e.GetModel() != "embed-v4.0" || e.GetDimensions() != 1536 {
+		t.Errorf("unexpected defaults: %s %d", e.GetModel(), e.GetDimensions())
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	var got embedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/embed" || r.Header.Get("Authorization") != "Bearer key" {
+			http.Error(w, `{"message":"bad request"}`, http.StatusBadRequest)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		vectors := make([][]float32, len(got.Texts))
+		for i := range got.Texts {
+			vectors[i] = []float32{float32(i), 1}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"embeddings": map[string]interface{}{"float": vectors},
+		})
+	}))
+	defer srv.Close()
+
+	e, _ := New(Config{APIKey: "key", BaseURL: srv.URL, InputType: InputSearchQuery, Dimensions: 2})
+	embeddings, err := e.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	if len(embeddings) != 2 || embeddings[1][0] != 1 {
+		t.Errorf("Embed() = %v", embeddings)
+	}
+	if got.InputType != InputSearchQuery || got.OutputDimension != 2 || got.EmbeddingTypes[0] != "float" {
+		t.Errorf("request = %+v", got)
+	}
+	if e.GetDimensions() != 2 {
+		t.Errorf("GetDimensions() = %d, want 2", e.GetDimensions())
+	}
+}
+
+func TestEmbed_SplitsBatches(t *testing.T) {
+	var sizes []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req embedRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		sizes = append(sizes, len(req.Texts))
+		vectors := make([][]float32, len(req.Texts))
+		for i := range vectors {
+			vectors[i] = []float32{1}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"embeddings": map[string]interface{}{"float": vectors},
+		})
+	}))
+	defer srv.Close()
+
+	e, _ := New(Config{APIKey: "key", BaseURL: srv.URL})
+	texts := make([]string, maxBatchSize+4)
+	embeddings, err := e.Embed(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	if len(embeddings) != len(texts) || len(sizes) != 2 || sizes[0] != maxBatchSize {
+		t.Errorf("got %d embeddings in batches %v", len(embeddings), sizes)
+	}
+}
+
+func TestEmbed_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"invalid api token"}`, http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	e, _ := New(Config{APIKey: "key", BaseURL: srv.URL})
+	_, err := e.EmbedSingle(context.Background(), "a")
+	if err == nil || !strings.Contains(err.Error(), "invalid api token") || !strings.Contains(err.Error(), "status 401") {
+		t.Fatalf("EmbedSingle() error = %v", err)
+	}
+}
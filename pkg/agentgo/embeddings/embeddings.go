@@ -0,0 +1,220 @@
+// Package embeddings defines the embedding interface shared by vector
+// databases, memory and knowledge bases, and a Client that adds batching,
+// caching and rate limiting to any provider.
+//
+// Providers live in sub-packages: openai, cohere, voyage, vllm, local
+// (GGUF and ONNX models served on this machine) and sentencetransformer.
+// Persistent caches live in sqlitecache and rediscache.
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+)
+
+// Embedder turns texts into vectors. It has the same method set as
+// vectordb.EmbeddingFunction, so every Embedder can be passed to vector
+// databases and every existing EmbeddingFunction is an Embedder.
+type Embedder interface {
+	// Embed returns one embedding per text, in order
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// EmbedSingle returns the embedding of one text
+	EmbedSingle(ctx context.Context, text string) ([]float32, error)
+}
+
+var (
+	_ vectordb.EmbeddingFunction = Embedder(nil)
+	_ Embedder                   = vectordb.EmbeddingFunction(nil)
+	_ Embedder                   = (*Client)(nil)
+)
+
+// ModelInfo is implemented by embedders that report their model and vector size
+type ModelInfo interface {
+	GetModel() string
+	GetDimensions() int
+}
+
+// Config configures a Client
+type Config struct {
+	// Embedder is the provider to call (required)
+	Embedder Embedder
+
+	// BatchSize is the maximum number of texts per provider call (default: 96)
+	BatchSize int
+	// MaxConcurrency is the number of batches sent in parallel (default: 1)
+	MaxConcurrency int
+
+	// Cache stores embeddings by model and text (optional)
+	Cache Cache
+	// CacheNamespace separates cache entries of different models sharing a
+	// cache (default: the embedder's model when it implements ModelInfo)
+	CacheNamespace string
+
+	// RequestsPerMinute limits provider calls (0 = unlimited)
+	RequestsPerMinute int
+	// TokensPerMinute limits estimated input tokens (0 = unlimited)
+	TokensPerMinute int
+}
+
+// Client wraps an Embedder with batching, caching and rate limiting.
+// Duplicate texts within a call are embedded once.
+type Client struct {
+	embedder    Embedder
+	batchSize   int
+	concurrency int
+	cache       Cache
+	namespace   string
+	requests    *limiter
+	tokens      *limiter
+}
+
+// New creates a Client
+func New(config Config) (*Client, error) {
+	if config.Embedder == nil {
+		return nil, fmt.Errorf("embedder is required")
+	}
+	if config.BatchSize < 0 || config.MaxConcurrency < 0 || config.RequestsPerMinute < 0 || config.TokensPerMinute < 0 {
+		return nil, fmt.Errorf("batch size, concurrency and rate limits must not be negative")
+	}
+	if config.BatchSize == 0 {
+		config.BatchSize = 96
+	}
+	if config.MaxConcurrency == 0 {
+		config.MaxConcurrency = 1
+	}
+	if config.CacheNamespace == "" {
+		if info, ok := config.Embedder.(ModelInfo); ok {
+			config.CacheNamespace = info.GetModel()
+		}
+	}
+
+	return &Client{
+		embedder:    config.Embedder,
+		batchSize:   config.BatchSize,
+		concurrency: config.MaxConcurrency,
+		cache:       config.Cache,
+		namespace:   config.CacheNamespace,
+		requests:    newLimiter(config.RequestsPerMinute),
+		tokens:      newLimiter(config.TokensPerMinute),
+	}, nil
+}
+
+// Unwrap returns the wrapped embedder
+func (c *Client) Unwrap() Embedder {
+	return c.embedder
+}
+
+// GetModel returns the wrapped embedder's model, if it reports one
+func (c *Client) GetModel() string {
+	if info, ok := c.embedder.(ModelInfo); ok {
+		return info.GetModel()
+	}
+	return ""
+}
+
+// GetDimensions returns the wrapped embedder's vector size, if it reports one
+func (c *Client) GetDimensions() int {
+	if info, ok := c.embedder.(ModelInfo); ok {
+		return info.GetDimensions()
+	}
+	return 0
+}
+
+// Embed returns one embedding per text. Cached texts are served from the
+// cache; the rest are embedded in batches and cached.
+func (c *Client) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return embedCached(ctx, texts, c.cache, c.namespace, c.embedBatches)
+}
+
+// EmbedSingle returns the embedding of one text
+func (c *Client) EmbedSingle(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := c.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// embedBatches splits texts into batches and embeds them with at most
+// MaxConcurrency calls in flight
+func (c *Client) embedBatches(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	sem := make(chan struct{}, c.concurrency)
+	for start := 0; start < len(texts); start += c.batchSize {
+		end := min(start+c.batchSize, len(texts))
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			embeddings, err := c.embedBatch(ctx, texts[start:end])
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to embed batch %d-%d: %w", start, end, err)
+				}
+				mu.Unlock()
+				cancel()
+				return
+			}
+			copy(out[start:end], embeddings)
+		}(start, end)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if err := c.requests.wait(ctx, 1); err != nil {
+		return nil, err
+	}
+	if err := c.tokens.wait(ctx, estimateTokens(texts)); err != nil {
+		return nil, err
+	}
+
+	embeddings, err := c.embedder.Embed(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) != len(texts) {
+		return nil, fmt.Errorf("embedder returned %d embeddings for %d texts", len(embeddings), len(texts))
+	}
+	return embeddings, nil
+}
+
+// estimateTokens approximates input tokens at four bytes per token
+func estimateTokens(texts []string) int {
+	total := 0
+	for _, text := range texts {
+		total += (len(text) + 3) / 4
+	}
+	return max(total, 1)
+}
@@ -0,0 +1,219 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingEmbedder returns [len(text)] for each text and records its calls
+type countingEmbedder struct {
+	mu      sync.Mutex
+	batches [][]string
+	err     error
+}
+
+func (e *countingEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	e.mu.Lock()
+	e.batches = append(e.batches, append([]string(nil), texts...))
+	e.mu.Unlock()
+	if e.err != nil {
+		return nil, e.err
+	}
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		out[i] = []float32{float32(len(text))}
+	}
+	return out, nil
+}
+
+func (e *countingEmbedder) EmbedSingle(ctx context.Context, text string) ([]float32, error) {
+	out, err := e.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return out[0], nil
+}
+
+func (e *countingEmbedder) GetModel() string   { return "counting" }
+func (e *countingEmbedder) GetDimensions() int { return 1 }
+
+func (e *countingEmbedder) calls() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.batches)
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Config{}); err == nil {
+		t.Error("expected error without embedder")
+	}
+	if _, err := New(Config{Embedder: &countingEmbedder{}, BatchSize: -1}); err == nil {
+		t.Error("expected error for negative batch size")
+	}
+}
+
+func TestClient_BatchesAndDeduplicates(t *testing.T) {
+	provider := &countingEmbedder{}
+	client, err := New(Config{Embedder: provider, BatchSize: 2, MaxConcurrency: 2})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	texts := []string{"a", "bb", "a", "ccc", "dddd", "eeeee"}
+	got, err := client.Embed(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	for i, text := range texts {
+		if len(got[i]) != 1 || got[i][0] != float32(len(text)) {
+			t.Errorf("embedding %d = %v, want [%d]", i, got[i], len(text))
+		}
+	}
+	// Five unique texts in batches of two.
+	if provider.calls() != 3 {
+		t.Errorf("provider calls = %d, want 3", provider.calls())
+	}
+	for _, batch := range provider.batches {
+		if len(batch) > 2 {
+			t.Errorf("batch %v exceeds batch size", batch)
+		}
+	}
+	if client.GetModel() != "counting" || client.GetDimensions() != 1 {
+		t.Errorf("model info not forwarded")
+	}
+}
+
+func TestClient_Cache(t *testing.T) {
+	ctx := context.Background()
+	provider := &countingEmbedder{}
+	cache := NewMemoryCache(0, 0)
+	client, _ := New(Config{Embedder: provider, Cache: cache})
+
+	if _, err := client.Embed(ctx, []string{"a", "bb"}); err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	got, err := client.Embed(ctx, []string{"bb", "ccc"})
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	if got[0][0] != 2 || got[1][0] != 3 {
+		t.Errorf("Embed() = %v", got)
+	}
+	if provider.calls() != 2 || len(provider.batches[1]) != 1 || provider.batches[1][0] != "ccc" {
+		t.Errorf("cached text was embedded again: %v", provider.batches)
+	}
+	if cache.Len() != 3 {
+		t.Errorf("cache.Len() = %d, want 3", cache.Len())
+	}
+
+	// A different namespace does not share entries.
+	other, _ := New(Config{Embedder: provider, Cache: cache, CacheNamespace: "other-model"})
+	if _, err := other.EmbedSingle(ctx, "a"); err != nil {
+		t.Fatalf("EmbedSingle() error = %v", err)
+	}
+	if provider.calls() != 3 {
+		t.Errorf("provider calls = %d, want 3", provider.calls())
+	}
+}
+
+func TestClient_Error(t *testing.T) {
+	provider := &countingEmbedder{err: errors.New("provider down")}
+	client, _ := New(Config{Embedder: provider, BatchSize: 1, MaxConcurrency: 1})
+
+	_, err := client.Embed(context.Background(), []string{"a", "b", "c"})
+	if err == nil || !errors.Is(err, provider.err) {
+		t.Fatalf("Embed() error = %v, want provider error", err)
+	}
+	if provider.calls() != 1 {
+		t.Errorf("provider calls = %d, want 1 (later batches cancelled)", provider.calls())
+	}
+}
+
+func TestClient_RateLimit(t *testing.T) {
+	provider := &countingEmbedder{}
+	client, _ := New(Config{Embedder: provider, BatchSize: 1, RequestsPerMinute: 2})
+
+	if _, err := client.Embed(context.Background(), []string{"a", "b"}); err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.Embed(ctx, []string{"c"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Embed() error = %v, want deadline exceeded while rate limited", err)
+	}
+	if provider.calls() != 2 {
+		t.Errorf("provider calls = %d, want 2", provider.calls())
+	}
+}
+
+func TestLimiter_Refills(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newLimiter(60) // one token per second
+	l.now = func() time.Time { return now }
+	l.last = now
+
+	ctx := context.Background()
+	if err := l.wait(ctx, 60); err != nil {
+		t.Fatalf("wait() error = %v", err)
+	}
+	now = now.Add(2 * time.Second)
+	if err := l.wait(ctx, 2); err != nil {
+		t.Fatalf("wait() error = %v", err)
+	}
+	if l.tokens != 0 {
+		t.Errorf("tokens = %v, want 0", l.tokens)
+	}
+}
+
+func TestCachedEmbedder(t *testing.T) {
+	ctx := context.Background()
+	if _, err := NewCachedEmbedder(&countingEmbedder{}, nil, ""); err == nil {
+		t.Error("expected error without cache")
+	}
+
+	provider := &countingEmbedder{}
+	cache := NewMemoryCache(0, 0)
+	embedder, err := NewCachedEmbedder(provider, cache, "")
+	if err != nil {
+		t.Fatalf("NewCachedEmbedder() error = %v", err)
+	}
+
+	if _, err := embedder.Embed(ctx, []string{"a", "bb", "a"}); err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	got, err := embedder.Embed(ctx, []string{"bb", "ccc"})
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	if got[0][0] != 2 || got[1][0] != 3 {
+		t.Errorf("Embed() = %v", got)
+	}
+	if provider.calls() != 2 || len(provider.batches[0]) != 2 || len(provider.batches[1]) != 1 {
+		t.Errorf("provider batches = %v", provider.batches)
+	}
+
+	// The namespace defaults to the model, so Client and CachedEmbedder share entries.
+	if _, ok, _ := cache.Get(ctx, CacheKey("counting", "ccc")); !ok {
+		t.Error("entry not keyed by model namespace")
+	}
+}
+
+func TestEncodeEmbedding_RoundTrip(t *testing.T) {
+	want := []float32{0, 1.5, -2.25, 1e-7}
+	got, err := DecodeEmbedding(EncodeEmbedding(want))
+	if err != nil {
+		t.Fatalf("DecodeEmbedding() error = %v", err)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("DecodeEmbedding() = %v, want %v", got, want)
+		}
+	}
+	if _, err := DecodeEmbedding([]byte{1, 2, 3}); err == nil {
+		t.Error("expected error for truncated data")
+	}
+}
@@ -0,0 +1,260 @@
+// Package local embeds texts with a model file on this machine. GGUF models
+// are served by llama.cpp's llama-server and ONNX models by Hugging Face's
+// text-embeddings-router; the embedder starts the server on first use and
+// stops it on Close, or talks to a server that is already running.
+package local
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jholhewres/agent-go/internal/localserver"
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+)
+
+// Format identifies the model format and the server that runs it
+type Format string
+
+const (
+	// FormatGGUF runs a .gguf file with llama-server
+	FormatGGUF Format = "gguf"
+	// FormatONNX runs an ONNX model directory with text-embeddings-router
+	FormatONNX Format = "onnx"
+)
+
+// Config holds configuration for local embeddings
+type Config struct {
+	// ModelPath is a .gguf file or an ONNX model directory (required unless URL is set)
+	ModelPath string
+
+	// Format of the model (default: inferred from ModelPath; .gguf is GGUF, anything else ONNX)
+	Format Format
+
+	// URL of a running server; when set no process is started
+	URL string
+
+	// Command is the server binary (default: llama-server for GGUF, text-embeddings-router for ONNX)
+	Command string
+
+	// Args are extra arguments passed to the server
+	Args []string
+
+	// Port for the started server (default: a free port)
+	Port int
+
+	// StartTimeout bounds server start-up, including model loading (default: 2m)
+	StartTimeout time.Duration
+
+	// Dimensions is reported by GetDimensions (optional)
+	Dimensions int
+
+	// HTTPClient to use for requests (optional)
+	HTTPClient *http.Client
+}
+
+// Embedding implements vectordb.EmbeddingFunction with a local model server
+type Embedding struct {
+	config     Config
+	httpClient *http.Client
+	server     *localserver.Server
+}
+
+var _ vectordb.EmbeddingFunction = (*Embedding)(nil)
+
+// New creates a local embedding function. The server is started lazily.
+func New(config Config) (*Embedding, error) {
+	if config.ModelPath == "" && config.URL == "" {
+		return nil, fmt.Errorf("model path or URL is required")
+	}
+	if config.Format == "" {
+		config.Format = FormatONNX
+		if strings.EqualFold(filepath.Ext(config.ModelPath), ".gguf") {
+			config.Format = FormatGGUF
+		}
+	}
+	switch config.Format {
+	case FormatGGUF:
+		if config.Command == "" {
+			config.Command = "llama-server"
+		}
+	case FormatONNX:
+		if config.Command == "" {
+			config.Command = "text-embeddings-router"
+		}
+	default:
+		return nil, fmt.Errorf("unsupported model format %q", config.Format)
+	}
+	if config.URL == "" {
+		if _, err := os.Stat(config.ModelPath); err != nil {
+			return nil, fmt.Errorf("model not found: %w", err)
+		}
+	}
+	if config.StartTimeout <= 0 {
+		config.StartTimeout = 2 * time.Minute
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 60 * time.Second}
+	}
+
+	return &Embedding{
+		config:     config,
+		httpClient: config.HTTPClient,
+		server: localserver.New(localserver.Config{
+			Name:         "embedding server",
+			URL:          config.URL,
+			Command:      config.Command,
+			Args:         func(port int) []string { return commandArgs(config, port) },
+			Port:         config.Port,
+			StartTimeout: config.StartTimeout,
+			HTTPClient:   config.HTTPClient,
+		}),
+	}, nil
+}
+
+// Embed generates embeddings for multiple texts
+func (e *Embedding) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+	baseURL, err := e.server.URL(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var embeddings [][]float32
+	if e.config.Format == FormatGGUF {
+		embeddings, err = e.embedOpenAI(ctx, baseURL, texts)
+	} else {
+		embeddings, err = e.embedTEI(ctx, baseURL, texts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(embeddings))
+	}
+	for i, emb := range embeddings {
+		if len(emb) == 0 {
+			return nil, fmt.Errorf("missing embedding for text at index %d", i)
+		}
+	}
+	return embeddings, nil
+}
+
+// EmbedSingle generates embedding for a single text
+func (e *Embedding) EmbedSingle(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := e.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// GetModel returns the model path, or the server URL when no path is set
+func (e *Embedding) GetModel() string {
+	if e.config.ModelPath != "" {
+		return e.config.ModelPath
+	}
+	return e.config.URL
+}
+
+// GetDimensions returns the configured embedding dimensions
+func (e *Embedding) GetDimensions() int {
+	return e.config.Dimensions
+}
+
+// Close stops the server started by the embedder. Servers given by URL are left running.
+func (e *Embedding) Close() error {
+	return e.server.Close()
+}
+
+// commandArgs returns the server arguments for config
+func commandArgs(config Config, port int) []string {
+	var args []string
+	if config.Format == FormatGGUF {
+		args = []string{"-m", config.ModelPath, "--embeddings", "--host", "127.0.0.1", "--port", strconv.Itoa(port)}
+	} else {
+		args = []string{"--model-id", config.ModelPath, "--hostname", "127.0.0.1", "--port", strconv.Itoa(port)}
+	}
+	return append(args, config.Args...)
+}
+
+type openAIRequest struct {
+	Input []string `json:"input"`
+	Model string   `json:"model"`
+}
+
+type openAIResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// embedOpenAI calls llama-server's OpenAI-compatible endpoint
+func (e *Embedding) embedOpenAI(ctx context.Context, baseURL string, texts []string) ([][]float32, error) {
+	var resp openAIResponse
+	if err := e.post(ctx, baseURL+"/v1/embeddings", openAIRequest{Input: texts, Model: "local"}, &resp); err != nil {
+		return nil, err
+	}
+	embeddings := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index >= 0 && d.Index < len(texts) {
+			embeddings[d.Index] = d.Embedding
+		}
+	}
+	return embeddings, nil
+}
+
+type teiRequest struct {
+	Inputs   []string `json:"inputs"`
+	Truncate bool     `json:"truncate"`
+}
+
+// embedTEI calls text-embeddings-router's native endpoint
+func (e *Embedding) embedTEI(ctx context.Context, baseURL string, texts []string) ([][]float32, error) {
+	var embeddings [][]float32
+	if err := e.post(ctx, baseURL+"/embed", teiRequest{Inputs: texts, Truncate: true}, &embeddings); err != nil {
+		return nil, err
+	}
+	return embeddings, nil
+}
+
+func (e *Embedding) post(ctx context.Context, url string, body, out interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func fakeServer() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/v1/embeddings", func(w http.ResponseWriter, r *http.Request) {
+		var req openAIRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		data := make([]map[string]interface{}, len(req.Input))
+		for i, text := range req.Input {
+			data[i] = map[string]interface{}{"index": i, "embedding": []float32{float32(len(text)), 0}}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	})
+	mux.HandleFunc("/embed", func(w http.ResponseWriter, r *http.Request) {
+		var req teiRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		out := make([][]float32, len(req.Inputs))
+		for i, text := range req.Inputs {
+			out[i] = []float32{0, float32(len(text))}
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	})
+	return mux
+}
+
+func writeModel(t *testing.T, name string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte("model"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Config{}); err == nil {
+		t.Error("expected error without model path or URL")
+	}
+	if _, err := New(Config{ModelPath: filepath.Join(t.TempDir(), "missing.gguf")}); err == nil {
+		t.Error("expected error for missing model")
+	}
+	if _, err := New(Config{URL: "http://localhost:1", Format: "safetensors"}); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+
+	gguf, err := New(Config{ModelPath: writeModel(t, "model.GGUF")})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if gguf.config.Format != FormatGGUF || gguf.config.Command != "llama-server" {
+		t.Errorf("gguf config = %+v", gguf.config)
+	}
+	onnx, err := New(Config{ModelPath: t.TempDir()})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if onnx.config.Format != FormatONNX || onnx.config.Command != "text-embeddings-router" {
+		t.Errorf("onnx config = %+v", onnx.config)
+	}
+}
+
+func TestCommandArgs(t *testing.T) {
+	got := commandArgs(Config{Format: FormatGGUF, ModelPath: "m.gguf", Args: []string{"-c", "512"}}, 9000)
+	want := []string{"-m", "m.gguf", "--embeddings", "--host", "127.0.0.1", "--port", "9000", "-c", "512"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("gguf args = %v, want %v", got, want)
+	}
+	got = commandArgs(Config{Format: FormatONNX, ModelPath: "bge"}, 9000)
+	want = []string{"--model-id", "bge", "--hostname", "127.0.0.1", "--port", "9000"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("onnx args = %v, want %v", got, want)
+	}
+}
+
+func TestEmbed_ExistingServer(t *testing.T) {
+	srv := httptest.NewServer(fakeServer())
+	defer srv.Close()
+	ctx := context.Background()
+
+	for _, format := range []Format{FormatGGUF, FormatONNX} {
+		e, err := New(Config{URL: srv.URL + "/", Format: format, Dimensions: 2})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		embeddings, err := e.Embed(ctx, []string{"a", "bbb"})
+		if err != nil {
+			t.Fatalf("%s: Embed() error = %v", format, err)
+		}
+		if len(embeddings) != 2 || embeddings[1][0]+embeddings[1][1] != 3 {
+			t.Errorf("%s: Embed() = %v", format, embeddings)
+		}
+		if e.GetDimensions() != 2 || e.Close() != nil {
+			t.Errorf("%s: unexpected dimensions or close error", format)
+		}
+	}
+}
+
+func TestEmbed_ServerFailsToStart(t *testing.T) {
+	e, err := New(Config{ModelPath: writeModel(t, "model.gguf"), Command: filepath.Join(t.TempDir(), "no-such-binary")})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := e.EmbedSingle(context.Background(), "hello"); err == nil {
+		t.Fatal("expected start error")
+	}
+}
@@ -0,0 +1,62 @@
+package embeddings
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// limiter is a token bucket refilled at perMinute tokens per minute. A nil
+// limiter never waits.
+type limiter struct {
+	mu       sync.Mutex
+	capacity float64
+	rate     float64 // tokens per second
+	tokens   float64
+	last     time.Time
+	now      func() time.Time
+}
+
+func newLimiter(perMinute int) *limiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &limiter{
+		capacity: float64(perMinute),
+		rate:     float64(perMinute) / 60,
+		tokens:   float64(perMinute),
+		last:     time.Now(),
+		now:      time.Now,
+	}
+}
+
+// wait blocks until n tokens are available and takes them. Requests larger
+// than the bucket wait for a full bucket.
+func (l *limiter) wait(ctx context.Context, n int) error {
+	if l == nil {
+		return nil
+	}
+	need := min(float64(n), l.capacity)
+
+	for {
+		l.mu.Lock()
+		now := l.now()
+		l.tokens = min(l.capacity, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+		if l.tokens >= need {
+			l.tokens -= need
+			l.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((need - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,87 @@
+//go:build redis
+
+// Package rediscache implements embeddings.Cache on top of Redis, so cached
+// embeddings are shared by every process using the same server.
+package rediscache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/embeddings"
+	"github.com/redis/go-redis/v9"
+)
+
+// Config for the Redis embedding cache
+type Config struct {
+	// Client is an existing client to use; when nil one is created from Addr
+	Client redis.UniversalClient
+	// Addr like "localhost:6379"
+	Addr string
+	// Password optional
+	Password string
+	// DB index
+	DB int
+	// KeyPrefix is prepended to every key (default: "embedding:")
+	KeyPrefix string
+	// TTL expires entries after this long (0 = never)
+	TTL time.Duration
+}
+
+// Cache stores embeddings as Redis strings keyed by content hash
+type Cache struct {
+	client redis.UniversalClient
+	owned  bool
+	prefix string
+	ttl    time.Duration
+}
+
+var _ embeddings.Cache = (*Cache)(nil)
+
+// New creates a Redis embedding cache
+func New(cfg Config) (*Cache, error) {
+	if cfg.TTL < 0 {
+		return nil, fmt.Errorf("ttl must not be negative")
+	}
+	client, owned := cfg.Client, false
+	if client == nil {
+		client = redis.NewClient(&redis.Options{Addr: cfg.Addr, Password: cfg.Password, DB: cfg.DB})
+		owned = true
+	}
+	prefix := cfg.KeyPrefix
+	if prefix == "" {
+		prefix = "embedding:"
+	}
+	return &Cache{client: client, owned: owned, prefix: prefix, ttl: cfg.TTL}, nil
+}
+
+// Get implements embeddings.Cache
+func (c *Cache) Get(ctx context.Context, key string) ([]float32, bool, error) {
+	data, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	embedding, err := embeddings.DecodeEmbedding(data)
+	if err != nil {
+		return nil, false, err
+	}
+	return embedding, true, nil
+}
+
+// Set implements embeddings.Cache
+func (c *Cache) Set(ctx context.Context, key string, embedding []float32) error {
+	return c.client.Set(ctx, c.prefix+key, embeddings.EncodeEmbedding(embedding), c.ttl).Err()
+}
+
+// Close closes the client if the cache created it
+func (c *Cache) Close() error {
+	if c.owned {
+		return c.client.Close()
+	}
+	return nil
+}
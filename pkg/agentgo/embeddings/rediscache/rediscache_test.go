@@ -0,0 +1,37 @@
+//go:build redis
+
+package rediscache
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+func TestCache_Smoke(t *testing.T) {
+	if os.Getenv("TEST_REDIS_EMBEDDING_CACHE") != "1" {
+		t.Skip("set TEST_REDIS_EMBEDDING_CACHE=1 to run redis embedding cache test")
+	}
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+	cache, err := New(Config{Addr: addr, KeyPrefix: "test-embedding:"})
+	if err != nil {
+		t.Fatalf("new redis cache: %v", err)
+	}
+	defer cache.Close()
+
+	ctx := context.Background()
+	defer cache.client.Del(ctx, "test-embedding:k")
+	if err := cache.Set(ctx, "k", []float32{0.25, -2}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	got, ok, err := cache.Get(ctx, "k")
+	if err != nil || !ok || len(got) != 2 || got[0] != 0.25 || got[1] != -2 {
+		t.Fatalf("get = %v, %v, %v", got, ok, err)
+	}
+	if _, ok, err := cache.Get(ctx, "missing"); err != nil || ok {
+		t.Fatalf("get missing = %v, %v", ok, err)
+	}
+}
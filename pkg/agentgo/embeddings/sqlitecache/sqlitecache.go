@@ -0,0 +1,143 @@
+// Package sqlitecache implements embeddings.Cache on top of SQLite, so cached
+// embeddings survive restarts and can be shared by processes on one host.
+package sqlitecache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/embeddings"
+)
+
+const (
+	defaultTable            = "embedding_cache"
+	defaultOperationTimeout = 2 * time.Second
+)
+
+var identifierPattern = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
+// Config configures the SQLite embedding cache.
+type Config struct {
+	// Table is the cache table name (default: embedding_cache).
+	Table string
+
+	// TTL expires entries after this long (0 = never).
+	TTL time.Duration
+
+	// OperationTimeout bounds every query (default: 2s).
+	OperationTimeout time.Duration
+}
+
+// Cache stores embeddings in a SQLite table keyed by content hash.
+type Cache struct {
+	db      *sql.DB
+	table   string
+	ttl     time.Duration
+	timeout time.Duration
+	now     func() time.Time
+}
+
+var _ embeddings.Cache = (*Cache)(nil)
+
+// New creates the cache table if needed and returns a Cache. The caller owns
+// db.
+func New(db *sql.DB, cfg Config) (*Cache, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db cannot be nil")
+	}
+
+	table := cfg.Table
+	if table == "" {
+		table = defaultTable
+	}
+	if !identifierPattern.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name: %s", table)
+	}
+	if cfg.TTL < 0 {
+		return nil, fmt.Errorf("ttl must not be negative")
+	}
+
+	timeout := cfg.OperationTimeout
+	if timeout <= 0 {
+		timeout = defaultOperationTimeout
+	}
+
+	c := &Cache{db: db, table: table, ttl: cfg.TTL, timeout: timeout, now: time.Now}
+	if err := c.ensureSchema(); err != nil {
+		return nil, fmt.Errorf("failed to create schema: %w", err)
+	}
+	return c, nil
+}
+
+func (c *Cache) ensureSchema() error {
+	_, err := c.db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		key TEXT PRIMARY KEY,
+		embedding BLOB NOT NULL,
+		expires_at INTEGER
+	)`, c.table))
+	return err
+}
+
+// Get implements embeddings.Cache. Expired entries are reported as misses.
+func (c *Cache) Get(ctx context.Context, key string) ([]float32, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var (
+		data      []byte
+		expiresAt sql.NullInt64
+	)
+	err := c.db.QueryRowContext(ctx,
+		fmt.Sprintf(`SELECT embedding, expires_at FROM %s WHERE key = ?`, c.table), key,
+	).Scan(&data, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if expiresAt.Valid && c.now().UnixNano() >= expiresAt.Int64 {
+		return nil, false, nil
+	}
+
+	embedding, err := embeddings.DecodeEmbedding(data)
+	if err != nil {
+		return nil, false, err
+	}
+	return embedding, true, nil
+}
+
+// Set implements embeddings.Cache
+func (c *Cache) Set(ctx context.Context, key string, embedding []float32) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var expiresAt sql.NullInt64
+	if c.ttl > 0 {
+		expiresAt = sql.NullInt64{Int64: c.now().Add(c.ttl).UnixNano(), Valid: true}
+	}
+	_, err := c.db.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (key, embedding, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET embedding = excluded.embedding, expires_at = excluded.expires_at`, c.table),
+		key, embeddings.EncodeEmbedding(embedding), expiresAt)
+	return err
+}
+
+// Prune deletes expired entries and returns how many were removed
+func (c *Cache) Prune(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	result, err := c.db.ExecContext(ctx,
+		fmt.Sprintf(`DELETE FROM %s WHERE expires_at IS NOT NULL AND expires_at <= ?`, c.table),
+		c.now().UnixNano())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
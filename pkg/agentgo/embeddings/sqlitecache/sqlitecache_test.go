@@ -0,0 +1,116 @@
+package sqlitecache
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/embeddings"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file:"+t.Name()+"?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("sql.Open failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(nil, Config{}); err == nil {
+		t.Fatal("expected error for nil db")
+	}
+	if _, err := New(openTestDB(t), Config{Table: "bad;name"}); err == nil {
+		t.Fatal("expected error for invalid table name")
+	}
+}
+
+func TestCache_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	cache, err := New(openTestDB(t), Config{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if _, ok, err := cache.Get(ctx, "missing"); err != nil || ok {
+		t.Fatalf("Get(missing) = %v, %v", ok, err)
+	}
+	if err := cache.Set(ctx, "k", []float32{0.5, -1, 3}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := cache.Set(ctx, "k", []float32{1, 2}); err != nil {
+		t.Fatalf("Set() overwrite error = %v", err)
+	}
+	got, ok, err := cache.Get(ctx, "k")
+	if err != nil || !ok || len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("Get() = %v, %v, %v", got, ok, err)
+	}
+}
+
+func TestCache_TTL(t *testing.T) {
+	ctx := context.Background()
+	cache, err := New(openTestDB(t), Config{TTL: time.Minute})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	if err := cache.Set(ctx, "k", []float32{1}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if _, ok, _ := cache.Get(ctx, "k"); !ok {
+		t.Fatal("entry should be cached before the TTL")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok, _ := cache.Get(ctx, "k"); ok {
+		t.Fatal("entry should expire after the TTL")
+	}
+	if n, err := cache.Prune(ctx); err != nil || n != 1 {
+		t.Fatalf("Prune() = %d, %v", n, err)
+	}
+}
+
+func TestCache_WithCachedEmbedder(t *testing.T) {
+	cache, err := New(openTestDB(t), Config{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	calls := 0
+	provider := embedFunc(func(texts []string) [][]float32 {
+		calls++
+		out := make([][]float32, len(texts))
+		for i, text := range texts {
+			out[i] = []float32{float32(len(text))}
+		}
+		return out
+	})
+	embedder, err := embeddings.NewCachedEmbedder(provider, cache, "test")
+	if err != nil {
+		t.Fatalf("NewCachedEmbedder() error = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		got, err := embedder.EmbedSingle(context.Background(), "hello")
+		if err != nil || got[0] != 5 {
+			t.Fatalf("EmbedSingle() = %v, %v", got, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("provider calls = %d, want 1", calls)
+	}
+}
+
+type embedFunc func(texts []string) [][]float32
+
+func (f embedFunc) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	return f(texts), nil
+}
+
+func (f embedFunc) EmbedSingle(_ context.Context, text string) ([]float32, error) {
+	return f([]string{text})[0], nil
+}
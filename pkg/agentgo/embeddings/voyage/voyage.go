@@ -0,0 +1,204 @@
+package voyage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+)
+
+// Input types accepted by Voyage embedding models
+const (
+	InputDocument = "document"
+	InputQuery    = "query"
+)
+
+// maxBatchSize is the number of texts Voyage accepts per request
+const maxBatchSize = 1000
+
+// Embedding implements vectordb.EmbeddingFunction using Voyage AI's API
+type Embedding struct {
+	apiKey     string
+	model      string
+	inputType  string
+	dimensions int
+	baseURL    string
+	httpClient *http.Client
+}
+
+// Config holds configuration for Voyage embeddings
+type Config struct {
+	// APIKey for Voyage API
+	APIKey string
+
+	// Model to use (default: voyage-3.5)
+	// Options: voyage-3.5, voyage-3.5-lite, voyage-3-large, voyage-code-3, ...
+	Model string
+
+	// InputType is "document", "query" or empty for no prompt (default: empty).
+	// Use a second embedder with InputQuery for queries.
+	InputType string
+
+	// Dimensions requests a smaller output size on models that support it (optional)
+	Dimensions int
+
+	// BaseURL for Voyage API (default: https://api.voyageai.com/v1)
+	BaseURL string
+
+	// HTTPClient to use for requests (optional)
+	HTTPClient *http.Client
+}
+
+type embeddingRequest struct {
+	Input           []string `json:"input"`
+	Model           string   `json:"model"`
+	InputType       string   `json:"input_type,omitempty"`
+	OutputDimension int      `json:"output_dimension,omitempty"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Model string `json:"model"`
+}
+
+type errorResponse struct {
+	Detail string `json:"detail"`
+}
+
+var _ vectordb.EmbeddingFunction = (*Embedding)(nil)
+
+// New creates a new Voyage embedding function
+func New(config Config) (*Embedding, error) {
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("API key is required")
+	}
+	if config.InputType != "" && config.InputType != InputDocument && config.InputType != InputQuery {
+		return nil, fmt.Errorf("unsupported input type %q", config.InputType)
+	}
+	if config.Model == "" {
+		config.Model = "voyage-3.5"
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = "https://api.voyageai.com/v1"
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return &Embedding{
+		apiKey:     config.APIKey,
+		model:      config.Model,
+		inputType:  config.InputType,
+		dimensions: config.Dimensions,
+		baseURL:    config.BaseURL,
+		httpClient: config.HTTPClient,
+	}, nil
+}
+
+// Embed generates embeddings for multiple texts
+func (e *Embedding) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+
+	embeddings := make([][]float32, 0, len(texts))
+	for i := 0; i < len(texts); i += maxBatchSize {
+		end := min(i+maxBatchSize, len(texts))
+		batch, err := e.embed(ctx, texts[i:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed batch %d-%d: %w", i, end, err)
+		}
+		embeddings = append(embeddings, batch...)
+	}
+	return embeddings, nil
+}
+
+func (e *Embedding) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	data, err := json.Marshal(embeddingRequest{
+		Input:           texts,
+		Model:           e.model,
+		InputType:       e.inputType,
+		OutputDimension: e.dimensions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/embeddings", bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+e.apiKey)
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var errResp errorResponse
+		if err := json.Unmarshal(body, &errResp); err != nil || errResp.Detail == "" {
+			return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		}
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, errResp.Detail)
+	}
+
+	var embResp embeddingResponse
+	if err := json.Unmarshal(body, &embResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	embeddings := make([][]float32, len(texts))
+	for _, d := range embResp.Data {
+		if d.Index >= 0 && d.Index < len(texts) {
+			embeddings[d.Index] = d.Embedding
+		}
+	}
+	for i, emb := range embeddings {
+		if len(emb) == 0 {
+			return nil, fmt.Errorf("missing embedding for text at index %d", i)
+		}
+	}
+	return embeddings, nil
+}
+
+// EmbedSingle generates embedding for a single text
+func (e *Embedding) EmbedSingle(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := e.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// GetModel returns the model name being used
+func (e *Embedding) GetModel() string {
+	return e.model
+}
+
+// GetDimensions returns the expected embedding dimensions for the model
+func (e *Embedding) GetDimensions() int {
+	if e.dimensions > 0 {
+		return e.dimensions
+	}
+	switch e.model {
+	case "voyage-3.5-lite", "voyage-3-lite":
+		return 512
+	default:
+		return 1024
+	}
+}
@@ -0,0 +1,70 @@
+package voyage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Config{}); err == nil {
+		t.Error("expected error without API key")
+	}
+	if _, err := New(Config{APIKey: "key", InputType: "passage"}); err == nil {
+		t.Error("expected error for unsupported input type")
+	}
+	e, err := New(Config{APIKey: "key"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if e.GetModel() != "voyage-3.5" || e.GetDimensions() != 1024 {
+		t.Errorf("unexpected defaults: %s %d", e.GetModel(), e.GetDimensions())
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	var got embeddingRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embeddings" || r.Header.Get("Authorization") != "Bearer key" {
+			http.Error(w, `{"detail":"bad request"}`, http.StatusBadRequest)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		// Out of order on purpose: results are placed by index.
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"index": 1, "embedding": []float32{0, 1}},
+				{"index": 0, "embedding": []float32{1, 0}},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	e, _ := New(Config{APIKey: "key", BaseURL: srv.URL, InputType: InputQuery})
+	embeddings, err := e.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	if embeddings[0][0] != 1 || embeddings[1][1] != 1 {
+		t.Errorf("Embed() = %v", embeddings)
+	}
+	if got.InputType != InputQuery || got.Model != "voyage-3.5" {
+		t.Errorf("request = %+v", got)
+	}
+}
+
+func TestEmbed_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"detail":"Rate limit exceeded"}`, http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	e, _ := New(Config{APIKey: "key", BaseURL: srv.URL})
+	_, err := e.EmbedSingle(context.Background(), "a")
+	if err == nil || !strings.Contains(err.Error(), "Rate limit exceeded") || !strings.Contains(err.Error(), "status 429") {
+		t.Fatalf("EmbedSingle() error = %v", err)
+	}
+}
@@ -0,0 +1,294 @@
+// Package bench runs a prompt set against several model or configuration
+// variants of an agent and reports latency percentiles, token cost and
+// quality scores side by side, to find the cheapest variant that is good
+// enough.
+package bench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/agent"
+	"github.com/jholhewres/agent-go/pkg/agentgo/eval"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// Variant is one model or configuration under test.
+type Variant struct {
+	Name string
+	// New builds the agent for the variant. A fresh agent is built for every
+	// run so conversation memory does not leak between prompts (required).
+	// Set agent.Config.Pricing to get costs.
+	New func() (*agent.Agent, error)
+}
+
+// Config configures a benchmark.
+type Config struct {
+	Prompts  []*eval.TestCase
+	Variants []Variant
+	// Evaluators score the runs of each variant; their pass rates are the
+	// quality scores of the variant.
+	Evaluators []eval.Evaluator
+	// Repetitions is how many times each prompt is run per variant (default 1).
+	Repetitions int
+	// Concurrency is the number of runs in flight across all variants (default 1).
+	Concurrency int
+}
+
+// Result is the benchmark of one variant.
+type Result struct {
+	Variant    string        `json:"variant"`
+	Runs       int           `json:"runs"`
+	Errors     int           `json:"errors"`
+	LatencyP50 time.Duration `json:"latency_p50"`
+	LatencyP95 time.Duration `json:"latency_p95"`
+	LatencyP99 time.Duration `json:"latency_p99"`
+	// Usage is the total over all runs; Usage.EstimatedCost is zero for
+	// models without pricing.
+	Usage      types.Usage `json:"usage"`
+	CostPerRun float64     `json:"cost_per_run"`
+	// Quality is the pass rate of each evaluator, keyed by evaluator name.
+	Quality map[string]float64      `json:"quality,omitempty"`
+	Reports map[string]*eval.Report `json:"reports,omitempty"`
+	// EvalRuns are the individual runs, in prompt order.
+	EvalRuns []*eval.EvalRun `json:"-"`
+}
+
+// QualityScore is the mean pass rate over all evaluators, or 1 without evaluators.
+func (r *Result) QualityScore() float64 {
+	if len(r.Quality) == 0 {
+		return 1
+	}
+	sum := 0.0
+	for _, q := range r.Quality {
+		sum += q
+	}
+	return sum / float64(len(r.Quality))
+}
+
+// Report is the outcome of a benchmark, with one result per variant in the
+// order the variants were configured.
+type Report struct {
+	Results   []*Result `json:"results"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Run benchmarks every variant on every prompt. Run failures are counted per
+// variant; only a cancelled context or a failing evaluator aborts the
+// benchmark.
+func Run(ctx context.Context, cfg Config) (*Report, error) {
+	if len(cfg.Variants) == 0 {
+		return nil, fmt.Errorf("bench: at least one variant is required")
+	}
+	for i, v := range cfg.Variants {
+		if v.New == nil {
+			return nil, fmt.Errorf("bench: variant %d (%q) has no New function", i, v.Name)
+		}
+	}
+	if cfg.Repetitions <= 0 {
+		cfg.Repetitions = 1
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+
+	perVariant := len(cfg.Prompts) * cfg.Repetitions
+	runs := make([][]*eval.EvalRun, len(cfg.Variants))
+	for i := range runs {
+		runs[i] = make([]*eval.EvalRun, perVariant)
+	}
+
+	sem := make(chan struct{}, cfg.Concurrency)
+	var wg sync.WaitGroup
+	// Interleave variants so a slow variant does not hold back the others.
+	for j := 0; j < perVariant && ctx.Err() == nil; j++ {
+		for i, v := range cfg.Variants {
+			if ctx.Err() != nil {
+				break
+			}
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i, j int, v Variant) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				runs[i][j] = runPrompt(ctx, v, cfg.Prompts[j/cfg.Repetitions])
+			}(i, j, v)
+		}
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("benchmark cancelled: %w", err)
+	}
+
+	rep := &Report{Results: make([]*Result, len(cfg.Variants)), Timestamp: time.Now()}
+	for i, v := range cfg.Variants {
+		res, err := summarize(ctx, v.Name, runs[i], cfg.Evaluators)
+		if err != nil {
+			return nil, err
+		}
+		rep.Results[i] = res
+	}
+	return rep, nil
+}
+
+// runPrompt runs one prompt on a fresh agent of the variant.
+func runPrompt(ctx context.Context, v Variant, tc *eval.TestCase) *eval.EvalRun {
+	r := &eval.EvalRun{
+		Input:          tc.Input,
+		ExpectedOutput: tc.Expected,
+		Metadata:       map[string]any{"case_name": tc.Name, "tags": tc.Tags, "variant": v.Name},
+	}
+	a, err := v.New()
+	if err != nil {
+		r.Err = fmt.Errorf("build agent: %w", err)
+		return r
+	}
+	start := time.Now()
+	r.Output, r.Err = a.Run(ctx, tc.Input)
+	r.Duration = time.Since(start)
+	return r
+}
+
+// summarize computes the result of one variant from its runs.
+func summarize(ctx context.Context, name string, runs []*eval.EvalRun, evaluators []eval.Evaluator) (*Result, error) {
+	res := &Result{Variant: name, Runs: len(runs), EvalRuns: runs}
+	latencies := make([]time.Duration, 0, len(runs))
+	for _, r := range runs {
+		if r.Err != nil {
+			res.Errors++
+		}
+		if r.Output != nil {
+			res.Usage = res.Usage.Add(r.Output.Usage)
+		}
+		if r.Duration > 0 {
+			latencies = append(latencies, r.Duration)
+		}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	res.LatencyP50 = percentile(latencies, 50)
+	res.LatencyP95 = percentile(latencies, 95)
+	res.LatencyP99 = percentile(latencies, 99)
+	if res.Runs > 0 {
+		res.CostPerRun = res.Usage.EstimatedCost / float64(res.Runs)
+	}
+
+	if len(evaluators) > 0 {
+		res.Quality = make(map[string]float64, len(evaluators))
+		res.Reports = make(map[string]*eval.Report, len(evaluators))
+	}
+	for _, ev := range evaluators {
+		rep, err := ev.Evaluate(ctx, runs)
+		if err != nil {
+			return nil, fmt.Errorf("evaluator %q failed for variant %q: %w", ev.Name(), name, err)
+		}
+		res.Quality[ev.Name()] = rep.PassRate
+		res.Reports[ev.Name()] = rep
+	}
+	return res, nil
+}
+
+// percentile returns the p-th percentile of a sorted slice (nearest-rank).
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := (p * len(sorted)) / 100
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
+
+// Cheapest returns the cheapest variant whose quality score is at least
+// minQuality and whose runs all succeeded, or nil if none qualifies. Cost is
+// compared per run, then total tokens (for variants without pricing), then
+// p95 latency.
+func (r *Report) Cheapest(minQuality float64) *Result {
+	var best *Result
+	for _, res := range r.Results {
+		if res.Errors > 0 || res.QualityScore() < minQuality {
+			continue
+		}
+		if best == nil || cheaper(res, best) {
+			best = res
+		}
+	}
+	return best
+}
+
+func cheaper(a, b *Result) bool {
+	if a.CostPerRun != b.CostPerRun {
+		return a.CostPerRun < b.CostPerRun
+	}
+	if a.Usage.TotalTokens != b.Usage.TotalTokens {
+		return a.Usage.TotalTokens < b.Usage.TotalTokens
+	}
+	return a.LatencyP95 < b.LatencyP95
+}
+
+// WriteTable writes the results side by side as an aligned text table, one
+// row per variant with a column per evaluator.
+func (r *Report) WriteTable(w io.Writer) error {
+	var names []string
+	seen := map[string]bool{}
+	for _, res := range r.Results {
+		for name := range res.Quality {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := []string{"VARIANT", "RUNS", "ERRORS", "P50", "P95", "P99", "TOKENS", "COST/RUN"}
+	for _, name := range names {
+		header = append(header, strings.ToUpper(name))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, res := range r.Results {
+		row := []string{
+			res.Variant,
+			fmt.Sprint(res.Runs),
+			fmt.Sprint(res.Errors),
+			formatLatency(res.LatencyP50),
+			formatLatency(res.LatencyP95),
+			formatLatency(res.LatencyP99),
+			fmt.Sprint(res.Usage.TotalTokens),
+			fmt.Sprintf("$%.6f", res.CostPerRun),
+		}
+		for _, name := range names {
+			if q, ok := res.Quality[name]; ok {
+				row = append(row, fmt.Sprintf("%.2f", q))
+			} else {
+				row = append(row, "-")
+			}
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func formatLatency(d time.Duration) string {
+	return fmt.Sprintf("%.0fms", float64(d)/float64(time.Millisecond))
+}
+
+// Reports returns the evaluator reports of every variant keyed
+// "<variant>/<evaluator>", for eval.WriteJSON or eval.WriteJUnit.
+func (r *Report) Reports() map[string]*eval.Report {
+	out := map[string]*eval.Report{}
+	for _, res := range r.Results {
+		for name, rep := range res.Reports {
+			out[res.Variant+"/"+name] = rep
+		}
+	}
+	return out
+}
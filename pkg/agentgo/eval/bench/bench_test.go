@@ -0,0 +1,143 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/agent"
+	"github.com/jholhewres/agent-go/pkg/agentgo/eval"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// mockModel answers with a fixed content after a delay.
+type mockModel struct {
+	models.BaseModel
+	content string
+	tokens  int
+	delay   time.Duration
+}
+
+func (m *mockModel) Invoke(ctx context.Context, _ *models.InvokeRequest) (*types.ModelResponse, error) {
+	select {
+	case <-time.After(m.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	usage := types.Usage{PromptTokens: m.tokens, CompletionTokens: m.tokens, TotalTokens: 2 * m.tokens}
+	return &types.ModelResponse{Content: m.content, Model: m.ID, Usage: usage}, nil
+}
+
+func (m *mockModel) InvokeStream(context.Context, *models.InvokeRequest) (<-chan types.ResponseChunk, error) {
+	return nil, errors.New("not supported")
+}
+
+var pricing = models.PricingTable{
+	"large": {PromptPerMillion: 10, CompletionPerMillion: 30},
+	"small": {PromptPerMillion: 1, CompletionPerMillion: 2},
+	"tiny":  {PromptPerMillion: 0.1, CompletionPerMillion: 0.2},
+}
+
+func variant(id, content string, tokens int, delay time.Duration) Variant {
+	return Variant{Name: id, New: func() (*agent.Agent, error) {
+		m := &mockModel{BaseModel: models.BaseModel{ID: id, Provider: "mock"}, content: content, tokens: tokens, delay: delay}
+		return agent.New(agent.Config{Model: m, Pricing: pricing})
+	}}
+}
+
+var prompts = []*eval.TestCase{
+	{Name: "france", Input: "Capital of France?", Expected: "Paris"},
+	{Name: "paris", Input: "Where is the Eiffel tower?", Expected: "Paris"},
+}
+
+func TestRun(t *testing.T) {
+	rep, err := Run(context.Background(), Config{
+		Prompts: prompts,
+		Variants: []Variant{
+			variant("large", "Paris", 100, 5*time.Millisecond),
+			variant("small", "Paris, France", 50, time.Millisecond),
+			variant("tiny", "London", 10, 0),
+		},
+		Evaluators:  []eval.Evaluator{eval.NewAccuracyEvaluator(eval.MatchContains)},
+		Repetitions: 2,
+		Concurrency: 4,
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(rep.Results) != 3 {
+		t.Fatalf("results = %d, want 3", len(rep.Results))
+	}
+
+	large := rep.Results[0]
+	if large.Variant != "large" || large.Runs != 4 || large.Errors != 0 || len(large.EvalRuns) != 4 {
+		t.Fatalf("unexpected result: %+v", large)
+	}
+	if large.Usage.TotalTokens != 800 || large.CostPerRun <= rep.Results[1].CostPerRun {
+		t.Fatalf("usage = %+v, cost per run = %v", large.Usage, large.CostPerRun)
+	}
+	if large.LatencyP50 < 5*time.Millisecond || large.LatencyP99 < large.LatencyP50 {
+		t.Fatalf("latencies = %v %v %v", large.LatencyP50, large.LatencyP95, large.LatencyP99)
+	}
+	if large.Quality["accuracy"] != 1 || rep.Results[2].Quality["accuracy"] != 0 {
+		t.Fatalf("quality = %v / %v", large.Quality, rep.Results[2].Quality)
+	}
+
+	if best := rep.Cheapest(0.9); best == nil || best.Variant != "small" {
+		t.Fatalf("Cheapest(0.9) = %+v, want small", best)
+	}
+	if best := rep.Cheapest(0); best == nil || best.Variant != "tiny" {
+		t.Fatalf("Cheapest(0) = %+v, want tiny", best)
+	}
+
+	var buf bytes.Buffer
+	if err := rep.WriteTable(&buf); err != nil {
+		t.Fatalf("WriteTable: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || !strings.Contains(lines[0], "ACCURACY") || !strings.HasPrefix(lines[2], "small") {
+		t.Fatalf("table:\n%s", buf.String())
+	}
+
+	if reports := rep.Reports(); reports["tiny/accuracy"] == nil || len(reports) != 3 {
+		t.Fatalf("reports = %v", reports)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	broken := Variant{Name: "broken", New: func() (*agent.Agent, error) {
+		return nil, errors.New("no credentials")
+	}}
+	rep, err := Run(context.Background(), Config{
+		Prompts:  prompts,
+		Variants: []Variant{broken, variant("small", "Paris", 10, 0)},
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if rep.Results[0].Errors != 2 || rep.Results[1].Errors != 0 {
+		t.Fatalf("errors = %d / %d", rep.Results[0].Errors, rep.Results[1].Errors)
+	}
+	if best := rep.Cheapest(1); best == nil || best.Variant != "small" {
+		t.Fatalf("Cheapest = %+v, want small", best)
+	}
+
+	if _, err := Run(context.Background(), Config{Prompts: prompts}); err == nil {
+		t.Fatal("expected an error without variants")
+	}
+	if _, err := Run(context.Background(), Config{Prompts: prompts, Variants: []Variant{{Name: "x"}}}); err == nil {
+		t.Fatal("expected an error for a variant without New")
+	}
+}
+
+func TestRun_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Run(ctx, Config{Prompts: prompts, Variants: []Variant{variant("small", "Paris", 10, 0)}}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,373 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/agent"
+	"github.com/jholhewres/agent-go/pkg/agentgo/storage"
+)
+
+// ReplayCase is one stored run to replay against a candidate configuration.
+type ReplayCase struct {
+	ID       string         `json:"id"`
+	Input    string         `json:"input"`
+	Baseline string         `json:"baseline"` // Output recorded for the current configuration
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// CasesFromRuns builds replay cases from stored run records. Runs without an
+// input or that did not complete are skipped.
+func CasesFromRuns(runs []*storage.RunRecord) []*ReplayCase {
+	cases := make([]*ReplayCase, 0, len(runs))
+	for _, r := range runs {
+		if r == nil || r.Input == "" || (r.Status != "" && r.Status != string(agent.RunStatusCompleted)) {
+			continue
+		}
+		cases = append(cases, &ReplayCase{
+			ID:       r.RunID,
+			Input:    r.Input,
+			Baseline: r.Content,
+			Metadata: map[string]any{"session_id": r.SessionID, "agent_id": r.AgentID},
+		})
+	}
+	return cases
+}
+
+// LoadCorpus reads the runs of the given sessions from store and returns them
+// as replay cases. limit caps the runs read per session (<= 0 reads all).
+func LoadCorpus(ctx context.Context, store storage.SessionStorage, sessionIDs []string, limit int) ([]*ReplayCase, error) {
+	var cases []*ReplayCase
+	for _, id := range sessionIDs {
+		runs, err := store.GetRuns(ctx, id, limit)
+		if err != nil {
+			return nil, fmt.Errorf("load runs for session %q: %w", id, err)
+		}
+		cases = append(cases, CasesFromRuns(runs)...)
+	}
+	return cases, nil
+}
+
+// Embedder produces embeddings for similarity scoring. It is satisfied by
+// vectordb.EmbeddingFunction and the embeddings providers.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// DiffConfig configures a DiffRunner.
+type DiffConfig struct {
+	// Candidate builds the agent under test. A fresh agent is built for every
+	// case so conversation memory does not leak between replays (required).
+	Candidate func() (*agent.Agent, error)
+
+	// Embedder enables embedding similarity between baseline and candidate outputs.
+	Embedder Embedder
+	// MinSimilarity flags a regression below this cosine similarity (default 0.85).
+	MinSimilarity float64
+
+	// Judge enables LLM judging of the candidate output against the baseline.
+	Judge *agent.Agent
+	// JudgeCriteria overrides the default judging instructions.
+	JudgeCriteria string
+	// MinJudgeScore flags a regression below this judge score in [0, 1] (default 0.5).
+	MinJudgeScore float64
+
+	// Concurrency is the number of cases replayed in parallel (default 1).
+	Concurrency int
+}
+
+// CaseDiff is the comparison of one replayed case.
+type CaseDiff struct {
+	CaseID      string   `json:"case_id"`
+	Input       string   `json:"input"`
+	Baseline    string   `json:"baseline"`
+	Candidate   string   `json:"candidate"`
+	ExactMatch  bool     `json:"exact_match"`
+	Similarity  *float64 `json:"similarity,omitempty"`
+	JudgeScore  *float64 `json:"judge_score,omitempty"`
+	JudgeReason string   `json:"judge_reason,omitempty"`
+	Error       string   `json:"error,omitempty"`
+	Regression  bool     `json:"regression"`
+	Reasons     []string `json:"reasons,omitempty"`
+}
+
+// DiffReport summarizes a replay of a corpus against a candidate configuration.
+type DiffReport struct {
+	Total          int         `json:"total"`
+	ExactMatches   int         `json:"exact_matches"`
+	Regressions    int         `json:"regressions"`
+	Errors         int         `json:"errors"`
+	MeanSimilarity float64     `json:"mean_similarity,omitempty"`
+	MeanJudgeScore float64     `json:"mean_judge_score,omitempty"`
+	Cases          []*CaseDiff `json:"cases"`
+	Timestamp      time.Time   `json:"timestamp"`
+}
+
+// DiffRunner replays stored runs against a new prompt or model configuration
+// and diffs the outputs by exact match, embedding similarity and judge score.
+type DiffRunner struct {
+	cfg     DiffConfig
+	judgeMu sync.Mutex // The judge agent is shared across concurrent cases
+}
+
+const defaultDiffCriteria = "Compare the candidate answer with the baseline answer to the same input. " +
+	"Score 1 if the candidate is at least as correct and helpful as the baseline, 0 if it is clearly worse."
+
+// NewDiffRunner constructs a DiffRunner.
+func NewDiffRunner(cfg DiffConfig) (*DiffRunner, error) {
+	if cfg.Candidate == nil {
+		return nil, fmt.Errorf("DiffConfig.Candidate is required")
+	}
+	if cfg.MinSimilarity <= 0 {
+		cfg.MinSimilarity = 0.85
+	}
+	if cfg.MinJudgeScore <= 0 {
+		cfg.MinJudgeScore = 0.5
+	}
+	if cfg.JudgeCriteria == "" {
+		cfg.JudgeCriteria = defaultDiffCriteria
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	return &DiffRunner{cfg: cfg}, nil
+}
+
+// Run replays every case and returns the diff report. Candidate and scoring
+// failures are recorded per case; only a cancelled context aborts the run.
+func (d *DiffRunner) Run(ctx context.Context, cases []*ReplayCase) (*DiffReport, error) {
+	diffs := make([]*CaseDiff, len(cases))
+	sem := make(chan struct{}, d.cfg.Concurrency)
+	var wg sync.WaitGroup
+
+	for i, c := range cases {
+		if err := ctx.Err(); err != nil {
+			break
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, c *ReplayCase) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			diffs[i] = d.diffCase(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("replay cancelled: %w", err)
+	}
+	return summarizeDiffs(diffs), nil
+}
+
+// diffCase replays one case and scores it.
+func (d *DiffRunner) diffCase(ctx context.Context, c *ReplayCase) *CaseDiff {
+	diff := &CaseDiff{CaseID: c.ID, Input: c.Input, Baseline: c.Baseline}
+
+	candidate, err := d.cfg.Candidate()
+	if err == nil {
+		var out *agent.RunOutput
+		out, err = candidate.Run(ctx, c.Input)
+		if err == nil {
+			diff.Candidate = out.Content
+		}
+	}
+	if err != nil {
+		diff.Error = err.Error()
+		diff.Regression = true
+		diff.Reasons = append(diff.Reasons, "candidate run failed")
+		return diff
+	}
+
+	diff.ExactMatch = strings.TrimSpace(diff.Candidate) == strings.TrimSpace(c.Baseline)
+	if diff.ExactMatch {
+		return diff
+	}
+
+	scored := false
+	if d.cfg.Embedder != nil {
+		if sim, err := d.similarity(ctx, c.Baseline, diff.Candidate); err != nil {
+			diff.Reasons = append(diff.Reasons, fmt.Sprintf("similarity failed: %v", err))
+		} else {
+			scored = true
+			diff.Similarity = &sim
+			if sim < d.cfg.MinSimilarity {
+				diff.Regression = true
+				diff.Reasons = append(diff.Reasons, fmt.Sprintf("similarity %.2f below %.2f", sim, d.cfg.MinSimilarity))
+			}
+		}
+	}
+	if d.cfg.Judge != nil {
+		if score, reason, err := d.judge(ctx, c.Input, c.Baseline, diff.Candidate); err != nil {
+			diff.Reasons = append(diff.Reasons, fmt.Sprintf("judge failed: %v", err))
+		} else {
+			scored = true
+			diff.JudgeScore, diff.JudgeReason = &score, reason
+			if score < d.cfg.MinJudgeScore {
+				diff.Regression = true
+				diff.Reasons = append(diff.Reasons, fmt.Sprintf("judge score %.2f below %.2f", score, d.cfg.MinJudgeScore))
+			}
+		}
+	}
+
+	// Without a usable fuzzy score, any change is treated as a regression.
+	if !scored {
+		diff.Regression = true
+		diff.Reasons = append(diff.Reasons, "output changed")
+	}
+	return diff
+}
+
+func (d *DiffRunner) similarity(ctx context.Context, a, b string) (float64, error) {
+	vecs, err := d.cfg.Embedder.Embed(ctx, []string{a, b})
+	if err != nil {
+		return 0, err
+	}
+	if len(vecs) != 2 {
+		return 0, fmt.Errorf("expected 2 embeddings, got %d", len(vecs))
+	}
+	return cosineSimilarity(vecs[0], vecs[1]), nil
+}
+
+// diffVerdict is the JSON structure the diff judge must return.
+type diffVerdict struct {
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
+
+func (d *DiffRunner) judge(ctx context.Context, input, baseline, candidate string) (float64, string, error) {
+	prompt := fmt.Sprintf(`%s
+
+Input: %s
+Baseline: %s
+Candidate: %s
+
+Respond ONLY with valid JSON: {"score": 0.0-1.0, "reason": "..."}`,
+		d.cfg.JudgeCriteria, input, baseline, candidate)
+
+	d.judgeMu.Lock()
+	out, err := d.cfg.Judge.Run(ctx, prompt)
+	d.judgeMu.Unlock()
+	if err != nil {
+		return 0, "", err
+	}
+
+	start := strings.Index(out.Content, "{")
+	end := strings.LastIndex(out.Content, "}")
+	if start == -1 || end < start {
+		return 0, "", fmt.Errorf("no JSON object found in: %q", out.Content)
+	}
+	var v diffVerdict
+	if err := json.Unmarshal([]byte(out.Content[start:end+1]), &v); err != nil {
+		return 0, "", fmt.Errorf("unmarshal failed: %w", err)
+	}
+	return math.Max(0, math.Min(1, v.Score)), v.Reason, nil
+}
+
+func summarizeDiffs(diffs []*CaseDiff) *DiffReport {
+	rep := &DiffReport{Total: len(diffs), Cases: diffs, Timestamp: time.Now()}
+	var simSum, judgeSum float64
+	var simN, judgeN int
+	for _, d := range diffs {
+		if d.ExactMatch {
+			rep.ExactMatches++
+		}
+		if d.Regression {
+			rep.Regressions++
+		}
+		if d.Error != "" {
+			rep.Errors++
+		}
+		if d.Similarity != nil {
+			simSum += *d.Similarity
+			simN++
+		}
+		if d.JudgeScore != nil {
+			judgeSum += *d.JudgeScore
+			judgeN++
+		}
+	}
+	if simN > 0 {
+		rep.MeanSimilarity = simSum / float64(simN)
+	}
+	if judgeN > 0 {
+		rep.MeanJudgeScore = judgeSum / float64(judgeN)
+	}
+	return rep
+}
+
+// Report converts the diff into a Report so it can be written with WriteJSON
+// or WriteJUnit alongside other evaluators. Regressions become failures.
+func (r *DiffReport) Report() *Report {
+	passRate := 0.0
+	if r.Total > 0 {
+		passRate = float64(r.Total-r.Regressions) / float64(r.Total)
+	}
+	rep := &Report{
+		Evaluator: "diff",
+		PassRate:  passRate,
+		Metrics: map[string]float64{
+			"pass_rate":        passRate,
+			"total":            float64(r.Total),
+			"exact_matches":    float64(r.ExactMatches),
+			"regressions":      float64(r.Regressions),
+			"errors":           float64(r.Errors),
+			"mean_similarity":  r.MeanSimilarity,
+			"mean_judge_score": r.MeanJudgeScore,
+		},
+		Timestamp: r.Timestamp,
+	}
+	for _, c := range r.Cases {
+		if c.Regression {
+			rep.Failures = append(rep.Failures, &Failure{
+				Input:    c.Input,
+				Expected: c.Baseline,
+				Actual:   c.Candidate,
+				Reason:   strings.Join(c.Reasons, "; "),
+			})
+		}
+	}
+	return rep
+}
+
+// Summary returns a short human-readable summary listing the regressed cases.
+func (r *DiffReport) Summary() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d cases: %d exact, %d regressions, %d errors", r.Total, r.ExactMatches, r.Regressions, r.Errors)
+	if r.MeanSimilarity > 0 {
+		fmt.Fprintf(&sb, ", mean similarity %.2f", r.MeanSimilarity)
+	}
+	if r.MeanJudgeScore > 0 {
+		fmt.Fprintf(&sb, ", mean judge score %.2f", r.MeanJudgeScore)
+	}
+	sb.WriteString("\n")
+	for _, c := range r.Cases {
+		if c.Regression {
+			fmt.Fprintf(&sb, "- %s: %s\n", c.CaseID, strings.Join(c.Reasons, "; "))
+		}
+	}
+	return sb.String()
+}
+
+// cosineSimilarity returns the cosine similarity of two vectors, or 0 when
+// either is empty or their lengths differ.
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,130 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/agent"
+	"github.com/jholhewres/agent-go/pkg/agentgo/storage"
+)
+
+// newCandidate returns a factory for agents that always answer with content.
+func newCandidate(content string) func() (*agent.Agent, error) {
+	return func() (*agent.Agent, error) {
+		return newJudgeAgent(content), nil
+	}
+}
+
+// mockEmbedder maps known texts to fixed vectors.
+type mockEmbedder map[string][]float32
+
+func (m mockEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		v, ok := m[t]
+		if !ok {
+			return nil, errors.New("unknown text")
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func TestCasesFromRuns(t *testing.T) {
+	cases := CasesFromRuns([]*storage.RunRecord{
+		{RunID: "r1", SessionID: "s", Input: "hi", Content: "hello", Status: "completed"},
+		{RunID: "r2", SessionID: "s", Input: "hi", Status: "cancelled"},
+		{RunID: "r3", SessionID: "s", Input: ""},
+	})
+	if len(cases) != 1 || cases[0].ID != "r1" || cases[0].Baseline != "hello" {
+		t.Fatalf("unexpected cases: %+v", cases)
+	}
+}
+
+func TestDiffRunner_ExactAndChanged(t *testing.T) {
+	runner, err := NewDiffRunner(DiffConfig{Candidate: newCandidate("4")})
+	if err != nil {
+		t.Fatalf("NewDiffRunner: %v", err)
+	}
+
+	rep, err := runner.Run(context.Background(), []*ReplayCase{
+		{ID: "same", Input: "2+2?", Baseline: "4"},
+		{ID: "changed", Input: "2+2?", Baseline: "four"},
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if rep.Total != 2 || rep.ExactMatches != 1 || rep.Regressions != 1 {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+	if !strings.Contains(rep.Summary(), "- changed: output changed") {
+		t.Errorf("summary should list the regression, got %q", rep.Summary())
+	}
+
+	r := rep.Report()
+	if r.PassRate != 0.5 || len(r.Failures) != 1 || r.Failures[0].Expected != "four" {
+		t.Errorf("unexpected converted report: %+v", r)
+	}
+}
+
+func TestDiffRunner_EmbeddingSimilarity(t *testing.T) {
+	emb := mockEmbedder{
+		"candidate": {1, 0},
+		"close":     {0.99, 0.1},
+		"far":       {0, 1},
+	}
+	runner, _ := NewDiffRunner(DiffConfig{Candidate: newCandidate("candidate"), Embedder: emb, Concurrency: 2})
+
+	rep, err := runner.Run(context.Background(), []*ReplayCase{
+		{ID: "close", Input: "q", Baseline: "close"},
+		{ID: "far", Input: "q", Baseline: "far"},
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if rep.Cases[0].Regression || rep.Cases[0].Similarity == nil {
+		t.Errorf("similar output should not regress: %+v", rep.Cases[0])
+	}
+	if !rep.Cases[1].Regression {
+		t.Errorf("dissimilar output should regress: %+v", rep.Cases[1])
+	}
+}
+
+func TestDiffRunner_JudgeScore(t *testing.T) {
+	runner, _ := NewDiffRunner(DiffConfig{
+		Candidate: newCandidate("a different answer"),
+		Judge:     newJudgeAgent(`{"score": 0.2, "reason": "less precise"}`),
+	})
+
+	rep, err := runner.Run(context.Background(), []*ReplayCase{{ID: "c", Input: "q", Baseline: "answer"}})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	c := rep.Cases[0]
+	if !c.Regression || c.JudgeScore == nil || *c.JudgeScore != 0.2 || c.JudgeReason != "less precise" {
+		t.Fatalf("unexpected case diff: %+v", c)
+	}
+	if rep.MeanJudgeScore != 0.2 {
+		t.Errorf("MeanJudgeScore = %v, want 0.2", rep.MeanJudgeScore)
+	}
+}
+
+func TestDiffRunner_CandidateError(t *testing.T) {
+	runner, _ := NewDiffRunner(DiffConfig{Candidate: func() (*agent.Agent, error) {
+		return nil, errors.New("bad config")
+	}})
+
+	rep, err := runner.Run(context.Background(), []*ReplayCase{{ID: "c", Input: "q", Baseline: "a"}})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if rep.Errors != 1 || rep.Regressions != 1 {
+		t.Errorf("unexpected report: %+v", rep)
+	}
+
+	if _, err := NewDiffRunner(DiffConfig{}); err == nil {
+		t.Error("expected error without Candidate")
+	}
+}
@@ -0,0 +1,143 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// Handler receives events. Handlers run synchronously on the goroutine of the
+// run publishing the event, so they should return quickly; use Channel to
+// consume events elsewhere.
+type Handler func(ctx context.Context, event Event)
+
+type subscription struct {
+	handler Handler
+	types   map[Type]bool // nil = all types
+}
+
+// Bus delivers published events to its subscribers. It is safe for
+// concurrent use and may be shared by several agents.
+type Bus struct {
+	mu     sync.RWMutex
+	subs   map[int]*subscription
+	order  []int
+	nextID int
+
+	logger *slog.Logger
+}
+
+// NewBus creates an event bus. Handler panics are recovered and logged with
+// slog.Default().
+func NewBus() *Bus {
+	return &Bus{subs: map[int]*subscription{}, logger: slog.Default()}
+}
+
+// Subscribe registers handler for the given event types, or every event when
+// none are given. Handlers are called in subscription order. The returned
+// function removes the subscription.
+func (b *Bus) Subscribe(handler Handler, types ...Type) (unsubscribe func()) {
+	sub := &subscription{handler: handler}
+	if len(types) > 0 {
+		sub.types = make(map[Type]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.subs[id] = sub
+	b.order = append(b.order, id)
+	b.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subs, id)
+			for i, other := range b.order {
+				if other == id {
+					b.order = append(b.order[:i:i], b.order[i+1:]...)
+					break
+				}
+			}
+		})
+	}
+}
+
+// On subscribes handler to the events of type E.
+//
+//	events.On(bus, func(ctx context.Context, e *events.RunFinished) { ... })
+func On[E Event](b *Bus, handler func(ctx context.Context, event E)) (unsubscribe func()) {
+	return b.Subscribe(func(ctx context.Context, event Event) {
+		if e, ok := event.(E); ok {
+			handler(ctx, e)
+		}
+	})
+}
+
+// Channel returns a channel receiving the given event types, or every event
+// when none are given, for consumers on another goroutine such as a UI
+// stream. Events are dropped while the channel's buffer of size events is
+// full, so a slow consumer never holds up a run. The returned function
+// removes the subscription and closes the channel.
+func (b *Bus) Channel(size int, types ...Type) (<-chan Event, func()) {
+	ch := make(chan Event, size)
+	var mu sync.Mutex
+	closed := false
+	unsubscribe := b.Subscribe(func(ctx context.Context, event Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			return
+		}
+		select {
+		case ch <- event:
+		default:
+		}
+	}, types...)
+
+	return ch, func() {
+		unsubscribe()
+		mu.Lock()
+		defer mu.Unlock()
+		if !closed {
+			closed = true
+			close(ch)
+		}
+	}
+}
+
+// Publish delivers event to the matching subscribers. Publishing on a nil
+// bus does nothing.
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	if b == nil || event == nil {
+		return
+	}
+	b.mu.RLock()
+	handlers := make([]Handler, 0, len(b.order))
+	for _, id := range b.order {
+		sub := b.subs[id]
+		if sub.types == nil || sub.types[event.Type()] {
+			handlers = append(handlers, sub.handler)
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		b.call(ctx, handler, event)
+	}
+}
+
+// call runs one handler, recovering a panic so that it cannot fail the run
+func (b *Bus) call(ctx context.Context, handler Handler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.logger.Error("event handler panicked", "event", event.Type(), "panic", r)
+		}
+	}()
+	handler(ctx, event)
+}
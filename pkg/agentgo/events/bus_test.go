@@ -0,0 +1,76 @@
+package events
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBus_Subscribe(t *testing.T) {
+	bus := NewBus()
+	var all, runs []Type
+	bus.Subscribe(func(ctx context.Context, e Event) { all = append(all, e.Type()) })
+	unsubscribe := bus.Subscribe(func(ctx context.Context, e Event) { runs = append(runs, e.Type()) }, TypeRunStarted, TypeRunFinished)
+
+	ctx := context.Background()
+	bus.Publish(ctx, &RunStarted{Input: "hi"})
+	bus.Publish(ctx, &ToolExecuted{Tool: "add"})
+	unsubscribe()
+	unsubscribe()
+	bus.Publish(ctx, &RunFinished{Status: RunCompleted})
+
+	if len(all) != 3 {
+		t.Errorf("all = %v, want 3 events", all)
+	}
+	if len(runs) != 1 || runs[0] != TypeRunStarted {
+		t.Errorf("runs = %v, want [run.started]", runs)
+	}
+}
+
+func TestOn(t *testing.T) {
+	bus := NewBus()
+	var tools []string
+	On(bus, func(ctx context.Context, e *ToolExecuted) { tools = append(tools, e.Tool) })
+
+	bus.Publish(context.Background(), &RunStarted{})
+	bus.Publish(context.Background(), &ToolExecuted{Header: Header{AgentID: "a"}, Tool: "add"})
+
+	if len(tools) != 1 || tools[0] != "add" {
+		t.Errorf("tools = %v", tools)
+	}
+}
+
+func TestBus_HandlerPanic(t *testing.T) {
+	bus := NewBus()
+	called := false
+	bus.Subscribe(func(ctx context.Context, e Event) { panic("boom") })
+	bus.Subscribe(func(ctx context.Context, e Event) { called = true })
+
+	bus.Publish(context.Background(), &RunStarted{})
+	if !called {
+		t.Error("a panicking handler stopped delivery to the next one")
+	}
+}
+
+func TestBus_Channel(t *testing.T) {
+	bus := NewBus()
+	ch, unsubscribe := bus.Channel(1, TypeModelCallFinished)
+
+	bus.Publish(context.Background(), &ModelCallStarted{})
+	bus.Publish(context.Background(), &ModelCallFinished{Model: "m1"})
+	bus.Publish(context.Background(), &ModelCallFinished{Model: "m2"}) // dropped: buffer full
+	unsubscribe()
+	bus.Publish(context.Background(), &ModelCallFinished{Model: "m3"})
+
+	var got []string
+	for e := range ch {
+		got = append(got, e.(*ModelCallFinished).Model)
+	}
+	if len(got) != 1 || got[0] != "m1" {
+		t.Errorf("received %v, want [m1]", got)
+	}
+}
+
+func TestBus_NilPublish(t *testing.T) {
+	var bus *Bus
+	bus.Publish(context.Background(), &RunStarted{})
+}
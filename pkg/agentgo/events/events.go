@@ -0,0 +1,147 @@
+// Package events is a typed event bus for agent runs. An agent given a Bus
+// in agent.Config.Events publishes an event when a run starts and finishes,
+// around every model call, for every tool call and whenever a guardrail
+// fails, so logging, metrics and UI streaming can all subscribe to one
+// mechanism instead of each installing its own hooks.
+//
+//	bus := events.NewBus()
+//	events.On(bus, func(ctx context.Context, e *events.ToolExecuted) {
+//	    log.Printf("%s took %s", e.Tool, e.Duration)
+//	})
+//	ag, _ := agent.New(agent.Config{Model: model, Events: bus})
+package events
+
+import (
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// Type identifies the kind of an event
+type Type string
+
+// Event types published by agents
+const (
+	TypeRunStarted         Type = "run.started"
+	TypeRunFinished        Type = "run.finished"
+	TypeModelCallStarted   Type = "model_call.started"
+	TypeModelCallFinished  Type = "model_call.finished"
+	TypeToolExecuted       Type = "tool.executed"
+	TypeGuardrailTriggered Type = "guardrail.triggered"
+)
+
+// Run statuses reported by RunFinished
+const (
+	RunCompleted = "completed"
+	RunPaused    = "paused"
+	RunCancelled = "cancelled"
+	RunBlocked   = "blocked"
+	RunError     = "error"
+)
+
+// Event is implemented by every event type. Handlers switch on the concrete
+// type, or use On to receive a single type.
+type Event interface {
+	Type() Type
+	EventHeader() Header
+}
+
+// Header identifies the run an event belongs to
+type Header struct {
+	Time      time.Time `json:"time"`
+	AgentID   string    `json:"agent_id,omitempty"`
+	RunID     string    `json:"run_id,omitempty"`
+	SessionID string    `json:"session_id,omitempty"`
+	UserID    string    `json:"user_id,omitempty"`
+}
+
+// EventHeader returns the header
+func (h Header) EventHeader() Header {
+	return h
+}
+
+// RunStarted is published when a run begins. Resumed runs (Resume and
+// ResumeTurn) publish it too, with the input of the original run.
+type RunStarted struct {
+	Header
+	Input string `json:"input"`
+}
+
+// Type returns TypeRunStarted
+func (*RunStarted) Type() Type { return TypeRunStarted }
+
+// RunFinished is published when a run returns
+type RunFinished struct {
+	Header
+	// Status is RunCompleted, RunPaused (waiting for an approval or the next
+	// stateless turn), RunCancelled, RunBlocked (by a pre-hook or guardrail)
+	// or RunError
+	Status   string        `json:"status"`
+	Output   string        `json:"output,omitempty"`
+	Usage    types.Usage   `json:"usage"`
+	Duration time.Duration `json:"duration"`
+	Err      error         `json:"-"`
+}
+
+// Type returns TypeRunFinished
+func (*RunFinished) Type() Type { return TypeRunFinished }
+
+// ModelCallStarted is published before the model is called
+type ModelCallStarted struct {
+	Header
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	Messages int    `json:"messages"` // Messages sent
+	Tools    int    `json:"tools"`    // Tools offered
+	Stream   bool   `json:"stream"`
+}
+
+// Type returns TypeModelCallStarted
+func (*ModelCallStarted) Type() Type { return TypeModelCallStarted }
+
+// ModelCallFinished is published when the model answered or failed. For
+// streams it is published once the stream ends.
+type ModelCallFinished struct {
+	Header
+	Provider  string        `json:"provider"`
+	Model     string        `json:"model"`
+	Usage     types.Usage   `json:"usage"`
+	ToolCalls int           `json:"tool_calls"` // Tool calls requested
+	Stream    bool          `json:"stream"`
+	Duration  time.Duration `json:"duration"`
+	Err       error         `json:"-"`
+}
+
+// Type returns TypeModelCallFinished
+func (*ModelCallFinished) Type() Type { return TypeModelCallFinished }
+
+// ToolExecuted is published for every tool call the model asked for, including
+// calls that were blocked, timed out or are waiting for approval
+type ToolExecuted struct {
+	Header
+	ToolCallID string                 `json:"tool_call_id"`
+	Tool       string                 `json:"tool"`
+	Arguments  map[string]interface{} `json:"arguments,omitempty"`
+	// Status is "success", "failed", "blocked", "pending_approval" or "timeout"
+	Status   string        `json:"status"`
+	Result   interface{}   `json:"result,omitempty"` // Value returned by the tool, nil unless it succeeded
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Type returns TypeToolExecuted
+func (*ToolExecuted) Type() Type { return TypeToolExecuted }
+
+// GuardrailTriggered is published when a guardrail check fails, whatever its
+// action. A blocking guardrail that is not retried stops the run, which then
+// finishes with RunBlocked.
+type GuardrailTriggered struct {
+	Header
+	Guardrail string `json:"guardrail"`
+	Stage     string `json:"stage"`  // "input" or "output"
+	Action    string `json:"action"` // "block", "warn" or "redact"
+	Message   string `json:"message"`
+}
+
+// Type returns TypeGuardrailTriggered
+func (*GuardrailTriggered) Type() Type { return TypeGuardrailTriggered }
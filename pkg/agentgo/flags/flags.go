@@ -0,0 +1,151 @@
+// Package flags defines the feature flag integration points used by prompt
+// sections, tool availability and model routing.
+//
+// FlagProvider is intentionally small so OpenFeature, LaunchDarkly or in-house
+// flag services can be adapted with a few lines of code. Providers must never
+// fail a run: on lookup errors they return the supplied default value.
+//
+// flags 包定义了提示部分、工具可用性和模型路由所使用的功能开关集成点。
+package flags
+
+import (
+	"context"
+	"sync"
+)
+
+// EvalContext identifies who a flag is being evaluated for
+// EvalContext 标识功能开关的评估对象
+type EvalContext struct {
+	// UserID is the end user of the run
+	// UserID 是运行的终端用户
+	UserID string
+
+	// TenantID is the tenant / organization of the run
+	// TenantID 是运行所属的租户 / 组织
+	TenantID string
+
+	// Attributes carries additional targeting attributes
+	// Attributes 携带额外的定向属性
+	Attributes map[string]interface{}
+}
+
+// FlagProvider evaluates feature flags for an evaluation context
+// FlagProvider 为评估上下文计算功能开关
+type FlagProvider interface {
+	// BoolValue returns the boolean value of key, or defaultValue if unknown
+	// BoolValue 返回 key 的布尔值，未知时返回 defaultValue
+	BoolValue(ctx context.Context, key string, defaultValue bool, evalCtx EvalContext) bool
+
+	// StringValue returns the string value of key, or defaultValue if unknown
+	// StringValue 返回 key 的字符串值，未知时返回 defaultValue
+	StringValue(ctx context.Context, key string, defaultValue string, evalCtx EvalContext) string
+}
+
+type evalContextKey struct{}
+
+// WithEvalContext attaches an evaluation context to ctx
+// WithEvalContext 将评估上下文附加到 ctx
+func WithEvalContext(ctx context.Context, evalCtx EvalContext) context.Context {
+	return context.WithValue(ctx, evalContextKey{}, evalCtx)
+}
+
+// EvalContextFromContext returns the evaluation context attached to ctx
+// EvalContextFromContext 返回附加到 ctx 的评估上下文
+func EvalContextFromContext(ctx context.Context) (EvalContext, bool) {
+	if ctx == nil {
+		return EvalContext{}, false
+	}
+	evalCtx, ok := ctx.Value(evalContextKey{}).(EvalContext)
+	return evalCtx, ok
+}
+
+// StaticProvider serves flags from memory with optional per-tenant and
+// per-user overrides. User overrides win over tenant overrides, which win
+// over global values. Useful for tests and simple deployments.
+// StaticProvider 从内存提供功能开关，支持按租户和按用户覆盖。
+type StaticProvider struct {
+	mu      sync.RWMutex
+	global  map[string]interface{}
+	tenants map[string]map[string]interface{}
+	users   map[string]map[string]interface{}
+}
+
+// NewStaticProvider creates a provider with the given global values
+// NewStaticProvider 使用给定的全局值创建提供者
+func NewStaticProvider(values map[string]interface{}) *StaticProvider {
+	global := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		global[k] = v
+	}
+	return &StaticProvider{
+		global:  global,
+		tenants: make(map[string]map[string]interface{}),
+		users:   make(map[string]map[string]interface{}),
+	}
+}
+
+// Set sets a global flag value
+// Set 设置全局开关值
+func (p *StaticProvider) Set(key string, value interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.global[key] = value
+}
+
+// SetForTenant overrides a flag for one tenant
+// SetForTenant 为单个租户覆盖开关值
+func (p *StaticProvider) SetForTenant(tenantID, key string, value interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	setScoped(p.tenants, tenantID, key, value)
+}
+
+// SetForUser overrides a flag for one user
+// SetForUser 为单个用户覆盖开关值
+func (p *StaticProvider) SetForUser(userID, key string, value interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	setScoped(p.users, userID, key, value)
+}
+
+// BoolValue implements FlagProvider
+func (p *StaticProvider) BoolValue(_ context.Context, key string, defaultValue bool, evalCtx EvalContext) bool {
+	if value, ok := p.lookup(key, evalCtx).(bool); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// StringValue implements FlagProvider
+func (p *StaticProvider) StringValue(_ context.Context, key string, defaultValue string, evalCtx EvalContext) string {
+	if value, ok := p.lookup(key, evalCtx).(string); ok {
+		return value
+	}
+	return defaultValue
+}
+
+func (p *StaticProvider) lookup(key string, evalCtx EvalContext) interface{} {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if evalCtx.UserID != "" {
+		if value, ok := p.users[evalCtx.UserID][key]; ok {
+			return value
+		}
+	}
+	if evalCtx.TenantID != "" {
+		if value, ok := p.tenants[evalCtx.TenantID][key]; ok {
+			return value
+		}
+	}
+	return p.global[key]
+}
+
+func setScoped(scopes map[string]map[string]interface{}, scope, key string, value interface{}) {
+	values, ok := scopes[scope]
+	if !ok {
+		values = make(map[string]interface{})
+		scopes[scope] = values
+	}
+	values[key] = value
+}
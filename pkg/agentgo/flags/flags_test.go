@@ -0,0 +1,44 @@
+package flags
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStaticProvider_Precedence(t *testing.T) {
+	ctx := context.Background()
+	p := NewStaticProvider(map[string]interface{}{"beta": false, "model": "fast"})
+	p.SetForTenant("acme", "beta", true)
+	p.SetForUser("ada", "beta", false)
+	p.SetForUser("ada", "model", "smart")
+
+	if p.BoolValue(ctx, "beta", true, EvalContext{}) {
+		t.Error("expected global value false")
+	}
+	if !p.BoolValue(ctx, "beta", false, EvalContext{TenantID: "acme"}) {
+		t.Error("expected tenant override true")
+	}
+	if p.BoolValue(ctx, "beta", true, EvalContext{TenantID: "acme", UserID: "ada"}) {
+		t.Error("expected user override to win over tenant")
+	}
+	if got := p.StringValue(ctx, "model", "", EvalContext{UserID: "ada"}); got != "smart" {
+		t.Errorf("expected user string override, got %q", got)
+	}
+	if !p.BoolValue(ctx, "missing", true, EvalContext{}) {
+		t.Error("expected default for unknown flag")
+	}
+	if got := p.StringValue(ctx, "beta", "fallback", EvalContext{}); got != "fallback" {
+		t.Errorf("expected default for mistyped flag, got %q", got)
+	}
+}
+
+func TestEvalContextRoundTrip(t *testing.T) {
+	if _, ok := EvalContextFromContext(context.Background()); ok {
+		t.Fatal("expected no eval context on empty ctx")
+	}
+	ctx := WithEvalContext(context.Background(), EvalContext{UserID: "u1", TenantID: "t1"})
+	evalCtx, ok := EvalContextFromContext(ctx)
+	if !ok || evalCtx.UserID != "u1" || evalCtx.TenantID != "t1" {
+		t.Fatalf("unexpected eval context %+v", evalCtx)
+	}
+}
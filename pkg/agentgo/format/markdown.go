@@ -0,0 +1,125 @@
+package format
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	headingRe    = regexp.MustCompile(`(?m)^#{1,6}[ \t]+`)
+	ruleRe       = regexp.MustCompile(`(?m)^[ \t]*([-*_])[ \t]*([-*_][ \t]*){2,}$\n?`)
+	imageRe      = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	linkRe       = regexp.MustCompile(`\[([^\]]+)\]\(([^)]+)\)`)
+	boldRe       = regexp.MustCompile(`(\*\*|__)(\S(?:.*?\S)?)(\*\*|__)`)
+	italicRe     = regexp.MustCompile(`(^|[^\w*])[*_](\S(?:[^*_\n]*?\S)?)[*_]([^\w*]|$)`)
+	inlineCodeRe = regexp.MustCompile("`([^`\n]+)`")
+	fenceLineRe  = regexp.MustCompile("(?m)^```.*$\n?")
+	quoteRe      = regexp.MustCompile(`(?m)^>[ \t]?`)
+	bulletRe     = regexp.MustCompile(`(?m)^([ \t]*)[*+][ \t]+`)
+	tableSepRe   = regexp.MustCompile(`(?m)^[ \t]*\|?[ \t]*:?-{3,}:?[ \t]*(\|[ \t]*:?-{3,}:?[ \t]*)*\|?[ \t]*$\n?`)
+	tableRowRe   = regexp.MustCompile(`(?m)^[ \t]*\|(.*)\|[ \t]*$`)
+	blankLinesRe = regexp.MustCompile(`\n{3,}`)
+
+	markdownHintRe = regexp.MustCompile("(?m)(^#{1,6} |^[ \t]*[-*+] |^[ \t]*\\d+\\. |\\*\\*\\S|__\\S|`|\\[[^\\]]+\\]\\([^)]+\\)|^> )")
+)
+
+// StripMarkdown converts markdown to plain text, keeping the content of code
+// blocks, links (as "text (url)") and list items
+// StripMarkdown 将 markdown 转换为纯文本，保留代码块、链接（"文本 (url)"）和列表项的内容
+func StripMarkdown(content string) string {
+	content = fenceLineRe.ReplaceAllString(content, "")
+	content = stripAdvancedMarkdown(content)
+	content = linkRe.ReplaceAllString(content, "$1 ($2)")
+	content = boldRe.ReplaceAllString(content, "$2")
+	content = italicRe.ReplaceAllString(content, "$1$2$3")
+	content = inlineCodeRe.ReplaceAllString(content, "$1")
+	content = quoteRe.ReplaceAllString(content, "")
+	content = bulletRe.ReplaceAllString(content, "$1- ")
+	return strings.TrimSpace(content)
+}
+
+// stripAdvancedMarkdown removes headings, tables, images and horizontal rules
+func stripAdvancedMarkdown(content string) string {
+	content = headingRe.ReplaceAllString(content, "")
+	content = ruleRe.ReplaceAllString(content, "")
+	content = imageRe.ReplaceAllString(content, "$1")
+	content = tableSepRe.ReplaceAllString(content, "")
+	content = tableRowRe.ReplaceAllStringFunc(content, func(row string) string {
+		cells := strings.Split(strings.Trim(strings.TrimSpace(row), "|"), "|")
+		for i := range cells {
+			cells[i] = strings.TrimSpace(cells[i])
+		}
+		return strings.Join(cells, " - ")
+	})
+	content = blankLinesRe.ReplaceAllString(content, "\n\n")
+	return strings.TrimSpace(content)
+}
+
+// HasMarkdown reports whether content uses any common markdown construct
+// HasMarkdown 判断内容是否使用了常见的 markdown 结构
+func HasMarkdown(content string) bool {
+	return markdownHintRe.MatchString(content)
+}
+
+// Truncate shortens content to at most maxLen characters including suffix.
+// It cuts at the last paragraph break, sentence end or word boundary in the
+// final part of the allowed text, and closes an open code fence.
+// Truncate 将内容缩短到最多 maxLen 个字符（包括后缀），优先在段落、句子或单词边界截断，并闭合未结束的代码块。
+func Truncate(content string, maxLen int, suffix string) string {
+	if maxLen <= 0 || utf8.RuneCountInString(content) <= maxLen {
+		return content
+	}
+
+	const fenceClose = "\n```"
+	budget := maxLen - utf8.RuneCountInString(suffix)
+	if budget <= 0 {
+		return string([]rune(suffix)[:maxLen])
+	}
+	runes := []rune(content)
+	cut := smartCut(runes[:budget])
+	text := strings.TrimRight(string(runes[:cut]), " \t\n")
+
+	if strings.Count(text, "```")%2 == 1 {
+		room := budget - utf8.RuneCountInString(fenceClose)
+		if room <= 0 {
+			return string([]rune(suffix)[:maxLen])
+		}
+		if utf8.RuneCountInString(text) > room {
+			text = strings.TrimRight(string([]rune(text)[:room]), " \t\n")
+		}
+		// Re-check: trimming may have removed the opening fence itself.
+		if strings.Count(text, "```")%2 == 1 {
+			return text + suffix + fenceClose
+		}
+	}
+	return text + suffix
+}
+
+// smartCut returns the index to cut runes at, preferring a natural boundary
+// within the last 40% of the text
+func smartCut(runes []rune) int {
+	n := len(runes)
+	minCut := n * 6 / 10
+	text := string(runes)
+
+	if i := strings.LastIndex(text, "\n\n"); i >= 0 {
+		if c := utf8.RuneCountInString(text[:i]); c >= minCut {
+			return c
+		}
+	}
+	for i := n - 1; i >= minCut; i-- {
+		switch runes[i] {
+		case '.', '!', '?', '。', '！', '？':
+			if i == n-1 || runes[i+1] == ' ' || runes[i+1] == '\n' {
+				return i + 1
+			}
+		}
+	}
+	for i := n - 1; i >= minCut; i-- {
+		if runes[i] == ' ' || runes[i] == '\n' {
+			return i
+		}
+	}
+	return n
+}
@@ -0,0 +1,220 @@
+// Package format enforces response formatting policies (markdown level, hard
+// length limits, code-block only and JSON-only output) after generation.
+//
+// A Policy first applies deterministic fixes (stripping markdown, unwrapping
+// fenced JSON, smart truncation). Violations that cannot be fixed locally are
+// returned so the caller can ask the model to repair its answer.
+//
+// format 包在生成后强制执行响应格式策略（markdown 级别、硬性长度限制、仅代码块和仅 JSON 输出）。
+package format
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// MarkdownMode controls how much markdown a response may contain
+// MarkdownMode 控制响应中允许的 markdown 程度
+type MarkdownMode string
+
+const (
+	// MarkdownAny applies no markdown rules (default)
+	// MarkdownAny 不应用 markdown 规则（默认）
+	MarkdownAny MarkdownMode = ""
+	// MarkdownNone strips all markdown, producing plain text
+	// MarkdownNone 去除所有 markdown，生成纯文本
+	MarkdownNone MarkdownMode = "none"
+	// MarkdownBasic keeps emphasis, lists, links and code but strips headings,
+	// tables, images and horizontal rules (chat apps with limited rendering)
+	// MarkdownBasic 保留强调、列表、链接和代码，去除标题、表格、图片和分隔线
+	MarkdownBasic MarkdownMode = "basic"
+	// MarkdownRequired requires the response to use markdown formatting
+	// MarkdownRequired 要求响应使用 markdown 格式
+	MarkdownRequired MarkdownMode = "required"
+)
+
+// Policy describes how a response must be formatted
+// Policy 描述响应必须遵循的格式
+type Policy struct {
+	// Markdown sets the allowed markdown level
+	// Markdown 设置允许的 markdown 级别
+	Markdown MarkdownMode `json:"markdown,omitempty" yaml:"markdown,omitempty"`
+
+	// MaxLength is a hard limit in characters (0 = unlimited). Longer responses
+	// are truncated at a paragraph, sentence or word boundary.
+	// MaxLength 是以字符计的硬性限制（0 = 不限制），超长响应会在段落、句子或单词边界截断。
+	MaxLength int `json:"max_length,omitempty" yaml:"max_length,omitempty"`
+
+	// TruncationSuffix is appended to truncated responses (default "…")
+	// TruncationSuffix 附加在被截断的响应后（默认 "…"）
+	TruncationSuffix string `json:"truncation_suffix,omitempty" yaml:"truncation_suffix,omitempty"`
+
+	// CodeBlockOnly requires the response to be a single fenced code block
+	// CodeBlockOnly 要求响应为单个围栏代码块
+	CodeBlockOnly bool `json:"code_block_only,omitempty" yaml:"code_block_only,omitempty"`
+
+	// CodeLanguage is the language tag used for CodeBlockOnly responses
+	// CodeLanguage 是 CodeBlockOnly 响应使用的语言标签
+	CodeLanguage string `json:"code_language,omitempty" yaml:"code_language,omitempty"`
+
+	// JSONOnly requires the response to be a valid JSON document
+	// JSONOnly 要求响应为有效的 JSON 文档
+	JSONOnly bool `json:"json_only,omitempty" yaml:"json_only,omitempty"`
+
+	// MaxRepairs is how many times the model is asked to fix violations that
+	// cannot be fixed locally (default 0)
+	// MaxRepairs 是请求模型修复无法本地修复的违规的次数（默认 0）
+	MaxRepairs int `json:"max_repairs,omitempty" yaml:"max_repairs,omitempty"`
+
+	// Strict fails the run when violations remain after repairs; otherwise the
+	// best-effort response is returned and the violations are reported
+	// Strict 在修复后仍存在违规时使运行失败；否则返回尽力而为的响应并报告违规
+	Strict bool `json:"strict,omitempty" yaml:"strict,omitempty"`
+}
+
+// Violation describes one way a response breaks a policy
+// Violation 描述响应违反策略的一种方式
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Validate checks the policy for conflicting options
+// Validate 检查策略中相互冲突的选项
+func (p *Policy) Validate() error {
+	if p.MaxLength < 0 || p.MaxRepairs < 0 {
+		return fmt.Errorf("max_length and max_repairs must not be negative")
+	}
+	if p.CodeBlockOnly && p.JSONOnly {
+		return fmt.Errorf("code_block_only and json_only are mutually exclusive")
+	}
+	switch p.Markdown {
+	case MarkdownAny, MarkdownNone, MarkdownBasic, MarkdownRequired:
+	default:
+		return fmt.Errorf("unknown markdown mode %q", p.Markdown)
+	}
+	if p.Markdown == MarkdownNone && p.CodeBlockOnly {
+		return fmt.Errorf("markdown none conflicts with code_block_only")
+	}
+	if p.MaxLength > 0 && p.MaxLength <= utf8.RuneCountInString(p.suffix()) {
+		return fmt.Errorf("max_length must be longer than the truncation suffix")
+	}
+	return nil
+}
+
+// Apply fixes what can be fixed deterministically and returns the formatted
+// content along with the violations that remain
+// Apply 确定性地修复可修复的问题，返回格式化后的内容和剩余违规
+func (p *Policy) Apply(content string) (string, []Violation) {
+	content = strings.TrimSpace(content)
+	var violations []Violation
+
+	switch {
+	case p.JSONOnly:
+		content = unwrapFence(content)
+		if !json.Valid([]byte(content)) {
+			violations = append(violations, Violation{Rule: "json_only", Message: "response is not valid JSON"})
+		}
+		// Truncating JSON would corrupt it, so length is only reported.
+		if p.MaxLength > 0 && utf8.RuneCountInString(content) > p.MaxLength {
+			violations = append(violations, Violation{
+				Rule:    "max_length",
+				Message: fmt.Sprintf("response exceeds %d characters", p.MaxLength),
+			})
+		}
+		return content, violations
+
+	case p.CodeBlockOnly:
+		if code, ok := singleFence(content); ok {
+			content = "```" + p.CodeLanguage + "\n" + code + "\n```"
+		} else {
+			violations = append(violations, Violation{Rule: "code_block_only", Message: "response must be a single fenced code block"})
+		}
+	}
+
+	switch p.Markdown {
+	case MarkdownNone:
+		content = StripMarkdown(content)
+	case MarkdownBasic:
+		content = stripAdvancedMarkdown(content)
+	case MarkdownRequired:
+		if !HasMarkdown(content) {
+			violations = append(violations, Violation{Rule: "markdown_required", Message: "response must use markdown formatting"})
+		}
+	}
+
+	if p.MaxLength > 0 {
+		content = Truncate(content, p.MaxLength, p.suffix())
+	}
+	return content, violations
+}
+
+// RepairPrompt builds the instruction sent to the model to fix violations
+// RepairPrompt 构建发送给模型以修复违规的指令
+func (p *Policy) RepairPrompt(violations []Violation) string {
+	var sb strings.Builder
+	sb.WriteString("Your previous answer does not follow the required format:\n")
+	for _, v := range violations {
+		sb.WriteString("- " + v.Message + "\n")
+	}
+	sb.WriteString("Rewrite the answer so it follows the format exactly. Reply with the rewritten answer only.")
+	switch {
+	case p.JSONOnly:
+		sb.WriteString(" Output only the JSON document, without code fences or commentary.")
+	case p.CodeBlockOnly:
+		sb.WriteString(" Output exactly one fenced code block and nothing else.")
+	}
+	if p.MaxLength > 0 {
+		sb.WriteString(fmt.Sprintf(" Keep it under %d characters.", p.MaxLength))
+	}
+	return sb.String()
+}
+
+func (p *Policy) suffix() string {
+	if p.TruncationSuffix == "" {
+		return "…"
+	}
+	return p.TruncationSuffix
+}
+
+type channelKey struct{}
+
+// WithChannel attaches the delivery channel (e.g. "slack", "sms", "api") to
+// ctx so per-channel policies can be selected
+// WithChannel 将投递渠道（例如 "slack"、"sms"、"api"）附加到 ctx，以便选择按渠道的策略
+func WithChannel(ctx context.Context, channel string) context.Context {
+	return context.WithValue(ctx, channelKey{}, channel)
+}
+
+// ChannelFromContext returns the channel attached to ctx
+// ChannelFromContext 返回附加到 ctx 的渠道
+func ChannelFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	channel, _ := ctx.Value(channelKey{}).(string)
+	return channel
+}
+
+var fenceRe = regexp.MustCompile("(?s)^```[\\w+-]*[ \\t]*\\n(.*?)\\n?```$")
+
+// singleFence returns the body of content when it is exactly one fenced block
+func singleFence(content string) (string, bool) {
+	m := fenceRe.FindStringSubmatch(content)
+	if m == nil || strings.Contains(m[1], "\n```") {
+		return "", false
+	}
+	return m[1], true
+}
+
+// unwrapFence removes a surrounding code fence, if any
+func unwrapFence(content string) string {
+	if code, ok := singleFence(content); ok {
+		return strings.TrimSpace(code)
+	}
+	return content
+}
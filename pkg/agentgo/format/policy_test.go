@@ -0,0 +1,114 @@
+package format
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		wantErr bool
+	}{
+		{"empty", Policy{}, false},
+		{"json and code", Policy{JSONOnly: true, CodeBlockOnly: true}, true},
+		{"unknown markdown", Policy{Markdown: "fancy"}, true},
+		{"negative length", Policy{MaxLength: -1}, true},
+		{"length shorter than suffix", Policy{MaxLength: 2, TruncationSuffix: "..."}, true},
+		{"plain text code block", Policy{Markdown: MarkdownNone, CodeBlockOnly: true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStripMarkdown(t *testing.T) {
+	in := "# Title\n\nSome **bold** and _italic_ with `code` and [a link](https://x.io).\n\n* one\n* two\n\n---\n\n> quoted\n\n```go\nfmt.Println()\n```"
+	want := "Title\n\nSome bold and italic with code and a link (https://x.io).\n\n- one\n- two\n\nquoted\n\nfmt.Println()"
+	if got := StripMarkdown(in); got != want {
+		t.Errorf("StripMarkdown() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestPolicy_ApplyBasicMarkdown(t *testing.T) {
+	p := &Policy{Markdown: MarkdownBasic}
+	got, violations := p.Apply("## Heading\n\n| a | b |\n|---|---|\n| 1 | 2 |\n\n**keep** this")
+	if len(violations) != 0 {
+		t.Fatalf("unexpected violations: %v", violations)
+	}
+	if got != "Heading\n\na - b\n1 - 2\n\n**keep** this" {
+		t.Errorf("Apply() = %q", got)
+	}
+}
+
+func TestPolicy_ApplyMarkdownRequired(t *testing.T) {
+	p := &Policy{Markdown: MarkdownRequired}
+	if _, v := p.Apply("plain answer"); len(v) != 1 || v[0].Rule != "markdown_required" {
+		t.Errorf("expected markdown_required violation, got %v", v)
+	}
+	if _, v := p.Apply("- item"); len(v) != 0 {
+		t.Errorf("unexpected violations: %v", v)
+	}
+}
+
+func TestPolicy_ApplyJSONOnly(t *testing.T) {
+	p := &Policy{JSONOnly: true}
+	got, v := p.Apply("```json\n{\"a\": 1}\n```")
+	if len(v) != 0 || got != `{"a": 1}` {
+		t.Errorf("Apply() = %q, %v", got, v)
+	}
+	if _, v := p.Apply("Sure! {\"a\": 1}"); len(v) != 1 || v[0].Rule != "json_only" {
+		t.Errorf("expected json_only violation, got %v", v)
+	}
+}
+
+func TestPolicy_ApplyCodeBlockOnly(t *testing.T) {
+	p := &Policy{CodeBlockOnly: true, CodeLanguage: "sql"}
+	got, v := p.Apply("```\nSELECT 1;\n```")
+	if len(v) != 0 || got != "```sql\nSELECT 1;\n```" {
+		t.Errorf("Apply() = %q, %v", got, v)
+	}
+	if _, v := p.Apply("Here you go:\n```\nSELECT 1;\n```"); len(v) != 1 || v[0].Rule != "code_block_only" {
+		t.Errorf("expected code_block_only violation, got %v", v)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	text := "First sentence here. Second sentence is longer and keeps going on."
+	// The only sentence end is too early, so the cut falls on a word boundary.
+	if got := Truncate(text, 40, "…"); got != "First sentence here. Second sentence…" {
+		t.Errorf("Truncate() = %q", got)
+	}
+	if got := Truncate("One two three four five. Six seven eight", 30, "…"); got != "One two three four five.…" {
+		t.Errorf("Truncate() at sentence = %q", got)
+	}
+
+	if got := Truncate("short", 40, "…"); got != "short" {
+		t.Errorf("short text changed: %q", got)
+	}
+
+	code := "Example:\n```go\nfunc main() {\n\tfmt.Println(\"hello world\")\n}\n```"
+	got := Truncate(code, 30, "…")
+	if utf8.RuneCountInString(got) > 30 {
+		t.Errorf("Truncate() exceeded limit: %d", utf8.RuneCountInString(got))
+	}
+	if strings.Count(got, "```")%2 != 0 {
+		t.Errorf("code fence left open: %q", got)
+	}
+}
+
+func TestChannelContext(t *testing.T) {
+	if ChannelFromContext(context.Background()) != "" {
+		t.Error("expected empty channel")
+	}
+	if ChannelFromContext(WithChannel(context.Background(), "sms")) != "sms" {
+		t.Error("expected sms channel")
+	}
+}
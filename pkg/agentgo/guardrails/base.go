@@ -15,16 +15,36 @@ type Guardrail interface {
 	Name() string
 }
 
+// Redactor is implemented by guardrails that can remove the content they
+// reject instead of blocking it, e.g. by masking PII.
+type Redactor interface {
+	// Redact returns text with the offending content replaced.
+	Redact(text string) string
+}
+
+// Corrector is implemented by guardrail errors that can tell the model how to
+// fix a rejected answer, so the agent can ask for a corrected one.
+type Corrector interface {
+	// Correction returns instructions for producing an acceptable answer.
+	Correction() string
+}
+
 // CheckInput contains the data to be validated by a guardrail.
 type CheckInput struct {
 	// Input is the raw input string to validate
 	Input string
 
+	// Output is the generated output (only set for post-generation checks)
+	Output string
+
 	// Messages are the conversation messages (optional)
 	Messages []interface{}
 
 	// Metadata contains additional context for validation
 	Metadata map[string]interface{}
+
+	// Report collects non-blocking findings (optional)
+	Report *GuardrailReport
 }
 
 // NewCheckInput creates a new CheckInput with the given input string.
@@ -46,3 +66,9 @@ func (ci *CheckInput) WithMetadata(metadata map[string]interface{}) *CheckInput
 	ci.Metadata = metadata
 	return ci
 }
+
+// WithReport attaches a report that collects non-blocking findings.
+func (ci *CheckInput) WithReport(report *GuardrailReport) *CheckInput {
+	ci.Report = report
+	return ci
+}
@@ -0,0 +1,340 @@
+package guardrails
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// FactCheckSourcesKey is the CheckInput metadata key holding extra source
+// texts ([]string), e.g. retrieved documents
+// FactCheckSourcesKey 是 CheckInput 元数据中存放额外来源文本（[]string）的键，例如检索到的文档
+const FactCheckSourcesKey = "fact_check_sources"
+
+// ClaimKind is the kind of claim extracted from the output
+// ClaimKind 是从输出中提取的声明类型
+type ClaimKind string
+
+const (
+	// ClaimNumber is a numeric claim such as "42%", "$1,200" or "3.5 million"
+	// ClaimNumber 是数字声明，例如 "42%"、"$1,200" 或 "3.5 million"
+	ClaimNumber ClaimKind = "number"
+	// ClaimDate is a calendar date such as "2024-03-15" or "March 15, 2024"
+	// ClaimDate 是日历日期，例如 "2024-03-15" 或 "March 15, 2024"
+	ClaimDate ClaimKind = "date"
+)
+
+// Claim is a number or date found in a text
+// Claim 是在文本中找到的数字或日期
+type Claim struct {
+	Kind ClaimKind `json:"kind"`
+	// Text is the claim as written
+	// Text 是原文中的声明
+	Text string `json:"text"`
+	// Value is the numeric value, scaled by words like "million"
+	// Value 是数值，已按 "million" 等词缩放
+	Value float64 `json:"value,omitempty"`
+	// Precision is half the unit of the last written digit, used to accept rounding
+	// Precision 是最后一位有效数字单位的一半，用于接受四舍五入
+	Precision float64 `json:"-"`
+	// Date is the normalized date (YYYY-MM-DD)
+	// Date 是规范化的日期（YYYY-MM-DD）
+	Date string `json:"date,omitempty"`
+}
+
+// FactCheckConfig configures the fact-check guardrail
+// FactCheckConfig 配置事实核查防护栏
+type FactCheckConfig struct {
+	// SourceRoles are the message roles whose content counts as evidence
+	// (default: tool results only)
+	// SourceRoles 是其内容可作为证据的消息角色（默认：仅工具结果）
+	SourceRoles []types.Role
+	// Tolerance is the accepted relative difference between a claim and a
+	// source number, on top of rounding (e.g. 0.01 = 1%)
+	// Tolerance 是声明与来源数字之间可接受的相对差异（在四舍五入之外，例如 0.01 = 1%）
+	Tolerance float64
+	// CheckSmallIntegers also checks integers below 10, which are usually
+	// counts and ordinals (default: skipped)
+	// CheckSmallIntegers 也检查小于 10 的整数（通常是计数和序号，默认跳过）
+	CheckSmallIntegers bool
+	// Action is "flag" (default, record findings on the report) or "block"
+	// (fail the check when any claim is unverified)
+	// Action 为 "flag"（默认，在报告中记录问题）或 "block"（存在未验证声明时检查失败）
+	Action string
+}
+
+// FactCheckGuardrail extracts numeric claims and dates from the output and
+// verifies them against tool results and retrieved documents. Unverifiable
+// claims are recorded on the GuardrailReport, or fail the check in block mode.
+// Without any source text there is nothing to verify against, so the check
+// is skipped.
+// FactCheckGuardrail 从输出中提取数字声明和日期，并与工具结果和检索文档核对。
+// 无法验证的声明会记录到 GuardrailReport，在 block 模式下会使检查失败。
+type FactCheckGuardrail struct {
+	config FactCheckConfig
+	roles  map[types.Role]bool
+}
+
+// NewFactCheckGuardrail creates a new fact-check guardrail
+// NewFactCheckGuardrail 创建新的事实核查防护栏
+func NewFactCheckGuardrail(config FactCheckConfig) (*FactCheckGuardrail, error) {
+	switch config.Action {
+	case "":
+		config.Action = "flag"
+	case "flag", "block":
+	default:
+		return nil, fmt.Errorf("invalid action %q (want flag or block)", config.Action)
+	}
+	if config.Tolerance < 0 {
+		return nil, fmt.Errorf("tolerance must not be negative")
+	}
+	if len(config.SourceRoles) == 0 {
+		config.SourceRoles = []types.Role{types.RoleTool}
+	}
+
+	roles := make(map[types.Role]bool, len(config.SourceRoles))
+	for _, role := range config.SourceRoles {
+		roles[role] = true
+	}
+	return &FactCheckGuardrail{config: config, roles: roles}, nil
+}
+
+// Check verifies the claims in the output (or the input for pre-hooks)
+// Check 核对输出（在前置钩子中为输入）中的声明
+func (g *FactCheckGuardrail) Check(ctx context.Context, input *CheckInput) error {
+	text := input.Output
+	if text == "" {
+		text = input.Input
+	}
+
+	sources := g.sources(input)
+	if len(sources) == 0 {
+		return nil
+	}
+
+	var sourceNumbers []Claim
+	sourceDates := map[string]bool{}
+	for _, source := range sources {
+		for _, claim := range ExtractClaims(source, true) {
+			if claim.Kind == ClaimDate {
+				sourceDates[claim.Date] = true
+				// A date also backs the numbers it is made of (e.g. its year).
+				sourceNumbers = append(sourceNumbers, dateNumbers(claim.Date)...)
+			} else {
+				sourceNumbers = append(sourceNumbers, claim)
+			}
+		}
+	}
+
+	var unverified []Claim
+	for _, claim := range ExtractClaims(text, g.config.CheckSmallIntegers) {
+		if claim.Kind == ClaimDate {
+			if !sourceDates[claim.Date] {
+				unverified = append(unverified, claim)
+			}
+			continue
+		}
+		if !g.numberSupported(claim, sourceNumbers) {
+			unverified = append(unverified, claim)
+		}
+	}
+
+	if len(unverified) == 0 {
+		return nil
+	}
+
+	if g.config.Action == "block" {
+		return types.NewOutputCheckError(
+			fmt.Sprintf("unverifiable %s claim: %s", unverified[0].Kind, unverified[0].Text),
+			nil,
+		)
+	}
+
+	for _, claim := range unverified {
+		input.Report.Add(Finding{
+			Guardrail: g.Name(),
+			Rule:      "unverified_" + string(claim.Kind),
+			Message:   fmt.Sprintf("%s %q is not supported by any source", claim.Kind, claim.Text),
+			Value:     claim.Text,
+		})
+	}
+	if input.Metadata != nil {
+		input.Metadata["unverified_claims"] = unverified
+	}
+	return nil
+}
+
+// Name returns the guardrail name
+// Name 返回防护栏名称
+func (g *FactCheckGuardrail) Name() string {
+	return "FactCheckGuardrail"
+}
+
+// sources collects evidence texts from messages and metadata
+func (g *FactCheckGuardrail) sources(input *CheckInput) []string {
+	var sources []string
+	for _, m := range input.Messages {
+		switch msg := m.(type) {
+		case *types.Message:
+			if msg != nil && g.roles[msg.Role] && msg.Content != "" {
+				sources = append(sources, msg.Content)
+			}
+		case types.Message:
+			if g.roles[msg.Role] && msg.Content != "" {
+				sources = append(sources, msg.Content)
+			}
+		}
+	}
+	if extra, ok := input.Metadata[FactCheckSourcesKey].([]string); ok {
+		sources = append(sources, extra...)
+	}
+	return sources
+}
+
+func (g *FactCheckGuardrail) numberSupported(claim Claim, sources []Claim) bool {
+	for _, source := range sources {
+		diff := math.Abs(source.Value - claim.Value)
+		if diff <= claim.Precision || diff <= g.config.Tolerance*math.Abs(claim.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	monthPattern = `(jan(?:uary)?|feb(?:ruary)?|mar(?:ch)?|apr(?:il)?|may|june?|july?|aug(?:ust)?|sep(?:t(?:ember)?)?|oct(?:ober)?|nov(?:ember)?|dec(?:ember)?)\.?`
+
+	isoDateRe      = regexp.MustCompile(`\b(\d{4})-(\d{2})-(\d{2})\b`)
+	monthDayYearRe = regexp.MustCompile(`(?i)\b` + monthPattern + `\s+(\d{1,2})(?:st|nd|rd|th)?,?\s+(\d{4})\b`)
+	dayMonthYearRe = regexp.MustCompile(`(?i)\b(\d{1,2})(?:st|nd|rd|th)?\s+(?:of\s+)?` + monthPattern + `,?\s+(\d{4})\b`)
+
+	numberRe    = regexp.MustCompile(`(?i)([$€£¥])?(\d{1,3}(?:,\d{3})+|\d+)(\.\d+)?(\s*(?:%|percent\b|thousand\b|million\b|billion\b|trillion\b|k\b|bn\b))?`)
+	listIndexRe = regexp.MustCompile(`(?m)^[ \t]*\d+[.)][ \t]`)
+)
+
+var monthNumbers = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var scaleWords = map[string]float64{
+	"thousand": 1e3, "k": 1e3,
+	"million": 1e6,
+	"billion": 1e9, "bn": 1e9,
+	"trillion": 1e12,
+}
+
+// ExtractClaims returns the dates and numbers in text, in order of kind.
+// Numbered list markers are ignored, and so are integers below 10 unless
+// includeSmall is true or they carry a unit.
+// ExtractClaims 返回文本中的日期和数字；忽略列表序号，以及小于 10 且没有单位的整数（除非 includeSmall 为 true）。
+func ExtractClaims(text string, includeSmall bool) []Claim {
+	var claims []Claim
+	masked := []byte(text)
+	mask := func(loc []int) {
+		for i := loc[0]; i < loc[1]; i++ {
+			masked[i] = ' '
+		}
+	}
+
+	for _, loc := range isoDateRe.FindAllStringSubmatchIndex(text, -1) {
+		if date, ok := makeDate(text[loc[2]:loc[3]], text[loc[4]:loc[5]], text[loc[6]:loc[7]]); ok {
+			claims = append(claims, Claim{Kind: ClaimDate, Text: text[loc[0]:loc[1]], Date: date})
+			mask(loc)
+		}
+	}
+	for _, loc := range monthDayYearRe.FindAllStringSubmatchIndex(string(masked), -1) {
+		month := strconv.Itoa(monthNumbers[strings.ToLower(text[loc[2]:loc[2]+3])])
+		if date, ok := makeDate(text[loc[6]:loc[7]], month, text[loc[4]:loc[5]]); ok {
+			claims = append(claims, Claim{Kind: ClaimDate, Text: text[loc[0]:loc[1]], Date: date})
+			mask(loc)
+		}
+	}
+	for _, loc := range dayMonthYearRe.FindAllStringSubmatchIndex(string(masked), -1) {
+		month := strconv.Itoa(monthNumbers[strings.ToLower(text[loc[4]:loc[4]+3])])
+		if date, ok := makeDate(text[loc[6]:loc[7]], month, text[loc[2]:loc[3]]); ok {
+			claims = append(claims, Claim{Kind: ClaimDate, Text: text[loc[0]:loc[1]], Date: date})
+			mask(loc)
+		}
+	}
+	for _, loc := range listIndexRe.FindAllStringIndex(string(masked), -1) {
+		mask(loc)
+	}
+
+	current := string(masked)
+	for _, loc := range numberRe.FindAllStringSubmatchIndex(current, -1) {
+		// Skip digits that are part of a word or identifier (e.g. "gpt4", "v2").
+		if loc[0] > 0 && isWordByte(current[loc[0]-1]) {
+			continue
+		}
+		if loc[1] < len(current) && isWordByte(current[loc[1]]) {
+			continue
+		}
+
+		intPart := strings.ReplaceAll(current[loc[4]:loc[5]], ",", "")
+		frac := ""
+		if loc[6] >= 0 {
+			frac = current[loc[6]:loc[7]]
+		}
+		value, err := strconv.ParseFloat(intPart+frac, 64)
+		if err != nil {
+			continue
+		}
+
+		precision := 0.5
+		if len(frac) > 1 {
+			precision = 0.5 * math.Pow(10, -float64(len(frac)-1))
+		}
+		hasUnit := loc[2] >= 0
+		if loc[8] >= 0 {
+			hasUnit = true
+			unit := strings.ToLower(strings.TrimSpace(current[loc[8]:loc[9]]))
+			if scale, ok := scaleWords[unit]; ok {
+				value *= scale
+				precision *= scale
+			}
+		}
+		if !includeSmall && !hasUnit && frac == "" && value < 10 {
+			continue
+		}
+
+		claims = append(claims, Claim{
+			Kind:      ClaimNumber,
+			Text:      strings.TrimSpace(text[loc[0]:loc[1]]),
+			Value:     value,
+			Precision: precision,
+		})
+	}
+	return claims
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9'
+}
+
+// makeDate validates and normalizes a date to YYYY-MM-DD
+func makeDate(year, month, day string) (string, bool) {
+	y, err1 := strconv.Atoi(year)
+	m, err2 := strconv.Atoi(month)
+	d, err3 := strconv.Atoi(day)
+	if err1 != nil || err2 != nil || err3 != nil || m < 1 || m > 12 || d < 1 || d > 31 {
+		return "", false
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", y, m, d), true
+}
+
+// dateNumbers returns the year and day of a normalized date as number claims
+func dateNumbers(date string) []Claim {
+	var claims []Claim
+	for _, part := range []string{date[:4], date[8:]} {
+		if v, err := strconv.ParseFloat(part, 64); err == nil {
+			claims = append(claims, Claim{Kind: ClaimNumber, Text: part, Value: v, Precision: 0.5})
+		}
+	}
+	return claims
+}
@@ -0,0 +1,111 @@
+package guardrails
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+func TestExtractClaims(t *testing.T) {
+	text := "Steps:\n1. Check the report\nRevenue rose 12.5% to $4.2 million on March 3, 2024 (2024-03-05), " +
+		"with 1,200 customers and 3 offices using gpt4."
+	claims := ExtractClaims(text, false)
+
+	var dates, numbers []string
+	for _, c := range claims {
+		if c.Kind == ClaimDate {
+			dates = append(dates, c.Date)
+		} else {
+			numbers = append(numbers, c.Text)
+		}
+	}
+
+	if len(dates) != 2 || dates[0] != "2024-03-05" || dates[1] != "2024-03-03" {
+		t.Errorf("unexpected dates: %v", dates)
+	}
+	want := []string{"12.5%", "$4.2 million", "1,200"}
+	if len(numbers) != len(want) {
+		t.Fatalf("numbers = %v, want %v", numbers, want)
+	}
+	for i := range want {
+		if numbers[i] != want[i] {
+			t.Errorf("numbers[%d] = %q, want %q", i, numbers[i], want[i])
+		}
+	}
+	for _, c := range claims {
+		if c.Text == "$4.2 million" && c.Value != 4.2e6 {
+			t.Errorf("scaled value = %v", c.Value)
+		}
+	}
+}
+
+func TestFactCheckGuardrail_Flag(t *testing.T) {
+	g, err := NewFactCheckGuardrail(FactCheckConfig{})
+	if err != nil {
+		t.Fatalf("NewFactCheckGuardrail: %v", err)
+	}
+
+	report := NewGuardrailReport()
+	input := NewCheckInput("how did we do?").
+		WithMessages([]interface{}{
+			types.NewUserMessage("The user claims 99%"),
+			types.NewToolMessage("call-1", `{"revenue": 4213000, "growth": 0.12, "closed": "2024-01-31"}`),
+		}).
+		WithReport(report)
+	input.Output = "Revenue was about $4.21 million, closing on January 31, 2024. Growth was 99%."
+
+	if err := g.Check(context.Background(), input); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+
+	findings := report.Findings()
+	if len(findings) != 1 || findings[0].Value != "99%" || findings[0].Rule != "unverified_number" {
+		t.Errorf("unexpected findings: %+v", findings)
+	}
+	if _, ok := input.Metadata["unverified_claims"]; !ok {
+		t.Error("unverified claims should be recorded in metadata")
+	}
+}
+
+func TestFactCheckGuardrail_MetadataSourcesAndTolerance(t *testing.T) {
+	g, _ := NewFactCheckGuardrail(FactCheckConfig{Tolerance: 0.05})
+
+	input := NewCheckInput("")
+	input.Metadata[FactCheckSourcesKey] = []string{"The bridge is 1,280 meters long and opened 1937-05-27."}
+	input.Output = "The bridge is roughly 1,300 meters long and opened on 27 May 1937."
+	input.Report = NewGuardrailReport()
+
+	if err := g.Check(context.Background(), input); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if input.Report.HasFindings() {
+		t.Errorf("expected all claims verified, got %+v", input.Report.Findings())
+	}
+}
+
+func TestFactCheckGuardrail_Block(t *testing.T) {
+	g, _ := NewFactCheckGuardrail(FactCheckConfig{Action: "block"})
+
+	input := NewCheckInput("").WithMessages([]interface{}{types.NewToolMessage("c", "temperature: 21")})
+	input.Output = "It is 35 degrees."
+	if err := g.Check(context.Background(), input); err == nil {
+		t.Error("expected unverified claim to block")
+	}
+
+	// Without sources there is nothing to check against.
+	input = NewCheckInput("")
+	input.Output = "It is 35 degrees."
+	if err := g.Check(context.Background(), input); err != nil {
+		t.Errorf("expected no error without sources, got %v", err)
+	}
+}
+
+func TestNewFactCheckGuardrail_Validation(t *testing.T) {
+	if _, err := NewFactCheckGuardrail(FactCheckConfig{Action: "drop"}); err == nil {
+		t.Error("expected error for invalid action")
+	}
+	if _, err := NewFactCheckGuardrail(FactCheckConfig{Tolerance: -1}); err == nil {
+		t.Error("expected error for negative tolerance")
+	}
+}
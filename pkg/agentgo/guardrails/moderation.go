@@ -0,0 +1,222 @@
+package guardrails
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// ModerationResult is a moderator's verdict on a text
+// ModerationResult 是审核器对文本的判定
+type ModerationResult struct {
+	// Flagged reports whether any category was flagged
+	// Flagged 表示是否有任何类别被标记
+	Flagged bool `json:"flagged"`
+	// Categories maps each category, e.g. "hate" or "violence", to whether it was flagged
+	// Categories 将每个类别（例如 "hate" 或 "violence"）映射到是否被标记
+	Categories map[string]bool `json:"categories"`
+	// Scores maps each category to its score (0.0 - 1.0), when the moderator provides them
+	// Scores 将每个类别映射到其得分（0.0 - 1.0），如果审核器提供
+	Scores map[string]float64 `json:"category_scores,omitempty"`
+}
+
+// Moderator classifies text into moderation categories, e.g. the OpenAI
+// moderation endpoint or a local classifier
+// Moderator 将文本分类到审核类别，例如 OpenAI 审核接口或本地分类器
+type Moderator interface {
+	Moderate(ctx context.Context, text string) (*ModerationResult, error)
+}
+
+// ModeratorFunc adapts a function to the Moderator interface
+// ModeratorFunc 将函数适配为 Moderator 接口
+type ModeratorFunc func(ctx context.Context, text string) (*ModerationResult, error)
+
+// Moderate calls f
+// Moderate 调用 f
+func (f ModeratorFunc) Moderate(ctx context.Context, text string) (*ModerationResult, error) {
+	return f(ctx, text)
+}
+
+// ModerationConfig configures the moderation guardrail
+// ModerationConfig 配置内容审核防护栏
+type ModerationConfig struct {
+	// Moderator classifies the text (required)
+	// Moderator 对文本进行分类（必需）
+	Moderator Moderator
+	// Policy maps categories to "block" (fail the check), "flag" (record a
+	// finding on the report) or "allow" (ignore)
+	// Policy 将类别映射到 "block"（检查失败）、"flag"（在报告中记录问题）或 "allow"（忽略）
+	Policy map[string]string
+	// DefaultAction applies to flagged categories missing from Policy (default: "block")
+	// DefaultAction 适用于 Policy 中未列出的被标记类别（默认："block"）
+	DefaultAction string
+	// Thresholds trigger a category when its score reaches the threshold,
+	// instead of relying on the moderator's own flag
+	// Thresholds 在类别得分达到阈值时触发该类别，而不依赖审核器自身的标记
+	Thresholds map[string]float64
+	// FailOpen lets content through when the moderator fails (default: the check fails)
+	// FailOpen 在审核器失败时放行内容（默认：检查失败）
+	FailOpen bool
+}
+
+// ModerationGuardrail checks text with a Moderator and blocks or flags the
+// triggered categories according to a policy. It checks the output when set,
+// otherwise the input, so it works as an input and as an output guardrail.
+// ModerationGuardrail 使用 Moderator 检查文本，并按策略阻止或标记触发的类别。
+// 设置了输出时检查输出，否则检查输入，因此可同时用作输入和输出防护栏。
+type ModerationGuardrail struct {
+	config ModerationConfig
+}
+
+// NewModerationGuardrail creates a new moderation guardrail
+// NewModerationGuardrail 创建新的内容审核防护栏
+func NewModerationGuardrail(config ModerationConfig) (*ModerationGuardrail, error) {
+	if config.Moderator == nil {
+		return nil, fmt.Errorf("moderator is required")
+	}
+	if config.DefaultAction == "" {
+		config.DefaultAction = "block"
+	}
+	if !validModerationAction(config.DefaultAction) {
+		return nil, fmt.Errorf("invalid default action %q (want block, flag or allow)", config.DefaultAction)
+	}
+	for category, action := range config.Policy {
+		if !validModerationAction(action) {
+			return nil, fmt.Errorf("invalid action %q for category %s (want block, flag or allow)", action, category)
+		}
+	}
+	for category, threshold := range config.Thresholds {
+		if threshold <= 0 || threshold > 1 {
+			return nil, fmt.Errorf("threshold for category %s must be in (0, 1]", category)
+		}
+	}
+	return &ModerationGuardrail{config: config}, nil
+}
+
+func validModerationAction(action string) bool {
+	return action == "block" || action == "flag" || action == "allow"
+}
+
+// Check moderates the output (or the input for pre-hooks)
+// Check 审核输出（在前置钩子中为输入）
+func (g *ModerationGuardrail) Check(ctx context.Context, input *CheckInput) error {
+	text := input.Output
+	if text == "" {
+		text = input.Input
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+
+	result, err := g.config.Moderator.Moderate(ctx, text)
+	if err != nil {
+		if g.config.FailOpen {
+			input.Report.Add(Finding{Guardrail: g.Name(), Rule: "moderation_unavailable", Message: err.Error()})
+			return nil
+		}
+		return types.NewContentModerationError("content moderation failed", err)
+	}
+
+	var blocked []string
+	for _, category := range g.triggered(result) {
+		action, ok := g.config.Policy[category]
+		if !ok {
+			action = g.config.DefaultAction
+		}
+		switch action {
+		case "block":
+			blocked = append(blocked, category)
+		case "flag":
+			input.Report.Add(Finding{
+				Guardrail: g.Name(),
+				Rule:      category,
+				Message:   fmt.Sprintf("content flagged for %s", category),
+			})
+		}
+	}
+
+	if input.Metadata != nil {
+		input.Metadata["moderation"] = result
+	}
+	if len(blocked) > 0 {
+		return types.NewContentModerationError(fmt.Sprintf("content blocked by moderation: %s", strings.Join(blocked, ", ")), nil)
+	}
+	return nil
+}
+
+// triggered returns the categories that are flagged or reach their
+// threshold, sorted
+func (g *ModerationGuardrail) triggered(result *ModerationResult) []string {
+	var categories []string
+	for category, flagged := range result.Categories {
+		if threshold, ok := g.config.Thresholds[category]; ok {
+			flagged = result.Scores[category] >= threshold
+		}
+		if flagged {
+			categories = append(categories, category)
+		}
+	}
+	for category, threshold := range g.config.Thresholds {
+		if _, ok := result.Categories[category]; !ok && result.Scores[category] >= threshold {
+			categories = append(categories, category)
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// Name returns the guardrail name
+// Name 返回防护栏名称
+func (g *ModerationGuardrail) Name() string {
+	return "ModerationGuardrail"
+}
+
+// KeywordModerator is a local moderator that flags a category when the text
+// contains one of its words or phrases, matched case-insensitively on word
+// boundaries
+// KeywordModerator 是本地审核器，当文本包含类别中的任一词语或短语时标记该类别（不区分大小写，按词边界匹配）
+type KeywordModerator struct {
+	patterns map[string]*regexp.Regexp
+}
+
+// NewKeywordModerator creates a moderator from category -> words
+// NewKeywordModerator 根据 类别 -> 词语 创建审核器
+func NewKeywordModerator(keywords map[string][]string) *KeywordModerator {
+	m := &KeywordModerator{patterns: make(map[string]*regexp.Regexp, len(keywords))}
+	for category, words := range keywords {
+		quoted := make([]string, 0, len(words))
+		for _, word := range words {
+			if word = strings.TrimSpace(word); word != "" {
+				quoted = append(quoted, regexp.QuoteMeta(word))
+			}
+		}
+		if len(quoted) > 0 {
+			m.patterns[category] = regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+		}
+	}
+	return m
+}
+
+// Moderate flags the categories whose words appear in text
+// Moderate 标记其词语出现在文本中的类别
+func (m *KeywordModerator) Moderate(ctx context.Context, text string) (*ModerationResult, error) {
+	result := &ModerationResult{
+		Categories: make(map[string]bool, len(m.patterns)),
+		Scores:     make(map[string]float64, len(m.patterns)),
+	}
+	for category, pattern := range m.patterns {
+		flagged := pattern.MatchString(text)
+		result.Categories[category] = flagged
+		if flagged {
+			result.Scores[category] = 1
+			result.Flagged = true
+		} else {
+			result.Scores[category] = 0
+		}
+	}
+	return result, nil
+}
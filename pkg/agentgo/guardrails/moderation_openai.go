@@ -0,0 +1,96 @@
+package guardrails
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIModeratorConfig configures the OpenAI moderation endpoint client
+// OpenAIModeratorConfig 配置 OpenAI 审核接口客户端
+type OpenAIModeratorConfig struct {
+	// APIKey is the OpenAI API key (required)
+	// APIKey 是 OpenAI API 密钥（必需）
+	APIKey string
+	// BaseURL defaults to https://api.openai.com/v1
+	// BaseURL 默认为 https://api.openai.com/v1
+	BaseURL string
+	// Model defaults to omni-moderation-latest
+	// Model 默认为 omni-moderation-latest
+	Model string
+	// Timeout is the request timeout (default: 10s)
+	// Timeout 是请求超时时间（默认：10 秒）
+	Timeout time.Duration
+}
+
+// OpenAIModerator classifies text with the OpenAI moderation endpoint
+// OpenAIModerator 使用 OpenAI 审核接口对文本进行分类
+type OpenAIModerator struct {
+	config OpenAIModeratorConfig
+	client *http.Client
+}
+
+// NewOpenAIModerator creates a moderator that calls the OpenAI moderation endpoint
+// NewOpenAIModerator 创建调用 OpenAI 审核接口的审核器
+func NewOpenAIModerator(config OpenAIModeratorConfig) (*OpenAIModerator, error) {
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("OpenAI API key is required")
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = "https://api.openai.com/v1"
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	if config.Model == "" {
+		config.Model = "omni-moderation-latest"
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	return &OpenAIModerator{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+	}, nil
+}
+
+// Moderate sends text to the moderation endpoint
+// Moderate 将文本发送到审核接口
+func (m *OpenAIModerator) Moderate(ctx context.Context, text string) (*ModerationResult, error) {
+	body, err := json.Marshal(map[string]string{"model": m.config.Model, "input": text})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.config.BaseURL+"/moderations", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+m.config.APIKey)
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("moderation request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("moderation request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var out struct {
+		Results []ModerationResult `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode moderation response: %w", err)
+	}
+	if len(out.Results) == 0 {
+		return nil, fmt.Errorf("moderation response has no results")
+	}
+	return &out.Results[0], nil
+}
@@ -0,0 +1,142 @@
+package guardrails
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+func TestNewModerationGuardrail_Validation(t *testing.T) {
+	moderator := NewKeywordModerator(nil)
+	configs := []ModerationConfig{
+		{},
+		{Moderator: moderator, DefaultAction: "drop"},
+		{Moderator: moderator, Policy: map[string]string{"hate": "warn"}},
+		{Moderator: moderator, Thresholds: map[string]float64{"hate": 1.5}},
+	}
+	for _, config := range configs {
+		if _, err := NewModerationGuardrail(config); err == nil {
+			t.Errorf("NewModerationGuardrail(%+v) expected an error", config)
+		}
+	}
+}
+
+func TestModerationGuardrail_Policy(t *testing.T) {
+	moderator := NewKeywordModerator(map[string][]string{
+		"violence":  {"attack", "kill"},
+		"profanity": {"damn"},
+		"spam":      {"buy now"},
+	})
+	g, err := NewModerationGuardrail(ModerationConfig{
+		Moderator: moderator,
+		Policy:    map[string]string{"profanity": "flag", "spam": "allow"},
+	})
+	if err != nil {
+		t.Fatalf("NewModerationGuardrail: %v", err)
+	}
+	ctx := context.Background()
+
+	report := NewGuardrailReport()
+	if err := g.Check(ctx, NewCheckInput("Damn, BUY NOW!").WithReport(report)); err != nil {
+		t.Fatalf("Check() error = %v, want flag only", err)
+	}
+	findings := report.Findings()
+	if len(findings) != 1 || findings[0].Rule != "profanity" {
+		t.Errorf("findings = %+v", findings)
+	}
+
+	err = g.Check(ctx, &CheckInput{Input: "hello", Output: "We will attack at dawn"})
+	var agentErr *types.AgnoError
+	if !errors.As(err, &agentErr) || agentErr.Code != types.ErrCodeContentModeration || !strings.Contains(err.Error(), "violence") {
+		t.Errorf("Check() error = %v, want the output blocked for violence", err)
+	}
+
+	// Words match on boundaries only
+	if err := g.Check(ctx, NewCheckInput("The skill tree")); err != nil {
+		t.Errorf("Check() error = %v", err)
+	}
+}
+
+func TestModerationGuardrail_Thresholds(t *testing.T) {
+	moderator := ModeratorFunc(func(ctx context.Context, text string) (*ModerationResult, error) {
+		return &ModerationResult{
+			Flagged:    true,
+			Categories: map[string]bool{"harassment": true, "hate": false},
+			Scores:     map[string]float64{"harassment": 0.55, "hate": 0.4},
+		}, nil
+	})
+
+	g, _ := NewModerationGuardrail(ModerationConfig{
+		Moderator:  moderator,
+		Thresholds: map[string]float64{"harassment": 0.9, "hate": 0.3},
+	})
+	err := g.Check(context.Background(), NewCheckInput("text"))
+	if err == nil || !strings.Contains(err.Error(), "blocked by moderation: hate") {
+		t.Errorf("Check() error = %v, want hate blocked by its threshold and harassment allowed", err)
+	}
+}
+
+func TestModerationGuardrail_ModeratorFailure(t *testing.T) {
+	moderator := ModeratorFunc(func(ctx context.Context, text string) (*ModerationResult, error) {
+		return nil, errors.New("service unavailable")
+	})
+
+	g, _ := NewModerationGuardrail(ModerationConfig{Moderator: moderator})
+	if err := g.Check(context.Background(), NewCheckInput("text")); err == nil {
+		t.Error("expected the check to fail closed")
+	}
+
+	g, _ = NewModerationGuardrail(ModerationConfig{Moderator: moderator, FailOpen: true})
+	report := NewGuardrailReport()
+	if err := g.Check(context.Background(), NewCheckInput("text").WithReport(report)); err != nil {
+		t.Errorf("Check() error = %v, want fail open", err)
+	}
+	if !report.HasFindings() {
+		t.Error("expected the failure on the report")
+	}
+}
+
+func TestOpenAIModerator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/moderations" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["model"] != "omni-moderation-latest" || body["input"] != "I will hurt you" {
+			http.Error(w, "unexpected body", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"id":"modr-1","results":[{"flagged":true,
+			"categories":{"violence":true,"hate":false},
+			"category_scores":{"violence":0.93,"hate":0.01}}]}`))
+	}))
+	defer server.Close()
+
+	if _, err := NewOpenAIModerator(OpenAIModeratorConfig{}); err == nil {
+		t.Error("expected an error without an API key")
+	}
+	moderator, err := NewOpenAIModerator(OpenAIModeratorConfig{APIKey: "sk-test", BaseURL: server.URL + "/v1/"})
+	if err != nil {
+		t.Fatalf("NewOpenAIModerator: %v", err)
+	}
+
+	result, err := moderator.Moderate(context.Background(), "I will hurt you")
+	if err != nil {
+		t.Fatalf("Moderate: %v", err)
+	}
+	if !result.Flagged || !result.Categories["violence"] || result.Scores["violence"] != 0.93 {
+		t.Errorf("result = %+v", result)
+	}
+
+	if _, err := moderator.Moderate(context.Background(), "other"); err == nil || !strings.Contains(err.Error(), "status 400") {
+		t.Errorf("Moderate() error = %v, want the HTTP status", err)
+	}
+}
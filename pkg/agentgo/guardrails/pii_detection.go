@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/jholhewres/agent-go/pkg/agentgo/types"
@@ -34,6 +35,70 @@ const (
 	PIITypeCNPJ PIIType = "cnpj"
 )
 
+// PIIMappingKey is the CheckInput metadata key holding the PIIMapping of a
+// redacting PII guardrail. A PIIMapping already stored there is extended, so
+// a value keeps its placeholder across the input and output checks of a run.
+// PIIMappingKey 是 CheckInput 元数据中存放 redact 模式 PII 防护栏 PIIMapping 的键。
+// 已存在的 PIIMapping 会被扩展，因此同一个值在一次运行的输入和输出检查中保持相同的占位符。
+const PIIMappingKey = "pii_mapping"
+
+// PIIMapping maps placeholders such as <EMAIL_1> to the values they replace
+// PIIMapping 将 <EMAIL_1> 等占位符映射到其替换的值
+type PIIMapping map[string]string
+
+// Restore replaces the placeholders in text with their original values
+// Restore 将文本中的占位符替换为原始值
+func (m PIIMapping) Restore(text string) string {
+	if len(m) == 0 || !strings.Contains(text, "<") {
+		return text
+	}
+	pairs := make([]string, 0, len(m)*2)
+	for placeholder, value := range m {
+		pairs = append(pairs, placeholder, value)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// Anonymize replaces the original values in text with their placeholders,
+// e.g. in a tool result that echoes a restored argument
+// Anonymize 将文本中的原始值替换为其占位符，例如回显已还原参数的工具结果
+func (m PIIMapping) Anonymize(text string) string {
+	if len(m) == 0 {
+		return text
+	}
+	values := make([]string, 0, len(m))
+	byValue := make(map[string]string, len(m))
+	for placeholder, value := range m {
+		values = append(values, value)
+		byValue[value] = placeholder
+	}
+	// Longer values first, so a value containing another is replaced whole.
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	pairs := make([]string, 0, len(values)*2)
+	for _, value := range values {
+		pairs = append(pairs, value, byValue[value])
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// placeholder returns the placeholder for value, adding it when new
+func (m PIIMapping) placeholder(piiType PIIType, value string) string {
+	prefix := "<" + strings.ToUpper(string(piiType)) + "_"
+	n := 0
+	for placeholder, v := range m {
+		if !strings.HasPrefix(placeholder, prefix) {
+			continue
+		}
+		if v == value {
+			return placeholder
+		}
+		n++
+	}
+	placeholder := fmt.Sprintf("%s%d>", prefix, n+1)
+	m[placeholder] = value
+	return placeholder
+}
+
 // PIIDetection contains details about detected PII
 // PIIDetection 包含检测到的 PII 的详细信息
 type PIIDetection struct {
@@ -60,8 +125,11 @@ type PIIDetectionGuardrail struct {
 	// EnabledTypes specifies which PII types to detect (empty = all)
 	// EnabledTypes 指定要检测的 PII 类型（空 = 全部）
 	EnabledTypes []PIIType
-	// OnDetection specifies the action: "block" or "warn"
-	// OnDetection 指定操作："block" 或 "warn"
+	// OnDetection specifies the action: "block", "warn" or "redact". Redact
+	// replaces each match with a typed placeholder such as <EMAIL_1> and records
+	// the PIIMapping under PIIMappingKey in the metadata.
+	// OnDetection 指定操作："block"、"warn" 或 "redact"。redact 将每个匹配替换为
+	// <EMAIL_1> 这样的类型化占位符，并在元数据的 PIIMappingKey 下记录 PIIMapping。
 	OnDetection string
 	// MaskInOutput determines if PII should be masked in error messages
 	// MaskInOutput 确定是否在错误消息中掩码 PII
@@ -69,6 +137,10 @@ type PIIDetectionGuardrail struct {
 	// patterns is the compiled regex patterns for each PII type
 	// patterns 是每种 PII 类型的编译正则表达式模式
 	patterns map[PIIType]*regexp.Regexp
+	// validators reject pattern matches that are not real values, e.g. card
+	// numbers failing the Luhn checksum
+	// validators 排除不是真实值的模式匹配，例如未通过 Luhn 校验的卡号
+	validators map[PIIType]func(string) bool
 }
 
 // NewPIIDetectionGuardrail creates a new PII detection guardrail with default settings.
@@ -100,6 +172,11 @@ func NewPIIDetectionGuardrailWithTypes(piiTypes []PIIType, action string) *PIIDe
 
 func (g *PIIDetectionGuardrail) compilePatterns() {
 	g.patterns = make(map[PIIType]*regexp.Regexp)
+	g.validators = map[PIIType]func(string) bool{
+		PIITypeCreditCard: ValidLuhn,
+		PIITypeCPF:        ValidCPF,
+		PIITypeCNPJ:       ValidCNPJ,
+	}
 
 	// Email pattern
 	// 电子邮件模式
@@ -138,9 +215,56 @@ func (g *PIIDetectionGuardrail) compilePatterns() {
 	)
 }
 
-// Check validates the input for PII
-// Check 验证输入中的 PII
+// RegisterPattern adds a custom PII type, e.g. employee IDs or account
+// numbers, and enables it. validator, when not nil, rejects matches that are
+// not real values. Registering an existing name replaces its pattern and
+// validator. Register patterns before the guardrail is used; it is not safe
+// to call concurrently with Check.
+// RegisterPattern 添加自定义 PII 类型（例如员工编号或账号）并启用它。validator 不为 nil 时
+// 用于排除不是真实值的匹配。注册已存在的名称会替换其模式和校验器。请在使用防护栏之前注册，
+// 不能与 Check 并发调用。
+func (g *PIIDetectionGuardrail) RegisterPattern(name string, pattern *regexp.Regexp, validator func(match string) bool) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("pattern name is required")
+	}
+	if pattern == nil {
+		return fmt.Errorf("pattern %s: regexp is required", name)
+	}
+	if g.patterns == nil {
+		g.compilePatterns()
+	}
+
+	piiType := PIIType(name)
+	g.patterns[piiType] = pattern
+	if validator != nil {
+		g.validators[piiType] = validator
+	} else {
+		delete(g.validators, piiType)
+	}
+	for _, enabled := range g.EnabledTypes {
+		if enabled == piiType {
+			return nil
+		}
+	}
+	g.EnabledTypes = append(g.EnabledTypes, piiType)
+	return nil
+}
+
+// valid reports whether a match of piiType passes its validator
+func (g *PIIDetectionGuardrail) valid(piiType PIIType, value string) bool {
+	validator, ok := g.validators[piiType]
+	return !ok || validator(value)
+}
+
+// Check validates the input for PII. In redact mode it rewrites the output
+// when set, otherwise the input, and never fails.
+// Check 验证输入中的 PII。在 redact 模式下，设置了输出时改写输出，否则改写输入，且不会失败。
 func (g *PIIDetectionGuardrail) Check(ctx context.Context, input *CheckInput) error {
+	if g.OnDetection == "redact" {
+		g.pseudonymize(input)
+		return nil
+	}
+
 	var detections []PIIDetection
 
 	for _, piiType := range g.EnabledTypes {
@@ -152,6 +276,9 @@ func (g *PIIDetectionGuardrail) Check(ctx context.Context, input *CheckInput) er
 		matches := pattern.FindAllStringIndex(input.Input, -1)
 		for _, match := range matches {
 			value := input.Input[match[0]:match[1]]
+			if !g.valid(piiType, value) {
+				continue
+			}
 			detections = append(detections, PIIDetection{
 				Type:       piiType,
 				Value:      g.maskValue(value),
@@ -178,6 +305,83 @@ func (g *PIIDetectionGuardrail) Check(ctx context.Context, input *CheckInput) er
 	return nil
 }
 
+// Redact replaces detected PII with a placeholder naming its type, e.g.
+// [REDACTED:email]
+// Redact 将检测到的 PII 替换为标明类型的占位符，例如 [REDACTED:email]
+func (g *PIIDetectionGuardrail) Redact(text string) string {
+	for _, piiType := range g.redactionOrder() {
+		text = g.replace(piiType, text, func(string) string {
+			return "[REDACTED:" + string(piiType) + "]"
+		})
+	}
+	return text
+}
+
+// builtinRedactionOrder lists specific formats before the broad phone
+// pattern, which also matches parts of document and card numbers
+var builtinRedactionOrder = []PIIType{
+	PIITypeEmail, PIITypeCNPJ, PIITypeCPF, PIITypeSSN, PIITypeCreditCard, PIITypePhone,
+}
+
+// redactionOrder returns the enabled types in replacement order: custom
+// types, which are usually the most specific, then the built-in ones
+func (g *PIIDetectionGuardrail) redactionOrder() []PIIType {
+	enabled := make(map[PIIType]bool, len(g.EnabledTypes))
+	var order []PIIType
+	for _, piiType := range g.EnabledTypes {
+		enabled[piiType] = true
+		if _, builtin := knownPIITypes[piiType]; !builtin {
+			order = append(order, piiType)
+		}
+	}
+	for _, piiType := range builtinRedactionOrder {
+		if enabled[piiType] {
+			order = append(order, piiType)
+		}
+	}
+	return order
+}
+
+// replace replaces the valid matches of piiType in text
+func (g *PIIDetectionGuardrail) replace(piiType PIIType, text string, repl func(string) string) string {
+	pattern, ok := g.patterns[piiType]
+	if !ok {
+		return text
+	}
+	return pattern.ReplaceAllStringFunc(text, func(value string) string {
+		if !g.valid(piiType, value) {
+			return value
+		}
+		return repl(value)
+	})
+}
+
+// pseudonymize replaces PII in the checked text with typed placeholders
+func (g *PIIDetectionGuardrail) pseudonymize(input *CheckInput) {
+	text := &input.Input
+	if input.Output != "" {
+		text = &input.Output
+	}
+
+	if input.Metadata == nil {
+		input.Metadata = make(map[string]interface{})
+	}
+	mapping, ok := input.Metadata[PIIMappingKey].(PIIMapping)
+	if !ok {
+		mapping = PIIMapping{}
+	}
+
+	for _, piiType := range g.redactionOrder() {
+		*text = g.replace(piiType, *text, func(value string) string {
+			return mapping.placeholder(piiType, value)
+		})
+	}
+
+	if len(mapping) > 0 {
+		input.Metadata[PIIMappingKey] = mapping
+	}
+}
+
 func (g *PIIDetectionGuardrail) maskValue(value string) string {
 	if !g.MaskInOutput {
 		return value
@@ -207,3 +411,91 @@ func (g *PIIDetectionGuardrail) buildDetectionMessage(detections []PIIDetection)
 func (g *PIIDetectionGuardrail) Name() string {
 	return "PIIDetectionGuardrail"
 }
+
+// ValidLuhn reports whether the digits in s, ignoring spaces and dashes, pass
+// the Luhn checksum used by payment card numbers
+// ValidLuhn 判断 s 中的数字（忽略空格和连字符）是否通过支付卡号使用的 Luhn 校验
+func ValidLuhn(s string) bool {
+	digits := onlyDigits(s)
+	if len(digits) < 12 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := digits[i]
+		if (len(digits)-1-i)%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// ValidCPF reports whether s is a Brazilian CPF with correct check digits
+// ValidCPF 判断 s 是否为校验位正确的巴西 CPF
+func ValidCPF(s string) bool {
+	digits := onlyDigits(s)
+	if len(digits) != 11 || allSame(digits) {
+		return false
+	}
+	return digits[9] == cpfCheckDigit(digits[:9]) && digits[10] == cpfCheckDigit(digits[:10])
+}
+
+func cpfCheckDigit(digits []int) int {
+	sum := 0
+	for i, d := range digits {
+		sum += d * (len(digits) + 1 - i)
+	}
+	if r := sum % 11; r >= 2 {
+		return 11 - r
+	}
+	return 0
+}
+
+// ValidCNPJ reports whether s is a Brazilian CNPJ with correct check digits
+// ValidCNPJ 判断 s 是否为校验位正确的巴西 CNPJ
+func ValidCNPJ(s string) bool {
+	digits := onlyDigits(s)
+	if len(digits) != 14 || allSame(digits) {
+		return false
+	}
+	return digits[12] == cnpjCheckDigit(digits[:12]) && digits[13] == cnpjCheckDigit(digits[:13])
+}
+
+func cnpjCheckDigit(digits []int) int {
+	sum := 0
+	weight := len(digits) - 7 // 5 then 6, cycling down to 2 and restarting at 9
+	for _, d := range digits {
+		sum += d * weight
+		weight--
+		if weight < 2 {
+			weight = 9
+		}
+	}
+	if r := sum % 11; r >= 2 {
+		return 11 - r
+	}
+	return 0
+}
+
+func onlyDigits(s string) []int {
+	digits := make([]int, 0, len(s))
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, int(r-'0'))
+		}
+	}
+	return digits
+}
+
+func allSame(digits []int) bool {
+	for _, d := range digits[1:] {
+		if d != digits[0] {
+			return false
+		}
+	}
+	return true
+}
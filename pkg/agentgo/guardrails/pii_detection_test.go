@@ -2,6 +2,7 @@ package guardrails
 
 import (
 	"context"
+	"regexp"
 	"strings"
 	"testing"
 )
@@ -93,8 +94,8 @@ func TestPIIDetectionGuardrail_CreditCard(t *testing.T) {
 		input     string
 		shouldErr bool
 	}{
-		{"credit card with dashes", "Card: 1234-5678-9012-3456", true},
-		{"credit card with spaces", "Card: 1234 5678 9012 3456", true},
+		{"credit card with dashes", "Card: 5500-0000-0000-0004", true},
+		{"credit card with spaces", "Card: 4111 1111 1111 1111", true},
 		{"no credit card", "This text has no credit card", false},
 	}
 
@@ -119,7 +120,7 @@ func TestPIIDetectionGuardrail_CPF(t *testing.T) {
 		input     string
 		shouldErr bool
 	}{
-		{"valid CPF", "Meu CPF é 123.456.789-00", true},
+		{"valid CPF", "Meu CPF é 529.982.247-25", true},
 		{"no CPF", "Este texto não tem CPF", false},
 		{"wrong format", "CPF format is XXX.XXX.XXX-XX", false},
 	}
@@ -145,7 +146,7 @@ func TestPIIDetectionGuardrail_CNPJ(t *testing.T) {
 		input     string
 		shouldErr bool
 	}{
-		{"valid CNPJ", "O CNPJ da empresa é 12.345.678/0001-90", true},
+		{"valid CNPJ", "O CNPJ da empresa é 11.222.333/0001-81", true},
 		{"no CNPJ", "Este texto não tem CNPJ", false},
 	}
 
@@ -165,7 +166,7 @@ func TestPIIDetectionGuardrail_CNPJ(t *testing.T) {
 func TestPIIDetectionGuardrail_MultipleTypes(t *testing.T) {
 	g := NewPIIDetectionGuardrail()
 
-	input := "Contact user@example.com or call 555-123-4567. My CPF is 123.456.789-00."
+	input := "Contact user@example.com or call 555-123-4567. My CPF is 123.456.789-09."
 	err := g.Check(context.Background(), &CheckInput{Input: input, Metadata: make(map[string]interface{})})
 
 	if err == nil {
@@ -260,3 +261,122 @@ func TestPIIDetectionGuardrail_Name(t *testing.T) {
 		t.Errorf("Expected name 'PIIDetectionGuardrail', got %s", g.Name())
 	}
 }
+
+func TestPIIDetectionGuardrail_Redact(t *testing.T) {
+	g := NewPIIDetectionGuardrailWithTypes([]PIIType{PIITypeEmail, PIITypeSSN}, "block")
+
+	got := g.Redact("Mail john@example.com, SSN 123-45-6789.")
+	want := "Mail [REDACTED:email], SSN [REDACTED:ssn]."
+	if got != want {
+		t.Errorf("Redact() = %q, want %q", got, want)
+	}
+
+	if err := g.Check(context.Background(), &CheckInput{Input: got}); err != nil {
+		t.Errorf("Check() on redacted text error = %v", err)
+	}
+}
+
+func TestPIIDetectionGuardrail_RedactMode(t *testing.T) {
+	g := NewPIIDetectionGuardrailWithTypes([]PIIType{PIITypeEmail, PIITypeCPF}, "redact")
+
+	input := NewCheckInput("Mail ana@example.com and bob@example.com, CPF 123.456.789-09, cc ana@example.com")
+	if err := g.Check(context.Background(), input); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	want := "Mail <EMAIL_1> and <EMAIL_2>, CPF <CPF_1>, cc <EMAIL_1>"
+	if input.Input != want {
+		t.Errorf("Input = %q, want %q", input.Input, want)
+	}
+	mapping, ok := input.Metadata[PIIMappingKey].(PIIMapping)
+	if !ok || len(mapping) != 3 || mapping["<EMAIL_2>"] != "bob@example.com" {
+		t.Fatalf("mapping = %v", input.Metadata[PIIMappingKey])
+	}
+	if got := mapping.Restore("Write to <EMAIL_2>"); got != "Write to bob@example.com" {
+		t.Errorf("Restore() = %q", got)
+	}
+	if got := mapping.Anonymize("sent to bob@example.com"); got != "sent to <EMAIL_2>" {
+		t.Errorf("Anonymize() = %q", got)
+	}
+
+	// The output reuses the mapping, so known values keep their placeholder
+	output := &CheckInput{Input: "q", Output: "Ask bob@example.com or eve@example.com", Metadata: input.Metadata}
+	if err := g.Check(context.Background(), output); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if output.Output != "Ask <EMAIL_2> or <EMAIL_3>" || output.Input != "q" {
+		t.Errorf("Output = %q, Input = %q", output.Output, output.Input)
+	}
+	if len(mapping) != 4 {
+		t.Errorf("mapping = %v, want the existing mapping extended", mapping)
+	}
+}
+
+func TestPIIDetectionGuardrail_CheckDigits(t *testing.T) {
+	tests := []struct {
+		piiType   PIIType
+		input     string
+		shouldErr bool
+	}{
+		{PIITypeCreditCard, "Card: 4111-1111-1111-1111", true},
+		{PIITypeCreditCard, "Order 1234 5678 9012 3456", false},
+		{PIITypeCPF, "CPF 123.456.789-09", true},
+		{PIITypeCPF, "CPF 123.456.789-00", false},
+		{PIITypeCPF, "CPF 111.111.111-11", false},
+		{PIITypeCNPJ, "CNPJ 12.345.678/0001-95", true},
+		{PIITypeCNPJ, "CNPJ 12.345.678/0001-90", false},
+	}
+
+	for _, tt := range tests {
+		g := NewPIIDetectionGuardrailWithTypes([]PIIType{tt.piiType}, "block")
+		err := g.Check(context.Background(), NewCheckInput(tt.input))
+		if (err != nil) != tt.shouldErr {
+			t.Errorf("Check(%q) error = %v, want error %v", tt.input, err, tt.shouldErr)
+		}
+	}
+
+	// Invalid numbers are left alone when redacting
+	g := NewPIIDetectionGuardrailWithTypes([]PIIType{PIITypeCreditCard}, "block")
+	if got := g.Redact("4111 1111 1111 1111 and 1234 5678 9012 3456"); got != "[REDACTED:credit_card] and 1234 5678 9012 3456" {
+		t.Errorf("Redact() = %q", got)
+	}
+}
+
+func TestPIIDetectionGuardrail_RegisterPattern(t *testing.T) {
+	g := NewPIIDetectionGuardrailWithTypes([]PIIType{PIITypeEmail}, "redact")
+	if err := g.RegisterPattern("", regexp.MustCompile(`x`), nil); err == nil {
+		t.Error("expected an error for an empty name")
+	}
+	if err := g.RegisterPattern("employee_id", nil, nil); err == nil {
+		t.Error("expected an error for a nil pattern")
+	}
+
+	// Account numbers end with a mod-10 check digit
+	account := func(match string) bool {
+		digits := onlyDigits(match)
+		sum := 0
+		for _, d := range digits[:len(digits)-1] {
+			sum += d
+		}
+		return sum%10 == digits[len(digits)-1]
+	}
+	if err := g.RegisterPattern("employee_id", regexp.MustCompile(`\bEMP-[0-9]{6}\b`), nil); err != nil {
+		t.Fatalf("RegisterPattern() error = %v", err)
+	}
+	if err := g.RegisterPattern("account", regexp.MustCompile(`\bACC[0-9]{4}\b`), account); err != nil {
+		t.Fatalf("RegisterPattern() error = %v", err)
+	}
+
+	input := NewCheckInput("EMP-004217 owns ACC1236 and ACC1239, mail a@b.io")
+	if err := g.Check(context.Background(), input); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if want := "<EMPLOYEE_ID_1> owns <ACCOUNT_1> and ACC1239, mail <EMAIL_1>"; input.Input != want {
+		t.Errorf("Input = %q, want %q", input.Input, want)
+	}
+
+	g.OnDetection = "block"
+	err := g.Check(context.Background(), NewCheckInput("ask EMP-000001"))
+	if err == nil || !strings.Contains(err.Error(), "employee_id: 1") {
+		t.Errorf("Check() error = %v, want the custom type detected", err)
+	}
+}
@@ -0,0 +1,306 @@
+package guardrails
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Policy describes a set of guardrails loadable from a YAML or JSON file
+// Policy 描述可从 YAML 或 JSON 文件加载的一组防护栏
+type Policy struct {
+	// PromptInjection configures the prompt injection guardrail (nil = disabled)
+	// PromptInjection 配置提示注入防护栏（nil = 禁用）
+	PromptInjection *PromptInjectionPolicy `json:"prompt_injection,omitempty" yaml:"prompt_injection,omitempty"`
+	// PII configures the PII detection guardrail (nil = disabled)
+	// PII 配置 PII 检测防护栏（nil = 禁用）
+	PII *PIIPolicy `json:"pii,omitempty" yaml:"pii,omitempty"`
+	// URLValidation configures the URL validation guardrail (nil = disabled)
+	// URLValidation 配置 URL 验证防护栏（nil = 禁用）
+	URLValidation *URLValidationPolicy `json:"url_validation,omitempty" yaml:"url_validation,omitempty"`
+	// FactCheck configures the numeric/date claim verification guardrail (nil = disabled)
+	// FactCheck 配置数字/日期声明核查防护栏（nil = 禁用）
+	FactCheck *FactCheckPolicy `json:"fact_check,omitempty" yaml:"fact_check,omitempty"`
+}
+
+// PromptInjectionPolicy configures prompt injection detection
+// PromptInjectionPolicy 配置提示注入检测
+type PromptInjectionPolicy struct {
+	// Patterns are additional patterns to block
+	// Patterns 是要拦截的附加模式
+	Patterns []string `json:"patterns,omitempty" yaml:"patterns,omitempty"`
+	// UseDefaults includes DefaultInjectionPatterns
+	// UseDefaults 包含 DefaultInjectionPatterns
+	UseDefaults bool `json:"use_defaults,omitempty" yaml:"use_defaults,omitempty"`
+	// CaseSensitive enables case-sensitive matching
+	// CaseSensitive 启用区分大小写的匹配
+	CaseSensitive bool `json:"case_sensitive,omitempty" yaml:"case_sensitive,omitempty"`
+}
+
+// PIIPolicy configures PII detection
+// PIIPolicy 配置 PII 检测
+type PIIPolicy struct {
+	// Types lists the PII types to detect (empty = all)
+	// Types 列出要检测的 PII 类型（空 = 全部）
+	Types []PIIType `json:"types,omitempty" yaml:"types,omitempty"`
+	// Action is "block" (default), "warn" or "redact"
+	// Action 为 "block"（默认）、"warn" 或 "redact"
+	Action string `json:"action,omitempty" yaml:"action,omitempty"`
+	// Patterns adds custom PII types, name -> regular expression, e.g.
+	// employee_id: "EMP-[0-9]{6}". They are detected in addition to Types.
+	// Patterns 添加自定义 PII 类型（名称 -> 正则表达式），例如 employee_id: "EMP-[0-9]{6}"，
+	// 与 Types 一起检测。
+	Patterns map[string]string `json:"patterns,omitempty" yaml:"patterns,omitempty"`
+}
+
+// URLValidationPolicy mirrors URLValidationConfig for policy files
+// URLValidationPolicy 是策略文件中对应 URLValidationConfig 的配置
+type URLValidationPolicy struct {
+	AllowedDomains       []string `json:"allowed_domains,omitempty" yaml:"allowed_domains,omitempty"`
+	BlockedDomains       []string `json:"blocked_domains,omitempty" yaml:"blocked_domains,omitempty"`
+	AllowPrivateIPs      bool     `json:"allow_private_ips,omitempty" yaml:"allow_private_ips,omitempty"`
+	AllowFileScheme      bool     `json:"allow_file_scheme,omitempty" yaml:"allow_file_scheme,omitempty"`
+	DetectHallucinations bool     `json:"detect_hallucinations,omitempty" yaml:"detect_hallucinations,omitempty"`
+}
+
+// FactCheckPolicy mirrors FactCheckConfig for policy files
+// FactCheckPolicy 是策略文件中对应 FactCheckConfig 的配置
+type FactCheckPolicy struct {
+	SourceRoles        []types.Role `json:"source_roles,omitempty" yaml:"source_roles,omitempty"`
+	Tolerance          float64      `json:"tolerance,omitempty" yaml:"tolerance,omitempty"`
+	CheckSmallIntegers bool         `json:"check_small_integers,omitempty" yaml:"check_small_integers,omitempty"`
+	Action             string       `json:"action,omitempty" yaml:"action,omitempty"`
+}
+
+var knownPIITypes = map[PIIType]struct{}{
+	PIITypeEmail:      {},
+	PIITypePhone:      {},
+	PIITypeSSN:        {},
+	PIITypeCreditCard: {},
+	PIITypeCPF:        {},
+	PIITypeCNPJ:       {},
+}
+
+// Validate checks the policy for unknown values
+// Validate 检查策略中的未知值
+func (p *Policy) Validate() error {
+	if p.PromptInjection != nil {
+		if !p.PromptInjection.UseDefaults && len(p.PromptInjection.Patterns) == 0 {
+			return fmt.Errorf("prompt_injection: patterns are required when use_defaults is false")
+		}
+		for i, pattern := range p.PromptInjection.Patterns {
+			if strings.TrimSpace(pattern) == "" {
+				return fmt.Errorf("prompt_injection: pattern %d is empty", i)
+			}
+		}
+	}
+
+	if p.PII != nil {
+		switch p.PII.Action {
+		case "", "block", "warn", "redact":
+		default:
+			return fmt.Errorf("pii: invalid action %q (want block, warn or redact)", p.PII.Action)
+		}
+		for _, piiType := range p.PII.Types {
+			if _, ok := knownPIITypes[piiType]; !ok {
+				return fmt.Errorf("pii: unknown type %q", piiType)
+			}
+		}
+		for name, pattern := range p.PII.Patterns {
+			if _, ok := knownPIITypes[PIIType(name)]; ok {
+				return fmt.Errorf("pii: pattern %q uses a built-in type name", name)
+			}
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("pii: pattern %q: %w", name, err)
+			}
+		}
+	}
+
+	if fc := p.FactCheck; fc != nil {
+		if _, err := NewFactCheckGuardrail(fc.config()); err != nil {
+			return fmt.Errorf("fact_check: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (fc *FactCheckPolicy) config() FactCheckConfig {
+	return FactCheckConfig{
+		SourceRoles:        fc.SourceRoles,
+		Tolerance:          fc.Tolerance,
+		CheckSmallIntegers: fc.CheckSmallIntegers,
+		Action:             fc.Action,
+	}
+}
+
+// Build validates the policy and creates the configured guardrails
+// Build 验证策略并创建配置的防护栏
+func (p *Policy) Build() ([]Guardrail, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	var result []Guardrail
+
+	if pi := p.PromptInjection; pi != nil {
+		var patterns []string
+		if pi.UseDefaults {
+			patterns = append(patterns, DefaultInjectionPatterns()...)
+		}
+		patterns = append(patterns, pi.Patterns...)
+		result = append(result, NewPromptInjectionGuardrailWithPatterns(patterns, pi.CaseSensitive))
+	}
+
+	if pii := p.PII; pii != nil {
+		action := pii.Action
+		if action == "" {
+			action = "block"
+		}
+		var g *PIIDetectionGuardrail
+		if len(pii.Types) == 0 {
+			g = NewPIIDetectionGuardrail()
+			g.OnDetection = action
+		} else {
+			g = NewPIIDetectionGuardrailWithTypes(pii.Types, action)
+		}
+		names := make([]string, 0, len(pii.Patterns))
+		for name := range pii.Patterns {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err := g.RegisterPattern(name, regexp.MustCompile(pii.Patterns[name]), nil); err != nil {
+				return nil, fmt.Errorf("pii: %w", err)
+			}
+		}
+		result = append(result, g)
+	}
+
+	if u := p.URLValidation; u != nil {
+		result = append(result, NewURLValidationGuardrail(URLValidationConfig{
+			AllowedDomains:       u.AllowedDomains,
+			BlockedDomains:       u.BlockedDomains,
+			AllowPrivateIPs:      u.AllowPrivateIPs,
+			AllowFileScheme:      u.AllowFileScheme,
+			DetectHallucinations: u.DetectHallucinations,
+		}))
+	}
+
+	if fc := p.FactCheck; fc != nil {
+		g, err := NewFactCheckGuardrail(fc.config())
+		if err != nil {
+			return nil, fmt.Errorf("fact_check: %w", err)
+		}
+		result = append(result, g)
+	}
+
+	return result, nil
+}
+
+// ParsePolicy decodes a policy from YAML or JSON based on the file extension
+// ParsePolicy 根据文件扩展名从 YAML 或 JSON 解码策略
+func ParsePolicy(name string, data []byte) (*Policy, error) {
+	var policy Policy
+	var err error
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		err = json.Unmarshal(data, &policy)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &policy)
+	default:
+		return nil, fmt.Errorf("unsupported policy file extension: %s", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse policy %s: %w", name, err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %w", name, err)
+	}
+	return &policy, nil
+}
+
+// LoadPolicy reads and validates a policy file
+// LoadPolicy 读取并验证策略文件
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy %s: %w", path, err)
+	}
+	return ParsePolicy(path, data)
+}
+
+// PolicyGuardrail runs the guardrails of a policy that can be swapped at runtime
+// PolicyGuardrail 运行可在运行时替换的策略防护栏
+type PolicyGuardrail struct {
+	current atomic.Pointer[policySet]
+}
+
+type policySet struct {
+	policy     *Policy
+	guardrails []Guardrail
+}
+
+// NewPolicyGuardrail creates a guardrail from an initial policy
+// NewPolicyGuardrail 使用初始策略创建防护栏
+func NewPolicyGuardrail(policy *Policy) (*PolicyGuardrail, error) {
+	g := &PolicyGuardrail{}
+	if err := g.Update(policy); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Update validates the policy and swaps it in atomically. In-flight checks
+// finish against the previous policy; on error the current policy is kept.
+// Update 验证策略并原子替换；出错时保留当前策略。
+func (g *PolicyGuardrail) Update(policy *Policy) error {
+	if policy == nil {
+		return fmt.Errorf("policy cannot be nil")
+	}
+	built, err := policy.Build()
+	if err != nil {
+		return err
+	}
+	g.current.Store(&policySet{policy: policy, guardrails: built})
+	return nil
+}
+
+// Policy returns the active policy
+// Policy 返回当前生效的策略
+func (g *PolicyGuardrail) Policy() *Policy {
+	if set := g.current.Load(); set != nil {
+		return set.policy
+	}
+	return nil
+}
+
+// Check runs every guardrail of the active policy
+// Check 运行当前策略的所有防护栏
+func (g *PolicyGuardrail) Check(ctx context.Context, input *CheckInput) error {
+	set := g.current.Load()
+	if set == nil {
+		return nil
+	}
+	for _, guardrail := range set.guardrails {
+		if err := guardrail.Check(ctx, input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Name returns the guardrail name
+// Name 返回防护栏名称
+func (g *PolicyGuardrail) Name() string {
+	return "PolicyGuardrail"
+}
@@ -0,0 +1,108 @@
+package guardrails
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParsePolicy_YAML(t *testing.T) {
+	data := []byte(`
+prompt_injection:
+  patterns: ["reveal the secret"]
+pii:
+  types: [email]
+  action: block
+url_validation:
+  blocked_domains: [evil.com]
+fact_check:
+  source_roles: [tool, system]
+  tolerance: 0.01
+`)
+	policy, err := ParsePolicy("policy.yaml", data)
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+
+	built, err := policy.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if len(built) != 4 {
+		t.Fatalf("expected 4 guardrails, got %d", len(built))
+	}
+}
+
+func TestParsePolicy_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"unknown pii type": `{"pii": {"types": ["passport"]}}`,
+		"bad action":       `{"pii": {"action": "explode"}}`,
+		"no patterns":      `{"prompt_injection": {}}`,
+		"fact check":       `{"fact_check": {"action": "drop"}}`,
+		"bad pii pattern":  `{"pii": {"patterns": {"employee_id": "EMP-[0-9"}}}`,
+		"builtin pattern":  `{"pii": {"patterns": {"email": "x"}}}`,
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParsePolicy("policy.json", []byte(data)); err == nil {
+				t.Fatal("expected validation error")
+			}
+		})
+	}
+
+	if _, err := ParsePolicy("policy.toml", nil); err == nil {
+		t.Fatal("expected error for unsupported extension")
+	}
+}
+
+func TestParsePolicy_PIIPatterns(t *testing.T) {
+	data := []byte(`
+pii:
+  types: [email]
+  patterns:
+    employee_id: "EMP-[0-9]{6}"
+`)
+	policy, err := ParsePolicy("policy.yaml", data)
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+	built, err := policy.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if err := built[0].Check(context.Background(), NewCheckInput("badge EMP-123456")); err == nil {
+		t.Error("expected the custom pattern to block")
+	}
+}
+
+func TestPolicyGuardrail_Update(t *testing.T) {
+	ctx := context.Background()
+	g, err := NewPolicyGuardrail(&Policy{
+		PromptInjection: &PromptInjectionPolicy{Patterns: []string{"open sesame"}},
+	})
+	if err != nil {
+		t.Fatalf("NewPolicyGuardrail() error = %v", err)
+	}
+
+	if err := g.Check(ctx, NewCheckInput("please open sesame")); err == nil {
+		t.Fatal("expected initial policy to block input")
+	}
+
+	if err := g.Update(&Policy{PII: &PIIPolicy{Action: "nope"}}); err == nil {
+		t.Fatal("expected invalid policy to be rejected")
+	}
+	if err := g.Check(ctx, NewCheckInput("please open sesame")); err == nil {
+		t.Fatal("expected previous policy to remain active after failed update")
+	}
+
+	if err := g.Update(&Policy{
+		PromptInjection: &PromptInjectionPolicy{Patterns: []string{"abracadabra"}},
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := g.Check(ctx, NewCheckInput("please open sesame")); err != nil {
+		t.Fatalf("expected new policy to allow input, got %v", err)
+	}
+	if err := g.Check(ctx, NewCheckInput("abracadabra")); err == nil {
+		t.Fatal("expected new policy to block input")
+	}
+}
@@ -0,0 +1,78 @@
+package guardrails
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Finding is a non-blocking issue recorded by a guardrail
+// Finding 是防护栏记录的非阻断问题
+type Finding struct {
+	// Guardrail is the name of the guardrail that recorded the finding
+	// Guardrail 是记录该问题的防护栏名称
+	Guardrail string `json:"guardrail"`
+	// Rule identifies the check that failed, e.g. "unverified_number"
+	// Rule 标识失败的检查，例如 "unverified_number"
+	Rule string `json:"rule"`
+	// Message is a human-readable description
+	// Message 是可读的描述
+	Message string `json:"message"`
+	// Value is the offending text, if any
+	// Value 是相关的文本（如有）
+	Value string `json:"value,omitempty"`
+}
+
+// GuardrailReport collects findings from guardrails that flag content
+// without blocking it. It is safe for concurrent use; a nil report ignores
+// findings.
+// GuardrailReport 收集仅标记而不阻断内容的防护栏问题，可并发使用；nil 报告会忽略问题。
+type GuardrailReport struct {
+	mu       sync.Mutex
+	findings []Finding
+}
+
+// NewGuardrailReport creates an empty report
+// NewGuardrailReport 创建空报告
+func NewGuardrailReport() *GuardrailReport {
+	return &GuardrailReport{}
+}
+
+// Add records a finding
+// Add 记录一个问题
+func (r *GuardrailReport) Add(f Finding) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.findings = append(r.findings, f)
+}
+
+// Findings returns a copy of the recorded findings
+// Findings 返回已记录问题的副本
+func (r *GuardrailReport) Findings() []Finding {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Finding(nil), r.findings...)
+}
+
+// HasFindings reports whether any finding was recorded
+// HasFindings 判断是否记录了任何问题
+func (r *GuardrailReport) HasFindings() bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.findings) > 0
+}
+
+// MarshalJSON encodes the report as {"findings": [...]}
+func (r *GuardrailReport) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Findings []Finding `json:"findings"`
+	}{Findings: r.Findings()})
+}
@@ -0,0 +1,108 @@
+package guardrails
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/structured"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// SchemaConfig configures the schema validation guardrail; set exactly one of
+// Schema and Type
+// SchemaConfig 配置模式校验防护栏；Schema 和 Type 必须且只能设置一个
+type SchemaConfig struct {
+	// Schema is the JSON Schema the answer must match
+	// Schema 是回答必须匹配的 JSON Schema
+	Schema map[string]interface{}
+	// Type is a Go struct (or pointer to one) the schema is derived from
+	// Type 是用于生成模式的 Go 结构体（或其指针）
+	Type interface{}
+}
+
+// SchemaViolation is the cause of a failed schema check. Its Correction tells
+// the model what was wrong and which schema to follow.
+// SchemaViolation 是模式检查失败的原因，其 Correction 告诉模型错误之处以及应遵循的模式。
+type SchemaViolation struct {
+	Problems []string
+	schema   string
+}
+
+func (v *SchemaViolation) Error() string {
+	return strings.Join(v.Problems, "; ")
+}
+
+// Correction implements Corrector
+// Correction 实现 Corrector
+func (v *SchemaViolation) Correction() string {
+	var b strings.Builder
+	b.WriteString("Your previous answer does not match the required JSON schema:\n")
+	for _, p := range v.Problems {
+		b.WriteString("- " + p + "\n")
+	}
+	b.WriteString("\nAnswer again with only a JSON value, without any other text, that matches this schema:\n")
+	b.WriteString(v.schema)
+	return b.String()
+}
+
+// SchemaGuardrail checks that an answer is JSON matching a schema. It checks
+// the output when set, otherwise the input. Used as an agent output guardrail
+// with MaxRetries, failures are sent back to the model for correction.
+// SchemaGuardrail 检查回答是否为匹配模式的 JSON。设置了输出时检查输出，否则检查输入。
+// 作为设置了 MaxRetries 的代理输出防护栏时，失败会发回模型进行修正。
+type SchemaGuardrail struct {
+	schema     map[string]interface{}
+	schemaJSON string
+}
+
+// NewSchemaGuardrail creates a new schema validation guardrail
+// NewSchemaGuardrail 创建新的模式校验防护栏
+func NewSchemaGuardrail(config SchemaConfig) (*SchemaGuardrail, error) {
+	schema := config.Schema
+	switch {
+	case schema != nil && config.Type != nil:
+		return nil, fmt.Errorf("set either a schema or a type, not both")
+	case config.Type != nil:
+		out, err := structured.SchemaFromType(config.Type)
+		if err != nil {
+			return nil, err
+		}
+		schema = out.Schema
+	case schema == nil:
+		return nil, fmt.Errorf("a schema or a type is required")
+	}
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return &SchemaGuardrail{schema: schema, schemaJSON: string(data)}, nil
+}
+
+// Check validates the output (or the input for pre-hooks) against the schema
+// Check 根据模式校验输出（在前置钩子中为输入）
+func (g *SchemaGuardrail) Check(ctx context.Context, input *CheckInput) error {
+	text := input.Output
+	if text == "" {
+		text = input.Input
+	}
+
+	err := structured.ValidateJSON(g.schema, []byte(text))
+	if err == nil {
+		return nil
+	}
+	var invalid *structured.ValidationError
+	if !errors.As(err, &invalid) {
+		return types.NewOutputCheckError("output does not match the schema", err)
+	}
+	return types.NewOutputCheckError("output does not match the schema", &SchemaViolation{Problems: invalid.Problems, schema: g.schemaJSON})
+}
+
+// Name returns the guardrail name
+// Name 返回防护栏名称
+func (g *SchemaGuardrail) Name() string {
+	return "SchemaGuardrail"
+}
@@ -0,0 +1,42 @@
+package guardrails
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testInvoice struct {
+	Number string  `json:"number"`
+	Total  float64 `json:"total"`
+}
+
+func TestSchemaGuardrail(t *testing.T) {
+	ctx := context.Background()
+	g, err := NewSchemaGuardrail(SchemaConfig{Type: testInvoice{}})
+	if err != nil {
+		t.Fatalf("NewSchemaGuardrail: %v", err)
+	}
+
+	if err := g.Check(ctx, &CheckInput{Output: `{"number": "A-1", "total": 12.5}`}); err != nil {
+		t.Errorf("Check() error = %v", err)
+	}
+
+	err = g.Check(ctx, &CheckInput{Input: "invoice?", Output: `{"number": "A-1", "total": "12.5"}`})
+	var corrector Corrector
+	if !errors.As(err, &corrector) {
+		t.Fatalf("Check() error = %v, want a Corrector", err)
+	}
+	correction := corrector.Correction()
+	if !strings.Contains(correction, "$.total: expected number, got string") || !strings.Contains(correction, `"total"`) {
+		t.Errorf("Correction() = %q", correction)
+	}
+
+	if _, err := NewSchemaGuardrail(SchemaConfig{}); err == nil {
+		t.Error("expected error without a schema")
+	}
+	if _, err := NewSchemaGuardrail(SchemaConfig{Schema: map[string]interface{}{}, Type: testInvoice{}}); err == nil {
+		t.Error("expected error with both a schema and a type")
+	}
+}
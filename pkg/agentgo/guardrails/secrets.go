@@ -0,0 +1,274 @@
+package guardrails
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// SecretType is the kind of credential detected
+// SecretType 表示检测到的凭据类型
+type SecretType string
+
+const (
+	// SecretPrivateKey is a PEM private key block
+	// SecretPrivateKey 是 PEM 私钥块
+	SecretPrivateKey SecretType = "private_key"
+	// SecretJWT is a JSON Web Token
+	// SecretJWT 是 JSON Web Token
+	SecretJWT SecretType = "jwt"
+	// SecretAPIKey is a provider key with a known prefix (AWS, GitHub, OpenAI,
+	// Anthropic, Slack, Stripe, Google)
+	// SecretAPIKey 是带有已知前缀的服务商密钥（AWS、GitHub、OpenAI、Anthropic、Slack、Stripe、Google）
+	SecretAPIKey SecretType = "api_key"
+	// SecretConnectionString is a database or broker URL with a password
+	// SecretConnectionString 是带密码的数据库或消息代理 URL
+	SecretConnectionString SecretType = "connection_string"
+	// SecretGeneric is a high-entropy value assigned to a key such as
+	// "password" or "api_key"
+	// SecretGeneric 是赋给 "password" 或 "api_key" 等键的高熵值
+	SecretGeneric SecretType = "generic_secret"
+)
+
+// SecretLeakageConfig configures the secret leakage guardrail
+// SecretLeakageConfig 配置凭据泄露防护栏
+type SecretLeakageConfig struct {
+	// Types lists the secret types to detect (empty = all)
+	// Types 列出要检测的凭据类型（空 = 全部）
+	Types []SecretType
+	// Action is "block" (default, fail the check) or "flag" (record
+	// findings on the report)
+	// Action 为 "block"（默认，检查失败）或 "flag"（在报告中记录问题）
+	Action string
+	// MinEntropy is the Shannon entropy, in bits per character, a value
+	// assigned to a secret-like key must reach (default: 3.0)
+	// MinEntropy 是赋给类似凭据键的值必须达到的香农熵（每字符比特数，默认：3.0）
+	MinEntropy float64
+	// Allowlist holds values that are never reported, e.g. documented
+	// example keys
+	// Allowlist 包含永不报告的值，例如文档中的示例密钥
+	Allowlist []string
+}
+
+// SecretDetection is a credential found in a text
+// SecretDetection 是在文本中发现的凭据
+type SecretDetection struct {
+	Type SecretType `json:"type"`
+	// Value is the masked credential
+	// Value 是掩码后的凭据
+	Value      string `json:"value"`
+	StartIndex int    `json:"start_index"`
+	EndIndex   int    `json:"end_index"`
+}
+
+// SecretLeakageGuardrail detects API keys, JWTs, private keys and connection
+// strings, typically in output after the agent read files or environment
+// variables with tools. It checks the output when set, otherwise the input.
+// SecretLeakageGuardrail 检测 API 密钥、JWT、私钥和连接字符串，通常用于代理通过工具读取文件或
+// 环境变量后的输出。设置了输出时检查输出，否则检查输入。
+type SecretLeakageGuardrail struct {
+	config    SecretLeakageConfig
+	enabled   map[SecretType]bool
+	allowlist map[string]bool
+}
+
+// secretPattern finds one kind of secret. When group is set, only that
+// submatch is the secret and must reach MinEntropy.
+type secretPattern struct {
+	secretType SecretType
+	re         *regexp.Regexp
+	group      int
+}
+
+// secretPatterns are listed from most to least specific; a match overlapping
+// an earlier one is ignored
+var secretPatterns = []secretPattern{
+	{SecretPrivateKey, regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY-----(?:[\s\S]*?-----END (?:[A-Z0-9]+ )*PRIVATE KEY-----)?`), 0},
+	{SecretConnectionString, regexp.MustCompile(`\b(?:postgres(?:ql)?|mysql|mariadb|mongodb(?:\+srv)?|rediss?|amqps?|mssql|sqlserver)://[^\s:@/]+:[^\s@/]+@[^\s"'<>]+`), 0},
+	{SecretJWT, regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`), 0},
+	{SecretAPIKey, regexp.MustCompile(`\b(?:(?:AKIA|ASIA)[0-9A-Z]{16}` +
+		`|gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,}` +
+		`|sk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}` +
+		`|xox[abposr]-[A-Za-z0-9-]{10,}` +
+		`|[rs]k_(?:live|test)_[0-9A-Za-z]{16,}` +
+		`|AIza[0-9A-Za-z_-]{35})`), 0},
+	{SecretGeneric, regexp.MustCompile(`(?i)\b[a-z0-9_.-]*(?:api[_-]?key|secret|token|passw(?:or)?d|pwd|access[_-]?key|private[_-]?key|credentials?)\b["']?\s*[:=]\s*["']?([A-Za-z0-9_\-+/=.!@#$%^&*]{12,})`), 1},
+}
+
+// NewSecretLeakageGuardrail creates a new secret leakage guardrail
+// NewSecretLeakageGuardrail 创建新的凭据泄露防护栏
+func NewSecretLeakageGuardrail(config SecretLeakageConfig) (*SecretLeakageGuardrail, error) {
+	switch config.Action {
+	case "":
+		config.Action = "block"
+	case "block", "flag":
+	default:
+		return nil, fmt.Errorf("invalid action %q (want block or flag)", config.Action)
+	}
+	if config.MinEntropy < 0 {
+		return nil, fmt.Errorf("min entropy must not be negative")
+	}
+	if config.MinEntropy == 0 {
+		config.MinEntropy = 3.0
+	}
+
+	enabled := make(map[SecretType]bool)
+	for _, p := range secretPatterns {
+		enabled[p.secretType] = len(config.Types) == 0
+	}
+	for _, secretType := range config.Types {
+		if _, ok := enabled[secretType]; !ok {
+			return nil, fmt.Errorf("unknown secret type %q", secretType)
+		}
+		enabled[secretType] = true
+	}
+
+	allowlist := make(map[string]bool, len(config.Allowlist))
+	for _, value := range config.Allowlist {
+		allowlist[value] = true
+	}
+	return &SecretLeakageGuardrail{config: config, enabled: enabled, allowlist: allowlist}, nil
+}
+
+// Check detects secrets in the output (or the input for pre-hooks)
+// Check 检测输出（在前置钩子中为输入）中的凭据
+func (g *SecretLeakageGuardrail) Check(ctx context.Context, input *CheckInput) error {
+	text := input.Output
+	if text == "" {
+		text = input.Input
+	}
+
+	detections := g.Detect(text)
+	if len(detections) == 0 {
+		return nil
+	}
+
+	if g.config.Action == "flag" {
+		for _, d := range detections {
+			input.Report.Add(Finding{
+				Guardrail: g.Name(),
+				Rule:      string(d.Type),
+				Message:   fmt.Sprintf("possible %s leaked", strings.ReplaceAll(string(d.Type), "_", " ")),
+				Value:     d.Value,
+			})
+		}
+		return nil
+	}
+
+	counts := make(map[SecretType]int)
+	var order []SecretType
+	for _, d := range detections {
+		if counts[d.Type] == 0 {
+			order = append(order, d.Type)
+		}
+		counts[d.Type]++
+	}
+	parts := make([]string, 0, len(order))
+	for _, secretType := range order {
+		parts = append(parts, fmt.Sprintf("%s: %d", secretType, counts[secretType]))
+	}
+	return types.NewOutputCheckError(fmt.Sprintf("possible secrets detected: %s", strings.Join(parts, ", ")), nil)
+}
+
+// Detect returns the secrets in text, ordered by position
+// Detect 返回文本中的凭据，按位置排序
+func (g *SecretLeakageGuardrail) Detect(text string) []SecretDetection {
+	var detections []SecretDetection
+	overlaps := func(start, end int) bool {
+		for _, d := range detections {
+			if start < d.EndIndex && end > d.StartIndex {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, p := range secretPatterns {
+		if !g.enabled[p.secretType] {
+			continue
+		}
+		for _, m := range p.re.FindAllStringSubmatchIndex(text, -1) {
+			start, end := m[2*p.group], m[2*p.group+1]
+			value := text[start:end]
+			if g.allowlist[value] || overlaps(start, end) {
+				continue
+			}
+			if p.group > 0 && ShannonEntropy(value) < g.config.MinEntropy {
+				continue
+			}
+			detections = append(detections, SecretDetection{
+				Type:       p.secretType,
+				Value:      maskSecret(value),
+				StartIndex: start,
+				EndIndex:   end,
+			})
+		}
+	}
+
+	sort.Slice(detections, func(i, j int) bool { return detections[i].StartIndex < detections[j].StartIndex })
+	return detections
+}
+
+// Redact replaces detected secrets with [REDACTED:<type>]; for key/value
+// assignments only the value is replaced
+// Redact 将检测到的凭据替换为 [REDACTED:<类型>]；对于键值赋值仅替换值
+func (g *SecretLeakageGuardrail) Redact(text string) string {
+	detections := g.Detect(text)
+	if len(detections) == 0 {
+		return text
+	}
+	var b strings.Builder
+	last := 0
+	for _, d := range detections {
+		b.WriteString(text[last:d.StartIndex])
+		b.WriteString("[REDACTED:" + string(d.Type) + "]")
+		last = d.EndIndex
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// Name returns the guardrail name
+// Name 返回防护栏名称
+func (g *SecretLeakageGuardrail) Name() string {
+	return "SecretLeakageGuardrail"
+}
+
+// ShannonEntropy returns the Shannon entropy of s in bits per character
+// ShannonEntropy 返回 s 的香农熵（每字符比特数）
+func ShannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+	entropy := 0.0
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// maskSecret keeps the first four characters, which identify the kind of
+// key, and hides the rest
+func maskSecret(value string) string {
+	if strings.HasPrefix(value, "-----BEGIN") {
+		if end := strings.Index(value[5:], "-----"); end >= 0 {
+			return value[:end+10] + "[...]"
+		}
+	}
+	if len(value) <= 8 {
+		return strings.Repeat("*", len(value))
+	}
+	return value[:4] + strings.Repeat("*", min(len(value)-4, 12))
+}
@@ -81,53 +81,8 @@ func (g *URLValidationGuardrail) Check(ctx context.Context, input *CheckInput) e
 			continue // Skip invalid URLs
 		}
 
-		// Check scheme
-		// 检查协议
-		if !g.config.AllowFileScheme && parsed.Scheme == "file" {
-			return types.NewOutputCheckError(
-				fmt.Sprintf("file:// URLs are not allowed: %s", g.maskURL(rawURL)),
-				nil,
-			)
-		}
-
-		// Check blocked domains
-		// 检查黑名单域名
-		for _, blocked := range g.config.BlockedDomains {
-			if strings.HasSuffix(parsed.Host, blocked) || parsed.Host == blocked {
-				return types.NewOutputCheckError(
-					fmt.Sprintf("blocked domain detected: %s", g.maskURL(rawURL)),
-					nil,
-				)
-			}
-		}
-
-		// Check allowed domains (if specified)
-		// 检查白名单域名（如果指定）
-		if len(g.config.AllowedDomains) > 0 {
-			allowed := false
-			for _, domain := range g.config.AllowedDomains {
-				if strings.HasSuffix(parsed.Host, domain) || parsed.Host == domain {
-					allowed = true
-					break
-				}
-			}
-			if !allowed {
-				return types.NewOutputCheckError(
-					fmt.Sprintf("domain not in allowlist: %s", g.maskURL(rawURL)),
-					nil,
-				)
-			}
-		}
-
-		// Check private IPs
-		// 检查私有 IP
-		if !g.config.AllowPrivateIPs {
-			if g.isPrivateIP(parsed.Host) {
-				return types.NewOutputCheckError(
-					fmt.Sprintf("private IP addresses are not allowed: %s", g.maskURL(rawURL)),
-					nil,
-				)
-			}
+		if msg := g.violation(rawURL, parsed); msg != "" {
+			return types.NewOutputCheckError(msg, nil)
 		}
 
 		// Check for hallucinated domains
@@ -148,6 +103,58 @@ func (g *URLValidationGuardrail) Check(ctx context.Context, input *CheckInput) e
 	return nil
 }
 
+// Redact replaces the URLs that Check would reject with [REDACTED:url]
+// Redact 将 Check 会拒绝的 URL 替换为 [REDACTED:url]
+func (g *URLValidationGuardrail) Redact(text string) string {
+	return g.urlPath.ReplaceAllStringFunc(text, func(rawURL string) string {
+		parsed, err := url.Parse(rawURL)
+		if err != nil || g.violation(rawURL, parsed) == "" {
+			return rawURL
+		}
+		return "[REDACTED:url]"
+	})
+}
+
+// violation describes why a URL is rejected, or returns "" when it is allowed
+// violation 描述 URL 被拒绝的原因，允许时返回 ""
+func (g *URLValidationGuardrail) violation(rawURL string, parsed *url.URL) string {
+	// Check scheme
+	// 检查协议
+	if !g.config.AllowFileScheme && parsed.Scheme == "file" {
+		return fmt.Sprintf("file:// URLs are not allowed: %s", g.maskURL(rawURL))
+	}
+
+	// Check blocked domains
+	// 检查黑名单域名
+	for _, blocked := range g.config.BlockedDomains {
+		if strings.HasSuffix(parsed.Host, blocked) || parsed.Host == blocked {
+			return fmt.Sprintf("blocked domain detected: %s", g.maskURL(rawURL))
+		}
+	}
+
+	// Check allowed domains (if specified)
+	// 检查白名单域名（如果指定）
+	if len(g.config.AllowedDomains) > 0 {
+		allowed := false
+		for _, domain := range g.config.AllowedDomains {
+			if strings.HasSuffix(parsed.Host, domain) || parsed.Host == domain {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Sprintf("domain not in allowlist: %s", g.maskURL(rawURL))
+		}
+	}
+
+	// Check private IPs
+	// 检查私有 IP
+	if !g.config.AllowPrivateIPs && g.isPrivateIP(parsed.Host) {
+		return fmt.Sprintf("private IP addresses are not allowed: %s", g.maskURL(rawURL))
+	}
+	return ""
+}
+
 func (g *URLValidationGuardrail) extractURLs(text string) []string {
 	return g.urlPath.FindAllString(text, -1)
 }
@@ -213,3 +213,13 @@ func TestURLValidationGuardrail_Masking(t *testing.T) {
 		t.Error("Expected non-empty error message")
 	}
 }
+
+func TestURLValidationGuardrail_Redact(t *testing.T) {
+	g := NewURLValidationGuardrailWithBlockedDomains([]string{"evil.com"})
+
+	got := g.Redact("See https://evil.com/x and https://docs.go.dev/ref")
+	want := "See [REDACTED:url] and https://docs.go.dev/ref"
+	if got != want {
+		t.Errorf("Redact() = %q, want %q", got, want)
+	}
+}
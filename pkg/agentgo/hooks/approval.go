@@ -10,11 +10,26 @@ import (
 // The function can be blocking (e.g., wait for user input via channel, HTTP callback, etc.)
 type ApprovalFunc func(ctx context.Context, input *ToolHookInput) (approved bool, err error)
 
+// ApprovalRequiredError is returned by a deferred ApprovalHook for a tool call
+// that needs a human decision. The agent pauses the run instead of blocking
+// the call; see agent.Agent.Resume.
+type ApprovalRequiredError struct {
+	ToolCallID   string
+	FunctionName string
+	Arguments    map[string]interface{}
+}
+
+func (e *ApprovalRequiredError) Error() string {
+	return fmt.Sprintf("tool %s requires approval", e.FunctionName)
+}
+
 // ApprovalHook implements ToolHooker to gate tool execution behind an approval function.
 // Tools not in ToolFilter (when set) execute without approval.
 type ApprovalHook struct {
 	approvalFn ApprovalFunc
-	toolFilter map[string]struct{} // nil means all tools require approval
+	toolFilter map[string]struct{}       // nil means all tools require approval
+	rule       func(*ToolHookInput) bool // Optional extra condition for requiring approval
+	deferred   bool                      // Return ApprovalRequiredError instead of calling approvalFn
 }
 
 // NewApprovalHook creates an ApprovalHook.
@@ -36,12 +51,41 @@ func NewApprovalHook(fn ApprovalFunc, tools ...string) *ApprovalHook {
 	return h
 }
 
+// NewDeferredApprovalHook creates an ApprovalHook that pauses the run instead
+// of waiting for a decision: matching tool calls fail with an
+// ApprovalRequiredError, and the agent returns a paused run with a pending
+// approval that is decided later with Agent.Resume. If tools are specified,
+// only those tools require approval.
+func NewDeferredApprovalHook(tools ...string) *ApprovalHook {
+	h := NewApprovalHook(func(context.Context, *ToolHookInput) (bool, error) { return false, nil }, tools...)
+	h.deferred = true
+	return h
+}
+
+// WithRule narrows the tools that require approval to calls for which rule
+// returns true, e.g. emails sent outside the company.
+func (h *ApprovalHook) WithRule(rule func(input *ToolHookInput) bool) *ApprovalHook {
+	h.rule = rule
+	return h
+}
+
 // OnToolPre checks if approval is needed and blocks execution if denied.
-// Returns an error to signal the agent to block the tool call.
+// Returns an error to signal the agent to block the tool call. Tool calls
+// already approved on ctx (see WithApprovedToolCalls) run without asking again.
 func (h *ApprovalHook) OnToolPre(ctx context.Context, input *ToolHookInput) error {
-	if !h.requiresApproval(input.FunctionName) {
+	if !h.requiresApproval(input.FunctionName) || (h.rule != nil && !h.rule(input)) {
+		return nil
+	}
+	if ToolCallApproved(ctx, input.ToolCallID) {
 		return nil
 	}
+	if h.deferred {
+		return &ApprovalRequiredError{
+			ToolCallID:   input.ToolCallID,
+			FunctionName: input.FunctionName,
+			Arguments:    input.Arguments,
+		}
+	}
 
 	approved, err := h.approvalFn(ctx, input)
 	if err != nil {
@@ -65,3 +109,26 @@ func (h *ApprovalHook) requiresApproval(functionName string) bool {
 	_, ok := h.toolFilter[functionName]
 	return ok
 }
+
+type approvedToolCallsKey struct{}
+
+// WithApprovedToolCalls marks tool calls as approved by a human, so approval
+// hooks let them run.
+func WithApprovedToolCalls(ctx context.Context, toolCallIDs ...string) context.Context {
+	approved := make(map[string]bool, len(toolCallIDs))
+	if previous, ok := ctx.Value(approvedToolCallsKey{}).(map[string]bool); ok {
+		for id := range previous {
+			approved[id] = true
+		}
+	}
+	for _, id := range toolCallIDs {
+		approved[id] = true
+	}
+	return context.WithValue(ctx, approvedToolCallsKey{}, approved)
+}
+
+// ToolCallApproved reports whether the tool call was approved on ctx.
+func ToolCallApproved(ctx context.Context, toolCallID string) bool {
+	approved, _ := ctx.Value(approvedToolCallsKey{}).(map[string]bool)
+	return approved[toolCallID]
+}
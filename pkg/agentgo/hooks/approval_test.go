@@ -3,6 +3,7 @@ package hooks
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 )
 
@@ -146,3 +147,43 @@ func TestApprovalHook_ReceivesCorrectInput(t *testing.T) {
 		t.Errorf("expected path /tmp/test, got %v", capturedInput.Arguments["path"])
 	}
 }
+
+func TestDeferredApprovalHook(t *testing.T) {
+	hook := NewDeferredApprovalHook("send_email").WithRule(func(input *ToolHookInput) bool {
+		to, _ := input.Arguments["to"].(string)
+		return !strings.HasSuffix(to, "@example.com")
+	})
+	ctx := context.Background()
+
+	internal := NewToolHookInput("agent-1", "call-1", "send_email", map[string]interface{}{"to": "ana@example.com"})
+	if err := hook.OnToolPre(ctx, internal); err != nil {
+		t.Errorf("expected no approval for an internal address, got: %v", err)
+	}
+
+	external := NewToolHookInput("agent-1", "call-2", "send_email", map[string]interface{}{"to": "bob@other.org"})
+	err := hook.OnToolPre(ctx, external)
+	var required *ApprovalRequiredError
+	if !errors.As(err, &required) || required.ToolCallID != "call-2" || required.FunctionName != "send_email" {
+		t.Fatalf("expected ApprovalRequiredError, got: %v", err)
+	}
+
+	if err := hook.OnToolPre(WithApprovedToolCalls(ctx, "call-2"), external); err != nil {
+		t.Errorf("expected approved call to pass, got: %v", err)
+	}
+
+	other := NewToolHookInput("agent-1", "call-3", "calculator_add", map[string]interface{}{})
+	if err := hook.OnToolPre(ctx, other); err != nil {
+		t.Errorf("expected tools outside the filter to pass, got: %v", err)
+	}
+}
+
+func TestWithApprovedToolCalls(t *testing.T) {
+	ctx := WithApprovedToolCalls(context.Background(), "a")
+	ctx = WithApprovedToolCalls(ctx, "b")
+	if !ToolCallApproved(ctx, "a") || !ToolCallApproved(ctx, "b") || ToolCallApproved(ctx, "c") {
+		t.Error("unexpected approvals on context")
+	}
+	if ToolCallApproved(context.Background(), "a") {
+		t.Error("expected no approvals on an empty context")
+	}
+}
@@ -2,6 +2,7 @@ package hooks
 
 import (
 	"context"
+	"sync"
 
 	"github.com/jholhewres/agent-go/pkg/agentgo/guardrails"
 )
@@ -28,6 +29,13 @@ type HookInput struct {
 
 	// AgentID is the ID of the agent/team
 	AgentID string
+
+	// Report collects non-blocking guardrail findings (optional)
+	Report *guardrails.GuardrailReport
+
+	mu       sync.Mutex
+	ctx      context.Context // Replaces the run's context, see SetContext
+	onRunEnd []func(err error)
 }
 
 // HookFunc is a function type for hooks
@@ -65,16 +73,71 @@ func (hi *HookInput) WithAgentID(agentID string) *HookInput {
 	return hi
 }
 
+// WithReport attaches a report that collects non-blocking guardrail findings.
+func (hi *HookInput) WithReport(report *guardrails.GuardrailReport) *HookInput {
+	hi.Report = report
+	return hi
+}
+
+// SetContext replaces the context the run continues with after the hook, so
+// that what the hook stored in it, such as a tracing span, reaches the hooks,
+// models and tools that follow. ctx must derive from the context the hook was
+// given. It only has an effect in pre-hooks.
+func (hi *HookInput) SetContext(ctx context.Context) {
+	hi.mu.Lock()
+	defer hi.mu.Unlock()
+	hi.ctx = ctx
+}
+
+// Context returns the context set with SetContext, or nil.
+func (hi *HookInput) Context() context.Context {
+	hi.mu.Lock()
+	defer hi.mu.Unlock()
+	return hi.ctx
+}
+
+// OnRunEnd registers fn to be called once the run a pre-hook belongs to ends,
+// with the run's error, or nil when it succeeded or paused. Unlike post-hooks,
+// which only run on success, it also sees runs that fail or are cancelled, so
+// hooks can release what they started.
+func (hi *HookInput) OnRunEnd(fn func(err error)) {
+	hi.mu.Lock()
+	defer hi.mu.Unlock()
+	hi.onRunEnd = append(hi.onRunEnd, fn)
+}
+
+// RunEnded calls the functions registered with OnRunEnd, the last registered
+// first. Callers running hooks call it once when the run ends; it is safe on
+// a nil HookInput.
+func (hi *HookInput) RunEnded(err error) {
+	if hi == nil {
+		return
+	}
+	hi.mu.Lock()
+	fns := hi.onRunEnd
+	hi.onRunEnd = nil
+	hi.mu.Unlock()
+	for i := len(fns) - 1; i >= 0; i-- {
+		fns[i](err)
+	}
+}
+
 // ExecuteHook executes a single hook, handling both function hooks and guardrail hooks.
+// Changes a guardrail makes to the input, output or metadata are copied back to input.
 func ExecuteHook(ctx context.Context, hook Hook, input *HookInput) error {
 	// Check if it's a Guardrail
 	if guardrail, ok := hook.(guardrails.Guardrail); ok {
 		checkInput := &guardrails.CheckInput{
 			Input:    input.Input,
+			Output:   input.Output,
 			Messages: input.Messages,
 			Metadata: input.Metadata,
+			Report:   input.Report,
 		}
-		return guardrail.Check(ctx, checkInput)
+		err := guardrail.Check(ctx, checkInput)
+		// Guardrails may rewrite the text, e.g. to redact PII.
+		input.Input, input.Output, input.Metadata = checkInput.Input, checkInput.Output, checkInput.Metadata
+		return err
 	}
 
 	// Check if it's a HookFunc
@@ -92,8 +155,12 @@ func ExecuteHook(ctx context.Context, hook Hook, input *HookInput) error {
 
 // ExecuteHooks executes a list of hooks in order.
 // If any hook returns an error, execution stops and the error is returned.
+// Each hook receives the context set by the hooks before it with SetContext.
 func ExecuteHooks(ctx context.Context, hooks []Hook, input *HookInput) error {
 	for _, hook := range hooks {
+		if hookCtx := input.Context(); hookCtx != nil {
+			ctx = hookCtx
+		}
 		if err := ExecuteHook(ctx, hook, input); err != nil {
 			return err
 		}
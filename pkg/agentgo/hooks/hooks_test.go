@@ -3,6 +3,7 @@ package hooks
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/jholhewres/agent-go/pkg/agentgo/guardrails"
@@ -216,3 +217,67 @@ func TestExecuteHook_MixedHooks(t *testing.T) {
 		t.Errorf("expected no error, got %v", err)
 	}
 }
+
+// rewritingGuardrail upper-cases the input
+type rewritingGuardrail struct{}
+
+func (rewritingGuardrail) Check(ctx context.Context, input *guardrails.CheckInput) error {
+	input.Input = strings.ToUpper(input.Input)
+	input.Metadata["rewritten"] = true
+	return nil
+}
+
+func (rewritingGuardrail) Name() string { return "rewriting" }
+
+func TestExecuteHook_GuardrailRewrite(t *testing.T) {
+	hookInput := NewHookInput("hello")
+	if err := ExecuteHook(context.Background(), rewritingGuardrail{}, hookInput); err != nil {
+		t.Fatalf("ExecuteHook() error = %v", err)
+	}
+	if hookInput.Input != "HELLO" || hookInput.Metadata["rewritten"] != true {
+		t.Errorf("hook input = %+v, want the guardrail's changes", hookInput)
+	}
+}
+
+type ctxKey struct{}
+
+func TestExecuteHooks_SetContext(t *testing.T) {
+	input := NewHookInput("hi")
+	var seen interface{}
+	hooks := []Hook{
+		HookFunc(func(ctx context.Context, input *HookInput) error {
+			input.SetContext(context.WithValue(ctx, ctxKey{}, "span"))
+			return nil
+		}),
+		HookFunc(func(ctx context.Context, input *HookInput) error {
+			seen = ctx.Value(ctxKey{})
+			return nil
+		}),
+	}
+	if err := ExecuteHooks(context.Background(), hooks, input); err != nil {
+		t.Fatalf("ExecuteHooks: %v", err)
+	}
+	if seen != "span" {
+		t.Errorf("second hook saw %v, want the context set by the first", seen)
+	}
+	if input.Context() == nil || input.Context().Value(ctxKey{}) != "span" {
+		t.Error("Context() should return the context set by the hooks")
+	}
+}
+
+func TestHookInput_RunEnded(t *testing.T) {
+	input := NewHookInput("hi")
+	var calls []string
+	runErr := errors.New("boom")
+	input.OnRunEnd(func(err error) { calls = append(calls, "first:"+err.Error()) })
+	input.OnRunEnd(func(err error) { calls = append(calls, "second:"+err.Error()) })
+
+	input.RunEnded(runErr)
+	input.RunEnded(nil) // already reported
+	if strings.Join(calls, ",") != "second:boom,first:boom" {
+		t.Errorf("calls = %v, want latest first and once", calls)
+	}
+
+	var nilInput *HookInput
+	nilInput.RunEnded(nil)
+}
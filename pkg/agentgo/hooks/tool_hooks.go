@@ -2,6 +2,7 @@ package hooks
 
 import (
 	"context"
+	"sync"
 	"time"
 )
 
@@ -18,8 +19,12 @@ const (
 	ToolHookPhasePost ToolHookPhase = "post"
 )
 
-// ToolHookInput contains data passed to tool hooks.
-// ToolHookInput 包含传递给工具钩子的数据。
+// ToolHookInput contains data passed to tool hooks. The agent passes the same
+// instance to the pre and post hooks of a tool call, and to the tool itself
+// through the context (see ToolHookInputFromContext), so hooks can keep
+// per-call state in Metadata even when tool calls run in parallel.
+// ToolHookInput 包含传递给工具钩子的数据。代理将同一实例传给工具调用的前置和后置钩子，并通过上下文传给工具本身
+// （参见 ToolHookInputFromContext），因此即使工具调用并行执行，钩子也可以在 Metadata 中保存每次调用的状态。
 type ToolHookInput struct {
 	// Phase indicates whether this is a pre or post hook
 	// Phase 表示这是一个前置还是后置钩子
@@ -29,6 +34,10 @@ type ToolHookInput struct {
 	// AgentID 是执行工具的代理 ID
 	AgentID string
 
+	// UserID is the ID of the user the agent is running for
+	// UserID 是代理为之运行的用户 ID
+	UserID string
+
 	// ToolCallID is the unique ID of this tool call
 	// ToolCallID 是此工具调用的唯一 ID
 	ToolCallID string
@@ -61,9 +70,18 @@ type ToolHookInput struct {
 	// Duration 是总执行时间（仅在 post 钩子中可用）
 	Duration time.Duration
 
-	// Metadata allows hooks to pass data between pre and post phases
-	// Metadata 允许钩子在 pre 和 post 阶段之间传递数据
+	// Metadata allows hooks to pass data between pre and post phases. Use
+	// SetMetadata and GetMetadata when it may be accessed concurrently, e.g.
+	// from goroutines started by a hook or by the tool.
+	// Metadata 允许钩子在 pre 和 post 阶段之间传递数据。可能被并发访问时（例如钩子或工具启动的 goroutine），
+	// 请使用 SetMetadata 和 GetMetadata。
 	Metadata map[string]interface{}
+
+	mu sync.RWMutex // Guards Metadata, the context and the skipped result
+
+	ctx           context.Context // Replaces the tool call's context, see SetContext
+	skipped       bool
+	skippedResult interface{}
 }
 
 // ToolHookFunc is the function signature for tool hooks.
@@ -115,9 +133,18 @@ func (thi *ToolHookInput) WithResult(result interface{}, err error) *ToolHookInp
 	return thi
 }
 
+// WithUserID sets the user the tool is called for.
+// WithUserID 设置工具调用所属的用户。
+func (thi *ToolHookInput) WithUserID(userID string) *ToolHookInput {
+	thi.UserID = userID
+	return thi
+}
+
 // WithMetadata adds metadata to the hook input.
 // WithMetadata 向钩子输入添加元数据。
 func (thi *ToolHookInput) WithMetadata(metadata map[string]interface{}) *ToolHookInput {
+	thi.mu.Lock()
+	defer thi.mu.Unlock()
 	if thi.Metadata == nil {
 		thi.Metadata = make(map[string]interface{})
 	}
@@ -127,6 +154,91 @@ func (thi *ToolHookInput) WithMetadata(metadata map[string]interface{}) *ToolHoo
 	return thi
 }
 
+// SetMetadata stores a metadata value; safe for concurrent use.
+// SetMetadata 存储一个元数据值；可安全并发使用。
+func (thi *ToolHookInput) SetMetadata(key string, value interface{}) {
+	thi.mu.Lock()
+	defer thi.mu.Unlock()
+	if thi.Metadata == nil {
+		thi.Metadata = make(map[string]interface{})
+	}
+	thi.Metadata[key] = value
+}
+
+// GetMetadata returns a metadata value; safe for concurrent use.
+// GetMetadata 返回一个元数据值；可安全并发使用。
+func (thi *ToolHookInput) GetMetadata(key string) (interface{}, bool) {
+	thi.mu.RLock()
+	defer thi.mu.RUnlock()
+	value, ok := thi.Metadata[key]
+	return value, ok
+}
+
+// MetadataSnapshot returns a copy of the metadata; safe for concurrent use.
+// MetadataSnapshot 返回元数据的副本；可安全并发使用。
+func (thi *ToolHookInput) MetadataSnapshot() map[string]interface{} {
+	thi.mu.RLock()
+	defer thi.mu.RUnlock()
+	out := make(map[string]interface{}, len(thi.Metadata))
+	for k, v := range thi.Metadata {
+		out[k] = v
+	}
+	return out
+}
+
+// SetContext replaces the context the tool and the later hooks of this call
+// run with, e.g. to make a tracing span their parent. ctx must derive from the
+// context the hook was given. Call it from a pre hook.
+// SetContext 替换此次调用中工具和后续钩子使用的上下文，例如使追踪 span 成为它们的父级。
+// ctx 必须派生自钩子收到的上下文。应在前置钩子中调用。
+func (thi *ToolHookInput) SetContext(ctx context.Context) {
+	thi.mu.Lock()
+	defer thi.mu.Unlock()
+	thi.ctx = ctx
+}
+
+// Context returns the context set with SetContext, or nil.
+// Context 返回通过 SetContext 设置的上下文，未设置时返回 nil。
+func (thi *ToolHookInput) Context() context.Context {
+	thi.mu.RLock()
+	defer thi.mu.RUnlock()
+	return thi.ctx
+}
+
+// SkipExecution makes the agent use result instead of running the tool, e.g.
+// for a cached result. Post hooks still run. Call it from a pre hook.
+// SkipExecution 让代理使用 result 而不执行工具，例如使用缓存的结果。后置钩子仍会运行。应在前置钩子中调用。
+func (thi *ToolHookInput) SkipExecution(result interface{}) {
+	thi.mu.Lock()
+	defer thi.mu.Unlock()
+	thi.skipped = true
+	thi.skippedResult = result
+}
+
+// SkippedResult returns the result passed to SkipExecution, and whether it was called.
+// SkippedResult 返回传给 SkipExecution 的结果，以及是否调用过 SkipExecution。
+func (thi *ToolHookInput) SkippedResult() (interface{}, bool) {
+	thi.mu.RLock()
+	defer thi.mu.RUnlock()
+	return thi.skippedResult, thi.skipped
+}
+
+type toolHookInputKey struct{}
+
+// WithToolHookInput attaches the input of the tool call being executed to ctx.
+// WithToolHookInput 将正在执行的工具调用的输入附加到 ctx。
+func WithToolHookInput(ctx context.Context, input *ToolHookInput) context.Context {
+	return context.WithValue(ctx, toolHookInputKey{}, input)
+}
+
+// ToolHookInputFromContext returns the input of the tool call being executed,
+// or nil outside a tool call.
+// ToolHookInputFromContext 返回正在执行的工具调用的输入；不在工具调用中时返回 nil。
+func ToolHookInputFromContext(ctx context.Context) *ToolHookInput {
+	input, _ := ctx.Value(toolHookInputKey{}).(*ToolHookInput)
+	return input
+}
+
 // IsPre returns true if this is a pre-execution hook.
 // IsPre 如果这是一个 pre-execution 钩子，则返回 true。
 func (thi *ToolHookInput) IsPre() bool {
@@ -57,10 +57,15 @@ func ExecuteToolPostHook(ctx context.Context, hook ToolHook, input *ToolHookInpu
 
 // ExecuteToolPreHooks executes all pre-tool hooks in order.
 // Stops and returns error if any hook blocks execution.
+// Each hook receives the context set by the hooks before it with SetContext.
 // ExecuteToolPreHooks 按顺序执行所有前置工具钩子。
 // 如果任何钩子阻止执行，则停止并返回错误。
+// 每个钩子接收之前的钩子通过 SetContext 设置的上下文。
 func ExecuteToolPreHooks(ctx context.Context, hooks []ToolHook, input *ToolHookInput) error {
 	for i, hook := range hooks {
+		if hookCtx := input.Context(); hookCtx != nil {
+			ctx = hookCtx
+		}
 		if err := ExecuteToolPreHook(ctx, hook, input); err != nil {
 			return fmt.Errorf("tool pre-hook %d blocked execution: %w", i, err)
 		}
@@ -3,6 +3,8 @@ package hooks
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
 	"testing"
 )
 
@@ -191,3 +193,70 @@ func TestExecuteToolPostHooks_FirstFails(t *testing.T) {
 		t.Error("Expected error, got nil")
 	}
 }
+
+func TestToolHookInput_ConcurrentMetadata(t *testing.T) {
+	input := NewToolHookInput("agent-1", "call-1", "test_func", nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprintf("key-%d", i)
+			input.SetMetadata(key, i)
+			if v, ok := input.GetMetadata(key); !ok || v != i {
+				t.Errorf("GetMetadata(%s) = %v, %v", key, v, ok)
+			}
+			_ = input.MetadataSnapshot()
+			input.WithMetadata(map[string]interface{}{"shared": i})
+		}(i)
+	}
+	wg.Wait()
+
+	if snapshot := input.MetadataSnapshot(); len(snapshot) != 21 {
+		t.Errorf("MetadataSnapshot() has %d keys, want 21", len(snapshot))
+	}
+}
+
+func TestToolHookInputFromContext(t *testing.T) {
+	if ToolHookInputFromContext(context.Background()) != nil {
+		t.Error("expected nil outside a tool call")
+	}
+	input := NewToolHookInput("agent-1", "call-1", "test_func", nil)
+	if got := ToolHookInputFromContext(WithToolHookInput(context.Background(), input)); got != input {
+		t.Error("expected the attached input")
+	}
+}
+
+func TestToolHookInput_SkipExecution(t *testing.T) {
+	input := NewToolHookInput("agent-1", "call-1", "test_func", nil)
+	if _, skipped := input.SkippedResult(); skipped {
+		t.Error("new input should not be skipped")
+	}
+	input.SkipExecution("cached")
+	if result, skipped := input.SkippedResult(); !skipped || result != "cached" {
+		t.Errorf("SkippedResult() = %v, %v", result, skipped)
+	}
+}
+
+func TestExecuteToolPreHooks_SetContext(t *testing.T) {
+	type key struct{}
+	input := NewToolHookInput("agent", "call", "fn", nil)
+	var seen interface{}
+	hooks := []ToolHook{
+		ToolHookFunc(func(ctx context.Context, input *ToolHookInput) error {
+			input.SetContext(context.WithValue(ctx, key{}, "span"))
+			return nil
+		}),
+		ToolHookFunc(func(ctx context.Context, input *ToolHookInput) error {
+			seen = ctx.Value(key{})
+			return nil
+		}),
+	}
+	if err := ExecuteToolPreHooks(context.Background(), hooks, input); err != nil {
+		t.Fatalf("ExecuteToolPreHooks: %v", err)
+	}
+	if seen != "span" || input.Context() == nil {
+		t.Errorf("second hook saw %v, want the context set by the first", seen)
+	}
+}
@@ -0,0 +1,221 @@
+// Package ids generates the identifiers used across agent-go for messages,
+// runs, tool calls, memories and documents.
+//
+// IDs are ULIDs (https://github.com/ulid/spec): 26 Crockford base32
+// characters encoding a 48-bit millisecond timestamp followed by 80 random
+// bits. They sort lexicographically in creation order, so storages can
+// answer time range queries with plain string comparisons on the ID column
+// (see Min and Max). IDs generated by the same process within one
+// millisecond are strictly increasing.
+package ids
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Length is the length of an encoded ULID.
+const Length = 26
+
+// crockford is the Crockford base32 alphabet used by ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// maxTime is the largest timestamp a ULID can encode.
+const maxTime = 1<<48 - 1
+
+// ErrInvalid is returned when a string is not a valid ULID.
+var ErrInvalid = errors.New("invalid ULID")
+
+// ULID is a decoded identifier.
+type ULID [16]byte
+
+// Generator produces monotonic ULIDs. It is safe for concurrent use.
+type Generator struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	entropy io.Reader
+	lastMS  uint64
+	last    ULID
+}
+
+// NewGenerator returns a generator reading the clock from now and randomness
+// from entropy. Nil arguments default to time.Now and crypto/rand.
+func NewGenerator(now func() time.Time, entropy io.Reader) *Generator {
+	if now == nil {
+		now = time.Now
+	}
+	if entropy == nil {
+		entropy = rand.Reader
+	}
+	return &Generator{now: now, entropy: entropy}
+}
+
+var defaultGenerator = NewGenerator(nil, nil)
+
+// New returns a new ULID string from the default generator.
+func New() string {
+	return defaultGenerator.New()
+}
+
+// Prefixed returns prefix + "-" + a new ULID, e.g. "run-01J9Z3...". IDs with
+// the same prefix still sort in creation order.
+func Prefixed(prefix string) string {
+	return prefix + "-" + New()
+}
+
+// New returns a new ULID string.
+func (g *Generator) New() string {
+	return g.Next().String()
+}
+
+// Next returns a new ULID. Within the same millisecond the random part of the
+// previous ULID is incremented, so IDs stay ordered; should it overflow, the
+// timestamp is advanced by one millisecond.
+func (g *Generator) Next() ULID {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := uint64(g.now().UnixMilli())
+	if ms <= g.lastMS {
+		id := g.last
+		if increment(&id) {
+			g.last = id
+			return id
+		}
+		ms = g.lastMS + 1
+	}
+
+	var id ULID
+	putTime(&id, ms)
+	if _, err := io.ReadFull(g.entropy, id[6:]); err != nil {
+		// crypto/rand does not fail on supported platforms; fall back to a
+		// counter so IDs stay unique even if a custom reader does.
+		copy(id[6:], g.last[6:])
+		increment(&id)
+	}
+	g.lastMS = ms
+	g.last = id
+	return id
+}
+
+// String returns the canonical 26-character encoding.
+func (u ULID) String() string {
+	var out [Length]byte
+	// 128 bits are encoded as 26 characters of 5 bits; the first character
+	// only carries the top 3 bits.
+	var hi, lo uint64
+	for i := 0; i < 8; i++ {
+		hi = hi<<8 | uint64(u[i])
+		lo = lo<<8 | uint64(u[i+8])
+	}
+	for i := Length - 1; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// Time returns the timestamp encoded in the ULID.
+func (u ULID) Time() time.Time {
+	var ms uint64
+	for i := 0; i < 6; i++ {
+		ms = ms<<8 | uint64(u[i])
+	}
+	return time.UnixMilli(int64(ms)).UTC()
+}
+
+// Parse decodes a ULID, ignoring any "prefix-" added by Prefixed. Lowercase
+// input is accepted.
+func Parse(id string) (ULID, error) {
+	if i := strings.LastIndexByte(id, '-'); i >= 0 {
+		id = id[i+1:]
+	}
+	var u ULID
+	if len(id) != Length {
+		return u, ErrInvalid
+	}
+	var hi, lo uint64
+	for i := 0; i < Length; i++ {
+		v := strings.IndexByte(crockford, upper(id[i]))
+		if v < 0 || (i == 0 && v > 7) {
+			return u, ErrInvalid
+		}
+		hi = hi<<5 | lo>>59
+		lo = lo<<5 | uint64(v)
+	}
+	for i := 7; i >= 0; i-- {
+		u[i] = byte(hi)
+		u[i+8] = byte(lo)
+		hi >>= 8
+		lo >>= 8
+	}
+	return u, nil
+}
+
+// Time returns the creation time encoded in id, which may carry a prefix.
+func Time(id string) (time.Time, error) {
+	u, err := Parse(id)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return u.Time(), nil
+}
+
+// Min returns the smallest ULID with timestamp t. Together with Max it turns
+// a time range into an ID range: Min(from) <= id <= Max(to).
+func Min(t time.Time) string {
+	var u ULID
+	putTime(&u, clampTime(t))
+	return u.String()
+}
+
+// Max returns the largest ULID with timestamp t.
+func Max(t time.Time) string {
+	var u ULID
+	putTime(&u, clampTime(t))
+	for i := 6; i < len(u); i++ {
+		u[i] = 0xff
+	}
+	return u.String()
+}
+
+func putTime(u *ULID, ms uint64) {
+	for i := 5; i >= 0; i-- {
+		u[i] = byte(ms)
+		ms >>= 8
+	}
+}
+
+func clampTime(t time.Time) uint64 {
+	ms := t.UnixMilli()
+	if ms < 0 {
+		return 0
+	}
+	if ms > maxTime {
+		return maxTime
+	}
+	return uint64(ms)
+}
+
+// increment adds one to the random part, reporting false on overflow.
+func increment(u *ULID) bool {
+	for i := len(u) - 1; i >= 6; i-- {
+		u[i]++
+		if u[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func upper(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
@@ -0,0 +1,77 @@
+package ids
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse_RoundTrip(t *testing.T) {
+	// Example from the ULID spec.
+	const spec = "01ARZ3NDEKTSV4RRFFQ69G5FAV"
+	u, err := Parse(spec)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if u.String() != spec {
+		t.Fatalf("String() = %s, want %s", u, spec)
+	}
+	if got := u.Time().UnixMilli(); got != 1469922850259 {
+		t.Fatalf("Time() = %d ms, want 1469922850259", got)
+	}
+
+	lower, err := Parse("run-" + strings.ToLower(spec))
+	if err != nil || lower != u {
+		t.Fatalf("Parse(prefixed lowercase) = %s, %v", lower, err)
+	}
+
+	for _, bad := range []string{"", "01ARZ3NDEK", "81ARZ3NDEKTSV4RRFFQ69G5FAV", "01ARZ3NDEKTSV4RRFFQ69G5FAU!"} {
+		if _, err := Parse(bad); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Parse(%q) error = %v, want ErrInvalid", bad, err)
+		}
+	}
+}
+
+func TestGenerator_MonotonicWithinMillisecond(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	g := NewGenerator(func() time.Time { return now }, bytes.NewReader(bytes.Repeat([]byte{0xff}, 10)))
+
+	first := g.Next()
+	// Random part is all ones: the next increment overflows into the next millisecond.
+	second := g.Next()
+	if second.String() <= first.String() {
+		t.Fatalf("IDs not increasing: %s then %s", first, second)
+	}
+	if !second.Time().Equal(now.Add(time.Millisecond)) {
+		t.Fatalf("overflow should advance the timestamp, got %v", second.Time())
+	}
+}
+
+func TestNew_SortsByCreationTime(t *testing.T) {
+	generated := make([]string, 1000)
+	for i := range generated {
+		generated[i] = New()
+	}
+	if !sort.StringsAreSorted(generated) {
+		t.Fatal("IDs generated in sequence are not sorted")
+	}
+
+	start := time.Now().Add(-time.Second)
+	id := Prefixed("msg")
+	if !strings.HasPrefix(id, "msg-") || len(id) != len("msg-")+Length {
+		t.Fatalf("Prefixed() = %q", id)
+	}
+	ts, err := Time(id)
+	if err != nil {
+		t.Fatalf("Time() error = %v", err)
+	}
+	if ts.Before(start) || ts.After(time.Now().Add(time.Second)) {
+		t.Fatalf("Time() = %v, want around now", ts)
+	}
+	if id[4:] < Min(start) || id[4:] > Max(time.Now()) {
+		t.Fatalf("%s outside [Min, Max] range", id)
+	}
+}
@@ -0,0 +1,347 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// AudioLoader transcribes audio files with the OpenAI transcription API
+// (Whisper) or any endpoint compatible with it, such as Groq or a self-hosted
+// Whisper server. Each file becomes one transcript document, or one document
+// per SegmentWindow of audio, with the timestamps of every segment in metadata.
+type AudioLoader struct {
+	FilePaths      []string
+	APIKey         string            // API key (default: OPENAI_API_KEY)
+	BaseURL        string            // API base URL (default: https://api.openai.com/v1)
+	Model          string            // Transcription model (default: whisper-1)
+	Language       string            // ISO-639-1 language of the audio (empty = auto-detect)
+	Prompt         string            // Spelling hints: names, products, jargon
+	SegmentWindow  time.Duration     // Audio per document (0 = one document per file)
+	Timestamps     bool              // Prefix every segment with its start time, e.g. "[01:02:03]" (default: true)
+	MaxFileSize    int64             // Files above this size are rejected before upload (default: 25 MB, the OpenAI limit)
+	MaxConcurrent  int               // Maximum concurrent uploads (default: 2)
+	ContinueOnErr  bool              // Skip files that fail instead of aborting (default: true)
+	Timeout        time.Duration     // Timeout per file (default: 5m)
+	Headers        map[string]string // Custom headers
+	HTTPClient     *http.Client      // HTTP client (default: http.DefaultClient)
+	CommonMetadata map[string]interface{}
+}
+
+// NewAudioLoader creates a new audio transcription loader
+func NewAudioLoader(filePaths ...string) *AudioLoader {
+	return &AudioLoader{
+		FilePaths:     filePaths,
+		APIKey:        os.Getenv("OPENAI_API_KEY"),
+		BaseURL:       "https://api.openai.com/v1",
+		Model:         "whisper-1",
+		Timestamps:    true,
+		MaxFileSize:   25 << 20,
+		MaxConcurrent: 2,
+		ContinueOnErr: true,
+		Timeout:       5 * time.Minute,
+	}
+}
+
+// transcription is the verbose_json response of the transcription API
+type transcription struct {
+	Text     string                 `json:"text"`
+	Language string                 `json:"language"`
+	Duration float64                `json:"duration"`
+	Segments []transcriptionSegment `json:"segments"`
+}
+
+type transcriptionSegment struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// Load transcribes every file, keeping the order of FilePaths
+func (l *AudioLoader) Load() ([]Document, error) {
+	if len(l.FilePaths) == 0 {
+		return nil, fmt.Errorf("at least one audio file is required")
+	}
+
+	maxConcurrent := l.MaxConcurrent
+	if maxConcurrent <= 0 {
+		maxConcurrent = 2
+	}
+
+	type result struct {
+		docs []Document
+		err  error
+	}
+	results := make([]result, len(l.FilePaths))
+	semaphore := make(chan struct{}, maxConcurrent)
+	done := make(chan int, len(l.FilePaths))
+
+	for i, path := range l.FilePaths {
+		go func(i int, path string) {
+			semaphore <- struct{}{}        // Acquire
+			defer func() { <-semaphore }() // Release
+
+			docs, err := l.loadFile(path)
+			results[i] = result{docs: docs, err: err}
+			done <- i
+		}(i, path)
+	}
+	for range l.FilePaths {
+		<-done
+	}
+
+	var allDocs []Document
+	var errs []error
+	for _, res := range results {
+		if res.err != nil {
+			errs = append(errs, res.err)
+			if !l.ContinueOnErr {
+				return nil, fmt.Errorf("failed to transcribe audio: %v", errs)
+			}
+			continue
+		}
+		allDocs = append(allDocs, res.docs...)
+	}
+
+	if len(allDocs) == 0 && len(errs) > 0 {
+		return nil, fmt.Errorf("all audio files failed to transcribe: %v", errs)
+	}
+
+	return allDocs, nil
+}
+
+// Version reports the size and modification time of every file, so
+// KnowledgeBase.Sync only pays for transcription when a recording changes
+func (l *AudioLoader) Version() (string, error) {
+	var versions []string
+	for _, path := range l.FilePaths {
+		version, err := fileVersion(path)
+		if err != nil {
+			return "", err
+		}
+		versions = append(versions, version)
+	}
+	return strings.Join(versions, ","), nil
+}
+
+func (l *AudioLoader) loadFile(path string) ([]Document, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio file %s: %w", path, err)
+	}
+	if l.MaxFileSize > 0 && info.Size() > l.MaxFileSize {
+		return nil, fmt.Errorf("audio file %s is %d bytes, above the %d byte limit; split it first", path, info.Size(), l.MaxFileSize)
+	}
+
+	result, err := l.transcribe(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Endpoints that ignore verbose_json segments still return the text
+	segments := result.Segments
+	if len(segments) == 0 && strings.TrimSpace(result.Text) != "" {
+		segments = []transcriptionSegment{{Start: 0, End: result.Duration, Text: result.Text}}
+	}
+	if len(segments) == 0 {
+		return nil, nil
+	}
+
+	base := filepath.Base(path)
+	var docs []Document
+	for _, group := range groupSegments(segments, l.SegmentWindow) {
+		start, end := group[0].Start, group[len(group)-1].End
+
+		id := base
+		if l.SegmentWindow > 0 {
+			id = fmt.Sprintf("%s#t=%d", base, int(start))
+		}
+
+		metadata := map[string]interface{}{
+			"filename":   base,
+			"path":       path,
+			"ext":        filepath.Ext(path),
+			"type":       "audio_transcript",
+			"model":      l.model(),
+			"start":      start,
+			"end":        end,
+			"start_time": formatTimestamp(start),
+			"end_time":   formatTimestamp(end),
+			"segments":   segmentMetadata(group),
+		}
+		if result.Language != "" {
+			metadata["language"] = result.Language
+		}
+		if result.Duration > 0 {
+			metadata["duration"] = result.Duration
+		}
+		for k, v := range l.CommonMetadata {
+			metadata[k] = v
+		}
+
+		docs = append(docs, Document{
+			ID:       id,
+			Content:  l.transcriptText(group),
+			Source:   path,
+			Metadata: metadata,
+		})
+	}
+
+	return docs, nil
+}
+
+func (l *AudioLoader) transcribe(path string) (*transcription, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audio file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build transcription request: %w", err)
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return nil, fmt.Errorf("failed to read audio file %s: %w", path, err)
+	}
+	fields := [][2]string{
+		{"model", l.model()},
+		{"response_format", "verbose_json"},
+		{"timestamp_granularities[]", "segment"},
+		{"language", l.Language},
+		{"prompt", l.Prompt},
+	}
+	for _, field := range fields {
+		if field[1] == "" {
+			continue
+		}
+		if err := form.WriteField(field[0], field[1]); err != nil {
+			return nil, fmt.Errorf("failed to build transcription request: %w", err)
+		}
+	}
+	if err := form.Close(); err != nil {
+		return nil, fmt.Errorf("failed to build transcription request: %w", err)
+	}
+
+	timeout := l.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	baseURL := strings.TrimRight(l.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/audio/transcriptions", &body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if l.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+l.APIKey)
+	}
+	for key, value := range l.Headers {
+		req.Header.Set(key, value)
+	}
+
+	client := l.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transcribe %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transcription of %s: %w", path, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to transcribe %s: HTTP error %d: %s", path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var result transcription
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode transcription of %s: %w", path, err)
+	}
+	return &result, nil
+}
+
+func (l *AudioLoader) model() string {
+	if l.Model == "" {
+		return "whisper-1"
+	}
+	return l.Model
+}
+
+func (l *AudioLoader) transcriptText(segments []transcriptionSegment) string {
+	var sb strings.Builder
+	for _, segment := range segments {
+		text := strings.TrimSpace(segment.Text)
+		if text == "" {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		if l.Timestamps {
+			sb.WriteString("[" + formatTimestamp(segment.Start) + "] ")
+		}
+		sb.WriteString(text)
+	}
+	return sb.String()
+}
+
+// groupSegments splits segments into consecutive groups spanning at most
+// window of audio each; a single segment longer than window forms its own group
+func groupSegments(segments []transcriptionSegment, window time.Duration) [][]transcriptionSegment {
+	if window <= 0 {
+		return [][]transcriptionSegment{segments}
+	}
+
+	var groups [][]transcriptionSegment
+	var current []transcriptionSegment
+	for _, segment := range segments {
+		if len(current) > 0 && segment.End-current[0].Start > window.Seconds() {
+			groups = append(groups, current)
+			current = nil
+		}
+		current = append(current, segment)
+	}
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+	return groups
+}
+
+func segmentMetadata(segments []transcriptionSegment) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(segments))
+	for _, segment := range segments {
+		out = append(out, map[string]interface{}{
+			"start": segment.Start,
+			"end":   segment.End,
+			"text":  strings.TrimSpace(segment.Text),
+		})
+	}
+	return out
+}
+
+// formatTimestamp renders seconds as HH:MM:SS
+func formatTimestamp(seconds float64) string {
+	total := int(seconds)
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, total%3600/60, total%60)
+}
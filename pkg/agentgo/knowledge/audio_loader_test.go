@@ -0,0 +1,138 @@
+package knowledge
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testTranscription = `{
+  "text": "Welcome to the sync. Revenue grew. Next, hiring. We hire two engineers.",
+  "language": "english",
+  "duration": 95.5,
+  "segments": [
+    {"id": 0, "start": 0.0, "end": 4.2, "text": " Welcome to the sync."},
+    {"id": 1, "start": 4.2, "end": 30.0, "text": " Revenue grew."},
+    {"id": 2, "start": 61.0, "end": 70.5, "text": " Next, hiring."},
+    {"id": 3, "start": 70.5, "end": 95.5, "text": " We hire two engineers."}
+  ]
+}`
+
+func newTranscriptionServer(t *testing.T, requests *int) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if r.URL.Path != "/v1/audio/transcriptions" || r.Header.Get("Authorization") != "Bearer test-key" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		audio, _ := io.ReadAll(file)
+		if string(audio) == "corrupt" {
+			http.Error(w, `{"error":{"message":"Invalid file format."}}`, http.StatusBadRequest)
+			return
+		}
+		if r.FormValue("model") != "whisper-1" || r.FormValue("response_format") != "verbose_json" ||
+			r.FormValue("language") != "en" || header.Filename != "standup.mp3" {
+			http.Error(w, "unexpected form", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, testTranscription)
+	}))
+}
+
+func writeAudio(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAudioLoader_TranscriptWithSegments(t *testing.T) {
+	requests := 0
+	server := newTranscriptionServer(t, &requests)
+	defer server.Close()
+
+	dir := t.TempDir()
+	loader := NewAudioLoader(writeAudio(t, dir, "standup.mp3", "ID3 audio"))
+	loader.APIKey = "test-key"
+	loader.BaseURL = server.URL + "/v1/"
+	loader.Language = "en"
+	loader.CommonMetadata = map[string]interface{}{"team": "core"}
+
+	docs, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("Load() returned %d documents, want 1", len(docs))
+	}
+
+	doc := docs[0]
+	wantContent := "[00:00:00] Welcome to the sync.\n[00:00:04] Revenue grew.\n[00:01:01] Next, hiring.\n[00:01:10] We hire two engineers."
+	if doc.ID != "standup.mp3" || doc.Content != wantContent {
+		t.Errorf("document = %q %q", doc.ID, doc.Content)
+	}
+	if doc.Metadata["language"] != "english" || doc.Metadata["duration"] != 95.5 || doc.Metadata["team"] != "core" ||
+		doc.Metadata["end_time"] != "00:01:35" {
+		t.Errorf("metadata = %v", doc.Metadata)
+	}
+	segments := doc.Metadata["segments"].([]map[string]interface{})
+	if len(segments) != 4 || segments[2]["start"] != 61.0 || segments[2]["text"] != "Next, hiring." {
+		t.Errorf("segments = %v", segments)
+	}
+}
+
+func TestAudioLoader_SegmentWindowAndErrors(t *testing.T) {
+	requests := 0
+	server := newTranscriptionServer(t, &requests)
+	defer server.Close()
+
+	dir := t.TempDir()
+	loader := NewAudioLoader(
+		writeAudio(t, dir, "standup.mp3", "ID3 audio"),
+		writeAudio(t, dir, "broken.mp3", "corrupt"),
+		writeAudio(t, dir, "huge.wav", strings.Repeat("x", 64)),
+	)
+	loader.APIKey = "test-key"
+	loader.BaseURL = server.URL + "/v1"
+	loader.Language = "en"
+	loader.SegmentWindow = time.Minute
+	loader.Timestamps = false
+	loader.MaxFileSize = 32
+	loader.MaxConcurrent = 1
+
+	docs, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("Load() returned %d documents, want 2", len(docs))
+	}
+	if docs[0].ID != "standup.mp3#t=0" || docs[0].Content != "Welcome to the sync.\nRevenue grew." {
+		t.Errorf("first window = %q %q", docs[0].ID, docs[0].Content)
+	}
+	if docs[1].ID != "standup.mp3#t=61" || docs[1].Metadata["start_time"] != "00:01:01" || docs[1].Metadata["end"] != 95.5 {
+		t.Errorf("second window = %q %v", docs[1].ID, docs[1].Metadata)
+	}
+	if requests != 2 {
+		t.Errorf("transcription requests = %d, want 2 (oversized files are not uploaded)", requests)
+	}
+
+	loader.ContinueOnErr = false
+	if _, err := loader.Load(); err == nil || !strings.Contains(err.Error(), "Invalid file format") {
+		t.Errorf("Load() with ContinueOnErr=false error = %v", err)
+	}
+}
@@ -0,0 +1,276 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ObjectInfo describes an object in a cloud bucket
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+}
+
+// ObjectStore lists and reads the objects of one bucket. S3Loader and
+// GCSLoader provide implementations; implement it for other stores (Azure
+// Blob, an in-house object service) and load it with BucketLoader.
+type ObjectStore interface {
+	// List returns every object whose key starts with prefix
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+
+	// Open streams the content of an object
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// BucketLoader loads the objects of an ObjectStore, dispatching each one to
+// the format loader matching its extension or content type
+type BucketLoader struct {
+	Store          ObjectStore
+	SourceType     string                 // Recorded as metadata source_type (e.g. "s3", "gcs")
+	Bucket         string                 // Recorded in metadata and source URIs
+	Prefix         string                 // Only load keys starting with Prefix
+	Pattern        string                 // Glob on the key, or on the base name if it has no "/" (e.g. "*.pdf")
+	MaxConcurrent  int                    // Objects fetched at once (default: 5)
+	ContinueOnErr  bool                   // Skip objects that fail instead of aborting (default: true)
+	MaxObjectSize  int64                  // Skip larger objects; 0 means no limit
+	Timeout        time.Duration          // Bound for the whole load (default: 5m)
+	CommonMetadata map[string]interface{} // Added to every document
+}
+
+// NewBucketLoader creates a loader for store with the default limits
+func NewBucketLoader(store ObjectStore, sourceType, bucket, prefix string) *BucketLoader {
+	return &BucketLoader{
+		Store:         store,
+		SourceType:    sourceType,
+		Bucket:        bucket,
+		Prefix:        prefix,
+		MaxConcurrent: 5,
+		ContinueOnErr: true,
+		Timeout:       5 * time.Minute,
+	}
+}
+
+// Load lists the matching objects and loads them concurrently
+func (l *BucketLoader) Load() ([]Document, error) {
+	ctx, cancel := l.context()
+	defer cancel()
+
+	objects, err := l.objects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		docs []Document
+		err  error
+	}
+
+	results := make([]result, len(objects))
+	semaphore := make(chan struct{}, max(l.MaxConcurrent, 1))
+	var wg sync.WaitGroup
+
+	for i, obj := range objects {
+		wg.Add(1)
+		go func(i int, obj ObjectInfo) {
+			defer wg.Done()
+			semaphore <- struct{}{}        // Acquire
+			defer func() { <-semaphore }() // Release
+
+			docs, err := l.loadObject(ctx, obj)
+			if err != nil {
+				err = fmt.Errorf("%s: %w", l.uri(obj.Key), err)
+			}
+			results[i] = result{docs: docs, err: err}
+		}(i, obj)
+	}
+	wg.Wait()
+
+	// Collect in key order so repeated loads return documents in the same order
+	var allDocs []Document
+	var errs []error
+	for _, res := range results {
+		if res.err != nil {
+			errs = append(errs, res.err)
+			if !l.ContinueOnErr {
+				return nil, fmt.Errorf("failed to load objects: %v", errs)
+			}
+			continue
+		}
+		allDocs = append(allDocs, res.docs...)
+	}
+
+	if len(allDocs) == 0 && len(errs) > 0 {
+		return nil, fmt.Errorf("all objects failed to load: %v", errs)
+	}
+
+	return allDocs, nil
+}
+
+// Version hashes the keys and ETags of the matching objects, so
+// KnowledgeBase.Sync skips the bucket when nothing changed
+func (l *BucketLoader) Version() (string, error) {
+	ctx, cancel := l.context()
+	defer cancel()
+
+	objects, err := l.objects(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	for _, obj := range objects {
+		fmt.Fprintf(h, "%s\x00%s\x00%d\x00%d\n", obj.Key, obj.ETag, obj.Size, obj.LastModified.UnixNano())
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (l *BucketLoader) context() (context.Context, context.CancelFunc) {
+	timeout := l.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// objects lists the objects to load, sorted by key
+func (l *BucketLoader) objects(ctx context.Context) ([]ObjectInfo, error) {
+	if l.Store == nil {
+		return nil, fmt.Errorf("object store is required")
+	}
+	listed, err := l.Store.List(ctx, l.Prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", l.uri(l.Prefix), err)
+	}
+
+	var objects []ObjectInfo
+	for _, obj := range listed {
+		// Skip "directory" placeholders
+		if strings.HasSuffix(obj.Key, "/") {
+			continue
+		}
+		if l.MaxObjectSize > 0 && obj.Size > l.MaxObjectSize {
+			continue
+		}
+		if l.Pattern != "" {
+			name := obj.Key
+			if !strings.Contains(l.Pattern, "/") {
+				name = path.Base(obj.Key)
+			}
+			matched, err := path.Match(l.Pattern, name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", l.Pattern, err)
+			}
+			if !matched {
+				continue
+			}
+		}
+		objects = append(objects, obj)
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+func (l *BucketLoader) loadObject(ctx context.Context, obj ObjectInfo) ([]Document, error) {
+	body, err := l.Store.Open(ctx, obj.Key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object: %w", err)
+	}
+
+	metadata := map[string]interface{}{
+		"source":      l.uri(obj.Key),
+		"source_type": l.SourceType,
+		"bucket":      l.Bucket,
+		"key":         obj.Key,
+		"size":        len(data),
+	}
+	if obj.ETag != "" {
+		metadata["etag"] = obj.ETag
+	}
+	if !obj.LastModified.IsZero() {
+		metadata["last_modified"] = obj.LastModified.UTC().Format(time.RFC3339)
+	}
+	for k, v := range l.CommonMetadata {
+		metadata[k] = v
+	}
+
+	format := objectFormat(obj.Key, obj.ContentType, data)
+	metadata["content_type"] = format
+
+	var loader Loader
+	reader := bytes.NewReader(data)
+	switch format {
+	case "pdf":
+		loader = NewPDFReaderLoader(data, obj.Key, metadata)
+	case "csv":
+		loader = NewCSVReaderLoader(reader, obj.Key, metadata)
+	case "xlsx":
+		loader = NewXLSXReaderLoader(reader, obj.Key, metadata)
+	case "json":
+		loader = NewJSONReaderLoader(reader, obj.Key, metadata)
+	case "html":
+		loader = NewHTMLReaderLoader(reader, obj.Key, metadata)
+	default:
+		loader = NewReaderLoader(reader, obj.Key, metadata)
+	}
+	return loader.Load()
+}
+
+func (l *BucketLoader) uri(key string) string {
+	scheme := l.SourceType
+	if scheme == "gcs" {
+		scheme = "gs"
+	}
+	return fmt.Sprintf("%s://%s/%s", scheme, l.Bucket, key)
+}
+
+// objectFormat picks the format loader for an object: by extension first,
+// then by content type, then by sniffing the content like URLLoader does
+func objectFormat(key, contentType string, data []byte) string {
+	switch strings.ToLower(path.Ext(key)) {
+	case ".pdf":
+		return "pdf"
+	case ".csv":
+		return "csv"
+	case ".xlsx":
+		return "xlsx"
+	case ".json":
+		return "json"
+	case ".html", ".htm":
+		return "html"
+	case ".txt", ".md", ".markdown", ".xml", ".yaml", ".yml":
+		return "text"
+	}
+
+	lowerType := strings.ToLower(contentType)
+	switch {
+	case strings.Contains(lowerType, "spreadsheetml"):
+		return "xlsx"
+	case strings.Contains(lowerType, "csv"):
+		return "csv"
+	}
+	// Object stores default unknown uploads to application/octet-stream,
+	// which says nothing about the content.
+	if strings.Contains(lowerType, "octet-stream") {
+		contentType = ""
+	}
+	return (&URLLoader{}).detectContentType(contentType, data)
+}
@@ -272,7 +272,7 @@ func (c *ParagraphChunker) Chunk(doc Document) ([]Chunk, error) {
 	var currentChunk strings.Builder
 	index := 0
 
-	for i, para := range paragraphs {
+	for _, para := range paragraphs {
 		para = strings.TrimSpace(para)
 		if len(para) == 0 {
 			continue
@@ -319,11 +319,6 @@ func (c *ParagraphChunker) Chunk(doc Document) ([]Chunk, error) {
 			currentChunk.WriteString("\n\n")
 		}
 		currentChunk.WriteString(para)
-
-		// If this is the last paragraph, save the chunk
-		if i == len(paragraphs)-1 {
-			chunks = append(chunks, c.createChunk(doc, currentChunk.String(), index))
-		}
 	}
 
 	// Add any remaining content
@@ -357,3 +352,32 @@ func (c *ParagraphChunker) createChunk(doc Document, content string, index int)
 
 	return chunk
 }
+
+// locateChunks records the start_char and end_char of chunks that appear
+// verbatim in the document, so citations can point into it. Chunks are
+// searched in order from the start of the previous one, which allows overlap;
+// offsets already set by the chunker are kept.
+func locateChunks(doc Document, chunks []Chunk) {
+	from := 0
+	for i := range chunks {
+		chunk := &chunks[i]
+		if chunk.Content == "" {
+			continue
+		}
+		if start, ok := chunk.Metadata["start_char"].(int); ok {
+			from = start
+			continue
+		}
+		offset := strings.Index(doc.Content[from:], chunk.Content)
+		if offset < 0 {
+			continue
+		}
+		start := from + offset
+		if chunk.Metadata == nil {
+			chunk.Metadata = map[string]interface{}{}
+		}
+		chunk.Metadata["start_char"] = start
+		chunk.Metadata["end_char"] = start + len(chunk.Content)
+		from = start
+	}
+}
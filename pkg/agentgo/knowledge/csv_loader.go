@@ -41,6 +41,11 @@ func (l *CSVLoader) Load() ([]Document, error) {
 	return l.loadFromReader(file, filepath.Base(l.FilePath), l.FilePath)
 }
 
+// Version reports the size and modification time of the file
+func (l *CSVLoader) Version() (string, error) {
+	return fileVersion(l.FilePath)
+}
+
 func (l *CSVLoader) loadFromReader(reader io.Reader, id, source string) ([]Document, error) {
 	csvReader := csv.NewReader(reader)
 	csvReader.Comma = l.Delimiter
@@ -214,7 +219,7 @@ func (l *CSVReaderLoader) Load() ([]Document, error) {
 		ContentFormat: l.ContentFormat,
 	}
 
-	docs, err := loader.loadFromReader(l.Reader, l.ID, "")
+	docs, err := loader.loadFromReader(l.Reader, readerDocID(l.ID), "")
 	if err != nil {
 		return nil, err
 	}
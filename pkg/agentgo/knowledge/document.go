@@ -6,6 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/ids"
 )
 
 // Document represents a document with metadata
@@ -60,6 +62,11 @@ func (l *TextLoader) Load() ([]Document, error) {
 	return []Document{doc}, nil
 }
 
+// Version reports the size and modification time of the file
+func (l *TextLoader) Version() (string, error) {
+	return fileVersion(l.FilePath)
+}
+
 // DirectoryLoader loads documents from a directory
 type DirectoryLoader struct {
 	DirPath    string
@@ -137,6 +144,17 @@ func (l *DirectoryLoader) Load() ([]Document, error) {
 	return documents, nil
 }
 
+// Version reports the paths, sizes and modification times of the matching files
+func (l *DirectoryLoader) Version() (string, error) {
+	var ext string
+	if l.Pattern != "" && strings.Contains(l.Pattern, "*") {
+		ext = strings.TrimPrefix(l.Pattern, "*")
+	}
+	return dirVersion(l.DirPath, l.Recursive, func(path string) bool {
+		return ext == "" || filepath.Ext(path) == ext
+	})
+}
+
 // ReaderLoader loads documents from an io.Reader
 type ReaderLoader struct {
 	Reader   io.Reader
@@ -161,10 +179,20 @@ func (l *ReaderLoader) Load() ([]Document, error) {
 	}
 
 	doc := Document{
-		ID:       l.ID,
+		ID:       readerDocID(l.ID),
 		Content:  string(content),
 		Metadata: l.Metadata,
 	}
 
 	return []Document{doc}, nil
 }
+
+// readerDocID returns id, or a new time-ordered ID when the caller did not
+// name the source. File loaders keep deriving IDs from the path so that
+// re-syncing a file updates its documents instead of duplicating them.
+func readerDocID(id string) string {
+	if id == "" {
+		return ids.New()
+	}
+	return id
+}
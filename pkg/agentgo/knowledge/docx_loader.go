@@ -0,0 +1,196 @@
+package knowledge
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// DOCXLoader loads the text of Word (.docx) documents. Paragraphs are
+// separated by blank lines and table rows become "cell | cell" lines.
+type DOCXLoader struct {
+	FilePath string
+}
+
+// NewDOCXLoader creates a new DOCX loader
+func NewDOCXLoader(filePath string) *DOCXLoader {
+	return &DOCXLoader{FilePath: filePath}
+}
+
+// Load loads a DOCX file
+func (l *DOCXLoader) Load() ([]Document, error) {
+	zr, err := zip.OpenReader(l.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open DOCX file %s: %w", l.FilePath, err)
+	}
+	defer zr.Close()
+
+	content, err := docxText(&zr.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DOCX file %s: %w", l.FilePath, err)
+	}
+	return []Document{{
+		ID:      filepath.Base(l.FilePath),
+		Content: content,
+		Source:  l.FilePath,
+		Metadata: map[string]interface{}{
+			"filename":  filepath.Base(l.FilePath),
+			"path":      l.FilePath,
+			"ext":       ".docx",
+			"file_type": "docx",
+		},
+	}}, nil
+}
+
+// Version reports the size and modification time of the file
+func (l *DOCXLoader) Version() (string, error) {
+	return fileVersion(l.FilePath)
+}
+
+// DOCXReaderLoader loads a DOCX document from an io.Reader
+type DOCXReaderLoader struct {
+	Reader   io.Reader
+	ID       string
+	Metadata map[string]interface{}
+}
+
+// NewDOCXReaderLoader creates a new DOCX reader loader
+func NewDOCXReaderLoader(reader io.Reader, id string, metadata map[string]interface{}) *DOCXReaderLoader {
+	return &DOCXReaderLoader{
+		Reader:   reader,
+		ID:       id,
+		Metadata: metadata,
+	}
+}
+
+// Load loads DOCX content from a reader. The document is buffered in memory.
+func (l *DOCXReaderLoader) Load() ([]Document, error) {
+	data, err := io.ReadAll(l.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DOCX content: %w", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open DOCX content: %w", err)
+	}
+	content, err := docxText(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]interface{}{"file_type": "docx"}
+	for k, v := range l.Metadata {
+		metadata[k] = v
+	}
+	return []Document{{
+		ID:       readerDocID(l.ID),
+		Content:  content,
+		Metadata: metadata,
+	}}, nil
+}
+
+// docxText extracts the text of word/document.xml
+func docxText(zr *zip.Reader) (string, error) {
+	f := findZipFile(zr, "word/document.xml")
+	if f == nil {
+		return "", fmt.Errorf("invalid DOCX: missing word/document.xml")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open word/document.xml: %w", err)
+	}
+	defer rc.Close()
+
+	var (
+		b          strings.Builder
+		paragraph  strings.Builder
+		cells      []string // cells of the current table row
+		tableDepth int
+		inText     bool
+	)
+	endParagraph := func() {
+		text := strings.TrimSpace(paragraph.String())
+		paragraph.Reset()
+		if tableDepth > 0 {
+			if len(cells) == 0 {
+				cells = append(cells, "")
+			}
+			if text != "" {
+				if cells[len(cells)-1] != "" {
+					cells[len(cells)-1] += " "
+				}
+				cells[len(cells)-1] += text
+			}
+			return
+		}
+		if text != "" {
+			b.WriteString(text)
+			b.WriteString("\n\n")
+		}
+	}
+
+	decoder := xml.NewDecoder(rc)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to parse word/document.xml: %w", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				paragraph.WriteString("\t")
+			case "br", "cr":
+				paragraph.WriteString("\n")
+			case "tbl":
+				endParagraph()
+				tableDepth++
+			case "tr":
+				if tableDepth == 1 {
+					cells = nil
+				}
+			case "tc":
+				// Nested tables are flattened into the outer cell
+				if tableDepth == 1 {
+					cells = append(cells, "")
+				}
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				endParagraph()
+			case "tr":
+				if tableDepth == 1 && len(cells) > 0 {
+					b.WriteString(strings.Join(cells, " | "))
+					b.WriteString("\n")
+				}
+			case "tbl":
+				tableDepth--
+				if tableDepth == 0 {
+					b.WriteString("\n")
+				}
+			}
+		case xml.CharData:
+			if inText {
+				paragraph.Write(t)
+			}
+		}
+	}
+
+	content := strings.TrimSpace(b.String())
+	if content == "" {
+		return "", fmt.Errorf("no text content in DOCX")
+	}
+	return content, nil
+}
@@ -0,0 +1,95 @@
+package knowledge
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestDOCX builds a minimal Word document with a table
+func writeTestDOCX(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+  <w:body>
+    <w:p><w:r><w:t>Quarterly</w:t></w:r><w:r><w:t xml:space="preserve"> report</w:t></w:r></w:p>
+    <w:p><w:r><w:t>Revenue grew</w:t><w:tab/><w:t>12%</w:t></w:r></w:p>
+    <w:tbl>
+      <w:tr><w:tc><w:p><w:r><w:t>Region</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>Sales</w:t></w:r></w:p></w:tc></w:tr>
+      <w:tr><w:tc><w:p><w:r><w:t>North</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>1200</w:t></w:r></w:p></w:tc></w:tr>
+    </w:tbl>
+    <w:p><w:r><w:t>End of report</w:t></w:r></w:p>
+  </w:body>
+</w:document>`))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDOCXLoader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.docx")
+	if err := os.WriteFile(path, writeTestDOCX(t), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	docs, err := NewDOCXLoader(path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := "Quarterly report\n\nRevenue grew\t12%\n\nRegion | Sales\nNorth | 1200\n\nEnd of report"
+	if len(docs) != 1 || docs[0].Content != want {
+		t.Fatalf("content = %q, want %q", docs[0].Content, want)
+	}
+	if docs[0].ID != "report.docx" || docs[0].Metadata["file_type"] != "docx" {
+		t.Errorf("doc = %+v", docs[0])
+	}
+
+	if _, err := NewDOCXReaderLoader(strings.NewReader("not a zip"), "x", nil).Load(); err == nil {
+		t.Error("expected an error for invalid DOCX content")
+	}
+}
+
+func TestLoaderForFile(t *testing.T) {
+	loader, err := LoaderForFile("uploads/report.docx", writeTestDOCX(t), map[string]interface{}{"session_id": "s1"})
+	if err != nil {
+		t.Fatalf("LoaderForFile() error = %v", err)
+	}
+	docs, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if docs[0].ID != "report.docx" || docs[0].Source != "uploads/report.docx" || docs[0].Metadata["session_id"] != "s1" {
+		t.Errorf("doc = %+v", docs[0])
+	}
+
+	loader, err = LoaderForFile("prices.csv", []byte("item,price\napple,1\n"), nil)
+	if err != nil {
+		t.Fatalf("LoaderForFile() error = %v", err)
+	}
+	if docs, err := loader.Load(); err != nil || !strings.Contains(docs[0].Content, "apple") {
+		t.Errorf("csv docs = %+v, %v", docs, err)
+	}
+
+	loader, err = LoaderForFile("notes.md", []byte("# Notes\nremember"), nil)
+	if err != nil {
+		t.Fatalf("LoaderForFile() error = %v", err)
+	}
+	if docs, err := loader.Load(); err != nil || docs[0].Content != "# Notes\nremember" {
+		t.Errorf("text docs = %+v, %v", docs, err)
+	}
+
+	if _, err := LoaderForFile("photo.png", []byte{0x89, 'P', 'N', 'G', 0}, nil); !errors.Is(err, ErrUnsupportedFileType) {
+		t.Errorf("err = %v, want ErrUnsupportedFileType", err)
+	}
+}
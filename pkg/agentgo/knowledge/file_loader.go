@@ -0,0 +1,68 @@
+package knowledge
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrUnsupportedFileType is returned by LoaderForFile for files it cannot read
+var ErrUnsupportedFileType = errors.New("unsupported file type")
+
+// LoaderForFile returns a loader for file contents held in memory, such as an
+// upload, chosen by the extension of name: PDF, CSV, DOCX, XLSX, HTML, JSON,
+// or plain text for other UTF-8 files. Documents take their ID and source
+// from name and carry metadata in addition to the loader's own.
+func LoaderForFile(name string, data []byte, metadata map[string]interface{}) (Loader, error) {
+	base := filepath.Base(name)
+	ext := strings.ToLower(filepath.Ext(base))
+	meta := map[string]interface{}{"filename": base, "ext": ext}
+	for k, v := range metadata {
+		meta[k] = v
+	}
+
+	var loader Loader
+	switch ext {
+	case ".pdf":
+		loader = NewPDFReaderLoader(data, base, meta)
+	case ".csv":
+		loader = NewCSVReaderLoader(bytes.NewReader(data), base, meta)
+	case ".docx":
+		loader = NewDOCXReaderLoader(bytes.NewReader(data), base, meta)
+	case ".xlsx":
+		loader = NewXLSXReaderLoader(bytes.NewReader(data), base, meta)
+	case ".html", ".htm":
+		loader = NewHTMLReaderLoader(bytes.NewReader(data), base, meta)
+	case ".json":
+		loader = NewJSONReaderLoader(bytes.NewReader(data), base, meta)
+	default:
+		if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedFileType, base)
+		}
+		loader = NewReaderLoader(bytes.NewReader(data), base, meta)
+	}
+	return &sourceLoader{Loader: loader, source: name}, nil
+}
+
+// sourceLoader sets the source of documents from loaders that read from
+// memory and leave it empty
+type sourceLoader struct {
+	Loader
+	source string
+}
+
+func (l *sourceLoader) Load() ([]Document, error) {
+	docs, err := l.Loader.Load()
+	if err != nil {
+		return nil, err
+	}
+	for i := range docs {
+		if docs[i].Source == "" {
+			docs[i].Source = l.source
+		}
+	}
+	return docs, nil
+}
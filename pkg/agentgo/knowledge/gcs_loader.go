@@ -0,0 +1,148 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/api/option"
+	storage "google.golang.org/api/storage/v1"
+)
+
+// GCSLoader loads documents from a Google Cloud Storage bucket. Without
+// explicit credentials it uses Application Default Credentials.
+type GCSLoader struct {
+	BucketLoader
+
+	// MatchGlob is evaluated server-side by GCS (e.g. "**/*.pdf"), which avoids
+	// listing unrelated objects; Pattern is still applied afterwards
+	MatchGlob string
+
+	CredentialsJSON string                // Service account JSON, or a path to it
+	Endpoint        string                // Custom endpoint, e.g. a GCS emulator
+	Anonymous       bool                  // Skip authentication (public buckets, emulators)
+	HTTPClient      *http.Client          // Used as is when set; it must handle authentication
+	ClientOptions   []option.ClientOption // Extra options for the storage client
+
+	mu     sync.Mutex
+	client *storage.Service
+}
+
+// NewGCSLoader creates a loader for the objects of bucket under prefix
+func NewGCSLoader(bucket, prefix string) *GCSLoader {
+	return &GCSLoader{
+		BucketLoader: *NewBucketLoader(nil, "gcs", bucket, prefix),
+	}
+}
+
+// Load lists the matching objects and loads them concurrently
+func (l *GCSLoader) Load() ([]Document, error) {
+	return l.bucketLoader().Load()
+}
+
+// Version changes whenever a matching object is added, removed or modified
+func (l *GCSLoader) Version() (string, error) {
+	return l.bucketLoader().Version()
+}
+
+func (l *GCSLoader) bucketLoader() *BucketLoader {
+	b := l.BucketLoader
+	b.Store = l
+	b.SourceType = "gcs"
+	return &b
+}
+
+// List returns the objects under prefix, following page tokens
+func (l *GCSLoader) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	service, err := l.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	call := service.Objects.List(l.Bucket).Context(ctx).
+		Fields("nextPageToken", "items(name,size,contentType,etag,updated)")
+	if prefix != "" {
+		call = call.Prefix(prefix)
+	}
+	if l.MatchGlob != "" {
+		call = call.MatchGlob(l.MatchGlob)
+	}
+
+	var objects []ObjectInfo
+	err = call.Pages(ctx, func(page *storage.Objects) error {
+		for _, item := range page.Items {
+			info := ObjectInfo{
+				Key:         item.Name,
+				Size:        int64(item.Size),
+				ContentType: item.ContentType,
+				ETag:        item.Etag,
+			}
+			if updated, err := time.Parse(time.RFC3339, item.Updated); err == nil {
+				info.LastModified = updated
+			}
+			objects = append(objects, info)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// Open streams the content of an object
+func (l *GCSLoader) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	service, err := l.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := service.Objects.Get(l.Bucket, key).Context(ctx).Download()
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// service returns the storage client, created on first use
+func (l *GCSLoader) service(ctx context.Context) (*storage.Service, error) {
+	if l.Bucket == "" {
+		return nil, fmt.Errorf("bucket is required")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.client != nil {
+		return l.client, nil
+	}
+
+	opts := []option.ClientOption{option.WithScopes(storage.DevstorageReadOnlyScope)}
+	switch {
+	case l.HTTPClient != nil:
+		opts = append(opts, option.WithHTTPClient(l.HTTPClient))
+	case l.Anonymous:
+		opts = append(opts, option.WithoutAuthentication())
+	case l.CredentialsJSON != "":
+		credsJSON := []byte(l.CredentialsJSON)
+		if data, err := os.ReadFile(l.CredentialsJSON); err == nil {
+			credsJSON = data
+		}
+		opts = append(opts, option.WithCredentialsJSON(credsJSON))
+	}
+	if l.Endpoint != "" {
+		opts = append(opts, option.WithEndpoint(l.Endpoint))
+	}
+	opts = append(opts, l.ClientOptions...)
+
+	// The client outlives the load that created it, so it must not be bound
+	// to that load's context
+	service, err := storage.NewService(context.WithoutCancel(ctx), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage client: %w", err)
+	}
+	l.client = service
+	return service, nil
+}
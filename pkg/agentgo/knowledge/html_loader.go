@@ -42,6 +42,11 @@ func (l *HTMLLoader) Load() ([]Document, error) {
 	return l.loadFromReader(file, filepath.Base(l.FilePath), l.FilePath)
 }
 
+// Version reports the size and modification time of the file
+func (l *HTMLLoader) Version() (string, error) {
+	return fileVersion(l.FilePath)
+}
+
 func (l *HTMLLoader) loadFromReader(reader io.Reader, id, source string) ([]Document, error) {
 	// Parse HTML
 	doc, err := goquery.NewDocumentFromReader(reader)
@@ -202,7 +207,7 @@ func (l *HTMLReaderLoader) Load() ([]Document, error) {
 		PreserveLinks:   l.PreserveLinks,
 	}
 
-	docs, err := loader.loadFromReader(l.Reader, l.ID, "")
+	docs, err := loader.loadFromReader(l.Reader, readerDocID(l.ID), "")
 	if err != nil {
 		return nil, err
 	}
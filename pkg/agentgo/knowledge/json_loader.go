@@ -36,6 +36,11 @@ func (l *JSONLoader) Load() ([]Document, error) {
 	return l.loadFromReader(file, filepath.Base(l.FilePath), l.FilePath)
 }
 
+// Version reports the size and modification time of the file
+func (l *JSONLoader) Version() (string, error) {
+	return fileVersion(l.FilePath)
+}
+
 func (l *JSONLoader) loadFromReader(reader io.Reader, id, source string) ([]Document, error) {
 	// Read JSON content
 	data, err := io.ReadAll(reader)
@@ -242,7 +247,7 @@ func (l *JSONReaderLoader) Load() ([]Document, error) {
 		MetadataFields: l.MetadataFields,
 	}
 
-	docs, err := loader.loadFromReader(l.Reader, l.ID, "")
+	docs, err := loader.loadFromReader(l.Reader, readerDocID(l.ID), "")
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,382 @@
+package knowledge
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/embeddings"
+	"github.com/jholhewres/agent-go/pkg/agentgo/rerank"
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+)
+
+const defaultSearchLimit = 5
+
+// KnowledgeBaseConfig configures a KnowledgeBase
+type KnowledgeBaseConfig struct {
+	// VectorDB stores the chunks (required)
+	VectorDB vectordb.VectorDB
+
+	// Chunker splits documents (default: CharacterChunker of 1000 characters)
+	Chunker Chunker
+
+	// Embedder embeds chunks and queries. When nil, the VectorDB's own
+	// embedding function is used.
+	Embedder embeddings.Embedder
+
+	// StateStore persists what was ingested, so a new process only embeds the
+	// documents that changed since the last Sync. When nil, the state is kept
+	// in memory and the first Sync of a process rewrites every document.
+	StateStore StateStore
+
+	// Reranker reorders the vector search candidates (optional). Search then
+	// returns reranker scores, so thresholds such as the agent's
+	// KnowledgeConfidenceThreshold apply to them. When reranking fails, the
+	// vector ranking is kept.
+	Reranker rerank.Reranker
+
+	// RerankCandidates is how many chunks are retrieved for reranking
+	// (default: 4x the requested number)
+	RerankCandidates int
+}
+
+// KnowledgeBase ties loaders, a chunker, an embedder and a vector database
+// together. Sources are registered with AddSource and ingested by Sync, which
+// only re-embeds documents whose content changed and removes documents that
+// disappeared from their source.
+//
+// KnowledgeBase satisfies the agent's Knowledge option, which injects the
+// chunks retrieved for each input into the prompt.
+type KnowledgeBase struct {
+	db       vectordb.VectorDB
+	chunker  Chunker
+	embedder embeddings.Embedder
+	store    StateStore
+	reranker rerank.Reranker
+	rerankN  int
+
+	mu      sync.Mutex // serializes Sync and guards sources and state
+	sources []Loader
+	state   *SyncState // nil until loaded from the store
+}
+
+// SyncStats summarizes one Sync
+type SyncStats struct {
+	Documents      int // documents in all sources
+	Added          int // new documents
+	Updated        int // documents whose content changed
+	Unchanged      int // documents skipped
+	Removed        int // documents no longer returned by any source
+	Chunks         int // chunks written
+	SourcesSkipped int // versioned sources not loaded because their version did not change
+}
+
+// NewKnowledgeBase creates a knowledge base
+func NewKnowledgeBase(config KnowledgeBaseConfig) (*KnowledgeBase, error) {
+	if config.VectorDB == nil {
+		return nil, fmt.Errorf("vector database is required")
+	}
+	if config.Chunker == nil {
+		config.Chunker = NewCharacterChunker(1000, 200)
+	}
+	return &KnowledgeBase{
+		db:       config.VectorDB,
+		chunker:  config.Chunker,
+		embedder: config.Embedder,
+		store:    config.StateStore,
+		reranker: config.Reranker,
+		rerankN:  config.RerankCandidates,
+	}, nil
+}
+
+// AddSource registers a loader; its documents are ingested by the next Sync.
+// Sources are identified by the order in which they were added.
+func (kb *KnowledgeBase) AddSource(loader Loader) {
+	kb.mu.Lock()
+	defer kb.mu.Unlock()
+	kb.sources = append(kb.sources, loader)
+}
+
+// Sync brings the vector database up to date with the sources. A source
+// implementing VersionedLoader is only loaded when its version changed; other
+// sources are loaded every time, and only documents whose content hash
+// changed are re-embedded. Document IDs must be unique across sources.
+//
+// The state is saved to the StateStore after every Sync, including one that
+// failed part way, so the work already done is not repeated.
+func (kb *KnowledgeBase) Sync(ctx context.Context) (stats *SyncStats, err error) {
+	kb.mu.Lock()
+	defer kb.mu.Unlock()
+
+	if kb.state == nil {
+		if kb.store != nil {
+			if kb.state, err = kb.store.LoadState(ctx); err != nil {
+				return nil, fmt.Errorf("failed to load sync state: %w", err)
+			}
+		}
+		if kb.state == nil {
+			kb.state = NewSyncState()
+		}
+	}
+	if kb.store != nil {
+		defer func() {
+			if saveErr := kb.store.SaveState(ctx, kb.state); saveErr != nil && err == nil {
+				err = fmt.Errorf("failed to save sync state: %w", saveErr)
+			}
+		}()
+	}
+
+	stats = &SyncStats{}
+	seen := make(map[string]bool)
+	sourceKeys := make(map[string]bool, len(kb.sources))
+	for i, source := range kb.sources {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		key := strconv.Itoa(i)
+		sourceKeys[key] = true
+
+		var version string
+		if versioned, ok := source.(VersionedLoader); ok {
+			// A failing Version falls back to Load, which reports the error.
+			version, _ = versioned.Version()
+		}
+		if ids, ok := kb.unchangedSource(key, version); ok {
+			for _, id := range ids {
+				if seen[id] {
+					return stats, fmt.Errorf("duplicate document ID %q", id)
+				}
+				seen[id] = true
+			}
+			stats.Documents += len(ids)
+			stats.Unchanged += len(ids)
+			stats.SourcesSkipped++
+			continue
+		}
+
+		docs, err := source.Load()
+		if err != nil {
+			return stats, fmt.Errorf("failed to load source %d: %w", i, err)
+		}
+
+		ids := make([]string, 0, len(docs))
+		for _, doc := range docs {
+			if doc.ID == "" {
+				return stats, fmt.Errorf("source %d returned a document without ID", i)
+			}
+			if seen[doc.ID] {
+				return stats, fmt.Errorf("duplicate document ID %q", doc.ID)
+			}
+			seen[doc.ID] = true
+			ids = append(ids, doc.ID)
+			stats.Documents++
+
+			hash, err := documentHash(doc)
+			if err != nil {
+				return stats, err
+			}
+			previous, known := kb.state.Documents[doc.ID]
+			if known && previous.Hash == hash {
+				stats.Unchanged++
+				continue
+			}
+
+			chunkIDs, err := kb.writeDocument(ctx, doc, previous.ChunkIDs)
+			if err != nil {
+				return stats, fmt.Errorf("failed to ingest document %q: %w", doc.ID, err)
+			}
+			kb.state.Documents[doc.ID] = DocumentState{Hash: hash, ChunkIDs: chunkIDs}
+			stats.Chunks += len(chunkIDs)
+			if known {
+				stats.Updated++
+			} else {
+				stats.Added++
+			}
+		}
+
+		if version != "" {
+			kb.state.Sources[key] = SourceState{Version: version, DocumentIDs: ids}
+		} else {
+			delete(kb.state.Sources, key)
+		}
+	}
+
+	for id, state := range kb.state.Documents {
+		if seen[id] {
+			continue
+		}
+		if len(state.ChunkIDs) > 0 {
+			if err := kb.db.Delete(ctx, state.ChunkIDs); err != nil {
+				return stats, fmt.Errorf("failed to remove document %q: %w", id, err)
+			}
+		}
+		delete(kb.state.Documents, id)
+		stats.Removed++
+	}
+	for key := range kb.state.Sources {
+		if !sourceKeys[key] {
+			delete(kb.state.Sources, key)
+		}
+	}
+
+	return stats, nil
+}
+
+// unchangedSource returns the document IDs of a source whose version matches
+// the last Sync and whose documents are all still recorded
+func (kb *KnowledgeBase) unchangedSource(key, version string) ([]string, bool) {
+	if version == "" {
+		return nil, false
+	}
+	previous, ok := kb.state.Sources[key]
+	if !ok || previous.Version != version {
+		return nil, false
+	}
+	for _, id := range previous.DocumentIDs {
+		if _, ok := kb.state.Documents[id]; !ok {
+			return nil, false
+		}
+	}
+	return previous.DocumentIDs, true
+}
+
+// writeDocument replaces the chunks of a document and returns the new chunk IDs
+func (kb *KnowledgeBase) writeDocument(ctx context.Context, doc Document, oldChunkIDs []string) ([]string, error) {
+	chunks, err := kb.chunker.Chunk(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to chunk: %w", err)
+	}
+	locateChunks(doc, chunks)
+
+	records := make([]vectordb.Document, 0, len(chunks))
+	ids := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		if chunk.Content == "" {
+			continue
+		}
+		records = append(records, vectordb.Document{
+			ID:       chunk.ID,
+			Content:  chunk.Content,
+			Metadata: chunk.Metadata,
+		})
+		ids = append(ids, chunk.ID)
+	}
+
+	// Remove the previous version, plus any chunks with the new IDs left by an
+	// earlier process, so databases without upserts do not keep duplicates.
+	stale := append(append([]string(nil), oldChunkIDs...), ids...)
+	if len(stale) > 0 {
+		if err := kb.db.Delete(ctx, stale); err != nil {
+			return nil, fmt.Errorf("failed to delete previous chunks: %w", err)
+		}
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	if kb.embedder != nil {
+		texts := make([]string, len(records))
+		for i, record := range records {
+			texts[i] = record.Content
+		}
+		vectors, err := kb.embedder.Embed(ctx, texts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed chunks: %w", err)
+		}
+		if len(vectors) != len(records) {
+			return nil, fmt.Errorf("embedder returned %d embeddings for %d chunks", len(vectors), len(records))
+		}
+		for i := range records {
+			records[i].Embedding = vectors[i]
+		}
+	}
+
+	if err := kb.db.Add(ctx, records); err != nil {
+		return nil, fmt.Errorf("failed to store chunks: %w", err)
+	}
+	return ids, nil
+}
+
+// Search returns the k chunks most relevant to query (default k: 5)
+func (kb *KnowledgeBase) Search(ctx context.Context, query string, k int) ([]vectordb.SearchResult, error) {
+	return kb.SearchWithFilter(ctx, query, k, nil)
+}
+
+// SearchWithFilter is Search restricted to chunks whose metadata matches
+// filter, in the filter language of vectordb.Filter; nil matches every chunk
+func (kb *KnowledgeBase) SearchWithFilter(ctx context.Context, query string, k int, filter map[string]interface{}) ([]vectordb.SearchResult, error) {
+	if k <= 0 {
+		k = defaultSearchLimit
+	}
+	if kb.reranker == nil {
+		return kb.query(ctx, query, k, filter)
+	}
+
+	candidates, err := kb.query(ctx, query, rerank.Candidates(k, kb.rerankN), filter)
+	if err != nil {
+		return nil, err
+	}
+	return kb.rerank(ctx, query, candidates, k), nil
+}
+
+func (kb *KnowledgeBase) query(ctx context.Context, query string, k int, filter map[string]interface{}) ([]vectordb.SearchResult, error) {
+	if kb.embedder == nil {
+		return kb.db.Query(ctx, query, k, filter)
+	}
+	embedding, err := kb.embedder.EmbedSingle(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	return kb.db.QueryWithEmbedding(ctx, embedding, k, filter)
+}
+
+// rerank returns the k best candidates by reranker score, or the first k
+// candidates when the reranker fails
+func (kb *KnowledgeBase) rerank(ctx context.Context, query string, candidates []vectordb.SearchResult, k int) []vectordb.SearchResult {
+	fallback := candidates
+	if len(fallback) > k {
+		fallback = fallback[:k]
+	}
+	if len(candidates) == 0 {
+		return candidates
+	}
+
+	documents := make([]string, len(candidates))
+	for i, c := range candidates {
+		documents[i] = c.Content
+	}
+	ranked, err := kb.reranker.Rerank(ctx, query, documents, k)
+	if err != nil {
+		return fallback
+	}
+
+	results := make([]vectordb.SearchResult, 0, len(ranked))
+	for _, r := range ranked {
+		if r.Index < 0 || r.Index >= len(candidates) {
+			return fallback
+		}
+		result := candidates[r.Index]
+		result.Score = float32(r.Score)
+		results = append(results, result)
+	}
+	return results
+}
+
+// documentHash fingerprints the parts of a document that end up in its chunks
+func documentHash(doc Document) (string, error) {
+	metadata, err := json.Marshal(doc.Metadata)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode metadata of document %q: %w", doc.ID, err)
+	}
+	h := sha256.New()
+	h.Write([]byte(doc.Source))
+	h.Write([]byte{0})
+	h.Write(metadata)
+	h.Write([]byte{0})
+	h.Write([]byte(doc.Content))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,367 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/rerank"
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb/memvec"
+)
+
+// keywordEmbedder embeds texts by counting a fixed set of keywords
+type keywordEmbedder struct {
+	embedded int
+}
+
+var keywords = []string{"go", "rust", "python", "database"}
+
+func (e *keywordEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		out[i], _ = e.EmbedSingle(ctx, text)
+	}
+	return out, nil
+}
+
+func (e *keywordEmbedder) EmbedSingle(_ context.Context, text string) ([]float32, error) {
+	e.embedded++
+	vector := make([]float32, len(keywords)+1)
+	lower := strings.ToLower(text)
+	for i, keyword := range keywords {
+		vector[i] = float32(strings.Count(lower, keyword))
+	}
+	vector[len(keywords)] = 0.1 // keeps the vector non-zero
+	return vector, nil
+}
+
+// staticLoader returns its documents, or err
+type staticLoader struct {
+	docs []Document
+	err  error
+}
+
+func (l *staticLoader) Load() ([]Document, error) {
+	return l.docs, l.err
+}
+
+// countingLoader counts Load calls of the wrapped loader
+type countingLoader struct {
+	VersionedLoader
+	loads int
+}
+
+func (l *countingLoader) Load() ([]Document, error) {
+	l.loads++
+	return l.VersionedLoader.Load()
+}
+
+func newTestKnowledgeBase(t *testing.T) (*KnowledgeBase, *memvec.MemVec, *keywordEmbedder) {
+	t.Helper()
+	db, err := memvec.New(memvec.Config{})
+	if err != nil {
+		t.Fatalf("memvec.New() error = %v", err)
+	}
+	kb, embedder := newTestKnowledgeBaseWith(t, db, nil)
+	return kb, db, embedder
+}
+
+func newTestKnowledgeBaseWith(t *testing.T, db *memvec.MemVec, store StateStore) (*KnowledgeBase, *keywordEmbedder) {
+	t.Helper()
+	embedder := &keywordEmbedder{}
+	kb, err := NewKnowledgeBase(KnowledgeBaseConfig{
+		VectorDB:   db,
+		Chunker:    NewParagraphChunker(30),
+		Embedder:   embedder,
+		StateStore: store,
+	})
+	if err != nil {
+		t.Fatalf("NewKnowledgeBase() error = %v", err)
+	}
+	return kb, embedder
+}
+
+func TestNewKnowledgeBase_RequiresVectorDB(t *testing.T) {
+	if _, err := NewKnowledgeBase(KnowledgeBaseConfig{}); err == nil {
+		t.Fatal("expected error without vector database")
+	}
+}
+
+func TestKnowledgeBase_SyncAndSearch(t *testing.T) {
+	ctx := context.Background()
+	kb, db, _ := newTestKnowledgeBase(t)
+
+	kb.AddSource(&staticLoader{docs: []Document{
+		{ID: "go", Source: "go.md", Content: "Go has goroutines.\n\nGo compiles fast, go go."},
+		{ID: "rust", Source: "rust.md", Content: "Rust has a borrow checker."},
+	}})
+	kb.AddSource(&staticLoader{docs: []Document{
+		{ID: "db", Source: "db.md", Content: "A vector database stores embeddings."},
+	}})
+
+	stats, err := kb.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if stats.Documents != 3 || stats.Added != 3 || stats.Chunks != 4 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if n, _ := db.Count(ctx); n != 4 {
+		t.Errorf("Count() = %d, want 4 chunks", n)
+	}
+
+	results, err := kb.Search(ctx, "rust", 1)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Metadata["source"] != "rust.md" {
+		t.Errorf("Search() = %+v", results)
+	}
+
+	results, err = kb.SearchWithFilter(ctx, "rust", 5, map[string]interface{}{"source": []interface{}{"go.md", "db.md"}})
+	if err != nil || len(results) != 3 {
+		t.Fatalf("SearchWithFilter() = %+v, %v, want the 3 go.md and db.md chunks", results, err)
+	}
+	for _, r := range results {
+		if r.Metadata["source"] == "rust.md" {
+			t.Errorf("SearchWithFilter() returned %+v outside the filter", r)
+		}
+	}
+}
+
+func TestKnowledgeBase_ChunkOffsets(t *testing.T) {
+	ctx := context.Background()
+	kb, db, _ := newTestKnowledgeBase(t)
+
+	content := "Go has goroutines.\n\nGo compiles fast, go go."
+	kb.AddSource(&staticLoader{docs: []Document{{ID: "go", Source: "go.md", Content: content}}})
+	if _, err := kb.Sync(ctx); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	chunks, err := db.Get(ctx, []string{"go_chunk_0", "go_chunk_1"})
+	if err != nil || len(chunks) != 2 {
+		t.Fatalf("Get() = %+v, %v", chunks, err)
+	}
+	for _, chunk := range chunks {
+		start, okStart := chunk.Metadata["start_char"].(int)
+		end, okEnd := chunk.Metadata["end_char"].(int)
+		if !okStart || !okEnd || content[start:end] != chunk.Content {
+			t.Errorf("chunk %s offsets [%v:%v] do not locate %q", chunk.ID, chunk.Metadata["start_char"], chunk.Metadata["end_char"], chunk.Content)
+		}
+	}
+}
+
+func TestKnowledgeBase_SearchReranks(t *testing.T) {
+	ctx := context.Background()
+	db, err := memvec.New(memvec.Config{})
+	if err != nil {
+		t.Fatalf("memvec.New() error = %v", err)
+	}
+
+	var candidates int
+	reranker := rerank.Func(func(ctx context.Context, query string, documents []string, topN int) ([]rerank.Result, error) {
+		candidates = len(documents)
+		results := make([]rerank.Result, len(documents))
+		for i, doc := range documents {
+			// Prefers the chunk that actually answers the question
+			results[i] = rerank.Result{Index: i, Score: 0.2}
+			if strings.Contains(doc, "borrow checker") {
+				results[i].Score = 0.95
+			}
+		}
+		return rerank.Top(results, topN), nil
+	})
+	kb, err := NewKnowledgeBase(KnowledgeBaseConfig{
+		VectorDB: db,
+		Chunker:  NewParagraphChunker(30),
+		Embedder: &keywordEmbedder{},
+		Reranker: reranker,
+	})
+	if err != nil {
+		t.Fatalf("NewKnowledgeBase() error = %v", err)
+	}
+	kb.AddSource(&staticLoader{docs: []Document{
+		{ID: "go", Source: "go.md", Content: "Go and Rust both compile.\n\nGo go go, rust."},
+		// Ranked below the first chunk of go.md by vector similarity
+		{ID: "rust", Source: "rust.md", Content: "Rust: borrow checker. Go, go."},
+	}})
+	if _, err := kb.Sync(ctx); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	results, err := kb.Search(ctx, "rust ownership", 1)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Metadata["source"] != "rust.md" || results[0].Score != 0.95 {
+		t.Errorf("Search() = %+v, want the rust chunk with the reranker score", results)
+	}
+	if candidates != 3 {
+		t.Errorf("reranked %d candidates, want all 3 chunks", candidates)
+	}
+
+	// Reranking failures keep the vector ranking
+	kb.reranker = rerank.Func(func(ctx context.Context, query string, documents []string, topN int) ([]rerank.Result, error) {
+		return nil, errors.New("rerank service unavailable")
+	})
+	if results, err := kb.Search(ctx, "rust ownership", 1); err != nil || len(results) != 1 || results[0].Metadata["source"] != "go.md" {
+		t.Errorf("Search() with failing reranker = %+v, %v", results, err)
+	}
+}
+
+func TestKnowledgeBase_IncrementalSync(t *testing.T) {
+	ctx := context.Background()
+	kb, db, embedder := newTestKnowledgeBase(t)
+
+	source := &staticLoader{docs: []Document{
+		{ID: "a", Content: "Go is simple."},
+		{ID: "b", Content: "Python is popular."},
+		{ID: "c", Content: "Rust is safe."},
+	}}
+	kb.AddSource(source)
+	if _, err := kb.Sync(ctx); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	embeddedAfterFirst := embedder.embedded
+
+	// Unchanged, updated, removed.
+	source.docs = []Document{
+		{ID: "a", Content: "Go is simple."},
+		{ID: "b", Content: "Python is popular.\n\nPython has a large ecosystem."},
+	}
+	stats, err := kb.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if stats.Unchanged != 1 || stats.Updated != 1 || stats.Removed != 1 || stats.Added != 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if embedder.embedded-embeddedAfterFirst != 2 {
+		t.Errorf("embedded %d chunks, want only the 2 chunks of the updated document", embedder.embedded-embeddedAfterFirst)
+	}
+	if n, _ := db.Count(ctx); n != 3 {
+		t.Errorf("Count() = %d, want 3 chunks", n)
+	}
+	if docs, _ := db.Get(ctx, []string{"c_chunk_0"}); len(docs) != 0 {
+		t.Error("chunks of the removed document are still stored")
+	}
+}
+
+func TestKnowledgeBase_SyncErrors(t *testing.T) {
+	ctx := context.Background()
+
+	kb, _, _ := newTestKnowledgeBase(t)
+	kb.AddSource(&staticLoader{err: errors.New("disk gone")})
+	if _, err := kb.Sync(ctx); err == nil || !strings.Contains(err.Error(), "disk gone") {
+		t.Errorf("Sync() error = %v, want loader error", err)
+	}
+
+	kb, _, _ = newTestKnowledgeBase(t)
+	kb.AddSource(&staticLoader{docs: []Document{{ID: "x", Content: "one"}}})
+	kb.AddSource(&staticLoader{docs: []Document{{ID: "x", Content: "two"}}})
+	if _, err := kb.Sync(ctx); err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Errorf("Sync() error = %v, want duplicate ID error", err)
+	}
+}
+
+func TestKnowledgeBase_PersistentSync(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeFile := func(name, content string, modTime time.Time) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	writeFile("go.md", "Go is simple.", base)
+	writeFile("rust.md", "Rust is safe.", base)
+	writeFile("notes.txt", "ignored by the pattern", base)
+
+	db, err := memvec.New(memvec.Config{})
+	if err != nil {
+		t.Fatalf("memvec.New() error = %v", err)
+	}
+	store := NewFileStateStore(filepath.Join(dir, "state", "sync.json"))
+
+	// newProcess simulates a restart: a new knowledge base over the same stores.
+	newProcess := func() (*KnowledgeBase, *keywordEmbedder, *countingLoader) {
+		kb, embedder := newTestKnowledgeBaseWith(t, db, store)
+		loader := &countingLoader{VersionedLoader: NewDirectoryLoader(dir, "*.md", false)}
+		kb.AddSource(loader)
+		return kb, embedder, loader
+	}
+
+	kb, _, _ := newProcess()
+	if stats, err := kb.Sync(ctx); err != nil || stats.Added != 2 {
+		t.Fatalf("Sync() = %+v, %v", stats, err)
+	}
+
+	// Nothing changed: the directory is not even read.
+	kb, embedder, loader := newProcess()
+	stats, err := kb.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if loader.loads != 0 || embedder.embedded != 0 || stats.SourcesSkipped != 1 || stats.Unchanged != 2 {
+		t.Errorf("loads = %d, embedded = %d, stats = %+v", loader.loads, embedder.embedded, stats)
+	}
+
+	// Touched without content change, modified, and removed files.
+	writeFile("go.md", "Go is simple.", base.Add(time.Hour))
+	writeFile("rust.md", "Rust is safe and fast.", base.Add(time.Hour))
+	writeFile("python.md", "Python is popular.", base)
+	kb, embedder, loader = newProcess()
+	stats, err = kb.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if loader.loads != 1 || embedder.embedded != 2 || stats.Unchanged != 1 || stats.Updated != 1 || stats.Added != 1 {
+		t.Errorf("loads = %d, embedded = %d, stats = %+v", loader.loads, embedder.embedded, stats)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "python.md")); err != nil {
+		t.Fatal(err)
+	}
+	kb, _, _ = newProcess()
+	if stats, err := kb.Sync(ctx); err != nil || stats.Removed != 1 {
+		t.Errorf("Sync() = %+v, %v", stats, err)
+	}
+	if n, _ := db.Count(ctx); n != 2 {
+		t.Errorf("Count() = %d, want 2 chunks", n)
+	}
+}
+
+func TestFileStateStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileStateStore(filepath.Join(t.TempDir(), "sync.json"))
+
+	state, err := store.LoadState(ctx)
+	if err != nil || state != nil {
+		t.Fatalf("LoadState() = %v, %v, want nil state for a missing file", state, err)
+	}
+
+	state = NewSyncState()
+	state.Documents["a"] = DocumentState{Hash: "h", ChunkIDs: []string{"a_chunk_0"}}
+	state.Sources["0"] = SourceState{Version: "v1", DocumentIDs: []string{"a"}}
+	if err := store.SaveState(ctx, state); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+
+	loaded, err := store.LoadState(ctx)
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if loaded.Documents["a"].ChunkIDs[0] != "a_chunk_0" || loaded.Sources["0"].Version != "v1" {
+		t.Errorf("LoadState() = %+v", loaded)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/ids"
 )
 
 func TestTextLoader(t *testing.T) {
@@ -121,6 +123,16 @@ func TestReaderLoader(t *testing.T) {
 	}
 }
 
+func TestReaderLoader_GeneratesID(t *testing.T) {
+	docs, err := NewReaderLoader(strings.NewReader("unnamed"), "", nil).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if _, err := ids.Parse(docs[0].ID); err != nil {
+		t.Errorf("ID = %q, want a generated ULID: %v", docs[0].ID, err)
+	}
+}
+
 func TestCharacterChunker(t *testing.T) {
 	doc := Document{
 		ID:      "test-doc",
@@ -212,8 +224,9 @@ func TestParagraphChunker(t *testing.T) {
 		t.Fatalf("Chunk() error = %v", err)
 	}
 
-	if len(chunks) == 0 {
-		t.Error("Expected at least one chunk")
+	// All three paragraphs fit in one chunk
+	if len(chunks) != 1 {
+		t.Errorf("Expected 1 chunk, got %d", len(chunks))
 	}
 
 	// Verify chunk metadata
@@ -0,0 +1,191 @@
+package knowledge
+
+import (
+	"strings"
+)
+
+// markdownSeparators splits a section that is too large; headings were already
+// handled by the section split
+var markdownSeparators = []string{"\n```", "\n\n", "\n", ". ", " ", ""}
+
+// MarkdownChunker splits Markdown documents along their heading structure.
+// Every chunk belongs to a single section, and its metadata records the
+// heading path of that section:
+//
+//   - "heading": title of the innermost heading
+//   - "heading_level": level of that heading (1-6)
+//   - "heading_path": titles from the top level down, joined by " > "
+//
+// Sections larger than ChunkSize are split further by a RecursiveChunker.
+// ATX headings ("## Title") are recognized; lines in fenced code blocks are
+// never taken for headings.
+type MarkdownChunker struct {
+	ChunkSize    int // Maximum characters per chunk
+	ChunkOverlap int // Overlap when a section is split
+
+	// MaxHeadingLevel is the deepest heading that starts a new section
+	// (default: 6); deeper headings stay in their parent's text
+	MaxHeadingLevel int
+
+	// IncludeHeadingPath prefixes every chunk after the first of a split
+	// section with its heading path, so each chunk keeps its context
+	IncludeHeadingPath bool
+}
+
+// NewMarkdownChunker creates a Markdown chunker
+func NewMarkdownChunker(chunkSize, chunkOverlap int) *MarkdownChunker {
+	if chunkSize <= 0 {
+		chunkSize = 1000
+	}
+	if chunkOverlap < 0 || chunkOverlap >= chunkSize {
+		chunkOverlap = chunkSize / 10
+	}
+
+	return &MarkdownChunker{
+		ChunkSize:       chunkSize,
+		ChunkOverlap:    chunkOverlap,
+		MaxHeadingLevel: 6,
+	}
+}
+
+// markdownSection is the text under one heading, up to the next heading of
+// any level
+type markdownSection struct {
+	path  []string // heading titles, outermost first
+	level int      // 0 before the first heading
+	text  string
+	body  bool // the section has content besides its heading line
+}
+
+// Chunk splits a Markdown document into chunks that follow its sections
+func (c *MarkdownChunker) Chunk(doc Document) ([]Chunk, error) {
+	splitter := &RecursiveChunker{
+		ChunkSize:    c.ChunkSize,
+		ChunkOverlap: c.ChunkOverlap,
+		Separators:   markdownSeparators,
+	}
+
+	chunks := []Chunk{}
+	for _, section := range c.sections(doc.Content) {
+		if !section.body {
+			continue // a heading directly followed by a subheading
+		}
+
+		var extra map[string]interface{}
+		pathText := ""
+		if len(section.path) > 0 {
+			pathText = strings.Join(section.path, " > ")
+			extra = map[string]interface{}{
+				"heading":       section.path[len(section.path)-1],
+				"heading_level": section.level,
+				"heading_path":  pathText,
+			}
+		}
+
+		for i, text := range splitter.SplitText(section.text) {
+			if i > 0 && c.IncludeHeadingPath && pathText != "" {
+				text = pathText + "\n\n" + text
+			}
+			chunks = append(chunks, newChunk(doc, text, len(chunks), extra))
+		}
+	}
+	return chunks, nil
+}
+
+// sections splits Markdown text at its headings
+func (c *MarkdownChunker) sections(text string) []markdownSection {
+	maxLevel := c.MaxHeadingLevel
+	if maxLevel <= 0 || maxLevel > 6 {
+		maxLevel = 6
+	}
+
+	var (
+		sections []markdownSection
+		current  markdownSection
+		lines    []string
+		fence    string
+		titles   [7]string // titles[level] of the enclosing headings
+	)
+	flush := func() {
+		current.text = strings.Join(lines, "\n")
+		if strings.TrimSpace(current.text) != "" {
+			sections = append(sections, current)
+		}
+		lines = nil
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if marker := codeFence(trimmed); marker != "" {
+			switch {
+			case fence == "":
+				fence = marker
+			case strings.HasPrefix(trimmed, fence):
+				fence = ""
+			}
+		}
+
+		level, title := 0, ""
+		if fence == "" && !strings.HasPrefix(line, "    ") {
+			level, title = atxHeading(trimmed)
+		}
+		if level == 0 || level > maxLevel {
+			lines = append(lines, line)
+			if trimmed != "" {
+				current.body = true
+			}
+			continue
+		}
+
+		flush()
+		titles[level] = title
+		for l := level + 1; l < len(titles); l++ {
+			titles[l] = ""
+		}
+		var path []string
+		for l := 1; l <= level; l++ {
+			if titles[l] != "" {
+				path = append(path, titles[l])
+			}
+		}
+		current = markdownSection{path: path, level: level}
+		lines = []string{line}
+	}
+	flush()
+	return sections
+}
+
+// atxHeading parses "## Title" lines, returning level 0 for other lines
+func atxHeading(line string) (int, string) {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return 0, ""
+	}
+	rest := line[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return 0, "" // "#hashtag"
+	}
+	// Optional closing sequence, "## Title ##", but not "## C#"
+	title := strings.TrimSpace(rest)
+	end := len(title)
+	for end > 0 && title[end-1] == '#' {
+		end--
+	}
+	if end == 0 || title[end-1] == ' ' || title[end-1] == '\t' {
+		title = strings.TrimSpace(title[:end])
+	}
+	return level, title
+}
+
+// codeFence returns the fence marker opening or closing a code block, or ""
+func codeFence(line string) string {
+	for _, marker := range []string{"```", "~~~"} {
+		if strings.HasPrefix(line, marker) {
+			return marker
+		}
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+package knowledge
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleMarkdown = `Intro before any heading.
+
+# Guide
+
+Welcome to the guide.
+
+## Install
+
+### Linux
+
+Run the installer.
+
+` + "```sh\n# not a heading\n./install.sh\n```" + `
+
+### C#
+
+Use NuGet.
+
+## Usage ##
+
+Call Run.
+#hashtag is not a heading.
+`
+
+func TestMarkdownChunker_Sections(t *testing.T) {
+	doc := Document{ID: "guide", Source: "guide.md", Content: sampleMarkdown}
+
+	chunks, err := NewMarkdownChunker(500, 50).Chunk(doc)
+	if err != nil {
+		t.Fatalf("Chunk() error = %v", err)
+	}
+
+	want := []struct {
+		path    string
+		level   int
+		content string
+	}{
+		{"", 0, "Intro before any heading."},
+		{"Guide", 1, "# Guide\n\nWelcome to the guide."},
+		{"Guide > Install > Linux", 3, "### Linux\n\nRun the installer.\n\n```sh\n# not a heading\n./install.sh\n```"},
+		{"Guide > Install > C#", 3, "### C#\n\nUse NuGet."},
+		{"Guide > Usage", 2, "## Usage ##\n\nCall Run.\n#hashtag is not a heading."},
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("got %d chunks: %+v", len(chunks), chunks)
+	}
+	for i, w := range want {
+		chunk := chunks[i]
+		if chunk.Content != w.content {
+			t.Errorf("chunk %d content = %q, want %q", i, chunk.Content, w.content)
+		}
+		if w.path == "" {
+			if _, ok := chunk.Metadata["heading_path"]; ok {
+				t.Errorf("chunk %d has a heading path: %v", i, chunk.Metadata)
+			}
+			continue
+		}
+		if chunk.Metadata["heading_path"] != w.path || chunk.Metadata["heading_level"] != w.level {
+			t.Errorf("chunk %d metadata = %v, want path %q level %d", i, chunk.Metadata, w.path, w.level)
+		}
+		if chunk.Metadata["document_id"] != "guide" || chunk.Metadata["chunk_index"] != i {
+			t.Errorf("chunk %d metadata = %v", i, chunk.Metadata)
+		}
+	}
+}
+
+func TestMarkdownChunker_SplitsLargeSections(t *testing.T) {
+	body := strings.Repeat("A sentence about setup. ", 20)
+	doc := Document{ID: "doc", Content: "# Setup\n\n" + body + "\n\n# Next\n\nShort."}
+
+	chunker := NewMarkdownChunker(150, 0)
+	chunker.IncludeHeadingPath = true
+	chunks, err := chunker.Chunk(doc)
+	if err != nil {
+		t.Fatalf("Chunk() error = %v", err)
+	}
+	if len(chunks) < 4 {
+		t.Fatalf("got %d chunks", len(chunks))
+	}
+
+	last := chunks[len(chunks)-1]
+	if last.Content != "# Next\n\nShort." || last.Metadata["heading"] != "Next" {
+		t.Errorf("last chunk = %+v", last)
+	}
+	for _, chunk := range chunks[1 : len(chunks)-1] {
+		if !strings.HasPrefix(chunk.Content, "Setup\n\n") || chunk.Metadata["heading_path"] != "Setup" {
+			t.Errorf("chunk = %+v, want the heading path of its section", chunk)
+		}
+	}
+}
+
+func TestMarkdownChunker_MaxHeadingLevel(t *testing.T) {
+	doc := Document{ID: "doc", Content: "# Top\n\nIntro.\n\n## Detail\n\nMore."}
+	chunker := NewMarkdownChunker(500, 0)
+	chunker.MaxHeadingLevel = 1
+
+	chunks, err := chunker.Chunk(doc)
+	if err != nil {
+		t.Fatalf("Chunk() error = %v", err)
+	}
+	if len(chunks) != 1 || !strings.Contains(chunks[0].Content, "## Detail") || chunks[0].Metadata["heading_path"] != "Top" {
+		t.Errorf("chunks = %+v", chunks)
+	}
+}
@@ -0,0 +1,365 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OCREngine extracts the text of an image (PNG, JPEG, TIFF, ...)
+type OCREngine interface {
+	Recognize(ctx context.Context, image []byte) (string, error)
+}
+
+// PDFRasterizer renders every page of a PDF as an image, in page order
+type PDFRasterizer interface {
+	Rasterize(ctx context.Context, pdfPath string) ([][]byte, error)
+}
+
+// imageExtensions are the files OCRLoader reads as a single image
+var imageExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".tif": true, ".tiff": true,
+	".bmp": true, ".gif": true, ".webp": true,
+}
+
+// OCRLoader extracts text from scanned documents: images, and PDFs whose
+// pages are images. PDFs are rendered page by page with Rasterizer, then every
+// image goes through Engine. For PDFs that mix text and scanned pages, prefer
+// PDFLoader with its OCR field, which only recognizes the pages without text.
+type OCRLoader struct {
+	FilePaths      []string
+	Engine         OCREngine     // Required: TesseractOCR, VisionOCR or a custom engine
+	Rasterizer     PDFRasterizer // Renders PDF pages (default: PopplerRasterizer)
+	PageSeparator  string        // Separator between PDF pages
+	ContinueOnErr  bool          // Skip files that fail instead of aborting (default: true)
+	Timeout        time.Duration // Timeout per file (default: 5m)
+	CommonMetadata map[string]interface{}
+}
+
+// NewOCRLoader creates a new OCR loader
+func NewOCRLoader(engine OCREngine, filePaths ...string) *OCRLoader {
+	return &OCRLoader{
+		FilePaths:     filePaths,
+		Engine:        engine,
+		Rasterizer:    NewPopplerRasterizer(),
+		PageSeparator: "\n\n---\n\n",
+		ContinueOnErr: true,
+		Timeout:       5 * time.Minute,
+	}
+}
+
+// Load recognizes every file, one document per file
+func (l *OCRLoader) Load() ([]Document, error) {
+	if l.Engine == nil {
+		return nil, fmt.Errorf("an OCR engine is required")
+	}
+	if len(l.FilePaths) == 0 {
+		return nil, fmt.Errorf("at least one file is required")
+	}
+
+	var documents []Document
+	var errs []error
+	for _, path := range l.FilePaths {
+		doc, err := l.loadFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			if !l.ContinueOnErr {
+				return nil, fmt.Errorf("failed to OCR files: %v", errs)
+			}
+			continue
+		}
+		documents = append(documents, doc)
+	}
+
+	if len(documents) == 0 && len(errs) > 0 {
+		return nil, fmt.Errorf("all files failed to OCR: %v", errs)
+	}
+
+	return documents, nil
+}
+
+// Version reports the size and modification time of every file
+func (l *OCRLoader) Version() (string, error) {
+	var versions []string
+	for _, path := range l.FilePaths {
+		version, err := fileVersion(path)
+		if err != nil {
+			return "", err
+		}
+		versions = append(versions, version)
+	}
+	return strings.Join(versions, ","), nil
+}
+
+func (l *OCRLoader) loadFile(path string) (Document, error) {
+	timeout := l.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	ext := strings.ToLower(filepath.Ext(path))
+	metadata := map[string]interface{}{
+		"filename": filepath.Base(path),
+		"path":     path,
+		"ext":      filepath.Ext(path),
+		"ocr":      true,
+	}
+
+	var content string
+	switch {
+	case ext == ".pdf":
+		rasterizer := l.Rasterizer
+		if rasterizer == nil {
+			rasterizer = NewPopplerRasterizer()
+		}
+		pages, err := rasterizer.Rasterize(ctx, path)
+		if err != nil {
+			return Document{}, fmt.Errorf("failed to render PDF %s: %w", path, err)
+		}
+		texts := make([]string, 0, len(pages))
+		for i, page := range pages {
+			text, err := l.Engine.Recognize(ctx, page)
+			if err != nil {
+				return Document{}, fmt.Errorf("failed to OCR page %d of %s: %w", i+1, path, err)
+			}
+			if text = strings.TrimSpace(text); text != "" {
+				texts = append(texts, text)
+			}
+		}
+		content = strings.Join(texts, l.PageSeparator)
+		metadata["pages"] = len(pages)
+		metadata["file_type"] = "pdf"
+	case imageExtensions[ext]:
+		image, err := os.ReadFile(path)
+		if err != nil {
+			return Document{}, fmt.Errorf("failed to read image %s: %w", path, err)
+		}
+		text, err := l.Engine.Recognize(ctx, image)
+		if err != nil {
+			return Document{}, fmt.Errorf("failed to OCR %s: %w", path, err)
+		}
+		content = strings.TrimSpace(text)
+		metadata["file_type"] = "image"
+	default:
+		return Document{}, fmt.Errorf("unsupported file type for OCR: %s", path)
+	}
+
+	if content == "" {
+		return Document{}, fmt.Errorf("no text recognized in %s", path)
+	}
+	for k, v := range l.CommonMetadata {
+		metadata[k] = v
+	}
+
+	return Document{
+		ID:       filepath.Base(path),
+		Content:  content,
+		Source:   path,
+		Metadata: metadata,
+	}, nil
+}
+
+// TesseractOCR recognizes text with the tesseract command line tool
+// (https://github.com/tesseract-ocr/tesseract), which must be installed
+type TesseractOCR struct {
+	Binary      string   // Path of the executable (default: "tesseract")
+	Languages   []string // Trained data to use, e.g. {"eng", "deu"} (default: eng)
+	PageSegMode int      // --psm value (0 = tesseract's default)
+	ExtraArgs   []string // Additional arguments, e.g. {"-c", "preserve_interword_spaces=1"}
+}
+
+// NewTesseractOCR creates a tesseract engine for the given languages
+func NewTesseractOCR(languages ...string) *TesseractOCR {
+	return &TesseractOCR{Binary: "tesseract", Languages: languages}
+}
+
+// Recognize pipes the image through tesseract
+func (t *TesseractOCR) Recognize(ctx context.Context, image []byte) (string, error) {
+	binary := t.Binary
+	if binary == "" {
+		binary = "tesseract"
+	}
+	args := []string{"stdin", "stdout"}
+	if len(t.Languages) > 0 {
+		args = append(args, "-l", strings.Join(t.Languages, "+"))
+	}
+	if t.PageSegMode > 0 {
+		args = append(args, "--psm", strconv.Itoa(t.PageSegMode))
+	}
+	args = append(args, t.ExtraArgs...)
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, binary, args...)
+	cmd.Stdin = bytes.NewReader(image)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("tesseract failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// VisionOCR transcribes images with a vision model through an OpenAI
+// compatible chat completions API. It reads handwriting, tables and poor
+// scans better than tesseract, at the price of an API call per page.
+type VisionOCR struct {
+	APIKey     string       // API key (default: OPENAI_API_KEY)
+	BaseURL    string       // API base URL (default: https://api.openai.com/v1)
+	Model      string       // Vision model (default: gpt-4o-mini)
+	Prompt     string       // Instruction sent with every image
+	MaxTokens  int          // Maximum tokens per page (default: 4096)
+	HTTPClient *http.Client // HTTP client (default: http.DefaultClient)
+}
+
+// NewVisionOCR creates a vision model engine
+func NewVisionOCR(model string) *VisionOCR {
+	return &VisionOCR{
+		APIKey:    os.Getenv("OPENAI_API_KEY"),
+		BaseURL:   "https://api.openai.com/v1",
+		Model:     model,
+		Prompt:    "Transcribe all text in this image exactly as written, preserving reading order. Render tables as Markdown. Reply with the transcription only.",
+		MaxTokens: 4096,
+	}
+}
+
+// Recognize sends the image as a data URL and returns the model's transcription
+func (v *VisionOCR) Recognize(ctx context.Context, image []byte) (string, error) {
+	model := v.Model
+	if model == "" {
+		model = "gpt-4o-mini"
+	}
+	maxTokens := v.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = 4096
+	}
+	dataURL := "data:" + http.DetectContentType(image) + ";base64," + base64.StdEncoding.EncodeToString(image)
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"model":      model,
+		"max_tokens": maxTokens,
+		"messages": []map[string]interface{}{{
+			"role": "user",
+			"content": []map[string]interface{}{
+				{"type": "text", "text": v.Prompt},
+				{"type": "image_url", "image_url": map[string]string{"url": dataURL}},
+			},
+		}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode vision request: %w", err)
+	}
+
+	baseURL := strings.TrimRight(v.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if v.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+v.APIKey)
+	}
+
+	client := v.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vision OCR request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read vision OCR response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("vision OCR failed: HTTP error %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("failed to decode vision OCR response: %w", err)
+	}
+	if len(result.Choices) == 0 {
+		return "", fmt.Errorf("vision OCR returned no choices")
+	}
+	return result.Choices[0].Message.Content, nil
+}
+
+// PopplerRasterizer renders PDF pages with pdftoppm from poppler-utils,
+// which must be installed
+type PopplerRasterizer struct {
+	Binary string // Path of the executable (default: "pdftoppm")
+	DPI    int    // Resolution (default: 300, what tesseract expects)
+}
+
+// NewPopplerRasterizer creates a pdftoppm rasterizer at 300 DPI
+func NewPopplerRasterizer() *PopplerRasterizer {
+	return &PopplerRasterizer{Binary: "pdftoppm", DPI: 300}
+}
+
+// Rasterize renders every page as PNG
+func (r *PopplerRasterizer) Rasterize(ctx context.Context, pdfPath string) ([][]byte, error) {
+	binary := r.Binary
+	if binary == "" {
+		binary = "pdftoppm"
+	}
+	dpi := r.DPI
+	if dpi <= 0 {
+		dpi = 300
+	}
+
+	dir, err := os.MkdirTemp("", "agentgo-ocr-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, binary, "-png", "-r", strconv.Itoa(dpi), pdfPath, filepath.Join(dir, "page"))
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("pdftoppm failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	// Pages are written as page-1.png ... or zero-padded (page-01.png) for
+	// longer documents, so sorting by name keeps page order
+	files, err := filepath.Glob(filepath.Join(dir, "page-*.png"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	pages := make([][]byte, 0, len(files))
+	for _, file := range files {
+		page, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, page)
+	}
+	return pages, nil
+}
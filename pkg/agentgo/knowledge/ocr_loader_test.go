@@ -0,0 +1,209 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeOCR "recognizes" images whose bytes are the text itself
+type fakeOCR struct {
+	calls int
+}
+
+func (f *fakeOCR) Recognize(ctx context.Context, image []byte) (string, error) {
+	f.calls++
+	if bytes.Equal(image, []byte("unreadable")) {
+		return "", fmt.Errorf("image too blurry")
+	}
+	return "  " + string(image) + "\n", nil
+}
+
+// fakeRasterizer returns fixed page images for every PDF
+type fakeRasterizer struct {
+	pages []string
+}
+
+func (r fakeRasterizer) Rasterize(ctx context.Context, pdfPath string) ([][]byte, error) {
+	images := make([][]byte, len(r.pages))
+	for i, page := range r.pages {
+		images[i] = []byte(page)
+	}
+	return images, nil
+}
+
+// writeTestPDF writes a PDF with one page per entry of pages; empty entries
+// are pages without a text layer, like scanned pages
+func writeTestPDF(t *testing.T, path string, pages ...string) {
+	t.Helper()
+	var objects []string
+	kids := make([]string, len(pages))
+	for i, text := range pages {
+		pageObj, contentObj := 4+2*i, 5+2*i
+		kids[i] = fmt.Sprintf("%d 0 R", pageObj)
+		stream := ""
+		if text != "" {
+			stream = fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+		}
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", contentObj),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream))
+	}
+	objects = append([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	}, objects...)
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPDFLoader_OCRFallbackForScannedPages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "contract.pdf")
+	writeTestPDF(t, path, "Typed cover page", "", "")
+
+	// Without OCR, scanned pages are skipped
+	docs, err := NewPDFLoader(path).Load()
+	if err != nil || !strings.Contains(docs[0].Content, "Typed cover page") {
+		t.Fatalf("Load() = %v, %v", docs, err)
+	}
+
+	engine := &fakeOCR{}
+	loader := NewPDFLoader(path)
+	loader.OCR = engine
+	loader.Rasterizer = fakeRasterizer{pages: []string{"cover image", "Scanned clause 1", "Scanned clause 2"}}
+	docs, err = loader.Load()
+	if err != nil {
+		t.Fatalf("Load() with OCR error = %v", err)
+	}
+	want := "Typed cover page\n\n---\n\nScanned clause 1\n\n---\n\nScanned clause 2"
+	if docs[0].Content != want {
+		t.Errorf("Content = %q, want %q", docs[0].Content, want)
+	}
+	if engine.calls != 2 {
+		t.Errorf("OCR calls = %d, want 2 (pages with text are not recognized)", engine.calls)
+	}
+	if pages, _ := docs[0].Metadata["ocr_pages"].([]int); len(pages) != 2 || pages[0] != 2 || pages[1] != 3 {
+		t.Errorf("ocr_pages = %v", docs[0].Metadata["ocr_pages"])
+	}
+
+	// Scan-only PDFs no longer fail
+	writeTestPDF(t, path, "")
+	loader.Rasterizer = fakeRasterizer{pages: []string{"Scanned receipt"}}
+	if docs, err = loader.Load(); err != nil || docs[0].Content != "Scanned receipt" {
+		t.Errorf("scan-only Load() = %v, %v", docs, err)
+	}
+}
+
+func TestOCRLoader_ImagesAndPDFs(t *testing.T) {
+	dir := t.TempDir()
+	receipt := filepath.Join(dir, "receipt.png")
+	blurry := filepath.Join(dir, "blurry.jpg")
+	scan := filepath.Join(dir, "scan.pdf")
+	os.WriteFile(receipt, []byte("Total: 42.00 EUR"), 0o644)
+	os.WriteFile(blurry, []byte("unreadable"), 0o644)
+	writeTestPDF(t, scan, "", "")
+
+	loader := NewOCRLoader(&fakeOCR{}, receipt, blurry, scan, filepath.Join(dir, "notes.docx"))
+	loader.Rasterizer = fakeRasterizer{pages: []string{"Page one", "Page two"}}
+	loader.CommonMetadata = map[string]interface{}{"batch": "march"}
+
+	docs, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("Load() returned %d documents, want 2", len(docs))
+	}
+	if docs[0].ID != "receipt.png" || docs[0].Content != "Total: 42.00 EUR" || docs[0].Metadata["batch"] != "march" {
+		t.Errorf("image document = %+v", docs[0])
+	}
+	if docs[1].Content != "Page one\n\n---\n\nPage two" || docs[1].Metadata["pages"] != 2 {
+		t.Errorf("PDF document = %+v", docs[1])
+	}
+
+	loader.ContinueOnErr = false
+	if _, err := loader.Load(); err == nil || !strings.Contains(err.Error(), "too blurry") {
+		t.Errorf("Load() with ContinueOnErr=false error = %v", err)
+	}
+}
+
+func TestTesseractOCR_PipesImage(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "tesseract")
+	script := "#!/bin/sh\necho \"args: $*\"\ncat\n"
+	if err := os.WriteFile(binary, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	engine := NewTesseractOCR("eng", "deu")
+	engine.Binary = binary
+	engine.PageSegMode = 6
+	text, err := engine.Recognize(context.Background(), []byte("image bytes"))
+	if err != nil {
+		t.Fatalf("Recognize() error = %v", err)
+	}
+	if text != "args: stdin stdout -l eng+deu --psm 6\nimage bytes" {
+		t.Errorf("Recognize() = %q", text)
+	}
+
+	engine.Binary = filepath.Join(dir, "missing")
+	if _, err := engine.Recognize(context.Background(), nil); err == nil {
+		t.Error("Recognize() with a missing binary should fail")
+	}
+}
+
+func TestVisionOCR_SendsImageAsDataURL(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\nfake")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model    string `json:"model"`
+			Messages []struct {
+				Content []struct {
+					Type     string            `json:"type"`
+					ImageURL map[string]string `json:"image_url"`
+				} `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		want := "data:image/png;base64," + base64.StdEncoding.EncodeToString(png)
+		if r.URL.Path != "/v1/chat/completions" || req.Model != "gpt-4o" || req.Messages[0].Content[1].ImageURL["url"] != want {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, `{"choices":[{"message":{"content":"| Item | Qty |\n|---|---|\n| Bolt | 4 |"}}]}`)
+	}))
+	defer server.Close()
+
+	engine := NewVisionOCR("gpt-4o")
+	engine.BaseURL = server.URL + "/v1"
+	text, err := engine.Recognize(context.Background(), png)
+	if err != nil || !strings.Contains(text, "| Bolt | 4 |") {
+		t.Fatalf("Recognize() = %q, %v", text, err)
+	}
+}
@@ -2,6 +2,7 @@ package knowledge
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -16,6 +17,11 @@ type PDFLoader struct {
 	ExtractImages  bool // Future: extract images from PDF
 	PageSeparator  string
 	PreserveLayout bool // Try to preserve text layout
+
+	// OCR recognizes pages without a text layer, such as scanned pages
+	// (nil = skip them). Pages are rendered with Rasterizer.
+	OCR        OCREngine
+	Rasterizer PDFRasterizer // Renders pages for OCR (default: PopplerRasterizer)
 }
 
 // NewPDFLoader creates a new PDF loader
@@ -36,8 +42,8 @@ func (l *PDFLoader) Load() ([]Document, error) {
 	defer file.Close()
 
 	// Extract text from all pages
-	var contentBuilder strings.Builder
 	numPages := reader.NumPage()
+	pageTexts := make([]string, numPages)
 
 	for pageNum := 1; pageNum <= numPages; pageNum++ {
 		page := reader.Page(pageNum)
@@ -52,12 +58,29 @@ func (l *PDFLoader) Load() ([]Document, error) {
 			fmt.Printf("Warning: failed to extract text from page %d: %v\n", pageNum, err)
 			continue
 		}
+		pageTexts[pageNum-1] = strings.TrimSpace(text)
+	}
+
+	// Recognize the pages that have no text layer
+	var ocrPages []int
+	if l.OCR != nil {
+		var err error
+		ocrPages, err = l.recognizeEmptyPages(pageTexts)
+		if err != nil {
+			return nil, err
+		}
+	}
 
+	var contentBuilder strings.Builder
+	for _, text := range pageTexts {
+		if text == "" {
+			continue
+		}
 		// Add page content
 		if contentBuilder.Len() > 0 {
 			contentBuilder.WriteString(l.PageSeparator)
 		}
-		contentBuilder.WriteString(strings.TrimSpace(text))
+		contentBuilder.WriteString(text)
 	}
 
 	content := contentBuilder.String()
@@ -77,11 +100,60 @@ func (l *PDFLoader) Load() ([]Document, error) {
 			"file_type": "pdf",
 		},
 	}
+	if len(ocrPages) > 0 {
+		doc.Metadata["ocr_pages"] = ocrPages
+	}
 
 	return []Document{doc}, nil
 }
 
+// recognizeEmptyPages fills the empty entries of pageTexts with OCR text and
+// returns the (1-based) numbers of the pages it recognized
+func (l *PDFLoader) recognizeEmptyPages(pageTexts []string) ([]int, error) {
+	empty := false
+	for _, text := range pageTexts {
+		if text == "" {
+			empty = true
+			break
+		}
+	}
+	if !empty {
+		return nil, nil
+	}
+
+	ctx := context.Background()
+	rasterizer := l.Rasterizer
+	if rasterizer == nil {
+		rasterizer = NewPopplerRasterizer()
+	}
+	images, err := rasterizer.Rasterize(ctx, l.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render PDF %s for OCR: %w", l.FilePath, err)
+	}
+
+	var ocrPages []int
+	for i, text := range pageTexts {
+		if text != "" || i >= len(images) {
+			continue
+		}
+		recognized, err := l.OCR.Recognize(ctx, images[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to OCR page %d of %s: %w", i+1, l.FilePath, err)
+		}
+		if recognized = strings.TrimSpace(recognized); recognized != "" {
+			pageTexts[i] = recognized
+			ocrPages = append(ocrPages, i+1)
+		}
+	}
+	return ocrPages, nil
+}
+
 // PDFDirectoryLoader loads all PDF files from a directory
+// Version reports the size and modification time of the file
+func (l *PDFLoader) Version() (string, error) {
+	return fileVersion(l.FilePath)
+}
+
 type PDFDirectoryLoader struct {
 	DirPath   string
 	Recursive bool
@@ -139,6 +211,13 @@ func (l *PDFDirectoryLoader) Load() ([]Document, error) {
 }
 
 // PDFReaderLoader loads PDF from a byte slice (in-memory PDF)
+// Version reports the paths, sizes and modification times of the PDF files
+func (l *PDFDirectoryLoader) Version() (string, error) {
+	return dirVersion(l.DirPath, l.Recursive, func(path string) bool {
+		return strings.ToLower(filepath.Ext(path)) == ".pdf"
+	})
+}
+
 type PDFReaderLoader struct {
 	Data     []byte
 	ID       string
@@ -196,7 +275,7 @@ func (l *PDFReaderLoader) Load() ([]Document, error) {
 	metadata["file_type"] = "pdf"
 
 	doc := Document{
-		ID:       l.ID,
+		ID:       readerDocID(l.ID),
 		Content:  content,
 		Metadata: metadata,
 	}
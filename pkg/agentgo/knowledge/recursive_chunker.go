@@ -0,0 +1,193 @@
+package knowledge
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultSeparators is the separator hierarchy of RecursiveChunker: Markdown
+// headings, paragraphs, lines, sentences, words and finally characters
+var DefaultSeparators = []string{"\n# ", "\n## ", "\n### ", "\n#### ", "\n\n", "\n", ". ", " ", ""}
+
+// RecursiveChunker splits documents on the first separator of a hierarchy that
+// occurs in the text, and splits pieces that are still too large with the next
+// separators. Adjacent pieces are then merged up to ChunkSize, so chunks end
+// at the coarsest boundary that fits.
+type RecursiveChunker struct {
+	ChunkSize    int      // Maximum characters per chunk
+	ChunkOverlap int      // Characters repeated from the end of the previous chunk
+	Separators   []string // From coarsest to finest; "" splits between characters
+}
+
+// NewRecursiveChunker creates a recursive chunker using DefaultSeparators
+func NewRecursiveChunker(chunkSize, chunkOverlap int) *RecursiveChunker {
+	if chunkSize <= 0 {
+		chunkSize = 1000
+	}
+	if chunkOverlap < 0 || chunkOverlap >= chunkSize {
+		chunkOverlap = chunkSize / 10
+	}
+
+	return &RecursiveChunker{
+		ChunkSize:    chunkSize,
+		ChunkOverlap: chunkOverlap,
+		Separators:   DefaultSeparators,
+	}
+}
+
+// Chunk splits a document into chunks of at most ChunkSize characters
+func (c *RecursiveChunker) Chunk(doc Document) ([]Chunk, error) {
+	var chunks []Chunk
+	for _, text := range c.SplitText(doc.Content) {
+		chunks = append(chunks, newChunk(doc, text, len(chunks), nil))
+	}
+	if chunks == nil {
+		return []Chunk{}, nil
+	}
+	return chunks, nil
+}
+
+// SplitText splits text into trimmed, non-empty pieces of at most ChunkSize characters
+func (c *RecursiveChunker) SplitText(text string) []string {
+	separators := c.Separators
+	if len(separators) == 0 {
+		separators = DefaultSeparators
+	}
+
+	var out []string
+	for _, piece := range c.split(text, separators) {
+		if piece = strings.TrimSpace(piece); piece != "" {
+			out = append(out, piece)
+		}
+	}
+	return out
+}
+
+// split splits text with the first separator it contains and recurses into
+// pieces that are too large
+func (c *RecursiveChunker) split(text string, separators []string) []string {
+	if len(text) <= c.ChunkSize {
+		return []string{text}
+	}
+
+	sep, rest := "", []string(nil)
+	for i, s := range separators {
+		if s == "" || strings.Contains(text, s) {
+			sep, rest = s, separators[i+1:]
+			break
+		}
+	}
+	if sep == "" {
+		return splitRunes(text, c.ChunkSize, c.ChunkOverlap)
+	}
+
+	var chunks, small []string
+	for _, piece := range splitKeepSeparator(text, sep) {
+		if len(piece) <= c.ChunkSize {
+			small = append(small, piece)
+			continue
+		}
+		chunks = append(chunks, c.merge(small)...)
+		small = nil
+		chunks = append(chunks, c.split(piece, rest)...)
+	}
+	return append(chunks, c.merge(small)...)
+}
+
+// merge joins adjacent pieces into chunks of at most ChunkSize characters,
+// starting each chunk with up to ChunkOverlap characters of trailing pieces
+// from the previous one
+func (c *RecursiveChunker) merge(pieces []string) []string {
+	var chunks, window []string
+	size := 0
+	for _, piece := range pieces {
+		if size+len(piece) > c.ChunkSize && len(window) > 0 {
+			chunks = append(chunks, strings.Join(window, ""))
+			for len(window) > 0 && (size > c.ChunkOverlap || size+len(piece) > c.ChunkSize) {
+				size -= len(window[0])
+				window = window[1:]
+			}
+		}
+		window = append(window, piece)
+		size += len(piece)
+	}
+	if len(window) > 0 {
+		chunks = append(chunks, strings.Join(window, ""))
+	}
+	return chunks
+}
+
+// splitKeepSeparator splits text on sep without dropping it. Separators that
+// start a line (headings, paragraphs) open the next piece; others (". ", " ")
+// close the previous one.
+func splitKeepSeparator(text, sep string) []string {
+	parts := strings.Split(text, sep)
+	pieces := make([]string, 0, len(parts))
+	leading := strings.HasPrefix(sep, "\n")
+	for i, part := range parts {
+		switch {
+		case leading && i > 0:
+			part = sep + part
+		case !leading && i < len(parts)-1:
+			part += sep
+		}
+		if part != "" {
+			pieces = append(pieces, part)
+		}
+	}
+	return pieces
+}
+
+// splitRunes cuts text into windows of at most size bytes that overlap by about
+// overlap bytes, without splitting runes
+func splitRunes(text string, size, overlap int) []string {
+	size = max(size, utf8.UTFMax)
+	var pieces []string
+	for start := 0; start < len(text); {
+		end := min(start+size, len(text))
+		for end < len(text) && !utf8.RuneStart(text[end]) {
+			end--
+		}
+		pieces = append(pieces, text[start:end])
+		if end == len(text) {
+			break
+		}
+
+		next := end - overlap
+		for next > start && !utf8.RuneStart(text[next]) {
+			next++
+		}
+		if next <= start {
+			next = end
+		}
+		start = next
+	}
+	return pieces
+}
+
+// newChunk builds the index-th chunk of doc with the standard chunk metadata,
+// the document metadata, and extra
+func newChunk(doc Document, content string, index int, extra map[string]interface{}) Chunk {
+	chunk := Chunk{
+		ID:      fmt.Sprintf("%s_chunk_%d", doc.ID, index),
+		Content: content,
+		Index:   index,
+		Metadata: map[string]interface{}{
+			"document_id": doc.ID,
+			"source":      doc.Source,
+			"chunk_index": index,
+		},
+	}
+	for k, v := range extra {
+		chunk.Metadata[k] = v
+	}
+
+	// Copy document metadata
+	for k, v := range doc.Metadata {
+		if _, exists := chunk.Metadata[k]; !exists {
+			chunk.Metadata[k] = v
+		}
+	}
+	return chunk
+}
@@ -0,0 +1,85 @@
+package knowledge
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRecursiveChunker_PrefersCoarseSeparators(t *testing.T) {
+	doc := Document{
+		ID:       "doc",
+		Source:   "doc.md",
+		Metadata: map[string]interface{}{"lang": "en"},
+		Content: "First paragraph is short.\n\n" +
+			"Second paragraph is a little longer than the first one.\n\n" +
+			"Third paragraph.",
+	}
+
+	chunks, err := NewRecursiveChunker(60, 0).Chunk(doc)
+	if err != nil {
+		t.Fatalf("Chunk() error = %v", err)
+	}
+	want := []string{
+		"First paragraph is short.",
+		"Second paragraph is a little longer than the first one.",
+		"Third paragraph.",
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("got %d chunks: %+v", len(chunks), chunks)
+	}
+	for i, chunk := range chunks {
+		if chunk.Content != want[i] {
+			t.Errorf("chunk %d = %q, want %q", i, chunk.Content, want[i])
+		}
+		if chunk.ID != fmt.Sprintf("doc_chunk_%d", i) || chunk.Metadata["chunk_index"] != i || chunk.Metadata["lang"] != "en" {
+			t.Errorf("chunk %d = %+v", i, chunk)
+		}
+	}
+}
+
+func TestRecursiveChunker_FallsBackToFinerSeparators(t *testing.T) {
+	sentence := "Every sentence here is twenty-nine. "
+	doc := Document{ID: "doc", Content: strings.Repeat(sentence, 10)}
+
+	chunker := NewRecursiveChunker(100, 40)
+	chunks, err := chunker.Chunk(doc)
+	if err != nil {
+		t.Fatalf("Chunk() error = %v", err)
+	}
+	if len(chunks) < 4 {
+		t.Fatalf("got %d chunks", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if len(chunk.Content) > 100 {
+			t.Errorf("chunk %d has %d characters", i, len(chunk.Content))
+		}
+		if !strings.HasSuffix(chunk.Content, ".") {
+			t.Errorf("chunk %d = %q, want it to end at a sentence", i, chunk.Content)
+		}
+	}
+	if !strings.HasPrefix(sentence, chunks[1].Content[:10]) {
+		t.Errorf("chunk 1 = %q, want it to start with an overlapping sentence", chunks[1].Content)
+	}
+}
+
+func TestRecursiveChunker_SplitsLongWordsByRune(t *testing.T) {
+	chunker := &RecursiveChunker{ChunkSize: 10, ChunkOverlap: 2}
+	pieces := chunker.SplitText(strings.Repeat("é", 25))
+	if len(pieces) < 5 {
+		t.Fatalf("got %d pieces", len(pieces))
+	}
+	for _, piece := range pieces {
+		if len(piece) > 10 || !utf8.ValidString(piece) {
+			t.Errorf("invalid piece %q", piece)
+		}
+	}
+}
+
+func TestRecursiveChunker_EmptyDocument(t *testing.T) {
+	chunks, err := NewRecursiveChunker(100, 10).Chunk(Document{ID: "empty", Content: " \n\n "})
+	if err != nil || len(chunks) != 0 {
+		t.Errorf("Chunk() = %v, %v", chunks, err)
+	}
+}
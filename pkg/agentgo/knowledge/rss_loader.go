@@ -0,0 +1,355 @@
+package knowledge
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// RSSLoader loads the entries of RSS 2.0, RSS 1.0 and Atom feeds, one
+// document per entry. Entries are identified by their GUID (Atom: id, falling
+// back to the link), so an entry published in several feeds, or repeated in
+// one, is loaded once and keeps the same document ID across syncs.
+type RSSLoader struct {
+	FeedURLs       []string
+	MaxItems       int               // Maximum entries per feed (0 = all)
+	Since          time.Time         // Skip entries published before Since (entries without a date are kept)
+	ContinueOnErr  bool              // Skip feeds that fail instead of aborting (default: true)
+	Timeout        time.Duration     // Request timeout (default: 30s)
+	Headers        map[string]string // Custom headers
+	CommonMetadata map[string]interface{}
+}
+
+// NewRSSLoader creates a new feed loader
+func NewRSSLoader(feedURLs ...string) *RSSLoader {
+	return &RSSLoader{
+		FeedURLs:      feedURLs,
+		ContinueOnErr: true,
+		Timeout:       30 * time.Second,
+	}
+}
+
+// feedDocument decodes RSS 2.0 (<rss><channel>), RSS 1.0 (<rdf:RDF>) and
+// Atom (<feed>) documents
+type feedDocument struct {
+	Channel struct {
+		Title string    `xml:"title"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items   []rssItem   `xml:"item"` // RSS 1.0 items are siblings of the channel
+	Title   string      `xml:"title"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        string   `xml:"guid"`
+	Description string   `xml:"description"`
+	Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PubDate     string   `xml:"pubDate"`
+	Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Author      string   `xml:"author"`
+	Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories  []string `xml:"category"`
+}
+
+type atomEntry struct {
+	Title string `xml:"title"`
+	ID    string `xml:"id"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+	Published  string `xml:"published"`
+	Updated    string `xml:"updated"`
+	Summary    string `xml:"summary"`
+	Content    string `xml:"content"`
+	Author     string `xml:"author>name"`
+	Categories []struct {
+		Term string `xml:"term,attr"`
+	} `xml:"category"`
+}
+
+// feedEntry is an entry in a format-independent shape
+type feedEntry struct {
+	guid       string
+	title      string
+	link       string
+	body       string
+	published  string
+	author     string
+	categories []string
+}
+
+// Load fetches the feeds and returns one document per unique entry
+func (l *RSSLoader) Load() ([]Document, error) {
+	if len(l.FeedURLs) == 0 {
+		return nil, fmt.Errorf("at least one feed URL is required")
+	}
+
+	var allDocs []Document
+	var errs []error
+	seen := make(map[string]bool)
+
+	for _, feedURL := range l.FeedURLs {
+		title, entries, err := l.fetchFeed(feedURL)
+		if err != nil {
+			errs = append(errs, err)
+			if !l.ContinueOnErr {
+				return nil, fmt.Errorf("failed to load feeds: %v", errs)
+			}
+			continue
+		}
+
+		count := 0
+		for _, entry := range entries {
+			if l.MaxItems > 0 && count >= l.MaxItems {
+				break
+			}
+			id := entryID(feedURL, entry)
+			if seen[id] {
+				continue
+			}
+			published, hasDate := parseFeedDate(entry.published)
+			if !l.Since.IsZero() && hasDate && published.Before(l.Since) {
+				continue
+			}
+			seen[id] = true
+			count++
+
+			doc, ok := l.entryDocument(feedURL, title, id, entry, published, hasDate)
+			if ok {
+				allDocs = append(allDocs, doc)
+			}
+		}
+	}
+
+	if len(allDocs) == 0 && len(errs) > 0 {
+		return nil, fmt.Errorf("all feeds failed to load: %v", errs)
+	}
+
+	return allDocs, nil
+}
+
+func (l *RSSLoader) fetchFeed(feedURL string) (string, []feedEntry, error) {
+	timeout := l.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "AgentGo-Knowledge-Loader/1.0")
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, */*;q=0.8")
+	for key, value := range l.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to fetch feed %s: %w", feedURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", nil, fmt.Errorf("failed to fetch feed %s: HTTP error %d", feedURL, resp.StatusCode)
+	}
+
+	var doc feedDocument
+	decoder := xml.NewDecoder(resp.Body)
+	decoder.Strict = false // Feeds in the wild often contain HTML entities
+	decoder.Entity = xml.HTMLEntity
+	if err := decoder.Decode(&doc); err != nil {
+		return "", nil, fmt.Errorf("failed to parse feed %s: %w", feedURL, err)
+	}
+
+	var entries []feedEntry
+	for _, item := range append(doc.Channel.Items, doc.Items...) {
+		body := item.Content
+		if strings.TrimSpace(body) == "" {
+			body = item.Description
+		}
+		published := item.PubDate
+		if published == "" {
+			published = item.Date
+		}
+		author := item.Author
+		if author == "" {
+			author = item.Creator
+		}
+		entries = append(entries, feedEntry{
+			guid:       strings.TrimSpace(item.GUID),
+			title:      strings.TrimSpace(item.Title),
+			link:       strings.TrimSpace(item.Link),
+			body:       body,
+			published:  strings.TrimSpace(published),
+			author:     strings.TrimSpace(author),
+			categories: item.Categories,
+		})
+	}
+	for _, entry := range doc.Entries {
+		body := entry.Content
+		if strings.TrimSpace(body) == "" {
+			body = entry.Summary
+		}
+		published := entry.Published
+		if published == "" {
+			published = entry.Updated
+		}
+		var link string
+		for _, candidate := range entry.Links {
+			if candidate.Rel == "" || candidate.Rel == "alternate" {
+				link = candidate.Href
+				break
+			}
+		}
+		var categories []string
+		for _, category := range entry.Categories {
+			categories = append(categories, category.Term)
+		}
+		entries = append(entries, feedEntry{
+			guid:       strings.TrimSpace(entry.ID),
+			title:      strings.TrimSpace(entry.Title),
+			link:       strings.TrimSpace(link),
+			body:       body,
+			published:  strings.TrimSpace(published),
+			author:     strings.TrimSpace(entry.Author),
+			categories: categories,
+		})
+	}
+
+	title := doc.Channel.Title
+	if title == "" {
+		title = doc.Title
+	}
+	return strings.TrimSpace(title), entries, nil
+}
+
+func (l *RSSLoader) entryDocument(feedURL, feedTitle, id string, entry feedEntry, published time.Time, hasDate bool) (Document, bool) {
+	text := feedText(entry.body)
+	var content strings.Builder
+	if entry.title != "" {
+		content.WriteString(entry.title)
+	}
+	if text != "" {
+		if content.Len() > 0 {
+			content.WriteString("\n\n")
+		}
+		content.WriteString(text)
+	}
+	if content.Len() == 0 {
+		return Document{}, false
+	}
+
+	source := entry.link
+	if source == "" {
+		source = feedURL
+	}
+	metadata := map[string]interface{}{
+		"source":      source,
+		"source_type": "rss",
+		"feed":        feedURL,
+		"guid":        id,
+	}
+	if feedTitle != "" {
+		metadata["feed_title"] = feedTitle
+	}
+	if entry.title != "" {
+		metadata["title"] = entry.title
+	}
+	if entry.link != "" {
+		metadata["url"] = entry.link
+	}
+	if hasDate {
+		metadata["published"] = published.UTC().Format(time.RFC3339)
+	}
+	if entry.author != "" {
+		metadata["author"] = entry.author
+	}
+	if len(entry.categories) > 0 {
+		metadata["categories"] = entry.categories
+	}
+	for k, v := range l.CommonMetadata {
+		metadata[k] = v
+	}
+
+	return Document{
+		ID:       id,
+		Content:  content.String(),
+		Source:   source,
+		Metadata: metadata,
+	}, true
+}
+
+// entryID returns the GUID of an entry. GUIDs that are not URLs or URNs (some
+// feeds use bare numbers) are scoped to their feed; entries without any
+// identifier are keyed by their title and date.
+func entryID(feedURL string, entry feedEntry) string {
+	id := entry.guid
+	if id == "" {
+		id = entry.link
+	}
+	if id == "" {
+		sum := sha256.Sum256([]byte(entry.title + "\x00" + entry.published))
+		id = hex.EncodeToString(sum[:8])
+	}
+	if !strings.Contains(id, ":") {
+		id = feedURL + "#" + id
+	}
+	return id
+}
+
+// feedText converts an entry body, usually escaped HTML, to plain text
+func feedText(body string) string {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return ""
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(body))
+	if err != nil {
+		return body
+	}
+	doc.Find("script, style").Remove()
+
+	var lines []string
+	for _, line := range strings.Split(doc.Text(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseFeedDate parses the RFC 822 dates of RSS and the RFC 3339 dates of Atom
+func parseFeedDate(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{
+		time.RFC1123Z,
+		time.RFC1123,
+		"Mon, 2 Jan 2006 15:04:05 -0700",
+		"Mon, 2 Jan 2006 15:04:05 MST",
+		time.RFC822Z,
+		time.RFC822,
+		time.RFC3339,
+		"2006-01-02",
+	} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
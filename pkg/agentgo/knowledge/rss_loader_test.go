@@ -0,0 +1,112 @@
+package knowledge
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testRSSFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+<channel>
+  <title>Release notes</title>
+  <item>
+    <title>v2.0 released</title>
+    <link>https://example.com/v2</link>
+    <guid>https://example.com/v2</guid>
+    <pubDate>Mon, 02 Mar 2026 10:00:00 +0000</pubDate>
+    <description>Short summary</description>
+    <content:encoded><![CDATA[<p>Full <b>notes</b> for v2.</p><script>track()</script>]]></content:encoded>
+    <category>release</category>
+  </item>
+  <item>
+    <title>v2.0 released</title>
+    <link>https://example.com/v2</link>
+    <guid>https://example.com/v2</guid>
+    <description>Duplicate entry</description>
+  </item>
+  <item>
+    <title>Maintenance window</title>
+    <guid isPermaLink="false">42</guid>
+    <pubDate>Sun, 1 Feb 2026 08:00:00 GMT</pubDate>
+    <description>&lt;p&gt;Downtime on Sunday&amp;nbsp;night&lt;/p&gt;</description>
+  </item>
+  <item>
+    <title>Ancient history</title>
+    <guid>urn:old</guid>
+    <pubDate>Tue, 01 Jan 2019 00:00:00 +0000</pubDate>
+  </item>
+</channel>
+</rss>`
+
+const testAtomFeed = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Engineering blog</title>
+  <entry>
+    <title>Cross-posted v2 announcement</title>
+    <id>https://example.com/v2</id>
+    <link rel="alternate" href="https://blog.example.com/v2"/>
+  </entry>
+  <entry>
+    <title>Scaling the index</title>
+    <id>tag:example.com,2026:scaling</id>
+    <link href="https://blog.example.com/scaling"/>
+    <updated>2026-03-05T12:00:00Z</updated>
+    <author><name>Ana</name></author>
+    <summary type="html">&lt;p&gt;How we sharded&lt;/p&gt;</summary>
+  </entry>
+</feed>`
+
+func TestRSSLoader_RSSAndAtomWithDedupe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rss":
+			fmt.Fprint(w, testRSSFeed)
+		case "/atom":
+			fmt.Fprint(w, testAtomFeed)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	loader := NewRSSLoader(server.URL+"/rss", server.URL+"/atom", server.URL+"/missing")
+	loader.Since = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	docs, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	var ids []string
+	for _, doc := range docs {
+		ids = append(ids, doc.ID)
+	}
+	want := []string{"https://example.com/v2", server.URL + "/rss#42", "tag:example.com,2026:scaling"}
+	if fmt.Sprint(ids) != fmt.Sprint(want) {
+		t.Fatalf("IDs = %v, want %v", ids, want)
+	}
+
+	v2 := docs[0]
+	if v2.Content != "v2.0 released\n\nFull notes for v2." {
+		t.Errorf("content = %q", v2.Content)
+	}
+	if v2.Metadata["feed_title"] != "Release notes" || v2.Metadata["published"] != "2026-03-02T10:00:00Z" || v2.Source != "https://example.com/v2" {
+		t.Errorf("unexpected metadata %v", v2.Metadata)
+	}
+	if !strings.Contains(docs[1].Content, "Downtime on Sunday") {
+		t.Errorf("escaped HTML description not converted: %q", docs[1].Content)
+	}
+	atom := docs[2]
+	if atom.Metadata["author"] != "Ana" || atom.Metadata["url"] != "https://blog.example.com/scaling" || !strings.Contains(atom.Content, "How we sharded") {
+		t.Errorf("unexpected Atom document %+v", atom)
+	}
+
+	loader.ContinueOnErr = false
+	if _, err := loader.Load(); err == nil {
+		t.Error("expected error for the missing feed")
+	}
+}
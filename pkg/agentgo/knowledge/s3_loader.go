@@ -0,0 +1,298 @@
+package knowledge
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// emptyPayloadHash is the SHA-256 of an empty request body
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Loader loads documents from an Amazon S3 bucket or an S3-compatible store
+// (MinIO, Cloudflare R2, ...). Requests are signed with AWS Signature V4;
+// without credentials they are sent anonymously, which works for public buckets.
+type S3Loader struct {
+	BucketLoader
+
+	Region          string       // Default: AWS_REGION, then "us-east-1"
+	Endpoint        string       // Custom endpoint, e.g. "http://localhost:9000" (default: AWS)
+	PathStyle       bool         // Address the bucket as Endpoint/Bucket instead of Bucket.host
+	AccessKeyID     string       // Default: AWS_ACCESS_KEY_ID
+	SecretAccessKey string       // Default: AWS_SECRET_ACCESS_KEY
+	SessionToken    string       // Default: AWS_SESSION_TOKEN
+	HTTPClient      *http.Client // Default: http.DefaultClient
+
+	now func() time.Time
+}
+
+// NewS3Loader creates a loader for the objects of bucket under prefix.
+// Credentials and region are read from the standard AWS environment variables.
+func NewS3Loader(bucket, prefix string) *S3Loader {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	return &S3Loader{
+		BucketLoader:    *NewBucketLoader(nil, "s3", bucket, prefix),
+		Region:          region,
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
+
+// Load lists the matching objects and loads them concurrently
+func (l *S3Loader) Load() ([]Document, error) {
+	return l.bucketLoader().Load()
+}
+
+// Version changes whenever a matching object is added, removed or modified
+func (l *S3Loader) Version() (string, error) {
+	return l.bucketLoader().Version()
+}
+
+func (l *S3Loader) bucketLoader() *BucketLoader {
+	b := l.BucketLoader
+	b.Store = l
+	b.SourceType = "s3"
+	return &b
+}
+
+// s3ListResult is the ListObjectsV2 response
+type s3ListResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		ETag         string    `xml:"ETag"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+}
+
+// List returns the objects under prefix, following continuation tokens
+func (l *S3Loader) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}}
+		if prefix != "" {
+			query.Set("prefix", prefix)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := l.do(ctx, "", query)
+		if err != nil {
+			return nil, err
+		}
+		var result s3ListResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode object listing: %w", err)
+		}
+
+		for _, c := range result.Contents {
+			objects = append(objects, ObjectInfo{
+				Key:          c.Key,
+				Size:         c.Size,
+				ETag:         strings.Trim(c.ETag, `"`),
+				LastModified: c.LastModified,
+			})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// Open streams the content of an object
+func (l *S3Loader) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := l.do(ctx, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// do sends a signed GET request for key (or the bucket when key is empty)
+func (l *S3Loader) do(ctx context.Context, key string, query url.Values) (*http.Response, error) {
+	if l.Bucket == "" {
+		return nil, fmt.Errorf("bucket is required")
+	}
+	u, err := l.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = s3EncodeQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	l.sign(req)
+
+	client := l.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+func (l *S3Loader) region() string {
+	if l.Region != "" {
+		return l.Region
+	}
+	return "us-east-1"
+}
+
+func (l *S3Loader) objectURL(key string) (*url.URL, error) {
+	endpoint := l.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", l.region())
+	}
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+
+	p := "/" + key
+	if l.PathStyle {
+		p = "/" + l.Bucket + p
+	} else {
+		u.Host = l.Bucket + "." + u.Host
+	}
+	u.Path = u.Path + p
+	u.RawPath = s3EscapePath(u.Path)
+	return u, nil
+}
+
+// sign adds the AWS Signature V4 headers to req
+func (l *S3Loader) sign(req *http.Request) {
+	now := time.Now
+	if l.now != nil {
+		now = l.now
+	}
+	t := now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", emptyPayloadHash)
+	if l.SessionToken != "" {
+		req.Header.Set("x-amz-security-token", l.SessionToken)
+	}
+	if l.AccessKeyID == "" || l.SecretAccessKey == "" {
+		return // Anonymous request
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(req.Header.Get(name))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		emptyPayloadHash,
+	}, "\n")
+
+	scope := date + "/" + l.region() + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+l.SecretAccessKey), date)
+	key = hmacSHA256(key, l.region())
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		l.AccessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3Escape percent-encodes everything but the RFC 3986 unreserved characters,
+// as Signature V4 requires
+func s3Escape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', keepSlash && c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func s3EscapePath(p string) string {
+	return s3Escape(p, true)
+}
+
+// s3EncodeQuery encodes query sorted by key, in canonical Signature V4 form
+func s3EncodeQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, s3Escape(k, false)+"="+s3Escape(v, false))
+		}
+	}
+	return strings.Join(parts, "&")
+}
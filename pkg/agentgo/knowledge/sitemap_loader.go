@@ -0,0 +1,361 @@
+package knowledge
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SitemapLoader loads the pages listed in a sitemap.xml. Sitemap indexes and
+// gzipped sitemaps are followed; pages are fetched concurrently, optionally
+// rate limited, and loaded like URLLoader does.
+type SitemapLoader struct {
+	SitemapURL        string
+	IncludeFilter     []string          // Only load page URLs containing one of these substrings
+	ExcludeFilter     []string          // Skip page URLs containing one of these substrings
+	ModifiedSince     time.Time         // Skip pages whose <lastmod> is older (pages without one are kept)
+	MaxPages          int               // Maximum pages to load (0 = no limit)
+	MaxDepth          int               // Nested sitemap index levels followed (default: 3)
+	MaxConcurrent     int               // Maximum concurrent requests (default: 5)
+	RequestsPerSecond float64           // Rate limit across all requests (0 = unlimited)
+	ContinueOnErr     bool              // Skip pages that fail instead of aborting (default: true)
+	Timeout           time.Duration     // Request timeout (default: 30s)
+	Headers           map[string]string // Custom headers
+	CommonMetadata    map[string]interface{}
+}
+
+// NewSitemapLoader creates a new sitemap loader
+func NewSitemapLoader(sitemapURL string) *SitemapLoader {
+	return &SitemapLoader{
+		SitemapURL:    sitemapURL,
+		MaxDepth:      3,
+		MaxConcurrent: 5,
+		ContinueOnErr: true,
+		Timeout:       30 * time.Second,
+	}
+}
+
+// sitemapEntry is a <url> of a urlset or a <sitemap> of a sitemap index
+type sitemapEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// sitemapDocument decodes both <urlset> and <sitemapindex> roots
+type sitemapDocument struct {
+	URLs     []sitemapEntry `xml:"url"`
+	Sitemaps []sitemapEntry `xml:"sitemap"`
+}
+
+// Load reads the sitemap and loads the selected pages concurrently
+func (l *SitemapLoader) Load() ([]Document, error) {
+	limiter := newRateLimiter(l.RequestsPerSecond)
+	entries, err := l.pages(limiter)
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		docs []Document
+		err  error
+	}
+
+	results := make([]result, len(entries))
+	semaphore := make(chan struct{}, max(l.MaxConcurrent, 1))
+	var wg sync.WaitGroup
+
+	for i, entry := range entries {
+		wg.Add(1)
+		go func(i int, entry sitemapEntry) {
+			defer wg.Done()
+			semaphore <- struct{}{}        // Acquire
+			defer func() { <-semaphore }() // Release
+
+			if err := limiter.wait(context.Background()); err != nil {
+				results[i] = result{err: err}
+				return
+			}
+			docs, err := l.loadPage(entry)
+			results[i] = result{docs: docs, err: err}
+		}(i, entry)
+	}
+	wg.Wait()
+
+	// Collect in sitemap order so repeated loads return documents in the same order
+	var allDocs []Document
+	var errs []error
+	for _, res := range results {
+		if res.err != nil {
+			errs = append(errs, res.err)
+			if !l.ContinueOnErr {
+				return nil, fmt.Errorf("failed to load pages: %v", errs)
+			}
+			continue
+		}
+		allDocs = append(allDocs, res.docs...)
+	}
+
+	if len(allDocs) == 0 && len(errs) > 0 {
+		return nil, fmt.Errorf("all pages failed to load: %v", errs)
+	}
+
+	return allDocs, nil
+}
+
+// Version hashes the page URLs and their <lastmod>, so KnowledgeBase.Sync only
+// refetches the pages when the sitemap changed. Without a <lastmod> on every
+// page there is no way to tell, and the version is empty (always load).
+func (l *SitemapLoader) Version() (string, error) {
+	entries, err := l.pages(newRateLimiter(l.RequestsPerSecond))
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	for _, entry := range entries {
+		if entry.LastMod == "" {
+			return "", nil
+		}
+		fmt.Fprintf(h, "%s\x00%s\n", entry.Loc, entry.LastMod)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// pages returns the selected page entries of the sitemap, following indexes
+func (l *SitemapLoader) pages(limiter *rateLimiter) ([]sitemapEntry, error) {
+	if l.SitemapURL == "" {
+		return nil, fmt.Errorf("sitemap URL is required")
+	}
+
+	var entries []sitemapEntry
+	seen := make(map[string]bool)
+	visited := make(map[string]bool)
+
+	var walk func(sitemapURL string, depth int) error
+	walk = func(sitemapURL string, depth int) error {
+		if visited[sitemapURL] {
+			return nil
+		}
+		visited[sitemapURL] = true
+
+		doc, err := l.fetchSitemap(sitemapURL, limiter)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range doc.URLs {
+			entry.Loc = strings.TrimSpace(entry.Loc)
+			entry.LastMod = strings.TrimSpace(entry.LastMod)
+			if entry.Loc == "" || seen[entry.Loc] || !l.selected(entry) {
+				continue
+			}
+			if l.MaxPages > 0 && len(entries) >= l.MaxPages {
+				return nil
+			}
+			seen[entry.Loc] = true
+			entries = append(entries, entry)
+		}
+
+		maxDepth := l.MaxDepth
+		if maxDepth <= 0 {
+			maxDepth = 3
+		}
+		for _, child := range doc.Sitemaps {
+			if depth >= maxDepth {
+				break
+			}
+			if l.MaxPages > 0 && len(entries) >= l.MaxPages {
+				return nil
+			}
+			if loc := strings.TrimSpace(child.Loc); loc != "" {
+				if err := walk(loc, depth+1); err != nil {
+					if !l.ContinueOnErr {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := walk(l.SitemapURL, 0); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// selected applies the URL filters and ModifiedSince to a page entry
+func (l *SitemapLoader) selected(entry sitemapEntry) bool {
+	if len(l.IncludeFilter) > 0 && !containsAny(entry.Loc, l.IncludeFilter) {
+		return false
+	}
+	if containsAny(entry.Loc, l.ExcludeFilter) {
+		return false
+	}
+	if !l.ModifiedSince.IsZero() && entry.LastMod != "" {
+		if modified, ok := parseLastMod(entry.LastMod); ok && modified.Before(l.ModifiedSince) {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *SitemapLoader) fetchSitemap(sitemapURL string, limiter *rateLimiter) (*sitemapDocument, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), l.timeout())
+	defer cancel()
+
+	if err := limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sitemapURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "AgentGo-Knowledge-Loader/1.0")
+	for key, value := range l.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sitemap %s: %w", sitemapURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to fetch sitemap %s: HTTP error %d", sitemapURL, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sitemap %s: %w", sitemapURL, err)
+	}
+
+	// sitemap.xml.gz files are served as-is rather than with Content-Encoding
+	if bytes.HasPrefix(body, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress sitemap %s: %w", sitemapURL, err)
+		}
+		defer gz.Close()
+		if body, err = io.ReadAll(gz); err != nil {
+			return nil, fmt.Errorf("failed to decompress sitemap %s: %w", sitemapURL, err)
+		}
+	}
+
+	var doc sitemapDocument
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse sitemap %s: %w", sitemapURL, err)
+	}
+	return &doc, nil
+}
+
+// loadPage loads one page; its URL is the document ID so it stays stable
+// across syncs
+func (l *SitemapLoader) loadPage(entry sitemapEntry) ([]Document, error) {
+	loader := NewURLLoader(entry.Loc)
+	loader.Timeout = l.timeout()
+	loader.Headers = l.Headers
+
+	docs, err := loader.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range docs {
+		docs[i].ID = entry.Loc
+		if len(docs) > 1 {
+			docs[i].ID = fmt.Sprintf("%s#%d", entry.Loc, i)
+		}
+		docs[i].Source = entry.Loc
+		docs[i].Metadata["source"] = entry.Loc
+		docs[i].Metadata["source_type"] = "sitemap"
+		docs[i].Metadata["sitemap"] = l.SitemapURL
+		if entry.LastMod != "" {
+			docs[i].Metadata["lastmod"] = entry.LastMod
+		}
+		for k, v := range l.CommonMetadata {
+			docs[i].Metadata[k] = v
+		}
+	}
+	return docs, nil
+}
+
+func (l *SitemapLoader) timeout() time.Duration {
+	if l.Timeout <= 0 {
+		return 30 * time.Second
+	}
+	return l.Timeout
+}
+
+// parseLastMod parses the W3C datetime formats allowed in <lastmod>
+func parseLastMod(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04Z07:00", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func containsAny(s string, substrings []string) bool {
+	for _, sub := range substrings {
+		if sub != "" && strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// rateLimiter spaces requests at least interval apart; a nil limiter does not wait
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// wait blocks until the next request slot
+func (r *rateLimiter) wait(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	now := time.Now()
+	slot := r.next
+	if slot.Before(now) {
+		slot = now
+	}
+	r.next = slot.Add(r.interval)
+	r.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,124 @@
+package knowledge
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newSitemapServer(t *testing.T, lastmod string) (*httptest.Server, *int32) {
+	t.Helper()
+	var pageHits int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>%[1]s/docs.xml.gz</loc></sitemap>
+  <sitemap><loc>%[1]s/blog.xml</loc></sitemap>
+</sitemapindex>`, server.URL)
+		case "/docs.xml.gz":
+			var buf bytes.Buffer
+			gz := gzip.NewWriter(&buf)
+			fmt.Fprintf(gz, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>%[1]s/docs/intro</loc><lastmod>%[2]s</lastmod></url>
+  <url><loc>%[1]s/docs/setup</loc><lastmod>2026-01-01</lastmod></url>
+  <url><loc>%[1]s/private/admin</loc><lastmod>2026-01-01</lastmod></url>
+</urlset>`, server.URL, lastmod)
+			gz.Close()
+			w.Write(buf.Bytes())
+		case "/blog.xml":
+			fmt.Fprintf(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>%[1]s/docs/intro</loc><lastmod>%[2]s</lastmod></url>
+  <url><loc>%[1]s/blog/old-post</loc><lastmod>2020-05-01</lastmod></url>
+</urlset>`, server.URL, lastmod)
+		default:
+			atomic.AddInt32(&pageHits, 1)
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintf(w, "<html><head><title>%s</title></head><body><p>Page %s</p></body></html>", r.URL.Path, r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &pageHits
+}
+
+func TestSitemapLoader_FollowsIndexAndFilters(t *testing.T) {
+	server, pageHits := newSitemapServer(t, "2026-02-01T10:00:00Z")
+
+	loader := NewSitemapLoader(server.URL + "/sitemap.xml")
+	loader.ExcludeFilter = []string{"/private/"}
+	loader.ModifiedSince = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	loader.RequestsPerSecond = 1000
+	loader.CommonMetadata = map[string]interface{}{"site": "docs"}
+
+	docs, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(docs) != 2 || atomic.LoadInt32(pageHits) != 2 {
+		t.Fatalf("expected 2 pages (deduplicated, filtered), got %d docs / %d fetches", len(docs), *pageHits)
+	}
+	if docs[0].ID != server.URL+"/docs/intro" || docs[1].ID != server.URL+"/docs/setup" {
+		t.Errorf("unexpected IDs %q, %q", docs[0].ID, docs[1].ID)
+	}
+	if !strings.Contains(docs[0].Content, "Page /docs/intro") {
+		t.Errorf("unexpected content %q", docs[0].Content)
+	}
+	meta := docs[0].Metadata
+	if meta["source_type"] != "sitemap" || meta["lastmod"] != "2026-02-01T10:00:00Z" || meta["site"] != "docs" {
+		t.Errorf("unexpected metadata %v", meta)
+	}
+
+	loader.MaxPages = 1
+	docs, err = loader.Load()
+	if err != nil || len(docs) != 1 {
+		t.Fatalf("MaxPages: got %d docs, err %v", len(docs), err)
+	}
+}
+
+func TestSitemapLoader_VersionTracksLastMod(t *testing.T) {
+	server, pageHits := newSitemapServer(t, "2026-02-01")
+	loader := NewSitemapLoader(server.URL + "/sitemap.xml")
+
+	v1, err := loader.Version()
+	if err != nil || v1 == "" {
+		t.Fatalf("Version() = %q, %v", v1, err)
+	}
+	v2, _ := loader.Version()
+	if v1 != v2 {
+		t.Error("version changed without sitemap changes")
+	}
+	if atomic.LoadInt32(pageHits) != 0 {
+		t.Error("Version fetched pages")
+	}
+
+	changed, _ := newSitemapServer(t, "2026-03-01")
+	loader.SitemapURL = changed.URL + "/sitemap.xml"
+	v3, _ := loader.Version()
+	if v3 == v1 {
+		t.Error("version did not change with lastmod")
+	}
+}
+
+func TestRateLimiter_SpacesRequests(t *testing.T) {
+	limiter := newRateLimiter(50) // 20ms apart
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.wait(t.Context()); err != nil {
+			t.Fatalf("wait() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("3 requests took %v, want at least 40ms", elapsed)
+	}
+	if err := (*rateLimiter)(nil).wait(t.Context()); err != nil {
+		t.Errorf("nil limiter should not wait: %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package knowledge
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// VersionedLoader is a Loader that can cheaply report whether its content
+// changed, typically from file modification times. KnowledgeBase.Sync does not
+// call Load on a source whose version is the same as at the previous Sync.
+type VersionedLoader interface {
+	Loader
+
+	// Version returns a value that changes whenever Load would return
+	// different documents
+	Version() (string, error)
+}
+
+// SyncState records what a KnowledgeBase has ingested
+type SyncState struct {
+	// Sources is keyed by the position of the source in the knowledge base
+	Sources map[string]SourceState `json:"sources"`
+
+	// Documents is keyed by document ID
+	Documents map[string]DocumentState `json:"documents"`
+}
+
+// SourceState records the last synced version of a source
+type SourceState struct {
+	Version     string   `json:"version"`
+	DocumentIDs []string `json:"document_ids"`
+}
+
+// DocumentState records what Sync stored for a document
+type DocumentState struct {
+	Hash     string   `json:"hash"`
+	ChunkIDs []string `json:"chunk_ids"`
+}
+
+// NewSyncState creates an empty sync state
+func NewSyncState() *SyncState {
+	return &SyncState{
+		Sources:   make(map[string]SourceState),
+		Documents: make(map[string]DocumentState),
+	}
+}
+
+// StateStore persists sync state between processes
+type StateStore interface {
+	// LoadState returns the stored state, or nil when nothing was stored yet
+	LoadState(ctx context.Context) (*SyncState, error)
+
+	// SaveState replaces the stored state
+	SaveState(ctx context.Context, state *SyncState) error
+}
+
+// FileStateStore stores sync state as a JSON file
+type FileStateStore struct {
+	Path string
+}
+
+// NewFileStateStore creates a state store backed by the file at path
+func NewFileStateStore(path string) *FileStateStore {
+	return &FileStateStore{Path: path}
+}
+
+// LoadState reads the state file; a missing file yields nil
+func (s *FileStateStore) LoadState(ctx context.Context) (*SyncState, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync state %s: %w", s.Path, err)
+	}
+
+	state := NewSyncState()
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to decode sync state %s: %w", s.Path, err)
+	}
+	if state.Sources == nil {
+		state.Sources = make(map[string]SourceState)
+	}
+	if state.Documents == nil {
+		state.Documents = make(map[string]DocumentState)
+	}
+	return state, nil
+}
+
+// SaveState writes the state file through a temporary file, so a crash never
+// leaves a truncated state behind
+func (s *FileStateStore) SaveState(ctx context.Context, state *SyncState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode sync state: %w", err)
+	}
+
+	dir := filepath.Dir(s.Path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.Path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create sync state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.Path); err != nil {
+		return fmt.Errorf("failed to replace sync state %s: %w", s.Path, err)
+	}
+	return nil
+}
+
+// fileVersion derives a version from the path, size and modification time of a file
+func fileVersion(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	writeFileVersion(h, path, info)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// dirVersion derives a version from every file of a directory accepted by match,
+// so adding, removing or modifying a file changes it
+func dirVersion(dirPath string, recursive bool, match func(path string) bool) (string, error) {
+	h := sha256.New()
+	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if !recursive && path != dirPath {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if match(path) {
+			writeFileVersion(h, path, info)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func writeFileVersion(h io.Writer, path string, info os.FileInfo) {
+	fmt.Fprintf(h, "%s\x00%d\x00%d\n", path, info.Size(), info.ModTime().UnixNano())
+}
@@ -0,0 +1,521 @@
+package knowledge
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// XLSXLoader loads documents from Excel (.xlsx) workbooks
+type XLSXLoader struct {
+	FilePath   string
+	Sheets     []string // Sheet names to load (nil = all sheets, in workbook order)
+	Range      string   // A1-style cell range applied to every sheet, e.g. "A1:F200" (empty = whole sheet)
+	HeaderRows int      // Leading rows of the range used as column headers (0 = use column letters)
+	RowsPerDoc int      // Number of data rows per document (0 = one document per sheet)
+}
+
+// NewXLSXLoader creates a new XLSX loader
+func NewXLSXLoader(filePath string) *XLSXLoader {
+	return &XLSXLoader{
+		FilePath:   filePath,
+		HeaderRows: 1,
+		RowsPerDoc: 0, // One document per sheet by default
+	}
+}
+
+// Load loads an XLSX file
+func (l *XLSXLoader) Load() ([]Document, error) {
+	zr, err := zip.OpenReader(l.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open XLSX file %s: %w", l.FilePath, err)
+	}
+	defer zr.Close()
+
+	return l.loadFromZip(&zr.Reader, filepath.Base(l.FilePath), l.FilePath)
+}
+
+// Version reports the size and modification time of the file
+func (l *XLSXLoader) Version() (string, error) {
+	return fileVersion(l.FilePath)
+}
+
+func (l *XLSXLoader) loadFromZip(zr *zip.Reader, id, source string) ([]Document, error) {
+	bounds, err := parseCellRange(l.Range)
+	if err != nil {
+		return nil, err
+	}
+
+	wb, err := readWorkbook(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	sheets := wb.sheets
+	if len(l.Sheets) > 0 {
+		sheets = sheets[:0:0]
+		for _, name := range l.Sheets {
+			sheet, ok := wb.sheet(name)
+			if !ok {
+				return nil, fmt.Errorf("sheet %q not found in workbook", name)
+			}
+			sheets = append(sheets, sheet)
+		}
+	}
+
+	var documents []Document
+	for _, sheet := range sheets {
+		rows, err := wb.readSheet(sheet, bounds)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, l.sheetDocuments(id, source, sheet.name, bounds, rows)...)
+	}
+
+	if len(documents) == 0 {
+		return nil, fmt.Errorf("no data rows in XLSX")
+	}
+	return documents, nil
+}
+
+// sheetDocuments splits a sheet's rows into documents
+func (l *XLSXLoader) sheetDocuments(id, source, sheetName string, bounds cellRange, rows []xlsxRow) []Document {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	minCol, maxCol := bounds.minCol, bounds.maxCol
+	if maxCol == 0 {
+		minCol, maxCol = 1, 0
+		for _, row := range rows {
+			if n := len(row.cells); n > maxCol {
+				maxCol = n
+			}
+		}
+	}
+
+	headerRows := l.HeaderRows
+	if headerRows < 0 {
+		headerRows = 0
+	}
+	if headerRows > len(rows) {
+		headerRows = len(rows)
+	}
+	headers := make([]string, 0, maxCol-minCol+1)
+	for col := minCol; col <= maxCol; col++ {
+		var parts []string
+		for _, row := range rows[:headerRows] {
+			if v := row.cell(col); v != "" {
+				parts = append(parts, v)
+			}
+		}
+		if len(parts) == 0 {
+			headers = append(headers, columnName(col))
+		} else {
+			headers = append(headers, strings.Join(parts, " "))
+		}
+	}
+
+	dataRows := rows[headerRows:]
+	if len(dataRows) == 0 {
+		return nil
+	}
+
+	batch := l.RowsPerDoc
+	if batch <= 0 {
+		batch = len(dataRows)
+	}
+
+	var documents []Document
+	for i := 0; i < len(dataRows); i += batch {
+		end := i + batch
+		if end > len(dataRows) {
+			end = len(dataRows)
+		}
+		chunk := dataRows[i:end]
+		first, last := chunk[0].number, chunk[len(chunk)-1].number
+
+		var content strings.Builder
+		content.WriteString("Sheet: " + sheetName + "\n")
+		content.WriteString("Headers: " + strings.Join(headers, " | ") + "\n\n")
+		for _, row := range chunk {
+			values := make([]string, 0, len(headers))
+			for col := minCol; col <= maxCol; col++ {
+				values = append(values, row.cell(col))
+			}
+			content.WriteString(fmt.Sprintf("Row %d: %s\n", row.number, strings.Join(values, " | ")))
+		}
+
+		docID := fmt.Sprintf("%s_%s", id, sheetName)
+		if l.RowsPerDoc > 0 {
+			docID = fmt.Sprintf("%s_rows_%d_%d", docID, first, last)
+		}
+		documents = append(documents, Document{
+			ID:      docID,
+			Content: content.String(),
+			Source:  source,
+			Metadata: map[string]interface{}{
+				"filename":  id,
+				"path":      source,
+				"ext":       ".xlsx",
+				"file_type": "xlsx",
+				"sheet":     sheetName,
+				"range":     fmt.Sprintf("%s%d:%s%d", columnName(minCol), first, columnName(maxCol), last),
+				"row_start": first,
+				"row_end":   last,
+				"rows":      len(chunk),
+				"columns":   len(headers),
+				"headers":   headers,
+			},
+		})
+	}
+	return documents
+}
+
+// XLSXReaderLoader loads an XLSX workbook from an io.Reader
+type XLSXReaderLoader struct {
+	Reader     io.Reader
+	ID         string
+	Sheets     []string
+	Range      string
+	HeaderRows int
+	RowsPerDoc int
+	Metadata   map[string]interface{}
+}
+
+// NewXLSXReaderLoader creates a new XLSX reader loader
+func NewXLSXReaderLoader(reader io.Reader, id string, metadata map[string]interface{}) *XLSXReaderLoader {
+	return &XLSXReaderLoader{
+		Reader:     reader,
+		ID:         id,
+		HeaderRows: 1,
+		Metadata:   metadata,
+	}
+}
+
+// Load loads XLSX content from a reader. The workbook is buffered in memory.
+func (l *XLSXReaderLoader) Load() ([]Document, error) {
+	data, err := io.ReadAll(l.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read XLSX content: %w", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open XLSX content: %w", err)
+	}
+
+	loader := &XLSXLoader{
+		Sheets:     l.Sheets,
+		Range:      l.Range,
+		HeaderRows: l.HeaderRows,
+		RowsPerDoc: l.RowsPerDoc,
+	}
+	docs, err := loader.loadFromZip(zr, readerDocID(l.ID), "")
+	if err != nil {
+		return nil, err
+	}
+
+	// Add custom metadata
+	if l.Metadata != nil {
+		for i := range docs {
+			for k, v := range l.Metadata {
+				docs[i].Metadata[k] = v
+			}
+		}
+	}
+
+	return docs, nil
+}
+
+// cellRange is an inclusive, 1-based cell rectangle; zero values are unbounded
+type cellRange struct {
+	minCol, maxCol int
+	minRow, maxRow int
+}
+
+func (r cellRange) contains(col, row int) bool {
+	if r.maxRow > 0 && (row < r.minRow || row > r.maxRow) {
+		return false
+	}
+	if r.maxCol > 0 && (col < r.minCol || col > r.maxCol) {
+		return false
+	}
+	return true
+}
+
+// parseCellRange parses "A1:D20" (or a single cell "B3")
+func parseCellRange(s string) (cellRange, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return cellRange{}, nil
+	}
+	from, to, found := strings.Cut(s, ":")
+	if !found {
+		to = from
+	}
+	c1, r1, err1 := parseCellRef(from)
+	c2, r2, err2 := parseCellRef(to)
+	if err1 != nil || err2 != nil || r1 == 0 || r2 == 0 {
+		return cellRange{}, fmt.Errorf("invalid XLSX range %q", s)
+	}
+	if c1 > c2 {
+		c1, c2 = c2, c1
+	}
+	if r1 > r2 {
+		r1, r2 = r2, r1
+	}
+	return cellRange{minCol: c1, maxCol: c2, minRow: r1, maxRow: r2}, nil
+}
+
+// parseCellRef parses "AB12" into column 28, row 12. The row is 0 when absent.
+func parseCellRef(ref string) (col, row int, err error) {
+	ref = strings.ToUpper(strings.TrimSpace(strings.ReplaceAll(ref, "$", "")))
+	i := 0
+	for i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z' {
+		col = col*26 + int(ref[i]-'A'+1)
+		i++
+	}
+	if i == 0 {
+		return 0, 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	if i < len(ref) {
+		row, err = strconv.Atoi(ref[i:])
+		if err != nil || row <= 0 {
+			return 0, 0, fmt.Errorf("invalid cell reference %q", ref)
+		}
+	}
+	return col, row, nil
+}
+
+// columnName converts a 1-based column index to its letters (1 -> A, 28 -> AB)
+func columnName(col int) string {
+	var name []byte
+	for col > 0 {
+		col--
+		name = append([]byte{byte('A' + col%26)}, name...)
+		col /= 26
+	}
+	return string(name)
+}
+
+// --- workbook parsing (SpreadsheetML) ---
+
+type xlsxSheetRef struct {
+	name string
+	path string
+}
+
+type xlsxWorkbook struct {
+	zr      *zip.Reader
+	sheets  []xlsxSheetRef
+	strings []string
+}
+
+func (w *xlsxWorkbook) sheet(name string) (xlsxSheetRef, bool) {
+	for _, s := range w.sheets {
+		if s.name == name {
+			return s, true
+		}
+	}
+	return xlsxSheetRef{}, false
+}
+
+type xlsxRow struct {
+	number int
+	cells  []string // index 0 = column A
+}
+
+func (r xlsxRow) cell(col int) string {
+	if col < 1 || col > len(r.cells) {
+		return ""
+	}
+	return r.cells[col-1]
+}
+
+func readWorkbook(zr *zip.Reader) (*xlsxWorkbook, error) {
+	var wbXML struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodeZipXML(zr, "xl/workbook.xml", &wbXML); err != nil {
+		return nil, err
+	}
+
+	var relsXML struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodeZipXML(zr, "xl/_rels/workbook.xml.rels", &relsXML); err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string, len(relsXML.Relationships))
+	for _, rel := range relsXML.Relationships {
+		target := rel.Target
+		if strings.HasPrefix(target, "/") {
+			target = strings.TrimPrefix(target, "/")
+		} else {
+			target = path.Join("xl", target)
+		}
+		targets[rel.ID] = target
+	}
+
+	wb := &xlsxWorkbook{zr: zr}
+	for _, s := range wbXML.Sheets {
+		target, ok := targets[s.RID]
+		if !ok {
+			return nil, fmt.Errorf("sheet %q has no worksheet part", s.Name)
+		}
+		wb.sheets = append(wb.sheets, xlsxSheetRef{name: s.Name, path: target})
+	}
+
+	if findZipFile(zr, "xl/sharedStrings.xml") != nil {
+		var sst struct {
+			Items []struct {
+				T    string `xml:"t"`
+				Runs []struct {
+					T string `xml:"t"`
+				} `xml:"r"`
+			} `xml:"si"`
+		}
+		if err := decodeZipXML(zr, "xl/sharedStrings.xml", &sst); err != nil {
+			return nil, err
+		}
+		wb.strings = make([]string, len(sst.Items))
+		for i, item := range sst.Items {
+			if len(item.Runs) == 0 {
+				wb.strings[i] = item.T
+				continue
+			}
+			var sb strings.Builder
+			for _, r := range item.Runs {
+				sb.WriteString(r.T)
+			}
+			wb.strings[i] = sb.String()
+		}
+	}
+
+	return wb, nil
+}
+
+// readSheet returns the non-empty rows of a sheet within bounds
+func (w *xlsxWorkbook) readSheet(sheet xlsxSheetRef, bounds cellRange) ([]xlsxRow, error) {
+	var wsXML struct {
+		Rows []struct {
+			R     int `xml:"r,attr"`
+			Cells []struct {
+				R  string `xml:"r,attr"`
+				T  string `xml:"t,attr"`
+				V  string `xml:"v"`
+				IS struct {
+					T    string `xml:"t"`
+					Runs []struct {
+						T string `xml:"t"`
+					} `xml:"r"`
+				} `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := decodeZipXML(w.zr, sheet.path, &wsXML); err != nil {
+		return nil, fmt.Errorf("sheet %q: %w", sheet.name, err)
+	}
+
+	var rows []xlsxRow
+	prevRow := 0
+	for _, rowXML := range wsXML.Rows {
+		rowNum := rowXML.R
+		if rowNum == 0 {
+			rowNum = prevRow + 1
+		}
+		prevRow = rowNum
+
+		row := xlsxRow{number: rowNum}
+		nonEmpty := false
+		prevCol := 0
+		for _, c := range rowXML.Cells {
+			col := prevCol + 1
+			if c.R != "" {
+				parsed, _, err := parseCellRef(c.R)
+				if err != nil {
+					return nil, fmt.Errorf("sheet %q: %w", sheet.name, err)
+				}
+				col = parsed
+			}
+			prevCol = col
+			if !bounds.contains(col, rowNum) {
+				continue
+			}
+
+			var value string
+			switch c.T {
+			case "s":
+				idx, err := strconv.Atoi(strings.TrimSpace(c.V))
+				if err != nil || idx < 0 || idx >= len(w.strings) {
+					return nil, fmt.Errorf("sheet %q: invalid shared string index in %s", sheet.name, c.R)
+				}
+				value = w.strings[idx]
+			case "inlineStr":
+				value = c.IS.T
+				for _, r := range c.IS.Runs {
+					value += r.T
+				}
+			case "b":
+				if strings.TrimSpace(c.V) == "1" {
+					value = "TRUE"
+				} else {
+					value = "FALSE"
+				}
+			default:
+				value = c.V
+			}
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+
+			for len(row.cells) < col {
+				row.cells = append(row.cells, "")
+			}
+			row.cells[col-1] = value
+			nonEmpty = true
+		}
+		if nonEmpty {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func findZipFile(zr *zip.Reader, name string) *zip.File {
+	for _, f := range zr.File {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func decodeZipXML(zr *zip.Reader, name string, v interface{}) error {
+	f := findZipFile(zr, name)
+	if f == nil {
+		return fmt.Errorf("invalid XLSX: missing %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package knowledge
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestXLSX builds a minimal two-sheet workbook using shared and inline strings.
+func writeTestXLSX(t *testing.T) []byte {
+	t.Helper()
+	files := map[string]string{
+		"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+  <sheets>
+    <sheet name="Sales" sheetId="1" r:id="rId1"/>
+    <sheet name="Notes" sheetId="2" r:id="rId2"/>
+  </sheets>
+</workbook>`,
+		"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="worksheet" Target="worksheets/sheet1.xml"/>
+  <Relationship Id="rId2" Type="worksheet" Target="/xl/worksheets/sheet2.xml"/>
+</Relationships>`,
+		"xl/sharedStrings.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <si><t>Region</t></si>
+  <si><t>Revenue</t></si>
+  <si><r><t>No</t></r><r><t>rth</t></r></si>
+  <si><t>South</t></si>
+</sst>`,
+		"xl/worksheets/sheet1.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>
+    <row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="inlineStr"><is><t>Active</t></is></c></row>
+    <row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2"><v>1200.5</v></c><c r="C2" t="b"><v>1</v></c></row>
+    <row r="3"><c r="A3" t="s"><v>3</v></c><c r="B3"><v>800</v></c><c r="C3" t="b"><v>0</v></c></row>
+    <row r="5"><c r="B5"><v>2000.5</v></c></row>
+  </sheetData>
+</worksheet>`,
+		"xl/worksheets/sheet2.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>
+    <row r="1"><c r="A1" t="inlineStr"><is><t>Note</t></is></c></row>
+    <row r="2"><c r="A2" t="inlineStr"><is><t>Q3 closed early</t></is></c></row>
+  </sheetData>
+</worksheet>`,
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestXLSXLoader_OneDocumentPerSheet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.xlsx")
+	if err := os.WriteFile(path, writeTestXLSX(t), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	docs, err := NewXLSXLoader(path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("expected 2 documents, got %d", len(docs))
+	}
+
+	sales := docs[0]
+	if sales.ID != "report.xlsx_Sales" || sales.Metadata["sheet"] != "Sales" {
+		t.Fatalf("unexpected first document %s %v", sales.ID, sales.Metadata)
+	}
+	for _, want := range []string{"Headers: Region | Revenue | Active", "Row 2: North | 1200.5 | TRUE", "Row 3: South | 800 | FALSE", "Row 5:  | 2000.5 | "} {
+		if !strings.Contains(sales.Content, want) {
+			t.Errorf("content missing %q:\n%s", want, sales.Content)
+		}
+	}
+	if sales.Metadata["range"] != "A2:C5" || sales.Metadata["rows"] != 3 {
+		t.Errorf("unexpected metadata %v", sales.Metadata)
+	}
+	if !strings.Contains(docs[1].Content, "Row 2: Q3 closed early") {
+		t.Errorf("unexpected notes content:\n%s", docs[1].Content)
+	}
+}
+
+func TestXLSXLoader_SheetRangeAndBatches(t *testing.T) {
+	loader := NewXLSXReaderLoader(bytes.NewReader(writeTestXLSX(t)), "report", map[string]interface{}{"team": "finance"})
+	loader.Sheets = []string{"Sales"}
+	loader.Range = "A1:B3"
+	loader.RowsPerDoc = 1
+
+	docs, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("expected 2 documents, got %d", len(docs))
+	}
+	if docs[0].ID != "report_Sales_rows_2_2" || docs[1].Metadata["range"] != "A3:B3" {
+		t.Fatalf("unexpected documents %s %v", docs[0].ID, docs[1].Metadata)
+	}
+	if strings.Contains(docs[0].Content, "TRUE") || !strings.Contains(docs[0].Content, "Headers: Region | Revenue") {
+		t.Errorf("range not applied:\n%s", docs[0].Content)
+	}
+	if docs[0].Metadata["team"] != "finance" {
+		t.Errorf("custom metadata missing: %v", docs[0].Metadata)
+	}
+
+	loader = NewXLSXReaderLoader(bytes.NewReader(writeTestXLSX(t)), "report", nil)
+	loader.Sheets = []string{"Missing"}
+	if _, err := loader.Load(); err == nil {
+		t.Fatal("expected error for unknown sheet")
+	}
+}
+
+func TestXLSXLoader_NoHeaderUsesColumnLetters(t *testing.T) {
+	loader := NewXLSXReaderLoader(bytes.NewReader(writeTestXLSX(t)), "report", nil)
+	loader.Sheets = []string{"Sales"}
+	loader.Range = "B1:C2"
+	loader.HeaderRows = 0
+
+	docs, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !strings.Contains(docs[0].Content, "Headers: B | C") || !strings.Contains(docs[0].Content, "Row 1: Revenue | Active") {
+		t.Errorf("unexpected content:\n%s", docs[0].Content)
+	}
+
+	if _, err := parseCellRange("1A:B2"); err == nil {
+		t.Error("expected invalid range error")
+	}
+	if got := columnName(28); got != "AB" {
+		t.Errorf("columnName(28) = %q", got)
+	}
+}
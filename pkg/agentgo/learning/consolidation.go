@@ -0,0 +1,267 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/embeddings"
+	"github.com/jholhewres/agent-go/pkg/agentgo/ids"
+	"github.com/jholhewres/agent-go/pkg/agentgo/pagination"
+)
+
+// ErrConsolidationNotSupported is returned by Consolidate when the storage
+// does not implement MemoryEditor
+var ErrConsolidationNotSupported = errors.New("learning storage does not support consolidation")
+
+// ConsolidationConfig configures Machine.Consolidate
+type ConsolidationConfig struct {
+	// Embedder finds memories that say the same thing in different words.
+	// Without it only memories with the same text are merged.
+	Embedder embeddings.Embedder
+
+	// SimilarityThreshold is the cosine similarity above which two memories
+	// of the same type are duplicates (default: 0.9)
+	SimilarityThreshold float64
+
+	// MaxMemories caps the memories kept per user, dropping the oldest
+	// (default: no cap)
+	MaxMemories int
+
+	// Window is the number of latest memories consolidated when the storage
+	// does not implement Lister (default: 1000)
+	Window int
+}
+
+// ConsolidationResult reports what Consolidate changed for a user
+type ConsolidationResult struct {
+	UserID   string `json:"user_id"`
+	Memories int    `json:"memories"` // Memories before consolidation
+	Merged   int    `json:"merged"`   // Duplicates merged into a newer memory
+	Resolved int    `json:"resolved"` // Memories superseded by a newer one with the same dedup key
+	Trimmed  int    `json:"trimmed"`  // Oldest memories dropped by MaxMemories
+}
+
+// Removed returns the number of memories deleted
+func (r ConsolidationResult) Removed() int {
+	return r.Merged + r.Resolved + r.Trimmed
+}
+
+// SetConsolidation configures Consolidate and RunConsolidation
+func (m *Machine) SetConsolidation(config ConsolidationConfig) {
+	if config.SimilarityThreshold <= 0 {
+		config.SimilarityThreshold = 0.9
+	}
+	if config.Window <= 0 {
+		config.Window = 1000
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.consolidation = config
+}
+
+// Consolidate cleans up a user's memories. Walking from the newest memory, it
+// deletes older memories with the same dedup key (the newest wins a
+// contradiction), merges duplicates into the newest copy and then drops the
+// oldest memories above MaxMemories.
+func (m *Machine) Consolidate(ctx context.Context, userID string) (*ConsolidationResult, error) {
+	editor, ok := m.storage.(MemoryEditor)
+	if !ok {
+		return nil, ErrConsolidationNotSupported
+	}
+	m.mu.Lock()
+	config := m.consolidation
+	m.mu.Unlock()
+
+	memories, err := m.consolidationMemories(ctx, userID, config.Window)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load memories: %w", err)
+	}
+	result := &ConsolidationResult{UserID: userID, Memories: len(memories)}
+	if len(memories) == 0 {
+		return result, nil
+	}
+
+	var vectors [][]float32
+	if config.Embedder != nil {
+		texts := make([]string, len(memories))
+		for i, memory := range memories {
+			texts[i] = memory.Content
+		}
+		if vectors, err = config.Embedder.Embed(ctx, texts); err != nil {
+			return nil, fmt.Errorf("failed to embed memories: %w", err)
+		}
+	}
+
+	var kept []int
+	var removed []UserMemory
+	merged := make(map[int]int) // kept index -> memories merged into it
+	keys := make(map[string]bool)
+	for i, memory := range memories {
+		if key := dedupKey(memory); key != "" {
+			if keys[key] {
+				removed = append(removed, memory)
+				result.Resolved++
+				continue
+			}
+			keys[key] = true
+		}
+
+		duplicate := -1
+		for _, k := range kept {
+			if memories[k].Type != memory.Type {
+				continue
+			}
+			if normalizeContent(memories[k].Content) == normalizeContent(memory.Content) ||
+				(vectors != nil && cosineSimilarity(vectors[k], vectors[i]) >= config.SimilarityThreshold) {
+				duplicate = k
+				break
+			}
+		}
+		if duplicate >= 0 {
+			removed = append(removed, memory)
+			merged[duplicate]++
+			result.Merged++
+			continue
+		}
+		kept = append(kept, i)
+	}
+
+	if config.MaxMemories > 0 && len(kept) > config.MaxMemories {
+		for _, k := range kept[config.MaxMemories:] {
+			removed = append(removed, memories[k])
+			delete(merged, k)
+			result.Trimmed++
+		}
+	}
+
+	for _, memory := range removed {
+		if err := editor.DeleteUserMemory(ctx, userID, memory.ID); err != nil && !errors.Is(err, ErrMemoryNotFound) {
+			return nil, fmt.Errorf("failed to delete memory: %w", err)
+		}
+	}
+	for k, count := range merged {
+		memory := memories[k]
+		metadata := make(map[string]interface{}, len(memory.Metadata)+2)
+		for key, value := range memory.Metadata {
+			metadata[key] = value
+		}
+		previous, _ := metadata["merged_count"].(float64)
+		metadata["merged_count"] = previous + float64(count)
+		metadata["consolidated_at"] = time.Now()
+		memory.Metadata = metadata
+		if err := editor.UpdateUserMemory(ctx, &memory); err != nil && !errors.Is(err, ErrMemoryNotFound) {
+			return nil, fmt.Errorf("failed to update memory: %w", err)
+		}
+	}
+
+	if result.Removed() > 0 {
+		event := &LearningEvent{
+			ID:        ids.New(),
+			UserID:    userID,
+			EventType: "consolidation",
+			Data: map[string]interface{}{
+				"memories_count": result.Memories,
+				"merged":         result.Merged,
+				"resolved":       result.Resolved,
+				"trimmed":        result.Trimmed,
+			},
+			OccurredAt: time.Now(),
+		}
+		if err := m.storage.SaveLearningEvent(ctx, event); err != nil {
+			// Log but don't fail
+			fmt.Printf("Warning: failed to save learning event: %v\n", err)
+		}
+	}
+	return result, nil
+}
+
+// RunConsolidation consolidates the memories of the users Learn saved memories
+// for since the previous pass, every interval until ctx is cancelled
+func (m *Machine) RunConsolidation(ctx context.Context, interval time.Duration) {
+	m.mu.Lock()
+	if m.pending == nil {
+		m.pending = make(map[string]struct{})
+	}
+	m.mu.Unlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.mu.Lock()
+			users := m.pending
+			m.pending = make(map[string]struct{})
+			m.mu.Unlock()
+
+			for userID := range users {
+				if _, err := m.Consolidate(ctx, userID); err != nil {
+					fmt.Printf("Warning: failed to consolidate memories of %s: %v\n", userID, err)
+				}
+			}
+		}
+	}
+}
+
+// markPending queues a user for the next RunConsolidation pass
+func (m *Machine) markPending(userID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.pending != nil {
+		m.pending[userID] = struct{}{}
+	}
+}
+
+// consolidationMemories returns a user's memories, newest first: all of them
+// when the storage is a Lister, else the latest window
+func (m *Machine) consolidationMemories(ctx context.Context, userID string, window int) ([]UserMemory, error) {
+	lister, ok := m.storage.(Lister)
+	if !ok {
+		return m.storage.GetUserMemories(ctx, userID, window)
+	}
+
+	var memories []UserMemory
+	opts := pagination.ListOptions{Limit: pagination.MaxLimit}
+	for {
+		page, err := lister.ListUserMemories(ctx, userID, opts)
+		if err != nil {
+			return nil, err
+		}
+		memories = append(memories, page.Items...)
+		if page.NextCursor == "" {
+			return memories, nil
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
+
+// normalizeContent lowercases text and collapses whitespace and trailing
+// punctuation, so trivially different copies compare equal
+func normalizeContent(s string) string {
+	return strings.TrimRight(strings.Join(strings.Fields(strings.ToLower(s)), " "), ".!")
+}
+
+// cosineSimilarity returns the cosine similarity of two vectors, or 0 when
+// either is empty or their lengths differ
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
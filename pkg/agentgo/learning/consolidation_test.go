@@ -0,0 +1,146 @@
+package learning
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// topicEmbedder maps texts to fixed vectors
+type topicEmbedder map[string][]float32
+
+func (e topicEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vector, ok := e[text]
+		if !ok {
+			return nil, fmt.Errorf("no vector for %q", text)
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+func (e topicEmbedder) EmbedSingle(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := e.Embed(ctx, []string{text})
+	return vectors[0], err
+}
+
+// seed saves memories for ada, the first one oldest
+func seed(storage *memoryStorage, memories ...UserMemory) {
+	base := time.Now().Add(-time.Hour)
+	for i, memory := range memories {
+		memory.ID = fmt.Sprintf("m%d", i)
+		memory.UserID = "ada"
+		memory.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		if memory.Type == "" {
+			memory.Type = MemoryTypeFact
+		}
+		storage.memories = append(storage.memories, memory)
+	}
+}
+
+func TestMachine_Consolidate(t *testing.T) {
+	storage := newMemoryStorage()
+	seed(storage,
+		UserMemory{Content: "Works at Acme", Metadata: map[string]interface{}{MetadataDedupKey: "employer"}},
+		UserMemory{Content: "Likes hiking"},
+		UserMemory{Content: "Enjoys going on hikes"},
+		UserMemory{Content: "likes  hiking."},
+		UserMemory{Content: "Works at Globex", Metadata: map[string]interface{}{MetadataDedupKey: "employer"}},
+		UserMemory{Content: "Enjoys going on hikes", Type: MemoryTypePreference},
+	)
+	machine, _ := NewMachine(storage)
+	machine.SetConsolidation(ConsolidationConfig{Embedder: topicEmbedder{
+		"Works at Acme":         {0, 0, 1},
+		"Works at Globex":       {0, 0.2, 1},
+		"Likes hiking":          {1, 0.1, 0},
+		"likes  hiking.":        {1, 0.1, 0},
+		"Enjoys going on hikes": {1, 0.05, 0},
+	}})
+
+	result, err := machine.Consolidate(context.Background(), "ada")
+	if err != nil {
+		t.Fatalf("Consolidate() error = %v", err)
+	}
+	if result.Memories != 6 || result.Resolved != 1 || result.Merged != 2 || result.Trimmed != 0 {
+		t.Fatalf("result = %+v", result)
+	}
+
+	// The newest of each group survives; types are never merged
+	var got []string
+	for _, m := range storage.memories {
+		got = append(got, m.ID)
+	}
+	if fmt.Sprint(got) != "[m3 m4 m5]" {
+		t.Fatalf("remaining memories = %v, want [m3 m4 m5]", got)
+	}
+	if merged := storage.memories[0].Metadata["merged_count"]; merged != float64(2) {
+		t.Fatalf("merged_count = %v, want 2", merged)
+	}
+	if len(storage.events) != 1 || storage.events[0].EventType != "consolidation" {
+		t.Fatalf("events = %+v", storage.events)
+	}
+}
+
+func TestMachine_ConsolidateCapsMemories(t *testing.T) {
+	storage := newMemoryStorage()
+	seed(storage, UserMemory{Content: "a"}, UserMemory{Content: "b"}, UserMemory{Content: "c"}, UserMemory{Content: "c"})
+	machine, _ := NewMachine(storage)
+	machine.SetConsolidation(ConsolidationConfig{MaxMemories: 1})
+
+	result, err := machine.Consolidate(context.Background(), "ada")
+	if err != nil {
+		t.Fatalf("Consolidate() error = %v", err)
+	}
+	if result.Merged != 1 || result.Trimmed != 2 || len(storage.memories) != 1 || storage.memories[0].ID != "m3" {
+		t.Fatalf("result = %+v, memories = %+v", result, storage.memories)
+	}
+}
+
+func TestMachine_RunConsolidation(t *testing.T) {
+	storage := newMemoryStorage()
+	machine, _ := NewMachine(storage)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		machine.RunConsolidation(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// Wait for the loop to start tracking users
+	for deadline := time.Now().Add(time.Second); ; {
+		machine.mu.Lock()
+		started := machine.pending != nil
+		machine.mu.Unlock()
+		if started || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// The heuristic extractor saves the same long message twice: once as a
+	// preference and once as context
+	long := "I prefer detailed explanations with examples whenever we talk about code."
+	for i := 0; i < 2; i++ {
+		if err := machine.Learn(ctx, "ada", []types.Message{*types.NewUserMessage(long)}); err != nil {
+			t.Fatalf("Learn() error = %v", err)
+		}
+	}
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		storage.mu.Lock()
+		n := len(storage.memories)
+		storage.mu.Unlock()
+		if n == 2 {
+			return
+		}
+	}
+	t.Fatalf("expected the duplicates to be consolidated, got %+v", storage.memories)
+}
@@ -1,23 +1,34 @@
 package learning
 
 import (
+	"context"
 	"strings"
 	"time"
 
-	"github.com/google/uuid"
+	"github.com/jholhewres/agent-go/pkg/agentgo/ids"
 	"github.com/jholhewres/agent-go/pkg/agentgo/types"
 )
 
-// Extractor extracts learning data from messages
-type Extractor struct {
-	// Future: could use LLM to extract structured information
+// MemoryExtractor turns a conversation into memories about the user.
+// Machine.SetMemoryExtractor replaces the keyword-based Extractor with it, e.g.
+// with an LLMExtractor.
+type MemoryExtractor interface {
+	Extract(ctx context.Context, userID string, messages []types.Message) ([]UserMemory, error)
 }
 
+// Extractor extracts learning data from messages with keyword heuristics
+type Extractor struct{}
+
 // NewExtractor creates a new extractor
 func NewExtractor() *Extractor {
 	return &Extractor{}
 }
 
+// Extract implements MemoryExtractor with ExtractMemories
+func (e *Extractor) Extract(ctx context.Context, userID string, messages []types.Message) ([]UserMemory, error) {
+	return e.ExtractMemories(userID, messages), nil
+}
+
 // ExtractMemories extracts memories from messages
 func (e *Extractor) ExtractMemories(userID string, messages []types.Message) []UserMemory {
 	var memories []UserMemory
@@ -35,7 +46,7 @@ func (e *Extractor) ExtractMemories(userID string, messages []types.Message) []U
 		// Extract preferences (simple heuristic-based extraction)
 		if e.containsPreference(content) {
 			memories = append(memories, UserMemory{
-				ID:        uuid.New().String(),
+				ID:        ids.New(),
 				UserID:    userID,
 				Content:   content,
 				Type:      MemoryTypePreference,
@@ -47,7 +58,7 @@ func (e *Extractor) ExtractMemories(userID string, messages []types.Message) []U
 		// Extract factual information
 		if e.containsFact(content) {
 			memories = append(memories, UserMemory{
-				ID:        uuid.New().String(),
+				ID:        ids.New(),
 				UserID:    userID,
 				Content:   content,
 				Type:      MemoryTypeFact,
@@ -59,7 +70,7 @@ func (e *Extractor) ExtractMemories(userID string, messages []types.Message) []U
 		// Store as context if it's substantial
 		if len(content) > 50 {
 			memories = append(memories, UserMemory{
-				ID:        uuid.New().String(),
+				ID:        ids.New(),
 				UserID:    userID,
 				Content:   content,
 				Type:      MemoryTypeContext,
@@ -90,7 +101,7 @@ func (e *Extractor) ExtractKnowledge(messages []types.Message) []Knowledge {
 		if e.containsKnowledge(content) {
 			topic := e.extractTopic(content)
 			knowledge = append(knowledge, Knowledge{
-				ID:        uuid.New().String(),
+				ID:        ids.New(),
 				Topic:     topic,
 				Content:   content,
 				Source:    "extracted",
@@ -2,8 +2,10 @@ package learning
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"github.com/jholhewres/agent-go/pkg/agentgo/pagination"
 	"github.com/jholhewres/agent-go/pkg/agentgo/types"
 )
 
@@ -85,6 +87,9 @@ type Storage interface {
 	SaveUserMemory(ctx context.Context, memory *UserMemory) error
 	GetUserMemories(ctx context.Context, userID string, limit int) ([]UserMemory, error)
 	DeleteUserMemories(ctx context.Context, userID string) error
+	// SearchMemories returns up to limit of the user's memories most relevant
+	// to query, best first
+	SearchMemories(ctx context.Context, userID, query string, limit int) ([]ScoredMemory, error)
 
 	// Learned Knowledge
 	SaveKnowledge(ctx context.Context, knowledge *Knowledge) error
@@ -101,3 +106,44 @@ type Storage interface {
 	// Close closes the storage connection
 	Close() error
 }
+
+// Lister is implemented by storages that support cursor-based listing. Unlike
+// the Get methods, which only return the latest N records, it pages through
+// all of them with filters and either sort order.
+type Lister interface {
+	// ListUserMemories pages through a user's memories by created_at.
+	// Filters: "type".
+	ListUserMemories(ctx context.Context, userID string, opts pagination.ListOptions) (pagination.Page[UserMemory], error)
+
+	// ListKnowledge pages through learned knowledge by created_at.
+	// Filters: "topic", "source".
+	ListKnowledge(ctx context.Context, opts pagination.ListOptions) (pagination.Page[Knowledge], error)
+
+	// ListLearningEvents pages through a user's learning events by occurred_at.
+	// Filters: "event_type".
+	ListLearningEvents(ctx context.Context, userID string, opts pagination.ListOptions) (pagination.Page[LearningEvent], error)
+}
+
+// ErrMemoryNotFound is returned by MemoryEditor methods when the user has no
+// memory with the given ID
+var ErrMemoryNotFound = errors.New("memory not found")
+
+// MemoryEditor is implemented by storages that can change or remove a single
+// memory, e.g. so the model can curate memories with tools/memorytool.
+type MemoryEditor interface {
+	// UpdateUserMemory replaces the content, type and metadata of the memory
+	// with memory.ID owned by memory.UserID.
+	UpdateUserMemory(ctx context.Context, memory *UserMemory) error
+
+	// DeleteUserMemory deletes one memory of a user.
+	DeleteUserMemory(ctx context.Context, userID, memoryID string) error
+}
+
+// MemoryKey returns the pagination key of a memory.
+func MemoryKey(m UserMemory) (time.Time, string) { return m.CreatedAt, m.ID }
+
+// KnowledgeKey returns the pagination key of a knowledge entry.
+func KnowledgeKey(k Knowledge) (time.Time, string) { return k.CreatedAt, k.ID }
+
+// EventKey returns the pagination key of a learning event.
+func EventKey(e LearningEvent) (time.Time, string) { return e.OccurredAt, e.ID }
@@ -0,0 +1,193 @@
+package learning
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/ids"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/structured"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// Metadata keys set on memories extracted by an LLMExtractor
+const (
+	// MetadataDedupKey holds a short stable key naming what a memory is about,
+	// e.g. "employer". Machine.Learn updates the user's memory with the same
+	// key instead of saving a duplicate.
+	MetadataDedupKey = "dedup_key"
+
+	// MetadataConfidence holds how sure the model is of a memory (0-1)
+	MetadataConfidence = "confidence"
+)
+
+const defaultExtractionPrompt = `You extract long-term memories about the user from a conversation.
+Only keep information that will still be useful in future conversations: facts about the user (name, job, location, family), preferences, ongoing context (projects, plans) and skills.
+Ignore small talk, one-off requests and anything the assistant said that the user did not confirm.
+For each memory return:
+- type: one of fact, preference, context, skill
+- content: a short self-contained sentence in the third person, e.g. "Works as a nurse in Lisbon"
+- confidence: how sure you are the memory is correct and lasting, from 0 to 1
+- dedup_key: a short snake_case key naming what the memory is about, e.g. "employer" or "favorite_language", so a later memory about the same thing replaces it
+Return an empty list when there is nothing worth remembering.`
+
+// LLMExtractorConfig configures an LLMExtractor
+type LLMExtractorConfig struct {
+	// Prompt replaces the default extraction instructions
+	Prompt string
+
+	// MinConfidence drops memories the model is less sure of (default: 0.5)
+	MinConfidence float64
+
+	// MaxMemories caps the memories kept per conversation (default: 10)
+	MaxMemories int
+
+	// Fallback extracts the memories when the model call fails or returns
+	// invalid output. The error is returned when nil.
+	Fallback MemoryExtractor
+}
+
+// LLMExtractor extracts memories with a structured-output model call
+type LLMExtractor struct {
+	model  models.Model
+	config LLMExtractorConfig
+}
+
+// extraction is the structured output the model returns
+type extraction struct {
+	Memories []extractedMemory `json:"memories"`
+}
+
+type extractedMemory struct {
+	Type       string  `json:"type"`
+	Content    string  `json:"content"`
+	Confidence float64 `json:"confidence"`
+	DedupKey   string  `json:"dedup_key"`
+}
+
+var extractionFormat = func() *models.ResponseFormat {
+	schema, err := structured.SchemaFromType(extraction{})
+	if err != nil {
+		panic(err)
+	}
+	schema.Name = "memory_extraction"
+	return schema.ToResponseFormat()
+}()
+
+// NewLLMExtractor creates an extractor backed by model
+func NewLLMExtractor(model models.Model, config LLMExtractorConfig) (*LLMExtractor, error) {
+	if model == nil {
+		return nil, fmt.Errorf("model is required")
+	}
+	if config.Prompt == "" {
+		config.Prompt = defaultExtractionPrompt
+	}
+	if config.MinConfidence <= 0 {
+		config.MinConfidence = 0.5
+	}
+	if config.MaxMemories <= 0 {
+		config.MaxMemories = 10
+	}
+	return &LLMExtractor{model: model, config: config}, nil
+}
+
+// Extract implements MemoryExtractor
+func (e *LLMExtractor) Extract(ctx context.Context, userID string, messages []types.Message) ([]UserMemory, error) {
+	transcript := formatTranscript(messages)
+	if transcript == "" {
+		return nil, nil
+	}
+
+	memories, err := e.extract(ctx, userID, transcript)
+	if err != nil && e.config.Fallback != nil {
+		return e.config.Fallback.Extract(ctx, userID, messages)
+	}
+	return memories, err
+}
+
+func (e *LLMExtractor) extract(ctx context.Context, userID, transcript string) ([]UserMemory, error) {
+	resp, err := e.model.Invoke(ctx, &models.InvokeRequest{
+		Messages: []*types.Message{
+			types.NewSystemMessage(e.config.Prompt),
+			types.NewUserMessage(transcript),
+		},
+		ResponseFormat: extractionFormat,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("memory extraction failed: %w", err)
+	}
+
+	var out extraction
+	if err := structured.ParseResponse(resp, &out); err != nil {
+		return nil, fmt.Errorf("memory extraction failed: %w", err)
+	}
+
+	now := time.Now()
+	var memories []UserMemory
+	for _, m := range out.Memories {
+		content := strings.TrimSpace(m.Content)
+		if content == "" || m.Confidence < e.config.MinConfidence {
+			continue
+		}
+		metadata := map[string]interface{}{
+			"extracted_at":     now,
+			"extractor":        "llm",
+			MetadataConfidence: m.Confidence,
+		}
+		if key := normalizeDedupKey(m.DedupKey); key != "" {
+			metadata[MetadataDedupKey] = key
+		}
+		memories = append(memories, UserMemory{
+			ID:        ids.New(),
+			UserID:    userID,
+			Content:   content,
+			Type:      memoryType(m.Type),
+			Metadata:  metadata,
+			CreatedAt: now,
+		})
+		if len(memories) == e.config.MaxMemories {
+			break
+		}
+	}
+	return memories, nil
+}
+
+// formatTranscript renders the user and assistant turns of a conversation
+func formatTranscript(messages []types.Message) string {
+	var b strings.Builder
+	for _, msg := range messages {
+		if msg.Role != types.RoleUser && msg.Role != types.RoleAssistant {
+			continue
+		}
+		content := strings.TrimSpace(msg.Content)
+		if content == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "%s: %s\n", msg.Role, content)
+	}
+	return b.String()
+}
+
+// memoryType maps the model's type to a MemoryType, defaulting to a fact
+func memoryType(s string) MemoryType {
+	switch t := MemoryType(strings.ToLower(strings.TrimSpace(s))); t {
+	case MemoryTypeFact, MemoryTypePreference, MemoryTypeContext, MemoryTypeSkill:
+		return t
+	}
+	return MemoryTypeFact
+}
+
+// normalizeDedupKey lowercases a key and joins its words with underscores
+func normalizeDedupKey(key string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(key), func(r rune) bool {
+		return r == ' ' || r == '_' || r == '-' || r == '.'
+	}), "_")
+}
+
+// dedupKey returns the dedup key of a memory, if any
+func dedupKey(m UserMemory) string {
+	key, _ := m.Metadata[MetadataDedupKey].(string)
+	return key
+}
@@ -0,0 +1,210 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// jsonModel answers every call with content, or fails with err
+type jsonModel struct {
+	models.BaseModel
+	content string
+	err     error
+	req     *models.InvokeRequest
+}
+
+func (m *jsonModel) Invoke(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+	m.req = req
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &types.ModelResponse{Content: m.content}, nil
+}
+
+func (m *jsonModel) InvokeStream(ctx context.Context, req *models.InvokeRequest) (<-chan types.ResponseChunk, error) {
+	return nil, errors.New("not supported")
+}
+
+// memoryStorage keeps profiles and memories in memory. The embedded Storage
+// is nil, so only the methods below may be called.
+type memoryStorage struct {
+	Storage
+	mu       sync.Mutex
+	profiles map[string]*UserProfile
+	memories []UserMemory
+	events   []LearningEvent
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{profiles: map[string]*UserProfile{}}
+}
+
+func (s *memoryStorage) SaveUserProfile(ctx context.Context, profile *UserProfile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.profiles[profile.UserID] = profile
+	return nil
+}
+
+func (s *memoryStorage) GetUserProfile(ctx context.Context, userID string) (*UserProfile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if profile, ok := s.profiles[userID]; ok {
+		return profile, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (s *memoryStorage) SaveUserMemory(ctx context.Context, memory *UserMemory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.memories = append(s.memories, *memory)
+	return nil
+}
+
+func (s *memoryStorage) GetUserMemories(ctx context.Context, userID string, limit int) ([]UserMemory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var memories []UserMemory
+	for i := len(s.memories) - 1; i >= 0 && len(memories) < limit; i-- {
+		if s.memories[i].UserID == userID {
+			memories = append(memories, s.memories[i])
+		}
+	}
+	return memories, nil
+}
+
+func (s *memoryStorage) UpdateUserMemory(ctx context.Context, memory *UserMemory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, m := range s.memories {
+		if m.ID == memory.ID && m.UserID == memory.UserID {
+			s.memories[i] = *memory
+			return nil
+		}
+	}
+	return ErrMemoryNotFound
+}
+
+func (s *memoryStorage) DeleteUserMemory(ctx context.Context, userID, memoryID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, m := range s.memories {
+		if m.ID == memoryID && m.UserID == userID {
+			s.memories = append(s.memories[:i], s.memories[i+1:]...)
+			return nil
+		}
+	}
+	return ErrMemoryNotFound
+}
+
+func (s *memoryStorage) SaveKnowledge(ctx context.Context, knowledge *Knowledge) error { return nil }
+
+func (s *memoryStorage) SaveLearningEvent(ctx context.Context, event *LearningEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, *event)
+	return nil
+}
+
+var conversation = []types.Message{
+	*types.NewUserMessage("Hi! I just started a new job at Acme, and please keep answers short."),
+	*types.NewAssistantMessage("Congratulations! Will do."),
+}
+
+func TestLLMExtractor_Extract(t *testing.T) {
+	model := &jsonModel{content: `{"memories": [
+		{"type": "fact", "content": "Works at Acme", "confidence": 0.9, "dedup_key": "Employer"},
+		{"type": "Preference", "content": "Prefers short answers", "confidence": 0.8, "dedup_key": "answer length"},
+		{"type": "mood", "content": "Seems happy", "confidence": 0.3}
+	]}`}
+	extractor, err := NewLLMExtractor(model, LLMExtractorConfig{})
+	if err != nil {
+		t.Fatalf("NewLLMExtractor() error = %v", err)
+	}
+
+	memories, err := extractor.Extract(context.Background(), "ada", conversation)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if model.req.ResponseFormat == nil || model.req.ResponseFormat.Type != "json_schema" {
+		t.Errorf("expected a structured output request, got %+v", model.req.ResponseFormat)
+	}
+	if got := model.req.Messages[1].Content; got != "user: Hi! I just started a new job at Acme, and please keep answers short.\nassistant: Congratulations! Will do.\n" {
+		t.Errorf("transcript = %q", got)
+	}
+
+	// The low-confidence memory is dropped
+	if len(memories) != 2 {
+		t.Fatalf("memories = %+v, want 2", memories)
+	}
+	if m := memories[0]; m.UserID != "ada" || m.Type != MemoryTypeFact || dedupKey(m) != "employer" || m.Metadata[MetadataConfidence] != 0.9 {
+		t.Errorf("first memory = %+v", m)
+	}
+	if m := memories[1]; m.Type != MemoryTypePreference || dedupKey(m) != "answer_length" {
+		t.Errorf("second memory = %+v", m)
+	}
+}
+
+func TestLLMExtractor_Fallback(t *testing.T) {
+	model := &jsonModel{content: "not json"}
+	extractor, _ := NewLLMExtractor(model, LLMExtractorConfig{})
+	if _, err := extractor.Extract(context.Background(), "ada", conversation); err == nil {
+		t.Fatal("expected an error for invalid output")
+	}
+
+	model.err = errors.New("rate limited")
+	extractor, _ = NewLLMExtractor(model, LLMExtractorConfig{Fallback: NewExtractor()})
+	memories, err := extractor.Extract(context.Background(), "ada", conversation)
+	if err != nil || len(memories) == 0 {
+		t.Fatalf("expected the heuristic memories, got %+v, %v", memories, err)
+	}
+}
+
+func TestMachine_LearnDeduplicates(t *testing.T) {
+	storage := newMemoryStorage()
+	machine, err := NewMachine(storage)
+	if err != nil {
+		t.Fatalf("NewMachine() error = %v", err)
+	}
+	model := &jsonModel{content: `{"memories": [{"type": "fact", "content": "Works at Acme", "confidence": 0.9, "dedup_key": "employer"}]}`}
+	extractor, _ := NewLLMExtractor(model, LLMExtractorConfig{})
+	machine.SetMemoryExtractor(extractor)
+	ctx := context.Background()
+
+	if err := machine.Learn(ctx, "ada", conversation); err != nil {
+		t.Fatalf("Learn() error = %v", err)
+	}
+	// The same memory again is not saved twice
+	if err := machine.Learn(ctx, "ada", conversation); err != nil {
+		t.Fatalf("Learn() error = %v", err)
+	}
+	if len(storage.memories) != 1 {
+		t.Fatalf("memories = %+v, want 1", storage.memories)
+	}
+	id := storage.memories[0].ID
+
+	// A new job replaces the old one
+	model.content = `{"memories": [{"type": "fact", "content": "Works at Globex", "confidence": 0.9, "dedup_key": "employer"}]}`
+	if err := machine.Learn(ctx, "ada", conversation); err != nil {
+		t.Fatalf("Learn() error = %v", err)
+	}
+	if len(storage.memories) != 1 || storage.memories[0].ID != id || storage.memories[0].Content != "Works at Globex" {
+		t.Fatalf("memories = %+v, want the employer memory updated", storage.memories)
+	}
+
+	// Errors from the extractor fail the run; nil restores the heuristics
+	model.err = errors.New("rate limited")
+	if err := machine.Learn(ctx, "ada", conversation); err == nil {
+		t.Fatal("expected the extraction error")
+	}
+	machine.SetMemoryExtractor(nil)
+	if err := machine.Learn(ctx, "ada", conversation); err != nil || len(storage.memories) == 1 {
+		t.Fatalf("expected heuristic memories, got %d, %v", len(storage.memories), err)
+	}
+}
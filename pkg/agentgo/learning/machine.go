@@ -2,32 +2,54 @@ package learning
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/google/uuid"
+	"github.com/jholhewres/agent-go/pkg/agentgo/ids"
+	"github.com/jholhewres/agent-go/pkg/agentgo/pagination"
 	"github.com/jholhewres/agent-go/pkg/agentgo/types"
 )
 
+var _ MemoryRetriever = (*Machine)(nil)
+
+// ErrListingNotSupported is returned by the Machine List methods when the
+// storage does not implement Lister
+var ErrListingNotSupported = errors.New("learning storage does not support listing")
+
 // Machine is the default implementation of LearningMachine
 type Machine struct {
-	storage   Storage
-	extractor *Extractor
-	enabled   bool
+	storage         Storage
+	extractor       *Extractor
+	memoryExtractor MemoryExtractor
+	enabled         bool
+
+	mu            sync.Mutex
+	consolidation ConsolidationConfig
+	pending       map[string]struct{} // Users to consolidate, while RunConsolidation runs
 }
 
+// dedupWindow is the number of the user's latest memories Learn compares
+// dedup keys against
+const dedupWindow = 200
+
 // NewMachine creates a new learning machine
 func NewMachine(storage Storage) (*Machine, error) {
 	if storage == nil {
 		return nil, fmt.Errorf("storage is required")
 	}
 
-	return &Machine{
-		storage:   storage,
-		extractor: NewExtractor(),
-		enabled:   true,
-	}, nil
+	extractor := NewExtractor()
+	m := &Machine{
+		storage:         storage,
+		extractor:       extractor,
+		memoryExtractor: extractor,
+		enabled:         true,
+	}
+	m.SetConsolidation(ConsolidationConfig{})
+	return m, nil
 }
 
 // Learn extracts information from messages and stores it
@@ -53,18 +75,15 @@ func (m *Machine) Learn(ctx context.Context, userID string, messages []types.Mes
 	}
 
 	// Extract memories from messages
-	memories := m.extractor.ExtractMemories(userID, messages)
-	for _, memory := range memories {
-		// Check context cancellation
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-
-		if err := m.storage.SaveUserMemory(ctx, &memory); err != nil {
-			return fmt.Errorf("failed to save memory: %w", err)
-		}
+	memories, err := m.memoryExtractor.Extract(ctx, userID, messages)
+	if err != nil {
+		return fmt.Errorf("failed to extract memories: %w", err)
+	}
+	if err := m.saveMemories(ctx, userID, memories); err != nil {
+		return err
+	}
+	if len(memories) > 0 {
+		m.markPending(userID)
 	}
 
 	// Extract knowledge from messages
@@ -90,7 +109,7 @@ func (m *Machine) Learn(ctx context.Context, userID string, messages []types.Mes
 
 	// Log learning event
 	event := &LearningEvent{
-		ID:        uuid.New().String(),
+		ID:        ids.New(),
 		UserID:    userID,
 		EventType: "learning_session",
 		Data: map[string]interface{}{
@@ -108,6 +127,77 @@ func (m *Machine) Learn(ctx context.Context, userID string, messages []types.Mes
 	return nil
 }
 
+// saveMemories stores extracted memories. A memory whose dedup key matches one
+// of the user's memories replaces it when the storage is a MemoryEditor, and
+// is saved as a new memory otherwise.
+func (m *Machine) saveMemories(ctx context.Context, userID string, memories []UserMemory) error {
+	editor, canEdit := m.storage.(MemoryEditor)
+	var existing map[string]UserMemory
+	if canEdit && hasDedupKeys(memories) {
+		recent, err := m.storage.GetUserMemories(ctx, userID, dedupWindow)
+		if err != nil {
+			return fmt.Errorf("failed to load memories: %w", err)
+		}
+		// Memories come newest first; keep the newest per key
+		existing = make(map[string]UserMemory)
+		for i := len(recent) - 1; i >= 0; i-- {
+			if key := dedupKey(recent[i]); key != "" {
+				existing[key] = recent[i]
+			}
+		}
+	}
+
+	for _, memory := range memories {
+		// Check context cancellation
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		key := dedupKey(memory)
+		old, ok := existing[key]
+		if key == "" || !ok {
+			if err := m.storage.SaveUserMemory(ctx, &memory); err != nil {
+				return fmt.Errorf("failed to save memory: %w", err)
+			}
+			if key != "" && existing != nil {
+				existing[key] = memory
+			}
+			continue
+		}
+
+		if old.Content == memory.Content && old.Type == memory.Type {
+			continue
+		}
+		memory.ID, memory.CreatedAt = old.ID, old.CreatedAt
+		memory.Metadata["updated_at"] = time.Now()
+		if err := editor.UpdateUserMemory(ctx, &memory); err != nil {
+			return fmt.Errorf("failed to update memory: %w", err)
+		}
+		existing[key] = memory
+	}
+	return nil
+}
+
+func hasDedupKeys(memories []UserMemory) bool {
+	for _, memory := range memories {
+		if dedupKey(memory) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// SetMemoryExtractor replaces the keyword-based memory extraction, e.g. with an
+// LLMExtractor. nil restores the default.
+func (m *Machine) SetMemoryExtractor(extractor MemoryExtractor) {
+	if extractor == nil {
+		extractor = m.extractor
+	}
+	m.memoryExtractor = extractor
+}
+
 // GetUserProfile returns the profile for a user
 func (m *Machine) GetUserProfile(ctx context.Context, userID string) (*UserProfile, error) {
 	return m.storage.GetUserProfile(ctx, userID)
@@ -121,6 +211,15 @@ func (m *Machine) GetUserMemories(ctx context.Context, userID string, limit int)
 	return m.storage.GetUserMemories(ctx, userID, limit)
 }
 
+// SearchUserMemories returns the user's memories most relevant to query. It
+// makes the Machine a MemoryRetriever for agent.Config.UserMemories.
+func (m *Machine) SearchUserMemories(ctx context.Context, userID, query string, limit int) ([]ScoredMemory, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	return m.storage.SearchMemories(ctx, userID, query, limit)
+}
+
 // GetLearnedKnowledge returns learned knowledge on a topic
 func (m *Machine) GetLearnedKnowledge(ctx context.Context, topic string, limit int) ([]Knowledge, error) {
 	if limit <= 0 {
@@ -135,6 +234,33 @@ func (m *Machine) GetLearnedKnowledge(ctx context.Context, topic string, limit i
 	return m.storage.GetKnowledge(ctx, topic, limit)
 }
 
+// ListUserMemories pages through a user's memories
+func (m *Machine) ListUserMemories(ctx context.Context, userID string, opts pagination.ListOptions) (pagination.Page[UserMemory], error) {
+	lister, ok := m.storage.(Lister)
+	if !ok {
+		return pagination.Page[UserMemory]{}, ErrListingNotSupported
+	}
+	return lister.ListUserMemories(ctx, userID, opts)
+}
+
+// ListKnowledge pages through learned knowledge
+func (m *Machine) ListKnowledge(ctx context.Context, opts pagination.ListOptions) (pagination.Page[Knowledge], error) {
+	lister, ok := m.storage.(Lister)
+	if !ok {
+		return pagination.Page[Knowledge]{}, ErrListingNotSupported
+	}
+	return lister.ListKnowledge(ctx, opts)
+}
+
+// ListLearningEvents pages through a user's learning events
+func (m *Machine) ListLearningEvents(ctx context.Context, userID string, opts pagination.ListOptions) (pagination.Page[LearningEvent], error) {
+	lister, ok := m.storage.(Lister)
+	if !ok {
+		return pagination.Page[LearningEvent]{}, ErrListingNotSupported
+	}
+	return lister.ListLearningEvents(ctx, userID, opts)
+}
+
 // DeleteUserData removes all data for a user (GDPR compliance)
 func (m *Machine) DeleteUserData(ctx context.Context, userID string) error {
 	return m.storage.DeleteUserData(ctx, userID)
@@ -5,14 +5,28 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/jholhewres/agent-go/pkg/agentgo/learning"
+	"github.com/jholhewres/agent-go/pkg/agentgo/pagination"
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
 )
 
+var (
+	_ learning.Storage      = (*Storage)(nil)
+	_ learning.Lister       = (*Storage)(nil)
+	_ learning.MemoryEditor = (*Storage)(nil)
+)
+
+// keywordSearchWindow is the number of latest memories SearchMemories ranks by
+// keywords when no vector database is set
+const keywordSearchWindow = 500
+
 // Storage implements learning.Storage for PostgreSQL
 type Storage struct {
 	db     *sql.DB
 	schema string
+	index  *learning.MemoryIndex // Set by SetVectorDB
 }
 
 // New creates a new PostgreSQL storage for learning
@@ -193,7 +207,7 @@ func (s *Storage) SaveUserMemory(ctx context.Context, memory *learning.UserMemor
 		ON CONFLICT (id) DO NOTHING
 	`, s.schema)
 
-	_, err = s.db.ExecContext(ctx, query,
+	result, err := s.db.ExecContext(ctx, query,
 		memory.ID,
 		memory.UserID,
 		memory.Content,
@@ -201,8 +215,16 @@ func (s *Storage) SaveUserMemory(ctx context.Context, memory *learning.UserMemor
 		metadataJSON,
 		memory.CreatedAt,
 	)
-
-	return err
+	if err != nil || s.index == nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+	if err := s.index.Add(ctx, *memory); err != nil {
+		return fmt.Errorf("failed to index memory: %w", err)
+	}
+	return nil
 }
 
 // GetUserMemories retrieves user memories
@@ -221,6 +243,38 @@ func (s *Storage) GetUserMemories(ctx context.Context, userID string, limit int)
 	}
 	defer rows.Close()
 
+	return scanMemories(rows)
+}
+
+// ListUserMemories pages through a user's memories by created_at
+func (s *Storage) ListUserMemories(ctx context.Context, userID string, opts pagination.ListOptions) (pagination.Page[learning.UserMemory], error) {
+	opts, cursor, err := opts.Prepare()
+	if err != nil {
+		return pagination.Page[learning.UserMemory]{}, err
+	}
+
+	b := pagination.NewSQLBuilder(pagination.Dollar)
+	b.Where("user_id = ?", userID)
+	if memoryType, ok := opts.Filter("type"); ok {
+		b.Where("type = ?", memoryType)
+	}
+	query := fmt.Sprintf(`SELECT id, user_id, content, type, metadata, created_at FROM %s.learning_user_memories`, s.schema) +
+		b.Clause(opts, cursor, "created_at", "id")
+
+	rows, err := s.db.QueryContext(ctx, query, b.Args()...)
+	if err != nil {
+		return pagination.Page[learning.UserMemory]{}, err
+	}
+	defer rows.Close()
+
+	memories, err := scanMemories(rows)
+	if err != nil {
+		return pagination.Page[learning.UserMemory]{}, err
+	}
+	return pagination.Trim(memories, opts.Limit, learning.MemoryKey), nil
+}
+
+func scanMemories(rows *sql.Rows) ([]learning.UserMemory, error) {
 	var memories []learning.UserMemory
 	for rows.Next() {
 		var memory learning.UserMemory
@@ -254,8 +308,151 @@ func (s *Storage) GetUserMemories(ctx context.Context, userID string, limit int)
 // DeleteUserMemories deletes all memories for a user
 func (s *Storage) DeleteUserMemories(ctx context.Context, userID string) error {
 	query := fmt.Sprintf(`DELETE FROM %s.learning_user_memories WHERE user_id = $1`, s.schema)
-	_, err := s.db.ExecContext(ctx, query, userID)
-	return err
+	if _, err := s.db.ExecContext(ctx, query, userID); err != nil {
+		return err
+	}
+	return s.unindexUser(ctx, userID)
+}
+
+// UpdateUserMemory replaces the content, type and metadata of a memory
+func (s *Storage) UpdateUserMemory(ctx context.Context, memory *learning.UserMemory) error {
+	metadataJSON, err := json.Marshal(memory.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE %s.learning_user_memories SET content = $1, type = $2, metadata = $3
+		WHERE id = $4 AND user_id = $5
+	`, s.schema)
+
+	result, err := s.db.ExecContext(ctx, query,
+		memory.Content,
+		string(memory.Type),
+		metadataJSON,
+		memory.ID,
+		memory.UserID,
+	)
+	if err != nil {
+		return err
+	}
+	if err := memoryAffected(result); err != nil || s.index == nil {
+		return err
+	}
+	if err := s.index.Add(ctx, *memory); err != nil {
+		return fmt.Errorf("failed to index memory: %w", err)
+	}
+	return nil
+}
+
+// DeleteUserMemory deletes one memory of a user
+func (s *Storage) DeleteUserMemory(ctx context.Context, userID, memoryID string) error {
+	query := fmt.Sprintf(`DELETE FROM %s.learning_user_memories WHERE id = $1 AND user_id = $2`, s.schema)
+	result, err := s.db.ExecContext(ctx, query, memoryID, userID)
+	if err != nil {
+		return err
+	}
+	if err := memoryAffected(result); err != nil || s.index == nil {
+		return err
+	}
+	if err := s.index.Delete(ctx, memoryID); err != nil {
+		return fmt.Errorf("failed to unindex memory: %w", err)
+	}
+	return nil
+}
+
+// SetVectorDB makes SearchMemories search by meaning in db, which embeds
+// memories and queries with its EmbeddingFunction, e.g. a pgvector store. The
+// existing memories are indexed when db is empty. Call it before using the
+// storage.
+func (s *Storage) SetVectorDB(ctx context.Context, db vectordb.VectorDB) error {
+	index, err := learning.NewMemoryIndex(db)
+	if err != nil {
+		return err
+	}
+	empty, err := index.Empty(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to count indexed memories: %w", err)
+	}
+	if empty {
+		query := fmt.Sprintf(`SELECT id, user_id, content, type, metadata, created_at FROM %s.learning_user_memories`, s.schema)
+		rows, err := s.db.QueryContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		memories, err := scanMemories(rows)
+		rows.Close()
+		if err != nil {
+			return err
+		}
+		if err := index.Add(ctx, memories...); err != nil {
+			return fmt.Errorf("failed to index memories: %w", err)
+		}
+	}
+	s.index = index
+	return nil
+}
+
+// SearchMemories returns the user's memories most relevant to query: by
+// meaning after SetVectorDB, else by keywords over the latest memories
+func (s *Storage) SearchMemories(ctx context.Context, userID, query string, limit int) ([]learning.ScoredMemory, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, nil
+	}
+	if s.index == nil {
+		memories, err := s.GetUserMemories(ctx, userID, keywordSearchWindow)
+		if err != nil {
+			return nil, err
+		}
+		return learning.RankByKeywords(memories, query, limit), nil
+	}
+
+	ids, scores, err := s.index.Search(ctx, userID, query, limit)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	placeholders := make([]string, len(ids))
+	args := []interface{}{userID}
+	for i, id := range ids {
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+		args = append(args, id)
+	}
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT id, user_id, content, type, metadata, created_at
+		FROM %s.learning_user_memories
+		WHERE user_id = $1 AND id IN (%s)
+	`, s.schema, strings.Join(placeholders, ",")), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	memories, err := scanMemories(rows)
+	if err != nil {
+		return nil, err
+	}
+	return learning.RankByScores(memories, ids, scores), nil
+}
+
+func (s *Storage) unindexUser(ctx context.Context, userID string) error {
+	if s.index == nil {
+		return nil
+	}
+	if err := s.index.DeleteUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to unindex memories: %w", err)
+	}
+	return nil
+}
+
+func memoryAffected(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return learning.ErrMemoryNotFound
+	}
+	return nil
 }
 
 // SaveKnowledge saves learned knowledge
@@ -310,6 +507,36 @@ func (s *Storage) SearchKnowledge(ctx context.Context, query string, limit int)
 	return s.queryKnowledge(ctx, searchQuery, "%"+query+"%", limit)
 }
 
+// ListKnowledge pages through learned knowledge by created_at
+func (s *Storage) ListKnowledge(ctx context.Context, opts pagination.ListOptions) (pagination.Page[learning.Knowledge], error) {
+	opts, cursor, err := opts.Prepare()
+	if err != nil {
+		return pagination.Page[learning.Knowledge]{}, err
+	}
+
+	b := pagination.NewSQLBuilder(pagination.Dollar)
+	if topic, ok := opts.Filter("topic"); ok {
+		b.Where("topic = ?", topic)
+	}
+	if source, ok := opts.Filter("source"); ok {
+		b.Where("source = ?", source)
+	}
+	query := fmt.Sprintf(`SELECT id, topic, content, source, relevance, metadata, created_at FROM %s.learning_knowledge`, s.schema) +
+		b.Clause(opts, cursor, "created_at", "id")
+
+	rows, err := s.db.QueryContext(ctx, query, b.Args()...)
+	if err != nil {
+		return pagination.Page[learning.Knowledge]{}, err
+	}
+	defer rows.Close()
+
+	knowledgeList, err := scanKnowledge(rows)
+	if err != nil {
+		return pagination.Page[learning.Knowledge]{}, err
+	}
+	return pagination.Trim(knowledgeList, opts.Limit, learning.KnowledgeKey), nil
+}
+
 func (s *Storage) queryKnowledge(ctx context.Context, query string, arg interface{}, limit int) ([]learning.Knowledge, error) {
 	rows, err := s.db.QueryContext(ctx, query, arg, limit)
 	if err != nil {
@@ -317,6 +544,10 @@ func (s *Storage) queryKnowledge(ctx context.Context, query string, arg interfac
 	}
 	defer rows.Close()
 
+	return scanKnowledge(rows)
+}
+
+func scanKnowledge(rows *sql.Rows) ([]learning.Knowledge, error) {
 	var knowledgeList []learning.Knowledge
 	for rows.Next() {
 		var k learning.Knowledge
@@ -384,6 +615,38 @@ func (s *Storage) GetLearningEvents(ctx context.Context, userID string, limit in
 	}
 	defer rows.Close()
 
+	return scanEvents(rows)
+}
+
+// ListLearningEvents pages through a user's learning events by occurred_at
+func (s *Storage) ListLearningEvents(ctx context.Context, userID string, opts pagination.ListOptions) (pagination.Page[learning.LearningEvent], error) {
+	opts, cursor, err := opts.Prepare()
+	if err != nil {
+		return pagination.Page[learning.LearningEvent]{}, err
+	}
+
+	b := pagination.NewSQLBuilder(pagination.Dollar)
+	b.Where("user_id = ?", userID)
+	if eventType, ok := opts.Filter("event_type"); ok {
+		b.Where("event_type = ?", eventType)
+	}
+	query := fmt.Sprintf(`SELECT id, user_id, event_type, data, occurred_at FROM %s.learning_events`, s.schema) +
+		b.Clause(opts, cursor, "occurred_at", "id")
+
+	rows, err := s.db.QueryContext(ctx, query, b.Args()...)
+	if err != nil {
+		return pagination.Page[learning.LearningEvent]{}, err
+	}
+	defer rows.Close()
+
+	events, err := scanEvents(rows)
+	if err != nil {
+		return pagination.Page[learning.LearningEvent]{}, err
+	}
+	return pagination.Trim(events, opts.Limit, learning.EventKey), nil
+}
+
+func scanEvents(rows *sql.Rows) ([]learning.LearningEvent, error) {
 	var events []learning.LearningEvent
 	for rows.Next() {
 		var event learning.LearningEvent
@@ -425,10 +688,11 @@ func (s *Storage) DeleteUserData(ctx context.Context, userID string) error {
 		}
 	}
 
-	return nil
+	return s.unindexUser(ctx, userID)
 }
 
-// Close closes the database connection
+// Close releases storage resources. The *sql.DB is owned by the caller and
+// is left open.
 func (s *Storage) Close() error {
-	return s.db.Close()
+	return nil
 }
@@ -0,0 +1,151 @@
+package learning
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/embeddings"
+)
+
+// ScoredMemory is a memory with its relevance to a query
+type ScoredMemory struct {
+	UserMemory
+	Score float64 `json:"score"`
+}
+
+// MemoryRetriever finds the memories of a user most relevant to a query.
+// MemorySearcher implements it; agents use it to put the relevant memories
+// into each run's prompt.
+type MemoryRetriever interface {
+	SearchUserMemories(ctx context.Context, userID, query string, limit int) ([]ScoredMemory, error)
+}
+
+// MemorySearchConfig configures a MemorySearcher
+type MemorySearchConfig struct {
+	// Window is the number of the user's latest memories searched (default: 200)
+	Window int
+}
+
+// MemorySearcher ranks a user's memories by embedding similarity to a query.
+// Storages only return memories by recency, so it embeds them itself and
+// keeps the vectors of the memories last seen for each user.
+type MemorySearcher struct {
+	storage  Storage
+	embedder embeddings.Embedder
+	config   MemorySearchConfig
+
+	mu      sync.Mutex
+	vectors map[string]map[string]memoryVector // user ID -> memory ID -> vector
+}
+
+type memoryVector struct {
+	content string
+	vector  []float32
+}
+
+var _ MemoryRetriever = (*MemorySearcher)(nil)
+
+// NewMemorySearcher creates a searcher over storage's memories
+func NewMemorySearcher(storage Storage, embedder embeddings.Embedder, config MemorySearchConfig) (*MemorySearcher, error) {
+	if storage == nil {
+		return nil, fmt.Errorf("storage is required")
+	}
+	if embedder == nil {
+		return nil, fmt.Errorf("embedder is required")
+	}
+	if config.Window <= 0 {
+		config.Window = 200
+	}
+	return &MemorySearcher{
+		storage:  storage,
+		embedder: embedder,
+		config:   config,
+		vectors:  make(map[string]map[string]memoryVector),
+	}, nil
+}
+
+// SearchUserMemories returns up to limit memories of userID, most relevant
+// to query first
+func (s *MemorySearcher) SearchUserMemories(ctx context.Context, userID, query string, limit int) ([]ScoredMemory, error) {
+	if limit <= 0 {
+		limit = 5
+	}
+	if strings.TrimSpace(query) == "" {
+		return nil, nil
+	}
+
+	memories, err := s.storage.GetUserMemories(ctx, userID, s.config.Window)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load memories: %w", err)
+	}
+	if len(memories) == 0 {
+		return nil, nil
+	}
+
+	vectors, err := s.memoryVectors(ctx, userID, memories)
+	if err != nil {
+		return nil, err
+	}
+	queryVector, err := s.embedder.EmbedSingle(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+
+	scored := make([]ScoredMemory, len(memories))
+	for i, memory := range memories {
+		scored[i] = ScoredMemory{UserMemory: memory, Score: cosineSimilarity(queryVector, vectors[i])}
+	}
+	// Memories come newest first; the stable sort prefers recent ones on ties
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].Score > scored[j].Score })
+	if len(scored) > limit {
+		scored = scored[:limit]
+	}
+	return scored, nil
+}
+
+// memoryVectors returns the embedding of each memory, embedding only those
+// that are new or changed since the last search
+func (s *MemorySearcher) memoryVectors(ctx context.Context, userID string, memories []UserMemory) ([][]float32, error) {
+	s.mu.Lock()
+	cached := s.vectors[userID]
+	s.mu.Unlock()
+
+	vectors := make([][]float32, len(memories))
+	var missing []int
+	var texts []string
+	for i, memory := range memories {
+		if v, ok := cached[memory.ID]; ok && v.content == memory.Content {
+			vectors[i] = v.vector
+			continue
+		}
+		missing = append(missing, i)
+		texts = append(texts, memory.Content)
+	}
+
+	if len(texts) > 0 {
+		embedded, err := s.embedder.Embed(ctx, texts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed memories: %w", err)
+		}
+		if len(embedded) != len(texts) {
+			return nil, fmt.Errorf("failed to embed memories: got %d embeddings for %d texts", len(embedded), len(texts))
+		}
+		for j, i := range missing {
+			vectors[i] = embedded[j]
+		}
+	}
+
+	// Keep only the memories in the window, so deleted ones are forgotten
+	fresh := make(map[string]memoryVector, len(memories))
+	for i, memory := range memories {
+		fresh[memory.ID] = memoryVector{content: memory.Content, vector: vectors[i]}
+	}
+	s.mu.Lock()
+	s.vectors[userID] = fresh
+	s.mu.Unlock()
+
+	return vectors, nil
+}
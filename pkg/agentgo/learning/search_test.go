@@ -0,0 +1,63 @@
+package learning
+
+import (
+	"context"
+	"testing"
+)
+
+// countingEmbedder counts the texts it embeds
+type countingEmbedder struct {
+	topicEmbedder
+	embedded int
+}
+
+func (e *countingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	e.embedded += len(texts)
+	return e.topicEmbedder.Embed(ctx, texts)
+}
+
+func (e *countingEmbedder) EmbedSingle(ctx context.Context, text string) ([]float32, error) {
+	return e.topicEmbedder.EmbedSingle(ctx, text)
+}
+
+func TestMemorySearcher(t *testing.T) {
+	storage := newMemoryStorage()
+	seed(storage,
+		UserMemory{Content: "Is allergic to peanuts"},
+		UserMemory{Content: "Likes jazz"},
+		UserMemory{Content: "Cooks Thai food on weekends"},
+	)
+	embedder := &countingEmbedder{topicEmbedder: topicEmbedder{
+		"Is allergic to peanuts":      {1, 0, 0},
+		"Likes jazz":                  {0, 1, 0},
+		"Cooks Thai food on weekends": {0.6, 0, 0.8},
+		"Loves peanut sauce":          {1, 0, 0},
+		"suggest a satay recipe":      {0.8, 0, 0.6},
+	}}
+	searcher, err := NewMemorySearcher(storage, embedder, MemorySearchConfig{})
+	if err != nil {
+		t.Fatalf("NewMemorySearcher() error = %v", err)
+	}
+	ctx := context.Background()
+
+	results, err := searcher.SearchUserMemories(ctx, "ada", "suggest a satay recipe", 2)
+	if err != nil {
+		t.Fatalf("SearchUserMemories() error = %v", err)
+	}
+	if len(results) != 2 || results[0].Content != "Cooks Thai food on weekends" || results[1].Content != "Is allergic to peanuts" {
+		t.Fatalf("results = %+v", results)
+	}
+
+	// Only new or changed memories are embedded again
+	storage.memories[1].Content = "Loves peanut sauce"
+	if _, err := searcher.SearchUserMemories(ctx, "ada", "suggest a satay recipe", 2); err != nil {
+		t.Fatalf("SearchUserMemories() error = %v", err)
+	}
+	if embedder.embedded != 4 {
+		t.Fatalf("embedded %d texts, want 4", embedder.embedded)
+	}
+
+	if results, _ := searcher.SearchUserMemories(ctx, "bob", "suggest a satay recipe", 2); len(results) != 0 {
+		t.Fatalf("expected no memories for another user, got %+v", results)
+	}
+}
@@ -5,14 +5,29 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jholhewres/agent-go/pkg/agentgo/learning"
+	"github.com/jholhewres/agent-go/pkg/agentgo/pagination"
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
 	_ "modernc.org/sqlite"
 )
 
+var (
+	_ learning.Storage      = (*Storage)(nil)
+	_ learning.Lister       = (*Storage)(nil)
+	_ learning.MemoryEditor = (*Storage)(nil)
+)
+
+// keywordSearchWindow is the number of latest memories SearchMemories ranks by
+// keywords when no vector database is set
+const keywordSearchWindow = 500
+
 // Storage implements learning.Storage for SQLite
 type Storage struct {
-	db *sql.DB
+	db    *sql.DB
+	index *learning.MemoryIndex // Set by SetVectorDB
 }
 
 // New creates a new SQLite storage for learning
@@ -98,7 +113,7 @@ func (s *Storage) SaveUserProfile(ctx context.Context, profile *learning.UserPro
 	_, err = s.db.ExecContext(ctx, `
 		INSERT OR REPLACE INTO learning_user_profiles (user_id, name, preferences, context, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?)
-	`, profile.UserID, profile.Name, string(preferencesJSON), string(contextJSON), profile.CreatedAt, profile.UpdatedAt)
+	`, profile.UserID, profile.Name, string(preferencesJSON), string(contextJSON), profile.CreatedAt.UTC(), profile.UpdatedAt.UTC())
 
 	return err
 }
@@ -111,7 +126,7 @@ func (s *Storage) GetUserProfile(ctx context.Context, userID string) (*learning.
 	err := s.db.QueryRowContext(ctx, `
 		SELECT user_id, name, preferences, context, created_at, updated_at
 		FROM learning_user_profiles WHERE user_id = ?
-	`, userID).Scan(&profile.UserID, &profile.Name, &preferencesJSON, &contextJSON, &profile.CreatedAt, &profile.UpdatedAt)
+	`, userID).Scan(&profile.UserID, &profile.Name, &preferencesJSON, &contextJSON, timeColumn{&profile.CreatedAt}, timeColumn{&profile.UpdatedAt})
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("user profile not found")
@@ -137,12 +152,20 @@ func (s *Storage) SaveUserMemory(ctx context.Context, memory *learning.UserMemor
 		return fmt.Errorf("failed to marshal metadata: %w", err)
 	}
 
-	_, err = s.db.ExecContext(ctx, `
+	result, err := s.db.ExecContext(ctx, `
 		INSERT OR IGNORE INTO learning_user_memories (id, user_id, content, type, metadata, created_at)
 		VALUES (?, ?, ?, ?, ?, ?)
-	`, memory.ID, memory.UserID, memory.Content, string(memory.Type), string(metadataJSON), memory.CreatedAt)
-
-	return err
+	`, memory.ID, memory.UserID, memory.Content, string(memory.Type), string(metadataJSON), memory.CreatedAt.UTC())
+	if err != nil || s.index == nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+	if err := s.index.Add(ctx, *memory); err != nil {
+		return fmt.Errorf("failed to index memory: %w", err)
+	}
+	return nil
 }
 
 // GetUserMemories retrieves user memories
@@ -157,12 +180,44 @@ func (s *Storage) GetUserMemories(ctx context.Context, userID string, limit int)
 	}
 	defer rows.Close()
 
+	return scanMemories(rows)
+}
+
+// ListUserMemories pages through a user's memories by created_at
+func (s *Storage) ListUserMemories(ctx context.Context, userID string, opts pagination.ListOptions) (pagination.Page[learning.UserMemory], error) {
+	opts, cursor, err := opts.Prepare()
+	if err != nil {
+		return pagination.Page[learning.UserMemory]{}, err
+	}
+
+	b := pagination.NewSQLBuilder(pagination.Question)
+	b.Where("user_id = ?", userID)
+	if memoryType, ok := opts.Filter("type"); ok {
+		b.Where("type = ?", memoryType)
+	}
+	query := `SELECT id, user_id, content, type, metadata, created_at FROM learning_user_memories` +
+		b.Clause(opts, cursor, "created_at", "id")
+
+	rows, err := s.db.QueryContext(ctx, query, b.Args()...)
+	if err != nil {
+		return pagination.Page[learning.UserMemory]{}, err
+	}
+	defer rows.Close()
+
+	memories, err := scanMemories(rows)
+	if err != nil {
+		return pagination.Page[learning.UserMemory]{}, err
+	}
+	return pagination.Trim(memories, opts.Limit, learning.MemoryKey), nil
+}
+
+func scanMemories(rows *sql.Rows) ([]learning.UserMemory, error) {
 	var memories []learning.UserMemory
 	for rows.Next() {
 		var memory learning.UserMemory
 		var memoryType, metadataJSON string
 
-		if err := rows.Scan(&memory.ID, &memory.UserID, &memory.Content, &memoryType, &metadataJSON, &memory.CreatedAt); err != nil {
+		if err := rows.Scan(&memory.ID, &memory.UserID, &memory.Content, &memoryType, &metadataJSON, timeColumn{&memory.CreatedAt}); err != nil {
 			return nil, err
 		}
 
@@ -178,8 +233,139 @@ func (s *Storage) GetUserMemories(ctx context.Context, userID string, limit int)
 
 // DeleteUserMemories deletes all memories for a user
 func (s *Storage) DeleteUserMemories(ctx context.Context, userID string) error {
-	_, err := s.db.ExecContext(ctx, `DELETE FROM learning_user_memories WHERE user_id = ?`, userID)
-	return err
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM learning_user_memories WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+	return s.unindexUser(ctx, userID)
+}
+
+// UpdateUserMemory replaces the content, type and metadata of a memory
+func (s *Storage) UpdateUserMemory(ctx context.Context, memory *learning.UserMemory) error {
+	metadataJSON, err := json.Marshal(memory.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE learning_user_memories SET content = ?, type = ?, metadata = ?
+		WHERE id = ? AND user_id = ?
+	`, memory.Content, string(memory.Type), string(metadataJSON), memory.ID, memory.UserID)
+	if err != nil {
+		return err
+	}
+	if err := memoryAffected(result); err != nil || s.index == nil {
+		return err
+	}
+	if err := s.index.Add(ctx, *memory); err != nil {
+		return fmt.Errorf("failed to index memory: %w", err)
+	}
+	return nil
+}
+
+// DeleteUserMemory deletes one memory of a user
+func (s *Storage) DeleteUserMemory(ctx context.Context, userID, memoryID string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM learning_user_memories WHERE id = ? AND user_id = ?`, memoryID, userID)
+	if err != nil {
+		return err
+	}
+	if err := memoryAffected(result); err != nil || s.index == nil {
+		return err
+	}
+	if err := s.index.Delete(ctx, memoryID); err != nil {
+		return fmt.Errorf("failed to unindex memory: %w", err)
+	}
+	return nil
+}
+
+// SetVectorDB makes SearchMemories search by meaning in db, which embeds
+// memories and queries with its EmbeddingFunction, e.g. a memvec store. The
+// existing memories are indexed when db is empty. Call it before using the
+// storage.
+func (s *Storage) SetVectorDB(ctx context.Context, db vectordb.VectorDB) error {
+	index, err := learning.NewMemoryIndex(db)
+	if err != nil {
+		return err
+	}
+	empty, err := index.Empty(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to count indexed memories: %w", err)
+	}
+	if empty {
+		rows, err := s.db.QueryContext(ctx, `SELECT id, user_id, content, type, metadata, created_at FROM learning_user_memories`)
+		if err != nil {
+			return err
+		}
+		memories, err := scanMemories(rows)
+		rows.Close()
+		if err != nil {
+			return err
+		}
+		if err := index.Add(ctx, memories...); err != nil {
+			return fmt.Errorf("failed to index memories: %w", err)
+		}
+	}
+	s.index = index
+	return nil
+}
+
+// SearchMemories returns the user's memories most relevant to query: by
+// meaning after SetVectorDB, else by keywords over the latest memories
+func (s *Storage) SearchMemories(ctx context.Context, userID, query string, limit int) ([]learning.ScoredMemory, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, nil
+	}
+	if s.index == nil {
+		memories, err := s.GetUserMemories(ctx, userID, keywordSearchWindow)
+		if err != nil {
+			return nil, err
+		}
+		return learning.RankByKeywords(memories, query, limit), nil
+	}
+
+	ids, scores, err := s.index.Search(ctx, userID, query, limit)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := []interface{}{userID}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, content, type, metadata, created_at
+		FROM learning_user_memories WHERE user_id = ? AND id IN (`+placeholders+`)
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	memories, err := scanMemories(rows)
+	if err != nil {
+		return nil, err
+	}
+	return learning.RankByScores(memories, ids, scores), nil
+}
+
+func (s *Storage) unindexUser(ctx context.Context, userID string) error {
+	if s.index == nil {
+		return nil
+	}
+	if err := s.index.DeleteUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to unindex memories: %w", err)
+	}
+	return nil
+}
+
+func memoryAffected(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return learning.ErrMemoryNotFound
+	}
+	return nil
 }
 
 // SaveKnowledge saves learned knowledge
@@ -192,7 +378,7 @@ func (s *Storage) SaveKnowledge(ctx context.Context, knowledge *learning.Knowled
 	_, err = s.db.ExecContext(ctx, `
 		INSERT OR IGNORE INTO learning_knowledge (id, topic, content, source, relevance, metadata, created_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?)
-	`, knowledge.ID, knowledge.Topic, knowledge.Content, knowledge.Source, knowledge.Relevance, string(metadataJSON), knowledge.CreatedAt)
+	`, knowledge.ID, knowledge.Topic, knowledge.Content, knowledge.Source, knowledge.Relevance, string(metadataJSON), knowledge.CreatedAt.UTC())
 
 	return err
 }
@@ -209,22 +395,7 @@ func (s *Storage) GetKnowledge(ctx context.Context, topic string, limit int) ([]
 	}
 	defer rows.Close()
 
-	var knowledgeList []learning.Knowledge
-	for rows.Next() {
-		var k learning.Knowledge
-		var metadataJSON string
-
-		if err := rows.Scan(&k.ID, &k.Topic, &k.Content, &k.Source, &k.Relevance, &metadataJSON, &k.CreatedAt); err != nil {
-			return nil, err
-		}
-
-		if err := json.Unmarshal([]byte(metadataJSON), &k.Metadata); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal knowledge metadata: %w", err)
-		}
-		knowledgeList = append(knowledgeList, k)
-	}
-
-	return knowledgeList, rows.Err()
+	return scanKnowledge(rows)
 }
 
 // SearchKnowledge searches knowledge by content
@@ -239,12 +410,46 @@ func (s *Storage) SearchKnowledge(ctx context.Context, query string, limit int)
 	}
 	defer rows.Close()
 
+	return scanKnowledge(rows)
+}
+
+// ListKnowledge pages through learned knowledge by created_at
+func (s *Storage) ListKnowledge(ctx context.Context, opts pagination.ListOptions) (pagination.Page[learning.Knowledge], error) {
+	opts, cursor, err := opts.Prepare()
+	if err != nil {
+		return pagination.Page[learning.Knowledge]{}, err
+	}
+
+	b := pagination.NewSQLBuilder(pagination.Question)
+	if topic, ok := opts.Filter("topic"); ok {
+		b.Where("topic = ?", topic)
+	}
+	if source, ok := opts.Filter("source"); ok {
+		b.Where("source = ?", source)
+	}
+	query := `SELECT id, topic, content, source, relevance, metadata, created_at FROM learning_knowledge` +
+		b.Clause(opts, cursor, "created_at", "id")
+
+	rows, err := s.db.QueryContext(ctx, query, b.Args()...)
+	if err != nil {
+		return pagination.Page[learning.Knowledge]{}, err
+	}
+	defer rows.Close()
+
+	knowledgeList, err := scanKnowledge(rows)
+	if err != nil {
+		return pagination.Page[learning.Knowledge]{}, err
+	}
+	return pagination.Trim(knowledgeList, opts.Limit, learning.KnowledgeKey), nil
+}
+
+func scanKnowledge(rows *sql.Rows) ([]learning.Knowledge, error) {
 	var knowledgeList []learning.Knowledge
 	for rows.Next() {
 		var k learning.Knowledge
 		var metadataJSON string
 
-		if err := rows.Scan(&k.ID, &k.Topic, &k.Content, &k.Source, &k.Relevance, &metadataJSON, &k.CreatedAt); err != nil {
+		if err := rows.Scan(&k.ID, &k.Topic, &k.Content, &k.Source, &k.Relevance, &metadataJSON, timeColumn{&k.CreatedAt}); err != nil {
 			return nil, err
 		}
 
@@ -267,7 +472,7 @@ func (s *Storage) SaveLearningEvent(ctx context.Context, event *learning.Learnin
 	_, err = s.db.ExecContext(ctx, `
 		INSERT INTO learning_events (id, user_id, event_type, data, occurred_at)
 		VALUES (?, ?, ?, ?, ?)
-	`, event.ID, event.UserID, event.EventType, string(dataJSON), event.OccurredAt)
+	`, event.ID, event.UserID, event.EventType, string(dataJSON), event.OccurredAt.UTC())
 
 	return err
 }
@@ -284,12 +489,44 @@ func (s *Storage) GetLearningEvents(ctx context.Context, userID string, limit in
 	}
 	defer rows.Close()
 
+	return scanEvents(rows)
+}
+
+// ListLearningEvents pages through a user's learning events by occurred_at
+func (s *Storage) ListLearningEvents(ctx context.Context, userID string, opts pagination.ListOptions) (pagination.Page[learning.LearningEvent], error) {
+	opts, cursor, err := opts.Prepare()
+	if err != nil {
+		return pagination.Page[learning.LearningEvent]{}, err
+	}
+
+	b := pagination.NewSQLBuilder(pagination.Question)
+	b.Where("user_id = ?", userID)
+	if eventType, ok := opts.Filter("event_type"); ok {
+		b.Where("event_type = ?", eventType)
+	}
+	query := `SELECT id, user_id, event_type, data, occurred_at FROM learning_events` +
+		b.Clause(opts, cursor, "occurred_at", "id")
+
+	rows, err := s.db.QueryContext(ctx, query, b.Args()...)
+	if err != nil {
+		return pagination.Page[learning.LearningEvent]{}, err
+	}
+	defer rows.Close()
+
+	events, err := scanEvents(rows)
+	if err != nil {
+		return pagination.Page[learning.LearningEvent]{}, err
+	}
+	return pagination.Trim(events, opts.Limit, learning.EventKey), nil
+}
+
+func scanEvents(rows *sql.Rows) ([]learning.LearningEvent, error) {
 	var events []learning.LearningEvent
 	for rows.Next() {
 		var event learning.LearningEvent
 		var dataJSON string
 
-		if err := rows.Scan(&event.ID, &event.UserID, &event.EventType, &dataJSON, &event.OccurredAt); err != nil {
+		if err := rows.Scan(&event.ID, &event.UserID, &event.EventType, &dataJSON, timeColumn{&event.OccurredAt}); err != nil {
 			return nil, err
 		}
 
@@ -316,7 +553,50 @@ func (s *Storage) DeleteUserData(ctx context.Context, userID string) error {
 		}
 	}
 
-	return nil
+	return s.unindexUser(ctx, userID)
+}
+
+// timeLayouts are the formats found in the TEXT timestamp columns: the
+// driver's time.Time encoding and SQLite's CURRENT_TIMESTAMP
+var timeLayouts = []string{
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05",
+}
+
+// timeColumn scans a TEXT timestamp column, which the driver returns as a
+// string rather than a time.Time
+type timeColumn struct {
+	dst *time.Time
+}
+
+func (c timeColumn) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*c.dst = time.Time{}
+		return nil
+	case time.Time:
+		*c.dst = v
+		return nil
+	case []byte:
+		src = string(v)
+	}
+	text, ok := src.(string)
+	if !ok {
+		return fmt.Errorf("unsupported timestamp type %T", src)
+	}
+	// Rows written from a local time.Time carry its monotonic clock reading
+	if i := strings.Index(text, " m="); i >= 0 {
+		text = text[:i]
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			*c.dst = t
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid timestamp %q", text)
 }
 
 // Close closes the database connection
@@ -0,0 +1,265 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/learning"
+	"github.com/jholhewres/agent-go/pkg/agentgo/pagination"
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb/memvec"
+)
+
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestStorage_ListUserMemoriesPages(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	if err := s.SaveUserProfile(ctx, &learning.UserProfile{UserID: "u1"}); err != nil {
+		t.Fatalf("SaveUserProfile() error = %v", err)
+	}
+	base := time.Now()
+	for i := 0; i < 5; i++ {
+		memoryType := learning.MemoryTypeFact
+		if i == 2 {
+			memoryType = learning.MemoryTypePreference
+		}
+		err := s.SaveUserMemory(ctx, &learning.UserMemory{
+			ID:        fmt.Sprintf("m%d", i),
+			UserID:    "u1",
+			Content:   "memory",
+			Type:      memoryType,
+			CreatedAt: base.Add(time.Duration(i) * 1500 * time.Millisecond),
+		})
+		if err != nil {
+			t.Fatalf("SaveUserMemory() error = %v", err)
+		}
+	}
+
+	var got []string
+	opts := pagination.ListOptions{Limit: 2}
+	for {
+		page, err := s.ListUserMemories(ctx, "u1", opts)
+		if err != nil {
+			t.Fatalf("ListUserMemories() error = %v", err)
+		}
+		for _, m := range page.Items {
+			got = append(got, m.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+	if fmt.Sprint(got) != "[m4 m3 m2 m1 m0]" {
+		t.Fatalf("memories = %v, want newest first", got)
+	}
+
+	page, err := s.ListUserMemories(ctx, "u1", pagination.ListOptions{
+		Order:   pagination.OrderAsc,
+		Filters: map[string]string{"type": string(learning.MemoryTypeFact)},
+	})
+	if err != nil {
+		t.Fatalf("ListUserMemories() error = %v", err)
+	}
+	if len(page.Items) != 4 || page.Items[0].ID != "m0" || page.Items[0].CreatedAt.IsZero() {
+		t.Fatalf("unexpected filtered page %+v", page.Items)
+	}
+}
+
+func TestStorage_ListKnowledgeAndEvents(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		if err := s.SaveKnowledge(ctx, &learning.Knowledge{
+			ID: fmt.Sprintf("k%d", i), Topic: "go", Source: "user", CreatedAt: base.Add(time.Duration(i) * time.Hour),
+		}); err != nil {
+			t.Fatalf("SaveKnowledge() error = %v", err)
+		}
+		// Same timestamp for every event: the ID orders them.
+		if err := s.SaveLearningEvent(ctx, &learning.LearningEvent{
+			ID: fmt.Sprintf("e%d", i), UserID: "u1", EventType: "learned", OccurredAt: base,
+		}); err != nil {
+			t.Fatalf("SaveLearningEvent() error = %v", err)
+		}
+	}
+
+	knowledge, err := s.ListKnowledge(ctx, pagination.ListOptions{
+		Filters: map[string]string{"topic": "go"},
+		Since:   base.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("ListKnowledge() error = %v", err)
+	}
+	if len(knowledge.Items) != 2 || knowledge.Items[0].ID != "k2" {
+		t.Fatalf("unexpected knowledge page %+v", knowledge.Items)
+	}
+
+	events, err := s.ListLearningEvents(ctx, "u1", pagination.ListOptions{Limit: 2, Order: pagination.OrderAsc})
+	if err != nil {
+		t.Fatalf("ListLearningEvents() error = %v", err)
+	}
+	if len(events.Items) != 2 || events.Items[0].ID != "e0" || events.NextCursor == "" {
+		t.Fatalf("unexpected first events page %+v", events)
+	}
+	events, err = s.ListLearningEvents(ctx, "u1", pagination.ListOptions{Limit: 2, Order: pagination.OrderAsc, Cursor: events.NextCursor})
+	if err != nil {
+		t.Fatalf("ListLearningEvents() error = %v", err)
+	}
+	if len(events.Items) != 1 || events.Items[0].ID != "e2" || events.NextCursor != "" {
+		t.Fatalf("unexpected last events page %+v", events)
+	}
+}
+
+func TestStorage_UserProfileRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	// time.Now() carries a monotonic clock reading
+	now := time.Now()
+	profile := &learning.UserProfile{UserID: "u1", Name: "Ada", CreatedAt: now, UpdatedAt: now}
+	if err := s.SaveUserProfile(ctx, profile); err != nil {
+		t.Fatalf("SaveUserProfile() error = %v", err)
+	}
+
+	got, err := s.GetUserProfile(ctx, "u1")
+	if err != nil {
+		t.Fatalf("GetUserProfile() error = %v", err)
+	}
+	if got.Name != "Ada" || !got.CreatedAt.Equal(now) || !got.UpdatedAt.Equal(now) {
+		t.Errorf("GetUserProfile() = %+v, want created/updated at %v", got, now)
+	}
+}
+
+func TestStorage_EditUserMemory(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	if err := s.SaveUserProfile(ctx, &learning.UserProfile{UserID: "u1"}); err != nil {
+		t.Fatalf("SaveUserProfile() error = %v", err)
+	}
+	memory := &learning.UserMemory{ID: "m1", UserID: "u1", Content: "likes tea", Type: learning.MemoryTypePreference, CreatedAt: time.Now()}
+	if err := s.SaveUserMemory(ctx, memory); err != nil {
+		t.Fatalf("SaveUserMemory() error = %v", err)
+	}
+
+	memory.Content = "likes green tea"
+	memory.Metadata = map[string]interface{}{"source": "model"}
+	if err := s.UpdateUserMemory(ctx, memory); err != nil {
+		t.Fatalf("UpdateUserMemory() error = %v", err)
+	}
+	got, err := s.GetUserMemories(ctx, "u1", 10)
+	if err != nil {
+		t.Fatalf("GetUserMemories() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Content != "likes green tea" || got[0].Metadata["source"] != "model" {
+		t.Fatalf("GetUserMemories() = %+v", got)
+	}
+
+	// Another user's memory cannot be changed
+	other := *memory
+	other.UserID = "u2"
+	if err := s.UpdateUserMemory(ctx, &other); err != learning.ErrMemoryNotFound {
+		t.Fatalf("UpdateUserMemory() for another user error = %v, want ErrMemoryNotFound", err)
+	}
+	if err := s.DeleteUserMemory(ctx, "u2", "m1"); err != learning.ErrMemoryNotFound {
+		t.Fatalf("DeleteUserMemory() for another user error = %v, want ErrMemoryNotFound", err)
+	}
+
+	if err := s.DeleteUserMemory(ctx, "u1", "m1"); err != nil {
+		t.Fatalf("DeleteUserMemory() error = %v", err)
+	}
+	if got, _ := s.GetUserMemories(ctx, "u1", 10); len(got) != 0 {
+		t.Fatalf("expected no memories after delete, got %+v", got)
+	}
+}
+
+// topicEmbedder maps texts to a pets/work plane by the words they contain
+type topicEmbedder struct{}
+
+func (topicEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i], _ = topicEmbedder{}.EmbedSingle(ctx, text)
+	}
+	return vectors, nil
+}
+
+func (topicEmbedder) EmbedSingle(ctx context.Context, text string) ([]float32, error) {
+	vector := []float32{0.01, 0.01}
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		switch strings.Trim(word, "?.,") {
+		case "cat", "cats", "dog", "pets", "animals":
+			vector[0]++
+		case "nurse", "job", "works", "work":
+			vector[1]++
+		}
+	}
+	return vector, nil
+}
+
+func TestStorage_SearchMemories(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	if err := s.SaveUserProfile(ctx, &learning.UserProfile{UserID: "u1"}); err != nil {
+		t.Fatalf("SaveUserProfile() error = %v", err)
+	}
+	now := time.Now()
+	for i, content := range []string{"Works as a nurse", "Has two cats"} {
+		memory := &learning.UserMemory{ID: fmt.Sprintf("m%d", i+1), UserID: "u1", Content: content, Type: learning.MemoryTypeFact, CreatedAt: now.Add(time.Duration(i) * time.Second)}
+		if err := s.SaveUserMemory(ctx, memory); err != nil {
+			t.Fatalf("SaveUserMemory() error = %v", err)
+		}
+	}
+
+	// Without a vector database only keywords match
+	got, err := s.SearchMemories(ctx, "u1", "cats", 5)
+	if err != nil || len(got) != 1 || got[0].ID != "m2" {
+		t.Fatalf("keyword SearchMemories() = %+v, %v", got, err)
+	}
+	if got, _ := s.SearchMemories(ctx, "u1", "any animals?", 5); len(got) != 0 {
+		t.Fatalf("keyword SearchMemories() = %+v, want no paraphrase matches", got)
+	}
+
+	// Existing memories are indexed when the vector database is set
+	db, err := memvec.New(memvec.Config{EmbeddingFunction: topicEmbedder{}})
+	if err != nil {
+		t.Fatalf("memvec.New() error = %v", err)
+	}
+	if err := s.SetVectorDB(ctx, db); err != nil {
+		t.Fatalf("SetVectorDB() error = %v", err)
+	}
+	got, err = s.SearchMemories(ctx, "u1", "any animals?", 1)
+	if err != nil || len(got) != 1 || got[0].ID != "m2" || got[0].Score <= 0 {
+		t.Fatalf("SearchMemories() = %+v, %v", got, err)
+	}
+	if got, _ := s.SearchMemories(ctx, "u2", "any animals?", 1); len(got) != 0 {
+		t.Fatalf("SearchMemories() for another user = %+v", got)
+	}
+
+	// Updates and deletes keep the index in sync
+	if err := s.UpdateUserMemory(ctx, &learning.UserMemory{ID: "m1", UserID: "u1", Content: "Has a dog", Type: learning.MemoryTypeFact}); err != nil {
+		t.Fatalf("UpdateUserMemory() error = %v", err)
+	}
+	if err := s.DeleteUserMemory(ctx, "u1", "m2"); err != nil {
+		t.Fatalf("DeleteUserMemory() error = %v", err)
+	}
+	got, err = s.SearchMemories(ctx, "u1", "any animals?", 5)
+	if err != nil || len(got) != 1 || got[0].Content != "Has a dog" {
+		t.Fatalf("SearchMemories() after edits = %+v, %v", got, err)
+	}
+}
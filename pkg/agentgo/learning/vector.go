@@ -0,0 +1,134 @@
+package learning
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+)
+
+// MemoryIndex keeps user memories in a vector database, so storages can
+// search them by meaning. The database embeds the memories and queries with
+// its EmbeddingFunction.
+type MemoryIndex struct {
+	db vectordb.VectorDB
+}
+
+// NewMemoryIndex creates an index over db
+func NewMemoryIndex(db vectordb.VectorDB) (*MemoryIndex, error) {
+	if db == nil {
+		return nil, fmt.Errorf("vector database is required")
+	}
+	return &MemoryIndex{db: db}, nil
+}
+
+// Add indexes memories, replacing the entries of memories already indexed
+func (x *MemoryIndex) Add(ctx context.Context, memories ...UserMemory) error {
+	if len(memories) == 0 {
+		return nil
+	}
+	ids := make([]string, len(memories))
+	docs := make([]vectordb.Document, len(memories))
+	for i, memory := range memories {
+		ids[i] = memory.ID
+		docs[i] = vectordb.Document{
+			ID:      memory.ID,
+			Content: memory.Content,
+			Metadata: map[string]interface{}{
+				"user_id": memory.UserID,
+				"type":    string(memory.Type),
+			},
+			CreatedAt: memory.CreatedAt,
+		}
+	}
+	if err := x.db.Delete(ctx, ids); err != nil {
+		return err
+	}
+	return x.db.Add(ctx, docs)
+}
+
+// Delete removes memories from the index
+func (x *MemoryIndex) Delete(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return x.db.Delete(ctx, ids)
+}
+
+// DeleteUser removes all memories of a user from the index
+func (x *MemoryIndex) DeleteUser(ctx context.Context, userID string) error {
+	return x.db.DeleteByFilter(ctx, map[string]interface{}{"user_id": userID})
+}
+
+// Empty reports whether nothing is indexed yet
+func (x *MemoryIndex) Empty(ctx context.Context) (bool, error) {
+	n, err := x.db.Count(ctx)
+	return n == 0, err
+}
+
+// Search returns the IDs of the user's memories most similar to query, with
+// their scores, best first
+func (x *MemoryIndex) Search(ctx context.Context, userID, query string, limit int) ([]string, map[string]float64, error) {
+	results, err := x.db.Query(ctx, query, limit, map[string]interface{}{"user_id": userID})
+	if err != nil {
+		return nil, nil, err
+	}
+	ids := make([]string, len(results))
+	scores := make(map[string]float64, len(results))
+	for i, result := range results {
+		ids[i] = result.ID
+		scores[result.ID] = float64(result.Score)
+	}
+	return ids, scores, nil
+}
+
+// RankByScores orders memories loaded by ID as ids, attaching their scores.
+// IDs without a memory are skipped.
+func RankByScores(memories []UserMemory, ids []string, scores map[string]float64) []ScoredMemory {
+	byID := make(map[string]UserMemory, len(memories))
+	for _, memory := range memories {
+		byID[memory.ID] = memory
+	}
+	ranked := make([]ScoredMemory, 0, len(ids))
+	for _, id := range ids {
+		if memory, ok := byID[id]; ok {
+			ranked = append(ranked, ScoredMemory{UserMemory: memory, Score: scores[id]})
+		}
+	}
+	return ranked
+}
+
+// RankByKeywords scores memories by the share of the query's words they
+// contain and returns up to limit of them, best first. Storages use it to
+// search without a vector database; it misses paraphrases.
+func RankByKeywords(memories []UserMemory, query string, limit int) []ScoredMemory {
+	terms := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var ranked []ScoredMemory
+	for _, memory := range memories {
+		content := strings.ToLower(memory.Content)
+		matched := 0
+		for _, term := range terms {
+			if strings.Contains(content, term) {
+				matched++
+			}
+		}
+		if matched > 0 {
+			ranked = append(ranked, ScoredMemory{UserMemory: memory, Score: float64(matched) / float64(len(terms))})
+		}
+	}
+	// Memories come newest first; the stable sort prefers recent ones on ties
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Score > ranked[j].Score })
+	if limit > 0 && len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
@@ -0,0 +1,382 @@
+// Package mapreduce processes texts that are too long for one model call. The
+// text is chunked, each chunk is processed by the model in parallel (map) and
+// the partial results are combined (reduce), hierarchically when they do not
+// fit in one call.
+package mapreduce
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/knowledge"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// Task is the kind of processing applied to the text
+type Task string
+
+const (
+	// TaskSummarize produces a summary of the whole text
+	TaskSummarize Task = "summarize"
+	// TaskExtract lists the items described by Request.Instructions
+	TaskExtract Task = "extract"
+	// TaskClassify picks one of Request.Labels by majority vote across chunks
+	TaskClassify Task = "classify"
+)
+
+// Config configures a Processor
+type Config struct {
+	// Model runs the map and reduce passes (required)
+	Model models.Model
+	// Chunker splits the text (default: paragraph chunker with ChunkSize)
+	Chunker knowledge.Chunker
+	// ChunkSize is the maximum characters per chunk for the default chunker (default: 8000)
+	ChunkSize int
+	// MaxConcurrency is the number of model calls in flight (default: 4)
+	MaxConcurrency int
+	// MaxReduceInput is the maximum characters of partial results combined in
+	// one reduce call; larger inputs are reduced in rounds (default: ChunkSize)
+	MaxReduceInput int
+	// MaxTokens limits each model response (0 = provider default)
+	MaxTokens int
+	// Temperature for every model call (default: 0)
+	Temperature float64
+}
+
+// Request describes one processing job
+type Request struct {
+	Task Task
+	Text string
+	// Instructions refine the task, e.g. the focus of a summary or what to
+	// extract (required for TaskExtract)
+	Instructions string
+	// Labels are the classes for TaskClassify (required for TaskClassify)
+	Labels []string
+}
+
+// Result is the outcome of a processing job
+type Result struct {
+	// Output is the summary, the extracted items or the chosen label
+	Output string `json:"output"`
+	// Chunks is the number of chunks the text was split into
+	Chunks int `json:"chunks"`
+	// MapOutputs are the per-chunk results, in chunk order
+	MapOutputs []string `json:"map_outputs,omitempty"`
+	// ReduceRounds is the number of reduce levels that called the model
+	ReduceRounds int `json:"reduce_rounds"`
+	// Votes counts chunk labels for TaskClassify
+	Votes map[string]int `json:"votes,omitempty"`
+	// Usage sums the token usage of every model call
+	Usage types.Usage `json:"usage"`
+}
+
+// Processor runs map-reduce jobs
+type Processor struct {
+	model          models.Model
+	chunker        knowledge.Chunker
+	concurrency    int
+	maxReduceInput int
+	maxTokens      int
+	temperature    float64
+}
+
+// New creates a Processor
+func New(config Config) (*Processor, error) {
+	if config.Model == nil {
+		return nil, types.NewInvalidConfigError("model is required", nil)
+	}
+	if config.ChunkSize < 0 || config.MaxConcurrency < 0 || config.MaxReduceInput < 0 || config.MaxTokens < 0 {
+		return nil, types.NewInvalidConfigError("sizes and limits must not be negative", nil)
+	}
+	if config.ChunkSize == 0 {
+		config.ChunkSize = 8000
+	}
+	if config.Chunker == nil {
+		config.Chunker = knowledge.NewParagraphChunker(config.ChunkSize)
+	}
+	if config.MaxConcurrency == 0 {
+		config.MaxConcurrency = 4
+	}
+	if config.MaxReduceInput == 0 {
+		config.MaxReduceInput = config.ChunkSize
+	}
+	return &Processor{
+		model:          config.Model,
+		chunker:        config.Chunker,
+		concurrency:    config.MaxConcurrency,
+		maxReduceInput: config.MaxReduceInput,
+		maxTokens:      config.MaxTokens,
+		temperature:    config.Temperature,
+	}, nil
+}
+
+// Summarize summarizes text; instructions may set the focus or length
+func (p *Processor) Summarize(ctx context.Context, text, instructions string) (*Result, error) {
+	return p.Process(ctx, Request{Task: TaskSummarize, Text: text, Instructions: instructions})
+}
+
+// Extract lists the items described by instructions, deduplicated across chunks
+func (p *Processor) Extract(ctx context.Context, text, instructions string) (*Result, error) {
+	return p.Process(ctx, Request{Task: TaskExtract, Text: text, Instructions: instructions})
+}
+
+// Classify picks the label that most chunks are classified as
+func (p *Processor) Classify(ctx context.Context, text string, labels []string) (*Result, error) {
+	return p.Process(ctx, Request{Task: TaskClassify, Text: text, Labels: labels})
+}
+
+// Process runs a job
+func (p *Processor) Process(ctx context.Context, req Request) (*Result, error) {
+	if err := validate(req); err != nil {
+		return nil, err
+	}
+
+	chunks, err := p.chunker.Chunk(knowledge.Document{ID: "input", Content: req.Text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to chunk text: %w", err)
+	}
+	if len(chunks) == 0 {
+		return nil, types.NewInvalidInputError("text is empty", nil)
+	}
+
+	result := &Result{Chunks: len(chunks)}
+	var usage usageCounter
+
+	inputs := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		inputs[i] = chunk.Content
+	}
+	mapOutputs, err := p.parallel(ctx, inputs, func(ctx context.Context, i int, text string) (string, error) {
+		return p.call(ctx, &usage, mapPrompt(req, len(chunks)), chunkMessage(i, len(chunks), text))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("map failed: %w", err)
+	}
+	result.MapOutputs = mapOutputs
+
+	if req.Task == TaskClassify {
+		result.Output, result.Votes = vote(mapOutputs, req.Labels)
+	} else if len(mapOutputs) == 1 {
+		result.Output = strings.TrimSpace(mapOutputs[0])
+	} else {
+		result.Output, result.ReduceRounds, err = p.reduce(ctx, &usage, req, mapOutputs)
+		if err != nil {
+			return nil, fmt.Errorf("reduce failed: %w", err)
+		}
+	}
+
+	result.Usage = usage.total()
+	return result, nil
+}
+
+// reduce combines partial results, in rounds when they exceed MaxReduceInput
+func (p *Processor) reduce(ctx context.Context, usage *usageCounter, req Request, parts []string) (string, int, error) {
+	rounds := 0
+	for {
+		groups := p.group(parts)
+		// Guarantee progress when single parts exceed MaxReduceInput.
+		if len(groups) == len(parts) && len(parts) > 1 {
+			groups = pairs(parts)
+		}
+		final := len(groups) == 1
+
+		outputs, err := p.parallel(ctx, joinGroups(groups), func(ctx context.Context, _ int, combined string) (string, error) {
+			return p.call(ctx, usage, reducePrompt(req, final), combined)
+		})
+		if err != nil {
+			return "", rounds, err
+		}
+		rounds++
+		if final {
+			return strings.TrimSpace(outputs[0]), rounds, nil
+		}
+		parts = outputs
+	}
+}
+
+// group packs consecutive parts into groups of at most MaxReduceInput characters
+func (p *Processor) group(parts []string) [][]string {
+	var groups [][]string
+	var current []string
+	size := 0
+	for _, part := range parts {
+		if len(current) > 0 && size+len(part) > p.maxReduceInput {
+			groups = append(groups, current)
+			current, size = nil, 0
+		}
+		current = append(current, part)
+		size += len(part)
+	}
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+	return groups
+}
+
+func pairs(parts []string) [][]string {
+	var groups [][]string
+	for i := 0; i < len(parts); i += 2 {
+		groups = append(groups, parts[i:min(i+2, len(parts))])
+	}
+	return groups
+}
+
+func joinGroups(groups [][]string) []string {
+	joined := make([]string, len(groups))
+	for i, group := range groups {
+		var b strings.Builder
+		for j, part := range group {
+			fmt.Fprintf(&b, "--- Partial result %d ---\n%s\n\n", j+1, strings.TrimSpace(part))
+		}
+		joined[i] = b.String()
+	}
+	return joined
+}
+
+// parallel runs fn over inputs with at most MaxConcurrency calls in flight.
+// The first error cancels the remaining calls.
+func (p *Processor) parallel(ctx context.Context, inputs []string, fn func(ctx context.Context, i int, input string) (string, error)) ([]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	outputs := make([]string, len(inputs))
+	sem := make(chan struct{}, p.concurrency)
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for i, input := range inputs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int, input string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			out, err := fn(ctx, i, input)
+			if err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("part %d: %w", i+1, err)
+					cancel()
+				})
+				return
+			}
+			outputs[i] = out
+		}(i, input)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return outputs, nil
+}
+
+func (p *Processor) call(ctx context.Context, usage *usageCounter, system, user string) (string, error) {
+	resp, err := p.model.Invoke(ctx, &models.InvokeRequest{
+		Messages: []*types.Message{
+			types.NewSystemMessage(system),
+			types.NewUserMessage(user),
+		},
+		Temperature: p.temperature,
+		MaxTokens:   p.maxTokens,
+	})
+	if err != nil {
+		return "", err
+	}
+	usage.add(resp.Usage)
+	return resp.Content, nil
+}
+
+func validate(req Request) error {
+	switch req.Task {
+	case TaskSummarize:
+	case TaskExtract:
+		if strings.TrimSpace(req.Instructions) == "" {
+			return types.NewInvalidInputError("extract requires instructions describing what to extract", nil)
+		}
+	case TaskClassify:
+		if len(req.Labels) < 2 {
+			return types.NewInvalidInputError("classify requires at least two labels", nil)
+		}
+	default:
+		return types.NewInvalidInputError(fmt.Sprintf("unsupported task %q", req.Task), nil)
+	}
+	if strings.TrimSpace(req.Text) == "" {
+		return types.NewInvalidInputError("text is empty", nil)
+	}
+	return nil
+}
+
+// vote maps each chunk answer to a label and returns the most common one.
+// Ties go to the label listed first.
+func vote(answers, labels []string) (string, map[string]int) {
+	votes := make(map[string]int)
+	for _, answer := range answers {
+		if label := matchLabel(answer, labels); label != "" {
+			votes[label]++
+		}
+	}
+
+	ranked := append([]string(nil), labels...)
+	order := make(map[string]int, len(labels))
+	for i, label := range labels {
+		order[label] = i
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if votes[ranked[i]] != votes[ranked[j]] {
+			return votes[ranked[i]] > votes[ranked[j]]
+		}
+		return order[ranked[i]] < order[ranked[j]]
+	})
+	if votes[ranked[0]] == 0 {
+		return "", votes
+	}
+	return ranked[0], votes
+}
+
+// matchLabel finds the label in a model answer: an exact match first, then
+// the longest label the answer contains
+func matchLabel(answer string, labels []string) string {
+	answer = strings.ToLower(strings.Trim(strings.TrimSpace(answer), ".\"'`*"))
+	best := ""
+	for _, label := range labels {
+		l := strings.ToLower(label)
+		if answer == l {
+			return label
+		}
+		if strings.Contains(answer, l) && len(label) > len(best) {
+			best = label
+		}
+	}
+	return best
+}
+
+type usageCounter struct {
+	mu    sync.Mutex
+	usage types.Usage
+}
+
+func (u *usageCounter) add(usage types.Usage) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.usage = u.usage.Add(usage)
+}
+
+func (u *usageCounter) total() types.Usage {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.usage
+}
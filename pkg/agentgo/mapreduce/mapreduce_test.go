@@ -0,0 +1,236 @@
+package mapreduce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// funcModel answers with respond(system, user) and counts calls
+type funcModel struct {
+	models.BaseModel
+	respond func(system, user string) (string, error)
+
+	mu       sync.Mutex
+	calls    int
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (m *funcModel) Invoke(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+	n := m.inFlight.Add(1)
+	defer m.inFlight.Add(-1)
+	for {
+		peak := m.peak.Load()
+		if n <= peak || m.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	m.mu.Lock()
+	m.calls++
+	m.mu.Unlock()
+
+	content, err := m.respond(req.Messages[0].Content, req.Messages[1].Content)
+	if err != nil {
+		return nil, err
+	}
+	return &types.ModelResponse{Content: content, Usage: types.Usage{TotalTokens: 10}}, nil
+}
+
+func (m *funcModel) InvokeStream(ctx context.Context, req *models.InvokeRequest) (<-chan types.ResponseChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *funcModel) callCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls
+}
+
+// paragraphs builds n paragraphs of about size characters each
+func paragraphs(n, size int) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = fmt.Sprintf("p%d %s", i, strings.Repeat("x", size))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Config{}); err == nil {
+		t.Error("expected error without model")
+	}
+	model := &funcModel{}
+	if _, err := New(Config{Model: model, ChunkSize: -1}); err == nil {
+		t.Error("expected error for negative chunk size")
+	}
+}
+
+func TestProcess_Validation(t *testing.T) {
+	p, _ := New(Config{Model: &funcModel{}})
+	ctx := context.Background()
+
+	tests := []Request{
+		{Task: "translate", Text: "x"},
+		{Task: TaskSummarize, Text: "  "},
+		{Task: TaskExtract, Text: "x"},
+		{Task: TaskClassify, Text: "x", Labels: []string{"only"}},
+	}
+	for _, req := range tests {
+		if _, err := p.Process(ctx, req); err == nil {
+			t.Errorf("Process(%+v) expected error", req)
+		}
+	}
+}
+
+func TestSummarize_SingleChunk(t *testing.T) {
+	model := &funcModel{respond: func(system, user string) (string, error) {
+		if !strings.HasPrefix(system, "Summarize the text.") || !strings.Contains(system, "one sentence") {
+			return "", fmt.Errorf("unexpected prompt %q", system)
+		}
+		return " short summary ", nil
+	}}
+	p, _ := New(Config{Model: model})
+
+	result, err := p.Summarize(context.Background(), "A short text.", "one sentence")
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	if result.Output != "short summary" || result.Chunks != 1 || result.ReduceRounds != 0 || model.callCount() != 1 {
+		t.Errorf("result = %+v, calls = %d", result, model.callCount())
+	}
+}
+
+func TestSummarize_MapReduce(t *testing.T) {
+	model := &funcModel{respond: func(system, user string) (string, error) {
+		switch {
+		case strings.Contains(system, "one part of a longer document"):
+			part := strings.SplitN(user, ":", 2)[0] // "Part i of n"
+			return "summary of " + part, nil
+		case strings.Contains(system, "single summary of the whole document"):
+			return fmt.Sprintf("final of %d", strings.Count(user, "--- Partial result")), nil
+		default:
+			return "", fmt.Errorf("unexpected prompt %q", system)
+		}
+	}}
+	p, _ := New(Config{Model: model, ChunkSize: 100, MaxConcurrency: 2, MaxReduceInput: 1000})
+
+	result, err := p.Summarize(context.Background(), paragraphs(5, 80), "")
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	if result.Chunks != 5 || len(result.MapOutputs) != 5 || result.MapOutputs[2] != "summary of Part 3 of 5" {
+		t.Errorf("map outputs = %v", result.MapOutputs)
+	}
+	if result.Output != "final of 5" || result.ReduceRounds != 1 {
+		t.Errorf("result = %+v", result)
+	}
+	if result.Usage.TotalTokens != 60 {
+		t.Errorf("usage = %+v, want 6 calls x 10 tokens", result.Usage)
+	}
+	if peak := model.peak.Load(); peak > 2 {
+		t.Errorf("peak concurrency = %d, want <= 2", peak)
+	}
+}
+
+func TestSummarize_HierarchicalReduce(t *testing.T) {
+	model := &funcModel{respond: func(system, user string) (string, error) {
+		if strings.Contains(system, "one part of a longer document") {
+			return strings.Repeat("s", 40), nil
+		}
+		if strings.Contains(system, "Merge them into one summary") {
+			return strings.Repeat("m", 40), nil
+		}
+		return "final", nil
+	}}
+	// Eight 40-character partial results with room for two per reduce call.
+	p, _ := New(Config{Model: model, ChunkSize: 100, MaxReduceInput: 100})
+
+	result, err := p.Summarize(context.Background(), paragraphs(8, 80), "")
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	// 8 -> 4 -> 2 -> 1
+	if result.Output != "final" || result.ReduceRounds != 3 {
+		t.Errorf("result = %+v", result)
+	}
+	if model.callCount() != 8+4+2+1 {
+		t.Errorf("calls = %d, want 15", model.callCount())
+	}
+}
+
+func TestExtract(t *testing.T) {
+	model := &funcModel{respond: func(system, user string) (string, error) {
+		if !strings.Contains(system, "Instructions: deadlines") {
+			return "", fmt.Errorf("instructions missing from %q", system)
+		}
+		if strings.Contains(system, "Merge them into one list") {
+			return "- May 1\n- June 2", nil
+		}
+		return "- May 1", nil
+	}}
+	p, _ := New(Config{Model: model, ChunkSize: 100})
+
+	result, err := p.Extract(context.Background(), paragraphs(3, 80), "deadlines")
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if result.Output != "- May 1\n- June 2" {
+		t.Errorf("Output = %q", result.Output)
+	}
+}
+
+func TestClassify_MajorityVote(t *testing.T) {
+	answers := []string{"Billing.", "technical support", "billing", "**Billing**", "I am not sure"}
+	var next atomic.Int32
+	model := &funcModel{respond: func(system, user string) (string, error) {
+		if !strings.Contains(system, "billing, technical support, sales") {
+			return "", fmt.Errorf("labels missing from %q", system)
+		}
+		return answers[next.Add(1)-1], nil
+	}}
+	p, _ := New(Config{Model: model, ChunkSize: 100, MaxConcurrency: 1})
+
+	result, err := p.Classify(context.Background(), paragraphs(5, 80), []string{"billing", "technical support", "sales"})
+	if err != nil {
+		t.Fatalf("Classify() error = %v", err)
+	}
+	if result.Output != "billing" || result.Votes["billing"] != 3 || result.Votes["technical support"] != 1 {
+		t.Errorf("result = %+v", result)
+	}
+	if model.callCount() != 5 {
+		t.Errorf("classification should not call the model to reduce, calls = %d", model.callCount())
+	}
+}
+
+func TestVote_TieGoesToFirstLabel(t *testing.T) {
+	label, _ := vote([]string{"b", "a"}, []string{"a", "b"})
+	if label != "a" {
+		t.Errorf("vote() = %q, want a", label)
+	}
+	if label, _ := vote([]string{"none"}, []string{"a", "b"}); label != "" {
+		t.Errorf("vote() = %q, want no label", label)
+	}
+}
+
+func TestProcess_MapError(t *testing.T) {
+	model := &funcModel{respond: func(system, user string) (string, error) {
+		if strings.HasPrefix(user, "Part 2 ") {
+			return "", errors.New("rate limited")
+		}
+		return "ok", nil
+	}}
+	p, _ := New(Config{Model: model, ChunkSize: 100})
+
+	_, err := p.Summarize(context.Background(), paragraphs(3, 80), "")
+	if err == nil || !strings.Contains(err.Error(), "part 2: rate limited") {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+}
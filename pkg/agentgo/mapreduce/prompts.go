@@ -0,0 +1,69 @@
+package mapreduce
+
+import (
+	"fmt"
+	"strings"
+)
+
+// mapPrompt is the system prompt for one chunk. A text that fits in one chunk
+// gets the final-answer prompt directly.
+func mapPrompt(req Request, chunks int) string {
+	var b strings.Builder
+	switch req.Task {
+	case TaskSummarize:
+		if chunks == 1 {
+			b.WriteString("Summarize the text.")
+		} else {
+			b.WriteString("The text is one part of a longer document. Summarize this part, keeping facts, names and numbers that a summary of the whole document may need.")
+		}
+	case TaskExtract:
+		if chunks == 1 {
+			b.WriteString("Extract the requested items from the text as a list, one item per line.")
+		} else {
+			b.WriteString("The text is one part of a longer document. Extract the requested items from this part as a list, one item per line. Answer NONE if the part has none.")
+		}
+	case TaskClassify:
+		if chunks == 1 {
+			b.WriteString("Classify the text.")
+		} else {
+			b.WriteString("The text is one part of a longer document. Classify this part.")
+		}
+		fmt.Fprintf(&b, " Answer with exactly one of these labels and nothing else: %s.", strings.Join(req.Labels, ", "))
+	}
+	writeInstructions(&b, req)
+	b.WriteString("\nUse only the information in the text.")
+	return b.String()
+}
+
+// reducePrompt is the system prompt for combining partial results
+func reducePrompt(req Request, final bool) string {
+	var b strings.Builder
+	switch req.Task {
+	case TaskSummarize:
+		if final {
+			b.WriteString("The input contains summaries of consecutive parts of one document. Write a single summary of the whole document.")
+		} else {
+			b.WriteString("The input contains summaries of consecutive parts of one document. Merge them into one summary of these parts, keeping facts, names and numbers.")
+		}
+	case TaskExtract:
+		b.WriteString("The input contains items extracted from consecutive parts of one document. Merge them into one list, one item per line, removing duplicates and NONE entries.")
+	}
+	writeInstructions(&b, req)
+	b.WriteString("\nUse only the information in the input.")
+	return b.String()
+}
+
+func writeInstructions(b *strings.Builder, req Request) {
+	if instructions := strings.TrimSpace(req.Instructions); instructions != "" {
+		b.WriteString("\n\nInstructions: ")
+		b.WriteString(instructions)
+	}
+}
+
+// chunkMessage frames a chunk for the map pass
+func chunkMessage(index, total int, text string) string {
+	if total == 1 {
+		return text
+	}
+	return fmt.Sprintf("Part %d of %d:\n\n%s", index+1, total, text)
+}
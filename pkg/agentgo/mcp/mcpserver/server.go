@@ -0,0 +1,291 @@
+// Package mcpserver serves agent-go toolkits, and optionally an agent, as an
+// MCP server, so MCP clients such as desktop assistants and IDEs can call them
+// over stdio or HTTP.
+// Package mcpserver 将 agent-go 工具包（以及可选的代理）作为 MCP 服务器提供，
+// 使桌面助手和 IDE 等 MCP 客户端可以通过 stdio 或 HTTP 调用它们。
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/agent"
+	"github.com/jholhewres/agent-go/pkg/agentgo/mcp/protocol"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+)
+
+// DefaultProtocolVersion is the MCP protocol version announced when the client
+// does not request one
+// DefaultProtocolVersion 是客户端未请求版本时声明的 MCP 协议版本
+const DefaultProtocolVersion = "2024-11-05"
+
+// Config contains configuration for the MCP server
+// Config 包含 MCP 服务器的配置
+type Config struct {
+	// Name is the server name reported to clients (default: "agent-go")
+	// Name 是报告给客户端的服务器名称（默认: "agent-go"）
+	Name string
+
+	// Version is the server version reported to clients (default: "0.1.0")
+	// Version 是报告给客户端的服务器版本（默认: "0.1.0"）
+	Version string
+
+	// Toolkits are the toolkits whose functions are served as MCP tools
+	// Toolkits 是其函数作为 MCP 工具提供的工具包
+	Toolkits []toolkit.Toolkit
+
+	// Agent is served as a tool taking a message and returning the agent's answer (optional)
+	// Agent 作为接收消息并返回代理回答的工具提供（可选）
+	Agent *agent.Agent
+
+	// AgentToolName is the name of the agent tool (default: "ask_agent")
+	// AgentToolName 是代理工具的名称（默认: "ask_agent"）
+	AgentToolName string
+
+	// AgentToolDescription describes the agent tool to clients
+	// AgentToolDescription 向客户端描述代理工具
+	AgentToolDescription string
+
+	// Logger for server events (default: slog.Default())
+	// Logger 用于服务器事件（默认: slog.Default()）
+	Logger *slog.Logger
+}
+
+// Server answers MCP requests with the configured tools. It is safe for
+// concurrent use; calls to the agent tool run one at a time.
+// Server 使用配置的工具响应 MCP 请求。可安全并发使用；对代理工具的调用依次执行。
+type Server struct {
+	info   protocol.ServerInfo
+	tools  []protocol.Tool
+	funcs  map[string]*toolkit.Function
+	logger *slog.Logger
+
+	agent     *agent.Agent
+	agentTool string
+	agentMu   sync.Mutex
+}
+
+// New creates an MCP server. Tool names must be unique across toolkits.
+// New 创建 MCP 服务器。工具名称在所有工具包中必须唯一。
+func New(config Config) (*Server, error) {
+	if len(config.Toolkits) == 0 && config.Agent == nil {
+		return nil, fmt.Errorf("at least one toolkit or an agent is required")
+	}
+	if config.Name == "" {
+		config.Name = "agent-go"
+	}
+	if config.Version == "" {
+		config.Version = "0.1.0"
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+
+	s := &Server{
+		info:   protocol.ServerInfo{Name: config.Name, Version: config.Version},
+		funcs:  make(map[string]*toolkit.Function),
+		logger: config.Logger,
+		agent:  config.Agent,
+	}
+
+	for _, tk := range config.Toolkits {
+		for _, def := range toolkit.ToModelToolDefinitions([]toolkit.Toolkit{tk}) {
+			name := def.Function.Name
+			if _, exists := s.funcs[name]; exists {
+				return nil, fmt.Errorf("duplicate tool name %q in toolkit %s", name, tk.Name())
+			}
+			s.funcs[name] = tk.Functions()[name]
+			s.tools = append(s.tools, protocol.Tool{
+				Name:        name,
+				Description: def.Function.Description,
+				InputSchema: inputSchema(def.Function.Parameters),
+			})
+		}
+	}
+
+	if config.Agent != nil {
+		s.agentTool = config.AgentToolName
+		if s.agentTool == "" {
+			s.agentTool = "ask_agent"
+		}
+		if _, exists := s.funcs[s.agentTool]; exists {
+			return nil, fmt.Errorf("agent tool name %q is already used by a toolkit", s.agentTool)
+		}
+		description := config.AgentToolDescription
+		if description == "" {
+			description = "Send a message to the agent and return its answer"
+			if config.Agent.Name != "" {
+				description = fmt.Sprintf("Send a message to the %s agent and return its answer", config.Agent.Name)
+			}
+		}
+		s.tools = append(s.tools, protocol.Tool{
+			Name:        s.agentTool,
+			Description: description,
+			InputSchema: protocol.InputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"message": map[string]interface{}{"type": "string", "description": "The message for the agent"},
+				},
+				Required: []string{"message"},
+			},
+		})
+	}
+
+	sort.Slice(s.tools, func(i, j int) bool { return s.tools[i].Name < s.tools[j].Name })
+	return s, nil
+}
+
+// Tools returns the tools the server exposes
+// Tools 返回服务器提供的工具
+func (s *Server) Tools() []protocol.Tool {
+	return append([]protocol.Tool(nil), s.tools...)
+}
+
+// Handle answers one JSON-RPC message. It returns nil for notifications.
+// Handle 响应一条 JSON-RPC 消息。对于通知返回 nil。
+func (s *Server) Handle(ctx context.Context, req *protocol.JSONRPCRequest) *protocol.JSONRPCResponse {
+	if req.ID == nil {
+		// Notifications such as "notifications/initialized" need no answer
+		// 诸如 "notifications/initialized" 的通知无需响应
+		return nil
+	}
+
+	var result interface{}
+	var rpcErr *protocol.JSONRPCError
+	switch req.Method {
+	case protocol.MethodInitialize:
+		result, rpcErr = s.initialize(req.Params)
+	case protocol.MethodPing:
+		result = map[string]interface{}{}
+	case protocol.MethodToolsList:
+		result = protocol.ToolsListResult{Tools: s.tools}
+	case protocol.MethodToolsCall:
+		result, rpcErr = s.callTool(ctx, req.Params)
+	default:
+		rpcErr = &protocol.JSONRPCError{Code: protocol.ErrorCodeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+
+	if rpcErr != nil {
+		return &protocol.JSONRPCResponse{JSONRPC: protocol.JSONRPCVersion, Error: rpcErr, ID: req.ID}
+	}
+	resp, err := protocol.NewResponse(result, req.ID)
+	if err != nil {
+		return &protocol.JSONRPCResponse{
+			JSONRPC: protocol.JSONRPCVersion,
+			Error:   &protocol.JSONRPCError{Code: protocol.ErrorCodeInternalError, Message: err.Error()},
+			ID:      req.ID,
+		}
+	}
+	return resp
+}
+
+func (s *Server) initialize(params json.RawMessage) (interface{}, *protocol.JSONRPCError) {
+	var init protocol.InitializeParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &init); err != nil {
+			return nil, invalidParams(err)
+		}
+	}
+	version := init.ProtocolVersion
+	if version == "" {
+		version = DefaultProtocolVersion
+	}
+	s.logger.Info("mcp client connected", "client", init.ClientInfo.Name, "version", init.ClientInfo.Version)
+	return protocol.InitializeResult{
+		ProtocolVersion: version,
+		ServerInfo:      s.info,
+		Capabilities:    map[string]interface{}{"tools": map[string]interface{}{}},
+	}, nil
+}
+
+func (s *Server) callTool(ctx context.Context, params json.RawMessage) (interface{}, *protocol.JSONRPCError) {
+	var call protocol.ToolsCallParams
+	if err := json.Unmarshal(params, &call); err != nil {
+		return nil, invalidParams(err)
+	}
+	if call.Arguments == nil {
+		call.Arguments = map[string]interface{}{}
+	}
+
+	if s.agent != nil && call.Name == s.agentTool {
+		return s.callAgent(ctx, call.Arguments), nil
+	}
+
+	fn, ok := s.funcs[call.Name]
+	if !ok {
+		return nil, &protocol.JSONRPCError{Code: protocol.ErrorCodeInvalidParams, Message: "unknown tool: " + call.Name}
+	}
+	for name, param := range fn.Parameters {
+		if _, exists := call.Arguments[name]; param.Required && !exists {
+			return toolError(fmt.Errorf("required parameter %s missing", name)), nil
+		}
+	}
+
+	s.logger.Info("mcp tool call", "tool", call.Name)
+	result, err := fn.Handler(ctx, call.Arguments)
+	if err != nil {
+		s.logger.Warn("mcp tool call failed", "tool", call.Name, "error", err)
+		return toolError(err), nil
+	}
+	return toolResult(result), nil
+}
+
+func (s *Server) callAgent(ctx context.Context, args map[string]interface{}) *protocol.ToolsCallResult {
+	message, _ := args["message"].(string)
+	if message == "" {
+		return toolError(fmt.Errorf("required parameter message missing"))
+	}
+
+	s.agentMu.Lock()
+	defer s.agentMu.Unlock()
+
+	out, err := s.agent.Run(ctx, message)
+	if err != nil {
+		return toolError(err)
+	}
+	if out.Status == agent.RunStatusPaused {
+		return toolError(fmt.Errorf("the agent paused for human approval, which is not available over MCP"))
+	}
+	return &protocol.ToolsCallResult{Content: []protocol.Content{{Type: protocol.ContentTypeText, Text: out.Content}}}
+}
+
+// toolResult returns strings as text and other results as JSON
+func toolResult(result interface{}) *protocol.ToolsCallResult {
+	text, ok := result.(string)
+	if !ok {
+		formatted, err := toolkit.FormatResult(result)
+		if err != nil {
+			return toolError(err)
+		}
+		text = formatted
+	}
+	return &protocol.ToolsCallResult{Content: []protocol.Content{{Type: protocol.ContentTypeText, Text: text}}}
+}
+
+// toolError reports a failed call in the result, as MCP expects, so the
+// client's model can see and react to it
+func toolError(err error) *protocol.ToolsCallResult {
+	return &protocol.ToolsCallResult{
+		Content: []protocol.Content{{Type: protocol.ContentTypeText, Text: err.Error()}},
+		IsError: true,
+	}
+}
+
+func invalidParams(err error) *protocol.JSONRPCError {
+	return &protocol.JSONRPCError{Code: protocol.ErrorCodeInvalidParams, Message: "invalid params: " + err.Error()}
+}
+
+func inputSchema(parameters map[string]interface{}) protocol.InputSchema {
+	schema := protocol.InputSchema{Type: "object"}
+	if properties, ok := parameters["properties"].(map[string]interface{}); ok && len(properties) > 0 {
+		schema.Properties = properties
+	}
+	if required, ok := parameters["required"].([]string); ok {
+		schema.Required = required
+	}
+	return schema
+}
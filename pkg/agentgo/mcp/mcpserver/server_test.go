@@ -0,0 +1,200 @@
+package mcpserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/agent"
+	"github.com/jholhewres/agent-go/pkg/agentgo/mcp/protocol"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+type echoModel struct {
+	models.BaseModel
+}
+
+func (m *echoModel) Invoke(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+	last := req.Messages[len(req.Messages)-1]
+	return &types.ModelResponse{Content: "echo: " + last.Content}, nil
+}
+
+func (m *echoModel) InvokeStream(ctx context.Context, req *models.InvokeRequest) (<-chan types.ResponseChunk, error) {
+	return nil, errors.New("not supported")
+}
+
+func mathToolkit() toolkit.Toolkit {
+	tk := toolkit.NewBaseToolkit("math")
+	tk.RegisterFunction(&toolkit.Function{
+		Name:        "add",
+		Description: "Add two numbers",
+		Parameters: map[string]toolkit.Parameter{
+			"a": {Type: "number", Required: true},
+			"b": {Type: "number", Required: true},
+		},
+		Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			return map[string]interface{}{"sum": args["a"].(float64) + args["b"].(float64)}, nil
+		},
+	})
+	tk.RegisterFunction(&toolkit.Function{
+		Name: "fail",
+		Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			return nil, errors.New("boom")
+		},
+	})
+	return tk
+}
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	ag, err := agent.New(agent.Config{
+		Name:  "echo",
+		Model: &echoModel{BaseModel: models.BaseModel{ID: "echo", Provider: "test"}},
+	})
+	if err != nil {
+		t.Fatalf("agent.New: %v", err)
+	}
+	s, err := New(Config{Name: "test", Toolkits: []toolkit.Toolkit{mathToolkit()}, Agent: ag})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return s
+}
+
+func TestServe_Stdio(t *testing.T) {
+	s := newTestServer(t)
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","clientInfo":{"name":"ide","version":"1"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"add","arguments":{"a":2,"b":3}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"fail"}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"ask_agent","arguments":{"message":"hi"}}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"tools/call","params":{"name":"missing"}}`,
+		`{"jsonrpc":"2.0","id":7,"method":"resources/list"}`,
+		`not json`,
+	}, "\n")
+
+	var out bytes.Buffer
+	if err := s.Serve(context.Background(), strings.NewReader(input), &out); err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+
+	responses := map[string]protocol.JSONRPCResponse{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var resp protocol.JSONRPCResponse
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("invalid response %q: %v", line, err)
+		}
+		id, _ := json.Marshal(resp.ID)
+		responses[string(id)] = resp
+	}
+	if len(responses) != 8 {
+		t.Fatalf("got %d responses, want 8 (notifications get none): %s", len(responses), out.String())
+	}
+
+	var init protocol.InitializeResult
+	json.Unmarshal(responses["1"].Result, &init)
+	if init.ProtocolVersion != "2025-03-26" || init.ServerInfo.Name != "test" || init.Capabilities["tools"] == nil {
+		t.Errorf("initialize = %+v", init)
+	}
+
+	var list protocol.ToolsListResult
+	json.Unmarshal(responses["2"].Result, &list)
+	var names []string
+	for _, tool := range list.Tools {
+		names = append(names, tool.Name)
+	}
+	if strings.Join(names, ",") != "add,ask_agent,fail" {
+		t.Errorf("tools = %v", names)
+	}
+	if add := list.Tools[0]; add.InputSchema.Type != "object" || len(add.InputSchema.Required) != 2 || add.InputSchema.Properties["a"] == nil {
+		t.Errorf("add schema = %+v", add.InputSchema)
+	}
+
+	if text := callText(t, responses["3"], false); text != `{"sum":5}` {
+		t.Errorf("add = %s", text)
+	}
+	if text := callText(t, responses["4"], true); text != "boom" {
+		t.Errorf("fail = %s", text)
+	}
+	if text := callText(t, responses["5"], false); text != "echo: hi" {
+		t.Errorf("ask_agent = %s", text)
+	}
+	if resp := responses["6"]; resp.Error == nil || resp.Error.Code != protocol.ErrorCodeInvalidParams {
+		t.Errorf("unknown tool = %+v", resp)
+	}
+	if resp := responses["7"]; resp.Error == nil || resp.Error.Code != protocol.ErrorCodeMethodNotFound {
+		t.Errorf("unknown method = %+v", resp)
+	}
+	if resp := responses["null"]; resp.Error == nil || resp.Error.Code != protocol.ErrorCodeParseError {
+		t.Errorf("parse error = %+v", resp)
+	}
+}
+
+func callText(t *testing.T, resp protocol.JSONRPCResponse, isError bool) string {
+	t.Helper()
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %+v", resp.Error)
+	}
+	var result protocol.ToolsCallResult
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("invalid result: %v", err)
+	}
+	if result.IsError != isError || len(result.Content) != 1 {
+		t.Fatalf("result = %+v", result)
+	}
+	return result.Content[0].Text
+}
+
+func TestServeHTTP(t *testing.T) {
+	srv := httptest.NewServer(newTestServer(t))
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL, "application/json", strings.NewReader(`{"jsonrpc":"2.0","id":"a","method":"tools/call","params":{"name":"add","arguments":{"a":1,"b":1}}}`))
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	defer resp.Body.Close()
+	var rpc protocol.JSONRPCResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rpc); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if rpc.ID != "a" || callText(t, rpc, false) != `{"sum":2}` {
+		t.Errorf("response = %+v", rpc)
+	}
+
+	notif, err := http.Post(srv.URL, "application/json", strings.NewReader(`{"jsonrpc":"2.0","method":"notifications/initialized"}`))
+	if err != nil {
+		t.Fatalf("POST notification: %v", err)
+	}
+	notif.Body.Close()
+	if notif.StatusCode != http.StatusAccepted {
+		t.Errorf("notification status = %d", notif.StatusCode)
+	}
+
+	get, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	get.Body.Close()
+	if get.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d", get.StatusCode)
+	}
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Config{}); err == nil {
+		t.Error("expected an error without toolkits or agent")
+	}
+	if _, err := New(Config{Toolkits: []toolkit.Toolkit{mathToolkit(), mathToolkit()}}); err == nil {
+		t.Error("expected an error for duplicate tool names")
+	}
+}
@@ -0,0 +1,132 @@
+package mcpserver
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/mcp/protocol"
+)
+
+// maxMessageSize bounds one JSON-RPC message, as in the stdio client
+const maxMessageSize = 1024 * 1024
+
+// maxHTTPBody bounds the body of an HTTP request
+const maxHTTPBody = 4 * 1024 * 1024
+
+// ServeStdio serves MCP over the process's stdin and stdout, as used by
+// clients that launch the server as a subprocess. Log to stderr, not stdout.
+// ServeStdio 通过进程的 stdin 和 stdout 提供 MCP 服务，供将服务器作为子进程启动的客户端使用。
+// 日志应写入 stderr 而不是 stdout。
+func (s *Server) ServeStdio(ctx context.Context) error {
+	return s.Serve(ctx, os.Stdin, os.Stdout)
+}
+
+// Serve reads newline-delimited JSON-RPC messages from r and writes the
+// responses to w until r is exhausted or ctx is cancelled. Requests are
+// handled concurrently, so a slow tool does not hold up other requests.
+// Serve 从 r 读取以换行分隔的 JSON-RPC 消息并将响应写入 w，直到 r 读完或 ctx 被取消。
+// 请求会并发处理，因此慢工具不会阻塞其他请求。
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var writeMu sync.Mutex
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	write := func(resp *protocol.JSONRPCResponse) {
+		data, err := json.Marshal(resp)
+		if err != nil {
+			s.logger.Error("failed to encode mcp response", "error", err)
+			return
+		}
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		if _, err := w.Write(append(data, '\n')); err != nil {
+			s.logger.Error("failed to write mcp response", "error", err)
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+	lines := make(chan []byte)
+	go func() {
+		defer close(lines)
+		for scanner.Scan() {
+			line := append([]byte(nil), scanner.Bytes()...)
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case line, ok := <-lines:
+			if !ok {
+				return scanner.Err()
+			}
+			if len(line) == 0 {
+				continue
+			}
+			var req protocol.JSONRPCRequest
+			if err := json.Unmarshal(line, &req); err != nil {
+				write(parseError(err))
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if resp := s.Handle(ctx, &req); resp != nil {
+					write(resp)
+				}
+			}()
+		}
+	}
+}
+
+// ServeHTTP implements http.Handler: each POST carries one JSON-RPC message
+// and gets the response as JSON. Notifications are answered with 202 Accepted.
+// ServeHTTP 实现 http.Handler：每个 POST 携带一条 JSON-RPC 消息并以 JSON 形式获得响应。
+// 通知以 202 Accepted 响应。
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req protocol.JSONRPCRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxHTTPBody)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, parseError(err))
+		return
+	}
+	resp := s.Handle(r.Context(), &req)
+	if resp == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func parseError(err error) *protocol.JSONRPCResponse {
+	return &protocol.JSONRPCResponse{
+		JSONRPC: protocol.JSONRPCVersion,
+		Error:   &protocol.JSONRPCError{Code: protocol.ErrorCodeParseError, Message: "parse error: " + err.Error()},
+	}
+}
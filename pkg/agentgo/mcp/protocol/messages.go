@@ -11,6 +11,7 @@ const (
 	MethodPromptsList     = "prompts/list"
 	MethodPromptsGet      = "prompts/get"
 	MethodLoggingSetLevel = "logging/setLevel"
+	MethodPing            = "ping"
 )
 
 // InitializeParams represents the parameters for the initialize method
@@ -0,0 +1,261 @@
+package memory
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// BM25 parameters: k1 controls term frequency saturation, b the length normalization
+// BM25 参数：k1 控制词频饱和度，b 控制长度归一化
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// bm25Index is an incrementally updated BM25 full-text index over the
+// short-term messages of one user. It is not safe for concurrent use.
+// bm25Index 是单个用户短期消息上增量更新的 BM25 全文索引，非并发安全。
+type bm25Index struct {
+	docs     map[string]bm25Doc // by message ID
+	docFreq  map[string]int     // number of documents containing each term
+	totalLen int                // sum of document lengths, in terms
+}
+
+type bm25Doc struct {
+	length int
+	terms  map[string]int // term frequencies
+}
+
+func newBM25Index() *bm25Index {
+	return &bm25Index{
+		docs:    make(map[string]bm25Doc),
+		docFreq: make(map[string]int),
+	}
+}
+
+// add indexes text under id, replacing a previous document with the same id
+// add 以 id 索引文本，替换相同 id 的旧文档
+func (idx *bm25Index) add(id, text string) {
+	idx.remove(id)
+
+	tokens := tokenize(text)
+	terms := make(map[string]int, len(tokens))
+	for _, token := range tokens {
+		terms[token]++
+	}
+	for term := range terms {
+		idx.docFreq[term]++
+	}
+	idx.docs[id] = bm25Doc{length: len(tokens), terms: terms}
+	idx.totalLen += len(tokens)
+}
+
+// remove drops the document with id, if indexed
+// remove 删除指定 id 的文档（如已索引）
+func (idx *bm25Index) remove(id string) {
+	doc, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	for term := range doc.terms {
+		if idx.docFreq[term]--; idx.docFreq[term] <= 0 {
+			delete(idx.docFreq, term)
+		}
+	}
+	idx.totalLen -= doc.length
+	delete(idx.docs, id)
+}
+
+// retain removes every document whose id is not in keep
+// retain 删除 id 不在 keep 中的所有文档
+func (idx *bm25Index) retain(keep map[string]bool) {
+	for id := range idx.docs {
+		if !keep[id] {
+			idx.remove(id)
+		}
+	}
+}
+
+// idf is the BM25 inverse document frequency, kept positive for common terms
+func (idx *bm25Index) idf(term string) float64 {
+	n := float64(len(idx.docs))
+	df := float64(idx.docFreq[term])
+	return math.Log(1 + (n-df+0.5)/(df+0.5))
+}
+
+// search scores the documents that contain at least one query term, from 0
+// to 1. BM25 ranks the documents; the best one is then scaled by the share of
+// the query (weighted by IDF) it contains, so a document matching every query
+// term scores 1 and weaker matches score proportionally less.
+// search 为至少包含一个查询词的文档评分（0 到 1）。BM25 决定排序；
+// 最佳文档按其包含的查询词比例（按 IDF 加权）缩放，因此匹配全部查询词的文档得 1 分。
+func (idx *bm25Index) search(query string) map[string]float64 {
+	queryTerms := uniqueTokens(query)
+	if len(queryTerms) == 0 || len(idx.docs) == 0 {
+		return nil
+	}
+
+	idfs := make(map[string]float64, len(queryTerms))
+	var queryWeight float64
+	for _, term := range queryTerms {
+		idfs[term] = idx.idf(term)
+		queryWeight += idfs[term]
+	}
+
+	avgLen := float64(idx.totalLen) / float64(len(idx.docs))
+	if avgLen == 0 {
+		avgLen = 1
+	}
+
+	scores := make(map[string]float64)
+	var bestID string
+	for id, doc := range idx.docs {
+		var score float64
+		for _, term := range queryTerms {
+			tf := float64(doc.terms[term])
+			if tf == 0 {
+				continue
+			}
+			norm := 1 - bm25B + bm25B*float64(doc.length)/avgLen
+			score += idfs[term] * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+		}
+		if score <= 0 {
+			continue
+		}
+		scores[id] = score
+		if bestID == "" || score > scores[bestID] || (score == scores[bestID] && id < bestID) {
+			bestID = id
+		}
+	}
+	if bestID == "" {
+		return nil
+	}
+
+	var covered float64
+	for _, term := range queryTerms {
+		if idx.docs[bestID].terms[term] > 0 {
+			covered += idfs[term]
+		}
+	}
+	scale := covered / queryWeight / scores[bestID]
+	for id := range scores {
+		scores[id] *= scale
+	}
+	return scores
+}
+
+// tokenize lowercases text, splits it into words, drops stop words and stems
+// the rest. Han characters are indexed one by one, since Chinese text has no
+// spaces between words.
+// tokenize 将文本转为小写并切分为词，去除停用词并做词干提取；汉字逐字索引，因为中文词之间没有空格。
+func tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	flush := func() {
+		if len(word) == 0 {
+			return
+		}
+		w := string(word)
+		word = word[:0]
+		if stopWords[w] {
+			return
+		}
+		tokens = append(tokens, stem(w))
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flush()
+			tokens = append(tokens, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word = append(word, r)
+		case r == '\'' || r == '’':
+			// Contractions and possessives: "don't" -> "dont", "user's" -> "users"
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
+
+func uniqueTokens(text string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, token := range tokenize(text) {
+		if !seen[token] {
+			seen[token] = true
+			unique = append(unique, token)
+		}
+	}
+	return unique
+}
+
+// stem strips common English inflections, so "deploys", "deploying" and
+// "deployed" index as the same term. It is deliberately light: a wrong stem
+// only costs recall when query and message inflect a word differently.
+// stem 去除常见的英语词形变化；实现刻意保持轻量。
+func stem(w string) string {
+	if len(w) <= 3 || !isASCIIWord(w) {
+		return w
+	}
+
+	switch {
+	case strings.HasSuffix(w, "ies") && len(w) > 4:
+		w = w[:len(w)-3] + "y"
+	case strings.HasSuffix(w, "sses"):
+		w = w[:len(w)-2]
+	case strings.HasSuffix(w, "ing") && len(w) > 5:
+		w = undouble(w[:len(w)-3])
+	case strings.HasSuffix(w, "ed") && len(w) > 4:
+		w = undouble(w[:len(w)-2])
+	case strings.HasSuffix(w, "ly") && len(w) > 4:
+		w = w[:len(w)-2]
+	case strings.HasSuffix(w, "es") && (strings.HasSuffix(w, "xes") || strings.HasSuffix(w, "ches") || strings.HasSuffix(w, "shes")):
+		w = w[:len(w)-2]
+	case strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") && !strings.HasSuffix(w, "us") && !strings.HasSuffix(w, "is"):
+		w = w[:len(w)-1]
+	}
+
+	// "invoice", "invoices" and "invoiced" all become "invoic"
+	if len(w) > 3 && strings.HasSuffix(w, "e") {
+		w = w[:len(w)-1]
+	}
+	return w
+}
+
+// undouble turns "runn" into "run" and "stopp" into "stop"
+func undouble(w string) string {
+	n := len(w)
+	if n >= 4 && w[n-1] == w[n-2] && !strings.ContainsRune("lsz", rune(w[n-1])) {
+		return w[:n-1]
+	}
+	return w
+}
+
+func isASCIIWord(w string) bool {
+	for i := 0; i < len(w); i++ {
+		if w[i] < 'a' || w[i] > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// stopWords are frequent English words that carry no meaning for search
+var stopWords = map[string]bool{
+	"a": true, "about": true, "after": true, "all": true, "also": true, "am": true, "an": true,
+	"and": true, "any": true, "are": true, "as": true, "at": true, "be": true, "been": true,
+	"before": true, "but": true, "by": true, "can": true, "could": true, "did": true, "do": true,
+	"does": true, "for": true, "from": true, "had": true, "has": true, "have": true, "he": true,
+	"her": true, "his": true, "how": true, "i": true, "if": true, "in": true, "into": true,
+	"is": true, "it": true, "its": true, "just": true, "me": true, "my": true, "no": true,
+	"not": true, "of": true, "on": true, "or": true, "our": true, "she": true, "so": true,
+	"some": true, "than": true, "that": true, "the": true, "their": true, "them": true,
+	"then": true, "there": true, "these": true, "they": true, "this": true, "to": true,
+	"too": true, "us": true, "very": true, "was": true, "we": true, "were": true, "what": true,
+	"when": true, "where": true, "which": true, "who": true, "why": true, "will": true,
+	"with": true, "would": true, "you": true, "your": true,
+}
@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"The deploys were deploying, then deployed!", []string{"deploy", "deploy", "deploy"}},
+		{"Invoices: resend the invoice", []string{"invoic", "resend", "invoic"}},
+		{"User's API keys don't rotate", []string{"user", "api", "key", "dont", "rotat"}},
+		{"running stopped classes", []string{"run", "stop", "class"}},
+		{"部署失败 deploy", []string{"部", "署", "失", "败", "deploy"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tokenize(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestBM25Index_Search(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		text    string
+		wantMin float64
+		wantMax float64
+	}{
+		{"exact match", "hello world", "hello world", 0.99, 1},
+		{"partial match", "hello world", "hello there", 0.2, 0.7},
+		{"inflected match", "deployed failing", "the deploys failed", 0.99, 1},
+		{"no match", "hello", "goodbye", 0, 0},
+		{"only stop words", "what is the", "what is the answer", 0, 0},
+		{"empty query", "", "any text", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx := newBM25Index()
+			idx.add("doc", tt.text)
+			idx.add("other", "unrelated filler message")
+			score := idx.search(tt.query)["doc"]
+			if score < tt.wantMin || score > tt.wantMax+1e-9 {
+				t.Errorf("score = %f, want between [%f, %f]", score, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestBM25Index_PrefersFocusedMessages(t *testing.T) {
+	idx := newBM25Index()
+	idx.add("short", "postgres migration failed")
+	idx.add("long", "we talked about lunch, the offsite, hiring plans, the roadmap, a postgres migration, "+
+		"holiday schedules, the new office coffee machine and why the build failed last week")
+	idx.add("rare", "postgres postgres postgres")
+
+	scores := idx.search("postgres migration failed")
+	if scores["short"] < 0.99 {
+		t.Errorf("focused message score = %f, want 1", scores["short"])
+	}
+	if scores["long"] >= scores["short"] {
+		t.Errorf("long message (%f) should rank below the focused one (%f)", scores["long"], scores["short"])
+	}
+	if scores["rare"] >= scores["short"] {
+		t.Errorf("repeating one term (%f) should not beat matching all terms (%f)", scores["rare"], scores["short"])
+	}
+}
+
+func TestBM25Index_AddAndRemove(t *testing.T) {
+	idx := newBM25Index()
+	idx.add("a", "kubernetes upgrade")
+	idx.add("b", "kubernetes")
+	idx.add("a", "postgres upgrade") // replaces a
+
+	if idx.docFreq["kubernet"] != 1 || idx.docFreq["postgr"] != 1 || idx.totalLen != 3 {
+		t.Errorf("index after replace: df=%v totalLen=%d", idx.docFreq, idx.totalLen)
+	}
+
+	idx.retain(map[string]bool{"a": true})
+	if len(idx.docs) != 1 || idx.docFreq["kubernet"] != 0 || idx.totalLen != 2 {
+		t.Errorf("index after retain: docs=%d df=%v totalLen=%d", len(idx.docs), idx.docFreq, idx.totalLen)
+	}
+	if scores := idx.search("kubernetes"); len(scores) != 0 {
+		t.Errorf("removed document still matches: %v", scores)
+	}
+}
+
+func TestHybridMemory_TextIndexFollowsShortTerm(t *testing.T) {
+	mem, err := NewHybridMemory(HybridMemoryConfig{
+		VectorDB:             newMockVectorDB(),
+		Embedder:             newMockEmbedder(),
+		MaxShortTermMessages: 2,
+	})
+	if err != nil {
+		t.Fatalf("failed to create hybrid memory: %v", err)
+	}
+
+	userID := "test-user"
+	mem.Add(types.NewUserMessage("the invoice was wrong"), userID)
+	mem.Add(types.NewUserMessage("resend invoices please"), userID)
+	mem.Add(types.NewUserMessage("thanks"), userID) // evicts the first message
+
+	if n := len(mem.textIndex[userID].docs); n != 2 {
+		t.Errorf("indexed %d messages, want the 2 kept in short-term memory", n)
+	}
+	results, err := mem.Search(context.Background(), "invoice", 5, userID)
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Message.Content != "resend invoices please" {
+		t.Errorf("expected only the remaining invoice message, got %+v", results)
+	}
+
+	mem.Clear(userID)
+	if _, ok := mem.textIndex[userID]; ok {
+		t.Error("Clear should drop the user's text index")
+	}
+}
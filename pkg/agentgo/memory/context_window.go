@@ -0,0 +1,219 @@
+package memory
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// ContextWindowConfig configures a ContextWindow
+// ContextWindowConfig 配置上下文窗口
+type ContextWindowConfig struct {
+	// MaxTokens is the model context size the request must fit in (required)
+	// MaxTokens 是请求必须适应的模型上下文大小（必需）
+	MaxTokens int
+
+	// ReserveTokens is kept free for the completion (default 0)
+	// ReserveTokens 为补全预留的令牌数（默认 0）
+	ReserveTokens int
+
+	// Counter counts message tokens (default models.HeuristicTokenCounter)
+	// Counter 计算消息令牌数（默认 models.HeuristicTokenCounter）
+	Counter models.TokenCounter
+
+	// Summarizer, when set, condenses trimmed messages into a system note
+	// instead of discarding them
+	// Summarizer 设置后，会将被裁剪的消息压缩为系统说明而不是直接丢弃
+	Summarizer models.Model
+
+	// MaxSummaryTokens bounds the summary length (default 500)
+	// MaxSummaryTokens 限制摘要长度（默认 500）
+	MaxSummaryTokens int
+
+	// SummaryPrompt overrides the summarization system prompt
+	// SummaryPrompt 覆盖摘要生成的系统提示
+	SummaryPrompt string
+
+	// SummaryTag is prepended to the summary note (default "[Conversation Summary]")
+	// SummaryTag 添加在摘要说明前（默认 "[Conversation Summary]"）
+	SummaryTag string
+}
+
+// FitResult describes what Fit did to a message list
+// FitResult 描述 Fit 对消息列表所做的处理
+type FitResult struct {
+	OriginalTokens int  // Tokens before trimming / 裁剪前的令牌数
+	FinalTokens    int  // Tokens after trimming / 裁剪后的令牌数
+	Dropped        int  // Messages removed from the request / 从请求中移除的消息数
+	Summarized     bool // Whether dropped messages were replaced by a summary / 是否用摘要替换了被移除的消息
+}
+
+// ContextWindow trims the oldest messages of a request so it fits within a
+// token budget. Leading system messages and the most recent turn are always
+// kept, and assistant tool calls are never separated from their tool results.
+// The stored conversation is not modified; only the outgoing request is.
+// ContextWindow 裁剪请求中最早的消息，使其适应令牌预算。
+type ContextWindow struct {
+	cfg ContextWindowConfig
+
+	mu           sync.Mutex
+	summaryKey   string
+	summaryCache string
+}
+
+// NewContextWindow creates a context window manager
+// NewContextWindow 创建上下文窗口管理器
+func NewContextWindow(cfg ContextWindowConfig) (*ContextWindow, error) {
+	if cfg.MaxTokens <= 0 {
+		return nil, fmt.Errorf("ContextWindowConfig.MaxTokens must be positive")
+	}
+	if cfg.ReserveTokens < 0 || cfg.ReserveTokens >= cfg.MaxTokens {
+		return nil, fmt.Errorf("ContextWindowConfig.ReserveTokens must be between 0 and MaxTokens")
+	}
+	if cfg.Counter == nil {
+		cfg.Counter = models.HeuristicTokenCounter{}
+	}
+	if cfg.MaxSummaryTokens <= 0 {
+		cfg.MaxSummaryTokens = defaultMaxSummaryTokens
+	}
+	if cfg.SummaryPrompt == "" {
+		cfg.SummaryPrompt = defaultSummaryPrompt
+	}
+	if cfg.SummaryTag == "" {
+		cfg.SummaryTag = defaultSummaryTag
+	}
+	return &ContextWindow{cfg: cfg}, nil
+}
+
+// Fit returns messages trimmed to the token budget. When a Summarizer is
+// configured and fails, Fit falls back to plain truncation.
+// Fit 返回裁剪到令牌预算内的消息。配置的 Summarizer 失败时退回到直接截断。
+func (w *ContextWindow) Fit(ctx context.Context, messages []*types.Message) ([]*types.Message, FitResult) {
+	budget := w.cfg.MaxTokens - w.cfg.ReserveTokens
+	result := FitResult{OriginalTokens: w.cfg.Counter.CountTokens(messages)}
+	result.FinalTokens = result.OriginalTokens
+	if result.OriginalTokens <= budget {
+		return messages, result
+	}
+
+	pinned, rest := splitLeadingSystem(messages)
+	units := groupTurnUnits(rest)
+
+	keepBudget := budget
+	if w.cfg.Summarizer != nil {
+		keepBudget -= w.cfg.MaxSummaryTokens
+	}
+
+	// Drop whole units from the oldest until the remainder fits; the newest unit always stays.
+	start := 0
+	for start < len(units)-1 && w.cfg.Counter.CountTokens(joinUnits(pinned, nil, units[start:])) > keepBudget {
+		start++
+	}
+
+	var dropped []*types.Message
+	for _, unit := range units[:start] {
+		dropped = append(dropped, unit...)
+	}
+
+	var summary *types.Message
+	if len(dropped) > 0 && w.cfg.Summarizer != nil {
+		if text, err := w.summarize(ctx, dropped); err == nil {
+			summary = types.NewSystemMessage(w.cfg.SummaryTag + " " + text)
+			result.Summarized = true
+		}
+	}
+
+	fitted := joinUnits(pinned, summary, units[start:])
+	result.Dropped = len(dropped)
+	result.FinalTokens = w.cfg.Counter.CountTokens(fitted)
+	return fitted, result
+}
+
+// summarize condenses dropped messages, reusing the last summary while the
+// dropped prefix is unchanged (the common case across tool-calling loops).
+func (w *ContextWindow) summarize(ctx context.Context, dropped []*types.Message) (string, error) {
+	key := messagesKey(dropped)
+
+	w.mu.Lock()
+	if w.summaryKey == key {
+		cached := w.summaryCache
+		w.mu.Unlock()
+		return cached, nil
+	}
+	w.mu.Unlock()
+
+	var sb strings.Builder
+	for _, msg := range dropped {
+		sb.WriteString(fmt.Sprintf("%s: %s\n", msg.Role, msg.Content))
+	}
+
+	resp, err := w.cfg.Summarizer.Invoke(ctx, &models.InvokeRequest{
+		Messages: []*types.Message{
+			types.NewSystemMessage(w.cfg.SummaryPrompt),
+			types.NewUserMessage(sb.String()),
+		},
+		MaxTokens: w.cfg.MaxSummaryTokens,
+	})
+	if err != nil {
+		return "", fmt.Errorf("context summarizer invoke: %w", err)
+	}
+
+	w.mu.Lock()
+	w.summaryKey, w.summaryCache = key, resp.Content
+	w.mu.Unlock()
+	return resp.Content, nil
+}
+
+// messagesKey hashes the content of messages. Message IDs are not used since
+// messages built without a constructor have none.
+func messagesKey(messages []*types.Message) string {
+	h := sha256.New()
+	for _, msg := range messages {
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00", msg.Role, msg.Name, msg.ToolCallID, msg.Content)
+		for _, tc := range msg.ToolCalls {
+			fmt.Fprintf(h, "%s\x00%s\x00%s\x00", tc.ID, tc.Function.Name, tc.Function.Arguments)
+		}
+		h.Write([]byte{1})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func splitLeadingSystem(messages []*types.Message) (pinned, rest []*types.Message) {
+	i := 0
+	for i < len(messages) && messages[i] != nil && messages[i].Role == types.RoleSystem {
+		i++
+	}
+	return messages[:i], messages[i:]
+}
+
+// groupTurnUnits groups messages so an assistant message with tool calls and
+// the tool results that follow it are kept or dropped together.
+func groupTurnUnits(messages []*types.Message) [][]*types.Message {
+	var units [][]*types.Message
+	for _, msg := range messages {
+		if msg != nil && msg.Role == types.RoleTool && len(units) > 0 {
+			units[len(units)-1] = append(units[len(units)-1], msg)
+			continue
+		}
+		units = append(units, []*types.Message{msg})
+	}
+	return units
+}
+
+func joinUnits(pinned []*types.Message, summary *types.Message, units [][]*types.Message) []*types.Message {
+	out := make([]*types.Message, 0, len(pinned)+1+len(units))
+	out = append(out, pinned...)
+	if summary != nil {
+		out = append(out, summary)
+	}
+	for _, unit := range units {
+		out = append(out, unit...)
+	}
+	return out
+}
@@ -0,0 +1,148 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// oneTokenPerMessage counts every message as a single token.
+var oneTokenPerMessage = models.TokenCounterFunc(func(messages []*types.Message) int {
+	return len(messages)
+})
+
+func TestNewContextWindow_Validation(t *testing.T) {
+	if _, err := NewContextWindow(ContextWindowConfig{}); err == nil {
+		t.Error("expected error for zero MaxTokens")
+	}
+	if _, err := NewContextWindow(ContextWindowConfig{MaxTokens: 10, ReserveTokens: 10}); err == nil {
+		t.Error("expected error when ReserveTokens consumes the whole window")
+	}
+}
+
+func TestContextWindow_UnderBudgetUnchanged(t *testing.T) {
+	w, _ := NewContextWindow(ContextWindowConfig{MaxTokens: 10, Counter: oneTokenPerMessage})
+	msgs := []*types.Message{types.NewSystemMessage("sys"), types.NewUserMessage("hi")}
+
+	got, res := w.Fit(context.Background(), msgs)
+	if len(got) != 2 || res.Dropped != 0 {
+		t.Fatalf("expected unchanged messages, got %d (dropped %d)", len(got), res.Dropped)
+	}
+}
+
+func TestContextWindow_TrimsOldestKeepsSystemAndToolPairs(t *testing.T) {
+	w, _ := NewContextWindow(ContextWindowConfig{MaxTokens: 4, Counter: oneTokenPerMessage})
+
+	call := types.NewAssistantMessage("")
+	call.ToolCalls = []types.ToolCall{{ID: "c1", Function: types.ToolCallFunction{Name: "add"}}}
+	msgs := []*types.Message{
+		types.NewSystemMessage("sys"),
+		types.NewUserMessage("old question"),
+		types.NewAssistantMessage("old answer"),
+		types.NewUserMessage("question"),
+		call,
+		types.NewToolMessage("c1", "3"),
+		types.NewUserMessage("latest"),
+	}
+
+	got, res := w.Fit(context.Background(), msgs)
+	if res.Dropped != 3 {
+		t.Fatalf("expected 3 dropped messages, got %d", res.Dropped)
+	}
+	if got[0].Role != types.RoleSystem {
+		t.Error("leading system message must be kept")
+	}
+	// Dropping only "question" would fit, but the tool pair must stay intact, so
+	// the remaining request is system + assistant call + tool result + latest.
+	if len(got) != 4 || got[1] != call || got[2].Role != types.RoleTool || got[3].Content != "latest" {
+		t.Fatalf("unexpected fitted messages: %+v", got)
+	}
+	if res.FinalTokens != 4 || res.OriginalTokens != 7 {
+		t.Errorf("unexpected token counts: %+v", res)
+	}
+}
+
+func TestContextWindow_KeepsLatestTurnWhenOverBudget(t *testing.T) {
+	w, _ := NewContextWindow(ContextWindowConfig{MaxTokens: 1, Counter: oneTokenPerMessage})
+	msgs := []*types.Message{types.NewUserMessage("a"), types.NewUserMessage("b")}
+
+	got, _ := w.Fit(context.Background(), msgs)
+	if len(got) != 1 || got[0].Content != "b" {
+		t.Fatalf("expected only the latest message, got %+v", got)
+	}
+}
+
+func TestContextWindow_SummarizesDroppedMessages(t *testing.T) {
+	model := &mockModel{invokeFn: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+		if !strings.Contains(req.Messages[1].Content, "old question") {
+			t.Errorf("summary input missing dropped content: %q", req.Messages[1].Content)
+		}
+		return &types.ModelResponse{Content: "user asked something"}, nil
+	}}
+	w, _ := NewContextWindow(ContextWindowConfig{
+		MaxTokens:        4,
+		MaxSummaryTokens: 1,
+		Counter:          oneTokenPerMessage,
+		Summarizer:       model,
+	})
+	msgs := []*types.Message{
+		types.NewSystemMessage("sys"),
+		types.NewUserMessage("old question"),
+		types.NewAssistantMessage("old answer"),
+		types.NewUserMessage("q"),
+		types.NewUserMessage("latest"),
+	}
+
+	got, res := w.Fit(context.Background(), msgs)
+	if !res.Summarized || res.Dropped != 2 {
+		t.Fatalf("expected 2 summarized messages, got %+v", res)
+	}
+	if len(got) != 4 || !strings.HasPrefix(got[1].Content, defaultSummaryTag) {
+		t.Fatalf("expected summary after system message, got %+v", got)
+	}
+
+	// The same dropped prefix reuses the cached summary.
+	w.Fit(context.Background(), msgs)
+	if model.callCount != 1 {
+		t.Errorf("expected cached summary, got %d model calls", model.callCount)
+	}
+}
+
+func TestContextWindow_SummaryCacheKeysOnContent(t *testing.T) {
+	model := &mockModel{invokeFn: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+		return &types.ModelResponse{Content: req.Messages[1].Content}, nil
+	}}
+	w, _ := NewContextWindow(ContextWindowConfig{MaxTokens: 3, Counter: oneTokenPerMessage, Summarizer: model})
+
+	// Messages built as literals have no IDs
+	history := func(answer string) []*types.Message {
+		return []*types.Message{
+			{Role: types.RoleUser, Content: "question"},
+			{Role: types.RoleAssistant, Content: answer},
+			{Role: types.RoleUser, Content: "q"},
+			{Role: types.RoleUser, Content: "latest"},
+		}
+	}
+	first, _ := w.Fit(context.Background(), history("cats"))
+	second, _ := w.Fit(context.Background(), history("dogs"))
+	if model.callCount != 2 || !strings.Contains(second[0].Content, "dogs") {
+		t.Fatalf("expected a new summary for a different history, got %q after %d calls (first %q)", second[0].Content, model.callCount, first[0].Content)
+	}
+}
+
+func TestContextWindow_SummarizerFailureFallsBackToTruncation(t *testing.T) {
+	model := &mockModel{invokeFn: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+		return nil, errors.New("boom")
+	}}
+	w, _ := NewContextWindow(ContextWindowConfig{MaxTokens: 2, MaxSummaryTokens: 1, Counter: oneTokenPerMessage, Summarizer: model})
+	msgs := []*types.Message{types.NewUserMessage("a"), types.NewUserMessage("b"), types.NewUserMessage("c")}
+
+	got, res := w.Fit(context.Background(), msgs)
+	if res.Summarized || len(got) != 1 {
+		t.Fatalf("expected plain truncation, got %+v (%d messages)", res, len(got))
+	}
+}
@@ -4,9 +4,10 @@ import (
 	"context"
 	"fmt"
 	"sort"
-	"strings"
 	"sync"
+	"time"
 
+	"github.com/jholhewres/agent-go/pkg/agentgo/rerank"
 	"github.com/jholhewres/agent-go/pkg/agentgo/types"
 	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
 )
@@ -57,16 +58,28 @@ type SearchOptions struct {
 	// RecentCount is the number of recent messages to include
 	// RecentCount 是要包含的最近消息数
 	RecentCount int
+
+	// Reranker reorders the best candidates before Limit is applied
+	// (default: HybridMemoryConfig.Reranker). When it fails, the hybrid
+	// ranking is kept.
+	// Reranker 在应用 Limit 之前对最佳候选结果重新排序（默认：HybridMemoryConfig.Reranker），
+	// 失败时保留混合排序。
+	Reranker rerank.Reranker
+
+	// RerankCandidates is how many candidates are reranked (default: 4x Limit)
+	// RerankCandidates 是参与重排序的候选数（默认：Limit 的 4 倍）
+	RerankCandidates int
 }
 
 // SearchResult represents a memory search result with relevance score
 // SearchResult 表示带有相关性分数的内存搜索结果
 type SearchResult struct {
 	Message     *types.Message `json:"message"`
-	Score       float64        `json:"score"`        // Combined relevance score (0-1)
-	VectorScore float64        `json:"vector_score"` // Vector similarity score
-	TextScore   float64        `json:"text_score"`   // Text similarity score
-	Source      string         `json:"source"`       // "short_term" or "long_term"
+	Score       float64        `json:"score"`                  // Combined relevance score (0-1)
+	VectorScore float64        `json:"vector_score"`           // Vector similarity score
+	TextScore   float64        `json:"text_score"`             // Text similarity score
+	Source      string         `json:"source"`                 // "short_term" or "long_term"
+	RerankScore float64        `json:"rerank_score,omitempty"` // Reranker score; Score equals it when reranked
 }
 
 // HybridMemoryConfig configures the hybrid memory behavior
@@ -97,16 +110,32 @@ type HybridMemoryConfig struct {
 	DefaultVectorWeight float64
 	DefaultTextWeight   float64
 	DefaultMinScore     float64
+
+	// Reranker reorders search results by relevance (optional)
+	// Reranker 按相关性对搜索结果重新排序（可选）
+	Reranker rerank.Reranker
 }
 
-// HybridMemory combines short-term (InMemory) and long-term (VectorDB) storage
-// HybridMemory 结合了短期（InMemory）和长期（VectorDB）存储
+// HybridMemory combines short-term (InMemory) and long-term (VectorDB) storage.
+// Long-term storage is optional at runtime: when the vector DB or the embedder
+// fails, searches fall back to short-term memory only and LongTermErr reports
+// the failure until long-term storage answers again.
+// HybridMemory 结合了短期（InMemory）和长期（VectorDB）存储。
+// 运行时长期存储是可选的：向量数据库或嵌入器失败时，搜索降级为仅使用短期内存，
+// LongTermErr 会报告该错误，直到长期存储恢复。
 type HybridMemory struct {
 	shortTerm *InMemory
 	longTerm  vectordb.VectorDB
 	embedder  vectordb.EmbeddingFunction
 	config    HybridMemoryConfig
 	mu        sync.RWMutex
+
+	// textIndex is the BM25 index of each user's short-term messages, guarded by mu
+	// textIndex 是每个用户短期消息的 BM25 索引，由 mu 保护
+	textIndex map[string]*bm25Index
+
+	healthMu    sync.Mutex
+	longTermErr error
 }
 
 // NewHybridMemory creates a new hybrid memory instance
@@ -146,6 +175,7 @@ func NewHybridMemory(config HybridMemoryConfig) (*HybridMemory, error) {
 		longTerm:  config.VectorDB,
 		embedder:  config.Embedder,
 		config:    config,
+		textIndex: make(map[string]*bm25Index),
 	}, nil
 }
 
@@ -171,6 +201,7 @@ func (m *HybridMemory) Add(message *types.Message, userID ...string) {
 	// Add to short-term memory
 	// 添加到短期内存
 	m.shortTerm.Add(message, uid)
+	m.indexMessage(message, uid)
 
 	// Check if we need to move old messages to long-term
 	// 检查是否需要将旧消息移动到长期存储
@@ -179,6 +210,29 @@ func (m *HybridMemory) Add(message *types.Message, userID ...string) {
 	}
 }
 
+// indexMessage adds a message to the user's text index and drops the messages
+// short-term memory evicted to stay within MaxShortTermMessages
+// indexMessage 将消息加入用户的文本索引，并删除短期内存为保持容量而淘汰的消息
+func (m *HybridMemory) indexMessage(message *types.Message, userID string) {
+	if message == nil {
+		return
+	}
+	idx := m.textIndex[userID]
+	if idx == nil {
+		idx = newBM25Index()
+		m.textIndex[userID] = idx
+	}
+	idx.add(message.ID, message.Content)
+
+	if len(idx.docs) > m.shortTerm.Size(userID) {
+		keep := make(map[string]bool)
+		for _, msg := range m.shortTerm.GetMessages(userID) {
+			keep[msg.ID] = true
+		}
+		idx.retain(keep)
+	}
+}
+
 // moveToLongTerm moves old messages from short-term to long-term storage
 func (m *HybridMemory) moveToLongTerm(userID string) {
 	allMessages := m.shortTerm.GetMessages(userID)
@@ -209,6 +263,7 @@ func (m *HybridMemory) moveToLongTerm(userID string) {
 	// 生成嵌入并存储在向量数据库中
 	ctx := context.Background()
 	documents := make([]vectordb.Document, 0, len(toMove))
+	embedFailed := false
 
 	for _, msg := range toMove {
 		// Skip if already in long-term (check by ID)
@@ -222,8 +277,10 @@ func (m *HybridMemory) moveToLongTerm(userID string) {
 		// 生成嵌入
 		embedding, err := m.embedder.EmbedSingle(ctx, msg.Content)
 		if err != nil {
-			// Log error but continue
-			// 记录错误但继续
+			// Record the failure but continue: the message stays in short-term memory
+			// 记录错误但继续：消息仍保留在短期内存中
+			m.setLongTermErr(fmt.Errorf("embedding failed: %w", err))
+			embedFailed = true
 			continue
 		}
 
@@ -240,36 +297,77 @@ func (m *HybridMemory) moveToLongTerm(userID string) {
 	}
 
 	if len(documents) > 0 {
-		_ = m.longTerm.Add(ctx, documents)
+		if err := m.longTerm.Add(ctx, documents); err != nil {
+			m.setLongTermErr(fmt.Errorf("vector DB add failed: %w", err))
+			return
+		}
+		if !embedFailed {
+			m.setLongTermErr(nil)
+		}
 	}
 }
 
+// LongTermErr returns the error of the last long-term operation, or nil when it
+// succeeded. Searches that hit a long-term failure only cover short-term memory.
+// LongTermErr 返回最近一次长期存储操作的错误，成功时返回 nil。
+// 遇到长期存储失败的搜索仅覆盖短期内存。
+func (m *HybridMemory) LongTermErr() error {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	return m.longTermErr
+}
+
+func (m *HybridMemory) setLongTermErr(err error) {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	m.longTermErr = err
+}
+
 // GetMessages returns all messages for a specific user
 // GetMessages 返回特定用户的所有消息
 func (m *HybridMemory) GetMessages(userID ...string) []*types.Message {
 	return m.shortTerm.GetMessages(userID...)
 }
 
-// Clear removes all messages for a specific user
-// Clear 删除特定用户的所有消息
+// clearTimeout bounds the long-term delete issued by Clear
+const clearTimeout = 30 * time.Second
+
+// Clear removes all messages for a specific user, including the ones moved to
+// long-term storage. The vector DB delete is bounded by a 30 second timeout;
+// use ClearContext to pass your own context. If the delete fails, LongTermErr
+// reports it.
+// Clear 删除特定用户的所有消息，包括已移至长期存储的消息。
+// 向量数据库删除受 30 秒超时限制；使用 ClearContext 传入自定义 context。
+// 如果删除失败，LongTermErr 会报告该错误。
 func (m *HybridMemory) Clear(userID ...string) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), clearTimeout)
+	defer cancel()
+	m.ClearContext(ctx, userID...)
+}
 
+// ClearContext is Clear with a caller-supplied context for the vector DB delete.
+// The memory lock is released before the delete, so a slow vector DB does not
+// block other users' reads and writes.
+// ClearContext 与 Clear 相同，但使用调用方提供的 context 执行向量数据库删除。
+// 删除前会释放内存锁，因此较慢的向量数据库不会阻塞其他用户的读写。
+func (m *HybridMemory) ClearContext(ctx context.Context, userID ...string) {
 	uid := getUserID(userID...)
 
 	// Clear short-term
 	// 清除短期内存
+	m.mu.Lock()
 	m.shortTerm.Clear(uid)
+	delete(m.textIndex, uid)
+	m.mu.Unlock()
 
 	// Clear long-term for this user (delete by filter)
 	// 清除此用户的长期存储（通过过滤器删除）
-	ctx := context.Background()
-	// Note: This requires vector DB to support delete by filter
-	// Many vector DBs don't support this efficiently, so we skip for now
-	// 注意：这需要向量数据库支持按过滤器删除
-	// 许多向量数据库不高效支持此功能，所以我们暂时跳过
-	_ = ctx
+	filter := map[string]interface{}{"user_id": uid}
+	if err := m.longTerm.DeleteByFilter(ctx, filter); err != nil {
+		m.setLongTermErr(fmt.Errorf("vector DB delete failed: %w", err))
+		return
+	}
+	m.setLongTermErr(nil)
 }
 
 // Size returns the number of messages in short-term memory
@@ -311,6 +409,9 @@ func (m *HybridMemory) SearchWithOptions(ctx context.Context, query string, opti
 	if options.MinScore < 0 {
 		options.MinScore = m.config.DefaultMinScore
 	}
+	if options.Reranker == nil {
+		options.Reranker = m.config.Reranker
+	}
 
 	// Normalize weights
 	totalWeight := options.VectorWeight + options.TextWeight
@@ -334,7 +435,16 @@ func (m *HybridMemory) SearchWithOptions(ctx context.Context, query string, opti
 	// 2. Search long-term memory (vector-based)
 	// 2. 搜索长期内存（基于向量）
 	longTermResults, err := m.searchLongTerm(ctx, query, uid, options)
-	if err == nil {
+	if err != nil {
+		// Long-term storage is down: degrade to short-term only, scoring by text
+		// similarity alone so results are not held back by the missing vector score
+		// 长期存储不可用：降级为仅短期搜索，仅按文本相似度评分
+		m.setLongTermErr(fmt.Errorf("vector DB query failed: %w", err))
+		for _, result := range resultMap {
+			result.Score = result.TextScore
+		}
+	} else {
+		m.setLongTermErr(nil)
 		for i := range longTermResults {
 			if existing, exists := resultMap[longTermResults[i].Message.ID]; exists {
 				// Merge scores: take the maximum
@@ -377,8 +487,14 @@ func (m *HybridMemory) SearchWithOptions(ctx context.Context, query string, opti
 		return results[i].Score > results[j].Score
 	})
 
-	// 5. Apply limit
-	// 5. 应用限制
+	// 5. Rerank the best candidates
+	// 5. 对最佳候选结果重新排序
+	if options.Reranker != nil && len(results) > 0 {
+		results = m.rerankResults(ctx, query, results, options)
+	}
+
+	// 6. Apply limit
+	// 6. 应用限制
 	if len(results) > options.Limit {
 		results = results[:options.Limit]
 	}
@@ -386,16 +502,17 @@ func (m *HybridMemory) SearchWithOptions(ctx context.Context, query string, opti
 	return results, nil
 }
 
-// searchShortTerm performs text-based search on short-term memory
+// searchShortTerm performs BM25 full-text search on short-term memory
 func (m *HybridMemory) searchShortTerm(query string, userID string, options SearchOptions) []SearchResult {
-	messages := m.shortTerm.GetMessages(userID)
-	queryLower := strings.ToLower(query)
+	idx := m.textIndex[userID]
+	if idx == nil {
+		return nil
+	}
+	scores := idx.search(query)
 
 	var results []SearchResult
-	for _, msg := range messages {
-		// Calculate text similarity using simple keyword matching
-		// 使用简单的关键词匹配计算文本相似度
-		textScore := calculateTextSimilarity(queryLower, strings.ToLower(msg.Content))
+	for _, msg := range m.shortTerm.GetMessages(userID) {
+		textScore := scores[msg.ID]
 
 		if textScore > 0 {
 			results = append(results, SearchResult{
@@ -416,7 +533,11 @@ func (m *HybridMemory) searchLongTerm(ctx context.Context, query string, userID
 	// Query vector DB with user filter
 	// 使用用户过滤器查询向量数据库
 	filter := map[string]interface{}{"user_id": userID}
-	vectorResults, err := m.longTerm.Query(ctx, query, options.Limit*2, filter) // Get more candidates
+	candidates := options.Limit * 2 // Get more candidates
+	if options.Reranker != nil {
+		candidates = max(candidates, rerank.Candidates(options.Limit, options.RerankCandidates))
+	}
+	vectorResults, err := m.longTerm.Query(ctx, query, candidates, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -452,28 +573,32 @@ func (m *HybridMemory) searchLongTerm(ctx context.Context, query string, userID
 	return results, nil
 }
 
-// calculateTextSimilarity calculates simple text similarity score
-// Uses word overlap as a simple metric (more sophisticated methods could be added)
-func calculateTextSimilarity(query, text string) float64 {
-	queryWords := strings.Fields(query)
-	if len(queryWords) == 0 {
-		return 0
+// rerankResults reorders the top candidates of results, sorted by hybrid
+// score, with the reranker; the hybrid order is kept if reranking fails
+// rerankResults 使用重排序器对按混合分数排序的前若干候选结果重新排序；重排序失败时保留混合排序
+func (m *HybridMemory) rerankResults(ctx context.Context, query string, results []SearchResult, options SearchOptions) []SearchResult {
+	candidates := results
+	if n := rerank.Candidates(options.Limit, options.RerankCandidates); len(candidates) > n {
+		candidates = candidates[:n]
 	}
-
-	textWords := strings.Fields(text)
-	textWordSet := make(map[string]bool)
-	for _, w := range textWords {
-		textWordSet[w] = true
+	documents := make([]string, len(candidates))
+	for i, result := range candidates {
+		documents[i] = result.Message.Content
 	}
 
-	matches := 0
-	for _, qw := range queryWords {
-		if textWordSet[qw] {
-			matches++
+	ranked, err := options.Reranker.Rerank(ctx, query, documents, options.Limit)
+	if err != nil {
+		return results
+	}
+	reranked := make([]SearchResult, 0, len(ranked))
+	for _, r := range ranked {
+		if r.Index < 0 || r.Index >= len(candidates) {
+			return results
 		}
+		result := candidates[r.Index]
+		result.RerankScore = r.Score
+		result.Score = r.Score
+		reranked = append(reranked, result)
 	}
-
-	// Jaccard-like similarity
-	// 类似 Jaccard 的相似度
-	return float64(matches) / float64(len(queryWords))
+	return reranked
 }
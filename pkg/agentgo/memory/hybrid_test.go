@@ -2,10 +2,14 @@ package memory
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"testing"
 
+	"github.com/jholhewres/agent-go/pkg/agentgo/rerank"
 	"github.com/jholhewres/agent-go/pkg/agentgo/types"
 	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
 )
@@ -60,6 +64,40 @@ func (m *mockVectorDB) Delete(ctx context.Context, ids []string) error {
 	return nil
 }
 
+func (m *mockVectorDB) DeleteByFilter(ctx context.Context, filter map[string]interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, doc := range m.docs {
+		match := true
+		for key, value := range filter {
+			if doc.Metadata[key] != value {
+				match = false
+			}
+		}
+		if match {
+			delete(m.docs, id)
+		}
+	}
+	return nil
+}
+
+func (m *mockVectorDB) ListCollections(ctx context.Context) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var names []string
+	for name := range m.collections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (m *mockVectorDB) CollectionStats(ctx context.Context, name string) (vectordb.CollectionStats, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return vectordb.CollectionStats{Name: name, Count: len(m.docs), DiskSize: -1}, nil
+}
+
 func (m *mockVectorDB) Query(ctx context.Context, query string, limit int, filter map[string]interface{}) ([]vectordb.SearchResult, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -312,6 +350,103 @@ func TestHybridMemoryClear(t *testing.T) {
 	}
 }
 
+// TestHybridMemoryClearPurgesLongTerm tests that Clear deletes the user's long-term messages
+func TestHybridMemoryClearPurgesLongTerm(t *testing.T) {
+	vdb := newMockVectorDB()
+	mem, err := NewHybridMemory(HybridMemoryConfig{
+		VectorDB:          vdb,
+		Embedder:          newMockEmbedder(),
+		LongTermThreshold: 1,
+	})
+	if err != nil {
+		t.Fatalf("failed to create hybrid memory: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		mem.Add(types.NewUserMessage(fmt.Sprintf("alice message %d", i)), "alice")
+		mem.Add(types.NewUserMessage(fmt.Sprintf("bob message %d", i)), "bob")
+	}
+	if n, _ := vdb.Count(context.Background()); n != 4 {
+		t.Fatalf("expected 4 long-term messages before clear, got %d", n)
+	}
+
+	mem.Clear("alice")
+
+	for _, doc := range vdb.docs {
+		if doc.Metadata["user_id"] == "alice" {
+			t.Errorf("long-term message of cleared user survived: %q", doc.Content)
+		}
+	}
+	if n, _ := vdb.Count(context.Background()); n != 2 {
+		t.Errorf("expected bob's 2 long-term messages to remain, got %d", n)
+	}
+	if mem.LongTermErr() != nil {
+		t.Errorf("unexpected LongTermErr: %v", mem.LongTermErr())
+	}
+
+	// A failed purge is reported instead of silently ignored
+	failing, err := NewHybridMemory(HybridMemoryConfig{
+		VectorDB: &failingVectorDB{newMockVectorDB()},
+		Embedder: newMockEmbedder(),
+	})
+	if err != nil {
+		t.Fatalf("failed to create hybrid memory: %v", err)
+	}
+	failing.Clear("alice")
+	if err := failing.LongTermErr(); err == nil || !strings.Contains(err.Error(), "delete") {
+		t.Errorf("expected LongTermErr to report the failed delete, got %v", err)
+	}
+}
+
+// blockingVectorDB simulates a vector DB whose deletes hang until the context ends
+type blockingVectorDB struct {
+	*mockVectorDB
+	started chan struct{}
+}
+
+func (b *blockingVectorDB) DeleteByFilter(ctx context.Context, filter map[string]interface{}) error {
+	close(b.started)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// TestHybridMemoryClearContextDoesNotHoldLock tests that a hanging delete
+// neither blocks other operations nor outlives its context
+func TestHybridMemoryClearContextDoesNotHoldLock(t *testing.T) {
+	vdb := &blockingVectorDB{mockVectorDB: newMockVectorDB(), started: make(chan struct{})}
+	mem, err := NewHybridMemory(HybridMemoryConfig{
+		VectorDB: vdb,
+		Embedder: newMockEmbedder(),
+	})
+	if err != nil {
+		t.Fatalf("failed to create hybrid memory: %v", err)
+	}
+	mem.Add(types.NewUserMessage("alice message"), "alice")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		mem.ClearContext(ctx, "alice")
+	}()
+	<-vdb.started
+
+	// The delete is in flight: other users can still write and search
+	mem.Add(types.NewUserMessage("bob message"), "bob")
+	if _, err := mem.Search(context.Background(), "bob", 1, "bob"); err != nil {
+		t.Errorf("search during clear failed: %v", err)
+	}
+	if mem.Size("alice") != 0 {
+		t.Errorf("expected alice's short-term memory to be cleared before the delete")
+	}
+
+	cancel()
+	<-done
+	if err := mem.LongTermErr(); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected LongTermErr to report the canceled delete, got %v", err)
+	}
+}
+
 // TestHybridMemorySearch tests searching memory
 func TestHybridMemorySearch(t *testing.T) {
 	vdb := newMockVectorDB()
@@ -366,6 +501,49 @@ func TestHybridMemorySearch(t *testing.T) {
 	}
 }
 
+// failingVectorDB simulates a vector DB that is down
+type failingVectorDB struct {
+	*mockVectorDB
+}
+
+func (f *failingVectorDB) Query(ctx context.Context, query string, limit int, filter map[string]interface{}) ([]vectordb.SearchResult, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (f *failingVectorDB) DeleteByFilter(ctx context.Context, filter map[string]interface{}) error {
+	return errors.New("connection refused")
+}
+
+// TestHybridMemorySearchDegradesToShortTerm tests the fallback when the vector DB is down
+func TestHybridMemorySearchDegradesToShortTerm(t *testing.T) {
+	mem, err := NewHybridMemory(HybridMemoryConfig{
+		VectorDB: &failingVectorDB{newMockVectorDB()},
+		Embedder: newMockEmbedder(),
+	})
+	if err != nil {
+		t.Fatalf("failed to create hybrid memory: %v", err)
+	}
+
+	userID := "test-user"
+	mem.Add(types.NewUserMessage("the deploy runs on fridays"), userID)
+	mem.Add(types.NewUserMessage("unrelated chatter"), userID)
+
+	results, err := mem.Search(context.Background(), "deploy fridays", 5, userID)
+	if err != nil {
+		t.Fatalf("search should degrade instead of failing: %v", err)
+	}
+	if len(results) != 1 || results[0].Source != "short_term" {
+		t.Fatalf("expected one short-term result, got %+v", results)
+	}
+	// Scored by text similarity alone while long-term storage is down
+	if results[0].Score != 1 {
+		t.Errorf("expected full text score, got %f", results[0].Score)
+	}
+	if mem.LongTermErr() == nil {
+		t.Error("expected LongTermErr to report the vector DB failure")
+	}
+}
+
 // TestHybridMemorySearchWithOptions tests advanced search options
 func TestHybridMemorySearchWithOptions(t *testing.T) {
 	mem, err := NewHybridMemory(HybridMemoryConfig{
@@ -419,6 +597,63 @@ func TestHybridMemorySearchWithOptions(t *testing.T) {
 	}
 }
 
+// TestHybridMemorySearchReranks tests reranking of the hybrid candidates
+func TestHybridMemorySearchReranks(t *testing.T) {
+	var reranked []string
+	reranker := rerank.Func(func(ctx context.Context, query string, documents []string, topN int) ([]rerank.Result, error) {
+		reranked = documents
+		results := make([]rerank.Result, len(documents))
+		for i, doc := range documents {
+			results[i] = rerank.Result{Index: i, Score: 0.1}
+			if strings.Contains(doc, "invoice") {
+				results[i].Score = 0.9
+			}
+		}
+		return rerank.Top(results, topN), nil
+	})
+
+	mem, err := NewHybridMemory(HybridMemoryConfig{
+		VectorDB: newMockVectorDB(),
+		Embedder: newMockEmbedder(),
+		Reranker: reranker,
+	})
+	if err != nil {
+		t.Fatalf("failed to create hybrid memory: %v", err)
+	}
+
+	userID := "test-user"
+	mem.Add(types.NewUserMessage("billing question about billing"), userID)
+	mem.Add(types.NewUserMessage("my billing question is about the invoice"), userID)
+	mem.Add(types.NewUserMessage("weather"), userID)
+
+	ctx := context.Background()
+	results, err := mem.Search(ctx, "billing question", 1, userID)
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(results) != 1 || !strings.Contains(results[0].Message.Content, "invoice") {
+		t.Fatalf("expected the reranked invoice message first, got %+v", results)
+	}
+	if results[0].Score != 0.9 || results[0].RerankScore != 0.9 || results[0].TextScore == 0 {
+		t.Errorf("unexpected scores %+v", results[0])
+	}
+	if len(reranked) != 2 {
+		t.Errorf("expected the 2 matching candidates to be reranked, got %v", reranked)
+	}
+
+	// A failing reranker keeps the hybrid ranking
+	failing := rerank.Func(func(ctx context.Context, query string, documents []string, topN int) ([]rerank.Result, error) {
+		return nil, errors.New("rerank service unavailable")
+	})
+	results, err = mem.SearchWithOptions(ctx, "billing question", SearchOptions{Limit: 1, Reranker: failing}, userID)
+	if err != nil {
+		t.Fatalf("search should not fail when reranking fails: %v", err)
+	}
+	if len(results) != 1 || results[0].RerankScore != 0 || results[0].Score != results[0].TextScore*0.3 {
+		t.Errorf("expected the hybrid ranking, got %+v", results)
+	}
+}
+
 // TestHybridMemoryMultiTenant tests multi-tenant isolation
 func TestHybridMemoryMultiTenant(t *testing.T) {
 	mem, err := NewHybridMemory(HybridMemoryConfig{
@@ -484,55 +719,6 @@ func TestHybridMemoryConcurrentAccess(t *testing.T) {
 	}
 }
 
-// TestCalculateTextSimilarity tests text similarity calculation
-func TestCalculateTextSimilarity(t *testing.T) {
-	tests := []struct {
-		name    string
-		query   string
-		text    string
-		wantMin float64
-		wantMax float64
-	}{
-		{
-			name:    "exact match",
-			query:   "hello world",
-			text:    "hello world",
-			wantMin: 0.9,
-			wantMax: 1.0,
-		},
-		{
-			name:    "partial match",
-			query:   "hello world",
-			text:    "hello there",
-			wantMin: 0.4,
-			wantMax: 0.6,
-		},
-		{
-			name:    "no match",
-			query:   "hello",
-			text:    "goodbye",
-			wantMin: 0.0,
-			wantMax: 0.0,
-		},
-		{
-			name:    "empty query",
-			query:   "",
-			text:    "any text",
-			wantMin: 0.0,
-			wantMax: 0.0,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			score := calculateTextSimilarity(tt.query, tt.text)
-			if score < tt.wantMin || score > tt.wantMax {
-				t.Errorf("score = %f, want between [%f, %f]", score, tt.wantMin, tt.wantMax)
-			}
-		})
-	}
-}
-
 // TestHybridMemoryBackwardCompatibility tests backward compatibility with Memory interface
 func TestHybridMemoryBackwardCompatibility(t *testing.T) {
 	mem, err := NewHybridMemory(HybridMemoryConfig{
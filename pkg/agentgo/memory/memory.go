@@ -3,7 +3,7 @@ package memory
 import (
 	"sync"
 
-	"github.com/google/uuid"
+	"github.com/jholhewres/agent-go/pkg/agentgo/ids"
 	"github.com/jholhewres/agent-go/pkg/agentgo/types"
 )
 
@@ -72,7 +72,7 @@ func (m *InMemory) Add(message *types.Message, userID ...string) {
 
 	// Ensure message has an ID for traceability
 	if message != nil && message.ID == "" {
-		message.ID = "msg-" + uuid.NewString()
+		message.ID = ids.Prefixed("msg")
 	}
 
 	// Initialize user's message list if not exists
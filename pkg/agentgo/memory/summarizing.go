@@ -30,6 +30,13 @@ type SummarizingConfig struct {
 	// Threshold triggers compaction when len(messages) > Threshold (default 50).
 	Threshold int
 
+	// TokenThreshold also triggers compaction when the messages exceed this many
+	// tokens as counted by Counter (default 0: disabled).
+	TokenThreshold int
+
+	// Counter counts tokens for TokenThreshold (default models.HeuristicTokenCounter).
+	Counter models.TokenCounter
+
 	// PreserveLast always keeps the last N messages verbatim (default 10).
 	PreserveLast int
 
@@ -44,7 +51,9 @@ type SummarizingConfig struct {
 }
 
 // SummarizingMemory wraps any Memory and condenses old messages via an LLM
-// when the message count exceeds a configurable threshold.
+// when the message count or token count exceeds a configurable threshold.
+// Leading system messages (such as agent instructions) are never summarized,
+// and an assistant tool call is never separated from its tool results.
 type SummarizingMemory struct {
 	inner Memory
 	cfg   SummarizingConfig
@@ -63,6 +72,12 @@ func NewSummarizingMemory(cfg SummarizingConfig) (*SummarizingMemory, error) {
 	if cfg.Threshold <= 0 {
 		cfg.Threshold = defaultThreshold
 	}
+	if cfg.TokenThreshold < 0 {
+		return nil, fmt.Errorf("SummarizingConfig.TokenThreshold must not be negative")
+	}
+	if cfg.TokenThreshold > 0 && cfg.Counter == nil {
+		cfg.Counter = models.HeuristicTokenCounter{}
+	}
 	if cfg.PreserveLast <= 0 {
 		cfg.PreserveLast = defaultPreserveLast
 	}
@@ -91,7 +106,7 @@ func (s *SummarizingMemory) Add(message *types.Message, userID ...string) {
 
 	s.inner.Add(message, userID...)
 
-	if s.inner.Size(userID...) > s.cfg.Threshold {
+	if s.shouldCompact(userID...) {
 		if err := s.compact(context.Background(), userID...); err != nil {
 			log.Printf("summarizing_memory: compact failed, keeping original state: %v", err)
 		}
@@ -113,18 +128,44 @@ func (s *SummarizingMemory) Size(userID ...string) int {
 	return s.inner.Size(userID...)
 }
 
+// shouldCompact reports whether either threshold is exceeded.
+func (s *SummarizingMemory) shouldCompact(userID ...string) bool {
+	if s.inner.Size(userID...) > s.cfg.Threshold {
+		return true
+	}
+	if s.cfg.TokenThreshold > 0 {
+		return s.cfg.Counter.CountTokens(s.inner.GetMessages(userID...)) > s.cfg.TokenThreshold
+	}
+	return false
+}
+
 // compact condenses messages older than the last PreserveLast into a single
-// System summary message. If the LLM call fails the inner state is untouched.
+// System summary message placed after any pinned leading system messages.
+// Previous summaries are folded into the new one. If the LLM call fails the
+// inner state is untouched.
 func (s *SummarizingMemory) compact(ctx context.Context, userID ...string) error {
 	all := s.inner.GetMessages(userID...)
 
+	pinnedEnd := 0
+	for pinnedEnd < len(all) && all[pinnedEnd].Role == types.RoleSystem &&
+		!strings.HasPrefix(all[pinnedEnd].Content, s.cfg.SummaryTag) {
+		pinnedEnd++
+	}
+
+	// Move the cut back so tool results stay with the assistant call that produced them.
+	cut := len(all) - s.cfg.PreserveLast
+	for cut > pinnedEnd && all[cut].Role == types.RoleTool {
+		cut--
+	}
+
 	// Not enough messages to summarize after preserving the tail.
-	if len(all) <= s.cfg.PreserveLast {
+	if cut <= pinnedEnd {
 		return nil
 	}
 
-	toSummarize := all[:len(all)-s.cfg.PreserveLast]
-	keep := all[len(all)-s.cfg.PreserveLast:]
+	pinned := all[:pinnedEnd]
+	toSummarize := all[pinnedEnd:cut]
+	keep := all[cut:]
 
 	// Build the summarization prompt content.
 	var sb strings.Builder
@@ -148,8 +189,11 @@ func (s *SummarizingMemory) compact(ctx context.Context, userID ...string) error
 	summaryContent := s.cfg.SummaryTag + " " + resp.Content
 	summaryMsg := types.NewSystemMessage(summaryContent)
 
-	// Atomically replace inner memory with [summary, ...keep].
+	// Atomically replace inner memory with [...pinned, summary, ...keep].
 	s.inner.Clear(userID...)
+	for _, msg := range pinned {
+		s.inner.Add(msg, userID...)
+	}
 	s.inner.Add(summaryMsg, userID...)
 	for _, msg := range keep {
 		s.inner.Add(msg, userID...)
@@ -234,3 +234,80 @@ func TestNewSummarizingMemory_ValidationErrors(t *testing.T) {
 		t.Error("expected error when Model is nil")
 	}
 }
+
+// Test 9: TokenThreshold triggers compaction before the message threshold.
+func TestSummarizingMemory_TokenThreshold(t *testing.T) {
+	model := &mockModel{}
+	sm, err := NewSummarizingMemory(SummarizingConfig{
+		Inner:          NewInMemory(200),
+		Model:          model,
+		Threshold:      100,
+		TokenThreshold: 3,
+		PreserveLast:   1,
+		Counter: models.TokenCounterFunc(func(messages []*types.Message) int {
+			return len(messages)
+		}),
+	})
+	if err != nil {
+		t.Fatalf("NewSummarizingMemory: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		sm.Add(types.NewUserMessage("msg"))
+	}
+
+	if model.callCount != 1 {
+		t.Errorf("expected 1 compaction, got %d", model.callCount)
+	}
+	if sm.Size() != 2 {
+		t.Errorf("expected summary + 1 kept message, got %d", sm.Size())
+	}
+
+	if _, err := NewSummarizingMemory(SummarizingConfig{Inner: NewInMemory(10), Model: model, TokenThreshold: -1}); err == nil {
+		t.Error("expected error for negative TokenThreshold")
+	}
+}
+
+// Test 10: leading instructions are pinned and earlier summaries are folded in.
+func TestSummarizingMemory_PinsInstructions(t *testing.T) {
+	model := &mockModel{}
+	sm := newTestSummarizing(t, 4, 1, model)
+
+	sm.Add(types.NewSystemMessage("you are helpful"))
+	for i := 0; i < 8; i++ {
+		sm.Add(types.NewUserMessage("msg"))
+	}
+
+	msgs := sm.GetMessages()
+	if msgs[0].Content != "you are helpful" {
+		t.Errorf("instructions should stay first, got %q", msgs[0].Content)
+	}
+	summaries := 0
+	for _, msg := range msgs {
+		if strings.HasPrefix(msg.Content, defaultSummaryTag) {
+			summaries++
+		}
+	}
+	if summaries != 1 {
+		t.Errorf("expected exactly 1 summary message, got %d", summaries)
+	}
+}
+
+// Test 11: the tail never starts with a tool result separated from its call.
+func TestSummarizingMemory_KeepsToolCallWithResults(t *testing.T) {
+	model := &mockModel{}
+	sm := newTestSummarizing(t, 4, 1, model)
+
+	call := types.NewAssistantMessage("")
+	call.ToolCalls = []types.ToolCall{{ID: "c1", Function: types.ToolCallFunction{Name: "add"}}}
+	sm.Add(types.NewUserMessage("a"))
+	sm.Add(types.NewUserMessage("b"))
+	sm.Add(types.NewUserMessage("c"))
+	sm.Add(call)
+	sm.Add(types.NewToolMessage("c1", "3"))
+
+	msgs := sm.GetMessages()
+	if len(msgs) != 3 || len(msgs[1].ToolCalls) != 1 || msgs[2].Role != types.RoleTool {
+		t.Fatalf("expected [summary, call, tool result], got %d messages", len(msgs))
+	}
+}
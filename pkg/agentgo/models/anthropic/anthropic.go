@@ -1,6 +1,7 @@
 package anthropic
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -179,20 +180,39 @@ func (a *Anthropic) InvokeStream(ctx context.Context, req *models.InvokeRequest)
 		defer close(chunks)
 		defer resp.Body.Close()
 
-		decoder := json.NewDecoder(resp.Body)
-		for {
+		// Input tokens come with message_start, output tokens with message_delta;
+		// the final chunk carries both
+		var usage ClaudeUsage
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data:")
+			if !ok {
+				continue
+			}
 			var event StreamEvent
-			if err := decoder.Decode(&event); err != nil {
-				if err != io.EOF {
-					chunks <- types.ResponseChunk{
-						Done:  true,
-						Error: err,
-					}
+			if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &event); err != nil {
+				chunks <- types.ResponseChunk{
+					Done:  true,
+					Error: err,
 				}
 				return
 			}
+			if event.Message != nil {
+				usage.InputTokens = event.Message.Usage.InputTokens
+			}
+			if event.Usage != nil {
+				usage.OutputTokens = event.Usage.OutputTokens
+			}
 
 			chunk := a.convertStreamEvent(&event)
+			if chunk.Done && chunk.Error == nil {
+				chunk.Usage = &types.Usage{
+					PromptTokens:     usage.InputTokens,
+					CompletionTokens: usage.OutputTokens,
+					TotalTokens:      usage.InputTokens + usage.OutputTokens,
+				}
+			}
 			select {
 			case chunks <- chunk:
 				if chunk.Done {
@@ -206,6 +226,12 @@ func (a *Anthropic) InvokeStream(ctx context.Context, req *models.InvokeRequest)
 				return
 			}
 		}
+		if err := scanner.Err(); err != nil {
+			chunks <- types.ResponseChunk{
+				Done:  true,
+				Error: err,
+			}
+		}
 	}()
 
 	return chunks, nil
@@ -238,6 +264,17 @@ func (a *Anthropic) buildClaudeRequest(req *models.InvokeRequest) *ClaudeRequest
 		thinkingCfg = &cfgCopy
 	}
 
+	// A reasoning budget on the request overrides the configured one
+	if budget := models.ReasoningBudget(req); budget > 0 {
+		if _, unsupported := nonThinkingModels[strings.ToLower(a.ID)]; !unsupported {
+			if thinkingCfg == nil {
+				thinkingCfg = &ThinkingConfig{}
+			}
+			thinkingCfg.Type = "enabled"
+			thinkingCfg.BudgetTokens = budget
+		}
+	}
+
 	if req.Extra != nil {
 		if raw, ok := req.Extra["thinking"]; ok {
 			if cfgMap, ok := raw.(map[string]interface{}); ok {
@@ -305,6 +342,10 @@ func (a *Anthropic) buildClaudeRequest(req *models.InvokeRequest) *ClaudeRequest
 		if strings.EqualFold(thinkingCfg.Type, "disabled") || thinkingCfg.BudgetTokens > 0 {
 			claudeReq.Thinking = thinkingCfg
 		}
+		// max_tokens counts the thinking tokens, so it must exceed the budget
+		if thinkingCfg.BudgetTokens > 0 && claudeReq.MaxTokens <= thinkingCfg.BudgetTokens {
+			claudeReq.MaxTokens += thinkingCfg.BudgetTokens
+		}
 	}
 
 	if len(a.config.Betas) > 0 {
@@ -493,8 +534,11 @@ func (a *Anthropic) convertStreamEvent(event *StreamEvent) types.ResponseChunk {
 
 	switch event.Type {
 	case "content_block_delta":
-		if event.Delta.Type == "text_delta" {
+		switch event.Delta.Type {
+		case "text_delta":
 			chunk.Content = event.Delta.Text
+		case "thinking_delta":
+			chunk.ReasoningContent = event.Delta.Thinking
 		}
 	case "message_stop":
 		chunk.Done = true
@@ -511,6 +555,7 @@ func (a *Anthropic) setHeaders(req *http.Request) {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("x-api-key", a.config.APIKey)
 	req.Header.Set("anthropic-version", apiVersion)
+	models.ApplyContextHeaders(req)
 }
 
 // ClaudeRequest represents the Anthropic API request
@@ -576,6 +621,10 @@ type StreamEvent struct {
 	Type  string      `json:"type"`
 	Delta StreamDelta `json:"delta,omitempty"`
 	Error StreamError `json:"error,omitempty"`
+
+	// Message is sent with message_start, Usage with message_delta
+	Message *ClaudeResponse `json:"message,omitempty"`
+	Usage   *ClaudeUsage    `json:"usage,omitempty"`
 }
 
 // StreamDelta represents delta content in streaming
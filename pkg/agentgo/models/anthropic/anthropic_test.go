@@ -3,7 +3,9 @@ package anthropic
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
@@ -143,6 +145,43 @@ func TestAnthropicBuildRequestThinkingConfig(t *testing.T) {
 	}
 }
 
+func TestAnthropicBuildRequestReasoningBudget(t *testing.T) {
+	model, err := New("claude-sonnet-4-5", Config{
+		APIKey:    "test-key",
+		MaxTokens: 1024,
+		Thinking:  &ThinkingConfig{Type: "enabled", BudgetTokens: 512},
+	})
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	claudeReq := model.buildClaudeRequest(&models.InvokeRequest{ReasoningEffort: models.ReasoningEffortMedium})
+	if claudeReq.Thinking == nil || claudeReq.Thinking.BudgetTokens != 8192 {
+		t.Fatalf("Thinking = %+v, want a budget of 8192", claudeReq.Thinking)
+	}
+	if claudeReq.MaxTokens <= claudeReq.Thinking.BudgetTokens {
+		t.Errorf("MaxTokens = %d, want more than the thinking budget", claudeReq.MaxTokens)
+	}
+
+	claudeReq = model.buildClaudeRequest(&models.InvokeRequest{ReasoningEffort: models.ReasoningEffortHigh, MaxReasoningTokens: 2048})
+	if claudeReq.Thinking == nil || claudeReq.Thinking.BudgetTokens != 2048 {
+		t.Fatalf("Thinking = %+v, want a budget of 2048", claudeReq.Thinking)
+	}
+
+	haiku, _ := New("claude-3-5-haiku-latest", Config{APIKey: "test-key"})
+	if claudeReq := haiku.buildClaudeRequest(&models.InvokeRequest{MaxReasoningTokens: 2048}); claudeReq.Thinking != nil {
+		t.Errorf("Thinking = %+v, want none for a model without extended thinking", claudeReq.Thinking)
+	}
+
+	chunk := model.convertStreamEvent(&StreamEvent{
+		Type:  "content_block_delta",
+		Delta: StreamDelta{Type: "thinking_delta", Thinking: "Let me think"},
+	})
+	if chunk.ReasoningContent != "Let me think" || chunk.Content != "" {
+		t.Errorf("thinking delta = %+v, want reasoning only", chunk)
+	}
+}
+
 func TestAnthropicConvertResponseReasoning(t *testing.T) {
 	model := &Anthropic{
 		BaseModel: models.BaseModel{ID: "claude-3-5-sonnet", Provider: "anthropic"},
@@ -768,3 +807,45 @@ func TestNew_WithTimeout(t *testing.T) {
 		})
 	}
 }
+
+func TestInvokeStream_Usage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: message_start\n"+
+			`data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],"usage":{"input_tokens":25,"output_tokens":1}}}`+"\n\n"+
+			"event: content_block_delta\n"+
+			`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}`+"\n\n"+
+			"event: message_delta\n"+
+			`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":15}}`+"\n\n"+
+			"event: message_stop\n"+
+			`data: {"type":"message_stop"}`+"\n\n")
+	}))
+	defer server.Close()
+
+	model, err := New("claude-3-5-sonnet-20241022", Config{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	stream, err := model.InvokeStream(context.Background(), &models.InvokeRequest{Messages: []*types.Message{types.NewUserMessage("hi")}})
+	if err != nil {
+		t.Fatalf("InvokeStream() error = %v", err)
+	}
+
+	var content string
+	var usage *types.Usage
+	for chunk := range stream {
+		if chunk.Error != nil {
+			t.Fatalf("stream error = %v", chunk.Error)
+		}
+		content += chunk.Content
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+	}
+	if content != "Hello" {
+		t.Errorf("content = %q, want Hello", content)
+	}
+	if usage == nil || usage.PromptTokens != 25 || usage.CompletionTokens != 15 || usage.TotalTokens != 40 {
+		t.Fatalf("usage = %+v, want 25 prompt and 15 completion tokens", usage)
+	}
+}
@@ -22,6 +22,15 @@ type InvokeRequest struct {
 	Stream         bool
 	Extra          map[string]interface{}
 	ResponseFormat *ResponseFormat // Optional: structured output constraint
+	Seed           *int            // Optional: sampling seed for providers that support it
+
+	// ReasoningEffort asks reasoning models to think less or more: "low",
+	// "medium" or "high". Providers that take a token budget map it with
+	// ReasoningBudget; others ignore it.
+	ReasoningEffort string
+	// MaxReasoningTokens caps the tokens a reasoning model may spend thinking,
+	// taking precedence over ReasoningEffort for providers that take a budget
+	MaxReasoningTokens int
 }
 
 // ToolDefinition defines a tool that can be called by the model
@@ -42,6 +42,7 @@ func (c *HTTPClient) PostJSON(ctx context.Context, url string, headers map[strin
 	for key, value := range headers {
 		req.Header.Set(key, value)
 	}
+	ApplyContextHeaders(req)
 
 	// Execute request
 	resp, err := c.client.Do(req)
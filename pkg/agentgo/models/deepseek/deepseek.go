@@ -3,6 +3,9 @@ package deepseek
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
 
 	"github.com/jholhewres/agent-go/pkg/agentgo/models"
 	"github.com/jholhewres/agent-go/pkg/agentgo/types"
@@ -42,6 +45,7 @@ func New(modelID string, config Config) (*DeepSeek, error) {
 
 	clientConfig := openai.DefaultConfig(config.APIKey)
 	clientConfig.BaseURL = baseURL
+	clientConfig.HTTPClient = &http.Client{Transport: models.NewHeaderTransport(nil)}
 
 	return &DeepSeek{
 		BaseModel: models.BaseModel{
@@ -82,6 +86,14 @@ func (d *DeepSeek) Invoke(ctx context.Context, req *models.InvokeRequest) (*type
 		},
 	}
 
+	// deepseek-reasoner returns its chain of thought apart from the answer
+	if choice.Message.ReasoningContent != "" {
+		modelResp.ReasoningContent = types.NewReasoningContent(choice.Message.ReasoningContent)
+		if details := resp.Usage.CompletionTokensDetails; details != nil && details.ReasoningTokens > 0 {
+			modelResp.ReasoningContent.WithTokenCount(details.ReasoningTokens)
+		}
+	}
+
 	// Convert tool calls if present
 	if len(choice.Message.ToolCalls) > 0 {
 		modelResp.ToolCalls = make([]types.ToolCall, len(choice.Message.ToolCalls))
@@ -100,10 +112,13 @@ func (d *DeepSeek) Invoke(ctx context.Context, req *models.InvokeRequest) (*type
 	return modelResp, nil
 }
 
-// InvokeStream calls the DeepSeek API with streaming response
+// InvokeStream calls the DeepSeek API with streaming response.
+// Reasoning deltas are sent in ResponseChunk.ReasoningContent, and tool calls,
+// which arrive in fragments, are sent complete with the final chunk.
 func (d *DeepSeek) InvokeStream(ctx context.Context, req *models.InvokeRequest) (<-chan types.ResponseChunk, error) {
 	chatReq := d.buildChatRequest(req)
 	chatReq.Stream = true
+	chatReq.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
 
 	stream, err := d.client.CreateChatCompletionStream(ctx, chatReq)
 	if err != nil {
@@ -116,8 +131,29 @@ func (d *DeepSeek) InvokeStream(ctx context.Context, req *models.InvokeRequest)
 		defer close(chunks)
 		defer stream.Close()
 
+		var (
+			toolCalls []types.ToolCall
+			usage     *types.Usage
+		)
+		send := func(chunk types.ResponseChunk) bool {
+			select {
+			case chunks <- chunk:
+				return true
+			case <-ctx.Done():
+				chunks <- types.ResponseChunk{
+					Done:  true,
+					Error: ctx.Err(),
+				}
+				return false
+			}
+		}
+
 		for {
 			response, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				send(types.ResponseChunk{ToolCalls: toolCalls, Usage: usage, Done: true})
+				return
+			}
 			if err != nil {
 				chunks <- types.ResponseChunk{
 					Done:  true,
@@ -126,37 +162,23 @@ func (d *DeepSeek) InvokeStream(ctx context.Context, req *models.InvokeRequest)
 				return
 			}
 
+			if response.Usage != nil {
+				usage = &types.Usage{
+					PromptTokens:     response.Usage.PromptTokens,
+					CompletionTokens: response.Usage.CompletionTokens,
+					TotalTokens:      response.Usage.TotalTokens,
+				}
+			}
 			if len(response.Choices) == 0 {
 				continue
 			}
 
 			delta := response.Choices[0].Delta
-			chunk := types.ResponseChunk{
-				Content: delta.Content,
-			}
-
-			// Handle tool calls in stream
-			if len(delta.ToolCalls) > 0 {
-				chunk.ToolCalls = make([]types.ToolCall, len(delta.ToolCalls))
-				for i, tc := range delta.ToolCalls {
-					chunk.ToolCalls[i] = types.ToolCall{
-						ID:   tc.ID,
-						Type: string(tc.Type),
-						Function: types.ToolCallFunction{
-							Name:      tc.Function.Name,
-							Arguments: tc.Function.Arguments,
-						},
-					}
-				}
+			toolCalls = mergeToolCallDeltas(toolCalls, delta.ToolCalls)
+			if delta.Content == "" && delta.ReasoningContent == "" {
+				continue
 			}
-
-			select {
-			case chunks <- chunk:
-			case <-ctx.Done():
-				chunks <- types.ResponseChunk{
-					Done:  true,
-					Error: ctx.Err(),
-				}
+			if !send(types.ResponseChunk{Content: delta.Content, ReasoningContent: delta.ReasoningContent}) {
 				return
 			}
 		}
@@ -165,6 +187,33 @@ func (d *DeepSeek) InvokeStream(ctx context.Context, req *models.InvokeRequest)
 	return chunks, nil
 }
 
+// mergeToolCallDeltas appends streamed tool call fragments to calls. The first
+// fragment of a call carries its ID and name; later fragments of the same
+// index carry more of the arguments.
+func mergeToolCallDeltas(calls []types.ToolCall, deltas []openai.ToolCall) []types.ToolCall {
+	for _, tc := range deltas {
+		index := len(calls)
+		if tc.Index != nil {
+			index = *tc.Index
+		} else if tc.ID == "" && len(calls) > 0 {
+			index = len(calls) - 1
+		}
+		for len(calls) <= index {
+			calls = append(calls, types.ToolCall{Type: "function"})
+		}
+		call := &calls[index]
+		if tc.ID != "" {
+			call.ID = tc.ID
+		}
+		if tc.Type != "" {
+			call.Type = string(tc.Type)
+		}
+		call.Function.Name += tc.Function.Name
+		call.Function.Arguments += tc.Function.Arguments
+	}
+	return calls
+}
+
 // buildChatRequest converts InvokeRequest to OpenAI ChatCompletionRequest
 func (d *DeepSeek) buildChatRequest(req *models.InvokeRequest) openai.ChatCompletionRequest {
 	chatReq := openai.ChatCompletionRequest{
@@ -528,3 +528,122 @@ func TestValidateConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestInvoke_ReasoningContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":    "chatcmpl-r1",
+			"model": "deepseek-reasoner",
+			"choices": []map[string]interface{}{
+				{
+					"index": 0,
+					"message": map[string]interface{}{
+						"role":              "assistant",
+						"content":           "4",
+						"reasoning_content": "2 plus 2 is 4.",
+					},
+					"finish_reason": "stop",
+				},
+			},
+			"usage": map[string]interface{}{
+				"prompt_tokens":             5,
+				"completion_tokens":         12,
+				"total_tokens":              17,
+				"completion_tokens_details": map[string]interface{}{"reasoning_tokens": 10},
+			},
+		})
+	}))
+	defer server.Close()
+
+	model, err := New("deepseek-reasoner", Config{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("failed to create model: %v", err)
+	}
+	resp, err := model.Invoke(context.Background(), &models.InvokeRequest{
+		Messages: []*types.Message{{Role: types.RoleUser, Content: "2+2?"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Content != "4" {
+		t.Errorf("expected content %q, got %q", "4", resp.Content)
+	}
+	if resp.ReasoningContent == nil || resp.ReasoningContent.Content != "2 plus 2 is 4." {
+		t.Fatalf("unexpected reasoning content: %+v", resp.ReasoningContent)
+	}
+	if resp.ReasoningContent.TokenCount == nil || *resp.ReasoningContent.TokenCount != 10 {
+		t.Errorf("expected 10 reasoning tokens, got %v", resp.ReasoningContent.TokenCount)
+	}
+}
+
+func TestInvokeStream_ReasoningAndToolCalls(t *testing.T) {
+	deltas := []map[string]interface{}{
+		{"reasoning_content": "Need the "},
+		{"reasoning_content": "weather."},
+		{"tool_calls": []map[string]interface{}{{"index": 0, "id": "call_1", "type": "function", "function": map[string]interface{}{"name": "get_weather", "arguments": `{"loc`}}}},
+		{"tool_calls": []map[string]interface{}{{"index": 0, "function": map[string]interface{}{"arguments": `ation":"Paris"}`}}}},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if opts, _ := body["stream_options"].(map[string]interface{}); opts["include_usage"] != true {
+			t.Errorf("expected stream_options.include_usage, got %v", body["stream_options"])
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, delta := range deltas {
+			data, _ := json.Marshal(map[string]interface{}{
+				"id":      "chatcmpl-123",
+				"model":   "deepseek-reasoner",
+				"choices": []map[string]interface{}{{"index": 0, "delta": delta}},
+			})
+			w.Write([]byte("data: " + string(data) + "\n\n"))
+		}
+		usage, _ := json.Marshal(map[string]interface{}{
+			"id":      "chatcmpl-123",
+			"choices": []map[string]interface{}{},
+			"usage":   map[string]interface{}{"prompt_tokens": 3, "completion_tokens": 7, "total_tokens": 10},
+		})
+		w.Write([]byte("data: " + string(usage) + "\n\n"))
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	model, err := New("deepseek-reasoner", Config{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("failed to create model: %v", err)
+	}
+	chunks, err := model.InvokeStream(context.Background(), &models.InvokeRequest{
+		Messages: []*types.Message{{Role: types.RoleUser, Content: "Weather in Paris?"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var reasoning, content string
+	var last types.ResponseChunk
+	for chunk := range chunks {
+		if chunk.Error != nil {
+			t.Fatalf("chunk error: %v", chunk.Error)
+		}
+		reasoning += chunk.ReasoningContent
+		content += chunk.Content
+		last = chunk
+	}
+
+	if reasoning != "Need the weather." || content != "" {
+		t.Errorf("unexpected reasoning %q and content %q", reasoning, content)
+	}
+	if !last.Done || len(last.ToolCalls) != 1 {
+		t.Fatalf("expected a final chunk with one tool call, got %+v", last)
+	}
+	call := last.ToolCalls[0]
+	if call.ID != "call_1" || call.Function.Name != "get_weather" || call.Function.Arguments != `{"location":"Paris"}` {
+		t.Errorf("unexpected tool call: %+v", call)
+	}
+	if last.Usage == nil || last.Usage.TotalTokens != 10 {
+		t.Errorf("unexpected usage: %+v", last.Usage)
+	}
+}
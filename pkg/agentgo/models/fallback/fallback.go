@@ -20,6 +20,9 @@ type Option func(*Options)
 type Options struct {
 	MaxRetries int           // Max retries per model before falling back (default: 1)
 	RetryDelay time.Duration // Delay between retries (default: 500ms)
+
+	// OnFallback is called when a model fails and the next one in the chain is tried
+	OnFallback func(from, to models.Model, err error)
 }
 
 func defaultOptions() Options {
@@ -48,6 +51,13 @@ func WithRetryDelay(d time.Duration) Option {
 	}
 }
 
+// WithOnFallback registers a callback invoked on every failover.
+func WithOnFallback(fn func(from, to models.Model, err error)) Option {
+	return func(o *Options) {
+		o.OnFallback = fn
+	}
+}
+
 // FallbackModel wraps multiple models and tries each in order until one succeeds.
 type FallbackModel struct {
 	models.BaseModel
@@ -111,6 +121,7 @@ func (f *FallbackModel) Invoke(ctx context.Context, req *models.InvokeRequest) (
 		}
 
 		lastErr = err
+		f.notifyFallback(i, err)
 	}
 
 	return nil, fmt.Errorf("all models in fallback chain failed: %w", lastErr)
@@ -121,7 +132,7 @@ func (f *FallbackModel) Invoke(ctx context.Context, req *models.InvokeRequest) (
 func (f *FallbackModel) InvokeStream(ctx context.Context, req *models.InvokeRequest) (<-chan types.ResponseChunk, error) {
 	var lastErr error
 
-	for _, model := range f.chain {
+	for i, model := range f.chain {
 		if ctx.Err() != nil {
 			return nil, ctx.Err()
 		}
@@ -136,11 +147,20 @@ func (f *FallbackModel) InvokeStream(ctx context.Context, req *models.InvokeRequ
 		}
 
 		lastErr = err
+		f.notifyFallback(i, err)
 	}
 
 	return nil, fmt.Errorf("all models in fallback chain failed (stream): %w", lastErr)
 }
 
+// notifyFallback reports a failover from chain[i] to chain[i+1], if any.
+func (f *FallbackModel) notifyFallback(i int, err error) {
+	if f.options.OnFallback == nil || i+1 >= len(f.chain) {
+		return
+	}
+	f.options.OnFallback(f.chain[i], f.chain[i+1], err)
+}
+
 func (f *FallbackModel) invokeWithRetry(ctx context.Context, model models.Model, req *models.InvokeRequest) (*types.ModelResponse, error) {
 	var lastErr error
 
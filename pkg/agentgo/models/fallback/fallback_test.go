@@ -300,3 +300,29 @@ func TestWithOptions(t *testing.T) {
 		t.Errorf("expected RetryDelay 2s, got %v", fb.options.RetryDelay)
 	}
 }
+
+func TestInvoke_OnFallbackCallback(t *testing.T) {
+	primary := newMockModel("primary")
+	primary.invokeFunc = func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+		return nil, types.NewRateLimitError("slow down", nil)
+	}
+	secondary := newMockModel("secondary")
+
+	var from, to string
+	fm, err := New([]models.Model{primary, secondary},
+		WithMaxRetries(0),
+		WithOnFallback(func(f, t models.Model, err error) {
+			from, to = f.GetID(), t.GetID()
+		}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := fm.Invoke(context.Background(), &models.InvokeRequest{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if from != "primary" || to != "secondary" {
+		t.Fatalf("expected fallback primary -> secondary, got %q -> %q", from, to)
+	}
+}
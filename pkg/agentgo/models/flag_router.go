@@ -0,0 +1,102 @@
+package models
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/flags"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// FlagRouterConfig configures a FlagRouter
+type FlagRouterConfig struct {
+	Provider flags.FlagProvider // Flag provider consulted on every call
+	FlagKey  string             // String flag naming the variant to use
+	Default  Model              // Model used when the flag is unset or names an unknown variant
+	Variants map[string]Model   // Models keyed by flag value
+}
+
+// FlagRouter routes each call to a model chosen by a string feature flag.
+// The evaluation context is read from ctx (see flags.WithEvalContext), so the
+// same router can send different users or tenants to different providers.
+type FlagRouter struct {
+	provider flags.FlagProvider
+	flagKey  string
+	fallback Model
+	variants map[string]Model
+}
+
+// NewFlagRouter creates a model router driven by a feature flag
+func NewFlagRouter(config FlagRouterConfig) (*FlagRouter, error) {
+	if config.Provider == nil {
+		return nil, types.NewInvalidConfigError("flag provider is required", nil)
+	}
+	if config.FlagKey == "" {
+		return nil, types.NewInvalidConfigError("flag key is required", nil)
+	}
+	if config.Default == nil {
+		return nil, types.NewInvalidConfigError("default model is required", nil)
+	}
+
+	variants := make(map[string]Model, len(config.Variants))
+	for name, model := range config.Variants {
+		if model == nil {
+			return nil, types.NewInvalidConfigError(fmt.Sprintf("variant %s has no model", name), nil)
+		}
+		variants[name] = model
+	}
+
+	return &FlagRouter{
+		provider: config.Provider,
+		flagKey:  config.FlagKey,
+		fallback: config.Default,
+		variants: variants,
+	}, nil
+}
+
+// Route returns the model selected for ctx and the variant name ("" for default)
+func (r *FlagRouter) Route(ctx context.Context) (Model, string) {
+	evalCtx, _ := flags.EvalContextFromContext(ctx)
+	variant := r.provider.StringValue(ctx, r.flagKey, "", evalCtx)
+	if model, ok := r.variants[variant]; ok {
+		return model, variant
+	}
+	return r.fallback, ""
+}
+
+// Invoke calls the routed model and records the chosen variant in the response metadata
+func (r *FlagRouter) Invoke(ctx context.Context, req *InvokeRequest) (*types.ModelResponse, error) {
+	model, variant := r.Route(ctx)
+	resp, err := model.Invoke(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Metadata.Extra == nil {
+		resp.Metadata.Extra = make(map[string]interface{})
+	}
+	resp.Metadata.Extra["flag_key"] = r.flagKey
+	resp.Metadata.Extra["flag_variant"] = variant
+	resp.Metadata.Extra["routed_model"] = model.GetID()
+	return resp, nil
+}
+
+// InvokeStream streams from the routed model
+func (r *FlagRouter) InvokeStream(ctx context.Context, req *InvokeRequest) (<-chan types.ResponseChunk, error) {
+	model, _ := r.Route(ctx)
+	return model.InvokeStream(ctx, req)
+}
+
+// GetProvider returns the default model provider
+func (r *FlagRouter) GetProvider() string {
+	return r.fallback.GetProvider()
+}
+
+// GetID returns the default model ID
+func (r *FlagRouter) GetID() string {
+	return r.fallback.GetID()
+}
+
+// GetName returns the default model name
+func (r *FlagRouter) GetName() string {
+	return r.fallback.GetName()
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/flags"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+type namedModel struct {
+	BaseModel
+}
+
+func (m *namedModel) Invoke(ctx context.Context, req *InvokeRequest) (*types.ModelResponse, error) {
+	return &types.ModelResponse{Content: m.ID}, nil
+}
+
+func (m *namedModel) InvokeStream(ctx context.Context, req *InvokeRequest) (<-chan types.ResponseChunk, error) {
+	ch := make(chan types.ResponseChunk, 1)
+	ch <- types.ResponseChunk{Content: m.ID, Done: true}
+	close(ch)
+	return ch, nil
+}
+
+func TestFlagRouter_RoutesByEvalContext(t *testing.T) {
+	provider := flags.NewStaticProvider(nil)
+	provider.SetForTenant("enterprise", "model_variant", "large")
+	provider.SetForUser("tester", "model_variant", "unknown")
+
+	router, err := NewFlagRouter(FlagRouterConfig{
+		Provider: provider,
+		FlagKey:  "model_variant",
+		Default:  &namedModel{BaseModel{ID: "small"}},
+		Variants: map[string]Model{"large": &namedModel{BaseModel{ID: "large"}}},
+	})
+	if err != nil {
+		t.Fatalf("NewFlagRouter() error = %v", err)
+	}
+
+	cases := map[string]struct {
+		evalCtx flags.EvalContext
+		want    string
+	}{
+		"default":         {flags.EvalContext{}, "small"},
+		"tenant override": {flags.EvalContext{TenantID: "enterprise"}, "large"},
+		"unknown variant": {flags.EvalContext{UserID: "tester", TenantID: "enterprise"}, "small"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := flags.WithEvalContext(context.Background(), tc.evalCtx)
+			resp, err := router.Invoke(ctx, &InvokeRequest{})
+			if err != nil {
+				t.Fatalf("Invoke() error = %v", err)
+			}
+			if resp.Content != tc.want || resp.Metadata.Extra["routed_model"] != tc.want {
+				t.Fatalf("expected %s, got %s (%v)", tc.want, resp.Content, resp.Metadata.Extra)
+			}
+		})
+	}
+
+	stream, err := router.InvokeStream(flags.WithEvalContext(context.Background(), flags.EvalContext{TenantID: "enterprise"}), &InvokeRequest{})
+	if err != nil {
+		t.Fatalf("InvokeStream() error = %v", err)
+	}
+	if chunk := <-stream; chunk.Content != "large" {
+		t.Fatalf("expected stream from large model, got %q", chunk.Content)
+	}
+}
+
+func TestNewFlagRouter_Validation(t *testing.T) {
+	provider := flags.NewStaticProvider(nil)
+	if _, err := NewFlagRouter(FlagRouterConfig{FlagKey: "k", Default: &namedModel{}}); err == nil {
+		t.Error("expected error without provider")
+	}
+	if _, err := NewFlagRouter(FlagRouterConfig{Provider: provider, Default: &namedModel{}}); err == nil {
+		t.Error("expected error without flag key")
+	}
+	if _, err := NewFlagRouter(FlagRouterConfig{Provider: provider, FlagKey: "k"}); err == nil {
+		t.Error("expected error without default model")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/jholhewres/agent-go/pkg/agentgo/ids"
 	"github.com/jholhewres/agent-go/pkg/agentgo/models"
 	"github.com/jholhewres/agent-go/pkg/agentgo/types"
 )
@@ -99,6 +100,7 @@ func (g *Gemini) Invoke(ctx context.Context, req *models.InvokeRequest) (*types.
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
+	models.ApplyContextHeaders(httpReq)
 
 	resp, err := g.httpClient.Do(httpReq)
 	if err != nil {
@@ -135,6 +137,7 @@ func (g *Gemini) InvokeStream(ctx context.Context, req *models.InvokeRequest) (<
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
+	models.ApplyContextHeaders(httpReq)
 
 	resp, err := g.httpClient.Do(httpReq)
 	if err != nil {
@@ -219,6 +222,16 @@ func (g *Gemini) buildGeminiRequest(req *models.InvokeRequest) *GeminiRequest {
 		includeThoughtsPtr = g.config.IncludeThoughts
 	}
 
+	// A reasoning budget on the request overrides the configured one, and
+	// thoughts are included so the reasoning can be returned
+	if budget := models.ReasoningBudget(req); budget > 0 {
+		thinkingBudget = budget
+		if includeThoughtsPtr == nil {
+			include := true
+			includeThoughtsPtr = &include
+		}
+	}
+
 	if req.Extra != nil {
 		if val, ok := req.Extra["thinking_budget"]; ok {
 			if budget, ok := toInt(val); ok {
@@ -450,9 +463,11 @@ func (g *Gemini) convertToChunk(resp *GeminiResponse) types.ResponseChunk {
 		return chunk
 	}
 
-	// Extract content
+	// Extract content, keeping thoughts apart
 	for _, part := range candidate.Content.Parts {
-		if part.Text != "" {
+		if part.Text != "" && part.Thought {
+			chunk.ReasoningContent += part.Text
+		} else if part.Text != "" {
 			chunk.Content += part.Text
 		}
 
@@ -614,7 +629,7 @@ func (d *SSEDecoder) Next() ([]byte, error) {
 
 // generateToolCallID generates a unique tool call ID
 func generateToolCallID() string {
-	return fmt.Sprintf("call_%d", len(strings.Repeat("x", 24)))
+	return "call_" + ids.New()
 }
 
 func toInt(value interface{}) (int, bool) {
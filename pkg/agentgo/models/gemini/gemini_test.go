@@ -533,6 +533,28 @@ func TestGeminiBuildRequestThinkingConfig(t *testing.T) {
 	}
 }
 
+func TestGeminiBuildRequestReasoningBudget(t *testing.T) {
+	model, err := New("gemini-2.5-flash", Config{APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	geminiReq := model.buildGeminiRequest(&models.InvokeRequest{ReasoningEffort: models.ReasoningEffortLow})
+	if geminiReq.ThinkingConfig == nil || geminiReq.ThinkingConfig.BudgetTokens != 1024 {
+		t.Fatalf("ThinkingConfig = %+v, want a budget of 1024", geminiReq.ThinkingConfig)
+	}
+	if geminiReq.ThinkingConfig.IncludeThoughts == nil || !*geminiReq.ThinkingConfig.IncludeThoughts {
+		t.Error("IncludeThoughts should be enabled with a reasoning budget")
+	}
+
+	chunk := model.convertToChunk(&GeminiResponse{Candidates: []Candidate{{
+		Content: Content{Parts: []Part{{Text: "Step 1", Thought: true}, {Text: "Answer"}}},
+	}}})
+	if chunk.ReasoningContent != "Step 1" || chunk.Content != "Answer" {
+		t.Errorf("chunk = %+v, want thoughts apart from the answer", chunk)
+	}
+}
+
 func TestGeminiConvertResponseReasoning(t *testing.T) {
 	model := &Gemini{
 		BaseModel: models.BaseModel{ID: "gemini-2.5", Provider: "gemini"},
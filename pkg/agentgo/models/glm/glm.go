@@ -378,6 +378,7 @@ func (g *GLM) createHTTPRequest(ctx context.Context, glmReq *glmRequest) (*http.
 	// 设置请求头
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	models.ApplyContextHeaders(httpReq)
 
 	return httpReq, nil
 }
@@ -62,7 +62,8 @@ func New(modelID string, config Config) (*Groq, error) {
 		timeout = 60 * time.Second // Default 60 seconds / 默认 60 秒
 	}
 	clientConfig.HTTPClient = &http.Client{
-		Timeout: timeout,
+		Timeout:   timeout,
+		Transport: models.NewHeaderTransport(nil),
 	}
 
 	return &Groq{
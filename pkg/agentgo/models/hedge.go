@@ -0,0 +1,326 @@
+package models
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// HedgeConfig configures hedged requests for WithHedging
+type HedgeConfig struct {
+	Delay     time.Duration // Latency after which a hedge is sent (default: 1s)
+	MaxHedges int           // Hedges sent per call, each after another Delay (default: 1)
+
+	// Secondary receives the hedges (default: the wrapped model)
+	Secondary Model
+
+	// OnHedge is called when a call that sent at least one hedge completes
+	OnHedge func(info HedgeInfo)
+}
+
+// HedgeInfo describes a call that sent hedges
+type HedgeInfo struct {
+	ModelID  string
+	Provider string
+	Hedges   int           // Hedges sent
+	Winner   int           // 0 = primary, n = nth hedge, -1 = every request failed
+	WinnerID string        // ID of the model that answered
+	Latency  time.Duration // Time until the winning response (or the final error)
+	Err      error         // nil on success
+	Stream   bool
+}
+
+// HedgeStats counts hedging activity since the model was created
+type HedgeStats struct {
+	Requests   int64 `json:"requests"`    // Calls made through the model
+	Hedged     int64 `json:"hedged"`      // Calls that sent at least one hedge
+	HedgesSent int64 `json:"hedges_sent"` // Hedge requests sent
+	HedgeWins  int64 `json:"hedge_wins"`  // Calls answered by a hedge
+	Failures   int64 `json:"failures"`    // Calls where every request failed
+}
+
+// HedgedModel wraps a Model and sends duplicate requests when a call is slow
+type HedgedModel struct {
+	model  Model
+	config HedgeConfig
+
+	requests   atomic.Int64
+	hedged     atomic.Int64
+	hedgesSent atomic.Int64
+	hedgeWins  atomic.Int64
+	failures   atomic.Int64
+}
+
+// WithHedging wraps model so that a call still running after Delay is
+// duplicated to the same or a secondary model. The first successful response
+// wins and the other requests are cancelled. Errors are not hedged: a request
+// that fails before Delay returns its error (combine with WithRetry for that).
+// Streams are hedged until their first chunk arrives.
+func WithHedging(model Model, config HedgeConfig) *HedgedModel {
+	if config.Delay <= 0 {
+		config.Delay = time.Second
+	}
+	if config.MaxHedges <= 0 {
+		config.MaxHedges = 1
+	}
+	if config.Secondary == nil {
+		config.Secondary = model
+	}
+	return &HedgedModel{model: model, config: config}
+}
+
+// Unwrap returns the wrapped model
+func (h *HedgedModel) Unwrap() Model {
+	return h.model
+}
+
+// GetProvider returns the wrapped model provider
+func (h *HedgedModel) GetProvider() string {
+	return h.model.GetProvider()
+}
+
+// GetID returns the wrapped model ID
+func (h *HedgedModel) GetID() string {
+	return h.model.GetID()
+}
+
+// GetName returns the wrapped model name
+func (h *HedgedModel) GetName() string {
+	return h.model.GetName()
+}
+
+// Stats returns a snapshot of the hedging counters
+func (h *HedgedModel) Stats() HedgeStats {
+	return HedgeStats{
+		Requests:   h.requests.Load(),
+		Hedged:     h.hedged.Load(),
+		HedgesSent: h.hedgesSent.Load(),
+		HedgeWins:  h.hedgeWins.Load(),
+		Failures:   h.failures.Load(),
+	}
+}
+
+// target returns the model for an attempt: 0 is the primary request
+func (h *HedgedModel) target(attempt int) Model {
+	if attempt == 0 {
+		return h.model
+	}
+	return h.config.Secondary
+}
+
+type hedgeResult struct {
+	attempt int
+	resp    *types.ModelResponse
+	err     error
+}
+
+// Invoke calls the wrapped model, hedging if it is slower than Delay
+func (h *HedgedModel) Invoke(ctx context.Context, req *InvokeRequest) (*types.ModelResponse, error) {
+	h.requests.Add(1)
+	start := time.Now()
+
+	// Cancelling on return stops the requests that lost.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan hedgeResult, h.config.MaxHedges+1)
+	launch := func(attempt int) {
+		go func() {
+			resp, err := h.target(attempt).Invoke(ctx, req)
+			results <- hedgeResult{attempt: attempt, resp: resp, err: err}
+		}()
+	}
+
+	launch(0)
+	launched, pending := 1, 1
+	timer := time.NewTimer(h.config.Delay)
+	defer timer.Stop()
+
+	var firstErr error
+	for {
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				h.finish(launched-1, r.attempt, start, nil, false)
+				return r.resp, nil
+			}
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			if pending == 0 {
+				h.finish(launched-1, -1, start, firstErr, false)
+				return nil, firstErr
+			}
+		case <-timer.C:
+			if launched <= h.config.MaxHedges {
+				launch(launched)
+				launched++
+				pending++
+				h.hedgesSent.Add(1)
+				timer.Reset(h.config.Delay)
+			}
+		case <-ctx.Done():
+			h.finish(launched-1, -1, start, ctx.Err(), false)
+			return nil, ctx.Err()
+		}
+	}
+}
+
+type hedgeStream struct {
+	attempt int
+	stream  <-chan types.ResponseChunk
+	first   types.ResponseChunk
+	open    bool // first was received before the stream closed
+	err     error
+	cancel  context.CancelFunc
+}
+
+// InvokeStream opens a stream, hedging if no chunk arrives within Delay. The
+// first stream to deliver a chunk wins; the others are cancelled and drained.
+func (h *HedgedModel) InvokeStream(ctx context.Context, req *InvokeRequest) (<-chan types.ResponseChunk, error) {
+	h.requests.Add(1)
+	start := time.Now()
+
+	results := make(chan hedgeStream, h.config.MaxHedges+1)
+	var cancels []context.CancelFunc
+	launch := func(attempt int) {
+		attemptCtx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+		go func() {
+			r := hedgeStream{attempt: attempt, cancel: cancel}
+			r.stream, r.err = h.target(attempt).InvokeStream(attemptCtx, req)
+			if r.err == nil {
+				r.first, r.open = <-r.stream
+				if r.open && r.first.Error != nil {
+					r.err = r.first.Error
+				}
+			}
+			results <- r
+		}()
+	}
+
+	launch(0)
+	launched, pending := 1, 1
+	timer := time.NewTimer(h.config.Delay)
+	defer timer.Stop()
+
+	var firstErr error
+	for {
+		select {
+		case r := <-results:
+			pending--
+			if r.err != nil {
+				r.discard()
+				if firstErr == nil {
+					firstErr = r.err
+				}
+				if pending == 0 {
+					h.finish(launched-1, -1, start, firstErr, true)
+					return nil, firstErr
+				}
+				continue
+			}
+
+			// Cancel the requests that lost and drain them in the background.
+			for attempt, cancel := range cancels {
+				if attempt != r.attempt {
+					cancel()
+				}
+			}
+			go func(n int) {
+				for ; n > 0; n-- {
+					(<-results).discard()
+				}
+			}(pending)
+
+			h.finish(launched-1, r.attempt, start, nil, true)
+			return r.relay(ctx), nil
+		case <-timer.C:
+			if launched <= h.config.MaxHedges {
+				launch(launched)
+				launched++
+				pending++
+				h.hedgesSent.Add(1)
+				timer.Reset(h.config.Delay)
+			}
+		case <-ctx.Done():
+			go func(n int) {
+				for ; n > 0; n-- {
+					(<-results).discard()
+				}
+			}(pending)
+			h.finish(launched-1, -1, start, ctx.Err(), true)
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// relay forwards the winning stream, starting with its first chunk
+func (r hedgeStream) relay(ctx context.Context) <-chan types.ResponseChunk {
+	out := make(chan types.ResponseChunk)
+	go func() {
+		defer r.cancel()
+		defer close(out)
+		if !r.open {
+			return
+		}
+		select {
+		case out <- r.first:
+		case <-ctx.Done():
+			r.discard()
+			return
+		}
+		for chunk := range r.stream {
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				r.discard()
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// discard cancels a request and drains its stream so the provider goroutine can exit
+func (r hedgeStream) discard() {
+	r.cancel()
+	if r.stream == nil || !r.open {
+		return
+	}
+	for range r.stream {
+	}
+}
+
+// finish records the outcome of a call
+func (h *HedgedModel) finish(hedges, winner int, start time.Time, err error, stream bool) {
+	if err != nil {
+		h.failures.Add(1)
+	}
+	if hedges == 0 {
+		return
+	}
+	h.hedged.Add(1)
+	if winner > 0 {
+		h.hedgeWins.Add(1)
+	}
+	if h.config.OnHedge == nil {
+		return
+	}
+	info := HedgeInfo{
+		ModelID:  h.model.GetID(),
+		Provider: h.model.GetProvider(),
+		Hedges:   hedges,
+		Winner:   winner,
+		Latency:  time.Since(start),
+		Err:      err,
+		Stream:   stream,
+	}
+	if winner >= 0 {
+		info.WinnerID = h.target(winner).GetID()
+	}
+	h.config.OnHedge(info)
+}
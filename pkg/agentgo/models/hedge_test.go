@@ -0,0 +1,174 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// latencyModel answers after a fixed delay unless its context is cancelled first
+type latencyModel struct {
+	BaseModel
+	delay     time.Duration
+	err       error
+	calls     atomic.Int32
+	cancelled atomic.Int32
+}
+
+func (m *latencyModel) wait(ctx context.Context) error {
+	m.calls.Add(1)
+	select {
+	case <-time.After(m.delay):
+		return m.err
+	case <-ctx.Done():
+		m.cancelled.Add(1)
+		return ctx.Err()
+	}
+}
+
+func (m *latencyModel) Invoke(ctx context.Context, req *InvokeRequest) (*types.ModelResponse, error) {
+	if err := m.wait(ctx); err != nil {
+		return nil, err
+	}
+	return &types.ModelResponse{Content: m.ID}, nil
+}
+
+func (m *latencyModel) InvokeStream(ctx context.Context, req *InvokeRequest) (<-chan types.ResponseChunk, error) {
+	ch := make(chan types.ResponseChunk)
+	go func() {
+		defer close(ch)
+		if err := m.wait(ctx); err != nil {
+			return
+		}
+		for _, part := range []string{m.ID, "-done"} {
+			select {
+			case ch <- types.ResponseChunk{Content: part}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func newLatencyModel(id string, delay time.Duration) *latencyModel {
+	return &latencyModel{BaseModel: BaseModel{ID: id, Provider: "test"}, delay: delay}
+}
+
+func TestWithHedging_FastPrimaryIsNotHedged(t *testing.T) {
+	primary := newLatencyModel("primary", time.Millisecond)
+	secondary := newLatencyModel("secondary", time.Millisecond)
+	hedged := WithHedging(primary, HedgeConfig{Delay: 200 * time.Millisecond, Secondary: secondary})
+
+	resp, err := hedged.Invoke(context.Background(), &InvokeRequest{})
+	if err != nil || resp.Content != "primary" {
+		t.Fatalf("Invoke() = %v, %v", resp, err)
+	}
+	if secondary.calls.Load() != 0 {
+		t.Error("secondary should not be called")
+	}
+	if stats := hedged.Stats(); stats.Requests != 1 || stats.Hedged != 0 || stats.HedgesSent != 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestWithHedging_SlowPrimaryLosesToHedge(t *testing.T) {
+	primary := newLatencyModel("primary", 2*time.Second)
+	secondary := newLatencyModel("secondary", time.Millisecond)
+
+	var infos []HedgeInfo
+	hedged := WithHedging(primary, HedgeConfig{
+		Delay:     10 * time.Millisecond,
+		Secondary: secondary,
+		OnHedge:   func(info HedgeInfo) { infos = append(infos, info) },
+	})
+
+	start := time.Now()
+	resp, err := hedged.Invoke(context.Background(), &InvokeRequest{})
+	if err != nil || resp.Content != "secondary" {
+		t.Fatalf("Invoke() = %v, %v", resp, err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("hedge did not cut latency")
+	}
+	waitFor(t, func() bool { return primary.cancelled.Load() == 1 })
+
+	if len(infos) != 1 || infos[0].Winner != 1 || infos[0].WinnerID != "secondary" || infos[0].Hedges != 1 {
+		t.Errorf("infos = %+v", infos)
+	}
+	if stats := hedged.Stats(); stats.Hedged != 1 || stats.HedgesSent != 1 || stats.HedgeWins != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestWithHedging_PrimaryCanStillWin(t *testing.T) {
+	primary := newLatencyModel("primary", 30*time.Millisecond)
+	hedged := WithHedging(primary, HedgeConfig{Delay: 10 * time.Millisecond})
+
+	// The hedge goes to the same model, so it finishes 10ms after the primary.
+	resp, err := hedged.Invoke(context.Background(), &InvokeRequest{})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if resp.Content != "primary" || primary.calls.Load() != 2 {
+		t.Errorf("resp = %q, calls = %d", resp.Content, primary.calls.Load())
+	}
+	if stats := hedged.Stats(); stats.Hedged != 1 || stats.HedgeWins != 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestWithHedging_ErrorsAreNotHedged(t *testing.T) {
+	primary := newLatencyModel("primary", time.Millisecond)
+	primary.err = errors.New("boom")
+	secondary := newLatencyModel("secondary", time.Millisecond)
+	hedged := WithHedging(primary, HedgeConfig{Delay: 100 * time.Millisecond, Secondary: secondary})
+
+	if _, err := hedged.Invoke(context.Background(), &InvokeRequest{}); err == nil || err.Error() != "boom" {
+		t.Fatalf("Invoke() error = %v, want boom", err)
+	}
+	if secondary.calls.Load() != 0 {
+		t.Error("secondary should not be called")
+	}
+	if stats := hedged.Stats(); stats.Failures != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestWithHedging_FailedHedgeWaitsForPrimary(t *testing.T) {
+	primary := newLatencyModel("primary", 40*time.Millisecond)
+	secondary := newLatencyModel("secondary", time.Millisecond)
+	secondary.err = errors.New("secondary down")
+	hedged := WithHedging(primary, HedgeConfig{Delay: 10 * time.Millisecond, Secondary: secondary})
+
+	resp, err := hedged.Invoke(context.Background(), &InvokeRequest{})
+	if err != nil || resp.Content != "primary" {
+		t.Fatalf("Invoke() = %v, %v", resp, err)
+	}
+}
+
+func TestWithHedging_Stream(t *testing.T) {
+	primary := newLatencyModel("primary", 2*time.Second)
+	secondary := newLatencyModel("secondary", time.Millisecond)
+	hedged := WithHedging(primary, HedgeConfig{Delay: 10 * time.Millisecond, Secondary: secondary})
+
+	stream, err := hedged.InvokeStream(context.Background(), &InvokeRequest{})
+	if err != nil {
+		t.Fatalf("InvokeStream() error = %v", err)
+	}
+	var content string
+	for chunk := range stream {
+		content += chunk.Content
+	}
+	if content != "secondary-done" {
+		t.Errorf("content = %q", content)
+	}
+	waitFor(t, func() bool { return primary.cancelled.Load() == 1 })
+	if stats := hedged.Stats(); stats.HedgeWins != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
@@ -38,7 +38,7 @@ func New(modelID string, config Config) (*InternLM, error) {
 	if to == 0 {
 		to = 60 * time.Second
 	}
-	cc.HTTPClient = &http.Client{Timeout: to}
+	cc.HTTPClient = &http.Client{Timeout: to, Transport: models.NewHeaderTransport(nil)}
 	return &InternLM{BaseModel: models.BaseModel{ID: modelID, Provider: "internlm"}, client: openai.NewClientWithConfig(cc), config: Config{APIKey: config.APIKey, BaseURL: config.BaseURL, Temperature: config.Temperature, MaxTokens: config.MaxTokens, Timeout: to}}, nil
 }
 
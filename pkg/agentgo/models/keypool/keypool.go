@@ -0,0 +1,318 @@
+// Package keypool provides a Model implementation that spreads calls across
+// several API keys of one provider, tracking each key's request rate and
+// putting a key on cooldown when the provider rate limits it.
+package keypool
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// Config configures a Pool
+type Config struct {
+	// Keys are the API keys to spread calls across (at least one)
+	Keys []string
+
+	// New creates the provider model for one key, e.g.
+	//   func(key string) (models.Model, error) { return openai.New("gpt-4o", openai.Config{APIKey: key}) }
+	New func(apiKey string) (models.Model, error)
+
+	RequestsPerMinute int           // Calls allowed per key per minute (default: 0, unlimited)
+	Cooldown          time.Duration // Pause of a key after a 429, doubling on each consecutive one (default: 30s)
+	MaxCooldown       time.Duration // Upper bound on a single pause (default: 5m)
+	MaxWait           time.Duration // How long a call waits when every key is busy (default: 0, fail at once)
+
+	// OnCooldown is called when a rate-limited key is paused
+	OnCooldown func(info CooldownInfo)
+}
+
+// CooldownInfo describes a key paused after a rate limit
+type CooldownInfo struct {
+	Key     string    // Masked key, e.g. "...a1b2"
+	Strikes int       // Consecutive rate limits of the key
+	Until   time.Time // When the key is used again
+	Err     error     // The rate-limit error
+}
+
+// KeyStats reports the activity of one key
+type KeyStats struct {
+	Key            string    `json:"key"`                      // Masked key
+	Requests       int64     `json:"requests"`                 // Calls made with the key
+	RateLimited    int64     `json:"rate_limited"`             // Calls rejected with a rate limit
+	RecentRequests int       `json:"recent_requests"`          // Calls in the last minute
+	CooldownUntil  time.Time `json:"cooldown_until,omitempty"` // Set while the key is paused
+}
+
+// key is the state of one API key
+type key struct {
+	masked        string
+	model         models.Model
+	recent        []time.Time // Start of the calls in the last minute
+	cooldownUntil time.Time
+	strikes       int
+	requests      int64
+	rateLimited   int64
+}
+
+// Pool is a Model that sends each call with the next available key. A call
+// rejected with a rate limit (429) pauses its key and is retried with another
+// one; other errors are returned as they are.
+type Pool struct {
+	config Config
+	now    func() time.Time
+
+	mu   sync.Mutex
+	keys []*key
+	next int
+}
+
+// New creates a pool with one model per key
+func New(config Config) (*Pool, error) {
+	if len(config.Keys) == 0 {
+		return nil, types.NewInvalidConfigError("key pool requires at least one API key", nil)
+	}
+	if config.New == nil {
+		return nil, types.NewInvalidConfigError("key pool requires a model factory", nil)
+	}
+	if config.RequestsPerMinute < 0 {
+		return nil, types.NewInvalidConfigError("requests per minute cannot be negative", nil)
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = 30 * time.Second
+	}
+	if config.MaxCooldown <= 0 {
+		config.MaxCooldown = 5 * time.Minute
+	}
+	if config.MaxCooldown < config.Cooldown {
+		config.MaxCooldown = config.Cooldown
+	}
+
+	p := &Pool{config: config, now: time.Now}
+	seen := make(map[string]bool, len(config.Keys))
+	for i, apiKey := range config.Keys {
+		if apiKey == "" {
+			return nil, types.NewInvalidConfigError(fmt.Sprintf("API key %d is empty", i), nil)
+		}
+		if seen[apiKey] {
+			return nil, types.NewInvalidConfigError(fmt.Sprintf("API key %s is listed twice", mask(apiKey)), nil)
+		}
+		seen[apiKey] = true
+		model, err := config.New(apiKey)
+		if err != nil {
+			return nil, fmt.Errorf("create model for API key %s: %w", mask(apiKey), err)
+		}
+		p.keys = append(p.keys, &key{masked: mask(apiKey), model: model})
+	}
+	return p, nil
+}
+
+// GetProvider returns the provider of the pooled models
+func (p *Pool) GetProvider() string {
+	return p.keys[0].model.GetProvider()
+}
+
+// GetID returns the ID of the pooled models
+func (p *Pool) GetID() string {
+	return p.keys[0].model.GetID()
+}
+
+// GetName returns the name of the pooled models
+func (p *Pool) GetName() string {
+	return p.keys[0].model.GetName()
+}
+
+// Invoke calls the model with the next available key
+func (p *Pool) Invoke(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+	var resp *types.ModelResponse
+	err := p.do(ctx, func(m models.Model) error {
+		var err error
+		resp, err = m.Invoke(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// InvokeStream opens a stream with the next available key. Rate limits are
+// only handled while the stream opens.
+func (p *Pool) InvokeStream(ctx context.Context, req *models.InvokeRequest) (<-chan types.ResponseChunk, error) {
+	var stream <-chan types.ResponseChunk
+	err := p.do(ctx, func(m models.Model) error {
+		var err error
+		stream, err = m.InvokeStream(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+// Stats reports the activity of every key, in the order of Config.Keys
+func (p *Pool) Stats() []KeyStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	stats := make([]KeyStats, len(p.keys))
+	for i, k := range p.keys {
+		k.prune(now)
+		stats[i] = KeyStats{
+			Key:            k.masked,
+			Requests:       k.requests,
+			RateLimited:    k.rateLimited,
+			RecentRequests: len(k.recent),
+		}
+		if now.Before(k.cooldownUntil) {
+			stats[i].CooldownUntil = k.cooldownUntil
+		}
+	}
+	return stats
+}
+
+// do runs call with available keys until one is not rate limited. Each key
+// is tried at most once per call.
+func (p *Pool) do(ctx context.Context, call func(models.Model) error) error {
+	var lastErr error
+	tried := make(map[*key]bool, len(p.keys))
+	for len(tried) < len(p.keys) {
+		k, err := p.acquire(ctx, tried)
+		if err != nil {
+			if lastErr != nil {
+				return lastErr
+			}
+			return err
+		}
+		tried[k] = true
+
+		err = call(k.model)
+		if err == nil {
+			p.release(k)
+			return nil
+		}
+		if !models.IsRateLimitError(err) {
+			return err
+		}
+		p.pause(k, err)
+		lastErr = err
+	}
+	return lastErr
+}
+
+// acquire reserves the next key that is not paused, below its rate and not
+// in skip, waiting up to MaxWait for one to free up
+func (p *Pool) acquire(ctx context.Context, skip map[*key]bool) (*key, error) {
+	deadline := p.now().Add(p.config.MaxWait)
+	for {
+		k, wait := p.tryAcquire(skip)
+		if k != nil {
+			return k, nil
+		}
+		if wait < 0 || p.now().Add(wait).After(deadline) {
+			msg := fmt.Sprintf("all %d API keys are rate limited", len(p.keys))
+			if wait >= 0 {
+				msg += fmt.Sprintf(", next one free in %s", wait.Round(time.Millisecond))
+			}
+			return nil, types.NewRateLimitError(msg, nil)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// tryAcquire reserves a key, or reports how long until one frees up (-1 when
+// every key not in skip has been tried)
+func (p *Pool) tryAcquire(skip map[*key]bool) (*key, time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	wait := time.Duration(-1)
+	for i := range p.keys {
+		idx := (p.next + i) % len(p.keys)
+		k := p.keys[idx]
+		if skip[k] {
+			continue
+		}
+		k.prune(now)
+		free := now
+		if now.Before(k.cooldownUntil) {
+			free = k.cooldownUntil
+		}
+		if limit := p.config.RequestsPerMinute; limit > 0 && len(k.recent) >= limit {
+			if at := k.recent[len(k.recent)-limit].Add(time.Minute); at.After(free) {
+				free = at
+			}
+		}
+		if free.After(now) {
+			if d := free.Sub(now); wait < 0 || d < wait {
+				wait = d
+			}
+			continue
+		}
+
+		k.recent = append(k.recent, now)
+		k.requests++
+		p.next = idx + 1
+		return k, 0
+	}
+	return nil, wait
+}
+
+// release clears the rate-limit strikes of a key after a successful call
+func (p *Pool) release(k *key) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	k.strikes = 0
+}
+
+// pause puts a rate-limited key on cooldown
+func (p *Pool) pause(k *key, err error) {
+	p.mu.Lock()
+	k.rateLimited++
+	k.strikes++
+	cooldown := p.config.Cooldown
+	for i := 1; i < k.strikes && cooldown < p.config.MaxCooldown; i++ {
+		cooldown *= 2
+	}
+	if cooldown > p.config.MaxCooldown {
+		cooldown = p.config.MaxCooldown
+	}
+	k.cooldownUntil = p.now().Add(cooldown)
+	info := CooldownInfo{Key: k.masked, Strikes: k.strikes, Until: k.cooldownUntil, Err: err}
+	p.mu.Unlock()
+
+	if p.config.OnCooldown != nil {
+		p.config.OnCooldown(info)
+	}
+}
+
+// prune drops the calls older than a minute
+func (k *key) prune(now time.Time) {
+	cutoff := now.Add(-time.Minute)
+	i := 0
+	for i < len(k.recent) && !k.recent[i].After(cutoff) {
+		i++
+	}
+	k.recent = k.recent[i:]
+}
+
+// mask hides all but the last four characters of an API key
+func mask(apiKey string) string {
+	if len(apiKey) <= 8 {
+		return "****"
+	}
+	return "..." + apiKey[len(apiKey)-4:]
+}
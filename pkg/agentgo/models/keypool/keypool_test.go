@@ -0,0 +1,250 @@
+package keypool
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// keyModel answers with its key, failing with the queued errors first
+type keyModel struct {
+	models.BaseModel
+	apiKey string
+
+	mu    sync.Mutex
+	errs  []error
+	calls int
+}
+
+func (m *keyModel) fail(errs ...error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errs = append(m.errs, errs...)
+}
+
+func (m *keyModel) call() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	if len(m.errs) > 0 {
+		err := m.errs[0]
+		m.errs = m.errs[1:]
+		return err
+	}
+	return nil
+}
+
+func (m *keyModel) Invoke(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+	if err := m.call(); err != nil {
+		return nil, err
+	}
+	return &types.ModelResponse{Content: m.apiKey}, nil
+}
+
+func (m *keyModel) InvokeStream(ctx context.Context, req *models.InvokeRequest) (<-chan types.ResponseChunk, error) {
+	if err := m.call(); err != nil {
+		return nil, err
+	}
+	ch := make(chan types.ResponseChunk, 1)
+	ch <- types.ResponseChunk{Content: m.apiKey, Done: true}
+	close(ch)
+	return ch, nil
+}
+
+var rateLimited = types.NewAPIError("API error (status 429): slow down", nil)
+
+// newPool creates a pool of keyModels on a fake clock
+func newPool(t *testing.T, config Config) (*Pool, map[string]*keyModel, *time.Time) {
+	t.Helper()
+	created := make(map[string]*keyModel)
+	config.New = func(apiKey string) (models.Model, error) {
+		m := &keyModel{BaseModel: models.BaseModel{ID: "gpt-test", Provider: "test"}, apiKey: apiKey}
+		created[apiKey] = m
+		return m, nil
+	}
+	pool, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	pool.now = func() time.Time { return now }
+	return pool, created, &now
+}
+
+func invoke(t *testing.T, pool *Pool) string {
+	t.Helper()
+	resp, err := pool.Invoke(context.Background(), &models.InvokeRequest{})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	return resp.Content
+}
+
+func TestPool_RoundRobin(t *testing.T) {
+	pool, _, _ := newPool(t, Config{Keys: []string{"key-a", "key-b", "key-c"}})
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		got = append(got, invoke(t, pool))
+	}
+	if strings.Join(got, ",") != "key-a,key-b,key-c,key-a" {
+		t.Fatalf("keys used = %v", got)
+	}
+	if pool.GetID() != "gpt-test" || pool.GetProvider() != "test" {
+		t.Fatal("pool does not report the pooled model")
+	}
+}
+
+func TestPool_CooldownOnRateLimit(t *testing.T) {
+	var cooldowns []CooldownInfo
+	pool, keys, now := newPool(t, Config{
+		Keys:        []string{"sk-first-0001", "sk-second-0002"},
+		Cooldown:    10 * time.Second,
+		MaxCooldown: 15 * time.Second,
+		OnCooldown:  func(info CooldownInfo) { cooldowns = append(cooldowns, info) },
+	})
+	keys["sk-first-0001"].fail(rateLimited)
+
+	// The rate-limited call is retried with the other key
+	if got := invoke(t, pool); got != "sk-second-0002" {
+		t.Fatalf("first call answered by %q", got)
+	}
+	if len(cooldowns) != 1 || cooldowns[0].Key != "...0001" || !cooldowns[0].Until.Equal(now.Add(10*time.Second)) {
+		t.Fatalf("cooldowns = %+v", cooldowns)
+	}
+
+	// The paused key is skipped until its cooldown ends
+	if got := invoke(t, pool); got != "sk-second-0002" {
+		t.Fatalf("second call answered by %q", got)
+	}
+	stats := pool.Stats()
+	if stats[0].RateLimited != 1 || stats[0].CooldownUntil.IsZero() || stats[1].Requests != 2 {
+		t.Fatalf("stats = %+v", stats)
+	}
+
+	*now = now.Add(10 * time.Second)
+	keys["sk-first-0001"].fail(rateLimited)
+	keys["sk-second-0002"].fail(rateLimited)
+	_, err := pool.Invoke(context.Background(), &models.InvokeRequest{})
+	if !models.IsRateLimitError(err) {
+		t.Fatalf("expected a rate-limit error when every key is limited, got %v", err)
+	}
+	// Consecutive rate limits double the cooldown up to MaxCooldown
+	if len(cooldowns) != 3 || cooldowns[1].Strikes != 2 || !cooldowns[1].Until.Equal(now.Add(15*time.Second)) {
+		t.Fatalf("cooldowns = %+v", cooldowns)
+	}
+
+	_, err = pool.Invoke(context.Background(), &models.InvokeRequest{})
+	if err == nil || !strings.Contains(err.Error(), "all 2 API keys are rate limited, next one free in 10s") {
+		t.Fatalf("expected the pool to refuse while every key is paused, got %v", err)
+	}
+
+	// A success clears the strikes
+	*now = now.Add(15 * time.Second)
+	invoke(t, pool)
+	invoke(t, pool)
+	keys["sk-first-0001"].fail(rateLimited)
+	invoke(t, pool)
+	if len(cooldowns) != 4 || cooldowns[3].Key != "...0001" || cooldowns[3].Strikes != 1 {
+		t.Fatalf("cooldowns after a success = %+v", cooldowns)
+	}
+}
+
+func TestPool_RequestsPerMinute(t *testing.T) {
+	pool, _, now := newPool(t, Config{Keys: []string{"key-a", "key-b"}, RequestsPerMinute: 2})
+
+	for i := 0; i < 4; i++ {
+		invoke(t, pool)
+		*now = now.Add(time.Second)
+	}
+	_, err := pool.Invoke(context.Background(), &models.InvokeRequest{})
+	if !models.IsRateLimitError(err) || !strings.Contains(err.Error(), "next one free in 56s") {
+		t.Fatalf("expected the per-key limit to refuse the fifth call, got %v", err)
+	}
+	if stats := pool.Stats(); stats[0].RecentRequests != 2 || stats[1].RecentRequests != 2 {
+		t.Fatalf("stats = %+v", stats)
+	}
+
+	*now = now.Add(56 * time.Second)
+	if got := invoke(t, pool); got != "key-a" {
+		t.Fatalf("call after the window answered by %q", got)
+	}
+}
+
+func TestPool_WaitsForKey(t *testing.T) {
+	var created []*keyModel
+	pool, err := New(Config{
+		Keys:     []string{"only-key"},
+		Cooldown: 20 * time.Millisecond,
+		MaxWait:  time.Second,
+		New: func(apiKey string) (models.Model, error) {
+			m := &keyModel{apiKey: apiKey}
+			created = append(created, m)
+			return m, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	created[0].fail(rateLimited)
+
+	if _, err := pool.InvokeStream(context.Background(), &models.InvokeRequest{}); !models.IsRateLimitError(err) {
+		t.Fatalf("expected the provider's rate limit, got %v", err)
+	}
+	start := time.Now()
+	stream, err := pool.InvokeStream(context.Background(), &models.InvokeRequest{})
+	if err != nil {
+		t.Fatalf("InvokeStream() error = %v", err)
+	}
+	if chunk := <-stream; chunk.Content != "only-key" {
+		t.Fatalf("chunk = %+v", chunk)
+	}
+	if waited := time.Since(start); waited < 10*time.Millisecond {
+		t.Fatalf("call did not wait for the cooldown (%s)", waited)
+	}
+
+	created[0].fail(rateLimited)
+	pool.Invoke(context.Background(), &models.InvokeRequest{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := pool.Invoke(ctx, &models.InvokeRequest{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancellation while waiting, got %v", err)
+	}
+}
+
+func TestPool_OtherErrorsAreReturned(t *testing.T) {
+	pool, keys, _ := newPool(t, Config{Keys: []string{"key-a", "key-b"}})
+	keys["key-a"].fail(types.NewAPIError("API error (status 401): unauthorized", nil))
+
+	if _, err := pool.Invoke(context.Background(), &models.InvokeRequest{}); err == nil || models.IsRateLimitError(err) {
+		t.Fatalf("expected the authorization error, got %v", err)
+	}
+	if keys["key-b"].calls != 0 {
+		t.Fatal("a non rate-limit error moved on to another key")
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	factory := func(apiKey string) (models.Model, error) { return &keyModel{apiKey: apiKey}, nil }
+	cases := map[string]Config{
+		"no keys":      {New: factory},
+		"no factory":   {Keys: []string{"a"}},
+		"empty key":    {Keys: []string{"a", ""}, New: factory},
+		"duplicate":    {Keys: []string{"a", "a"}, New: factory},
+		"negative rpm": {Keys: []string{"a"}, New: factory, RequestsPerMinute: -1},
+		"factory error": {Keys: []string{"a"}, New: func(string) (models.Model, error) {
+			return nil, errors.New("bad key")
+		}},
+	}
+	for name, config := range cases {
+		if _, err := New(config); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package models
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// InvokeHandler performs a synchronous model call
+type InvokeHandler func(ctx context.Context, req *InvokeRequest) (*types.ModelResponse, error)
+
+// StreamHandler opens a streaming model call
+type StreamHandler func(ctx context.Context, req *InvokeRequest) (<-chan types.ResponseChunk, error)
+
+// Handler is the pair of calls a middleware wraps
+type Handler struct {
+	Invoke       InvokeHandler
+	InvokeStream StreamHandler
+}
+
+// Middleware wraps the calls of a model. It receives the next handler of the
+// chain and returns a handler that usually calls it, after changing the
+// request or context and before changing the response. A nil field in the
+// returned Handler passes that call through to next.
+type Middleware func(next Handler) Handler
+
+// MiddlewareModel wraps a Model and routes its calls through middleware
+type MiddlewareModel struct {
+	model   Model
+	handler Handler
+}
+
+// WithMiddleware wraps model so every call runs through middleware. The first
+// middleware is the outermost: it sees the request first and the response last.
+func WithMiddleware(model Model, middleware ...Middleware) *MiddlewareModel {
+	handler := Handler{Invoke: model.Invoke, InvokeStream: model.InvokeStream}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		if middleware[i] == nil {
+			continue
+		}
+		next := handler
+		handler = middleware[i](next)
+		if handler.Invoke == nil {
+			handler.Invoke = next.Invoke
+		}
+		if handler.InvokeStream == nil {
+			handler.InvokeStream = next.InvokeStream
+		}
+	}
+	return &MiddlewareModel{model: model, handler: handler}
+}
+
+// Unwrap returns the wrapped model
+func (m *MiddlewareModel) Unwrap() Model {
+	return m.model
+}
+
+// GetProvider returns the wrapped model provider
+func (m *MiddlewareModel) GetProvider() string {
+	return m.model.GetProvider()
+}
+
+// GetID returns the wrapped model ID
+func (m *MiddlewareModel) GetID() string {
+	return m.model.GetID()
+}
+
+// GetName returns the wrapped model name
+func (m *MiddlewareModel) GetName() string {
+	return m.model.GetName()
+}
+
+// Invoke calls the model through the middleware
+func (m *MiddlewareModel) Invoke(ctx context.Context, req *InvokeRequest) (*types.ModelResponse, error) {
+	return m.handler.Invoke(ctx, req)
+}
+
+// InvokeStream opens a stream through the middleware
+func (m *MiddlewareModel) InvokeStream(ctx context.Context, req *InvokeRequest) (<-chan types.ResponseChunk, error) {
+	return m.handler.InvokeStream(ctx, req)
+}
+
+type headersKey struct{}
+
+// WithHeaders returns a context whose model calls send headers with their
+// HTTP request, on top of (and overriding) the ones set by the provider.
+// Headers already in ctx are kept unless headers sets them again.
+func WithHeaders(ctx context.Context, headers map[string]string) context.Context {
+	merged := make(map[string]string, len(headers))
+	for key, value := range HeadersFromContext(ctx) {
+		merged[key] = value
+	}
+	for key, value := range headers {
+		merged[key] = value
+	}
+	return context.WithValue(ctx, headersKey{}, merged)
+}
+
+// HeadersFromContext returns the headers set with WithHeaders, or nil
+func HeadersFromContext(ctx context.Context) map[string]string {
+	headers, _ := ctx.Value(headersKey{}).(map[string]string)
+	return headers
+}
+
+// ApplyContextHeaders sets the headers of req's context on req. Providers
+// call it after setting their own headers.
+func ApplyContextHeaders(req *http.Request) {
+	for key, value := range HeadersFromContext(req.Context()) {
+		req.Header.Set(key, value)
+	}
+}
+
+// headerTransport applies context headers to every request it sends
+type headerTransport struct {
+	base http.RoundTripper
+}
+
+// NewHeaderTransport wraps base (http.DefaultTransport when nil) so requests
+// carry the headers of their context. Providers built on an SDK client use it
+// as the client's transport.
+func NewHeaderTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return headerTransport{base: base}
+}
+
+func (t headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	headers := HeadersFromContext(req.Context())
+	if len(headers) == 0 {
+		return t.base.RoundTrip(req)
+	}
+	// A RoundTripper must not modify the caller's request
+	req = req.Clone(req.Context())
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// SetHeaders adds headers to the HTTP request of every call
+func SetHeaders(headers map[string]string) Middleware {
+	return func(next Handler) Handler {
+		return Handler{
+			Invoke: func(ctx context.Context, req *InvokeRequest) (*types.ModelResponse, error) {
+				return next.Invoke(WithHeaders(ctx, headers), req)
+			},
+			InvokeStream: func(ctx context.Context, req *InvokeRequest) (<-chan types.ResponseChunk, error) {
+				return next.InvokeStream(WithHeaders(ctx, headers), req)
+			},
+		}
+	}
+}
+
+// RoundRobin spreads calls evenly across the wrapped model and alternates,
+// typically the same model created with other API keys to share their rate
+// limits. Each alternate is called directly, without the middleware that
+// follows RoundRobin in the chain.
+func RoundRobin(alternates ...Model) Middleware {
+	var calls atomic.Uint64
+	return func(next Handler) Handler {
+		pick := func() Handler {
+			i := int((calls.Add(1) - 1) % uint64(len(alternates)+1))
+			if i == 0 {
+				return next
+			}
+			m := alternates[i-1]
+			return Handler{Invoke: m.Invoke, InvokeStream: m.InvokeStream}
+		}
+		return Handler{
+			Invoke: func(ctx context.Context, req *InvokeRequest) (*types.ModelResponse, error) {
+				return pick().Invoke(ctx, req)
+			},
+			InvokeStream: func(ctx context.Context, req *InvokeRequest) (<-chan types.ResponseChunk, error) {
+				return pick().InvokeStream(ctx, req)
+			},
+		}
+	}
+}
+
+// LogCalls logs every call to logger with its duration, token usage and
+// error. Streams are logged when they open. Use logger.With to add the model
+// or other attributes.
+func LogCalls(logger *slog.Logger) Middleware {
+	return func(next Handler) Handler {
+		return Handler{
+			Invoke: func(ctx context.Context, req *InvokeRequest) (*types.ModelResponse, error) {
+				start := time.Now()
+				resp, err := next.Invoke(ctx, req)
+				attrs := []interface{}{"messages", len(req.Messages), "duration", time.Since(start)}
+				if err != nil {
+					logger.WarnContext(ctx, "model call failed", append(attrs, "error", err)...)
+					return nil, err
+				}
+				logger.InfoContext(ctx, "model call", append(attrs, "total_tokens", resp.Usage.TotalTokens)...)
+				return resp, nil
+			},
+			InvokeStream: func(ctx context.Context, req *InvokeRequest) (<-chan types.ResponseChunk, error) {
+				start := time.Now()
+				stream, err := next.InvokeStream(ctx, req)
+				attrs := []interface{}{"messages", len(req.Messages), "duration", time.Since(start)}
+				if err != nil {
+					logger.WarnContext(ctx, "model stream failed", append(attrs, "error", err)...)
+					return nil, err
+				}
+				logger.InfoContext(ctx, "model stream opened", attrs...)
+				return stream, nil
+			},
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// tagMiddleware appends tag to the request's Extra["trace"] on the way in
+// and to the response content on the way out
+func tagMiddleware(tag string) Middleware {
+	return func(next Handler) Handler {
+		return Handler{Invoke: func(ctx context.Context, req *InvokeRequest) (*types.ModelResponse, error) {
+			req.Extra["trace"] = req.Extra["trace"].(string) + tag
+			resp, err := next.Invoke(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			resp.Content += tag
+			return resp, nil
+		}}
+	}
+}
+
+func TestWithMiddleware_Order(t *testing.T) {
+	var trace string
+	model := &flakyModel{BaseModel: BaseModel{ID: "m", Provider: "test"}}
+	wrapped := WithMiddleware(&traceModel{flakyModel: model, trace: &trace}, tagMiddleware("a"), nil, tagMiddleware("b"))
+
+	resp, err := wrapped.Invoke(context.Background(), &InvokeRequest{Extra: map[string]interface{}{"trace": ""}})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if trace != "ab" || resp.Content != "okba" {
+		t.Fatalf("request trace %q, response %q; want ab and okba", trace, resp.Content)
+	}
+	if wrapped.GetID() != "m" || wrapped.Unwrap().GetProvider() != "test" {
+		t.Fatal("wrapper does not report the wrapped model")
+	}
+
+	// Streams pass through a middleware that only wraps Invoke
+	if _, err := wrapped.InvokeStream(context.Background(), &InvokeRequest{}); err != nil || model.calls != 2 {
+		t.Fatalf("InvokeStream() error = %v after %d calls", err, model.calls)
+	}
+}
+
+// traceModel records the request trace set by tagMiddleware
+type traceModel struct {
+	*flakyModel
+	trace *string
+}
+
+func (m *traceModel) Invoke(ctx context.Context, req *InvokeRequest) (*types.ModelResponse, error) {
+	*m.trace = req.Extra["trace"].(string)
+	return m.flakyModel.Invoke(ctx, req)
+}
+
+func TestSetHeaders(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	httpModel := &headerModel{BaseModel: BaseModel{ID: "h"}, url: server.URL}
+	wrapped := WithMiddleware(httpModel,
+		SetHeaders(map[string]string{"X-Tenant": "acme", "X-Trace": "1"}),
+		SetHeaders(map[string]string{"X-Trace": "2"}))
+	if _, err := wrapped.Invoke(context.Background(), &InvokeRequest{}); err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if got.Get("X-Tenant") != "acme" || got.Get("X-Trace") != "2" || got.Get("Authorization") != "Bearer key" {
+		t.Fatalf("unexpected headers %v", got)
+	}
+
+	// The transport used by SDK-based providers applies them too, without
+	// changing the caller's request
+	ctx := WithHeaders(context.Background(), map[string]string{"X-Tenant": "acme"})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	client := &http.Client{Transport: NewHeaderTransport(nil)}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+	if got.Get("X-Tenant") != "acme" || req.Header.Get("X-Tenant") != "" {
+		t.Fatalf("transport headers: sent %v, caller's request %v", got, req.Header)
+	}
+}
+
+// headerModel posts to url with HTTPClient like the raw HTTP providers
+type headerModel struct {
+	BaseModel
+	url string
+}
+
+func (m *headerModel) Invoke(ctx context.Context, req *InvokeRequest) (*types.ModelResponse, error) {
+	resp, err := NewHTTPClient().PostJSON(ctx, m.url, map[string]string{"Authorization": "Bearer key"}, map[string]string{})
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return &types.ModelResponse{}, nil
+}
+
+func (m *headerModel) InvokeStream(ctx context.Context, req *InvokeRequest) (<-chan types.ResponseChunk, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestRoundRobin(t *testing.T) {
+	primary := &flakyModel{BaseModel: BaseModel{ID: "key-1"}}
+	second := &flakyModel{BaseModel: BaseModel{ID: "key-2"}}
+	third := &flakyModel{BaseModel: BaseModel{ID: "key-3"}}
+	wrapped := WithMiddleware(primary, RoundRobin(second, third))
+
+	for i := 0; i < 5; i++ {
+		if _, err := wrapped.Invoke(context.Background(), &InvokeRequest{}); err != nil {
+			t.Fatalf("Invoke() error = %v", err)
+		}
+	}
+	if _, err := wrapped.InvokeStream(context.Background(), &InvokeRequest{}); err != nil {
+		t.Fatalf("InvokeStream() error = %v", err)
+	}
+	if primary.calls != 2 || second.calls != 2 || third.calls != 2 {
+		t.Fatalf("calls = %d, %d, %d; want 2 each", primary.calls, second.calls, third.calls)
+	}
+}
+
+func TestLogCalls(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&out, nil))
+	model := &flakyModel{
+		BaseModel: BaseModel{ID: "m"},
+		errs:      []error{types.NewAPIError("API error (status 500): boom", nil)},
+	}
+	wrapped := WithMiddleware(model, LogCalls(logger.With("model", "m")))
+
+	if _, err := wrapped.Invoke(context.Background(), &InvokeRequest{}); err == nil {
+		t.Fatal("expected the model error")
+	}
+	if _, err := wrapped.Invoke(context.Background(), &InvokeRequest{}); err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	logs := out.String()
+	if !strings.Contains(logs, `msg="model call failed" model=m`) || !strings.Contains(logs, `msg="model call" model=m`) {
+		t.Fatalf("unexpected logs:\n%s", logs)
+	}
+}
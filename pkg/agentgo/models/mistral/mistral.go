@@ -0,0 +1,280 @@
+package mistral
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+const (
+	// DefaultBaseURL is the Mistral AI API endpoint
+	DefaultBaseURL = "https://api.mistral.ai/v1"
+)
+
+// Mistral is a Mistral AI chat model. It supports native function calling,
+// and the reasoning of Magistral models is returned in
+// ModelResponse.ReasoningContent rather than in the content.
+type Mistral struct {
+	models.BaseModel
+	http   *models.HTTPClient
+	config Config
+}
+
+// Config contains Mistral-specific configuration
+type Config struct {
+	APIKey      string
+	BaseURL     string
+	Temperature float64
+	MaxTokens   int
+}
+
+// New creates a Mistral model
+func New(modelID string, config Config) (*Mistral, error) {
+	if config.APIKey == "" {
+		return nil, types.NewInvalidConfigError("Mistral API key is required", nil)
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultBaseURL
+	}
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+
+	return &Mistral{
+		BaseModel: models.BaseModel{
+			ID:       modelID,
+			Provider: "mistral",
+			Name:     modelID,
+		},
+		http:   models.NewHTTPClient(),
+		config: config,
+	}, nil
+}
+
+// Invoke calls the Mistral API synchronously
+func (m *Mistral) Invoke(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+	resp, err := m.post(ctx, m.buildChatRequest(req, false))
+	if err != nil {
+		return nil, err
+	}
+
+	var chatResp chatResponse
+	if err := models.ReadJSONResponse(resp, &chatResp); err != nil {
+		return nil, err
+	}
+	if len(chatResp.Choices) == 0 {
+		return nil, types.NewAPIError("no response from Mistral", nil)
+	}
+
+	choice := chatResp.Choices[0]
+	modelResp := &types.ModelResponse{
+		ID:        chatResp.ID,
+		Content:   choice.Message.Content.Text,
+		ToolCalls: convertToolCalls(choice.Message.ToolCalls),
+		Model:     chatResp.Model,
+		Metadata: types.Metadata{
+			FinishReason: choice.FinishReason,
+		},
+	}
+	if choice.Message.Content.Thinking != "" {
+		modelResp.ReasoningContent = types.NewReasoningContent(choice.Message.Content.Thinking)
+	}
+	if chatResp.Usage != nil {
+		modelResp.Usage = convertUsage(chatResp.Usage)
+	}
+
+	return modelResp, nil
+}
+
+// InvokeStream calls the Mistral API with streaming response. Reasoning is
+// sent in ResponseChunk.ReasoningContent and the usage with the final chunk.
+func (m *Mistral) InvokeStream(ctx context.Context, req *models.InvokeRequest) (<-chan types.ResponseChunk, error) {
+	resp, err := m.post(ctx, m.buildChatRequest(req, true))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, models.ReadErrorResponse(resp)
+	}
+
+	chunks := make(chan types.ResponseChunk)
+
+	go func() {
+		defer close(chunks)
+		defer resp.Body.Close()
+
+		var lastUsage *types.Usage
+		send := func(chunk types.ResponseChunk) bool {
+			select {
+			case chunks <- chunk:
+				return true
+			case <-ctx.Done():
+				chunks <- types.ResponseChunk{
+					Done:  true,
+					Error: ctx.Err(),
+				}
+				return false
+			}
+		}
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data:")
+			if !ok {
+				continue
+			}
+			data = strings.TrimSpace(data)
+			if data == "[DONE]" {
+				send(types.ResponseChunk{Usage: lastUsage, Done: true})
+				return
+			}
+
+			var event chatResponse
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				chunks <- types.ResponseChunk{
+					Done:  true,
+					Error: types.NewAPIError("failed to parse Mistral stream", err),
+				}
+				return
+			}
+			if event.Usage != nil {
+				u := convertUsage(event.Usage)
+				lastUsage = &u
+			}
+			if len(event.Choices) == 0 || event.Choices[0].Delta == nil {
+				continue
+			}
+
+			delta := event.Choices[0].Delta
+			chunk := types.ResponseChunk{
+				Content:          delta.Content.Text,
+				ReasoningContent: delta.Content.Thinking,
+				// Mistral sends each tool call whole, never in fragments
+				ToolCalls: convertToolCalls(delta.ToolCalls),
+			}
+			if chunk.Content == "" && chunk.ReasoningContent == "" && len(chunk.ToolCalls) == 0 {
+				continue
+			}
+			if !send(chunk) {
+				return
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			chunks <- types.ResponseChunk{
+				Done:  true,
+				Error: types.NewAPIError("error reading Mistral stream", err),
+			}
+			return
+		}
+		// The stream ended without [DONE]
+		send(types.ResponseChunk{Usage: lastUsage, Done: true})
+	}()
+
+	return chunks, nil
+}
+
+// post sends a chat request
+func (m *Mistral) post(ctx context.Context, chatReq chatRequest) (*http.Response, error) {
+	headers := map[string]string{
+		"Authorization": "Bearer " + m.config.APIKey,
+	}
+	if chatReq.Stream {
+		headers["Accept"] = "text/event-stream"
+	}
+	return m.http.PostJSON(ctx, m.config.BaseURL+"/chat/completions", headers, chatReq)
+}
+
+// buildChatRequest converts an InvokeRequest to a Mistral chat request
+func (m *Mistral) buildChatRequest(req *models.InvokeRequest, stream bool) chatRequest {
+	chatReq := chatRequest{
+		Model:    m.ID,
+		Messages: make([]message, len(req.Messages)),
+		Stream:   stream,
+	}
+
+	for i, msg := range req.Messages {
+		chatMsg := message{
+			Role:       string(msg.Role),
+			Content:    content{Text: msg.Content},
+			Name:       msg.Name,
+			ToolCallID: msg.ToolCallID,
+		}
+		for _, tc := range msg.ToolCalls {
+			chatMsg.ToolCalls = append(chatMsg.ToolCalls, toolCall{
+				ID:   tc.ID,
+				Type: "function",
+				Function: functionCall{
+					Name:      tc.Function.Name,
+					Arguments: tc.Function.Arguments,
+				},
+			})
+		}
+		chatReq.Messages[i] = chatMsg
+	}
+
+	for _, t := range req.Tools {
+		chatReq.Tools = append(chatReq.Tools, tool{
+			Type: "function",
+			Function: function{
+				Name:        t.Function.Name,
+				Description: t.Function.Description,
+				Parameters:  t.Function.Parameters,
+			},
+		})
+	}
+	if len(chatReq.Tools) > 0 {
+		chatReq.ToolChoice = "auto"
+	}
+
+	temperature, maxTokens := models.MergeConfig(req.Temperature, m.config.Temperature, req.MaxTokens, m.config.MaxTokens)
+	if temperature > 0 {
+		chatReq.Temperature = &temperature
+	}
+	chatReq.MaxTokens = maxTokens
+
+	return chatReq
+}
+
+// convertToolCalls converts Mistral tool calls to ToolCalls
+func convertToolCalls(calls []toolCall) []types.ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+	result := make([]types.ToolCall, len(calls))
+	for i, tc := range calls {
+		result[i] = types.ToolCall{
+			ID:   tc.ID,
+			Type: "function",
+			Function: types.ToolCallFunction{
+				Name:      tc.Function.Name,
+				Arguments: tc.Function.Arguments,
+			},
+		}
+	}
+	return result
+}
+
+func convertUsage(u *usage) types.Usage {
+	return types.Usage{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+	}
+}
+
+// ValidateConfig validates the Mistral configuration
+func ValidateConfig(config Config) error {
+	if config.APIKey == "" {
+		return types.NewInvalidConfigError("API key is required", nil)
+	}
+	if config.Temperature < 0 || config.Temperature > 1.5 {
+		return types.NewInvalidConfigError(fmt.Sprintf("temperature must be between 0 and 1.5, got %v", config.Temperature), nil)
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package mistral
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+func TestNew(t *testing.T) {
+	if _, err := New("mistral-large-latest", Config{}); err == nil {
+		t.Fatal("expected an error without an API key")
+	}
+
+	model, err := New("mistral-large-latest", Config{APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if model.GetID() != "mistral-large-latest" || model.GetProvider() != "mistral" {
+		t.Errorf("unexpected model %s/%s", model.GetProvider(), model.GetID())
+	}
+	if model.config.BaseURL != DefaultBaseURL {
+		t.Errorf("expected default base URL, got %s", model.config.BaseURL)
+	}
+}
+
+func TestInvoke_ToolCalls(t *testing.T) {
+	var got chatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" || r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("unexpected request %s with authorization %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var body struct {
+			Model      string `json:"model"`
+			Tools      []tool `json:"tools"`
+			ToolChoice string `json:"tool_choice"`
+			Messages   []struct {
+				Role       string `json:"role"`
+				Content    string `json:"content"`
+				ToolCallID string `json:"tool_call_id"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		got.Model, got.Tools, got.ToolChoice = body.Model, body.Tools, body.ToolChoice
+		if len(body.Messages) != 3 || body.Messages[2].Role != "tool" || body.Messages[2].ToolCallID != "abc123def" {
+			t.Errorf("unexpected messages: %+v", body.Messages)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"id": "cmpl-1",
+			"model": "mistral-large-latest",
+			"choices": [{
+				"index": 0,
+				"message": {
+					"role": "assistant",
+					"content": "",
+					"tool_calls": [{"id": "xyz789abc", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}}]
+				},
+				"finish_reason": "tool_calls"
+			}],
+			"usage": {"prompt_tokens": 20, "completion_tokens": 8, "total_tokens": 28}
+		}`))
+	}))
+	defer server.Close()
+
+	model, err := New("mistral-large-latest", Config{APIKey: "test-key", BaseURL: server.URL + "/"})
+	if err != nil {
+		t.Fatalf("failed to create model: %v", err)
+	}
+	resp, err := model.Invoke(context.Background(), &models.InvokeRequest{
+		Messages: []*types.Message{
+			{Role: types.RoleUser, Content: "Weather in Lyon?"},
+			{Role: types.RoleAssistant, ToolCalls: []types.ToolCall{{ID: "abc123def", Type: "function", Function: types.ToolCallFunction{Name: "get_weather", Arguments: `{"city":"Lyon"}`}}}},
+			{Role: types.RoleTool, Content: "sunny", ToolCallID: "abc123def"},
+		},
+		Tools: []models.ToolDefinition{{
+			Type: "function",
+			Function: models.FunctionSchema{
+				Name:       "get_weather",
+				Parameters: map[string]interface{}{"type": "object"},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got.Model != "mistral-large-latest" || len(got.Tools) != 1 || got.Tools[0].Function.Name != "get_weather" || got.ToolChoice != "auto" {
+		t.Errorf("unexpected request: %+v", got)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID != "xyz789abc" || resp.ToolCalls[0].Function.Arguments != `{"city":"Paris"}` {
+		t.Fatalf("unexpected tool calls: %+v", resp.ToolCalls)
+	}
+	if resp.Usage.TotalTokens != 28 || resp.Metadata.FinishReason != "tool_calls" {
+		t.Errorf("unexpected usage %+v or finish reason %q", resp.Usage, resp.Metadata.FinishReason)
+	}
+	if resp.ReasoningContent != nil {
+		t.Errorf("expected no reasoning, got %+v", resp.ReasoningContent)
+	}
+}
+
+func TestInvoke_ThinkingChunks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"id": "cmpl-2",
+			"model": "magistral-medium-latest",
+			"choices": [{
+				"index": 0,
+				"message": {
+					"role": "assistant",
+					"content": [
+						{"type": "thinking", "thinking": [{"type": "text", "text": "2 plus 2 "}, {"type": "text", "text": "is 4."}]},
+						{"type": "text", "text": "4"}
+					]
+				},
+				"finish_reason": "stop"
+			}]
+		}`))
+	}))
+	defer server.Close()
+
+	model, _ := New("magistral-medium-latest", Config{APIKey: "test-key", BaseURL: server.URL})
+	resp, err := model.Invoke(context.Background(), &models.InvokeRequest{
+		Messages: []*types.Message{{Role: types.RoleUser, Content: "2+2?"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Content != "4" {
+		t.Errorf("expected content %q, got %q", "4", resp.Content)
+	}
+	if resp.ReasoningContent == nil || resp.ReasoningContent.Content != "2 plus 2 is 4." {
+		t.Errorf("unexpected reasoning: %+v", resp.ReasoningContent)
+	}
+}
+
+func TestInvoke_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Unauthorized"}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	model, _ := New("mistral-small-latest", Config{APIKey: "bad-key", BaseURL: server.URL})
+	if _, err := model.Invoke(context.Background(), &models.InvokeRequest{
+		Messages: []*types.Message{{Role: types.RoleUser, Content: "hi"}},
+	}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if _, err := model.InvokeStream(context.Background(), &models.InvokeRequest{
+		Messages: []*types.Message{{Role: types.RoleUser, Content: "hi"}},
+	}); err == nil {
+		t.Fatal("expected a stream error")
+	}
+}
+
+func TestInvokeStream(t *testing.T) {
+	events := []string{
+		`{"id":"c","model":"magistral-small-latest","choices":[{"index":0,"delta":{"role":"assistant","content":""}}]}`,
+		`{"id":"c","choices":[{"index":0,"delta":{"content":[{"type":"thinking","thinking":[{"type":"text","text":"Need weather."}]}]}}]}`,
+		`{"id":"c","choices":[{"index":0,"delta":{"content":"Checking"}}]}`,
+		`{"id":"c","choices":[{"index":0,"delta":{"content":"","tool_calls":[{"id":"call00001","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"},"index":0}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":4,"completion_tokens":6,"total_tokens":10}}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["stream"] != true {
+			t.Errorf("expected stream=true, got %v", body["stream"])
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range events {
+			w.Write([]byte("data: " + event + "\n\n"))
+		}
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	model, _ := New("magistral-small-latest", Config{APIKey: "test-key", BaseURL: server.URL})
+	chunks, err := model.InvokeStream(context.Background(), &models.InvokeRequest{
+		Messages: []*types.Message{{Role: types.RoleUser, Content: "Weather in Paris?"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var reasoning, content string
+	var calls []types.ToolCall
+	var last types.ResponseChunk
+	for chunk := range chunks {
+		if chunk.Error != nil {
+			t.Fatalf("chunk error: %v", chunk.Error)
+		}
+		reasoning += chunk.ReasoningContent
+		content += chunk.Content
+		calls = append(calls, chunk.ToolCalls...)
+		last = chunk
+	}
+
+	if reasoning != "Need weather." || content != "Checking" {
+		t.Errorf("unexpected reasoning %q and content %q", reasoning, content)
+	}
+	if len(calls) != 1 || calls[0].ID != "call00001" || calls[0].Function.Name != "get_weather" {
+		t.Errorf("unexpected tool calls: %+v", calls)
+	}
+	if !last.Done || last.Usage == nil || last.Usage.TotalTokens != 10 {
+		t.Errorf("unexpected final chunk: %+v", last)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	if err := ValidateConfig(Config{APIKey: "test-key", Temperature: 0.7}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidateConfig(Config{}); err == nil {
+		t.Error("expected an error without an API key")
+	}
+	if err := ValidateConfig(Config{APIKey: "test-key", Temperature: 2}); err == nil {
+		t.Error("expected an error for an out of range temperature")
+	}
+}
@@ -0,0 +1,127 @@
+package mistral
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// chatRequest is the body of the chat completions endpoint
+type chatRequest struct {
+	Model       string    `json:"model"`
+	Messages    []message `json:"messages"`
+	Temperature *float64  `json:"temperature,omitempty"`
+	MaxTokens   int       `json:"max_tokens,omitempty"`
+	Stream      bool      `json:"stream,omitempty"`
+	Tools       []tool    `json:"tools,omitempty"`
+	ToolChoice  string    `json:"tool_choice,omitempty"`
+}
+
+// message is a chat message. Responses may carry the content as a list of
+// chunks, see content.
+type message struct {
+	Role       string     `json:"role,omitempty"`
+	Content    content    `json:"content"`
+	Name       string     `json:"name,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+	ToolCalls  []toolCall `json:"tool_calls,omitempty"`
+}
+
+// content is the content of a message. Requests always send a string.
+// Responses of reasoning models (Magistral) send a list of chunks instead,
+// where "thinking" chunks hold the reasoning and "text" chunks the answer.
+type content struct {
+	Text     string
+	Thinking string
+}
+
+// MarshalJSON sends the text as a plain string
+func (c content) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Text)
+}
+
+// UnmarshalJSON accepts a string, null or a list of chunks
+func (c *content) UnmarshalJSON(data []byte) error {
+	*c = content{}
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &c.Text)
+	}
+
+	var chunks []contentChunk
+	if err := json.Unmarshal(data, &chunks); err != nil {
+		return err
+	}
+	var text, thinking strings.Builder
+	for _, chunk := range chunks {
+		switch chunk.Type {
+		case "text":
+			text.WriteString(chunk.Text)
+		case "thinking":
+			for _, part := range chunk.Thinking {
+				thinking.WriteString(part.Text)
+			}
+		}
+	}
+	c.Text, c.Thinking = text.String(), thinking.String()
+	return nil
+}
+
+// contentChunk is one chunk of a list content
+type contentChunk struct {
+	Type     string         `json:"type"`
+	Text     string         `json:"text,omitempty"`
+	Thinking []contentChunk `json:"thinking,omitempty"`
+}
+
+// toolCall is a function call requested by the model
+type toolCall struct {
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Index    int          `json:"index,omitempty"`
+	Function functionCall `json:"function"`
+}
+
+// functionCall holds the name and JSON arguments of a call
+type functionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// tool declares a function the model can call
+type tool struct {
+	Type     string   `json:"type"`
+	Function function `json:"function"`
+}
+
+// function is the schema of a callable function
+type function struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// chatResponse is the response of the chat completions endpoint, and also a
+// chunk of a streamed response
+type chatResponse struct {
+	ID      string   `json:"id"`
+	Model   string   `json:"model"`
+	Choices []choice `json:"choices"`
+	Usage   *usage   `json:"usage,omitempty"`
+}
+
+// choice is one completion; Delta is set when streaming
+type choice struct {
+	Index        int      `json:"index"`
+	Message      message  `json:"message"`
+	Delta        *message `json:"delta,omitempty"`
+	FinishReason string   `json:"finish_reason"`
+}
+
+// usage is the token usage of a completion
+type usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
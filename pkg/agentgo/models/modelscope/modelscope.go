@@ -3,6 +3,7 @@ package modelscope
 import (
 	"context"
 	"encoding/json"
+	"net/http"
 
 	"github.com/jholhewres/agent-go/pkg/agentgo/models"
 	"github.com/jholhewres/agent-go/pkg/agentgo/types"
@@ -42,6 +43,7 @@ func New(modelID string, config Config) (*ModelScope, error) {
 
 	clientConfig := openai.DefaultConfig(config.APIKey)
 	clientConfig.BaseURL = baseURL
+	clientConfig.HTTPClient = &http.Client{Transport: models.NewHeaderTransport(nil)}
 
 	return &ModelScope{
 		BaseModel: models.BaseModel{
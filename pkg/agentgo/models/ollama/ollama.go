@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 
+	"github.com/jholhewres/agent-go/pkg/agentgo/ids"
 	"github.com/jholhewres/agent-go/pkg/agentgo/models"
 	"github.com/jholhewres/agent-go/pkg/agentgo/types"
 )
@@ -66,6 +67,7 @@ func (o *Ollama) Invoke(ctx context.Context, req *models.InvokeRequest) (*types.
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
+	models.ApplyContextHeaders(httpReq)
 
 	resp, err := o.httpClient.Do(httpReq)
 	if err != nil {
@@ -102,6 +104,7 @@ func (o *Ollama) InvokeStream(ctx context.Context, req *models.InvokeRequest) (<
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
+	models.ApplyContextHeaders(httpReq)
 
 	resp, err := o.httpClient.Do(httpReq)
 	if err != nil {
@@ -239,7 +242,7 @@ func (o *Ollama) convertResponse(resp *OllamaResponse) *types.ModelResponse {
 		modelResp.ToolCalls = make([]types.ToolCall, len(resp.Message.ToolCalls))
 		for i, tc := range resp.Message.ToolCalls {
 			modelResp.ToolCalls[i] = types.ToolCall{
-				ID:   fmt.Sprintf("call_%d", i),
+				ID:   "call_" + ids.New(),
 				Type: "function",
 				Function: types.ToolCallFunction{
 					Name:      tc.Function.Name,
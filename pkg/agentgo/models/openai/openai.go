@@ -3,8 +3,11 @@ package openai
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/jholhewres/agent-go/pkg/agentgo/models"
@@ -47,7 +50,8 @@ func New(modelID string, config Config) (*OpenAI, error) {
 		timeout = 60 * time.Second // Default 60 seconds / 默认60秒
 	}
 	clientConfig.HTTPClient = &http.Client{
-		Timeout: timeout,
+		Timeout:   timeout,
+		Transport: models.NewHeaderTransport(nil),
 	}
 
 	return &OpenAI{
@@ -110,6 +114,8 @@ func (o *OpenAI) Invoke(ctx context.Context, req *models.InvokeRequest) (*types.
 func (o *OpenAI) InvokeStream(ctx context.Context, req *models.InvokeRequest) (<-chan types.ResponseChunk, error) {
 	chatReq := o.buildChatRequest(req)
 	chatReq.Stream = true
+	// Ask for a final chunk with the token usage of the whole response
+	chatReq.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
 
 	stream, err := o.client.CreateChatCompletionStream(ctx, chatReq)
 	if err != nil {
@@ -122,8 +128,16 @@ func (o *OpenAI) InvokeStream(ctx context.Context, req *models.InvokeRequest) (<
 		defer close(chunks)
 		defer stream.Close()
 
+		var usage *types.Usage
 		for {
 			response, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				chunks <- types.ResponseChunk{
+					Done:  true,
+					Usage: usage,
+				}
+				return
+			}
 			if err != nil {
 				chunks <- types.ResponseChunk{
 					Done:  true,
@@ -132,6 +146,13 @@ func (o *OpenAI) InvokeStream(ctx context.Context, req *models.InvokeRequest) (<
 				return
 			}
 
+			if response.Usage != nil {
+				usage = &types.Usage{
+					PromptTokens:     response.Usage.PromptTokens,
+					CompletionTokens: response.Usage.CompletionTokens,
+					TotalTokens:      response.Usage.TotalTokens,
+				}
+			}
 			if len(response.Choices) == 0 {
 				continue
 			}
@@ -242,6 +263,17 @@ func (o *OpenAI) buildChatRequest(req *models.InvokeRequest) openai.ChatCompleti
 		chatReq.MaxTokens = o.config.MaxTokens
 	}
 
+	// Set seed
+	if req.Seed != nil {
+		seed := *req.Seed
+		chatReq.Seed = &seed
+	}
+
+	// Set reasoning effort; other models reject the parameter
+	if req.ReasoningEffort != "" && isReasoningModel(o.ID) {
+		chatReq.ReasoningEffort = req.ReasoningEffort
+	}
+
 	// Set response format (structured output)
 	if req.ResponseFormat != nil {
 		chatReq.ResponseFormat = &openai.ChatCompletionResponseFormat{
@@ -284,3 +316,14 @@ func ValidateConfig(config Config) error {
 	}
 	return nil
 }
+
+// isReasoningModel reports whether modelID accepts reasoning_effort (o-series and GPT-5)
+func isReasoningModel(modelID string) bool {
+	id := strings.ToLower(modelID)
+	for _, prefix := range []string{"o1", "o3", "o4", "gpt-5"} {
+		if strings.HasPrefix(id, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,7 +1,11 @@
 package openai
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -135,6 +139,16 @@ func TestOpenAI_buildChatRequest(t *testing.T) {
 			},
 			want: "gpt-4o-mini",
 		},
+		{
+			name: "with seed",
+			req: &models.InvokeRequest{
+				Messages: []*types.Message{
+					types.NewUserMessage("Hello"),
+				},
+				Seed: func() *int { s := 42; return &s }(),
+			},
+			want: "gpt-4o-mini",
+		},
 	}
 
 	for _, tt := range tests {
@@ -152,6 +166,9 @@ func TestOpenAI_buildChatRequest(t *testing.T) {
 			if tt.req.MaxTokens > 0 && chatReq.MaxTokens != tt.req.MaxTokens {
 				t.Errorf("buildChatRequest() max_tokens = %v, want %v", chatReq.MaxTokens, tt.req.MaxTokens)
 			}
+			if tt.req.Seed != nil && (chatReq.Seed == nil || *chatReq.Seed != *tt.req.Seed) {
+				t.Errorf("buildChatRequest() seed = %v, want %v", chatReq.Seed, *tt.req.Seed)
+			}
 		})
 	}
 }
@@ -688,3 +705,63 @@ func TestOpenAI_buildChatRequest_WithResponseFormat(t *testing.T) {
 		}
 	})
 }
+
+func TestOpenAI_buildChatRequest_ReasoningEffort(t *testing.T) {
+	req := &models.InvokeRequest{
+		Messages:        []*types.Message{{Role: types.RoleUser, Content: "Hi"}},
+		ReasoningEffort: models.ReasoningEffortHigh,
+	}
+
+	for modelID, want := range map[string]string{"o3-mini": "high", "gpt-5": "high", "gpt-4o": ""} {
+		model, err := New(modelID, Config{APIKey: "test-key"})
+		if err != nil {
+			t.Fatalf("Failed to create model: %v", err)
+		}
+		if got := model.buildChatRequest(req).ReasoningEffort; got != want {
+			t.Errorf("%s: ReasoningEffort = %q, want %q", modelID, got, want)
+		}
+	}
+}
+
+func TestOpenAI_InvokeStream_Usage(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w,
+			`data: {"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"Hello"}}]}`+"\n\n"+
+				`data: {"id":"1","object":"chat.completion.chunk","choices":[],"usage":{"prompt_tokens":12,"completion_tokens":3,"total_tokens":15}}`+"\n\n"+
+				"data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	model, err := New("gpt-4o-mini", Config{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	stream, err := model.InvokeStream(context.Background(), &models.InvokeRequest{Messages: []*types.Message{types.NewUserMessage("hi")}})
+	if err != nil {
+		t.Fatalf("InvokeStream() error = %v", err)
+	}
+
+	var content string
+	var usage *types.Usage
+	for chunk := range stream {
+		if chunk.Error != nil {
+			t.Fatalf("stream error = %v", chunk.Error)
+		}
+		content += chunk.Content
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+	}
+	if content != "Hello" {
+		t.Errorf("content = %q, want Hello", content)
+	}
+	if options, _ := body["stream_options"].(map[string]interface{}); options["include_usage"] != true {
+		t.Errorf("stream_options = %v, want include_usage", body["stream_options"])
+	}
+	if usage == nil || usage.PromptTokens != 12 || usage.CompletionTokens != 3 || usage.TotalTokens != 15 {
+		t.Fatalf("usage = %+v, want 12 prompt and 3 completion tokens", usage)
+	}
+}
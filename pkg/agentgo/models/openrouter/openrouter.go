@@ -71,7 +71,7 @@ func New(modelID string, config Config) (*OpenRouter, error) {
 		timeout = 60 * time.Second
 	}
 	// custom transport for extra headers
-	transport := http.DefaultTransport
+	transport := models.NewHeaderTransport(nil)
 	clientConfig.HTTPClient = &http.Client{
 		Timeout:   timeout,
 		Transport: headerRoundTripper{base: transport, referer: config.Referer, title: config.Title},
@@ -40,7 +40,7 @@ func New(modelID string, config Config) (*Portkey, error) {
 	if to == 0 {
 		to = 60 * time.Second
 	}
-	cc.HTTPClient = &http.Client{Timeout: to}
+	cc.HTTPClient = &http.Client{Timeout: to, Transport: models.NewHeaderTransport(nil)}
 	return &Portkey{BaseModel: models.BaseModel{ID: modelID, Provider: "portkey"}, client: openai.NewClientWithConfig(cc), config: Config{APIKey: config.APIKey, BaseURL: baseURL, Temperature: config.Temperature, MaxTokens: config.MaxTokens, Timeout: to}}, nil
 }
 
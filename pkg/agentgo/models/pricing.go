@@ -0,0 +1,47 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// ModelPricing is the price of a model in USD per million tokens
+type ModelPricing struct {
+	PromptPerMillion     float64 `json:"prompt_per_million" yaml:"prompt_per_million"`
+	CompletionPerMillion float64 `json:"completion_per_million" yaml:"completion_per_million"`
+}
+
+// PricingTable maps model IDs to prices. Keys match exactly or as a prefix,
+// so "gpt-4o" also prices dated IDs such as "gpt-4o-2024-08-06"; the longest
+// matching prefix wins. No prices are built in because they change often.
+type PricingTable map[string]ModelPricing
+
+// Lookup returns the pricing for modelID
+func (t PricingTable) Lookup(modelID string) (ModelPricing, bool) {
+	if pricing, ok := t[modelID]; ok {
+		return pricing, true
+	}
+
+	var (
+		best    ModelPricing
+		bestLen int
+	)
+	for prefix, pricing := range t {
+		if len(prefix) > bestLen && strings.HasPrefix(modelID, prefix) {
+			best, bestLen = pricing, len(prefix)
+		}
+	}
+	return best, bestLen > 0
+}
+
+// EstimateCost returns the cost of usage for modelID and whether the model is priced
+func (t PricingTable) EstimateCost(modelID string, usage types.Usage) (float64, bool) {
+	pricing, ok := t.Lookup(modelID)
+	if !ok {
+		return 0, false
+	}
+	cost := float64(usage.PromptTokens)*pricing.PromptPerMillion/1e6 +
+		float64(usage.CompletionTokens)*pricing.CompletionPerMillion/1e6
+	return cost, true
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+func TestPricingTable_EstimateCost(t *testing.T) {
+	table := PricingTable{
+		"gpt-4o":      {PromptPerMillion: 2.5, CompletionPerMillion: 10},
+		"gpt-4o-mini": {PromptPerMillion: 0.15, CompletionPerMillion: 0.6},
+	}
+	usage := types.Usage{PromptTokens: 1_000_000, CompletionTokens: 500_000}
+
+	cases := map[string]float64{
+		"gpt-4o":                 7.5,
+		"gpt-4o-2024-08-06":      7.5,
+		"gpt-4o-mini-2024-07-18": 0.45,
+	}
+	for modelID, want := range cases {
+		got, ok := table.EstimateCost(modelID, usage)
+		if !ok || math.Abs(got-want) > 1e-9 {
+			t.Errorf("EstimateCost(%s) = %v, %v; want %v", modelID, got, ok, want)
+		}
+	}
+
+	if _, ok := table.EstimateCost("claude-3", usage); ok {
+		t.Error("expected unknown model to be unpriced")
+	}
+}
@@ -0,0 +1,34 @@
+package models
+
+// Reasoning effort levels for InvokeRequest.ReasoningEffort
+const (
+	ReasoningEffortLow    = "low"
+	ReasoningEffortMedium = "medium"
+	ReasoningEffortHigh   = "high"
+)
+
+// Thinking budgets used for an effort level by providers that only accept a
+// token budget (Anthropic, Gemini)
+var reasoningEffortBudgets = map[string]int{
+	ReasoningEffortLow:    1024,
+	ReasoningEffortMedium: 8192,
+	ReasoningEffortHigh:   24576,
+}
+
+// ValidReasoningEffort reports whether effort is empty or a known level
+func ValidReasoningEffort(effort string) bool {
+	_, ok := reasoningEffortBudgets[effort]
+	return ok || effort == ""
+}
+
+// ReasoningBudget returns the reasoning token budget a request asks for:
+// MaxReasoningTokens when set, else the budget of its effort level, else 0.
+func ReasoningBudget(req *InvokeRequest) int {
+	if req == nil {
+		return 0
+	}
+	if req.MaxReasoningTokens > 0 {
+		return req.MaxReasoningTokens
+	}
+	return reasoningEffortBudgets[req.ReasoningEffort]
+}
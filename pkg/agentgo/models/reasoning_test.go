@@ -0,0 +1,28 @@
+package models
+
+import "testing"
+
+func TestReasoningBudget(t *testing.T) {
+	cases := []struct {
+		req  *InvokeRequest
+		want int
+	}{
+		{nil, 0},
+		{&InvokeRequest{}, 0},
+		{&InvokeRequest{ReasoningEffort: ReasoningEffortLow}, 1024},
+		{&InvokeRequest{ReasoningEffort: ReasoningEffortHigh}, 24576},
+		{&InvokeRequest{ReasoningEffort: ReasoningEffortHigh, MaxReasoningTokens: 2000}, 2000},
+		{&InvokeRequest{ReasoningEffort: "extreme"}, 0},
+	}
+	for _, c := range cases {
+		if got := ReasoningBudget(c.req); got != c.want {
+			t.Errorf("ReasoningBudget(%+v) = %d, want %d", c.req, got, c.want)
+		}
+	}
+
+	for effort, want := range map[string]bool{"": true, "low": true, "medium": true, "high": true, "max": false} {
+		if got := ValidReasoningEffort(effort); got != want {
+			t.Errorf("ValidReasoningEffort(%q) = %v, want %v", effort, got, want)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// RetryConfig configures retry behaviour for WithRetry
+type RetryConfig struct {
+	MaxRetries     int           // Retries after the first attempt (default: 3, negative disables)
+	InitialBackoff time.Duration // Delay before the first retry (default: 500ms)
+	MaxBackoff     time.Duration // Upper bound on a single delay (default: 30s)
+	Multiplier     float64       // Backoff growth factor (default: 2)
+	Jitter         float64       // Random +/- fraction applied to each delay, 0-1 (default: 0, no jitter)
+
+	// Retryable decides whether an error should be retried (default: IsTransientError)
+	Retryable func(err error) bool
+
+	// OnAttempt is called after every attempt, successful or not
+	OnAttempt func(info AttemptInfo)
+}
+
+// AttemptInfo describes a single model invocation attempt
+type AttemptInfo struct {
+	ModelID   string
+	Provider  string
+	Attempt   int           // 1-based attempt number
+	Err       error         // nil on success
+	Duration  time.Duration // Time spent in the attempt
+	NextDelay time.Duration // Backoff before the next attempt; 0 when no retry follows
+	Stream    bool
+}
+
+// RetryModel wraps a Model and retries transient failures with exponential backoff
+type RetryModel struct {
+	model  Model
+	config RetryConfig
+}
+
+// WithRetry wraps model so that transient errors (rate limits, 5xx, timeouts)
+// are retried with exponential backoff. Streams are only retried while opening.
+func WithRetry(model Model, config RetryConfig) *RetryModel {
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	} else if config.MaxRetries == 0 {
+		config.MaxRetries = 3
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = 500 * time.Millisecond
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = 30 * time.Second
+	}
+	if config.Multiplier < 1 {
+		config.Multiplier = 2
+	}
+	if config.Jitter < 0 {
+		config.Jitter = 0
+	} else if config.Jitter > 1 {
+		config.Jitter = 1
+	}
+	if config.Retryable == nil {
+		config.Retryable = IsTransientError
+	}
+	return &RetryModel{model: model, config: config}
+}
+
+// Unwrap returns the wrapped model
+func (r *RetryModel) Unwrap() Model {
+	return r.model
+}
+
+// GetProvider returns the wrapped model provider
+func (r *RetryModel) GetProvider() string {
+	return r.model.GetProvider()
+}
+
+// GetID returns the wrapped model ID
+func (r *RetryModel) GetID() string {
+	return r.model.GetID()
+}
+
+// GetName returns the wrapped model name
+func (r *RetryModel) GetName() string {
+	return r.model.GetName()
+}
+
+// Invoke calls the wrapped model, retrying transient failures
+func (r *RetryModel) Invoke(ctx context.Context, req *InvokeRequest) (*types.ModelResponse, error) {
+	var resp *types.ModelResponse
+	err := r.do(ctx, false, func() error {
+		var err error
+		resp, err = r.model.Invoke(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// InvokeStream opens a stream, retrying transient failures to open it.
+// Errors after the stream has started are not retried.
+func (r *RetryModel) InvokeStream(ctx context.Context, req *InvokeRequest) (<-chan types.ResponseChunk, error) {
+	var stream <-chan types.ResponseChunk
+	err := r.do(ctx, true, func() error {
+		var err error
+		stream, err = r.model.InvokeStream(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+func (r *RetryModel) do(ctx context.Context, stream bool, call func() error) error {
+	delay := r.config.InitialBackoff
+
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		start := time.Now()
+		err := call()
+		info := AttemptInfo{
+			ModelID:  r.model.GetID(),
+			Provider: r.model.GetProvider(),
+			Attempt:  attempt,
+			Err:      err,
+			Duration: time.Since(start),
+			Stream:   stream,
+		}
+
+		retry := err != nil && attempt <= r.config.MaxRetries && r.config.Retryable(err)
+		if retry {
+			info.NextDelay = r.jitter(delay)
+		}
+		if r.config.OnAttempt != nil {
+			r.config.OnAttempt(info)
+		}
+		if !retry {
+			return err
+		}
+
+		timer := time.NewTimer(info.NextDelay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+
+		delay = time.Duration(float64(delay) * r.config.Multiplier)
+		if delay > r.config.MaxBackoff {
+			delay = r.config.MaxBackoff
+		}
+	}
+}
+
+func (r *RetryModel) jitter(d time.Duration) time.Duration {
+	if r.config.Jitter == 0 {
+		return d
+	}
+	factor := 1 + r.config.Jitter*(2*rand.Float64()-1)
+	d = time.Duration(float64(d) * factor)
+	if d > r.config.MaxBackoff {
+		d = r.config.MaxBackoff
+	}
+	return d
+}
+
+// statusCodePattern matches "status 503" and the "status code: 503" of
+// OpenAI-compatible SDK errors
+var statusCodePattern = regexp.MustCompile(`status(?: code)?:? (\d{3})`)
+
+// IsTransientError reports whether err is worth retrying: rate limits,
+// timeouts, 408/429/5xx API responses and network failures. Client errors,
+// validation failures and context cancellation are not retried.
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var agnoErr *types.AgnoError
+	if errors.As(err, &agnoErr) {
+		switch agnoErr.Code {
+		case types.ErrCodeRateLimitError, types.ErrCodeModelTimeout:
+			return true
+		case types.ErrCodeAPIError:
+			if status, ok := statusCode(agnoErr.Error()); ok {
+				return isTransientStatus(status)
+			}
+			return true
+		default:
+			return false
+		}
+	}
+
+	if status, ok := statusCode(err.Error()); ok {
+		return isTransientStatus(status)
+	}
+	// Unclassified errors are usually transport failures (connection reset, DNS).
+	return true
+}
+
+// IsRateLimitError reports whether err is a rate limit: a rate-limit error or
+// a 429 API response
+func IsRateLimitError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var agnoErr *types.AgnoError
+	if errors.As(err, &agnoErr) && agnoErr.Code == types.ErrCodeRateLimitError {
+		return true
+	}
+	status, ok := statusCode(err.Error())
+	return ok && status == 429
+}
+
+func statusCode(message string) (int, bool) {
+	match := statusCodePattern.FindStringSubmatch(message)
+	if match == nil {
+		return 0, false
+	}
+	code, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, false
+	}
+	return code, true
+}
+
+func isTransientStatus(code int) bool {
+	return code == 408 || code == 429 || code >= 500
+}
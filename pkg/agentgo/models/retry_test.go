@@ -0,0 +1,152 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+type flakyModel struct {
+	BaseModel
+	errs  []error
+	calls int
+}
+
+func (m *flakyModel) Invoke(ctx context.Context, req *InvokeRequest) (*types.ModelResponse, error) {
+	m.calls++
+	if m.calls <= len(m.errs) {
+		return nil, m.errs[m.calls-1]
+	}
+	return &types.ModelResponse{Content: "ok"}, nil
+}
+
+func (m *flakyModel) InvokeStream(ctx context.Context, req *InvokeRequest) (<-chan types.ResponseChunk, error) {
+	m.calls++
+	if m.calls <= len(m.errs) {
+		return nil, m.errs[m.calls-1]
+	}
+	ch := make(chan types.ResponseChunk)
+	close(ch)
+	return ch, nil
+}
+
+func TestWithRetry_RetriesTransientErrors(t *testing.T) {
+	model := &flakyModel{
+		BaseModel: BaseModel{ID: "flaky", Provider: "test"},
+		errs: []error{
+			types.NewRateLimitError("rate limited", nil),
+			types.NewAPIError("API error (status 503): unavailable", nil),
+		},
+	}
+
+	var attempts []AttemptInfo
+	retrying := WithRetry(model, RetryConfig{
+		InitialBackoff: time.Millisecond,
+		OnAttempt:      func(info AttemptInfo) { attempts = append(attempts, info) },
+	})
+
+	resp, err := retrying.Invoke(context.Background(), &InvokeRequest{})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if resp.Content != "ok" || model.calls != 3 {
+		t.Fatalf("expected success on third call, got %q after %d calls", resp.Content, model.calls)
+	}
+	if len(attempts) != 3 || attempts[0].Err == nil || attempts[2].Err != nil {
+		t.Fatalf("unexpected attempts %+v", attempts)
+	}
+	if attempts[1].NextDelay != 2*time.Millisecond || attempts[2].NextDelay != 0 {
+		t.Fatalf("expected exponential backoff, got %v then %v", attempts[1].NextDelay, attempts[2].NextDelay)
+	}
+	if retrying.GetID() != "flaky" {
+		t.Fatalf("expected wrapper to report wrapped ID, got %s", retrying.GetID())
+	}
+}
+
+func TestWithRetry_DoesNotRetryClientErrors(t *testing.T) {
+	model := &flakyModel{
+		BaseModel: BaseModel{ID: "flaky"},
+		errs:      []error{types.NewAPIError("API error (status 400): bad request", nil)},
+	}
+	retrying := WithRetry(model, RetryConfig{InitialBackoff: time.Millisecond})
+
+	if _, err := retrying.Invoke(context.Background(), &InvokeRequest{}); err == nil {
+		t.Fatal("expected client error to be returned")
+	}
+	if model.calls != 1 {
+		t.Fatalf("expected a single attempt, got %d", model.calls)
+	}
+}
+
+func TestWithRetry_GivesUpAfterMaxRetries(t *testing.T) {
+	boom := types.NewRateLimitError("rate limited", nil)
+	model := &flakyModel{
+		BaseModel: BaseModel{ID: "flaky"},
+		errs:      []error{boom, boom, boom},
+	}
+	retrying := WithRetry(model, RetryConfig{MaxRetries: 1, InitialBackoff: time.Millisecond})
+
+	if _, err := retrying.InvokeStream(context.Background(), &InvokeRequest{}); !errors.Is(err, boom) {
+		t.Fatalf("expected last error, got %v", err)
+	}
+	if model.calls != 2 {
+		t.Fatalf("expected 2 attempts, got %d", model.calls)
+	}
+}
+
+func TestWithRetry_StopsOnContextCancel(t *testing.T) {
+	model := &flakyModel{
+		BaseModel: BaseModel{ID: "flaky"},
+		errs:      []error{types.NewRateLimitError("rate limited", nil)},
+	}
+	retrying := WithRetry(model, RetryConfig{InitialBackoff: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := retrying.Invoke(ctx, &InvokeRequest{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context error, got %v", err)
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{types.NewRateLimitError("429", nil), true},
+		{types.NewModelTimeoutError("timeout", nil), true},
+		{types.NewAPIError("API error (status 500): boom", nil), true},
+		{types.NewAPIError("API error (status 429): slow down", nil), true},
+		{types.NewAPIError("API error (status 401): unauthorized", nil), false},
+		{types.NewInvalidInputError("bad", nil), false},
+		{context.Canceled, false},
+		{errors.New("connection reset by peer"), true},
+	}
+	for _, tc := range cases {
+		if got := IsTransientError(tc.err); got != tc.want {
+			t.Errorf("IsTransientError(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestIsRateLimitError(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{types.NewRateLimitError("queue full", nil), true},
+		{types.NewAPIError("API error (status 429): slow down", nil), true},
+		{types.NewAPIError("failed to call OpenAI API", errors.New("error, status code: 429, status: 429 Too Many Requests, message: Rate limit reached")), true},
+		{types.NewAPIError("failed to call OpenAI API", errors.New("error, status code: 400, status: 400 Bad Request, message: bad")), false},
+		{types.NewAPIError("API error (status 500): boom", nil), false},
+		{nil, false},
+	}
+	for _, tc := range cases {
+		if got := IsRateLimitError(tc.err); got != tc.want {
+			t.Errorf("IsRateLimitError(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,389 @@
+package models
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/flags"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// SchedulerConfig configures a Scheduler
+type SchedulerConfig struct {
+	MaxConcurrent       int            // Calls in flight across all tenants (default: 8)
+	TenantWeights       map[string]int // Share of capacity per tenant; tenants not listed use DefaultWeight
+	DefaultWeight       int            // Weight of unlisted tenants (default: 1)
+	MaxTenantConcurrent int            // Calls in flight per tenant (0 = only the global limit applies)
+	MaxQueuePerTenant   int            // Calls waiting per tenant before new ones are rejected (0 = unlimited)
+
+	// TenantFunc returns the tenant of a call (default: TenantFromContext)
+	TenantFunc func(ctx context.Context) string
+}
+
+// Scheduler queues model calls in front of provider clients. It enforces a
+// global concurrency limit and dispatches waiting calls by priority, then by
+// weighted fair share across tenants (start-time fair queuing), so a tenant
+// that floods the queue only delays its own calls.
+type Scheduler struct {
+	mu       sync.Mutex
+	config   SchedulerConfig
+	inFlight int
+	queue    callQueue
+	tenants  map[string]*tenantState
+	vtime    float64 // Start tag of the last dispatched call
+	seq      uint64
+}
+
+type tenantState struct {
+	inFlight  int
+	queued    int
+	completed int64
+	rejected  int64
+	finish    float64 // Finish tag of the tenant's last queued call
+}
+
+type queuedCall struct {
+	tenant   string
+	priority int
+	start    float64
+	seq      uint64
+	ready    chan struct{}
+	index    int
+}
+
+// SchedulerStats is a snapshot of scheduler activity
+type SchedulerStats struct {
+	InFlight int                    `json:"in_flight"`
+	Queued   int                    `json:"queued"`
+	Tenants  map[string]TenantStats `json:"tenants"`
+}
+
+// TenantStats is a snapshot of one tenant's calls
+type TenantStats struct {
+	InFlight  int   `json:"in_flight"`
+	Queued    int   `json:"queued"`
+	Completed int64 `json:"completed"`
+	Rejected  int64 `json:"rejected"`
+}
+
+// NewScheduler creates a scheduler
+func NewScheduler(config SchedulerConfig) (*Scheduler, error) {
+	if config.MaxConcurrent < 0 || config.MaxTenantConcurrent < 0 || config.MaxQueuePerTenant < 0 || config.DefaultWeight < 0 {
+		return nil, types.NewInvalidConfigError("scheduler limits and weights must not be negative", nil)
+	}
+	if config.MaxConcurrent == 0 {
+		config.MaxConcurrent = 8
+	}
+	if config.DefaultWeight == 0 {
+		config.DefaultWeight = 1
+	}
+	for tenant, weight := range config.TenantWeights {
+		if weight <= 0 {
+			return nil, types.NewInvalidConfigError(fmt.Sprintf("tenant %s must have a positive weight", tenant), nil)
+		}
+	}
+	if config.TenantFunc == nil {
+		config.TenantFunc = TenantFromContext
+	}
+	return &Scheduler{
+		config:  config,
+		tenants: make(map[string]*tenantState),
+	}, nil
+}
+
+// Acquire waits for a slot for tenant and returns the function that releases
+// it. It fails when ctx is done first or the tenant's queue is full.
+func (s *Scheduler) Acquire(ctx context.Context, tenant string) (func(), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	ts := s.tenant(tenant)
+	if len(s.queue) == 0 && s.canRun(tenant, ts) {
+		s.start(ts)
+		s.mu.Unlock()
+		return s.releaser(tenant), nil
+	}
+	if s.config.MaxQueuePerTenant > 0 && ts.queued >= s.config.MaxQueuePerTenant {
+		ts.rejected++
+		s.mu.Unlock()
+		return nil, types.NewRateLimitError(fmt.Sprintf("scheduler queue for tenant %q is full", tenant), nil)
+	}
+
+	start := ts.finish
+	if s.vtime > start {
+		start = s.vtime
+	}
+	ts.finish = start + 1/float64(s.weight(tenant))
+	ts.queued++
+	s.seq++
+	call := &queuedCall{
+		tenant:   tenant,
+		priority: PriorityFromContext(ctx),
+		start:    start,
+		seq:      s.seq,
+		ready:    make(chan struct{}),
+	}
+	heap.Push(&s.queue, call)
+	s.dispatch()
+	s.mu.Unlock()
+
+	select {
+	case <-call.ready:
+		return s.releaser(tenant), nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		select {
+		case <-call.ready:
+			// Dispatched while giving up: hand the slot to the next call.
+			s.finish(tenant)
+		default:
+			heap.Remove(&s.queue, call.index)
+			ts.queued--
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// Stats returns a snapshot of the scheduler
+func (s *Scheduler) Stats() SchedulerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := SchedulerStats{
+		InFlight: s.inFlight,
+		Queued:   len(s.queue),
+		Tenants:  make(map[string]TenantStats, len(s.tenants)),
+	}
+	for name, ts := range s.tenants {
+		stats.Tenants[name] = TenantStats{
+			InFlight:  ts.inFlight,
+			Queued:    ts.queued,
+			Completed: ts.completed,
+			Rejected:  ts.rejected,
+		}
+	}
+	return stats
+}
+
+// Wrap returns a model whose calls go through the scheduler. Several models
+// can share one scheduler to enforce a limit across providers.
+func (s *Scheduler) Wrap(model Model) *ScheduledModel {
+	return &ScheduledModel{model: model, scheduler: s}
+}
+
+func (s *Scheduler) tenant(name string) *tenantState {
+	ts, ok := s.tenants[name]
+	if !ok {
+		ts = &tenantState{}
+		s.tenants[name] = ts
+	}
+	return ts
+}
+
+func (s *Scheduler) weight(tenant string) int {
+	if w, ok := s.config.TenantWeights[tenant]; ok {
+		return w
+	}
+	return s.config.DefaultWeight
+}
+
+func (s *Scheduler) canRun(tenant string, ts *tenantState) bool {
+	if s.inFlight >= s.config.MaxConcurrent {
+		return false
+	}
+	return s.config.MaxTenantConcurrent == 0 || ts.inFlight < s.config.MaxTenantConcurrent
+}
+
+func (s *Scheduler) start(ts *tenantState) {
+	s.inFlight++
+	ts.inFlight++
+}
+
+// dispatch starts queued calls while capacity allows. Calls whose tenant is
+// at its own limit are skipped and stay queued. Must be called with mu held.
+func (s *Scheduler) dispatch() {
+	var skipped []*queuedCall
+	for s.inFlight < s.config.MaxConcurrent && len(s.queue) > 0 {
+		call := heap.Pop(&s.queue).(*queuedCall)
+		ts := s.tenants[call.tenant]
+		if !s.canRun(call.tenant, ts) {
+			skipped = append(skipped, call)
+			continue
+		}
+		ts.queued--
+		s.start(ts)
+		if call.start > s.vtime {
+			s.vtime = call.start
+		}
+		close(call.ready)
+	}
+	for _, call := range skipped {
+		heap.Push(&s.queue, call)
+	}
+}
+
+// finish releases a slot. Must be called with mu held.
+func (s *Scheduler) finish(tenant string) {
+	ts := s.tenants[tenant]
+	s.inFlight--
+	ts.inFlight--
+	ts.completed++
+	if len(s.queue) == 0 && s.inFlight == 0 {
+		// Idle: restart virtual time so old tags do not penalize new calls.
+		s.vtime = 0
+		for _, t := range s.tenants {
+			t.finish = 0
+		}
+	}
+	s.dispatch()
+}
+
+func (s *Scheduler) releaser(tenant string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.finish(tenant)
+		})
+	}
+}
+
+// callQueue orders calls by priority (high first), start tag, then arrival
+type callQueue []*queuedCall
+
+func (q callQueue) Len() int { return len(q) }
+
+func (q callQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	if q[i].start != q[j].start {
+		return q[i].start < q[j].start
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q callQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *callQueue) Push(x interface{}) {
+	call := x.(*queuedCall)
+	call.index = len(*q)
+	*q = append(*q, call)
+}
+
+func (q *callQueue) Pop() interface{} {
+	old := *q
+	call := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	call.index = -1
+	return call
+}
+
+// ScheduledModel wraps a Model so every call waits for a scheduler slot
+type ScheduledModel struct {
+	model     Model
+	scheduler *Scheduler
+}
+
+// Unwrap returns the wrapped model
+func (m *ScheduledModel) Unwrap() Model {
+	return m.model
+}
+
+// GetProvider returns the wrapped model provider
+func (m *ScheduledModel) GetProvider() string {
+	return m.model.GetProvider()
+}
+
+// GetID returns the wrapped model ID
+func (m *ScheduledModel) GetID() string {
+	return m.model.GetID()
+}
+
+// GetName returns the wrapped model name
+func (m *ScheduledModel) GetName() string {
+	return m.model.GetName()
+}
+
+// Invoke waits for a slot, then calls the wrapped model
+func (m *ScheduledModel) Invoke(ctx context.Context, req *InvokeRequest) (*types.ModelResponse, error) {
+	release, err := m.scheduler.Acquire(ctx, m.scheduler.config.TenantFunc(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return m.model.Invoke(ctx, req)
+}
+
+// InvokeStream waits for a slot and holds it until the stream is drained
+func (m *ScheduledModel) InvokeStream(ctx context.Context, req *InvokeRequest) (<-chan types.ResponseChunk, error) {
+	release, err := m.scheduler.Acquire(ctx, m.scheduler.config.TenantFunc(ctx))
+	if err != nil {
+		return nil, err
+	}
+	stream, err := m.model.InvokeStream(ctx, req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	out := make(chan types.ResponseChunk)
+	go func() {
+		defer release()
+		defer close(out)
+		for chunk := range stream {
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				// Drain so the provider goroutine can exit.
+				for range stream {
+				}
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+type tenantKey struct{}
+type priorityKey struct{}
+
+// WithTenant attaches the tenant used by schedulers to ctx
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant set with WithTenant, falling back to
+// the TenantID of the flags evaluation context ("" when neither is set)
+func TenantFromContext(ctx context.Context) string {
+	if tenant, ok := ctx.Value(tenantKey{}).(string); ok && tenant != "" {
+		return tenant
+	}
+	if evalCtx, ok := flags.EvalContextFromContext(ctx); ok {
+		return evalCtx.TenantID
+	}
+	return ""
+}
+
+// WithPriority sets the scheduling priority of calls made with ctx. Higher
+// priorities are dispatched first, before fair sharing applies (default: 0).
+func WithPriority(ctx context.Context, priority int) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// PriorityFromContext returns the priority set with WithPriority
+func PriorityFromContext(ctx context.Context) int {
+	priority, _ := ctx.Value(priorityKey{}).(int)
+	return priority
+}
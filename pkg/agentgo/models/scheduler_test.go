@@ -0,0 +1,247 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/flags"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// enqueue starts a goroutine that acquires a slot, reports its name and
+// releases immediately, and waits until the call is queued.
+func enqueue(t *testing.T, s *Scheduler, ctx context.Context, tenant, name string, order chan<- string) {
+	t.Helper()
+	before := s.Stats().Queued
+	go func() {
+		release, err := s.Acquire(ctx, tenant)
+		if err != nil {
+			order <- "error:" + name
+			return
+		}
+		order <- name
+		release()
+	}()
+	waitFor(t, func() bool { return s.Stats().Queued == before+1 })
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func collect(t *testing.T, order <-chan string, n int) []string {
+	t.Helper()
+	var got []string
+	for i := 0; i < n; i++ {
+		select {
+		case name := <-order:
+			got = append(got, name)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out after %v", got)
+		}
+	}
+	return got
+}
+
+func TestScheduler_FairAcrossTenants(t *testing.T) {
+	s, _ := NewScheduler(SchedulerConfig{MaxConcurrent: 1})
+	ctx := context.Background()
+
+	hold, err := s.Acquire(ctx, "holder")
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	order := make(chan string, 10)
+	for _, name := range []string{"a1", "a2", "a3", "a4"} {
+		enqueue(t, s, ctx, "noisy", name, order)
+	}
+	enqueue(t, s, ctx, "quiet", "b1", order)
+
+	hold()
+	got := collect(t, order, 5)
+	want := []string{"a1", "b1", "a2", "a3", "a4"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("dispatch order = %v, want %v", got, want)
+		}
+	}
+
+	stats := s.Stats()
+	if stats.InFlight != 0 || stats.Queued != 0 || stats.Tenants["noisy"].Completed != 4 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestScheduler_WeightsAndPriority(t *testing.T) {
+	s, _ := NewScheduler(SchedulerConfig{MaxConcurrent: 1, TenantWeights: map[string]int{"gold": 2}})
+	ctx := context.Background()
+
+	hold, _ := s.Acquire(ctx, "holder")
+	order := make(chan string, 10)
+	enqueue(t, s, ctx, "basic", "b1", order)
+	enqueue(t, s, ctx, "basic", "b2", order)
+	enqueue(t, s, ctx, "gold", "g1", order)
+	enqueue(t, s, ctx, "gold", "g2", order)
+	enqueue(t, s, ctx, "gold", "g3", order)
+	enqueue(t, s, WithPriority(ctx, 10), "basic", "urgent", order)
+
+	hold()
+	got := collect(t, order, 6)
+	// Priority first; then gold gets two slots for each basic one.
+	want := []string{"urgent", "b1", "g1", "g2", "b2", "g3"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("dispatch order = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestScheduler_QueueLimitAndCancel(t *testing.T) {
+	s, _ := NewScheduler(SchedulerConfig{MaxConcurrent: 1, MaxQueuePerTenant: 1})
+	ctx := context.Background()
+
+	hold, _ := s.Acquire(ctx, "t")
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	order := make(chan string, 2)
+	enqueue(t, s, cancelCtx, "t", "queued", order)
+
+	_, err := s.Acquire(ctx, "t")
+	var agnoErr *types.AgnoError
+	if !errors.As(err, &agnoErr) || agnoErr.Code != types.ErrCodeRateLimitError {
+		t.Fatalf("expected rate limit error for full queue, got %v", err)
+	}
+	if s.Stats().Tenants["t"].Rejected != 1 {
+		t.Error("rejection not counted")
+	}
+
+	cancel()
+	if got := collect(t, order, 1); got[0] != "error:queued" {
+		t.Errorf("expected cancelled call, got %v", got)
+	}
+	if q := s.Stats().Queued; q != 0 {
+		t.Errorf("cancelled call still queued: %d", q)
+	}
+
+	hold()
+	if s.Stats().InFlight != 0 {
+		t.Error("slot not released")
+	}
+}
+
+func TestScheduler_TenantConcurrencyLimit(t *testing.T) {
+	s, _ := NewScheduler(SchedulerConfig{MaxConcurrent: 4, MaxTenantConcurrent: 1})
+	ctx := context.Background()
+
+	holdA, _ := s.Acquire(ctx, "a")
+	order := make(chan string, 4)
+	enqueue(t, s, ctx, "a", "a2", order)
+
+	// Another tenant is not blocked by a's limit.
+	enqueueFree(s, ctx, "b", order)
+	if got := collect(t, order, 1); got[0] != "b1" {
+		t.Errorf("expected b to run, got %v", got)
+	}
+
+	holdA()
+	if got := collect(t, order, 1); got[0] != "a2" {
+		t.Errorf("expected a2 after release, got %v", got)
+	}
+}
+
+func enqueueFree(s *Scheduler, ctx context.Context, tenant string, order chan<- string) {
+	go func() {
+		release, err := s.Acquire(ctx, tenant)
+		if err != nil {
+			order <- "error"
+			return
+		}
+		order <- tenant + "1"
+		release()
+	}()
+}
+
+type slowModel struct {
+	namedModel
+	active, peak int32
+}
+
+func (m *slowModel) Invoke(ctx context.Context, req *InvokeRequest) (*types.ModelResponse, error) {
+	n := atomic.AddInt32(&m.active, 1)
+	for {
+		peak := atomic.LoadInt32(&m.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(&m.peak, peak, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	atomic.AddInt32(&m.active, -1)
+	return &types.ModelResponse{Content: "ok"}, nil
+}
+
+func TestScheduledModel_LimitsConcurrency(t *testing.T) {
+	s, _ := NewScheduler(SchedulerConfig{MaxConcurrent: 2})
+	inner := &slowModel{namedModel: namedModel{BaseModel{ID: "slow"}}}
+	model := s.Wrap(inner)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx := flags.WithEvalContext(context.Background(), flags.EvalContext{TenantID: []string{"x", "y"}[i%2]})
+			if _, err := model.Invoke(ctx, &InvokeRequest{}); err != nil {
+				t.Errorf("Invoke: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if inner.peak > 2 {
+		t.Errorf("peak concurrency = %d, want <= 2", inner.peak)
+	}
+	stats := s.Stats()
+	if stats.Tenants["x"].Completed != 4 || stats.Tenants["y"].Completed != 4 {
+		t.Errorf("calls not attributed to tenants: %+v", stats.Tenants)
+	}
+	if model.GetID() != "slow" {
+		t.Errorf("GetID = %q", model.GetID())
+	}
+}
+
+func TestScheduledModel_StreamHoldsSlot(t *testing.T) {
+	s, _ := NewScheduler(SchedulerConfig{MaxConcurrent: 1})
+	model := s.Wrap(&namedModel{BaseModel{ID: "m"}})
+
+	stream, err := model.InvokeStream(WithTenant(context.Background(), "t"), &InvokeRequest{})
+	if err != nil {
+		t.Fatalf("InvokeStream: %v", err)
+	}
+	if s.Stats().Tenants["t"].InFlight != 1 {
+		t.Error("stream should hold a slot while open")
+	}
+	for range stream {
+	}
+	waitFor(t, func() bool { return s.Stats().InFlight == 0 })
+}
+
+func TestNewScheduler_Validation(t *testing.T) {
+	if _, err := NewScheduler(SchedulerConfig{MaxConcurrent: -1}); err == nil {
+		t.Error("expected error for negative limit")
+	}
+	if _, err := NewScheduler(SchedulerConfig{TenantWeights: map[string]int{"a": 0}}); err == nil {
+		t.Error("expected error for zero weight")
+	}
+}
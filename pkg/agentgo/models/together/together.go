@@ -49,7 +49,7 @@ func New(modelID string, config Config) (*Together, error) {
 	if timeout == 0 {
 		timeout = 60 * time.Second
 	}
-	clientConfig.HTTPClient = &http.Client{Timeout: timeout}
+	clientConfig.HTTPClient = &http.Client{Timeout: timeout, Transport: models.NewHeaderTransport(nil)}
 
 	return &Together{
 		BaseModel: models.BaseModel{ID: modelID, Provider: "together"},
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"unicode/utf8"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// TokenCounter counts the tokens a list of messages occupies in a model's
+// context window. Providers with an exact tokenizer can implement it on their
+// Model type; HeuristicTokenCounter is used otherwise. None of the built-in
+// providers implements it yet, so token budgets are estimates unless a counter
+// is passed explicitly (e.g. memory.ContextWindowConfig.Counter).
+type TokenCounter interface {
+	CountTokens(messages []*types.Message) int
+}
+
+// TokenCounterFunc adapts a function to TokenCounter
+type TokenCounterFunc func(messages []*types.Message) int
+
+// CountTokens implements TokenCounter
+func (f TokenCounterFunc) CountTokens(messages []*types.Message) int {
+	return f(messages)
+}
+
+// HeuristicTokenCounter estimates tokens at roughly four characters per token
+// plus a fixed per-message overhead for role and formatting. It errs on the
+// high side so trimmed histories stay within the real limit.
+type HeuristicTokenCounter struct {
+	CharsPerToken      float64 // default: 4
+	PerMessageOverhead int     // default: 4
+}
+
+// CountTokens implements TokenCounter
+func (h HeuristicTokenCounter) CountTokens(messages []*types.Message) int {
+	charsPerToken := h.CharsPerToken
+	if charsPerToken <= 0 {
+		charsPerToken = 4
+	}
+	overhead := h.PerMessageOverhead
+	if overhead <= 0 {
+		overhead = 4
+	}
+
+	total := 0
+	for _, msg := range messages {
+		if msg == nil {
+			continue
+		}
+		chars := utf8.RuneCountInString(msg.Content)
+		for _, tc := range msg.ToolCalls {
+			chars += utf8.RuneCountInString(tc.Function.Name) + utf8.RuneCountInString(tc.Function.Arguments)
+		}
+		total += overhead + int(float64(chars)/charsPerToken+0.999)
+	}
+	return total
+}
+
+// TokenCounterFor returns the model's own counter when it implements
+// TokenCounter, or a HeuristicTokenCounter otherwise
+func TokenCounterFor(model Model) TokenCounter {
+	if counter, ok := model.(TokenCounter); ok {
+		return counter
+	}
+	return HeuristicTokenCounter{}
+}
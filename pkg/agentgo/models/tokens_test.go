@@ -0,0 +1,42 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+func TestHeuristicTokenCounter(t *testing.T) {
+	counter := HeuristicTokenCounter{}
+
+	if got := counter.CountTokens(nil); got != 0 {
+		t.Errorf("empty = %d, want 0", got)
+	}
+
+	// 8 chars -> 2 tokens, plus 4 overhead
+	if got := counter.CountTokens([]*types.Message{types.NewUserMessage("abcdefgh")}); got != 6 {
+		t.Errorf("CountTokens = %d, want 6", got)
+	}
+
+	// Partial tokens round up; tool call names and arguments count.
+	msg := types.NewAssistantMessage("a")
+	msg.ToolCalls = []types.ToolCall{{Function: types.ToolCallFunction{Name: "add", Arguments: "{}"}}}
+	if got := counter.CountTokens([]*types.Message{msg}); got != 6 {
+		t.Errorf("CountTokens with tool call = %d, want 6", got)
+	}
+}
+
+type countingModel struct {
+	namedModel
+}
+
+func (*countingModel) CountTokens(messages []*types.Message) int { return 42 }
+
+func TestTokenCounterFor(t *testing.T) {
+	if got := TokenCounterFor(&countingModel{}).CountTokens(nil); got != 42 {
+		t.Errorf("expected model tokenizer, got %d", got)
+	}
+	if _, ok := TokenCounterFor(&namedModel{}).(HeuristicTokenCounter); !ok {
+		t.Error("expected heuristic fallback")
+	}
+}
@@ -40,7 +40,7 @@ func New(modelID string, config Config) (*Vercel, error) {
 	if to == 0 {
 		to = 60 * time.Second
 	}
-	clientConfig.HTTPClient = &http.Client{Timeout: to}
+	clientConfig.HTTPClient = &http.Client{Timeout: to, Transport: models.NewHeaderTransport(nil)}
 
 	return &Vercel{
 		BaseModel: models.BaseModel{ID: modelID, Provider: "vercel"},
@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// Run statuses recorded in agentgo_agent_runs_total
+const (
+	RunCompleted = "completed"
+	RunPaused    = "paused"
+	RunCancelled = "cancelled"
+	RunBlocked   = "blocked"
+	RunError     = "error"
+)
+
+// builtin holds the metrics an agent records
+type builtin struct {
+	runs          *CounterVec
+	runDuration   *HistogramVec
+	runsInFlight  *GaugeVec
+	tokens        *CounterVec
+	cost          *CounterVec
+	toolCalls     *CounterVec
+	toolDuration  *HistogramVec
+	guardrails    *CounterVec
+	vectorDB      *HistogramVec
+	vectorDBError *CounterVec
+}
+
+// NewRegistry creates a registry with the built-in metrics:
+//
+//   - agentgo_agent_runs_total{agent,status} — finished runs, by status:
+//     completed, paused, cancelled, blocked (by a guardrail) or error. Runs
+//     waiting for an approval, or stateless turns leaving work for the next
+//     turn, are paused; Resume and ResumeTurn calls are counted as runs.
+//   - agentgo_agent_run_duration_seconds{agent} — run latency
+//   - agentgo_agent_runs_in_flight{agent} — runs in progress
+//   - agentgo_model_tokens_total{agent,model,type} — tokens by type:
+//     prompt or completion
+//   - agentgo_model_cost_usd_total{agent,model} — estimated model cost, for
+//     models with pricing
+//   - agentgo_tool_calls_total{agent,tool,status} — tool calls by status:
+//     success, failed, blocked, pending_approval or timeout
+//   - agentgo_tool_duration_seconds{agent,tool} — tool latency
+//   - agentgo_guardrail_blocks_total{agent,stage,guardrail} — inputs and
+//     outputs blocked by a guardrail
+//   - agentgo_vectordb_operation_duration_seconds{db,operation} — vector
+//     database latency, recorded by MeteredVectorDB
+//   - agentgo_vectordb_errors_total{db,operation} — failed vector database
+//     operations
+func NewRegistry() *Registry {
+	r := &Registry{families: map[string]family{}}
+	r.runs = r.mustCounter("agentgo_agent_runs_total", "Agent runs finished, by status.", "agent", "status")
+	r.runDuration = r.mustHistogram("agentgo_agent_run_duration_seconds", "Agent run latency in seconds.", "agent")
+	r.runsInFlight = r.mustGauge("agentgo_agent_runs_in_flight", "Agent runs in progress.", "agent")
+	r.tokens = r.mustCounter("agentgo_model_tokens_total", "Model tokens used, by type.", "agent", "model", "type")
+	r.cost = r.mustCounter("agentgo_model_cost_usd_total", "Estimated model cost in USD.", "agent", "model")
+	r.toolCalls = r.mustCounter("agentgo_tool_calls_total", "Tool calls, by status.", "agent", "tool", "status")
+	r.toolDuration = r.mustHistogram("agentgo_tool_duration_seconds", "Tool call latency in seconds.", "agent", "tool")
+	r.guardrails = r.mustCounter("agentgo_guardrail_blocks_total", "Inputs and outputs blocked by a guardrail.", "agent", "stage", "guardrail")
+	r.vectorDB = r.mustHistogram("agentgo_vectordb_operation_duration_seconds", "Vector database operation latency in seconds.", "db", "operation")
+	r.vectorDBError = r.mustCounter("agentgo_vectordb_errors_total", "Failed vector database operations.", "db", "operation")
+	return r
+}
+
+func (r *Registry) mustCounter(name, help string, labels ...string) *CounterVec {
+	c, err := r.Counter(name, help, labels...)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func (r *Registry) mustGauge(name, help string, labels ...string) *GaugeVec {
+	g, err := r.Gauge(name, help, labels...)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+func (r *Registry) mustHistogram(name, help string, labels ...string) *HistogramVec {
+	h, err := r.Histogram(name, help, nil, labels...)
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
+// StartRun records the start of a run and returns the function recording its
+// end with one of the Run statuses. A nil registry records nothing.
+func (r *Registry) StartRun(agentID string) func(status string) {
+	if r == nil {
+		return func(string) {}
+	}
+	start := time.Now()
+	r.runsInFlight.Add(1, agentID)
+	return func(status string) {
+		r.runsInFlight.Add(-1, agentID)
+		r.runs.Inc(agentID, status)
+		r.runDuration.Observe(time.Since(start).Seconds(), agentID)
+	}
+}
+
+// AddTokens records the tokens and cost of one model response
+func (r *Registry) AddTokens(agentID, model string, usage types.Usage) {
+	if r == nil {
+		return
+	}
+	r.tokens.Add(float64(usage.PromptTokens), agentID, model, "prompt")
+	r.tokens.Add(float64(usage.CompletionTokens), agentID, model, "completion")
+	r.cost.Add(usage.EstimatedCost, agentID, model)
+}
+
+// ObserveToolCall records a tool call with its status and latency
+func (r *Registry) ObserveToolCall(agentID, tool, status string, d time.Duration) {
+	if r == nil {
+		return
+	}
+	r.toolCalls.Inc(agentID, tool, status)
+	r.toolDuration.Observe(d.Seconds(), agentID, tool)
+}
+
+// GuardrailBlocked records an input or output blocked by a guardrail; stage
+// is "input" or "output"
+func (r *Registry) GuardrailBlocked(agentID, stage, guardrail string) {
+	if r == nil {
+		return
+	}
+	r.guardrails.Inc(agentID, stage, guardrail)
+}
+
+// ObserveVectorDB records a vector database operation
+func (r *Registry) ObserveVectorDB(db, operation string, d time.Duration, err error) {
+	if r == nil {
+		return
+	}
+	r.vectorDB.Observe(d.Seconds(), db, operation)
+	if err != nil {
+		r.vectorDBError.Inc(db, operation)
+	}
+}
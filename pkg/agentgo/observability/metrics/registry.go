@@ -0,0 +1,364 @@
+// Package metrics collects agent metrics and serves them in the Prometheus
+// text exposition format, so operators can scrape agent health without a
+// Prometheus client dependency or hooks of their own.
+//
+// A Registry comes with built-in metrics (see NewRegistry) that an agent
+// records when it is given the registry in agent.Config.Metrics. Custom
+// metrics are created with Counter, Gauge and Histogram.
+//
+//	reg := metrics.NewRegistry()
+//	ag, _ := agent.New(agent.Config{Model: model, Metrics: reg})
+//	http.Handle("/metrics", reg.Handler())
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the latency buckets in seconds used by the built-in
+// histograms, from 5ms to 2 minutes
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+
+var (
+	metricNameRe = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRe  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Registry holds metric families and writes them in the Prometheus text format
+type Registry struct {
+	mu       sync.RWMutex
+	families map[string]family
+
+	builtin
+}
+
+// family is a named metric with its samples
+type family interface {
+	write(w *bufio.Writer, name string)
+}
+
+// register adds a family after checking its name and labels
+func (r *Registry) register(name string, labels []string, f family) error {
+	if !metricNameRe.MatchString(name) {
+		return fmt.Errorf("invalid metric name %q", name)
+	}
+	seen := map[string]bool{}
+	for _, label := range labels {
+		if !labelNameRe.MatchString(label) || strings.HasPrefix(label, "__") || label == "le" {
+			return fmt.Errorf("invalid label name %q for metric %s", label, name)
+		}
+		if seen[label] {
+			return fmt.Errorf("duplicate label %q for metric %s", label, name)
+		}
+		seen[label] = true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.families[name]; exists {
+		return fmt.Errorf("metric %s is already registered", name)
+	}
+	r.families[name] = f
+	return nil
+}
+
+// Counter registers a counter, a value that only goes up, e.g. requests served
+func (r *Registry) Counter(name, help string, labels ...string) (*CounterVec, error) {
+	c := &CounterVec{vec: newVec(help, "counter", labels)}
+	if err := r.register(name, labels, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Gauge registers a gauge, a value that goes up and down, e.g. runs in flight
+func (r *Registry) Gauge(name, help string, labels ...string) (*GaugeVec, error) {
+	g := &GaugeVec{vec: newVec(help, "gauge", labels)}
+	if err := r.register(name, labels, g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Histogram registers a histogram counting observations, e.g. latencies in
+// seconds, into buckets (default: DefaultBuckets)
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) (*HistogramVec, error) {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	if n := len(buckets); math.IsInf(buckets[n-1], 1) {
+		buckets = buckets[:n-1]
+	}
+	h := &HistogramVec{vec: newVec(help, "histogram", labels), buckets: buckets}
+	if err := r.register(name, labels, h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// WriteTo writes every metric in the Prometheus text exposition format,
+// sorted by name
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	families := make(map[string]family, len(r.families))
+	for name, f := range r.families {
+		families[name] = f
+	}
+	r.mu.RUnlock()
+	sort.Strings(names)
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, name := range names {
+		families[name].write(bw, name)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// Handler serves the metrics for Prometheus to scrape
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = r.WriteTo(w)
+	})
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// vec holds the label values of a family's series
+type vec struct {
+	help   string
+	kind   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// series is one set of label values. Counters and gauges use value;
+// histograms use counts, sum and count.
+type series struct {
+	values []string
+	value  float64
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func newVec(help, kind string, labels []string) vec {
+	return vec{help: help, kind: kind, labels: append([]string(nil), labels...), series: map[string]*series{}}
+}
+
+// get returns the series for values, creating it; missing values are empty
+// and extra values are ignored. Callers hold v.mu.
+func (v *vec) get(values []string, buckets int) *series {
+	normalized := make([]string, len(v.labels))
+	copy(normalized, values)
+	key := strings.Join(normalized, "\xff")
+	s, ok := v.series[key]
+	if !ok {
+		s = &series{values: normalized}
+		if buckets > 0 {
+			s.counts = make([]uint64, buckets)
+		}
+		v.series[key] = s
+	}
+	return s
+}
+
+// sorted returns the series ordered by label values. Callers hold v.mu.
+func (v *vec) sorted() []*series {
+	out := make([]*series, 0, len(v.series))
+	for _, s := range v.series {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i].values, out[j].values
+		for k := range a {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return false
+	})
+	return out
+}
+
+func (v *vec) header(w *bufio.Writer, name string) {
+	if v.help != "" {
+		fmt.Fprintf(w, "# HELP %s %s\n", name, escapeHelp(v.help))
+	}
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, v.kind)
+}
+
+// labelPairs formats the labels of s, plus extra pairs, as {a="x",b="y"}
+func (v *vec) labelPairs(s *series, extra ...string) string {
+	if len(v.labels) == 0 && len(extra) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, label := range v.labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `%s="%s"`, label, escapeLabel(s.values[i]))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		if b.Len() > 1 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `%s="%s"`, extra[i], escapeLabel(extra[i+1]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// CounterVec is a counter with labels
+type CounterVec struct {
+	vec
+}
+
+// Add adds delta, which must not be negative, to the series with the given
+// label values, in the order the labels were registered
+func (c *CounterVec) Add(delta float64, values ...string) {
+	if c == nil || delta < 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.get(values, 0).value += delta
+}
+
+// Inc adds one to the series with the given label values
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+func (c *CounterVec) write(w *bufio.Writer, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header(w, name)
+	for _, s := range c.sorted() {
+		fmt.Fprintf(w, "%s%s %s\n", name, c.labelPairs(s), formatFloat(s.value))
+	}
+}
+
+// GaugeVec is a gauge with labels
+type GaugeVec struct {
+	vec
+}
+
+// Set sets the series with the given label values
+func (g *GaugeVec) Set(value float64, values ...string) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.get(values, 0).value = value
+}
+
+// Add adds delta, which may be negative, to the series with the given label values
+func (g *GaugeVec) Add(delta float64, values ...string) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.get(values, 0).value += delta
+}
+
+func (g *GaugeVec) write(w *bufio.Writer, name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.header(w, name)
+	for _, s := range g.sorted() {
+		fmt.Fprintf(w, "%s%s %s\n", name, g.labelPairs(s), formatFloat(s.value))
+	}
+}
+
+// HistogramVec is a histogram with labels
+type HistogramVec struct {
+	vec
+	buckets []float64
+}
+
+// Observe records value in the series with the given label values
+func (h *HistogramVec) Observe(value float64, values ...string) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.get(values, len(h.buckets))
+	for i, bound := range h.buckets {
+		if value <= bound {
+			s.counts[i]++
+			break
+		}
+	}
+	s.sum += value
+	s.count++
+}
+
+func (h *HistogramVec) write(w *bufio.Writer, name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w, name)
+	for _, s := range h.sorted() {
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", name, h.labelPairs(s, "le", formatFloat(bound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", name, h.labelPairs(s, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", name, h.labelPairs(s), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", name, h.labelPairs(s), s.count)
+	}
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_WriteTo(t *testing.T) {
+	reg := &Registry{families: map[string]family{}}
+	requests, err := reg.Counter("http_requests_total", "Requests served.", "method", "path")
+	if err != nil {
+		t.Fatalf("Counter: %v", err)
+	}
+	inflight, _ := reg.Gauge("inflight", "")
+	latency, err := reg.Histogram("latency_seconds", "Latency\nin seconds.", []float64{1, 0.1, 0.5}, "op")
+	if err != nil {
+		t.Fatalf("Histogram: %v", err)
+	}
+
+	requests.Inc("GET", `/a"b\`)
+	requests.Add(2, "GET", `/a"b\`)
+	requests.Add(-1, "GET", `/a"b\`) // ignored: counters only go up
+	requests.Inc("POST")
+	inflight.Add(3)
+	inflight.Add(-1)
+	latency.Observe(0.05, "q")
+	latency.Observe(0.7, "q")
+	latency.Observe(4, "q")
+
+	var b strings.Builder
+	if _, err := reg.WriteTo(&b); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	want := `# HELP http_requests_total Requests served.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",path="/a\"b\\"} 3
+http_requests_total{method="POST",path=""} 1
+# TYPE inflight gauge
+inflight 2
+# HELP latency_seconds Latency\nin seconds.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{op="q",le="0.1"} 1
+latency_seconds_bucket{op="q",le="0.5"} 1
+latency_seconds_bucket{op="q",le="1"} 2
+latency_seconds_bucket{op="q",le="+Inf"} 3
+latency_seconds_sum{op="q"} 4.75
+latency_seconds_count{op="q"} 3
+`
+	if b.String() != want {
+		t.Errorf("exposition =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestRegistry_RegisterErrors(t *testing.T) {
+	reg := NewRegistry()
+	for _, tc := range []struct {
+		name   string
+		labels []string
+	}{
+		{"agentgo_agent_runs_total", nil}, // already registered
+		{"bad-name", nil},
+		{"ok_total", []string{"le"}},
+		{"ok_total", []string{"__reserved"}},
+		{"ok_total", []string{"a", "a"}},
+	} {
+		if _, err := reg.Counter(tc.name, "", tc.labels...); err == nil {
+			t.Errorf("Counter(%q, %v) succeeded", tc.name, tc.labels)
+		}
+	}
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	reg := NewRegistry()
+	reg.GuardrailBlocked("support", "input", "prompt_injection")
+
+	rec := httptest.NewRecorder()
+	reg.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), `agentgo_guardrail_blocks_total{agent="support",stage="input",guardrail="prompt_injection"} 1`) {
+		t.Errorf("body =\n%s", rec.Body.String())
+	}
+}
+
+func TestRegistry_NilIsNoop(t *testing.T) {
+	var reg *Registry
+	reg.StartRun("a")(RunCompleted)
+	reg.GuardrailBlocked("a", "input", "g")
+	reg.ObserveVectorDB("memvec", "query", 0, nil)
+}
@@ -0,0 +1,157 @@
+package metrics
+
+import (
+	"context"
+	"path"
+	"reflect"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+)
+
+// MeteredVectorDB wraps a vectordb.VectorDB and records the latency and
+// errors of every operation in agentgo_vectordb_operation_duration_seconds
+// and agentgo_vectordb_errors_total. The db label is the database package,
+// e.g. "chromadb" or "memvec"; operation is e.g. "query" or "add".
+//
+// Optional interfaces of the wrapped database, such as vectordb.Scanner, are
+// reachable through Unwrap.
+type MeteredVectorDB struct {
+	db     vectordb.VectorDB
+	reg    *Registry
+	system string
+}
+
+var _ vectordb.VectorDB = (*MeteredVectorDB)(nil)
+
+// NewMeteredVectorDB wraps db so that every operation is recorded in reg.
+//
+//	kb, _ := knowledge.NewKnowledgeBase(knowledge.KnowledgeBaseConfig{
+//	    VectorDB: metrics.NewMeteredVectorDB(db, reg),
+//	    ...
+//	})
+func NewMeteredVectorDB(db vectordb.VectorDB, reg *Registry) *MeteredVectorDB {
+	return &MeteredVectorDB{db: db, reg: reg, system: dbSystem(db)}
+}
+
+// dbSystem names a database after the package implementing it
+func dbSystem(db vectordb.VectorDB) string {
+	t := reflect.TypeOf(db)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if pkg := path.Base(t.PkgPath()); pkg != "." && pkg != "/" {
+		return pkg
+	}
+	return "other"
+}
+
+// Unwrap returns the wrapped database
+func (d *MeteredVectorDB) Unwrap() vectordb.VectorDB {
+	return d.db
+}
+
+// CreateCollection creates a collection and records its latency
+func (d *MeteredVectorDB) CreateCollection(ctx context.Context, name string, metadata map[string]interface{}) error {
+	start := time.Now()
+	err := d.db.CreateCollection(ctx, name, metadata)
+	d.observe("create_collection", start, err)
+	return err
+}
+
+// DeleteCollection deletes a collection and records its latency
+func (d *MeteredVectorDB) DeleteCollection(ctx context.Context, name string) error {
+	start := time.Now()
+	err := d.db.DeleteCollection(ctx, name)
+	d.observe("delete_collection", start, err)
+	return err
+}
+
+// Add adds documents and records the latency
+func (d *MeteredVectorDB) Add(ctx context.Context, documents []vectordb.Document) error {
+	start := time.Now()
+	err := d.db.Add(ctx, documents)
+	d.observe("add", start, err)
+	return err
+}
+
+// Update updates documents and records the latency
+func (d *MeteredVectorDB) Update(ctx context.Context, documents []vectordb.Document) error {
+	start := time.Now()
+	err := d.db.Update(ctx, documents)
+	d.observe("update", start, err)
+	return err
+}
+
+// Delete deletes documents and records the latency
+func (d *MeteredVectorDB) Delete(ctx context.Context, ids []string) error {
+	start := time.Now()
+	err := d.db.Delete(ctx, ids)
+	d.observe("delete", start, err)
+	return err
+}
+
+// DeleteByFilter deletes matching documents and records the latency
+func (d *MeteredVectorDB) DeleteByFilter(ctx context.Context, filter map[string]interface{}) error {
+	start := time.Now()
+	err := d.db.DeleteByFilter(ctx, filter)
+	d.observe("delete_by_filter", start, err)
+	return err
+}
+
+// Query searches by text and records the latency
+func (d *MeteredVectorDB) Query(ctx context.Context, query string, limit int, filter map[string]interface{}) ([]vectordb.SearchResult, error) {
+	start := time.Now()
+	results, err := d.db.Query(ctx, query, limit, filter)
+	d.observe("query", start, err)
+	return results, err
+}
+
+// QueryWithEmbedding searches by embedding and records the latency
+func (d *MeteredVectorDB) QueryWithEmbedding(ctx context.Context, embedding []float32, limit int, filter map[string]interface{}) ([]vectordb.SearchResult, error) {
+	start := time.Now()
+	results, err := d.db.QueryWithEmbedding(ctx, embedding, limit, filter)
+	d.observe("query_with_embedding", start, err)
+	return results, err
+}
+
+// Get retrieves documents and records the latency
+func (d *MeteredVectorDB) Get(ctx context.Context, ids []string) ([]vectordb.Document, error) {
+	start := time.Now()
+	documents, err := d.db.Get(ctx, ids)
+	d.observe("get", start, err)
+	return documents, err
+}
+
+// Count counts documents and records the latency
+func (d *MeteredVectorDB) Count(ctx context.Context) (int, error) {
+	start := time.Now()
+	count, err := d.db.Count(ctx)
+	d.observe("count", start, err)
+	return count, err
+}
+
+// ListCollections lists collections and records the latency
+func (d *MeteredVectorDB) ListCollections(ctx context.Context) ([]string, error) {
+	start := time.Now()
+	names, err := d.db.ListCollections(ctx)
+	d.observe("list_collections", start, err)
+	return names, err
+}
+
+// CollectionStats describes a collection and records the latency
+func (d *MeteredVectorDB) CollectionStats(ctx context.Context, name string) (vectordb.CollectionStats, error) {
+	start := time.Now()
+	stats, err := d.db.CollectionStats(ctx, name)
+	d.observe("collection_stats", start, err)
+	return stats, err
+}
+
+// Close closes the wrapped database
+func (d *MeteredVectorDB) Close() error {
+	return d.db.Close()
+}
+
+func (d *MeteredVectorDB) observe(operation string, start time.Time, err error) {
+	d.reg.ObserveVectorDB(d.system, operation, time.Since(start), err)
+}
@@ -21,9 +21,12 @@ import (
 //   - tool.status    — "success" | "error"
 //   - tool.duration_ms — wall-clock duration in milliseconds
 //   - agent.id       — owning agent ID
+//   - gen_ai.operation.name, gen_ai.tool.name, gen_ai.tool.call.id and
+//     gen_ai.agent.id — the GenAI semantic-convention equivalents
 //
 // The hook stores the open span in ToolHookInput.Metadata so that OnToolPost
-// can find and finish it.
+// can find and finish it, and sets the tool's context to one carrying the
+// span, so spans the tool starts become its children.
 type ToolTracingHook struct {
 	tracer trace.Tracer
 }
@@ -35,21 +38,26 @@ func NewToolTracingHook(tracer trace.Tracer) hooks.ToolHooker {
 
 // OnToolPre starts a span named "tool.<FunctionName>" and stores it in Metadata.
 func (h *ToolTracingHook) OnToolPre(ctx context.Context, input *hooks.ToolHookInput) error {
-	_, span := h.tracer.Start(ctx, "tool."+input.FunctionName,
+	spanCtx, span := h.tracer.Start(ctx, "tool."+input.FunctionName,
 		trace.WithSpanKind(trace.SpanKindInternal),
 		trace.WithAttributes(
 			attribute.String("tool.name", input.FunctionName),
 			attribute.String("agent.id", input.AgentID),
 			attribute.String("tool.call_id", input.ToolCallID),
+			attrGenAIOperationName.String(operationExecuteTool),
+			attrGenAIToolName.String(input.FunctionName),
+			attrGenAIToolCallID.String(input.ToolCallID),
+			attrGenAIAgentID.String(input.AgentID),
 		),
 	)
-	input.Metadata["otel.span"] = span
+	input.SetMetadata("otel.span", span)
+	input.SetContext(spanCtx)
 	return nil
 }
 
 // OnToolPost ends the span stored by OnToolPre, recording status and duration.
 func (h *ToolTracingHook) OnToolPost(ctx context.Context, input *hooks.ToolHookInput) error {
-	raw, ok := input.Metadata["otel.span"]
+	raw, ok := input.GetMetadata("otel.span")
 	if !ok {
 		return nil
 	}
@@ -80,12 +88,37 @@ func (h *ToolTracingHook) OnToolPost(ctx context.Context, input *hooks.ToolHookI
 type agentSpanEntry struct {
 	span      trace.Span
 	startTime time.Time
+	once      sync.Once
 }
 
+// end finishes the span once, with the run's error or nil on success.
+func (e *agentSpanEntry) end(runErr error) {
+	e.once.Do(func() {
+		durationMs := float64(time.Since(e.startTime)) / float64(time.Millisecond)
+		e.span.SetAttributes(attribute.Float64("agent.duration_ms", durationMs))
+		if runErr != nil {
+			e.span.SetStatus(codes.Error, runErr.Error())
+			e.span.SetAttributes(attribute.String("agent.status", "error"))
+			e.span.RecordError(runErr)
+		} else {
+			e.span.SetStatus(codes.Ok, "")
+			e.span.SetAttributes(attribute.String("agent.status", "success"))
+		}
+		e.span.End()
+	})
+}
+
+type agentSpanKey struct{}
+
 // AgentTracingHook holds state for tracing an agent Run.
-// Because the PreHook and PostHook are separate hooks.HookFunc values that
-// both reference a shared span store (sync.Map keyed by AgentID), parent spans
-// propagate correctly across the pre→run→post lifecycle.
+//
+// The pre-hook starts an "agent.run" span and hands the agent a context
+// carrying it (hooks.HookInput.SetContext), so model, tool, memory and vector
+// database spans of the run become its children. The span ends when the run
+// does (hooks.HookInput.OnRunEnd), including runs that fail after the
+// pre-hooks; the post-hook records the output. Callers that drive the hooks
+// themselves without those signals are matched by AgentID instead, through a
+// shared span store.
 type AgentTracingHook struct {
 	tracer trace.Tracer
 	spans  sync.Map // map[agentID]*agentSpanEntry
@@ -105,33 +138,44 @@ func NewAgentTracingHook(tracer trace.Tracer) (hooks.HookFunc, hooks.HookFunc) {
 	return h.preHook, h.postHook
 }
 
-// preHook starts an "agent.run" span and stores it keyed by AgentID.
+// preHook starts an "agent.run" span and makes it the parent of the rest of the run.
 func (h *AgentTracingHook) preHook(ctx context.Context, input *hooks.HookInput) error {
 	if input.AgentID == "" {
 		return nil
 	}
 
-	_, span := h.tracer.Start(ctx, "agent.run",
+	spanCtx, span := h.tracer.Start(ctx, "agent.run",
 		trace.WithSpanKind(trace.SpanKindInternal),
 		trace.WithAttributes(
 			attribute.String("agent.id", input.AgentID),
 			attribute.Int("input.length", len(input.Input)),
+			attrGenAIOperationName.String(operationInvokeAgent),
+			attrGenAIAgentID.String(input.AgentID),
 		),
 	)
 
-	h.spans.Store(input.AgentID, &agentSpanEntry{
-		span:      span,
-		startTime: time.Now(),
+	entry := &agentSpanEntry{span: span, startTime: time.Now()}
+	h.spans.Store(input.AgentID, entry)
+	input.SetContext(context.WithValue(spanCtx, agentSpanKey{}, entry))
+	input.OnRunEnd(func(err error) {
+		h.spans.CompareAndDelete(input.AgentID, entry)
+		entry.end(err)
 	})
 	return nil
 }
 
-// postHook ends the span opened by preHook, recording output length and status.
+// postHook records the output on the run's span. The span is ended here only
+// when the caller does not report the end of the run.
 func (h *AgentTracingHook) postHook(ctx context.Context, input *hooks.HookInput) error {
 	if input.AgentID == "" {
 		return nil
 	}
 
+	if entry, ok := ctx.Value(agentSpanKey{}).(*agentSpanEntry); ok {
+		entry.span.SetAttributes(attribute.Int("output.length", len(input.Output)))
+		return nil
+	}
+
 	raw, ok := h.spans.LoadAndDelete(input.AgentID)
 	if !ok {
 		return nil
@@ -140,9 +184,6 @@ func (h *AgentTracingHook) postHook(ctx context.Context, input *hooks.HookInput)
 	if !ok {
 		return nil
 	}
-	defer entry.span.End()
-
-	durationMs := float64(time.Since(entry.startTime)) / float64(time.Millisecond)
 
 	// Determine whether this is an error post-hook (output empty + error signalled
 	// via input.Metadata["error"]) or a successful completion.
@@ -155,19 +196,7 @@ func (h *AgentTracingHook) postHook(ctx context.Context, input *hooks.HookInput)
 		}
 	}
 
-	entry.span.SetAttributes(
-		attribute.Int("output.length", len(input.Output)),
-		attribute.Float64("agent.duration_ms", durationMs),
-	)
-
-	if runErr != nil {
-		entry.span.SetStatus(codes.Error, runErr.Error())
-		entry.span.SetAttributes(attribute.String("agent.status", "error"))
-		entry.span.RecordError(runErr)
-	} else {
-		entry.span.SetStatus(codes.Ok, "")
-		entry.span.SetAttributes(attribute.String("agent.status", "success"))
-	}
-
+	entry.span.SetAttributes(attribute.Int("output.length", len(input.Output)))
+	entry.end(runErr)
 	return nil
 }
@@ -0,0 +1,264 @@
+package otel_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/agent"
+	"github.com/jholhewres/agent-go/pkg/agentgo/hooks"
+	"github.com/jholhewres/agent-go/pkg/agentgo/memory"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	agentootel "github.com/jholhewres/agent-go/pkg/agentgo/observability/otel"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb/memvec"
+)
+
+// scriptedModel asks for the "add" tool once, then answers
+type scriptedModel struct {
+	models.BaseModel
+	err error
+}
+
+func (m *scriptedModel) Invoke(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	usage := types.Usage{PromptTokens: 12, CompletionTokens: 3, TotalTokens: 15}
+	if last := req.Messages[len(req.Messages)-1]; last.Role == types.RoleTool {
+		return &types.ModelResponse{ID: "resp-2", Model: "fake-1-2025", Content: "3", Usage: usage}, nil
+	}
+	return &types.ModelResponse{ID: "resp-1", Usage: usage, ToolCalls: []types.ToolCall{{
+		ID:       "call-1",
+		Type:     "function",
+		Function: types.ToolCallFunction{Name: "add", Arguments: `{"a": 1, "b": 2}`},
+	}}}, nil
+}
+
+func (m *scriptedModel) InvokeStream(ctx context.Context, req *models.InvokeRequest) (<-chan types.ResponseChunk, error) {
+	ch := make(chan types.ResponseChunk, 2)
+	ch <- types.ResponseChunk{Content: "hel"}
+	ch <- types.ResponseChunk{Content: "lo", Done: true, Usage: &types.Usage{PromptTokens: 4, CompletionTokens: 2}}
+	close(ch)
+	return ch, nil
+}
+
+func tracedAgent(t *testing.T, tp *sdktrace.TracerProvider, model models.Model) *agent.Agent {
+	t.Helper()
+	tracer := tp.Tracer("test")
+	preHook, postHook := agentootel.NewAgentTracingHook(tracer)
+
+	tk := toolkit.NewBaseToolkit("math")
+	tk.RegisterFunction(&toolkit.Function{
+		Name: "add",
+		Parameters: map[string]toolkit.Parameter{
+			"a": {Type: "number", Required: true},
+			"b": {Type: "number", Required: true},
+		},
+		Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			// Work the tool traces is part of the tool's span
+			_, span := tracer.Start(ctx, "add.compute")
+			span.End()
+			return args["a"].(float64) + args["b"].(float64), nil
+		},
+	})
+
+	ag, err := agent.New(agent.Config{
+		ID:        "calc",
+		Model:     agentootel.NewTracedModel(model, tracer),
+		Toolkits:  []toolkit.Toolkit{tk},
+		PreHooks:  []hooks.Hook{preHook},
+		PostHooks: []hooks.Hook{postHook},
+		ToolHooks: []hooks.ToolHook{agentootel.NewToolTracingHook(tracer)},
+	})
+	if err != nil {
+		t.Fatalf("agent.New: %v", err)
+	}
+	return ag
+}
+
+func spansByName(spans []sdktrace.ReadOnlySpan) map[string][]sdktrace.ReadOnlySpan {
+	m := map[string][]sdktrace.ReadOnlySpan{}
+	for _, span := range spans {
+		m[span.Name()] = append(m[span.Name()], span)
+	}
+	return m
+}
+
+// TestAgentRun_OneTrace verifies that the model and tool spans of a run are
+// children of its agent.run span and carry the GenAI attributes.
+func TestAgentRun_OneTrace(t *testing.T) {
+	tp, sr := newMemoryProvider()
+	model := &scriptedModel{BaseModel: models.BaseModel{ID: "fake-1", Provider: "fake"}}
+	ag := tracedAgent(t, tp, model)
+
+	if _, err := ag.Run(context.Background(), "1+2"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	spans := spansByName(sr.Ended())
+	if len(spans["agent.run"]) != 1 || len(spans["chat fake-1"]) != 2 || len(spans["tool.add"]) != 1 {
+		t.Fatalf("spans = %v", spans)
+	}
+	run := spans["agent.run"][0]
+	if run.Status().Code != codes.Ok || attrsByKey(run)["gen_ai.operation.name"] != "invoke_agent" {
+		t.Errorf("run span status = %v, attrs %v", run.Status(), attrsByKey(run))
+	}
+	if intAttr(run, "output.length") != 1 {
+		t.Errorf("run span attributes = %v, want output.length 1", run.Attributes())
+	}
+
+	runID := run.SpanContext().SpanID()
+	for _, chat := range spans["chat fake-1"] {
+		if chat.Parent().SpanID() != runID {
+			t.Errorf("chat span parent = %v, want the run span", chat.Parent().SpanID())
+		}
+		attrs := attrsByKey(chat)
+		if attrs["gen_ai.provider.name"] != "fake" || attrs["gen_ai.request.model"] != "fake-1" {
+			t.Errorf("chat attributes = %v", attrs)
+		}
+		if intAttr(chat, "gen_ai.usage.input_tokens") != 12 || intAttr(chat, "gen_ai.usage.output_tokens") != 3 {
+			t.Errorf("chat usage = %v", chat.Attributes())
+		}
+	}
+	if attrsByKey(spans["chat fake-1"][1])["gen_ai.response.model"] != "fake-1-2025" {
+		t.Errorf("second chat attributes = %v", attrsByKey(spans["chat fake-1"][1]))
+	}
+
+	tool := spans["tool.add"][0]
+	if tool.Parent().SpanID() != runID || attrsByKey(tool)["gen_ai.tool.name"] != "add" {
+		t.Errorf("tool span parent = %v, attrs %v", tool.Parent().SpanID(), attrsByKey(tool))
+	}
+	if compute := spans["add.compute"]; len(compute) != 1 || compute[0].Parent().SpanID() != tool.SpanContext().SpanID() {
+		t.Errorf("the tool's own span is not a child of the tool span: %v", compute)
+	}
+}
+
+// TestAgentRun_FailedRunEndsSpan verifies that a run failing after the
+// pre-hooks, when post-hooks do not run, still ends its span with an error.
+func TestAgentRun_FailedRunEndsSpan(t *testing.T) {
+	tp, sr := newMemoryProvider()
+	model := &scriptedModel{BaseModel: models.BaseModel{ID: "fake-1", Provider: "fake"}, err: errors.New("rate limited")}
+	ag := tracedAgent(t, tp, model)
+
+	if _, err := ag.Run(context.Background(), "1+2"); err == nil {
+		t.Fatal("expected the run to fail")
+	}
+
+	spans := spansByName(sr.Ended())
+	if len(spans["agent.run"]) != 1 || spans["agent.run"][0].Status().Code != codes.Error {
+		t.Fatalf("agent.run spans = %v", spans["agent.run"])
+	}
+	if chat := spans["chat fake-1"]; len(chat) != 1 || chat[0].Status().Code != codes.Error || attrsByKey(chat[0])["error.type"] != "_OTHER" {
+		t.Errorf("chat spans = %v", chat)
+	}
+}
+
+func TestTracedModel_Stream(t *testing.T) {
+	tp, sr := newMemoryProvider()
+	model := agentootel.NewTracedModel(&scriptedModel{BaseModel: models.BaseModel{ID: "fake-1", Provider: "fake"}}, tp.Tracer("test"))
+
+	stream, err := model.InvokeStream(context.Background(), &models.InvokeRequest{Temperature: 0.2})
+	if err != nil {
+		t.Fatalf("InvokeStream: %v", err)
+	}
+	var content string
+	for chunk := range stream {
+		content += chunk.Content
+	}
+	if content != "hello" {
+		t.Errorf("content = %q", content)
+	}
+
+	spans := sr.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("spans = %d, want 1", len(spans))
+	}
+	if intAttr(spans[0], "gen_ai.usage.output_tokens") != 2 || spans[0].Status().Code != codes.Ok {
+		t.Errorf("stream span attributes = %v", spans[0].Attributes())
+	}
+}
+
+func TestTracedVectorDB(t *testing.T) {
+	tp, sr := newMemoryProvider()
+	mem, err := memvec.New(memvec.Config{CollectionName: "docs"})
+	if err != nil {
+		t.Fatalf("memvec.New: %v", err)
+	}
+	db := agentootel.NewTracedVectorDB(mem, tp.Tracer("test"))
+	defer db.Close()
+
+	ctx := context.Background()
+	docs := []vectordb.Document{{ID: "a", Content: "alpha", Embedding: []float32{1, 0}}, {ID: "b", Content: "beta", Embedding: []float32{0, 1}}}
+	if err := db.Add(ctx, docs); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if _, err := db.QueryWithEmbedding(ctx, []float32{1, 0}, 1, nil); err != nil {
+		t.Fatalf("QueryWithEmbedding: %v", err)
+	}
+
+	spans := spansByName(sr.Ended())
+	add, query := spans["vectordb.add"], spans["vectordb.query_with_embedding"]
+	if len(add) != 1 || intAttr(add[0], "db.operation.batch.size") != 2 || attrsByKey(add[0])["db.system.name"] != "memvec" {
+		t.Errorf("add spans = %v", add)
+	}
+	if len(query) != 1 || intAttr(query[0], "db.response.returned_rows") != 1 || attrsByKey(query[0])["db.operation.name"] != "query_with_embedding" {
+		t.Errorf("query spans = %v", query)
+	}
+}
+
+// searchMemory is a SearchableMemory returning a fixed result
+type searchMemory struct {
+	*memory.InMemory
+	err error
+}
+
+func (m *searchMemory) Search(ctx context.Context, query string, limit int, userID ...string) ([]memory.SearchResult, error) {
+	return m.SearchWithOptions(ctx, query, memory.SearchOptions{Limit: limit}, userID...)
+}
+
+func (m *searchMemory) SearchWithOptions(ctx context.Context, query string, options memory.SearchOptions, userID ...string) ([]memory.SearchResult, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return []memory.SearchResult{{Message: types.NewUserMessage("hi"), Score: 0.9}}, nil
+}
+
+func TestTracedMemory(t *testing.T) {
+	tp, sr := newMemoryProvider()
+	mem := agentootel.NewTracedMemory(&searchMemory{InMemory: memory.NewInMemory(10)}, tp.Tracer("test"))
+
+	if _, err := mem.Search(context.Background(), "hi", 5); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	mem.Unwrap().(*searchMemory).err = errors.New("index offline")
+	if _, err := mem.Search(context.Background(), "hi", 5); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	spans := sr.Ended()
+	if len(spans) != 2 || spans[0].Name() != "memory.search" {
+		t.Fatalf("spans = %v", spans)
+	}
+	if intAttr(spans[0], "db.query.limit") != 5 || intAttr(spans[0], "db.response.returned_rows") != 1 {
+		t.Errorf("search span attributes = %v", spans[0].Attributes())
+	}
+	if spans[1].Status().Code != codes.Error {
+		t.Errorf("failed search status = %v", spans[1].Status())
+	}
+}
+
+// intAttr returns an integer attribute of span, or -1
+func intAttr(span sdktrace.ReadOnlySpan, key string) int64 {
+	for _, a := range span.Attributes() {
+		if string(a.Key) == key {
+			return a.Value.AsInt64()
+		}
+	}
+	return -1
+}
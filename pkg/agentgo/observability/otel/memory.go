@@ -0,0 +1,85 @@
+package otel
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/memory"
+)
+
+// TracedMemory wraps a memory.SearchableMemory and creates a span per search,
+// named "memory.search". Spans carry:
+//
+//   - db.operation.name          — "search"
+//   - db.query.limit             — the requested number of results
+//   - db.response.returned_rows  — the number of results
+//   - memory.duration_ms         — wall-clock latency in milliseconds
+//
+// Adding and reading messages is not traced. Searches the agent makes for
+// EnableMemorySearch are children of the run's "agent.run" span.
+type TracedMemory struct {
+	memory.SearchableMemory
+	tracer trace.Tracer
+}
+
+var _ memory.SearchableMemory = (*TracedMemory)(nil)
+
+// NewTracedMemory wraps mem so that its searches are traced.
+//
+//	ag, _ := agent.New(agent.Config{
+//	    Memory:             otel.NewTracedMemory(hybridMemory, tracer),
+//	    EnableMemorySearch: true,
+//	})
+func NewTracedMemory(mem memory.SearchableMemory, tracer trace.Tracer) *TracedMemory {
+	return &TracedMemory{SearchableMemory: mem, tracer: tracer}
+}
+
+// Unwrap returns the wrapped memory
+func (m *TracedMemory) Unwrap() memory.SearchableMemory {
+	return m.SearchableMemory
+}
+
+// Search searches the wrapped memory inside a span
+func (m *TracedMemory) Search(ctx context.Context, query string, limit int, userID ...string) ([]memory.SearchResult, error) {
+	ctx, span, start := m.start(ctx, limit)
+	results, err := m.SearchableMemory.Search(ctx, query, limit, userID...)
+	m.end(span, start, len(results), err)
+	return results, err
+}
+
+// SearchWithOptions searches the wrapped memory inside a span
+func (m *TracedMemory) SearchWithOptions(ctx context.Context, query string, options memory.SearchOptions, userID ...string) ([]memory.SearchResult, error) {
+	ctx, span, start := m.start(ctx, options.Limit)
+	results, err := m.SearchableMemory.SearchWithOptions(ctx, query, options, userID...)
+	m.end(span, start, len(results), err)
+	return results, err
+}
+
+// start opens the span of a search
+func (m *TracedMemory) start(ctx context.Context, limit int) (context.Context, trace.Span, time.Time) {
+	ctx, span := m.tracer.Start(ctx, "memory.search",
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(
+			attrDBOperation.String("search"),
+			attrDBQueryLimit.Int(limit),
+		),
+	)
+	return ctx, span, time.Now()
+}
+
+// end finishes the span of a search
+func (m *TracedMemory) end(span trace.Span, start time.Time, results int, err error) {
+	defer span.End()
+	span.SetAttributes(
+		attrMemoryDurationMs.Float64(sinceMs(start)),
+		attrDBReturnedRows.Int(results),
+	)
+	if err != nil {
+		recordError(span, err)
+		return
+	}
+	span.SetStatus(codes.Ok, "")
+}
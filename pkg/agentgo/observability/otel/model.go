@@ -0,0 +1,210 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// TracedModel wraps a models.Model and creates a client span per invocation,
+// named "chat <model id>" as in the GenAI semantic conventions. Spans carry:
+//
+//   - gen_ai.operation.name, gen_ai.provider.name, gen_ai.request.model
+//   - gen_ai.request.temperature and gen_ai.request.max_tokens when set
+//   - gen_ai.response.model and gen_ai.response.id when the provider reports them
+//   - gen_ai.usage.input_tokens and gen_ai.usage.output_tokens
+//   - model.duration_ms — wall-clock latency in milliseconds
+//   - model.time_to_first_chunk_ms — for streams
+//   - model.tool_calls — number of tool calls requested
+//
+// Invocations made with the context of a traced run are children of its
+// "agent.run" span.
+type TracedModel struct {
+	model  models.Model
+	tracer trace.Tracer
+}
+
+var _ models.Model = (*TracedModel)(nil)
+
+// NewTracedModel wraps model so that every Invoke and InvokeStream is traced.
+//
+//	ag, _ := agent.New(agent.Config{
+//	    Model: otel.NewTracedModel(model, tracer),
+//	})
+func NewTracedModel(model models.Model, tracer trace.Tracer) *TracedModel {
+	return &TracedModel{model: model, tracer: tracer}
+}
+
+// Unwrap returns the wrapped model
+func (m *TracedModel) Unwrap() models.Model {
+	return m.model
+}
+
+// GetProvider returns the wrapped model provider
+func (m *TracedModel) GetProvider() string {
+	return m.model.GetProvider()
+}
+
+// GetID returns the wrapped model ID
+func (m *TracedModel) GetID() string {
+	return m.model.GetID()
+}
+
+// GetName returns the wrapped model name
+func (m *TracedModel) GetName() string {
+	return m.model.GetName()
+}
+
+// Invoke calls the wrapped model inside a span
+func (m *TracedModel) Invoke(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+	ctx, span := m.start(ctx, req)
+	defer span.End()
+
+	start := time.Now()
+	resp, err := m.model.Invoke(ctx, req)
+	span.SetAttributes(attrModelDurationMs.Float64(sinceMs(start)))
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+
+	if resp.Model != "" {
+		span.SetAttributes(attrGenAIResponseModel.String(resp.Model))
+	}
+	if resp.ID != "" {
+		span.SetAttributes(attrGenAIResponseID.String(resp.ID))
+	}
+	span.SetAttributes(attrModelToolCalls.Int(len(resp.ToolCalls)))
+	setUsage(span, resp.Usage)
+	span.SetStatus(codes.Ok, "")
+	return resp, nil
+}
+
+// InvokeStream opens the wrapped model stream inside a span, which ends when
+// the stream is closed
+func (m *TracedModel) InvokeStream(ctx context.Context, req *models.InvokeRequest) (<-chan types.ResponseChunk, error) {
+	ctx, span := m.start(ctx, req)
+
+	start := time.Now()
+	stream, err := m.model.InvokeStream(ctx, req)
+	if err != nil {
+		span.SetAttributes(attrModelDurationMs.Float64(sinceMs(start)))
+		recordError(span, err)
+		span.End()
+		return nil, err
+	}
+
+	out := make(chan types.ResponseChunk)
+	go func() {
+		defer close(out)
+		defer span.End()
+
+		var (
+			usage      *types.Usage
+			toolCalls  int
+			streamErr  error
+			first      = true
+			forwarding = true
+		)
+		for chunk := range stream {
+			if first {
+				span.SetAttributes(attrModelTimeToFirstChunk.Float64(sinceMs(start)))
+				first = false
+			}
+			if chunk.Usage != nil {
+				usage = chunk.Usage
+			}
+			if chunk.Error != nil {
+				streamErr = chunk.Error
+			}
+			toolCalls += len(chunk.ToolCalls)
+			if !forwarding {
+				continue
+			}
+			// Once the consumer gives up, drain the stream so the model
+			// can finish.
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				forwarding = false
+				streamErr = ctx.Err()
+			}
+		}
+
+		span.SetAttributes(
+			attrModelDurationMs.Float64(sinceMs(start)),
+			attrModelToolCalls.Int(toolCalls),
+		)
+		if usage != nil {
+			setUsage(span, *usage)
+		}
+		if streamErr != nil {
+			recordError(span, streamErr)
+			return
+		}
+		span.SetStatus(codes.Ok, "")
+	}()
+	return out, nil
+}
+
+// start opens the span of an invocation
+func (m *TracedModel) start(ctx context.Context, req *models.InvokeRequest) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attrGenAIOperationName.String(operationChat),
+		attrGenAIProviderName.String(m.model.GetProvider()),
+		attrGenAIRequestModel.String(m.model.GetID()),
+	}
+	if req != nil {
+		if req.Temperature != 0 {
+			attrs = append(attrs, attrGenAIRequestTemp.Float64(req.Temperature))
+		}
+		if req.MaxTokens > 0 {
+			attrs = append(attrs, attrGenAIRequestMaxTokens.Int(req.MaxTokens))
+		}
+	}
+	return m.tracer.Start(ctx, operationChat+" "+m.model.GetID(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// setUsage records token usage on span
+func setUsage(span trace.Span, usage types.Usage) {
+	span.SetAttributes(
+		attrGenAIInputTokens.Int(usage.PromptTokens),
+		attrGenAIOutputTokens.Int(usage.CompletionTokens),
+	)
+}
+
+// recordError marks span as failed with err
+func recordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	span.SetAttributes(attrErrorType.String(errorType(err)))
+}
+
+// errorType classifies err for the error.type attribute
+func errorType(err error) string {
+	var agentErr *types.AgnoError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.As(err, &agentErr) && agentErr.Code != "":
+		return string(agentErr.Code)
+	}
+	return "_OTHER"
+}
+
+// sinceMs returns the milliseconds elapsed since start
+func sinceMs(start time.Time) float64 {
+	return float64(time.Since(start)) / float64(time.Millisecond)
+}
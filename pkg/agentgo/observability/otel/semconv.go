@@ -0,0 +1,41 @@
+package otel
+
+import "go.opentelemetry.io/otel/attribute"
+
+// Attribute keys from the OpenTelemetry GenAI and database semantic
+// conventions. They are declared here rather than taken from a semconv
+// package because the GenAI conventions are still experimental and their
+// keys move between semconv versions.
+const (
+	attrGenAIOperationName    = attribute.Key("gen_ai.operation.name")
+	attrGenAIProviderName     = attribute.Key("gen_ai.provider.name")
+	attrGenAIRequestModel     = attribute.Key("gen_ai.request.model")
+	attrGenAIRequestMaxTokens = attribute.Key("gen_ai.request.max_tokens")
+	attrGenAIRequestTemp      = attribute.Key("gen_ai.request.temperature")
+	attrGenAIResponseModel    = attribute.Key("gen_ai.response.model")
+	attrGenAIResponseID       = attribute.Key("gen_ai.response.id")
+	attrGenAIInputTokens      = attribute.Key("gen_ai.usage.input_tokens")
+	attrGenAIOutputTokens     = attribute.Key("gen_ai.usage.output_tokens")
+	attrGenAIAgentID          = attribute.Key("gen_ai.agent.id")
+	attrGenAIToolName         = attribute.Key("gen_ai.tool.name")
+	attrGenAIToolCallID       = attribute.Key("gen_ai.tool.call.id")
+	attrErrorType             = attribute.Key("error.type")
+	attrDBSystem              = attribute.Key("db.system.name")
+	attrDBOperation           = attribute.Key("db.operation.name")
+	attrDBCollection          = attribute.Key("db.collection.name")
+	attrDBQueryLimit          = attribute.Key("db.query.limit")
+	attrDBReturnedRows        = attribute.Key("db.response.returned_rows")
+	attrDBBatchSize           = attribute.Key("db.operation.batch.size")
+	attrModelDurationMs       = attribute.Key("model.duration_ms")
+	attrModelTimeToFirstChunk = attribute.Key("model.time_to_first_chunk_ms")
+	attrMemoryDurationMs      = attribute.Key("memory.duration_ms")
+	attrVectorDBDurationMs    = attribute.Key("vectordb.duration_ms")
+	attrModelToolCalls        = attribute.Key("model.tool_calls")
+)
+
+// GenAI operation names (gen_ai.operation.name)
+const (
+	operationChat        = "chat"
+	operationExecuteTool = "execute_tool"
+	operationInvokeAgent = "invoke_agent"
+)
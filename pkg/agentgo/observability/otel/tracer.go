@@ -1,6 +1,9 @@
 // Package otel provides OpenTelemetry tracing integration for AgentGo.
-// It exposes a tracer provider factory and hook implementations that plug into
-// the existing agent hook system without modifying agent.go.
+// It exposes a tracer provider factory, hook implementations that plug into
+// the existing agent hook system, and wrappers that trace models, memory
+// searches and vector databases. Spans follow the GenAI semantic conventions
+// where they apply, and the run's span is handed to the rest of the run
+// through the hooks' context, so one run is one trace.
 package otel
 
 import (
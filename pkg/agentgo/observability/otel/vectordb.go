@@ -0,0 +1,190 @@
+package otel
+
+import (
+	"context"
+	"path"
+	"reflect"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+)
+
+// TracedVectorDB wraps a vectordb.VectorDB and creates a client span per
+// operation, named "vectordb.<operation>". Spans carry:
+//
+//   - db.system.name     — the database package, e.g. "chromadb" or "memvec"
+//   - db.operation.name  — e.g. "query", "add", "delete"
+//   - db.collection.name — for operations on a named collection
+//   - db.query.limit and db.response.returned_rows — for queries
+//   - db.operation.batch.size — documents or IDs sent
+//   - vectordb.duration_ms — wall-clock latency in milliseconds
+//
+// Optional interfaces of the wrapped database, such as vectordb.Scanner, are
+// reachable through Unwrap.
+type TracedVectorDB struct {
+	db     vectordb.VectorDB
+	tracer trace.Tracer
+	system string
+}
+
+var _ vectordb.VectorDB = (*TracedVectorDB)(nil)
+
+// NewTracedVectorDB wraps db so that every operation is traced.
+//
+//	kb, _ := knowledge.NewKnowledgeBase(knowledge.KnowledgeBaseConfig{
+//	    VectorDB: otel.NewTracedVectorDB(db, tracer),
+//	    ...
+//	})
+func NewTracedVectorDB(db vectordb.VectorDB, tracer trace.Tracer) *TracedVectorDB {
+	return &TracedVectorDB{db: db, tracer: tracer, system: dbSystem(db)}
+}
+
+// dbSystem names a database after the package implementing it
+func dbSystem(db vectordb.VectorDB) string {
+	t := reflect.TypeOf(db)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if pkg := path.Base(t.PkgPath()); pkg != "." && pkg != "/" {
+		return pkg
+	}
+	return "other"
+}
+
+// Unwrap returns the wrapped database
+func (d *TracedVectorDB) Unwrap() vectordb.VectorDB {
+	return d.db
+}
+
+// CreateCollection creates a collection inside a span
+func (d *TracedVectorDB) CreateCollection(ctx context.Context, name string, metadata map[string]interface{}) error {
+	ctx, span, start := d.start(ctx, "create_collection", attrDBCollection.String(name))
+	err := d.db.CreateCollection(ctx, name, metadata)
+	d.end(span, start, err)
+	return err
+}
+
+// DeleteCollection deletes a collection inside a span
+func (d *TracedVectorDB) DeleteCollection(ctx context.Context, name string) error {
+	ctx, span, start := d.start(ctx, "delete_collection", attrDBCollection.String(name))
+	err := d.db.DeleteCollection(ctx, name)
+	d.end(span, start, err)
+	return err
+}
+
+// Add adds documents inside a span
+func (d *TracedVectorDB) Add(ctx context.Context, documents []vectordb.Document) error {
+	ctx, span, start := d.start(ctx, "add", attrDBBatchSize.Int(len(documents)))
+	err := d.db.Add(ctx, documents)
+	d.end(span, start, err)
+	return err
+}
+
+// Update updates documents inside a span
+func (d *TracedVectorDB) Update(ctx context.Context, documents []vectordb.Document) error {
+	ctx, span, start := d.start(ctx, "update", attrDBBatchSize.Int(len(documents)))
+	err := d.db.Update(ctx, documents)
+	d.end(span, start, err)
+	return err
+}
+
+// Delete deletes documents inside a span
+func (d *TracedVectorDB) Delete(ctx context.Context, ids []string) error {
+	ctx, span, start := d.start(ctx, "delete", attrDBBatchSize.Int(len(ids)))
+	err := d.db.Delete(ctx, ids)
+	d.end(span, start, err)
+	return err
+}
+
+// DeleteByFilter deletes matching documents inside a span
+func (d *TracedVectorDB) DeleteByFilter(ctx context.Context, filter map[string]interface{}) error {
+	ctx, span, start := d.start(ctx, "delete_by_filter")
+	err := d.db.DeleteByFilter(ctx, filter)
+	d.end(span, start, err)
+	return err
+}
+
+// Query searches by text inside a span
+func (d *TracedVectorDB) Query(ctx context.Context, query string, limit int, filter map[string]interface{}) ([]vectordb.SearchResult, error) {
+	ctx, span, start := d.start(ctx, "query", attrDBQueryLimit.Int(limit))
+	results, err := d.db.Query(ctx, query, limit, filter)
+	span.SetAttributes(attrDBReturnedRows.Int(len(results)))
+	d.end(span, start, err)
+	return results, err
+}
+
+// QueryWithEmbedding searches by embedding inside a span
+func (d *TracedVectorDB) QueryWithEmbedding(ctx context.Context, embedding []float32, limit int, filter map[string]interface{}) ([]vectordb.SearchResult, error) {
+	ctx, span, start := d.start(ctx, "query_with_embedding", attrDBQueryLimit.Int(limit))
+	results, err := d.db.QueryWithEmbedding(ctx, embedding, limit, filter)
+	span.SetAttributes(attrDBReturnedRows.Int(len(results)))
+	d.end(span, start, err)
+	return results, err
+}
+
+// Get retrieves documents inside a span
+func (d *TracedVectorDB) Get(ctx context.Context, ids []string) ([]vectordb.Document, error) {
+	ctx, span, start := d.start(ctx, "get", attrDBBatchSize.Int(len(ids)))
+	documents, err := d.db.Get(ctx, ids)
+	span.SetAttributes(attrDBReturnedRows.Int(len(documents)))
+	d.end(span, start, err)
+	return documents, err
+}
+
+// Count counts documents inside a span
+func (d *TracedVectorDB) Count(ctx context.Context) (int, error) {
+	ctx, span, start := d.start(ctx, "count")
+	count, err := d.db.Count(ctx)
+	d.end(span, start, err)
+	return count, err
+}
+
+// ListCollections lists collections inside a span
+func (d *TracedVectorDB) ListCollections(ctx context.Context) ([]string, error) {
+	ctx, span, start := d.start(ctx, "list_collections")
+	names, err := d.db.ListCollections(ctx)
+	span.SetAttributes(attrDBReturnedRows.Int(len(names)))
+	d.end(span, start, err)
+	return names, err
+}
+
+// CollectionStats describes a collection inside a span
+func (d *TracedVectorDB) CollectionStats(ctx context.Context, name string) (vectordb.CollectionStats, error) {
+	ctx, span, start := d.start(ctx, "collection_stats", attrDBCollection.String(name))
+	stats, err := d.db.CollectionStats(ctx, name)
+	d.end(span, start, err)
+	return stats, err
+}
+
+// Close closes the wrapped database
+func (d *TracedVectorDB) Close() error {
+	return d.db.Close()
+}
+
+// start opens the span of an operation
+func (d *TracedVectorDB) start(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span, time.Time) {
+	attrs = append(attrs,
+		attrDBSystem.String(d.system),
+		attrDBOperation.String(operation),
+	)
+	ctx, span := d.tracer.Start(ctx, "vectordb."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	return ctx, span, time.Now()
+}
+
+// end finishes the span of an operation
+func (d *TracedVectorDB) end(span trace.Span, start time.Time, err error) {
+	defer span.End()
+	span.SetAttributes(attrVectorDBDurationMs.Float64(sinceMs(start)))
+	if err != nil {
+		recordError(span, err)
+		return
+	}
+	span.SetStatus(codes.Ok, "")
+}
@@ -0,0 +1,203 @@
+// Package pagination defines the cursor-based listing options shared by the
+// learning, run, session and vector storages.
+//
+// Listings are ordered by a timestamp with the record ID as tie-breaker, so a
+// cursor (the position of the last item of a page) stays valid while new
+// records are written. Cursors are opaque to callers.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultLimit is the page size used when ListOptions.Limit is not set.
+	DefaultLimit = 50
+
+	// MaxLimit caps ListOptions.Limit.
+	MaxLimit = 1000
+)
+
+// ErrInvalidCursor is returned when a cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Order is the sort direction of a listing.
+type Order string
+
+const (
+	// OrderDesc lists newest records first (default).
+	OrderDesc Order = "desc"
+
+	// OrderAsc lists oldest records first.
+	OrderAsc Order = "asc"
+)
+
+// ListOptions selects a page of records.
+type ListOptions struct {
+	// Cursor is the NextCursor of the previous page ("" for the first page).
+	Cursor string `json:"cursor,omitempty"`
+
+	// Limit is the page size (default: DefaultLimit, max: MaxLimit).
+	Limit int `json:"limit,omitempty"`
+
+	// Order is the sort direction (default: OrderDesc).
+	Order Order `json:"order,omitempty"`
+
+	// Since and Until bound the record timestamp; zero values are unbounded.
+	// Since is inclusive, Until is exclusive.
+	Since time.Time `json:"since,omitempty"`
+	Until time.Time `json:"until,omitempty"`
+
+	// Filters are exact-match field filters. Each storage documents the keys
+	// it supports and ignores the others.
+	Filters map[string]string `json:"filters,omitempty"`
+}
+
+// Page is one page of a listing.
+type Page[T any] struct {
+	Items []T `json:"items"`
+
+	// NextCursor fetches the following page; it is "" after the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Cursor is the decoded position of the last item of a page.
+type Cursor struct {
+	Time time.Time `json:"t"`
+	ID   string    `json:"id"`
+}
+
+// Normalize applies defaults and validates the order.
+func (o ListOptions) Normalize() (ListOptions, error) {
+	if o.Limit <= 0 {
+		o.Limit = DefaultLimit
+	}
+	if o.Limit > MaxLimit {
+		o.Limit = MaxLimit
+	}
+	switch o.Order {
+	case "":
+		o.Order = OrderDesc
+	case OrderAsc, OrderDesc:
+	default:
+		return o, errors.New("order must be asc or desc")
+	}
+	return o, nil
+}
+
+// Filter returns the value of a filter and whether it is set.
+func (o ListOptions) Filter(key string) (string, bool) {
+	v, ok := o.Filters[key]
+	return v, ok && v != ""
+}
+
+// DecodeCursor returns the position encoded in Cursor, or nil for the first page.
+func (o ListOptions) DecodeCursor() (*Cursor, error) {
+	if o.Cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(o.Cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// Prepare normalizes the options and decodes their cursor.
+func (o ListOptions) Prepare() (ListOptions, *Cursor, error) {
+	o, err := o.Normalize()
+	if err != nil {
+		return o, nil, err
+	}
+	cursor, err := o.DecodeCursor()
+	return o, cursor, err
+}
+
+// EncodeCursor returns the opaque cursor for the record at (t, id).
+func EncodeCursor(t time.Time, id string) string {
+	data, _ := json.Marshal(Cursor{Time: t.UTC(), ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// After reports whether the record at (t, id) comes after the cursor in
+// the given order.
+func (c *Cursor) After(t time.Time, id string, order Order) bool {
+	if c == nil {
+		return true
+	}
+	cmp := t.Compare(c.Time)
+	if cmp == 0 {
+		cmp = strings.Compare(id, c.ID)
+	}
+	if order == OrderAsc {
+		return cmp > 0
+	}
+	return cmp < 0
+}
+
+// InRange reports whether t falls within Since and Until.
+func (o ListOptions) InRange(t time.Time) bool {
+	if !o.Since.IsZero() && t.Before(o.Since) {
+		return false
+	}
+	if !o.Until.IsZero() && !t.Before(o.Until) {
+		return false
+	}
+	return true
+}
+
+// Paginate pages through an in-memory slice. key returns the timestamp and ID
+// of an item; items must already satisfy opts.Filters. Storages without
+// native cursor support use it as a fallback.
+func Paginate[T any](items []T, opts ListOptions, key func(T) (time.Time, string)) (Page[T], error) {
+	opts, cursor, err := opts.Prepare()
+	if err != nil {
+		return Page[T]{}, err
+	}
+
+	selected := make([]T, 0, len(items))
+	for _, item := range items {
+		t, id := key(item)
+		if opts.InRange(t) && cursor.After(t, id, opts.Order) {
+			selected = append(selected, item)
+		}
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		ti, idi := key(selected[i])
+		tj, idj := key(selected[j])
+		cmp := ti.Compare(tj)
+		if cmp == 0 {
+			cmp = strings.Compare(idi, idj)
+		}
+		if opts.Order == OrderAsc {
+			return cmp < 0
+		}
+		return cmp > 0
+	})
+
+	return Trim(selected, opts.Limit, key), nil
+}
+
+// Trim builds a page from items fetched with limit+1 rows: the extra row only
+// signals that another page exists.
+func Trim[T any](items []T, limit int, key func(T) (time.Time, string)) Page[T] {
+	page := Page[T]{Items: items}
+	if limit > 0 && len(items) > limit {
+		page.Items = items[:limit]
+		t, id := key(page.Items[limit-1])
+		page.NextCursor = EncodeCursor(t, id)
+	}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	return page
+}
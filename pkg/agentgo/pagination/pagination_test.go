@@ -0,0 +1,94 @@
+package pagination
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type item struct {
+	id string
+	at time.Time
+}
+
+func itemKey(i item) (time.Time, string) { return i.at, i.id }
+
+func TestPaginate_WalksAllPagesInOrder(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	items := []item{
+		{"c", base.Add(time.Minute)},
+		{"a", base},
+		{"b", base.Add(time.Minute)}, // same timestamp as "c": ID breaks the tie
+		{"d", base.Add(2 * time.Minute)},
+		{"e", base.Add(3 * time.Minute)},
+	}
+
+	for _, tc := range []struct {
+		order Order
+		want  string
+	}{
+		{OrderDesc, "edcba"},
+		{OrderAsc, "abcde"},
+	} {
+		var got strings.Builder
+		opts := ListOptions{Limit: 2, Order: tc.order}
+		for pages := 0; ; pages++ {
+			if pages > 3 {
+				t.Fatalf("%s: too many pages", tc.order)
+			}
+			page, err := Paginate(items, opts, itemKey)
+			if err != nil {
+				t.Fatalf("Paginate() error = %v", err)
+			}
+			for _, it := range page.Items {
+				got.WriteString(it.id)
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			opts.Cursor = page.NextCursor
+		}
+		if got.String() != tc.want {
+			t.Errorf("%s order = %q, want %q", tc.order, got.String(), tc.want)
+		}
+	}
+}
+
+func TestPaginate_TimeRangeAndErrors(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	items := []item{{"a", base}, {"b", base.Add(time.Hour)}, {"c", base.Add(2 * time.Hour)}}
+
+	page, err := Paginate(items, ListOptions{Since: base.Add(time.Hour), Until: base.Add(2 * time.Hour)}, itemKey)
+	if err != nil {
+		t.Fatalf("Paginate() error = %v", err)
+	}
+	if len(page.Items) != 1 || page.Items[0].id != "b" || page.NextCursor != "" {
+		t.Fatalf("unexpected page %+v", page)
+	}
+
+	if _, err := Paginate(items, ListOptions{Cursor: "not a cursor!"}, itemKey); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor, got %v", err)
+	}
+	if _, err := Paginate(items, ListOptions{Order: "sideways"}, itemKey); err == nil {
+		t.Fatal("expected error for invalid order")
+	}
+}
+
+func TestSQLBuilder_Clause(t *testing.T) {
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	opts, _, _ := ListOptions{Limit: 10, Order: OrderAsc}.Prepare()
+
+	b := NewSQLBuilder(Dollar)
+	b.Where("user_id = ?", "u1")
+	clause := b.Clause(opts, &Cursor{Time: at, ID: "m1"}, "created_at", "id")
+
+	want := " WHERE user_id = $1 AND (created_at > $2 OR (created_at = $3 AND id > $4)) ORDER BY created_at ASC, id ASC LIMIT $5"
+	if clause != want {
+		t.Fatalf("clause =\n%q\nwant\n%q", clause, want)
+	}
+	args := b.Args()
+	if len(args) != 5 || args[0] != "u1" || args[3] != "m1" || args[4] != 11 {
+		t.Fatalf("unexpected args %v", args)
+	}
+}
@@ -0,0 +1,77 @@
+package pagination
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dollar formats Postgres bind parameters ($1, $2, ...).
+func Dollar(n int) string { return fmt.Sprintf("$%d", n) }
+
+// Question formats SQLite and MySQL bind parameters (?).
+func Question(int) string { return "?" }
+
+// SQLBuilder builds the WHERE, ORDER BY and LIMIT clauses of a keyset listing
+// ordered by a timestamp column with an ID column as tie-breaker.
+type SQLBuilder struct {
+	placeholder func(n int) string
+	where       []string
+	args        []interface{}
+}
+
+// NewSQLBuilder returns a builder whose bind parameters are formatted by
+// placeholder (Dollar or Question).
+func NewSQLBuilder(placeholder func(n int) string) *SQLBuilder {
+	return &SQLBuilder{placeholder: placeholder}
+}
+
+// Where adds a condition; each "?" in cond binds the next of args.
+func (b *SQLBuilder) Where(cond string, args ...interface{}) {
+	var sb strings.Builder
+	for _, r := range cond {
+		if r == '?' {
+			b.args = append(b.args, args[0])
+			args = args[1:]
+			sb.WriteString(b.placeholder(len(b.args)))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	b.where = append(b.where, sb.String())
+}
+
+// Clause returns the WHERE (including opts' time range and cursor), ORDER BY
+// and LIMIT clauses. LIMIT fetches one extra row; pass the rows to Trim.
+// Timestamps are bound in UTC, so they must be stored in UTC as well.
+func (b *SQLBuilder) Clause(opts ListOptions, cursor *Cursor, timeCol, idCol string) string {
+	if !opts.Since.IsZero() {
+		b.Where(timeCol+" >= ?", opts.Since.UTC())
+	}
+	if !opts.Until.IsZero() {
+		b.Where(timeCol+" < ?", opts.Until.UTC())
+	}
+
+	op, dir := "<", "DESC"
+	if opts.Order == OrderAsc {
+		op, dir = ">", "ASC"
+	}
+	if cursor != nil {
+		t := cursor.Time.UTC()
+		b.Where(fmt.Sprintf("(%s %s ? OR (%s = ? AND %s %s ?))", timeCol, op, timeCol, idCol, op), t, t, cursor.ID)
+	}
+
+	var sb strings.Builder
+	if len(b.where) > 0 {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(strings.Join(b.where, " AND "))
+	}
+	fmt.Fprintf(&sb, " ORDER BY %s %s, %s %s", timeCol, dir, idCol, dir)
+	b.args = append(b.args, opts.Limit+1)
+	sb.WriteString(" LIMIT " + b.placeholder(len(b.args)))
+	return sb.String()
+}
+
+// Args returns the bind arguments in order.
+func (b *SQLBuilder) Args() []interface{} {
+	return b.args
+}
@@ -0,0 +1,259 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/hooks"
+)
+
+// Request is a tool call to evaluate
+// Request 是要求值的工具调用
+type Request struct {
+	Tool    string
+	AgentID string
+	UserID  string
+	Args    map[string]interface{}
+	Time    time.Time // Zero means now / 零值表示当前时间
+}
+
+// Decision is the outcome of evaluating a request
+// Decision 是请求的求值结果
+type Decision struct {
+	Allowed bool   `json:"allowed"`
+	Rule    string `json:"rule,omitempty"` // Empty when the default applied / 使用默认值时为空
+	Reason  string `json:"reason,omitempty"`
+}
+
+// DeniedError is returned by the tool hook when a policy denies a call
+// DeniedError 是策略拒绝调用时工具钩子返回的错误
+type DeniedError struct {
+	Tool   string
+	Rule   string
+	Reason string
+}
+
+func (e *DeniedError) Error() string {
+	msg := "tool call denied by policy: " + e.Tool
+	if e.Rule != "" {
+		msg += " (rule " + e.Rule + ")"
+	}
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+// Engine evaluates tool calls against a policy that can be swapped at runtime.
+// It implements hooks.ToolHooker, so it can be added to an agent's ToolHooks.
+// Engine 根据可在运行时替换的策略对工具调用求值。它实现了 hooks.ToolHooker，可以加入代理的 ToolHooks。
+type Engine struct {
+	current atomic.Pointer[compiledPolicy]
+	now     func() time.Time
+}
+
+var _ hooks.ToolHooker = (*Engine)(nil)
+
+// NewEngine creates an engine from an initial policy
+// NewEngine 使用初始策略创建引擎
+func NewEngine(policy *Policy) (*Engine, error) {
+	e := &Engine{now: time.Now}
+	if err := e.Update(policy); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Update validates the policy and swaps it in atomically; on error the
+// current policy is kept
+// Update 验证策略并原子替换；出错时保留当前策略
+func (e *Engine) Update(policy *Policy) error {
+	compiled, err := compile(policy)
+	if err != nil {
+		return err
+	}
+	e.current.Store(compiled)
+	return nil
+}
+
+// Policy returns the active policy
+// Policy 返回当前生效的策略
+func (e *Engine) Policy() *Policy {
+	return e.current.Load().policy
+}
+
+// Evaluate returns the decision of the first matching rule, or the default
+// Evaluate 返回第一个匹配规则的决策，或默认决策
+func (e *Engine) Evaluate(req Request) Decision {
+	p := e.current.Load()
+	if req.Time.IsZero() {
+		req.Time = e.now()
+	}
+	for _, rule := range p.rules {
+		if rule.matches(req) {
+			d := Decision{Allowed: rule.Effect == Allow, Rule: rule.Name}
+			if !d.Allowed {
+				d.Reason = rule.Reason
+			}
+			return d
+		}
+	}
+	if p.policy.Default == Allow {
+		return Decision{Allowed: true}
+	}
+	return Decision{Reason: "no rule allows this tool"}
+}
+
+// OnToolPre blocks tool calls the policy denies
+// OnToolPre 阻止策略拒绝的工具调用
+func (e *Engine) OnToolPre(ctx context.Context, input *hooks.ToolHookInput) error {
+	d := e.Evaluate(Request{
+		Tool:    input.FunctionName,
+		AgentID: input.AgentID,
+		UserID:  input.UserID,
+		Args:    input.Arguments,
+	})
+	input.SetMetadata("policy_decision", d)
+	if d.Allowed {
+		return nil
+	}
+	return &DeniedError{Tool: input.FunctionName, Rule: d.Rule, Reason: d.Reason}
+}
+
+// OnToolPost is a no-op for policies
+// OnToolPost 对策略而言不执行任何操作
+func (e *Engine) OnToolPost(ctx context.Context, input *hooks.ToolHookInput) error {
+	return nil
+}
+
+func (r *compiledRule) matches(req Request) bool {
+	if len(r.Tools) > 0 && !matchesAny(r.Tools, req.Tool, true) {
+		return false
+	}
+	if len(r.Agents) > 0 && !matchesAny(r.Agents, req.AgentID, false) {
+		return false
+	}
+	if len(r.Users) > 0 && !matchesAny(r.Users, req.UserID, false) {
+		return false
+	}
+	for name, c := range r.args {
+		value, ok := lookupArg(req.Args, name)
+		if !ok || !c.allows(value) {
+			return false
+		}
+	}
+	if len(r.windows) == 0 {
+		return true
+	}
+	for _, w := range r.windows {
+		if w.contains(req.Time) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAny(patterns []string, value string, glob bool) bool {
+	for _, pattern := range patterns {
+		if pattern == value {
+			return true
+		}
+		if glob {
+			if ok, _ := path.Match(pattern, value); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lookupArg finds an argument by name, following dots into nested objects
+func lookupArg(args map[string]interface{}, name string) (interface{}, bool) {
+	if value, ok := args[name]; ok {
+		return value, true
+	}
+	var current interface{} = args
+	for _, part := range strings.Split(name, ".") {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = obj[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func (c *compiledArg) allows(value interface{}) bool {
+	if len(c.OneOf) > 0 {
+		found := false
+		for _, option := range c.OneOf {
+			if fmt.Sprint(option) == fmt.Sprint(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if c.re != nil && !c.re.MatchString(fmt.Sprint(value)) {
+		return false
+	}
+	if c.Min != nil || c.Max != nil {
+		n, ok := toFloat(value)
+		if !ok || (c.Min != nil && n < *c.Min) || (c.Max != nil && n > *c.Max) {
+			return false
+		}
+	}
+	if c.MaxLength > 0 {
+		s, ok := value.(string)
+		if !ok || utf8.RuneCountInString(s) > c.MaxLength {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *compiledWindow) contains(t time.Time) bool {
+	t = t.In(w.loc)
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	if w.end >= 0 && w.end < w.start && minute < w.end {
+		// Past midnight in a window that started the day before
+		day = (day + 6) % 7
+	}
+	if w.days != nil && !w.days[day] {
+		return false
+	}
+	switch {
+	case w.end < 0:
+		return true
+	case w.start <= w.end:
+		return minute >= w.start && minute < w.end
+	default:
+		return minute >= w.start || minute < w.end
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,282 @@
+// Package policy lets administrators declare which tools an agent or user may
+// call, optionally limited by argument values and time windows. An Engine
+// enforces a policy as a tool hook.
+// Package policy 允许管理员声明代理或用户可以调用哪些工具，并可按参数值和时间窗口加以限制。
+// Engine 以工具钩子的形式执行策略。
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Effect is what a matching rule does with a tool call
+// Effect 是匹配规则对工具调用采取的操作
+type Effect string
+
+const (
+	// Allow lets the tool call run
+	// Allow 允许工具调用执行
+	Allow Effect = "allow"
+	// Deny blocks the tool call
+	// Deny 阻止工具调用
+	Deny Effect = "deny"
+)
+
+// Policy is an ordered list of rules; the first rule that matches a tool call
+// decides it, and calls no rule matches get Default
+// Policy 是有序的规则列表；第一个匹配工具调用的规则决定结果，没有规则匹配时使用 Default
+type Policy struct {
+	// Default applies when no rule matches (empty = deny)
+	// Default 在没有规则匹配时生效（空 = deny）
+	Default Effect `json:"default,omitempty" yaml:"default,omitempty"`
+	// Rules are evaluated in order
+	// Rules 按顺序求值
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+// Rule matches tool calls by tool, agent, user, arguments and time. Empty
+// fields match everything; a rule matches when all of its fields do.
+// Rule 按工具、代理、用户、参数和时间匹配工具调用。空字段匹配一切；所有字段都匹配时规则才匹配。
+type Rule struct {
+	// Name identifies the rule in decisions and errors
+	// Name 在决策和错误中标识规则
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Effect is allow or deny
+	// Effect 为 allow 或 deny
+	Effect Effect `json:"effect" yaml:"effect"`
+	// Tools are tool names or glob patterns such as "file_*"
+	// Tools 是工具名称或 glob 模式，例如 "file_*"
+	Tools []string `json:"tools,omitempty" yaml:"tools,omitempty"`
+	// Agents are agent IDs
+	// Agents 是代理 ID
+	Agents []string `json:"agents,omitempty" yaml:"agents,omitempty"`
+	// Users are user IDs
+	// Users 是用户 ID
+	Users []string `json:"users,omitempty" yaml:"users,omitempty"`
+	// Args constrains arguments by name; nested arguments use dots, e.g.
+	// "options.force". A missing argument does not match.
+	// Args 按名称约束参数；嵌套参数使用点号，例如 "options.force"。缺失的参数不匹配。
+	Args map[string]ArgConstraint `json:"args,omitempty" yaml:"args,omitempty"`
+	// Windows limits the rule to times of day and days of the week
+	// Windows 将规则限制在一天中的时段和一周中的日期
+	Windows []TimeWindow `json:"windows,omitempty" yaml:"windows,omitempty"`
+	// Reason is reported when the rule denies a call
+	// Reason 在规则拒绝调用时报告
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+// ArgConstraint limits the value of one argument; every set field must hold
+// ArgConstraint 限制一个参数的值；所有设置的字段都必须满足
+type ArgConstraint struct {
+	// OneOf lists the allowed values
+	// OneOf 列出允许的值
+	OneOf []interface{} `json:"one_of,omitempty" yaml:"one_of,omitempty"`
+	// Pattern is a regular expression the value must match
+	// Pattern 是值必须匹配的正则表达式
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	// Min and Max bound numeric values
+	// Min 和 Max 限定数值范围
+	Min *float64 `json:"min,omitempty" yaml:"min,omitempty"`
+	Max *float64 `json:"max,omitempty" yaml:"max,omitempty"`
+	// MaxLength bounds the length of string values
+	// MaxLength 限定字符串值的长度
+	MaxLength int `json:"max_length,omitempty" yaml:"max_length,omitempty"`
+}
+
+// TimeWindow is a daily time range, e.g. 09:00-18:00 on weekdays. When End is
+// before Start the window spans midnight.
+// TimeWindow 是每日时间范围，例如工作日 09:00-18:00。End 早于 Start 时窗口跨越午夜。
+type TimeWindow struct {
+	// Days are weekday names such as "mon" or "monday" (empty = every day)
+	// Days 是星期名称，例如 "mon" 或 "monday"（空 = 每天）
+	Days []string `json:"days,omitempty" yaml:"days,omitempty"`
+	// Start and End are "HH:MM" (empty = whole day)
+	// Start 和 End 为 "HH:MM" 格式（空 = 全天）
+	Start string `json:"start,omitempty" yaml:"start,omitempty"`
+	End   string `json:"end,omitempty" yaml:"end,omitempty"`
+	// Timezone is an IANA name such as "America/Sao_Paulo" (default: UTC)
+	// Timezone 是 IANA 时区名称，例如 "America/Sao_Paulo"（默认：UTC）
+	Timezone string `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+}
+
+// Validate checks the policy for errors
+// Validate 检查策略中的错误
+func (p *Policy) Validate() error {
+	_, err := compile(p)
+	return err
+}
+
+// Parse decodes a policy from YAML or JSON based on the file extension
+// Parse 根据文件扩展名从 YAML 或 JSON 解码策略
+func Parse(name string, data []byte) (*Policy, error) {
+	var policy Policy
+	var err error
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		err = json.Unmarshal(data, &policy)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &policy)
+	default:
+		return nil, fmt.Errorf("unsupported policy file extension: %s", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse policy %s: %w", name, err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %w", name, err)
+	}
+	return &policy, nil
+}
+
+// Load reads and validates a policy file
+// Load 读取并验证策略文件
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy %s: %w", path, err)
+	}
+	return Parse(path, data)
+}
+
+// compiledPolicy is a validated policy ready for evaluation
+type compiledPolicy struct {
+	policy *Policy
+	rules  []compiledRule
+}
+
+type compiledRule struct {
+	Rule
+	args    map[string]compiledArg
+	windows []compiledWindow
+}
+
+type compiledArg struct {
+	ArgConstraint
+	re *regexp.Regexp
+}
+
+type compiledWindow struct {
+	days       map[time.Weekday]bool
+	start, end int // Minutes since midnight; end < 0 means the whole day
+	loc        *time.Location
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+func compile(p *Policy) (*compiledPolicy, error) {
+	if p == nil {
+		return nil, fmt.Errorf("policy cannot be nil")
+	}
+	switch p.Default {
+	case "", Allow, Deny:
+	default:
+		return nil, fmt.Errorf("invalid default effect %q (want allow or deny)", p.Default)
+	}
+
+	out := &compiledPolicy{policy: p, rules: make([]compiledRule, 0, len(p.Rules))}
+	for i, rule := range p.Rules {
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		cr, err := compileRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", name, err)
+		}
+		cr.Name = name
+		out.rules = append(out.rules, cr)
+	}
+	return out, nil
+}
+
+func compileRule(rule Rule) (compiledRule, error) {
+	cr := compiledRule{Rule: rule, args: make(map[string]compiledArg, len(rule.Args))}
+	if rule.Effect != Allow && rule.Effect != Deny {
+		return cr, fmt.Errorf("invalid effect %q (want allow or deny)", rule.Effect)
+	}
+	for _, pattern := range rule.Tools {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return cr, fmt.Errorf("invalid tool pattern %q: %w", pattern, err)
+		}
+	}
+	for name, c := range rule.Args {
+		arg := compiledArg{ArgConstraint: c}
+		if c.Pattern != "" {
+			re, err := regexp.Compile(c.Pattern)
+			if err != nil {
+				return cr, fmt.Errorf("argument %s: invalid pattern: %w", name, err)
+			}
+			arg.re = re
+		}
+		if c.Min != nil && c.Max != nil && *c.Min > *c.Max {
+			return cr, fmt.Errorf("argument %s: min is greater than max", name)
+		}
+		cr.args[name] = arg
+	}
+	for _, w := range rule.Windows {
+		cw, err := compileWindow(w)
+		if err != nil {
+			return cr, err
+		}
+		cr.windows = append(cr.windows, cw)
+	}
+	return cr, nil
+}
+
+func compileWindow(w TimeWindow) (compiledWindow, error) {
+	cw := compiledWindow{loc: time.UTC, end: -1}
+	if w.Timezone != "" {
+		loc, err := time.LoadLocation(w.Timezone)
+		if err != nil {
+			return cw, fmt.Errorf("invalid timezone %q: %w", w.Timezone, err)
+		}
+		cw.loc = loc
+	}
+	if len(w.Days) > 0 {
+		cw.days = make(map[time.Weekday]bool, len(w.Days))
+		for _, day := range w.Days {
+			key := strings.ToLower(day)
+			if len(key) > 3 {
+				key = key[:3]
+			}
+			weekday, ok := weekdays[key]
+			if !ok {
+				return cw, fmt.Errorf("invalid day %q", day)
+			}
+			cw.days[weekday] = true
+		}
+	}
+	if (w.Start == "") != (w.End == "") {
+		return cw, fmt.Errorf("time window needs both start and end")
+	}
+	if w.Start != "" {
+		var err error
+		if cw.start, err = parseClock(w.Start); err != nil {
+			return cw, err
+		}
+		if cw.end, err = parseClock(w.End); err != nil {
+			return cw, err
+		}
+	}
+	return cw, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (want HH:MM)", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,177 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/hooks"
+)
+
+func float(f float64) *float64 { return &f }
+
+func TestEngine_Evaluate(t *testing.T) {
+	e, err := NewEngine(&Policy{Rules: []Rule{
+		{Name: "no-drop", Effect: Deny, Tools: []string{"run_sql"}, Args: map[string]ArgConstraint{"query": {Pattern: `(?i)\bdrop\b`}}, Reason: "destructive SQL"},
+		{Name: "sql", Effect: Allow, Tools: []string{"run_sql"}, Users: []string{"dba"}},
+		{Name: "files", Effect: Allow, Tools: []string{"file_*"}, Agents: []string{"ops"}},
+		{Name: "refunds", Effect: Allow, Tools: []string{"refund"}, Args: map[string]ArgConstraint{
+			"amount":       {Max: float(100)},
+			"options.mode": {OneOf: []interface{}{"card", "pix"}},
+		}},
+		{Name: "calc", Effect: Allow, Tools: []string{"calculator_*"}},
+	}})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		req  Request
+		rule string
+		ok   bool
+	}{
+		{"denied argument", Request{Tool: "run_sql", UserID: "dba", Args: map[string]interface{}{"query": "DROP TABLE users"}}, "no-drop", false},
+		{"allowed user", Request{Tool: "run_sql", UserID: "dba", Args: map[string]interface{}{"query": "select 1"}}, "sql", true},
+		{"other user", Request{Tool: "run_sql", UserID: "bob", Args: map[string]interface{}{"query": "select 1"}}, "", false},
+		{"glob and agent", Request{Tool: "file_read", AgentID: "ops"}, "files", true},
+		{"wrong agent", Request{Tool: "file_read", AgentID: "support"}, "", false},
+		{"argument limits", Request{Tool: "refund", Args: map[string]interface{}{"amount": 40.0, "options": map[string]interface{}{"mode": "pix"}}}, "refunds", true},
+		{"argument over max", Request{Tool: "refund", Args: map[string]interface{}{"amount": 400.0, "options": map[string]interface{}{"mode": "pix"}}}, "", false},
+		{"missing argument", Request{Tool: "refund", Args: map[string]interface{}{"amount": 40.0}}, "", false},
+		{"unlisted tool", Request{Tool: "send_email"}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := e.Evaluate(tt.req)
+			if d.Allowed != tt.ok || d.Rule != tt.rule {
+				t.Errorf("Evaluate() = %+v, want allowed=%v rule=%q", d, tt.ok, tt.rule)
+			}
+		})
+	}
+}
+
+func TestEngine_TimeWindows(t *testing.T) {
+	e, err := NewEngine(&Policy{Default: Allow, Rules: []Rule{
+		{Name: "business-hours", Effect: Allow, Tools: []string{"deploy"}, Windows: []TimeWindow{{Days: []string{"mon", "Tuesday", "wed", "thu", "fri"}, Start: "09:00", End: "18:00"}}},
+		{Name: "maintenance", Effect: Allow, Tools: []string{"reindex"}, Windows: []TimeWindow{{Days: []string{"sat"}, Start: "22:00", End: "02:00"}}},
+		{Name: "deploy-freeze", Effect: Deny, Tools: []string{"deploy", "reindex"}},
+	}})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+
+	at := func(s string) time.Time {
+		ts, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+	tests := []struct {
+		tool string
+		time string
+		ok   bool
+	}{
+		{"deploy", "2026-10-14T10:30:00Z", true},  // Wednesday
+		{"deploy", "2026-10-14T18:00:00Z", false}, // End is exclusive
+		{"deploy", "2026-10-17T10:30:00Z", false}, // Saturday
+		{"reindex", "2026-10-17T23:00:00Z", true}, // Saturday night
+		{"reindex", "2026-10-18T01:30:00Z", true}, // Past midnight, window started Saturday
+		{"reindex", "2026-10-19T01:30:00Z", false},
+		{"search", "2026-10-17T10:30:00Z", true}, // Default
+	}
+	for _, tt := range tests {
+		if d := e.Evaluate(Request{Tool: tt.tool, Time: at(tt.time)}); d.Allowed != tt.ok {
+			t.Errorf("%s at %s: Evaluate() = %+v, want allowed=%v", tt.tool, tt.time, d, tt.ok)
+		}
+	}
+
+	e.now = func() time.Time { return at("2026-10-14T10:30:00Z") }
+	if d := e.Evaluate(Request{Tool: "deploy"}); !d.Allowed {
+		t.Errorf("Evaluate() without time = %+v, want the current time used", d)
+	}
+}
+
+func TestEngine_ToolHook(t *testing.T) {
+	e, err := NewEngine(&Policy{Rules: []Rule{{Name: "calc", Effect: Allow, Tools: []string{"calculator_*"}}}})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+
+	input := hooks.NewToolHookInput("agent-1", "call-1", "calculator_add", map[string]interface{}{})
+	if err := hooks.ExecuteToolPreHooks(context.Background(), []hooks.ToolHook{e}, input); err != nil {
+		t.Errorf("ExecuteToolPreHooks() error = %v", err)
+	}
+
+	input = hooks.NewToolHookInput("agent-1", "call-2", "delete_file", map[string]interface{}{}).WithUserID("u1")
+	err = hooks.ExecuteToolPreHooks(context.Background(), []hooks.ToolHook{e}, input)
+	var denied *DeniedError
+	if !errors.As(err, &denied) || denied.Tool != "delete_file" {
+		t.Fatalf("ExecuteToolPreHooks() error = %v, want a DeniedError", err)
+	}
+	if d, ok := input.Metadata["policy_decision"].(Decision); !ok || d.Allowed {
+		t.Errorf("policy_decision = %+v", input.Metadata["policy_decision"])
+	}
+
+	// Update swaps the policy; an invalid one is rejected and the old one kept
+	if err := e.Update(&Policy{Default: Allow}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if !e.Evaluate(Request{Tool: "delete_file"}).Allowed {
+		t.Error("updated policy not applied")
+	}
+	if err := e.Update(&Policy{Default: "maybe"}); err == nil {
+		t.Error("expected error for an invalid default")
+	}
+	if e.Policy().Default != Allow {
+		t.Error("invalid update replaced the policy")
+	}
+}
+
+func TestParse(t *testing.T) {
+	data := []byte(`
+default: deny
+rules:
+  - name: refunds
+    effect: allow
+    tools: [refund]
+    users: [support-lead]
+    args:
+      amount: {max: 500}
+    windows:
+      - days: [mon, tue, wed, thu, fri]
+        start: "08:00"
+        end: "20:00"
+        timezone: UTC
+`)
+	p, err := Parse("tools.yaml", data)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(p.Rules) != 1 || *p.Rules[0].Args["amount"].Max != 500 || p.Rules[0].Windows[0].Start != "08:00" {
+		t.Errorf("Parse() = %+v", p)
+	}
+
+	invalid := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"effect", `{"rules": [{"effect": "audit"}]}`, "invalid effect"},
+		{"pattern", `{"rules": [{"effect": "deny", "args": {"q": {"pattern": "("}}}]}`, "invalid pattern"},
+		{"day", `{"rules": [{"effect": "allow", "windows": [{"days": ["someday"]}]}]}`, "invalid day"},
+		{"time", `{"rules": [{"effect": "allow", "windows": [{"start": "9am", "end": "5pm"}]}]}`, "invalid time"},
+		{"half window", `{"rules": [{"effect": "allow", "windows": [{"start": "09:00"}]}]}`, "both start and end"},
+	}
+	for _, tt := range invalid {
+		if _, err := Parse("tools.json", []byte(tt.data)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: Parse() error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+	if _, err := Parse("tools.toml", data); err == nil {
+		t.Error("expected error for an unsupported extension")
+	}
+}
@@ -2,10 +2,14 @@ package prompts
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 	"text/template"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/flags"
 )
 
 // PromptSection represents a modular section of a system prompt
@@ -34,11 +38,19 @@ type PromptSection struct {
 	// Variables are template variables (used if IsTemplate is true)
 	// Variables 是模板变量（如果 IsTemplate 为 true 则使用）
 	Variables map[string]interface{}
+
+	// FlagKey, when set, includes this section only if the feature flag is on.
+	// It is evaluated by ComposeWithFlags; Compose and ComposeWithVars ignore it.
+	// FlagKey 设置后，仅当功能开关开启时才包含此部分。
+	// 由 ComposeWithFlags 评估；Compose 和 ComposeWithVars 会忽略它。
+	FlagKey string
 }
 
-// PromptComposer composes multiple prompt sections into a final prompt
-// PromptComposer 将多个提示部分组合成最终提示
+// PromptComposer composes multiple prompt sections into a final prompt.
+// It is safe for concurrent use.
+// PromptComposer 将多个提示部分组合成最终提示，可并发使用。
 type PromptComposer struct {
+	mu       sync.RWMutex
 	sections []PromptSection
 }
 
@@ -53,12 +65,18 @@ func NewPromptComposer(sections ...PromptSection) *PromptComposer {
 // AddSection adds a new section to the composer
 // AddSection 向组合器添加一个新部分
 func (c *PromptComposer) AddSection(section PromptSection) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	c.sections = append(c.sections, section)
 }
 
 // RemoveSection removes a section by name
 // RemoveSection 按名称删除部分
 func (c *PromptComposer) RemoveSection(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	for i, s := range c.sections {
 		if s.Name == name {
 			c.sections = append(c.sections[:i], c.sections[i+1:]...)
@@ -67,9 +85,28 @@ func (c *PromptComposer) RemoveSection(name string) {
 	}
 }
 
+// ReplaceSection replaces the section with the same name, or adds it when
+// there is none, in a single step so concurrent composes see either version
+// ReplaceSection 替换同名部分（不存在时添加），一步完成，并发组合只会看到其中一个版本
+func (c *PromptComposer) ReplaceSection(section PromptSection) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := range c.sections {
+		if c.sections[i].Name == section.Name {
+			c.sections[i] = section
+			return
+		}
+	}
+	c.sections = append(c.sections, section)
+}
+
 // EnableSection enables a section by name
 // EnableSection 按名称启用部分
 func (c *PromptComposer) EnableSection(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	for i := range c.sections {
 		if c.sections[i].Name == name {
 			c.sections[i].Enabled = true
@@ -82,6 +119,9 @@ func (c *PromptComposer) EnableSection(name string) bool {
 // DisableSection disables a section by name
 // DisableSection 按名称禁用部分
 func (c *PromptComposer) DisableSection(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	for i := range c.sections {
 		if c.sections[i].Name == name {
 			c.sections[i].Enabled = false
@@ -94,6 +134,9 @@ func (c *PromptComposer) DisableSection(name string) bool {
 // SetSectionVariables updates variables for a template section
 // SetSectionVariables 更新模板部分的变量
 func (c *PromptComposer) SetSectionVariables(name string, vars map[string]interface{}) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	for i := range c.sections {
 		if c.sections[i].Name == name {
 			if c.sections[i].Variables == nil {
@@ -117,6 +160,39 @@ func (c *PromptComposer) Compose() (string, error) {
 // ComposeWithVars builds the final prompt with additional global variables
 // ComposeWithVars 使用额外的全局变量构建最终提示
 func (c *PromptComposer) ComposeWithVars(globalVars map[string]interface{}) (string, error) {
+	return c.compose(globalVars, nil)
+}
+
+// ComposeWithFlags builds the final prompt, dropping sections whose FlagKey is
+// off for the given evaluation context
+// ComposeWithFlags 构建最终提示，并丢弃 FlagKey 在给定评估上下文中关闭的部分
+func (c *PromptComposer) ComposeWithFlags(ctx context.Context, provider flags.FlagProvider, evalCtx flags.EvalContext, globalVars map[string]interface{}) (string, error) {
+	if provider == nil {
+		return c.compose(globalVars, nil)
+	}
+	return c.compose(globalVars, func(section PromptSection) bool {
+		return provider.BoolValue(ctx, section.FlagKey, false, evalCtx)
+	})
+}
+
+// HasFlaggedSections reports whether any section is gated by a feature flag
+// HasFlaggedSections 判断是否有部分受功能开关控制
+func (c *PromptComposer) HasFlaggedSections() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, section := range c.sections {
+		if section.FlagKey != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *PromptComposer) compose(globalVars map[string]interface{}, flagOn func(PromptSection) bool) (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	// Sort sections by priority (lower number = higher priority)
 	// 按优先级排序部分（较小的数字 = 较高的优先级）
 	sortedSections := make([]PromptSection, len(c.sections))
@@ -132,6 +208,9 @@ func (c *PromptComposer) ComposeWithVars(globalVars map[string]interface{}) (str
 		if !section.Enabled {
 			continue
 		}
+		if section.FlagKey != "" && flagOn != nil && !flagOn(section) {
+			continue
+		}
 
 		content := section.Content
 
@@ -185,6 +264,9 @@ func renderTemplate(name, content string, vars map[string]interface{}) (string,
 // GetSection returns a section by name (copy to prevent modification)
 // GetSection 按名称返回部分（复制以防止修改）
 func (c *PromptComposer) GetSection(name string) (PromptSection, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	for _, s := range c.sections {
 		if s.Name == name {
 			return s, true
@@ -196,6 +278,9 @@ func (c *PromptComposer) GetSection(name string) (PromptSection, bool) {
 // ListSections returns all section names
 // ListSections 返回所有部分名称
 func (c *PromptComposer) ListSections() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	names := make([]string, 0, len(c.sections))
 	for _, s := range c.sections {
 		names = append(names, s.Name)
@@ -206,12 +291,18 @@ func (c *PromptComposer) ListSections() []string {
 // Clear removes all sections
 // Clear 删除所有部分
 func (c *PromptComposer) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	c.sections = nil
 }
 
 // SectionCount returns the number of sections
 // SectionCount 返回部分的数量
 func (c *PromptComposer) SectionCount() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	return len(c.sections)
 }
 
@@ -295,6 +386,32 @@ Use this context when responding to the user's request.`, memoryResults)
 	return NewSection("memory", content, 30)
 }
 
+// UserMemorySection creates a section listing what is known about the user,
+// e.g. the learned memories most relevant to the current request
+// UserMemorySection 创建列出用户已知信息的部分，例如与当前请求最相关的已学习记忆
+func UserMemorySection(memories []string) PromptSection {
+	var b strings.Builder
+	for _, memory := range memories {
+		if memory = strings.TrimSpace(memory); memory != "" {
+			fmt.Fprintf(&b, "- %s\n", memory)
+		}
+	}
+	if b.Len() == 0 {
+		return PromptSection{
+			Name:     "user_memory",
+			Content:  "",
+			Priority: 25,
+			Enabled:  false,
+		}
+	}
+
+	content := fmt.Sprintf(`## What You Know About the User
+
+%s
+Use these memories to personalize your response when they are relevant.`, b.String())
+	return NewSection("user_memory", content, 25)
+}
+
 // InstructionsSection creates a custom instructions section
 // InstructionsSection 创建自定义指令部分
 func InstructionsSection(instructions string) PromptSection {
@@ -1,8 +1,11 @@
 package prompts
 
 import (
+	"context"
 	"strings"
 	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/flags"
 )
 
 // TestNewPromptComposer tests creating a new prompt composer
@@ -79,6 +82,25 @@ func TestRemoveSection(t *testing.T) {
 	}
 }
 
+// TestReplaceSection tests replacing and adding sections by name
+func TestReplaceSection(t *testing.T) {
+	composer := NewPromptComposer(NewSection("section1", "content1", 1))
+
+	composer.ReplaceSection(NewSection("section1", "updated", 1))
+	composer.ReplaceSection(NewSection("section2", "content2", 2))
+
+	if composer.SectionCount() != 2 {
+		t.Fatalf("expected 2 sections, got %d", composer.SectionCount())
+	}
+	section, ok := composer.GetSection("section1")
+	if !ok || section.Content != "updated" {
+		t.Errorf("expected section1 to be replaced, got %+v", section)
+	}
+	if _, ok := composer.GetSection("section2"); !ok {
+		t.Error("expected section2 to be added")
+	}
+}
+
 // TestEnableDisableSection tests enabling/disabling sections
 func TestEnableDisableSection(t *testing.T) {
 	sections := []PromptSection{
@@ -312,6 +334,27 @@ func TestPredefinedSections(t *testing.T) {
 		}
 	})
 
+	t.Run("UserMemorySection", func(t *testing.T) {
+		section := UserMemorySection([]string{"Works at Acme", " ", "Prefers short answers"})
+		composer := NewPromptComposer(section)
+
+		result, err := composer.Compose()
+		if err != nil {
+			t.Fatalf("compose failed: %v", err)
+		}
+
+		if !strings.Contains(result, "- Works at Acme\n- Prefers short answers\n") {
+			t.Errorf("user memory section should list the memories, got %q", result)
+		}
+	})
+
+	t.Run("UserMemorySectionEmpty", func(t *testing.T) {
+		section := UserMemorySection(nil)
+		if section.Enabled {
+			t.Error("empty user memory section should be disabled")
+		}
+	})
+
 	t.Run("InstructionsSection", func(t *testing.T) {
 		instructions := "Always be polite and concise"
 		section := InstructionsSection(instructions)
@@ -505,3 +548,40 @@ func TestTemplateErrorHandling(t *testing.T) {
 		t.Errorf("expected '%s', got '%s'", expected, result)
 	}
 }
+
+// TestComposeWithFlags tests that flag-gated sections follow the flag provider
+func TestComposeWithFlags(t *testing.T) {
+	beta := NewSection("beta", "Beta features are available.", 20)
+	beta.FlagKey = "beta_prompt"
+	composer := NewPromptComposer(NewSection("base", "You are helpful.", 10), beta)
+
+	if !composer.HasFlaggedSections() {
+		t.Fatal("expected composer to report flagged sections")
+	}
+
+	provider := flags.NewStaticProvider(nil)
+	provider.SetForUser("beta-user", "beta_prompt", true)
+	ctx := context.Background()
+
+	off, err := composer.ComposeWithFlags(ctx, provider, flags.EvalContext{UserID: "regular"}, nil)
+	if err != nil {
+		t.Fatalf("ComposeWithFlags() error = %v", err)
+	}
+	if strings.Contains(off, "Beta") {
+		t.Errorf("expected beta section to be hidden, got %q", off)
+	}
+
+	on, err := composer.ComposeWithFlags(ctx, provider, flags.EvalContext{UserID: "beta-user"}, nil)
+	if err != nil {
+		t.Fatalf("ComposeWithFlags() error = %v", err)
+	}
+	if !strings.Contains(on, "Beta features are available.") {
+		t.Errorf("expected beta section for flagged user, got %q", on)
+	}
+
+	// Compose ignores flags entirely.
+	all, _ := composer.Compose()
+	if !strings.Contains(all, "Beta") {
+		t.Errorf("expected Compose to ignore FlagKey, got %q", all)
+	}
+}
@@ -0,0 +1,157 @@
+package prompts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Registry holds named prompt templates that can be replaced atomically
+type Registry struct {
+	mu        sync.RWMutex
+	templates map[string]*Template
+}
+
+// NewRegistry creates an empty prompt registry
+func NewRegistry() *Registry {
+	return &Registry{templates: make(map[string]*Template)}
+}
+
+// Register validates and adds a single prompt, keyed by ID (or Name when ID is empty)
+func (r *Registry) Register(prompt *Prompt) error {
+	tmpl, err := NewTemplate(prompt)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.templates[promptKey(prompt)] = tmpl
+	return nil
+}
+
+// Get returns the template registered under id
+func (r *Registry) Get(id string) (*Template, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tmpl, ok := r.templates[id]
+	return tmpl, ok
+}
+
+// Render renders the template registered under id
+func (r *Registry) Render(id string, vars map[string]interface{}) (string, error) {
+	tmpl, ok := r.Get(id)
+	if !ok {
+		return "", fmt.Errorf("prompt %s not found", id)
+	}
+	return tmpl.Render(vars)
+}
+
+// IDs returns the registered prompt IDs in sorted order
+func (r *Registry) IDs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]string, 0, len(r.templates))
+	for id := range r.templates {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Replace validates every prompt and swaps the registry contents in one step.
+// If any prompt is invalid the registry is left unchanged.
+func (r *Registry) Replace(prompts []*Prompt) error {
+	templates := make(map[string]*Template, len(prompts))
+	for _, prompt := range prompts {
+		tmpl, err := NewTemplate(prompt)
+		if err != nil {
+			return fmt.Errorf("prompt %s: %w", promptKey(prompt), err)
+		}
+		key := promptKey(prompt)
+		if _, exists := templates[key]; exists {
+			return fmt.Errorf("duplicate prompt id %s", key)
+		}
+		templates[key] = tmpl
+	}
+
+	r.mu.Lock()
+	r.templates = templates
+	r.mu.Unlock()
+	return nil
+}
+
+// LoadDir replaces the registry with every .yaml, .yml and .json prompt in dir
+func (r *Registry) LoadDir(dir string) error {
+	prompts, err := LoadPromptsDir(dir)
+	if err != nil {
+		return err
+	}
+	return r.Replace(prompts)
+}
+
+// ParsePrompt decodes a prompt from YAML or JSON based on the file extension
+func ParsePrompt(name string, data []byte) (*Prompt, error) {
+	var prompt Prompt
+	var err error
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		err = json.Unmarshal(data, &prompt)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &prompt)
+	default:
+		return nil, fmt.Errorf("unsupported prompt file extension: %s", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prompt %s: %w", name, err)
+	}
+	return &prompt, nil
+}
+
+// LoadPromptsDir reads every prompt file in dir (non-recursive)
+func LoadPromptsDir(dir string) ([]*Prompt, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompts directory: %w", err)
+	}
+
+	var prompts []*Prompt
+	for _, entry := range entries {
+		if entry.IsDir() || !isPromptFile(entry.Name()) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read prompt %s: %w", path, err)
+		}
+		prompt, err := ParsePrompt(path, data)
+		if err != nil {
+			return nil, err
+		}
+		prompts = append(prompts, prompt)
+	}
+	return prompts, nil
+}
+
+func isPromptFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+func promptKey(prompt *Prompt) string {
+	if prompt.ID != "" {
+		return prompt.ID
+	}
+	return prompt.Name
+}
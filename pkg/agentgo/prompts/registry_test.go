@@ -0,0 +1,59 @@
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRegistry_LoadDirAndRender(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "greet.yaml"), "id: greet\nname: greeting\ntemplate: \"Hello {{.name}}\"\n")
+	writeFile(t, filepath.Join(dir, "bye.json"), `{"name": "bye", "template": "Bye {{.name}}"}`)
+	writeFile(t, filepath.Join(dir, "notes.txt"), "ignored")
+
+	r := NewRegistry()
+	if err := r.LoadDir(dir); err != nil {
+		t.Fatalf("LoadDir() error = %v", err)
+	}
+
+	if ids := r.IDs(); len(ids) != 2 || ids[0] != "bye" || ids[1] != "greet" {
+		t.Fatalf("unexpected ids %v", ids)
+	}
+
+	out, err := r.Render("greet", map[string]interface{}{"name": "Ada"})
+	if err != nil || out != "Hello Ada" {
+		t.Fatalf("Render() = %q, %v", out, err)
+	}
+	if _, err := r.Render("missing", nil); err == nil {
+		t.Fatal("expected error for unknown prompt")
+	}
+}
+
+func TestRegistry_ReplaceKeepsPreviousOnError(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register(&Prompt{Name: "a", Template: "A"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	err := r.Replace([]*Prompt{
+		{Name: "b", Template: "B"},
+		{Name: "broken", Template: "{{.unclosed"},
+	})
+	if err == nil {
+		t.Fatal("expected Replace to fail on invalid template")
+	}
+	if _, ok := r.Get("a"); !ok {
+		t.Fatal("expected previous prompts to be kept after failed Replace")
+	}
+	if _, ok := r.Get("b"); ok {
+		t.Fatal("expected no partial update after failed Replace")
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+}
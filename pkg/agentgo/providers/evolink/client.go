@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
 	"github.com/jholhewres/agent-go/pkg/agentgo/types"
 )
 
@@ -60,6 +61,7 @@ func (c *Client) post(ctx context.Context, path string, payload interface{}, out
 	}
 	req.Header.Set("content-type", "application/json")
 	req.Header.Set("authorization", "Bearer "+c.apiKey)
+	models.ApplyContextHeaders(req)
 	resp, err := c.http.Do(req)
 	if err != nil {
 		return types.NewAPIError("api request failed", err)
@@ -82,6 +84,7 @@ func (c *Client) get(ctx context.Context, path string, out interface{}) error {
 		return fmt.Errorf("create request: %w", err)
 	}
 	req.Header.Set("authorization", "Bearer "+c.apiKey)
+	models.ApplyContextHeaders(req)
 	resp, err := c.http.Do(req)
 	if err != nil {
 		return types.NewAPIError("api request failed", err)
@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryEntry holds the counts of one key
+type memoryEntry struct {
+	minute   int64
+	requests int
+	day      int64
+	tokens   int
+}
+
+// MemoryLimiter keeps the counts in process; every process has its own. Keys
+// not seen since the previous day are dropped when a new day starts.
+// MemoryLimiter 在进程内保存计数；每个进程各自独立。新的一天开始时，会删除前一天之后未出现的键。
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	swept   int64 // Day window of the last sweep
+	now     func() time.Time
+}
+
+var _ Limiter = (*MemoryLimiter)(nil)
+
+// NewMemoryLimiter creates an in-memory limiter
+// NewMemoryLimiter 创建内存限流器
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{entries: make(map[string]*memoryEntry), now: time.Now}
+}
+
+// entry returns the counts of key, resetting the windows that have passed.
+// The caller holds mu.
+func (m *MemoryLimiter) entry(key string, now time.Time) *memoryEntry {
+	m.sweep(now)
+	e, ok := m.entries[key]
+	if !ok {
+		e = &memoryEntry{}
+		m.entries[key] = e
+	}
+	if minute := minuteWindow(now); e.minute != minute {
+		e.minute, e.requests = minute, 0
+	}
+	if day := dayWindow(now); e.day != day {
+		e.day, e.tokens = day, 0
+	}
+	return e
+}
+
+// sweep drops the entries whose day window has passed, once per day: their
+// counts are all expired. The caller holds mu.
+func (m *MemoryLimiter) sweep(now time.Time) {
+	day := dayWindow(now)
+	if m.swept == day {
+		return
+	}
+	m.swept = day
+	for key, e := range m.entries {
+		if e.day != day {
+			delete(m.entries, key)
+		}
+	}
+}
+
+// TakeRequest implements Limiter
+// TakeRequest 实现 Limiter
+func (m *MemoryLimiter) TakeRequest(ctx context.Context, key string, limit int) (bool, time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return false, 0, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	e := m.entry(key, now)
+	if limit > 0 && e.requests >= limit {
+		return false, untilNextMinute(now), nil
+	}
+	e.requests++
+	return true, 0, nil
+}
+
+// AddTokens implements Limiter
+// AddTokens 实现 Limiter
+func (m *MemoryLimiter) AddTokens(ctx context.Context, key string, tokens int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entry(key, m.now()).tokens += tokens
+	return nil
+}
+
+// Usage implements Limiter
+// Usage 实现 Limiter
+func (m *MemoryLimiter) Usage(ctx context.Context, key string) (Usage, error) {
+	if err := ctx.Err(); err != nil {
+		return Usage{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	m.sweep(now)
+	if _, ok := m.entries[key]; !ok {
+		return Usage{}, nil
+	}
+	e := m.entry(key, now)
+	return Usage{Requests: e.requests, Tokens: e.tokens}, nil
+}
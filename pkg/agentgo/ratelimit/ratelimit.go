@@ -0,0 +1,204 @@
+// Package ratelimit enforces request rates and token budgets per key, e.g. per
+// agent and user: runs beyond RequestsPerMinute wait or are rejected, and runs
+// are rejected once TokensPerDay is spent. Counts live in a Limiter, in memory
+// or in Redis to share them between processes.
+// Package ratelimit 按键（例如按代理和用户）执行请求速率和令牌预算：超过 RequestsPerMinute 的运行
+// 会等待或被拒绝，TokensPerDay 用尽后运行被拒绝。计数保存在 Limiter 中，可在内存中或在 Redis 中跨进程共享。
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// Limiter counts the requests of each key per minute and its tokens per UTC
+// day, in fixed windows. Implementations must be safe for concurrent use.
+// Limiter 以固定窗口统计每个键每分钟的请求数和每个 UTC 日的令牌数。实现必须可安全并发使用。
+type Limiter interface {
+	// TakeRequest counts a request for key unless limit requests were already
+	// counted this minute; it then reports how long until the next minute
+	// TakeRequest 为 key 计入一次请求，除非本分钟已计入 limit 次；此时报告距下一分钟的时间
+	TakeRequest(ctx context.Context, key string, limit int) (ok bool, retryAfter time.Duration, err error)
+	// AddTokens adds tokens to the key's count for today
+	// AddTokens 将 tokens 计入 key 今天的用量
+	AddTokens(ctx context.Context, key string, tokens int) error
+	// Usage returns the counts of key in the current windows
+	// Usage 返回 key 在当前窗口中的计数
+	Usage(ctx context.Context, key string) (Usage, error)
+}
+
+// Usage is what a key consumed in the current windows
+// Usage 是键在当前窗口中的用量
+type Usage struct {
+	Requests int `json:"requests"` // Requests this minute / 本分钟的请求数
+	Tokens   int `json:"tokens"`   // Tokens today (UTC) / 今天（UTC）的令牌数
+}
+
+// Config configures the limits applied to every key
+// Config 配置应用于每个键的限制
+type Config struct {
+	// RequestsPerMinute caps the runs of a key per minute (0 = no limit)
+	// RequestsPerMinute 限制每个键每分钟的运行数（0 = 不限制）
+	RequestsPerMinute int
+	// TokensPerDay caps the tokens of a key per UTC day (0 = no limit). Tokens are
+	// counted when the model answers, so the run that crosses the budget completes.
+	// TokensPerDay 限制每个键每个 UTC 日的令牌数（0 = 不限制）。令牌在模型回答后计入，因此超出预算的那次运行会完成。
+	TokensPerDay int
+	// MaxWait queues a run over RequestsPerMinute for up to this long instead of
+	// rejecting it (0 = reject at once). A spent token budget is never waited for.
+	// MaxWait 使超过 RequestsPerMinute 的运行最多排队这么久而不是直接拒绝（0 = 立即拒绝）。令牌预算用尽时不会等待。
+	MaxWait time.Duration
+	// Limiter keeps the counts (default: a MemoryLimiter)
+	// Limiter 保存计数（默认：MemoryLimiter）
+	Limiter Limiter
+}
+
+// Reasons a run is rejected
+// 运行被拒绝的原因
+const (
+	ReasonRequestsPerMinute = "requests_per_minute"
+	ReasonTokensPerDay      = "tokens_per_day"
+)
+
+// LimitError is the cause of the rate-limit error returned for a rejected run
+// LimitError 是被拒绝运行所返回的限流错误的原因
+type LimitError struct {
+	Key        string
+	Reason     string        // ReasonRequestsPerMinute or ReasonTokensPerDay
+	RetryAfter time.Duration // When the window resets
+}
+
+func (e *LimitError) Error() string {
+	switch e.Reason {
+	case ReasonTokensPerDay:
+		return fmt.Sprintf("daily token budget of %q is spent, resets in %s", e.Key, e.RetryAfter.Round(time.Second))
+	default:
+		return fmt.Sprintf("%q is over its request rate, retry in %s", e.Key, e.RetryAfter.Round(time.Second))
+	}
+}
+
+// Status reports the consumption of a key against its limits
+// Status 报告键相对于其限制的用量
+type Status struct {
+	Key               string `json:"key"`
+	Requests          int    `json:"requests"`                      // This minute / 本分钟
+	RequestsPerMinute int    `json:"requests_per_minute,omitempty"` // 0 = no limit / 0 = 不限制
+	Tokens            int    `json:"tokens"`                        // Today / 今天
+	TokensPerDay      int    `json:"tokens_per_day,omitempty"`      // 0 = no limit / 0 = 不限制
+	TokensRemaining   int    `json:"tokens_remaining,omitempty"`    // Left of TokensPerDay / TokensPerDay 的剩余量
+}
+
+// Enforcer applies a Config to keys
+// Enforcer 将 Config 应用于各个键
+type Enforcer struct {
+	config  Config
+	limiter Limiter
+	now     func() time.Time
+}
+
+// New creates an enforcer
+// New 创建 Enforcer
+func New(config Config) (*Enforcer, error) {
+	if config.RequestsPerMinute < 0 || config.TokensPerDay < 0 {
+		return nil, fmt.Errorf("rate limits cannot be negative")
+	}
+	if config.MaxWait < 0 {
+		return nil, fmt.Errorf("max wait cannot be negative")
+	}
+	limiter := config.Limiter
+	if limiter == nil {
+		limiter = NewMemoryLimiter()
+	}
+	return &Enforcer{config: config, limiter: limiter, now: time.Now}, nil
+}
+
+// Admit counts a run for key, waiting up to MaxWait while the key is over its
+// request rate. A rejected run gets a types.ErrCodeRateLimitError error whose
+// cause is a *LimitError.
+// Admit 为 key 计入一次运行，键超过请求速率时最多等待 MaxWait。被拒绝的运行返回
+// types.ErrCodeRateLimitError 错误，其原因为 *LimitError。
+func (e *Enforcer) Admit(ctx context.Context, key string) error {
+	if e.config.TokensPerDay > 0 {
+		usage, err := e.limiter.Usage(ctx, key)
+		if err != nil {
+			return fmt.Errorf("read token budget: %w", err)
+		}
+		if usage.Tokens >= e.config.TokensPerDay {
+			return rejected(&LimitError{Key: key, Reason: ReasonTokensPerDay, RetryAfter: untilNextDay(e.now())})
+		}
+	}
+	if e.config.RequestsPerMinute == 0 {
+		return nil
+	}
+
+	deadline := e.now().Add(e.config.MaxWait)
+	for {
+		ok, retryAfter, err := e.limiter.TakeRequest(ctx, key, e.config.RequestsPerMinute)
+		if err != nil {
+			return fmt.Errorf("count request: %w", err)
+		}
+		if ok {
+			return nil
+		}
+		if e.now().Add(retryAfter).After(deadline) {
+			return rejected(&LimitError{Key: key, Reason: ReasonRequestsPerMinute, RetryAfter: retryAfter})
+		}
+
+		timer := time.NewTimer(retryAfter)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// Charge counts tokens spent by key
+// Charge 计入 key 消耗的令牌
+func (e *Enforcer) Charge(ctx context.Context, key string, tokens int) error {
+	if tokens <= 0 {
+		return nil
+	}
+	return e.limiter.AddTokens(ctx, key, tokens)
+}
+
+// Status returns the consumption of key against the limits
+// Status 返回 key 相对于限制的用量
+func (e *Enforcer) Status(ctx context.Context, key string) (Status, error) {
+	usage, err := e.limiter.Usage(ctx, key)
+	if err != nil {
+		return Status{}, err
+	}
+	status := Status{
+		Key:               key,
+		Requests:          usage.Requests,
+		RequestsPerMinute: e.config.RequestsPerMinute,
+		Tokens:            usage.Tokens,
+		TokensPerDay:      e.config.TokensPerDay,
+	}
+	if e.config.TokensPerDay > usage.Tokens {
+		status.TokensRemaining = e.config.TokensPerDay - usage.Tokens
+	}
+	return status, nil
+}
+
+func rejected(cause *LimitError) error {
+	return types.NewRateLimitError("run rejected by rate limit", cause)
+}
+
+// minuteWindow and dayWindow number the fixed windows containing t
+// minuteWindow 和 dayWindow 为包含 t 的固定窗口编号
+func minuteWindow(t time.Time) int64 { return t.Unix() / 60 }
+func dayWindow(t time.Time) int64    { return t.Unix() / 86400 }
+
+func untilNextMinute(t time.Time) time.Duration {
+	return time.Unix((minuteWindow(t)+1)*60, 0).Sub(t)
+}
+
+func untilNextDay(t time.Time) time.Duration {
+	return time.Unix((dayWindow(t)+1)*86400, 0).Sub(t)
+}
@@ -0,0 +1,137 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// newEnforcer returns an enforcer and its memory limiter on a shared fake clock
+func newEnforcer(t *testing.T, config Config) (*Enforcer, *time.Time) {
+	t.Helper()
+	now := time.Date(2026, 3, 1, 12, 0, 30, 0, time.UTC)
+	limiter := NewMemoryLimiter()
+	limiter.now = func() time.Time { return now }
+	config.Limiter = limiter
+	e, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	e.now = limiter.now
+	return e, &now
+}
+
+func limitError(t *testing.T, err error) *LimitError {
+	t.Helper()
+	var agnoErr *types.AgnoError
+	var limitErr *LimitError
+	if !errors.As(err, &agnoErr) || agnoErr.Code != types.ErrCodeRateLimitError || !errors.As(err, &limitErr) {
+		t.Fatalf("expected a rate-limit error, got %v", err)
+	}
+	return limitErr
+}
+
+func TestEnforcer_RequestsPerMinute(t *testing.T) {
+	e, now := newEnforcer(t, Config{RequestsPerMinute: 2})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := e.Admit(ctx, "agent:alice"); err != nil {
+			t.Fatalf("Admit() #%d error = %v", i, err)
+		}
+	}
+	limitErr := limitError(t, e.Admit(ctx, "agent:alice"))
+	if limitErr.Reason != ReasonRequestsPerMinute || limitErr.RetryAfter != 30*time.Second {
+		t.Fatalf("limit error = %+v", limitErr)
+	}
+	// Keys are limited separately
+	if err := e.Admit(ctx, "agent:bob"); err != nil {
+		t.Fatalf("Admit(bob) error = %v", err)
+	}
+
+	*now = now.Add(30 * time.Second)
+	if err := e.Admit(ctx, "agent:alice"); err != nil {
+		t.Fatalf("Admit() in the next minute error = %v", err)
+	}
+}
+
+func TestEnforcer_TokensPerDay(t *testing.T) {
+	e, now := newEnforcer(t, Config{TokensPerDay: 1000})
+	ctx := context.Background()
+
+	if err := e.Admit(ctx, "u"); err != nil {
+		t.Fatalf("Admit() error = %v", err)
+	}
+	if err := e.Charge(ctx, "u", 1200); err != nil {
+		t.Fatalf("Charge() error = %v", err)
+	}
+	status, err := e.Status(ctx, "u")
+	if err != nil || status.Tokens != 1200 || status.TokensRemaining != 0 || status.TokensPerDay != 1000 {
+		t.Fatalf("Status() = %+v, %v", status, err)
+	}
+
+	limitErr := limitError(t, e.Admit(ctx, "u"))
+	if limitErr.Reason != ReasonTokensPerDay || limitErr.RetryAfter != 12*time.Hour-30*time.Second {
+		t.Fatalf("limit error = %+v", limitErr)
+	}
+
+	*now = now.Add(12 * time.Hour)
+	if err := e.Admit(ctx, "u"); err != nil {
+		t.Fatalf("Admit() the next day error = %v", err)
+	}
+	if status, _ := e.Status(ctx, "u"); status.Tokens != 0 || status.TokensRemaining != 1000 {
+		t.Fatalf("Status() the next day = %+v", status)
+	}
+}
+
+func TestMemoryLimiter_DropsExpiredKeys(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 30, 0, time.UTC)
+	limiter := NewMemoryLimiter()
+	limiter.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for _, key := range []string{"a", "b", "c"} {
+		if err := limiter.AddTokens(ctx, key, 10); err != nil {
+			t.Fatalf("AddTokens() error = %v", err)
+		}
+	}
+
+	now = now.Add(24 * time.Hour)
+	if _, _, err := limiter.TakeRequest(ctx, "a", 0); err != nil {
+		t.Fatalf("TakeRequest() error = %v", err)
+	}
+	if len(limiter.entries) != 1 {
+		t.Fatalf("expected only the key seen today to remain, got %d entries", len(limiter.entries))
+	}
+	if usage, err := limiter.Usage(ctx, "b"); err != nil || usage != (Usage{}) {
+		t.Fatalf("Usage() of a dropped key = %+v, %v", usage, err)
+	}
+}
+
+func TestEnforcer_MaxWait(t *testing.T) {
+	e, err := New(Config{RequestsPerMinute: 1, MaxWait: time.Minute})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := e.Admit(context.Background(), "u"); err != nil {
+		t.Fatalf("Admit() error = %v", err)
+	}
+
+	// The second run waits for the next minute, until its context ends
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := e.Admit(ctx, "u"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the run to wait until its deadline, got %v", err)
+	}
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	for _, config := range []Config{{RequestsPerMinute: -1}, {TokensPerDay: -1}, {MaxWait: -time.Second}} {
+		if _, err := New(config); err == nil {
+			t.Errorf("New(%+v): expected an error", config)
+		}
+	}
+}
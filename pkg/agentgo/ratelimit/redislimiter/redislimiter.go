@@ -0,0 +1,134 @@
+//go:build redis
+
+// Package redislimiter implements ratelimit.Limiter on top of Redis, so every
+// process using the same server shares the request and token counts.
+package redislimiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/ratelimit"
+	"github.com/redis/go-redis/v9"
+)
+
+// Config for the Redis limiter
+type Config struct {
+	// Client is an existing client to use; when nil one is created from Addr
+	Client redis.UniversalClient
+	// Addr like "localhost:6379"
+	Addr string
+	// Password optional
+	Password string
+	// DB index
+	DB int
+	// KeyPrefix is prepended to every key (default: "ratelimit:")
+	KeyPrefix string
+}
+
+// Limiter keeps each window's count in a Redis counter that expires after the window
+type Limiter struct {
+	client redis.UniversalClient
+	owned  bool
+	prefix string
+	now    func() time.Time
+}
+
+var _ ratelimit.Limiter = (*Limiter)(nil)
+
+// takeRequest increments the minute counter unless it already reached the
+// limit, in one round trip
+var takeRequest = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then redis.call('EXPIRE', KEYS[1], 120) end
+if n > tonumber(ARGV[1]) then
+  redis.call('DECR', KEYS[1])
+  return 0
+end
+return 1
+`)
+
+// New creates a Redis limiter
+func New(cfg Config) *Limiter {
+	client, owned := cfg.Client, false
+	if client == nil {
+		client = redis.NewClient(&redis.Options{Addr: cfg.Addr, Password: cfg.Password, DB: cfg.DB})
+		owned = true
+	}
+	prefix := cfg.KeyPrefix
+	if prefix == "" {
+		prefix = "ratelimit:"
+	}
+	return &Limiter{client: client, owned: owned, prefix: prefix, now: time.Now}
+}
+
+func (l *Limiter) requestKey(key string, now time.Time) string {
+	return fmt.Sprintf("%sreq:%s:%d", l.prefix, key, now.Unix()/60)
+}
+
+func (l *Limiter) tokenKey(key string, now time.Time) string {
+	return fmt.Sprintf("%stok:%s:%d", l.prefix, key, now.Unix()/86400)
+}
+
+// TakeRequest implements ratelimit.Limiter
+func (l *Limiter) TakeRequest(ctx context.Context, key string, limit int) (bool, time.Duration, error) {
+	now := l.now()
+	if limit <= 0 {
+		limit = int(^uint(0) >> 1)
+	}
+	taken, err := takeRequest.Run(ctx, l.client, []string{l.requestKey(key, now)}, limit).Int()
+	if err != nil {
+		return false, 0, err
+	}
+	if taken == 0 {
+		return false, time.Unix((now.Unix()/60+1)*60, 0).Sub(now), nil
+	}
+	return true, 0, nil
+}
+
+// AddTokens implements ratelimit.Limiter
+func (l *Limiter) AddTokens(ctx context.Context, key string, tokens int) error {
+	k := l.tokenKey(key, l.now())
+	_, err := l.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.IncrBy(ctx, k, int64(tokens))
+		pipe.Expire(ctx, k, 48*time.Hour)
+		return nil
+	})
+	return err
+}
+
+// Usage implements ratelimit.Limiter
+func (l *Limiter) Usage(ctx context.Context, key string) (ratelimit.Usage, error) {
+	now := l.now()
+	values, err := l.client.MGet(ctx, l.requestKey(key, now), l.tokenKey(key, now)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return ratelimit.Usage{}, err
+	}
+	var usage ratelimit.Usage
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var n int
+		if _, err := fmt.Sscan(s, &n); err != nil {
+			return ratelimit.Usage{}, fmt.Errorf("invalid counter %q: %w", s, err)
+		}
+		if i == 0 {
+			usage.Requests = n
+		} else {
+			usage.Tokens = n
+		}
+	}
+	return usage, nil
+}
+
+// Close closes the client if the limiter created it
+func (l *Limiter) Close() error {
+	if l.owned {
+		return l.client.Close()
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+//go:build redis
+
+package redislimiter
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+func TestLimiter_Smoke(t *testing.T) {
+	if os.Getenv("TEST_REDIS_RATE_LIMIT") != "1" {
+		t.Skip("set TEST_REDIS_RATE_LIMIT=1 to run redis rate limit test")
+	}
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+	limiter := New(Config{Addr: addr, KeyPrefix: "test-ratelimit:"})
+	defer limiter.Close()
+
+	ctx := context.Background()
+	now := limiter.now()
+	defer limiter.client.Del(ctx, limiter.requestKey("u", now), limiter.tokenKey("u", now))
+
+	for i := 0; i < 2; i++ {
+		if ok, _, err := limiter.TakeRequest(ctx, "u", 2); err != nil || !ok {
+			t.Fatalf("request %d = %v, %v", i, ok, err)
+		}
+	}
+	if ok, retryAfter, err := limiter.TakeRequest(ctx, "u", 2); err != nil || ok || retryAfter <= 0 {
+		t.Fatalf("third request = %v, %s, %v", ok, retryAfter, err)
+	}
+	if err := limiter.AddTokens(ctx, "u", 150); err != nil {
+		t.Fatalf("add tokens: %v", err)
+	}
+	usage, err := limiter.Usage(ctx, "u")
+	if err != nil || usage.Requests != 2 || usage.Tokens != 150 {
+		t.Fatalf("usage = %+v, %v", usage, err)
+	}
+}
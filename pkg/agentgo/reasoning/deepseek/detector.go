@@ -0,0 +1,31 @@
+package deepseek
+
+import (
+	"strings"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+)
+
+// Detector 检测 DeepSeek 推理模型
+// Detector detects DeepSeek reasoning models
+type Detector struct{}
+
+// IsReasoningModel 检查是否为 DeepSeek 推理模型
+// Checks if this is a DeepSeek reasoning model
+func (d *Detector) IsReasoningModel(model models.Model) bool {
+	if model == nil || !strings.EqualFold(model.GetProvider(), "deepseek") {
+		return false
+	}
+
+	// DeepSeek 推理模型: deepseek-reasoner (R1) 系列
+	// DeepSeek reasoning models: deepseek-reasoner (R1) series
+	modelID := strings.ToLower(model.GetID())
+	return strings.Contains(modelID, "reasoner") ||
+		strings.Contains(modelID, "r1")
+}
+
+// Provider 返回提供商名称
+// Returns the provider name
+func (d *Detector) Provider() string {
+	return "deepseek"
+}
@@ -0,0 +1,45 @@
+package deepseek
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// Extractor 从 DeepSeek 响应中提取推理内容
+// Extractor extracts reasoning content from DeepSeek responses
+type Extractor struct{}
+
+// Extract 提取推理内容
+// Extracts reasoning content
+func (e *Extractor) Extract(ctx context.Context, response *types.ModelResponse) (*types.ReasoningContent, error) {
+	if response == nil {
+		return nil, nil
+	}
+
+	// DeepSeek API 在 reasoning_content 字段中单独返回推理过程
+	// The DeepSeek API returns the reasoning apart, in its reasoning_content field
+	if response.ReasoningContent != nil {
+		return response.ReasoningContent, nil
+	}
+
+	// 自托管的 R1 模型将推理放在 <think> 标签中
+	// Self-hosted R1 models put the reasoning in <think> tags
+	start := strings.Index(response.Content, "<think>")
+	end := strings.Index(response.Content, "</think>")
+	if start < 0 || end < start {
+		return nil, nil
+	}
+	reasoningText := strings.TrimSpace(response.Content[start+len("<think>") : end])
+	if reasoningText == "" {
+		return nil, nil
+	}
+	return types.NewReasoningContent(reasoningText), nil
+}
+
+// Provider 返回提供商名称
+// Returns the provider name
+func (e *Extractor) Provider() string {
+	return "deepseek"
+}
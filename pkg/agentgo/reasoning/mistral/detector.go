@@ -0,0 +1,29 @@
+package mistral
+
+import (
+	"strings"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+)
+
+// Detector 检测 Mistral 推理模型
+// Detector detects Mistral reasoning models
+type Detector struct{}
+
+// IsReasoningModel 检查是否为 Mistral 推理模型
+// Checks if this is a Mistral reasoning model
+func (d *Detector) IsReasoningModel(model models.Model) bool {
+	if model == nil || !strings.EqualFold(model.GetProvider(), "mistral") {
+		return false
+	}
+
+	// Mistral 推理模型: magistral 系列
+	// Mistral reasoning models: magistral series
+	return strings.Contains(strings.ToLower(model.GetID()), "magistral")
+}
+
+// Provider 返回提供商名称
+// Returns the provider name
+func (d *Detector) Provider() string {
+	return "mistral"
+}
@@ -0,0 +1,29 @@
+package mistral
+
+import (
+	"context"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// Extractor 从 Mistral 响应中提取推理内容
+// Extractor extracts reasoning content from Mistral responses
+type Extractor struct{}
+
+// Extract 提取推理内容
+// Extracts reasoning content
+func (e *Extractor) Extract(ctx context.Context, response *types.ModelResponse) (*types.ReasoningContent, error) {
+	// Mistral 提供商已将 thinking 内容块转换为 ReasoningContent
+	// The Mistral provider already turns thinking chunks into ReasoningContent
+	if response == nil || response.ReasoningContent == nil {
+		return nil, nil
+	}
+
+	return response.ReasoningContent, nil
+}
+
+// Provider 返回提供商名称
+// Returns the provider name
+func (e *Extractor) Provider() string {
+	return "mistral"
+}
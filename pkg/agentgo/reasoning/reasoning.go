@@ -8,7 +8,9 @@ import (
 
 	"github.com/jholhewres/agent-go/pkg/agentgo/models"
 	"github.com/jholhewres/agent-go/pkg/agentgo/reasoning/anthropic"
+	"github.com/jholhewres/agent-go/pkg/agentgo/reasoning/deepseek"
 	"github.com/jholhewres/agent-go/pkg/agentgo/reasoning/gemini"
+	"github.com/jholhewres/agent-go/pkg/agentgo/reasoning/mistral"
 	"github.com/jholhewres/agent-go/pkg/agentgo/reasoning/openai"
 	"github.com/jholhewres/agent-go/pkg/agentgo/reasoning/vertexai"
 	"github.com/jholhewres/agent-go/pkg/agentgo/types"
@@ -164,4 +166,14 @@ func init() {
 	// Register VertexAI reasoning support
 	DefaultRegistry.RegisterDetector(&vertexai.Detector{})
 	DefaultRegistry.RegisterExtractor(&vertexai.Extractor{})
+
+	// 注册 DeepSeek 推理支持
+	// Register DeepSeek reasoning support
+	DefaultRegistry.RegisterDetector(&deepseek.Detector{})
+	DefaultRegistry.RegisterExtractor(&deepseek.Extractor{})
+
+	// 注册 Mistral 推理支持
+	// Register Mistral reasoning support
+	DefaultRegistry.RegisterDetector(&mistral.Detector{})
+	DefaultRegistry.RegisterExtractor(&mistral.Extractor{})
 }
@@ -224,6 +224,30 @@ func TestIsReasoningModel_VertexAI(t *testing.T) {
 	}
 }
 
+func TestIsReasoningModel_DeepSeekAndMistral(t *testing.T) {
+	tests := []struct {
+		modelID  string
+		provider string
+		want     bool
+	}{
+		{modelID: "deepseek-reasoner", provider: "deepseek", want: true},
+		{modelID: "deepseek-r1-distill", provider: "deepseek", want: true},
+		{modelID: "deepseek-chat", provider: "deepseek", want: false},
+		{modelID: "magistral-medium-latest", provider: "mistral", want: true},
+		{modelID: "mistral-large-latest", provider: "mistral", want: false},
+		{modelID: "deepseek-reasoner", provider: "openrouter", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider+"/"+tt.modelID, func(t *testing.T) {
+			got := IsReasoningModel(&MockModel{id: tt.modelID, provider: tt.provider})
+			if got != tt.want {
+				t.Errorf("IsReasoningModel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestExtractReasoning_OpenAI(t *testing.T) {
 	ctx := context.Background()
 	model := &MockModel{id: "o1-preview", provider: "openai"}
@@ -323,6 +347,35 @@ func TestExtractReasoning_ReasoningProviders(t *testing.T) {
 			},
 			wantNil: false,
 		},
+		{
+			name:  "DeepSeek reasoning content",
+			model: &MockModel{id: "deepseek-reasoner", provider: "deepseek"},
+			response: &types.ModelResponse{
+				Content:          "4",
+				ReasoningContent: types.NewReasoningContent("2 plus 2"),
+			},
+			wantNil: false,
+		},
+		{
+			name:     "DeepSeek <think> tags",
+			model:    &MockModel{id: "deepseek-r1", provider: "deepseek"},
+			response: &types.ModelResponse{Content: "<think>2 plus 2</think>4"},
+			wantNil:  false,
+		},
+		{
+			name:     "DeepSeek without reasoning",
+			model:    &MockModel{id: "deepseek-reasoner", provider: "deepseek"},
+			response: &types.ModelResponse{Content: "4"},
+			wantNil:  true,
+		},
+		{
+			name:  "Mistral reasoning content",
+			model: &MockModel{id: "magistral-small-latest", provider: "mistral"},
+			response: &types.ModelResponse{
+				ReasoningContent: types.NewReasoningContent("magistral thinking"),
+			},
+			wantNil: false,
+		},
 		{
 			name:     "Gemini without reasoning",
 			model:    &MockModel{id: "gemini-2.5-flash", provider: "gemini"},
@@ -0,0 +1,265 @@
+// Package reload watches configuration files and applies changes at runtime.
+//
+// The watcher polls content hashes rather than relying on filesystem events, so
+// it works the same on local disks, network mounts and Kubernetes ConfigMap
+// volumes (which swap symlinks). Each watched path has an apply function that
+// must validate the new content before swapping it in; when it fails the
+// previous configuration stays active and the error is reported.
+//
+// reload 包监视配置文件并在运行时应用变更。监视器轮询内容哈希，每个路径的
+// apply 函数必须在替换前验证新内容；失败时保留原配置并报告错误。
+package reload
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/agent"
+	"github.com/jholhewres/agent-go/pkg/agentgo/guardrails"
+	"github.com/jholhewres/agent-go/pkg/agentgo/prompts"
+)
+
+// ApplyFunc loads, validates and activates the configuration at path
+// ApplyFunc 加载、验证并激活 path 处的配置
+type ApplyFunc func(path string) error
+
+// Config configures a Watcher
+// Config 配置监视器
+type Config struct {
+	// Interval between polls (default: 2s)
+	// Interval 轮询间隔（默认：2 秒）
+	Interval time.Duration
+
+	// OnReload is called after every reload attempt; err is nil on success
+	// OnReload 在每次重新加载尝试后调用；成功时 err 为 nil
+	OnReload func(path string, err error)
+
+	// Logger for reload events (default: slog.Default())
+	// Logger 用于记录重新加载事件
+	Logger *slog.Logger
+}
+
+// Watcher polls registered paths and applies changes
+// Watcher 轮询已注册的路径并应用变更
+type Watcher struct {
+	mu       sync.Mutex
+	entries  map[string]*entry
+	interval time.Duration
+	onReload func(path string, err error)
+	logger   *slog.Logger
+}
+
+type entry struct {
+	apply       ApplyFunc
+	fingerprint string
+}
+
+// NewWatcher creates a new watcher
+// NewWatcher 创建新的监视器
+func NewWatcher(config Config) *Watcher {
+	if config.Interval <= 0 {
+		config.Interval = 2 * time.Second
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+	return &Watcher{
+		entries:  make(map[string]*entry),
+		interval: config.Interval,
+		onReload: config.OnReload,
+		logger:   config.Logger,
+	}
+}
+
+// Watch registers a file or directory and applies it once immediately.
+// The initial apply error is returned so misconfiguration fails at startup.
+// Watch 注册文件或目录并立即应用一次；初始错误会被返回以便在启动时失败。
+func (w *Watcher) Watch(path string, apply ApplyFunc) error {
+	if apply == nil {
+		return fmt.Errorf("apply function is required")
+	}
+
+	fingerprint, err := fingerprintPath(path)
+	if err != nil {
+		return err
+	}
+	if err := apply(path); err != nil {
+		return fmt.Errorf("initial load of %s failed: %w", path, err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.entries[path] = &entry{apply: apply, fingerprint: fingerprint}
+	return nil
+}
+
+// Unwatch stops watching path
+// Unwatch 停止监视 path
+func (w *Watcher) Unwatch(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.entries, path)
+}
+
+// Check polls every path once and applies the ones that changed.
+// It returns the errors of failed reloads joined together.
+// Check 轮询所有路径一次并应用发生变化的路径。
+func (w *Watcher) Check() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	paths := make([]string, 0, len(w.entries))
+	for path := range w.entries {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var errs []error
+	for _, path := range paths {
+		e := w.entries[path]
+
+		fingerprint, err := fingerprintPath(path)
+		if err != nil {
+			// A file that is mid-rewrite or temporarily missing keeps the old config.
+			w.report(path, err)
+			errs = append(errs, err)
+			continue
+		}
+		if fingerprint == e.fingerprint {
+			continue
+		}
+
+		if err := e.apply(path); err != nil {
+			// Record the fingerprint anyway so a broken file is reported once,
+			// not on every poll; the next edit triggers another attempt.
+			e.fingerprint = fingerprint
+			w.report(path, err)
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+
+		e.fingerprint = fingerprint
+		w.report(path, nil)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Run polls until ctx is cancelled
+// Run 持续轮询直到 ctx 被取消
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = w.Check()
+		}
+	}
+}
+
+func (w *Watcher) report(path string, err error) {
+	if err != nil {
+		w.logger.Warn("config reload failed, keeping previous version", "path", path, "error", err)
+	} else {
+		w.logger.Info("config reloaded", "path", path)
+	}
+	if w.onReload != nil {
+		w.onReload(path, err)
+	}
+}
+
+// fingerprintPath hashes a file, or the names and contents of a directory's files
+func fingerprintPath(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	h := sha256.New()
+	if !info.IsDir() {
+		if err := hashFile(h, path); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%x", h.Sum(nil)), nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read directory %s: %w", path, err)
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		io.WriteString(h, e.Name())
+		h.Write([]byte{0})
+		if err := hashFile(h, filepath.Join(path, e.Name())); err != nil {
+			return "", err
+		}
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+func hashFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(w, f); err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return nil
+}
+
+// Instructions returns an ApplyFunc that sets an agent's instructions from a
+// text file. Empty files are rejected.
+// Instructions 返回从文本文件设置代理指令的 ApplyFunc，空文件会被拒绝。
+func Instructions(a *agent.Agent) ApplyFunc {
+	return func(path string) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		instructions := strings.TrimSpace(string(data))
+		if instructions == "" {
+			return fmt.Errorf("instructions file is empty")
+		}
+		a.SetInstructions(instructions)
+		return nil
+	}
+}
+
+// PromptRegistry returns an ApplyFunc that replaces a registry with the prompts
+// in a directory. All prompts are validated before the swap.
+// PromptRegistry 返回用目录中的提示替换注册表的 ApplyFunc，替换前会验证所有提示。
+func PromptRegistry(r *prompts.Registry) ApplyFunc {
+	return r.LoadDir
+}
+
+// GuardrailPolicy returns an ApplyFunc that loads a policy file into g
+// GuardrailPolicy 返回将策略文件加载到 g 的 ApplyFunc
+func GuardrailPolicy(g *guardrails.PolicyGuardrail) ApplyFunc {
+	return func(path string) error {
+		policy, err := guardrails.LoadPolicy(path)
+		if err != nil {
+			return err
+		}
+		return g.Update(policy)
+	}
+}
@@ -0,0 +1,249 @@
+package reload
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/agent"
+	"github.com/jholhewres/agent-go/pkg/agentgo/guardrails"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/prompts"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// stubModel records the system message of the last request
+type stubModel struct {
+	models.BaseModel
+	system string
+}
+
+func (m *stubModel) Invoke(_ context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+	m.system = ""
+	if len(req.Messages) > 0 && req.Messages[0].Role == types.RoleSystem {
+		m.system = req.Messages[0].Content
+	}
+	return &types.ModelResponse{Content: "ok"}, nil
+}
+
+func (m *stubModel) InvokeStream(context.Context, *models.InvokeRequest) (<-chan types.ResponseChunk, error) {
+	return nil, nil
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+}
+
+func TestWatcher_Instructions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "instructions.txt")
+	writeFile(t, path, "be brief")
+
+	ag, err := agent.New(agent.Config{Model: &stubModel{BaseModel: models.BaseModel{ID: "stub"}}})
+	if err != nil {
+		t.Fatalf("agent.New() error = %v", err)
+	}
+
+	var reloads []error
+	w := NewWatcher(Config{OnReload: func(_ string, err error) { reloads = append(reloads, err) }})
+	if err := w.Watch(path, Instructions(ag)); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	if got := ag.GetInstructions(); got != "be brief" {
+		t.Fatalf("expected initial instructions, got %q", got)
+	}
+
+	// Unchanged content does not trigger a reload.
+	if err := w.Check(); err != nil || len(reloads) != 0 {
+		t.Fatalf("expected no reload, got err=%v reloads=%v", err, reloads)
+	}
+
+	writeFile(t, path, "be verbose")
+	if err := w.Check(); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if got := ag.GetInstructions(); got != "be verbose" {
+		t.Fatalf("expected reloaded instructions, got %q", got)
+	}
+
+	// Invalid content is rejected and the previous instructions stay active.
+	writeFile(t, path, "   ")
+	if err := w.Check(); err == nil {
+		t.Fatal("expected empty instructions to be rejected")
+	}
+	if got := ag.GetInstructions(); got != "be verbose" {
+		t.Fatalf("expected previous instructions to be kept, got %q", got)
+	}
+	if len(reloads) != 2 || reloads[0] != nil || reloads[1] == nil {
+		t.Fatalf("unexpected reload notifications %v", reloads)
+	}
+
+	// The broken version is reported once, not on every poll.
+	if err := w.Check(); err != nil {
+		t.Fatalf("expected unchanged broken file to be skipped, got %v", err)
+	}
+}
+
+func TestWatcher_InstructionsReachModel(t *testing.T) {
+	dir := t.TempDir()
+	path, composerPath := filepath.Join(dir, "instructions.txt"), filepath.Join(dir, "composer.txt")
+	writeFile(t, path, "be brief")
+	writeFile(t, composerPath, "be brief")
+
+	model := &stubModel{BaseModel: models.BaseModel{ID: "stub"}}
+	ag, err := agent.New(agent.Config{Model: model, Instructions: "be brief"})
+	if err != nil {
+		t.Fatalf("agent.New() error = %v", err)
+	}
+	composed := &stubModel{BaseModel: models.BaseModel{ID: "stub"}}
+	withComposer, err := agent.New(agent.Config{
+		Model:          composed,
+		Instructions:   "be brief",
+		PromptComposer: prompts.NewPromptComposer(prompts.NewSection("persona", "You are Ada.", 1)),
+	})
+	if err != nil {
+		t.Fatalf("agent.New() error = %v", err)
+	}
+
+	w := NewWatcher(Config{})
+	if err := w.Watch(path, Instructions(ag)); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	if err := w.Watch(composerPath, Instructions(withComposer)); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	for _, want := range []string{"be verbose", "be terse"} {
+		writeFile(t, path, want)
+		writeFile(t, composerPath, want)
+		if err := w.Check(); err != nil {
+			t.Fatalf("Check() error = %v", err)
+		}
+		if _, err := ag.Run(context.Background(), "hi"); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if model.system != want {
+			t.Fatalf("model got system message %q, want %q", model.system, want)
+		}
+
+		// The composed prompt keeps its other sections
+		if _, err := withComposer.Run(context.Background(), "hi"); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if !strings.Contains(composed.system, "You are Ada.") || !strings.Contains(composed.system, want) || strings.Contains(composed.system, "be brief") {
+			t.Fatalf("model got system message %q, want the persona and %q", composed.system, want)
+		}
+	}
+}
+
+func TestWatcher_InstructionsReloadDuringRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "instructions.txt")
+	writeFile(t, path, "be brief")
+
+	model := &stubModel{BaseModel: models.BaseModel{ID: "stub"}}
+	ag, err := agent.New(agent.Config{
+		Model:          model,
+		Instructions:   "be brief",
+		PromptComposer: prompts.NewPromptComposer(prompts.NewSection("persona", "You are Ada.", 1)),
+	})
+	if err != nil {
+		t.Fatalf("agent.New() error = %v", err)
+	}
+
+	w := NewWatcher(Config{})
+	if err := w.Watch(path, Instructions(ag)); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	// Runs keep going until the reloads finish; go test -race reports
+	// unsynchronised access between the two.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			if err := os.WriteFile(path, []byte([]string{"be verbose", "be terse"}[i%2]), 0o644); err != nil {
+				t.Errorf("WriteFile failed: %v", err)
+				return
+			}
+			if err := w.Check(); err != nil {
+				t.Errorf("Check() error = %v", err)
+				return
+			}
+			runtime.Gosched()
+		}
+	}()
+	defer func() { <-done }()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		if _, err := ag.Run(context.Background(), "hi"); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if !strings.Contains(model.system, "You are Ada.") {
+			t.Fatalf("model got system message %q, want the persona", model.system)
+		}
+	}
+}
+
+func TestWatcher_PromptRegistryDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "greet.yaml"), "name: greet\ntemplate: \"Hi {{.name}}\"\n")
+
+	registry := prompts.NewRegistry()
+	w := NewWatcher(Config{})
+	if err := w.Watch(dir, PromptRegistry(registry)); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	writeFile(t, filepath.Join(dir, "greet.yaml"), "name: greet\ntemplate: \"Hello {{.name}}\"\n")
+	if err := w.Check(); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	out, err := registry.Render("greet", map[string]interface{}{"name": "Ada"})
+	if err != nil || out != "Hello Ada" {
+		t.Fatalf("Render() = %q, %v", out, err)
+	}
+}
+
+func TestWatcher_GuardrailPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	writeFile(t, path, "prompt_injection:\n  patterns: [\"open sesame\"]\n")
+
+	g, err := guardrails.NewPolicyGuardrail(&guardrails.Policy{})
+	if err != nil {
+		t.Fatalf("NewPolicyGuardrail() error = %v", err)
+	}
+	w := NewWatcher(Config{})
+	if err := w.Watch(path, GuardrailPolicy(g)); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := g.Check(ctx, guardrails.NewCheckInput("open sesame")); err == nil {
+		t.Fatal("expected loaded policy to block input")
+	}
+
+	writeFile(t, path, "pii:\n  action: explode\n")
+	if err := w.Check(); err == nil {
+		t.Fatal("expected invalid policy to fail reload")
+	}
+	if err := g.Check(ctx, guardrails.NewCheckInput("open sesame")); err == nil {
+		t.Fatal("expected previous policy to remain active")
+	}
+}
+
+func TestWatcher_InitialLoadFailure(t *testing.T) {
+	w := NewWatcher(Config{})
+	if err := w.Watch(filepath.Join(t.TempDir(), "missing.txt"), func(string) error { return nil }); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
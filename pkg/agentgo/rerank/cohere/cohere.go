@@ -0,0 +1,159 @@
+// Package cohere reranks documents with the Cohere Rerank API
+package cohere
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/rerank"
+)
+
+// maxDocuments is the number of documents Cohere accepts per request
+const maxDocuments = 1000
+
+// Config holds configuration for Cohere reranking
+type Config struct {
+	// APIKey for Cohere API
+	APIKey string
+
+	// Model to use (default: rerank-v3.5)
+	// Options: rerank-v3.5, rerank-english-v3.0, rerank-multilingual-v3.0, ...
+	Model string
+
+	// MaxTokensPerDoc truncates long documents (optional, Cohere's default: 4096)
+	MaxTokensPerDoc int
+
+	// BaseURL for Cohere API (default: https://api.cohere.com)
+	BaseURL string
+
+	// HTTPClient to use for requests (optional)
+	HTTPClient *http.Client
+}
+
+// Reranker implements rerank.Reranker using Cohere's rerank API
+type Reranker struct {
+	apiKey          string
+	model           string
+	maxTokensPerDoc int
+	baseURL         string
+	httpClient      *http.Client
+}
+
+var _ rerank.Reranker = (*Reranker)(nil)
+
+type rerankRequest struct {
+	Model           string   `json:"model"`
+	Query           string   `json:"query"`
+	Documents       []string `json:"documents"`
+	TopN            int      `json:"top_n,omitempty"`
+	MaxTokensPerDoc int      `json:"max_tokens_per_doc,omitempty"`
+}
+
+type rerankResponse struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float64 `json:"relevance_score"`
+	} `json:"results"`
+}
+
+type errorResponse struct {
+	Message string `json:"message"`
+}
+
+// New creates a new Cohere reranker
+func New(config Config) (*Reranker, error) {
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("API key is required")
+	}
+	if config.Model == "" {
+		config.Model = "rerank-v3.5"
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = "https://api.cohere.com"
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return &Reranker{
+		apiKey:          config.APIKey,
+		model:           config.Model,
+		maxTokensPerDoc: config.MaxTokensPerDoc,
+		baseURL:         config.BaseURL,
+		httpClient:      config.HTTPClient,
+	}, nil
+}
+
+// Rerank scores documents against query. Scores are relevance probabilities
+// between 0 and 1.
+func (r *Reranker) Rerank(ctx context.Context, query string, documents []string, topN int) ([]rerank.Result, error) {
+	if len(documents) == 0 {
+		return []rerank.Result{}, nil
+	}
+	if len(documents) > maxDocuments {
+		return nil, fmt.Errorf("cohere reranks at most %d documents per request, got %d", maxDocuments, len(documents))
+	}
+	if topN > len(documents) {
+		topN = len(documents)
+	}
+
+	data, err := json.Marshal(rerankRequest{
+		Model:           r.model,
+		Query:           query,
+		Documents:       documents,
+		TopN:            max(topN, 0),
+		MaxTokensPerDoc: r.maxTokensPerDoc,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.baseURL+"/v2/rerank", bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+r.apiKey)
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var errResp errorResponse
+		if err := json.Unmarshal(body, &errResp); err != nil || errResp.Message == "" {
+			return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		}
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, errResp.Message)
+	}
+
+	var rerankResp rerankResponse
+	if err := json.Unmarshal(body, &rerankResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	results := make([]rerank.Result, 0, len(rerankResp.Results))
+	for _, res := range rerankResp.Results {
+		if res.Index < 0 || res.Index >= len(documents) {
+			return nil, fmt.Errorf("result index %d out of range", res.Index)
+		}
+		results = append(results, rerank.Result{Index: res.Index, Score: res.RelevanceScore})
+	}
+	return rerank.Top(results, topN), nil
+}
+
+// GetModel returns the model name being used
+func (r *Reranker) GetModel() string {
+	return r.model
+}
@@ -0,0 +1,71 @@
+package cohere
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Config{}); err == nil {
+		t.Error("expected error without API key")
+	}
+	r, err := New(Config{APIKey: "key"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if r.GetModel() != "rerank-v3.5" {
+		t.Errorf("unexpected default model: %s", r.GetModel())
+	}
+}
+
+func TestRerank(t *testing.T) {
+	var got rerankRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/rerank" || r.Header.Get("Authorization") != "Bearer key" {
+			http.Error(w, `{"message":"bad request"}`, http.StatusBadRequest)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"results": []map[string]interface{}{
+				{"index": 2, "relevance_score": 0.97},
+				{"index": 0, "relevance_score": 0.12},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	r, _ := New(Config{APIKey: "key", BaseURL: srv.URL, MaxTokensPerDoc: 512})
+	results, err := r.Rerank(context.Background(), "refund policy", []string{"pricing", "careers", "refunds within 14 days"}, 2)
+	if err != nil {
+		t.Fatalf("Rerank() error = %v", err)
+	}
+	if len(results) != 2 || results[0].Index != 2 || results[0].Score != 0.97 || results[1].Index != 0 {
+		t.Errorf("Rerank() = %v", results)
+	}
+	if got.Model != "rerank-v3.5" || got.Query != "refund policy" || got.TopN != 2 || got.MaxTokensPerDoc != 512 || len(got.Documents) != 3 {
+		t.Errorf("request = %+v", got)
+	}
+}
+
+func TestRerank_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"invalid api token"}`, http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	r, _ := New(Config{APIKey: "key", BaseURL: srv.URL})
+	if _, err := r.Rerank(context.Background(), "q", []string{"a"}, 1); err == nil || !strings.Contains(err.Error(), "invalid api token") {
+		t.Errorf("Rerank() error = %v, want the API message", err)
+	}
+	if _, err := r.Rerank(context.Background(), "q", make([]string, maxDocuments+1), 1); err == nil {
+		t.Error("expected error above the document limit")
+	}
+	if results, err := r.Rerank(context.Background(), "q", nil, 1); err != nil || len(results) != 0 {
+		t.Errorf("Rerank() without documents = %v, %v", results, err)
+	}
+}
@@ -0,0 +1,176 @@
+// Package crossencoder reranks documents with a cross-encoder model on this
+// machine, such as BAAI/bge-reranker-base or
+// cross-encoder/ms-marco-MiniLM-L-6-v2 exported to ONNX. The model is served
+// by Hugging Face's text-embeddings-router; the reranker starts the server on
+// first use and stops it on Close, or talks to a server that is already
+// running.
+package crossencoder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jholhewres/agent-go/internal/localserver"
+	"github.com/jholhewres/agent-go/pkg/agentgo/rerank"
+)
+
+// Config holds configuration for the cross-encoder reranker
+type Config struct {
+	// ModelPath is an ONNX model directory (required unless URL is set)
+	ModelPath string
+
+	// URL of a running server; when set no process is started
+	URL string
+
+	// Command is the server binary (default: text-embeddings-router)
+	Command string
+
+	// Args are extra arguments passed to the server
+	Args []string
+
+	// Port for the started server (default: a free port)
+	Port int
+
+	// StartTimeout bounds server start-up, including model loading (default: 2m)
+	StartTimeout time.Duration
+
+	// RawScores returns the model's logits instead of scores normalized to 0-1
+	RawScores bool
+
+	// HTTPClient to use for requests (optional)
+	HTTPClient *http.Client
+}
+
+// Reranker implements rerank.Reranker with a local cross-encoder server
+type Reranker struct {
+	config     Config
+	httpClient *http.Client
+	server     *localserver.Server
+}
+
+var _ rerank.Reranker = (*Reranker)(nil)
+
+// New creates a cross-encoder reranker. The server is started lazily.
+func New(config Config) (*Reranker, error) {
+	if config.ModelPath == "" && config.URL == "" {
+		return nil, fmt.Errorf("model path or URL is required")
+	}
+	if config.Command == "" {
+		config.Command = "text-embeddings-router"
+	}
+	if config.URL == "" {
+		if _, err := os.Stat(config.ModelPath); err != nil {
+			return nil, fmt.Errorf("model not found: %w", err)
+		}
+	}
+	if config.StartTimeout <= 0 {
+		config.StartTimeout = 2 * time.Minute
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 60 * time.Second}
+	}
+
+	return &Reranker{
+		config:     config,
+		httpClient: config.HTTPClient,
+		server: localserver.New(localserver.Config{
+			Name:         "rerank server",
+			URL:          config.URL,
+			Command:      config.Command,
+			Args:         func(port int) []string { return commandArgs(config, port) },
+			Port:         config.Port,
+			StartTimeout: config.StartTimeout,
+			HTTPClient:   config.HTTPClient,
+		}),
+	}, nil
+}
+
+type rerankRequest struct {
+	Query     string   `json:"query"`
+	Texts     []string `json:"texts"`
+	RawScores bool     `json:"raw_scores"`
+	Truncate  bool     `json:"truncate"`
+}
+
+type rerankResponse []struct {
+	Index int     `json:"index"`
+	Score float64 `json:"score"`
+}
+
+// Rerank scores every document against query with the cross-encoder
+func (r *Reranker) Rerank(ctx context.Context, query string, documents []string, topN int) ([]rerank.Result, error) {
+	if len(documents) == 0 {
+		return []rerank.Result{}, nil
+	}
+	baseURL, err := r.server.URL(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(rerankRequest{Query: query, Texts: documents, RawScores: r.config.RawScores, Truncate: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/rerank", bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var rerankResp rerankResponse
+	if err := json.Unmarshal(body, &rerankResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(rerankResp) != len(documents) {
+		return nil, fmt.Errorf("expected %d scores, got %d", len(documents), len(rerankResp))
+	}
+
+	results := make([]rerank.Result, 0, len(rerankResp))
+	for _, res := range rerankResp {
+		if res.Index < 0 || res.Index >= len(documents) {
+			return nil, fmt.Errorf("result index %d out of range", res.Index)
+		}
+		results = append(results, rerank.Result{Index: res.Index, Score: res.Score})
+	}
+	return rerank.Top(results, topN), nil
+}
+
+// GetModel returns the model path, or the server URL when no path is set
+func (r *Reranker) GetModel() string {
+	if r.config.ModelPath != "" {
+		return r.config.ModelPath
+	}
+	return r.config.URL
+}
+
+// Close stops the server started by the reranker. Servers given by URL are left running.
+func (r *Reranker) Close() error {
+	return r.server.Close()
+}
+
+// commandArgs returns the text-embeddings-router arguments for config
+func commandArgs(config Config, port int) []string {
+	args := []string{"--model-id", config.ModelPath, "--hostname", "127.0.0.1", "--port", strconv.Itoa(port)}
+	return append(args, config.Args...)
+}
@@ -0,0 +1,81 @@
+package crossencoder
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeServer scores texts by how many query words they contain
+func fakeServer() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/rerank", func(w http.ResponseWriter, r *http.Request) {
+		var req rerankRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		out := make([]map[string]interface{}, len(req.Texts))
+		for i, text := range req.Texts {
+			score := 0.0
+			for _, word := range strings.Fields(req.Query) {
+				if strings.Contains(text, word) {
+					score += 0.4
+				}
+			}
+			out[i] = map[string]interface{}{"index": i, "score": score}
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	})
+	return mux
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Config{}); err == nil {
+		t.Error("expected error without model path or URL")
+	}
+	if _, err := New(Config{ModelPath: filepath.Join(t.TempDir(), "missing")}); err == nil {
+		t.Error("expected error for a missing model")
+	}
+}
+
+func TestRerank_RunningServer(t *testing.T) {
+	srv := httptest.NewServer(fakeServer())
+	defer srv.Close()
+
+	r, err := New(Config{URL: srv.URL + "/"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	results, err := r.Rerank(context.Background(), "reset password", []string{"billing", "reset your password here", "password rules"}, 2)
+	if err != nil {
+		t.Fatalf("Rerank() error = %v", err)
+	}
+	if len(results) != 2 || results[0].Index != 1 || results[1].Index != 2 {
+		t.Errorf("Rerank() = %v", results)
+	}
+	if r.GetModel() != srv.URL+"/" {
+		t.Errorf("GetModel() = %q", r.GetModel())
+	}
+}
+
+func TestCommandArgs(t *testing.T) {
+	got := commandArgs(Config{ModelPath: "bge-reranker", Args: []string{"--max-batch-tokens", "1024"}}, 9000)
+	want := []string{"--model-id", "bge-reranker", "--hostname", "127.0.0.1", "--port", "9000", "--max-batch-tokens", "1024"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("args = %v, want %v", got, want)
+	}
+}
+
+func TestRerank_ServerFailsToStart(t *testing.T) {
+	r, err := New(Config{ModelPath: t.TempDir(), Command: filepath.Join(t.TempDir(), "no-such-binary")})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := r.Rerank(context.Background(), "go", []string{"go"}, 1); err == nil {
+		t.Fatal("expected start error")
+	}
+}
@@ -0,0 +1,61 @@
+// Package rerank reorders search results by their relevance to a query.
+// Vector search ranks candidates by embedding similarity, which is fast but
+// coarse. A reranker reads the query together with each candidate, so
+// retrieving a few times more candidates than needed and reranking them
+// improves the precision of the top results.
+//
+// Implementations live in subpackages: cohere (Cohere Rerank API) and
+// crossencoder (a local ONNX cross-encoder).
+package rerank
+
+import (
+	"context"
+	"sort"
+)
+
+// DefaultCandidateMultiplier is how many more candidates than requested
+// results are retrieved for reranking by default
+const DefaultCandidateMultiplier = 4
+
+// Result is the relevance of one document
+type Result struct {
+	Index int     `json:"index"` // Position in the documents passed to Rerank
+	Score float64 `json:"score"` // Relevance, higher is better
+}
+
+// Reranker scores documents against a query
+type Reranker interface {
+	// Rerank returns at most topN results (topN <= 0 = all documents),
+	// sorted by descending score
+	Rerank(ctx context.Context, query string, documents []string, topN int) ([]Result, error)
+}
+
+// Func adapts a function to the Reranker interface
+type Func func(ctx context.Context, query string, documents []string, topN int) ([]Result, error)
+
+// Rerank calls f
+func (f Func) Rerank(ctx context.Context, query string, documents []string, topN int) ([]Result, error) {
+	return f(ctx, query, documents, topN)
+}
+
+// Top sorts results by descending score, keeping the original order of ties,
+// and truncates them to topN (topN <= 0 = all)
+func Top(results []Result, topN int) []Result {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if topN > 0 && len(results) > topN {
+		results = results[:topN]
+	}
+	return results
+}
+
+// Candidates returns how many results to retrieve before reranking down to
+// limit: candidates when it is larger than limit, otherwise
+// DefaultCandidateMultiplier times limit
+func Candidates(limit, candidates int) int {
+	if candidates > limit {
+		return candidates
+	}
+	return limit * DefaultCandidateMultiplier
+}
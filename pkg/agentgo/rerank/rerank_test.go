@@ -0,0 +1,30 @@
+package rerank
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTop(t *testing.T) {
+	results := []Result{{Index: 0, Score: 0.2}, {Index: 1, Score: 0.9}, {Index: 2, Score: 0.2}, {Index: 3, Score: 0.5}}
+	got := Top(results, 3)
+	want := []Result{{Index: 1, Score: 0.9}, {Index: 3, Score: 0.5}, {Index: 0, Score: 0.2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Top() = %v, want %v", got, want)
+	}
+	if got := Top([]Result{{Index: 0, Score: 1}}, 0); len(got) != 1 {
+		t.Errorf("Top() with topN 0 = %v, want all results", got)
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	if got := Candidates(5, 0); got != 20 {
+		t.Errorf("Candidates(5, 0) = %d, want 20", got)
+	}
+	if got := Candidates(5, 50); got != 50 {
+		t.Errorf("Candidates(5, 50) = %d, want 50", got)
+	}
+	if got := Candidates(5, 3); got != 20 {
+		t.Errorf("Candidates(5, 3) = %d, want 20 (fewer candidates than results is ignored)", got)
+	}
+}
@@ -0,0 +1,364 @@
+// Package resources provides an application-owned container for shared
+// connections (database pools, HTTP clients, vector stores and embedders).
+//
+// Components such as storages and vector databases borrow handles from the
+// container and never close them; the application closes everything once,
+// in reverse registration order, via Resources.Close.
+//
+// resources 包提供由应用程序拥有的共享连接容器（数据库连接池、HTTP 客户端、
+// 向量数据库和嵌入客户端）。组件只借用这些句柄而不关闭它们，应用程序通过
+// Resources.Close 按注册的逆序统一关闭。
+package resources
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+)
+
+// Kind identifies the category of a registered resource
+// Kind 表示已注册资源的类别
+type Kind string
+
+const (
+	KindDB       Kind = "db"
+	KindHTTP     Kind = "http"
+	KindVectorDB Kind = "vectordb"
+	KindEmbedder Kind = "embedder"
+	KindCustom   Kind = "custom"
+)
+
+var (
+	// ErrClosed is returned when registering into a closed container
+	// ErrClosed 在向已关闭的容器注册时返回
+	ErrClosed = errors.New("resources: container is closed")
+
+	// ErrDuplicate is returned when a name is registered twice
+	// ErrDuplicate 在重复注册同名资源时返回
+	ErrDuplicate = errors.New("resources: duplicate resource name")
+)
+
+// CloseFunc releases a resource
+// CloseFunc 释放资源
+type CloseFunc func(ctx context.Context) error
+
+// HealthFunc reports whether a resource is usable
+// HealthFunc 报告资源是否可用
+type HealthFunc func(ctx context.Context) error
+
+// Resource describes a custom resource registered with Register
+// Resource 描述通过 Register 注册的自定义资源
+type Resource struct {
+	Name   string
+	Kind   Kind
+	Value  interface{}
+	Close  CloseFunc
+	Health HealthFunc
+}
+
+// HealthStatus is the result of checking a single resource
+// HealthStatus 是单个资源的检查结果
+type HealthStatus struct {
+	Name    string        `json:"name"`
+	Kind    Kind          `json:"kind"`
+	Healthy bool          `json:"healthy"`
+	Error   string        `json:"error,omitempty"`
+	Latency time.Duration `json:"latency"`
+}
+
+// Config configures a Resources container
+// Config 配置资源容器
+type Config struct {
+	// CloseTimeout bounds each individual close call (default: 10s)
+	// CloseTimeout 限制每次关闭调用的时长（默认：10 秒）
+	CloseTimeout time.Duration
+
+	// HealthTimeout bounds each individual health check (default: 5s)
+	// HealthTimeout 限制每次健康检查的时长（默认：5 秒）
+	HealthTimeout time.Duration
+}
+
+// Resources owns shared handles and closes them in reverse registration order
+// Resources 拥有共享句柄，并按注册的逆序关闭它们
+type Resources struct {
+	mu            sync.RWMutex
+	entries       []*Resource
+	byName        map[string]*Resource
+	closed        bool
+	closeTimeout  time.Duration
+	healthTimeout time.Duration
+}
+
+// New creates an empty resources container
+// New 创建空的资源容器
+func New(config Config) *Resources {
+	if config.CloseTimeout <= 0 {
+		config.CloseTimeout = 10 * time.Second
+	}
+	if config.HealthTimeout <= 0 {
+		config.HealthTimeout = 5 * time.Second
+	}
+	return &Resources{
+		byName:        make(map[string]*Resource),
+		closeTimeout:  config.CloseTimeout,
+		healthTimeout: config.HealthTimeout,
+	}
+}
+
+// Register adds a custom resource. Close and Health are optional.
+// Register 添加自定义资源，Close 和 Health 均为可选。
+func (r *Resources) Register(res Resource) error {
+	if res.Name == "" {
+		return fmt.Errorf("resource name is required")
+	}
+	if res.Kind == "" {
+		res.Kind = KindCustom
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return ErrClosed
+	}
+	if _, exists := r.byName[res.Name]; exists {
+		return fmt.Errorf("%w: %s", ErrDuplicate, res.Name)
+	}
+
+	entry := res
+	r.entries = append(r.entries, &entry)
+	r.byName[res.Name] = &entry
+	return nil
+}
+
+// AddDB registers a database pool; health is checked with PingContext
+// AddDB 注册数据库连接池，使用 PingContext 进行健康检查
+func (r *Resources) AddDB(name string, db *sql.DB) error {
+	if db == nil {
+		return fmt.Errorf("db %s cannot be nil", name)
+	}
+	return r.Register(Resource{
+		Name:   name,
+		Kind:   KindDB,
+		Value:  db,
+		Close:  func(context.Context) error { return db.Close() },
+		Health: db.PingContext,
+	})
+}
+
+// AddHTTPClient registers an HTTP client; closing releases idle connections
+// AddHTTPClient 注册 HTTP 客户端，关闭时释放空闲连接
+func (r *Resources) AddHTTPClient(name string, client *http.Client) error {
+	if client == nil {
+		return fmt.Errorf("http client %s cannot be nil", name)
+	}
+	return r.Register(Resource{
+		Name:  name,
+		Kind:  KindHTTP,
+		Value: client,
+		Close: func(context.Context) error {
+			client.CloseIdleConnections()
+			return nil
+		},
+	})
+}
+
+// AddVectorDB registers a vector database client
+// AddVectorDB 注册向量数据库客户端
+func (r *Resources) AddVectorDB(name string, db vectordb.VectorDB) error {
+	if db == nil {
+		return fmt.Errorf("vector db %s cannot be nil", name)
+	}
+	return r.Register(Resource{
+		Name:   name,
+		Kind:   KindVectorDB,
+		Value:  db,
+		Close:  func(context.Context) error { return db.Close() },
+		Health: healthOf(db),
+	})
+}
+
+// AddEmbedder registers an embedding client; it is closed if it implements io.Closer
+// AddEmbedder 注册嵌入客户端；如果实现了 io.Closer 则会被关闭
+func (r *Resources) AddEmbedder(name string, embedder vectordb.EmbeddingFunction) error {
+	if embedder == nil {
+		return fmt.Errorf("embedder %s cannot be nil", name)
+	}
+	return r.Register(Resource{
+		Name:   name,
+		Kind:   KindEmbedder,
+		Value:  embedder,
+		Close:  closeOf(embedder),
+		Health: healthOf(embedder),
+	})
+}
+
+// Get returns the raw value registered under name
+// Get 返回以 name 注册的原始值
+func (r *Resources) Get(name string) (interface{}, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, ok := r.byName[name]
+	if !ok {
+		return nil, false
+	}
+	return entry.Value, true
+}
+
+// DB returns the database pool registered under name
+// DB 返回以 name 注册的数据库连接池
+func (r *Resources) DB(name string) (*sql.DB, bool) {
+	return lookup[*sql.DB](r, name)
+}
+
+// HTTPClient returns the HTTP client registered under name
+// HTTPClient 返回以 name 注册的 HTTP 客户端
+func (r *Resources) HTTPClient(name string) (*http.Client, bool) {
+	return lookup[*http.Client](r, name)
+}
+
+// VectorDB returns the vector database registered under name
+// VectorDB 返回以 name 注册的向量数据库
+func (r *Resources) VectorDB(name string) (vectordb.VectorDB, bool) {
+	return lookup[vectordb.VectorDB](r, name)
+}
+
+// Embedder returns the embedding client registered under name
+// Embedder 返回以 name 注册的嵌入客户端
+func (r *Resources) Embedder(name string) (vectordb.EmbeddingFunction, bool) {
+	return lookup[vectordb.EmbeddingFunction](r, name)
+}
+
+// Names returns registered resource names in registration order
+// Names 按注册顺序返回资源名称
+func (r *Resources) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.entries))
+	for _, entry := range r.entries {
+		names = append(names, entry.Name)
+	}
+	return names
+}
+
+// HealthCheck checks every resource that exposes a health probe.
+// Resources without a probe are reported healthy.
+// HealthCheck 检查所有提供健康探针的资源，没有探针的资源视为健康。
+func (r *Resources) HealthCheck(ctx context.Context) []HealthStatus {
+	r.mu.RLock()
+	entries := append([]*Resource(nil), r.entries...)
+	r.mu.RUnlock()
+
+	statuses := make([]HealthStatus, len(entries))
+	var wg sync.WaitGroup
+	for i, entry := range entries {
+		statuses[i] = HealthStatus{Name: entry.Name, Kind: entry.Kind, Healthy: true}
+		if entry.Health == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, entry *Resource) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, r.healthTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := entry.Health(checkCtx)
+			statuses[i].Latency = time.Since(start)
+			if err != nil {
+				statuses[i].Healthy = false
+				statuses[i].Error = err.Error()
+			}
+		}(i, entry)
+	}
+	wg.Wait()
+
+	sort.SliceStable(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Healthy reports whether every resource passed its health check
+// Healthy 判断所有资源是否都通过了健康检查
+func (r *Resources) Healthy(ctx context.Context) error {
+	var errs []error
+	for _, status := range r.HealthCheck(ctx) {
+		if !status.Healthy {
+			errs = append(errs, fmt.Errorf("%s: %s", status.Name, status.Error))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes all resources in reverse registration order. It is safe to
+// call more than once; later calls are no-ops.
+// Close 按注册的逆序关闭所有资源，可安全地多次调用。
+func (r *Resources) Close(ctx context.Context) error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	entries := r.entries
+	r.mu.Unlock()
+
+	var errs []error
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if entry.Close == nil {
+			continue
+		}
+
+		closeCtx, cancel := context.WithTimeout(ctx, r.closeTimeout)
+		err := entry.Close(closeCtx)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("close %s: %w", entry.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func lookup[T any](r *Resources, name string) (T, bool) {
+	var zero T
+	value, ok := r.Get(name)
+	if !ok {
+		return zero, false
+	}
+	typed, ok := value.(T)
+	if !ok {
+		return zero, false
+	}
+	return typed, true
+}
+
+// healthOf adapts values that expose Ping or HealthCheck methods
+func healthOf(value interface{}) HealthFunc {
+	switch v := value.(type) {
+	case interface{ Ping(context.Context) error }:
+		return v.Ping
+	case interface{ HealthCheck(context.Context) error }:
+		return v.HealthCheck
+	}
+	return nil
+}
+
+// closeOf adapts values that implement io.Closer
+func closeOf(value interface{}) CloseFunc {
+	if closer, ok := value.(io.Closer); ok {
+		return func(context.Context) error { return closer.Close() }
+	}
+	return nil
+}
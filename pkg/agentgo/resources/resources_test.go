@@ -0,0 +1,121 @@
+package resources
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestResources_CloseInReverseOrder(t *testing.T) {
+	r := New(Config{})
+
+	var order []string
+	for _, name := range []string{"first", "second", "third"} {
+		name := name
+		if err := r.Register(Resource{
+			Name:  name,
+			Close: func(context.Context) error { order = append(order, name); return nil },
+		}); err != nil {
+			t.Fatalf("Register(%s) error = %v", name, err)
+		}
+	}
+
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if want := []string{"third", "second", "first"}; !reflect.DeepEqual(order, want) {
+		t.Fatalf("expected close order %v, got %v", want, order)
+	}
+
+	// Second close is a no-op.
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatalf("second Close() error = %v", err)
+	}
+	if len(order) != 3 {
+		t.Fatalf("expected resources to be closed once, got %v", order)
+	}
+
+	if err := r.Register(Resource{Name: "late"}); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestResources_CloseJoinsErrors(t *testing.T) {
+	r := New(Config{})
+	boom := errors.New("boom")
+	closed := false
+
+	_ = r.Register(Resource{Name: "ok", Close: func(context.Context) error { closed = true; return nil }})
+	_ = r.Register(Resource{Name: "bad", Close: func(context.Context) error { return boom }})
+
+	err := r.Close(context.Background())
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected joined error to wrap boom, got %v", err)
+	}
+	if !closed {
+		t.Fatal("expected remaining resources to be closed after a failure")
+	}
+}
+
+func TestResources_DuplicateName(t *testing.T) {
+	r := New(Config{})
+	if err := r.Register(Resource{Name: "x"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := r.Register(Resource{Name: "x"}); !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("expected ErrDuplicate, got %v", err)
+	}
+}
+
+func TestResources_TypedAccessorsAndHealth(t *testing.T) {
+	db, err := sql.Open("sqlite", "file:resources_health?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("sql.Open failed: %v", err)
+	}
+
+	r := New(Config{})
+	if err := r.AddDB("main", db); err != nil {
+		t.Fatalf("AddDB() error = %v", err)
+	}
+	if err := r.AddHTTPClient("api", &http.Client{}); err != nil {
+		t.Fatalf("AddHTTPClient() error = %v", err)
+	}
+	_ = r.Register(Resource{
+		Name:   "flaky",
+		Health: func(context.Context) error { return errors.New("unreachable") },
+	})
+
+	if got, ok := r.DB("main"); !ok || got != db {
+		t.Fatal("expected DB accessor to return the registered pool")
+	}
+	if _, ok := r.HTTPClient("main"); ok {
+		t.Fatal("expected HTTPClient accessor to reject a DB entry")
+	}
+	if want := []string{"main", "api", "flaky"}; !reflect.DeepEqual(r.Names(), want) {
+		t.Fatalf("expected names %v, got %v", want, r.Names())
+	}
+
+	statuses := r.HealthCheck(context.Background())
+	health := make(map[string]bool)
+	for _, status := range statuses {
+		health[status.Name] = status.Healthy
+	}
+	if !health["main"] || !health["api"] || health["flaky"] {
+		t.Fatalf("unexpected health statuses %+v", statuses)
+	}
+	if err := r.Healthy(context.Background()); err == nil {
+		t.Fatal("expected Healthy to report the failing resource")
+	}
+
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := db.Ping(); err == nil {
+		t.Fatal("expected container to close the db it owns")
+	}
+}
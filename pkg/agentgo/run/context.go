@@ -4,7 +4,7 @@ import (
 	"context"
 	"sync"
 
-	"github.com/google/uuid"
+	"github.com/jholhewres/agent-go/pkg/agentgo/ids"
 )
 
 type contextKey string
@@ -63,7 +63,7 @@ func (rc *RunContext) EnsureRunID() string {
 	rc.mu.Lock()
 	defer rc.mu.Unlock()
 	if rc.RunID == "" {
-		rc.RunID = "run-" + uuid.New().String()
+		rc.RunID = ids.Prefixed("run")
 	}
 	return rc.RunID
 }
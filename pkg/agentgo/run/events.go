@@ -4,6 +4,7 @@ import "time"
 
 const (
 	EventTypeRunContent   = "run_content"
+	EventTypeRunReasoning = "run_reasoning"
 	EventTypeRunCompleted = "run_completed"
 )
 
@@ -44,6 +45,18 @@ type RunContentEvent struct {
 	Content  string `json:"content,omitempty"`
 }
 
+// RunReasoningEvent captures incremental reasoning of a reasoning model. It is
+// streamed apart from RunContentEvent so consumers can show or collapse the
+// reasoning without mixing it into the answer.
+type RunReasoningEvent struct {
+	eventBase
+	RunID    string `json:"run_id,omitempty"`
+	AgentID  string `json:"agent_id,omitempty"`
+	TeamID   string `json:"team_id,omitempty"`
+	Sequence int    `json:"sequence,omitempty"`
+	Content  string `json:"content,omitempty"`
+}
+
 // RunCompletedEvent signals that a run has reached a terminal state.
 type RunCompletedEvent struct {
 	eventBase
@@ -66,6 +79,17 @@ func NewRunContentEvent(runID, agentID, role, content string, sequence int) *Run
 	}
 }
 
+// NewRunReasoningEvent constructs a reasoning event for an agent run.
+func NewRunReasoningEvent(runID, agentID, content string, sequence int) *RunReasoningEvent {
+	return &RunReasoningEvent{
+		eventBase: eventBase{eventType: EventTypeRunReasoning, timestamp: time.Now().UTC()},
+		RunID:     runID,
+		AgentID:   agentID,
+		Sequence:  sequence,
+		Content:   content,
+	}
+}
+
 // NewTeamRunContentEvent constructs a content event emitted from a team run.
 func NewTeamRunContentEvent(runID, teamID, agentID, role, content string, sequence int) *RunContentEvent {
 
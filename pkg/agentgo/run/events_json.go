@@ -63,6 +63,15 @@ func decodeEvent(raw json.RawMessage) (BaseRunOutputEvent, error) {
 		kind = strings.ToLower(strings.TrimSpace(meta.EventType))
 	}
 	switch {
+	case strings.Contains(kind, "reasoning"):
+		var evt RunReasoningEvent
+		if err := json.Unmarshal(raw, &evt); err != nil {
+			return nil, err
+		}
+		if evt.eventBase.eventType == "" {
+			evt.eventBase.eventType = EventTypeRunReasoning
+		}
+		return &evt, nil
 	case kind == "" || strings.Contains(kind, "content"):
 		var evt RunContentEvent
 		if err := json.Unmarshal(raw, &evt); err != nil {
@@ -125,6 +134,38 @@ func (e *RunContentEvent) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// MarshalJSON serializes RunReasoningEvent with canonical metadata fields.
+func (e *RunReasoningEvent) MarshalJSON() ([]byte, error) {
+	type alias RunReasoningEvent
+	payload := struct {
+		Event     string `json:"event"`
+		CreatedAt int64  `json:"created_at"`
+		*alias
+	}{
+		Event:     canonicalEventType(e.eventBase.eventType, EventTypeRunReasoning),
+		CreatedAt: unixSeconds(e.timestamp),
+		alias:     (*alias)(e),
+	}
+	return json.Marshal(payload)
+}
+
+// UnmarshalJSON hydrates RunReasoningEvent from the serialized payload.
+func (e *RunReasoningEvent) UnmarshalJSON(data []byte) error {
+	type alias RunReasoningEvent
+	aux := struct {
+		*alias
+	}{alias: (*alias)(e)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	meta := map[string]interface{}{}
+	if err := json.Unmarshal(data, &meta); err == nil {
+		e.eventBase.eventType = extractEventKind(meta, EventTypeRunReasoning)
+		e.eventBase.timestamp = extractTimestamp(meta)
+	}
+	return nil
+}
+
 // MarshalJSON serializes RunCompletedEvent with canonical metadata fields.
 func (e *RunCompletedEvent) MarshalJSON() ([]byte, error) {
 	type alias RunCompletedEvent
@@ -9,6 +9,7 @@ func TestEventsRoundTrip(t *testing.T) {
 	events := Events{
 		NewRunContentEvent("run-1", "agent-1", "assistant", "hello", 0),
 		NewRunCompletedEvent("run-1", "agent-1", "", "completed", "done"),
+		NewRunReasoningEvent("run-1", "agent-1", "thinking", 1),
 	}
 	data, err := json.Marshal(events)
 	if err != nil {
@@ -27,6 +28,10 @@ func TestEventsRoundTrip(t *testing.T) {
 	if decoded[1].EventType() != EventTypeRunCompleted {
 		t.Fatalf("expected second event type %s, got %s", EventTypeRunCompleted, decoded[1].EventType())
 	}
+	reasoning, ok := decoded[2].(*RunReasoningEvent)
+	if !ok || reasoning.Content != "thinking" || reasoning.Sequence != 1 {
+		t.Fatalf("expected a reasoning event, got %#v", decoded[2])
+	}
 }
 
 func TestDecodeTeamEventWithoutAgentID(t *testing.T) {
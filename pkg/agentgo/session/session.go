@@ -69,10 +69,14 @@ func NewSession(sessionID string, agentID string) *Session {
 	}
 }
 
-// AddRun adds a run output to the session
+// AddRun adds a run output to the session and keeps the session state the
+// run ended with
 func (s *Session) AddRun(run *agent.RunOutput) {
 	// Simply append the new run (no deduplication since RunOutput doesn't have ID)
 	s.Runs = append(s.Runs, run)
+	if run != nil && run.SessionState != nil {
+		s.State = run.SessionState
+	}
 	s.UpdatedAt = time.Now()
 }
 
@@ -76,6 +76,12 @@ func TestSession_AddRun(t *testing.T) {
 	if len(session.Runs) != 2 {
 		t.Errorf("Expected 2 runs, got %d", len(session.Runs))
 	}
+
+	// A run carrying session state replaces the session's
+	session.AddRun(&agent.RunOutput{SessionState: map[string]interface{}{"cart": "apple"}})
+	if session.State["cart"] != "apple" {
+		t.Errorf("State = %v, want the run's session state", session.State)
+	}
 }
 
 func TestSession_GetRunCount(t *testing.T) {
@@ -3,6 +3,9 @@ package session
 import (
 	"context"
 	"errors"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/pagination"
 )
 
 var (
@@ -40,6 +43,35 @@ type Storage interface {
 	Close() error
 }
 
+// Pager is implemented by storages with native cursor-based listing
+type Pager interface {
+	// ListPage pages through sessions ordered by created_at.
+	// Filters: "agent_id", "user_id", "team_id", "workflow_id".
+	ListPage(ctx context.Context, opts pagination.ListOptions) (pagination.Page[*Session], error)
+}
+
+// ListPage pages through the sessions of any storage. Storages that do not
+// implement Pager are listed with List and paginated in memory.
+func ListPage(ctx context.Context, storage Storage, opts pagination.ListOptions) (pagination.Page[*Session], error) {
+	if pager, ok := storage.(Pager); ok {
+		return pager.ListPage(ctx, opts)
+	}
+
+	filters := make(map[string]interface{}, len(opts.Filters))
+	for key, value := range opts.Filters {
+		if value != "" {
+			filters[key] = value
+		}
+	}
+	sessions, err := storage.List(ctx, filters)
+	if err != nil {
+		return pagination.Page[*Session]{}, err
+	}
+	return pagination.Paginate(sessions, opts, sessionKey)
+}
+
+func sessionKey(s *Session) (time.Time, string) { return s.CreatedAt, s.SessionID }
+
 // ensureContext surfaces context cancellation or deadline errors before proceeding
 func ensureContext(ctx context.Context) error {
 	if ctx == nil {
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/shell"
 	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
 )
 
@@ -230,14 +231,7 @@ func (st *SkillTools) executeScript(skill *Skill, script *Script, args []string,
 }
 
 // allowedInterpreters whitelist for safe script execution
-var allowedInterpreters = map[string]bool{
-	"sh":      true,
-	"bash":    true,
-	"python":  true,
-	"python3": true,
-	"node":    true,
-	"ruby":    true,
-}
+var allowedInterpreters = shell.NewAllowlist(shell.DefaultInterpreters...)
 
 func (st *SkillTools) extractInterpreter(shebang string) string {
 	// Remove #! prefix
@@ -256,23 +250,14 @@ func (st *SkillTools) extractInterpreter(shebang string) string {
 		// Handle direct paths like /bin/bash, /usr/bin/python3
 		parts := strings.Fields(shebang)
 		if len(parts) > 0 {
-			interpreterName = filepath.Base(parts[0])
+			interpreterName = parts[0]
 		}
 	}
 
-	if interpreterName == "" {
-		return ""
-	}
-
-	// Security: Validate interpreter is in whitelist
-	if !allowedInterpreters[interpreterName] {
-		return "" // Triggers error in caller
-	}
-
-	// Security: Find interpreter in system PATH (don't trust shebang paths)
-	validPath, err := exec.LookPath(interpreterName)
+	// Security: Validate interpreter is in whitelist and find it in PATH (don't trust shebang paths)
+	validPath, err := allowedInterpreters.Resolve(interpreterName)
 	if err != nil {
-		return "" // Interpreter not found in PATH
+		return "" // Triggers error in caller
 	}
 
 	return validPath
@@ -0,0 +1,155 @@
+// Package elevenlabs implements speech.Synthesizer with the ElevenLabs
+// text-to-speech API.
+package elevenlabs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/speech"
+)
+
+const (
+	defaultBaseURL = "https://api.elevenlabs.io"
+	defaultTimeout = 45 * time.Second
+	defaultModel   = "eleven_multilingual_v2"
+	defaultFormat  = "mp3_44100_128"
+)
+
+// Config configures the ElevenLabs synthesizer
+type Config struct {
+	// APIKey is the ElevenLabs API key (required)
+	APIKey string
+
+	// VoiceID is the voice to use (required)
+	VoiceID string
+
+	// Model is the model ID (default: eleven_multilingual_v2)
+	Model string
+
+	// OutputFormat is the ElevenLabs output format, codec_samplerate_bitrate,
+	// e.g. mp3_44100_128 or pcm_16000 (default: mp3_44100_128)
+	OutputFormat string
+
+	// Stability and SimilarityBoost tune the voice, from 0 to 1 (default: the voice's settings)
+	Stability       *float64
+	SimilarityBoost *float64
+
+	// BaseURL overrides the API endpoint
+	BaseURL    string
+	HTTPClient *http.Client
+	Timeout    time.Duration
+}
+
+// Synthesizer generates speech with ElevenLabs
+type Synthesizer struct {
+	base     string
+	key      string
+	voiceID  string
+	model    string
+	format   string
+	settings map[string]float64
+	http     *http.Client
+}
+
+var _ speech.Synthesizer = (*Synthesizer)(nil)
+
+// New creates an ElevenLabs synthesizer
+func New(cfg Config) (*Synthesizer, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("elevenlabs api key is required")
+	}
+	if cfg.VoiceID == "" {
+		return nil, fmt.Errorf("elevenlabs voice id is required")
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultBaseURL
+	}
+	if cfg.Model == "" {
+		cfg.Model = defaultModel
+	}
+	if cfg.OutputFormat == "" {
+		cfg.OutputFormat = defaultFormat
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+	if cfg.Timeout > 0 {
+		httpClient.Timeout = cfg.Timeout
+	}
+
+	settings := map[string]float64{}
+	if cfg.Stability != nil {
+		settings["stability"] = *cfg.Stability
+	}
+	if cfg.SimilarityBoost != nil {
+		settings["similarity_boost"] = *cfg.SimilarityBoost
+	}
+
+	return &Synthesizer{
+		base:     strings.TrimRight(cfg.BaseURL, "/"),
+		key:      cfg.APIKey,
+		voiceID:  cfg.VoiceID,
+		model:    cfg.Model,
+		format:   cfg.OutputFormat,
+		settings: settings,
+		http:     httpClient,
+	}, nil
+}
+
+type speechRequest struct {
+	Text          string             `json:"text"`
+	ModelID       string             `json:"model_id"`
+	VoiceSettings map[string]float64 `json:"voice_settings,omitempty"`
+}
+
+// Synthesize implements speech.Synthesizer
+func (s *Synthesizer) Synthesize(ctx context.Context, text string) (*speech.Audio, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("text is empty")
+	}
+	payload, err := json.Marshal(speechRequest{Text: text, ModelID: s.model, VoiceSettings: s.settings})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode speech request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/text-to-speech/%s?output_format=%s",
+		s.base, url.PathEscape(s.voiceID), url.QueryEscape(s.format))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("xi-api-key", s.key)
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("elevenlabs request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("elevenlabs API error (%d): %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read speech audio: %w", err)
+	}
+	return &speech.Audio{Data: data, Format: audioFormat(s.format)}, nil
+}
+
+// audioFormat maps an output format such as mp3_44100_128 to its codec
+func audioFormat(outputFormat string) string {
+	codec, _, _ := strings.Cut(outputFormat, "_")
+	return codec
+}
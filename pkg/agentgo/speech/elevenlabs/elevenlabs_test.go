@@ -0,0 +1,51 @@
+package elevenlabs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSynthesize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/text-to-speech/voice-1" || r.URL.Query().Get("output_format") != "pcm_16000" {
+			t.Errorf("%s?%s", r.URL.Path, r.URL.RawQuery)
+		}
+		if r.Header.Get("xi-api-key") != "key" {
+			t.Error("missing api key header")
+		}
+		var body speechRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if body.Text != "Hello" || body.ModelID != defaultModel || body.VoiceSettings["stability"] != 0.3 {
+			t.Errorf("body = %+v", body)
+		}
+		w.Write([]byte{1, 2, 3})
+	}))
+	defer server.Close()
+
+	stability := 0.3
+	s, err := New(Config{APIKey: "key", VoiceID: "voice-1", OutputFormat: "pcm_16000", Stability: &stability, BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	audio, err := s.Synthesize(context.Background(), "Hello")
+	if err != nil {
+		t.Fatalf("Synthesize: %v", err)
+	}
+	if len(audio.Data) != 3 || audio.Format != "pcm" {
+		t.Errorf("audio = %+v", audio)
+	}
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Config{VoiceID: "voice-1"}); err == nil {
+		t.Error("expected an error without an API key")
+	}
+	if _, err := New(Config{APIKey: "key"}); err == nil {
+		t.Error("expected an error without a voice")
+	}
+}
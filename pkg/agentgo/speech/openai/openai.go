@@ -0,0 +1,281 @@
+// Package openai implements speech.Transcriber with the OpenAI transcription
+// API (Whisper) and speech.Synthesizer with the OpenAI speech API. Servers
+// compatible with them, such as Groq or a self-hosted Whisper, work through
+// BaseURL.
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/speech"
+)
+
+const (
+	defaultBaseURL  = "https://api.openai.com/v1"
+	defaultTimeout  = 2 * time.Minute
+	maxUploadSize   = 25 << 20 // the OpenAI transcription limit
+	errorBodyLength = 1024
+)
+
+// TranscriberConfig configures a Transcriber
+type TranscriberConfig struct {
+	// APIKey is sent as a bearer token (required unless HTTPClient authorizes requests)
+	APIKey string
+
+	// Model is the transcription model (default: whisper-1)
+	Model string
+
+	// Language is the ISO-639-1 language of the audio (default: auto-detect)
+	Language string
+
+	// Prompt gives spelling hints: names, products, jargon
+	Prompt string
+
+	// BaseURL overrides the API endpoint (default: https://api.openai.com/v1)
+	BaseURL    string
+	HTTPClient *http.Client
+	Timeout    time.Duration
+}
+
+// Transcriber transcribes speech with the OpenAI transcription API
+type Transcriber struct {
+	client   *client
+	model    string
+	language string
+	prompt   string
+}
+
+var _ speech.Transcriber = (*Transcriber)(nil)
+
+// NewTranscriber creates a Whisper transcriber
+func NewTranscriber(cfg TranscriberConfig) (*Transcriber, error) {
+	if cfg.APIKey == "" && cfg.HTTPClient == nil {
+		return nil, fmt.Errorf("openai api key is required")
+	}
+	if cfg.Model == "" {
+		cfg.Model = "whisper-1"
+	}
+	return &Transcriber{
+		client:   newClient(cfg.APIKey, cfg.BaseURL, cfg.HTTPClient, cfg.Timeout),
+		model:    cfg.Model,
+		language: cfg.Language,
+		prompt:   cfg.Prompt,
+	}, nil
+}
+
+type transcription struct {
+	Text     string  `json:"text"`
+	Language string  `json:"language"`
+	Duration float64 `json:"duration"`
+}
+
+// Transcribe implements speech.Transcriber
+func (t *Transcriber) Transcribe(ctx context.Context, audio *speech.Audio) (*speech.Transcript, error) {
+	if audio == nil || len(audio.Data) == 0 {
+		return nil, fmt.Errorf("audio is empty")
+	}
+	if len(audio.Data) > maxUploadSize {
+		return nil, fmt.Errorf("audio is %d bytes, above the %d byte limit", len(audio.Data), maxUploadSize)
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", audio.FileName())
+	if err != nil {
+		return nil, fmt.Errorf("failed to build transcription request: %w", err)
+	}
+	if _, err := part.Write(audio.Data); err != nil {
+		return nil, fmt.Errorf("failed to build transcription request: %w", err)
+	}
+	fields := [][2]string{
+		{"model", t.model},
+		{"response_format", "verbose_json"},
+		{"language", t.language},
+		{"prompt", t.prompt},
+	}
+	for _, field := range fields {
+		if field[1] == "" {
+			continue
+		}
+		if err := form.WriteField(field[0], field[1]); err != nil {
+			return nil, fmt.Errorf("failed to build transcription request: %w", err)
+		}
+	}
+	if err := form.Close(); err != nil {
+		return nil, fmt.Errorf("failed to build transcription request: %w", err)
+	}
+
+	resp, err := t.client.post(ctx, "/audio/transcriptions", form.FormDataContentType(), &body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result transcription
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode transcription: %w", err)
+	}
+	return &speech.Transcript{
+		Text:     strings.TrimSpace(result.Text),
+		Language: result.Language,
+		Duration: time.Duration(result.Duration * float64(time.Second)),
+	}, nil
+}
+
+// SynthesizerConfig configures a Synthesizer
+type SynthesizerConfig struct {
+	// APIKey is sent as a bearer token (required unless HTTPClient authorizes requests)
+	APIKey string
+
+	// Model is the speech model (default: tts-1)
+	Model string
+
+	// Voice is the voice to use, e.g. alloy, echo, nova, shimmer (default: alloy)
+	Voice string
+
+	// Format is the audio format: mp3, opus, aac, flac, wav or pcm (default: mp3)
+	Format string
+
+	// Speed is the speaking rate from 0.25 to 4.0 (default: 1.0)
+	Speed float64
+
+	// Instructions steer the tone of models that support it, e.g. gpt-4o-mini-tts
+	Instructions string
+
+	// BaseURL overrides the API endpoint (default: https://api.openai.com/v1)
+	BaseURL    string
+	HTTPClient *http.Client
+	Timeout    time.Duration
+}
+
+// Synthesizer generates speech with the OpenAI speech API
+type Synthesizer struct {
+	client       *client
+	model        string
+	voice        string
+	format       string
+	speed        float64
+	instructions string
+}
+
+var _ speech.Synthesizer = (*Synthesizer)(nil)
+
+// NewSynthesizer creates an OpenAI text-to-speech synthesizer
+func NewSynthesizer(cfg SynthesizerConfig) (*Synthesizer, error) {
+	if cfg.APIKey == "" && cfg.HTTPClient == nil {
+		return nil, fmt.Errorf("openai api key is required")
+	}
+	if cfg.Model == "" {
+		cfg.Model = "tts-1"
+	}
+	if cfg.Voice == "" {
+		cfg.Voice = "alloy"
+	}
+	if cfg.Format == "" {
+		cfg.Format = "mp3"
+	}
+	if cfg.Speed != 0 && (cfg.Speed < 0.25 || cfg.Speed > 4) {
+		return nil, fmt.Errorf("speed must be between 0.25 and 4.0")
+	}
+	return &Synthesizer{
+		client:       newClient(cfg.APIKey, cfg.BaseURL, cfg.HTTPClient, cfg.Timeout),
+		model:        cfg.Model,
+		voice:        cfg.Voice,
+		format:       cfg.Format,
+		speed:        cfg.Speed,
+		instructions: cfg.Instructions,
+	}, nil
+}
+
+type speechRequest struct {
+	Model          string  `json:"model"`
+	Input          string  `json:"input"`
+	Voice          string  `json:"voice"`
+	ResponseFormat string  `json:"response_format"`
+	Speed          float64 `json:"speed,omitempty"`
+	Instructions   string  `json:"instructions,omitempty"`
+}
+
+// Synthesize implements speech.Synthesizer
+func (s *Synthesizer) Synthesize(ctx context.Context, text string) (*speech.Audio, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("text is empty")
+	}
+	payload, err := json.Marshal(speechRequest{
+		Model:          s.model,
+		Input:          text,
+		Voice:          s.voice,
+		ResponseFormat: s.format,
+		Speed:          s.speed,
+		Instructions:   s.instructions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode speech request: %w", err)
+	}
+
+	resp, err := s.client.post(ctx, "/audio/speech", "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read speech audio: %w", err)
+	}
+	return &speech.Audio{Data: data, Format: s.format}, nil
+}
+
+type client struct {
+	base string
+	auth string
+	http *http.Client
+}
+
+func newClient(apiKey, baseURL string, httpClient *http.Client, timeout time.Duration) *client {
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+	if timeout > 0 {
+		httpClient.Timeout = timeout
+	}
+	c := &client{base: strings.TrimRight(baseURL, "/"), http: httpClient}
+	if apiKey != "" {
+		c.auth = "Bearer " + apiKey
+	}
+	return c
+}
+
+// post sends a request and returns the response when it succeeded
+func (c *client) post(ctx context.Context, path, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.base+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if c.auth != "" {
+		req.Header.Set("Authorization", c.auth)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("openai audio request failed: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, errorBodyLength))
+		return nil, fmt.Errorf("openai audio API error (%d): %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
@@ -0,0 +1,100 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/speech"
+)
+
+func TestTranscriber(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/transcriptions" || r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("%s %s auth=%q", r.Method, r.URL.Path, r.Header.Get("Authorization"))
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("FormFile: %v", err)
+		}
+		data, _ := io.ReadAll(file)
+		if header.Filename != "audio.wav" || string(data) != "RIFF" {
+			t.Errorf("file %s = %q", header.Filename, data)
+		}
+		if r.FormValue("model") != "whisper-1" || r.FormValue("language") != "pt" || r.FormValue("response_format") != "verbose_json" {
+			t.Errorf("form = %v", r.MultipartForm.Value)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"text": " Olá! ", "language": "portuguese", "duration": 1.5})
+	}))
+	defer server.Close()
+
+	tr, err := NewTranscriber(TranscriberConfig{APIKey: "key", Language: "pt", BaseURL: server.URL + "/v1"})
+	if err != nil {
+		t.Fatalf("NewTranscriber: %v", err)
+	}
+	transcript, err := tr.Transcribe(context.Background(), &speech.Audio{Data: []byte("RIFF"), Format: "wav"})
+	if err != nil {
+		t.Fatalf("Transcribe: %v", err)
+	}
+	if transcript.Text != "Olá!" || transcript.Language != "portuguese" || transcript.Duration != 1500*time.Millisecond {
+		t.Errorf("transcript = %+v", transcript)
+	}
+
+	if _, err := tr.Transcribe(context.Background(), &speech.Audio{}); err == nil {
+		t.Error("expected an error for empty audio")
+	}
+}
+
+func TestSynthesizer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/speech" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		var body speechRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if body.Input != "Hello" || body.Model != "tts-1" || body.Voice != "nova" || body.ResponseFormat != "opus" {
+			t.Errorf("body = %+v", body)
+		}
+		w.Write([]byte("OggS"))
+	}))
+	defer server.Close()
+
+	s, err := NewSynthesizer(SynthesizerConfig{APIKey: "key", Voice: "nova", Format: "opus", BaseURL: server.URL + "/v1"})
+	if err != nil {
+		t.Fatalf("NewSynthesizer: %v", err)
+	}
+	audio, err := s.Synthesize(context.Background(), "Hello")
+	if err != nil {
+		t.Fatalf("Synthesize: %v", err)
+	}
+	if string(audio.Data) != "OggS" || audio.Format != "opus" {
+		t.Errorf("audio = %+v", audio)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"invalid key"}}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	s, _ := NewSynthesizer(SynthesizerConfig{APIKey: "bad", BaseURL: server.URL})
+	if _, err := s.Synthesize(context.Background(), "Hello"); err == nil {
+		t.Error("expected an API error")
+	}
+}
+
+func TestConfigValidation(t *testing.T) {
+	if _, err := NewTranscriber(TranscriberConfig{}); err == nil {
+		t.Error("expected an error without an API key")
+	}
+	if _, err := NewSynthesizer(SynthesizerConfig{APIKey: "key", Speed: 8}); err == nil {
+		t.Error("expected an error for an out-of-range speed")
+	}
+}
@@ -0,0 +1,89 @@
+// Package speech defines speech-to-text and text-to-speech interfaces for
+// voice assistants. agent.RunVoice uses them to answer spoken input with
+// spoken replies.
+//
+// Providers live in sub-packages: openai (Whisper transcription and OpenAI
+// TTS, also usable with compatible servers such as Groq) and elevenlabs.
+package speech
+
+import (
+	"context"
+	"mime"
+	"strings"
+	"time"
+)
+
+// Audio is encoded audio, such as an MP3 or WAV file
+type Audio struct {
+	Data []byte
+	// Format is the container format or file extension without the dot,
+	// e.g. "mp3", "wav", "ogg", "webm"
+	Format string
+}
+
+// MIMEType returns the media type of the audio format, e.g. "audio/mpeg"
+func (a *Audio) MIMEType() string {
+	switch format := strings.ToLower(a.Format); format {
+	case "mp3", "mpeg", "mpga":
+		return "audio/mpeg"
+	case "wav":
+		return "audio/wav"
+	case "pcm":
+		return "audio/pcm"
+	case "opus", "ogg":
+		return "audio/ogg"
+	case "m4a", "mp4":
+		return "audio/mp4"
+	case "":
+		return "application/octet-stream"
+	default:
+		if t := mime.TypeByExtension("." + format); t != "" {
+			return t
+		}
+		return "audio/" + format
+	}
+}
+
+// FileName returns a file name with the audio's extension, for APIs that
+// detect the format from an uploaded file name
+func (a *Audio) FileName() string {
+	if a.Format == "" {
+		return "audio"
+	}
+	return "audio." + strings.ToLower(a.Format)
+}
+
+// Transcript is the text of transcribed speech
+type Transcript struct {
+	Text string `json:"text"`
+	// Language is the detected or requested language, when the provider reports it
+	Language string `json:"language,omitempty"`
+	// Duration is the length of the audio, when the provider reports it
+	Duration time.Duration `json:"duration,omitempty"`
+}
+
+// Transcriber turns speech into text
+type Transcriber interface {
+	Transcribe(ctx context.Context, audio *Audio) (*Transcript, error)
+}
+
+// Synthesizer turns text into speech
+type Synthesizer interface {
+	Synthesize(ctx context.Context, text string) (*Audio, error)
+}
+
+// TranscriberFunc adapts a function to the Transcriber interface
+type TranscriberFunc func(ctx context.Context, audio *Audio) (*Transcript, error)
+
+// Transcribe implements Transcriber
+func (f TranscriberFunc) Transcribe(ctx context.Context, audio *Audio) (*Transcript, error) {
+	return f(ctx, audio)
+}
+
+// SynthesizerFunc adapts a function to the Synthesizer interface
+type SynthesizerFunc func(ctx context.Context, text string) (*Audio, error)
+
+// Synthesize implements Synthesizer
+func (f SynthesizerFunc) Synthesize(ctx context.Context, text string) (*Audio, error) {
+	return f(ctx, text)
+}
@@ -0,0 +1,22 @@
+package speech
+
+import "testing"
+
+func TestAudio_MIMEType(t *testing.T) {
+	tests := map[string]string{
+		"mp3":  "audio/mpeg",
+		"WAV":  "audio/wav",
+		"opus": "audio/ogg",
+		"pcm":  "audio/pcm",
+		"":     "application/octet-stream",
+	}
+	for format, want := range tests {
+		audio := &Audio{Format: format}
+		if got := audio.MIMEType(); got != want {
+			t.Errorf("MIMEType(%q) = %q, want %q", format, got, want)
+		}
+	}
+	if name := (&Audio{Format: "MP3"}).FileName(); name != "audio.mp3" {
+		t.Errorf("FileName = %q", name)
+	}
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/pagination"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+var _ RunLister = (*MemoryStorage)(nil)
+
+// MemoryStorage is an in-process SessionStorage intended for tests and
+// single-process deployments.
+type MemoryStorage struct {
+	mu       sync.RWMutex
+	sessions map[string][]*RunRecord
+}
+
+// NewMemoryStorage creates an empty in-memory session storage.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{sessions: make(map[string][]*RunRecord)}
+}
+
+// SaveRun stores or replaces a run record.
+func (m *MemoryStorage) SaveRun(ctx context.Context, run *RunRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := ValidateRun(run); err != nil {
+		return err
+	}
+
+	copied := *run
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	runs := m.sessions[run.SessionID]
+	for i, existing := range runs {
+		if existing.RunID == run.RunID {
+			runs[i] = &copied
+			return nil
+		}
+	}
+	m.sessions[run.SessionID] = append(runs, &copied)
+	return nil
+}
+
+// GetRuns returns the last limit runs of a session.
+func (m *MemoryStorage) GetRuns(ctx context.Context, sessionID string, limit int) ([]*RunRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if sessionID == "" {
+		return nil, ErrInvalidSessionID
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	runs := m.sessions[sessionID]
+	if limit > 0 && len(runs) > limit {
+		runs = runs[len(runs)-limit:]
+	}
+	result := make([]*RunRecord, len(runs))
+	copy(result, runs)
+	return result, nil
+}
+
+// GetMessages returns the last limit messages of a session.
+func (m *MemoryStorage) GetMessages(ctx context.Context, sessionID string, limit int) ([]*types.Message, error) {
+	runs, err := m.GetRuns(ctx, sessionID, 0)
+	if err != nil {
+		return nil, err
+	}
+	return FlattenMessages(runs, limit), nil
+}
+
+// ListRuns pages through the runs of all sessions.
+func (m *MemoryStorage) ListRuns(ctx context.Context, opts pagination.ListOptions) (pagination.Page[*RunRecord], error) {
+	if err := ctx.Err(); err != nil {
+		return pagination.Page[*RunRecord]{}, err
+	}
+
+	m.mu.RLock()
+	var runs []*RunRecord
+	for _, sessionRuns := range m.sessions {
+		for _, run := range sessionRuns {
+			if MatchesRun(run, opts) {
+				runs = append(runs, run)
+			}
+		}
+	}
+	m.mu.RUnlock()
+
+	return pagination.Paginate(runs, opts, RunKey)
+}
+
+// DeleteSession removes all runs of a session.
+func (m *MemoryStorage) DeleteSession(ctx context.Context, sessionID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if sessionID == "" {
+		return ErrInvalidSessionID
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, sessionID)
+	return nil
+}
+
+// Close is a no-op for in-memory storage.
+func (m *MemoryStorage) Close() error {
+	return nil
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/pagination"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+func TestMemoryStorage_SaveAndGetRuns(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStorage()
+
+	for i, id := range []string{"run-1", "run-2", "run-3"} {
+		err := store.SaveRun(ctx, &RunRecord{
+			RunID:     id,
+			SessionID: "sess",
+			Messages:  []*types.Message{types.NewUserMessage(id)},
+			StartedAt: time.Unix(int64(i), 0),
+		})
+		if err != nil {
+			t.Fatalf("SaveRun() error = %v", err)
+		}
+	}
+
+	runs, err := store.GetRuns(ctx, "sess", 2)
+	if err != nil {
+		t.Fatalf("GetRuns() error = %v", err)
+	}
+	if len(runs) != 2 || runs[0].RunID != "run-2" || runs[1].RunID != "run-3" {
+		t.Fatalf("expected last two runs in order, got %+v", runs)
+	}
+
+	messages, err := store.GetMessages(ctx, "sess", 1)
+	if err != nil {
+		t.Fatalf("GetMessages() error = %v", err)
+	}
+	if len(messages) != 1 || messages[0].Content != "run-3" {
+		t.Fatalf("expected last message, got %+v", messages)
+	}
+}
+
+func TestMemoryStorage_ReplaceAndDelete(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStorage()
+
+	_ = store.SaveRun(ctx, &RunRecord{RunID: "r", SessionID: "s", Content: "old"})
+	_ = store.SaveRun(ctx, &RunRecord{RunID: "r", SessionID: "s", Content: "new"})
+
+	runs, _ := store.GetRuns(ctx, "s", 0)
+	if len(runs) != 1 || runs[0].Content != "new" {
+		t.Fatalf("expected replaced run, got %+v", runs)
+	}
+
+	if err := store.DeleteSession(ctx, "s"); err != nil {
+		t.Fatalf("DeleteSession() error = %v", err)
+	}
+	runs, _ = store.GetRuns(ctx, "s", 0)
+	if len(runs) != 0 {
+		t.Fatalf("expected no runs after delete, got %d", len(runs))
+	}
+}
+
+func TestMemoryStorage_Validation(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStorage()
+
+	if err := store.SaveRun(ctx, nil); !errors.Is(err, ErrInvalidRun) {
+		t.Fatalf("expected ErrInvalidRun, got %v", err)
+	}
+	if err := store.SaveRun(ctx, &RunRecord{RunID: "r"}); !errors.Is(err, ErrInvalidSessionID) {
+		t.Fatalf("expected ErrInvalidSessionID, got %v", err)
+	}
+	if _, err := store.GetRuns(ctx, "", 0); !errors.Is(err, ErrInvalidSessionID) {
+		t.Fatalf("expected ErrInvalidSessionID, got %v", err)
+	}
+}
+
+func TestMemoryStorage_ListRuns(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStorage()
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, id := range []string{"run-1", "run-2", "run-3"} {
+		status := "completed"
+		if id == "run-2" {
+			status = "failed"
+		}
+		err := store.SaveRun(ctx, &RunRecord{
+			RunID:     id,
+			SessionID: "sess-" + id,
+			Status:    status,
+			StartedAt: base.Add(time.Duration(i) * time.Minute),
+		})
+		if err != nil {
+			t.Fatalf("SaveRun() error = %v", err)
+		}
+	}
+
+	page, err := store.ListRuns(ctx, pagination.ListOptions{Limit: 2, Filters: map[string]string{"status": "completed"}})
+	if err != nil {
+		t.Fatalf("ListRuns() error = %v", err)
+	}
+	if len(page.Items) != 2 || page.Items[0].RunID != "run-3" || page.Items[1].RunID != "run-1" || page.NextCursor != "" {
+		t.Fatalf("unexpected page %+v", page)
+	}
+}
@@ -0,0 +1,291 @@
+// Package sqlite implements storage.SessionStorage on top of SQLite.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/pagination"
+	"github.com/jholhewres/agent-go/pkg/agentgo/storage"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+const (
+	defaultTable            = "agent_runs"
+	defaultOperationTimeout = 2 * time.Second
+)
+
+var identifierPattern = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
+// Config configures the SQLite session storage.
+type Config struct {
+	// Table is the runs table name (default: agent_runs).
+	Table string
+
+	// OperationTimeout bounds every query (default: 2s).
+	OperationTimeout time.Duration
+}
+
+// Storage persists agent runs in SQLite.
+type Storage struct {
+	db      *sql.DB
+	table   string
+	timeout time.Duration
+}
+
+var (
+	_ storage.SessionStorage = (*Storage)(nil)
+	_ storage.RunLister      = (*Storage)(nil)
+)
+
+// New creates the runs table if needed and returns a Storage. The caller owns
+// db; Close does not close it.
+func New(db *sql.DB, cfg Config) (*Storage, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db cannot be nil")
+	}
+
+	table := cfg.Table
+	if table == "" {
+		table = defaultTable
+	}
+	if !identifierPattern.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name: %s", table)
+	}
+
+	timeout := cfg.OperationTimeout
+	if timeout <= 0 {
+		timeout = defaultOperationTimeout
+	}
+
+	s := &Storage{db: db, table: table, timeout: timeout}
+	if err := s.ensureSchema(); err != nil {
+		return nil, fmt.Errorf("failed to create schema: %w", err)
+	}
+	return s, nil
+}
+
+func (s *Storage) ensureSchema() error {
+	stmts := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			run_id TEXT PRIMARY KEY,
+			session_id TEXT NOT NULL,
+			agent_id TEXT,
+			user_id TEXT,
+			status TEXT,
+			input TEXT,
+			content TEXT,
+			messages TEXT,
+			metadata TEXT,
+			started_at DATETIME,
+			completed_at DATETIME
+		)`, s.table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_session ON %s (session_id, started_at)`, s.table, s.table),
+	}
+	for _, stmt := range stmts {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveRun inserts or replaces a run record.
+func (s *Storage) SaveRun(ctx context.Context, run *storage.RunRecord) error {
+	if err := storage.ValidateRun(run); err != nil {
+		return err
+	}
+
+	messages, err := json.Marshal(run.Messages)
+	if err != nil {
+		return fmt.Errorf("failed to marshal messages: %w", err)
+	}
+	metadata, err := json.Marshal(run.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`INSERT INTO %s (run_id, session_id, agent_id, user_id, status, input, content,
+		messages, metadata, started_at, completed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(run_id) DO UPDATE SET
+		session_id = excluded.session_id,
+		agent_id = excluded.agent_id,
+		user_id = excluded.user_id,
+		status = excluded.status,
+		input = excluded.input,
+		content = excluded.content,
+		messages = excluded.messages,
+		metadata = excluded.metadata,
+		started_at = excluded.started_at,
+		completed_at = excluded.completed_at`, s.table)
+
+	_, err = s.db.ExecContext(ctx, query,
+		run.RunID,
+		run.SessionID,
+		run.AgentID,
+		run.UserID,
+		run.Status,
+		run.Input,
+		run.Content,
+		string(messages),
+		string(metadata),
+		run.StartedAt.UTC(),
+		run.CompletedAt.UTC(),
+	)
+	return err
+}
+
+// GetRuns returns the last limit runs of a session in chronological order.
+func (s *Storage) GetRuns(ctx context.Context, sessionID string, limit int) ([]*storage.RunRecord, error) {
+	if sessionID == "" {
+		return nil, storage.ErrInvalidSessionID
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`SELECT run_id, session_id, agent_id, user_id, status, input, content,
+		messages, metadata, started_at, completed_at
+		FROM %s WHERE session_id = ? ORDER BY started_at DESC, rowid DESC`, s.table)
+	args := []interface{}{sessionID}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs, err := scanRuns(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reverse into chronological order.
+	for i, j := 0, len(runs)-1; i < j; i, j = i+1, j-1 {
+		runs[i], runs[j] = runs[j], runs[i]
+	}
+	return runs, nil
+}
+
+// ListRuns pages through the runs of all sessions.
+func (s *Storage) ListRuns(ctx context.Context, opts pagination.ListOptions) (pagination.Page[*storage.RunRecord], error) {
+	opts, cursor, err := opts.Prepare()
+	if err != nil {
+		return pagination.Page[*storage.RunRecord]{}, err
+	}
+
+	b := pagination.NewSQLBuilder(pagination.Question)
+	for _, column := range []string{"session_id", "agent_id", "user_id", "status"} {
+		if v, ok := opts.Filter(column); ok {
+			b.Where(column+" = ?", v)
+		}
+	}
+	query := fmt.Sprintf(`SELECT run_id, session_id, agent_id, user_id, status, input, content,
+		messages, metadata, started_at, completed_at FROM %s`, s.table) + b.Clause(opts, cursor, "started_at", "run_id")
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, b.Args()...)
+	if err != nil {
+		return pagination.Page[*storage.RunRecord]{}, err
+	}
+	defer rows.Close()
+
+	runs, err := scanRuns(rows)
+	if err != nil {
+		return pagination.Page[*storage.RunRecord]{}, err
+	}
+	return pagination.Trim(runs, opts.Limit, storage.RunKey), nil
+}
+
+func scanRuns(rows *sql.Rows) ([]*storage.RunRecord, error) {
+	var runs []*storage.RunRecord
+	for rows.Next() {
+		var (
+			run                 storage.RunRecord
+			agentID, userID     sql.NullString
+			status, input       sql.NullString
+			content             sql.NullString
+			messages, metadata  sql.NullString
+			startedAt, finished sql.NullTime
+		)
+		if err := rows.Scan(&run.RunID, &run.SessionID, &agentID, &userID, &status, &input, &content,
+			&messages, &metadata, &startedAt, &finished); err != nil {
+			return nil, err
+		}
+		run.AgentID = agentID.String
+		run.UserID = userID.String
+		run.Status = status.String
+		run.Input = input.String
+		run.Content = content.String
+		run.StartedAt = startedAt.Time
+		run.CompletedAt = finished.Time
+		if messages.Valid && messages.String != "" {
+			if err := json.Unmarshal([]byte(messages.String), &run.Messages); err != nil {
+				return nil, fmt.Errorf("failed to decode messages of run %s: %w", run.RunID, err)
+			}
+		}
+		if metadata.Valid && metadata.String != "" {
+			if err := json.Unmarshal([]byte(metadata.String), &run.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to decode metadata of run %s: %w", run.RunID, err)
+			}
+		}
+		runs = append(runs, &run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// GetMessages returns the last limit messages of a session.
+func (s *Storage) GetMessages(ctx context.Context, sessionID string, limit int) ([]*types.Message, error) {
+	runs, err := s.GetRuns(ctx, sessionID, 0)
+	if err != nil {
+		return nil, err
+	}
+	return storage.FlattenMessages(runs, limit), nil
+}
+
+// DeleteSession removes all runs of a session.
+func (s *Storage) DeleteSession(ctx context.Context, sessionID string) error {
+	if sessionID == "" {
+		return storage.ErrInvalidSessionID
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE session_id = ?`, s.table), sessionID)
+	return err
+}
+
+// Close is a no-op: the database handle is owned by the caller.
+func (s *Storage) Close() error {
+	return nil
+}
+
+func (s *Storage) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= s.timeout {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, s.timeout)
+}
@@ -0,0 +1,163 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/pagination"
+	"github.com/jholhewres/agent-go/pkg/agentgo/storage"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file:"+t.Name()+"?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("sql.Open failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(nil, Config{}); err == nil {
+		t.Fatal("expected error for nil db")
+	}
+	if _, err := New(openTestDB(t), Config{Table: "bad;name"}); err == nil {
+		t.Fatal("expected error for invalid table name")
+	}
+}
+
+func TestStorage_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	store, err := New(db, Config{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, id := range []string{"run-1", "run-2"} {
+		err := store.SaveRun(ctx, &storage.RunRecord{
+			RunID:     id,
+			SessionID: "sess",
+			AgentID:   "agent",
+			Status:    "completed",
+			Input:     "in-" + id,
+			Content:   "out-" + id,
+			Messages: []*types.Message{
+				types.NewUserMessage("in-" + id),
+				types.NewAssistantMessage("out-" + id),
+			},
+			Metadata:    map[string]interface{}{"loops": i + 1},
+			StartedAt:   base.Add(time.Duration(i) * time.Minute),
+			CompletedAt: base.Add(time.Duration(i)*time.Minute + time.Second),
+		})
+		if err != nil {
+			t.Fatalf("SaveRun() error = %v", err)
+		}
+	}
+
+	runs, err := store.GetRuns(ctx, "sess", 0)
+	if err != nil {
+		t.Fatalf("GetRuns() error = %v", err)
+	}
+	if len(runs) != 2 || runs[0].RunID != "run-1" || runs[1].RunID != "run-2" {
+		t.Fatalf("expected runs in chronological order, got %+v", runs)
+	}
+	if runs[1].Metadata["loops"] != float64(2) {
+		t.Fatalf("expected metadata to round-trip, got %v", runs[1].Metadata)
+	}
+	if !runs[0].StartedAt.Equal(base) {
+		t.Fatalf("expected started_at %v, got %v", base, runs[0].StartedAt)
+	}
+
+	latest, err := store.GetRuns(ctx, "sess", 1)
+	if err != nil || len(latest) != 1 || latest[0].RunID != "run-2" {
+		t.Fatalf("expected latest run only, got %+v (err=%v)", latest, err)
+	}
+
+	messages, err := store.GetMessages(ctx, "sess", 3)
+	if err != nil {
+		t.Fatalf("GetMessages() error = %v", err)
+	}
+	if len(messages) != 3 || messages[0].Content != "out-run-1" || messages[2].Role != types.RoleAssistant {
+		t.Fatalf("unexpected messages %+v", messages)
+	}
+
+	if err := store.DeleteSession(ctx, "sess"); err != nil {
+		t.Fatalf("DeleteSession() error = %v", err)
+	}
+	runs, _ = store.GetRuns(ctx, "sess", 0)
+	if len(runs) != 0 {
+		t.Fatalf("expected no runs after delete, got %d", len(runs))
+	}
+}
+
+func TestStorage_CloseKeepsDBOpen(t *testing.T) {
+	db := openTestDB(t)
+	store, err := New(db, Config{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := db.Ping(); err != nil {
+		t.Fatalf("expected caller-owned db to stay open, got %v", err)
+	}
+}
+
+func TestStorage_ListRuns(t *testing.T) {
+	ctx := context.Background()
+	store, err := New(openTestDB(t), Config{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, id := range []string{"run-1", "run-2", "run-3", "run-4"} {
+		agentID := "agent-a"
+		if i%2 == 1 {
+			agentID = "agent-b"
+		}
+		err := store.SaveRun(ctx, &storage.RunRecord{
+			RunID:     id,
+			SessionID: "sess-" + id,
+			AgentID:   agentID,
+			Status:    "completed",
+			StartedAt: base.Add(time.Duration(i) * time.Second),
+		})
+		if err != nil {
+			t.Fatalf("SaveRun() error = %v", err)
+		}
+	}
+
+	page, err := store.ListRuns(ctx, pagination.ListOptions{Limit: 3})
+	if err != nil {
+		t.Fatalf("ListRuns() error = %v", err)
+	}
+	if len(page.Items) != 3 || page.Items[0].RunID != "run-4" || page.NextCursor == "" {
+		t.Fatalf("unexpected first page %+v", page)
+	}
+	page, err = store.ListRuns(ctx, pagination.ListOptions{Limit: 3, Cursor: page.NextCursor})
+	if err != nil {
+		t.Fatalf("ListRuns() error = %v", err)
+	}
+	if len(page.Items) != 1 || page.Items[0].RunID != "run-1" || page.NextCursor != "" {
+		t.Fatalf("unexpected last page %+v", page)
+	}
+
+	page, err = store.ListRuns(ctx, pagination.ListOptions{
+		Order:   pagination.OrderAsc,
+		Filters: map[string]string{"agent_id": "agent-b"},
+	})
+	if err != nil {
+		t.Fatalf("ListRuns() error = %v", err)
+	}
+	if len(page.Items) != 2 || page.Items[0].RunID != "run-2" || page.Items[1].RunID != "run-4" {
+		t.Fatalf("unexpected filtered page %+v", page)
+	}
+}
@@ -0,0 +1,110 @@
+// Package storage persists agent conversations so they survive process restarts.
+//
+// A SessionStorage records every completed run (input, final content, the
+// messages exchanged during the run and run metadata) keyed by session_id.
+// The agent package restores the stored messages into memory the first time
+// a session is used and appends a new record after each run.
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/pagination"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+var (
+	// ErrInvalidSessionID is returned when a session ID is empty.
+	ErrInvalidSessionID = errors.New("invalid session ID")
+
+	// ErrInvalidRun is returned when a run record is nil or has no run ID.
+	ErrInvalidRun = errors.New("invalid run record")
+)
+
+// RunRecord is the persisted form of a single agent run.
+type RunRecord struct {
+	RunID       string                 `json:"run_id"`
+	SessionID   string                 `json:"session_id"`
+	AgentID     string                 `json:"agent_id,omitempty"`
+	UserID      string                 `json:"user_id,omitempty"`
+	Status      string                 `json:"status"`
+	Input       string                 `json:"input"`
+	Content     string                 `json:"content"`
+	Messages    []*types.Message       `json:"messages,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	StartedAt   time.Time              `json:"started_at"`
+	CompletedAt time.Time              `json:"completed_at"`
+}
+
+// SessionStorage persists agent runs keyed by session ID.
+type SessionStorage interface {
+	// SaveRun stores a run record. Saving a record with an existing RunID
+	// replaces the previous record.
+	SaveRun(ctx context.Context, run *RunRecord) error
+
+	// GetRuns returns the most recent runs of a session in chronological
+	// order. limit <= 0 returns all runs.
+	GetRuns(ctx context.Context, sessionID string, limit int) ([]*RunRecord, error)
+
+	// GetMessages returns the most recent messages of a session in
+	// chronological order. limit <= 0 returns all messages.
+	GetMessages(ctx context.Context, sessionID string, limit int) ([]*types.Message, error)
+
+	// DeleteSession removes all runs of a session.
+	DeleteSession(ctx context.Context, sessionID string) error
+
+	// Close releases resources held by the storage. Implementations must not
+	// close database handles they did not open.
+	Close() error
+}
+
+// RunLister is implemented by storages that can page through runs across
+// sessions. Runs are ordered by started_at. Filters: "session_id",
+// "agent_id", "user_id", "status".
+type RunLister interface {
+	ListRuns(ctx context.Context, opts pagination.ListOptions) (pagination.Page[*RunRecord], error)
+}
+
+// RunKey returns the pagination key of a run.
+func RunKey(run *RunRecord) (time.Time, string) { return run.StartedAt, run.RunID }
+
+// MatchesRun reports whether run satisfies the RunLister filters of opts.
+func MatchesRun(run *RunRecord, opts pagination.ListOptions) bool {
+	for key, want := range map[string]string{
+		"session_id": run.SessionID,
+		"agent_id":   run.AgentID,
+		"user_id":    run.UserID,
+		"status":     run.Status,
+	} {
+		if v, ok := opts.Filter(key); ok && v != want {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateRun checks the fields every implementation requires.
+func ValidateRun(run *RunRecord) error {
+	if run == nil || run.RunID == "" {
+		return ErrInvalidRun
+	}
+	if run.SessionID == "" {
+		return ErrInvalidSessionID
+	}
+	return nil
+}
+
+// FlattenMessages concatenates the messages of runs and keeps the last limit
+// entries. limit <= 0 keeps all messages.
+func FlattenMessages(runs []*RunRecord, limit int) []*types.Message {
+	var messages []*types.Message
+	for _, run := range runs {
+		messages = append(messages, run.Messages...)
+	}
+	if limit > 0 && len(messages) > limit {
+		messages = messages[len(messages)-limit:]
+	}
+	return messages
+}
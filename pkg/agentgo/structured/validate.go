@@ -0,0 +1,346 @@
+package structured
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ValidationError lists the ways a value does not match a JSON Schema.
+type ValidationError struct {
+	Problems []string // One entry per violation, prefixed with the JSON path, e.g. "$.items[0].price: ..."
+}
+
+func (e *ValidationError) Error() string {
+	return "schema validation failed: " + strings.Join(e.Problems, "; ")
+}
+
+// ValidateJSON parses data and validates it against schema. Markdown code
+// fences around the JSON are ignored, since models often add them.
+//
+// The supported keywords are type, properties, required,
+// additionalProperties, items, enum, const, minimum, maximum,
+// exclusiveMinimum, exclusiveMaximum, minLength, maxLength, pattern,
+// minItems, maxItems, format (date-time, date, email) and anyOf, oneOf and
+// allOf. Other keywords are ignored.
+func ValidateJSON(schema map[string]interface{}, data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal([]byte(StripCodeFence(string(data))), &value); err != nil {
+		return &ValidationError{Problems: []string{"$: invalid JSON: " + err.Error()}}
+	}
+	return Validate(schema, value)
+}
+
+// Validate validates a decoded JSON value (as produced by encoding/json into
+// an interface{}) against schema.
+func Validate(schema map[string]interface{}, value interface{}) error {
+	var problems []string
+	validateValue(schema, value, "$", &problems)
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// StripCodeFence removes a surrounding ``` or ```json fence from s.
+func StripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") || !strings.HasSuffix(s, "```") || len(s) < 6 {
+		return s
+	}
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "```"), "```")
+	if i := strings.IndexByte(s, '\n'); i >= 0 && !strings.ContainsAny(s[:i], "{[\"") {
+		s = s[i+1:]
+	}
+	return strings.TrimSpace(s)
+}
+
+var dateOnly = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+var emailFormat = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+func validateValue(schema map[string]interface{}, value interface{}, path string, problems *[]string) {
+	if len(schema) == 0 {
+		return
+	}
+	add := func(format string, args ...interface{}) {
+		*problems = append(*problems, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if types := stringList(schema["type"]); len(types) > 0 {
+		matched := false
+		for _, t := range types {
+			if hasType(value, t) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			add("expected %s, got %s", strings.Join(types, " or "), jsonType(value))
+			return
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, option := range enum {
+			if jsonEqual(option, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			add("value %s is not one of %s", compactJSON(value), compactJSON(enum))
+		}
+	} else if enum, ok := schema["enum"].([]string); ok {
+		s, isString := value.(string)
+		found := false
+		for _, option := range enum {
+			if isString && option == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			add("value %s is not one of %s", compactJSON(value), compactJSON(enum))
+		}
+	}
+	if c, ok := schema["const"]; ok && !jsonEqual(c, value) {
+		add("value must be %s", compactJSON(c))
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		validateObject(schema, v, path, problems)
+	case []interface{}:
+		if n, ok := number(schema["minItems"]); ok && float64(len(v)) < n {
+			add("expected at least %v items, got %d", n, len(v))
+		}
+		if n, ok := number(schema["maxItems"]); ok && float64(len(v)) > n {
+			add("expected at most %v items, got %d", n, len(v))
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validateValue(items, item, fmt.Sprintf("%s[%d]", path, i), problems)
+			}
+		}
+	case string:
+		length := float64(utf8.RuneCountInString(v))
+		if n, ok := number(schema["minLength"]); ok && length < n {
+			add("expected at least %v characters", n)
+		}
+		if n, ok := number(schema["maxLength"]); ok && length > n {
+			add("expected at most %v characters", n)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(v) {
+				add("value %q does not match pattern %s", v, pattern)
+			}
+		}
+		if format, ok := schema["format"].(string); ok && !validFormat(format, v) {
+			add("value %q is not a valid %s", v, format)
+		}
+	case float64:
+		if n, ok := number(schema["minimum"]); ok && v < n {
+			add("value %v is below the minimum %v", v, n)
+		}
+		if n, ok := number(schema["maximum"]); ok && v > n {
+			add("value %v is above the maximum %v", v, n)
+		}
+		if n, ok := number(schema["exclusiveMinimum"]); ok && v <= n {
+			add("value %v must be greater than %v", v, n)
+		}
+		if n, ok := number(schema["exclusiveMaximum"]); ok && v >= n {
+			add("value %v must be less than %v", v, n)
+		}
+	}
+
+	for _, sub := range schemaList(schema["allOf"]) {
+		validateValue(sub, value, path, problems)
+	}
+	if anyOf := schemaList(schema["anyOf"]); len(anyOf) > 0 && countMatches(anyOf, value) == 0 {
+		add("value does not match any of the allowed schemas")
+	}
+	if oneOf := schemaList(schema["oneOf"]); len(oneOf) > 0 {
+		if n := countMatches(oneOf, value); n != 1 {
+			add("value matches %d of the oneOf schemas, expected exactly 1", n)
+		}
+	}
+}
+
+func validateObject(schema map[string]interface{}, obj map[string]interface{}, path string, problems *[]string) {
+	for _, name := range stringList(schema["required"]) {
+		if _, ok := obj[name]; !ok {
+			*problems = append(*problems, fmt.Sprintf("%s: missing required property %q", path, name))
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		childPath := path + "." + name
+		if prop, ok := properties[name].(map[string]interface{}); ok {
+			validateValue(prop, obj[name], childPath, problems)
+			continue
+		}
+		if _, declared := properties[name]; declared {
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				*problems = append(*problems, fmt.Sprintf("%s: unexpected property %q", path, name))
+			}
+		case map[string]interface{}:
+			validateValue(additional, obj[name], childPath, problems)
+		}
+	}
+}
+
+func countMatches(schemas []map[string]interface{}, value interface{}) int {
+	n := 0
+	for _, sub := range schemas {
+		var problems []string
+		validateValue(sub, value, "$", &problems)
+		if len(problems) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func hasType(value interface{}, t string) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func validFormat(format, s string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	case "date":
+		if !dateOnly.MatchString(s) {
+			return false
+		}
+		_, err := time.Parse("2006-01-02", s)
+		return err == nil
+	case "email":
+		return emailFormat.MatchString(s)
+	}
+	return true
+}
+
+// stringList reads a string or a list of strings, as found in "type" and
+// "required", from schemas built in Go or decoded from JSON.
+func stringList(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func schemaList(v interface{}) []map[string]interface{} {
+	var out []map[string]interface{}
+	switch v := v.(type) {
+	case []map[string]interface{}:
+		return v
+	case []interface{}:
+		for _, item := range v {
+			if m, ok := item.(map[string]interface{}); ok {
+				out = append(out, m)
+			}
+		}
+	}
+	return out
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// jsonEqual compares values after a JSON round trip, so Go literals in a
+// schema (e.g. int 1) equal decoded values (float64 1).
+func jsonEqual(a, b interface{}) bool {
+	return compactJSON(a) == compactJSON(b)
+}
+
+func compactJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
@@ -0,0 +1,95 @@
+package structured
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateJSON(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"name":  map[string]interface{}{"type": "string", "minLength": 1},
+			"score": map[string]interface{}{"type": "number", "minimum": 0, "maximum": 10},
+			"tags":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "maxItems": 2},
+			"level": map[string]interface{}{"enum": []interface{}{"low", "high"}},
+			"at":    map[string]interface{}{"type": "string", "format": "date-time"},
+		},
+		"required":             []string{"name", "score"},
+		"additionalProperties": false,
+	}
+
+	tests := []struct {
+		name     string
+		data     string
+		problems []string
+	}{
+		{"valid", `{"name": "a", "score": 3, "tags": ["x"], "level": "low", "at": "2026-01-02T15:04:05Z"}`, nil},
+		{"code fence", "```json\n{\"name\": \"a\", \"score\": 3}\n```", nil},
+		{"missing required", `{"name": "a"}`, []string{`$: missing required property "score"`}},
+		{"wrong type", `{"name": 1, "score": 3}`, []string{"$.name: expected string, got number"}},
+		{"range", `{"name": "a", "score": 11}`, []string{"$.score: value 11 is above the maximum 10"}},
+		{"item type", `{"name": "a", "score": 1, "tags": ["x", 2]}`, []string{"$.tags[1]: expected string, got number"}},
+		{"enum", `{"name": "a", "score": 1, "level": "mid"}`, []string{`$.level: value "mid" is not one of ["low","high"]`}},
+		{"format", `{"name": "a", "score": 1, "at": "yesterday"}`, []string{`$.at: value "yesterday" is not a valid date-time`}},
+		{"additional", `{"name": "a", "score": 1, "extra": true}`, []string{`$: unexpected property "extra"`}},
+		{"not json", `Sure! Here it is.`, []string{"$: invalid JSON"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateJSON(schema, []byte(tt.data))
+			if tt.problems == nil {
+				if err != nil {
+					t.Fatalf("ValidateJSON() error = %v", err)
+				}
+				return
+			}
+			var invalid *ValidationError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("ValidateJSON() error = %v, want a ValidationError", err)
+			}
+			if len(invalid.Problems) != len(tt.problems) {
+				t.Fatalf("problems = %q, want %q", invalid.Problems, tt.problems)
+			}
+			for i, p := range tt.problems {
+				if !strings.HasPrefix(invalid.Problems[i], p) {
+					t.Errorf("problem %d = %q, want %q", i, invalid.Problems[i], p)
+				}
+			}
+		})
+	}
+}
+
+func TestValidate_GeneratedSchema(t *testing.T) {
+	out, err := SchemaFromType(WithSlice{})
+	if err != nil {
+		t.Fatalf("SchemaFromType: %v", err)
+	}
+
+	valid := `{"tags": ["a"], "items": [{"name": "n", "score": 1.5, "count": 2, "active": true}]}`
+	if err := ValidateJSON(out.Schema, []byte(valid)); err != nil {
+		t.Errorf("ValidateJSON() error = %v", err)
+	}
+
+	invalid := `{"tags": ["a"], "items": [{"name": "n", "score": 1.5, "count": 2.5, "active": true}]}`
+	if err := ValidateJSON(out.Schema, []byte(invalid)); err == nil || !strings.Contains(err.Error(), "$.items[0].count: expected integer") {
+		t.Errorf("ValidateJSON() error = %v, want an integer error", err)
+	}
+}
+
+func TestValidate_Composition(t *testing.T) {
+	schema := map[string]interface{}{
+		"anyOf": []interface{}{
+			map[string]interface{}{"type": "string"},
+			map[string]interface{}{"type": "integer"},
+		},
+	}
+	if err := Validate(schema, "x"); err != nil {
+		t.Errorf("Validate(string) error = %v", err)
+	}
+	if err := Validate(schema, true); err == nil {
+		t.Error("Validate(bool) = nil, want an error")
+	}
+}
@@ -194,7 +194,7 @@ type AgentOutput struct {
 }
 
 // Run executes the team with the given input
-func (t *Team) Run(ctx context.Context, input string) (*RunOutput, error) {
+func (t *Team) Run(ctx context.Context, input string) (_ *RunOutput, runErr error) {
 	if input == "" {
 		return nil, types.NewInvalidInputError("input cannot be empty", nil)
 	}
@@ -237,11 +237,15 @@ func (t *Team) Run(ctx context.Context, input string) (*RunOutput, error) {
 		hookInput := hooks.NewHookInput(input).
 			WithAgentID(t.ID).
 			WithMessages([]interface{}{})
+		defer func() { hookInput.RunEnded(runErr) }()
 
 		if err := hooks.ExecuteHooks(ctx, t.PreHooks, hookInput); err != nil {
 			t.logger.Error("team pre-hook failed", "error", err)
 			return nil, types.NewInputCheckError("team pre-hook validation failed", err)
 		}
+		if hookCtx := hookInput.Context(); hookCtx != nil {
+			ctx = hookCtx
+		}
 	}
 
 	var output *RunOutput
@@ -637,6 +641,10 @@ func annotateEventsWithTeam(teamID string, events run.Events) {
 			if e.TeamID == "" {
 				e.TeamID = teamID
 			}
+		case *run.RunReasoningEvent:
+			if e.TeamID == "" {
+				e.TeamID = teamID
+			}
 		case *run.RunCompletedEvent:
 			if e.TeamID == "" {
 				e.TeamID = teamID
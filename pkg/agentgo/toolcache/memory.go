@@ -0,0 +1,67 @@
+package toolcache
+
+import (
+	"context"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+const defaultCapacity = 1024
+
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// MemoryStore is an in-process LRU store whose entries expire after their own TTL
+// MemoryStore 是进程内 LRU 存储，每个条目在各自的 TTL 后过期
+type MemoryStore struct {
+	lru *lru.Cache[string, memoryEntry]
+	now func() time.Time
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore creates a memory store holding up to capacity results (default 1024)
+// NewMemoryStore 创建最多保存 capacity 个结果的内存存储（默认 1024）
+func NewMemoryStore(capacity int) *MemoryStore {
+	if capacity <= 0 {
+		capacity = defaultCapacity
+	}
+	cache, _ := lru.New[string, memoryEntry](capacity)
+	return &MemoryStore{lru: cache, now: time.Now}
+}
+
+// Get implements Store
+// Get 实现 Store
+func (m *MemoryStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	entry, ok := m.lru.Get(key)
+	if !ok {
+		return nil, false, nil
+	}
+	if !m.now().Before(entry.expires) {
+		m.lru.Remove(key)
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+// Set implements Store
+// Set 实现 Store
+func (m *MemoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.lru.Add(key, memoryEntry{value: append([]byte(nil), value...), expires: m.now().Add(ttl)})
+	return nil
+}
+
+// Len returns the number of stored results, including expired ones not yet evicted
+// Len 返回存储的结果数量，包括尚未清除的过期结果
+func (m *MemoryStore) Len() int {
+	return m.lru.Len()
+}
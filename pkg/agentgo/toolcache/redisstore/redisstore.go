@@ -0,0 +1,76 @@
+//go:build redis
+
+// Package redisstore implements toolcache.Store on top of Redis, so cached
+// tool results are shared by every process using the same server.
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/toolcache"
+	"github.com/redis/go-redis/v9"
+)
+
+// Config for the Redis tool result store
+type Config struct {
+	// Client is an existing client to use; when nil one is created from Addr
+	Client redis.UniversalClient
+	// Addr like "localhost:6379"
+	Addr string
+	// Password optional
+	Password string
+	// DB index
+	DB int
+	// KeyPrefix is prepended to every key (default: "toolcache:")
+	KeyPrefix string
+}
+
+// Store keeps tool results as Redis strings that expire after their TTL
+type Store struct {
+	client redis.UniversalClient
+	owned  bool
+	prefix string
+}
+
+var _ toolcache.Store = (*Store)(nil)
+
+// New creates a Redis tool result store
+func New(cfg Config) *Store {
+	client, owned := cfg.Client, false
+	if client == nil {
+		client = redis.NewClient(&redis.Options{Addr: cfg.Addr, Password: cfg.Password, DB: cfg.DB})
+		owned = true
+	}
+	prefix := cfg.KeyPrefix
+	if prefix == "" {
+		prefix = "toolcache:"
+	}
+	return &Store{client: client, owned: owned, prefix: prefix}
+}
+
+// Get implements toolcache.Store
+func (s *Store) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	data, err := s.client.Get(ctx, s.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Set implements toolcache.Store
+func (s *Store) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, s.prefix+key, value, ttl).Err()
+}
+
+// Close closes the client if the store created it
+func (s *Store) Close() error {
+	if s.owned {
+		return s.client.Close()
+	}
+	return nil
+}
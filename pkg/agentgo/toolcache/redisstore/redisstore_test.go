@@ -0,0 +1,35 @@
+//go:build redis
+
+package redisstore
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestStore_Smoke(t *testing.T) {
+	if os.Getenv("TEST_REDIS_TOOL_CACHE") != "1" {
+		t.Skip("set TEST_REDIS_TOOL_CACHE=1 to run redis tool cache test")
+	}
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+	store := New(Config{Addr: addr, KeyPrefix: "test-toolcache:"})
+	defer store.Close()
+
+	ctx := context.Background()
+	defer store.client.Del(ctx, "test-toolcache:k")
+	if err := store.Set(ctx, "k", []byte(`{"temp":21}`), time.Minute); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	got, ok, err := store.Get(ctx, "k")
+	if err != nil || !ok || string(got) != `{"temp":21}` {
+		t.Fatalf("get = %s, %v, %v", got, ok, err)
+	}
+	if _, ok, err := store.Get(ctx, "missing"); err != nil || ok {
+		t.Fatalf("get missing = %v, %v", ok, err)
+	}
+}
@@ -0,0 +1,160 @@
+// Package toolcache caches tool results, so repeated calls with the same
+// arguments (e.g. the weather for the same city within a minute) skip
+// execution. A Hook enforces the cache as a tool hook; only the tools it is
+// configured with are cached.
+// Package toolcache 缓存工具结果，使相同参数的重复调用（例如一分钟内查询同一城市的天气）跳过执行。
+// Hook 以工具钩子的形式使用缓存；只有配置的工具才会被缓存。
+package toolcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/hooks"
+)
+
+// Store keeps encoded tool results. Implementations must be safe for concurrent use.
+// Store 保存编码后的工具结果。实现必须可安全并发使用。
+type Store interface {
+	// Get returns the value stored under key
+	// Get 返回 key 下存储的值
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key for ttl
+	// Set 在 key 下存储 value，有效期为 ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// Config configures the cache hook
+// Config 配置缓存钩子
+type Config struct {
+	// Store holds the results (default: a MemoryStore with default capacity)
+	// Store 保存结果（默认：默认容量的 MemoryStore）
+	Store Store
+	// Tools are the names of the tools to cache and their TTL; a zero TTL uses TTL
+	// Tools 是要缓存的工具名称及其 TTL；TTL 为零时使用 TTL 字段
+	Tools map[string]time.Duration
+	// TTL is the default time to keep a result (default: 1 minute)
+	// TTL 是结果的默认保留时间（默认：1 分钟）
+	TTL time.Duration
+	// PerUser keeps separate results per user, for tools whose answer depends on the caller
+	// PerUser 为每个用户分别保存结果，适用于结果取决于调用者的工具
+	PerUser bool
+}
+
+const defaultTTL = time.Minute
+
+// MetadataKey is the tool hook metadata key holding "hit" or "miss"
+// MetadataKey 是保存 "hit" 或 "miss" 的工具钩子元数据键
+const MetadataKey = "tool_cache"
+
+// Hook serves cached results for the configured tools and stores successful
+// results. Store errors never block a tool call; they count as a miss.
+// Hook 为配置的工具提供缓存结果并存储成功的结果。存储错误不会阻止工具调用，只视为未命中。
+type Hook struct {
+	store   Store
+	tools   map[string]time.Duration
+	perUser bool
+}
+
+var _ hooks.ToolHooker = (*Hook)(nil)
+
+// NewHook creates a cache hook
+// NewHook 创建缓存钩子
+func NewHook(config Config) (*Hook, error) {
+	if len(config.Tools) == 0 {
+		return nil, fmt.Errorf("at least one tool to cache is required")
+	}
+	if config.TTL < 0 {
+		return nil, fmt.Errorf("ttl must not be negative")
+	}
+	ttl := config.TTL
+	if ttl == 0 {
+		ttl = defaultTTL
+	}
+
+	tools := make(map[string]time.Duration, len(config.Tools))
+	for name, toolTTL := range config.Tools {
+		if toolTTL < 0 {
+			return nil, fmt.Errorf("tool %s: ttl must not be negative", name)
+		}
+		if toolTTL == 0 {
+			toolTTL = ttl
+		}
+		tools[name] = toolTTL
+	}
+
+	store := config.Store
+	if store == nil {
+		store = NewMemoryStore(0)
+	}
+	return &Hook{store: store, tools: tools, perUser: config.PerUser}, nil
+}
+
+// OnToolPre skips the tool call when a cached result exists
+// OnToolPre 在存在缓存结果时跳过工具调用
+func (h *Hook) OnToolPre(ctx context.Context, input *hooks.ToolHookInput) error {
+	if _, ok := h.tools[input.FunctionName]; !ok {
+		return nil
+	}
+	key, err := h.key(input)
+	if err != nil {
+		return nil
+	}
+	data, ok, err := h.store.Get(ctx, key)
+	if err != nil || !ok {
+		input.SetMetadata(MetadataKey, "miss")
+		return nil
+	}
+	var result interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		input.SetMetadata(MetadataKey, "miss")
+		return nil
+	}
+	input.SetMetadata(MetadataKey, "hit")
+	input.SkipExecution(result)
+	return nil
+}
+
+// OnToolPost stores successful results of cached tools
+// OnToolPost 存储被缓存工具的成功结果
+func (h *Hook) OnToolPost(ctx context.Context, input *hooks.ToolHookInput) error {
+	ttl, ok := h.tools[input.FunctionName]
+	if !ok || input.ResultError != nil {
+		return nil
+	}
+	if _, skipped := input.SkippedResult(); skipped {
+		return nil
+	}
+	key, err := h.key(input)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(input.Result)
+	if err != nil {
+		return fmt.Errorf("tool cache: failed to encode result of %s: %w", input.FunctionName, err)
+	}
+	if err := h.store.Set(ctx, key, data, ttl); err != nil {
+		return fmt.Errorf("tool cache: %w", err)
+	}
+	return nil
+}
+
+// key is the function name (and user) plus a hash of the arguments. The
+// arguments are encoded as JSON, which sorts object keys, so equal arguments
+// give the same key regardless of order.
+func (h *Hook) key(input *hooks.ToolHookInput) (string, error) {
+	args, err := json.Marshal(input.Arguments)
+	if err != nil {
+		return "", fmt.Errorf("tool cache: failed to encode arguments of %s: %w", input.FunctionName, err)
+	}
+	sum := sha256.Sum256(args)
+	key := input.FunctionName + ":" + hex.EncodeToString(sum[:])
+	if h.perUser {
+		key = input.UserID + ":" + key
+	}
+	return key, nil
+}
@@ -0,0 +1,133 @@
+package toolcache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/hooks"
+)
+
+// call runs a tool call through the hook the way the agent does and reports
+// whether the tool was executed.
+func call(t *testing.T, h *Hook, user, tool string, args map[string]interface{}, result interface{}) (interface{}, bool) {
+	t.Helper()
+	ctx := context.Background()
+	input := hooks.NewToolHookInput("agent", "call", tool, args).WithUserID(user)
+	if err := h.OnToolPre(ctx, input); err != nil {
+		t.Fatalf("OnToolPre: %v", err)
+	}
+	cached, skipped := input.SkippedResult()
+	if skipped {
+		result = cached
+	}
+	input.WithResult(result, nil)
+	if err := h.OnToolPost(ctx, input); err != nil {
+		t.Fatalf("OnToolPost: %v", err)
+	}
+	return result, !skipped
+}
+
+func TestHook_CachesByCanonicalArguments(t *testing.T) {
+	h, err := NewHook(Config{Tools: map[string]time.Duration{"weather": 0}})
+	if err != nil {
+		t.Fatalf("NewHook: %v", err)
+	}
+
+	args := map[string]interface{}{"city": "Lisbon", "units": map[string]interface{}{"temp": "c", "wind": "kmh"}}
+	if _, ran := call(t, h, "u1", "weather", args, map[string]interface{}{"temp": 21}); !ran {
+		t.Fatal("first call was served from the cache")
+	}
+
+	// Same arguments built in another order hit the cache.
+	same := map[string]interface{}{"units": map[string]interface{}{"wind": "kmh", "temp": "c"}, "city": "Lisbon"}
+	got, ran := call(t, h, "u2", "weather", same, nil)
+	if ran {
+		t.Fatal("identical call was executed again")
+	}
+	if m, ok := got.(map[string]interface{}); !ok || m["temp"] != float64(21) {
+		t.Errorf("cached result = %#v", got)
+	}
+
+	if _, ran := call(t, h, "u1", "weather", map[string]interface{}{"city": "Porto"}, "sunny"); !ran {
+		t.Error("call with other arguments was served from the cache")
+	}
+	if _, ran := call(t, h, "u1", "delete_file", map[string]interface{}{"path": "a"}, "ok"); !ran {
+		t.Error("tool without caching was not executed")
+	}
+	if _, ran := call(t, h, "u1", "delete_file", map[string]interface{}{"path": "a"}, "ok"); !ran {
+		t.Error("tool without caching was served from the cache")
+	}
+}
+
+func TestHook_PerUser(t *testing.T) {
+	h, err := NewHook(Config{Tools: map[string]time.Duration{"inbox": time.Minute}, PerUser: true})
+	if err != nil {
+		t.Fatalf("NewHook: %v", err)
+	}
+	args := map[string]interface{}{"folder": "main"}
+	call(t, h, "alice", "inbox", args, "alice's mail")
+	if _, ran := call(t, h, "bob", "inbox", args, "bob's mail"); !ran {
+		t.Error("bob got alice's cached result")
+	}
+	if got, ran := call(t, h, "alice", "inbox", args, nil); ran || got != "alice's mail" {
+		t.Errorf("alice: result = %v, executed = %v", got, ran)
+	}
+}
+
+func TestHook_FailuresAreNotCached(t *testing.T) {
+	h, err := NewHook(Config{Tools: map[string]time.Duration{"weather": 0}})
+	if err != nil {
+		t.Fatalf("NewHook: %v", err)
+	}
+	ctx := context.Background()
+	args := map[string]interface{}{"city": "Lisbon"}
+
+	input := hooks.NewToolHookInput("agent", "call", "weather", args)
+	if err := h.OnToolPre(ctx, input); err != nil {
+		t.Fatalf("OnToolPre: %v", err)
+	}
+	input.WithResult(nil, errors.New("upstream unavailable"))
+	if err := h.OnToolPost(ctx, input); err != nil {
+		t.Fatalf("OnToolPost: %v", err)
+	}
+
+	if _, ran := call(t, h, "", "weather", args, "sunny"); !ran {
+		t.Error("failed call was cached")
+	}
+}
+
+func TestMemoryStore_PerEntryTTL(t *testing.T) {
+	store := NewMemoryStore(2)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	store.Set(ctx, "short", []byte("1"), time.Second)
+	store.Set(ctx, "long", []byte("2"), time.Hour)
+	now = now.Add(time.Minute)
+
+	if _, ok, _ := store.Get(ctx, "short"); ok {
+		t.Error("expired entry was returned")
+	}
+	if v, ok, _ := store.Get(ctx, "long"); !ok || string(v) != "2" {
+		t.Errorf("long = %s, %v", v, ok)
+	}
+
+	// Capacity evicts the least recently used entry.
+	store.Set(ctx, "a", []byte("3"), time.Hour)
+	store.Set(ctx, "b", []byte("4"), time.Hour)
+	if _, ok, _ := store.Get(ctx, "long"); ok {
+		t.Error("least recently used entry was not evicted")
+	}
+}
+
+func TestNewHook_Validation(t *testing.T) {
+	if _, err := NewHook(Config{}); err == nil {
+		t.Error("expected an error without tools")
+	}
+	if _, err := NewHook(Config{Tools: map[string]time.Duration{"weather": -time.Second}}); err == nil {
+		t.Error("expected an error for a negative tool ttl")
+	}
+}
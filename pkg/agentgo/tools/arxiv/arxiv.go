@@ -19,6 +19,10 @@ type ArXivToolkit struct {
 	*toolkit.BaseToolkit
 }
 
+func init() {
+	toolkit.MustRegister("arxiv", toolkit.NoOptions(New))
+}
+
 // New creates a new ArXiv toolkit
 func New() *ArXivToolkit {
 	t := &ArXivToolkit{
@@ -0,0 +1,367 @@
+// Package bgtask lets tools that take minutes (report generation, builds,
+// exports) run in the background. The tool call returns a task handle right
+// away and the model polls it with companion tools instead of blocking its turn.
+package bgtask
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/ids"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+)
+
+// Status is the lifecycle state of a background task
+type Status string
+
+const (
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+)
+
+// Done reports whether the status is terminal
+func (s Status) Done() bool {
+	return s != StatusRunning
+}
+
+// Reporter lets a running task publish progress (0-1) and a status message
+type Reporter func(progress float64, message string)
+
+// Handler is a long-running tool handler. ctx outlives the tool call that
+// started the task and is cancelled by cancel_task or Close.
+type Handler func(ctx context.Context, args map[string]interface{}, report Reporter) (interface{}, error)
+
+// Task is a snapshot of a background task
+type Task struct {
+	ID         string      `json:"task_id"`
+	Tool       string      `json:"tool"`
+	Status     Status      `json:"status"`
+	Progress   float64     `json:"progress,omitempty"`
+	Message    string      `json:"message,omitempty"`
+	Result     interface{} `json:"result,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	StartedAt  time.Time   `json:"started_at"`
+	FinishedAt *time.Time  `json:"finished_at,omitempty"`
+}
+
+// Config configures a BackgroundToolkit
+type Config struct {
+	InlineWait time.Duration // Wait for a result before returning a handle (default: 2s, negative = return immediately)
+	MaxWait    time.Duration // Cap on a single wait_for_task call (default: 60s)
+	Retention  time.Duration // How long finished tasks stay queryable (default: 1h)
+}
+
+type task struct {
+	Task
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// BackgroundToolkit runs long tools in the background and exposes
+// check_task_status, wait_for_task and cancel_task to poll them.
+type BackgroundToolkit struct {
+	*toolkit.BaseToolkit
+	config Config
+
+	mu    sync.Mutex
+	tasks map[string]*task
+}
+
+// New creates a background task toolkit
+func New(config Config) *BackgroundToolkit {
+	if config.InlineWait == 0 {
+		config.InlineWait = 2 * time.Second
+	}
+	if config.MaxWait <= 0 {
+		config.MaxWait = 60 * time.Second
+	}
+	if config.Retention <= 0 {
+		config.Retention = time.Hour
+	}
+
+	t := &BackgroundToolkit{
+		BaseToolkit: toolkit.NewBaseToolkit("background_tasks"),
+		config:      config,
+		tasks:       make(map[string]*task),
+	}
+
+	taskIDParam := toolkit.Parameter{
+		Type:        "string",
+		Description: "The task_id returned when the task was started",
+		Required:    true,
+	}
+
+	t.RegisterFunction(&toolkit.Function{
+		Name:        "check_task_status",
+		Description: "Check the status of a background task. Returns its progress, or its result once it has finished.",
+		Parameters:  map[string]toolkit.Parameter{"task_id": taskIDParam},
+		Handler:     t.checkStatus,
+	})
+
+	t.RegisterFunction(&toolkit.Function{
+		Name:        "wait_for_task",
+		Description: "Wait for a background task to finish, up to a timeout, and return its status or result.",
+		Parameters: map[string]toolkit.Parameter{
+			"task_id": taskIDParam,
+			"timeout_seconds": {
+				Type:        "integer",
+				Description: fmt.Sprintf("How long to wait (max %d)", int(config.MaxWait/time.Second)),
+			},
+		},
+		Handler: t.wait,
+	})
+
+	t.RegisterFunction(&toolkit.Function{
+		Name:        "cancel_task",
+		Description: "Cancel a running background task.",
+		Parameters:  map[string]toolkit.Parameter{"task_id": taskIDParam},
+		Handler:     t.cancelTask,
+	})
+
+	return t
+}
+
+// RegisterBackground registers fn as a background tool in this toolkit. The
+// function's Handler is replaced by one that starts handler as a task.
+func (t *BackgroundToolkit) RegisterBackground(fn *toolkit.Function, handler Handler) {
+	fn.Handler = t.Wrap(fn.Name, handler)
+	t.RegisterFunction(fn)
+}
+
+// Wrap returns a tool handler that runs handler in the background. Use it for
+// functions registered in other toolkits; the model polls them through this
+// toolkit's companion tools, so both toolkits must be given to the agent.
+//
+// If the task finishes within InlineWait its result (or error) is returned
+// directly. Otherwise the handler returns the task status with its task_id.
+func (t *BackgroundToolkit) Wrap(toolName string, handler Handler) toolkit.HandlerFunc {
+	return func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		tk := t.start(ctx, toolName, args, handler)
+		if t.config.InlineWait > 0 {
+			timer := time.NewTimer(t.config.InlineWait)
+			defer timer.Stop()
+			select {
+			case <-tk.done:
+				snap := t.snapshot(tk)
+				if snap.Status == StatusFailed {
+					return nil, fmt.Errorf("%s", snap.Error)
+				}
+				if snap.Status == StatusCompleted {
+					return snap.Result, nil
+				}
+			case <-timer.C:
+			case <-ctx.Done():
+			}
+		}
+		return statusResponse(t.snapshot(tk)), nil
+	}
+}
+
+// Get returns a snapshot of the task
+func (t *BackgroundToolkit) Get(taskID string) (Task, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tk, ok := t.tasks[taskID]
+	if !ok {
+		return Task{}, false
+	}
+	return tk.Task, true
+}
+
+// List returns snapshots of all known tasks, oldest first
+func (t *BackgroundToolkit) List() []Task {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]Task, 0, len(t.tasks))
+	for _, tk := range t.tasks {
+		out = append(out, tk.Task)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].StartedAt.Before(out[j].StartedAt) })
+	return out
+}
+
+// Close cancels all running tasks
+func (t *BackgroundToolkit) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, tk := range t.tasks {
+		if !tk.Status.Done() {
+			tk.cancel()
+		}
+	}
+}
+
+func (t *BackgroundToolkit) start(ctx context.Context, toolName string, args map[string]interface{}, handler Handler) *task {
+	// The task must survive the tool call, but keeps the caller's values.
+	taskCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	tk := &task{
+		Task: Task{
+			ID:        ids.Prefixed("task"),
+			Tool:      toolName,
+			Status:    StatusRunning,
+			StartedAt: time.Now().UTC(),
+		},
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	t.mu.Lock()
+	t.pruneLocked()
+	t.tasks[tk.ID] = tk
+	t.mu.Unlock()
+
+	report := func(progress float64, message string) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if tk.Status.Done() {
+			return
+		}
+		if progress < 0 {
+			progress = 0
+		} else if progress > 1 {
+			progress = 1
+		}
+		tk.Progress = progress
+		tk.Message = message
+	}
+
+	go func() {
+		defer cancel()
+		result, err := runHandler(taskCtx, handler, args, report)
+
+		t.mu.Lock()
+		now := time.Now().UTC()
+		tk.FinishedAt = &now
+		switch {
+		case tk.Status == StatusCancelled:
+		case err != nil && taskCtx.Err() != nil:
+			tk.Status = StatusCancelled
+			tk.Error = err.Error()
+		case err != nil:
+			tk.Status = StatusFailed
+			tk.Error = err.Error()
+		default:
+			tk.Status = StatusCompleted
+			tk.Progress = 1
+			tk.Result = result
+		}
+		t.mu.Unlock()
+		close(tk.done)
+	}()
+
+	return tk
+}
+
+func runHandler(ctx context.Context, handler Handler, args map[string]interface{}, report Reporter) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task panicked: %v", r)
+		}
+	}()
+	return handler(ctx, args, report)
+}
+
+// pruneLocked drops finished tasks older than the retention window.
+func (t *BackgroundToolkit) pruneLocked() {
+	cutoff := time.Now().Add(-t.config.Retention)
+	for id, tk := range t.tasks {
+		if tk.FinishedAt != nil && tk.FinishedAt.Before(cutoff) {
+			delete(t.tasks, id)
+		}
+	}
+}
+
+func (t *BackgroundToolkit) snapshot(tk *task) Task {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return tk.Task
+}
+
+func (t *BackgroundToolkit) lookup(args map[string]interface{}) (*task, error) {
+	id, _ := args["task_id"].(string)
+	if id == "" {
+		return nil, fmt.Errorf("task_id is required")
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tk, ok := t.tasks[id]
+	if !ok {
+		return nil, fmt.Errorf("task %s not found", id)
+	}
+	return tk, nil
+}
+
+func (t *BackgroundToolkit) checkStatus(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	tk, err := t.lookup(args)
+	if err != nil {
+		return nil, err
+	}
+	return statusResponse(t.snapshot(tk)), nil
+}
+
+func (t *BackgroundToolkit) wait(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	tk, err := t.lookup(args)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := t.config.MaxWait
+	if secs, ok := args["timeout_seconds"].(float64); ok && secs > 0 {
+		if d := time.Duration(secs * float64(time.Second)); d < timeout {
+			timeout = d
+		}
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-tk.done:
+	case <-timer.C:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return statusResponse(t.snapshot(tk)), nil
+}
+
+func (t *BackgroundToolkit) cancelTask(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	tk, err := t.lookup(args)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	if !tk.Status.Done() {
+		tk.Status = StatusCancelled
+		tk.Error = "task cancelled"
+		tk.cancel()
+	}
+	t.mu.Unlock()
+
+	return statusResponse(t.snapshot(tk)), nil
+}
+
+func statusResponse(task Task) map[string]interface{} {
+	resp := map[string]interface{}{
+		"task_id": task.ID,
+		"tool":    task.Tool,
+		"status":  task.Status,
+	}
+	switch task.Status {
+	case StatusRunning:
+		resp["progress"] = task.Progress
+		if task.Message != "" {
+			resp["message"] = task.Message
+		}
+		resp["next_step"] = "The task is still running. Call wait_for_task or check_task_status with this task_id to get the result."
+	case StatusCompleted:
+		resp["result"] = task.Result
+	default:
+		resp["error"] = task.Error
+	}
+	return resp
+}
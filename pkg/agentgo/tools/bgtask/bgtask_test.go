@@ -0,0 +1,112 @@
+package bgtask
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+)
+
+func TestBackgroundToolkit_FastTaskReturnsInline(t *testing.T) {
+	tk := New(Config{InlineWait: time.Second})
+	tk.RegisterBackground(&toolkit.Function{Name: "quick"}, func(ctx context.Context, args map[string]interface{}, report Reporter) (interface{}, error) {
+		return "done", nil
+	})
+
+	result, err := tk.Execute(context.Background(), "quick", nil)
+	if err != nil || result != "done" {
+		t.Fatalf("Execute() = %v, %v", result, err)
+	}
+
+	failing := tk.Wrap("broken", func(ctx context.Context, args map[string]interface{}, report Reporter) (interface{}, error) {
+		return nil, errors.New("boom")
+	})
+	if _, err := failing(context.Background(), nil); err == nil || err.Error() != "boom" {
+		t.Fatalf("expected inline error, got %v", err)
+	}
+}
+
+func TestBackgroundToolkit_SlowTaskReturnsHandleAndCanBeAwaited(t *testing.T) {
+	tk := New(Config{InlineWait: -1})
+	release := make(chan struct{})
+	tk.RegisterBackground(&toolkit.Function{Name: "build"}, func(ctx context.Context, args map[string]interface{}, report Reporter) (interface{}, error) {
+		report(0.5, "compiling")
+		<-release
+		return map[string]interface{}{"artifact": args["target"]}, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out, err := tk.Execute(ctx, "build", map[string]interface{}{"target": "app"})
+	cancel() // the task must outlive the tool call
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	resp := out.(map[string]interface{})
+	if resp["status"] != StatusRunning {
+		t.Fatalf("expected running handle, got %v", resp)
+	}
+	id := resp["task_id"].(string)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		status, err := tk.Execute(context.Background(), "check_task_status", map[string]interface{}{"task_id": id})
+		if err != nil {
+			t.Fatalf("check_task_status error = %v", err)
+		}
+		if status.(map[string]interface{})["message"] == "compiling" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("progress not reported: %v", status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	close(release)
+	waited, err := tk.Execute(context.Background(), "wait_for_task", map[string]interface{}{"task_id": id, "timeout_seconds": float64(1)})
+	if err != nil {
+		t.Fatalf("wait_for_task error = %v", err)
+	}
+	final := waited.(map[string]interface{})
+	if final["status"] != StatusCompleted {
+		t.Fatalf("expected completed, got %v", final)
+	}
+	if final["result"].(map[string]interface{})["artifact"] != "app" {
+		t.Fatalf("unexpected result %v", final["result"])
+	}
+}
+
+func TestBackgroundToolkit_CancelTask(t *testing.T) {
+	tk := New(Config{InlineWait: -1})
+	stopped := make(chan struct{})
+	run := tk.Wrap("export", func(ctx context.Context, args map[string]interface{}, report Reporter) (interface{}, error) {
+		<-ctx.Done()
+		close(stopped)
+		return nil, ctx.Err()
+	})
+
+	out, _ := run(context.Background(), nil)
+	id := out.(map[string]interface{})["task_id"].(string)
+
+	res, err := tk.Execute(context.Background(), "cancel_task", map[string]interface{}{"task_id": id})
+	if err != nil {
+		t.Fatalf("cancel_task error = %v", err)
+	}
+	if res.(map[string]interface{})["status"] != StatusCancelled {
+		t.Fatalf("expected cancelled, got %v", res)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("task context was not cancelled")
+	}
+
+	if _, err := tk.Execute(context.Background(), "check_task_status", map[string]interface{}{"task_id": "missing"}); err == nil {
+		t.Fatal("expected error for unknown task")
+	}
+	if got := tk.List(); len(got) != 1 || got[0].ID != id {
+		t.Fatalf("List() = %v", got)
+	}
+}
@@ -12,6 +12,10 @@ type CalculatorToolkit struct {
 	*toolkit.BaseToolkit
 }
 
+func init() {
+	toolkit.MustRegister("calculator", toolkit.NoOptions(New))
+}
+
 // New creates a new calculator toolkit
 func New() *CalculatorToolkit {
 	t := &CalculatorToolkit{
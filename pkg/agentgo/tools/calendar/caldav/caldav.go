@@ -0,0 +1,283 @@
+// Package caldav implements calendar.Provider for CalDAV servers (RFC 4791),
+// such as Nextcloud, iCloud, Fastmail and Radicale.
+package caldav
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/calendar"
+)
+
+const (
+	defaultTimeout = 30 * time.Second
+	utcLayout      = "20060102T150405Z"
+)
+
+// Config configures the CalDAV provider
+type Config struct {
+	// URL is the calendar collection, e.g.
+	// https://cloud.example.com/remote.php/dav/calendars/alice/personal/
+	URL string
+	// Username and Password use HTTP basic auth (app passwords for iCloud
+	// and Fastmail)
+	Username   string
+	Password   string
+	HTTPClient *http.Client
+	Timeout    time.Duration
+}
+
+// Provider reads and writes a CalDAV calendar collection
+type Provider struct {
+	url      string
+	username string
+	password string
+	http     *http.Client
+	now      func() time.Time
+}
+
+var (
+	_ calendar.Provider     = (*Provider)(nil)
+	_ calendar.EventUpdater = (*Provider)(nil)
+)
+
+// New creates a CalDAV provider
+func New(cfg Config) (*Provider, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("caldav calendar URL is required")
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+	if cfg.Timeout > 0 {
+		httpClient.Timeout = cfg.Timeout
+	}
+
+	return &Provider{
+		url:      strings.TrimRight(cfg.URL, "/") + "/",
+		username: cfg.Username,
+		password: cfg.Password,
+		http:     httpClient,
+		now:      time.Now,
+	}, nil
+}
+
+type multistatus struct {
+	Responses []struct {
+		Href      string `xml:"href"`
+		Propstats []struct {
+			Status string `xml:"status"`
+			Prop   struct {
+				GetETag      string `xml:"getetag"`
+				CalendarData string `xml:"calendar-data"`
+			} `xml:"prop"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+// ListEvents implements calendar.Provider. The server expands recurring
+// events into the instances within the range.
+func (p *Provider) ListEvents(ctx context.Context, start, end time.Time) ([]calendar.Event, error) {
+	from, to := start.UTC().Format(utcLayout), end.UTC().Format(utcLayout)
+	body := fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop>
+    <C:calendar-data><C:expand start="%s" end="%s"/></C:calendar-data>
+  </D:prop>
+  <C:filter>
+    <C:comp-filter name="VCALENDAR">
+      <C:comp-filter name="VEVENT">
+        <C:time-range start="%s" end="%s"/>
+      </C:comp-filter>
+    </C:comp-filter>
+  </C:filter>
+</C:calendar-query>`, from, to, from, to)
+
+	resp, err := p.do(ctx, "REPORT", p.url, "application/xml; charset=utf-8", body, map[string]string{"Depth": "1"})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusMultiStatus && resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+
+	var result multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode caldav response: %w", err)
+	}
+
+	var events []calendar.Event
+	for _, r := range result.Responses {
+		for _, ps := range r.Propstats {
+			if ps.Prop.CalendarData == "" || (ps.Status != "" && !strings.Contains(ps.Status, " 200 ")) {
+				continue
+			}
+			parsed, err := parseICS(ps.Prop.CalendarData)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", r.Href, err)
+			}
+			for _, event := range parsed {
+				if event.End.After(start) && event.Start.Before(end) {
+					events = append(events, event)
+				}
+			}
+		}
+	}
+	return events, nil
+}
+
+// CreateEvent implements calendar.Provider. The event is stored as
+// <uid>.ics in the collection.
+func (p *Provider) CreateEvent(ctx context.Context, event calendar.Event) (*calendar.Event, error) {
+	if event.ID == "" {
+		id, err := newUID()
+		if err != nil {
+			return nil, err
+		}
+		event.ID = id
+	}
+
+	resp, err := p.do(ctx, http.MethodPut, p.url+event.ID+".ics", "text/calendar; charset=utf-8",
+		formatICS(event, p.now()), map[string]string{"If-None-Match": "*"})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, statusError(resp)
+	}
+	return &event, nil
+}
+
+// UpdateEvent implements calendar.EventUpdater. It edits the stored
+// iCalendar object in place, so recurrence rules, alarms and other
+// properties the toolkit does not know about are kept. The write is
+// conditional on the ETag, failing if the event changed in the meantime.
+func (p *Provider) UpdateEvent(ctx context.Context, id string, update calendar.EventUpdate) (*calendar.Event, error) {
+	href, etag, data, err := p.findEvent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	patched, event, err := patchICS(data, update, p.now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to update %s: %w", href, err)
+	}
+
+	headers := map[string]string{}
+	if etag != "" {
+		headers["If-Match"] = etag
+	}
+	resp, err := p.do(ctx, http.MethodPut, href, "text/calendar; charset=utf-8", patched, headers)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		return nil, fmt.Errorf("event %s was changed by someone else; try again", id)
+	}
+	if resp.StatusCode >= 300 {
+		return nil, statusError(resp)
+	}
+	return event, nil
+}
+
+// findEvent looks up the resource holding the event with the given UID and
+// returns its URL, ETag and iCalendar data
+func (p *Provider) findEvent(ctx context.Context, uid string) (string, string, string, error) {
+	var escaped bytes.Buffer
+	if err := xml.EscapeText(&escaped, []byte(uid)); err != nil {
+		return "", "", "", fmt.Errorf("failed to encode event id: %w", err)
+	}
+	body := fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop>
+    <D:getetag/>
+    <C:calendar-data/>
+  </D:prop>
+  <C:filter>
+    <C:comp-filter name="VCALENDAR">
+      <C:comp-filter name="VEVENT">
+        <C:prop-filter name="UID">
+          <C:text-match collation="i;octet">%s</C:text-match>
+        </C:prop-filter>
+      </C:comp-filter>
+    </C:comp-filter>
+  </C:filter>
+</C:calendar-query>`, escaped.String())
+
+	resp, err := p.do(ctx, "REPORT", p.url, "application/xml; charset=utf-8", body, map[string]string{"Depth": "1"})
+	if err != nil {
+		return "", "", "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusMultiStatus && resp.StatusCode != http.StatusOK {
+		return "", "", "", statusError(resp)
+	}
+
+	var result multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", "", "", fmt.Errorf("failed to decode caldav response: %w", err)
+	}
+	base, err := url.Parse(p.url)
+	if err != nil {
+		return "", "", "", fmt.Errorf("invalid calendar URL: %w", err)
+	}
+	for _, r := range result.Responses {
+		for _, ps := range r.Propstats {
+			if ps.Prop.CalendarData == "" || (ps.Status != "" && !strings.Contains(ps.Status, " 200 ")) {
+				continue
+			}
+			ref, err := url.Parse(r.Href)
+			if err != nil {
+				return "", "", "", fmt.Errorf("invalid href %q: %w", r.Href, err)
+			}
+			return base.ResolveReference(ref).String(), ps.Prop.GetETag, ps.Prop.CalendarData, nil
+		}
+	}
+	return "", "", "", fmt.Errorf("event %s not found", uid)
+}
+
+func (p *Provider) do(ctx context.Context, method, endpoint, contentType, body string, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewBufferString(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if p.username != "" || p.password != "" {
+		req.SetBasicAuth(p.username, p.password)
+	}
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("caldav request failed: %w", err)
+	}
+	return resp, nil
+}
+
+func statusError(resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("caldav server error (%d): %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+}
+
+func newUID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate event UID: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
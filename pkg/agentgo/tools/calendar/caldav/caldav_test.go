@@ -0,0 +1,246 @@
+package caldav
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/calendar"
+)
+
+const sampleICS = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup-1\r\n" +
+	"DTSTART:20260302T090000Z\r\n" +
+	"DURATION:PT15M\r\n" +
+	"SUMMARY:Standup\\, daily\r\n" +
+	"DESCRIPTION:Line one\\nline \r\n" +
+	" two\r\n" +
+	"ATTENDEE;CN=\"Doe: Jane\":mailto:jane@example.com\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"DESCRIPTION:alarm\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:offsite\r\n" +
+	"DTSTART;VALUE=DATE:20260303\r\n" +
+	"TRANSP:TRANSPARENT\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:cancelled\r\n" +
+	"DTSTART;TZID=Europe/Berlin:20260302T100000\r\n" +
+	"DTEND;TZID=Europe/Berlin:20260302T110000\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseICS(t *testing.T) {
+	events, err := parseICS(sampleICS)
+	if err != nil {
+		t.Fatalf("parseICS() error = %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("events = %+v, want cancelled event skipped", events)
+	}
+
+	standup := events[0]
+	if standup.ID != "standup-1" || standup.Title != "Standup, daily" || standup.Description != "Line one\nline two" {
+		t.Errorf("standup = %+v", standup)
+	}
+	if standup.End.Sub(standup.Start) != 15*time.Minute || len(standup.Attendees) != 1 || standup.Attendees[0] != "jane@example.com" {
+		t.Errorf("standup = %+v", standup)
+	}
+
+	offsite := events[1]
+	if !offsite.AllDay || !offsite.Free || offsite.End.Sub(offsite.Start) != 24*time.Hour {
+		t.Errorf("offsite = %+v", offsite)
+	}
+}
+
+func TestFormatICS_RoundTrip(t *testing.T) {
+	event := calendar.Event{
+		ID:          "uid-1",
+		Title:       "Planning; Q2, " + strings.Repeat("long title ", 10),
+		Description: "Agenda:\n1. Budget",
+		Start:       time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC),
+		End:         time.Date(2026, 3, 2, 16, 0, 0, 0, time.UTC),
+		Attendees:   []string{"bob@example.com"},
+	}
+	data := formatICS(event, time.Now())
+	for _, line := range strings.Split(data, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+	}
+
+	events, err := parseICS(data)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("parseICS() = %v, %v", events, err)
+	}
+	got := events[0]
+	if got.ID != event.ID || got.Title != event.Title || got.Description != event.Description ||
+		!got.Start.Equal(event.Start) || !got.End.Equal(event.End) || got.Attendees[0] != "bob@example.com" {
+		t.Errorf("round trip = %+v, want %+v", got, event)
+	}
+}
+
+func TestProvider(t *testing.T) {
+	var putBody, putPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "alice" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method {
+		case "REPORT":
+			body, _ := io.ReadAll(r.Body)
+			if r.Header.Get("Depth") != "1" || !strings.Contains(string(body), `start="20260302T000000Z"`) {
+				t.Errorf("unexpected REPORT: depth=%s body=%s", r.Header.Get("Depth"), body)
+			}
+			w.WriteHeader(http.StatusMultiStatus)
+			io.WriteString(w, `<?xml version="1.0"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">
+  <d:response>
+    <d:href>/cal/standup.ics</d:href>
+    <d:propstat>
+      <d:prop><cal:calendar-data>`+strings.ReplaceAll(sampleICS, "&", "&amp;")+`</cal:calendar-data></d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+</d:multistatus>`)
+		case http.MethodPut:
+			if r.Header.Get("If-None-Match") != "*" {
+				t.Errorf("PUT without If-None-Match")
+			}
+			body, _ := io.ReadAll(r.Body)
+			putBody, putPath = string(body), r.URL.Path
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer server.Close()
+
+	p, err := New(Config{URL: server.URL + "/cal", Username: "alice", Password: "secret"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+
+	// The offsite on 3 March falls outside the range.
+	events, err := p.ListEvents(ctx, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 2, 23, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("ListEvents() error = %v", err)
+	}
+	if len(events) != 1 || events[0].ID != "standup-1" {
+		t.Errorf("events = %+v", events)
+	}
+
+	created, err := p.CreateEvent(ctx, calendar.Event{
+		Title: "Review",
+		Start: time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC),
+		End:   time.Date(2026, 3, 2, 15, 30, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if created.ID == "" || putPath != "/cal/"+created.ID+".ics" || !strings.Contains(putBody, "SUMMARY:Review") {
+		t.Errorf("created = %+v, PUT %s %q", created, putPath, putBody)
+	}
+}
+
+const recurringICS = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:weekly\r\n" +
+	"DTSTART;TZID=Europe/Berlin:20260302T100000\r\n" +
+	"DTEND;TZID=Europe/Berlin:20260302T110000\r\n" +
+	"RRULE:FREQ=WEEKLY\r\n" +
+	"SUMMARY:Weekly sync\r\n" +
+	"SEQUENCE:2\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"DESCRIPTION:reminder\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:weekly\r\n" +
+	"RECURRENCE-ID;TZID=Europe/Berlin:20260309T100000\r\n" +
+	"DTSTART;TZID=Europe/Berlin:20260309T120000\r\n" +
+	"DTEND;TZID=Europe/Berlin:20260309T130000\r\n" +
+	"SUMMARY:Weekly sync (moved)\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestPatchICS(t *testing.T) {
+	title := "Weekly planning"
+	start := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	end := start.Add(30 * time.Minute)
+	data, event, err := patchICS(recurringICS, calendar.EventUpdate{Title: &title, Start: &start, End: &end}, time.Now())
+	if err != nil {
+		t.Fatalf("patchICS() error = %v", err)
+	}
+	if event.ID != "weekly" || event.Title != title || !event.Start.Equal(start) || !event.End.Equal(end) {
+		t.Errorf("event = %+v", event)
+	}
+	for _, want := range []string{"RRULE:FREQ=WEEKLY", "SEQUENCE:3", "DESCRIPTION:reminder", "SUMMARY:Weekly sync (moved)", "DTSTART:20260302T140000Z"} {
+		if !strings.Contains(data, want) {
+			t.Errorf("patched object is missing %q:\n%s", want, data)
+		}
+	}
+	if strings.Contains(data, "SUMMARY:Weekly sync\r\n") || strings.Contains(data, "DTSTART;TZID=Europe/Berlin:20260302T100000") {
+		t.Errorf("old properties kept:\n%s", data)
+	}
+	if strings.Index(data, "SEQUENCE:3") > strings.Index(data, "BEGIN:VALARM") {
+		t.Errorf("new properties must come before nested components:\n%s", data)
+	}
+}
+
+func TestProvider_UpdateEvent(t *testing.T) {
+	var putBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "REPORT":
+			body, _ := io.ReadAll(r.Body)
+			if !strings.Contains(string(body), `<C:text-match collation="i;octet">weekly</C:text-match>`) {
+				t.Errorf("unexpected REPORT body: %s", body)
+			}
+			w.WriteHeader(http.StatusMultiStatus)
+			io.WriteString(w, `<?xml version="1.0"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">
+  <d:response>
+    <d:href>/cal/weekly.ics</d:href>
+    <d:propstat>
+      <d:prop><d:getetag>"v1"</d:getetag><cal:calendar-data>`+recurringICS+`</cal:calendar-data></d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+</d:multistatus>`)
+		case http.MethodPut:
+			if r.URL.Path != "/cal/weekly.ics" || r.Header.Get("If-Match") != `"v1"` {
+				t.Errorf("PUT %s If-Match=%s", r.URL.Path, r.Header.Get("If-Match"))
+			}
+			body, _ := io.ReadAll(r.Body)
+			putBody = string(body)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	p, _ := New(Config{URL: server.URL + "/cal/"})
+	location := "Room 4"
+	updated, err := p.UpdateEvent(context.Background(), "weekly", calendar.EventUpdate{Location: &location})
+	if err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+	if updated.Location != location || updated.Title != "Weekly sync" {
+		t.Errorf("updated = %+v", updated)
+	}
+	if !strings.Contains(putBody, "LOCATION:Room 4") || !strings.Contains(putBody, "RRULE:FREQ=WEEKLY") {
+		t.Errorf("PUT body = %q", putBody)
+	}
+}
@@ -0,0 +1,393 @@
+package caldav
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/calendar"
+)
+
+// property is one iCalendar content line: NAME;PARAM=VALUE:value
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// parseICS reads the VEVENTs of an iCalendar object (RFC 5545). Cancelled
+// events are skipped.
+func parseICS(data string) ([]calendar.Event, error) {
+	var (
+		events    []calendar.Event
+		current   *calendar.Event
+		cancelled bool
+		hasEnd    bool
+		duration  time.Duration
+		depth     int // nesting inside the VEVENT, e.g. VALARM
+	)
+
+	for _, line := range unfold(data) {
+		prop := parseLine(line)
+		switch {
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VEVENT") && current == nil:
+			current = &calendar.Event{}
+			cancelled, hasEnd, duration, depth = false, false, 0, 0
+			continue
+		case current == nil:
+			continue
+		case prop.name == "BEGIN":
+			depth++
+			continue
+		case prop.name == "END" && depth > 0:
+			depth--
+			continue
+		case prop.name == "END" && strings.EqualFold(prop.value, "VEVENT"):
+			if !hasEnd {
+				switch {
+				case duration > 0:
+					current.End = current.Start.Add(duration)
+				case current.AllDay:
+					current.End = current.Start.AddDate(0, 0, 1)
+				default:
+					current.End = current.Start
+				}
+			}
+			if !cancelled {
+				events = append(events, *current)
+			}
+			current = nil
+			continue
+		case depth > 0:
+			continue
+		}
+
+		var err error
+		switch prop.name {
+		case "UID":
+			current.ID = prop.value
+		case "SUMMARY":
+			current.Title = unescapeText(prop.value)
+		case "DESCRIPTION":
+			current.Description = unescapeText(prop.value)
+		case "LOCATION":
+			current.Location = unescapeText(prop.value)
+		case "DTSTART":
+			current.Start, current.AllDay, err = parseDateTime(prop)
+		case "DTEND":
+			current.End, _, err = parseDateTime(prop)
+			hasEnd = true
+		case "DURATION":
+			duration, err = parseDuration(prop.value)
+		case "TRANSP":
+			current.Free = strings.EqualFold(prop.value, "TRANSPARENT")
+		case "STATUS":
+			cancelled = strings.EqualFold(prop.value, "CANCELLED")
+		case "ATTENDEE":
+			email := prop.value
+			if len(email) > 7 && strings.EqualFold(email[:7], "mailto:") {
+				email = email[7:]
+			}
+			current.Attendees = append(current.Attendees, email)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", prop.name, prop.value, err)
+		}
+	}
+	return events, nil
+}
+
+// unfold joins continuation lines, which start with a space or tab
+func unfold(data string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func parseLine(line string) property {
+	// The value starts at the first colon outside a quoted parameter value.
+	inQuotes, colon := false, -1
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		} else if r == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return property{name: strings.ToUpper(line)}
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	prop := property{name: strings.ToUpper(parts[0]), value: line[colon+1:], params: map[string]string{}}
+	for _, param := range parts[1:] {
+		if key, value, ok := strings.Cut(param, "="); ok {
+			prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return prop
+}
+
+// parseDateTime reads a DATE or DATE-TIME value; it reports whether the value
+// is a date (all-day)
+func parseDateTime(prop property) (time.Time, bool, error) {
+	value := prop.value
+	if strings.EqualFold(prop.params["VALUE"], "DATE") || len(value) == len("20060102") {
+		t, err := time.ParseInLocation("20060102", value, time.Local)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(utcLayout, value)
+		return t, false, err
+	}
+	loc := time.Local
+	if tzid := prop.params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+var durationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseDuration reads an iCalendar DURATION such as PT1H30M or P1D
+func parseDuration(value string) (time.Duration, error) {
+	m := durationPattern.FindStringSubmatch(value)
+	if m == nil {
+		return 0, fmt.Errorf("unsupported duration")
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+2] != "" {
+			n, _ := strconv.Atoi(m[i+2])
+			d += time.Duration(n) * unit
+		}
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+// formatICS writes event as an iCalendar object
+func formatICS(event calendar.Event, now time.Time) string {
+	var b strings.Builder
+	write := func(line string) {
+		b.WriteString(fold(line))
+		b.WriteString("\r\n")
+	}
+
+	write("BEGIN:VCALENDAR")
+	write("VERSION:2.0")
+	write("PRODID:-//agent-go//calendar//EN")
+	write("BEGIN:VEVENT")
+	write("UID:" + event.ID)
+	write("DTSTAMP:" + now.UTC().Format(utcLayout))
+	if event.AllDay {
+		write("DTSTART;VALUE=DATE:" + event.Start.Format("20060102"))
+		write("DTEND;VALUE=DATE:" + event.End.Format("20060102"))
+	} else {
+		write("DTSTART:" + event.Start.UTC().Format(utcLayout))
+		write("DTEND:" + event.End.UTC().Format(utcLayout))
+	}
+	write("SUMMARY:" + escapeText(event.Title))
+	if event.Description != "" {
+		write("DESCRIPTION:" + escapeText(event.Description))
+	}
+	if event.Location != "" {
+		write("LOCATION:" + escapeText(event.Location))
+	}
+	for _, email := range event.Attendees {
+		write("ATTENDEE:mailto:" + email)
+	}
+	if event.Free {
+		write("TRANSP:TRANSPARENT")
+	}
+	write("END:VEVENT")
+	write("END:VCALENDAR")
+	return b.String()
+}
+
+// patchICS applies update to the master VEVENT of an iCalendar object (the
+// one without a RECURRENCE-ID) and returns the new object and the updated
+// event. Properties the update does not touch, such as RRULE and VALARMs,
+// are kept as they are; DTSTAMP is refreshed and SEQUENCE incremented.
+func patchICS(data string, update calendar.EventUpdate, now time.Time) (string, *calendar.Event, error) {
+	lines := unfold(data)
+	var (
+		out     []string
+		block   []string // the VEVENT being read
+		inEvent bool
+		patched *calendar.Event
+	)
+	for _, line := range lines {
+		prop := parseLine(line)
+		if !inEvent {
+			if prop.name == "BEGIN" && strings.EqualFold(prop.value, "VEVENT") {
+				inEvent, block = true, []string{line}
+				continue
+			}
+			out = append(out, line)
+			continue
+		}
+		block = append(block, line)
+		if prop.name != "END" || !strings.EqualFold(prop.value, "VEVENT") {
+			continue
+		}
+		inEvent = false
+		if patched == nil && !hasProperty(block, "RECURRENCE-ID") {
+			block = patchEvent(block, update, now)
+			events, err := parseICS(strings.Join(block, "\r\n"))
+			if err != nil {
+				return "", nil, err
+			}
+			if len(events) != 1 {
+				return "", nil, fmt.Errorf("event is cancelled")
+			}
+			patched = &events[0]
+		}
+		out = append(out, block...)
+	}
+	if patched == nil {
+		return "", nil, fmt.Errorf("no event found")
+	}
+
+	var b strings.Builder
+	for _, line := range out {
+		b.WriteString(fold(line))
+		b.WriteString("\r\n")
+	}
+	return b.String(), patched, nil
+}
+
+// hasProperty reports whether a VEVENT has a property of its own, outside
+// nested components
+func hasProperty(block []string, name string) bool {
+	depth := 0
+	for _, line := range block[1:] {
+		prop := parseLine(line)
+		switch {
+		case prop.name == "BEGIN":
+			depth++
+		case prop.name == "END":
+			depth--
+		case depth == 0 && prop.name == name:
+			return true
+		}
+	}
+	return false
+}
+
+// patchEvent rewrites the properties of a VEVENT block that update changes
+func patchEvent(block []string, update calendar.EventUpdate, now time.Time) []string {
+	replaced := map[string]bool{"DTSTAMP": true, "SEQUENCE": true}
+	var added []string
+	if update.Title != nil {
+		replaced["SUMMARY"] = true
+		added = append(added, "SUMMARY:"+escapeText(*update.Title))
+	}
+	if update.Description != nil {
+		replaced["DESCRIPTION"] = true
+		if *update.Description != "" {
+			added = append(added, "DESCRIPTION:"+escapeText(*update.Description))
+		}
+	}
+	if update.Location != nil {
+		replaced["LOCATION"] = true
+		if *update.Location != "" {
+			added = append(added, "LOCATION:"+escapeText(*update.Location))
+		}
+	}
+	if update.Start != nil && update.End != nil {
+		replaced["DTSTART"], replaced["DTEND"], replaced["DURATION"] = true, true, true
+		added = append(added,
+			"DTSTART:"+update.Start.UTC().Format(utcLayout),
+			"DTEND:"+update.End.UTC().Format(utcLayout))
+	}
+	if update.Attendees != nil {
+		replaced["ATTENDEE"] = true
+		for _, email := range update.Attendees {
+			added = append(added, "ATTENDEE:mailto:"+email)
+		}
+	}
+
+	sequence, depth := 0, 0
+	result := []string{block[0]}
+	for _, line := range block[1 : len(block)-1] {
+		prop := parseLine(line)
+		switch {
+		case prop.name == "BEGIN":
+			depth++
+		case prop.name == "END":
+			depth--
+		case depth == 0 && prop.name == "SEQUENCE":
+			sequence, _ = strconv.Atoi(prop.value)
+			continue
+		case depth == 0 && replaced[prop.name]:
+			continue
+		}
+		result = append(result, line)
+	}
+	// New properties go before any nested component, which must come last
+	insert := len(result)
+	for i, line := range result[1:] {
+		if parseLine(line).name == "BEGIN" {
+			insert = i + 1
+			break
+		}
+	}
+	added = append(added,
+		"DTSTAMP:"+now.UTC().Format(utcLayout),
+		"SEQUENCE:"+strconv.Itoa(sequence+1))
+	result = append(result[:insert], append(added, result[insert:]...)...)
+	return append(result, block[len(block)-1])
+}
+
+// fold splits lines longer than 75 octets without breaking UTF-8 sequences
+func fold(line string) string {
+	const limit = 75
+	if len(line) <= limit {
+		return line
+	}
+	var b strings.Builder
+	width := limit
+	for len(line) > width {
+		cut := width
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		width = limit - 1 // the leading space counts
+	}
+	b.WriteString(line)
+	return b.String()
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+var textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+func unescapeText(s string) string {
+	return textUnescaper.Replace(s)
+}
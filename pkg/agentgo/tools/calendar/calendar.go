@@ -0,0 +1,632 @@
+// Package calendar provides a calendaring toolkit for scheduling assistants:
+// list events, query free/busy times, find free slots, and create and update
+// events after confirmation.
+//
+// The toolkit works with any Provider; google (Google Calendar API) and caldav
+// (CalDAV servers such as Nextcloud, iCloud and Fastmail) are included.
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+)
+
+const (
+	defaultWorkdayStart = 9 * time.Hour
+	defaultWorkdayEnd   = 17 * time.Hour
+	defaultMaxRange     = 31 * 24 * time.Hour
+	defaultMaxSlots     = 10
+)
+
+// Event is a calendar event
+type Event struct {
+	ID          string    `json:"id,omitempty"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	Location    string    `json:"location,omitempty"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	AllDay      bool      `json:"all_day,omitempty"`
+	Attendees   []string  `json:"attendees,omitempty"`
+	// Free marks events that do not block time (transparent events)
+	Free bool `json:"free,omitempty"`
+}
+
+// Provider is a calendar backend
+type Provider interface {
+	// ListEvents returns the events overlapping [start, end), recurring
+	// events expanded into instances
+	ListEvents(ctx context.Context, start, end time.Time) ([]Event, error)
+	// CreateEvent creates an event and returns it with its ID
+	CreateEvent(ctx context.Context, event Event) (*Event, error)
+}
+
+// EventUpdate holds the fields of an event to change; nil fields are kept
+type EventUpdate struct {
+	Title       *string
+	Description *string
+	Location    *string
+	Start       *time.Time
+	End         *time.Time
+	Attendees   []string // nil keeps the attendees
+}
+
+// EventUpdater is implemented by providers that can change events
+type EventUpdater interface {
+	// UpdateEvent applies update to the event and returns the updated event
+	UpdateEvent(ctx context.Context, id string, update EventUpdate) (*Event, error)
+}
+
+// FreeBusyProvider is implemented by providers that can report when other
+// calendars, such as those of colleagues or meeting rooms, are busy
+type FreeBusyProvider interface {
+	// FreeBusy returns the busy periods in [start, end) of each calendar,
+	// keyed by calendar ID (usually an email address)
+	FreeBusy(ctx context.Context, start, end time.Time, calendars []string) (map[string][]Slot, error)
+}
+
+// ConfirmFunc is asked before an event is created or updated. It typically
+// shows the event to the user; returning false cancels the change. For an
+// update the event holds the ID and only the fields being changed.
+type ConfirmFunc func(ctx context.Context, event Event) (bool, error)
+
+// Config configures the calendar toolkit
+type Config struct {
+	// Provider is the calendar backend (required)
+	Provider Provider
+
+	// Confirm approves creating and updating events. Without it
+	// create_calendar_event and update_calendar_event always fail, so agents
+	// cannot change the calendar unattended.
+	Confirm ConfirmFunc
+
+	// Location bounds working hours, and interprets and presents times when
+	// a tool call names no timezone (default: time.Local)
+	Location *time.Location
+
+	// WorkdayStart and WorkdayEnd are the working hours, as offsets from
+	// midnight, searched by find_free_slots (default: 9:00-17:00)
+	WorkdayStart time.Duration
+	WorkdayEnd   time.Duration
+
+	// MaxRange limits the span of a single query (default: 31 days)
+	MaxRange time.Duration
+}
+
+// CalendarToolkit provides calendar tools
+type CalendarToolkit struct {
+	*toolkit.BaseToolkit
+	provider     Provider
+	confirm      ConfirmFunc
+	location     *time.Location
+	workdayStart time.Duration
+	workdayEnd   time.Duration
+	maxRange     time.Duration
+}
+
+// New creates a calendar toolkit
+func New(config Config) (*CalendarToolkit, error) {
+	if config.Provider == nil {
+		return nil, fmt.Errorf("calendar provider is required")
+	}
+	if config.Location == nil {
+		config.Location = time.Local
+	}
+	if config.WorkdayStart == 0 && config.WorkdayEnd == 0 {
+		config.WorkdayStart, config.WorkdayEnd = defaultWorkdayStart, defaultWorkdayEnd
+	}
+	if config.WorkdayStart < 0 || config.WorkdayEnd > 24*time.Hour || config.WorkdayStart >= config.WorkdayEnd {
+		return nil, fmt.Errorf("invalid working hours %s-%s", config.WorkdayStart, config.WorkdayEnd)
+	}
+	if config.MaxRange <= 0 {
+		config.MaxRange = defaultMaxRange
+	}
+
+	t := &CalendarToolkit{
+		BaseToolkit:  toolkit.NewBaseToolkit("calendar"),
+		provider:     config.Provider,
+		confirm:      config.Confirm,
+		location:     config.Location,
+		workdayStart: config.WorkdayStart,
+		workdayEnd:   config.WorkdayEnd,
+		maxRange:     config.MaxRange,
+	}
+
+	timeParam := func(description string) toolkit.Parameter {
+		return toolkit.Parameter{
+			Type:        "string",
+			Description: description + " (RFC 3339, e.g. 2026-03-02T09:00:00+01:00; without a zone the timezone argument or the calendar's zone is used)",
+			Required:    true,
+		}
+	}
+	timezoneParam := toolkit.Parameter{
+		Type:        "string",
+		Description: fmt.Sprintf("IANA timezone, e.g. Europe/Lisbon, for times without a zone and for the times returned (default: %s)", config.Location),
+	}
+	_, freeBusy := config.Provider.(FreeBusyProvider)
+	calendarsParam := toolkit.Parameter{
+		Type:        "array",
+		Description: "Other calendars to include, usually attendee email addresses",
+		Items:       &toolkit.Parameter{Type: "string"},
+	}
+
+	t.RegisterFunction(&toolkit.Function{
+		Name:        "list_calendar_events",
+		Description: "List calendar events between two times",
+		Parameters: map[string]toolkit.Parameter{
+			"start":    timeParam("Start of the range"),
+			"end":      timeParam("End of the range"),
+			"timezone": timezoneParam,
+		},
+		Handler: t.listEvents,
+	})
+
+	freeBusyParams := map[string]toolkit.Parameter{
+		"start":    timeParam("Start of the range"),
+		"end":      timeParam("End of the range"),
+		"timezone": timezoneParam,
+	}
+	if freeBusy {
+		freeBusyParams["calendars"] = calendarsParam
+	}
+	t.RegisterFunction(&toolkit.Function{
+		Name:        "get_free_busy",
+		Description: "Get the busy periods of the calendar, and of other calendars if given, between two times",
+		Parameters:  freeBusyParams,
+		Handler:     t.getFreeBusy,
+	})
+
+	slotParams := map[string]toolkit.Parameter{
+		"start": timeParam("Start of the search range"),
+		"end":   timeParam("End of the search range"),
+		"duration_minutes": {
+			Type:        "integer",
+			Description: "Minimum length of a slot in minutes",
+			Required:    true,
+		},
+		"working_hours_only": {
+			Type:        "boolean",
+			Description: "Only return slots within working hours on weekdays (default: true)",
+			Default:     true,
+		},
+		"max_slots": {
+			Type:        "integer",
+			Description: "Maximum number of slots to return (default: 10)",
+			Default:     defaultMaxSlots,
+		},
+		"timezone": timezoneParam,
+	}
+	if freeBusy {
+		calendarsParam.Description = "Other calendars that must be free too, usually attendee email addresses"
+		slotParams["calendars"] = calendarsParam
+	}
+	t.RegisterFunction(&toolkit.Function{
+		Name:        "find_free_slots",
+		Description: "Find free time slots of at least the given duration between two times",
+		Parameters:  slotParams,
+		Handler:     t.findFreeSlots,
+	})
+
+	t.RegisterFunction(&toolkit.Function{
+		Name:        "create_calendar_event",
+		Description: "Create a calendar event. The user is asked to confirm before the event is created.",
+		Parameters: map[string]toolkit.Parameter{
+			"title": {
+				Type:        "string",
+				Description: "Event title",
+				Required:    true,
+			},
+			"start": timeParam("Event start"),
+			"end":   timeParam("Event end"),
+			"description": {
+				Type:        "string",
+				Description: "Event description",
+			},
+			"location": {
+				Type:        "string",
+				Description: "Event location",
+			},
+			"attendees": {
+				Type:        "array",
+				Description: "Email addresses of attendees",
+				Items:       &toolkit.Parameter{Type: "string"},
+			},
+			"timezone": timezoneParam,
+		},
+		Handler: t.createEvent,
+	})
+
+	if _, ok := config.Provider.(EventUpdater); ok {
+		optionalTime := func(description string) toolkit.Parameter {
+			param := timeParam(description)
+			param.Required = false
+			return param
+		}
+		t.RegisterFunction(&toolkit.Function{
+			Name:        "update_calendar_event",
+			Description: "Change a calendar event. Only the given fields change; start and end must be given together. The user is asked to confirm first.",
+			Parameters: map[string]toolkit.Parameter{
+				"event_id": {
+					Type:        "string",
+					Description: "ID of the event, as returned by list_calendar_events",
+					Required:    true,
+				},
+				"title":       {Type: "string", Description: "New title"},
+				"start":       optionalTime("New start"),
+				"end":         optionalTime("New end"),
+				"description": {Type: "string", Description: "New description"},
+				"location":    {Type: "string", Description: "New location"},
+				"attendees": {
+					Type:        "array",
+					Description: "New list of attendee email addresses, replacing the current one",
+					Items:       &toolkit.Parameter{Type: "string"},
+				},
+				"timezone": timezoneParam,
+			},
+			Handler: t.updateEvent,
+		})
+	}
+
+	return t, nil
+}
+
+func (t *CalendarToolkit) listEvents(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	loc, err := t.locationFor(args)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := t.parseRange(args, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := t.provider.ListEvents(ctx, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Start.Before(events[j].Start) })
+	for i := range events {
+		events[i] = eventIn(events[i], loc)
+	}
+
+	return map[string]interface{}{
+		"events": events,
+		"count":  len(events),
+	}, nil
+}
+
+func (t *CalendarToolkit) getFreeBusy(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	loc, err := t.locationFor(args)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := t.parseRange(args, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := t.provider.ListEvents(ctx, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	result := map[string]interface{}{
+		"busy": slotsIn(BusySlots(events), loc),
+	}
+
+	others, err := t.otherCalendarsBusy(ctx, args, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if others != nil {
+		for id, busy := range others {
+			others[id] = slotsIn(busy, loc)
+		}
+		result["calendars"] = others
+	}
+	return result, nil
+}
+
+func (t *CalendarToolkit) findFreeSlots(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	loc, err := t.locationFor(args)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := t.parseRange(args, loc)
+	if err != nil {
+		return nil, err
+	}
+	minutes, ok := toInt(args["duration_minutes"])
+	if !ok || minutes <= 0 {
+		return nil, fmt.Errorf("duration_minutes must be a positive integer")
+	}
+	workingHours := true
+	if v, ok := args["working_hours_only"].(bool); ok {
+		workingHours = v
+	}
+	maxSlots := defaultMaxSlots
+	if v, ok := toInt(args["max_slots"]); ok && v > 0 {
+		maxSlots = v
+	}
+
+	events, err := t.provider.ListEvents(ctx, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	others, err := t.otherCalendarsBusy(ctx, args, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, busy := range others {
+		for _, slot := range busy {
+			events = append(events, Event{Start: slot.Start, End: slot.End})
+		}
+	}
+
+	windows := []Slot{{Start: start, End: end}}
+	if workingHours {
+		windows = t.workingWindows(start, end)
+	}
+	slots := slotsIn(FreeSlots(events, windows, time.Duration(minutes)*time.Minute, maxSlots), loc)
+
+	return map[string]interface{}{
+		"slots": slots,
+		"count": len(slots),
+	}, nil
+}
+
+// otherCalendarsBusy returns the busy periods of the calendars argument, or
+// nil when there is none
+func (t *CalendarToolkit) otherCalendarsBusy(ctx context.Context, args map[string]interface{}, start, end time.Time) (map[string][]Slot, error) {
+	calendars := stringList(args["calendars"])
+	if len(calendars) == 0 {
+		return nil, nil
+	}
+	provider, ok := t.provider.(FreeBusyProvider)
+	if !ok {
+		return nil, fmt.Errorf("this calendar cannot look up other calendars")
+	}
+	busy, err := provider.FreeBusy(ctx, start, end, calendars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query free/busy: %w", err)
+	}
+	return busy, nil
+}
+
+func (t *CalendarToolkit) createEvent(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	title, _ := args["title"].(string)
+	if strings.TrimSpace(title) == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	loc, err := t.locationFor(args)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := t.parseRange(args, loc)
+	if err != nil {
+		return nil, err
+	}
+	event := Event{Title: title, Start: start, End: end}
+	event.Description, _ = args["description"].(string)
+	event.Location, _ = args["location"].(string)
+	event.Attendees = stringList(args["attendees"])
+
+	if t.confirm == nil {
+		return nil, fmt.Errorf("event creation is disabled: no confirmation handler is configured")
+	}
+	confirmed, err := t.confirm(ctx, event)
+	if err != nil {
+		return nil, fmt.Errorf("confirmation failed: %w", err)
+	}
+	if !confirmed {
+		return map[string]interface{}{
+			"created": false,
+			"message": "The user declined to create the event.",
+		}, nil
+	}
+
+	created, err := t.provider.CreateEvent(ctx, event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event: %w", err)
+	}
+	*created = eventIn(*created, loc)
+	return map[string]interface{}{
+		"created": true,
+		"event":   created,
+	}, nil
+}
+
+func (t *CalendarToolkit) updateEvent(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	id, _ := args["event_id"].(string)
+	if id == "" {
+		return nil, fmt.Errorf("event_id is required")
+	}
+	loc, err := t.locationFor(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var update EventUpdate
+	preview := Event{ID: id}
+	if v, ok := args["title"].(string); ok {
+		if strings.TrimSpace(v) == "" {
+			return nil, fmt.Errorf("title must not be empty")
+		}
+		update.Title, preview.Title = &v, v
+	}
+	if v, ok := args["description"].(string); ok {
+		update.Description, preview.Description = &v, v
+	}
+	if v, ok := args["location"].(string); ok {
+		update.Location, preview.Location = &v, v
+	}
+	if _, ok := args["attendees"]; ok {
+		update.Attendees = stringList(args["attendees"])
+		if update.Attendees == nil {
+			update.Attendees = []string{}
+		}
+		preview.Attendees = update.Attendees
+	}
+	_, hasStart := args["start"]
+	_, hasEnd := args["end"]
+	if hasStart || hasEnd {
+		start, end, err := t.parseRange(args, loc)
+		if err != nil {
+			return nil, err
+		}
+		update.Start, update.End = &start, &end
+		preview.Start, preview.End = start, end
+	}
+	if update.Title == nil && update.Description == nil && update.Location == nil &&
+		update.Start == nil && update.Attendees == nil {
+		return nil, fmt.Errorf("nothing to update")
+	}
+
+	if t.confirm == nil {
+		return nil, fmt.Errorf("event updates are disabled: no confirmation handler is configured")
+	}
+	confirmed, err := t.confirm(ctx, preview)
+	if err != nil {
+		return nil, fmt.Errorf("confirmation failed: %w", err)
+	}
+	if !confirmed {
+		return map[string]interface{}{
+			"updated": false,
+			"message": "The user declined to update the event.",
+		}, nil
+	}
+
+	updated, err := t.provider.(EventUpdater).UpdateEvent(ctx, id, update)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update event: %w", err)
+	}
+	*updated = eventIn(*updated, loc)
+	return map[string]interface{}{
+		"updated": true,
+		"event":   updated,
+	}, nil
+}
+
+// locationFor returns the timezone named by the timezone argument, or the
+// calendar's location
+func (t *CalendarToolkit) locationFor(args map[string]interface{}) (*time.Location, error) {
+	name, _ := args["timezone"].(string)
+	if name == "" {
+		return t.location, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q: use an IANA name such as Europe/Lisbon", name)
+	}
+	return loc, nil
+}
+
+// parseRange reads the start and end arguments
+func (t *CalendarToolkit) parseRange(args map[string]interface{}, loc *time.Location) (time.Time, time.Time, error) {
+	start, err := parseTime(args["start"], "start", loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := parseTime(args["end"], "end", loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("end must be after start")
+	}
+	if end.Sub(start) > t.maxRange {
+		return time.Time{}, time.Time{}, fmt.Errorf("range must not exceed %s", t.maxRange)
+	}
+	return start, end, nil
+}
+
+var timeLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+func parseTime(value interface{}, name string, loc *time.Location) (time.Time, error) {
+	s, ok := value.(string)
+	if !ok || s == "" {
+		return time.Time{}, fmt.Errorf("%s is required", name)
+	}
+	if parsed, err := time.Parse(time.RFC3339, s); err == nil {
+		return parsed, nil
+	}
+	for _, layout := range timeLayouts {
+		if parsed, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid %s time %q: use RFC 3339", name, s)
+}
+
+// eventIn presents the times of a timed event in loc. All-day events keep
+// their dates.
+func eventIn(event Event, loc *time.Location) Event {
+	if !event.AllDay {
+		event.Start, event.End = event.Start.In(loc), event.End.In(loc)
+	}
+	return event
+}
+
+func slotsIn(slots []Slot, loc *time.Location) []Slot {
+	for i := range slots {
+		slots[i] = Slot{Start: slots[i].Start.In(loc), End: slots[i].End.In(loc)}
+	}
+	return slots
+}
+
+func stringList(value interface{}) []string {
+	raw, _ := value.([]interface{})
+	var list []string
+	for _, item := range raw {
+		if s, ok := item.(string); ok && s != "" {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// workingWindows returns the working hours of each weekday in [start, end)
+func (t *CalendarToolkit) workingWindows(start, end time.Time) []Slot {
+	var windows []Slot
+	local := start.In(t.location)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, t.location)
+	for ; day.Before(end); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			continue
+		}
+		from := day.Add(t.workdayStart)
+		to := day.Add(t.workdayEnd)
+		if from.Before(start) {
+			from = start
+		}
+		if to.After(end) {
+			to = end
+		}
+		if to.After(from) {
+			windows = append(windows, Slot{Start: from, End: to})
+		}
+	}
+	return windows
+}
+
+func toInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	default:
+		return 0, false
+	}
+}
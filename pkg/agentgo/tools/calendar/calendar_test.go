@@ -0,0 +1,405 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeProvider struct {
+	events  []Event
+	created []Event
+}
+
+func (p *fakeProvider) ListEvents(_ context.Context, start, end time.Time) ([]Event, error) {
+	var out []Event
+	for _, e := range p.events {
+		if e.End.After(start) && e.Start.Before(end) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (p *fakeProvider) CreateEvent(_ context.Context, event Event) (*Event, error) {
+	event.ID = "new-1"
+	p.created = append(p.created, event)
+	return &event, nil
+}
+
+// Monday 2 March 2026, UTC
+func at(day, hour, minute int) time.Time {
+	return time.Date(2026, 3, day, hour, minute, 0, 0, time.UTC)
+}
+
+func newToolkit(t *testing.T, provider Provider, confirm ConfirmFunc) *CalendarToolkit {
+	t.Helper()
+	tk, err := New(Config{Provider: provider, Confirm: confirm, Location: time.UTC})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return tk
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Config{}); err == nil {
+		t.Error("expected error without provider")
+	}
+	if _, err := New(Config{Provider: &fakeProvider{}, WorkdayStart: 18 * time.Hour, WorkdayEnd: 9 * time.Hour}); err == nil {
+		t.Error("expected error for inverted working hours")
+	}
+}
+
+func TestListEvents(t *testing.T) {
+	provider := &fakeProvider{events: []Event{
+		{ID: "b", Title: "Lunch", Start: at(2, 12, 0), End: at(2, 13, 0)},
+		{ID: "a", Title: "Standup", Start: at(2, 9, 0), End: at(2, 9, 15)},
+		{ID: "c", Title: "Next week", Start: at(9, 9, 0), End: at(9, 10, 0)},
+	}}
+	tk := newToolkit(t, provider, nil)
+
+	out, err := tk.Execute(context.Background(), "list_calendar_events", map[string]interface{}{
+		"start": "2026-03-02",
+		"end":   "2026-03-03T00:00:00Z",
+	})
+	if err != nil {
+		t.Fatalf("list_calendar_events error = %v", err)
+	}
+	events := out.(map[string]interface{})["events"].([]Event)
+	if len(events) != 2 || events[0].ID != "a" || events[1].ID != "b" {
+		t.Errorf("events = %+v", events)
+	}
+}
+
+func TestListEvents_InvalidRange(t *testing.T) {
+	tk := newToolkit(t, &fakeProvider{}, nil)
+	ctx := context.Background()
+
+	tests := []map[string]interface{}{
+		{"start": "tomorrow", "end": "2026-03-03"},
+		{"start": "2026-03-03", "end": "2026-03-02"},
+		{"start": "2026-01-01", "end": "2026-06-01"},
+	}
+	for _, args := range tests {
+		if _, err := tk.Execute(ctx, "list_calendar_events", args); err == nil {
+			t.Errorf("args %v: expected error", args)
+		}
+	}
+}
+
+func TestFindFreeSlots_WorkingHours(t *testing.T) {
+	provider := &fakeProvider{events: []Event{
+		{Start: at(2, 9, 0), End: at(2, 10, 0)},
+		{Start: at(2, 9, 30), End: at(2, 11, 0)}, // overlaps the first
+		{Start: at(2, 11, 15), End: at(2, 12, 0)},
+		{Start: at(2, 14, 0), End: at(2, 15, 0), Free: true},
+	}}
+	tk := newToolkit(t, provider, nil)
+
+	// Monday to Sunday: only Monday to Friday are searched.
+	out, err := tk.Execute(context.Background(), "find_free_slots", map[string]interface{}{
+		"start":            "2026-03-02T00:00:00Z",
+		"end":              "2026-03-09T00:00:00Z",
+		"duration_minutes": float64(30),
+	})
+	if err != nil {
+		t.Fatalf("find_free_slots error = %v", err)
+	}
+	slots := out.(map[string]interface{})["slots"].([]Slot)
+	want := []Slot{
+		{at(2, 12, 0), at(2, 17, 0)}, // the 15-minute gap at 11:00 is too short
+		{at(3, 9, 0), at(3, 17, 0)},
+		{at(4, 9, 0), at(4, 17, 0)},
+		{at(5, 9, 0), at(5, 17, 0)},
+		{at(6, 9, 0), at(6, 17, 0)},
+	}
+	if len(slots) != len(want) {
+		t.Fatalf("slots = %v, want %v", slots, want)
+	}
+	for i := range want {
+		if !slots[i].Start.Equal(want[i].Start) || !slots[i].End.Equal(want[i].End) {
+			t.Errorf("slot %d = %v, want %v", i, slots[i], want[i])
+		}
+	}
+}
+
+func TestFindFreeSlots_AnyTimeWithLimit(t *testing.T) {
+	provider := &fakeProvider{events: []Event{
+		{Start: at(7, 10, 0), End: at(7, 11, 0)}, // Saturday
+		{Start: at(7, 12, 0), End: at(7, 13, 0)},
+	}}
+	tk := newToolkit(t, provider, nil)
+
+	out, err := tk.Execute(context.Background(), "find_free_slots", map[string]interface{}{
+		"start":              "2026-03-07T08:00:00Z",
+		"end":                "2026-03-07T20:00:00Z",
+		"duration_minutes":   float64(60),
+		"working_hours_only": false,
+		"max_slots":          float64(2),
+	})
+	if err != nil {
+		t.Fatalf("find_free_slots error = %v", err)
+	}
+	slots := out.(map[string]interface{})["slots"].([]Slot)
+	if len(slots) != 2 || !slots[0].End.Equal(at(7, 10, 0)) || !slots[1].Start.Equal(at(7, 11, 0)) {
+		t.Errorf("slots = %v", slots)
+	}
+}
+
+func TestCreateEvent_Confirmation(t *testing.T) {
+	args := map[string]interface{}{
+		"title":     "Review",
+		"start":     "2026-03-02T15:00:00Z",
+		"end":       "2026-03-02T15:30:00Z",
+		"attendees": []interface{}{"bob@example.com"},
+	}
+	ctx := context.Background()
+
+	// No confirmation handler: creation is refused.
+	provider := &fakeProvider{}
+	if _, err := newToolkit(t, provider, nil).Execute(ctx, "create_calendar_event", args); err == nil {
+		t.Error("expected error without confirmation handler")
+	}
+
+	// Declined.
+	var asked Event
+	decline := func(_ context.Context, event Event) (bool, error) { asked = event; return false, nil }
+	out, err := newToolkit(t, provider, decline).Execute(ctx, "create_calendar_event", args)
+	if err != nil || out.(map[string]interface{})["created"] != false {
+		t.Errorf("declined = %v, %v", out, err)
+	}
+	if asked.Title != "Review" || len(asked.Attendees) != 1 {
+		t.Errorf("confirmation saw %+v", asked)
+	}
+
+	// Confirmation error.
+	fail := func(context.Context, Event) (bool, error) { return false, errors.New("ui closed") }
+	if _, err := newToolkit(t, provider, fail).Execute(ctx, "create_calendar_event", args); err == nil {
+		t.Error("expected confirmation error")
+	}
+	if len(provider.created) != 0 {
+		t.Fatalf("events created without confirmation: %v", provider.created)
+	}
+
+	// Approved.
+	approve := func(context.Context, Event) (bool, error) { return true, nil }
+	out, err = newToolkit(t, provider, approve).Execute(ctx, "create_calendar_event", args)
+	if err != nil {
+		t.Fatalf("create_calendar_event error = %v", err)
+	}
+	result := out.(map[string]interface{})
+	if result["created"] != true || result["event"].(*Event).ID != "new-1" || len(provider.created) != 1 {
+		t.Errorf("result = %v", result)
+	}
+}
+
+// schedulingProvider also updates events and reports other calendars' busy times
+type schedulingProvider struct {
+	fakeProvider
+	busy    map[string][]Slot
+	updates map[string]EventUpdate
+}
+
+func (p *schedulingProvider) UpdateEvent(_ context.Context, id string, update EventUpdate) (*Event, error) {
+	p.updates[id] = update
+	event := Event{ID: id, Start: at(2, 9, 0), End: at(2, 10, 0)}
+	if update.Title != nil {
+		event.Title = *update.Title
+	}
+	if update.Start != nil {
+		event.Start, event.End = *update.Start, *update.End
+	}
+	return &event, nil
+}
+
+func (p *schedulingProvider) FreeBusy(_ context.Context, _, _ time.Time, calendars []string) (map[string][]Slot, error) {
+	out := make(map[string][]Slot, len(calendars))
+	for _, id := range calendars {
+		out[id] = p.busy[id]
+	}
+	return out, nil
+}
+
+func TestOptionalTools(t *testing.T) {
+	basic := newToolkit(t, &fakeProvider{}, nil).Functions()
+	if _, ok := basic["update_calendar_event"]; ok {
+		t.Error("update_calendar_event registered for a provider that cannot update")
+	}
+	if _, ok := basic["get_free_busy"].Parameters["calendars"]; ok {
+		t.Error("calendars parameter offered for a provider without free/busy")
+	}
+
+	full := newToolkit(t, &schedulingProvider{}, nil).Functions()
+	if _, ok := full["update_calendar_event"]; !ok {
+		t.Error("update_calendar_event not registered")
+	}
+	if _, ok := full["find_free_slots"].Parameters["calendars"]; !ok {
+		t.Error("calendars parameter missing from find_free_slots")
+	}
+}
+
+func TestTimezone(t *testing.T) {
+	provider := &fakeProvider{events: []Event{
+		{ID: "a", Title: "Standup", Start: at(2, 9, 0), End: at(2, 9, 15)},
+		{ID: "b", Title: "Offsite", Start: at(3, 0, 0), End: at(4, 0, 0), AllDay: true},
+	}}
+	tk := newToolkit(t, provider, nil)
+	ctx := context.Background()
+
+	// 09:00 UTC is 01:00 in Los Angeles, so a local day starting at 02:00
+	// excludes the standup.
+	out, err := tk.Execute(ctx, "list_calendar_events", map[string]interface{}{
+		"start":    "2026-03-02T02:00",
+		"end":      "2026-03-03T12:00",
+		"timezone": "America/Los_Angeles",
+	})
+	if err != nil {
+		t.Fatalf("list_calendar_events error = %v", err)
+	}
+	events := out.(map[string]interface{})["events"].([]Event)
+	if len(events) != 1 || events[0].ID != "b" || events[0].Start.Location() != time.UTC {
+		t.Errorf("events = %+v, want the all-day event with its date kept", events)
+	}
+
+	out, err = tk.Execute(ctx, "list_calendar_events", map[string]interface{}{
+		"start":    "2026-03-02",
+		"end":      "2026-03-03",
+		"timezone": "Asia/Tokyo",
+	})
+	if err != nil {
+		t.Fatalf("list_calendar_events error = %v", err)
+	}
+	events = out.(map[string]interface{})["events"].([]Event)
+	if len(events) != 1 || events[0].Start.Hour() != 18 || events[0].Start.Location().String() != "Asia/Tokyo" {
+		t.Errorf("events = %+v, want the standup at 18:00 Tokyo time", events)
+	}
+
+	if _, err := tk.Execute(ctx, "list_calendar_events", map[string]interface{}{
+		"start": "2026-03-02", "end": "2026-03-03", "timezone": "Mars/Olympus",
+	}); err == nil {
+		t.Error("expected error for an unknown timezone")
+	}
+}
+
+func TestGetFreeBusy(t *testing.T) {
+	provider := &schedulingProvider{
+		fakeProvider: fakeProvider{events: []Event{
+			{Start: at(2, 9, 0), End: at(2, 10, 0)},
+			{Start: at(2, 9, 30), End: at(2, 11, 0)},
+			{Start: at(2, 14, 0), End: at(2, 15, 0), Free: true},
+		}},
+		busy: map[string][]Slot{"bob@example.com": {{at(2, 13, 0), at(2, 14, 0)}}},
+	}
+	tk := newToolkit(t, provider, nil)
+
+	out, err := tk.Execute(context.Background(), "get_free_busy", map[string]interface{}{
+		"start":     "2026-03-02T00:00:00Z",
+		"end":       "2026-03-03T00:00:00Z",
+		"calendars": []interface{}{"bob@example.com"},
+	})
+	if err != nil {
+		t.Fatalf("get_free_busy error = %v", err)
+	}
+	result := out.(map[string]interface{})
+	busy := result["busy"].([]Slot)
+	if len(busy) != 1 || !busy[0].Start.Equal(at(2, 9, 0)) || !busy[0].End.Equal(at(2, 11, 0)) {
+		t.Errorf("busy = %v, want overlapping events merged and free events left out", busy)
+	}
+	if others := result["calendars"].(map[string][]Slot); len(others["bob@example.com"]) != 1 {
+		t.Errorf("calendars = %v", others)
+	}
+
+	_, err = newToolkit(t, &fakeProvider{}, nil).Execute(context.Background(), "get_free_busy", map[string]interface{}{
+		"start":     "2026-03-02T00:00:00Z",
+		"end":       "2026-03-03T00:00:00Z",
+		"calendars": []interface{}{"bob@example.com"},
+	})
+	if err == nil {
+		t.Error("expected error for other calendars without free/busy support")
+	}
+}
+
+func TestFindFreeSlots_OtherCalendars(t *testing.T) {
+	provider := &schedulingProvider{
+		fakeProvider: fakeProvider{events: []Event{{Start: at(2, 9, 0), End: at(2, 12, 0)}}},
+		busy:         map[string][]Slot{"bob@example.com": {{at(2, 12, 0), at(2, 16, 0)}}},
+	}
+	tk := newToolkit(t, provider, nil)
+
+	out, err := tk.Execute(context.Background(), "find_free_slots", map[string]interface{}{
+		"start":            "2026-03-02T00:00:00Z",
+		"end":              "2026-03-03T00:00:00Z",
+		"duration_minutes": float64(30),
+		"calendars":        []interface{}{"bob@example.com"},
+	})
+	if err != nil {
+		t.Fatalf("find_free_slots error = %v", err)
+	}
+	slots := out.(map[string]interface{})["slots"].([]Slot)
+	if len(slots) != 1 || !slots[0].Start.Equal(at(2, 16, 0)) || !slots[0].End.Equal(at(2, 17, 0)) {
+		t.Errorf("slots = %v, want only the hour both calendars are free", slots)
+	}
+}
+
+func TestUpdateEvent(t *testing.T) {
+	provider := &schedulingProvider{updates: map[string]EventUpdate{}}
+	ctx := context.Background()
+	var asked Event
+	approve := func(_ context.Context, event Event) (bool, error) { asked = event; return true, nil }
+	tk := newToolkit(t, provider, approve)
+
+	out, err := tk.Execute(ctx, "update_calendar_event", map[string]interface{}{
+		"event_id": "evt-1",
+		"title":    "Design review",
+		"start":    "2026-03-02T16:00",
+		"end":      "2026-03-02T17:00",
+		"timezone": "Europe/Berlin",
+	})
+	if err != nil {
+		t.Fatalf("update_calendar_event error = %v", err)
+	}
+	update := provider.updates["evt-1"]
+	if *update.Title != "Design review" || !update.Start.Equal(at(2, 15, 0)) || update.Location != nil || update.Attendees != nil {
+		t.Errorf("update = %+v", update)
+	}
+	if asked.ID != "evt-1" || asked.Title != "Design review" {
+		t.Errorf("confirmation saw %+v", asked)
+	}
+	if event := out.(map[string]interface{})["event"].(*Event); event.Start.Hour() != 16 {
+		t.Errorf("event = %+v, want times in Europe/Berlin", event)
+	}
+
+	// Clearing the attendees is a change.
+	if _, err := tk.Execute(ctx, "update_calendar_event", map[string]interface{}{
+		"event_id": "evt-2", "attendees": []interface{}{},
+	}); err != nil {
+		t.Fatalf("update_calendar_event error = %v", err)
+	}
+	if attendees := provider.updates["evt-2"].Attendees; attendees == nil || len(attendees) != 0 {
+		t.Errorf("attendees = %#v, want empty", attendees)
+	}
+
+	invalid := []map[string]interface{}{
+		{"title": "No ID"},
+		{"event_id": "evt-3"},
+		{"event_id": "evt-3", "start": "2026-03-02T16:00:00Z"},
+		{"event_id": "evt-3", "title": " "},
+	}
+	for _, args := range invalid {
+		if _, err := tk.Execute(ctx, "update_calendar_event", args); err == nil {
+			t.Errorf("args %v: expected error", args)
+		}
+	}
+
+	decline := func(context.Context, Event) (bool, error) { return false, nil }
+	out, err = newToolkit(t, provider, decline).Execute(ctx, "update_calendar_event", map[string]interface{}{
+		"event_id": "evt-4", "location": "Room 4",
+	})
+	if err != nil || out.(map[string]interface{})["updated"] != false {
+		t.Errorf("declined = %v, %v", out, err)
+	}
+	if _, ok := provider.updates["evt-4"]; ok {
+		t.Error("event updated without confirmation")
+	}
+}
@@ -0,0 +1,350 @@
+// Package google implements calendar.Provider with the Google Calendar API v3.
+package google
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/calendar"
+)
+
+const (
+	defaultBaseURL = "https://www.googleapis.com/calendar/v3"
+	defaultTimeout = 30 * time.Second
+	pageSize       = 250
+)
+
+// Config configures the Google Calendar provider
+type Config struct {
+	// AccessToken is an OAuth 2.0 token with a calendar scope. Leave empty
+	// when HTTPClient already authorizes requests (e.g. an oauth2 client).
+	AccessToken string
+	// CalendarID is the calendar to use (default: "primary")
+	CalendarID string
+	// BaseURL overrides the API endpoint
+	BaseURL    string
+	HTTPClient *http.Client
+	Timeout    time.Duration
+}
+
+// Provider reads and writes a Google calendar
+type Provider struct {
+	base       string
+	calendarID string
+	auth       string
+	http       *http.Client
+}
+
+var (
+	_ calendar.Provider         = (*Provider)(nil)
+	_ calendar.EventUpdater     = (*Provider)(nil)
+	_ calendar.FreeBusyProvider = (*Provider)(nil)
+)
+
+// New creates a Google Calendar provider
+func New(cfg Config) (*Provider, error) {
+	if cfg.AccessToken == "" && cfg.HTTPClient == nil {
+		return nil, fmt.Errorf("google calendar access token or authorized HTTP client is required")
+	}
+
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	calendarID := cfg.CalendarID
+	if calendarID == "" {
+		calendarID = "primary"
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+	if cfg.Timeout > 0 {
+		httpClient.Timeout = cfg.Timeout
+	}
+
+	p := &Provider{
+		base:       strings.TrimRight(baseURL, "/"),
+		calendarID: calendarID,
+		http:       httpClient,
+	}
+	if cfg.AccessToken != "" {
+		p.auth = "Bearer " + cfg.AccessToken
+	}
+	return p, nil
+}
+
+type eventTime struct {
+	DateTime string `json:"dateTime,omitempty"`
+	Date     string `json:"date,omitempty"`
+}
+
+type attendee struct {
+	Email string `json:"email"`
+}
+
+type apiEvent struct {
+	ID           string     `json:"id,omitempty"`
+	Status       string     `json:"status,omitempty"`
+	Summary      string     `json:"summary"`
+	Description  string     `json:"description,omitempty"`
+	Location     string     `json:"location,omitempty"`
+	Start        eventTime  `json:"start"`
+	End          eventTime  `json:"end"`
+	Attendees    []attendee `json:"attendees,omitempty"`
+	Transparency string     `json:"transparency,omitempty"`
+}
+
+type eventList struct {
+	Items         []apiEvent `json:"items"`
+	NextPageToken string     `json:"nextPageToken"`
+}
+
+// ListEvents implements calendar.Provider
+func (p *Provider) ListEvents(ctx context.Context, start, end time.Time) ([]calendar.Event, error) {
+	var events []calendar.Event
+	pageToken := ""
+	for {
+		query := url.Values{}
+		query.Set("timeMin", start.Format(time.RFC3339))
+		query.Set("timeMax", end.Format(time.RFC3339))
+		query.Set("singleEvents", "true")
+		query.Set("orderBy", "startTime")
+		query.Set("maxResults", fmt.Sprint(pageSize))
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+
+		var page eventList
+		if err := p.do(ctx, http.MethodGet, p.eventsURL()+"?"+query.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+		for _, item := range page.Items {
+			if item.Status == "cancelled" {
+				continue
+			}
+			event, err := fromAPI(item)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, event)
+		}
+
+		if page.NextPageToken == "" {
+			return events, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+// CreateEvent implements calendar.Provider
+func (p *Provider) CreateEvent(ctx context.Context, event calendar.Event) (*calendar.Event, error) {
+	body := toAPI(event)
+	var created apiEvent
+	if err := p.do(ctx, http.MethodPost, p.eventsURL(), body, &created); err != nil {
+		return nil, err
+	}
+	result, err := fromAPI(created)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// eventPatch is a partial event for PATCH requests
+type eventPatch struct {
+	Summary     *string     `json:"summary,omitempty"`
+	Description *string     `json:"description,omitempty"`
+	Location    *string     `json:"location,omitempty"`
+	Start       *eventTime  `json:"start,omitempty"`
+	End         *eventTime  `json:"end,omitempty"`
+	Attendees   *[]attendee `json:"attendees,omitempty"`
+}
+
+// UpdateEvent implements calendar.EventUpdater
+func (p *Provider) UpdateEvent(ctx context.Context, id string, update calendar.EventUpdate) (*calendar.Event, error) {
+	body := eventPatch{
+		Summary:     update.Title,
+		Description: update.Description,
+		Location:    update.Location,
+	}
+	if update.Start != nil {
+		body.Start = &eventTime{DateTime: update.Start.Format(time.RFC3339)}
+	}
+	if update.End != nil {
+		body.End = &eventTime{DateTime: update.End.Format(time.RFC3339)}
+	}
+	if update.Attendees != nil {
+		attendees := make([]attendee, 0, len(update.Attendees))
+		for _, email := range update.Attendees {
+			attendees = append(attendees, attendee{Email: email})
+		}
+		body.Attendees = &attendees
+	}
+
+	var updated apiEvent
+	if err := p.do(ctx, http.MethodPatch, p.eventsURL()+"/"+url.PathEscape(id), body, &updated); err != nil {
+		return nil, err
+	}
+	result, err := fromAPI(updated)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+type freeBusyRequest struct {
+	TimeMin string         `json:"timeMin"`
+	TimeMax string         `json:"timeMax"`
+	Items   []freeBusyItem `json:"items"`
+}
+
+type freeBusyItem struct {
+	ID string `json:"id"`
+}
+
+type freeBusyResponse struct {
+	Calendars map[string]struct {
+		Busy []struct {
+			Start time.Time `json:"start"`
+			End   time.Time `json:"end"`
+		} `json:"busy"`
+		Errors []struct {
+			Reason string `json:"reason"`
+		} `json:"errors"`
+	} `json:"calendars"`
+}
+
+// FreeBusy implements calendar.FreeBusyProvider
+func (p *Provider) FreeBusy(ctx context.Context, start, end time.Time, calendars []string) (map[string][]calendar.Slot, error) {
+	body := freeBusyRequest{
+		TimeMin: start.Format(time.RFC3339),
+		TimeMax: end.Format(time.RFC3339),
+	}
+	for _, id := range calendars {
+		body.Items = append(body.Items, freeBusyItem{ID: id})
+	}
+
+	var resp freeBusyResponse
+	if err := p.do(ctx, http.MethodPost, p.base+"/freeBusy", body, &resp); err != nil {
+		return nil, err
+	}
+	busy := make(map[string][]calendar.Slot, len(calendars))
+	for _, id := range calendars {
+		info, ok := resp.Calendars[id]
+		if !ok {
+			return nil, fmt.Errorf("calendar %s: missing from free/busy response", id)
+		}
+		if len(info.Errors) > 0 {
+			return nil, fmt.Errorf("calendar %s: %s", id, info.Errors[0].Reason)
+		}
+		slots := make([]calendar.Slot, 0, len(info.Busy))
+		for _, b := range info.Busy {
+			slots = append(slots, calendar.Slot{Start: b.Start, End: b.End})
+		}
+		busy[id] = slots
+	}
+	return busy, nil
+}
+
+func (p *Provider) eventsURL() string {
+	return fmt.Sprintf("%s/calendars/%s/events", p.base, url.PathEscape(p.calendarID))
+}
+
+func (p *Provider) do(ctx context.Context, method, endpoint string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if p.auth != "" {
+		req.Header.Set("Authorization", p.auth)
+	}
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("google calendar request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("google calendar API error (%d): %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode google calendar response: %w", err)
+	}
+	return nil
+}
+
+func fromAPI(item apiEvent) (calendar.Event, error) {
+	event := calendar.Event{
+		ID:          item.ID,
+		Title:       item.Summary,
+		Description: item.Description,
+		Location:    item.Location,
+		Free:        item.Transparency == "transparent",
+	}
+	var err error
+	if event.Start, event.AllDay, err = parseEventTime(item.Start); err != nil {
+		return event, fmt.Errorf("event %s: invalid start: %w", item.ID, err)
+	}
+	if event.End, _, err = parseEventTime(item.End); err != nil {
+		return event, fmt.Errorf("event %s: invalid end: %w", item.ID, err)
+	}
+	for _, a := range item.Attendees {
+		event.Attendees = append(event.Attendees, a.Email)
+	}
+	return event, nil
+}
+
+// parseEventTime reads a timed (dateTime) or all-day (date) event boundary
+func parseEventTime(t eventTime) (time.Time, bool, error) {
+	if t.DateTime != "" {
+		parsed, err := time.Parse(time.RFC3339, t.DateTime)
+		return parsed, false, err
+	}
+	parsed, err := time.ParseInLocation("2006-01-02", t.Date, time.Local)
+	return parsed, true, err
+}
+
+func toAPI(event calendar.Event) apiEvent {
+	item := apiEvent{
+		Summary:     event.Title,
+		Description: event.Description,
+		Location:    event.Location,
+	}
+	if event.AllDay {
+		item.Start = eventTime{Date: event.Start.Format("2006-01-02")}
+		item.End = eventTime{Date: event.End.Format("2006-01-02")}
+	} else {
+		item.Start = eventTime{DateTime: event.Start.Format(time.RFC3339)}
+		item.End = eventTime{DateTime: event.End.Format(time.RFC3339)}
+	}
+	if event.Free {
+		item.Transparency = "transparent"
+	}
+	for _, email := range event.Attendees {
+		item.Attendees = append(item.Attendees, attendee{Email: email})
+	}
+	return item
+}
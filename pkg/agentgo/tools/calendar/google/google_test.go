@@ -0,0 +1,191 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/calendar"
+)
+
+func TestNew_RequiresAuth(t *testing.T) {
+	if _, err := New(Config{}); err == nil {
+		t.Fatal("expected error without token or client")
+	}
+}
+
+func TestListEvents_Paginates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/calendars/team@example.com/events" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("missing authorization header")
+		}
+		q := r.URL.Query()
+		if q.Get("singleEvents") != "true" || q.Get("timeMin") != "2026-03-02T00:00:00Z" {
+			t.Errorf("query = %v", q)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if q.Get("pageToken") == "" {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"items": []map[string]interface{}{
+					{"id": "1", "summary": "Standup", "start": map[string]string{"dateTime": "2026-03-02T09:00:00Z"},
+						"end": map[string]string{"dateTime": "2026-03-02T09:15:00Z"}, "attendees": []map[string]string{{"email": "a@example.com"}}},
+					{"id": "2", "status": "cancelled", "start": map[string]string{"dateTime": "2026-03-02T10:00:00Z"},
+						"end": map[string]string{"dateTime": "2026-03-02T11:00:00Z"}},
+				},
+				"nextPageToken": "page2",
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"items": []map[string]interface{}{
+				{"id": "3", "summary": "Offsite", "transparency": "transparent",
+					"start": map[string]string{"date": "2026-03-03"}, "end": map[string]string{"date": "2026-03-04"}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	p, err := New(Config{AccessToken: "token", CalendarID: "team@example.com", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	events, err := p.ListEvents(context.Background(),
+		time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("ListEvents() error = %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("events = %+v, want cancelled event skipped", events)
+	}
+	if events[0].Title != "Standup" || events[0].End.Sub(events[0].Start) != 15*time.Minute || events[0].Attendees[0] != "a@example.com" {
+		t.Errorf("events[0] = %+v", events[0])
+	}
+	if !events[1].AllDay || !events[1].Free {
+		t.Errorf("events[1] = %+v", events[1])
+	}
+}
+
+func TestCreateEvent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/calendars/primary/events" {
+			t.Errorf("%s %s", r.Method, r.URL.Path)
+		}
+		var body apiEvent
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if body.Summary != "Review" || body.Start.DateTime != "2026-03-02T15:00:00Z" || len(body.Attendees) != 1 {
+			t.Errorf("body = %+v", body)
+		}
+		body.ID = "evt-1"
+		json.NewEncoder(w).Encode(body)
+	}))
+	defer server.Close()
+
+	p, _ := New(Config{AccessToken: "token", BaseURL: server.URL})
+	created, err := p.CreateEvent(context.Background(), calendar.Event{
+		Title:     "Review",
+		Start:     time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC),
+		End:       time.Date(2026, 3, 2, 15, 30, 0, 0, time.UTC),
+		Attendees: []string{"bob@example.com"},
+	})
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if created.ID != "evt-1" {
+		t.Errorf("created = %+v", created)
+	}
+}
+
+func TestUpdateEvent_PatchesChangedFields(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.Path != "/calendars/primary/events/evt-1" {
+			t.Errorf("%s %s", r.Method, r.URL.Path)
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if len(body) != 3 || body["summary"] != "Design review" || body["start"] == nil || body["end"] == nil {
+			t.Errorf("body = %v, want only summary, start and end", body)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id": "evt-1", "summary": "Design review",
+			"start": body["start"], "end": body["end"],
+		})
+	}))
+	defer server.Close()
+
+	p, _ := New(Config{AccessToken: "token", BaseURL: server.URL})
+	title := "Design review"
+	start := time.Date(2026, 3, 2, 16, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	updated, err := p.UpdateEvent(context.Background(), "evt-1", calendar.EventUpdate{Title: &title, Start: &start, End: &end})
+	if err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+	if updated.Title != title || !updated.Start.Equal(start) {
+		t.Errorf("updated = %+v", updated)
+	}
+}
+
+func TestFreeBusy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/freeBusy" {
+			t.Errorf("%s %s", r.Method, r.URL.Path)
+		}
+		var body freeBusyRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if body.TimeMin != "2026-03-02T00:00:00Z" || len(body.Items) == 0 || body.Items[0].ID != "bob@example.com" {
+			t.Errorf("body = %+v", body)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"calendars": map[string]interface{}{
+				"bob@example.com": map[string]interface{}{
+					"busy": []map[string]string{{"start": "2026-03-02T10:00:00Z", "end": "2026-03-02T11:00:00Z"}},
+				},
+				"eve@example.com": map[string]interface{}{
+					"errors": []map[string]string{{"domain": "global", "reason": "notFound"}},
+				},
+			},
+		})
+	}))
+	defer server.Close()
+
+	p, _ := New(Config{AccessToken: "token", BaseURL: server.URL})
+	start := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	busy, err := p.FreeBusy(context.Background(), start, start.Add(24*time.Hour), []string{"bob@example.com"})
+	if err != nil {
+		t.Fatalf("FreeBusy() error = %v", err)
+	}
+	if slots := busy["bob@example.com"]; len(slots) != 1 || slots[0].Start.Hour() != 10 {
+		t.Errorf("busy = %+v", busy)
+	}
+
+	_, err = p.FreeBusy(context.Background(), start, start.Add(24*time.Hour), []string{"bob@example.com", "eve@example.com"})
+	if err == nil {
+		t.Error("expected an error for a calendar the API could not read")
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	p, _ := New(Config{AccessToken: "token", BaseURL: server.URL})
+	if _, err := p.ListEvents(context.Background(), time.Now(), time.Now().Add(time.Hour)); err == nil {
+		t.Fatal("expected API error")
+	}
+}
@@ -0,0 +1,70 @@
+package calendar
+
+import (
+	"sort"
+	"time"
+)
+
+// Slot is a span of time
+type Slot struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// BusySlots returns the time blocked by events, in order, with overlapping
+// events merged. Events marked Free do not block time.
+func BusySlots(events []Event) []Slot {
+	busy := make([]Slot, 0, len(events))
+	for _, event := range events {
+		if !event.Free && event.End.After(event.Start) {
+			busy = append(busy, Slot{Start: event.Start, End: event.End})
+		}
+	}
+	sort.Slice(busy, func(i, j int) bool { return busy[i].Start.Before(busy[j].Start) })
+
+	// Merge overlapping busy periods.
+	merged := busy[:0]
+	for _, b := range busy {
+		if n := len(merged); n > 0 && !b.Start.After(merged[n-1].End) {
+			if b.End.After(merged[n-1].End) {
+				merged[n-1].End = b.End
+			}
+			continue
+		}
+		merged = append(merged, b)
+	}
+	return merged
+}
+
+// FreeSlots returns the parts of windows not covered by busy events that last
+// at least duration, in order, at most limit of them (0 = no limit). Events
+// marked Free do not block time.
+func FreeSlots(events []Event, windows []Slot, duration time.Duration, limit int) []Slot {
+	merged := BusySlots(events)
+
+	var free []Slot
+	for _, window := range windows {
+		cursor := window.Start
+		for _, b := range merged {
+			if !b.End.After(cursor) {
+				continue
+			}
+			if !b.Start.Before(window.End) {
+				break
+			}
+			if b.Start.Sub(cursor) >= duration {
+				free = append(free, Slot{Start: cursor, End: b.Start})
+			}
+			if b.End.After(cursor) {
+				cursor = b.End
+			}
+		}
+		if window.End.Sub(cursor) >= duration {
+			free = append(free, Slot{Start: cursor, End: window.End})
+		}
+		if limit > 0 && len(free) >= limit {
+			return free[:limit]
+		}
+	}
+	return free
+}
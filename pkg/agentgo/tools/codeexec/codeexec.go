@@ -0,0 +1,273 @@
+// Package codeexec lets agents run model-generated code, such as Python for
+// data analysis, in a sandbox. Sandboxes are pluggable and must be chosen
+// explicitly: DockerSandbox runs a throwaway container with no network,
+// optionally under gVisor, while ProcessSandbox runs a local subprocess with
+// resource limits but no filesystem or network isolation. Output is streamed
+// as it is produced.
+package codeexec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/hooks"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+)
+
+const (
+	defaultTimeout        = 30 * time.Second
+	defaultMaxOutputBytes = 64 * 1024
+)
+
+// MetadataKey is the tool call metadata key holding the *Result of a run
+const MetadataKey = "codeexec"
+
+// Stream identifies an output stream
+type Stream string
+
+const (
+	Stdout Stream = "stdout"
+	Stderr Stream = "stderr"
+)
+
+// OutputFunc receives output as it is produced. Calls are never concurrent,
+// and data is only valid until the call returns.
+type OutputFunc func(stream Stream, data []byte)
+
+// Request is code to run in a sandbox
+type Request struct {
+	Language string
+	Code     string
+
+	// MaxOutputBytes truncates the captured stdout and stderr, each. Output
+	// is still streamed in full.
+	MaxOutputBytes int
+
+	// Output receives output as it is produced (optional)
+	Output OutputFunc
+}
+
+// Result is the outcome of a run
+type Result struct {
+	Language        string        `json:"language"`
+	ExitCode        int           `json:"exit_code"`
+	Stdout          string        `json:"stdout"`
+	Stderr          string        `json:"stderr"`
+	StdoutTruncated bool          `json:"stdout_truncated,omitempty"`
+	StderrTruncated bool          `json:"stderr_truncated,omitempty"`
+	TimedOut        bool          `json:"timed_out,omitempty"`
+	Duration        time.Duration `json:"duration"`
+}
+
+// Sandbox runs code in isolation. Run stops the code when ctx is done and
+// reports a deadline as Result.TimedOut. It returns an error only when the
+// code could not be run; a failing program is a Result with a non-zero
+// ExitCode.
+type Sandbox interface {
+	// Languages returns the languages the sandbox can run
+	Languages() []string
+
+	// Run runs req.Code and returns its captured output
+	Run(ctx context.Context, req Request) (*Result, error)
+}
+
+// ErrUnsupportedLanguage is returned for languages a sandbox cannot run
+var ErrUnsupportedLanguage = errors.New("unsupported language")
+
+// ExitError is returned by the tool when the code exits with a non-zero
+// status or times out. It carries the output, e.g. a traceback, so the model
+// can fix the code.
+type ExitError struct {
+	Result *Result
+}
+
+func (e *ExitError) Error() string {
+	var b strings.Builder
+	if e.Result.TimedOut {
+		fmt.Fprintf(&b, "%s code timed out after %s", e.Result.Language, e.Result.Duration.Round(time.Millisecond))
+	} else {
+		fmt.Fprintf(&b, "%s code exited with code %d", e.Result.Language, e.Result.ExitCode)
+	}
+	if e.Result.Stdout != "" {
+		b.WriteString("\nstdout:\n" + e.Result.Stdout)
+	}
+	if e.Result.Stderr != "" {
+		b.WriteString("\nstderr:\n" + e.Result.Stderr)
+	}
+	return b.String()
+}
+
+// Config configures the code execution toolkit
+type Config struct {
+	// Sandbox runs the code and is required. Use a DockerSandbox for
+	// model-generated code; a ProcessSandbox is not isolated from the host.
+	Sandbox Sandbox
+
+	// Timeout limits each run (default: 30s)
+	Timeout time.Duration
+
+	// MaxOutputBytes truncates stdout and stderr returned to the model, each (default: 64KB)
+	MaxOutputBytes int
+
+	// OnOutput receives output as it is produced, e.g. to show it in a UI.
+	// hooks.ToolHookInputFromContext(ctx) identifies the tool call.
+	OnOutput func(ctx context.Context, stream Stream, data []byte)
+}
+
+// Toolkit runs code on behalf of an agent
+type Toolkit struct {
+	*toolkit.BaseToolkit
+	sandbox   Sandbox
+	timeout   time.Duration
+	maxOutput int
+	onOutput  func(ctx context.Context, stream Stream, data []byte)
+}
+
+// New creates a code execution toolkit
+func New(cfg Config) (*Toolkit, error) {
+	if cfg.Sandbox == nil {
+		return nil, fmt.Errorf("sandbox is required")
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.MaxOutputBytes <= 0 {
+		cfg.MaxOutputBytes = defaultMaxOutputBytes
+	}
+	languages := cfg.Sandbox.Languages()
+	if len(languages) == 0 {
+		return nil, fmt.Errorf("sandbox supports no languages")
+	}
+	sort.Strings(languages)
+
+	t := &Toolkit{
+		BaseToolkit: toolkit.NewBaseToolkit("codeexec"),
+		sandbox:     cfg.Sandbox,
+		timeout:     cfg.Timeout,
+		maxOutput:   cfg.MaxOutputBytes,
+		onOutput:    cfg.OnOutput,
+	}
+
+	t.RegisterFunction(&toolkit.Function{
+		Name: "execute_code",
+		Description: "Run code in an isolated sandbox and return its stdout and stderr. " +
+			"Print the values you need; nothing is kept between runs.",
+		Parameters: map[string]toolkit.Parameter{
+			"language": {Type: "string", Description: "The language of the code", Required: true, Enum: languages},
+			"code":     {Type: "string", Description: "The complete program to run", Required: true},
+		},
+		Handler: t.executeCode,
+	})
+
+	return t, nil
+}
+
+func (t *Toolkit) executeCode(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	language, _ := args["language"].(string)
+	code, ok := args["code"].(string)
+	if !ok || strings.TrimSpace(code) == "" {
+		return nil, fmt.Errorf("code must be a non-empty string")
+	}
+
+	result, err := t.Execute(ctx, language, code)
+	if result != nil {
+		if input := hooks.ToolHookInputFromContext(ctx); input != nil {
+			input.SetMetadata(MetadataKey, result)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Execute runs code in the sandbox. A non-zero exit status or a timeout
+// returns both the result and an *ExitError.
+func (t *Toolkit) Execute(ctx context.Context, language, code string) (*Result, error) {
+	runCtx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	req := Request{Language: language, Code: code, MaxOutputBytes: t.maxOutput}
+	if t.onOutput != nil {
+		req.Output = func(stream Stream, data []byte) { t.onOutput(ctx, stream, data) }
+	}
+	result, err := t.sandbox.Run(runCtx, req)
+	if err != nil {
+		return nil, err
+	}
+	if result.ExitCode != 0 || result.TimedOut {
+		return result, &ExitError{Result: result}
+	}
+	return result, nil
+}
+
+// Output captures the stdout and stderr of a run for sandbox implementations.
+// It streams every write to the request's OutputFunc and keeps up to
+// MaxOutputBytes of each stream.
+type Output struct {
+	mu     sync.Mutex
+	max    int
+	sink   OutputFunc
+	stdout capturedStream
+	stderr capturedStream
+}
+
+type capturedStream struct {
+	buf       bytes.Buffer
+	truncated bool
+}
+
+// NewOutput creates an Output for req
+func NewOutput(req Request) *Output {
+	maxBytes := req.MaxOutputBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxOutputBytes
+	}
+	return &Output{max: maxBytes, sink: req.Output}
+}
+
+// Writer returns the writer for a stream
+func (o *Output) Writer(stream Stream) *StreamWriter {
+	return &StreamWriter{output: o, stream: stream}
+}
+
+// Fill copies the captured output into result
+func (o *Output) Fill(result *Result) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	result.Stdout, result.StdoutTruncated = o.stdout.buf.String(), o.stdout.truncated
+	result.Stderr, result.StderrTruncated = o.stderr.buf.String(), o.stderr.truncated
+}
+
+// StreamWriter is the io.Writer for one stream of an Output
+type StreamWriter struct {
+	output *Output
+	stream Stream
+}
+
+func (w *StreamWriter) Write(p []byte) (int, error) {
+	o := w.output
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	captured := &o.stdout
+	if w.stream == Stderr {
+		captured = &o.stderr
+	}
+	if room := o.max - captured.buf.Len(); len(p) > room {
+		captured.buf.Write(p[:max(room, 0)])
+		captured.truncated = true
+	} else {
+		captured.buf.Write(p)
+	}
+	if o.sink != nil {
+		o.sink(w.stream, p)
+	}
+	return len(p), nil
+}
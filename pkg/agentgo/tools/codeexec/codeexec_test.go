@@ -0,0 +1,181 @@
+package codeexec
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/hooks"
+)
+
+func requireLanguage(t *testing.T, sandbox Sandbox, language string) {
+	t.Helper()
+	for _, l := range sandbox.Languages() {
+		if l == language {
+			return
+		}
+	}
+	t.Skipf("%s is not installed", language)
+}
+
+func TestProcessSandbox_Run(t *testing.T) {
+	sandbox := NewProcessSandbox(ProcessConfig{Env: map[string]string{"GREETING": "hi"}})
+	requireLanguage(t, sandbox, "bash")
+
+	var mu sync.Mutex
+	var streamed []string
+	result, err := sandbox.Run(context.Background(), Request{
+		Language: "bash",
+		Code:     `echo "$GREETING from $(basename "$PWD")"; echo oops >&2; test "$HOME" = "$PWD"`,
+		Output: func(stream Stream, data []byte) {
+			mu.Lock()
+			defer mu.Unlock()
+			streamed = append(streamed, string(stream)+":"+string(data))
+		},
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.ExitCode != 0 || !strings.HasPrefix(result.Stdout, "hi from codeexec-") || result.Stderr != "oops\n" {
+		t.Errorf("result = %+v", result)
+	}
+	if got := strings.Join(streamed, ""); !strings.Contains(got, "stdout:hi from") || !strings.Contains(got, "stderr:oops") {
+		t.Errorf("streamed = %q", streamed)
+	}
+
+	if _, err := sandbox.Run(context.Background(), Request{Language: "cobol", Code: "x"}); !errors.Is(err, ErrUnsupportedLanguage) {
+		t.Errorf("unsupported language error = %v", err)
+	}
+}
+
+func TestProcessSandbox_Python(t *testing.T) {
+	sandbox := NewProcessSandbox(ProcessConfig{})
+	requireLanguage(t, sandbox, "python")
+
+	result, err := sandbox.Run(context.Background(), Request{Language: "python", Code: "print(sum(range(10)))"})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.Stdout != "45\n" {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestProcessSandbox_Limits(t *testing.T) {
+	sandbox := NewProcessSandbox(ProcessConfig{Limits: Limits{MaxFileBytes: 1024}})
+	requireLanguage(t, sandbox, "bash")
+
+	result, err := sandbox.Run(context.Background(), Request{
+		Language: "bash",
+		Code:     "head -c 4096 /dev/zero > big.bin",
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.ExitCode == 0 {
+		t.Errorf("writing past MaxFileBytes succeeded: %+v", result)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	result, err = sandbox.Run(ctx, Request{Language: "bash", Code: "sleep 5 & wait"})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !result.TimedOut || time.Since(start) > 3*time.Second {
+		t.Errorf("timeout result = %+v after %s", result, time.Since(start))
+	}
+}
+
+func TestToolkit_ExecuteCode(t *testing.T) {
+	sandbox := NewProcessSandbox(ProcessConfig{})
+	requireLanguage(t, sandbox, "bash")
+
+	var streamedCallID string
+	tk, err := New(Config{
+		Sandbox:        sandbox,
+		MaxOutputBytes: 4,
+		OnOutput: func(ctx context.Context, stream Stream, data []byte) {
+			streamedCallID = hooks.ToolHookInputFromContext(ctx).ToolCallID
+		},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	fn := tk.Functions()["execute_code"]
+	if enum := fn.Parameters["language"].Enum; len(enum) == 0 {
+		t.Errorf("language enum is empty")
+	}
+
+	input := hooks.NewToolHookInput("agent", "call-1", "execute_code", nil)
+	ctx := hooks.WithToolHookInput(context.Background(), input)
+	result, err := fn.Handler(ctx, map[string]interface{}{"language": "bash", "code": "echo 0123456789"})
+	if err != nil {
+		t.Fatalf("Handler: %v", err)
+	}
+	if r := result.(*Result); r.Stdout != "0123" || !r.StdoutTruncated {
+		t.Errorf("result = %+v", r)
+	}
+	if streamedCallID != "call-1" {
+		t.Errorf("OnOutput tool call = %q", streamedCallID)
+	}
+
+	_, err = fn.Handler(ctx, map[string]interface{}{"language": "bash", "code": "echo bad >&2; exit 2"})
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Result.ExitCode != 2 || !strings.Contains(err.Error(), "bad") {
+		t.Fatalf("error = %v, want *ExitError with stderr", err)
+	}
+	if value, _ := input.GetMetadata(MetadataKey); value != exitErr.Result {
+		t.Errorf("metadata = %v", value)
+	}
+}
+
+type fakeSandbox struct{}
+
+func (fakeSandbox) Languages() []string { return nil }
+
+func (fakeSandbox) Run(ctx context.Context, req Request) (*Result, error) { return nil, nil }
+
+func TestNew_NoLanguages(t *testing.T) {
+	if _, err := New(Config{Sandbox: fakeSandbox{}}); err == nil {
+		t.Error("expected an error for a sandbox without languages")
+	}
+}
+
+func TestNew_RequiresSandbox(t *testing.T) {
+	if _, err := New(Config{}); err == nil {
+		t.Error("expected an error without a sandbox")
+	}
+}
+
+func TestDockerSandbox_RunArgs(t *testing.T) {
+	s := NewDockerSandbox(DockerConfig{Runtime: "runsc"})
+	args := strings.Join(s.runArgs("codeexec-1", DefaultDockerImages["python"]), " ")
+	for _, want := range []string{"run --rm -i --name codeexec-1", "--network none", "--read-only", "--cap-drop ALL", "--runtime runsc", "python:3.12-slim python3 -"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("args %q missing %q", args, want)
+		}
+	}
+}
+
+func TestDockerSandbox_Run(t *testing.T) {
+	if os.Getenv("TEST_DOCKER_CODEEXEC") == "" {
+		t.Skip("set TEST_DOCKER_CODEEXEC=1 to run against a local docker daemon")
+	}
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skip("docker is not installed")
+	}
+	result, err := NewDockerSandbox(DockerConfig{}).Run(context.Background(), Request{Language: "python", Code: "print(6 * 7)"})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.Stdout != "42\n" {
+		t.Errorf("result = %+v", result)
+	}
+}
@@ -0,0 +1,158 @@
+package codeexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/ids"
+)
+
+// DockerImage is the container image and command for one language. The code
+// is passed on stdin.
+type DockerImage struct {
+	Image   string
+	Command []string
+}
+
+// DefaultDockerImages are the languages a DockerSandbox runs by default
+var DefaultDockerImages = map[string]DockerImage{
+	"python":     {Image: "python:3.12-slim", Command: []string{"python3", "-"}},
+	"javascript": {Image: "node:22-slim", Command: []string{"node", "-"}},
+	"bash":       {Image: "bash:5", Command: []string{"bash", "-s"}},
+}
+
+// DockerConfig configures a DockerSandbox
+type DockerConfig struct {
+	// Images maps languages to images (default: DefaultDockerImages)
+	Images map[string]DockerImage
+
+	// Binary is the docker CLI (default: "docker"); podman works too
+	Binary string
+
+	// Runtime is the OCI runtime, e.g. "runsc" for gVisor (default: the daemon's default)
+	Runtime string
+
+	// Network is the container network (default: "none")
+	Network string
+
+	// Memory limits container memory, in docker syntax (default: "512m")
+	Memory string
+
+	// CPUs limits the container's CPUs (default: "1")
+	CPUs string
+
+	// PidsLimit limits the number of processes (default: 128)
+	PidsLimit int
+}
+
+// DockerSandbox runs each piece of code in a new container with no network,
+// a read-only root filesystem and a writable /tmp. Set Runtime to "runsc" to
+// run the container under gVisor for kernel-level isolation.
+type DockerSandbox struct {
+	config DockerConfig
+}
+
+// NewDockerSandbox creates a DockerSandbox
+func NewDockerSandbox(cfg DockerConfig) *DockerSandbox {
+	if cfg.Images == nil {
+		cfg.Images = DefaultDockerImages
+	}
+	if cfg.Binary == "" {
+		cfg.Binary = "docker"
+	}
+	if cfg.Network == "" {
+		cfg.Network = "none"
+	}
+	if cfg.Memory == "" {
+		cfg.Memory = "512m"
+	}
+	if cfg.CPUs == "" {
+		cfg.CPUs = "1"
+	}
+	if cfg.PidsLimit <= 0 {
+		cfg.PidsLimit = 128
+	}
+	return &DockerSandbox{config: cfg}
+}
+
+// Languages implements Sandbox
+func (s *DockerSandbox) Languages() []string {
+	languages := make([]string, 0, len(s.config.Images))
+	for language := range s.config.Images {
+		languages = append(languages, language)
+	}
+	return languages
+}
+
+// Run implements Sandbox
+func (s *DockerSandbox) Run(ctx context.Context, req Request) (*Result, error) {
+	image, ok := s.config.Images[req.Language]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedLanguage, req.Language)
+	}
+
+	name := strings.ToLower(ids.Prefixed("codeexec"))
+	output := NewOutput(req)
+	cmd := exec.CommandContext(ctx, s.config.Binary, s.runArgs(name, image)...)
+	cmd.Stdin = strings.NewReader(req.Code)
+	cmd.Stdout = output.Writer(Stdout)
+	cmd.Stderr = output.Writer(Stderr)
+	cmd.WaitDelay = 5 * time.Second
+	// Killing the CLI leaves the container running, so remove it instead
+	cmd.Cancel = func() error {
+		removeCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := exec.CommandContext(removeCtx, s.config.Binary, "rm", "-f", name).Run(); err != nil {
+			return cmd.Process.Kill()
+		}
+		return nil
+	}
+
+	start := time.Now()
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) && ctx.Err() == nil {
+		return nil, fmt.Errorf("failed to run container: %w", err)
+	}
+	result := &Result{
+		Language: req.Language,
+		ExitCode: cmd.ProcessState.ExitCode(),
+		TimedOut: errors.Is(ctx.Err(), context.DeadlineExceeded),
+		Duration: time.Since(start),
+	}
+	// Exit code 125 means docker itself failed, not the code
+	if result.ExitCode == 125 && !result.TimedOut {
+		output.Fill(result)
+		return nil, fmt.Errorf("docker failed: %s", strings.TrimSpace(result.Stderr))
+	}
+	output.Fill(result)
+	return result, nil
+}
+
+func (s *DockerSandbox) runArgs(name string, image DockerImage) []string {
+	args := []string{
+		"run", "--rm", "-i",
+		"--name", name,
+		"--network", s.config.Network,
+		"--memory", s.config.Memory,
+		"--cpus", s.config.CPUs,
+		"--pids-limit", strconv.Itoa(s.config.PidsLimit),
+		"--read-only",
+		"--tmpfs", "/tmp:rw,size=64m",
+		"--workdir", "/tmp",
+		"--env", "HOME=/tmp",
+		"--cap-drop", "ALL",
+		"--security-opt", "no-new-privileges",
+		"--user", "65534:65534",
+	}
+	if s.config.Runtime != "" {
+		args = append(args, "--runtime", s.config.Runtime)
+	}
+	args = append(args, image.Image)
+	return append(args, image.Command...)
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package codeexec
+
+import "os/exec"
+
+// isolate is a no-op: cancelling kills only the command itself
+func isolate(cmd *exec.Cmd) {}
+
+// withLimits returns the command unchanged: resource limits are only supported on Unix
+func withLimits(path string, args []string, limits Limits) (string, []string, error) {
+	return path, args, nil
+}
@@ -0,0 +1,50 @@
+//go:build unix
+
+package codeexec
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// isolate runs the command in its own process group, so cancelling it also
+// kills the processes it started
+func isolate(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// withLimits returns a command that sets limits with the shell's ulimit and
+// then execs path. Setting them before exec means nothing the code starts can
+// escape them.
+func withLimits(path string, args []string, limits Limits) (string, []string, error) {
+	var script []string
+	if limits.CPUTime > 0 {
+		script = append(script, fmt.Sprintf("ulimit -t %d", (limits.CPUTime+time.Second-1)/time.Second))
+	}
+	if limits.MemoryBytes > 0 {
+		script = append(script, fmt.Sprintf("ulimit -v %d", max(limits.MemoryBytes/1024, 1)))
+	}
+	if limits.MaxFileBytes > 0 {
+		// POSIX sh counts file sizes in 512-byte blocks
+		script = append(script, fmt.Sprintf("ulimit -f %d", max(limits.MaxFileBytes/512, 1)))
+	}
+	if limits.MaxOpenFiles > 0 {
+		script = append(script, fmt.Sprintf("ulimit -n %d", limits.MaxOpenFiles))
+	}
+	if len(script) == 0 {
+		return path, args, nil
+	}
+
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		return "", nil, fmt.Errorf("sh is required to apply limits: %w", err)
+	}
+	script = append(script, `exec "$0" "$@"`)
+	return sh, append([]string{"-c", strings.Join(script, " && "), path}, args...), nil
+}
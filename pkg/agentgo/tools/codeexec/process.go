@@ -0,0 +1,172 @@
+package codeexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/shell"
+)
+
+// Interpreter runs the code of one language in a ProcessSandbox
+type Interpreter struct {
+	// Command is the interpreter and its flags; the script path is appended.
+	// The binary is looked up in PATH.
+	Command []string
+
+	// Extension is the script file extension, e.g. ".py"
+	Extension string
+}
+
+// DefaultInterpreters are the languages a ProcessSandbox runs by default,
+// when their interpreter is installed
+var DefaultInterpreters = map[string]Interpreter{
+	"python":     {Command: []string{"python3", "-I"}, Extension: ".py"},
+	"javascript": {Command: []string{"node"}, Extension: ".js"},
+	"bash":       {Command: []string{"bash"}, Extension: ".sh"},
+}
+
+// Limits are the resource limits of a ProcessSandbox run, set with ulimit
+// before the interpreter starts. They are enforced on Unix only; zero means
+// no limit.
+type Limits struct {
+	CPUTime      time.Duration // CPU time, rounded up to whole seconds
+	MemoryBytes  int64         // Address space
+	MaxFileBytes int64         // Largest file the code may write
+	MaxOpenFiles int64         // Open file descriptors
+}
+
+// DefaultLimits are the limits used when ProcessConfig.Limits is zero
+var DefaultLimits = Limits{
+	CPUTime:      30 * time.Second,
+	MemoryBytes:  2 << 30,
+	MaxFileBytes: 64 << 20,
+	MaxOpenFiles: 256,
+}
+
+// ProcessConfig configures a ProcessSandbox
+type ProcessConfig struct {
+	// Interpreters maps languages to interpreters (default: DefaultInterpreters)
+	Interpreters map[string]Interpreter
+
+	// Limits are the resource limits of each run (default: DefaultLimits)
+	Limits Limits
+
+	// Env is added to the environment, which otherwise holds only PATH, and
+	// HOME and TMPDIR pointing to the run's scratch directory
+	Env map[string]string
+
+	// TempDir is where scratch directories are created (default: os.TempDir())
+	TempDir string
+}
+
+// ProcessSandbox runs code as a local subprocess in a fresh scratch directory
+// with a scrubbed environment and resource limits. It is NOT isolated: the
+// code runs as the host user and can read and write any file that user can
+// and use the network. Only use it for trusted code; use DockerSandbox for
+// model-generated code.
+type ProcessSandbox struct {
+	interpreters map[string]Interpreter
+	paths        map[string]string
+	limits       Limits
+	env          map[string]string
+	tempDir      string
+}
+
+// NewProcessSandbox creates a ProcessSandbox. Languages whose interpreter is
+// not installed are left out.
+func NewProcessSandbox(cfg ProcessConfig) *ProcessSandbox {
+	if cfg.Interpreters == nil {
+		cfg.Interpreters = DefaultInterpreters
+	}
+	if cfg.Limits == (Limits{}) {
+		cfg.Limits = DefaultLimits
+	}
+
+	s := &ProcessSandbox{
+		interpreters: cfg.Interpreters,
+		paths:        make(map[string]string, len(cfg.Interpreters)),
+		limits:       cfg.Limits,
+		env:          cfg.Env,
+		tempDir:      cfg.TempDir,
+	}
+	for language, interpreter := range cfg.Interpreters {
+		if len(interpreter.Command) == 0 {
+			continue
+		}
+		path, err := shell.NewAllowlist(interpreter.Command[0]).Resolve(interpreter.Command[0])
+		if err == nil {
+			s.paths[language] = path
+		}
+	}
+	return s
+}
+
+// Languages implements Sandbox
+func (s *ProcessSandbox) Languages() []string {
+	languages := make([]string, 0, len(s.paths))
+	for language := range s.paths {
+		languages = append(languages, language)
+	}
+	return languages
+}
+
+// Run implements Sandbox
+func (s *ProcessSandbox) Run(ctx context.Context, req Request) (*Result, error) {
+	path, ok := s.paths[req.Language]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedLanguage, req.Language)
+	}
+	interpreter := s.interpreters[req.Language]
+
+	dir, err := os.MkdirTemp(s.tempDir, "codeexec-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	script := filepath.Join(dir, "main"+interpreter.Extension)
+	if err := os.WriteFile(script, []byte(req.Code), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write code: %w", err)
+	}
+
+	output := NewOutput(req)
+	args := append(append([]string(nil), interpreter.Command[1:]...), script)
+	path, args, err = withLimits(path, args, s.limits)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Dir = dir
+	cmd.Env = []string{"PATH=" + os.Getenv("PATH"), "HOME=" + dir, "TMPDIR=" + dir}
+	for name, value := range s.env {
+		cmd.Env = append(cmd.Env, name+"="+value)
+	}
+	cmd.Stdout = output.Writer(Stdout)
+	cmd.Stderr = output.Writer(Stderr)
+	cmd.WaitDelay = time.Second
+	isolate(cmd)
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", interpreter.Command[0], err)
+	}
+	err = cmd.Wait()
+
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) && ctx.Err() == nil {
+		return nil, fmt.Errorf("failed to run code: %w", err)
+	}
+	result := &Result{
+		Language: req.Language,
+		ExitCode: cmd.ProcessState.ExitCode(),
+		TimedOut: errors.Is(ctx.Err(), context.DeadlineExceeded),
+		Duration: time.Since(start),
+	}
+	output.Fill(result)
+	return result, nil
+}
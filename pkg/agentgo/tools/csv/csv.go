@@ -19,6 +19,10 @@ type CSVToolkit struct {
 	*toolkit.BaseToolkit
 }
 
+func init() {
+	toolkit.MustRegister("csv", toolkit.NoOptions(New))
+}
+
 // New creates a new CSV toolkit
 func New() *CSVToolkit {
 	t := &CSVToolkit{
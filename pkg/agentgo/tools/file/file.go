@@ -5,27 +5,118 @@ import (
 	"bytes"
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
 )
 
+const (
+	defaultMaxFileSize    = 10 * 1024 * 1024
+	defaultMaxGlobResults = 1000
+)
+
+// ErrOutsideRoot is returned for paths that resolve outside the root directory,
+// including through symlinks
+var ErrOutsideRoot = errors.New("path is outside the allowed directory")
+
+// ErrReadOnly is returned when writing in read-only mode
+var ErrReadOnly = errors.New("file tools are read-only")
+
+// Config configures FileTools
+type Config struct {
+	// Root confines all operations to this directory. Relative paths are
+	// resolved against it, and paths that escape it, through ".." or
+	// symlinks, are refused. Empty means no restriction.
+	Root string `json:"root,omitempty"`
+
+	// MaxFileSize is the largest file that can be read or written, in bytes (default: 10MB)
+	MaxFileSize int64 `json:"max_file_size,omitempty"`
+
+	// ReadOnly leaves out write_file and delete_file
+	ReadOnly bool `json:"read_only,omitempty"`
+
+	// MaxGlobResults limits the matches returned by glob_files (default: 1000)
+	MaxGlobResults int `json:"max_glob_results,omitempty"`
+}
+
 // FileTools provides file operation capabilities
 type FileTools struct {
 	*toolkit.BaseToolkit
-	baseDir string // Base directory for file operations (for security)
+	baseDir        string // Base directory for file operations (for security)
+	maxFileSize    int64
+	maxGlobResults int
+	readOnly       bool
+}
+
+// init registers "file"; its options are the JSON fields of Config. The root
+// must be an existing directory and defaults to the working directory, so
+// toolkits built by name are always sandboxed.
+func init() {
+	toolkit.MustRegister("file", func(options map[string]interface{}) (toolkit.Toolkit, error) {
+		var cfg Config
+		if err := toolkit.DecodeOptions(options, &cfg); err != nil {
+			return nil, err
+		}
+		if cfg.Root == "" {
+			wd, err := os.Getwd()
+			if err != nil {
+				return nil, fmt.Errorf("resolve working directory: %w", err)
+			}
+			cfg.Root = wd
+		}
+		return NewWithConfig(cfg)
+	})
 }
 
 // New creates a new FileTools instance
 func New() *FileTools {
+	return newFileTools(Config{})
+}
+
+// NewWithBaseDir creates a FileTools instance with a base directory restriction
+func NewWithBaseDir(baseDir string) *FileTools {
+	return newFileTools(Config{Root: baseDir})
+}
+
+// NewWithConfig creates a FileTools instance sandboxed to cfg.Root, which must
+// be an existing directory
+func NewWithConfig(cfg Config) (*FileTools, error) {
+	if cfg.Root == "" {
+		return nil, fmt.Errorf("root directory is required")
+	}
+	info, err := os.Stat(cfg.Root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid root directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("root %s is not a directory", cfg.Root)
+	}
+	return newFileTools(cfg), nil
+}
+
+func newFileTools(cfg Config) *FileTools {
+	if cfg.MaxFileSize <= 0 {
+		cfg.MaxFileSize = defaultMaxFileSize
+	}
+	if cfg.MaxGlobResults <= 0 {
+		cfg.MaxGlobResults = defaultMaxGlobResults
+	}
+
 	ft := &FileTools{
-		BaseToolkit: toolkit.NewBaseToolkit("file_operations"),
-		baseDir:     "", // empty means no restriction
+		BaseToolkit:    toolkit.NewBaseToolkit("file_operations"),
+		baseDir:        rootDir(cfg.Root), // empty means no restriction
+		maxFileSize:    cfg.MaxFileSize,
+		maxGlobResults: cfg.MaxGlobResults,
+		readOnly:       cfg.ReadOnly,
 	}
 
 	ft.RegisterFunction(&toolkit.Function{
@@ -41,50 +132,84 @@ func New() *FileTools {
 		Handler: ft.readFile,
 	})
 
+	if !cfg.ReadOnly {
+		ft.RegisterFunction(&toolkit.Function{
+			Name:        "write_file",
+			Description: "Write content to a file",
+			Parameters: map[string]toolkit.Parameter{
+				"path": {
+					Type:        "string",
+					Description: "Path to the file to write",
+					Required:    true,
+				},
+				"content": {
+					Type:        "string",
+					Description: "Content to write to the file",
+					Required:    true,
+				},
+			},
+			Handler: ft.writeFile,
+		})
+	}
+
 	ft.RegisterFunction(&toolkit.Function{
-		Name:        "write_file",
-		Description: "Write content to a file",
+		Name:        "list_files",
+		Description: "List files in a directory",
 		Parameters: map[string]toolkit.Parameter{
 			"path": {
 				Type:        "string",
-				Description: "Path to the file to write",
-				Required:    true,
-			},
-			"content": {
-				Type:        "string",
-				Description: "Content to write to the file",
+				Description: "Directory path to list files from",
 				Required:    true,
 			},
 		},
-		Handler: ft.writeFile,
+		Handler: ft.listFiles,
 	})
 
 	ft.RegisterFunction(&toolkit.Function{
-		Name:        "list_files",
-		Description: "List files in a directory",
+		Name:        "glob_files",
+		Description: "Find files whose path matches a glob pattern such as *.go or docs/**/*.md",
 		Parameters: map[string]toolkit.Parameter{
-			"path": {
+			"pattern": {
 				Type:        "string",
-				Description: "Directory path to list files from",
+				Description: "Glob pattern relative to the search directory; ** matches any number of directories",
 				Required:    true,
 			},
+			"path": {
+				Type:        "string",
+				Description: "Directory to search in (default: the root directory)",
+			},
 		},
-		Handler: ft.listFiles,
+		Handler: ft.globFiles,
 	})
 
 	ft.RegisterFunction(&toolkit.Function{
-		Name:        "delete_file",
-		Description: "Delete a file",
+		Name:        "stat_file",
+		Description: "Get the size, type, permissions and modification time of a file or directory",
 		Parameters: map[string]toolkit.Parameter{
 			"path": {
 				Type:        "string",
-				Description: "Path to the file to delete",
+				Description: "Path to the file or directory",
 				Required:    true,
 			},
 		},
-		Handler: ft.deleteFile,
+		Handler: ft.statFile,
 	})
 
+	if !cfg.ReadOnly {
+		ft.RegisterFunction(&toolkit.Function{
+			Name:        "delete_file",
+			Description: "Delete a file",
+			Parameters: map[string]toolkit.Parameter{
+				"path": {
+					Type:        "string",
+					Description: "Path to the file to delete",
+					Required:    true,
+				},
+			},
+			Handler: ft.deleteFile,
+		})
+	}
+
 	ft.RegisterFunction(&toolkit.Function{
 		Name:        "file_exists",
 		Description: "Check if a file exists",
@@ -114,24 +239,26 @@ func New() *FileTools {
 	return ft
 }
 
-// NewWithBaseDir creates a FileTools instance with a base directory restriction
-func NewWithBaseDir(baseDir string) *FileTools {
-	ft := New()
-	ft.baseDir = baseDir
-	return ft
-}
-
 func (ft *FileTools) readFile(ctx context.Context, args map[string]interface{}) (interface{}, error) {
 	path, ok := args["path"].(string)
 	if !ok {
 		return nil, fmt.Errorf("path must be a string")
 	}
 
-	if err := ft.validatePath(path); err != nil {
+	resolved, err := ft.resolvePath(path)
+	if err != nil {
 		return nil, err
 	}
 
-	content, err := os.ReadFile(path)
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if info.Size() > ft.maxFileSize {
+		return nil, fmt.Errorf("file %s is %d bytes, more than the %d byte limit", path, info.Size(), ft.maxFileSize)
+	}
+
+	content, err := os.ReadFile(resolved)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
@@ -144,6 +271,10 @@ func (ft *FileTools) readFile(ctx context.Context, args map[string]interface{})
 }
 
 func (ft *FileTools) writeFile(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	if ft.readOnly {
+		return nil, ErrReadOnly
+	}
+
 	path, ok := args["path"].(string)
 	if !ok {
 		return nil, fmt.Errorf("path must be a string")
@@ -153,18 +284,22 @@ func (ft *FileTools) writeFile(ctx context.Context, args map[string]interface{})
 	if !ok {
 		return nil, fmt.Errorf("content must be a string")
 	}
+	if int64(len(content)) > ft.maxFileSize {
+		return nil, fmt.Errorf("content is %d bytes, more than the %d byte limit", len(content), ft.maxFileSize)
+	}
 
-	if err := ft.validatePath(path); err != nil {
+	resolved, err := ft.resolvePath(path)
+	if err != nil {
 		return nil, err
 	}
 
 	// Create directory if it doesn't exist
-	dir := filepath.Dir(path)
+	dir := filepath.Dir(resolved)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+	if err := os.WriteFile(resolved, []byte(content), 0644); err != nil {
 		return nil, fmt.Errorf("failed to write file: %w", err)
 	}
 
@@ -181,11 +316,12 @@ func (ft *FileTools) listFiles(ctx context.Context, args map[string]interface{})
 		return nil, fmt.Errorf("path must be a string")
 	}
 
-	if err := ft.validatePath(path); err != nil {
+	resolved, err := ft.resolvePath(path)
+	if err != nil {
 		return nil, err
 	}
 
-	entries, err := os.ReadDir(path)
+	entries, err := os.ReadDir(resolved)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read directory: %w", err)
 	}
@@ -211,17 +347,112 @@ func (ft *FileTools) listFiles(ctx context.Context, args map[string]interface{})
 	}, nil
 }
 
+func (ft *FileTools) globFiles(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	pattern, ok := args["pattern"].(string)
+	if !ok || pattern == "" {
+		return nil, fmt.Errorf("pattern must be a non-empty string")
+	}
+	pattern = filepath.ToSlash(pattern)
+	if _, err := path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	dir, _ := args["path"].(string)
+	if dir == "" {
+		dir = "."
+	}
+	resolved, err := ft.resolvePath(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []string{}
+	truncated := false
+	err = filepath.WalkDir(resolved, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Skip unreadable entries instead of failing the whole search
+			if p != resolved && d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if p == resolved {
+			return nil
+		}
+		rel, err := filepath.Rel(resolved, p)
+		if err != nil {
+			return nil
+		}
+		if matchGlob(pattern, filepath.ToSlash(rel)) {
+			if len(matches) == ft.maxGlobResults {
+				truncated = true
+				return fs.SkipAll
+			}
+			matches = append(matches, filepath.Join(dir, rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search %s: %w", dir, err)
+	}
+
+	return map[string]interface{}{
+		"pattern":   pattern,
+		"path":      dir,
+		"matches":   matches,
+		"count":     len(matches),
+		"truncated": truncated,
+	}, nil
+}
+
+func (ft *FileTools) statFile(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	path, ok := args["path"].(string)
+	if !ok {
+		return nil, fmt.Errorf("path must be a string")
+	}
+
+	resolved, err := ft.resolvePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	return map[string]interface{}{
+		"path":     path,
+		"name":     info.Name(),
+		"is_dir":   info.IsDir(),
+		"size":     info.Size(),
+		"mode":     info.Mode().String(),
+		"modified": info.ModTime().UTC().Format(time.RFC3339),
+	}, nil
+}
+
 func (ft *FileTools) deleteFile(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	if ft.readOnly {
+		return nil, ErrReadOnly
+	}
+
 	path, ok := args["path"].(string)
 	if !ok {
 		return nil, fmt.Errorf("path must be a string")
 	}
 
-	if err := ft.validatePath(path); err != nil {
+	resolved, err := ft.resolvePath(path)
+	if err != nil {
 		return nil, err
 	}
+	if resolved == ft.baseDir {
+		return nil, fmt.Errorf("cannot delete the root directory")
+	}
 
-	if err := os.Remove(path); err != nil {
+	if err := os.Remove(resolved); err != nil {
 		return nil, fmt.Errorf("failed to delete file: %w", err)
 	}
 
@@ -238,7 +469,8 @@ func (ft *FileTools) fileExists(ctx context.Context, args map[string]interface{}
 		return nil, fmt.Errorf("path must be a string")
 	}
 
-	if err := ft.validatePath(path); err != nil {
+	resolved, err := ft.resolvePath(path)
+	if err != nil {
 		return map[string]interface{}{
 			"path":   path,
 			"exists": false,
@@ -246,7 +478,7 @@ func (ft *FileTools) fileExists(ctx context.Context, args map[string]interface{}
 		}, nil
 	}
 
-	info, err := os.Stat(path)
+	info, err := os.Stat(resolved)
 	exists := err == nil
 
 	result := map[string]interface{}{
@@ -268,11 +500,20 @@ func (ft *FileTools) readPPTX(ctx context.Context, args map[string]interface{})
 		return nil, fmt.Errorf("path must be a string")
 	}
 
-	if err := ft.validatePath(path); err != nil {
+	resolved, err := ft.resolvePath(path)
+	if err != nil {
 		return nil, err
 	}
 
-	reader, err := zip.OpenReader(path)
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pptx: %w", err)
+	}
+	if info.Size() > ft.maxFileSize {
+		return nil, fmt.Errorf("file %s is %d bytes, more than the %d byte limit", path, info.Size(), ft.maxFileSize)
+	}
+
+	reader, err := zip.OpenReader(resolved)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open pptx: %w", err)
 	}
@@ -300,16 +541,23 @@ func (ft *FileTools) readPPTX(ctx context.Context, args map[string]interface{})
 
 	slides := make([]map[string]interface{}, 0, len(slideFiles))
 
+	// The decompressed slides share the file size limit, so a small archive
+	// cannot expand into an unbounded read
+	remaining := ft.maxFileSize
 	for idx, entry := range slideFiles {
 		rc, err := entry.file.Open()
 		if err != nil {
 			return nil, fmt.Errorf("failed to open slide %s: %w", entry.name, err)
 		}
-		data, err := io.ReadAll(rc)
+		data, err := io.ReadAll(io.LimitReader(rc, remaining+1))
 		rc.Close()
 		if err != nil {
 			return nil, fmt.Errorf("failed to read slide %s: %w", entry.name, err)
 		}
+		if int64(len(data)) > remaining {
+			return nil, fmt.Errorf("slides of %s are more than the %d byte limit when decompressed", path, ft.maxFileSize)
+		}
+		remaining -= int64(len(data))
 
 		text := extractSlideText(data)
 		slides = append(slides, map[string]interface{}{
@@ -378,24 +626,99 @@ func extractSlideText(data []byte) string {
 
 // validatePath checks if the path is allowed
 func (ft *FileTools) validatePath(path string) error {
+	_, err := ft.resolvePath(path)
+	return err
+}
+
+// resolvePath returns the path to operate on. With a base directory, relative
+// paths are resolved against it and symlinks are followed, so a link inside
+// the base directory cannot point outside of it.
+func (ft *FileTools) resolvePath(path string) (string, error) {
 	if ft.baseDir == "" {
-		return nil
+		return path, nil
 	}
 
-	absPath, err := filepath.Abs(path)
-	if err != nil {
-		return fmt.Errorf("invalid path: %w", err)
+	resolved := path
+	if !filepath.IsAbs(resolved) {
+		resolved = filepath.Join(ft.baseDir, resolved)
+	}
+	resolved = filepath.Clean(resolved)
+	if !within(ft.baseDir, resolved) {
+		return "", fmt.Errorf("%w: %s", ErrOutsideRoot, path)
 	}
 
-	absBaseDir, err := filepath.Abs(ft.baseDir)
-	if err != nil {
-		return fmt.Errorf("invalid base directory: %w", err)
+	// Resolve symlinks in the longest existing prefix; the rest does not exist yet
+	existing, rest := resolved, ""
+	for {
+		target, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			resolved = filepath.Join(target, rest)
+			break
+		}
+		// A broken symlink could still be written through to anywhere
+		if info, lerr := os.Lstat(existing); lerr == nil && info.Mode()&os.ModeSymlink != 0 {
+			return "", fmt.Errorf("%s is a broken symlink", path)
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
 	}
+	if !within(ft.baseDir, resolved) {
+		return "", fmt.Errorf("%w: %s", ErrOutsideRoot, path)
+	}
+
+	return resolved, nil
+}
 
-	relPath, err := filepath.Rel(absBaseDir, absPath)
-	if err != nil || filepath.IsAbs(relPath) || len(relPath) > 0 && relPath[0] == '.' {
-		return fmt.Errorf("path %s is outside allowed directory", path)
+// rootDir returns the absolute, symlink-free form of root
+func rootDir(root string) string {
+	if root == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	if target, err := filepath.EvalSymlinks(root); err == nil {
+		root = target
+	}
+	return filepath.Clean(root)
+}
+
+// within reports whether path is dir or inside it
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
 	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// matchGlob matches a slash-separated name against pattern, where a "**"
+// segment matches any number of directories
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
 
-	return nil
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
 }
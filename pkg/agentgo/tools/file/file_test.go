@@ -3,10 +3,14 @@ package file
 import (
 	"archive/zip"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
 )
 
 func TestNew(t *testing.T) {
@@ -16,7 +20,7 @@ func TestNew(t *testing.T) {
 	}
 
 	funcs := ft.Functions()
-	expectedFuncs := []string{"read_file", "write_file", "list_files", "delete_file", "file_exists", "read_pptx"}
+	expectedFuncs := []string{"read_file", "write_file", "list_files", "glob_files", "stat_file", "delete_file", "file_exists", "read_pptx"}
 
 	for _, name := range expectedFuncs {
 		if _, exists := funcs[name]; !exists {
@@ -203,6 +207,34 @@ func TestReadPPTX(t *testing.T) {
 	}
 }
 
+func TestReadPPTX_MaxFileSize(t *testing.T) {
+	root := t.TempDir()
+	if err := createTestPPTX(filepath.Join(root, "deck.pptx"), []string{strings.Repeat("x", 4096)}); err != nil {
+		t.Fatalf("failed to create test pptx: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(root, "deck.pptx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The archive fits but its slides expand beyond the limit
+	ft, err := NewWithConfig(Config{Root: root, MaxFileSize: info.Size() + 1})
+	if err != nil {
+		t.Fatalf("NewWithConfig: %v", err)
+	}
+	if _, err := ft.readPPTX(context.Background(), map[string]interface{}{"path": "deck.pptx"}); err == nil || !strings.Contains(err.Error(), "decompressed") {
+		t.Errorf("readPPTX error = %v, want the decompressed size refused", err)
+	}
+
+	ft, err = NewWithConfig(Config{Root: root, MaxFileSize: info.Size() - 1})
+	if err != nil {
+		t.Fatalf("NewWithConfig: %v", err)
+	}
+	if _, err := ft.readPPTX(context.Background(), map[string]interface{}{"path": "deck.pptx"}); err == nil || !strings.Contains(err.Error(), "byte limit") {
+		t.Errorf("readPPTX error = %v, want the file size refused", err)
+	}
+}
+
 func createTestPPTX(path string, slides []string) error {
 	file, err := os.Create(path)
 	if err != nil {
@@ -280,3 +312,177 @@ func TestValidatePath(t *testing.T) {
 		})
 	}
 }
+
+func TestNewWithConfig_Sandbox(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "new.txt"), filepath.Join(root, "dangling")); err != nil {
+		t.Fatal(err)
+	}
+
+	ft, err := NewWithConfig(Config{Root: root, MaxFileSize: 16})
+	if err != nil {
+		t.Fatalf("NewWithConfig: %v", err)
+	}
+	ctx := context.Background()
+
+	if _, err := ft.writeFile(ctx, map[string]interface{}{"path": "docs/.notes", "content": "hello"}); err != nil {
+		t.Fatalf("writeFile relative path: %v", err)
+	}
+	result, err := ft.readFile(ctx, map[string]interface{}{"path": filepath.Join(root, "docs", ".notes")})
+	if err != nil || result.(map[string]interface{})["content"] != "hello" {
+		t.Fatalf("readFile = %v, %v", result, err)
+	}
+
+	escapes := []string{"../secret.txt", filepath.Join(outside, "secret.txt"), "escape/secret.txt", "escape/new.txt", "dangling"}
+	for _, path := range escapes {
+		if _, err := ft.readFile(ctx, map[string]interface{}{"path": path}); err == nil {
+			t.Errorf("readFile(%s) succeeded outside the root", path)
+		}
+		if _, err := ft.writeFile(ctx, map[string]interface{}{"path": path, "content": "x"}); err == nil {
+			t.Errorf("writeFile(%s) succeeded outside the root", path)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outside, "new.txt")); !os.IsNotExist(err) {
+		t.Error("a file was written outside the root")
+	}
+	if _, err := ft.readFile(ctx, map[string]interface{}{"path": "escape/secret.txt"}); !errors.Is(err, ErrOutsideRoot) {
+		t.Errorf("symlink escape error = %v, want ErrOutsideRoot", err)
+	}
+
+	if _, err := ft.writeFile(ctx, map[string]interface{}{"path": "big.txt", "content": strings.Repeat("x", 17)}); err == nil {
+		t.Error("expected an error writing more than MaxFileSize")
+	}
+	if err := os.WriteFile(filepath.Join(root, "big.txt"), []byte(strings.Repeat("x", 17)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ft.readFile(ctx, map[string]interface{}{"path": "big.txt"}); err == nil {
+		t.Error("expected an error reading more than MaxFileSize")
+	}
+	if _, err := ft.deleteFile(ctx, map[string]interface{}{"path": "."}); err == nil {
+		t.Error("expected an error deleting the root")
+	}
+
+	if _, err := NewWithConfig(Config{Root: filepath.Join(root, "missing")}); err == nil {
+		t.Error("expected an error for a missing root")
+	}
+}
+
+func TestNewWithConfig_ReadOnly(t *testing.T) {
+	root := t.TempDir()
+	ft, err := NewWithConfig(Config{Root: root, ReadOnly: true})
+	if err != nil {
+		t.Fatalf("NewWithConfig: %v", err)
+	}
+
+	funcs := ft.Functions()
+	for _, name := range []string{"write_file", "delete_file"} {
+		if _, exists := funcs[name]; exists {
+			t.Errorf("%s registered in read-only mode", name)
+		}
+	}
+	for _, name := range []string{"read_file", "list_files", "glob_files", "stat_file"} {
+		if _, exists := funcs[name]; !exists {
+			t.Errorf("%s not registered in read-only mode", name)
+		}
+	}
+
+	_, err = ft.writeFile(context.Background(), map[string]interface{}{"path": "a.txt", "content": "x"})
+	if !errors.Is(err, ErrReadOnly) {
+		t.Errorf("writeFile error = %v, want ErrReadOnly", err)
+	}
+}
+
+func TestGlobFiles(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"main.go", "README.md", "docs/guide.md", "docs/api/tools.md", "pkg/file.go"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ft, err := NewWithConfig(Config{Root: root, MaxGlobResults: 2})
+	if err != nil {
+		t.Fatalf("NewWithConfig: %v", err)
+	}
+
+	tests := []struct {
+		pattern   string
+		path      string
+		want      []string
+		truncated bool
+	}{
+		{pattern: "*.go", want: []string{"main.go"}},
+		{pattern: "**/*.go", want: []string{"main.go", "pkg/file.go"}},
+		{pattern: "docs/**/*.md", want: []string{"docs/api/tools.md", "docs/guide.md"}},
+		{pattern: "*.md", path: "docs", want: []string{"docs/guide.md"}},
+		{pattern: "**", want: []string{"README.md", "docs"}, truncated: true},
+	}
+	for _, tt := range tests {
+		result, err := ft.globFiles(context.Background(), map[string]interface{}{"pattern": tt.pattern, "path": tt.path})
+		if err != nil {
+			t.Fatalf("globFiles(%s): %v", tt.pattern, err)
+		}
+		resultMap := result.(map[string]interface{})
+		got := resultMap["matches"].([]string)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") || resultMap["truncated"] != tt.truncated {
+			t.Errorf("globFiles(%s) = %v (truncated %v), want %v", tt.pattern, got, resultMap["truncated"], tt.want)
+		}
+	}
+
+	if _, err := ft.globFiles(context.Background(), map[string]interface{}{"pattern": "*", "path": ".."}); err == nil {
+		t.Error("expected an error searching outside the root")
+	}
+}
+
+func TestStatFile(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("abc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ft := NewWithBaseDir(root)
+
+	result, err := ft.statFile(context.Background(), map[string]interface{}{"path": "a.txt"})
+	if err != nil {
+		t.Fatalf("statFile: %v", err)
+	}
+	resultMap := result.(map[string]interface{})
+	if resultMap["size"] != int64(3) || resultMap["is_dir"] != false || resultMap["name"] != "a.txt" || resultMap["modified"] == "" {
+		t.Errorf("statFile = %v", resultMap)
+	}
+}
+
+func TestRegistry_DefaultsRootToWorkingDirectory(t *testing.T) {
+	wd := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(outside, []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(wd)
+
+	tk, err := toolkit.DefaultRegistry.New("file", nil)
+	if err != nil {
+		t.Fatalf("DefaultRegistry.New: %v", err)
+	}
+	ft := tk.(*FileTools)
+	ctx := context.Background()
+	if _, err := ft.writeFile(ctx, map[string]interface{}{"path": "notes.txt", "content": "hello"}); err != nil {
+		t.Fatalf("writeFile in the working directory: %v", err)
+	}
+	if _, err := ft.readFile(ctx, map[string]interface{}{"path": outside}); !errors.Is(err, ErrOutsideRoot) {
+		t.Errorf("readFile outside the working directory = %v, want ErrOutsideRoot", err)
+	}
+	if _, err := ft.deleteFile(ctx, map[string]interface{}{"path": outside}); !errors.Is(err, ErrOutsideRoot) {
+		t.Errorf("deleteFile outside the working directory = %v, want ErrOutsideRoot", err)
+	}
+}
@@ -20,6 +20,10 @@ type HackerNewsToolkit struct {
 	baseURL string
 }
 
+func init() {
+	toolkit.MustRegister("hackernews", toolkit.NoOptions(New))
+}
+
 // New creates a new HackerNews toolkit
 func New() *HackerNewsToolkit {
 	t := &HackerNewsToolkit{
@@ -11,7 +11,9 @@ import (
 	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
 )
 
-// HTTPToolkit provides HTTP request capabilities
+// HTTPToolkit provides HTTP request capabilities. It does not validate URLs
+// or block private addresses; use the httptool package, registered as "http",
+// for agents that handle untrusted input.
 type HTTPToolkit struct {
 	*toolkit.BaseToolkit
 	client *http.Client
@@ -0,0 +1,299 @@
+// Package httptool lets agents make GET, POST and PUT requests with SSRF
+// protection. Every URL, including redirects, is checked with a
+// guardrails.URLValidationGuardrail, and connections to private, loopback and
+// link-local addresses are refused after DNS resolution unless the URL
+// validation config allows private IPs.
+package httptool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"text/template"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/guardrails"
+	"github.com/jholhewres/agent-go/pkg/agentgo/structured"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+)
+
+const (
+	defaultTimeout          = 30 * time.Second
+	defaultMaxResponseBytes = 1 << 20
+	defaultMaxRedirects     = 5
+)
+
+// Config configures the HTTP request toolkit
+type Config struct {
+	// URLValidation is the guardrail config every URL must pass. The zero value
+	// blocks private IPs and file:// URLs.
+	URLValidation guardrails.URLValidationConfig
+
+	// Methods are the request methods to offer as tools: GET, POST and PUT (default: all three)
+	Methods []string
+
+	// Headers are added to every request. Values are text/template templates
+	// executed with Vars, plus an env function, e.g. "Bearer {{.token}}" or
+	// `Bearer {{env "API_TOKEN"}}`, so secrets never pass through the model.
+	// They are dropped when a redirect leads to another host.
+	Headers map[string]string
+
+	// Vars are the data for header templates
+	Vars map[string]string
+
+	// BodySchema is a JSON Schema that POST and PUT bodies must match (optional)
+	BodySchema map[string]interface{}
+
+	// MaxResponseBytes truncates longer response bodies (default: 1 MiB)
+	MaxResponseBytes int64
+
+	// MaxRedirects limits followed redirects (default: 5)
+	MaxRedirects int
+
+	// Timeout limits each request (default: 30s)
+	Timeout time.Duration
+}
+
+// Toolkit makes HTTP requests on behalf of an agent
+type Toolkit struct {
+	*toolkit.BaseToolkit
+	client     *http.Client
+	validator  *guardrails.URLValidationGuardrail
+	headers    map[string]*template.Template
+	vars       map[string]string
+	bodySchema map[string]interface{}
+	maxBytes   int64
+}
+
+var templateFuncs = template.FuncMap{"env": os.Getenv}
+
+// init registers "http". Its options are allowed_domains, blocked_domains,
+// methods, max_response_bytes and timeout_seconds; private addresses are
+// always refused.
+func init() {
+	toolkit.MustRegister("http", func(options map[string]interface{}) (toolkit.Toolkit, error) {
+		var opts struct {
+			AllowedDomains   []string `json:"allowed_domains"`
+			BlockedDomains   []string `json:"blocked_domains"`
+			Methods          []string `json:"methods"`
+			MaxResponseBytes int64    `json:"max_response_bytes"`
+			TimeoutSeconds   int      `json:"timeout_seconds"`
+		}
+		if err := toolkit.DecodeOptions(options, &opts); err != nil {
+			return nil, err
+		}
+		tk, err := New(Config{
+			URLValidation: guardrails.URLValidationConfig{
+				AllowedDomains: opts.AllowedDomains,
+				BlockedDomains: opts.BlockedDomains,
+			},
+			Methods:          opts.Methods,
+			MaxResponseBytes: opts.MaxResponseBytes,
+			Timeout:          time.Duration(opts.TimeoutSeconds) * time.Second,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return tk, nil
+	})
+}
+
+// New creates an HTTP request toolkit
+func New(cfg Config) (*Toolkit, error) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.MaxResponseBytes <= 0 {
+		cfg.MaxResponseBytes = defaultMaxResponseBytes
+	}
+	if cfg.MaxRedirects <= 0 {
+		cfg.MaxRedirects = defaultMaxRedirects
+	}
+	if len(cfg.Methods) == 0 {
+		cfg.Methods = []string{http.MethodGet, http.MethodPost, http.MethodPut}
+	}
+
+	t := &Toolkit{
+		BaseToolkit: toolkit.NewBaseToolkit("httptool"),
+		validator:   guardrails.NewURLValidationGuardrail(cfg.URLValidation),
+		headers:     make(map[string]*template.Template, len(cfg.Headers)),
+		vars:        cfg.Vars,
+		bodySchema:  cfg.BodySchema,
+		maxBytes:    cfg.MaxResponseBytes,
+	}
+	for name, value := range cfg.Headers {
+		tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template for header %s: %w", name, err)
+		}
+		t.headers[name] = tmpl
+	}
+
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.URLValidation.AllowPrivateIPs {
+		dialer.Control = refusePrivateAddresses
+	}
+	t.client = &http.Client{
+		Timeout: cfg.Timeout,
+		Transport: &http.Transport{
+			// No proxy: the proxy would make the connection instead of the checked dialer
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > cfg.MaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", cfg.MaxRedirects)
+			}
+			// Configured headers carry credentials for the requested host only
+			if req.URL.Host != via[0].URL.Host {
+				for name := range t.headers {
+					req.Header.Del(name)
+				}
+			}
+			return t.validateURL(req.Context(), req.URL.String())
+		},
+	}
+
+	for _, method := range cfg.Methods {
+		method = strings.ToUpper(method)
+		switch method {
+		case http.MethodGet:
+			t.RegisterFunction(&toolkit.Function{
+				Name:        "http_get",
+				Description: "Make an HTTP GET request to a URL and return the status, headers and body",
+				Parameters: map[string]toolkit.Parameter{
+					"url": {Type: "string", Description: "The http or https URL to request", Required: true},
+				},
+				Handler: t.handler(method),
+			})
+		case http.MethodPost, http.MethodPut:
+			t.RegisterFunction(&toolkit.Function{
+				Name:        "http_" + strings.ToLower(method),
+				Description: fmt.Sprintf("Make an HTTP %s request with a JSON body and return the status, headers and body", method),
+				Parameters: map[string]toolkit.Parameter{
+					"url":  {Type: "string", Description: "The http or https URL to request", Required: true},
+					"body": {Type: "string", Description: "The JSON request body", Required: false},
+				},
+				Handler: t.handler(method),
+			})
+		default:
+			return nil, fmt.Errorf("unsupported method %s (want GET, POST or PUT)", method)
+		}
+	}
+
+	return t, nil
+}
+
+func (t *Toolkit) handler(method string) toolkit.HandlerFunc {
+	return func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		rawURL, ok := args["url"].(string)
+		if !ok || rawURL == "" {
+			return nil, fmt.Errorf("url must be a non-empty string")
+		}
+		if err := t.validateURL(ctx, rawURL); err != nil {
+			return nil, err
+		}
+
+		var body io.Reader
+		if bodyStr, _ := args["body"].(string); method != http.MethodGet {
+			if t.bodySchema != nil {
+				if err := structured.ValidateJSON(t.bodySchema, []byte(bodyStr)); err != nil {
+					return nil, fmt.Errorf("invalid request body: %w", err)
+				}
+			}
+			if bodyStr != "" {
+				body = strings.NewReader(bodyStr)
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		for name, tmpl := range t.headers {
+			var value bytes.Buffer
+			if err := tmpl.Execute(&value, t.vars); err != nil {
+				return nil, fmt.Errorf("failed to render header %s: %w", name, err)
+			}
+			req.Header.Set(name, value.String())
+		}
+
+		resp, err := t.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+		defer resp.Body.Close()
+
+		data, err := io.ReadAll(io.LimitReader(resp.Body, t.maxBytes+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		truncated := int64(len(data)) > t.maxBytes
+		if truncated {
+			data = data[:t.maxBytes]
+		}
+
+		headers := make(map[string]string, len(resp.Header))
+		for name := range resp.Header {
+			headers[name] = resp.Header.Get(name)
+		}
+		return map[string]interface{}{
+			"status_code": resp.StatusCode,
+			"headers":     headers,
+			"body":        string(data),
+			"truncated":   truncated,
+		}, nil
+	}
+}
+
+// validateURL allows http and https URLs that pass the URL validation guardrail
+func (t *Toolkit) validateURL(ctx context.Context, rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("url scheme %q is not allowed (want http or https)", parsed.Scheme)
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("url has no host")
+	}
+	if err := t.validator.Check(ctx, &guardrails.CheckInput{Input: rawURL}); err != nil {
+		return fmt.Errorf("url rejected: %w", err)
+	}
+	return nil
+}
+
+// ErrPrivateAddress is returned when a request would connect to a private,
+// loopback or link-local address
+var ErrPrivateAddress = errors.New("connections to private addresses are not allowed")
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), often used internally
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// refusePrivateAddresses runs after DNS resolution, so host names that resolve
+// to internal addresses are refused too
+func refusePrivateAddresses(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() ||
+		sharedAddressSpace.Contains(ip) {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+	}
+	return nil
+}
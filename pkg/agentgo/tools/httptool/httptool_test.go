@@ -0,0 +1,228 @@
+package httptool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/guardrails"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+)
+
+// localConfig allows the loopback test server
+func localConfig() Config {
+	return Config{URLValidation: guardrails.URLValidationConfig{AllowPrivateIPs: true}}
+}
+
+func call(t *testing.T, tk *Toolkit, fn string, args map[string]interface{}) (map[string]interface{}, error) {
+	t.Helper()
+	f, ok := tk.Functions()[fn]
+	if !ok {
+		t.Fatalf("function %s not registered", fn)
+	}
+	result, err := f.Handler(context.Background(), args)
+	if err != nil {
+		return nil, err
+	}
+	return result.(map[string]interface{}), nil
+}
+
+func TestNew_Functions(t *testing.T) {
+	tk, err := New(Config{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for _, name := range []string{"http_get", "http_post", "http_put"} {
+		if _, ok := tk.Functions()[name]; !ok {
+			t.Errorf("%s not registered", name)
+		}
+	}
+
+	tk, err = New(Config{Methods: []string{"get"}})
+	if err != nil || len(tk.Functions()) != 1 {
+		t.Errorf("GET only: %v, %d functions", err, len(tk.Functions()))
+	}
+	if _, err := New(Config{Methods: []string{"DELETE"}}); err == nil {
+		t.Error("expected an error for DELETE")
+	}
+	if _, err := New(Config{Headers: map[string]string{"X": "{{"}}); err == nil {
+		t.Error("expected an error for an invalid header template")
+	}
+}
+
+func TestToolkit_Requests(t *testing.T) {
+	t.Setenv("HTTPTOOL_TEST_TOKEN", "from-env")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Method", r.Method)
+		w.Write([]byte(r.Header.Get("Authorization") + "|" + r.Header.Get("X-Env") + "|" + string(body)))
+	}))
+	defer server.Close()
+
+	cfg := localConfig()
+	cfg.Headers = map[string]string{"Authorization": "Bearer {{.token}}", "X-Env": `{{env "HTTPTOOL_TEST_TOKEN"}}`}
+	cfg.Vars = map[string]string{"token": "secret"}
+	cfg.BodySchema = map[string]interface{}{
+		"type":     "object",
+		"required": []string{"name"},
+	}
+	tk, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	out, err := call(t, tk, "http_get", map[string]interface{}{"url": server.URL})
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	if out["status_code"] != 200 || out["body"] != "Bearer secret|from-env|" || out["truncated"] != false {
+		t.Errorf("GET = %+v", out)
+	}
+
+	out, err = call(t, tk, "http_put", map[string]interface{}{"url": server.URL, "body": `{"name":"x"}`})
+	if err != nil {
+		t.Fatalf("PUT: %v", err)
+	}
+	if out["body"] != `Bearer secret|from-env|{"name":"x"}` || out["headers"].(map[string]string)["X-Method"] != "PUT" {
+		t.Errorf("PUT = %+v", out)
+	}
+
+	if _, err := call(t, tk, "http_post", map[string]interface{}{"url": server.URL, "body": `{"other":1}`}); err == nil || !strings.Contains(err.Error(), "invalid request body") {
+		t.Errorf("body schema error = %v", err)
+	}
+}
+
+func TestToolkit_ResponseLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("a", 100)))
+	}))
+	defer server.Close()
+
+	cfg := localConfig()
+	cfg.MaxResponseBytes = 10
+	tk, _ := New(cfg)
+	out, err := call(t, tk, "http_get", map[string]interface{}{"url": server.URL})
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	if out["body"] != strings.Repeat("a", 10) || out["truncated"] != true {
+		t.Errorf("GET = %+v", out)
+	}
+}
+
+func TestToolkit_BlocksPrivateAddressesByDefault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached the private server")
+	}))
+	defer server.Close()
+	port := server.Listener.Addr().(*net.TCPAddr).Port
+
+	tk, err := New(Config{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for _, rawURL := range []string{
+		server.URL,
+		fmt.Sprintf("http://localhost:%d/", port),
+		// Not caught by the guardrail's host patterns; refused when dialing
+		fmt.Sprintf("http://[::ffff:127.0.0.1]:%d/", port),
+		"http://169.254.169.254/latest/meta-data/",
+		"file:///etc/passwd",
+		"ftp://example.org/file",
+	} {
+		if _, err := call(t, tk, "http_get", map[string]interface{}{"url": rawURL}); err == nil {
+			t.Errorf("%s: expected the request to be blocked", rawURL)
+		}
+	}
+
+	_, err = call(t, tk, "http_get", map[string]interface{}{"url": fmt.Sprintf("http://[::ffff:127.0.0.1]:%d/", port)})
+	if !errors.Is(err, ErrPrivateAddress) {
+		t.Errorf("dial error = %v, want ErrPrivateAddress", err)
+	}
+}
+
+func TestRegistry_HTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached the private server")
+	}))
+	defer server.Close()
+
+	tk, err := toolkit.DefaultRegistry.New("http", map[string]interface{}{"methods": []interface{}{"GET"}})
+	if err != nil {
+		t.Fatalf("DefaultRegistry.New: %v", err)
+	}
+	ht, ok := tk.(*Toolkit)
+	if !ok {
+		t.Fatalf("\"http\" built %T, want *httptool.Toolkit", tk)
+	}
+	if _, ok := ht.Functions()["http_post"]; ok {
+		t.Error("http_post registered without POST in methods")
+	}
+	for _, rawURL := range []string{server.URL, "http://169.254.169.254/latest/meta-data/"} {
+		if _, err := call(t, ht, "http_get", map[string]interface{}{"url": rawURL}); err == nil {
+			t.Errorf("%s: expected the request to be blocked", rawURL)
+		}
+	}
+
+	if _, err := toolkit.DefaultRegistry.New("http", map[string]interface{}{"allow_private_ips": true}); err == nil {
+		t.Error("expected an error for an unknown option")
+	}
+	if _, err := toolkit.DefaultRegistry.New("http", map[string]interface{}{"methods": []interface{}{"DELETE"}}); err == nil {
+		t.Error("expected an error for an unsupported method")
+	}
+}
+
+func TestToolkit_ValidatesRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://blocked.test/", http.StatusFound)
+	}))
+	defer server.Close()
+
+	cfg := localConfig()
+	cfg.URLValidation.BlockedDomains = []string{"blocked.test"}
+	tk, _ := New(cfg)
+	_, err := call(t, tk, "http_get", map[string]interface{}{"url": server.URL})
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) || !strings.Contains(err.Error(), "blocked domain") {
+		t.Errorf("redirect error = %v", err)
+	}
+}
+
+func TestToolkit_RedirectDropsHeadersForOtherHosts(t *testing.T) {
+	var got []string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("Authorization")+"|"+r.Header.Get("X-Api-Key"))
+	}))
+	defer other.Close()
+	var origin *httptest.Server
+	origin = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/away":
+			http.Redirect(w, r, other.URL, http.StatusFound)
+		case "/same":
+			http.Redirect(w, r, origin.URL+"/final", http.StatusFound)
+		default:
+			got = append(got, r.Header.Get("Authorization")+"|"+r.Header.Get("X-Api-Key"))
+		}
+	}))
+	defer origin.Close()
+
+	cfg := localConfig()
+	cfg.Headers = map[string]string{"Authorization": "Bearer secret", "X-Api-Key": "key"}
+	tk, _ := New(cfg)
+	for _, path := range []string{"/away", "/same"} {
+		if _, err := call(t, tk, "http_get", map[string]interface{}{"url": origin.URL + path}); err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+	}
+	if len(got) != 2 || got[0] != "|" || got[1] != "Bearer secret|key" {
+		t.Errorf("headers received after redirects = %q, want none on the other host", got)
+	}
+}
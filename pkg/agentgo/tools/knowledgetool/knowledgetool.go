@@ -0,0 +1,197 @@
+// Package knowledgetool exposes a knowledge base as a toolkit, so the model
+// decides when and what to search instead of, or in addition to, the chunks
+// an agent injects automatically with agent.Config.Knowledge.
+package knowledgetool
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+)
+
+// Searcher searches a knowledge base with a metadata filter.
+// knowledge.KnowledgeBase implements it.
+type Searcher interface {
+	SearchWithFilter(ctx context.Context, query string, k int, filter map[string]interface{}) ([]vectordb.SearchResult, error)
+}
+
+// Config configures a KnowledgeToolkit
+type Config struct {
+	// Description tells the model what the knowledge base holds, e.g. "Search
+	// the product manuals". A generic description is used when empty.
+	Description string
+
+	// DefaultTopK is the number of chunks returned when the model does not
+	// ask for a number (default: 5)
+	DefaultTopK int
+
+	// MaxTopK caps the top_k the model can ask for (default: 20)
+	MaxTopK int
+
+	// MinScore drops chunks scoring below it
+	MinScore float64
+
+	// Filter is combined with the model's filter, so searches cannot leave
+	// it, e.g. {"tenant_id": "acme"}
+	Filter map[string]interface{}
+}
+
+// KnowledgeToolkit provides the search_knowledge_base function
+type KnowledgeToolkit struct {
+	*toolkit.BaseToolkit
+	searcher Searcher
+	config   Config
+}
+
+// Chunk is a search result returned to the model
+type Chunk struct {
+	ID       string                 `json:"id"`
+	Content  string                 `json:"content"`
+	Score    float64                `json:"score"`
+	Source   string                 `json:"source,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// New creates a knowledge toolkit backed by searcher. Panics if searcher is nil.
+func New(searcher Searcher, config Config) *KnowledgeToolkit {
+	if searcher == nil {
+		panic("knowledgetool.New: searcher must not be nil")
+	}
+	if config.DefaultTopK <= 0 {
+		config.DefaultTopK = 5
+	}
+	if config.MaxTopK <= 0 {
+		config.MaxTopK = 20
+	}
+	if config.DefaultTopK > config.MaxTopK {
+		config.DefaultTopK = config.MaxTopK
+	}
+	if config.Description == "" {
+		config.Description = "Search the knowledge base for passages relevant to a query. Use it when the answer may be in the documentation rather than in the conversation."
+	}
+
+	t := &KnowledgeToolkit{
+		BaseToolkit: toolkit.NewBaseToolkit("knowledge"),
+		searcher:    searcher,
+		config:      config,
+	}
+
+	t.RegisterFunction(&toolkit.Function{
+		Name:        "search_knowledge_base",
+		Description: config.Description,
+		Parameters: map[string]toolkit.Parameter{
+			"query": {
+				Type:        "string",
+				Description: "What to search for, phrased as a question or keywords",
+				Required:    true,
+			},
+			"top_k": {
+				Type:        "integer",
+				Description: fmt.Sprintf("Number of passages to return (default %d, at most %d)", config.DefaultTopK, config.MaxTopK),
+			},
+			"filter": {
+				Type: "object",
+				Description: `Optional metadata filter. {"field": value} matches equal values and {"field": [a, b]} any of them; ` +
+					`{"field": {"gte": 2020}} compares with eq, ne, in, gt, gte, lt or lte; {"$or": [filters]} matches any filter.`,
+			},
+		},
+		Handler: t.search,
+	})
+
+	return t
+}
+
+func (t *KnowledgeToolkit) search(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	query, _ := args["query"].(string)
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("query parameter is required and must be a non-empty string")
+	}
+
+	topK := t.config.DefaultTopK
+	if value, ok := args["top_k"]; ok && value != nil {
+		n, ok := toInt(value)
+		if !ok || n <= 0 {
+			return nil, fmt.Errorf("top_k must be a positive integer")
+		}
+		topK = min(n, t.config.MaxTopK)
+	}
+
+	filter, err := t.filter(args["filter"])
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := t.searcher.SearchWithFilter(ctx, query, topK, filter)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge search failed: %w", err)
+	}
+
+	chunks := make([]Chunk, 0, len(results))
+	for _, result := range results {
+		if float64(result.Score) < t.config.MinScore {
+			continue
+		}
+		source, _ := result.Metadata["source"].(string)
+		chunks = append(chunks, Chunk{
+			ID:       result.ID,
+			Content:  result.Content,
+			Score:    float64(result.Score),
+			Source:   source,
+			Metadata: result.Metadata,
+		})
+	}
+	return map[string]interface{}{
+		"query":   query,
+		"results": chunks,
+	}, nil
+}
+
+// filter validates the model's filter and combines it with Config.Filter
+func (t *KnowledgeToolkit) filter(value interface{}) (map[string]interface{}, error) {
+	var requested map[string]interface{}
+	if value != nil {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("filter must be an object")
+		}
+		requested = m
+	}
+
+	var clauses []vectordb.Filter
+	for _, m := range []map[string]interface{}{t.config.Filter, requested} {
+		if len(m) == 0 {
+			continue
+		}
+		f, err := vectordb.ParseFilter(m)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter: %w", err)
+		}
+		clauses = append(clauses, f)
+	}
+
+	switch len(clauses) {
+	case 0:
+		return nil, nil
+	case 1:
+		return clauses[0].Map(), nil
+	}
+	return vectordb.And(clauses...).Map(), nil
+}
+
+func toInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		if v != float64(int(v)) {
+			return 0, false
+		}
+		return int(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,113 @@
+package knowledgetool
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+)
+
+// fakeSearcher returns fixed results and records the last search
+type fakeSearcher struct {
+	results []vectordb.SearchResult
+	err     error
+	query   string
+	k       int
+	filter  map[string]interface{}
+}
+
+func (f *fakeSearcher) SearchWithFilter(ctx context.Context, query string, k int, filter map[string]interface{}) ([]vectordb.SearchResult, error) {
+	f.query, f.k, f.filter = query, k, filter
+	return f.results, f.err
+}
+
+func TestNew_RegistersFunction(t *testing.T) {
+	tk := New(&fakeSearcher{}, Config{Description: "Search the manuals"})
+	fn, ok := tk.Functions()["search_knowledge_base"]
+	if !ok {
+		t.Fatal("search_knowledge_base not registered")
+	}
+	if fn.Description != "Search the manuals" || !fn.Parameters["query"].Required {
+		t.Errorf("function = %+v", fn)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	searcher := &fakeSearcher{results: []vectordb.SearchResult{
+		{ID: "manual_chunk_0", Content: "Reset the router by holding the button.", Score: 0.9, Metadata: map[string]interface{}{"source": "manual.pdf"}},
+		{ID: "faq_chunk_3", Content: "Unrelated.", Score: 0.2},
+	}}
+	tk := New(searcher, Config{MinScore: 0.5, MaxTopK: 10})
+	ctx := context.Background()
+
+	out, err := tk.Execute(ctx, "search_knowledge_base", map[string]interface{}{"query": "reset router"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if searcher.query != "reset router" || searcher.k != 5 || searcher.filter != nil {
+		t.Errorf("SearchWithFilter(%q, %d, %v)", searcher.query, searcher.k, searcher.filter)
+	}
+	chunks := out.(map[string]interface{})["results"].([]Chunk)
+	if len(chunks) != 1 || chunks[0].ID != "manual_chunk_0" || chunks[0].Source != "manual.pdf" {
+		t.Errorf("results = %+v, want the chunk above MinScore", chunks)
+	}
+
+	// top_k arrives as a JSON number and is capped by MaxTopK
+	if _, err := tk.Execute(ctx, "search_knowledge_base", map[string]interface{}{"query": "q", "top_k": float64(50)}); err != nil || searcher.k != 10 {
+		t.Errorf("top_k = %d, %v, want 10", searcher.k, err)
+	}
+	if _, err := tk.Execute(ctx, "search_knowledge_base", map[string]interface{}{"query": "q", "top_k": 1.5}); err == nil {
+		t.Error("expected error for a fractional top_k")
+	}
+	if _, err := tk.Execute(ctx, "search_knowledge_base", map[string]interface{}{"query": " "}); err == nil {
+		t.Error("expected error for an empty query")
+	}
+
+	searcher.err = errors.New("db down")
+	if _, err := tk.Execute(ctx, "search_knowledge_base", map[string]interface{}{"query": "q"}); err == nil || !strings.Contains(err.Error(), "db down") {
+		t.Errorf("error = %v, want the search error", err)
+	}
+}
+
+func TestSearch_Filter(t *testing.T) {
+	searcher := &fakeSearcher{}
+	ctx := context.Background()
+
+	tk := New(searcher, Config{})
+	args := map[string]interface{}{
+		"query":  "q",
+		"filter": map[string]interface{}{"product": "router", "year": map[string]interface{}{"gte": float64(2020)}},
+	}
+	if _, err := tk.Execute(ctx, "search_knowledge_base", args); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	want := vectordb.And(vectordb.Eq("product", "router"), vectordb.Gte("year", float64(2020))).Map()
+	if !reflect.DeepEqual(searcher.filter, want) {
+		t.Errorf("filter = %v, want %v", searcher.filter, want)
+	}
+
+	// Config.Filter scopes every search
+	tk = New(searcher, Config{Filter: map[string]interface{}{"tenant_id": "acme"}})
+	if _, err := tk.Execute(ctx, "search_knowledge_base", map[string]interface{}{"query": "q"}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !reflect.DeepEqual(searcher.filter, map[string]interface{}{"tenant_id": "acme"}) {
+		t.Errorf("filter = %v, want the configured scope", searcher.filter)
+	}
+	args = map[string]interface{}{"query": "q", "filter": map[string]interface{}{"product": "router"}}
+	if _, err := tk.Execute(ctx, "search_knowledge_base", args); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	want = vectordb.And(vectordb.Eq("tenant_id", "acme"), vectordb.Eq("product", "router")).Map()
+	if !reflect.DeepEqual(searcher.filter, want) {
+		t.Errorf("filter = %v, want %v", searcher.filter, want)
+	}
+
+	args = map[string]interface{}{"query": "q", "filter": map[string]interface{}{"year": map[string]interface{}{"like": "20%"}}}
+	if _, err := tk.Execute(ctx, "search_knowledge_base", args); err == nil || !strings.Contains(err.Error(), "invalid filter") {
+		t.Errorf("error = %v, want invalid filter", err)
+	}
+}
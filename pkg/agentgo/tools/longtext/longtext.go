@@ -0,0 +1,169 @@
+// Package longtext exposes the mapreduce processor as a toolkit, so agents can
+// summarize, extract from and classify texts longer than their context window.
+package longtext
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/mapreduce"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+)
+
+// LongTextToolkit provides map-reduce processing of long texts
+type LongTextToolkit struct {
+	*toolkit.BaseToolkit
+	processor *mapreduce.Processor
+}
+
+// New creates a long text toolkit backed by processor. Panics if processor is nil.
+func New(processor *mapreduce.Processor) *LongTextToolkit {
+	if processor == nil {
+		panic("longtext.New: processor must not be nil")
+	}
+
+	t := &LongTextToolkit{
+		BaseToolkit: toolkit.NewBaseToolkit("long_text"),
+		processor:   processor,
+	}
+
+	t.RegisterFunction(&toolkit.Function{
+		Name:        "summarize_long_text",
+		Description: "Summarize a text too long to read at once. The text is split into parts that are summarized in parallel and then combined.",
+		Parameters: map[string]toolkit.Parameter{
+			"text": {
+				Type:        "string",
+				Description: "The full text to summarize",
+				Required:    true,
+			},
+			"instructions": {
+				Type:        "string",
+				Description: "Optional focus or length of the summary",
+			},
+		},
+		Handler: t.summarize,
+	})
+
+	t.RegisterFunction(&toolkit.Function{
+		Name:        "extract_from_long_text",
+		Description: "Extract items (names, dates, action items, ...) from a long text. Returns a deduplicated list, one item per line.",
+		Parameters: map[string]toolkit.Parameter{
+			"text": {
+				Type:        "string",
+				Description: "The full text to extract from",
+				Required:    true,
+			},
+			"instructions": {
+				Type:        "string",
+				Description: "What to extract, e.g. 'all deadlines with their owners'",
+				Required:    true,
+			},
+		},
+		Handler: t.extract,
+	})
+
+	t.RegisterFunction(&toolkit.Function{
+		Name:        "classify_long_text",
+		Description: "Classify a long text into one of the given labels by majority vote across its parts.",
+		Parameters: map[string]toolkit.Parameter{
+			"text": {
+				Type:        "string",
+				Description: "The full text to classify",
+				Required:    true,
+			},
+			"labels": {
+				Type:        "array",
+				Description: "The possible labels (at least two)",
+				Required:    true,
+				Items:       &toolkit.Parameter{Type: "string"},
+			},
+		},
+		Handler: t.classify,
+	})
+
+	return t
+}
+
+func (t *LongTextToolkit) summarize(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	text, err := requiredString(args, "text")
+	if err != nil {
+		return nil, err
+	}
+	instructions, _ := args["instructions"].(string)
+
+	result, err := t.processor.Summarize(ctx, text, instructions)
+	if err != nil {
+		return nil, err
+	}
+	return response(result), nil
+}
+
+func (t *LongTextToolkit) extract(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	text, err := requiredString(args, "text")
+	if err != nil {
+		return nil, err
+	}
+	instructions, err := requiredString(args, "instructions")
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := t.processor.Extract(ctx, text, instructions)
+	if err != nil {
+		return nil, err
+	}
+	return response(result), nil
+}
+
+func (t *LongTextToolkit) classify(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	text, err := requiredString(args, "text")
+	if err != nil {
+		return nil, err
+	}
+	labels, err := stringList(args["labels"])
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := t.processor.Classify(ctx, text, labels)
+	if err != nil {
+		return nil, err
+	}
+	resp := response(result)
+	resp["votes"] = result.Votes
+	return resp, nil
+}
+
+func response(result *mapreduce.Result) map[string]interface{} {
+	return map[string]interface{}{
+		"output": result.Output,
+		"chunks": result.Chunks,
+	}
+}
+
+func requiredString(args map[string]interface{}, name string) (string, error) {
+	value, ok := args[name].(string)
+	if !ok || value == "" {
+		return "", fmt.Errorf("%s parameter is required and must be a non-empty string", name)
+	}
+	return value, nil
+}
+
+func stringList(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case []string:
+		return v, nil
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("labels must be strings")
+			}
+			out = append(out, s)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("labels parameter is required and must be an array of strings")
+	}
+}
@@ -0,0 +1,88 @@
+package longtext
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/mapreduce"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+type echoModel struct {
+	models.BaseModel
+}
+
+func (m *echoModel) Invoke(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+	system := req.Messages[0].Content
+	switch {
+	case strings.Contains(system, "Answer with exactly one of these labels"):
+		return &types.ModelResponse{Content: "positive"}, nil
+	case strings.Contains(system, "Extract"):
+		return &types.ModelResponse{Content: "- item"}, nil
+	default:
+		return &types.ModelResponse{Content: "summary"}, nil
+	}
+}
+
+func (m *echoModel) InvokeStream(ctx context.Context, req *models.InvokeRequest) (<-chan types.ResponseChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func newToolkit(t *testing.T) *LongTextToolkit {
+	t.Helper()
+	processor, err := mapreduce.New(mapreduce.Config{Model: &echoModel{}})
+	if err != nil {
+		t.Fatalf("mapreduce.New() error = %v", err)
+	}
+	return New(processor)
+}
+
+func TestNew_RegistersFunctions(t *testing.T) {
+	tk := newToolkit(t)
+	for _, name := range []string{"summarize_long_text", "extract_from_long_text", "classify_long_text"} {
+		if _, ok := tk.Functions()[name]; !ok {
+			t.Errorf("function %s not registered", name)
+		}
+	}
+}
+
+func TestFunctions(t *testing.T) {
+	tk := newToolkit(t)
+	ctx := context.Background()
+
+	out, err := tk.Execute(ctx, "summarize_long_text", map[string]interface{}{"text": "Some text."})
+	if err != nil || out.(map[string]interface{})["output"] != "summary" {
+		t.Errorf("summarize = %v, %v", out, err)
+	}
+
+	out, err = tk.Execute(ctx, "extract_from_long_text", map[string]interface{}{"text": "Some text.", "instructions": "items"})
+	if err != nil || out.(map[string]interface{})["output"] != "- item" {
+		t.Errorf("extract = %v, %v", out, err)
+	}
+
+	out, err = tk.Execute(ctx, "classify_long_text", map[string]interface{}{
+		"text":   "Some text.",
+		"labels": []interface{}{"positive", "negative"},
+	})
+	if err != nil || out.(map[string]interface{})["output"] != "positive" {
+		t.Errorf("classify = %v, %v", out, err)
+	}
+}
+
+func TestFunctions_InvalidArgs(t *testing.T) {
+	tk := newToolkit(t)
+	ctx := context.Background()
+
+	if _, err := tk.Execute(ctx, "summarize_long_text", map[string]interface{}{}); err == nil {
+		t.Error("expected error without text")
+	}
+	if _, err := tk.Execute(ctx, "extract_from_long_text", map[string]interface{}{"text": "x"}); err == nil {
+		t.Error("expected error without instructions")
+	}
+	if _, err := tk.Execute(ctx, "classify_long_text", map[string]interface{}{"text": "x", "labels": []interface{}{1, 2}}); err == nil {
+		t.Error("expected error for non-string labels")
+	}
+}
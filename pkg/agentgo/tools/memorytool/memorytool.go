@@ -0,0 +1,345 @@
+// Package memorytool lets the model curate user memories itself. Its
+// functions add, update, delete and search the memories of the current user
+// in a learning.Storage, next to (or instead of) the heuristic extraction an
+// agent runs with Config.Learning.
+package memorytool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/ids"
+	"github.com/jholhewres/agent-go/pkg/agentgo/learning"
+	"github.com/jholhewres/agent-go/pkg/agentgo/run"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+)
+
+// ErrEditNotSupported is returned by update_memory and delete_memory when the
+// storage does not implement learning.MemoryEditor
+var ErrEditNotSupported = errors.New("memory storage does not support editing memories")
+
+// Config configures a MemoryToolkit
+type Config struct {
+	// UserID owns the memories when the run context has no user ID
+	UserID string
+
+	// DefaultLimit is the number of memories search_memories returns when the
+	// model does not ask for a number (default: 5)
+	DefaultLimit int
+
+	// MaxLimit caps the limit the model can ask for (default: 20)
+	MaxLimit int
+
+	// SearchWindow is the number of the user's latest memories that
+	// update_memory and delete_memory look through (default: 200)
+	SearchWindow int
+}
+
+// MemoryToolkit provides the add_memory, update_memory, delete_memory and
+// search_memories functions
+type MemoryToolkit struct {
+	*toolkit.BaseToolkit
+	storage learning.Storage
+	config  Config
+}
+
+// Memory is a memory returned to the model
+type Memory struct {
+	ID        string    `json:"id"`
+	Content   string    `json:"content"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+var memoryTypes = []string{
+	string(learning.MemoryTypeFact),
+	string(learning.MemoryTypePreference),
+	string(learning.MemoryTypeContext),
+	string(learning.MemoryTypeSkill),
+}
+
+// New creates a memory toolkit backed by storage. Panics if storage is nil.
+func New(storage learning.Storage, config Config) *MemoryToolkit {
+	if storage == nil {
+		panic("memorytool.New: storage must not be nil")
+	}
+	if config.DefaultLimit <= 0 {
+		config.DefaultLimit = 5
+	}
+	if config.MaxLimit <= 0 {
+		config.MaxLimit = 20
+	}
+	if config.DefaultLimit > config.MaxLimit {
+		config.DefaultLimit = config.MaxLimit
+	}
+	if config.SearchWindow <= 0 {
+		config.SearchWindow = 200
+	}
+
+	t := &MemoryToolkit{
+		BaseToolkit: toolkit.NewBaseToolkit("memory"),
+		storage:     storage,
+		config:      config,
+	}
+
+	t.RegisterFunction(&toolkit.Function{
+		Name:        "add_memory",
+		Description: "Remember something about the user for future conversations, such as a fact, a preference or a skill. Search first to avoid duplicates.",
+		Parameters: map[string]toolkit.Parameter{
+			"content": {
+				Type:        "string",
+				Description: "What to remember, as a short self-contained sentence",
+				Required:    true,
+			},
+			"type": {
+				Type:        "string",
+				Description: "Kind of memory (default fact)",
+				Enum:        memoryTypes,
+			},
+		},
+		Handler: t.add,
+	})
+
+	t.RegisterFunction(&toolkit.Function{
+		Name:        "update_memory",
+		Description: "Replace the content of a memory that is outdated or wrong. Get its ID with search_memories.",
+		Parameters: map[string]toolkit.Parameter{
+			"memory_id": {
+				Type:        "string",
+				Description: "ID of the memory to update",
+				Required:    true,
+			},
+			"content": {
+				Type:        "string",
+				Description: "The new content of the memory",
+				Required:    true,
+			},
+			"type": {
+				Type:        "string",
+				Description: "New kind of memory (default: unchanged)",
+				Enum:        memoryTypes,
+			},
+		},
+		Handler: t.update,
+	})
+
+	t.RegisterFunction(&toolkit.Function{
+		Name:        "delete_memory",
+		Description: "Forget a memory, e.g. when the user asks you to or it no longer holds. Get its ID with search_memories.",
+		Parameters: map[string]toolkit.Parameter{
+			"memory_id": {
+				Type:        "string",
+				Description: "ID of the memory to delete",
+				Required:    true,
+			},
+		},
+		Handler: t.delete,
+	})
+
+	t.RegisterFunction(&toolkit.Function{
+		Name:        "search_memories",
+		Description: "Search what you remember about the user. Leave the query empty to get the latest memories.",
+		Parameters: map[string]toolkit.Parameter{
+			"query": {
+				Type:        "string",
+				Description: "Keywords to look for",
+			},
+			"limit": {
+				Type:        "integer",
+				Description: fmt.Sprintf("Number of memories to return (default %d, at most %d)", config.DefaultLimit, config.MaxLimit),
+			},
+		},
+		Handler: t.search,
+	})
+
+	return t
+}
+
+func (t *MemoryToolkit) add(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	userID, err := t.userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	content, err := contentArg(args)
+	if err != nil {
+		return nil, err
+	}
+	memoryType, err := typeArg(args, learning.MemoryTypeFact)
+	if err != nil {
+		return nil, err
+	}
+
+	memory := learning.UserMemory{
+		ID:        ids.New(),
+		UserID:    userID,
+		Content:   content,
+		Type:      memoryType,
+		Metadata:  map[string]interface{}{"source": "model"},
+		CreatedAt: time.Now(),
+	}
+	if err := t.storage.SaveUserMemory(ctx, &memory); err != nil {
+		return nil, fmt.Errorf("failed to save memory: %w", err)
+	}
+	return toMemory(memory), nil
+}
+
+func (t *MemoryToolkit) update(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	editor, ok := t.storage.(learning.MemoryEditor)
+	if !ok {
+		return nil, ErrEditNotSupported
+	}
+	memory, err := t.find(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	if memory.Content, err = contentArg(args); err != nil {
+		return nil, err
+	}
+	if memory.Type, err = typeArg(args, memory.Type); err != nil {
+		return nil, err
+	}
+	if memory.Metadata == nil {
+		memory.Metadata = map[string]interface{}{}
+	}
+	memory.Metadata["updated_at"] = time.Now()
+
+	if err := editor.UpdateUserMemory(ctx, &memory); err != nil {
+		return nil, fmt.Errorf("failed to update memory: %w", err)
+	}
+	return toMemory(memory), nil
+}
+
+func (t *MemoryToolkit) delete(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	editor, ok := t.storage.(learning.MemoryEditor)
+	if !ok {
+		return nil, ErrEditNotSupported
+	}
+	memory, err := t.find(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := editor.DeleteUserMemory(ctx, memory.UserID, memory.ID); err != nil {
+		return nil, fmt.Errorf("failed to delete memory: %w", err)
+	}
+	return map[string]interface{}{"deleted": memory.ID}, nil
+}
+
+func (t *MemoryToolkit) search(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	userID, err := t.userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query, _ := args["query"].(string)
+
+	limit := t.config.DefaultLimit
+	if value, ok := args["limit"]; ok && value != nil {
+		n, ok := toInt(value)
+		if !ok || n <= 0 {
+			return nil, fmt.Errorf("limit must be a positive integer")
+		}
+		limit = min(n, t.config.MaxLimit)
+	}
+
+	// An empty query lists the latest memories
+	var memories []learning.UserMemory
+	if strings.TrimSpace(query) == "" {
+		memories, err = t.storage.GetUserMemories(ctx, userID, limit)
+	} else {
+		var scored []learning.ScoredMemory
+		scored, err = t.storage.SearchMemories(ctx, userID, query, limit)
+		for _, memory := range scored {
+			memories = append(memories, memory.UserMemory)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to search memories: %w", err)
+	}
+
+	results := make([]Memory, 0, len(memories))
+	for _, memory := range memories {
+		results = append(results, toMemory(memory))
+	}
+	return map[string]interface{}{
+		"query":    query,
+		"memories": results,
+	}, nil
+}
+
+// find returns the current user's memory named by the memory_id argument
+func (t *MemoryToolkit) find(ctx context.Context, args map[string]interface{}) (learning.UserMemory, error) {
+	userID, err := t.userID(ctx)
+	if err != nil {
+		return learning.UserMemory{}, err
+	}
+	id, _ := args["memory_id"].(string)
+	if strings.TrimSpace(id) == "" {
+		return learning.UserMemory{}, fmt.Errorf("memory_id parameter is required and must be a non-empty string")
+	}
+
+	memories, err := t.storage.GetUserMemories(ctx, userID, t.config.SearchWindow)
+	if err != nil {
+		return learning.UserMemory{}, fmt.Errorf("failed to load memories: %w", err)
+	}
+	for _, memory := range memories {
+		if memory.ID == id {
+			return memory, nil
+		}
+	}
+	return learning.UserMemory{}, fmt.Errorf("memory %q: %w", id, learning.ErrMemoryNotFound)
+}
+
+// userID returns the user of the current run, else Config.UserID
+func (t *MemoryToolkit) userID(ctx context.Context) (string, error) {
+	if rc, ok := run.FromContext(ctx); ok && rc.UserID != "" {
+		return rc.UserID, nil
+	}
+	if t.config.UserID != "" {
+		return t.config.UserID, nil
+	}
+	return "", fmt.Errorf("no user ID: run the agent with a UserID or set memorytool.Config.UserID")
+}
+
+func contentArg(args map[string]interface{}) (string, error) {
+	content, _ := args["content"].(string)
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", fmt.Errorf("content parameter is required and must be a non-empty string")
+	}
+	return content, nil
+}
+
+func typeArg(args map[string]interface{}, fallback learning.MemoryType) (learning.MemoryType, error) {
+	value, ok := args["type"]
+	if !ok || value == nil || value == "" {
+		return fallback, nil
+	}
+	s, _ := value.(string)
+	for _, memoryType := range memoryTypes {
+		if s == memoryType {
+			return learning.MemoryType(s), nil
+		}
+	}
+	return "", fmt.Errorf("type must be one of %s", strings.Join(memoryTypes, ", "))
+}
+
+func toMemory(m learning.UserMemory) Memory {
+	return Memory{ID: m.ID, Content: m.Content, Type: string(m.Type), CreatedAt: m.CreatedAt}
+}
+
+func toInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		if v != float64(int(v)) {
+			return 0, false
+		}
+		return int(v), true
+	}
+	return 0, false
+}
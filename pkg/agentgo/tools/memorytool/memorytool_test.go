@@ -0,0 +1,183 @@
+package memorytool
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/learning"
+	"github.com/jholhewres/agent-go/pkg/agentgo/run"
+)
+
+// fakeStorage keeps memories in a slice, newest last. The embedded Storage is
+// nil, so only the memory methods may be called.
+type fakeStorage struct {
+	learning.Storage
+	memories []learning.UserMemory
+}
+
+func (f *fakeStorage) SaveUserMemory(ctx context.Context, memory *learning.UserMemory) error {
+	f.memories = append(f.memories, *memory)
+	return nil
+}
+
+func (f *fakeStorage) GetUserMemories(ctx context.Context, userID string, limit int) ([]learning.UserMemory, error) {
+	var memories []learning.UserMemory
+	for i := len(f.memories) - 1; i >= 0 && len(memories) < limit; i-- {
+		if f.memories[i].UserID == userID {
+			memories = append(memories, f.memories[i])
+		}
+	}
+	return memories, nil
+}
+
+func (f *fakeStorage) SearchMemories(ctx context.Context, userID, query string, limit int) ([]learning.ScoredMemory, error) {
+	memories, _ := f.GetUserMemories(ctx, userID, len(f.memories))
+	return learning.RankByKeywords(memories, query, limit), nil
+}
+
+// editableStorage also implements learning.MemoryEditor
+type editableStorage struct {
+	fakeStorage
+}
+
+func (f *editableStorage) UpdateUserMemory(ctx context.Context, memory *learning.UserMemory) error {
+	for i, m := range f.memories {
+		if m.ID == memory.ID && m.UserID == memory.UserID {
+			f.memories[i] = *memory
+			return nil
+		}
+	}
+	return learning.ErrMemoryNotFound
+}
+
+func (f *editableStorage) DeleteUserMemory(ctx context.Context, userID, memoryID string) error {
+	for i, m := range f.memories {
+		if m.ID == memoryID && m.UserID == userID {
+			f.memories = append(f.memories[:i], f.memories[i+1:]...)
+			return nil
+		}
+	}
+	return learning.ErrMemoryNotFound
+}
+
+func userContext(userID string) context.Context {
+	return run.WithContext(context.Background(), &run.RunContext{UserID: userID})
+}
+
+func TestNew_RegistersFunctions(t *testing.T) {
+	tk := New(&fakeStorage{}, Config{})
+	for _, name := range []string{"add_memory", "update_memory", "delete_memory", "search_memories"} {
+		if _, ok := tk.Functions()[name]; !ok {
+			t.Errorf("%s not registered", name)
+		}
+	}
+}
+
+func TestAddAndSearch(t *testing.T) {
+	storage := &editableStorage{}
+	tk := New(storage, Config{})
+	ctx := userContext("ada")
+
+	for _, args := range []map[string]interface{}{
+		{"content": "Works as a nurse in Lisbon"},
+		{"content": "Prefers short answers", "type": "preference"},
+		{"content": "Lives in Lisbon with two cats"},
+	} {
+		if _, err := tk.Execute(ctx, "add_memory", args); err != nil {
+			t.Fatalf("add_memory error = %v", err)
+		}
+	}
+	if _, err := tk.Execute(userContext("bob"), "add_memory", map[string]interface{}{"content": "Lives in Lisbon"}); err != nil {
+		t.Fatalf("add_memory error = %v", err)
+	}
+	if m := storage.memories[1]; m.UserID != "ada" || m.Type != learning.MemoryTypePreference || m.ID == "" {
+		t.Fatalf("stored memory = %+v", m)
+	}
+
+	out, err := tk.Execute(ctx, "search_memories", map[string]interface{}{"query": "Lisbon cats?"})
+	if err != nil {
+		t.Fatalf("search_memories error = %v", err)
+	}
+	memories := out.(map[string]interface{})["memories"].([]Memory)
+	if len(memories) != 2 || memories[0].Content != "Lives in Lisbon with two cats" || memories[1].Content != "Works as a nurse in Lisbon" {
+		t.Fatalf("memories = %+v, want ada's Lisbon memories, best match first", memories)
+	}
+
+	// An empty query returns the latest memories
+	out, err = tk.Execute(ctx, "search_memories", map[string]interface{}{"limit": float64(1)})
+	if err != nil {
+		t.Fatalf("search_memories error = %v", err)
+	}
+	if memories := out.(map[string]interface{})["memories"].([]Memory); len(memories) != 1 || memories[0].Content != "Lives in Lisbon with two cats" {
+		t.Fatalf("memories = %+v, want the latest one", memories)
+	}
+
+	if _, err := tk.Execute(ctx, "add_memory", map[string]interface{}{"content": "x", "type": "mood"}); err == nil {
+		t.Error("expected an error for an unknown type")
+	}
+}
+
+func TestUpdateAndDelete(t *testing.T) {
+	storage := &editableStorage{}
+	storage.memories = []learning.UserMemory{
+		{ID: "m1", UserID: "ada", Content: "Prefers tea", Type: learning.MemoryTypePreference, CreatedAt: time.Now()},
+		{ID: "m2", UserID: "bob", Content: "Prefers coffee", Type: learning.MemoryTypePreference, CreatedAt: time.Now()},
+	}
+	tk := New(storage, Config{})
+	ctx := userContext("ada")
+
+	out, err := tk.Execute(ctx, "update_memory", map[string]interface{}{"memory_id": "m1", "content": "Prefers green tea"})
+	if err != nil {
+		t.Fatalf("update_memory error = %v", err)
+	}
+	if m := out.(Memory); m.Content != "Prefers green tea" || m.Type != "preference" {
+		t.Fatalf("updated memory = %+v, want the type unchanged", m)
+	}
+	if m := storage.memories[0]; m.Content != "Prefers green tea" || m.Metadata["updated_at"] == nil {
+		t.Fatalf("stored memory = %+v", m)
+	}
+
+	// Memories of other users are out of reach
+	if _, err := tk.Execute(ctx, "delete_memory", map[string]interface{}{"memory_id": "m2"}); !errors.Is(err, learning.ErrMemoryNotFound) {
+		t.Fatalf("delete_memory of bob's memory error = %v, want ErrMemoryNotFound", err)
+	}
+
+	if _, err := tk.Execute(ctx, "delete_memory", map[string]interface{}{"memory_id": "m1"}); err != nil {
+		t.Fatalf("delete_memory error = %v", err)
+	}
+	if len(storage.memories) != 1 || storage.memories[0].ID != "m2" {
+		t.Fatalf("memories = %+v, want only bob's", storage.memories)
+	}
+}
+
+func TestEditNotSupported(t *testing.T) {
+	tk := New(&fakeStorage{}, Config{UserID: "ada"})
+	_, err := tk.Execute(context.Background(), "delete_memory", map[string]interface{}{"memory_id": "m1"})
+	if !errors.Is(err, ErrEditNotSupported) {
+		t.Fatalf("delete_memory error = %v, want ErrEditNotSupported", err)
+	}
+}
+
+func TestUserID(t *testing.T) {
+	storage := &fakeStorage{}
+
+	_, err := New(storage, Config{}).Execute(context.Background(), "add_memory", map[string]interface{}{"content": "x"})
+	if err == nil || !strings.Contains(err.Error(), "no user ID") {
+		t.Fatalf("expected a missing user error, got %v", err)
+	}
+
+	// The run's user wins over Config.UserID
+	tk := New(storage, Config{UserID: "default"})
+	if _, err := tk.Execute(userContext("ada"), "add_memory", map[string]interface{}{"content": "x"}); err != nil {
+		t.Fatalf("add_memory error = %v", err)
+	}
+	if _, err := tk.Execute(context.Background(), "add_memory", map[string]interface{}{"content": "y"}); err != nil {
+		t.Fatalf("add_memory error = %v", err)
+	}
+	if storage.memories[0].UserID != "ada" || storage.memories[1].UserID != "default" {
+		t.Fatalf("memories = %+v", storage.memories)
+	}
+}
@@ -19,6 +19,10 @@ type PubMedToolkit struct {
 	*toolkit.BaseToolkit
 }
 
+func init() {
+	toolkit.MustRegister("pubmed", toolkit.NoOptions(New))
+}
+
 // New creates a new PubMed toolkit
 func New() *PubMedToolkit {
 	t := &PubMedToolkit{
@@ -0,0 +1,168 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	chart "github.com/wcharczuk/go-chart/v2"
+)
+
+// Chart types accepted by render_chart
+const (
+	ChartBar  = "bar"
+	ChartLine = "line"
+	ChartPie  = "pie"
+)
+
+// series is one named list of values, one per label
+type series struct {
+	name   string
+	values []float64
+}
+
+func (t *ReportToolkit) renderChart(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	chartType, _ := args["type"].(string)
+	labels, err := stringList(args["labels"], "labels")
+	if err != nil {
+		return nil, err
+	}
+	if len(labels) == 0 {
+		return nil, fmt.Errorf("labels must not be empty")
+	}
+	data, err := parseSeries(args["series"], len(labels))
+	if err != nil {
+		return nil, err
+	}
+	title, _ := args["title"].(string)
+	xLabel, _ := args["x_label"].(string)
+	yLabel, _ := args["y_label"].(string)
+
+	var buf bytes.Buffer
+	switch chartType {
+	case ChartBar:
+		if len(data) != 1 {
+			return nil, fmt.Errorf("bar charts take exactly one series, got %d", len(data))
+		}
+		bars := make([]chart.Value, len(labels))
+		for i, label := range labels {
+			bars[i] = chart.Value{Label: label, Value: data[0].values[i]}
+		}
+		graph := chart.BarChart{
+			Title:  title,
+			Width:  t.width,
+			Height: t.height,
+			Background: chart.Style{
+				Padding: chart.Box{Top: 40},
+			},
+			YAxis: chart.YAxis{Name: yLabel},
+			Bars:  bars,
+		}
+		err = graph.Render(chart.PNG, &buf)
+
+	case ChartLine:
+		if len(labels) < 2 {
+			return nil, fmt.Errorf("line charts need at least two labels")
+		}
+		xValues := make([]float64, len(labels))
+		ticks := make([]chart.Tick, len(labels))
+		for i, label := range labels {
+			xValues[i] = float64(i)
+			ticks[i] = chart.Tick{Value: float64(i), Label: label}
+		}
+		graph := chart.Chart{
+			Title:  title,
+			Width:  t.width,
+			Height: t.height,
+			XAxis:  chart.XAxis{Name: xLabel, Ticks: ticks},
+			YAxis:  chart.YAxis{Name: yLabel},
+		}
+		for _, s := range data {
+			graph.Series = append(graph.Series, chart.ContinuousSeries{
+				Name:    s.name,
+				XValues: xValues,
+				YValues: s.values,
+			})
+		}
+		if len(data) > 1 {
+			graph.Elements = []chart.Renderable{chart.Legend(&graph)}
+		}
+		err = graph.Render(chart.PNG, &buf)
+
+	case ChartPie:
+		if len(data) != 1 {
+			return nil, fmt.Errorf("pie charts take exactly one series, got %d", len(data))
+		}
+		values := make([]chart.Value, len(labels))
+		for i, label := range labels {
+			if data[0].values[i] < 0 {
+				return nil, fmt.Errorf("pie chart values must not be negative")
+			}
+			values[i] = chart.Value{Label: label, Value: data[0].values[i]}
+		}
+		graph := chart.PieChart{
+			Title:  title,
+			Width:  t.width,
+			Height: t.height,
+			Values: values,
+		}
+		err = graph.Render(chart.PNG, &buf)
+
+	default:
+		return nil, fmt.Errorf("unsupported chart type %q (use bar, line or pie)", chartType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to render chart: %w", err)
+	}
+
+	return t.artifact(fileName(args, "chart.png", ".png"), "image/png", buf.Bytes(), false)
+}
+
+// parseSeries reads the series argument; every series needs one value per label
+func parseSeries(value interface{}, labels int) ([]series, error) {
+	raw, ok := value.([]interface{})
+	if !ok || len(raw) == 0 {
+		return nil, fmt.Errorf("series parameter is required and must be a non-empty array")
+	}
+
+	out := make([]series, len(raw))
+	for i, item := range raw {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("series %d must be an object with name and values", i+1)
+		}
+		name, _ := obj["name"].(string)
+		if name == "" {
+			name = fmt.Sprintf("Series %d", i+1)
+		}
+		values, ok := obj["values"].([]interface{})
+		if !ok || len(values) != labels {
+			return nil, fmt.Errorf("series %q must have %d values, one per label", name, labels)
+		}
+		s := series{name: name, values: make([]float64, labels)}
+		for j, v := range values {
+			number, ok := toFloat(v)
+			if !ok {
+				return nil, fmt.Errorf("series %q value %d is not a number", name, j+1)
+			}
+			s.values[j] = number
+		}
+		out[i] = s
+	}
+	return out, nil
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
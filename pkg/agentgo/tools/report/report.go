@@ -0,0 +1,302 @@
+// Package report provides tools that render model-produced data as CSV,
+// Markdown tables and PNG charts, so reporting agents can return more than
+// prose.
+//
+// Every tool returns an artifact: a map with "name", "mime_type" and "size",
+// plus "path" when Config.OutputDir is set or "data_base64" otherwise. Text
+// artifacts also carry their "content".
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+)
+
+const (
+	defaultWidth  = 800
+	defaultHeight = 480
+)
+
+// Config configures the report toolkit
+type Config struct {
+	// OutputDir is where artifacts are written. When empty, artifacts are
+	// returned inline as base64.
+	OutputDir string
+
+	// Width and Height are the chart size in pixels (default: 800x480)
+	Width  int
+	Height int
+}
+
+// ReportToolkit renders tables and charts
+type ReportToolkit struct {
+	*toolkit.BaseToolkit
+	outputDir string
+	width     int
+	height    int
+}
+
+// New creates a report toolkit
+func New(config Config) *ReportToolkit {
+	if config.Width <= 0 {
+		config.Width = defaultWidth
+	}
+	if config.Height <= 0 {
+		config.Height = defaultHeight
+	}
+
+	t := &ReportToolkit{
+		BaseToolkit: toolkit.NewBaseToolkit("report"),
+		outputDir:   config.OutputDir,
+		width:       config.Width,
+		height:      config.Height,
+	}
+
+	tableParams := func(defaultName string) map[string]toolkit.Parameter {
+		return map[string]toolkit.Parameter{
+			"columns": {
+				Type:        "array",
+				Description: "Column names",
+				Required:    true,
+				Items:       &toolkit.Parameter{Type: "string"},
+			},
+			"rows": {
+				Type:        "array",
+				Description: "Rows of the table, each an array of values in column order",
+				Required:    true,
+				Items:       &toolkit.Parameter{Type: "array"},
+			},
+			"name": {
+				Type:        "string",
+				Description: fmt.Sprintf("File name of the artifact (default: %s)", defaultName),
+			},
+		}
+	}
+
+	t.RegisterFunction(&toolkit.Function{
+		Name:        "render_csv",
+		Description: "Render tabular data as a CSV file",
+		Parameters:  tableParams("table.csv"),
+		Handler:     t.renderCSV,
+	})
+
+	t.RegisterFunction(&toolkit.Function{
+		Name:        "render_markdown_table",
+		Description: "Render tabular data as a Markdown table",
+		Parameters:  tableParams("table.md"),
+		Handler:     t.renderMarkdownTable,
+	})
+
+	t.RegisterFunction(&toolkit.Function{
+		Name:        "render_chart",
+		Description: "Render data as a PNG chart. Bar and pie charts take one series; line charts take one or more.",
+		Parameters: map[string]toolkit.Parameter{
+			"type": {
+				Type:        "string",
+				Description: "Chart type",
+				Required:    true,
+				Enum:        []string{ChartBar, ChartLine, ChartPie},
+			},
+			"labels": {
+				Type:        "array",
+				Description: "Category labels (x axis for bar and line charts, slices for pie charts)",
+				Required:    true,
+				Items:       &toolkit.Parameter{Type: "string"},
+			},
+			"series": {
+				Type:        "array",
+				Description: "Data series, each {\"name\": string, \"values\": [numbers, one per label]}",
+				Required:    true,
+				Items:       &toolkit.Parameter{Type: "object"},
+			},
+			"title": {
+				Type:        "string",
+				Description: "Chart title",
+			},
+			"x_label": {
+				Type:        "string",
+				Description: "X axis name (line charts)",
+			},
+			"y_label": {
+				Type:        "string",
+				Description: "Y axis name (bar and line charts)",
+			},
+			"name": {
+				Type:        "string",
+				Description: "File name of the artifact (default: chart.png)",
+			},
+		},
+		Handler: t.renderChart,
+	})
+
+	return t
+}
+
+func (t *ReportToolkit) renderCSV(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	columns, rows, err := parseTable(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(columns); err != nil {
+		return nil, fmt.Errorf("failed to write csv: %w", err)
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return nil, fmt.Errorf("failed to write csv: %w", err)
+	}
+
+	return t.artifact(fileName(args, "table.csv", ".csv"), "text/csv", buf.Bytes(), true)
+}
+
+func (t *ReportToolkit) renderMarkdownTable(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	columns, rows, err := parseTable(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		b.WriteString("|")
+		for _, cell := range cells {
+			b.WriteString(" ")
+			b.WriteString(escapeMarkdownCell(cell))
+			b.WriteString(" |")
+		}
+		b.WriteString("\n")
+	}
+	writeRow(columns)
+	b.WriteString("|")
+	for range columns {
+		b.WriteString(" --- |")
+	}
+	b.WriteString("\n")
+	for _, row := range rows {
+		writeRow(row)
+	}
+
+	return t.artifact(fileName(args, "table.md", ".md"), "text/markdown", []byte(b.String()), true)
+}
+
+// artifact writes data to OutputDir, or inlines it, and describes it
+func (t *ReportToolkit) artifact(name, mimeType string, data []byte, text bool) (map[string]interface{}, error) {
+	result := map[string]interface{}{
+		"name":      name,
+		"mime_type": mimeType,
+		"size":      len(data),
+	}
+	if text {
+		result["content"] = string(data)
+	}
+
+	if t.outputDir == "" {
+		if !text {
+			result["data_base64"] = base64.StdEncoding.EncodeToString(data)
+		}
+		return result, nil
+	}
+
+	if err := os.MkdirAll(t.outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	path := filepath.Join(t.outputDir, name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write artifact: %w", err)
+	}
+	result["path"] = path
+	return result, nil
+}
+
+// parseTable reads the columns and rows arguments, padding short rows
+func parseTable(args map[string]interface{}) ([]string, [][]string, error) {
+	columns, err := stringList(args["columns"], "columns")
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(columns) == 0 {
+		return nil, nil, fmt.Errorf("columns must not be empty")
+	}
+
+	rawRows, ok := args["rows"].([]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("rows must be an array of arrays")
+	}
+	rows := make([][]string, len(rawRows))
+	for i, raw := range rawRows {
+		values, ok := raw.([]interface{})
+		if !ok {
+			return nil, nil, fmt.Errorf("row %d must be an array", i+1)
+		}
+		if len(values) > len(columns) {
+			return nil, nil, fmt.Errorf("row %d has %d values for %d columns", i+1, len(values), len(columns))
+		}
+		row := make([]string, len(columns))
+		for j, value := range values {
+			row[j] = formatCell(value)
+		}
+		rows[i] = row
+	}
+	return columns, rows, nil
+}
+
+func formatCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func escapeMarkdownCell(cell string) string {
+	cell = strings.ReplaceAll(cell, "|", `\|`)
+	cell = strings.ReplaceAll(cell, "\r\n", "<br>")
+	return strings.ReplaceAll(cell, "\n", "<br>")
+}
+
+func stringList(value interface{}, name string) ([]string, error) {
+	switch v := value.(type) {
+	case []string:
+		return v, nil
+	case []interface{}:
+		out := make([]string, len(v))
+		for i, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must be strings", name)
+			}
+			out[i] = s
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("%s parameter is required and must be an array of strings", name)
+	}
+}
+
+// fileName returns the name argument, without directories and with ext,
+// or fallback
+func fileName(args map[string]interface{}, fallback, ext string) string {
+	name, _ := args["name"].(string)
+	name = filepath.Base(strings.TrimSpace(name))
+	if name == "" || name == "." || name == string(filepath.Separator) {
+		return fallback
+	}
+	if !strings.EqualFold(filepath.Ext(name), ext) {
+		name += ext
+	}
+	return name
+}
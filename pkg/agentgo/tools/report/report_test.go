@@ -0,0 +1,158 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+func tableArgs() map[string]interface{} {
+	return map[string]interface{}{
+		"columns": []interface{}{"region", "revenue", "note"},
+		"rows": []interface{}{
+			[]interface{}{"EMEA", 1200.5, "a|b"},
+			[]interface{}{"APAC", float64(900)},
+		},
+	}
+}
+
+func TestNew_RegistersFunctions(t *testing.T) {
+	tk := New(Config{})
+	for _, name := range []string{"render_csv", "render_markdown_table", "render_chart"} {
+		if _, ok := tk.Functions()[name]; !ok {
+			t.Errorf("function %s not registered", name)
+		}
+	}
+}
+
+func TestRenderCSV(t *testing.T) {
+	out, err := New(Config{}).Execute(context.Background(), "render_csv", tableArgs())
+	if err != nil {
+		t.Fatalf("render_csv error = %v", err)
+	}
+	result := out.(map[string]interface{})
+	want := "region,revenue,note\nEMEA,1200.5,a|b\nAPAC,900,\n"
+	if result["content"] != want || result["mime_type"] != "text/csv" || result["name"] != "table.csv" {
+		t.Errorf("result = %v", result)
+	}
+}
+
+func TestRenderMarkdownTable(t *testing.T) {
+	out, err := New(Config{}).Execute(context.Background(), "render_markdown_table", tableArgs())
+	if err != nil {
+		t.Fatalf("render_markdown_table error = %v", err)
+	}
+	want := "| region | revenue | note |\n| --- | --- | --- |\n| EMEA | 1200.5 | a\\|b |\n| APAC | 900 |  |\n"
+	if got := out.(map[string]interface{})["content"]; got != want {
+		t.Errorf("content = %q, want %q", got, want)
+	}
+}
+
+func TestRenderTable_InvalidArgs(t *testing.T) {
+	tk := New(Config{})
+	args := tableArgs()
+	args["rows"] = []interface{}{[]interface{}{1, 2, 3, 4}}
+	if _, err := tk.Execute(context.Background(), "render_csv", args); err == nil {
+		t.Error("expected error for row longer than columns")
+	}
+}
+
+func TestRenderChart(t *testing.T) {
+	tk := New(Config{Width: 400, Height: 300})
+	labels := []interface{}{"Q1", "Q2", "Q3"}
+	tests := []struct {
+		chartType string
+		series    []interface{}
+	}{
+		{ChartBar, []interface{}{map[string]interface{}{"name": "revenue", "values": []interface{}{1.0, 3.0, 2.0}}}},
+		{ChartLine, []interface{}{
+			map[string]interface{}{"name": "2025", "values": []interface{}{1.0, 3.0, 2.0}},
+			map[string]interface{}{"name": "2026", "values": []interface{}{2.0, 4.0, 5.0}},
+		}},
+		{ChartPie, []interface{}{map[string]interface{}{"values": []interface{}{1.0, 3.0, 2.0}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.chartType, func(t *testing.T) {
+			out, err := tk.Execute(context.Background(), "render_chart", map[string]interface{}{
+				"type":   tt.chartType,
+				"title":  "Revenue",
+				"labels": labels,
+				"series": tt.series,
+			})
+			if err != nil {
+				t.Fatalf("render_chart error = %v", err)
+			}
+			result := out.(map[string]interface{})
+			data, err := base64.StdEncoding.DecodeString(result["data_base64"].(string))
+			if err != nil || !bytes.HasPrefix(data, pngSignature) {
+				t.Errorf("artifact is not a PNG: %v", err)
+			}
+			if result["mime_type"] != "image/png" || result["size"] != len(data) {
+				t.Errorf("result = %v", result)
+			}
+		})
+	}
+}
+
+func TestRenderChart_InvalidArgs(t *testing.T) {
+	tk := New(Config{})
+	ctx := context.Background()
+	one := map[string]interface{}{"values": []interface{}{1.0, 2.0}}
+
+	tests := map[string]map[string]interface{}{
+		"unknown type":      {"type": "radar", "labels": []interface{}{"a", "b"}, "series": []interface{}{one}},
+		"length mismatch":   {"type": ChartBar, "labels": []interface{}{"a"}, "series": []interface{}{one}},
+		"two bar series":    {"type": ChartBar, "labels": []interface{}{"a", "b"}, "series": []interface{}{one, one}},
+		"non-numeric":       {"type": ChartLine, "labels": []interface{}{"a", "b"}, "series": []interface{}{map[string]interface{}{"values": []interface{}{"x", 1.0}}}},
+		"negative pie":      {"type": ChartPie, "labels": []interface{}{"a", "b"}, "series": []interface{}{map[string]interface{}{"values": []interface{}{-1.0, 1.0}}}},
+		"single line point": {"type": ChartLine, "labels": []interface{}{"a"}, "series": []interface{}{map[string]interface{}{"values": []interface{}{1.0}}}},
+	}
+	for name, args := range tests {
+		if _, err := tk.Execute(ctx, "render_chart", args); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestOutputDir(t *testing.T) {
+	dir := t.TempDir()
+	tk := New(Config{OutputDir: dir})
+
+	args := map[string]interface{}{
+		"type":   ChartBar,
+		"labels": []interface{}{"a", "b"},
+		"series": []interface{}{map[string]interface{}{"values": []interface{}{1.0, 2.0}}},
+		"name":   "../escape/sales",
+	}
+	out, err := tk.Execute(context.Background(), "render_chart", args)
+	if err != nil {
+		t.Fatalf("render_chart error = %v", err)
+	}
+	result := out.(map[string]interface{})
+	path := result["path"].(string)
+	if path != filepath.Join(dir, "sales.png") {
+		t.Errorf("path = %s, want file inside output dir", path)
+	}
+	if _, inline := result["data_base64"]; inline {
+		t.Error("artifact written to disk should not be inlined")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !bytes.HasPrefix(data, pngSignature) {
+		t.Errorf("written file is not a PNG: %v", err)
+	}
+
+	out, err = tk.Execute(context.Background(), "render_markdown_table", tableArgs())
+	if err != nil {
+		t.Fatalf("render_markdown_table error = %v", err)
+	}
+	written, _ := os.ReadFile(filepath.Join(dir, "table.md"))
+	if !strings.HasPrefix(string(written), "| region |") || out.(map[string]interface{})["content"] != string(written) {
+		t.Errorf("table.md = %q", written)
+	}
+}
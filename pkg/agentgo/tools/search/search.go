@@ -40,6 +40,19 @@ type SearchResult struct {
 	Snippet string `json:"snippet"`
 }
 
+// init registers "search" with a max_results option
+func init() {
+	toolkit.MustRegister("search", func(options map[string]interface{}) (toolkit.Toolkit, error) {
+		var opts struct {
+			MaxResults int `json:"max_results"`
+		}
+		if err := toolkit.DecodeOptions(options, &opts); err != nil {
+			return nil, err
+		}
+		return New(Config{MaxResults: opts.MaxResults}), nil
+	})
+}
+
 // New creates a new DuckDuckGo search toolkit
 func New(config ...Config) *Search {
 	var cfg Config
@@ -0,0 +1,52 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+)
+
+// DefaultInterpreters are the script interpreters skills may run
+var DefaultInterpreters = []string{"sh", "bash", "python", "python3", "node", "ruby"}
+
+// ErrNotAllowed is returned for binaries that are not on the allowlist
+var ErrNotAllowed = errors.New("command is not allowed")
+
+// Allowlist is a set of binary names that may be executed
+type Allowlist map[string]bool
+
+// NewAllowlist creates an allowlist of binary names
+func NewAllowlist(names ...string) Allowlist {
+	a := make(Allowlist, len(names))
+	for _, name := range names {
+		a[name] = true
+	}
+	return a
+}
+
+// Resolve returns the path of an allowed binary. Only the base name of name
+// is used and it is looked up in PATH, so a caller cannot run a different
+// binary by giving a path such as /tmp/evil/bash.
+func (a Allowlist) Resolve(name string) (string, error) {
+	base := filepath.Base(name)
+	if name == "" || !a[base] {
+		return "", fmt.Errorf("%w: %s", ErrNotAllowed, name)
+	}
+	path, err := exec.LookPath(base)
+	if err != nil {
+		return "", fmt.Errorf("command %s not found: %w", base, err)
+	}
+	return path, nil
+}
+
+// Names returns the allowed binary names, sorted
+func (a Allowlist) Names() []string {
+	names := make([]string, 0, len(a))
+	for name := range a {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,30 @@
+//go:build linux
+
+package shell
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// isolate runs the command in its own process group, so cancelling it also
+// kills the processes it started
+func isolate(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// limitCPU caps the CPU time of a started command. The kernel sends SIGXCPU
+// at the limit and SIGKILL one second later.
+func limitCPU(cmd *exec.Cmd, limit time.Duration) error {
+	if limit <= 0 {
+		return nil
+	}
+	seconds := uint64((limit + time.Second - 1) / time.Second)
+	return unix.Prlimit(cmd.Process.Pid, unix.RLIMIT_CPU, &unix.Rlimit{Cur: seconds, Max: seconds + 1}, nil)
+}
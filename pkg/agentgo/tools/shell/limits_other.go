@@ -0,0 +1,16 @@
+//go:build !linux
+
+package shell
+
+import (
+	"os/exec"
+	"time"
+)
+
+// isolate is a no-op: cancelling kills only the command itself
+func isolate(cmd *exec.Cmd) {}
+
+// limitCPU is a no-op: CPU limits are only supported on Linux
+func limitCPU(cmd *exec.Cmd, limit time.Duration) error {
+	return nil
+}
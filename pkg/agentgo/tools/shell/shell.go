@@ -0,0 +1,308 @@
+// Package shell lets agents run allowlisted commands. Commands are executed
+// directly, without a shell, in a confined working directory with a scrubbed
+// environment and time, CPU and output limits. The full result is recorded in
+// the tool call's metadata, so it reaches the agent's ToolExecutionSummary
+// even when the command fails.
+package shell
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/hooks"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+)
+
+const (
+	defaultTimeout        = 30 * time.Second
+	defaultMaxOutputBytes = 64 * 1024
+)
+
+// MetadataKey is the tool call metadata key holding the *Result of a command
+const MetadataKey = "shell"
+
+// Config configures the shell toolkit
+type Config struct {
+	// AllowedCommands are the binary names that may be run, e.g. "git" or "go".
+	// They are looked up in PATH; paths given by the model are ignored.
+	AllowedCommands []string
+
+	// WorkDir is the directory commands run in. The model may pick a
+	// subdirectory, but never one outside of it.
+	WorkDir string
+
+	// Env is the environment of commands. Nothing else is inherited from the
+	// process except PATH and the variables named in PassEnv.
+	Env map[string]string
+
+	// PassEnv names process environment variables to pass through, e.g. "HOME"
+	PassEnv []string
+
+	// Timeout limits the wall-clock time of a command (default: 30s)
+	Timeout time.Duration
+
+	// CPUTime limits the CPU time of a command, rounded up to whole seconds.
+	// Only supported on Linux; zero means no limit.
+	CPUTime time.Duration
+
+	// MaxOutputBytes truncates stdout and stderr, each (default: 64KB)
+	MaxOutputBytes int
+}
+
+// Result is the outcome of a command
+type Result struct {
+	Command         string        `json:"command"`
+	Args            []string      `json:"args,omitempty"`
+	Dir             string        `json:"dir"`
+	ExitCode        int           `json:"exit_code"`
+	Stdout          string        `json:"stdout"`
+	Stderr          string        `json:"stderr"`
+	StdoutTruncated bool          `json:"stdout_truncated,omitempty"`
+	StderrTruncated bool          `json:"stderr_truncated,omitempty"`
+	TimedOut        bool          `json:"timed_out,omitempty"`
+	Duration        time.Duration `json:"duration"`
+}
+
+// ExitError is returned when a command exits with a non-zero status or is
+// killed. It carries the captured output so the model can react to it.
+type ExitError struct {
+	Result *Result
+	Err    error
+}
+
+func (e *ExitError) Error() string {
+	var b strings.Builder
+	if e.Result.TimedOut {
+		fmt.Fprintf(&b, "command %s timed out", e.Result.Command)
+	} else {
+		fmt.Fprintf(&b, "command %s failed: %v", e.Result.Command, e.Err)
+	}
+	if e.Result.Stdout != "" {
+		b.WriteString("\nstdout:\n" + e.Result.Stdout)
+	}
+	if e.Result.Stderr != "" {
+		b.WriteString("\nstderr:\n" + e.Result.Stderr)
+	}
+	return b.String()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// Toolkit runs commands on behalf of an agent
+type Toolkit struct {
+	*toolkit.BaseToolkit
+	allowed   Allowlist
+	workDir   string
+	env       []string
+	timeout   time.Duration
+	cpuTime   time.Duration
+	maxOutput int
+}
+
+// New creates a shell toolkit. WorkDir must be an existing directory and at
+// least one command must be allowed.
+func New(cfg Config) (*Toolkit, error) {
+	if len(cfg.AllowedCommands) == 0 {
+		return nil, fmt.Errorf("at least one allowed command is required")
+	}
+	if cfg.WorkDir == "" {
+		return nil, fmt.Errorf("work directory is required")
+	}
+	workDir, err := filepath.Abs(cfg.WorkDir)
+	if err == nil {
+		workDir, err = filepath.EvalSymlinks(workDir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid work directory: %w", err)
+	}
+	if info, err := os.Stat(workDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("work directory %s is not a directory", cfg.WorkDir)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.MaxOutputBytes <= 0 {
+		cfg.MaxOutputBytes = defaultMaxOutputBytes
+	}
+
+	t := &Toolkit{
+		BaseToolkit: toolkit.NewBaseToolkit("shell"),
+		allowed:     NewAllowlist(cfg.AllowedCommands...),
+		workDir:     workDir,
+		env:         buildEnv(cfg.Env, cfg.PassEnv),
+		timeout:     cfg.Timeout,
+		cpuTime:     cfg.CPUTime,
+		maxOutput:   cfg.MaxOutputBytes,
+	}
+
+	t.RegisterFunction(&toolkit.Function{
+		Name: "run_command",
+		Description: fmt.Sprintf("Run a command without a shell and return its exit code, stdout and stderr. Allowed commands: %s",
+			strings.Join(t.allowed.Names(), ", ")),
+		Parameters: map[string]toolkit.Parameter{
+			"command": {Type: "string", Description: "The command to run, e.g. git", Required: true},
+			"args": {
+				Type:        "array",
+				Description: "Arguments passed to the command as-is; there is no shell expansion",
+				Items:       &toolkit.Parameter{Type: "string"},
+			},
+			"dir": {Type: "string", Description: "Working directory relative to the workspace (default: the workspace)"},
+		},
+		Handler: t.runCommand,
+	})
+
+	return t, nil
+}
+
+func (t *Toolkit) runCommand(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	command, ok := args["command"].(string)
+	if !ok || command == "" {
+		return nil, fmt.Errorf("command must be a non-empty string")
+	}
+	var cmdArgs []string
+	if raw, ok := args["args"].([]interface{}); ok {
+		for _, arg := range raw {
+			s, ok := arg.(string)
+			if !ok {
+				return nil, fmt.Errorf("args must be strings")
+			}
+			cmdArgs = append(cmdArgs, s)
+		}
+	}
+	dir, _ := args["dir"].(string)
+
+	result, err := t.Run(ctx, command, cmdArgs, dir)
+	if result != nil {
+		if input := hooks.ToolHookInputFromContext(ctx); input != nil {
+			input.SetMetadata(MetadataKey, result)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Run runs an allowed command in dir, relative to the work directory. A
+// non-zero exit status or a timeout returns both the result and an *ExitError.
+func (t *Toolkit) Run(ctx context.Context, command string, args []string, dir string) (*Result, error) {
+	path, err := t.allowed.Resolve(command)
+	if err != nil {
+		return nil, err
+	}
+	workDir, err := t.resolveDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	stdout := &limitedBuffer{max: t.maxOutput}
+	stderr := &limitedBuffer{max: t.maxOutput}
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Dir = workDir
+	cmd.Env = t.env
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = time.Second
+	isolate(cmd)
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", command, err)
+	}
+	if err := limitCPU(cmd, t.cpuTime); err != nil {
+		_ = cmd.Cancel()
+		_ = cmd.Wait()
+		return nil, fmt.Errorf("failed to limit CPU time: %w", err)
+	}
+	err = cmd.Wait()
+
+	rel, _ := filepath.Rel(t.workDir, workDir)
+	result := &Result{
+		Command:         filepath.Base(command),
+		Args:            args,
+		Dir:             filepath.ToSlash(rel),
+		ExitCode:        cmd.ProcessState.ExitCode(),
+		Stdout:          stdout.String(),
+		Stderr:          stderr.String(),
+		StdoutTruncated: stdout.truncated,
+		StderrTruncated: stderr.truncated,
+		TimedOut:        errors.Is(ctx.Err(), context.DeadlineExceeded),
+		Duration:        time.Since(start),
+	}
+	if err != nil || result.TimedOut {
+		return result, &ExitError{Result: result, Err: err}
+	}
+	return result, nil
+}
+
+// resolveDir returns dir inside the work directory, following symlinks
+func (t *Toolkit) resolveDir(dir string) (string, error) {
+	if dir == "" {
+		return t.workDir, nil
+	}
+	if filepath.IsAbs(dir) {
+		return "", fmt.Errorf("dir must be relative to the workspace")
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Join(t.workDir, dir))
+	if err != nil {
+		return "", fmt.Errorf("invalid dir: %w", err)
+	}
+	rel, err := filepath.Rel(t.workDir, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("dir %s is outside the workspace", dir)
+	}
+	return resolved, nil
+}
+
+// buildEnv returns PATH, the passed-through variables and env, in that order
+// of precedence from lowest to highest
+func buildEnv(env map[string]string, pass []string) []string {
+	vars := map[string]string{"PATH": os.Getenv("PATH")}
+	for _, name := range pass {
+		if value, ok := os.LookupEnv(name); ok {
+			vars[name] = value
+		}
+	}
+	for name, value := range env {
+		vars[name] = value
+	}
+	list := make([]string, 0, len(vars))
+	for name, value := range vars {
+		list = append(list, name+"="+value)
+	}
+	return list
+}
+
+// limitedBuffer keeps the first max bytes written to it and discards the rest,
+// without failing the writer
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); len(p) > room {
+		b.buf.Write(p[:max(room, 0)])
+		b.truncated = true
+	} else {
+		b.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
@@ -0,0 +1,168 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/hooks"
+)
+
+func newTestToolkit(t *testing.T, cfg Config) *Toolkit {
+	t.Helper()
+	if cfg.WorkDir == "" {
+		cfg.WorkDir = t.TempDir()
+	}
+	if cfg.AllowedCommands == nil {
+		cfg.AllowedCommands = []string{"sh", "pwd"}
+	}
+	tk, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return tk
+}
+
+func TestRun_CapturesOutput(t *testing.T) {
+	tk := newTestToolkit(t, Config{})
+
+	result, err := tk.Run(context.Background(), "sh", []string{"-c", "echo out; echo err >&2"}, "")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.ExitCode != 0 || result.Stdout != "out\n" || result.Stderr != "err\n" || result.Dir != "." {
+		t.Errorf("result = %+v", result)
+	}
+
+	result, err = tk.Run(context.Background(), "/tmp/elsewhere/sh", []string{"-c", "echo out; exit 3"}, "")
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Result != result {
+		t.Fatalf("err = %v, want *ExitError", err)
+	}
+	if result.ExitCode != 3 || result.Stdout != "out\n" || result.Command != "sh" {
+		t.Errorf("result = %+v", result)
+	}
+	if !strings.Contains(err.Error(), "exit status 3") || !strings.Contains(err.Error(), "out") {
+		t.Errorf("error = %q", err)
+	}
+}
+
+func TestRun_NotAllowed(t *testing.T) {
+	tk := newTestToolkit(t, Config{})
+	for _, command := range []string{"rm", "/bin/rm", ""} {
+		if _, err := tk.Run(context.Background(), command, nil, ""); !errors.Is(err, ErrNotAllowed) {
+			t.Errorf("Run(%q) error = %v, want ErrNotAllowed", command, err)
+		}
+	}
+}
+
+func TestRun_Limits(t *testing.T) {
+	tk := newTestToolkit(t, Config{Timeout: 200 * time.Millisecond, MaxOutputBytes: 5})
+
+	result, err := tk.Run(context.Background(), "sh", []string{"-c", "echo 0123456789"}, "")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.Stdout != "01234" || !result.StdoutTruncated {
+		t.Errorf("truncated result = %+v", result)
+	}
+
+	start := time.Now()
+	result, err = tk.Run(context.Background(), "sh", []string{"-c", "sleep 5 & wait"}, "")
+	if err == nil || !result.TimedOut {
+		t.Fatalf("timeout result = %+v, %v", result, err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("timed out command took %s", elapsed)
+	}
+}
+
+func TestRun_CPUTime(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("CPU limits are only supported on Linux")
+	}
+	tk := newTestToolkit(t, Config{CPUTime: time.Second, Timeout: 10 * time.Second})
+
+	result, err := tk.Run(context.Background(), "sh", []string{"-c", "while :; do :; done"}, "")
+	if err == nil || result.TimedOut || result.Duration > 5*time.Second {
+		t.Errorf("result = %+v, %v; want killed by the CPU limit", result, err)
+	}
+}
+
+func TestRun_Environment(t *testing.T) {
+	t.Setenv("AGENTGO_SHELL_SECRET", "secret")
+	t.Setenv("AGENTGO_SHELL_PASSED", "passed")
+	tk := newTestToolkit(t, Config{
+		Env:     map[string]string{"FOO": "bar"},
+		PassEnv: []string{"AGENTGO_SHELL_PASSED"},
+	})
+
+	result, err := tk.Run(context.Background(), "sh", []string{"-c", `echo "$AGENTGO_SHELL_SECRET|$AGENTGO_SHELL_PASSED|$FOO"`}, "")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.Stdout != "|passed|bar\n" {
+		t.Errorf("stdout = %q", result.Stdout)
+	}
+}
+
+func TestRun_WorkDir(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(t.TempDir(), filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	tk := newTestToolkit(t, Config{WorkDir: root})
+
+	result, err := tk.Run(context.Background(), "pwd", nil, "sub")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.Dir != "sub" || filepath.Base(strings.TrimSpace(result.Stdout)) != "sub" {
+		t.Errorf("result = %+v", result)
+	}
+
+	for _, dir := range []string{"..", "escape", "/tmp", "missing"} {
+		if _, err := tk.Run(context.Background(), "pwd", nil, dir); err == nil {
+			t.Errorf("Run in %s succeeded", dir)
+		}
+	}
+}
+
+func TestRunCommand_RecordsMetadata(t *testing.T) {
+	tk := newTestToolkit(t, Config{})
+	input := hooks.NewToolHookInput("agent", "call-1", "run_command", nil)
+	ctx := hooks.WithToolHookInput(context.Background(), input)
+
+	fn := tk.Functions()["run_command"]
+	_, err := fn.Handler(ctx, map[string]interface{}{"command": "sh", "args": []interface{}{"-c", "echo oops >&2; exit 1"}})
+	if err == nil {
+		t.Fatal("expected an error for a failing command")
+	}
+	value, ok := input.GetMetadata(MetadataKey)
+	if !ok {
+		t.Fatal("result not recorded in metadata")
+	}
+	if result := value.(*Result); result.ExitCode != 1 || result.Stderr != "oops\n" {
+		t.Errorf("metadata result = %+v", result)
+	}
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Config{WorkDir: t.TempDir()}); err == nil {
+		t.Error("expected an error without allowed commands")
+	}
+	if _, err := New(Config{AllowedCommands: []string{"sh"}}); err == nil {
+		t.Error("expected an error without a work directory")
+	}
+	if _, err := New(Config{AllowedCommands: []string{"sh"}, WorkDir: filepath.Join(t.TempDir(), "missing")}); err == nil {
+		t.Error("expected an error for a missing work directory")
+	}
+}
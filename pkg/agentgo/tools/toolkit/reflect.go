@@ -0,0 +1,266 @@
+package toolkit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// FromFunc builds a Function from a Go function, deriving its parameters from
+// the fields of an arguments struct instead of a hand-written Parameter map.
+//
+// fn takes an optional context.Context followed by an optional struct (or
+// pointer to struct) holding the arguments, and returns a result, an error,
+// or both:
+//
+//	func(ctx context.Context, args WeatherArgs) (*Weather, error)
+//	func(args WeatherArgs) (string, error)
+//	func(ctx context.Context) error
+//
+// Fields follow the conventions of encoding/json: the json tag names a field,
+// "-" skips it and omitempty makes it optional; all other fields are required.
+// A description tag documents a field and an enum tag lists the allowed values
+// separated by commas:
+//
+//	type WeatherArgs struct {
+//	    City  string `json:"city" description:"City name"`
+//	    Units string `json:"units,omitempty" enum:"celsius,fahrenheit"`
+//	}
+//
+// The handler decodes the model's arguments into the struct, so invalid
+// arguments are reported as errors, and returns the result for the agent to
+// encode as JSON.
+func FromFunc(name, description string, fn interface{}) (*Function, error) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return nil, fmt.Errorf("tool %s: expected a function, got %T", name, fn)
+	}
+	t := v.Type()
+	if t.IsVariadic() {
+		return nil, fmt.Errorf("tool %s: variadic functions are not supported", name)
+	}
+
+	in := 0
+	takesContext := t.NumIn() > in && t.In(in) == contextType
+	if takesContext {
+		in++
+	}
+	var argsType reflect.Type
+	if t.NumIn() > in {
+		argsType = t.In(in)
+		in++
+	}
+	if t.NumIn() > in {
+		return nil, fmt.Errorf("tool %s: expected (context.Context, args struct), got %s", name, t)
+	}
+
+	parameters := map[string]Parameter{}
+	if argsType != nil {
+		structType := argsType
+		if structType.Kind() == reflect.Ptr {
+			structType = structType.Elem()
+		}
+		if structType.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("tool %s: arguments must be a struct, got %s", name, argsType)
+		}
+		var err error
+		if parameters, err = structParameters(structType, map[reflect.Type]bool{}); err != nil {
+			return nil, fmt.Errorf("tool %s: %w", name, err)
+		}
+	}
+
+	returnsError := t.NumOut() > 0 && t.Out(t.NumOut()-1) == errorType
+	results := t.NumOut()
+	if returnsError {
+		results--
+	}
+	if results > 1 {
+		return nil, fmt.Errorf("tool %s: expected at most a result and an error, got %s", name, t)
+	}
+
+	handler := func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		var in []reflect.Value
+		if takesContext {
+			in = append(in, reflect.ValueOf(ctx))
+		}
+		if argsType != nil {
+			arg, err := decodeArguments(args, argsType)
+			if err != nil {
+				return nil, fmt.Errorf("invalid arguments for %s: %w", name, err)
+			}
+			in = append(in, arg)
+		}
+
+		out := v.Call(in)
+		if returnsError {
+			if err, _ := out[len(out)-1].Interface().(error); err != nil {
+				return nil, err
+			}
+		}
+		if results == 0 {
+			return nil, nil
+		}
+		return out[0].Interface(), nil
+	}
+
+	return &Function{
+		Name:        name,
+		Description: description,
+		Parameters:  parameters,
+		Handler:     handler,
+	}, nil
+}
+
+// MustFromFunc is like FromFunc but panics on error, for package-level tool definitions
+func MustFromFunc(name, description string, fn interface{}) *Function {
+	f, err := FromFunc(name, description, fn)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// decodeArguments converts the parsed arguments into a value of type t with a
+// JSON round trip, so field names and types follow encoding/json
+func decodeArguments(args map[string]interface{}, t reflect.Type) (reflect.Value, error) {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if t.Kind() == reflect.Ptr {
+		ptr := reflect.New(t.Elem())
+		if err := json.Unmarshal(data, ptr.Interface()); err != nil {
+			return reflect.Value{}, err
+		}
+		return ptr, nil
+	}
+	ptr := reflect.New(t)
+	if err := json.Unmarshal(data, ptr.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	return ptr.Elem(), nil
+}
+
+func structParameters(t reflect.Type, seen map[reflect.Type]bool) (map[string]Parameter, error) {
+	if seen[t] {
+		return nil, fmt.Errorf("recursive type %s is not supported", t)
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	params := make(map[string]Parameter)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, optional, skip := jsonField(field)
+		if skip {
+			continue
+		}
+
+		// Embedded structs without a json name are flattened, as encoding/json does
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				inner, err := structParameters(embedded, seen)
+				if err != nil {
+					return nil, err
+				}
+				for k, p := range inner {
+					params[k] = p
+				}
+				continue
+			}
+		}
+
+		param, err := typeParameter(field.Type, seen)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		param.Description = field.Tag.Get("description")
+		if enum := field.Tag.Get("enum"); enum != "" {
+			param.Enum = strings.Split(enum, ",")
+		}
+		param.Required = !optional
+		params[name] = param
+	}
+	return params, nil
+}
+
+// jsonField returns the name of a field and whether it is optional or skipped
+func jsonField(field reflect.StructField) (name string, optional, skip bool) {
+	name = field.Name
+	tag := field.Tag.Get("json")
+	if tag == "" {
+		return name, false, false
+	}
+	parts := strings.Split(tag, ",")
+	if parts[0] == "-" && len(parts) == 1 {
+		return "", false, true
+	}
+	if parts[0] != "" {
+		name = parts[0]
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" || opt == "omitzero" {
+			optional = true
+		}
+	}
+	return name, optional, false
+}
+
+func typeParameter(t reflect.Type, seen map[reflect.Type]bool) (Parameter, error) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return Parameter{Type: "string"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return Parameter{Type: "string"}, nil
+	case reflect.Bool:
+		return Parameter{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Parameter{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return Parameter{Type: "number"}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Parameter{Type: "string"}, nil // Base64, as encoding/json expects
+		}
+		items, err := typeParameter(t.Elem(), seen)
+		if err != nil {
+			return Parameter{}, err
+		}
+		return Parameter{Type: "array", Items: &items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return Parameter{}, fmt.Errorf("map keys must be strings, got %s", t.Key())
+		}
+		return Parameter{Type: "object"}, nil
+	case reflect.Struct:
+		properties, err := structParameters(t, seen)
+		if err != nil {
+			return Parameter{}, err
+		}
+		return Parameter{Type: "object", Properties: properties}, nil
+	}
+	return Parameter{}, fmt.Errorf("unsupported type %s", t)
+}
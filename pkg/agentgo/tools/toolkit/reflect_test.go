@@ -0,0 +1,138 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type weatherArgs struct {
+	City   string   `json:"city" description:"City name"`
+	Units  string   `json:"units,omitempty" enum:"celsius,fahrenheit"`
+	Days   int      `json:"days,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+	Where  location `json:"where,omitempty"`
+	Secret string   `json:"-"`
+	hidden string
+}
+
+type location struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+type weather struct {
+	City  string  `json:"city"`
+	Temp  float64 `json:"temp"`
+	Units string  `json:"units"`
+}
+
+func TestFromFunc_Parameters(t *testing.T) {
+	fn, err := FromFunc("get_weather", "Current weather", func(ctx context.Context, args weatherArgs) (*weather, error) {
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("FromFunc: %v", err)
+	}
+
+	want := map[string]Parameter{
+		"city":  {Type: "string", Description: "City name", Required: true},
+		"units": {Type: "string", Enum: []string{"celsius", "fahrenheit"}},
+		"days":  {Type: "integer"},
+		"tags":  {Type: "array", Items: &Parameter{Type: "string"}},
+		"where": {Type: "object", Properties: map[string]Parameter{
+			"lat": {Type: "number", Required: true},
+			"lon": {Type: "number", Required: true},
+		}},
+	}
+	if !reflect.DeepEqual(fn.Parameters, want) {
+		t.Errorf("Parameters = %+v\nwant %+v", fn.Parameters, want)
+	}
+
+	tk := NewBaseToolkit("weather")
+	tk.RegisterFunction(fn)
+	schema := ToModelToolDefinitions([]Toolkit{tk})[0].Function.Parameters
+	where := schema["properties"].(map[string]interface{})["where"].(map[string]interface{})
+	if !reflect.DeepEqual(where["required"], []string{"lat", "lon"}) {
+		t.Errorf("nested schema = %+v", where)
+	}
+	if !reflect.DeepEqual(schema["required"], []string{"city"}) {
+		t.Errorf("required = %v", schema["required"])
+	}
+}
+
+func TestFromFunc_Handler(t *testing.T) {
+	fn := MustFromFunc("get_weather", "Current weather", func(ctx context.Context, args *weatherArgs) (weather, error) {
+		if args.City == "nowhere" {
+			return weather{}, errors.New("unknown city")
+		}
+		return weather{City: args.City, Temp: 21.5, Units: args.Units}, nil
+	})
+
+	result, err := fn.Handler(context.Background(), map[string]interface{}{"city": "Lisbon", "units": "celsius"})
+	if err != nil {
+		t.Fatalf("Handler: %v", err)
+	}
+	if got := result.(weather); got != (weather{City: "Lisbon", Temp: 21.5, Units: "celsius"}) {
+		t.Errorf("result = %+v", got)
+	}
+	out, _ := FormatResult(result)
+	if out != `{"city":"Lisbon","temp":21.5,"units":"celsius"}` {
+		t.Errorf("FormatResult = %s", out)
+	}
+
+	if _, err := fn.Handler(context.Background(), map[string]interface{}{"city": "nowhere"}); err == nil || err.Error() != "unknown city" {
+		t.Errorf("tool error = %v", err)
+	}
+	if _, err := fn.Handler(context.Background(), map[string]interface{}{"city": 42}); err == nil || !strings.Contains(err.Error(), "invalid arguments for get_weather") {
+		t.Errorf("invalid arguments error = %v", err)
+	}
+}
+
+func TestFromFunc_Signatures(t *testing.T) {
+	ctxKey := struct{}{}
+	tests := []struct {
+		name string
+		fn   interface{}
+		want interface{}
+	}{
+		{"args only", func(args weatherArgs) string { return args.City }, "Lisbon"},
+		{"context only", func(ctx context.Context) (interface{}, error) { return ctx.Value(ctxKey), nil }, "value"},
+		{"error only", func(ctx context.Context, args weatherArgs) error { return nil }, nil},
+		{"nothing", func() {}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, err := FromFunc("tool", "", tt.fn)
+			if err != nil {
+				t.Fatalf("FromFunc: %v", err)
+			}
+			ctx := context.WithValue(context.Background(), ctxKey, "value")
+			got, err := fn.Handler(ctx, map[string]interface{}{"city": "Lisbon"})
+			if err != nil || got != tt.want {
+				t.Errorf("Handler = %v, %v; want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromFunc_Invalid(t *testing.T) {
+	type recursive struct {
+		Next *recursive `json:"next"`
+	}
+	tests := map[string]interface{}{
+		"not a function":      "weather",
+		"non-struct args":     func(city string) string { return city },
+		"too many params":     func(ctx context.Context, a, b weatherArgs) {},
+		"too many results":    func() (string, string, error) { return "", "", nil },
+		"unsupported field":   func(args struct{ Fn func() }) {},
+		"recursive arguments": func(args recursive) {},
+	}
+	for name, fn := range tests {
+		if _, err := FromFunc("tool", "", fn); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
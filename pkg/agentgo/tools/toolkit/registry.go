@@ -0,0 +1,137 @@
+package toolkit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Factory creates a toolkit from options given in declarative configuration.
+// options is nil when none were given.
+type Factory func(options map[string]interface{}) (Toolkit, error)
+
+// Registry maps toolkit names to factories, so toolkits can be instantiated
+// from configuration by name. It is safe for concurrent use.
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]Factory
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{factories: make(map[string]Factory)}
+}
+
+// DefaultRegistry is the global registry. Built-in toolkits register
+// themselves in it when their package is imported, like database/sql drivers.
+var DefaultRegistry = NewRegistry()
+
+// Register adds a factory under name. It fails when name is empty, the
+// factory is nil or the name is already registered.
+func (r *Registry) Register(name string, factory Factory) error {
+	if name == "" {
+		return fmt.Errorf("toolkit name cannot be empty")
+	}
+	if factory == nil {
+		return fmt.Errorf("toolkit %q: factory cannot be nil", name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.factories[name]; ok {
+		return fmt.Errorf("toolkit %q is already registered", name)
+	}
+	r.factories[name] = factory
+	return nil
+}
+
+// MustRegister is like Register but panics on error; use it in init functions
+func (r *Registry) MustRegister(name string, factory Factory) {
+	if err := r.Register(name, factory); err != nil {
+		panic(err)
+	}
+}
+
+// Unregister removes name, reporting whether it was registered
+func (r *Registry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.factories[name]
+	delete(r.factories, name)
+	return ok
+}
+
+// Has reports whether name is registered
+func (r *Registry) Has(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.factories[name]
+	return ok
+}
+
+// Names returns the registered names, sorted
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New instantiates the toolkit registered under name
+func (r *Registry) New(name string, options map[string]interface{}) (Toolkit, error) {
+	r.mu.RLock()
+	factory, ok := r.factories[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown toolkit %q", name)
+	}
+	tk, err := factory(options)
+	if err != nil {
+		return nil, fmt.Errorf("toolkit %q: %w", name, err)
+	}
+	return tk, nil
+}
+
+// Register adds a factory to DefaultRegistry
+func Register(name string, factory Factory) error {
+	return DefaultRegistry.Register(name, factory)
+}
+
+// MustRegister adds a factory to DefaultRegistry, panicking on error
+func MustRegister(name string, factory Factory) {
+	DefaultRegistry.MustRegister(name, factory)
+}
+
+// NoOptions adapts a constructor without configuration into a Factory that
+// rejects options
+func NoOptions[T Toolkit](newToolkit func() T) Factory {
+	return func(options map[string]interface{}) (Toolkit, error) {
+		if len(options) > 0 {
+			return nil, fmt.Errorf("no options are supported")
+		}
+		return newToolkit(), nil
+	}
+}
+
+// DecodeOptions decodes options into the struct pointed to by v through its
+// JSON tags, rejecting unknown options
+func DecodeOptions(options map[string]interface{}, v interface{}) error {
+	if len(options) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(options)
+	if err != nil {
+		return fmt.Errorf("invalid options: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid options: %w", err)
+	}
+	return nil
+}
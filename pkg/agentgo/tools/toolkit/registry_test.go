@@ -0,0 +1,77 @@
+package toolkit
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	newEcho := func() *BaseToolkit { return NewBaseToolkit("echo") }
+	if err := r.Register("echo", NoOptions(newEcho)); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	type prefixOptions struct {
+		Prefix string `json:"prefix"`
+	}
+	if err := r.Register("prefixed", func(options map[string]interface{}) (Toolkit, error) {
+		var opts prefixOptions
+		if err := DecodeOptions(options, &opts); err != nil {
+			return nil, err
+		}
+		return NewBaseToolkit(opts.Prefix + "tools"), nil
+	}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	if err := r.Register("echo", NoOptions(newEcho)); err == nil {
+		t.Fatal("expected an error for a duplicate name")
+	}
+	if err := r.Register("", NoOptions(newEcho)); err == nil {
+		t.Fatal("expected an error for an empty name")
+	}
+	if err := r.Register("nil", nil); err == nil {
+		t.Fatal("expected an error for a nil factory")
+	}
+
+	if got := r.Names(); !reflect.DeepEqual(got, []string{"echo", "prefixed"}) {
+		t.Fatalf("Names = %v", got)
+	}
+	if !r.Has("echo") || r.Has("missing") {
+		t.Fatal("Has returned the wrong result")
+	}
+
+	tk, err := r.New("echo", nil)
+	if err != nil || tk.Name() != "echo" {
+		t.Fatalf("New(echo) = %v, %v", tk, err)
+	}
+	if _, err := r.New("echo", map[string]interface{}{"x": 1}); err == nil || !strings.Contains(err.Error(), `toolkit "echo"`) {
+		t.Fatalf("New(echo, options) error = %v", err)
+	}
+	tk, err = r.New("prefixed", map[string]interface{}{"prefix": "my_"})
+	if err != nil || tk.Name() != "my_tools" {
+		t.Fatalf("New(prefixed) = %v, %v", tk, err)
+	}
+	if _, err := r.New("prefixed", map[string]interface{}{"prefx": "my_"}); err == nil {
+		t.Fatal("expected an error for an unknown option")
+	}
+	if _, err := r.New("missing", nil); err == nil {
+		t.Fatal("expected an error for an unknown toolkit")
+	}
+
+	if !r.Unregister("echo") || r.Unregister("echo") || r.Has("echo") {
+		t.Fatal("Unregister did not remove the toolkit")
+	}
+}
+
+func TestMustRegister_Panics(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister("echo", NoOptions(func() *BaseToolkit { return NewBaseToolkit("echo") }))
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for a duplicate name")
+		}
+	}()
+	r.MustRegister("echo", NoOptions(func() *BaseToolkit { return NewBaseToolkit("echo") }))
+}
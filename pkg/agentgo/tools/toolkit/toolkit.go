@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/jholhewres/agent-go/pkg/agentgo/models"
 )
@@ -14,6 +16,7 @@ type Function struct {
 	Description string
 	Parameters  map[string]Parameter
 	Handler     HandlerFunc
+	Timeout     time.Duration // Overrides the agent's DefaultToolTimeout when non-zero
 }
 
 // Parameter defines a function parameter
@@ -24,6 +27,8 @@ type Parameter struct {
 	Enum        []string    `json:"enum,omitempty"`
 	Default     interface{} `json:"default,omitempty"`
 	Items       *Parameter  `json:"items,omitempty"` // For array types: defines the type of array elements
+
+	Properties map[string]Parameter `json:"properties,omitempty"` // For object types: the fields, with Required set per field
 }
 
 // HandlerFunc is the function signature for tool handlers
@@ -38,6 +43,12 @@ type Toolkit interface {
 	Functions() map[string]*Function
 }
 
+// Versioned is implemented by toolkits that report a version. Agents record it
+// in run reproducibility metadata; toolkits without one make a run non-reproducible.
+type Versioned interface {
+	Version() string
+}
+
 // BaseToolkit provides common functionality for toolkit implementations
 type BaseToolkit struct {
 	name      string
@@ -109,17 +120,9 @@ func ToModelToolDefinitions(toolkits []Toolkit) []models.ToolDefinition {
 
 				// Add items for array types (CRITICAL FIX)
 				if param.Type == "array" && param.Items != nil {
-					itemsSchema := map[string]interface{}{
-						"type": param.Items.Type,
-					}
-					if param.Items.Description != "" {
-						itemsSchema["description"] = param.Items.Description
-					}
-					if len(param.Items.Enum) > 0 {
-						itemsSchema["enum"] = param.Items.Enum
-					}
-					paramSchema["items"] = itemsSchema
+					paramSchema["items"] = nestedSchema(*param.Items)
 				}
+				addProperties(paramSchema, param)
 
 				properties[paramName] = paramSchema
 
@@ -133,6 +136,7 @@ func ToModelToolDefinitions(toolkits []Toolkit) []models.ToolDefinition {
 				"properties": properties,
 			}
 			if len(required) > 0 {
+				sort.Strings(required)
 				parameters["required"] = required
 			}
 
@@ -150,6 +154,44 @@ func ToModelToolDefinitions(toolkits []Toolkit) []models.ToolDefinition {
 	return definitions
 }
 
+// nestedSchema builds the schema of an array element or object field
+func nestedSchema(param Parameter) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type": param.Type,
+	}
+	if param.Description != "" {
+		schema["description"] = param.Description
+	}
+	if len(param.Enum) > 0 {
+		schema["enum"] = param.Enum
+	}
+	if param.Type == "array" && param.Items != nil {
+		schema["items"] = nestedSchema(*param.Items)
+	}
+	addProperties(schema, param)
+	return schema
+}
+
+// addProperties adds the fields of an object parameter to its schema
+func addProperties(schema map[string]interface{}, param Parameter) {
+	if param.Type != "object" || len(param.Properties) == 0 {
+		return
+	}
+	properties := make(map[string]interface{}, len(param.Properties))
+	var required []string
+	for name, field := range param.Properties {
+		properties[name] = nestedSchema(field)
+		if field.Required {
+			required = append(required, name)
+		}
+	}
+	schema["properties"] = properties
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+}
+
 // ParseArguments parses JSON arguments string into a map
 func ParseArguments(argsJSON string) (map[string]interface{}, error) {
 	var args map[string]interface{}
@@ -21,6 +21,10 @@ type WebSearchToolkit struct {
 	client *http.Client
 }
 
+func init() {
+	toolkit.MustRegister("websearch", toolkit.NoOptions(New))
+}
+
 // New creates a new WebSearch toolkit
 func New() *WebSearchToolkit {
 	t := &WebSearchToolkit{
@@ -1,7 +1,7 @@
 package types
 
 import (
-	"github.com/google/uuid"
+	"github.com/jholhewres/agent-go/pkg/agentgo/ids"
 )
 
 // Role represents the role of a message sender
@@ -46,7 +46,7 @@ type ToolCallFunction struct {
 // NewMessage creates a new message with the given role and content
 func NewMessage(role Role, content string) *Message {
 	return &Message{
-		ID:      "msg-" + uuid.NewString(),
+		ID:      ids.Prefixed("msg"),
 		Role:    role,
 		Content: content,
 	}
@@ -19,6 +19,19 @@ type Usage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+
+	// EstimatedCost is the cost in USD derived from a pricing table; zero when unpriced
+	EstimatedCost float64 `json:"estimated_cost,omitempty"`
+}
+
+// Add returns the sum of two usage records
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens + other.PromptTokens,
+		CompletionTokens: u.CompletionTokens + other.CompletionTokens,
+		TotalTokens:      u.TotalTokens + other.TotalTokens,
+		EstimatedCost:    u.EstimatedCost + other.EstimatedCost,
+	}
 }
 
 // Metadata contains additional response metadata
@@ -33,6 +46,14 @@ type ResponseChunk struct {
 	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
 	Done      bool       `json:"done"`
 	Error     error      `json:"error,omitempty"`
+
+	// Usage is reported by providers that include token counts in the stream,
+	// usually on the final chunk
+	Usage *Usage `json:"usage,omitempty"`
+
+	// ReasoningContent 是推理模型流式输出的推理片段
+	// ReasoningContent is a streamed part of a reasoning model's reasoning
+	ReasoningContent string `json:"reasoning_content,omitempty"`
 }
 
 // HasToolCalls checks if the response contains tool calls
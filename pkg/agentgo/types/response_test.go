@@ -77,3 +77,14 @@ func TestModelResponse_IsEmpty(t *testing.T) {
 		})
 	}
 }
+
+func TestUsage_Add(t *testing.T) {
+	a := Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15, EstimatedCost: 0.5}
+	b := Usage{PromptTokens: 1, CompletionTokens: 2, TotalTokens: 3, EstimatedCost: 0.25}
+
+	got := a.Add(b)
+	want := Usage{PromptTokens: 11, CompletionTokens: 7, TotalTokens: 18, EstimatedCost: 0.75}
+	if got != want {
+		t.Errorf("Add() = %+v, want %+v", got, want)
+	}
+}
@@ -2,7 +2,10 @@ package vectordb
 
 import (
 	"context"
+	"errors"
 	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/pagination"
 )
 
 // Document represents a document to be stored in the vector database
@@ -40,6 +43,11 @@ type VectorDB interface {
 	// Delete deletes documents from the collection by IDs
 	Delete(ctx context.Context, ids []string) error
 
+	// DeleteByFilter deletes every document whose metadata matches filter
+	// (see Filter, as in Query). An empty filter is an error rather than a
+	// way to empty the collection; use DeleteCollection for that
+	DeleteByFilter(ctx context.Context, filter map[string]interface{}) error
+
 	// Query searches for similar documents using text query
 	// The query text will be embedded automatically
 	Query(ctx context.Context, query string, limit int, filter map[string]interface{}) ([]SearchResult, error)
@@ -53,10 +61,50 @@ type VectorDB interface {
 	// Count returns the number of documents in the collection
 	Count(ctx context.Context) (int, error)
 
+	// ListCollections returns the names of the collections in the database,
+	// sorted
+	ListCollections(ctx context.Context) ([]string, error)
+
+	// CollectionStats describes the named collection ("" for the active
+	// one). It returns ErrCollectionNotFound for an unknown collection
+	CollectionStats(ctx context.Context, name string) (CollectionStats, error)
+
 	// Close closes the connection to the vector database
 	Close() error
 }
 
+// ErrCollectionNotFound is returned by CollectionStats for an unknown collection
+var ErrCollectionNotFound = errors.New("collection not found")
+
+// CollectionStats describes a collection, for maintenance tooling. Fields a
+// database cannot report are left at their zero value, except DiskSize,
+// which is -1 when unknown
+type CollectionStats struct {
+	Name             string           `json:"name"`
+	Count            int              `json:"count"`
+	Dimension        int              `json:"dimension,omitempty"`
+	DistanceFunction DistanceFunction `json:"distance_function,omitempty"`
+	IndexType        string           `json:"index_type,omitempty"` // e.g. "hnsw", "ivfflat", "flat"
+	DiskSize         int64            `json:"disk_size"`            // Bytes on disk, or -1
+}
+
+// Scanner is implemented by databases that can enumerate a collection, e.g.
+// to re-embed it with another model
+type Scanner interface {
+	// Scan returns up to limit documents, including their embeddings, that
+	// follow cursor ("" for the first page), and the cursor of the next page,
+	// which is "" after the last page
+	Scan(ctx context.Context, cursor string, limit int) ([]Document, string, error)
+}
+
+// Lister is implemented by databases that can page through the documents
+// matching a metadata filter, for browsing a collection without knowing IDs
+type Lister interface {
+	// List returns a page of documents matching filter, ordered by CreatedAt
+	// then ID. opts.Filters, Since and Until are ignored; use filter instead
+	List(ctx context.Context, filter map[string]interface{}, opts pagination.ListOptions) (pagination.Page[Document], error)
+}
+
 // EmbeddingFunction defines the interface for generating embeddings
 type EmbeddingFunction interface {
 	// Embed generates embeddings for the given texts
@@ -3,6 +3,8 @@ package chromadb
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
 
 	chroma "github.com/amikos-tech/chroma-go"
 	"github.com/amikos-tech/chroma-go/types"
@@ -305,6 +307,74 @@ func (c *ChromaDB) Delete(ctx context.Context, ids []string) error {
 	return nil
 }
 
+// DeleteByFilter deletes documents whose metadata matches filter
+func (c *ChromaDB) DeleteByFilter(ctx context.Context, filter map[string]interface{}) error {
+	if err := vectordb.CheckDeleteFilter(filter); err != nil {
+		return err
+	}
+
+	if c.collection == nil {
+		return fmt.Errorf("collection not initialized")
+	}
+
+	where, err := whereFilter(filter)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.collection.Delete(ctx, nil, where, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete documents: %w", err)
+	}
+
+	return nil
+}
+
+// whereFilter converts a metadata filter (see vectordb.Filter) to a Chroma
+// where clause. Chroma only accepts one key per object and needs at least two
+// operands for $and and $or, so clauses are nested accordingly. An empty
+// filter returns nil, meaning no where clause.
+func whereFilter(filter map[string]interface{}) (map[string]interface{}, error) {
+	f, err := vectordb.ParseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	if f.Op == vectordb.OpAnd && len(f.Filters) == 0 {
+		return nil, nil
+	}
+	return whereClause(f)
+}
+
+func whereClause(f vectordb.Filter) (map[string]interface{}, error) {
+	switch f.Op {
+	case vectordb.OpAnd, vectordb.OpOr:
+		if len(f.Filters) == 0 {
+			return nil, fmt.Errorf("chroma filters cannot contain an empty $%s", f.Op)
+		}
+		clauses := make([]interface{}, len(f.Filters))
+		for i, sub := range f.Filters {
+			clause, err := whereClause(sub)
+			if err != nil {
+				return nil, err
+			}
+			if len(f.Filters) == 1 {
+				return clause, nil
+			}
+			clauses[i] = clause
+		}
+		return map[string]interface{}{"$" + string(f.Op): clauses}, nil
+	case vectordb.OpEq:
+		return map[string]interface{}{f.Key: f.Value}, nil
+	case vectordb.OpIn:
+		values := f.Values()
+		if len(values) == 0 {
+			return nil, fmt.Errorf("chroma filters cannot contain an empty $in for %q", f.Key)
+		}
+		return map[string]interface{}{f.Key: map[string]interface{}{"$in": values}}, nil
+	}
+	return map[string]interface{}{f.Key: map[string]interface{}{"$" + string(f.Op): f.Value}}, nil
+}
+
 // Query searches for similar documents using text query
 func (c *ChromaDB) Query(ctx context.Context, query string, limit int, filter map[string]interface{}) ([]vectordb.SearchResult, error) {
 	if c.collection == nil {
@@ -345,8 +415,12 @@ func (c *ChromaDB) QueryWithEmbedding(ctx context.Context, embedding []float32,
 	}
 
 	// Add filter if provided
-	if filter != nil {
-		queryOpts = append(queryOpts, types.WithWhereMap(filter))
+	where, err := whereFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	if where != nil {
+		queryOpts = append(queryOpts, types.WithWhereMap(where))
 	}
 
 	// Query ChromaDB using QueryWithOptions
@@ -406,33 +480,77 @@ func (c *ChromaDB) Get(ctx context.Context, ids []string) ([]vectordb.Document,
 		return nil, fmt.Errorf("failed to get documents: %w", err)
 	}
 
-	// Convert to our Document format
-	documents := make([]vectordb.Document, 0)
-	if result != nil && len(result.Ids) > 0 {
-		for i := range result.Ids {
-			doc := vectordb.Document{
-				ID: result.Ids[i],
-			}
+	if result == nil {
+		return []vectordb.Document{}, nil
+	}
+	return documentsFromResult(result.Ids, result.Documents, result.Metadatas, result.Embeddings), nil
+}
 
-			if result.Documents != nil && len(result.Documents) > i {
-				doc.Content = result.Documents[i]
-			}
+// Scan returns up to limit documents, including their embeddings, that follow
+// cursor ("" for the first page). Chroma pages by offset, so the cursor is
+// the number of documents already read; documents added or deleted during a
+// scan can shift the pages.
+func (c *ChromaDB) Scan(ctx context.Context, cursor string, limit int) ([]vectordb.Document, string, error) {
+	if c.collection == nil {
+		return nil, "", fmt.Errorf("collection not initialized")
+	}
 
-			if result.Metadatas != nil && len(result.Metadatas) > i {
-				doc.Metadata = result.Metadatas[i]
-			}
+	offset := 0
+	if cursor != "" {
+		var err error
+		offset, err = strconv.Atoi(cursor)
+		if err != nil || offset < 0 {
+			return nil, "", fmt.Errorf("invalid cursor %q", cursor)
+		}
+	}
+	if limit <= 0 {
+		limit = 100
+	}
 
-			if result.Embeddings != nil && len(result.Embeddings) > i && result.Embeddings[i] != nil {
-				if result.Embeddings[i].ArrayOfFloat32 != nil {
-					doc.Embedding = *result.Embeddings[i].ArrayOfFloat32
-				}
-			}
+	result, err := c.collection.GetWithOptions(
+		ctx,
+		types.WithOffset(int32(offset)),
+		types.WithLimit(int32(limit)),
+		types.WithInclude("documents", "metadatas", "embeddings"),
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to scan documents: %w", err)
+	}
+	if result == nil {
+		return nil, "", nil
+	}
 
-			documents = append(documents, doc)
-		}
+	documents := documentsFromResult(result.Ids, result.Documents, result.Metadatas, result.Embeddings)
+	next := ""
+	if len(documents) == limit {
+		next = strconv.Itoa(offset + len(documents))
 	}
+	return documents, next, nil
+}
 
-	return documents, nil
+// documentsFromResult converts the columns of a Chroma get result to documents
+func documentsFromResult(ids, contents []string, metadatas []map[string]interface{}, embeddings []*types.Embedding) []vectordb.Document {
+	documents := make([]vectordb.Document, 0, len(ids))
+	for i := range ids {
+		doc := vectordb.Document{
+			ID: ids[i],
+		}
+
+		if len(contents) > i {
+			doc.Content = contents[i]
+		}
+
+		if len(metadatas) > i {
+			doc.Metadata = metadatas[i]
+		}
+
+		if len(embeddings) > i && embeddings[i] != nil && embeddings[i].ArrayOfFloat32 != nil {
+			doc.Embedding = *embeddings[i].ArrayOfFloat32
+		}
+
+		documents = append(documents, doc)
+	}
+	return documents
 }
 
 // Count returns the number of documents in the collection
@@ -449,6 +567,66 @@ func (c *ChromaDB) Count(ctx context.Context) (int, error) {
 	return int(count), nil
 }
 
+// ListCollections returns the names of the collections in the database, sorted
+func (c *ChromaDB) ListCollections(ctx context.Context) ([]string, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("client not initialized")
+	}
+
+	collections, err := c.client.ListCollections(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+
+	names := make([]string, len(collections))
+	for i, collection := range collections {
+		names[i] = collection.Name
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// CollectionStats describes a collection ("" for the active one). Chroma
+// does not report the dimension or the size on disk, so Dimension is 0 and
+// DiskSize is -1.
+func (c *ChromaDB) CollectionStats(ctx context.Context, name string) (vectordb.CollectionStats, error) {
+	if c.client == nil {
+		return vectordb.CollectionStats{}, fmt.Errorf("client not initialized")
+	}
+	if name == "" {
+		name = c.collectionName
+	}
+
+	collections, err := c.client.ListCollections(ctx)
+	if err != nil {
+		return vectordb.CollectionStats{}, fmt.Errorf("failed to list collections: %w", err)
+	}
+	for _, collection := range collections {
+		if collection.Name != name {
+			continue
+		}
+
+		count, err := collection.Count(ctx)
+		if err != nil {
+			return vectordb.CollectionStats{}, fmt.Errorf("failed to count documents: %w", err)
+		}
+
+		// Chroma keeps the distance in the "hnsw:space" metadata, l2 by default
+		distance := vectordb.L2
+		if space, ok := collection.Metadata["hnsw:space"].(string); ok {
+			distance = vectordb.DistanceFunction(space)
+		}
+		return vectordb.CollectionStats{
+			Name:             name,
+			Count:            int(count),
+			DistanceFunction: distance,
+			IndexType:        "hnsw",
+			DiskSize:         -1,
+		}, nil
+	}
+	return vectordb.CollectionStats{}, fmt.Errorf("%w: %s", vectordb.ErrCollectionNotFound, name)
+}
+
 // Close closes the connection to the vector database
 func (c *ChromaDB) Close() error {
 	// ChromaDB Go client doesn't require explicit closing
@@ -2,6 +2,8 @@ package chromadb
 
 import (
 	"context"
+	"errors"
+	"reflect"
 	"testing"
 
 	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
@@ -420,6 +422,88 @@ func TestDelete(t *testing.T) {
 	db.DeleteCollection(ctx, "test_delete")
 }
 
+func TestWhereFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  map[string]interface{}
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name:   "empty",
+			filter: nil,
+			want:   nil,
+		},
+		{
+			name:   "single key",
+			filter: map[string]interface{}{"user_id": "alice"},
+			want:   map[string]interface{}{"user_id": "alice"},
+		},
+		{
+			name:   "keys are combined with $and",
+			filter: map[string]interface{}{"user_id": "alice", "role": "user"},
+			want: map[string]interface{}{"$and": []interface{}{
+				map[string]interface{}{"role": "user"},
+				map[string]interface{}{"user_id": "alice"},
+			}},
+		},
+		{
+			name: "expression",
+			filter: vectordb.And(
+				vectordb.In("lang", "go", "rust"),
+				vectordb.Or(vectordb.Gte("year", 2020), vectordb.Ne("status", "draft")),
+			).Map(),
+			want: map[string]interface{}{"$and": []interface{}{
+				map[string]interface{}{"lang": map[string]interface{}{"$in": []interface{}{"go", "rust"}}},
+				map[string]interface{}{"$or": []interface{}{
+					map[string]interface{}{"year": map[string]interface{}{"$gte": 2020}},
+					map[string]interface{}{"status": map[string]interface{}{"$ne": "draft"}},
+				}},
+			}},
+		},
+		{
+			name:   "single operand is unwrapped",
+			filter: vectordb.Or(vectordb.Lt("score", 0.5)).Map(),
+			want:   map[string]interface{}{"score": map[string]interface{}{"$lt": 0.5}},
+		},
+		{
+			name:    "empty in",
+			filter:  vectordb.In("lang").Map(),
+			wantErr: true,
+		},
+		{
+			name:    "unknown operator",
+			filter:  map[string]interface{}{"year": map[string]interface{}{"between": 1}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := whereFilter(tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("whereFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("whereFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeleteByFilter_RejectsEmptyFilter(t *testing.T) {
+	db := &ChromaDB{collectionName: "test"}
+	for _, filter := range []map[string]interface{}{
+		nil,
+		{"$and": []interface{}{}},
+		{"$or": []interface{}{map[string]interface{}{}}},
+	} {
+		if err := db.DeleteByFilter(context.Background(), filter); !errors.Is(err, vectordb.ErrEmptyFilter) {
+			t.Errorf("DeleteByFilter(%v) = %v, want ErrEmptyFilter", filter, err)
+		}
+	}
+}
+
 func TestQueryWithEmbedding(t *testing.T) {
 	// This test requires a running ChromaDB instance
 	t.Skip("Requires running ChromaDB instance")
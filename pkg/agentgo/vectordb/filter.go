@@ -0,0 +1,380 @@
+package vectordb
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// FilterOp is the operator of a Filter
+type FilterOp string
+
+// Filter operators
+const (
+	OpEq  FilterOp = "eq"
+	OpNe  FilterOp = "ne"
+	OpIn  FilterOp = "in"
+	OpGt  FilterOp = "gt"
+	OpGte FilterOp = "gte"
+	OpLt  FilterOp = "lt"
+	OpLte FilterOp = "lte"
+	OpAnd FilterOp = "and"
+	OpOr  FilterOp = "or"
+)
+
+// Filter is a metadata filter expression. Build one with Eq, In, Gt, And,
+// Or, ..., and pass filter.Map() wherever a VectorDB method takes a filter:
+//
+//	vectordb.And(
+//		vectordb.Eq("user_id", "u-42"),
+//		vectordb.Or(vectordb.In("lang", "go", "rust"), vectordb.Gte("year", 2020)),
+//	).Map()
+//
+// The map form is also accepted directly, and is what flat equality filters
+// such as {"user_id": "u-42"} already are:
+//   - a scalar value matches by equality
+//   - a slice matches any of its values
+//   - a map of eq, ne, in, gt, gte, lt and lte compares against the value;
+//     Chroma-style names ($eq, $gte, ...) are accepted too
+//   - "$and" and "$or" hold a list of nested filters
+//
+// Every key of a map must match. Comparisons only match metadata of the same
+// kind as the operand: numbers compare with numbers, strings with strings.
+type Filter struct {
+	Op      FilterOp
+	Key     string      // Metadata field, for comparisons
+	Value   interface{} // Operand; a slice for OpIn
+	Filters []Filter    // Operands of OpAnd and OpOr
+}
+
+// Eq matches metadata whose key equals value
+func Eq(key string, value interface{}) Filter { return Filter{Op: OpEq, Key: key, Value: value} }
+
+// Ne matches metadata that has key with a value other than value
+func Ne(key string, value interface{}) Filter { return Filter{Op: OpNe, Key: key, Value: value} }
+
+// In matches metadata whose key equals any of values
+func In(key string, values ...interface{}) Filter { return Filter{Op: OpIn, Key: key, Value: values} }
+
+// Gt matches metadata whose key is greater than value
+func Gt(key string, value interface{}) Filter { return Filter{Op: OpGt, Key: key, Value: value} }
+
+// Gte matches metadata whose key is greater than or equal to value
+func Gte(key string, value interface{}) Filter { return Filter{Op: OpGte, Key: key, Value: value} }
+
+// Lt matches metadata whose key is less than value
+func Lt(key string, value interface{}) Filter { return Filter{Op: OpLt, Key: key, Value: value} }
+
+// Lte matches metadata whose key is less than or equal to value
+func Lte(key string, value interface{}) Filter { return Filter{Op: OpLte, Key: key, Value: value} }
+
+// And matches metadata that matches every filter
+func And(filters ...Filter) Filter { return Filter{Op: OpAnd, Filters: filters} }
+
+// Or matches metadata that matches at least one filter
+func Or(filters ...Filter) Filter { return Filter{Op: OpOr, Filters: filters} }
+
+// Map encodes the filter in the map form taken by VectorDB methods
+func (f Filter) Map() map[string]interface{} {
+	switch f.Op {
+	case OpAnd, OpOr:
+		nested := make([]interface{}, len(f.Filters))
+		for i, sub := range f.Filters {
+			nested[i] = sub.Map()
+		}
+		return map[string]interface{}{"$" + string(f.Op): nested}
+	case OpEq:
+		if !isList(f.Value) && !isMap(f.Value) {
+			return map[string]interface{}{f.Key: f.Value}
+		}
+	}
+	return map[string]interface{}{f.Key: map[string]interface{}{string(f.Op): f.Value}}
+}
+
+// ParseFilter decodes the map form of a filter. Keys are sorted, so the
+// result is deterministic. An empty map parses to an empty And, which
+// matches everything.
+func ParseFilter(filter map[string]interface{}) (Filter, error) {
+	keys := make([]string, 0, len(filter))
+	for key := range filter {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var clauses []Filter
+	for _, key := range keys {
+		value := filter[key]
+		switch key {
+		case "$and", "$or":
+			nested, err := parseNested(key, value)
+			if err != nil {
+				return Filter{}, err
+			}
+			clauses = append(clauses, Filter{Op: FilterOp(key[1:]), Filters: nested})
+			continue
+		}
+
+		if ops, ok := value.(map[string]interface{}); ok {
+			names := make([]string, 0, len(ops))
+			for op := range ops {
+				names = append(names, op)
+			}
+			sort.Strings(names)
+			for _, op := range names {
+				clause := Filter{Op: FilterOp(strings.TrimPrefix(op, "$")), Key: key, Value: ops[op]}
+				switch clause.Op {
+				case OpEq, OpNe, OpGt, OpGte, OpLt, OpLte:
+				case OpIn:
+					if !isList(clause.Value) {
+						return Filter{}, fmt.Errorf("filter %q: in needs a list, got %T", key, clause.Value)
+					}
+				default:
+					return Filter{}, fmt.Errorf("filter %q: unknown operator %q", key, op)
+				}
+				clauses = append(clauses, clause)
+			}
+			continue
+		}
+
+		if isList(value) {
+			clauses = append(clauses, Filter{Op: OpIn, Key: key, Value: value})
+			continue
+		}
+		clauses = append(clauses, Filter{Op: OpEq, Key: key, Value: value})
+	}
+
+	if len(clauses) == 1 {
+		return clauses[0], nil
+	}
+	return Filter{Op: OpAnd, Filters: clauses}, nil
+}
+
+func parseNested(key string, value interface{}) ([]Filter, error) {
+	maps, err := parseNestedMaps(key, value)
+	if err != nil {
+		return nil, err
+	}
+
+	nested := make([]Filter, len(maps))
+	for i, m := range maps {
+		f, err := ParseFilter(m)
+		if err != nil {
+			return nil, err
+		}
+		nested[i] = f
+	}
+	return nested, nil
+}
+
+func parseNestedMaps(key string, value interface{}) ([]map[string]interface{}, error) {
+	switch v := value.(type) {
+	case []map[string]interface{}:
+		return v, nil
+	case []interface{}:
+		maps := make([]map[string]interface{}, 0, len(v))
+		for _, item := range v {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("filter %q: expected a list of filters, got %T", key, item)
+			}
+			maps = append(maps, m)
+		}
+		return maps, nil
+	}
+	return nil, fmt.Errorf("filter %q: expected a list of filters, got %T", key, value)
+}
+
+// ErrEmptyFilter is returned by DeleteByFilter for a filter that would match
+// every document
+var ErrEmptyFilter = errors.New("filter is required")
+
+// CheckDeleteFilter rejects filters that would match every document: an empty
+// map, an empty $and or $or, an empty nested filter, or an empty operator map.
+// ParseFilter reads all of these as an empty And, so DeleteByFilter
+// implementations call it before deleting anything.
+func CheckDeleteFilter(filter map[string]interface{}) error {
+	if len(filter) == 0 {
+		return ErrEmptyFilter
+	}
+	for key, value := range filter {
+		switch key {
+		case "$and", "$or":
+			nested, err := parseNestedMaps(key, value)
+			if err != nil {
+				return err
+			}
+			if len(nested) == 0 {
+				return fmt.Errorf("%w: %q is empty", ErrEmptyFilter, key)
+			}
+			for _, m := range nested {
+				if err := CheckDeleteFilter(m); err != nil {
+					return err
+				}
+			}
+		default:
+			if ops, ok := value.(map[string]interface{}); ok && len(ops) == 0 {
+				return fmt.Errorf("%w: %q has no operator", ErrEmptyFilter, key)
+			}
+		}
+	}
+	return nil
+}
+
+// Match reports whether metadata satisfies the filter. Stores without a query
+// language of their own evaluate filters with it.
+func (f Filter) Match(metadata map[string]interface{}) bool {
+	switch f.Op {
+	case OpAnd:
+		for _, sub := range f.Filters {
+			if !sub.Match(metadata) {
+				return false
+			}
+		}
+		return true
+	case OpOr:
+		for _, sub := range f.Filters {
+			if sub.Match(metadata) {
+				return true
+			}
+		}
+		return false
+	}
+
+	got, ok := metadata[f.Key]
+	if !ok {
+		return false
+	}
+	switch f.Op {
+	case OpEq:
+		return equal(got, f.Value)
+	case OpNe:
+		return !equal(got, f.Value)
+	case OpIn:
+		for _, value := range f.Values() {
+			if equal(got, value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if g, ok := toFloat(got); ok {
+		o, ok := toFloat(f.Value)
+		return ok && compare(f.Op, cmpFloat(g, o))
+	}
+	if g, ok := got.(string); ok {
+		o, ok := f.Value.(string)
+		return ok && compare(f.Op, cmpString(g, o))
+	}
+	return false
+}
+
+// MatchMap reports whether metadata satisfies a filter in map form. Invalid
+// filters match nothing.
+func MatchMap(metadata, filter map[string]interface{}) bool {
+	f, err := ParseFilter(filter)
+	return err == nil && f.Match(metadata)
+}
+
+// Values returns the operands of an OpIn filter
+func (f Filter) Values() []interface{} {
+	rv := reflect.ValueOf(f.Value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil
+	}
+	values := make([]interface{}, rv.Len())
+	for i := range values {
+		values[i] = rv.Index(i).Interface()
+	}
+	return values
+}
+
+func isList(v interface{}) bool {
+	if _, ok := v.([]byte); ok {
+		return false
+	}
+	kind := reflect.ValueOf(v).Kind()
+	return kind == reflect.Slice || kind == reflect.Array
+}
+
+func isMap(v interface{}) bool {
+	return reflect.ValueOf(v).Kind() == reflect.Map
+}
+
+func compare(op FilterOp, c int) bool {
+	switch op {
+	case OpGt:
+		return c > 0
+	case OpGte:
+		return c >= 0
+	case OpLt:
+		return c < 0
+	case OpLte:
+		return c <= 0
+	}
+	return false
+}
+
+func cmpFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func cmpString(a, b string) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// equal compares numbers by value, so 2009 matches 2009.0 read back from JSON
+func equal(a, b interface{}) bool {
+	if af, ok := toFloat(a); ok {
+		bf, ok := toFloat(b)
+		return ok && af == bf
+	}
+	if reflect.TypeOf(a) == reflect.TypeOf(b) && reflect.TypeOf(a).Comparable() {
+		return a == b
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
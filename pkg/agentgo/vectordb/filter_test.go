@@ -0,0 +1,170 @@
+package vectordb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFilter_MapRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		want   map[string]interface{}
+	}{
+		{"eq", Eq("lang", "go"), map[string]interface{}{"lang": "go"}},
+		{"ne", Ne("lang", "go"), map[string]interface{}{"lang": map[string]interface{}{"ne": "go"}}},
+		{"in", In("lang", "go", "rust"), map[string]interface{}{"lang": map[string]interface{}{"in": []interface{}{"go", "rust"}}}},
+		{"gte", Gte("year", 2020), map[string]interface{}{"year": map[string]interface{}{"gte": 2020}}},
+		{"eq list", Eq("tags", []string{"a"}), map[string]interface{}{"tags": map[string]interface{}{"eq": []string{"a"}}}},
+		{
+			"and of or",
+			And(Eq("lang", "go"), Or(Lt("year", 2010), Gt("year", 2020))),
+			map[string]interface{}{"$and": []interface{}{
+				map[string]interface{}{"lang": "go"},
+				map[string]interface{}{"$or": []interface{}{
+					map[string]interface{}{"year": map[string]interface{}{"lt": 2010}},
+					map[string]interface{}{"year": map[string]interface{}{"gt": 2020}},
+				}},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.filter.Map()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Map() = %#v, want %#v", got, tt.want)
+			}
+			parsed, err := ParseFilter(got)
+			if err != nil {
+				t.Fatalf("ParseFilter() error = %v", err)
+			}
+			if !reflect.DeepEqual(parsed, tt.filter) {
+				t.Errorf("ParseFilter() = %#v, want %#v", parsed, tt.filter)
+			}
+		})
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	got, err := ParseFilter(map[string]interface{}{
+		"year": map[string]interface{}{"lt": 2025, "gte": 2020},
+		"lang": []string{"go", "rust"},
+		"team": "core",
+	})
+	if err != nil {
+		t.Fatalf("ParseFilter() error = %v", err)
+	}
+	want := And(
+		Filter{Op: OpIn, Key: "lang", Value: []string{"go", "rust"}},
+		Eq("team", "core"),
+		Gte("year", 2020),
+		Lt("year", 2025),
+	)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseFilter() = %#v, want %#v", got, want)
+	}
+
+	got, err = ParseFilter(map[string]interface{}{"date": map[string]interface{}{"$gte": "2025-01-01"}})
+	if err != nil || !reflect.DeepEqual(got, Gte("date", "2025-01-01")) {
+		t.Errorf("ParseFilter($gte) = %#v, %v", got, err)
+	}
+
+	if got, err := ParseFilter(nil); err != nil || !reflect.DeepEqual(got, Filter{Op: OpAnd}) {
+		t.Errorf("ParseFilter(nil) = %#v, %v; want an empty And", got, err)
+	}
+
+	invalid := []map[string]interface{}{
+		{"year": map[string]interface{}{"between": 1}},
+		{"lang": map[string]interface{}{"in": "go"}},
+		{"$or": "lang"},
+		{"$and": []interface{}{"lang"}},
+		{"$or": []interface{}{map[string]interface{}{"year": map[string]interface{}{"gt": 1, "bad": 2}}}},
+	}
+	for _, filter := range invalid {
+		if _, err := ParseFilter(filter); err == nil {
+			t.Errorf("ParseFilter(%v) should fail", filter)
+		}
+	}
+}
+
+func TestFilter_Match(t *testing.T) {
+	metadata := map[string]interface{}{
+		"lang":  "go",
+		"year":  2009.0, // as read back from JSON
+		"score": 0.75,
+		"draft": false,
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"eq", Eq("lang", "go"), true},
+		{"eq number across types", Eq("year", 2009), true},
+		{"eq bool", Eq("draft", false), true},
+		{"ne", Ne("lang", "rust"), true},
+		{"ne missing key", Ne("team", "core"), false},
+		{"in", In("lang", "rust", "go"), true},
+		{"in miss", In("lang", "rust"), false},
+		{"empty in", In("lang"), false},
+		{"gt", Gt("year", 2000), true},
+		{"gte equal", Gte("year", 2009), true},
+		{"lt", Lt("score", 0.5), false},
+		{"lte", Lte("score", 0.75), true},
+		{"string range", Gte("lang", "f"), true},
+		{"mixed kinds never compare", Gt("lang", 1), false},
+		{"missing key", Eq("team", "core"), false},
+		{"and", And(Eq("lang", "go"), Gt("year", 2000)), true},
+		{"and miss", And(Eq("lang", "go"), Gt("year", 2010)), false},
+		{"or", Or(Eq("lang", "rust"), Gt("year", 2000)), true},
+		{"or miss", Or(Eq("lang", "rust"), Gt("year", 2010)), false},
+		{"empty and", And(), true},
+		{"empty or", Or(), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(metadata); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+			if got := MatchMap(metadata, tt.filter.Map()); got != tt.want {
+				t.Errorf("MatchMap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if MatchMap(metadata, map[string]interface{}{"year": map[string]interface{}{"between": 1}}) {
+		t.Error("invalid filters should match nothing")
+	}
+}
+
+func TestCheckDeleteFilter(t *testing.T) {
+	rejected := []map[string]interface{}{
+		nil,
+		{},
+		{"$and": []interface{}{}},
+		{"$or": []interface{}{map[string]interface{}{}}},
+		{"$and": []map[string]interface{}{{"lang": "go"}, {"$or": []interface{}{}}}},
+		{"lang": map[string]interface{}{}},
+		And().Map(),
+		Or(And()).Map(),
+	}
+	for _, filter := range rejected {
+		if err := CheckDeleteFilter(filter); !errors.Is(err, ErrEmptyFilter) {
+			t.Errorf("CheckDeleteFilter(%v) = %v, want ErrEmptyFilter", filter, err)
+		}
+	}
+
+	accepted := []map[string]interface{}{
+		{"lang": "go"},
+		And(Eq("lang", "go"), Or(Gt("year", 2000), In("team", "core"))).Map(),
+	}
+	for _, filter := range accepted {
+		if err := CheckDeleteFilter(filter); err != nil {
+			t.Errorf("CheckDeleteFilter(%v) = %v, want nil", filter, err)
+		}
+	}
+}
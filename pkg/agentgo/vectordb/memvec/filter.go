@@ -0,0 +1,10 @@
+package memvec
+
+import "github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+
+// Match reports whether metadata satisfies filter. See vectordb.Filter for
+// the supported forms: equality, match-any slices, eq/ne/in/gt/gte/lt/lte
+// comparisons and nested $and/$or. Invalid filters match nothing.
+func Match(metadata, filter map[string]interface{}) bool {
+	return vectordb.MatchMap(metadata, filter)
+}
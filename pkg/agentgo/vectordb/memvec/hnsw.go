@@ -0,0 +1,243 @@
+package memvec
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+)
+
+// hnswIndex is a Hierarchical Navigable Small World graph (Malkov & Yashunin).
+// Deleted nodes stay in the graph as waypoints but are never returned; the
+// collection rebuilds the graph once they outnumber live nodes.
+type hnswIndex struct {
+	config    HNSWConfig
+	dist      func(a, b []float32) float32
+	rng       *rand.Rand
+	levelMult float64
+	nodes     []*hnswNode
+	entry     int32
+	maxLevel  int
+	deleted   int
+}
+
+type hnswNode struct {
+	id      string
+	vector  []float32
+	links   [][]int32 // neighbors per layer
+	deleted bool
+}
+
+func newHNSWIndex(config HNSWConfig, dist func(a, b []float32) float32) *hnswIndex {
+	return &hnswIndex{
+		config:    config,
+		dist:      dist,
+		rng:       rand.New(rand.NewSource(config.Seed)),
+		levelMult: 1 / math.Log(float64(max(config.M, 2))),
+		entry:     -1,
+	}
+}
+
+// reset returns an empty index with the same configuration
+func (h *hnswIndex) reset() *hnswIndex {
+	return newHNSWIndex(h.config, h.dist)
+}
+
+func (h *hnswIndex) needsRebuild() bool {
+	return h.deleted > 0 && h.deleted > len(h.nodes)-h.deleted
+}
+
+func (h *hnswIndex) maxLinks(layer int) int {
+	if layer == 0 {
+		return h.config.M * 2
+	}
+	return h.config.M
+}
+
+// insert adds a vector and returns its node number
+func (h *hnswIndex) insert(id string, vector []float32) int32 {
+	level := int(math.Floor(-math.Log(1-h.rng.Float64()) * h.levelMult))
+	n := int32(len(h.nodes))
+	node := &hnswNode{id: id, vector: vector, links: make([][]int32, level+1)}
+	h.nodes = append(h.nodes, node)
+
+	if h.entry < 0 {
+		h.entry = n
+		h.maxLevel = level
+		return n
+	}
+
+	ep := h.entry
+	for l := h.maxLevel; l > level; l-- {
+		ep = h.greedy(vector, ep, l)
+	}
+	for l := min(level, h.maxLevel); l >= 0; l-- {
+		candidates := h.searchLayer(vector, ep, h.config.EfConstruction, l)
+		node.links[l] = closest(candidates, h.config.M)
+		for _, nb := range node.links[l] {
+			h.link(nb, n, l)
+		}
+		ep = candidates[0].node
+	}
+	if level > h.maxLevel {
+		h.entry = n
+		h.maxLevel = level
+	}
+	return n
+}
+
+// link adds to as a neighbor of from, pruning from's links to the closest ones
+func (h *hnswIndex) link(from, to int32, layer int) {
+	node := h.nodes[from]
+	node.links[layer] = append(node.links[layer], to)
+	limit := h.maxLinks(layer)
+	if len(node.links[layer]) <= limit {
+		return
+	}
+	candidates := make([]candidate, len(node.links[layer]))
+	for i, nb := range node.links[layer] {
+		candidates[i] = candidate{node: nb, dist: h.dist(node.vector, h.nodes[nb].vector)}
+	}
+	sortCandidates(candidates)
+	node.links[layer] = closest(candidates, limit)
+}
+
+func (h *hnswIndex) remove(n int32) {
+	if n < 0 || int(n) >= len(h.nodes) || h.nodes[n].deleted {
+		return
+	}
+	h.nodes[n].deleted = true
+	h.deleted++
+}
+
+// search returns the IDs of up to k live nodes nearest to query, closest first
+func (h *hnswIndex) search(query []float32, k int) []string {
+	if h.entry < 0 {
+		return nil
+	}
+	ep := h.entry
+	for l := h.maxLevel; l > 0; l-- {
+		ep = h.greedy(query, ep, l)
+	}
+	// Widen the beam by the deleted share so tombstones do not starve results.
+	ef := max(h.config.EfSearch, k) + h.deleted
+	ids := make([]string, 0, k)
+	for _, c := range h.searchLayer(query, ep, ef, 0) {
+		if node := h.nodes[c.node]; !node.deleted {
+			ids = append(ids, node.id)
+			if len(ids) == k {
+				break
+			}
+		}
+	}
+	return ids
+}
+
+// greedy walks layer towards query and returns the closest node found
+func (h *hnswIndex) greedy(query []float32, ep int32, layer int) int32 {
+	best := h.dist(query, h.nodes[ep].vector)
+	for changed := true; changed; {
+		changed = false
+		for _, nb := range h.nodes[ep].links[layer] {
+			if d := h.dist(query, h.nodes[nb].vector); d < best {
+				best, ep, changed = d, nb, true
+			}
+		}
+	}
+	return ep
+}
+
+// searchLayer runs a beam search of width ef on layer and returns the
+// candidates found, closest first
+func (h *hnswIndex) searchLayer(query []float32, ep int32, ef, layer int) []candidate {
+	visited := map[int32]bool{ep: true}
+	first := candidate{node: ep, dist: h.dist(query, h.nodes[ep].vector)}
+	frontier := &minHeap{first}
+	results := &maxHeap{first}
+
+	for frontier.Len() > 0 {
+		c := heap.Pop(frontier).(candidate)
+		if results.Len() >= ef && c.dist > (*results)[0].dist {
+			break
+		}
+		for _, nb := range h.nodes[c.node].links[layer] {
+			if visited[nb] {
+				continue
+			}
+			visited[nb] = true
+			d := h.dist(query, h.nodes[nb].vector)
+			if results.Len() < ef || d < (*results)[0].dist {
+				heap.Push(frontier, candidate{node: nb, dist: d})
+				heap.Push(results, candidate{node: nb, dist: d})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	out := []candidate(*results)
+	sortCandidates(out)
+	return out
+}
+
+type candidate struct {
+	node int32
+	dist float32
+}
+
+func closest(candidates []candidate, n int) []int32 {
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	nodes := make([]int32, len(candidates))
+	for i, c := range candidates {
+		nodes[i] = c.node
+	}
+	return nodes
+}
+
+func sortCandidates(candidates []candidate) {
+	h := minHeap(candidates)
+	heap.Init(&h)
+	sorted := make([]candidate, 0, len(candidates))
+	for h.Len() > 0 {
+		sorted = append(sorted, heap.Pop(&h).(candidate))
+	}
+	copy(candidates, sorted)
+}
+
+type minHeap []candidate
+
+func (h minHeap) Len() int { return len(h) }
+func (h minHeap) Less(i, j int) bool {
+	if h[i].dist != h[j].dist {
+		return h[i].dist < h[j].dist
+	}
+	return h[i].node < h[j].node
+}
+func (h minHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(x interface{}) { *h = append(*h, x.(candidate)) }
+func (h *minHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+type maxHeap []candidate
+
+func (h maxHeap) Len() int { return len(h) }
+func (h maxHeap) Less(i, j int) bool {
+	if h[i].dist != h[j].dist {
+		return h[i].dist > h[j].dist
+	}
+	return h[i].node > h[j].node
+}
+func (h maxHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *maxHeap) Push(x interface{}) { *h = append(*h, x.(candidate)) }
+func (h *maxHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
@@ -0,0 +1,702 @@
+// Package memvec is an embedded, pure-Go implementation of vectordb.VectorDB.
+//
+// Documents live in memory and are searched by brute force, or through an
+// optional HNSW index for larger collections. Stores can be saved to and
+// loaded from a gob file, so tests, demos and small applications can run
+// without an external database.
+package memvec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/pagination"
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+)
+
+var (
+	_ vectordb.VectorDB = (*MemVec)(nil)
+	_ vectordb.Lister   = (*MemVec)(nil)
+)
+
+// HNSWConfig enables approximate search with an HNSW graph index
+type HNSWConfig struct {
+	M              int   // Links per node and layer (default: 16)
+	EfConstruction int   // Candidate list size while building (default: 200)
+	EfSearch       int   // Candidate list size while searching (default: 64)
+	Seed           int64 // Seed for layer assignment, for reproducible graphs (default: 1)
+}
+
+// Config holds MemVec configuration
+type Config struct {
+	// CollectionName is the active collection (default: "default")
+	CollectionName string
+	// DistanceFunction used by new collections (default: cosine)
+	DistanceFunction vectordb.DistanceFunction
+	// Dimension enforces the vector size; 0 infers it from the first document
+	Dimension int
+	// EmbeddingFunction embeds text queries and documents without embeddings
+	EmbeddingFunction vectordb.EmbeddingFunction
+	// HNSW enables the HNSW index (nil = brute-force search)
+	HNSW *HNSWConfig
+	// Path makes the store file-backed: it is loaded by New when the file
+	// exists and saved by Close
+	Path string
+}
+
+// MemVec implements vectordb.VectorDB in memory
+type MemVec struct {
+	mu          sync.RWMutex
+	collections map[string]*collection
+	current     string
+	distance    vectordb.DistanceFunction
+	dimension   int
+	embedder    vectordb.EmbeddingFunction
+	hnsw        *HNSWConfig
+	path        string
+}
+
+type collection struct {
+	distance  vectordb.DistanceFunction
+	dimension int
+	docs      map[string]*entry
+	index     *hnswIndex
+}
+
+type entry struct {
+	doc  vectordb.Document
+	node int32 // HNSW node, -1 when not indexed
+}
+
+// New creates a new MemVec instance
+func New(config Config) (*MemVec, error) {
+	if config.CollectionName == "" {
+		config.CollectionName = "default"
+	}
+	if config.DistanceFunction == "" {
+		config.DistanceFunction = vectordb.Cosine
+	}
+	switch config.DistanceFunction {
+	case vectordb.Cosine, vectordb.L2, vectordb.InnerProduct:
+	default:
+		return nil, fmt.Errorf("unsupported distance function %q", config.DistanceFunction)
+	}
+	if config.Dimension < 0 {
+		return nil, fmt.Errorf("dimension must not be negative")
+	}
+	if h := config.HNSW; h != nil {
+		copied := *h
+		if copied.M <= 0 {
+			copied.M = 16
+		}
+		if copied.EfConstruction <= 0 {
+			copied.EfConstruction = 200
+		}
+		if copied.EfSearch <= 0 {
+			copied.EfSearch = 64
+		}
+		if copied.Seed == 0 {
+			copied.Seed = 1
+		}
+		config.HNSW = &copied
+	}
+
+	m := &MemVec{
+		collections: make(map[string]*collection),
+		current:     config.CollectionName,
+		distance:    config.DistanceFunction,
+		dimension:   config.Dimension,
+		embedder:    config.EmbeddingFunction,
+		hnsw:        config.HNSW,
+		path:        config.Path,
+	}
+
+	if config.Path != "" {
+		if err := m.Load(config.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		m.current = config.CollectionName
+	}
+	return m, nil
+}
+
+// CreateCollection creates a collection if needed and makes it active. An
+// empty name uses the active collection. metadata may set "distance" and
+// "dimension" for a new collection.
+func (m *MemVec) CreateCollection(_ context.Context, name string, metadata map[string]interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if name != "" {
+		m.current = name
+	}
+	if _, ok := m.collections[m.current]; ok {
+		return nil
+	}
+
+	distance := m.distance
+	if d, ok := metadata["distance"].(string); ok && d != "" {
+		distance = vectordb.DistanceFunction(d)
+		switch distance {
+		case vectordb.Cosine, vectordb.L2, vectordb.InnerProduct:
+		default:
+			return fmt.Errorf("unsupported distance function %q", d)
+		}
+	}
+	dimension := m.dimension
+	if d, ok := metadata["dimension"].(int); ok && d > 0 {
+		dimension = d
+	}
+	m.collections[m.current] = m.newCollection(distance, dimension)
+	return nil
+}
+
+// DeleteCollection deletes a collection. An empty name uses the active collection.
+func (m *MemVec) DeleteCollection(_ context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if name == "" {
+		name = m.current
+	}
+	delete(m.collections, name)
+	return nil
+}
+
+// Collections returns the names of all collections, sorted
+func (m *MemVec) Collections() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.collections))
+	for name := range m.collections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ListCollections returns the names of all collections, sorted
+func (m *MemVec) ListCollections(_ context.Context) ([]string, error) {
+	return m.Collections(), nil
+}
+
+// CollectionStats describes a collection. An empty name uses the active
+// collection. DiskSize is -1: memvec lives in memory.
+func (m *MemVec) CollectionStats(_ context.Context, name string) (vectordb.CollectionStats, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if name == "" {
+		name = m.current
+	}
+	c, ok := m.collections[name]
+	if !ok {
+		return vectordb.CollectionStats{}, fmt.Errorf("%w: %s", vectordb.ErrCollectionNotFound, name)
+	}
+
+	indexType := "flat"
+	if c.index != nil {
+		indexType = "hnsw"
+	}
+	return vectordb.CollectionStats{
+		Name:             name,
+		Count:            len(c.docs),
+		Dimension:        c.dimension,
+		DistanceFunction: c.distance,
+		IndexType:        indexType,
+		DiskSize:         -1,
+	}, nil
+}
+
+// Add adds documents to the active collection, replacing documents with the same ID
+func (m *MemVec) Add(ctx context.Context, documents []vectordb.Document) error {
+	return m.put(ctx, documents, false)
+}
+
+// Update replaces existing documents. It fails if any document does not exist.
+func (m *MemVec) Update(ctx context.Context, documents []vectordb.Document) error {
+	return m.put(ctx, documents, true)
+}
+
+func (m *MemVec) put(ctx context.Context, documents []vectordb.Document, mustExist bool) error {
+	if len(documents) == 0 {
+		return nil
+	}
+
+	// Copy so generated embeddings are not written into the caller's slice.
+	documents = append([]vectordb.Document(nil), documents...)
+	if err := m.fillEmbeddings(ctx, documents); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c := m.ensureActive()
+	dimension := c.dimension
+	for i, doc := range documents {
+		if doc.ID == "" {
+			return fmt.Errorf("document %d has no ID", i)
+		}
+		if len(doc.Embedding) == 0 {
+			return fmt.Errorf("document %s has no embedding and no embedding function is configured", doc.ID)
+		}
+		if dimension == 0 {
+			dimension = len(doc.Embedding)
+		}
+		if len(doc.Embedding) != dimension {
+			return fmt.Errorf("document %s has dimension %d, expected %d", doc.ID, len(doc.Embedding), dimension)
+		}
+		if mustExist {
+			if _, ok := c.docs[doc.ID]; !ok {
+				return fmt.Errorf("document %s not found", doc.ID)
+			}
+		}
+	}
+
+	c.dimension = dimension
+	for _, doc := range documents {
+		if doc.CreatedAt.IsZero() {
+			doc.CreatedAt = time.Now()
+		}
+		doc.Embedding = append([]float32(nil), doc.Embedding...)
+		c.put(doc)
+	}
+	return nil
+}
+
+// fillEmbeddings embeds the content of documents that have no embedding
+func (m *MemVec) fillEmbeddings(ctx context.Context, documents []vectordb.Document) error {
+	if m.embedder == nil {
+		return nil
+	}
+
+	var idx []int
+	var contents []string
+	for i, doc := range documents {
+		if len(doc.Embedding) == 0 {
+			idx = append(idx, i)
+			contents = append(contents, doc.Content)
+		}
+	}
+	if len(idx) == 0 {
+		return nil
+	}
+
+	embeddings, err := m.embedder.Embed(ctx, contents)
+	if err != nil {
+		return fmt.Errorf("failed to generate embeddings: %w", err)
+	}
+	if len(embeddings) != len(idx) {
+		return fmt.Errorf("embedding function returned %d embeddings for %d documents", len(embeddings), len(idx))
+	}
+	for j, i := range idx {
+		documents[i].Embedding = embeddings[j]
+	}
+	return nil
+}
+
+// Delete deletes documents from the active collection by IDs
+func (m *MemVec) Delete(_ context.Context, ids []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c := m.active()
+	for _, id := range ids {
+		c.remove(id)
+	}
+	return nil
+}
+
+// DeleteByFilter deletes the documents of the active collection whose metadata
+// matches filter. See Match for the supported filter forms.
+func (m *MemVec) DeleteByFilter(_ context.Context, filter map[string]interface{}) error {
+	if err := vectordb.CheckDeleteFilter(filter); err != nil {
+		return err
+	}
+
+	f, err := vectordb.ParseFilter(filter)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c := m.active()
+	var ids []string
+	for id, e := range c.docs {
+		if f.Match(e.doc.Metadata) {
+			ids = append(ids, id)
+		}
+	}
+	for _, id := range ids {
+		c.remove(id)
+	}
+	return nil
+}
+
+// Query searches using a text query (requires an embedding function)
+func (m *MemVec) Query(ctx context.Context, query string, limit int, filter map[string]interface{}) ([]vectordb.SearchResult, error) {
+	if m.embedder == nil {
+		return nil, fmt.Errorf("embedding function is required for text queries")
+	}
+	embedding, err := m.embedder.EmbedSingle(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+	return m.QueryWithEmbedding(ctx, embedding, limit, filter)
+}
+
+// QueryWithEmbedding searches using a pre-computed embedding. See Match for
+// the supported filter forms.
+func (m *MemVec) QueryWithEmbedding(_ context.Context, embedding []float32, limit int, filter map[string]interface{}) ([]vectordb.SearchResult, error) {
+	if len(embedding) == 0 {
+		return nil, fmt.Errorf("embedding is required")
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	f, err := vectordb.ParseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c := m.active()
+	if c.dimension > 0 && len(embedding) != c.dimension {
+		return nil, fmt.Errorf("query has dimension %d, expected %d", len(embedding), c.dimension)
+	}
+
+	if c.index != nil {
+		if results := c.searchIndex(embedding, limit, f, len(filter) > 0); len(results) >= limit || len(results) == c.matching(f, limit) {
+			return results, nil
+		}
+		// The graph did not surface enough matches for a selective filter.
+	}
+	return c.searchExact(embedding, limit, f), nil
+}
+
+// Get retrieves documents by IDs, in the requested order. Missing IDs are skipped.
+func (m *MemVec) Get(_ context.Context, ids []string) ([]vectordb.Document, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c := m.active()
+	docs := make([]vectordb.Document, 0, len(ids))
+	for _, id := range ids {
+		if e, ok := c.docs[id]; ok {
+			docs = append(docs, copyDocument(e.doc))
+		}
+	}
+	return docs, nil
+}
+
+// Scan pages through the active collection in ID order; the cursor is the last
+// ID of the previous page
+func (m *MemVec) Scan(_ context.Context, cursor string, limit int) ([]vectordb.Document, string, error) {
+	if limit <= 0 {
+		return nil, "", fmt.Errorf("limit must be positive")
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c := m.active()
+	ids := make([]string, 0, len(c.docs))
+	for id := range c.docs {
+		if id > cursor {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	next := ""
+	if len(ids) > limit {
+		ids = ids[:limit]
+		next = ids[limit-1]
+	}
+	docs := make([]vectordb.Document, len(ids))
+	for i, id := range ids {
+		docs[i] = copyDocument(c.docs[id].doc)
+	}
+	return docs, next, nil
+}
+
+// List pages through the documents of the active collection that match
+// filter, ordered by CreatedAt then ID
+func (m *MemVec) List(_ context.Context, filter map[string]interface{}, opts pagination.ListOptions) (pagination.Page[vectordb.Document], error) {
+	f, err := vectordb.ParseFilter(filter)
+	if err != nil {
+		return pagination.Page[vectordb.Document]{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c := m.active()
+	docs := make([]vectordb.Document, 0, len(c.docs))
+	for _, e := range c.docs {
+		if f.Match(e.doc.Metadata) {
+			docs = append(docs, e.doc)
+		}
+	}
+
+	opts.Since, opts.Until = time.Time{}, time.Time{}
+	page, err := pagination.Paginate(docs, opts, func(d vectordb.Document) (time.Time, string) {
+		return d.CreatedAt, d.ID
+	})
+	if err != nil {
+		return page, err
+	}
+	for i := range page.Items {
+		page.Items[i] = copyDocument(page.Items[i])
+	}
+	return page, nil
+}
+
+// Count returns the number of documents in the active collection
+func (m *MemVec) Count(_ context.Context) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.active().docs), nil
+}
+
+// Close saves the store when it is file-backed
+func (m *MemVec) Close() error {
+	if m.path == "" {
+		return nil
+	}
+	return m.Save(m.path)
+}
+
+// active returns the active collection, or an empty one if it does not exist
+// yet. Must be called with mu held.
+func (m *MemVec) active() *collection {
+	if c, ok := m.collections[m.current]; ok {
+		return c
+	}
+	return m.newCollection(m.distance, m.dimension)
+}
+
+// ensureActive returns the active collection, creating it on first use. Must
+// be called with mu held for writing.
+func (m *MemVec) ensureActive() *collection {
+	c, ok := m.collections[m.current]
+	if !ok {
+		c = m.newCollection(m.distance, m.dimension)
+		m.collections[m.current] = c
+	}
+	return c
+}
+
+func (m *MemVec) newCollection(distance vectordb.DistanceFunction, dimension int) *collection {
+	c := &collection{
+		distance:  distance,
+		dimension: dimension,
+		docs:      make(map[string]*entry),
+	}
+	if m.hnsw != nil {
+		c.index = newHNSWIndex(*m.hnsw, distanceFunc(distance))
+	}
+	return c
+}
+
+func (c *collection) put(doc vectordb.Document) {
+	c.remove(doc.ID)
+	e := &entry{doc: doc, node: -1}
+	if c.index != nil {
+		e.node = c.index.insert(doc.ID, doc.Embedding)
+	}
+	c.docs[doc.ID] = e
+}
+
+func (c *collection) remove(id string) {
+	e, ok := c.docs[id]
+	if !ok {
+		return
+	}
+	delete(c.docs, id)
+	if c.index != nil && e.node >= 0 {
+		c.index.remove(e.node)
+		if c.index.needsRebuild() {
+			c.rebuild()
+		}
+	}
+}
+
+// rebuild recreates the HNSW graph without deleted nodes
+func (c *collection) rebuild() {
+	c.index = c.index.reset()
+	ids := make([]string, 0, len(c.docs))
+	for id := range c.docs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids) // deterministic graph for a given seed
+	for _, id := range ids {
+		e := c.docs[id]
+		e.node = c.index.insert(id, e.doc.Embedding)
+	}
+}
+
+func (c *collection) searchExact(query []float32, limit int, filter vectordb.Filter) []vectordb.SearchResult {
+	results := make([]vectordb.SearchResult, 0, limit)
+	for _, e := range c.docs {
+		if !filter.Match(e.doc.Metadata) {
+			continue
+		}
+		results = append(results, c.result(e.doc, query))
+	}
+	sortResults(results)
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+func (c *collection) searchIndex(query []float32, limit int, filter vectordb.Filter, filtered bool) []vectordb.SearchResult {
+	k := limit
+	if filtered {
+		// Over-fetch so filtering still leaves enough candidates.
+		k = limit * 10
+	}
+	results := make([]vectordb.SearchResult, 0, limit)
+	for _, id := range c.index.search(query, k) {
+		e, ok := c.docs[id]
+		if !ok || !filter.Match(e.doc.Metadata) {
+			continue
+		}
+		results = append(results, c.result(e.doc, query))
+	}
+	sortResults(results)
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// matching counts documents that pass filter, stopping at limit
+func (c *collection) matching(filter vectordb.Filter, limit int) int {
+	n := 0
+	for _, e := range c.docs {
+		if filter.Match(e.doc.Metadata) {
+			n++
+			if n >= limit {
+				break
+			}
+		}
+	}
+	return n
+}
+
+func (c *collection) result(doc vectordb.Document, query []float32) vectordb.SearchResult {
+	score, distance := scoreAndDistance(c.distance, query, doc.Embedding)
+	return vectordb.SearchResult{
+		ID:       doc.ID,
+		Content:  doc.Content,
+		Metadata: copyMetadata(doc.Metadata),
+		Score:    score,
+		Distance: distance,
+	}
+}
+
+func sortResults(results []vectordb.SearchResult) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Distance != results[j].Distance {
+			return results[i].Distance < results[j].Distance
+		}
+		return results[i].ID < results[j].ID
+	})
+}
+
+// scoreAndDistance follows the vectordb convention: Score is higher-is-better
+// and Distance lower-is-better for every distance function
+func scoreAndDistance(d vectordb.DistanceFunction, a, b []float32) (score, distance float32) {
+	switch d {
+	case vectordb.L2:
+		dist := float32(math.Sqrt(float64(squaredL2(a, b))))
+		return 1 / (1 + dist), dist
+	case vectordb.InnerProduct:
+		dot := dotProduct(a, b)
+		return dot, -dot
+	default:
+		cos := cosine(a, b)
+		return cos, 1 - cos
+	}
+}
+
+// distanceFunc returns a lower-is-better distance for the HNSW index
+func distanceFunc(d vectordb.DistanceFunction) func(a, b []float32) float32 {
+	switch d {
+	case vectordb.L2:
+		return squaredL2
+	case vectordb.InnerProduct:
+		return func(a, b []float32) float32 { return -dotProduct(a, b) }
+	default:
+		return func(a, b []float32) float32 { return 1 - cosine(a, b) }
+	}
+}
+
+func dotProduct(a, b []float32) float32 {
+	var dot float32
+	for i := range a {
+		if i >= len(b) {
+			break
+		}
+		dot += a[i] * b[i]
+	}
+	return dot
+}
+
+func squaredL2(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		if i >= len(b) {
+			break
+		}
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return sum
+}
+
+func cosine(a, b []float32) float32 {
+	var dot, na, nb float64
+	for i := range a {
+		if i >= len(b) {
+			break
+		}
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return float32(dot / (math.Sqrt(na) * math.Sqrt(nb)))
+}
+
+func copyDocument(doc vectordb.Document) vectordb.Document {
+	doc.Metadata = copyMetadata(doc.Metadata)
+	doc.Embedding = append([]float32(nil), doc.Embedding...)
+	return doc
+}
+
+func copyMetadata(metadata map[string]interface{}) map[string]interface{} {
+	if metadata == nil {
+		return nil
+	}
+	copied := make(map[string]interface{}, len(metadata))
+	for k, v := range metadata {
+		copied[k] = v
+	}
+	return copied
+}
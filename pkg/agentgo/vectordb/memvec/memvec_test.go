@@ -0,0 +1,388 @@
+package memvec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/pagination"
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+)
+
+// keywordEmbedder maps text to a vector by counting a few keywords.
+type keywordEmbedder struct{}
+
+func (keywordEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		out[i], _ = keywordEmbedder{}.EmbedSingle(ctx, text)
+	}
+	return out, nil
+}
+
+func (keywordEmbedder) EmbedSingle(_ context.Context, text string) ([]float32, error) {
+	vec := []float32{0.01, 0.01, 0.01}
+	for i, word := range []string{"go", "rust", "python"} {
+		if strings.Contains(text, word) {
+			vec[i] = 1
+		}
+	}
+	return vec, nil
+}
+
+func newTestStore(t *testing.T, config Config) *MemVec {
+	t.Helper()
+	if config.EmbeddingFunction == nil {
+		config.EmbeddingFunction = keywordEmbedder{}
+	}
+	m, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return m
+}
+
+func seed(t *testing.T, m *MemVec) {
+	t.Helper()
+	err := m.Add(context.Background(), []vectordb.Document{
+		{ID: "go", Content: "go is simple", Metadata: map[string]interface{}{"lang": "go", "year": 2009}},
+		{ID: "rust", Content: "rust is safe", Metadata: map[string]interface{}{"lang": "rust", "year": 2010}},
+		{ID: "python", Content: "python is popular", Metadata: map[string]interface{}{"lang": "python", "year": 1991}},
+	})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Config{DistanceFunction: "manhattan"}); err == nil {
+		t.Error("expected error for unsupported distance")
+	}
+	if _, err := New(Config{Dimension: -1}); err == nil {
+		t.Error("expected error for negative dimension")
+	}
+}
+
+func TestQuery(t *testing.T) {
+	ctx := context.Background()
+	m := newTestStore(t, Config{})
+	seed(t, m)
+
+	results, err := m.Query(ctx, "rust", 2, nil)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(results) != 2 || results[0].ID != "rust" {
+		t.Fatalf("results = %+v, want rust first", results)
+	}
+	if results[0].Score <= results[1].Score || results[0].Distance >= results[1].Distance {
+		t.Errorf("results not ordered by score: %+v", results)
+	}
+	if results[0].Metadata["lang"] != "rust" {
+		t.Errorf("metadata = %v", results[0].Metadata)
+	}
+}
+
+func TestQuery_Filter(t *testing.T) {
+	ctx := context.Background()
+	m := newTestStore(t, Config{})
+	seed(t, m)
+
+	tests := []struct {
+		name   string
+		filter map[string]interface{}
+		want   []string
+	}{
+		{"equality", map[string]interface{}{"lang": "go"}, []string{"go"}},
+		{"any", map[string]interface{}{"lang": []string{"go", "python"}}, []string{"go", "python"}},
+		{"range", map[string]interface{}{"year": map[string]interface{}{"gte": 2009.0}}, []string{"go", "rust"}},
+		{"ne", map[string]interface{}{"lang": map[string]interface{}{"ne": "go"}}, []string{"python", "rust"}},
+		{"missing key", map[string]interface{}{"team": "x"}, nil},
+		{"or", vectordb.Or(vectordb.Eq("lang", "python"), vectordb.Gt("year", 2009)).Map(), []string{"python", "rust"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := m.QueryWithEmbedding(ctx, []float32{1, 1, 1}, 10, tt.filter)
+			if err != nil {
+				t.Fatalf("QueryWithEmbedding() error = %v", err)
+			}
+			got := map[string]bool{}
+			for _, r := range results {
+				got[r.ID] = true
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for _, id := range tt.want {
+				if !got[id] {
+					t.Errorf("missing %s in %v", id, got)
+				}
+			}
+		})
+	}
+}
+
+func TestDistanceFunctions(t *testing.T) {
+	ctx := context.Background()
+	for _, d := range []vectordb.DistanceFunction{vectordb.Cosine, vectordb.L2, vectordb.InnerProduct} {
+		m := newTestStore(t, Config{DistanceFunction: d})
+		if err := m.Add(ctx, []vectordb.Document{
+			{ID: "near", Embedding: []float32{1, 0}},
+			{ID: "far", Embedding: []float32{-1, 0}},
+		}); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+		results, err := m.QueryWithEmbedding(ctx, []float32{1, 0}, 2, nil)
+		if err != nil {
+			t.Fatalf("%s: QueryWithEmbedding() error = %v", d, err)
+		}
+		if results[0].ID != "near" {
+			t.Errorf("%s: results = %+v, want near first", d, results)
+		}
+	}
+}
+
+func TestAddUpdateDelete(t *testing.T) {
+	ctx := context.Background()
+	m := newTestStore(t, Config{})
+	seed(t, m)
+
+	if err := m.Update(ctx, []vectordb.Document{{ID: "missing", Content: "go"}}); err == nil {
+		t.Error("expected error updating a missing document")
+	}
+	if err := m.Update(ctx, []vectordb.Document{{ID: "go", Content: "go and rust"}}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	docs, _ := m.Get(ctx, []string{"go", "nope"})
+	if len(docs) != 1 || docs[0].Content != "go and rust" {
+		t.Errorf("Get() = %+v", docs)
+	}
+
+	if err := m.Add(ctx, []vectordb.Document{{ID: "x", Embedding: []float32{1, 2}}}); err == nil {
+		t.Error("expected dimension mismatch error")
+	}
+
+	if err := m.Delete(ctx, []string{"go", "rust"}); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if n, _ := m.Count(ctx); n != 1 {
+		t.Errorf("Count() = %d, want 1", n)
+	}
+}
+
+func TestDeleteByFilter(t *testing.T) {
+	ctx := context.Background()
+	m := newTestStore(t, Config{})
+	seed(t, m)
+
+	if err := m.DeleteByFilter(ctx, nil); err == nil {
+		t.Error("expected error for an empty filter")
+	}
+	if err := m.DeleteByFilter(ctx, map[string]interface{}{"$and": []interface{}{}}); err == nil {
+		t.Error("expected error for an empty $and")
+	}
+	if n, _ := m.Count(ctx); n != 3 {
+		t.Fatalf("Count() after a refused delete = %d, want 3", n)
+	}
+	if err := m.DeleteByFilter(ctx, map[string]interface{}{"year": map[string]interface{}{"gte": 2009.0}}); err != nil {
+		t.Fatalf("DeleteByFilter() error = %v", err)
+	}
+	docs, _ := m.Get(ctx, []string{"go", "rust", "python"})
+	if len(docs) != 1 || docs[0].ID != "python" {
+		t.Errorf("Get() after DeleteByFilter = %+v, want only python", docs)
+	}
+}
+
+func TestGet_ReturnsCopies(t *testing.T) {
+	ctx := context.Background()
+	m := newTestStore(t, Config{})
+	seed(t, m)
+
+	docs, _ := m.Get(ctx, []string{"go"})
+	docs[0].Metadata["lang"] = "changed"
+	docs[0].Embedding[0] = 42
+
+	docs, _ = m.Get(ctx, []string{"go"})
+	if docs[0].Metadata["lang"] != "go" || docs[0].Embedding[0] == 42 {
+		t.Errorf("stored document was modified through Get: %+v", docs[0])
+	}
+}
+
+func TestScan(t *testing.T) {
+	m := newTestStore(t, Config{CollectionName: "test"})
+	seed(t, m)
+	ctx := context.Background()
+
+	docs, next, err := m.Scan(ctx, "", 2)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if len(docs) != 2 || docs[0].ID != "go" || docs[1].ID != "python" || next != "python" || len(docs[0].Embedding) != 3 {
+		t.Fatalf("first page = %+v, next %q", docs, next)
+	}
+
+	docs, next, err = m.Scan(ctx, next, 2)
+	if err != nil || len(docs) != 1 || docs[0].ID != "rust" || next != "" {
+		t.Errorf("last page = %+v, next %q, err %v", docs, next, err)
+	}
+
+	if _, _, err := m.Scan(ctx, "", 0); err == nil {
+		t.Error("expected error for zero limit")
+	}
+}
+
+func TestList(t *testing.T) {
+	m := newTestStore(t, Config{CollectionName: "test"})
+	ctx := context.Background()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	err := m.Add(ctx, []vectordb.Document{
+		{ID: "a", Content: "go one", Metadata: map[string]interface{}{"lang": "go"}, CreatedAt: base},
+		{ID: "b", Content: "rust", Metadata: map[string]interface{}{"lang": "rust"}, CreatedAt: base.Add(time.Minute)},
+		{ID: "c", Content: "go two", Metadata: map[string]interface{}{"lang": "go"}, CreatedAt: base.Add(2 * time.Minute)},
+		{ID: "d", Content: "go three", Metadata: map[string]interface{}{"lang": "go"}, CreatedAt: base.Add(3 * time.Minute)},
+	})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	filter := map[string]interface{}{"lang": "go"}
+	page, err := m.List(ctx, filter, pagination.ListOptions{Limit: 2})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(page.Items) != 2 || page.Items[0].ID != "d" || page.Items[1].ID != "c" || page.NextCursor == "" {
+		t.Fatalf("first page = %+v", page)
+	}
+
+	page, err = m.List(ctx, filter, pagination.ListOptions{Limit: 2, Cursor: page.NextCursor})
+	if err != nil || len(page.Items) != 1 || page.Items[0].ID != "a" || page.NextCursor != "" {
+		t.Errorf("last page = %+v, err %v", page, err)
+	}
+}
+
+func TestCollections(t *testing.T) {
+	ctx := context.Background()
+	m := newTestStore(t, Config{})
+	seed(t, m)
+
+	if err := m.CreateCollection(ctx, "other", map[string]interface{}{"distance": "l2"}); err != nil {
+		t.Fatalf("CreateCollection() error = %v", err)
+	}
+	if n, _ := m.Count(ctx); n != 0 {
+		t.Errorf("new collection Count() = %d, want 0", n)
+	}
+	if err := m.CreateCollection(ctx, "default", nil); err != nil {
+		t.Fatalf("CreateCollection() error = %v", err)
+	}
+	if n, _ := m.Count(ctx); n != 3 {
+		t.Errorf("default Count() = %d, want 3", n)
+	}
+	if got, err := m.ListCollections(ctx); err != nil || !reflect.DeepEqual(got, []string{"default", "other"}) {
+		t.Errorf("ListCollections() = %v, %v", got, err)
+	}
+	stats, err := m.CollectionStats(ctx, "")
+	want := vectordb.CollectionStats{Name: "default", Count: 3, Dimension: 3, DistanceFunction: vectordb.Cosine, IndexType: "flat", DiskSize: -1}
+	if err != nil || stats != want {
+		t.Errorf("CollectionStats() = %+v, %v, want %+v", stats, err, want)
+	}
+	if stats, _ := m.CollectionStats(ctx, "other"); stats.DistanceFunction != vectordb.L2 || stats.Count != 0 {
+		t.Errorf("CollectionStats(other) = %+v", stats)
+	}
+	if _, err := m.CollectionStats(ctx, "missing"); !errors.Is(err, vectordb.ErrCollectionNotFound) {
+		t.Errorf("CollectionStats(missing) error = %v, want ErrCollectionNotFound", err)
+	}
+	if err := m.DeleteCollection(ctx, "other"); err != nil {
+		t.Fatalf("DeleteCollection() error = %v", err)
+	}
+	if got := m.Collections(); len(got) != 1 || got[0] != "default" {
+		t.Errorf("Collections() = %v", got)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "store.gob")
+
+	m := newTestStore(t, Config{Path: path})
+	seed(t, m)
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	loaded := newTestStore(t, Config{Path: path, HNSW: &HNSWConfig{}})
+	if n, _ := loaded.Count(ctx); n != 3 {
+		t.Fatalf("Count() after load = %d, want 3", n)
+	}
+	docs, _ := loaded.Get(ctx, []string{"rust"})
+	if len(docs) != 1 || docs[0].Metadata["year"] != 2010.0 || docs[0].CreatedAt.IsZero() {
+		t.Errorf("loaded document = %+v", docs)
+	}
+	results, err := loaded.Query(ctx, "python", 1, map[string]interface{}{"year": 1991})
+	if err != nil || len(results) != 1 || results[0].ID != "python" {
+		t.Errorf("Query() after load = %+v, %v", results, err)
+	}
+
+	if err := loaded.Load(filepath.Join(t.TempDir(), "missing.gob")); err == nil {
+		t.Error("expected error loading a missing file")
+	}
+}
+
+func TestHNSW_Recall(t *testing.T) {
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(7))
+	randomVector := func() []float32 {
+		v := make([]float32, 16)
+		for i := range v {
+			v[i] = rng.Float32()*2 - 1
+		}
+		return v
+	}
+
+	docs := make([]vectordb.Document, 1000)
+	for i := range docs {
+		docs[i] = vectordb.Document{ID: fmt.Sprintf("doc-%d", i), Embedding: randomVector()}
+	}
+	exact := newTestStore(t, Config{})
+	approx := newTestStore(t, Config{HNSW: &HNSWConfig{}})
+	for _, m := range []*MemVec{exact, approx} {
+		if err := m.Add(ctx, docs); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	// Delete a third so the graph searches through tombstones.
+	var deleted []string
+	for i := 0; i < len(docs); i += 3 {
+		deleted = append(deleted, docs[i].ID)
+	}
+	for _, m := range []*MemVec{exact, approx} {
+		if err := m.Delete(ctx, deleted); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+	}
+
+	hits, total := 0, 0
+	for q := 0; q < 20; q++ {
+		query := randomVector()
+		want, _ := exact.QueryWithEmbedding(ctx, query, 10, nil)
+		got, _ := approx.QueryWithEmbedding(ctx, query, 10, nil)
+		ids := map[string]bool{}
+		for _, r := range got {
+			ids[r.ID] = true
+		}
+		for _, r := range want {
+			total++
+			if ids[r.ID] {
+				hits++
+			}
+		}
+	}
+	if recall := float64(hits) / float64(total); recall < 0.9 {
+		t.Errorf("recall = %.2f, want >= 0.9", recall)
+	}
+}
@@ -0,0 +1,158 @@
+package memvec
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+)
+
+// snapshotVersion is bumped when the file format changes incompatibly
+const snapshotVersion = 1
+
+type snapshot struct {
+	Version     int
+	Collections []collectionSnapshot
+}
+
+type collectionSnapshot struct {
+	Name      string
+	Distance  string
+	Dimension int
+	Documents []documentSnapshot
+}
+
+// documentSnapshot stores metadata as JSON because gob cannot encode
+// arbitrary interface values without registering their types
+type documentSnapshot struct {
+	ID        string
+	Content   string
+	Metadata  []byte
+	Embedding []float32
+	CreatedAt time.Time
+}
+
+// Save writes all collections to a gob file at path. The file is written to
+// a temporary file first and renamed, so a crash never leaves a partial file.
+func (m *MemVec) Save(path string) error {
+	m.mu.RLock()
+	snap, err := m.snapshot()
+	m.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := gob.NewEncoder(tmp).Encode(snap); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to encode store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// Load replaces all collections with the contents of a file written by Save.
+// The HNSW index, if configured, is rebuilt from the loaded vectors.
+func (m *MemVec) Load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	var snap snapshot
+	if err := gob.NewDecoder(f).Decode(&snap); err != nil {
+		return fmt.Errorf("failed to decode store: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("unsupported file version %d", snap.Version)
+	}
+
+	collections := make(map[string]*collection, len(snap.Collections))
+	for _, cs := range snap.Collections {
+		c := m.newCollection(vectordb.DistanceFunction(cs.Distance), cs.Dimension)
+		for _, ds := range cs.Documents {
+			doc := vectordb.Document{
+				ID:        ds.ID,
+				Content:   ds.Content,
+				Embedding: ds.Embedding,
+				CreatedAt: ds.CreatedAt,
+			}
+			if len(ds.Metadata) > 0 {
+				if err := json.Unmarshal(ds.Metadata, &doc.Metadata); err != nil {
+					return fmt.Errorf("failed to decode metadata of document %s: %w", ds.ID, err)
+				}
+			}
+			c.put(doc)
+		}
+		collections[cs.Name] = c
+	}
+
+	m.mu.Lock()
+	m.collections = collections
+	m.mu.Unlock()
+	return nil
+}
+
+// snapshot must be called with mu held
+func (m *MemVec) snapshot() (*snapshot, error) {
+	snap := &snapshot{Version: snapshotVersion}
+	names := make([]string, 0, len(m.collections))
+	for name := range m.collections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		c := m.collections[name]
+		cs := collectionSnapshot{
+			Name:      name,
+			Distance:  string(c.distance),
+			Dimension: c.dimension,
+			Documents: make([]documentSnapshot, 0, len(c.docs)),
+		}
+		ids := make([]string, 0, len(c.docs))
+		for id := range c.docs {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			doc := c.docs[id].doc
+			ds := documentSnapshot{
+				ID:        doc.ID,
+				Content:   doc.Content,
+				Embedding: doc.Embedding,
+				CreatedAt: doc.CreatedAt,
+			}
+			if doc.Metadata != nil {
+				data, err := json.Marshal(doc.Metadata)
+				if err != nil {
+					return nil, fmt.Errorf("failed to encode metadata of document %s: %w", id, err)
+				}
+				ds.Metadata = data
+			}
+			cs.Documents = append(cs.Documents, ds)
+		}
+		snap.Collections = append(snap.Collections, cs)
+	}
+	return snap, nil
+}
@@ -0,0 +1,753 @@
+// Package milvus implements vectordb.VectorDB on top of the Milvus v2 RESTful
+// API, which is served by Milvus 2.4+ and Zilliz Cloud.
+//
+// Each collection has a fixed schema: a VarChar primary key, the float vector,
+// the content, a JSON metadata field and a creation timestamp. When a
+// partition key is configured it is stored as its own scalar field, filled
+// from the document metadata, so large multi-tenant deployments can prune
+// searches to a single partition.
+package milvus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+)
+
+const (
+	fieldID        = "id"
+	fieldVector    = "vector"
+	fieldContent   = "content"
+	fieldMetadata  = "metadata"
+	fieldCreatedAt = "created_at"
+
+	maxIDLength      = 512
+	maxContentLength = 65535
+)
+
+var _ vectordb.VectorDB = (*Milvus)(nil)
+
+// Config holds Milvus configuration
+type Config struct {
+	// URL of the Milvus or Zilliz Cloud endpoint (default: "http://localhost:19530")
+	URL string
+	// Token is sent as a bearer token: "user:password" for Milvus or an API key for Zilliz Cloud
+	Token string
+	// DBName selects the database (default: server default)
+	DBName string
+	// CollectionName is the collection to operate on (required)
+	CollectionName string
+	// Dimension of the stored vectors, required to create collections
+	Dimension int
+	// DistanceFunction selects the metric type: cosine (default) → COSINE, l2 → L2, ip → IP
+	DistanceFunction vectordb.DistanceFunction
+	// IndexType is the vector index type (default: "AUTOINDEX"), e.g. "HNSW" or "IVF_FLAT"
+	IndexType string
+	// IndexParams are index build parameters, e.g. {"M": 16, "efConstruction": 200}
+	IndexParams map[string]interface{}
+	// SearchParams are search-time parameters, e.g. {"ef": 128} or {"nprobe": 16}
+	SearchParams map[string]interface{}
+	// PartitionKeyField names a metadata field used as the Milvus partition key (optional)
+	PartitionKeyField string
+	// PartitionsNum is the number of partitions when a partition key is used (default: server default)
+	PartitionsNum int
+	// EmbeddingFunction embeds text queries and documents without embeddings
+	EmbeddingFunction vectordb.EmbeddingFunction
+	// HTTPClient is used for all requests (default: client with Timeout). It is
+	// never closed by Milvus.
+	HTTPClient *http.Client
+	// Timeout for the default HTTP client (default: 30s)
+	Timeout time.Duration
+}
+
+// Milvus implements vectordb.VectorDB using Milvus
+type Milvus struct {
+	baseURL       string
+	token         string
+	dbName        string
+	collection    string
+	dimension     int
+	distance      vectordb.DistanceFunction
+	indexType     string
+	indexParams   map[string]interface{}
+	searchParams  map[string]interface{}
+	partitionKey  string
+	partitionsNum int
+	embedder      vectordb.EmbeddingFunction
+	client        *http.Client
+}
+
+// New creates a new Milvus instance
+func New(config Config) (*Milvus, error) {
+	if config.CollectionName == "" {
+		return nil, fmt.Errorf("collection name is required")
+	}
+	if config.URL == "" {
+		config.URL = "http://localhost:19530"
+	}
+	if _, err := url.Parse(config.URL); err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if config.DistanceFunction == "" {
+		config.DistanceFunction = vectordb.Cosine
+	}
+	if _, err := metricType(config.DistanceFunction); err != nil {
+		return nil, err
+	}
+	if config.Dimension < 0 {
+		return nil, fmt.Errorf("dimension must not be negative")
+	}
+	if config.IndexType == "" {
+		config.IndexType = "AUTOINDEX"
+	}
+	switch config.PartitionKeyField {
+	case fieldID, fieldVector, fieldContent, fieldMetadata, fieldCreatedAt:
+		return nil, fmt.Errorf("partition key field %q collides with a reserved field", config.PartitionKeyField)
+	}
+	if config.HTTPClient == nil {
+		if config.Timeout <= 0 {
+			config.Timeout = 30 * time.Second
+		}
+		config.HTTPClient = &http.Client{Timeout: config.Timeout}
+	}
+
+	return &Milvus{
+		baseURL:       strings.TrimRight(config.URL, "/"),
+		token:         config.Token,
+		dbName:        config.DBName,
+		collection:    config.CollectionName,
+		dimension:     config.Dimension,
+		distance:      config.DistanceFunction,
+		indexType:     config.IndexType,
+		indexParams:   config.IndexParams,
+		searchParams:  config.SearchParams,
+		partitionKey:  config.PartitionKeyField,
+		partitionsNum: config.PartitionsNum,
+		embedder:      config.EmbeddingFunction,
+		client:        config.HTTPClient,
+	}, nil
+}
+
+// CreateCollection creates the collection and its vector index if it does not
+// exist. An empty name uses the configured collection. metadata may override "dimension".
+func (m *Milvus) CreateCollection(ctx context.Context, name string, metadata map[string]interface{}) error {
+	if name != "" {
+		m.collection = name
+	}
+
+	var has struct {
+		Has bool `json:"has"`
+	}
+	if err := m.call(ctx, "/v2/vectordb/collections/has", m.body(nil), &has); err != nil {
+		return fmt.Errorf("failed to check collection: %w", err)
+	}
+	if has.Has {
+		return nil
+	}
+
+	dimension := m.dimension
+	if d, ok := metadata["dimension"].(int); ok && d > 0 {
+		dimension = d
+	}
+	if dimension <= 0 {
+		return fmt.Errorf("dimension is required to create collection %s", m.collection)
+	}
+
+	fields := []map[string]interface{}{
+		{"fieldName": fieldID, "dataType": "VarChar", "isPrimary": true,
+			"elementTypeParams": map[string]interface{}{"max_length": strconv.Itoa(maxIDLength)}},
+		{"fieldName": fieldVector, "dataType": "FloatVector",
+			"elementTypeParams": map[string]interface{}{"dim": strconv.Itoa(dimension)}},
+		{"fieldName": fieldContent, "dataType": "VarChar",
+			"elementTypeParams": map[string]interface{}{"max_length": strconv.Itoa(maxContentLength)}},
+		{"fieldName": fieldMetadata, "dataType": "JSON"},
+		{"fieldName": fieldCreatedAt, "dataType": "Int64"},
+	}
+	if m.partitionKey != "" {
+		fields = append(fields, map[string]interface{}{
+			"fieldName": m.partitionKey, "dataType": "VarChar", "isPartitionKey": true,
+			"elementTypeParams": map[string]interface{}{"max_length": strconv.Itoa(maxIDLength)},
+		})
+	}
+
+	metric, _ := metricType(m.distance)
+	indexParams := map[string]interface{}{"index_type": m.indexType}
+	for k, v := range m.indexParams {
+		indexParams[k] = fmt.Sprint(v)
+	}
+
+	body := m.body(map[string]interface{}{
+		"schema": map[string]interface{}{
+			"autoId":             false,
+			"enableDynamicField": false,
+			"fields":             fields,
+		},
+		"indexParams": []map[string]interface{}{{
+			"fieldName":  fieldVector,
+			"indexName":  fieldVector + "_idx",
+			"metricType": metric,
+			"params":     indexParams,
+		}},
+	})
+	if m.partitionKey != "" && m.partitionsNum > 0 {
+		body["params"] = map[string]interface{}{"partitionsNum": m.partitionsNum}
+	}
+
+	if err := m.call(ctx, "/v2/vectordb/collections/create", body, nil); err != nil {
+		return fmt.Errorf("failed to create collection: %w", err)
+	}
+	m.dimension = dimension
+	return nil
+}
+
+// DeleteCollection drops a collection. An empty name uses the configured collection.
+func (m *Milvus) DeleteCollection(ctx context.Context, name string) error {
+	body := m.body(nil)
+	if name != "" {
+		body["collectionName"] = name
+	}
+	if err := m.call(ctx, "/v2/vectordb/collections/drop", body, nil); err != nil {
+		return fmt.Errorf("failed to drop collection: %w", err)
+	}
+	return nil
+}
+
+// ListCollections returns the names of the collections in the database, sorted
+func (m *Milvus) ListCollections(ctx context.Context) ([]string, error) {
+	body := m.body(nil)
+	delete(body, "collectionName")
+	var names []string
+	if err := m.call(ctx, "/v2/vectordb/collections/list", body, &names); err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// CollectionStats describes a collection. An empty name uses the configured
+// collection. Count is Milvus' row count, which includes deleted rows until
+// compaction; DiskSize is -1, as the RESTful API does not report it.
+func (m *Milvus) CollectionStats(ctx context.Context, name string) (vectordb.CollectionStats, error) {
+	body := m.body(nil)
+	if name != "" {
+		body["collectionName"] = name
+	}
+	name, _ = body["collectionName"].(string)
+
+	var has struct {
+		Has bool `json:"has"`
+	}
+	if err := m.call(ctx, "/v2/vectordb/collections/has", body, &has); err != nil {
+		return vectordb.CollectionStats{}, fmt.Errorf("failed to check collection: %w", err)
+	}
+	if !has.Has {
+		return vectordb.CollectionStats{}, fmt.Errorf("%w: %s", vectordb.ErrCollectionNotFound, name)
+	}
+
+	stats := vectordb.CollectionStats{Name: name, DiskSize: -1}
+
+	var described struct {
+		Fields []struct {
+			Name   string `json:"name"`
+			Params []struct {
+				Key   string      `json:"key"`
+				Value interface{} `json:"value"`
+			} `json:"params"`
+		} `json:"fields"`
+		Indexes []struct {
+			FieldName  string `json:"fieldName"`
+			MetricType string `json:"metricType"`
+		} `json:"indexes"`
+	}
+	if err := m.call(ctx, "/v2/vectordb/collections/describe", body, &described); err != nil {
+		return vectordb.CollectionStats{}, fmt.Errorf("failed to describe collection: %w", err)
+	}
+	for _, field := range described.Fields {
+		if field.Name != fieldVector {
+			continue
+		}
+		for _, param := range field.Params {
+			if param.Key == "dim" {
+				stats.Dimension, _ = strconv.Atoi(fmt.Sprint(param.Value))
+			}
+		}
+	}
+	for _, index := range described.Indexes {
+		if index.FieldName == fieldVector {
+			stats.DistanceFunction = distanceFromMetric(index.MetricType)
+		}
+	}
+
+	indexBody := map[string]interface{}{"collectionName": name, "indexName": fieldVector + "_idx"}
+	if m.dbName != "" {
+		indexBody["dbName"] = m.dbName
+	}
+	var indexes []struct {
+		IndexType string `json:"indexType"`
+	}
+	if err := m.call(ctx, "/v2/vectordb/indexes/describe", indexBody, &indexes); err != nil {
+		return vectordb.CollectionStats{}, fmt.Errorf("failed to describe index: %w", err)
+	}
+	if len(indexes) > 0 {
+		stats.IndexType = strings.ToLower(indexes[0].IndexType)
+	}
+
+	var counted struct {
+		RowCount int `json:"rowCount"`
+	}
+	if err := m.call(ctx, "/v2/vectordb/collections/get_stats", body, &counted); err != nil {
+		return vectordb.CollectionStats{}, fmt.Errorf("failed to get collection stats: %w", err)
+	}
+	stats.Count = counted.RowCount
+	return stats, nil
+}
+
+// Add adds documents to the collection. Existing documents with the same ID are replaced.
+func (m *Milvus) Add(ctx context.Context, documents []vectordb.Document) error {
+	return m.upsert(ctx, documents)
+}
+
+// Update updates existing documents in the collection
+func (m *Milvus) Update(ctx context.Context, documents []vectordb.Document) error {
+	return m.upsert(ctx, documents)
+}
+
+func (m *Milvus) upsert(ctx context.Context, documents []vectordb.Document) error {
+	if len(documents) == 0 {
+		return nil
+	}
+
+	// Copy so generated embeddings are not written into the caller's slice.
+	documents = append([]vectordb.Document(nil), documents...)
+	if err := m.fillEmbeddings(ctx, documents); err != nil {
+		return err
+	}
+
+	rows := make([]map[string]interface{}, len(documents))
+	for i, doc := range documents {
+		if doc.ID == "" {
+			return fmt.Errorf("document %d has no ID", i)
+		}
+		if len(doc.Embedding) == 0 {
+			return fmt.Errorf("document %s has no embedding and no embedding function is configured", doc.ID)
+		}
+		createdAt := doc.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = time.Now()
+		}
+		metadata := doc.Metadata
+		if metadata == nil {
+			metadata = map[string]interface{}{}
+		}
+		row := map[string]interface{}{
+			fieldID:        doc.ID,
+			fieldVector:    doc.Embedding,
+			fieldContent:   doc.Content,
+			fieldMetadata:  metadata,
+			fieldCreatedAt: createdAt.UnixMilli(),
+		}
+		if m.partitionKey != "" {
+			value, ok := metadata[m.partitionKey]
+			if !ok {
+				return fmt.Errorf("document %s has no %s metadata for the partition key", doc.ID, m.partitionKey)
+			}
+			row[m.partitionKey] = fmt.Sprint(value)
+		}
+		rows[i] = row
+	}
+
+	if err := m.call(ctx, "/v2/vectordb/entities/upsert", m.body(map[string]interface{}{"data": rows}), nil); err != nil {
+		return fmt.Errorf("failed to upsert documents: %w", err)
+	}
+	return nil
+}
+
+// fillEmbeddings embeds the content of documents that have no embedding
+func (m *Milvus) fillEmbeddings(ctx context.Context, documents []vectordb.Document) error {
+	if m.embedder == nil {
+		return nil
+	}
+
+	var idx []int
+	var contents []string
+	for i, doc := range documents {
+		if len(doc.Embedding) == 0 {
+			idx = append(idx, i)
+			contents = append(contents, doc.Content)
+		}
+	}
+	if len(idx) == 0 {
+		return nil
+	}
+
+	embeddings, err := m.embedder.Embed(ctx, contents)
+	if err != nil {
+		return fmt.Errorf("failed to generate embeddings: %w", err)
+	}
+	if len(embeddings) != len(idx) {
+		return fmt.Errorf("embedding function returned %d embeddings for %d documents", len(embeddings), len(idx))
+	}
+	for j, i := range idx {
+		documents[i].Embedding = embeddings[j]
+	}
+	return nil
+}
+
+// Delete deletes documents from the collection by IDs
+func (m *Milvus) Delete(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	body := m.body(map[string]interface{}{"filter": fieldID + " in " + literal(ids)})
+	if err := m.call(ctx, "/v2/vectordb/entities/delete", body, nil); err != nil {
+		return fmt.Errorf("failed to delete documents: %w", err)
+	}
+	return nil
+}
+
+// DeleteByFilter deletes the documents whose metadata matches filter. See
+// BuildFilter for the supported filter forms.
+func (m *Milvus) DeleteByFilter(ctx context.Context, filter map[string]interface{}) error {
+	if err := vectordb.CheckDeleteFilter(filter); err != nil {
+		return err
+	}
+	expr, err := BuildFilter(filter, m.partitionKey)
+	if err != nil {
+		return err
+	}
+	if expr == "" {
+		return fmt.Errorf("filter is required")
+	}
+	body := m.body(map[string]interface{}{"filter": expr})
+	if err := m.call(ctx, "/v2/vectordb/entities/delete", body, nil); err != nil {
+		return fmt.Errorf("failed to delete documents: %w", err)
+	}
+	return nil
+}
+
+// Query searches using a text query (requires an embedding function)
+func (m *Milvus) Query(ctx context.Context, query string, limit int, filter map[string]interface{}) ([]vectordb.SearchResult, error) {
+	if m.embedder == nil {
+		return nil, fmt.Errorf("embedding function is required for text queries")
+	}
+	embedding, err := m.embedder.EmbedSingle(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+	return m.QueryWithEmbedding(ctx, embedding, limit, filter)
+}
+
+// QueryWithEmbedding searches using a pre-computed embedding. See BuildFilter
+// for the supported filter forms.
+func (m *Milvus) QueryWithEmbedding(ctx context.Context, embedding []float32, limit int, filter map[string]interface{}) ([]vectordb.SearchResult, error) {
+	if len(embedding) == 0 {
+		return nil, fmt.Errorf("embedding is required")
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	metric, _ := metricType(m.distance)
+	searchParams := map[string]interface{}{"metricType": metric}
+	if len(m.searchParams) > 0 {
+		searchParams["params"] = m.searchParams
+	}
+	body := m.body(map[string]interface{}{
+		"data":         [][]float32{embedding},
+		"annsField":    fieldVector,
+		"limit":        limit,
+		"outputFields": []string{fieldID, fieldContent, fieldMetadata},
+		"searchParams": searchParams,
+	})
+	expr, err := BuildFilter(filter, m.partitionKey)
+	if err != nil {
+		return nil, err
+	}
+	if expr != "" {
+		body["filter"] = expr
+	}
+
+	var hits []map[string]interface{}
+	if err := m.call(ctx, "/v2/vectordb/entities/search", body, &hits); err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+
+	results := make([]vectordb.SearchResult, len(hits))
+	for i, hit := range hits {
+		doc := documentFromRow(hit)
+		raw, _ := hit["distance"].(float64)
+		score, distance := m.scoreAndDistance(float32(raw))
+		results[i] = vectordb.SearchResult{
+			ID:       doc.ID,
+			Content:  doc.Content,
+			Metadata: doc.Metadata,
+			Score:    score,
+			Distance: distance,
+		}
+	}
+	return results, nil
+}
+
+// Get retrieves documents by IDs
+func (m *Milvus) Get(ctx context.Context, ids []string) ([]vectordb.Document, error) {
+	if len(ids) == 0 {
+		return []vectordb.Document{}, nil
+	}
+
+	body := m.body(map[string]interface{}{
+		"id":           ids,
+		"outputFields": []string{fieldID, fieldVector, fieldContent, fieldMetadata, fieldCreatedAt},
+	})
+	var rows []map[string]interface{}
+	if err := m.call(ctx, "/v2/vectordb/entities/get", body, &rows); err != nil {
+		return nil, fmt.Errorf("failed to get documents: %w", err)
+	}
+
+	docs := make([]vectordb.Document, len(rows))
+	for i, row := range rows {
+		docs[i] = documentFromRow(row)
+	}
+	return docs, nil
+}
+
+// Count returns the number of documents in the collection
+func (m *Milvus) Count(ctx context.Context) (int, error) {
+	body := m.body(map[string]interface{}{
+		"filter":       "",
+		"outputFields": []string{"count(*)"},
+	})
+	var rows []map[string]interface{}
+	if err := m.call(ctx, "/v2/vectordb/entities/query", body, &rows); err != nil {
+		return 0, fmt.Errorf("failed to count documents: %w", err)
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	count, _ := rows[0]["count(*)"].(float64)
+	return int(count), nil
+}
+
+// Close releases nothing: the HTTP client may be shared with the application
+func (m *Milvus) Close() error {
+	return nil
+}
+
+// BuildFilter converts a vectordb filter into a Milvus boolean expression.
+//
+// The "expr" key is used verbatim as a native expression. The other keys are
+// a vectordb.Filter in map form over metadata fields (or the partition key
+// field), combined with "and":
+//   - scalar values match exactly
+//   - slices match any of their values ("in")
+//   - maps with gt/gte/lt/lte/ne keys become comparisons
+//   - "$and" and "$or" hold nested filters
+func BuildFilter(filter map[string]interface{}, partitionKey string) (string, error) {
+	if len(filter) == 0 {
+		return "", nil
+	}
+
+	var clauses []string
+	fields := make(map[string]interface{}, len(filter))
+	for key, value := range filter {
+		if key == "expr" {
+			if expr, ok := value.(string); ok && expr != "" {
+				clauses = append(clauses, "("+expr+")")
+			}
+			continue
+		}
+		fields[key] = value
+	}
+
+	f, err := vectordb.ParseFilter(fields)
+	if err != nil {
+		return "", err
+	}
+	if f.Op != vectordb.OpAnd {
+		f = vectordb.And(f)
+	}
+	for _, sub := range f.Filters {
+		clause, err := expression(sub, partitionKey)
+		if err != nil {
+			return "", err
+		}
+		clauses = append(clauses, clause)
+	}
+	return strings.Join(clauses, " and "), nil
+}
+
+// expression converts one filter clause into a Milvus expression. Nested And
+// and Or clauses are parenthesized.
+func expression(f vectordb.Filter, partitionKey string) (string, error) {
+	switch f.Op {
+	case vectordb.OpAnd, vectordb.OpOr:
+		if len(f.Filters) == 0 {
+			return "", fmt.Errorf("milvus filters cannot contain an empty $%s", f.Op)
+		}
+		parts := make([]string, len(f.Filters))
+		for i, sub := range f.Filters {
+			part, err := expression(sub, partitionKey)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = part
+		}
+		if len(parts) == 1 {
+			return parts[0], nil
+		}
+		return "(" + strings.Join(parts, " "+string(f.Op)+" ") + ")", nil
+	}
+
+	field := fmt.Sprintf("%s[%s]", fieldMetadata, strconv.Quote(f.Key))
+	if f.Key == partitionKey {
+		field = f.Key
+	}
+	switch f.Op {
+	case vectordb.OpEq:
+		return field + " == " + literal(f.Value), nil
+	case vectordb.OpIn:
+		return field + " in " + literal(f.Values()), nil
+	}
+	return fmt.Sprintf("%s %s %s", field, comparisonOps[string(f.Op)], literal(f.Value)), nil
+}
+
+var comparisonOps = map[string]string{"gt": ">", "gte": ">=", "lt": "<", "lte": "<=", "ne": "!="}
+
+// literal renders a value as a Milvus expression literal
+func literal(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return strconv.Quote(fmt.Sprint(v))
+	}
+	return string(data)
+}
+
+// scoreAndDistance maps a Milvus distance to the vectordb convention. Milvus
+// returns similarity for COSINE and IP and distance for L2.
+func (m *Milvus) scoreAndDistance(raw float32) (score, distance float32) {
+	switch m.distance {
+	case vectordb.L2:
+		return 1 / (1 + raw), raw
+	case vectordb.InnerProduct:
+		return raw, -raw
+	default:
+		return raw, 1 - raw
+	}
+}
+
+// body returns a request body for the current collection
+func (m *Milvus) body(fields map[string]interface{}) map[string]interface{} {
+	body := map[string]interface{}{"collectionName": m.collection}
+	if m.dbName != "" {
+		body["dbName"] = m.dbName
+	}
+	for k, v := range fields {
+		body[k] = v
+	}
+	return body
+}
+
+// call posts a request and decodes the "data" field of the response into out
+func (m *Milvus) call(ctx context.Context, path string, body, out interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.token != "" {
+		req.Header.Set("Authorization", "Bearer "+m.token)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("milvus returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+
+	// Milvus reports errors with HTTP 200 and a non-zero code.
+	var envelope struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if envelope.Code != 0 {
+		return fmt.Errorf("milvus error %d: %s", envelope.Code, envelope.Message)
+	}
+	if out != nil && len(envelope.Data) > 0 {
+		if err := json.Unmarshal(envelope.Data, out); err != nil {
+			return fmt.Errorf("failed to decode response data: %w", err)
+		}
+	}
+	return nil
+}
+
+func metricType(d vectordb.DistanceFunction) (string, error) {
+	switch d {
+	case vectordb.Cosine:
+		return "COSINE", nil
+	case vectordb.L2:
+		return "L2", nil
+	case vectordb.InnerProduct:
+		return "IP", nil
+	default:
+		return "", fmt.Errorf("unsupported distance function %q", d)
+	}
+}
+
+// distanceFromMetric is the inverse of metricType; it returns "" for metrics
+// this package does not use
+func distanceFromMetric(metric string) vectordb.DistanceFunction {
+	switch metric {
+	case "COSINE":
+		return vectordb.Cosine
+	case "L2":
+		return vectordb.L2
+	case "IP":
+		return vectordb.InnerProduct
+	default:
+		return ""
+	}
+}
+
+func documentFromRow(row map[string]interface{}) vectordb.Document {
+	var doc vectordb.Document
+	doc.ID, _ = row[fieldID].(string)
+	doc.Content, _ = row[fieldContent].(string)
+	doc.Metadata, _ = row[fieldMetadata].(map[string]interface{})
+	if ms, ok := row[fieldCreatedAt].(float64); ok {
+		doc.CreatedAt = time.UnixMilli(int64(ms))
+	}
+	if vec, ok := row[fieldVector].([]interface{}); ok {
+		doc.Embedding = make([]float32, len(vec))
+		for i, v := range vec {
+			f, _ := v.(float64)
+			doc.Embedding[i] = float32(f)
+		}
+	}
+	return doc
+}
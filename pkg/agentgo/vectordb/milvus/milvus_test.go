@@ -0,0 +1,380 @@
+package milvus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+)
+
+// fakeMilvus is a minimal in-memory stand-in for the Milvus v2 RESTful API.
+type fakeMilvus struct {
+	mu          sync.Mutex
+	collections map[string]map[string]interface{}
+	rows        map[string]map[string]interface{}
+	lastSearch  map[string]interface{}
+	lastDelete  map[string]interface{}
+	auth        string
+}
+
+func newFakeMilvus(t *testing.T) (*fakeMilvus, *httptest.Server) {
+	f := &fakeMilvus{
+		collections: map[string]map[string]interface{}{},
+		rows:        map[string]map[string]interface{}{},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeMilvus) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.auth = r.Header.Get("Authorization")
+
+	var body map[string]interface{}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	name, _ := body["collectionName"].(string)
+
+	reply := func(data interface{}) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": data})
+	}
+
+	switch strings.TrimPrefix(r.URL.Path, "/v2/vectordb/") {
+	case "collections/has":
+		_, ok := f.collections[name]
+		reply(map[string]interface{}{"has": ok})
+	case "collections/create":
+		f.collections[name] = body
+		reply(map[string]interface{}{})
+	case "collections/list":
+		names := []string{}
+		for n := range f.collections {
+			names = append(names, n)
+		}
+		reply(names)
+	case "collections/describe":
+		// Rebuild the described schema from the create request
+		created := f.collections[name]
+		var fields []interface{}
+		for _, field := range created["schema"].(map[string]interface{})["fields"].([]interface{}) {
+			field := field.(map[string]interface{})
+			var params []interface{}
+			if typeParams, ok := field["elementTypeParams"].(map[string]interface{}); ok && typeParams["dim"] != nil {
+				dim := typeParams["dim"]
+				params = append(params, map[string]interface{}{"key": "dim", "value": dim})
+			}
+			fields = append(fields, map[string]interface{}{"name": field["fieldName"], "params": params})
+		}
+		index := created["indexParams"].([]interface{})[0].(map[string]interface{})
+		reply(map[string]interface{}{
+			"fields":  fields,
+			"indexes": []interface{}{map[string]interface{}{"fieldName": index["fieldName"], "metricType": index["metricType"]}},
+		})
+	case "indexes/describe":
+		index := f.collections[name]["indexParams"].([]interface{})[0].(map[string]interface{})
+		reply([]interface{}{map[string]interface{}{"indexType": index["params"].(map[string]interface{})["index_type"]}})
+	case "collections/get_stats":
+		reply(map[string]interface{}{"rowCount": len(f.rows)})
+	case "collections/drop":
+		delete(f.collections, name)
+		reply(map[string]interface{}{})
+	case "entities/upsert":
+		for _, row := range body["data"].([]interface{}) {
+			row := row.(map[string]interface{})
+			f.rows[row["id"].(string)] = row
+		}
+		reply(map[string]interface{}{"upsertCount": len(body["data"].([]interface{}))})
+	case "entities/get":
+		var result []interface{}
+		for _, id := range body["id"].([]interface{}) {
+			if row, ok := f.rows[id.(string)]; ok {
+				result = append(result, row)
+			}
+		}
+		reply(result)
+	case "entities/delete":
+		f.lastDelete = body
+		var ids []string
+		_ = json.Unmarshal([]byte(strings.TrimPrefix(body["filter"].(string), "id in ")), &ids)
+		for _, id := range ids {
+			delete(f.rows, id)
+		}
+		reply(map[string]interface{}{})
+	case "entities/query":
+		reply([]interface{}{map[string]interface{}{"count(*)": len(f.rows)}})
+	case "entities/search":
+		f.lastSearch = body
+		var result []interface{}
+		for _, row := range f.rows {
+			result = append(result, map[string]interface{}{
+				"id": row["id"], "content": row["content"], "metadata": row["metadata"], "distance": 0.9,
+			})
+		}
+		reply(result)
+	default:
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 1100, "message": "unexpected request"})
+	}
+}
+
+type fixedEmbedder struct{}
+
+func (fixedEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i := range texts {
+		out[i] = []float32{1, 0, 0}
+	}
+	return out, nil
+}
+
+func (fixedEmbedder) EmbedSingle(_ context.Context, _ string) ([]float32, error) {
+	return []float32{1, 0, 0}, nil
+}
+
+func TestMilvus_CollectionStats(t *testing.T) {
+	_, srv := newFakeMilvus(t)
+	ctx := context.Background()
+	db, _ := New(Config{URL: srv.URL, CollectionName: "docs", Dimension: 3, DistanceFunction: vectordb.L2})
+	for _, name := range []string{"docs", "archive"} {
+		if err := db.CreateCollection(ctx, name, nil); err != nil {
+			t.Fatalf("CreateCollection(%s) error = %v", name, err)
+		}
+	}
+	if err := db.Add(ctx, []vectordb.Document{{ID: "a", Embedding: []float32{1, 0, 0}}}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	names, err := db.ListCollections(ctx)
+	if err != nil || strings.Join(names, ",") != "archive,docs" {
+		t.Errorf("ListCollections() = %v, %v", names, err)
+	}
+
+	stats, err := db.CollectionStats(ctx, "docs")
+	want := vectordb.CollectionStats{Name: "docs", Count: 1, Dimension: 3, DistanceFunction: vectordb.L2, IndexType: "autoindex", DiskSize: -1}
+	if err != nil || stats != want {
+		t.Errorf("CollectionStats() = %+v, %v, want %+v", stats, err, want)
+	}
+	if _, err := db.CollectionStats(ctx, "missing"); !errors.Is(err, vectordb.ErrCollectionNotFound) {
+		t.Errorf("CollectionStats(missing) error = %v, want ErrCollectionNotFound", err)
+	}
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Config{}); err == nil {
+		t.Error("expected error without collection name")
+	}
+	if _, err := New(Config{CollectionName: "c", DistanceFunction: "manhattan"}); err == nil {
+		t.Error("expected error for unsupported distance")
+	}
+	if _, err := New(Config{CollectionName: "c", PartitionKeyField: "content"}); err == nil {
+		t.Error("expected error for reserved partition key field")
+	}
+}
+
+func TestMilvus_CollectionLifecycle(t *testing.T) {
+	fake, srv := newFakeMilvus(t)
+	db, err := New(Config{
+		URL:               srv.URL,
+		Token:             "root:Milvus",
+		CollectionName:    "docs",
+		Dimension:         3,
+		DistanceFunction:  vectordb.InnerProduct,
+		IndexType:         "HNSW",
+		IndexParams:       map[string]interface{}{"M": 16},
+		PartitionKeyField: "tenant",
+		PartitionsNum:     16,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := db.CreateCollection(ctx, "", nil); err != nil {
+		t.Fatalf("CreateCollection: %v", err)
+	}
+	created := fake.collections["docs"]
+	index := created["indexParams"].([]interface{})[0].(map[string]interface{})
+	params := index["params"].(map[string]interface{})
+	if index["metricType"] != "IP" || params["index_type"] != "HNSW" || params["M"] != "16" {
+		t.Errorf("unexpected index params: %v", index)
+	}
+	if created["params"].(map[string]interface{})["partitionsNum"] != float64(16) {
+		t.Errorf("partitionsNum not sent: %v", created["params"])
+	}
+	var partitionKey bool
+	for _, field := range created["schema"].(map[string]interface{})["fields"].([]interface{}) {
+		field := field.(map[string]interface{})
+		if field["fieldName"] == "tenant" && field["isPartitionKey"] == true {
+			partitionKey = true
+		}
+	}
+	if !partitionKey {
+		t.Error("partition key field missing from schema")
+	}
+	if fake.auth != "Bearer root:Milvus" {
+		t.Errorf("Authorization header = %q", fake.auth)
+	}
+
+	// Creating again is a no-op.
+	if err := db.CreateCollection(ctx, "", nil); err != nil {
+		t.Fatalf("CreateCollection (existing): %v", err)
+	}
+
+	if err := db.DeleteCollection(ctx, ""); err != nil {
+		t.Fatalf("DeleteCollection: %v", err)
+	}
+	if _, ok := fake.collections["docs"]; ok {
+		t.Error("collection should be dropped")
+	}
+}
+
+func TestMilvus_DocumentsAndSearch(t *testing.T) {
+	fake, srv := newFakeMilvus(t)
+	db, _ := New(Config{
+		URL:               srv.URL,
+		CollectionName:    "docs",
+		PartitionKeyField: "tenant",
+		SearchParams:      map[string]interface{}{"ef": 64},
+		EmbeddingFunction: fixedEmbedder{},
+	})
+	ctx := context.Background()
+
+	docs := []vectordb.Document{
+		{ID: "doc-1", Content: "hello", Metadata: map[string]interface{}{"tenant": "acme", "lang": "en"}},
+		{ID: "doc-2", Content: "olá", Embedding: []float32{0, 1, 0}, Metadata: map[string]interface{}{"tenant": "acme"}},
+	}
+	if err := db.Add(ctx, docs); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if docs[0].Embedding != nil {
+		t.Error("Add must not modify the caller's documents")
+	}
+	if fake.rows["doc-1"]["tenant"] != "acme" {
+		t.Errorf("partition key not stored: %v", fake.rows["doc-1"])
+	}
+
+	if err := db.Add(ctx, []vectordb.Document{{ID: "doc-3", Content: "x"}}); err == nil {
+		t.Error("expected error for document without partition key")
+	}
+
+	n, err := db.Count(ctx)
+	if err != nil || n != 2 {
+		t.Fatalf("Count = %d, %v", n, err)
+	}
+
+	got, err := db.Get(ctx, []string{"doc-1"})
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(got) != 1 || got[0].Content != "hello" || got[0].Metadata["lang"] != "en" || len(got[0].Embedding) != 3 || got[0].CreatedAt.IsZero() {
+		t.Errorf("unexpected document: %+v", got)
+	}
+
+	results, err := db.Query(ctx, "hi", 5, map[string]interface{}{"tenant": "acme", "lang": "en"})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(results) != 2 || results[0].Score != 0.9 {
+		t.Errorf("unexpected results: %+v", results)
+	}
+	if fake.lastSearch["filter"] != `metadata["lang"] == "en" and tenant == "acme"` {
+		t.Errorf("unexpected filter: %v", fake.lastSearch["filter"])
+	}
+	search := fake.lastSearch["searchParams"].(map[string]interface{})
+	if search["metricType"] != "COSINE" || search["params"].(map[string]interface{})["ef"] != float64(64) {
+		t.Errorf("unexpected search params: %v", search)
+	}
+
+	if err := db.Delete(ctx, []string{"doc-1", "doc-2"}); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if n, _ := db.Count(ctx); n != 0 {
+		t.Errorf("Count after delete = %d", n)
+	}
+}
+
+func TestMilvus_DeleteByFilter(t *testing.T) {
+	fake, srv := newFakeMilvus(t)
+	db, _ := New(Config{URL: srv.URL, CollectionName: "docs", Dimension: 3})
+	ctx := context.Background()
+
+	if err := db.DeleteByFilter(ctx, map[string]interface{}{}); err == nil {
+		t.Error("expected error for an empty filter")
+	}
+	if err := db.DeleteByFilter(ctx, map[string]interface{}{"$and": []interface{}{}}); err == nil {
+		t.Error("expected error for an empty $and")
+	}
+	if fake.lastDelete != nil {
+		t.Errorf("no delete request should be sent, got %v", fake.lastDelete)
+	}
+	if err := db.DeleteByFilter(ctx, map[string]interface{}{"user_id": "alice"}); err != nil {
+		t.Fatalf("DeleteByFilter: %v", err)
+	}
+	if got := fake.lastDelete["filter"]; got != `metadata["user_id"] == "alice"` {
+		t.Errorf("delete filter = %v", got)
+	}
+}
+
+func TestMilvus_ErrorCode(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":100,"message":"collection not found"}`))
+	}))
+	defer srv.Close()
+
+	db, _ := New(Config{URL: srv.URL, CollectionName: "missing"})
+	_, err := db.Count(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "collection not found") {
+		t.Errorf("expected server error, got %v", err)
+	}
+}
+
+func TestBuildFilter(t *testing.T) {
+	if got, err := BuildFilter(nil, ""); got != "" || err != nil {
+		t.Error("empty filter should be empty")
+	}
+
+	got, err := BuildFilter(map[string]interface{}{
+		"tags":   []string{"a", "b"},
+		"year":   map[string]interface{}{"gte": 2020, "lt": 2025},
+		"topic":  "go",
+		"tenant": "acme",
+		"expr":   "created_at > 0",
+	}, "tenant")
+	if err != nil {
+		t.Fatalf("BuildFilter() error = %v", err)
+	}
+	want := `(created_at > 0) and metadata["tags"] in ["a","b"] and tenant == "acme" and ` +
+		`metadata["topic"] == "go" and metadata["year"] >= 2020 and metadata["year"] < 2025`
+	if got != want {
+		t.Errorf("BuildFilter =\n%s\nwant\n%s", got, want)
+	}
+
+	got, err = BuildFilter(vectordb.And(
+		vectordb.Eq("tenant", "acme"),
+		vectordb.Or(vectordb.In("lang", "go", "rust"), vectordb.Ne("status", "draft")),
+	).Map(), "tenant")
+	if err != nil {
+		t.Fatalf("BuildFilter() error = %v", err)
+	}
+	want = `tenant == "acme" and (metadata["lang"] in ["go","rust"] or metadata["status"] != "draft")`
+	if got != want {
+		t.Errorf("BuildFilter =\n%s\nwant\n%s", got, want)
+	}
+
+	if _, err := BuildFilter(map[string]interface{}{"year": map[string]interface{}{"between": 1}}, ""); err == nil {
+		t.Error("expected error for an unknown operator")
+	}
+}
+
+func TestScoreAndDistance(t *testing.T) {
+	db, _ := New(Config{CollectionName: "c", DistanceFunction: vectordb.L2})
+	if score, distance := db.scoreAndDistance(1); score != 0.5 || distance != 1 {
+		t.Errorf("L2: score=%v distance=%v", score, distance)
+	}
+}
@@ -1,44 +1,128 @@
+// Package pgvector stores vectors in PostgreSQL with the pgvector extension.
+//
+// It runs on a *sql.DB (for example with the lib/pq driver) or on a
+// pgxpool.Pool. With pgx, statements are prepared once per connection and
+// cached, and large batches are loaded with pgx's native COPY.
+//
+// With Config.HybridSearch, documents also get a full-text tsvector column,
+// and Query fuses vector and keyword rankings with reciprocal rank fusion in
+// a single SQL statement.
 package pgvector
 
 import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jholhewres/agent-go/pkg/agentgo/pagination"
 	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+	"github.com/lib/pq"
 	"github.com/pgvector/pgvector-go"
 )
 
 // PgVector implements vectordb.VectorDB using PostgreSQL with pgvector extension
 type PgVector struct {
 	db             *sql.DB
+	pool           *pgxpool.Pool // set when running on pgx
+	ownsPool       bool          // the pool was opened from Config.DSN
 	tableName      string
 	dimension      int
 	indexType      string // "ivfflat" or "hnsw"
 	indexParams    map[string]interface{}
 	embedFunc      vectordb.EmbeddingFunction
 	collectionName string
+	batchSize      int
+	workers        int
+	queryTimeout   time.Duration
+	hybrid         *HybridSearchConfig
 }
 
-// Config holds pgvector configuration
+// HybridSearchConfig enables hybrid (vector + keyword) search. Query then
+// ranks documents by vector distance and by full-text relevance, and merges
+// both rankings with reciprocal rank fusion (RRF): a document scores
+// weight / (K + rank) for each ranking it appears in.
+type HybridSearchConfig struct {
+	// Language is the text search configuration used to index content, such
+	// as "english" or "simple" (default: "english"). It is fixed when the
+	// column is first created.
+	Language string
+
+	// K damps the influence of top ranks (default: 60)
+	K int
+
+	// Candidates is the number of documents taken from each ranking before
+	// fusion (default: 4 × the query limit)
+	Candidates int
+
+	// VectorWeight and TextWeight scale the two rankings. When both are
+	// zero, both default to 1; set one to 0 to rank by the other alone.
+	VectorWeight float64
+	TextWeight   float64
+}
+
+// Config holds pgvector configuration. Exactly one of DB, Pool and DSN is
+// required.
 type Config struct {
-	DB             *sql.DB
+	// DB is a database/sql connection, e.g. opened with the lib/pq driver.
+	// It is owned by the caller and left open by Close.
+	DB *sql.DB
+
+	// Pool is a pgx connection pool, owned by the caller and left open by Close
+	Pool *pgxpool.Pool
+
+	// DSN opens a pgx connection pool owned by the store, closed by Close
+	DSN string
+
 	TableName      string
 	CollectionName string
 	Dimension      int
 	IndexType      string                 // "ivfflat" or "hnsw" (default: "hnsw")
 	IndexParams    map[string]interface{} // Index-specific parameters
 	EmbeddingFunc  vectordb.EmbeddingFunction
+
+	// BatchSize is the number of documents written per transaction by Add
+	// and Update (default: 1000). Large batches are loaded with COPY.
+	BatchSize int
+
+	// Workers is the number of batches written concurrently (default: 1)
+	Workers int
+
+	// QueryTimeout bounds each database call, in addition to the deadline of
+	// the caller's context (default: none)
+	QueryTimeout time.Duration
+
+	// HybridSearch adds a full-text column and makes Query combine vector
+	// and keyword matches (nil = vector search only)
+	HybridSearch *HybridSearchConfig
 }
 
+// copyThreshold is the batch size from which documents are loaded with COPY
+// into a staging table; smaller batches use a prepared statement, which is
+// cheaper than creating the table
+const copyThreshold = 64
+
 // New creates a new PgVector instance
 func New(config Config) (*PgVector, error) {
-	if config.DB == nil {
+	connections := 0
+	for _, set := range []bool{config.DB != nil, config.Pool != nil, config.DSN != ""} {
+		if set {
+			connections++
+		}
+	}
+	if connections == 0 {
 		return nil, fmt.Errorf("database connection is required")
 	}
+	if connections > 1 {
+		return nil, fmt.Errorf("only one of DB, Pool and DSN can be set")
+	}
 
 	if config.TableName == "" {
 		config.TableName = "vector_documents"
@@ -60,23 +144,78 @@ func New(config Config) (*PgVector, error) {
 		return nil, fmt.Errorf("index_type must be 'ivfflat' or 'hnsw'")
 	}
 
+	if config.BatchSize <= 0 {
+		config.BatchSize = 1000
+	}
+
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+
+	if h := config.HybridSearch; h != nil {
+		copied := *h
+		if copied.Language == "" {
+			copied.Language = "english"
+		}
+		if !isValidMetadataKey(copied.Language) {
+			return nil, fmt.Errorf("invalid text search language: %s", copied.Language)
+		}
+		if copied.K <= 0 {
+			copied.K = 60
+		}
+		if copied.VectorWeight == 0 && copied.TextWeight == 0 {
+			copied.VectorWeight, copied.TextWeight = 1, 1
+		}
+		if copied.VectorWeight < 0 || copied.TextWeight < 0 {
+			return nil, fmt.Errorf("hybrid search weights must not be negative")
+		}
+		config.HybridSearch = &copied
+	}
+
 	pv := &PgVector{
 		db:             config.DB,
+		pool:           config.Pool,
 		tableName:      config.TableName,
 		dimension:      config.Dimension,
 		indexType:      config.IndexType,
 		indexParams:    config.IndexParams,
 		embedFunc:      config.EmbeddingFunc,
 		collectionName: config.CollectionName,
+		batchSize:      config.BatchSize,
+		workers:        config.Workers,
+		queryTimeout:   config.QueryTimeout,
+		hybrid:         config.HybridSearch,
+	}
+
+	if config.DSN != "" {
+		pool, err := pgxpool.New(context.Background(), config.DSN)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create connection pool: %w", err)
+		}
+		pv.pool, pv.ownsPool = pool, true
+	}
+	if pv.pool != nil {
+		// Queries go through database/sql on top of the pool; pgx caches
+		// their prepared statements per connection
+		pv.db = stdlib.OpenDBFromPool(pv.pool)
 	}
 
 	if err := pv.migrate(); err != nil {
+		pv.Close()
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
 	return pv, nil
 }
 
+// withTimeout bounds ctx by QueryTimeout
+func (pv *PgVector) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if pv.queryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, pv.queryTimeout)
+}
+
 // migrate creates the necessary tables and indexes
 func (pv *PgVector) migrate() error {
 	ctx := context.Background()
@@ -151,6 +290,31 @@ func (pv *PgVector) migrate() error {
 		return fmt.Errorf("failed to create metadata index: %w", err)
 	}
 
+	if pv.hybrid == nil {
+		return nil
+	}
+
+	// Full-text column for hybrid search, kept up to date by PostgreSQL
+	addTextColumn := fmt.Sprintf(`
+		ALTER TABLE %s ADD COLUMN IF NOT EXISTS content_tsv tsvector
+		GENERATED ALWAYS AS (to_tsvector('%s', content)) STORED
+	`, pv.tableName, pv.hybrid.Language)
+
+	_, err = pv.db.ExecContext(ctx, addTextColumn)
+	if err != nil {
+		return fmt.Errorf("failed to add text search column: %w", err)
+	}
+
+	textIndex := fmt.Sprintf("idx_%s_content_tsv", pv.tableName)
+	createTextIndex := fmt.Sprintf(`
+		CREATE INDEX IF NOT EXISTS %s ON %s USING GIN(content_tsv)
+	`, textIndex, pv.tableName)
+
+	_, err = pv.db.ExecContext(ctx, createTextIndex)
+	if err != nil {
+		return fmt.Errorf("failed to create text search index: %w", err)
+	}
+
 	return nil
 }
 
@@ -163,34 +327,121 @@ func (pv *PgVector) CreateCollection(ctx context.Context, name string, metadata
 
 // DeleteCollection deletes all documents in a collection
 func (pv *PgVector) DeleteCollection(ctx context.Context, name string) error {
+	ctx, cancel := pv.withTimeout(ctx)
+	defer cancel()
+
 	query := fmt.Sprintf(`DELETE FROM %s WHERE collection = $1`, pv.tableName)
 	_, err := pv.db.ExecContext(ctx, query, name)
 	return err
 }
 
-// Add adds documents to the collection
+// Add adds documents to the collection, replacing documents with the same ID.
+// Documents are written in batches of Config.BatchSize, each in its own
+// transaction; if a batch fails, batches already written stay in place.
 func (pv *PgVector) Add(ctx context.Context, docs []vectordb.Document) error {
 	return pv.upsert(ctx, docs, false)
 }
 
-// Update updates existing documents
+// Update updates existing documents, in batches like Add
 func (pv *PgVector) Update(ctx context.Context, docs []vectordb.Document) error {
 	return pv.upsert(ctx, docs, true)
 }
 
-// upsert inserts or updates documents
+// upsert inserts or updates documents, writing up to pv.workers batches at once
 func (pv *PgVector) upsert(ctx context.Context, docs []vectordb.Document, updateOnly bool) error {
 	if len(docs) == 0 {
 		return nil
 	}
 
+	// A statement cannot update the same row twice, so only the last
+	// version of a document is written
+	docs = lastByID(docs)
+
+	var batches [][]vectordb.Document
+	for start := 0; start < len(docs); start += pv.batchSize {
+		batches = append(batches, docs[start:min(start+pv.batchSize, len(docs))])
+	}
+	if len(batches) == 1 || pv.workers == 1 {
+		for i, batch := range batches {
+			if err := pv.writeBatch(ctx, batch, updateOnly); err != nil {
+				if len(batches) == 1 {
+					return err
+				}
+				return fmt.Errorf("batch %d of %d: %w", i+1, len(batches), err)
+			}
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make([]error, len(batches))
+	semaphore := make(chan struct{}, pv.workers)
+	var wg sync.WaitGroup
+	for i, batch := range batches {
+		wg.Add(1)
+		go func(i int, batch []vectordb.Document) {
+			defer wg.Done()
+			semaphore <- struct{}{}        // Acquire
+			defer func() { <-semaphore }() // Release
+
+			if err := ctx.Err(); err != nil {
+				errs[i] = err
+				return
+			}
+			if err := pv.writeBatch(ctx, batch, updateOnly); err != nil {
+				errs[i] = fmt.Errorf("batch %d of %d: %w", i+1, len(batches), err)
+				cancel() // Stop the remaining batches
+			}
+		}(i, batch)
+	}
+	wg.Wait()
+
+	// Report the failure that caused the cancellation, not the batches it stopped
+	var first error
+	for _, err := range errs {
+		if err != nil && (first == nil || errors.Is(first, context.Canceled)) {
+			first = err
+		}
+	}
+	return first
+}
+
+// writeBatch writes docs in one transaction
+func (pv *PgVector) writeBatch(ctx context.Context, docs []vectordb.Document, updateOnly bool) error {
+	ctx, cancel := pv.withTimeout(ctx)
+	defer cancel()
+
+	if pv.pool != nil && len(docs) >= copyThreshold {
+		return pv.copyDocumentsPgx(ctx, docs, updateOnly)
+	}
+
 	tx, err := pv.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	if len(docs) >= copyThreshold {
+		err = pv.copyDocuments(ctx, tx, docs, updateOnly)
+	} else {
+		err = pv.execDocuments(ctx, tx, docs, updateOnly)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// execDocuments writes docs with one prepared statement execution each
+func (pv *PgVector) execDocuments(ctx context.Context, tx *sql.Tx, docs []vectordb.Document, updateOnly bool) error {
 	var stmt *sql.Stmt
+	var err error
 	if updateOnly {
 		stmt, err = tx.PrepareContext(ctx, fmt.Sprintf(`
 			UPDATE %s SET content = $1, embedding = $2, metadata = $3
@@ -231,10 +482,139 @@ func (pv *PgVector) upsert(ctx context.Context, docs []vectordb.Document, update
 		}
 	}
 
-	return tx.Commit()
+	return nil
+}
+
+// copyDocuments loads docs into a temporary staging table with COPY, then
+// merges them into the documents table with a single statement
+func (pv *PgVector) copyDocuments(ctx context.Context, tx *sql.Tx, docs []vectordb.Document, updateOnly bool) error {
+	if _, err := tx.ExecContext(ctx, createStaging); err != nil {
+		return fmt.Errorf("failed to create staging table: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("pgvector_staging", stagingColumns...))
+	if err != nil {
+		return fmt.Errorf("failed to prepare COPY statement: %w", err)
+	}
+	defer stmt.Close()
+
+	rows, err := stagingRows(docs)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			return fmt.Errorf("failed to add row to COPY: %w", err)
+		}
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return fmt.Errorf("failed to execute COPY: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, pv.mergeStaging(updateOnly), pv.collectionName); err != nil {
+		return fmt.Errorf("failed to merge staged documents: %w", err)
+	}
+	return nil
+}
+
+// copyDocumentsPgx is copyDocuments for pgx pools, using pgx's COPY protocol
+// support in its own transaction
+func (pv *PgVector) copyDocumentsPgx(ctx context.Context, docs []vectordb.Document, updateOnly bool) error {
+	rows, err := stagingRows(docs)
+	if err != nil {
+		return err
+	}
+
+	return pgx.BeginFunc(ctx, pv.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, createStaging); err != nil {
+			return fmt.Errorf("failed to create staging table: %w", err)
+		}
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{"pgvector_staging"}, stagingColumns, pgx.CopyFromRows(rows)); err != nil {
+			return fmt.Errorf("failed to execute COPY: %w", err)
+		}
+		if _, err := tx.Exec(ctx, pv.mergeStaging(updateOnly), pv.collectionName); err != nil {
+			return fmt.Errorf("failed to merge staged documents: %w", err)
+		}
+		return nil
+	})
+}
+
+// createStaging creates the table COPY loads into. Embeddings and metadata
+// are staged as text and cast when merged, so COPY needs no knowledge of the
+// vector type.
+const createStaging = `
+	CREATE TEMPORARY TABLE pgvector_staging (
+		id VARCHAR(255),
+		content TEXT,
+		embedding TEXT,
+		metadata TEXT,
+		created_at TIMESTAMP
+	) ON COMMIT DROP
+`
+
+var stagingColumns = []string{"id", "content", "embedding", "metadata", "created_at"}
+
+// stagingRows converts docs to rows of the staging table
+func stagingRows(docs []vectordb.Document) ([][]interface{}, error) {
+	now := time.Now()
+	rows := make([][]interface{}, len(docs))
+	for i, doc := range docs {
+		metadataJSON, err := json.Marshal(doc.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+
+		createdAt := doc.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = now
+		}
+
+		rows[i] = []interface{}{doc.ID, doc.Content, pgvector.NewVector(doc.Embedding).String(), string(metadataJSON), createdAt}
+	}
+	return rows, nil
+}
+
+// mergeStaging returns the statement that moves staged documents into the
+// documents table; $1 is the collection
+func (pv *PgVector) mergeStaging(updateOnly bool) string {
+	if updateOnly {
+		return fmt.Sprintf(`
+			UPDATE %s AS t SET content = s.content, embedding = s.embedding::vector, metadata = s.metadata::jsonb
+			FROM pgvector_staging AS s
+			WHERE t.id = s.id AND t.collection = $1
+		`, pv.tableName)
+	}
+	return fmt.Sprintf(`
+		INSERT INTO %s (id, collection, content, embedding, metadata, created_at)
+		SELECT id, $1, content, embedding::vector, metadata::jsonb, created_at FROM pgvector_staging
+		ON CONFLICT (id, collection) DO UPDATE
+		SET content = EXCLUDED.content, embedding = EXCLUDED.embedding, metadata = EXCLUDED.metadata
+	`, pv.tableName)
 }
 
-// Query searches using text query (requires embedding function)
+// lastByID drops all but the last occurrence of each document ID, keeping
+// the order of the remaining documents
+func lastByID(docs []vectordb.Document) []vectordb.Document {
+	last := make(map[string]int, len(docs))
+	for i, doc := range docs {
+		last[doc.ID] = i
+	}
+	if len(last) == len(docs) {
+		return docs
+	}
+
+	unique := make([]vectordb.Document, 0, len(last))
+	for i, doc := range docs {
+		if last[doc.ID] == i {
+			unique = append(unique, doc)
+		}
+	}
+	return unique
+}
+
+// Query searches using text query (requires embedding function). With
+// HybridSearch, it also matches the query against the full-text column; see
+// HybridQuery.
 func (pv *PgVector) Query(ctx context.Context, query string, limit int, filter map[string]interface{}) ([]vectordb.SearchResult, error) {
 	if pv.embedFunc == nil {
 		return nil, fmt.Errorf("embedding function is required for text queries")
@@ -246,11 +626,111 @@ func (pv *PgVector) Query(ctx context.Context, query string, limit int, filter m
 		return nil, fmt.Errorf("failed to embed query: %w", err)
 	}
 
+	if pv.hybrid != nil {
+		return pv.HybridQuery(ctx, query, embedding, limit, filter)
+	}
 	return pv.QueryWithEmbedding(ctx, embedding, limit, filter)
 }
 
+// HybridQuery ranks documents both by distance to embedding and by full-text
+// relevance to text (parsed with websearch_to_tsquery), and fuses the two
+// rankings with RRF in one statement. Score is the fused RRF score, which is
+// only meaningful for ordering; Distance is the vector distance. It requires
+// HybridSearch.
+func (pv *PgVector) HybridQuery(ctx context.Context, text string, embedding []float32, limit int, filter map[string]interface{}) ([]vectordb.SearchResult, error) {
+	if pv.hybrid == nil {
+		return nil, fmt.Errorf("hybrid search is not enabled")
+	}
+
+	ctx, cancel := pv.withTimeout(ctx)
+	defer cancel()
+
+	if len(embedding) != pv.dimension {
+		return nil, fmt.Errorf("embedding dimension mismatch: expected %d, got %d", pv.dimension, len(embedding))
+	}
+
+	if limit <= 0 {
+		limit = 10
+	}
+	candidates := pv.hybrid.Candidates
+	if candidates <= 0 {
+		candidates = 4 * limit
+	}
+
+	args := []interface{}{pgvector.NewVector(embedding), pv.collectionName, text}
+	condition := "TRUE"
+	if len(filter) > 0 {
+		var err error
+		condition, err = filterSQL(filter, dollarArgs(&args))
+		if err != nil {
+			return nil, err
+		}
+	}
+	arg := dollarArgs(&args)
+	candidatesArg, kArg := arg(candidates), arg(pv.hybrid.K)
+	vectorWeightArg, textWeightArg := arg(pv.hybrid.VectorWeight), arg(pv.hybrid.TextWeight)
+	limitArg := arg(limit)
+
+	query := fmt.Sprintf(`
+		WITH vector_hits AS (
+			SELECT id, ROW_NUMBER() OVER (ORDER BY distance) AS rank
+			FROM (
+				SELECT id, embedding <=> $1 AS distance
+				FROM %[1]s
+				WHERE collection = $2 AND %[2]s
+				ORDER BY embedding <=> $1
+				LIMIT %[3]s
+			) AS nearest
+		), text_hits AS (
+			SELECT id, ROW_NUMBER() OVER (ORDER BY relevance DESC) AS rank
+			FROM (
+				SELECT id, ts_rank_cd(content_tsv, query) AS relevance
+				FROM %[1]s, websearch_to_tsquery('%[4]s', $3) AS query
+				WHERE collection = $2 AND content_tsv @@ query AND %[2]s
+				ORDER BY relevance DESC
+				LIMIT %[3]s
+			) AS matching
+		), fused AS (
+			SELECT COALESCE(v.id, t.id) AS id,
+				COALESCE(%[6]s::float8 / (%[5]s + v.rank), 0) + COALESCE(%[7]s::float8 / (%[5]s + t.rank), 0) AS score
+			FROM vector_hits AS v
+			FULL OUTER JOIN text_hits AS t ON v.id = t.id
+		)
+		SELECT d.id, d.content, d.metadata, f.score, d.embedding <=> $1 AS distance
+		FROM fused AS f
+		JOIN %[1]s AS d ON d.id = f.id AND d.collection = $2
+		ORDER BY f.score DESC, d.id
+		LIMIT %[8]s
+	`, pv.tableName, condition, candidatesArg, pv.hybrid.Language, kArg, vectorWeightArg, textWeightArg, limitArg)
+
+	rows, err := pv.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %w", err)
+	}
+	defer rows.Close()
+
+	var results []vectordb.SearchResult
+	for rows.Next() {
+		var result vectordb.SearchResult
+		var metadataJSON []byte
+
+		err := rows.Scan(&result.ID, &result.Content, &metadataJSON, &result.Score, &result.Distance)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		json.Unmarshal(metadataJSON, &result.Metadata)
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
 // QueryWithEmbedding searches using pre-computed embedding
 func (pv *PgVector) QueryWithEmbedding(ctx context.Context, embedding []float32, limit int, filter map[string]interface{}) ([]vectordb.SearchResult, error) {
+	ctx, cancel := pv.withTimeout(ctx)
+	defer cancel()
+
 	if len(embedding) != pv.dimension {
 		return nil, fmt.Errorf("embedding dimension mismatch: expected %d, got %d", pv.dimension, len(embedding))
 	}
@@ -267,23 +747,18 @@ func (pv *PgVector) QueryWithEmbedding(ctx context.Context, embedding []float32,
 	`, pv.tableName)
 
 	args := []interface{}{pgvector.NewVector(embedding), pv.collectionName}
-	argIdx := 3
 
 	// Add metadata filter
-	if filter != nil && len(filter) > 0 {
-		for key, value := range filter {
-			// Validate key to prevent SQL injection
-			if !isValidMetadataKey(key) {
-				return nil, fmt.Errorf("invalid metadata key: %s (must be alphanumeric with underscores)", key)
-			}
-			query += fmt.Sprintf(" AND metadata->>'%s' = $%d", key, argIdx)
-			args = append(args, value)
-			argIdx++
+	if len(filter) > 0 {
+		condition, err := filterSQL(filter, dollarArgs(&args))
+		if err != nil {
+			return nil, err
 		}
+		query += " AND " + condition
 	}
 
-	query += fmt.Sprintf(" ORDER BY embedding <=> $1 LIMIT $%d", argIdx)
 	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY embedding <=> $1 LIMIT $%d", len(args))
 
 	rows, err := pv.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -310,6 +785,9 @@ func (pv *PgVector) QueryWithEmbedding(ctx context.Context, embedding []float32,
 
 // Get retrieves documents by IDs
 func (pv *PgVector) Get(ctx context.Context, ids []string) ([]vectordb.Document, error) {
+	ctx, cancel := pv.withTimeout(ctx)
+	defer cancel()
+
 	if len(ids) == 0 {
 		return []vectordb.Document{}, nil
 	}
@@ -354,16 +832,211 @@ func (pv *PgVector) Get(ctx context.Context, ids []string) ([]vectordb.Document,
 	return documents, rows.Err()
 }
 
+// Scan pages through the collection in ID order; the cursor is the last ID of
+// the previous page
+func (pv *PgVector) Scan(ctx context.Context, cursor string, limit int) ([]vectordb.Document, string, error) {
+	ctx, cancel := pv.withTimeout(ctx)
+	defer cancel()
+
+	if limit <= 0 {
+		return nil, "", fmt.Errorf("limit must be positive")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, content, embedding, metadata, created_at
+		FROM %s
+		WHERE collection = $1 AND id > $2
+		ORDER BY id
+		LIMIT $3
+	`, pv.tableName)
+
+	// One extra row tells whether another page follows.
+	rows, err := pv.db.QueryContext(ctx, query, pv.collectionName, cursor, limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var documents []vectordb.Document
+	for rows.Next() {
+		var doc vectordb.Document
+		var embeddingVec pgvector.Vector
+		var metadataJSON []byte
+
+		if err := rows.Scan(&doc.ID, &doc.Content, &embeddingVec, &metadataJSON, &doc.CreatedAt); err != nil {
+			return nil, "", err
+		}
+
+		doc.Embedding = embeddingVec.Slice()
+		json.Unmarshal(metadataJSON, &doc.Metadata)
+		documents = append(documents, doc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(documents) > limit {
+		documents = documents[:limit]
+		next = documents[limit-1].ID
+	}
+	return documents, next, nil
+}
+
+// List pages through the documents of the collection that match filter,
+// ordered by created_at then ID
+func (pv *PgVector) List(ctx context.Context, filter map[string]interface{}, opts pagination.ListOptions) (pagination.Page[vectordb.Document], error) {
+	ctx, cancel := pv.withTimeout(ctx)
+	defer cancel()
+
+	opts, cursor, err := opts.Prepare()
+	if err != nil {
+		return pagination.Page[vectordb.Document]{}, err
+	}
+	opts.Since, opts.Until = time.Time{}, time.Time{}
+
+	b := pagination.NewSQLBuilder(pagination.Dollar)
+	b.Where("collection = ?", pv.collectionName)
+	if len(filter) > 0 {
+		var filterArgs []interface{}
+		condition, err := filterSQL(filter, func(value interface{}) string {
+			filterArgs = append(filterArgs, value)
+			return "?"
+		})
+		if err != nil {
+			return pagination.Page[vectordb.Document]{}, err
+		}
+		b.Where(condition, filterArgs...)
+	}
+	query := fmt.Sprintf(`SELECT id, content, embedding, metadata, created_at FROM %s`, pv.tableName) +
+		b.Clause(opts, cursor, "created_at", "id")
+
+	rows, err := pv.db.QueryContext(ctx, query, b.Args()...)
+	if err != nil {
+		return pagination.Page[vectordb.Document]{}, err
+	}
+	defer rows.Close()
+
+	var documents []vectordb.Document
+	for rows.Next() {
+		var doc vectordb.Document
+		var embeddingVec pgvector.Vector
+		var metadataJSON []byte
+
+		if err := rows.Scan(&doc.ID, &doc.Content, &embeddingVec, &metadataJSON, &doc.CreatedAt); err != nil {
+			return pagination.Page[vectordb.Document]{}, err
+		}
+
+		doc.Embedding = embeddingVec.Slice()
+		json.Unmarshal(metadataJSON, &doc.Metadata)
+		documents = append(documents, doc)
+	}
+	if err := rows.Err(); err != nil {
+		return pagination.Page[vectordb.Document]{}, err
+	}
+	return pagination.Trim(documents, opts.Limit, func(d vectordb.Document) (time.Time, string) {
+		return d.CreatedAt, d.ID
+	}), nil
+}
+
 // Count returns the number of documents in the collection
 func (pv *PgVector) Count(ctx context.Context) (int, error) {
+	ctx, cancel := pv.withTimeout(ctx)
+	defer cancel()
+
 	var count int
 	query := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE collection = $1`, pv.tableName)
 	err := pv.db.QueryRowContext(ctx, query, pv.collectionName).Scan(&count)
 	return count, err
 }
 
+// ListCollections returns the collections that hold documents, sorted.
+// Collections exist only through their documents in the shared table.
+func (pv *PgVector) ListCollections(ctx context.Context) ([]string, error) {
+	ctx, cancel := pv.withTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`SELECT DISTINCT collection FROM %s ORDER BY collection`, pv.tableName)
+	rows, err := pv.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// CollectionStats describes a collection ("" for the active one). DiskSize
+// is the stored size of its rows; the shared table's indexes are not
+// included. A collection without documents is only found when it is active.
+func (pv *PgVector) CollectionStats(ctx context.Context, name string) (vectordb.CollectionStats, error) {
+	ctx, cancel := pv.withTimeout(ctx)
+	defer cancel()
+
+	if name == "" {
+		name = pv.collectionName
+	}
+
+	stats := vectordb.CollectionStats{
+		Name:             name,
+		Dimension:        pv.dimension,
+		DistanceFunction: vectordb.Cosine,
+		IndexType:        pv.indexType,
+	}
+	query := fmt.Sprintf(`
+		SELECT COUNT(*), COALESCE(SUM(pg_column_size(t.*)), 0)
+		FROM %s AS t WHERE collection = $1
+	`, pv.tableName)
+	err := pv.db.QueryRowContext(ctx, query, name).Scan(&stats.Count, &stats.DiskSize)
+	if err != nil {
+		return vectordb.CollectionStats{}, fmt.Errorf("failed to get collection stats: %w", err)
+	}
+	if stats.Count == 0 && name != pv.collectionName {
+		return vectordb.CollectionStats{}, fmt.Errorf("%w: %s", vectordb.ErrCollectionNotFound, name)
+	}
+	return stats, nil
+}
+
+// Reindex rebuilds the indexes of the table, e.g. after a bulk load degraded
+// an IVFFlat index. It covers every collection in the table and blocks writes
+// while it runs.
+func (pv *PgVector) Reindex(ctx context.Context) error {
+	ctx, cancel := pv.withTimeout(ctx)
+	defer cancel()
+
+	_, err := pv.db.ExecContext(ctx, fmt.Sprintf(`REINDEX TABLE %s`, pv.tableName))
+	if err != nil {
+		return fmt.Errorf("failed to reindex: %w", err)
+	}
+	return nil
+}
+
+// Vacuum reclaims the space of deleted and updated rows and refreshes the
+// planner statistics of the table
+func (pv *PgVector) Vacuum(ctx context.Context) error {
+	ctx, cancel := pv.withTimeout(ctx)
+	defer cancel()
+
+	_, err := pv.db.ExecContext(ctx, fmt.Sprintf(`VACUUM ANALYZE %s`, pv.tableName))
+	if err != nil {
+		return fmt.Errorf("failed to vacuum: %w", err)
+	}
+	return nil
+}
+
 // Delete deletes documents by IDs
 func (pv *PgVector) Delete(ctx context.Context, ids []string) error {
+	ctx, cancel := pv.withTimeout(ctx)
+	defer cancel()
+
 	if len(ids) == 0 {
 		return nil
 	}
@@ -384,9 +1057,128 @@ func (pv *PgVector) Delete(ctx context.Context, ids []string) error {
 	return err
 }
 
-// Close closes the database connection
+// DeleteByFilter deletes the documents of the collection whose metadata
+// matches filter
+func (pv *PgVector) DeleteByFilter(ctx context.Context, filter map[string]interface{}) error {
+	ctx, cancel := pv.withTimeout(ctx)
+	defer cancel()
+
+	if err := vectordb.CheckDeleteFilter(filter); err != nil {
+		return err
+	}
+
+	args := []interface{}{pv.collectionName}
+	condition, err := filterSQL(filter, dollarArgs(&args))
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf(`DELETE FROM %s WHERE collection = $1 AND %s`, pv.tableName, condition)
+
+	_, err = pv.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// filterSQL translates a metadata filter (see vectordb.Filter) to a condition
+// on the JSONB metadata column. arg adds a query argument and returns its
+// placeholder. Values are compared as JSON, so equality can use the GIN index
+// through @>, and range comparisons only match values of the operand's type.
+func filterSQL(filter map[string]interface{}, arg func(value interface{}) string) (string, error) {
+	f, err := vectordb.ParseFilter(filter)
+	if err != nil {
+		return "", err
+	}
+	return filterCondition(f, arg)
+}
+
+func filterCondition(f vectordb.Filter, arg func(value interface{}) string) (string, error) {
+	switch f.Op {
+	case vectordb.OpAnd, vectordb.OpOr:
+		if len(f.Filters) == 0 {
+			// An empty And matches everything, an empty Or nothing
+			if f.Op == vectordb.OpAnd {
+				return "TRUE", nil
+			}
+			return "FALSE", nil
+		}
+		parts := make([]string, len(f.Filters))
+		for i, sub := range f.Filters {
+			part, err := filterCondition(sub, arg)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = part
+		}
+		return "(" + strings.Join(parts, " "+strings.ToUpper(string(f.Op))+" ") + ")", nil
+	}
+
+	// Validate key to prevent SQL injection
+	if !isValidMetadataKey(f.Key) {
+		return "", fmt.Errorf("invalid metadata key: %s (must be alphanumeric with underscores)", f.Key)
+	}
+	field := fmt.Sprintf("metadata->'%s'", f.Key)
+	jsonArg := func(value interface{}) (string, error) {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid value for metadata key %s: %w", f.Key, err)
+		}
+		return arg(string(data)) + "::jsonb", nil
+	}
+
+	switch f.Op {
+	case vectordb.OpEq:
+		contained, err := jsonArg(map[string]interface{}{f.Key: f.Value})
+		return "metadata @> " + contained, err
+	case vectordb.OpNe:
+		value, err := jsonArg(f.Value)
+		return field + " <> " + value, err
+	case vectordb.OpIn:
+		values := f.Values()
+		if len(values) == 0 {
+			return "FALSE", nil
+		}
+		placeholders := make([]string, len(values))
+		for i, v := range values {
+			placeholder, err := jsonArg(v)
+			if err != nil {
+				return "", err
+			}
+			placeholders[i] = placeholder
+		}
+		return field + " IN (" + strings.Join(placeholders, ", ") + ")", nil
+	}
+
+	sym, ok := map[vectordb.FilterOp]string{vectordb.OpGt: ">", vectordb.OpGte: ">=", vectordb.OpLt: "<", vectordb.OpLte: "<="}[f.Op]
+	if !ok {
+		return "", fmt.Errorf("unsupported filter operator: %s", f.Op)
+	}
+	typeOperand, err := jsonArg(f.Value)
+	if err != nil {
+		return "", err
+	}
+	operand, _ := jsonArg(f.Value)
+	return fmt.Sprintf("(jsonb_typeof(%s) = jsonb_typeof(%s) AND %s %s %s)", field, typeOperand, field, sym, operand), nil
+}
+
+// dollarArgs returns an arg function for filterSQL that appends to args and
+// numbers placeholders after them
+func dollarArgs(args *[]interface{}) func(value interface{}) string {
+	return func(value interface{}) string {
+		*args = append(*args, value)
+		return fmt.Sprintf("$%d", len(*args))
+	}
+}
+
+// Close releases vector store resources. Config.DB and Config.Pool are owned
+// by the caller and left open; a pool opened from Config.DSN is closed.
 func (pv *PgVector) Close() error {
-	return pv.db.Close()
+	if pv.pool == nil {
+		return nil
+	}
+	err := pv.db.Close() // Releases the pool connections held by database/sql
+	if pv.ownsPool {
+		pv.pool.Close()
+	}
+	return err
 }
 
 // isValidMetadataKey validates that a metadata key is safe for SQL queries
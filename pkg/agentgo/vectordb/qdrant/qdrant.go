@@ -0,0 +1,706 @@
+// Package qdrant implements vectordb.VectorDB on top of the Qdrant REST API.
+//
+// Documents are stored as points whose payload holds the original document
+// ID, content, metadata and creation time. Qdrant only accepts unsigned
+// integers or UUIDs as point IDs, so document IDs are mapped to deterministic
+// UUIDs (SHA-1 based) and the original ID is kept in the payload.
+package qdrant
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+)
+
+const (
+	payloadID        = "_id"
+	payloadContent   = "content"
+	payloadMetadata  = "metadata"
+	payloadCreatedAt = "created_at"
+)
+
+var _ vectordb.VectorDB = (*Qdrant)(nil)
+
+// pointNamespace seeds the UUIDs derived from document IDs
+var pointNamespace = uuid.MustParse("6f1c1e52-4a1b-4f6e-9d0a-3c1b8d7e2f10")
+
+// HNSWConfig configures the HNSW index of a collection. Zero values use the
+// Qdrant server defaults.
+type HNSWConfig struct {
+	M                 int  `json:"m,omitempty"`                   // Edges per node
+	EfConstruct       int  `json:"ef_construct,omitempty"`        // Neighbours considered while building
+	FullScanThreshold int  `json:"full_scan_threshold,omitempty"` // KB below which a full scan is used
+	OnDisk            bool `json:"on_disk,omitempty"`             // Store the index on disk
+}
+
+// Config holds Qdrant configuration
+type Config struct {
+	// URL of the Qdrant REST endpoint (default: "http://localhost:6333")
+	URL string
+	// APIKey is sent as the "api-key" header when set
+	APIKey string
+	// CollectionName is the collection to operate on (required)
+	CollectionName string
+	// Dimension of the stored vectors, required to create collections
+	Dimension int
+	// DistanceFunction defaults to cosine
+	DistanceFunction vectordb.DistanceFunction
+	// HNSW index parameters used when creating collections
+	HNSW *HNSWConfig
+	// SearchEf sets hnsw_ef at query time (0 = server default)
+	SearchEf int
+	// EmbeddingFunction embeds text queries and documents without embeddings
+	EmbeddingFunction vectordb.EmbeddingFunction
+	// HTTPClient is used for all requests (default: client with Timeout). It is
+	// never closed by Qdrant.
+	HTTPClient *http.Client
+	// Timeout for the default HTTP client (default: 30s)
+	Timeout time.Duration
+}
+
+// Qdrant implements vectordb.VectorDB using Qdrant
+type Qdrant struct {
+	baseURL    string
+	apiKey     string
+	collection string
+	dimension  int
+	distance   vectordb.DistanceFunction
+	hnsw       *HNSWConfig
+	searchEf   int
+	embedder   vectordb.EmbeddingFunction
+	client     *http.Client
+}
+
+// New creates a new Qdrant instance
+func New(config Config) (*Qdrant, error) {
+	if config.CollectionName == "" {
+		return nil, fmt.Errorf("collection name is required")
+	}
+	if config.URL == "" {
+		config.URL = "http://localhost:6333"
+	}
+	if _, err := url.Parse(config.URL); err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if config.DistanceFunction == "" {
+		config.DistanceFunction = vectordb.Cosine
+	}
+	if _, err := qdrantDistance(config.DistanceFunction); err != nil {
+		return nil, err
+	}
+	if config.Dimension < 0 {
+		return nil, fmt.Errorf("dimension must not be negative")
+	}
+	if config.HTTPClient == nil {
+		if config.Timeout <= 0 {
+			config.Timeout = 30 * time.Second
+		}
+		config.HTTPClient = &http.Client{Timeout: config.Timeout}
+	}
+
+	return &Qdrant{
+		baseURL:    strings.TrimRight(config.URL, "/"),
+		apiKey:     config.APIKey,
+		collection: config.CollectionName,
+		dimension:  config.Dimension,
+		distance:   config.DistanceFunction,
+		hnsw:       config.HNSW,
+		searchEf:   config.SearchEf,
+		embedder:   config.EmbeddingFunction,
+		client:     config.HTTPClient,
+	}, nil
+}
+
+// CreateCollection creates the collection if it does not exist. An empty name
+// uses the configured collection. metadata may override "dimension".
+func (q *Qdrant) CreateCollection(ctx context.Context, name string, metadata map[string]interface{}) error {
+	if name != "" {
+		q.collection = name
+	}
+
+	exists, err := q.collectionExists(ctx)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	dimension := q.dimension
+	if d, ok := metadata["dimension"].(int); ok && d > 0 {
+		dimension = d
+	}
+	if dimension <= 0 {
+		return fmt.Errorf("dimension is required to create collection %s", q.collection)
+	}
+
+	distance, _ := qdrantDistance(q.distance)
+	body := map[string]interface{}{
+		"vectors": map[string]interface{}{"size": dimension, "distance": distance},
+	}
+	if q.hnsw != nil {
+		body["hnsw_config"] = q.hnsw
+	}
+	if err := q.do(ctx, http.MethodPut, q.collectionPath(""), body, nil); err != nil {
+		return fmt.Errorf("failed to create collection: %w", err)
+	}
+	q.dimension = dimension
+	return nil
+}
+
+// DeleteCollection deletes a collection. An empty name uses the configured collection.
+func (q *Qdrant) DeleteCollection(ctx context.Context, name string) error {
+	if name == "" {
+		name = q.collection
+	}
+	if err := q.do(ctx, http.MethodDelete, "/collections/"+url.PathEscape(name), nil, nil); err != nil {
+		return fmt.Errorf("failed to delete collection: %w", err)
+	}
+	return nil
+}
+
+// ListCollections returns the names of all collections, sorted
+func (q *Qdrant) ListCollections(ctx context.Context) ([]string, error) {
+	var resp struct {
+		Result struct {
+			Collections []struct {
+				Name string `json:"name"`
+			} `json:"collections"`
+		} `json:"result"`
+	}
+	if err := q.do(ctx, http.MethodGet, "/collections", nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+	names := make([]string, len(resp.Result.Collections))
+	for i, c := range resp.Result.Collections {
+		names[i] = c.Name
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// CollectionStats describes a collection. An empty name uses the configured
+// collection. Count is Qdrant's approximate points count; DiskSize is -1, as
+// the collection info API does not report it.
+func (q *Qdrant) CollectionStats(ctx context.Context, name string) (vectordb.CollectionStats, error) {
+	if name == "" {
+		name = q.collection
+	}
+	var resp struct {
+		Result struct {
+			PointsCount int `json:"points_count"`
+			Config      struct {
+				Params struct {
+					Vectors struct {
+						Size     int    `json:"size"`
+						Distance string `json:"distance"`
+					} `json:"vectors"`
+				} `json:"params"`
+			} `json:"config"`
+		} `json:"result"`
+	}
+	err := q.do(ctx, http.MethodGet, "/collections/"+url.PathEscape(name), nil, &resp)
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.status == http.StatusNotFound {
+		return vectordb.CollectionStats{}, fmt.Errorf("%w: %s", vectordb.ErrCollectionNotFound, name)
+	}
+	if err != nil {
+		return vectordb.CollectionStats{}, fmt.Errorf("failed to get collection: %w", err)
+	}
+
+	vectors := resp.Result.Config.Params.Vectors
+	return vectordb.CollectionStats{
+		Name:             name,
+		Count:            resp.Result.PointsCount,
+		Dimension:        vectors.Size,
+		DistanceFunction: distanceFromQdrant(vectors.Distance),
+		IndexType:        "hnsw",
+		DiskSize:         -1,
+	}, nil
+}
+
+// CreatePayloadIndex indexes a metadata field to speed up filtered search.
+// schema is a Qdrant field schema such as "keyword", "integer", "float", "bool" or "datetime".
+func (q *Qdrant) CreatePayloadIndex(ctx context.Context, field, schema string) error {
+	body := map[string]interface{}{
+		"field_name":   payloadMetadata + "." + field,
+		"field_schema": schema,
+	}
+	if err := q.do(ctx, http.MethodPut, q.collectionPath("/index?wait=true"), body, nil); err != nil {
+		return fmt.Errorf("failed to create payload index: %w", err)
+	}
+	return nil
+}
+
+// Add adds documents to the collection. Existing documents with the same ID are replaced.
+func (q *Qdrant) Add(ctx context.Context, documents []vectordb.Document) error {
+	return q.upsert(ctx, documents)
+}
+
+// Update updates existing documents in the collection
+func (q *Qdrant) Update(ctx context.Context, documents []vectordb.Document) error {
+	return q.upsert(ctx, documents)
+}
+
+func (q *Qdrant) upsert(ctx context.Context, documents []vectordb.Document) error {
+	if len(documents) == 0 {
+		return nil
+	}
+
+	// Copy so generated embeddings are not written into the caller's slice.
+	documents = append([]vectordb.Document(nil), documents...)
+	if err := q.fillEmbeddings(ctx, documents); err != nil {
+		return err
+	}
+
+	points := make([]map[string]interface{}, len(documents))
+	for i, doc := range documents {
+		if doc.ID == "" {
+			return fmt.Errorf("document %d has no ID", i)
+		}
+		if len(doc.Embedding) == 0 {
+			return fmt.Errorf("document %s has no embedding and no embedding function is configured", doc.ID)
+		}
+		createdAt := doc.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = time.Now()
+		}
+		points[i] = map[string]interface{}{
+			"id":     pointID(doc.ID),
+			"vector": doc.Embedding,
+			"payload": map[string]interface{}{
+				payloadID:        doc.ID,
+				payloadContent:   doc.Content,
+				payloadMetadata:  doc.Metadata,
+				payloadCreatedAt: createdAt.UTC().Format(time.RFC3339Nano),
+			},
+		}
+	}
+
+	body := map[string]interface{}{"points": points}
+	if err := q.do(ctx, http.MethodPut, q.collectionPath("/points?wait=true"), body, nil); err != nil {
+		return fmt.Errorf("failed to upsert documents: %w", err)
+	}
+	return nil
+}
+
+// fillEmbeddings embeds the content of documents that have no embedding
+func (q *Qdrant) fillEmbeddings(ctx context.Context, documents []vectordb.Document) error {
+	if q.embedder == nil {
+		return nil
+	}
+
+	var idx []int
+	var contents []string
+	for i, doc := range documents {
+		if len(doc.Embedding) == 0 {
+			idx = append(idx, i)
+			contents = append(contents, doc.Content)
+		}
+	}
+	if len(idx) == 0 {
+		return nil
+	}
+
+	embeddings, err := q.embedder.Embed(ctx, contents)
+	if err != nil {
+		return fmt.Errorf("failed to generate embeddings: %w", err)
+	}
+	if len(embeddings) != len(idx) {
+		return fmt.Errorf("embedding function returned %d embeddings for %d documents", len(embeddings), len(idx))
+	}
+	for j, i := range idx {
+		documents[i].Embedding = embeddings[j]
+	}
+	return nil
+}
+
+// Delete deletes documents from the collection by IDs
+func (q *Qdrant) Delete(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	body := map[string]interface{}{"points": pointIDs(ids)}
+	if err := q.do(ctx, http.MethodPost, q.collectionPath("/points/delete?wait=true"), body, nil); err != nil {
+		return fmt.Errorf("failed to delete documents: %w", err)
+	}
+	return nil
+}
+
+// DeleteByFilter deletes the documents whose metadata matches filter. See
+// BuildFilter for the supported filter forms.
+func (q *Qdrant) DeleteByFilter(ctx context.Context, filter map[string]interface{}) error {
+	if err := vectordb.CheckDeleteFilter(filter); err != nil {
+		return err
+	}
+	f, err := BuildFilter(filter)
+	if err != nil {
+		return err
+	}
+	if f == nil {
+		return fmt.Errorf("filter is required")
+	}
+	body := map[string]interface{}{"filter": f}
+	if err := q.do(ctx, http.MethodPost, q.collectionPath("/points/delete?wait=true"), body, nil); err != nil {
+		return fmt.Errorf("failed to delete documents: %w", err)
+	}
+	return nil
+}
+
+// Query searches using a text query (requires an embedding function)
+func (q *Qdrant) Query(ctx context.Context, query string, limit int, filter map[string]interface{}) ([]vectordb.SearchResult, error) {
+	if q.embedder == nil {
+		return nil, fmt.Errorf("embedding function is required for text queries")
+	}
+	embedding, err := q.embedder.EmbedSingle(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+	return q.QueryWithEmbedding(ctx, embedding, limit, filter)
+}
+
+// QueryWithEmbedding searches using a pre-computed embedding. See BuildFilter
+// for the supported filter forms.
+func (q *Qdrant) QueryWithEmbedding(ctx context.Context, embedding []float32, limit int, filter map[string]interface{}) ([]vectordb.SearchResult, error) {
+	if len(embedding) == 0 {
+		return nil, fmt.Errorf("embedding is required")
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	body := map[string]interface{}{
+		"vector":       embedding,
+		"limit":        limit,
+		"with_payload": true,
+	}
+	f, err := BuildFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	if f != nil {
+		body["filter"] = f
+	}
+	if q.searchEf > 0 {
+		body["params"] = map[string]interface{}{"hnsw_ef": q.searchEf}
+	}
+
+	var resp struct {
+		Result []struct {
+			Score   float32                `json:"score"`
+			Payload map[string]interface{} `json:"payload"`
+		} `json:"result"`
+	}
+	if err := q.do(ctx, http.MethodPost, q.collectionPath("/points/search"), body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+
+	results := make([]vectordb.SearchResult, len(resp.Result))
+	for i, hit := range resp.Result {
+		doc := documentFromPayload(hit.Payload, nil)
+		score, distance := q.scoreAndDistance(hit.Score)
+		results[i] = vectordb.SearchResult{
+			ID:       doc.ID,
+			Content:  doc.Content,
+			Metadata: doc.Metadata,
+			Score:    score,
+			Distance: distance,
+		}
+	}
+	return results, nil
+}
+
+// Get retrieves documents by IDs
+func (q *Qdrant) Get(ctx context.Context, ids []string) ([]vectordb.Document, error) {
+	if len(ids) == 0 {
+		return []vectordb.Document{}, nil
+	}
+
+	body := map[string]interface{}{
+		"ids":          pointIDs(ids),
+		"with_payload": true,
+		"with_vector":  true,
+	}
+	var resp struct {
+		Result []struct {
+			Payload map[string]interface{} `json:"payload"`
+			Vector  []float32              `json:"vector"`
+		} `json:"result"`
+	}
+	if err := q.do(ctx, http.MethodPost, q.collectionPath("/points"), body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get documents: %w", err)
+	}
+
+	docs := make([]vectordb.Document, len(resp.Result))
+	for i, p := range resp.Result {
+		docs[i] = documentFromPayload(p.Payload, p.Vector)
+	}
+	return docs, nil
+}
+
+// Scan pages through the collection with the scroll API; the cursor is the
+// offset Qdrant returned for the next page
+func (q *Qdrant) Scan(ctx context.Context, cursor string, limit int) ([]vectordb.Document, string, error) {
+	if limit <= 0 {
+		return nil, "", fmt.Errorf("limit must be positive")
+	}
+
+	body := map[string]interface{}{
+		"limit":        limit,
+		"with_payload": true,
+		"with_vector":  true,
+	}
+	if cursor != "" {
+		body["offset"] = cursor
+	}
+	var resp struct {
+		Result struct {
+			Points []struct {
+				Payload map[string]interface{} `json:"payload"`
+				Vector  []float32              `json:"vector"`
+			} `json:"points"`
+			NextPageOffset interface{} `json:"next_page_offset"`
+		} `json:"result"`
+	}
+	if err := q.do(ctx, http.MethodPost, q.collectionPath("/points/scroll"), body, &resp); err != nil {
+		return nil, "", fmt.Errorf("failed to scroll documents: %w", err)
+	}
+
+	docs := make([]vectordb.Document, len(resp.Result.Points))
+	for i, p := range resp.Result.Points {
+		docs[i] = documentFromPayload(p.Payload, p.Vector)
+	}
+	next := ""
+	if offset := resp.Result.NextPageOffset; offset != nil {
+		next = fmt.Sprint(offset)
+	}
+	return docs, next, nil
+}
+
+// Count returns the number of documents in the collection
+func (q *Qdrant) Count(ctx context.Context) (int, error) {
+	var resp struct {
+		Result struct {
+			Count int `json:"count"`
+		} `json:"result"`
+	}
+	body := map[string]interface{}{"exact": true}
+	if err := q.do(ctx, http.MethodPost, q.collectionPath("/points/count"), body, &resp); err != nil {
+		return 0, fmt.Errorf("failed to count documents: %w", err)
+	}
+	return resp.Result.Count, nil
+}
+
+// Close releases nothing: the HTTP client may be shared with the application
+func (q *Qdrant) Close() error {
+	return nil
+}
+
+// BuildFilter converts a vectordb filter into a Qdrant filter.
+//
+// A filter containing "must", "should" or "must_not" is passed through as a
+// native Qdrant filter. Otherwise it is a vectordb.Filter in map form:
+//   - scalar values match exactly
+//   - slices match any of their values
+//   - maps with gt/gte/lt/lte keys become range conditions, ne a must_not
+//   - "$and" and "$or" become nested must and should filters
+func BuildFilter(filter map[string]interface{}) (map[string]interface{}, error) {
+	if len(filter) == 0 {
+		return nil, nil
+	}
+	for _, key := range []string{"must", "should", "must_not"} {
+		if _, ok := filter[key]; ok {
+			return filter, nil
+		}
+	}
+
+	f, err := vectordb.ParseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	if f.Op != vectordb.OpAnd && f.Op != vectordb.OpOr {
+		f = vectordb.And(f)
+	}
+	return condition(f)
+}
+
+// condition converts one filter clause into a Qdrant condition. And and Or
+// become nested filters, which Qdrant accepts wherever a condition is expected.
+func condition(f vectordb.Filter) (map[string]interface{}, error) {
+	switch f.Op {
+	case vectordb.OpAnd, vectordb.OpOr:
+		if f.Op == vectordb.OpOr && len(f.Filters) == 0 {
+			return nil, fmt.Errorf("qdrant filters cannot contain an empty $or")
+		}
+		conds := make([]map[string]interface{}, len(f.Filters))
+		for i, sub := range f.Filters {
+			cond, err := condition(sub)
+			if err != nil {
+				return nil, err
+			}
+			conds[i] = cond
+		}
+		if f.Op == vectordb.OpOr {
+			return map[string]interface{}{"should": conds}, nil
+		}
+		return map[string]interface{}{"must": conds}, nil
+	}
+
+	key := payloadMetadata + "." + f.Key
+	switch f.Op {
+	case vectordb.OpEq:
+		return map[string]interface{}{"key": key, "match": map[string]interface{}{"value": f.Value}}, nil
+	case vectordb.OpNe:
+		// Also exclude points without the field, which Ne does not match
+		return map[string]interface{}{"must_not": []map[string]interface{}{
+			{"is_empty": map[string]interface{}{"key": key}},
+			{"key": key, "match": map[string]interface{}{"value": f.Value}},
+		}}, nil
+	case vectordb.OpIn:
+		return map[string]interface{}{"key": key, "match": map[string]interface{}{"any": f.Values()}}, nil
+	}
+	return map[string]interface{}{"key": key, "range": map[string]interface{}{string(f.Op): f.Value}}, nil
+}
+
+func (q *Qdrant) collectionExists(ctx context.Context) (bool, error) {
+	err := q.do(ctx, http.MethodGet, q.collectionPath(""), nil, nil)
+	if err == nil {
+		return true, nil
+	}
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.status == http.StatusNotFound {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to get collection: %w", err)
+}
+
+func (q *Qdrant) collectionPath(suffix string) string {
+	return "/collections/" + url.PathEscape(q.collection) + suffix
+}
+
+// scoreAndDistance maps a Qdrant score to the vectordb convention. Qdrant
+// returns similarity for cosine and dot product and distance for Euclid.
+func (q *Qdrant) scoreAndDistance(raw float32) (score, distance float32) {
+	switch q.distance {
+	case vectordb.L2:
+		return 1 / (1 + raw), raw
+	case vectordb.InnerProduct:
+		return raw, -raw
+	default:
+		return raw, 1 - raw
+	}
+}
+
+type apiError struct {
+	status int
+	body   string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("qdrant returned status %d: %s", e.status, e.body)
+}
+
+// do sends a JSON request and decodes the response into out when non-nil
+func (q *Qdrant) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, q.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if q.apiKey != "" {
+		req.Header.Set("api-key", q.apiKey)
+	}
+
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &apiError{status: resp.StatusCode, body: strings.TrimSpace(string(data))}
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+func qdrantDistance(d vectordb.DistanceFunction) (string, error) {
+	switch d {
+	case vectordb.Cosine:
+		return "Cosine", nil
+	case vectordb.L2:
+		return "Euclid", nil
+	case vectordb.InnerProduct:
+		return "Dot", nil
+	default:
+		return "", fmt.Errorf("unsupported distance function %q", d)
+	}
+}
+
+// distanceFromQdrant is the inverse of qdrantDistance; it returns "" for
+// distances this package does not use
+func distanceFromQdrant(d string) vectordb.DistanceFunction {
+	switch d {
+	case "Cosine":
+		return vectordb.Cosine
+	case "Euclid":
+		return vectordb.L2
+	case "Dot":
+		return vectordb.InnerProduct
+	default:
+		return ""
+	}
+}
+
+func pointID(id string) string {
+	return uuid.NewSHA1(pointNamespace, []byte(id)).String()
+}
+
+func pointIDs(ids []string) []string {
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = pointID(id)
+	}
+	return out
+}
+
+func documentFromPayload(payload map[string]interface{}, vector []float32) vectordb.Document {
+	doc := vectordb.Document{Embedding: vector}
+	doc.ID, _ = payload[payloadID].(string)
+	doc.Content, _ = payload[payloadContent].(string)
+	doc.Metadata, _ = payload[payloadMetadata].(map[string]interface{})
+	if ts, ok := payload[payloadCreatedAt].(string); ok {
+		doc.CreatedAt, _ = time.Parse(time.RFC3339Nano, ts)
+	}
+	return doc
+}
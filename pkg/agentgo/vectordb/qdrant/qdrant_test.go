@@ -0,0 +1,390 @@
+package qdrant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+)
+
+// fakeQdrant is a minimal in-memory stand-in for the Qdrant REST API.
+type fakeQdrant struct {
+	mu          sync.Mutex
+	collections map[string]map[string]interface{}
+	points      map[string]map[string]interface{}
+	lastSearch  map[string]interface{}
+	lastDelete  map[string]interface{}
+	apiKey      string
+}
+
+func newFakeQdrant(t *testing.T) (*fakeQdrant, *httptest.Server) {
+	f := &fakeQdrant{
+		collections: map[string]map[string]interface{}{},
+		points:      map[string]map[string]interface{}{},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeQdrant) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.apiKey = r.Header.Get("api-key")
+
+	var body map[string]interface{}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	reply := func(result interface{}) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"result": result, "status": "ok"})
+	}
+	if len(parts) == 1 {
+		var collections []interface{}
+		for name := range f.collections {
+			collections = append(collections, map[string]interface{}{"name": name})
+		}
+		reply(map[string]interface{}{"collections": collections})
+		return
+	}
+	name := parts[1]
+	action := strings.Join(parts[2:], "/")
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		if _, ok := f.collections[name]; !ok {
+			http.Error(w, `{"status":{"error":"Not found"}}`, http.StatusNotFound)
+			return
+		}
+		reply(map[string]interface{}{"points_count": len(f.points), "config": map[string]interface{}{"params": f.collections[name]}})
+	case action == "" && r.Method == http.MethodPut:
+		f.collections[name] = body
+		reply(true)
+	case action == "" && r.Method == http.MethodDelete:
+		delete(f.collections, name)
+		reply(true)
+	case action == "points" && r.Method == http.MethodPut:
+		for _, p := range body["points"].([]interface{}) {
+			point := p.(map[string]interface{})
+			f.points[point["id"].(string)] = point
+		}
+		reply(map[string]interface{}{"status": "completed"})
+	case action == "points" && r.Method == http.MethodPost:
+		var result []interface{}
+		for _, id := range body["ids"].([]interface{}) {
+			if p, ok := f.points[id.(string)]; ok {
+				result = append(result, p)
+			}
+		}
+		reply(result)
+	case action == "points/delete":
+		f.lastDelete = body
+		points, _ := body["points"].([]interface{})
+		for _, id := range points {
+			delete(f.points, id.(string))
+		}
+		reply(map[string]interface{}{"status": "completed"})
+	case action == "points/scroll":
+		ids := make([]string, 0, len(f.points))
+		for id := range f.points {
+			if offset, ok := body["offset"].(string); !ok || id >= offset {
+				ids = append(ids, id)
+			}
+		}
+		sort.Strings(ids)
+		var next interface{}
+		if limit := int(body["limit"].(float64)); len(ids) > limit {
+			next = ids[limit]
+			ids = ids[:limit]
+		}
+		points := make([]interface{}, len(ids))
+		for i, id := range ids {
+			points[i] = f.points[id]
+		}
+		reply(map[string]interface{}{"points": points, "next_page_offset": next})
+	case action == "points/count":
+		reply(map[string]interface{}{"count": len(f.points)})
+	case action == "points/search":
+		f.lastSearch = body
+		var result []interface{}
+		for _, p := range f.points {
+			result = append(result, map[string]interface{}{"id": p["id"], "score": 0.9, "payload": p["payload"]})
+		}
+		reply(result)
+	default:
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+	}
+}
+
+type fixedEmbedder struct{}
+
+func (fixedEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i := range texts {
+		out[i] = []float32{1, 0, 0}
+	}
+	return out, nil
+}
+
+func (fixedEmbedder) EmbedSingle(_ context.Context, _ string) ([]float32, error) {
+	return []float32{1, 0, 0}, nil
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Config{}); err == nil {
+		t.Error("expected error without collection name")
+	}
+	if _, err := New(Config{CollectionName: "c", DistanceFunction: "manhattan"}); err == nil {
+		t.Error("expected error for unsupported distance")
+	}
+}
+
+func TestQdrant_CollectionStats(t *testing.T) {
+	_, srv := newFakeQdrant(t)
+	ctx := context.Background()
+	db, _ := New(Config{URL: srv.URL, CollectionName: "docs", Dimension: 3, DistanceFunction: vectordb.L2})
+	for _, name := range []string{"docs", "archive"} {
+		if err := db.CreateCollection(ctx, name, nil); err != nil {
+			t.Fatalf("CreateCollection(%s) error = %v", name, err)
+		}
+	}
+	if err := db.Add(ctx, []vectordb.Document{{ID: "a", Embedding: []float32{1, 0, 0}}}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	names, err := db.ListCollections(ctx)
+	if err != nil || strings.Join(names, ",") != "archive,docs" {
+		t.Errorf("ListCollections() = %v, %v", names, err)
+	}
+
+	stats, err := db.CollectionStats(ctx, "docs")
+	want := vectordb.CollectionStats{Name: "docs", Count: 1, Dimension: 3, DistanceFunction: vectordb.L2, IndexType: "hnsw", DiskSize: -1}
+	if err != nil || stats != want {
+		t.Errorf("CollectionStats() = %+v, %v, want %+v", stats, err, want)
+	}
+	if _, err := db.CollectionStats(ctx, "missing"); !errors.Is(err, vectordb.ErrCollectionNotFound) {
+		t.Errorf("CollectionStats(missing) error = %v, want ErrCollectionNotFound", err)
+	}
+}
+
+func TestQdrant_CollectionLifecycle(t *testing.T) {
+	fake, srv := newFakeQdrant(t)
+	db, err := New(Config{
+		URL:            srv.URL,
+		APIKey:         "secret",
+		CollectionName: "docs",
+		Dimension:      3,
+		HNSW:           &HNSWConfig{M: 32, EfConstruct: 200},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := db.CreateCollection(ctx, "", nil); err != nil {
+		t.Fatalf("CreateCollection: %v", err)
+	}
+	created := fake.collections["docs"]
+	hnsw, _ := created["hnsw_config"].(map[string]interface{})
+	vectors, _ := created["vectors"].(map[string]interface{})
+	if hnsw["m"] != float64(32) || hnsw["ef_construct"] != float64(200) || vectors["distance"] != "Cosine" {
+		t.Errorf("unexpected collection config: %v", created)
+	}
+	if fake.apiKey != "secret" {
+		t.Errorf("api-key header = %q", fake.apiKey)
+	}
+
+	// Creating again is a no-op.
+	if err := db.CreateCollection(ctx, "", nil); err != nil {
+		t.Fatalf("CreateCollection (existing): %v", err)
+	}
+
+	if err := db.DeleteCollection(ctx, ""); err != nil {
+		t.Fatalf("DeleteCollection: %v", err)
+	}
+	if _, ok := fake.collections["docs"]; ok {
+		t.Error("collection should be deleted")
+	}
+}
+
+func TestQdrant_DocumentsAndSearch(t *testing.T) {
+	fake, srv := newFakeQdrant(t)
+	db, _ := New(Config{
+		URL:               srv.URL,
+		CollectionName:    "docs",
+		SearchEf:          128,
+		EmbeddingFunction: fixedEmbedder{},
+	})
+	ctx := context.Background()
+
+	docs := []vectordb.Document{
+		{ID: "doc-1", Content: "hello", Metadata: map[string]interface{}{"lang": "en"}},
+		{ID: "doc-2", Content: "olá", Embedding: []float32{0, 1, 0}},
+	}
+	if err := db.Add(ctx, docs); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	n, err := db.Count(ctx)
+	if err != nil || n != 2 {
+		t.Fatalf("Count = %d, %v", n, err)
+	}
+
+	got, err := db.Get(ctx, []string{"doc-1"})
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != "doc-1" || got[0].Content != "hello" || got[0].Metadata["lang"] != "en" || len(got[0].Embedding) != 3 {
+		t.Errorf("unexpected document: %+v", got)
+	}
+
+	results, err := db.Query(ctx, "hi", 5, map[string]interface{}{"lang": "en"})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(results) != 2 || results[0].Score != 0.9 {
+		t.Errorf("unexpected results: %+v", results)
+	}
+	if params, _ := fake.lastSearch["params"].(map[string]interface{}); params["hnsw_ef"] != float64(128) {
+		t.Errorf("hnsw_ef not sent: %v", fake.lastSearch)
+	}
+	if _, ok := fake.lastSearch["filter"]; !ok {
+		t.Error("filter not sent")
+	}
+
+	if err := db.Delete(ctx, []string{"doc-1", "doc-2"}); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if n, _ := db.Count(ctx); n != 0 {
+		t.Errorf("Count after delete = %d", n)
+	}
+}
+
+func TestQdrant_DeleteByFilter(t *testing.T) {
+	fake, srv := newFakeQdrant(t)
+	db, _ := New(Config{URL: srv.URL, CollectionName: "docs"})
+	ctx := context.Background()
+
+	if err := db.DeleteByFilter(ctx, nil); err == nil {
+		t.Error("expected error for an empty filter")
+	}
+	if err := db.DeleteByFilter(ctx, map[string]interface{}{"$and": []interface{}{}}); err == nil {
+		t.Error("expected error for an empty $and")
+	}
+	if fake.lastDelete != nil {
+		t.Errorf("no delete request should be sent, got %v", fake.lastDelete)
+	}
+	if err := db.DeleteByFilter(ctx, map[string]interface{}{"user_id": "alice"}); err != nil {
+		t.Fatalf("DeleteByFilter: %v", err)
+	}
+	filter, _ := fake.lastDelete["filter"].(map[string]interface{})
+	must, _ := filter["must"].([]interface{})
+	if len(must) != 1 || must[0].(map[string]interface{})["key"] != "metadata.user_id" {
+		t.Errorf("unexpected delete request: %v", fake.lastDelete)
+	}
+}
+
+func TestQdrant_Scan(t *testing.T) {
+	_, srv := newFakeQdrant(t)
+	db, _ := New(Config{URL: srv.URL, CollectionName: "docs", EmbeddingFunction: fixedEmbedder{}})
+	ctx := context.Background()
+
+	if err := db.Add(ctx, []vectordb.Document{{ID: "a", Content: "1"}, {ID: "b", Content: "2"}, {ID: "c", Content: "3"}}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	seen := map[string]bool{}
+	cursor, pages := "", 0
+	for {
+		docs, next, err := db.Scan(ctx, cursor, 2)
+		if err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+		pages++
+		for _, doc := range docs {
+			if seen[doc.ID] || len(doc.Embedding) != 3 {
+				t.Errorf("unexpected document: %+v", doc)
+			}
+			seen[doc.ID] = true
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if len(seen) != 3 || pages != 2 {
+		t.Errorf("scanned %v in %d pages", seen, pages)
+	}
+}
+
+func TestBuildFilter(t *testing.T) {
+	if got, err := BuildFilter(nil); got != nil || err != nil {
+		t.Error("empty filter should be nil")
+	}
+
+	native := map[string]interface{}{"must_not": []interface{}{}}
+	if got, _ := BuildFilter(native); got["must_not"] == nil {
+		t.Error("native filter should pass through")
+	}
+
+	got, err := BuildFilter(map[string]interface{}{
+		"tags":  []string{"a", "b"},
+		"year":  map[string]interface{}{"gte": 2020},
+		"topic": "go",
+	})
+	if err != nil {
+		t.Fatalf("BuildFilter() error = %v", err)
+	}
+	must := got["must"].([]map[string]interface{})
+	if len(must) != 3 {
+		t.Fatalf("expected 3 conditions, got %v", must)
+	}
+	byKey := map[string]map[string]interface{}{}
+	for _, c := range must {
+		byKey[c["key"].(string)] = c
+	}
+	if _, ok := byKey["metadata.tags"]["match"].(map[string]interface{})["any"]; !ok {
+		t.Error("slice should become match any")
+	}
+	if _, ok := byKey["metadata.year"]["range"]; !ok {
+		t.Error("map should become range")
+	}
+	if byKey["metadata.topic"]["match"].(map[string]interface{})["value"] != "go" {
+		t.Error("scalar should become match value")
+	}
+
+	if _, err := BuildFilter(map[string]interface{}{"year": map[string]interface{}{"between": 1}}); err == nil {
+		t.Error("expected error for an unknown operator")
+	}
+}
+
+func TestBuildFilter_Expression(t *testing.T) {
+	got, err := BuildFilter(vectordb.Or(
+		vectordb.Eq("user_id", "alice"),
+		vectordb.And(vectordb.Ne("status", "draft"), vectordb.Lt("score", 0.5)),
+	).Map())
+	if err != nil {
+		t.Fatalf("BuildFilter() error = %v", err)
+	}
+	data, _ := json.Marshal(got)
+	want := `{"should":[` +
+		`{"key":"metadata.user_id","match":{"value":"alice"}},` +
+		`{"must":[` +
+		`{"must_not":[{"is_empty":{"key":"metadata.status"}},{"key":"metadata.status","match":{"value":"draft"}}]},` +
+		`{"key":"metadata.score","range":{"lt":0.5}}]}]}`
+	if string(data) != want {
+		t.Errorf("BuildFilter =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestPointIDDeterministic(t *testing.T) {
+	if pointID("a") != pointID("a") || pointID("a") == pointID("b") {
+		t.Error("point IDs must be deterministic and distinct")
+	}
+}
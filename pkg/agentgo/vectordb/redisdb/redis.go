@@ -7,7 +7,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
 	"github.com/redis/go-redis/v9"
@@ -57,19 +60,142 @@ func New(cfg Config) (*RedisDB, error) {
 func (r *RedisDB) keyDoc(id string) string { return fmt.Sprintf("%s:%s:doc:%s", r.prefix, r.coll, id) }
 func (r *RedisDB) keyIdx() string          { return fmt.Sprintf("%s:%s:index", r.prefix, r.coll) }
 
-func (r *RedisDB) CreateCollection(ctx context.Context, name string, _ map[string]interface{}) error {
-	if name != "" {
-		r.coll = name
+// keyTag is the set of IDs of documents whose metadata key has value
+func (r *RedisDB) keyTag(key, value string) string {
+	return fmt.Sprintf("%s:%s:tag:%s:%s", r.prefix, r.coll, key, value)
+}
+
+// Metadata tags: Add keeps, for every scalar metadata value, a set of the
+// document IDs holding it. Eq, In and their And/Or combinations select
+// candidates from these sets; everything else, and collections created before
+// tags existed, fall back to a scan. Candidates are always re-checked with
+// vectordb.Filter.Match.
+const fieldTagged = "_tagged"
+
+// tagValue encodes a metadata value for a tag key. Only scalars are tagged.
+// Numbers use one encoding whatever their Go type, so 2020 and 2020.0 (read
+// back from JSON) share a tag, as they do for vectordb.Filter.Match.
+func tagValue(v interface{}) (string, bool) {
+	switch val := v.(type) {
+	case string:
+		return val, true
+	case bool:
+		return strconv.FormatBool(val), true
 	}
-	// Mark index key
-	return r.client.HSetNX(ctx, r.keyIdx(), "_created", "1").Err()
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatFloat(float64(rv.Int()), 'g', -1, 64), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatFloat(float64(rv.Uint()), 'g', -1, 64), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, 64), true
+	}
+	return "", false
 }
 
-func (r *RedisDB) DeleteCollection(ctx context.Context, name string) error {
-	if name != "" {
-		r.coll = name
+// tagKeys returns the tag keys of a document
+func (r *RedisDB) tagKeys(metadata map[string]interface{}) []string {
+	keys := make([]string, 0, len(metadata))
+	for key, value := range metadata {
+		if tag, ok := tagValue(value); ok {
+			keys = append(keys, r.keyTag(key, tag))
+		}
+	}
+	return keys
+}
+
+// tagged reports whether every document of the collection has its tags
+func (r *RedisDB) tagged(ctx context.Context) (bool, error) {
+	ok, err := r.client.HExists(ctx, r.keyIdx(), fieldTagged).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return false, err
+	}
+	return ok, nil
+}
+
+// candidates returns the IDs of the documents that may match f, using the
+// tag sets. ok is false when f cannot be narrowed down that way.
+func (r *RedisDB) candidates(ctx context.Context, f vectordb.Filter) (ids map[string]bool, ok bool, err error) {
+	switch f.Op {
+	case vectordb.OpEq, vectordb.OpIn:
+		values := []interface{}{f.Value}
+		if f.Op == vectordb.OpIn {
+			values = f.Values()
+		}
+		ids = make(map[string]bool)
+		for _, value := range values {
+			tag, ok := tagValue(value)
+			if !ok {
+				return nil, false, nil
+			}
+			members, err := r.client.SMembers(ctx, r.keyTag(f.Key, tag)).Result()
+			if err != nil {
+				return nil, false, err
+			}
+			for _, id := range members {
+				ids[id] = true
+			}
+		}
+		return ids, true, nil
+	case vectordb.OpAnd:
+		for _, sub := range f.Filters {
+			subIDs, subOK, err := r.candidates(ctx, sub)
+			if err != nil {
+				return nil, false, err
+			}
+			if !subOK {
+				continue
+			}
+			if !ok {
+				ids, ok = subIDs, true
+				continue
+			}
+			for id := range ids {
+				if !subIDs[id] {
+					delete(ids, id)
+				}
+			}
+		}
+		return ids, ok, nil
+	case vectordb.OpOr:
+		ids = make(map[string]bool)
+		for _, sub := range f.Filters {
+			subIDs, subOK, err := r.candidates(ctx, sub)
+			if err != nil || !subOK {
+				return nil, false, err
+			}
+			for id := range subIDs {
+				ids[id] = true
+			}
+		}
+		return ids, true, nil
+	}
+	return nil, false, nil
+}
+
+// each calls fn with every document that may match f: the tagged candidates
+// when the filter and collection allow it, otherwise every document
+func (r *RedisDB) each(ctx context.Context, f vectordb.Filter, fn func(key string, doc vectordb.Document) error) error {
+	tagged, err := r.tagged(ctx)
+	if err != nil {
+		return err
+	}
+	if tagged {
+		ids, ok, err := r.candidates(ctx, f)
+		if err != nil {
+			return err
+		}
+		if ok {
+			keys := make([]string, 0, len(ids))
+			for id := range ids {
+				keys = append(keys, r.keyDoc(id))
+			}
+			sort.Strings(keys)
+			return r.load(ctx, keys, fn)
+		}
 	}
-	// Scan-delete all docs under prefix
+
 	cursor := uint64(0)
 	pattern := fmt.Sprintf("%s:%s:doc:*", r.prefix, r.coll)
 	for {
@@ -77,14 +203,108 @@ func (r *RedisDB) DeleteCollection(ctx context.Context, name string) error {
 		if err != nil {
 			return err
 		}
-		if len(keys) > 0 {
-			if err := r.client.Del(ctx, keys...).Err(); err != nil {
-				return err
-			}
+		if err := r.load(ctx, keys, fn); err != nil {
+			return err
 		}
 		cursor = next
 		if cursor == 0 {
-			break
+			return nil
+		}
+	}
+}
+
+// load fetches documents by key, 200 at a time, skipping missing ones
+func (r *RedisDB) load(ctx context.Context, keys []string, fn func(key string, doc vectordb.Document) error) error {
+	for start := 0; start < len(keys); start += 200 {
+		chunk := keys[start:min(start+200, len(keys))]
+		vals, err := r.client.MGet(ctx, chunk...).Result()
+		if err != nil {
+			return err
+		}
+		for i, v := range vals {
+			raw, ok := v.(string)
+			if !ok {
+				continue
+			}
+			var doc vectordb.Document
+			if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+				continue
+			}
+			if err := fn(chunk[i], doc); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// untag removes the tags of the documents stored under keys
+func (r *RedisDB) untag(ctx context.Context, pipe redis.Pipeliner, keys []string) error {
+	return r.load(ctx, keys, func(_ string, doc vectordb.Document) error {
+		for _, tag := range r.tagKeys(doc.Metadata) {
+			pipe.SRem(ctx, tag, doc.ID)
+		}
+		return nil
+	})
+}
+
+// Reindex tags the documents of a collection created before metadata tags
+// existed, so filtered queries stop scanning it
+func (r *RedisDB) Reindex(ctx context.Context) error {
+	err := r.each(ctx, vectordb.And(), func(_ string, doc vectordb.Document) error {
+		pipe := r.client.TxPipeline()
+		for _, tag := range r.tagKeys(doc.Metadata) {
+			pipe.SAdd(ctx, tag, doc.ID)
+		}
+		_, err := pipe.Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return r.client.HSet(ctx, r.keyIdx(), fieldTagged, "1").Err()
+}
+
+func (r *RedisDB) CreateCollection(ctx context.Context, name string, _ map[string]interface{}) error {
+	if name != "" {
+		r.coll = name
+	}
+	// Mark index key
+	if err := r.client.HSetNX(ctx, r.keyIdx(), "_created", "1").Err(); err != nil {
+		return err
+	}
+	// A new collection is tagged from its first document
+	n, err := r.Count(ctx)
+	if err != nil || n > 0 {
+		return err
+	}
+	return r.client.HSet(ctx, r.keyIdx(), fieldTagged, "1").Err()
+}
+
+func (r *RedisDB) DeleteCollection(ctx context.Context, name string) error {
+	if name != "" {
+		r.coll = name
+	}
+	// Scan-delete all docs and tags under prefix
+	for _, pattern := range []string{
+		fmt.Sprintf("%s:%s:doc:*", r.prefix, r.coll),
+		fmt.Sprintf("%s:%s:tag:*", r.prefix, r.coll),
+	} {
+		cursor := uint64(0)
+		for {
+			keys, next, err := r.client.Scan(ctx, cursor, pattern, 200).Result()
+			if err != nil {
+				return err
+			}
+			if len(keys) > 0 {
+				if err := r.client.Del(ctx, keys...).Err(); err != nil {
+					return err
+				}
+			}
+			cursor = next
+			if cursor == 0 {
+				break
+			}
 		}
 	}
 	return r.client.Del(ctx, r.keyIdx()).Err()
@@ -94,13 +314,24 @@ func (r *RedisDB) Add(ctx context.Context, documents []vectordb.Document) error
 	if len(documents) == 0 {
 		return nil
 	}
-	for _, d := range documents {
+	keys := make([]string, len(documents))
+	for i, d := range documents {
+		keys[i] = r.keyDoc(d.ID)
+	}
+	pipe := r.client.TxPipeline()
+	// Replaced documents may have had other metadata
+	if err := r.untag(ctx, pipe, keys); err != nil {
+		return err
+	}
+	for i, d := range documents {
 		b, _ := json.Marshal(d)
-		if err := r.client.Set(ctx, r.keyDoc(d.ID), b, 0).Err(); err != nil {
-			return err
+		pipe.Set(ctx, keys[i], b, 0)
+		for _, tag := range r.tagKeys(d.Metadata) {
+			pipe.SAdd(ctx, tag, d.ID)
 		}
 	}
-	return nil
+	_, err := pipe.Exec(ctx)
+	return err
 }
 
 func (r *RedisDB) Update(ctx context.Context, documents []vectordb.Document) error {
@@ -115,7 +346,42 @@ func (r *RedisDB) Delete(ctx context.Context, ids []string) error {
 	for i, id := range ids {
 		keys[i] = r.keyDoc(id)
 	}
-	return r.client.Del(ctx, keys...).Err()
+	return r.remove(ctx, keys)
+}
+
+// remove deletes the documents stored under keys along with their tags
+func (r *RedisDB) remove(ctx context.Context, keys []string) error {
+	pipe := r.client.TxPipeline()
+	if err := r.untag(ctx, pipe, keys); err != nil {
+		return err
+	}
+	pipe.Del(ctx, keys...)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// DeleteByFilter deletes the documents whose metadata matches filter. Eq and
+// In clauses select candidates from the metadata tags; other filters scan the
+// collection. The filter is evaluated with vectordb.Filter.Match.
+func (r *RedisDB) DeleteByFilter(ctx context.Context, filter map[string]interface{}) error {
+	if err := vectordb.CheckDeleteFilter(filter); err != nil {
+		return err
+	}
+	f, err := vectordb.ParseFilter(filter)
+	if err != nil {
+		return err
+	}
+	var matched []string
+	err = r.each(ctx, f, func(key string, doc vectordb.Document) error {
+		if f.Match(doc.Metadata) {
+			matched = append(matched, key)
+		}
+		return nil
+	})
+	if err != nil || len(matched) == 0 {
+		return err
+	}
+	return r.remove(ctx, matched)
 }
 
 func (r *RedisDB) Query(ctx context.Context, query string, limit int, filter map[string]interface{}) ([]vectordb.SearchResult, error) {
@@ -129,38 +395,26 @@ func (r *RedisDB) Query(ctx context.Context, query string, limit int, filter map
 	return r.QueryWithEmbedding(ctx, emb, limit, filter)
 }
 
-func (r *RedisDB) QueryWithEmbedding(ctx context.Context, embedding []float32, limit int, _ map[string]interface{}) ([]vectordb.SearchResult, error) {
+func (r *RedisDB) QueryWithEmbedding(ctx context.Context, embedding []float32, limit int, filter map[string]interface{}) ([]vectordb.SearchResult, error) {
 	if len(embedding) == 0 {
 		return nil, errors.New("embedding required")
 	}
-	// Fetch all docs for naive scoring
-	cursor := uint64(0)
-	pattern := fmt.Sprintf("%s:%s:doc:*", r.prefix, r.coll)
+	f, err := vectordb.ParseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	// Fetch candidate docs for naive scoring
 	results := make([]vectordb.SearchResult, 0)
-	for {
-		keys, next, err := r.client.Scan(ctx, cursor, pattern, 200).Result()
-		if err != nil {
-			return nil, err
-		}
-		for _, k := range keys {
-			raw, err := r.client.Get(ctx, k).Bytes()
-			if err != nil {
-				continue
-			}
-			var doc vectordb.Document
-			if err := json.Unmarshal(raw, &doc); err != nil {
-				continue
-			}
-			if len(doc.Embedding) == 0 {
-				continue
-			}
-			score, dist := scoreVectors(embedding, doc.Embedding, r.distance)
-			results = append(results, vectordb.SearchResult{ID: doc.ID, Content: doc.Content, Metadata: doc.Metadata, Score: float32(score), Distance: float32(dist)})
-		}
-		cursor = next
-		if cursor == 0 {
-			break
+	err = r.each(ctx, f, func(_ string, doc vectordb.Document) error {
+		if len(doc.Embedding) == 0 || !f.Match(doc.Metadata) {
+			return nil
 		}
+		score, dist := scoreVectors(embedding, doc.Embedding, r.distance)
+		results = append(results, vectordb.SearchResult{ID: doc.ID, Content: doc.Content, Metadata: doc.Metadata, Score: float32(score), Distance: float32(dist)})
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	// sort by distance asc (or score desc for cosine)
 	sort.Slice(results, func(i, j int) bool {
@@ -201,8 +455,12 @@ func (r *RedisDB) Get(ctx context.Context, ids []string) ([]vectordb.Document, e
 }
 
 func (r *RedisDB) Count(ctx context.Context) (int, error) {
+	return r.countDocs(ctx, r.coll)
+}
+
+func (r *RedisDB) countDocs(ctx context.Context, coll string) (int, error) {
 	cursor := uint64(0)
-	pattern := fmt.Sprintf("%s:%s:doc:*", r.prefix, r.coll)
+	pattern := fmt.Sprintf("%s:%s:doc:*", r.prefix, coll)
 	total := 0
 	for {
 		keys, next, err := r.client.Scan(ctx, cursor, pattern, 500).Result()
@@ -218,6 +476,70 @@ func (r *RedisDB) Count(ctx context.Context) (int, error) {
 	return total, nil
 }
 
+// ListCollections returns the collections created with CreateCollection
+// under the key prefix, sorted
+func (r *RedisDB) ListCollections(ctx context.Context) ([]string, error) {
+	cursor := uint64(0)
+	pattern := fmt.Sprintf("%s:*:index", r.prefix)
+	var names []string
+	for {
+		keys, next, err := r.client.Scan(ctx, cursor, pattern, 200).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range keys {
+			names = append(names, strings.TrimSuffix(strings.TrimPrefix(k, r.prefix+":"), ":index"))
+		}
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// CollectionStats describes a collection ("" for the active one). The
+// dimension is read from a stored document; DiskSize is -1, as documents
+// live in memory.
+func (r *RedisDB) CollectionStats(ctx context.Context, name string) (vectordb.CollectionStats, error) {
+	if name == "" {
+		name = r.coll
+	}
+	count, err := r.countDocs(ctx, name)
+	if err != nil {
+		return vectordb.CollectionStats{}, err
+	}
+	if count == 0 {
+		exists, err := r.client.Exists(ctx, fmt.Sprintf("%s:%s:index", r.prefix, name)).Result()
+		if err != nil {
+			return vectordb.CollectionStats{}, err
+		}
+		if exists == 0 {
+			return vectordb.CollectionStats{}, fmt.Errorf("%w: %s", vectordb.ErrCollectionNotFound, name)
+		}
+	}
+
+	stats := vectordb.CollectionStats{
+		Name:             name,
+		Count:            count,
+		DistanceFunction: r.distance,
+		IndexType:        "flat",
+		DiskSize:         -1,
+	}
+	keys, _, err := r.client.Scan(ctx, 0, fmt.Sprintf("%s:%s:doc:*", r.prefix, name), 50).Result()
+	if err != nil {
+		return vectordb.CollectionStats{}, err
+	}
+	err = r.load(ctx, keys, func(_ string, doc vectordb.Document) error {
+		if stats.Dimension == 0 {
+			stats.Dimension = len(doc.Embedding)
+		}
+		return nil
+	})
+	return stats, err
+}
+
 func (r *RedisDB) Close() error { return r.client.Close() }
 
 func scoreVectors(a, b []float32, dist vectordb.DistanceFunction) (score float64, distance float64) {
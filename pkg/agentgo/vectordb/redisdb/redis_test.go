@@ -4,8 +4,11 @@ package redisdb
 
 import (
 	"context"
+	"errors"
 	"os"
 	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
 )
 
 func TestRedisDB_Smoke(t *testing.T) {
@@ -25,7 +28,7 @@ func TestRedisDB_Smoke(t *testing.T) {
 		t.Fatalf("create: %v", err)
 	}
 	defer db.DeleteCollection(ctx, "")
-	err = db.Add(ctx, []Document{{ID: "1", Content: "hello", Embedding: []float32{0.1, 0.2, 0.3}}})
+	err = db.Add(ctx, []vectordb.Document{{ID: "1", Content: "hello", Embedding: []float32{0.1, 0.2, 0.3}}})
 	if err != nil {
 		t.Fatalf("add: %v", err)
 	}
@@ -34,3 +37,104 @@ func TestRedisDB_Smoke(t *testing.T) {
 		t.Fatalf("count: %v n=%d", err, n)
 	}
 }
+
+func TestTagValue(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+		ok    bool
+	}{
+		{"go", "go", true},
+		{true, "true", true},
+		{2020, "2020", true},
+		{float64(2020), "2020", true},
+		{float32(0.5), "0.5", true},
+		{uint8(7), "7", true},
+		{[]string{"a"}, "", false},
+		{map[string]interface{}{"a": 1}, "", false},
+		{nil, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := tagValue(tt.value)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("tagValue(%#v) = %q, %v, want %q, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRedisDB_Filter(t *testing.T) {
+	if os.Getenv("TEST_REDIS_VECTORDB") != "1" {
+		t.Skip("set TEST_REDIS_VECTORDB=1 to run redis vectordb test")
+	}
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+	db, err := New(Config{Addr: addr, CollectionName: "test-filter"})
+	if err != nil {
+		t.Fatalf("new redis db: %v", err)
+	}
+	ctx := context.Background()
+	_ = db.DeleteCollection(ctx, "")
+	if err := db.CreateCollection(ctx, "", nil); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	defer db.DeleteCollection(ctx, "")
+
+	emb := []float32{0.1, 0.2, 0.3}
+	err = db.Add(ctx, []vectordb.Document{
+		{ID: "1", Embedding: emb, Metadata: map[string]interface{}{"lang": "go", "year": 2019}},
+		{ID: "2", Embedding: emb, Metadata: map[string]interface{}{"lang": "go", "year": 2023}},
+		{ID: "3", Embedding: emb, Metadata: map[string]interface{}{"lang": "rust", "year": 2024}},
+	})
+	if err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	filter := vectordb.And(vectordb.In("lang", "go", "python"), vectordb.Gte("year", 2020)).Map()
+	results, err := db.QueryWithEmbedding(ctx, emb, 10, filter)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != "2" {
+		t.Fatalf("expected only doc 2, got %+v", results)
+	}
+
+	// Replacing a document moves its tags
+	err = db.Update(ctx, []vectordb.Document{{ID: "2", Embedding: emb, Metadata: map[string]interface{}{"lang": "rust", "year": 2023}}})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if results, _ := db.QueryWithEmbedding(ctx, emb, 10, filter); len(results) != 0 {
+		t.Fatalf("expected no results after update, got %+v", results)
+	}
+
+	if err := db.DeleteByFilter(ctx, map[string]interface{}{"$and": []interface{}{}}); err == nil {
+		t.Fatal("expected error for an empty $and")
+	}
+	if err := db.DeleteByFilter(ctx, map[string]interface{}{"lang": "rust"}); err != nil {
+		t.Fatalf("delete by filter: %v", err)
+	}
+	if n, _ := db.Count(ctx); n != 1 {
+		t.Fatalf("expected 1 document left, got %d", n)
+	}
+
+	names, err := db.ListCollections(ctx)
+	if err != nil {
+		t.Fatalf("list collections: %v", err)
+	}
+	found := false
+	for _, name := range names {
+		found = found || name == "test-filter"
+	}
+	if !found {
+		t.Errorf("collections %v should include test-filter", names)
+	}
+	stats, err := db.CollectionStats(ctx, "")
+	if err != nil || stats.Count != 1 || stats.Dimension != 3 || stats.IndexType != "flat" {
+		t.Errorf("stats: %+v, %v", stats, err)
+	}
+	if _, err := db.CollectionStats(ctx, "test-missing"); !errors.Is(err, vectordb.ErrCollectionNotFound) {
+		t.Errorf("stats of a missing collection: %v", err)
+	}
+}
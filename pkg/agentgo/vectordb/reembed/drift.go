@@ -0,0 +1,111 @@
+package reembed
+
+import (
+	"context"
+	"sort"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+)
+
+// maxMostChanged caps DriftStats.MostChanged
+const maxMostChanged = 10
+
+// DriftStats compares the nearest neighbours of sampled documents under the old
+// and the new model. Vectors of different models cannot be compared directly,
+// but their neighbourhoods can: a low overlap means retrieval results will
+// change noticeably.
+type DriftStats struct {
+	Sampled       int // documents compared
+	Neighbors     int // neighbours compared per document
+	OldDimensions int
+	NewDimensions int
+
+	// MeanNeighborOverlap is the average fraction of a document's old nearest
+	// neighbours that are still among its new nearest neighbours (1 = unchanged)
+	MeanNeighborOverlap float64
+	MinNeighborOverlap  float64
+
+	// MostChanged lists the sampled documents with the lowest overlap
+	MostChanged []string
+}
+
+// drift computes drift statistics for the sampled documents
+func (m *Migrator) drift(ctx context.Context, ids []string) (*DriftStats, error) {
+	oldDocs, err := m.source.Get(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	newDocs, err := m.target.Get(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	newByID := make(map[string][]float32, len(newDocs))
+	for _, doc := range newDocs {
+		newByID[doc.ID] = doc.Embedding
+	}
+
+	stats := &DriftStats{Neighbors: m.neighbors, MinNeighborOverlap: 1}
+	type sample struct {
+		id      string
+		overlap float64
+	}
+	var samples []sample
+	for _, doc := range oldDocs {
+		newEmbedding := newByID[doc.ID]
+		if len(doc.Embedding) == 0 || len(newEmbedding) == 0 {
+			continue
+		}
+		oldNeighbors, err := neighbors(ctx, m.source, doc.ID, doc.Embedding, m.neighbors)
+		if err != nil {
+			return nil, err
+		}
+		if len(oldNeighbors) == 0 {
+			continue
+		}
+		newNeighbors, err := neighbors(ctx, m.target, doc.ID, newEmbedding, m.neighbors)
+		if err != nil {
+			return nil, err
+		}
+
+		kept := 0
+		for id := range newNeighbors {
+			if oldNeighbors[id] {
+				kept++
+			}
+		}
+		overlap := float64(kept) / float64(len(oldNeighbors))
+		samples = append(samples, sample{id: doc.ID, overlap: overlap})
+		stats.OldDimensions, stats.NewDimensions = len(doc.Embedding), len(newEmbedding)
+		stats.MeanNeighborOverlap += overlap
+		stats.MinNeighborOverlap = min(stats.MinNeighborOverlap, overlap)
+	}
+	if len(samples) == 0 {
+		return nil, nil
+	}
+
+	stats.Sampled = len(samples)
+	stats.MeanNeighborOverlap /= float64(len(samples))
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].overlap < samples[j].overlap })
+	for _, s := range samples {
+		if s.overlap == 1 || len(stats.MostChanged) == maxMostChanged {
+			break
+		}
+		stats.MostChanged = append(stats.MostChanged, s.id)
+	}
+	return stats, nil
+}
+
+// neighbors returns the k nearest neighbours of a document, excluding itself
+func neighbors(ctx context.Context, db vectordb.VectorDB, id string, embedding []float32, k int) (map[string]bool, error) {
+	results, err := db.QueryWithEmbedding(ctx, embedding, k+1, nil)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]bool, k)
+	for _, result := range results {
+		if result.ID != id && len(out) < k {
+			out[result.ID] = true
+		}
+	}
+	return out, nil
+}
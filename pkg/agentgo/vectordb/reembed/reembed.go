@@ -0,0 +1,309 @@
+// Package reembed copies a vector collection into a new one, re-embedding every
+// document with another model. Use it when upgrading the embedding model: the
+// old collection keeps serving queries until the new one is complete.
+//
+// The source must implement vectordb.Scanner. Progress is checkpointed after
+// every batch, so an interrupted migration resumes where it stopped, and the
+// final report includes drift statistics comparing the nearest neighbours of
+// sampled documents under both models.
+package reembed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/embeddings"
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+)
+
+const (
+	defaultBatchSize       = 64
+	defaultDriftSampleSize = 100
+	defaultDriftNeighbors  = 10
+)
+
+// Config configures a Migrator
+type Config struct {
+	// Source is the collection to read; it must implement vectordb.Scanner (required)
+	Source vectordb.VectorDB
+
+	// Target is the new collection, embedded with Embedder (required)
+	Target vectordb.VectorDB
+
+	// Embedder is the new embedding model (required)
+	Embedder embeddings.Embedder
+
+	// BatchSize is the number of documents per embedding request (default: 64)
+	BatchSize int
+
+	// RequestsPerMinute limits embedding requests; 0 means no limit
+	RequestsPerMinute int
+
+	// CheckpointPath is a JSON file recording progress. When it exists, Run
+	// resumes from it. Empty disables resuming.
+	CheckpointPath string
+
+	// DriftSampleSize is the number of documents compared for drift statistics
+	// (default: 100; negative disables drift statistics)
+	DriftSampleSize int
+
+	// DriftNeighbors is the number of nearest neighbours compared per sampled
+	// document (default: 10)
+	DriftNeighbors int
+
+	// OnProgress is called after every batch
+	OnProgress func(Progress)
+}
+
+// Progress describes a migration in flight
+type Progress struct {
+	Total    int // documents in the source when Run started
+	Migrated int // documents written to the target
+	Skipped  int // documents without content
+	Batches  int // batches completed
+}
+
+// Report summarizes a migration
+type Report struct {
+	Progress
+	Resumed  bool          // Run continued from a checkpoint
+	Duration time.Duration // time spent by this Run
+	Drift    *DriftStats   // nil when disabled or when nothing could be sampled
+}
+
+// Migrator re-embeds a collection into another
+type Migrator struct {
+	source   vectordb.VectorDB
+	scanner  vectordb.Scanner
+	target   vectordb.VectorDB
+	embedder embeddings.Embedder
+
+	batchSize      int
+	interval       time.Duration
+	checkpointPath string
+	sampleSize     int
+	neighbors      int
+	onProgress     func(Progress)
+
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// checkpoint is the persisted state of a migration
+type checkpoint struct {
+	Cursor    string   `json:"cursor"`
+	Migrated  int      `json:"migrated"`
+	Skipped   int      `json:"skipped"`
+	Batches   int      `json:"batches"`
+	SampleIDs []string `json:"sample_ids,omitempty"`
+	Completed bool     `json:"completed"`
+}
+
+// New creates a Migrator
+func New(config Config) (*Migrator, error) {
+	if config.Source == nil || config.Target == nil {
+		return nil, fmt.Errorf("source and target are required")
+	}
+	scanner, ok := config.Source.(vectordb.Scanner)
+	if !ok {
+		return nil, fmt.Errorf("source %T does not implement vectordb.Scanner", config.Source)
+	}
+	if config.Embedder == nil {
+		return nil, fmt.Errorf("embedder is required")
+	}
+	if config.RequestsPerMinute < 0 {
+		return nil, fmt.Errorf("requests per minute must not be negative")
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultBatchSize
+	}
+	if config.DriftSampleSize == 0 {
+		config.DriftSampleSize = defaultDriftSampleSize
+	}
+	if config.DriftNeighbors <= 0 {
+		config.DriftNeighbors = defaultDriftNeighbors
+	}
+
+	m := &Migrator{
+		source:         config.Source,
+		scanner:        scanner,
+		target:         config.Target,
+		embedder:       config.Embedder,
+		batchSize:      config.BatchSize,
+		checkpointPath: config.CheckpointPath,
+		sampleSize:     max(config.DriftSampleSize, 0),
+		neighbors:      config.DriftNeighbors,
+		onProgress:     config.OnProgress,
+		sleep:          sleep,
+	}
+	if config.RequestsPerMinute > 0 {
+		m.interval = time.Minute / time.Duration(config.RequestsPerMinute)
+	}
+	return m, nil
+}
+
+// Run migrates the remaining documents and returns the report. After an
+// error, calling Run again with the same checkpoint resumes the migration.
+func (m *Migrator) Run(ctx context.Context) (*Report, error) {
+	started := time.Now()
+
+	state, resumed, err := m.loadCheckpoint()
+	if err != nil {
+		return nil, err
+	}
+	total, err := m.source.Count(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count source documents: %w", err)
+	}
+
+	report := &Report{Resumed: resumed}
+	progress := func() Progress {
+		return Progress{Total: total, Migrated: state.Migrated, Skipped: state.Skipped, Batches: state.Batches}
+	}
+
+	var lastRequest time.Time
+	for !state.Completed {
+		docs, next, err := m.scanner.Scan(ctx, state.Cursor, m.batchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read source after %q: %w", state.Cursor, err)
+		}
+
+		records := make([]vectordb.Document, 0, len(docs))
+		for _, doc := range docs {
+			if doc.Content == "" {
+				state.Skipped++
+				continue
+			}
+			if len(state.SampleIDs) < m.sampleSize && len(doc.Embedding) > 0 {
+				state.SampleIDs = append(state.SampleIDs, doc.ID)
+			}
+			records = append(records, vectordb.Document{
+				ID:        doc.ID,
+				Content:   doc.Content,
+				Metadata:  doc.Metadata,
+				CreatedAt: doc.CreatedAt,
+			})
+		}
+
+		if len(records) > 0 {
+			if m.interval > 0 && !lastRequest.IsZero() {
+				if err := m.sleep(ctx, m.interval-time.Since(lastRequest)); err != nil {
+					return nil, err
+				}
+			}
+			lastRequest = time.Now()
+			if err := m.writeBatch(ctx, records); err != nil {
+				return nil, err
+			}
+			state.Migrated += len(records)
+		}
+
+		state.Batches++
+		state.Cursor = next
+		state.Completed = next == ""
+		if err := m.saveCheckpoint(state); err != nil {
+			return nil, err
+		}
+		if m.onProgress != nil {
+			m.onProgress(progress())
+		}
+	}
+
+	report.Progress = progress()
+	if m.sampleSize > 0 && len(state.SampleIDs) > 0 {
+		if report.Drift, err = m.drift(ctx, state.SampleIDs); err != nil {
+			return nil, fmt.Errorf("failed to compute drift: %w", err)
+		}
+	}
+	report.Duration = time.Since(started)
+	return report, nil
+}
+
+// writeBatch embeds records with the new model and stores them in the target
+func (m *Migrator) writeBatch(ctx context.Context, records []vectordb.Document) error {
+	texts := make([]string, len(records))
+	ids := make([]string, len(records))
+	for i, record := range records {
+		texts[i] = record.Content
+		ids[i] = record.ID
+	}
+
+	vectors, err := m.embedder.Embed(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("failed to embed batch starting at %q: %w", ids[0], err)
+	}
+	if len(vectors) != len(records) {
+		return fmt.Errorf("embedder returned %d embeddings for %d documents", len(vectors), len(records))
+	}
+	for i := range records {
+		records[i].Embedding = vectors[i]
+	}
+
+	// A resumed batch may have been written before the interruption; deleting
+	// first keeps targets without upserts free of duplicates.
+	if err := m.target.Delete(ctx, ids); err != nil {
+		return fmt.Errorf("failed to clear batch starting at %q: %w", ids[0], err)
+	}
+	if err := m.target.Add(ctx, records); err != nil {
+		return fmt.Errorf("failed to write batch starting at %q: %w", ids[0], err)
+	}
+	return nil
+}
+
+func (m *Migrator) loadCheckpoint() (*checkpoint, bool, error) {
+	state := &checkpoint{}
+	if m.checkpointPath == "" {
+		return state, false, nil
+	}
+	data, err := os.ReadFile(m.checkpointPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, false, fmt.Errorf("failed to decode checkpoint %s: %w", m.checkpointPath, err)
+	}
+	return state, true, nil
+}
+
+func (m *Migrator) saveCheckpoint(state *checkpoint) error {
+	if m.checkpointPath == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+	tmp := m.checkpointPath + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(m.checkpointPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, m.checkpointPath); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
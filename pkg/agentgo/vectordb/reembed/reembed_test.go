@@ -0,0 +1,198 @@
+package reembed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
+	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb/memvec"
+)
+
+// keywordEmbedder embeds texts by counting keywords, one dimension per keyword.
+// It fails once failAfter texts have been embedded, when failAfter is positive.
+type keywordEmbedder struct {
+	keywords  []string
+	embedded  int
+	requests  int
+	failAfter int
+}
+
+func (e *keywordEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if e.failAfter > 0 && e.embedded+len(texts) > e.failAfter {
+		return nil, errors.New("quota exceeded")
+	}
+	e.requests++
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		out[i], _ = e.EmbedSingle(ctx, text)
+	}
+	e.embedded += len(texts)
+	return out, nil
+}
+
+func (e *keywordEmbedder) EmbedSingle(_ context.Context, text string) ([]float32, error) {
+	vector := make([]float32, len(e.keywords)+1)
+	for i, keyword := range e.keywords {
+		vector[i] = float32(strings.Count(text, keyword))
+	}
+	vector[len(e.keywords)] = 0.1
+	return vector, nil
+}
+
+var (
+	oldKeywords = []string{"go", "rust", "python"}
+	newKeywords = []string{"go", "rust", "python", "fast"}
+)
+
+func newSource(t *testing.T) *memvec.MemVec {
+	t.Helper()
+	db, err := memvec.New(memvec.Config{EmbeddingFunction: &keywordEmbedder{keywords: oldKeywords}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := []vectordb.Document{{ID: "empty"}}
+	for i, content := range []string{"go go", "go is fast", "rust", "rust is fast", "python", "python python go"} {
+		docs = append(docs, vectordb.Document{
+			ID:       fmt.Sprintf("doc-%d", i),
+			Content:  content,
+			Metadata: map[string]interface{}{"n": i},
+		})
+	}
+	if err := db.Add(context.Background(), docs); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func newTarget(t *testing.T) *memvec.MemVec {
+	t.Helper()
+	db, err := memvec.New(memvec.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestNew_Validation(t *testing.T) {
+	source, target := newSource(t), newTarget(t)
+	embedder := &keywordEmbedder{keywords: newKeywords}
+
+	if _, err := New(Config{Target: target, Embedder: embedder}); err == nil {
+		t.Error("expected error without source")
+	}
+	if _, err := New(Config{Source: source, Target: target}); err == nil {
+		t.Error("expected error without embedder")
+	}
+	// Embedding the interface hides Scan.
+	opaque := struct{ vectordb.VectorDB }{source}
+	if _, err := New(Config{Source: opaque, Target: target, Embedder: embedder}); err == nil {
+		t.Error("expected error for a source without Scan")
+	}
+}
+
+func TestMigrator_Run(t *testing.T) {
+	ctx := context.Background()
+	source, target := newSource(t), newTarget(t)
+	embedder := &keywordEmbedder{keywords: newKeywords}
+
+	var progress []Progress
+	m, err := New(Config{
+		Source:         source,
+		Target:         target,
+		Embedder:       embedder,
+		BatchSize:      3,
+		DriftNeighbors: 2,
+		OnProgress:     func(p Progress) { progress = append(progress, p) },
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	report, err := m.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if report.Total != 7 || report.Migrated != 6 || report.Skipped != 1 || report.Batches != 3 || report.Resumed {
+		t.Errorf("report = %+v", report)
+	}
+	if len(progress) != 3 || progress[2].Migrated != 6 {
+		t.Errorf("progress = %+v", progress)
+	}
+
+	docs, _ := target.Get(ctx, []string{"doc-1"})
+	if len(docs) != 1 || len(docs[0].Embedding) != len(newKeywords)+1 || docs[0].Metadata["n"] != 1 {
+		t.Errorf("migrated document = %+v", docs)
+	}
+
+	drift := report.Drift
+	if drift == nil || drift.Sampled != 6 || drift.OldDimensions != 4 || drift.NewDimensions != 5 || drift.Neighbors != 2 {
+		t.Fatalf("drift = %+v", drift)
+	}
+	if drift.MeanNeighborOverlap <= 0 || drift.MeanNeighborOverlap > 1 || drift.MinNeighborOverlap > drift.MeanNeighborOverlap {
+		t.Errorf("drift = %+v", drift)
+	}
+}
+
+func TestMigrator_Resume(t *testing.T) {
+	ctx := context.Background()
+	source, target := newSource(t), newTarget(t)
+	checkpoint := filepath.Join(t.TempDir(), "reembed.json")
+
+	failing := &keywordEmbedder{keywords: newKeywords, failAfter: 3}
+	m, _ := New(Config{Source: source, Target: target, Embedder: failing, BatchSize: 3, CheckpointPath: checkpoint, DriftSampleSize: -1})
+	if _, err := m.Run(ctx); err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Fatalf("Run() error = %v, want embedding failure", err)
+	}
+
+	embedder := &keywordEmbedder{keywords: newKeywords}
+	m, _ = New(Config{Source: source, Target: target, Embedder: embedder, BatchSize: 3, CheckpointPath: checkpoint, DriftSampleSize: -1})
+	report, err := m.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !report.Resumed || report.Migrated != 6 || report.Drift != nil {
+		t.Errorf("report = %+v", report)
+	}
+	// The first batch (doc-0 to doc-2) was not repeated.
+	if embedder.embedded != 3 {
+		t.Errorf("embedded %d documents after resuming, want 3", embedder.embedded)
+	}
+	if n, _ := target.Count(ctx); n != 6 {
+		t.Errorf("target Count() = %d, want 6", n)
+	}
+
+	// A completed checkpoint makes Run a no-op.
+	report, err = m.Run(ctx)
+	if err != nil || embedder.embedded != 3 || report.Migrated != 6 {
+		t.Errorf("Run() after completion = %+v, %v", report, err)
+	}
+}
+
+func TestMigrator_RateLimit(t *testing.T) {
+	source, target := newSource(t), newTarget(t)
+	embedder := &keywordEmbedder{keywords: newKeywords}
+	m, _ := New(Config{Source: source, Target: target, Embedder: embedder, BatchSize: 2, RequestsPerMinute: 30, DriftSampleSize: -1})
+
+	var waits []time.Duration
+	m.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	if _, err := m.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	// The last page only holds the empty document, which needs no request.
+	if embedder.requests != 3 || len(waits) != 2 {
+		t.Fatalf("requests = %d, waits = %v", embedder.requests, waits)
+	}
+	for _, d := range waits {
+		if d <= time.Second || d > 2*time.Second {
+			t.Errorf("wait = %v, want about 2s", d)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package webhooks
+
+import (
+	"context"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/hooks"
+	"github.com/jholhewres/agent-go/pkg/agentgo/run"
+)
+
+// NotifyApproval wraps fn so that an approval.required event is emitted before
+// fn is asked to decide. Use the result with hooks.NewApprovalHook.
+func NotifyApproval(d *Dispatcher, fn hooks.ApprovalFunc) hooks.ApprovalFunc {
+	return func(ctx context.Context, input *hooks.ToolHookInput) (bool, error) {
+		evt := NewEvent(EventApprovalRequired, map[string]interface{}{
+			"tool_call_id": input.ToolCallID,
+			"tool":         input.FunctionName,
+			"arguments":    input.Arguments,
+		})
+		evt.AgentID = input.AgentID
+		if rc, ok := run.FromContext(ctx); ok && rc != nil {
+			evt.RunID = rc.RunID
+			evt.SessionID = rc.SessionID
+		}
+		d.Emit(evt)
+		return fn(ctx, input)
+	}
+}
@@ -0,0 +1,72 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidSignature is returned when a signature header is malformed or does not match.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrSignatureExpired is returned when a signature timestamp is outside the tolerance.
+	ErrSignatureExpired = errors.New("webhook signature expired")
+)
+
+// Sign returns the signature header value for body sent at ts:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">".
+func Sign(secret string, ts time.Time, body []byte) string {
+	t := formatTimestamp(ts)
+	return "t=" + t + ",v1=" + computeMAC(secret, t, body)
+}
+
+// Verify checks a signature header produced by Sign. A positive tolerance
+// rejects timestamps further than tolerance from now, limiting replays.
+func Verify(secret, header string, body []byte, tolerance time.Duration) error {
+	var ts, mac string
+	for _, part := range strings.Split(header, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			mac = v
+		}
+	}
+	if ts == "" || mac == "" {
+		return ErrInvalidSignature
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(mac), []byte(computeMAC(secret, ts, body))) {
+		return ErrInvalidSignature
+	}
+	if tolerance > 0 {
+		age := time.Since(time.Unix(unix, 0))
+		if age > tolerance || age < -tolerance {
+			return ErrSignatureExpired
+		}
+	}
+	return nil
+}
+
+func computeMAC(secret, ts string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(ts))
+	h.Write([]byte("."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func formatTimestamp(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10)
+}
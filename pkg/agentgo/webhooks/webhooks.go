@@ -0,0 +1,287 @@
+// Package webhooks delivers signed run lifecycle notifications to external
+// HTTP endpoints with retries.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types emitted by the dispatcher.
+const (
+	EventRunStarted       = "run.started"
+	EventRunCompleted     = "run.completed"
+	EventRunFailed        = "run.failed"
+	EventApprovalRequired = "approval.required"
+)
+
+// Header names set on every delivery.
+const (
+	HeaderSignature = "X-AgentGo-Signature"
+	HeaderEvent     = "X-AgentGo-Event"
+	HeaderDelivery  = "X-AgentGo-Delivery"
+)
+
+// Event is the JSON payload posted to webhook endpoints.
+type Event struct {
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type"`
+	Timestamp time.Time              `json:"timestamp"`
+	AgentID   string                 `json:"agent_id,omitempty"`
+	RunID     string                 `json:"run_id,omitempty"`
+	SessionID string                 `json:"session_id,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// NewEvent creates an event of the given type with a fresh ID and timestamp.
+func NewEvent(eventType string, data map[string]interface{}) Event {
+	return Event{
+		ID:        uuid.NewString(),
+		Type:      eventType,
+		Timestamp: time.Now().UTC(),
+		Data:      data,
+	}
+}
+
+// Endpoint is a webhook receiver.
+type Endpoint struct {
+	// URL receives POST requests with the JSON event body
+	URL string
+
+	// Secret signs payloads with HMAC-SHA256 (empty = unsigned)
+	Secret string
+
+	// Events filters deliveries by event type (empty = all events)
+	Events []string
+
+	// Headers are added to every request
+	Headers map[string]string
+}
+
+func (e Endpoint) accepts(eventType string) bool {
+	if len(e.Events) == 0 {
+		return true
+	}
+	for _, t := range e.Events {
+		if t == eventType || t == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Config configures a Dispatcher
+type Config struct {
+	Endpoints []Endpoint
+
+	MaxRetries     int           // Retries after the first attempt (default: 3, negative disables)
+	InitialBackoff time.Duration // Delay before the first retry (default: 500ms)
+	MaxBackoff     time.Duration // Upper bound on a single delay (default: 30s)
+	Timeout        time.Duration // Per-attempt HTTP timeout (default: 10s)
+
+	// HTTPClient sends requests (default: http.Client with Timeout)
+	HTTPClient *http.Client
+
+	// Logger reports failed asynchronous deliveries (default: slog.Default())
+	Logger *slog.Logger
+}
+
+// DeliveryError describes a failed delivery to one endpoint.
+type DeliveryError struct {
+	URL        string
+	StatusCode int // 0 when the request did not complete
+	Attempts   int
+	Err        error
+}
+
+func (e *DeliveryError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("webhook %s failed after %d attempt(s): status %d", e.URL, e.Attempts, e.StatusCode)
+	}
+	return fmt.Sprintf("webhook %s failed after %d attempt(s): %v", e.URL, e.Attempts, e.Err)
+}
+
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// Dispatcher posts events to the configured endpoints.
+type Dispatcher struct {
+	config Config
+	client *http.Client
+	logger *slog.Logger
+
+	wg     sync.WaitGroup
+	mu     sync.RWMutex
+	closed bool
+}
+
+// New creates a Dispatcher.
+func New(config Config) (*Dispatcher, error) {
+	for i, ep := range config.Endpoints {
+		if ep.URL == "" {
+			return nil, fmt.Errorf("webhook endpoint %d: URL is required", i)
+		}
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	} else if config.MaxRetries == 0 {
+		config.MaxRetries = 3
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = 500 * time.Millisecond
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = 30 * time.Second
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: config.Timeout}
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Dispatcher{config: config, client: client, logger: logger}, nil
+}
+
+// Send delivers evt synchronously to every matching endpoint, retrying
+// transient failures. It returns the joined DeliveryErrors, if any.
+func (d *Dispatcher) Send(ctx context.Context, evt Event) error {
+	if evt.ID == "" {
+		evt.ID = uuid.NewString()
+	}
+	if evt.Timestamp.IsZero() {
+		evt.Timestamp = time.Now().UTC()
+	}
+	body, err := json.Marshal(evt)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+
+	var errs []error
+	for _, ep := range d.config.Endpoints {
+		if !ep.accepts(evt.Type) {
+			continue
+		}
+		if err := d.deliver(ctx, ep, evt, body); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Emit delivers evt in the background. Failures are logged. Events emitted
+// after Close are dropped.
+func (d *Dispatcher) Emit(evt Event) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return
+	}
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		if err := d.Send(context.Background(), evt); err != nil {
+			d.logger.Warn("webhook delivery failed", "event", evt.Type, "event_id", evt.ID, "error", err)
+		}
+	}()
+}
+
+// Close stops accepting new events and waits for in-flight deliveries or ctx.
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, ep Endpoint, evt Event, body []byte) error {
+	delay := d.config.InitialBackoff
+	attempts := d.config.MaxRetries + 1
+
+	var lastErr *DeliveryError
+	for attempt := 1; attempt <= attempts; attempt++ {
+		status, err := d.post(ctx, ep, evt, body)
+		if err == nil && status >= 200 && status < 300 {
+			return nil
+		}
+		lastErr = &DeliveryError{URL: ep.URL, StatusCode: status, Attempts: attempt, Err: err}
+		if !retryable(status, err) || attempt == attempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			lastErr.Err = ctx.Err()
+			return lastErr
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if delay > d.config.MaxBackoff {
+			delay = d.config.MaxBackoff
+		}
+	}
+	return lastErr
+}
+
+func (d *Dispatcher) post(ctx context.Context, ep Endpoint, evt Event, body []byte) (int, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, d.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, ep.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "agent-go-webhooks")
+	req.Header.Set(HeaderEvent, evt.Type)
+	req.Header.Set(HeaderDelivery, evt.ID)
+	for k, v := range ep.Headers {
+		req.Header.Set(k, v)
+	}
+	if ep.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(ep.Secret, time.Now(), body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// retryable reports whether a delivery attempt should be retried: network
+// errors, 408, 429 and 5xx responses are transient.
+func retryable(status int, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+	return status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= 500
+}
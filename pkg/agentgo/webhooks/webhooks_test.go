@@ -0,0 +1,129 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/hooks"
+)
+
+func TestDispatcher_SendSignsPayload(t *testing.T) {
+	var got Event
+	var verifyErr error
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		verifyErr = Verify("s3cret", r.Header.Get(HeaderSignature), body, time.Minute)
+		_ = json.Unmarshal(body, &got)
+		if r.Header.Get(HeaderEvent) != EventRunCompleted {
+			t.Errorf("event header = %q", r.Header.Get(HeaderEvent))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	d, err := New(Config{Endpoints: []Endpoint{{URL: srv.URL, Secret: "s3cret"}}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	evt := NewEvent(EventRunCompleted, map[string]interface{}{"content": "done"})
+	evt.RunID = "run-1"
+	if err := d.Send(context.Background(), evt); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if verifyErr != nil {
+		t.Fatalf("Verify() error = %v", verifyErr)
+	}
+	if got.ID != evt.ID || got.RunID != "run-1" || got.Data["content"] != "done" {
+		t.Fatalf("unexpected payload %+v", got)
+	}
+}
+
+func TestDispatcher_RetriesTransientFailures(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	d, _ := New(Config{Endpoints: []Endpoint{{URL: srv.URL}}, InitialBackoff: time.Millisecond})
+	if err := d.Send(context.Background(), NewEvent(EventRunStarted, nil)); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", calls)
+	}
+}
+
+func TestDispatcher_DoesNotRetryClientErrors(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	d, _ := New(Config{Endpoints: []Endpoint{{URL: srv.URL}}, InitialBackoff: time.Millisecond})
+	err := d.Send(context.Background(), NewEvent(EventRunFailed, nil))
+	var de *DeliveryError
+	if !errors.As(err, &de) || de.StatusCode != http.StatusBadRequest || de.Attempts != 1 {
+		t.Fatalf("expected single failed attempt, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected 1 attempt, got %d", calls)
+	}
+}
+
+func TestDispatcher_EventFilterAndEmit(t *testing.T) {
+	var mu sync.Mutex
+	var types []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		types = append(types, r.Header.Get(HeaderEvent))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	d, _ := New(Config{Endpoints: []Endpoint{{URL: srv.URL, Events: []string{EventApprovalRequired}}}})
+	approve := NotifyApproval(d, func(ctx context.Context, input *hooks.ToolHookInput) (bool, error) {
+		return true, nil
+	})
+	ok, err := approve(context.Background(), &hooks.ToolHookInput{FunctionName: "delete_file"})
+	if err != nil || !ok {
+		t.Fatalf("approval = %v, %v", ok, err)
+	}
+	d.Emit(NewEvent(EventRunStarted, nil))
+	if err := d.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if len(types) != 1 || types[0] != EventApprovalRequired {
+		t.Fatalf("expected only approval.required, got %v", types)
+	}
+}
+
+func TestVerify_RejectsTamperingAndStaleSignatures(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	header := Sign("k", time.Now(), body)
+	if err := Verify("k", header, []byte(`{"id":"2"}`), 0); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature, got %v", err)
+	}
+	if err := Verify("other", header, body, 0); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature for wrong secret, got %v", err)
+	}
+	stale := Sign("k", time.Now().Add(-time.Hour), body)
+	if err := Verify("k", stale, body, 5*time.Minute); !errors.Is(err, ErrSignatureExpired) {
+		t.Fatalf("expected ErrSignatureExpired, got %v", err)
+	}
+}
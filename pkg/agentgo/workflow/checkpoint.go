@@ -0,0 +1,177 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/run"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// ErrCheckpointNotFound is returned when no checkpoint exists for a run
+// ErrCheckpointNotFound 当运行不存在检查点时返回
+var ErrCheckpointNotFound = errors.New("checkpoint not found")
+
+// Checkpoint captures the resumable state of a workflow run after a step completes
+// Checkpoint 捕获步骤完成后工作流运行的可恢复状态
+type Checkpoint struct {
+	// RunID is the run this checkpoint belongs to
+	// RunID 是此检查点所属的运行
+	RunID string `json:"run_id"`
+
+	// Input is the original workflow input
+	// Input 是原始工作流输入
+	Input string `json:"input"`
+
+	// Output is the output produced by the last completed step
+	// Output 是最后完成的步骤产生的输出
+	Output string `json:"output"`
+
+	// CompletedStepID is the last top-level step that finished successfully
+	// CompletedStepID 是最后成功完成的顶层步骤
+	CompletedStepID string `json:"completed_step_id"`
+
+	// NextStepID is the step to resume from; empty when the run finished
+	// NextStepID 是恢复时的起始步骤；运行结束时为空
+	NextStepID string `json:"next_step_id,omitempty"`
+
+	// SessionState is a snapshot of the session state after CompletedStepID
+	// SessionState 是 CompletedStepID 之后的会话状态快照
+	SessionState map[string]interface{} `json:"session_state,omitempty"`
+
+	// UserID is the user the run was executed for
+	// UserID 是运行所属的用户
+	UserID string `json:"user_id,omitempty"`
+
+	// CreatedAt is when the checkpoint was recorded
+	// CreatedAt 是记录检查点的时间
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IsFinal reports whether the checkpoint was taken after the last step
+// IsFinal 判断检查点是否在最后一个步骤之后记录
+func (c *Checkpoint) IsFinal() bool {
+	return c.NextStepID == ""
+}
+
+// SaveCheckpoint stores the latest checkpoint for a run, replacing any previous one
+// SaveCheckpoint 存储运行的最新检查点，替换之前的检查点
+func (s *WorkflowSession) SaveCheckpoint(checkpoint *Checkpoint) {
+	if checkpoint == nil || checkpoint.RunID == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Checkpoints == nil {
+		s.Checkpoints = make(map[string]*Checkpoint)
+	}
+	s.Checkpoints[checkpoint.RunID] = checkpoint
+	s.UpdatedAt = time.Now()
+}
+
+// GetCheckpoint returns a copy of the checkpoint recorded for a run
+// GetCheckpoint 返回运行记录的检查点副本
+func (s *WorkflowSession) GetCheckpoint(runID string) (*Checkpoint, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	checkpoint, ok := s.Checkpoints[runID]
+	if !ok || checkpoint == nil {
+		return nil, false
+	}
+	cloned := *checkpoint
+	cloned.SessionState = cloneMap(checkpoint.SessionState)
+	return &cloned, true
+}
+
+// checkpointExcludedState lists session state keys rebuilt on every run; they
+// hold back-references to the session itself and must not be persisted.
+var checkpointExcludedState = map[string]struct{}{
+	"workflow_session":         {},
+	"workflow_history":         {},
+	"workflow_history_context": {},
+	"workflow_history_config":  {},
+}
+
+func newCheckpoint(runID, completedStepID, nextStepID string, execCtx *ExecutionContext) *Checkpoint {
+	state := execCtx.ExportSessionState()
+	for key := range checkpointExcludedState {
+		delete(state, key)
+	}
+	return &Checkpoint{
+		RunID:           runID,
+		Input:           execCtx.Input,
+		Output:          execCtx.Output,
+		CompletedStepID: completedStepID,
+		NextStepID:      nextStepID,
+		SessionState:    state,
+		UserID:          execCtx.UserID,
+		CreatedAt:       time.Now(),
+	}
+}
+
+// saveCheckpoint persists a checkpoint, creating the session when needed
+// saveCheckpoint 持久化检查点，必要时创建会话
+func (w *Workflow) saveCheckpoint(ctx context.Context, sessionID string, checkpoint *Checkpoint) error {
+	if w.historyStore == nil || checkpoint == nil {
+		return nil
+	}
+
+	session, err := w.historyStore.GetSession(ctx, sessionID)
+	if errors.Is(err, ErrSessionNotFound) {
+		session, err = w.historyStore.CreateSession(ctx, sessionID, w.ID, checkpoint.UserID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get session for checkpoint: %w", err)
+	}
+
+	session.SaveCheckpoint(checkpoint)
+	if err := w.historyStore.UpdateSession(ctx, session); err != nil {
+		return fmt.Errorf("failed to update session checkpoint: %w", err)
+	}
+
+	w.logger.Debug("saved checkpoint",
+		"session_id", sessionID,
+		"run_id", checkpoint.RunID,
+		"completed_step", checkpoint.CompletedStepID)
+	return nil
+}
+
+// Resume continues a previously interrupted run from its last checkpoint.
+// The original input, output and session state are restored and execution
+// starts at the step following the last completed one.
+// Resume 从最后一个检查点继续之前中断的运行。
+func (w *Workflow) Resume(ctx context.Context, sessionID, runID string, opts ...RunOption) (*ExecutionContext, error) {
+	if !w.enableCheckpoints || w.historyStore == nil {
+		return nil, types.NewInvalidConfigError("checkpoints are not enabled for this workflow", nil)
+	}
+
+	session, err := w.historyStore.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session %s: %w", sessionID, err)
+	}
+
+	checkpoint, ok := session.GetCheckpoint(runID)
+	if !ok {
+		return nil, fmt.Errorf("run %s: %w", runID, ErrCheckpointNotFound)
+	}
+	if checkpoint.IsFinal() {
+		return nil, types.NewInvalidInputError("run already completed", fmt.Errorf("run %s has no remaining steps", runID))
+	}
+
+	rc := run.NewContext()
+	rc.RunID = runID
+	rc.SessionID = sessionID
+
+	resumeOpts := []RunOption{
+		WithRunContext(rc),
+		WithUserID(checkpoint.UserID),
+		WithSessionState(checkpoint.SessionState),
+		WithResumeFrom(checkpoint.NextStepID),
+		withResumeOutput(checkpoint.Output),
+	}
+	return w.Run(ctx, checkpoint.Input, sessionID, append(resumeOpts, opts...)...)
+}
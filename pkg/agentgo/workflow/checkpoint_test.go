@@ -0,0 +1,122 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWorkflow_CheckpointAndResume(t *testing.T) {
+	store := NewMemoryStorage(10)
+	failStepB := true
+	var executed []string
+
+	stepA, _ := NewFunctionStep(FunctionStepConfig{
+		ID: "step-a",
+		Func: func(_ context.Context, execCtx *ExecutionContext) (*ExecutionContext, error) {
+			executed = append(executed, "a")
+			execCtx.Output = "a-out"
+			execCtx.SetSessionState("counter", 1)
+			return execCtx, nil
+		},
+	})
+	stepB, _ := NewFunctionStep(FunctionStepConfig{
+		ID: "step-b",
+		Func: func(_ context.Context, execCtx *ExecutionContext) (*ExecutionContext, error) {
+			executed = append(executed, "b")
+			if failStepB {
+				return nil, errors.New("transient failure")
+			}
+			if execCtx.Output != "a-out" {
+				return nil, errors.New("previous output not restored")
+			}
+			if v, _ := execCtx.GetSessionState("counter"); v != 1 {
+				return nil, errors.New("session state not restored")
+			}
+			execCtx.Output = "b-out"
+			return execCtx, nil
+		},
+	})
+
+	wf, err := New(Config{
+		Name:              "checkpointed",
+		Steps:             []Node{stepA, stepB},
+		HistoryStore:      store,
+		EnableCheckpoints: true,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := wf.Run(ctx, "start", "sess-cp"); err == nil {
+		t.Fatal("expected first run to fail")
+	}
+
+	session, err := store.GetSession(ctx, "sess-cp")
+	if err != nil {
+		t.Fatalf("GetSession() error = %v", err)
+	}
+	if len(session.Checkpoints) != 1 {
+		t.Fatalf("expected one checkpoint, got %d", len(session.Checkpoints))
+	}
+	var runID string
+	for id, cp := range session.Checkpoints {
+		runID = id
+		if cp.CompletedStepID != "step-a" || cp.NextStepID != "step-b" {
+			t.Fatalf("unexpected checkpoint %+v", cp)
+		}
+	}
+
+	failStepB = false
+	execCtx, err := wf.Resume(ctx, "sess-cp", runID)
+	if err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if execCtx.Output != "b-out" {
+		t.Fatalf("expected b-out, got %s", execCtx.Output)
+	}
+	if len(executed) != 3 || executed[2] != "b" {
+		t.Fatalf("expected step-a to be skipped on resume, got %v", executed)
+	}
+
+	cp, ok := session.GetCheckpoint(runID)
+	if !ok || !cp.IsFinal() {
+		t.Fatalf("expected final checkpoint after resume, got %+v", cp)
+	}
+	if _, err := wf.Resume(ctx, "sess-cp", runID); err == nil {
+		t.Fatal("expected error when resuming a completed run")
+	}
+}
+
+func TestWorkflow_ResumeErrors(t *testing.T) {
+	ctx := context.Background()
+	node := &stubNode{id: "only"}
+
+	plain, _ := New(Config{Name: "plain", Steps: []Node{node}})
+	if _, err := plain.Resume(ctx, "sess", "run"); err == nil {
+		t.Fatal("expected error when checkpoints are disabled")
+	}
+
+	wf, _ := New(Config{Name: "cp", Steps: []Node{node}, EnableCheckpoints: true})
+	if _, err := wf.Run(ctx, "in", "sess-1"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if _, err := wf.Resume(ctx, "sess-1", "unknown-run"); !errors.Is(err, ErrCheckpointNotFound) {
+		t.Fatalf("expected ErrCheckpointNotFound, got %v", err)
+	}
+}
+
+func TestCheckpoint_ExcludesHistoryState(t *testing.T) {
+	execCtx := NewExecutionContext("in")
+	execCtx.SetSessionState("workflow_session", NewWorkflowSession("s", "w", ""))
+	execCtx.SetSessionState("cart", []string{"apple"})
+
+	cp := newCheckpoint("run-1", "a", "b", execCtx)
+	if _, ok := cp.SessionState["workflow_session"]; ok {
+		t.Fatal("expected workflow_session to be excluded from checkpoint")
+	}
+	if _, ok := cp.SessionState["cart"]; !ok {
+		t.Fatal("expected user state to be kept in checkpoint")
+	}
+}
@@ -0,0 +1,338 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+)
+
+const (
+	// NodeTypeFunction identifies a step backed by a Go function
+	// NodeTypeFunction 表示由 Go 函数实现的步骤
+	NodeTypeFunction NodeType = "function"
+
+	// NodeTypeTool identifies a step backed by a toolkit function
+	// NodeTypeTool 表示由工具包函数实现的步骤
+	NodeTypeTool NodeType = "tool"
+)
+
+// StepFunc is the signature for function-backed steps
+// StepFunc 是函数步骤的签名
+type StepFunc func(ctx context.Context, execCtx *ExecutionContext) (*ExecutionContext, error)
+
+// FunctionStep executes a Go function as a workflow node
+// FunctionStep 将 Go 函数作为工作流节点执行
+type FunctionStep struct {
+	ID          string
+	Name        string
+	Description string
+	Func        StepFunc
+}
+
+// FunctionStepConfig contains function step configuration
+// FunctionStepConfig 包含函数步骤配置
+type FunctionStepConfig struct {
+	ID          string
+	Name        string
+	Description string
+	Func        StepFunc
+}
+
+// NewFunctionStep creates a new function step
+// NewFunctionStep 创建新的函数步骤
+func NewFunctionStep(config FunctionStepConfig) (*FunctionStep, error) {
+	if config.Func == nil {
+		return nil, fmt.Errorf("function is required for function step")
+	}
+
+	if config.ID == "" {
+		config.ID = fmt.Sprintf("function-%s", config.Name)
+	}
+
+	if config.Name == "" {
+		config.Name = config.ID
+	}
+
+	return &FunctionStep{
+		ID:          config.ID,
+		Name:        config.Name,
+		Description: config.Description,
+		Func:        config.Func,
+	}, nil
+}
+
+// Execute runs the function
+// Execute 执行函数
+func (f *FunctionStep) Execute(ctx context.Context, execCtx *ExecutionContext) (*ExecutionContext, error) {
+	result, err := f.Func(ctx, execCtx)
+	if err != nil {
+		return nil, fmt.Errorf("step %s execution failed: %w", f.ID, err)
+	}
+	if result == nil {
+		result = execCtx
+	}
+
+	result.Set(fmt.Sprintf("step_%s_output", f.ID), result.Output)
+	return result, nil
+}
+
+// GetID returns the step ID
+func (f *FunctionStep) GetID() string {
+	return f.ID
+}
+
+// GetType returns the node type
+func (f *FunctionStep) GetType() NodeType {
+	return NodeTypeFunction
+}
+
+// NewTypedStep creates a function step with typed input and output.
+// The input is decoded from the previous typed output (or the workflow input
+// as JSON when no typed output exists yet) and the result is stored both as a
+// typed value and as the JSON-encoded step output.
+// NewTypedStep 创建具有类型化输入和输出的函数步骤。
+func NewTypedStep[In, Out any](id string, fn func(ctx context.Context, input In) (Out, error)) (*FunctionStep, error) {
+	if fn == nil {
+		return nil, fmt.Errorf("function is required for typed step")
+	}
+
+	return NewFunctionStep(FunctionStepConfig{
+		ID: id,
+		Func: func(ctx context.Context, execCtx *ExecutionContext) (*ExecutionContext, error) {
+			input, err := TypedInput[In](execCtx)
+			if err != nil {
+				return nil, err
+			}
+
+			output, err := fn(ctx, input)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := SetTypedOutput(execCtx, id, output); err != nil {
+				return nil, err
+			}
+			return execCtx, nil
+		},
+	})
+}
+
+// TypedInput decodes the current step input into T.
+// The most recent typed output takes precedence over the raw text output,
+// which in turn takes precedence over the workflow input.
+// TypedInput 将当前步骤输入解码为 T。
+func TypedInput[T any](execCtx *ExecutionContext) (T, error) {
+	var zero T
+
+	if value, ok := latestTypedOutput(execCtx); ok {
+		if typed, ok := value.(T); ok {
+			return typed, nil
+		}
+	}
+
+	source := execCtx.Output
+	if source == "" {
+		source = execCtx.Input
+	}
+
+	// Strings can be passed through without requiring JSON quoting.
+	if _, ok := any(zero).(string); ok {
+		return any(source).(T), nil
+	}
+
+	var decoded T
+	if source == "" {
+		return zero, nil
+	}
+	if err := json.Unmarshal([]byte(source), &decoded); err != nil {
+		return zero, fmt.Errorf("failed to decode step input as %T: %w", zero, err)
+	}
+	return decoded, nil
+}
+
+// SetTypedOutput stores a typed step output on the execution context.
+// The value is available to the next step via TypedInput and to callers via
+// StepOutput; Output is set to its JSON encoding so agent steps can consume it.
+// SetTypedOutput 在执行上下文中存储类型化的步骤输出。
+func SetTypedOutput(execCtx *ExecutionContext, stepID string, value interface{}) error {
+	text, ok := value.(string)
+	if !ok {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to encode output of step %s: %w", stepID, err)
+		}
+		text = string(encoded)
+	}
+
+	execCtx.Output = text
+	execCtx.Set(typedOutputKey, typedOutput{value: value, text: text})
+	execCtx.Set(typedStepOutputKey(stepID), value)
+	return nil
+}
+
+// StepOutput returns the typed output recorded for the given step.
+// StepOutput 返回给定步骤记录的类型化输出。
+func StepOutput[T any](execCtx *ExecutionContext, stepID string) (T, bool) {
+	var zero T
+	raw, ok := execCtx.Get(typedStepOutputKey(stepID))
+	if !ok {
+		return zero, false
+	}
+	typed, ok := raw.(T)
+	return typed, ok
+}
+
+const typedOutputKey = "typed_output"
+
+// typedOutput remembers the text a typed value was rendered to, so a later
+// agent step that rewrites Output invalidates the typed value.
+type typedOutput struct {
+	value interface{}
+	text  string
+}
+
+func latestTypedOutput(execCtx *ExecutionContext) (interface{}, bool) {
+	raw, ok := execCtx.Get(typedOutputKey)
+	if !ok {
+		return nil, false
+	}
+	typed, ok := raw.(typedOutput)
+	if !ok || typed.text != execCtx.Output {
+		return nil, false
+	}
+	return typed.value, true
+}
+
+func typedStepOutputKey(stepID string) string {
+	return fmt.Sprintf("step_%s_typed_output", stepID)
+}
+
+// ArgsFunc builds tool arguments from the execution context
+// ArgsFunc 从执行上下文构建工具参数
+type ArgsFunc func(execCtx *ExecutionContext) (map[string]interface{}, error)
+
+// ToolStep executes a single toolkit function as a workflow node
+// ToolStep 将单个工具包函数作为工作流节点执行
+type ToolStep struct {
+	ID           string
+	Name         string
+	Toolkit      toolkit.Toolkit
+	FunctionName string
+	Args         ArgsFunc
+}
+
+// ToolStepConfig contains tool step configuration
+// ToolStepConfig 包含工具步骤配置
+type ToolStepConfig struct {
+	ID           string
+	Name         string
+	Toolkit      toolkit.Toolkit
+	FunctionName string
+
+	// Args builds the tool arguments. When nil, the step input is parsed as a
+	// JSON object.
+	// Args 构建工具参数。为 nil 时，步骤输入将被解析为 JSON 对象。
+	Args ArgsFunc
+}
+
+// NewToolStep creates a new tool step
+// NewToolStep 创建新的工具步骤
+func NewToolStep(config ToolStepConfig) (*ToolStep, error) {
+	if config.Toolkit == nil {
+		return nil, fmt.Errorf("toolkit is required for tool step")
+	}
+
+	if config.FunctionName == "" {
+		return nil, fmt.Errorf("function name is required for tool step")
+	}
+
+	if _, ok := config.Toolkit.Functions()[config.FunctionName]; !ok {
+		return nil, fmt.Errorf("function %s not found in toolkit %s", config.FunctionName, config.Toolkit.Name())
+	}
+
+	if config.ID == "" {
+		config.ID = fmt.Sprintf("tool-%s", config.FunctionName)
+	}
+
+	if config.Name == "" {
+		config.Name = config.ID
+	}
+
+	return &ToolStep{
+		ID:           config.ID,
+		Name:         config.Name,
+		Toolkit:      config.Toolkit,
+		FunctionName: config.FunctionName,
+		Args:         config.Args,
+	}, nil
+}
+
+// Execute invokes the tool and records its result
+// Execute 调用工具并记录其结果
+func (t *ToolStep) Execute(ctx context.Context, execCtx *ExecutionContext) (*ExecutionContext, error) {
+	fn := t.Toolkit.Functions()[t.FunctionName]
+	if fn == nil {
+		return nil, fmt.Errorf("step %s: function %s not found", t.ID, t.FunctionName)
+	}
+
+	var (
+		args map[string]interface{}
+		err  error
+	)
+	if t.Args != nil {
+		args, err = t.Args(execCtx)
+	} else {
+		args, err = defaultToolArgs(execCtx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("step %s: failed to build arguments: %w", t.ID, err)
+	}
+
+	for name, param := range fn.Parameters {
+		if param.Required {
+			if _, ok := args[name]; !ok {
+				return nil, fmt.Errorf("step %s: required parameter %s missing", t.ID, name)
+			}
+		}
+	}
+
+	result, err := fn.Handler(ctx, args)
+	if err != nil {
+		return nil, fmt.Errorf("step %s execution failed: %w", t.ID, err)
+	}
+
+	if err := SetTypedOutput(execCtx, t.ID, result); err != nil {
+		return nil, err
+	}
+	execCtx.Set(fmt.Sprintf("step_%s_output", t.ID), execCtx.Output)
+	return execCtx, nil
+}
+
+// GetID returns the step ID
+func (t *ToolStep) GetID() string {
+	return t.ID
+}
+
+// GetType returns the node type
+func (t *ToolStep) GetType() NodeType {
+	return NodeTypeTool
+}
+
+func defaultToolArgs(execCtx *ExecutionContext) (map[string]interface{}, error) {
+	if value, ok := latestTypedOutput(execCtx); ok {
+		if args, ok := value.(map[string]interface{}); ok {
+			return args, nil
+		}
+	}
+
+	source := execCtx.Output
+	if source == "" {
+		source = execCtx.Input
+	}
+	if source == "" {
+		return map[string]interface{}{}, nil
+	}
+	return toolkit.ParseArguments(source)
+}
@@ -0,0 +1,186 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+)
+
+type orderRequest struct {
+	Item     string `json:"item"`
+	Quantity int    `json:"quantity"`
+}
+
+type orderQuote struct {
+	Item  string  `json:"item"`
+	Total float64 `json:"total"`
+}
+
+func TestNewFunctionStep_RequiresFunc(t *testing.T) {
+	if _, err := NewFunctionStep(FunctionStepConfig{ID: "f"}); err == nil {
+		t.Fatal("expected error for missing function")
+	}
+}
+
+func TestFunctionStep_Execute(t *testing.T) {
+	step, err := NewFunctionStep(FunctionStepConfig{
+		ID: "upper",
+		Func: func(_ context.Context, execCtx *ExecutionContext) (*ExecutionContext, error) {
+			execCtx.Output = strings.ToUpper(execCtx.Input)
+			return execCtx, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewFunctionStep() error = %v", err)
+	}
+	if step.GetType() != NodeTypeFunction {
+		t.Fatalf("expected type %s, got %s", NodeTypeFunction, step.GetType())
+	}
+
+	execCtx, err := step.Execute(context.Background(), NewExecutionContext("hello"))
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if execCtx.Output != "HELLO" {
+		t.Fatalf("expected HELLO, got %s", execCtx.Output)
+	}
+	if v, _ := execCtx.Get("step_upper_output"); v != "HELLO" {
+		t.Fatalf("expected step output to be recorded, got %v", v)
+	}
+}
+
+func TestFunctionStep_PropagatesError(t *testing.T) {
+	step, _ := NewFunctionStep(FunctionStepConfig{
+		ID: "fail",
+		Func: func(context.Context, *ExecutionContext) (*ExecutionContext, error) {
+			return nil, errors.New("boom")
+		},
+	})
+
+	if _, err := step.Execute(context.Background(), NewExecutionContext("x")); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected wrapped error, got %v", err)
+	}
+}
+
+func TestTypedStep_ChainsTypedValues(t *testing.T) {
+	quote, err := NewTypedStep("quote", func(_ context.Context, req orderRequest) (orderQuote, error) {
+		return orderQuote{Item: req.Item, Total: float64(req.Quantity) * 2.5}, nil
+	})
+	if err != nil {
+		t.Fatalf("NewTypedStep() error = %v", err)
+	}
+	format, err := NewTypedStep("format", func(_ context.Context, q orderQuote) (string, error) {
+		if q.Item == "" {
+			return "", errors.New("typed value not propagated")
+		}
+		return fmt.Sprintf("%s: %.0f", q.Item, q.Total), nil
+	})
+	if err != nil {
+		t.Fatalf("NewTypedStep() error = %v", err)
+	}
+
+	wf, _ := New(Config{Name: "typed", Steps: []Node{quote, format}})
+	execCtx, err := wf.Run(context.Background(), `{"item":"apple","quantity":4}`, "sess-typed")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if execCtx.Output != "apple: 10" {
+		t.Fatalf("unexpected output %q", execCtx.Output)
+	}
+
+	q, ok := StepOutput[orderQuote](execCtx, "quote")
+	if !ok || q.Total != 10 {
+		t.Fatalf("expected typed output for quote step, got %+v (ok=%v)", q, ok)
+	}
+}
+
+func TestTypedInput_IgnoresStaleTypedOutput(t *testing.T) {
+	execCtx := NewExecutionContext("")
+	if err := SetTypedOutput(execCtx, "first", orderQuote{Item: "stale"}); err != nil {
+		t.Fatalf("SetTypedOutput() error = %v", err)
+	}
+
+	// Simulate an agent step overwriting the textual output.
+	execCtx.Output = `{"item":"fresh","total":1}`
+
+	got, err := TypedInput[orderQuote](execCtx)
+	if err != nil {
+		t.Fatalf("TypedInput() error = %v", err)
+	}
+	if got.Item != "fresh" {
+		t.Fatalf("expected decoded output to win over stale typed value, got %q", got.Item)
+	}
+}
+
+func TestTypedInput_DecodeError(t *testing.T) {
+	execCtx := NewExecutionContext("not json")
+	if _, err := TypedInput[orderRequest](execCtx); err == nil {
+		t.Fatal("expected decode error")
+	}
+}
+
+func newEchoToolkit() *toolkit.BaseToolkit {
+	tk := toolkit.NewBaseToolkit("echo")
+	tk.RegisterFunction(&toolkit.Function{
+		Name: "echo",
+		Parameters: map[string]toolkit.Parameter{
+			"text": {Type: "string", Required: true},
+		},
+		Handler: func(_ context.Context, args map[string]interface{}) (interface{}, error) {
+			return map[string]interface{}{"echo": args["text"]}, nil
+		},
+	})
+	return tk
+}
+
+func TestNewToolStep_Validation(t *testing.T) {
+	if _, err := NewToolStep(ToolStepConfig{FunctionName: "echo"}); err == nil {
+		t.Fatal("expected error for missing toolkit")
+	}
+	if _, err := NewToolStep(ToolStepConfig{Toolkit: newEchoToolkit(), FunctionName: "missing"}); err == nil {
+		t.Fatal("expected error for unknown function")
+	}
+}
+
+func TestToolStep_Execute(t *testing.T) {
+	step, err := NewToolStep(ToolStepConfig{
+		Toolkit:      newEchoToolkit(),
+		FunctionName: "echo",
+		Args: func(execCtx *ExecutionContext) (map[string]interface{}, error) {
+			return map[string]interface{}{"text": execCtx.Input}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewToolStep() error = %v", err)
+	}
+	if step.GetID() != "tool-echo" || step.GetType() != NodeTypeTool {
+		t.Fatalf("unexpected step identity %s/%s", step.GetID(), step.GetType())
+	}
+
+	execCtx, err := step.Execute(context.Background(), NewExecutionContext("ping"))
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if execCtx.Output != `{"echo":"ping"}` {
+		t.Fatalf("unexpected output %s", execCtx.Output)
+	}
+	result, ok := StepOutput[map[string]interface{}](execCtx, "tool-echo")
+	if !ok || result["echo"] != "ping" {
+		t.Fatalf("expected typed tool result, got %v", result)
+	}
+}
+
+func TestToolStep_DefaultArgsFromJSONInput(t *testing.T) {
+	step, _ := NewToolStep(ToolStepConfig{Toolkit: newEchoToolkit(), FunctionName: "echo"})
+
+	if _, err := step.Execute(context.Background(), NewExecutionContext(`{"text":"hi"}`)); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if _, err := step.Execute(context.Background(), NewExecutionContext(`{}`)); err == nil {
+		t.Fatal("expected missing required parameter error")
+	}
+}
@@ -12,6 +12,7 @@ type runOptions struct {
 	userID         string
 	sessionState   map[string]interface{}
 	resumeFromStep string
+	resumeOutput   string
 	mediaPayload   []media.Attachment
 	metadata       map[string]interface{}
 	mediaError     error
@@ -39,6 +40,13 @@ func WithResumeFrom(stepID string) RunOption {
 	}
 }
 
+// withResumeOutput restores the output of the last completed step when resuming.
+func withResumeOutput(output string) RunOption {
+	return func(o *runOptions) {
+		o.resumeOutput = output
+	}
+}
+
 // WithMediaPayload attaches media payload to the execution context.
 func WithMediaPayload(payload interface{}) RunOption {
 	return func(o *runOptions) {
@@ -81,6 +81,9 @@ type WorkflowSession struct {
 
 	// Cancellations captures cancellation snapshots for later recovery.
 	Cancellations []*CancellationRecord `json:"cancellations,omitempty"`
+
+	// Checkpoints holds the latest resumable checkpoint per run ID.
+	Checkpoints map[string]*Checkpoint `json:"checkpoints,omitempty"`
 }
 
 // NewWorkflowSession creates a new workflow session
@@ -96,6 +99,7 @@ func NewWorkflowSession(sessionID, workflowID, userID string) *WorkflowSession {
 		UpdatedAt:     now,
 		Metadata:      make(map[string]interface{}),
 		Cancellations: make([]*CancellationRecord, 0),
+		Checkpoints:   make(map[string]*Checkpoint),
 	}
 }
 
@@ -26,6 +26,10 @@ type Workflow struct {
 	historyStore      WorkflowStorage
 	numHistoryRuns    int
 	addHistoryToSteps bool
+
+	// enableCheckpoints records a checkpoint after every top-level step
+	// enableCheckpoints 在每个顶层步骤后记录检查点
+	enableCheckpoints bool
 }
 
 // Node represents a node in the workflow graph
@@ -69,6 +73,12 @@ type Config struct {
 	// AddHistoryToSteps automatically adds history context to all steps
 	// AddHistoryToSteps 自动将历史上下文添加到所有步骤
 	AddHistoryToSteps bool `json:"add_history_to_steps"`
+
+	// EnableCheckpoints persists a checkpoint to HistoryStore after every
+	// top-level step so interrupted runs can be continued with Resume
+	// EnableCheckpoints 在每个顶层步骤后将检查点持久化到 HistoryStore,
+	// 以便使用 Resume 继续中断的运行
+	EnableCheckpoints bool `json:"enable_checkpoints"`
 }
 
 // New creates a new workflow
@@ -88,7 +98,7 @@ func New(config Config) (*Workflow, error) {
 
 	// 历史配置验证和默认值
 	// History configuration validation and defaults
-	if config.EnableHistory && config.HistoryStore == nil {
+	if (config.EnableHistory || config.EnableCheckpoints) && config.HistoryStore == nil {
 		// 使用默认内存存储
 		// Use default memory storage
 		config.HistoryStore = NewMemoryStorage(100)
@@ -107,6 +117,7 @@ func New(config Config) (*Workflow, error) {
 		historyStore:      config.HistoryStore,
 		numHistoryRuns:    config.NumHistoryRuns,
 		addHistoryToSteps: config.AddHistoryToSteps,
+		enableCheckpoints: config.EnableCheckpoints,
 	}, nil
 }
 
@@ -160,6 +171,9 @@ func (w *Workflow) Run(ctx context.Context, input string, sessionID string, opts
 	execCtx.SetRunContextMetadata(runContextMetadata(runCtx))
 	execCtx.ApplySessionState(options.sessionState)
 	execCtx.MergeMetadata(options.metadata)
+	if options.resumeOutput != "" {
+		execCtx.Output = options.resumeOutput
+	}
 
 	if len(options.mediaPayload) > 0 {
 		execCtx.SetSessionState("media_payload", options.mediaPayload)
@@ -280,6 +294,17 @@ func (w *Workflow) Run(ctx context.Context, input string, sessionID string, opts
 				workflowRun.AddEvents(events)
 			}
 		}
+
+		if w.enableCheckpoints {
+			nextStepID := ""
+			if idx+1 < len(w.Steps) {
+				nextStepID = w.Steps[idx+1].GetID()
+			}
+			checkpoint := newCheckpoint(runCtx.RunID, currentStepID, nextStepID, execCtx)
+			if err := w.saveCheckpoint(ctx, sessionID, checkpoint); err != nil {
+				w.logger.Error("failed to save checkpoint", "step_id", currentStepID, "error", err)
+			}
+		}
 	}
 
 	if workflowRun != nil {
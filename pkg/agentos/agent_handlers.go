@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
@@ -12,7 +13,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/jholhewres/agent-go/pkg/agentgo/agent"
+	"github.com/jholhewres/agent-go/pkg/agentgo/events"
 	"github.com/jholhewres/agent-go/pkg/agentgo/media"
+	"github.com/jholhewres/agent-go/pkg/agentgo/ratelimit"
 	"github.com/jholhewres/agent-go/pkg/agentgo/run"
 	"github.com/jholhewres/agent-go/pkg/agentgo/session"
 	"github.com/jholhewres/agent-go/pkg/agentgo/types"
@@ -77,6 +80,7 @@ type AgentRunResponse struct {
 
 // handleAgentRun runs an agent with the given input
 // POST /api/v1/agents/:id/run
+// POST /api/v1/agents/:id/runs
 func (s *Server) handleAgentRun(c *gin.Context) {
 	agentID := c.Param("id")
 	if agentID == "" {
@@ -172,17 +176,24 @@ func (s *Server) handleAgentRun(c *gin.Context) {
 	}
 
 	// Run the agent (inject a run-context id for correlation)
-	baseCtx := ctxWithRunContext
+	baseCtx := withSessionState(ctxWithRunContext, sess)
+	s.emitRunStarted(agentID, runCtx.RunID, req.SessionID, req.Input)
 	// Run the agent
 	output, err := ag.Run(baseCtx, req.Input)
+	s.emitRunFinished(agentID, runCtx.RunID, req.SessionID, output, err)
 
 	if err != nil {
 		s.logger.Error("agent run failed", "error", err, "agent_id", agentID)
 		status := http.StatusInternalServerError
 		errorCode := "EXECUTION_ERROR"
+		var limitErr *ratelimit.LimitError
 		if agnoErr, ok := err.(*types.AgnoError); ok && agnoErr.Code == types.ErrCodeCancelled {
 			status = http.StatusRequestTimeout
 			errorCode = string(types.ErrCodeCancelled)
+		} else if ok && agnoErr.Code == types.ErrCodeRateLimitError && errors.As(err, &limitErr) {
+			status = http.StatusTooManyRequests
+			errorCode = string(types.ErrCodeRateLimitError)
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(limitErr.RetryAfter.Seconds()))))
 		}
 		c.JSON(status, ErrorResponse{
 			Error:   "agent execution failed",
@@ -231,8 +242,10 @@ func (s *Server) handleAgentRun(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// shouldStreamRequest reports whether the client asked for SSE, through the
+// body flag, the stream_events query parameter or the Accept header
 func shouldStreamRequest(c *gin.Context, bodyFlag bool) bool {
-	if bodyFlag {
+	if bodyFlag || acceptsEventStream(c.GetHeader("Accept")) {
 		return true
 	}
 	query := c.Query("stream_events")
@@ -246,6 +259,17 @@ func shouldStreamRequest(c *gin.Context, bodyFlag bool) bool {
 	return val
 }
 
+// acceptsEventStream reports whether an Accept header prefers text/event-stream
+func acceptsEventStream(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, _ := strings.Cut(part, ";")
+		if strings.EqualFold(strings.TrimSpace(mediaType), "text/event-stream") {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *Server) streamAgentRun(
 	c *gin.Context,
 	agentID string,
@@ -284,8 +308,7 @@ func (s *Server) streamAgentRun(
 	c.Header("Connection", "keep-alive")
 	c.Header("X-Accel-Buffering", "no")
 
-	flusher, ok := c.Writer.(http.Flusher)
-	if !ok {
+	if _, ok := c.Writer.(http.Flusher); !ok {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "streaming_not_supported",
 			"message": "streaming is not supported",
@@ -295,6 +318,13 @@ func (s *Server) streamAgentRun(
 
 	ctx, cancel := context.WithTimeout(ctxWithRunContext, 5*time.Minute)
 	defer cancel()
+	if s.config != nil && s.config.StreamOptions != nil {
+		ctx = agent.WithStreamOptions(ctx, *s.config.StreamOptions)
+	}
+
+	// A client that stops reading cancels its run like a disconnect does, instead of
+	// holding the run (and the model stream) open until the timeout
+	stream := s.newSSEStream(c.Writer, cancel)
 
 	runCtxID := ""
 	if baseRunCtx != nil {
@@ -305,6 +335,24 @@ func (s *Server) streamAgentRun(
 	}
 	ctx = agent.WithRunContext(ctx, runCtxID)
 
+	s.runStream(ctx, stream, filter, agentID, ag, req, attachments, sess, runCtxID, false)
+}
+
+// runStream runs ag with RunStream and sends its events to stream until the run
+// ends; toolCalls adds a tool call event as soon as each call finishes, which
+// SSE clients do not receive
+func (s *Server) runStream(
+	ctx context.Context,
+	stream eventSink,
+	filter *EventFilter,
+	agentID string,
+	ag *agent.Agent,
+	req AgentRunRequest,
+	attachments []media.Attachment,
+	sess *session.Session,
+	runCtxID string,
+	toolCalls bool,
+) {
 	startEvent := NewEvent(EventRunStart, RunStartData{
 		Input:     req.Input,
 		SessionID: req.SessionID,
@@ -314,12 +362,22 @@ func (s *Server) streamAgentRun(
 	startEvent.SessionID = req.SessionID
 	startEvent.RunContextID = runCtxID
 	if filter.ShouldSend(startEvent) {
-		s.sendSSE(c.Writer, startEvent)
-		flusher.Flush()
+		stream.send(startEvent)
 	}
 
-	result, err := ag.RunStream(ctx, req.Input)
+	runCtx := withSessionState(ctx, sess)
+	if toolCalls {
+		bus := events.NewBus()
+		defer events.On(bus, func(_ context.Context, e *events.ToolExecuted) {
+			s.sendToolCall(stream, filter, agentID, req.SessionID, runCtxID, e)
+		})()
+		runCtx = agent.WithEventBus(runCtx, bus)
+	}
+
+	s.emitRunStarted(agentID, runCtxID, req.SessionID, req.Input)
+	result, err := ag.RunStream(runCtx, req.Input)
 	if err != nil {
+		s.emitRunFinished(agentID, runCtxID, req.SessionID, nil, err)
 		code := "AGENT_ERROR"
 		if agnoErr, ok := err.(*types.AgnoError); ok {
 			code = string(agnoErr.Code)
@@ -332,8 +390,7 @@ func (s *Server) streamAgentRun(
 		errorEvent.SessionID = req.SessionID
 		errorEvent.RunContextID = runCtxID
 		if filter.ShouldSend(errorEvent) {
-			s.sendSSE(c.Writer, errorEvent)
-			flusher.Flush()
+			stream.send(errorEvent)
 		}
 		return
 	}
@@ -341,10 +398,12 @@ func (s *Server) streamAgentRun(
 	eventsCh := result.Events
 	doneCh := result.Done
 	tokenIndex := 0
+	reasoningIndex := 0
 
 	for {
 		select {
 		case <-ctx.Done():
+			s.emitRunFinished(agentID, runCtxID, req.SessionID, nil, ctx.Err())
 			errorEvent := NewEvent(EventError, ErrorData{
 				Error: ctx.Err().Error(),
 				Code:  "CONTEXT_CANCELED",
@@ -353,8 +412,7 @@ func (s *Server) streamAgentRun(
 			errorEvent.SessionID = req.SessionID
 			errorEvent.RunContextID = runCtxID
 			if filter.ShouldSend(errorEvent) {
-				s.sendSSE(c.Writer, errorEvent)
-				flusher.Flush()
+				stream.send(errorEvent)
 			}
 			return
 
@@ -367,6 +425,24 @@ func (s *Server) streamAgentRun(
 				continue
 			}
 
+			if reasoningEvent, ok := evt.(*run.RunReasoningEvent); ok {
+				if reasoningEvent.Content == "" {
+					continue
+				}
+				deltaEvent := NewEvent(EventReasoningDelta, ReasoningDeltaData{
+					Content: reasoningEvent.Content,
+					Index:   reasoningIndex,
+				})
+				reasoningIndex++
+				deltaEvent.AgentID = agentID
+				deltaEvent.SessionID = req.SessionID
+				deltaEvent.RunContextID = runCtxID
+				if filter.ShouldSend(deltaEvent) {
+					stream.send(deltaEvent)
+				}
+				continue
+			}
+
 			contentEvent, ok := evt.(*run.RunContentEvent)
 			if !ok || strings.TrimSpace(contentEvent.Content) == "" {
 				continue
@@ -385,8 +461,7 @@ func (s *Server) streamAgentRun(
 				tokenEvent.SessionID = req.SessionID
 				tokenEvent.RunContextID = runCtxID
 				if filter.ShouldSend(tokenEvent) {
-					s.sendSSE(c.Writer, tokenEvent)
-					flusher.Flush()
+					stream.send(tokenEvent)
 				}
 			}
 
@@ -401,6 +476,7 @@ func (s *Server) streamAgentRun(
 
 			output := res.Output
 			err := res.Err
+			s.emitRunFinished(agentID, runCtxID, req.SessionID, output, err)
 
 			if err != nil {
 				code := "AGENT_ERROR"
@@ -415,8 +491,7 @@ func (s *Server) streamAgentRun(
 				errorEvent.SessionID = req.SessionID
 				errorEvent.RunContextID = runCtxID
 				if filter.ShouldSend(errorEvent) {
-					s.sendSSE(c.Writer, errorEvent)
-					flusher.Flush()
+					stream.send(errorEvent)
 				}
 				if output == nil {
 					return
@@ -438,13 +513,47 @@ func (s *Server) streamAgentRun(
 					}
 					cancelUpdate()
 				}
-				s.emitRunEvents(c.Writer, flusher, filter, agentID, req.SessionID, runCtxID, output, ag)
+				s.emitRunEvents(stream, filter, agentID, req.SessionID, runCtxID, output, ag)
 			}
 			return
 		}
 	}
 }
 
+// sendToolCall sends a tool call of the run runContextID. It runs on the run's
+// goroutine when the call finishes, so the event precedes the model's answer
+// to the tool result.
+func (s *Server) sendToolCall(stream eventSink, filter *EventFilter, agentID, sessionID, runContextID string, e *events.ToolExecuted) {
+	if e.RunID != runContextID {
+		return
+	}
+	result := e.Result
+	if e.Error != "" {
+		result = map[string]interface{}{"error": e.Error}
+	}
+	evt := NewEvent(EventToolCall, ToolCallData{
+		ToolName:  e.Tool,
+		Arguments: e.Arguments,
+		Result:    result,
+	})
+	evt.AgentID = agentID
+	evt.SessionID = sessionID
+	evt.RunContextID = runContextID
+	if filter.ShouldSend(evt) {
+		stream.send(evt)
+	}
+}
+
+// withSessionState runs the agent on the stored state of sess, so one agent
+// serves many sessions without mixing their state. AddRun keeps the state the
+// run ended with.
+func withSessionState(ctx context.Context, sess *session.Session) context.Context {
+	if sess == nil {
+		return ctx
+	}
+	return agent.WithSessionState(ctx, agent.NewSessionState(sess.State))
+}
+
 func splitCommaQuery(value string) []string {
 	if value == "" {
 		return nil
@@ -492,10 +601,14 @@ func deriveRunContext(ctx context.Context, payload *RunContextRequest, fallbackS
 // GET /api/v1/agents
 func (s *Server) handleListAgents(c *gin.Context) {
 	agents := s.agentRegistry.List()
+	key, _ := APIKeyFromContext(c)
 
 	// Convert to response format
 	agentList := make([]map[string]interface{}, 0, len(agents))
 	for id, ag := range agents {
+		if key != nil && !key.AllowsAgent(id) {
+			continue
+		}
 		agentList = append(agentList, map[string]interface{}{
 			"id":   id,
 			"name": ag.Name,
@@ -0,0 +1,232 @@
+package agentos
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyContextKey is the gin context key holding the authenticated *APIKey.
+const apiKeyContextKey = "agentos.api_key"
+
+// APIKeyFromContext returns the API key that authenticated the request, if any.
+func APIKeyFromContext(c *gin.Context) (*APIKey, bool) {
+	v, ok := c.Get(apiKeyContextKey)
+	if !ok {
+		return nil, false
+	}
+	key, ok := v.(*APIKey)
+	return key, ok
+}
+
+// authorize returns middleware that validates the request's API key and
+// requires scope. Routes carrying an `:id` agent parameter additionally check
+// the key's agent list. When no APIKeyManager is configured it is a no-op.
+func (s *Server) authorize(scope APIKeyScope, agentParam bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.apiKeys == nil {
+			c.Next()
+			return
+		}
+
+		secret := extractAPIKey(c.Request)
+		if secret == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "api key is required",
+				Code:  "UNAUTHORIZED",
+			})
+			return
+		}
+
+		key, err := s.apiKeys.Authenticate(c.Request.Context(), secret)
+		if err != nil {
+			if errors.Is(err, ErrAPIKeyInvalid) || errors.Is(err, ErrAPIKeyRevoked) || errors.Is(err, ErrAPIKeyExpired) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{
+					Error: err.Error(),
+					Code:  "UNAUTHORIZED",
+				})
+				return
+			}
+			s.logger.Error("api key lookup failed", "error", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "failed to validate api key",
+				Code:  "INTERNAL_ERROR",
+			})
+			return
+		}
+
+		if !key.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{
+				Error:   "api key lacks required scope",
+				Message: string(scope),
+				Code:    "FORBIDDEN",
+			})
+			return
+		}
+		if agentParam && !key.AllowsAgent(c.Param("id")) {
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{
+				Error:   "api key is not allowed to access this agent",
+				Message: c.Param("id"),
+				Code:    "FORBIDDEN",
+			})
+			return
+		}
+
+		if err := s.apiKeys.consume(key); err != nil {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, ErrorResponse{
+				Error: err.Error(),
+				Code:  "QUOTA_EXCEEDED",
+			})
+			return
+		}
+
+		c.Set(apiKeyContextKey, key)
+		c.Next()
+	}
+}
+
+// requireAllAgents rejects keys limited to some agents. A team runs agents of
+// its own, so team routes are only open to keys that may access every agent.
+// It runs after authorize.
+func (s *Server) requireAllAgents(c *gin.Context) {
+	if key, ok := APIKeyFromContext(c); ok && !key.AllowsAllAgents() {
+		c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{
+			Error: "api key is limited to specific agents and cannot access teams",
+			Code:  "FORBIDDEN",
+		})
+		return
+	}
+	c.Next()
+}
+
+// extractAPIKey reads the key from `Authorization: Bearer <key>` or `X-API-Key`, or
+// from the `api_key` query parameter of WebSocket handshakes.
+func extractAPIKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if token, ok := strings.CutPrefix(auth, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+	if key := strings.TrimSpace(r.Header.Get("X-API-Key")); key != "" {
+		return key
+	}
+	// Browsers cannot set headers on WebSocket handshakes
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return strings.TrimSpace(r.URL.Query().Get("api_key"))
+	}
+	return ""
+}
+
+// APIKeyCreateRequest is the payload for POST /api/v1/keys.
+type APIKeyCreateRequest struct {
+	Name          string        `json:"name"`
+	Scopes        []APIKeyScope `json:"scopes"`
+	Agents        []string      `json:"agents,omitempty"`
+	MaxRequests   int           `json:"max_requests,omitempty"`
+	WindowSeconds int           `json:"window_seconds,omitempty"`
+	TTLSeconds    int           `json:"ttl_seconds,omitempty"`
+}
+
+// APIKeyResponse describes a stored key. Key is only set on creation.
+type APIKeyResponse struct {
+	*APIKey
+	Key           string `json:"key,omitempty"`
+	MaxRequests   int    `json:"max_requests,omitempty"`
+	WindowSeconds int    `json:"window_seconds,omitempty"`
+}
+
+func newAPIKeyResponse(key *APIKey, secret string) APIKeyResponse {
+	resp := APIKeyResponse{APIKey: key, Key: secret}
+	if key.Quota != nil {
+		resp.MaxRequests = key.Quota.MaxRequests
+		resp.WindowSeconds = int(key.Quota.Window / time.Second)
+	}
+	return resp
+}
+
+// handleCreateAPIKey issues a new API key
+// POST /api/v1/keys
+func (s *Server) handleCreateAPIKey(c *gin.Context) {
+	var req APIKeyCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid request",
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+
+	createReq := CreateAPIKeyRequest{
+		Name:   req.Name,
+		Scopes: req.Scopes,
+		Agents: req.Agents,
+		TTL:    time.Duration(req.TTLSeconds) * time.Second,
+	}
+	if req.MaxRequests != 0 {
+		createReq.Quota = &APIKeyQuota{
+			MaxRequests: req.MaxRequests,
+			Window:      time.Duration(req.WindowSeconds) * time.Second,
+		}
+	}
+
+	secret, key, err := s.apiKeys.Create(c.Request.Context(), createReq)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "failed to create api key",
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, newAPIKeyResponse(key, secret))
+}
+
+// handleListAPIKeys lists issued API keys without their secrets
+// GET /api/v1/keys
+func (s *Server) handleListAPIKeys(c *gin.Context) {
+	keys, err := s.apiKeys.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "failed to list api keys",
+			Message: err.Error(),
+			Code:    "INTERNAL_ERROR",
+		})
+		return
+	}
+
+	list := make([]APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		list = append(list, newAPIKeyResponse(key, ""))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"keys":  list,
+		"count": len(list),
+	})
+}
+
+// handleRevokeAPIKey revokes an API key
+// DELETE /api/v1/keys/:id
+func (s *Server) handleRevokeAPIKey(c *gin.Context) {
+	if err := s.apiKeys.Revoke(c.Request.Context(), c.Param("id")); err != nil {
+		if errors.Is(err, ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "api key not found",
+				Code:  "API_KEY_NOT_FOUND",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "failed to revoke api key",
+			Message: err.Error(),
+			Code:    "INTERNAL_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": c.Param("id"), "revoked": true})
+}
@@ -0,0 +1,369 @@
+package agentos
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKeyScope names a permission granted to an API key.
+type APIKeyScope string
+
+const (
+	// ScopeAgentsRead allows listing agents and inspecting team tools.
+	ScopeAgentsRead APIKeyScope = "agents:read"
+	// ScopeAgentsRun allows running agents.
+	ScopeAgentsRun APIKeyScope = "agents:run"
+	// ScopeSessions allows creating, reading and modifying sessions.
+	ScopeSessions APIKeyScope = "sessions"
+	// ScopeKnowledgeRead allows knowledge search and configuration lookups.
+	ScopeKnowledgeRead APIKeyScope = "knowledge:read"
+	// ScopeKnowledgeWrite allows knowledge ingestion.
+	ScopeKnowledgeWrite APIKeyScope = "knowledge:write"
+	// ScopeAdmin grants every scope, including API key management.
+	ScopeAdmin APIKeyScope = "admin"
+)
+
+// apiKeyPrefix marks plaintext keys issued by AgentOS so they are easy to spot
+// in logs and secret scanners.
+const apiKeyPrefix = "ago_"
+
+var (
+	// ErrAPIKeyNotFound is returned when no key matches the lookup.
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	// ErrAPIKeyInvalid is returned when a presented key is malformed or unknown.
+	ErrAPIKeyInvalid = errors.New("invalid api key")
+	// ErrAPIKeyRevoked is returned when a presented key has been revoked.
+	ErrAPIKeyRevoked = errors.New("api key revoked")
+	// ErrAPIKeyExpired is returned when a presented key is past its expiry.
+	ErrAPIKeyExpired = errors.New("api key expired")
+	// ErrAPIKeyQuotaExceeded is returned when a key has used up its quota window.
+	ErrAPIKeyQuotaExceeded = errors.New("api key quota exceeded")
+)
+
+// APIKeyQuota limits how many requests a key may make per window.
+type APIKeyQuota struct {
+	// MaxRequests is the number of requests allowed per window (0 = unlimited).
+	MaxRequests int
+	// Window is the length of the fixed quota window (default: 1 minute).
+	Window time.Duration
+}
+
+// APIKey describes an issued key. Only the SHA-256 hash of the secret is
+// stored; the plaintext is returned once by APIKeyManager.Create.
+type APIKey struct {
+	ID        string        `json:"id"`
+	Name      string        `json:"name,omitempty"`
+	Prefix    string        `json:"prefix"`
+	Hash      string        `json:"-"`
+	Scopes    []APIKeyScope `json:"scopes"`
+	Agents    []string      `json:"agents,omitempty"`
+	Quota     *APIKeyQuota  `json:"quota,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+	ExpiresAt *time.Time    `json:"expires_at,omitempty"`
+	RevokedAt *time.Time    `json:"revoked_at,omitempty"`
+}
+
+// HasScope reports whether the key grants scope. ScopeAdmin implies all scopes.
+func (k *APIKey) HasScope(scope APIKeyScope) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsAllAgents reports whether the key has no agent restriction.
+func (k *APIKey) AllowsAllAgents() bool {
+	return k.AllowsAgent("*")
+}
+
+// AllowsAgent reports whether the key may access agentID. Keys without an
+// agent list may access every agent.
+func (k *APIKey) AllowsAgent(agentID string) bool {
+	if len(k.Agents) == 0 {
+		return true
+	}
+	for _, id := range k.Agents {
+		if id == agentID || id == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *APIKey) clone() *APIKey {
+	if k == nil {
+		return nil
+	}
+	cp := *k
+	cp.Scopes = append([]APIKeyScope(nil), k.Scopes...)
+	cp.Agents = append([]string(nil), k.Agents...)
+	if k.Quota != nil {
+		q := *k.Quota
+		cp.Quota = &q
+	}
+	return &cp
+}
+
+// APIKeyStore persists API key records.
+type APIKeyStore interface {
+	// Save inserts or replaces the key record.
+	Save(ctx context.Context, key *APIKey) error
+	// Get returns the key with the given ID.
+	Get(ctx context.Context, id string) (*APIKey, error)
+	// GetByHash returns the key whose secret hashes to hash.
+	GetByHash(ctx context.Context, hash string) (*APIKey, error)
+	// List returns all stored keys.
+	List(ctx context.Context) ([]*APIKey, error)
+}
+
+// MemoryAPIKeyStore is an in-memory APIKeyStore.
+type MemoryAPIKeyStore struct {
+	mu     sync.RWMutex
+	keys   map[string]*APIKey
+	byHash map[string]string
+}
+
+// NewMemoryAPIKeyStore creates an empty in-memory key store.
+func NewMemoryAPIKeyStore() *MemoryAPIKeyStore {
+	return &MemoryAPIKeyStore{
+		keys:   make(map[string]*APIKey),
+		byHash: make(map[string]string),
+	}
+}
+
+// Save implements APIKeyStore.
+func (m *MemoryAPIKeyStore) Save(ctx context.Context, key *APIKey) error {
+	if key == nil || key.ID == "" {
+		return fmt.Errorf("api key ID cannot be empty")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if prev, ok := m.keys[key.ID]; ok && prev.Hash != key.Hash {
+		delete(m.byHash, prev.Hash)
+	}
+	m.keys[key.ID] = key.clone()
+	m.byHash[key.Hash] = key.ID
+	return nil
+}
+
+// Get implements APIKeyStore.
+func (m *MemoryAPIKeyStore) Get(ctx context.Context, id string) (*APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	key, ok := m.keys[id]
+	if !ok {
+		return nil, ErrAPIKeyNotFound
+	}
+	return key.clone(), nil
+}
+
+// GetByHash implements APIKeyStore.
+func (m *MemoryAPIKeyStore) GetByHash(ctx context.Context, hash string) (*APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	id, ok := m.byHash[hash]
+	if !ok {
+		return nil, ErrAPIKeyNotFound
+	}
+	return m.keys[id].clone(), nil
+}
+
+// List implements APIKeyStore.
+func (m *MemoryAPIKeyStore) List(ctx context.Context) ([]*APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]*APIKey, 0, len(m.keys))
+	for _, key := range m.keys {
+		out = append(out, key.clone())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+// CreateAPIKeyRequest describes a key to issue.
+type CreateAPIKeyRequest struct {
+	Name   string
+	Scopes []APIKeyScope
+	// Agents restricts the key to the listed agent IDs (empty = all agents).
+	Agents []string
+	Quota  *APIKeyQuota
+	// TTL sets an expiry relative to creation (0 = never expires).
+	TTL time.Duration
+}
+
+type quotaWindow struct {
+	start time.Time
+	count int
+}
+
+// APIKeyManager issues, revokes and validates API keys.
+type APIKeyManager struct {
+	store APIKeyStore
+	now   func() time.Time
+
+	mu    sync.Mutex
+	usage map[string]*quotaWindow
+}
+
+// NewAPIKeyManager creates a manager backed by store. A nil store falls back
+// to an in-memory store.
+func NewAPIKeyManager(store APIKeyStore) *APIKeyManager {
+	if store == nil {
+		store = NewMemoryAPIKeyStore()
+	}
+	return &APIKeyManager{
+		store: store,
+		now:   time.Now,
+		usage: make(map[string]*quotaWindow),
+	}
+}
+
+// Create issues a new key and returns its plaintext secret alongside the
+// stored record. The secret cannot be recovered afterwards.
+func (m *APIKeyManager) Create(ctx context.Context, req CreateAPIKeyRequest) (string, *APIKey, error) {
+	if len(req.Scopes) == 0 {
+		return "", nil, fmt.Errorf("api key requires at least one scope")
+	}
+	for _, scope := range req.Scopes {
+		if !validScope(scope) {
+			return "", nil, fmt.Errorf("unknown api key scope %q", scope)
+		}
+	}
+	if req.Quota != nil && req.Quota.MaxRequests < 0 {
+		return "", nil, fmt.Errorf("api key quota cannot be negative")
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+	secret := apiKeyPrefix + hex.EncodeToString(buf)
+
+	now := m.now().UTC()
+	key := &APIKey{
+		ID:        uuid.NewString(),
+		Name:      req.Name,
+		Prefix:    secret[:len(apiKeyPrefix)+8],
+		Hash:      hashAPIKey(secret),
+		Scopes:    append([]APIKeyScope(nil), req.Scopes...),
+		Agents:    append([]string(nil), req.Agents...),
+		CreatedAt: now,
+	}
+	if req.Quota != nil {
+		q := *req.Quota
+		if q.Window <= 0 {
+			q.Window = time.Minute
+		}
+		key.Quota = &q
+	}
+	if req.TTL > 0 {
+		expires := now.Add(req.TTL)
+		key.ExpiresAt = &expires
+	}
+
+	if err := m.store.Save(ctx, key); err != nil {
+		return "", nil, fmt.Errorf("failed to store api key: %w", err)
+	}
+	return secret, key.clone(), nil
+}
+
+// Revoke marks the key as revoked. Revoking an already revoked key is a no-op.
+func (m *APIKeyManager) Revoke(ctx context.Context, id string) error {
+	key, err := m.store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if key.RevokedAt != nil {
+		return nil
+	}
+	now := m.now().UTC()
+	key.RevokedAt = &now
+	if err := m.store.Save(ctx, key); err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	m.mu.Lock()
+	delete(m.usage, id)
+	m.mu.Unlock()
+	return nil
+}
+
+// List returns every stored key, including revoked ones.
+func (m *APIKeyManager) List(ctx context.Context) ([]*APIKey, error) {
+	return m.store.List(ctx)
+}
+
+// Authenticate resolves a plaintext secret to its key record, rejecting
+// unknown, revoked and expired keys.
+func (m *APIKeyManager) Authenticate(ctx context.Context, secret string) (*APIKey, error) {
+	secret = strings.TrimSpace(secret)
+	if !strings.HasPrefix(secret, apiKeyPrefix) {
+		return nil, ErrAPIKeyInvalid
+	}
+	hash := hashAPIKey(secret)
+	key, err := m.store.GetByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, ErrAPIKeyNotFound) {
+			return nil, ErrAPIKeyInvalid
+		}
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hash)) != 1 {
+		return nil, ErrAPIKeyInvalid
+	}
+	now := m.now()
+	if key.RevokedAt != nil {
+		return nil, ErrAPIKeyRevoked
+	}
+	if key.ExpiresAt != nil && !now.Before(*key.ExpiresAt) {
+		return nil, ErrAPIKeyExpired
+	}
+	return key, nil
+}
+
+// consume records one request against the key's quota.
+func (m *APIKeyManager) consume(key *APIKey) error {
+	if key.Quota == nil || key.Quota.MaxRequests == 0 {
+		return nil
+	}
+	now := m.now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	win, ok := m.usage[key.ID]
+	if !ok || now.Sub(win.start) >= key.Quota.Window {
+		win = &quotaWindow{start: now}
+		m.usage[key.ID] = win
+	}
+	if win.count >= key.Quota.MaxRequests {
+		return ErrAPIKeyQuotaExceeded
+	}
+	win.count++
+	return nil
+}
+
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func validScope(scope APIKeyScope) bool {
+	switch scope {
+	case ScopeAgentsRead, ScopeAgentsRun, ScopeSessions, ScopeKnowledgeRead, ScopeKnowledgeWrite, ScopeAdmin:
+		return true
+	}
+	return false
+}
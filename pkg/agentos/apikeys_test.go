@@ -0,0 +1,301 @@
+package agentos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/agent"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/team"
+)
+
+func TestAPIKeyManager_CreateAuthenticateRevoke(t *testing.T) {
+	ctx := context.Background()
+	mgr := NewAPIKeyManager(nil)
+
+	secret, key, err := mgr.Create(ctx, CreateAPIKeyRequest{Name: "ci", Scopes: []APIKeyScope{ScopeAgentsRun}})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if !strings.HasPrefix(secret, apiKeyPrefix) || !strings.HasPrefix(secret, key.Prefix) {
+		t.Fatalf("unexpected secret %q / prefix %q", secret, key.Prefix)
+	}
+	if key.Hash == "" || strings.Contains(key.Hash, secret) {
+		t.Fatalf("expected hashed secret, got %q", key.Hash)
+	}
+
+	got, err := mgr.Authenticate(ctx, secret)
+	if err != nil || got.ID != key.ID {
+		t.Fatalf("Authenticate() = %v, %v", got, err)
+	}
+	if _, err := mgr.Authenticate(ctx, apiKeyPrefix+"nope"); !errors.Is(err, ErrAPIKeyInvalid) {
+		t.Fatalf("expected ErrAPIKeyInvalid, got %v", err)
+	}
+
+	if err := mgr.Revoke(ctx, key.ID); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if _, err := mgr.Authenticate(ctx, secret); !errors.Is(err, ErrAPIKeyRevoked) {
+		t.Fatalf("expected ErrAPIKeyRevoked, got %v", err)
+	}
+	if err := mgr.Revoke(ctx, "missing"); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Fatalf("expected ErrAPIKeyNotFound, got %v", err)
+	}
+}
+
+func TestAPIKeyManager_ValidationExpiryAndQuota(t *testing.T) {
+	ctx := context.Background()
+	mgr := NewAPIKeyManager(nil)
+	now := time.Unix(1_700_000_000, 0)
+	mgr.now = func() time.Time { return now }
+
+	if _, _, err := mgr.Create(ctx, CreateAPIKeyRequest{}); err == nil {
+		t.Fatal("expected error for key without scopes")
+	}
+	if _, _, err := mgr.Create(ctx, CreateAPIKeyRequest{Scopes: []APIKeyScope{"bogus"}}); err == nil {
+		t.Fatal("expected error for unknown scope")
+	}
+
+	secret, key, err := mgr.Create(ctx, CreateAPIKeyRequest{
+		Scopes: []APIKeyScope{ScopeSessions},
+		Quota:  &APIKeyQuota{MaxRequests: 2},
+		TTL:    time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if key.Quota.Window != time.Minute {
+		t.Fatalf("expected default window, got %v", key.Quota.Window)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := mgr.consume(key); err != nil {
+			t.Fatalf("consume #%d error = %v", i, err)
+		}
+	}
+	if err := mgr.consume(key); !errors.Is(err, ErrAPIKeyQuotaExceeded) {
+		t.Fatalf("expected quota exceeded, got %v", err)
+	}
+	now = now.Add(time.Minute)
+	if err := mgr.consume(key); err != nil {
+		t.Fatalf("expected quota reset, got %v", err)
+	}
+
+	now = now.Add(time.Hour)
+	if _, err := mgr.Authenticate(ctx, secret); !errors.Is(err, ErrAPIKeyExpired) {
+		t.Fatalf("expected ErrAPIKeyExpired, got %v", err)
+	}
+}
+
+func TestServer_APIKeyMiddleware(t *testing.T) {
+	ctx := context.Background()
+	mgr := NewAPIKeyManager(nil)
+	server, err := NewServer(&Config{APIKeys: mgr})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	for _, id := range []string{"support", "billing"} {
+		ag, err := agent.New(agent.Config{Name: id, Model: &simpleModel{}})
+		if err != nil {
+			t.Fatalf("agent.New() error = %v", err)
+		}
+		if err := server.RegisterAgent(id, ag); err != nil {
+			t.Fatalf("RegisterAgent() error = %v", err)
+		}
+	}
+
+	supportKey, _, _ := mgr.Create(ctx, CreateAPIKeyRequest{
+		Scopes: []APIKeyScope{ScopeAgentsRun, ScopeAgentsRead},
+		Agents: []string{"support"},
+	})
+	adminKey, _, _ := mgr.Create(ctx, CreateAPIKeyRequest{Scopes: []APIKeyScope{ScopeAdmin}})
+
+	do := func(method, path, key string, body any) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			_ = json.NewEncoder(&buf).Encode(body)
+		}
+		req := httptest.NewRequest(method, path, &buf)
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do(http.MethodGet, "/health", "", nil); w.Code != http.StatusOK {
+		t.Fatalf("health should stay public, got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/api/v1/agents", "", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("missing key: got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/api/v1/sessions", supportKey, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("missing scope: got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/api/v1/agents/billing/run", supportKey, AgentRunRequest{Input: "hi"}); w.Code != http.StatusForbidden {
+		t.Fatalf("disallowed agent: got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/api/v1/agents/support/run", supportKey, AgentRunRequest{Input: "hi"}); w.Code != http.StatusOK {
+		t.Fatalf("allowed agent: got %d body=%s", w.Code, w.Body.String())
+	}
+
+	w := do(http.MethodGet, "/api/v1/agents", supportKey, nil)
+	var list struct {
+		Count int `json:"count"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &list)
+	if w.Code != http.StatusOK || list.Count != 1 {
+		t.Fatalf("agent list should be filtered, got %d count=%d", w.Code, list.Count)
+	}
+
+	if w := do(http.MethodPost, "/api/v1/keys", supportKey, APIKeyCreateRequest{Scopes: []APIKeyScope{ScopeSessions}}); w.Code != http.StatusForbidden {
+		t.Fatalf("non-admin key management: got %d", w.Code)
+	}
+	w = do(http.MethodPost, "/api/v1/keys", adminKey, APIKeyCreateRequest{
+		Name:        "limited",
+		Scopes:      []APIKeyScope{ScopeSessions},
+		MaxRequests: 1,
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create key: got %d body=%s", w.Code, w.Body.String())
+	}
+	var created APIKeyResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil || created.Key == "" || created.MaxRequests != 1 {
+		t.Fatalf("unexpected create response %s (%v)", w.Body.String(), err)
+	}
+
+	if w := do(http.MethodGet, "/api/v1/sessions", created.Key, nil); w.Code != http.StatusOK {
+		t.Fatalf("limited key first request: got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/api/v1/sessions", created.Key, nil); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("limited key quota: got %d", w.Code)
+	}
+
+	if w := do(http.MethodDelete, "/api/v1/keys/"+created.ID, adminKey, nil); w.Code != http.StatusOK {
+		t.Fatalf("revoke key: got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/api/v1/sessions", created.Key, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("revoked key: got %d", w.Code)
+	}
+}
+
+func TestServer_APIKeySessionsScopedToAgents(t *testing.T) {
+	ctx := context.Background()
+	mgr := NewAPIKeyManager(nil)
+	server, err := NewServer(&Config{APIKeys: mgr})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	supportKey, _, _ := mgr.Create(ctx, CreateAPIKeyRequest{
+		Scopes: []APIKeyScope{ScopeSessions},
+		Agents: []string{"support"},
+	})
+	adminKey, _, _ := mgr.Create(ctx, CreateAPIKeyRequest{Scopes: []APIKeyScope{ScopeAdmin}})
+
+	do := func(method, path, key string, body any) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			_ = json.NewEncoder(&buf).Encode(body)
+		}
+		req := httptest.NewRequest(method, path, &buf)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+key)
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		return w
+	}
+	create := func(agentID string) string {
+		w := do(http.MethodPost, "/api/v1/sessions", adminKey, CreateSessionRequest{AgentID: agentID})
+		var resp SessionResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); w.Code != http.StatusCreated || err != nil {
+			t.Fatalf("create session: got %d body=%s", w.Code, w.Body.String())
+		}
+		return resp.SessionID
+	}
+	supportSession := create("support")
+	billingSession := create("billing")
+
+	if w := do(http.MethodPost, "/api/v1/sessions", supportKey, CreateSessionRequest{AgentID: "billing"}); w.Code != http.StatusForbidden {
+		t.Fatalf("create for another agent: got %d", w.Code)
+	}
+
+	denied := []struct {
+		method, path string
+		body         any
+	}{
+		{http.MethodGet, "/api/v1/sessions/" + billingSession, nil},
+		{http.MethodPut, "/api/v1/sessions/" + billingSession, UpdateSessionRequest{Name: "stolen"}},
+		{http.MethodGet, "/api/v1/sessions/" + billingSession + "/summary", nil},
+		{http.MethodGet, "/api/v1/sessions/" + billingSession + "/history", nil},
+		{http.MethodPost, "/api/v1/sessions/" + billingSession + "/reuse", ReuseSessionRequest{AgentID: "support"}},
+		{http.MethodDelete, "/api/v1/sessions/" + billingSession, nil},
+	}
+	for _, tt := range denied {
+		if w := do(tt.method, tt.path, supportKey, tt.body); w.Code != http.StatusNotFound {
+			t.Errorf("%s %s: got %d, want 404", tt.method, tt.path, w.Code)
+		}
+	}
+	if w := do(http.MethodPost, "/api/v1/sessions/"+supportSession+"/reuse", supportKey, ReuseSessionRequest{AgentID: "billing"}); w.Code != http.StatusForbidden {
+		t.Errorf("reuse into another agent: got %d, want 403", w.Code)
+	}
+	if w := do(http.MethodGet, "/api/v1/sessions/"+billingSession, adminKey, nil); w.Code != http.StatusOK {
+		t.Fatalf("billing session should survive, got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/api/v1/sessions/"+supportSession, supportKey, nil); w.Code != http.StatusOK {
+		t.Fatalf("own session: got %d", w.Code)
+	}
+
+	for _, path := range []string{"/api/v1/sessions", "/api/v1/sessions?limit=10"} {
+		w := do(http.MethodGet, path, supportKey, nil)
+		var list struct {
+			Sessions []SessionResponse `json:"sessions"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &list)
+		if w.Code != http.StatusOK || len(list.Sessions) != 1 || list.Sessions[0].SessionID != supportSession {
+			t.Errorf("GET %s should list only the support session, got %d %s", path, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestServer_APIKeyTeamsRequireAllAgents(t *testing.T) {
+	ctx := context.Background()
+	mgr := NewAPIKeyManager(nil)
+	server, err := NewServer(&Config{APIKeys: mgr})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	member, err := agent.New(agent.Config{ID: "support", Model: &mockModel{BaseModel: models.BaseModel{ID: "m", Provider: "mock"}}})
+	if err != nil {
+		t.Fatalf("agent.New() error = %v", err)
+	}
+	tm, err := team.New(team.Config{ID: "desk", Agents: []*agent.Agent{member}})
+	if err != nil {
+		t.Fatalf("team.New() error = %v", err)
+	}
+	if err := server.RegisterTeam("desk", tm); err != nil {
+		t.Fatalf("RegisterTeam() error = %v", err)
+	}
+
+	restricted, _, _ := mgr.Create(ctx, CreateAPIKeyRequest{Scopes: []APIKeyScope{ScopeAgentsRead}, Agents: []string{"support"}})
+	unrestricted, _, _ := mgr.Create(ctx, CreateAPIKeyRequest{Scopes: []APIKeyScope{ScopeAgentsRead}})
+
+	for key, want := range map[string]int{restricted: http.StatusForbidden, unrestricted: http.StatusOK} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams/desk/tools", nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("GET /teams/desk/tools: got %d, want %d body=%s", w.Code, want, w.Body.String())
+		}
+	}
+}
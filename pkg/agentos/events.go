@@ -21,6 +21,10 @@ const (
 	// EventReasoning indicates reasoning content has been produced
 	EventReasoning EventType = "reasoning"
 
+	// EventReasoningDelta 推理增量事件（流式输出）
+	// EventReasoningDelta carries reasoning text as the model streams it
+	EventReasoningDelta EventType = "reasoning_delta"
+
 	// EventToolCall 工具调用事件
 	// EventToolCall indicates a tool call event
 	EventToolCall EventType = "tool_call"
@@ -74,6 +78,18 @@ type Event struct {
 	RunContextID string `json:"run_context_id,omitempty"`
 }
 
+// ReasoningDeltaData 推理增量事件数据
+// ReasoningDeltaData is the data for reasoning delta event
+type ReasoningDeltaData struct {
+	// Content 推理文本片段
+	// Content is the streamed piece of reasoning
+	Content string `json:"content"`
+
+	// Index 片段索引
+	// Index is the delta index
+	Index int `json:"index"`
+}
+
 // RunStartData 运行开始事件数据
 // RunStartData is the data for run start event
 type RunStartData struct {
@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -67,6 +68,73 @@ func (s *Server) sendSSE(w io.Writer, event *Event) error {
 	return err
 }
 
+// eventSink 是运行事件的目标，例如 SSE 或 WebSocket 客户端；客户端已失效时 send 返回 false
+// eventSink receives the events of a run, e.g. an SSE or WebSocket client; send returns
+// false once the client is gone
+type eventSink interface {
+	send(event *Event) bool
+}
+
+// sseStream 向单个客户端写入 SSE 事件，每次写入都有超时限制；写入失败或超时后会调用 onFail（通常取消运行），后续事件被丢弃
+// sseStream writes SSE events to one client with a timeout on every write; after a failed or
+// timed-out write it calls onFail (usually cancelling the run) and discards later events
+type sseStream struct {
+	server  *Server
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	timeout time.Duration
+	onFail  func()
+	err     error
+}
+
+func (s *Server) newSSEStream(w http.ResponseWriter, onFail func()) *sseStream {
+	var timeout time.Duration
+	if s.config != nil {
+		timeout = s.config.StreamSendTimeout
+	}
+	return &sseStream{
+		server:  s,
+		w:       w,
+		rc:      http.NewResponseController(w),
+		timeout: timeout,
+		onFail:  onFail,
+	}
+}
+
+// send 写入并刷新单个事件，客户端已失效时返回 false
+// send writes and flushes one event, returning false once the client is gone
+func (st *sseStream) send(event *Event) bool {
+	if st.err != nil {
+		return false
+	}
+	if st.timeout > 0 {
+		// Not every ResponseWriter supports deadlines; the write then just blocks as before
+		if err := st.rc.SetWriteDeadline(time.Now().Add(st.timeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			st.fail(err)
+			return false
+		}
+	}
+	if err := st.server.sendSSE(st.w, event); err != nil {
+		st.fail(err)
+		return false
+	}
+	if err := st.rc.Flush(); err != nil {
+		st.fail(err)
+		return false
+	}
+	return true
+}
+
+func (st *sseStream) fail(err error) {
+	st.err = err
+	if st.server.logger != nil {
+		st.server.logger.Warn("dropping slow or disconnected stream client", "error", err)
+	}
+	if st.onFail != nil {
+		st.onFail()
+	}
+}
+
 func buildReasoningEvents(messages []*types.Message, provider, modelID string) ([]*Event, *ReasoningSummary) {
 	if len(messages) == 0 {
 		return nil, nil
@@ -138,7 +206,9 @@ func buildUsageSummary(metadata map[string]interface{}) *UsageMetrics {
 	}
 }
 
-func (s *Server) emitRunEvents(w io.Writer, flusher http.Flusher, filter *EventFilter, agentID, sessionID string, runContextID string, output *agent.RunOutput, ag *agent.Agent) {
+// emitRunEvents 在运行结束后发送推理、用量和完成事件
+// emitRunEvents sends the reasoning, usage and completion events once the run has finished
+func (s *Server) emitRunEvents(stream eventSink, filter *EventFilter, agentID, sessionID string, runContextID string, output *agent.RunOutput, ag *agent.Agent) {
 	if output == nil {
 		return
 	}
@@ -158,8 +228,7 @@ func (s *Server) emitRunEvents(w io.Writer, flusher http.Flusher, filter *EventF
 		evt.SessionID = sessionID
 		evt.RunContextID = runContextID
 		if filter.ShouldSend(evt) {
-			s.sendSSE(w, evt)
-			flusher.Flush()
+			stream.send(evt)
 		}
 	}
 
@@ -202,8 +271,7 @@ func (s *Server) emitRunEvents(w io.Writer, flusher http.Flusher, filter *EventF
 	complete.SessionID = sessionID
 	complete.RunContextID = runContextID
 	if filter.ShouldSend(complete) {
-		s.sendSSE(w, complete)
-		flusher.Flush()
+		stream.send(complete)
 	}
 }
 
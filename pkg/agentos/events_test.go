@@ -2,6 +2,8 @@ package agentos
 
 import (
 	"encoding/json"
+	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
 	"time"
@@ -17,6 +19,7 @@ func TestEventTypes(t *testing.T) {
 	// Test all event type constants
 	assert.Equal(t, EventType("run_start"), EventRunStart)
 	assert.Equal(t, EventType("reasoning"), EventReasoning)
+	assert.Equal(t, EventType("reasoning_delta"), EventReasoningDelta)
 	assert.Equal(t, EventType("tool_call"), EventToolCall)
 	assert.Equal(t, EventType("token"), EventToken)
 	assert.Equal(t, EventType("step_start"), EventStepStart)
@@ -321,3 +324,30 @@ func TestEventWithSessionAndAgent(t *testing.T) {
 	assert.Contains(t, sse, "session789")
 	assert.Contains(t, sse, "agent123")
 }
+
+// stalledWriter 模拟已停止读取的客户端：写入总是超时
+// stalledWriter simulates a client that stopped reading: every write times out
+type stalledWriter struct {
+	*httptest.ResponseRecorder
+	writes int
+}
+
+func (w *stalledWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, os.ErrDeadlineExceeded
+}
+
+func TestSSEStream_FailedWriteCancelsOnce(t *testing.T) {
+	server := &Server{config: &Config{StreamSendTimeout: time.Millisecond}}
+	w := &stalledWriter{ResponseRecorder: httptest.NewRecorder()}
+	cancelled := 0
+	stream := server.newSSEStream(w, func() { cancelled++ })
+
+	assert.False(t, stream.send(NewEvent(EventToken, TokenData{Token: "a"})))
+	assert.False(t, stream.send(NewEvent(EventToken, TokenData{Token: "b"})))
+	assert.Equal(t, 1, cancelled)
+	assert.Equal(t, 1, w.writes, "events after a failed write are discarded")
+
+	ok := server.newSSEStream(httptest.NewRecorder(), nil)
+	assert.True(t, ok.send(NewEvent(EventToken, TokenData{Token: "a"})))
+}
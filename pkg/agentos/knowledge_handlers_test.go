@@ -49,6 +49,11 @@ func (m *MockVectorDB) Delete(ctx context.Context, ids []string) error {
 	return args.Error(0)
 }
 
+func (m *MockVectorDB) DeleteByFilter(ctx context.Context, filter map[string]interface{}) error {
+	args := m.Called(ctx, filter)
+	return args.Error(0)
+}
+
 func (m *MockVectorDB) Query(ctx context.Context, query string, limit int, filter map[string]interface{}) ([]vectordb.SearchResult, error) {
 	args := m.Called(ctx, query, limit, filter)
 	if args.Get(0) == nil {
@@ -78,6 +83,19 @@ func (m *MockVectorDB) Count(ctx context.Context) (int, error) {
 	return args.Int(0), args.Error(1)
 }
 
+func (m *MockVectorDB) ListCollections(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return []string{}, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockVectorDB) CollectionStats(ctx context.Context, name string) (vectordb.CollectionStats, error) {
+	args := m.Called(ctx, name)
+	return args.Get(0).(vectordb.CollectionStats), args.Error(1)
+}
+
 func (m *MockVectorDB) Close() error {
 	args := m.Called()
 	return args.Error(0)
@@ -15,6 +15,7 @@ import (
 	"github.com/jholhewres/agent-go/pkg/agentgo/team"
 	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb"
 	"github.com/jholhewres/agent-go/pkg/agentgo/vectordb/chromadb"
+	"github.com/jholhewres/agent-go/pkg/agentgo/webhooks"
 )
 
 // Server represents the AgentOS HTTP server
@@ -30,6 +31,8 @@ type Server struct {
 	summaryManager   *session.SummaryManager
 	instantiatedAt   time.Time
 	docsMounted      bool
+	apiKeys          *APIKeyManager
+	webhooks         *webhooks.Dispatcher
 }
 
 // Config holds server configuration
@@ -69,6 +72,16 @@ type Config struct {
 	// 最大请求大小 (字节)
 	MaxRequestSize int64
 
+	// Per-event write timeout for streaming responses; a client that does not accept an event
+	// in time is disconnected and its run cancelled (default: 30s)
+	// 流式响应中单个事件的写入超时；未能及时接收事件的客户端会被断开，其运行也会被取消（默认：30 秒）
+	StreamSendTimeout time.Duration
+
+	// How agent runs feed streaming responses when the client reads slower than the model
+	// streams (default: coalesce deltas, so a slow client never stalls the model stream)
+	// 客户端读取慢于模型输出时，代理运行如何向流式响应投递事件（默认：合并增量，慢客户端不会阻塞模型流）
+	StreamOptions *agent.StreamOptions
+
 	// VectorDBConfig 向量数据库配置（新增）
 	// VectorDBConfig is the vector database configuration (new)
 	VectorDBConfig *VectorDBConfig
@@ -86,6 +99,15 @@ type Config struct {
 
 	// HealthPath allows overriding the health check endpoint path.
 	HealthPath string
+
+	// APIKeys enables API key authentication on /api/v1 routes. When nil the
+	// API is unauthenticated. Health and docs endpoints are always public.
+	APIKeys *APIKeyManager
+
+	// Webhooks receives run.started, run.completed and run.failed events for
+	// agent runs, and approval.required when a run pauses for a tool approval.
+	// The server flushes pending deliveries on Shutdown.
+	Webhooks *webhooks.Dispatcher
 }
 
 // VectorDBConfig 向量数据库配置
@@ -238,6 +260,14 @@ func NewServer(config *Config) (*Server, error) {
 		config.RequestTimeout = 30 * time.Second
 	}
 
+	if config.StreamSendTimeout == 0 {
+		config.StreamSendTimeout = 30 * time.Second
+	}
+
+	if config.StreamOptions == nil {
+		config.StreamOptions = &agent.StreamOptions{Overflow: agent.StreamOverflowCoalesce}
+	}
+
 	if config.MaxRequestSize == 0 {
 		config.MaxRequestSize = 10 * 1024 * 1024 // 10MB
 	}
@@ -275,6 +305,8 @@ func NewServer(config *Config) (*Server, error) {
 		logger:         config.Logger,
 		summaryManager: config.SummaryManager,
 		instantiatedAt: time.Now().UTC(),
+		apiKeys:        config.APIKeys,
+		webhooks:       config.Webhooks,
 	}
 
 	// 初始化知识库服务（如果配置了）
@@ -323,6 +355,12 @@ func (s *Server) Shutdown(ctx context.Context) error {
 		}
 	}
 
+	if s.webhooks != nil {
+		if err := s.webhooks.Close(ctx); err != nil {
+			s.logger.Warn("failed to flush webhook deliveries", "error", err)
+		}
+	}
+
 	// Close storage
 	if s.sessionStorage != nil {
 		if err := s.sessionStorage.Close(); err != nil {
@@ -380,7 +418,7 @@ func (s *Server) registerRoutes() {
 	{
 		// Session endpoints
 		// Session 端点
-		sessions := v1.Group("/sessions")
+		sessions := v1.Group("/sessions", s.authorize(ScopeSessions, false))
 		{
 			sessions.POST("", s.handleCreateSession)
 			sessions.GET("/:id", s.handleGetSession)
@@ -397,13 +435,19 @@ func (s *Server) registerRoutes() {
 		// Agent 端点
 		agents := v1.Group("/agents")
 		{
-			agents.GET("", s.handleListAgents)
-			agents.POST("/:id/run", s.handleAgentRun)
-			agents.POST("/:id/run/stream", s.handleAgentRunStream) // P1: SSE 流式输出
+			agents.GET("", s.authorize(ScopeAgentsRead, false), s.handleListAgents)
+			agents.POST("/:id/run", s.authorize(ScopeAgentsRun, true), s.handleAgentRun)
+			agents.POST("/:id/run/stream", s.authorize(ScopeAgentsRun, true), s.handleAgentRunStream) // P1: SSE 流式输出
+			// Runs as a resource; streams SSE when requested with Accept: text/event-stream
+			// 以资源形式创建运行；请求头为 Accept: text/event-stream 时以 SSE 流式输出
+			agents.POST("/:id/runs", s.authorize(ScopeAgentsRun, true), s.handleAgentRun)
+			// Runs over a WebSocket, with mid-run cancellation
+			// 通过 WebSocket 运行，支持在运行中取消
+			agents.GET("/:id/ws", s.authorize(ScopeAgentsRun, true), s.handleAgentWebSocket)
 		}
 
 		// Team endpoints
-		teams := v1.Group("/teams")
+		teams := v1.Group("/teams", s.authorize(ScopeAgentsRead, false), s.requireAllAgents)
 		{
 			teams.GET("/:id/tools", s.handleTeamTools)
 		}
@@ -411,16 +455,26 @@ func (s *Server) registerRoutes() {
 		// Knowledge endpoints
 		if s.knowledgeService != nil {
 			knowledge := v1.Group("/knowledge")
-			knowledge.GET("/config", s.handleKnowledgeConfig)
+			knowledge.GET("/config", s.authorize(ScopeKnowledgeRead, false), s.handleKnowledgeConfig)
 			if s.knowledgeService.config.EnableSearch {
-				knowledge.POST("/search", s.handleKnowledgeSearch)
+				knowledge.POST("/search", s.authorize(ScopeKnowledgeRead, false), s.handleKnowledgeSearch)
 			}
 			if s.knowledgeService.config.EnableIngestion {
-				knowledge.POST("/content", s.handleAddContent)
-				knowledge.POST("/upload", s.handleAddContent)
+				knowledge.POST("/content", s.authorize(ScopeKnowledgeWrite, false), s.handleAddContent)
+				knowledge.POST("/upload", s.authorize(ScopeKnowledgeWrite, false), s.handleAddContent)
 			}
 			if s.knowledgeService.config.EnableHealth {
-				knowledge.GET("/health", s.handleKnowledgeHealth)
+				knowledge.GET("/health", s.authorize(ScopeKnowledgeRead, false), s.handleKnowledgeHealth)
+			}
+		}
+
+		// API key management (only when authentication is enabled)
+		if s.apiKeys != nil {
+			keys := v1.Group("/keys", s.authorize(ScopeAdmin, false))
+			{
+				keys.POST("", s.handleCreateAPIKey)
+				keys.GET("", s.handleListAPIKeys)
+				keys.DELETE("/:id", s.handleRevokeAPIKey)
 			}
 		}
 	}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -12,7 +13,9 @@ import (
 
 	"github.com/jholhewres/agent-go/pkg/agentgo/agent"
 	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/ratelimit"
 	"github.com/jholhewres/agent-go/pkg/agentgo/session"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
 	"github.com/jholhewres/agent-go/pkg/agentgo/types"
 )
 
@@ -600,6 +603,56 @@ func TestListSessions_WithFilter(t *testing.T) {
 	}
 }
 
+func TestListSessions_CursorPagination(t *testing.T) {
+	server, _ := NewServer(nil)
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i <= 3; i++ {
+		sess := session.NewSession(fmt.Sprintf("sess-%d", i), "agent-1")
+		sess.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		server.sessionStorage.Create(context.Background(), sess)
+	}
+
+	var ids []string
+	url := "/api/v1/sessions?limit=2&order=asc"
+	for pages := 0; url != ""; pages++ {
+		if pages > 2 {
+			t.Fatal("too many pages")
+		}
+		req, _ := http.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Status = %v, want %v: %s", w.Code, http.StatusOK, w.Body.String())
+		}
+
+		var response struct {
+			Sessions   []SessionResponse `json:"sessions"`
+			NextCursor string            `json:"next_cursor"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		for _, sess := range response.Sessions {
+			ids = append(ids, sess.SessionID)
+		}
+		url = ""
+		if response.NextCursor != "" {
+			url = "/api/v1/sessions?limit=2&order=asc&cursor=" + response.NextCursor
+		}
+	}
+	if fmt.Sprint(ids) != "[sess-1 sess-2 sess-3]" {
+		t.Errorf("ids = %v, want oldest first", ids)
+	}
+
+	req, _ := http.NewRequest("GET", "/api/v1/sessions?cursor=bogus!", nil)
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Status = %v, want %v for invalid cursor", w.Code, http.StatusBadRequest)
+	}
+}
+
 func TestAgentRun(t *testing.T) {
 	server, _ := NewServer(nil)
 
@@ -704,6 +757,101 @@ func TestAgentRun_Success(t *testing.T) {
 	}
 }
 
+func TestAgentRun_RateLimited(t *testing.T) {
+	server, _ := NewServer(nil)
+
+	model := &simpleModel{BaseModel: models.BaseModel{ID: "mock-model", Provider: "mock"}}
+	agentInstance, err := agent.New(agent.Config{
+		Name:      "limited",
+		Model:     model,
+		RateLimit: &ratelimit.Config{RequestsPerMinute: 1},
+	})
+	if err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+	if err := server.RegisterAgent("limited", agentInstance); err != nil {
+		t.Fatalf("failed to register agent: %v", err)
+	}
+
+	run := func() *httptest.ResponseRecorder {
+		body, _ := json.Marshal(AgentRunRequest{Input: "ping", RunContext: &RunContextRequest{UserID: "alice"}})
+		req, _ := http.NewRequest("POST", "/api/v1/agents/limited/run", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := run(); w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d", w.Code)
+	}
+	w := run()
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+	if retry := w.Header().Get("Retry-After"); retry == "" || retry == "0" {
+		t.Fatalf("expected a Retry-After header, got %q", retry)
+	}
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Code != string(types.ErrCodeRateLimitError) {
+		t.Fatalf("unexpected error response %s", w.Body.String())
+	}
+}
+
+func TestAgentRun_SessionState(t *testing.T) {
+	server, _ := NewServer(nil)
+
+	var prompt string
+	model := &funcModel{
+		simpleModel: simpleModel{BaseModel: models.BaseModel{ID: "mock-model", Provider: "mock"}},
+		invoke: func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+			prompt = req.Messages[0].Content
+			agent.SessionStateFromContext(ctx).Set("greeted", true)
+			return &types.ModelResponse{Content: "OK"}, nil
+		},
+	}
+	agentInstance, err := agent.New(agent.Config{
+		Name:         "stateful",
+		Model:        model,
+		Instructions: "The user is {{.state.name}}.",
+	})
+	if err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+	if err := server.RegisterAgent("stateful", agentInstance); err != nil {
+		t.Fatalf("failed to register agent: %v", err)
+	}
+
+	sess := session.NewSession("stateful-session", "stateful")
+	sess.State["name"] = "Ada"
+	if err := server.sessionStorage.Create(context.Background(), sess); err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	body, _ := json.Marshal(AgentRunRequest{Input: "hi", SessionID: "stateful-session"})
+	req, _ := http.NewRequest("POST", "/api/v1/agents/stateful/run", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	if prompt != "The user is Ada." {
+		t.Fatalf("expected the session state in the instructions, got %q", prompt)
+	}
+	stored, err := server.sessionStorage.Get(context.Background(), "stateful-session")
+	if err != nil {
+		t.Fatalf("failed to load session: %v", err)
+	}
+	if stored.State["name"] != "Ada" || stored.State["greeted"] != true {
+		t.Fatalf("expected the updated state to be stored, got %v", stored.State)
+	}
+	if _, ok := agentInstance.SessionState().Get("greeted"); ok {
+		t.Fatal("the session's state leaked into the shared agent")
+	}
+}
+
 func TestAgentRun_MediaOnly(t *testing.T) {
 	server, _ := NewServer(nil)
 
@@ -789,3 +937,170 @@ func TestAgentRun_StreamEvents(t *testing.T) {
 		t.Fatalf("expected complete event")
 	}
 }
+
+// funcModel answers Invoke with a function
+type funcModel struct {
+	simpleModel
+	invoke func(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error)
+}
+
+func (m *funcModel) Invoke(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+	return m.invoke(ctx, req)
+}
+
+// thinkingModel streams reasoning before its answer
+type thinkingModel struct {
+	simpleModel
+}
+
+func (m *thinkingModel) InvokeStream(ctx context.Context, req *models.InvokeRequest) (<-chan types.ResponseChunk, error) {
+	ch := make(chan types.ResponseChunk, 3)
+	ch <- types.ResponseChunk{ReasoningContent: "thinking it over"}
+	ch <- types.ResponseChunk{Content: "OK"}
+	ch <- types.ResponseChunk{Done: true}
+	close(ch)
+	return ch, nil
+}
+
+func TestAgentRun_StreamReasoningDeltas(t *testing.T) {
+	server, _ := NewServer(nil)
+
+	model := &thinkingModel{simpleModel{BaseModel: models.BaseModel{ID: "mock-model", Provider: "mock"}}}
+	agentInstance, err := agent.New(agent.Config{Name: "thinker", Model: model})
+	if err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+	if err := server.RegisterAgent("thinker", agentInstance); err != nil {
+		t.Fatalf("failed to register agent: %v", err)
+	}
+
+	body, _ := json.Marshal(AgentRunRequest{Input: "think"})
+	req, _ := http.NewRequest("POST", "/api/v1/agents/thinker/run?stream_events=true", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+
+	payload := w.Body.String()
+	reasoning := strings.Index(payload, "event: reasoning_delta")
+	token := strings.Index(payload, "event: token")
+	if reasoning < 0 || token < 0 || reasoning > token {
+		t.Fatalf("expected a reasoning_delta event before the tokens, got %s", payload)
+	}
+	if !strings.Contains(payload, `"content":"thinking it over"`) {
+		t.Fatalf("expected the reasoning text in the delta, got %s", payload)
+	}
+}
+
+// toolModel streams a call to the echo tool, then answers with its result
+type toolModel struct {
+	simpleModel
+}
+
+func (m *toolModel) InvokeStream(ctx context.Context, req *models.InvokeRequest) (<-chan types.ResponseChunk, error) {
+	ch := make(chan types.ResponseChunk, 2)
+	if last := req.Messages[len(req.Messages)-1]; last.Role == types.RoleTool {
+		ch <- types.ResponseChunk{Content: "echoed " + last.Content}
+	} else {
+		ch <- types.ResponseChunk{ToolCalls: []types.ToolCall{{
+			ID:       "call-1",
+			Type:     "function",
+			Function: types.ToolCallFunction{Name: "echo", Arguments: `{"text":"hi"}`},
+		}}}
+	}
+	ch <- types.ResponseChunk{Done: true}
+	close(ch)
+	return ch, nil
+}
+
+// registerToolAgent registers an agent that calls the echo tool once
+func registerToolAgent(t *testing.T, server *Server, id string) {
+	t.Helper()
+	tk := toolkit.NewBaseToolkit("echo")
+	tk.RegisterFunction(&toolkit.Function{
+		Name:       "echo",
+		Parameters: map[string]toolkit.Parameter{"text": {Type: "string", Required: true}},
+		Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			return args["text"], nil
+		},
+	})
+	ag, err := agent.New(agent.Config{
+		Name:     id,
+		Model:    &toolModel{simpleModel{BaseModel: models.BaseModel{ID: "mock-model", Provider: "mock"}}},
+		Toolkits: []toolkit.Toolkit{tk},
+	})
+	if err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+	if err := server.RegisterAgent(id, ag); err != nil {
+		t.Fatalf("failed to register agent: %v", err)
+	}
+}
+
+func TestAgentRun_StreamOmitsToolCallEvents(t *testing.T) {
+	server, _ := NewServer(nil)
+	registerToolAgent(t, server, "tools")
+
+	body, _ := json.Marshal(AgentRunRequest{Input: "echo hi"})
+	req, _ := http.NewRequest("POST", "/api/v1/agents/tools/runs", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+
+	payload := w.Body.String()
+	if !strings.Contains(payload, "event: complete") || !strings.Contains(payload, `"output":"echoed`) {
+		t.Fatalf("expected the run to complete with the tool result, got %s", payload)
+	}
+	// Tool call events are only sent over WebSocket
+	if strings.Contains(payload, "event: tool_call") {
+		t.Fatalf("unexpected tool_call event in SSE stream: %s", payload)
+	}
+}
+
+func TestAgentRuns_JSONAndEventStream(t *testing.T) {
+	server, _ := NewServer(nil)
+
+	model := &simpleModel{BaseModel: models.BaseModel{ID: "mock-model", Provider: "mock"}}
+	agentInstance, err := agent.New(agent.Config{
+		Name:  "runs",
+		Model: model,
+	})
+	if err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+	if err := server.RegisterAgent("runs", agentInstance); err != nil {
+		t.Fatalf("failed to register agent: %v", err)
+	}
+
+	body, _ := json.Marshal(AgentRunRequest{Input: "ping"})
+	req, _ := http.NewRequest("POST", "/api/v1/agents/runs/runs", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d", w.Code)
+	}
+	var resp AgentRunResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if resp.RunID == "" || resp.Status != agent.RunStatusCompleted {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+
+	req, _ = http.NewRequest("POST", "/api/v1/agents/runs/runs", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json;q=0.5, text/event-stream")
+	w = httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d", w.Code)
+	}
+	payload := w.Body.String()
+	if !strings.Contains(payload, "event: run_start") || !strings.Contains(payload, "event: complete") {
+		t.Fatalf("expected an event stream, got %s", payload)
+	}
+}
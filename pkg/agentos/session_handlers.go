@@ -10,6 +10,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/jholhewres/agent-go/pkg/agentgo/agent"
+	"github.com/jholhewres/agent-go/pkg/agentgo/pagination"
 	"github.com/jholhewres/agent-go/pkg/agentgo/session"
 	"github.com/jholhewres/agent-go/pkg/agentgo/types"
 )
@@ -93,6 +94,16 @@ func (s *Server) handleCreateSession(c *gin.Context) {
 		return
 	}
 
+	if key, _ := APIKeyFromContext(c); key != nil && !key.AllowsAgent(req.AgentID) {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Status:  "error",
+			Error:   "api key is not allowed to access this agent",
+			Message: req.AgentID,
+			Code:    "FORBIDDEN",
+		})
+		return
+	}
+
 	// Generate session ID
 	sessionID := uuid.New().String()
 
@@ -156,6 +167,9 @@ func (s *Server) handleGetSession(c *gin.Context) {
 		})
 		return
 	}
+	if s.hideSession(c, sess) {
+		return
+	}
 
 	c.JSON(http.StatusOK, sessionToResponse(sess))
 }
@@ -204,6 +218,9 @@ func (s *Server) handleUpdateSession(c *gin.Context) {
 		})
 		return
 	}
+	if s.hideSession(c, sess) {
+		return
+	}
 
 	// Update fields
 	if req.Name != "" {
@@ -246,7 +263,13 @@ func (s *Server) handleDeleteSession(c *gin.Context) {
 		return
 	}
 
-	err := s.sessionStorage.Delete(c.Request.Context(), sessionID)
+	sess, err := s.sessionStorage.Get(c.Request.Context(), sessionID)
+	if err == nil && s.hideSession(c, sess) {
+		return
+	}
+	if err == nil {
+		err = s.sessionStorage.Delete(c.Request.Context(), sessionID)
+	}
 	if err == session.ErrSessionNotFound {
 		c.JSON(http.StatusNotFound, ErrorResponse{
 			Status: "error",
@@ -292,6 +315,12 @@ func (s *Server) handleListSessions(c *gin.Context) {
 		filters["workflow_id"] = workflowID
 	}
 
+	// Cursor pagination is opt-in so existing clients keep getting every session.
+	if c.Query("cursor") != "" || c.Query("limit") != "" {
+		s.listSessionsPage(c, filters)
+		return
+	}
+
 	sessions, err := s.sessionStorage.List(c.Request.Context(), filters)
 	if err != nil {
 		s.logger.Error("failed to list sessions", "error", err)
@@ -304,9 +333,13 @@ func (s *Server) handleListSessions(c *gin.Context) {
 	}
 
 	// Convert to response format
-	responses := make([]SessionResponse, len(sessions))
-	for i, sess := range sessions {
-		responses[i] = sessionToResponse(sess)
+	key, _ := APIKeyFromContext(c)
+	responses := make([]SessionResponse, 0, len(sessions))
+	for _, sess := range sessions {
+		if key != nil && !key.AllowsAgent(sess.AgentID) {
+			continue
+		}
+		responses = append(responses, sessionToResponse(sess))
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -315,6 +348,56 @@ func (s *Server) handleListSessions(c *gin.Context) {
 	})
 }
 
+// listSessionsPage answers handleListSessions with one cursor page.
+func (s *Server) listSessionsPage(c *gin.Context, filters map[string]interface{}) {
+	opts := pagination.ListOptions{
+		Cursor:  c.Query("cursor"),
+		Limit:   parseIntQuery(c, "limit"),
+		Order:   pagination.Order(c.Query("order")),
+		Filters: make(map[string]string, len(filters)),
+	}
+	for key, value := range filters {
+		opts.Filters[key], _ = value.(string)
+	}
+
+	if _, _, err := opts.Prepare(); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid pagination parameters",
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+
+	page, err := session.ListPage(c.Request.Context(), s.sessionStorage, opts)
+	if err != nil {
+		s.logger.Error("failed to list sessions", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "failed to list sessions",
+			Message: err.Error(),
+			Code:    "STORAGE_ERROR",
+		})
+		return
+	}
+
+	// Sessions of agents outside the key's list are dropped, so a page may hold
+	// fewer than limit sessions while next_cursor is still set.
+	key, _ := APIKeyFromContext(c)
+	responses := make([]SessionResponse, 0, len(page.Items))
+	for _, sess := range page.Items {
+		if key != nil && !key.AllowsAgent(sess.AgentID) {
+			continue
+		}
+		responses = append(responses, sessionToResponse(sess))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sessions":    responses,
+		"count":       len(responses),
+		"next_cursor": page.NextCursor,
+	})
+}
+
 // handleGetSessionSummary returns the stored summary for a session.
 func (s *Server) handleGetSessionSummary(c *gin.Context) {
 	sessionID := c.Param("id")
@@ -344,6 +427,9 @@ func (s *Server) handleGetSessionSummary(c *gin.Context) {
 		})
 		return
 	}
+	if s.hideSession(c, sess) {
+		return
+	}
 
 	if sess.Summary == nil {
 		c.JSON(http.StatusNotFound, ErrorResponse{
@@ -394,6 +480,9 @@ func (s *Server) handlePostSessionSummary(c *gin.Context) {
 		})
 		return
 	}
+	if s.hideSession(c, sess) {
+		return
+	}
 
 	if async {
 		s.scheduleSessionSummary(sessionID)
@@ -464,6 +553,17 @@ func (s *Server) handleReuseSession(c *gin.Context) {
 		})
 		return
 	}
+	if s.hideSession(c, sess) {
+		return
+	}
+	if key, _ := APIKeyFromContext(c); key != nil && req.AgentID != "" && !key.AllowsAgent(req.AgentID) {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   "api key is not allowed to access this agent",
+			Message: req.AgentID,
+			Code:    "FORBIDDEN",
+		})
+		return
+	}
 
 	if req.AgentID != "" {
 		sess.AgentID = req.AgentID
@@ -522,6 +622,9 @@ func (s *Server) handleSessionHistory(c *gin.Context) {
 		})
 		return
 	}
+	if s.hideSession(c, sess) {
+		return
+	}
 
 	messages := cloneMessages(sess)
 	if limit := parseIntQuery(c, "num_messages"); limit > 0 && len(messages) > limit {
@@ -541,6 +644,21 @@ func (s *Server) handleSessionHistory(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// hideSession answers 404 when the request's API key may not access the
+// session's agent, so keys scoped to other agents cannot tell it exists.
+func (s *Server) hideSession(c *gin.Context, sess *session.Session) bool {
+	key, _ := APIKeyFromContext(c)
+	if key == nil || key.AllowsAgent(sess.AgentID) {
+		return false
+	}
+	c.JSON(http.StatusNotFound, ErrorResponse{
+		Status: "error",
+		Error:  "session not found",
+		Code:   "SESSION_NOT_FOUND",
+	})
+	return true
+}
+
 // sessionToResponse converts a session to API response format
 func sessionToResponse(sess *session.Session) SessionResponse {
 	return SessionResponse{
@@ -0,0 +1,50 @@
+package agentos
+
+import (
+	"github.com/jholhewres/agent-go/pkg/agentgo/agent"
+	"github.com/jholhewres/agent-go/pkg/agentgo/webhooks"
+)
+
+// emitRunStarted notifies webhook endpoints that a run has begun.
+func (s *Server) emitRunStarted(agentID, runID, sessionID, input string) {
+	if s.webhooks == nil {
+		return
+	}
+	evt := webhooks.NewEvent(webhooks.EventRunStarted, map[string]interface{}{
+		"input": input,
+	})
+	evt.AgentID, evt.RunID, evt.SessionID = agentID, runID, sessionID
+	s.webhooks.Emit(evt)
+}
+
+// emitRunFinished notifies webhook endpoints of a run's outcome: run.failed
+// when err is set, approval.required when the run paused for a tool approval,
+// run.completed otherwise.
+func (s *Server) emitRunFinished(agentID, runID, sessionID string, output *agent.RunOutput, err error) {
+	if s.webhooks == nil {
+		return
+	}
+	var evt webhooks.Event
+	if err != nil {
+		evt = webhooks.NewEvent(webhooks.EventRunFailed, map[string]interface{}{
+			"error": err.Error(),
+		})
+	} else if output != nil && output.Status == agent.RunStatusPaused && output.PendingApproval != nil {
+		evt = webhooks.NewEvent(webhooks.EventApprovalRequired, map[string]interface{}{
+			"approval_id": output.PendingApproval.ID,
+			"tool_calls":  output.PendingApproval.ToolCalls,
+		})
+	} else {
+		data := map[string]interface{}{}
+		if output != nil {
+			data["status"] = output.Status
+			data["content"] = output.Content
+		}
+		evt = webhooks.NewEvent(webhooks.EventRunCompleted, data)
+	}
+	if output != nil && output.RunID != "" {
+		runID = output.RunID
+	}
+	evt.AgentID, evt.RunID, evt.SessionID = agentID, runID, sessionID
+	s.webhooks.Emit(evt)
+}
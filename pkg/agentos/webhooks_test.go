@@ -0,0 +1,181 @@
+package agentos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/agent"
+	"github.com/jholhewres/agent-go/pkg/agentgo/hooks"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/tools/toolkit"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+	"github.com/jholhewres/agent-go/pkg/agentgo/webhooks"
+)
+
+func TestServer_EmitsRunWebhooks(t *testing.T) {
+	var mu sync.Mutex
+	var received []webhooks.Event
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var evt webhooks.Event
+		_ = json.NewDecoder(r.Body).Decode(&evt)
+		mu.Lock()
+		received = append(received, evt)
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+
+	dispatcher, err := webhooks.New(webhooks.Config{Endpoints: []webhooks.Endpoint{{URL: receiver.URL}}})
+	if err != nil {
+		t.Fatalf("webhooks.New() error = %v", err)
+	}
+	server, err := NewServer(&Config{Webhooks: dispatcher})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	ag, err := agent.New(agent.Config{Name: "hooked", Model: &simpleModel{}})
+	if err != nil {
+		t.Fatalf("agent.New() error = %v", err)
+	}
+	if err := server.RegisterAgent("hooked", ag); err != nil {
+		t.Fatalf("RegisterAgent() error = %v", err)
+	}
+
+	body, _ := json.Marshal(AgentRunRequest{Input: "hello"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/agents/hooked/run", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("run status = %d body=%s", w.Code, w.Body.String())
+	}
+	var resp AgentRunResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+
+	if err := server.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	types := map[string]webhooks.Event{}
+	for _, evt := range received {
+		types[evt.Type] = evt
+	}
+	started, ok := types[webhooks.EventRunStarted]
+	if !ok || started.AgentID != "hooked" || started.Data["input"] != "hello" {
+		t.Fatalf("missing or invalid run.started: %+v", received)
+	}
+	completed, ok := types[webhooks.EventRunCompleted]
+	if !ok || completed.RunID != resp.RunID || completed.Data["content"] != "OK" {
+		t.Fatalf("missing or invalid run.completed: %+v", received)
+	}
+	if started.RunID != completed.RunID {
+		t.Fatalf("run IDs differ: %q vs %q", started.RunID, completed.RunID)
+	}
+}
+
+// echoCallModel asks for the echo tool, then answers once the tool has run
+type echoCallModel struct {
+	simpleModel
+}
+
+func (m *echoCallModel) Invoke(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+	if last := req.Messages[len(req.Messages)-1]; last.Role == types.RoleTool {
+		return &types.ModelResponse{Content: "echoed " + last.Content}, nil
+	}
+	return &types.ModelResponse{ToolCalls: []types.ToolCall{{
+		ID:       "call-1",
+		Type:     "function",
+		Function: types.ToolCallFunction{Name: "echo", Arguments: `{"text":"hi"}`},
+	}}}, nil
+}
+
+func TestServer_EmitsApprovalRequiredWebhook(t *testing.T) {
+	var mu sync.Mutex
+	var received []webhooks.Event
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var evt webhooks.Event
+		_ = json.NewDecoder(r.Body).Decode(&evt)
+		mu.Lock()
+		received = append(received, evt)
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+
+	dispatcher, err := webhooks.New(webhooks.Config{Endpoints: []webhooks.Endpoint{{URL: receiver.URL}}})
+	if err != nil {
+		t.Fatalf("webhooks.New() error = %v", err)
+	}
+	server, err := NewServer(&Config{Webhooks: dispatcher})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	tk := toolkit.NewBaseToolkit("echo")
+	tk.RegisterFunction(&toolkit.Function{
+		Name:       "echo",
+		Parameters: map[string]toolkit.Parameter{"text": {Type: "string", Required: true}},
+		Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			return args["text"], nil
+		},
+	})
+	ag, err := agent.New(agent.Config{
+		Name:      "gated",
+		Model:     &echoCallModel{},
+		Toolkits:  []toolkit.Toolkit{tk},
+		ToolHooks: []hooks.ToolHook{hooks.NewDeferredApprovalHook("echo")},
+	})
+	if err != nil {
+		t.Fatalf("agent.New() error = %v", err)
+	}
+	if err := server.RegisterAgent("gated", ag); err != nil {
+		t.Fatalf("RegisterAgent() error = %v", err)
+	}
+
+	body, _ := json.Marshal(AgentRunRequest{Input: "echo hi"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/agents/gated/run", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("run status = %d body=%s", w.Code, w.Body.String())
+	}
+
+	if err := server.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	pending := ag.PendingApprovals()
+	if len(pending) != 1 {
+		t.Fatalf("expected one pending approval, got %d", len(pending))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var approval *webhooks.Event
+	for i, evt := range received {
+		switch evt.Type {
+		case webhooks.EventRunCompleted:
+			t.Fatalf("paused run reported as run.completed: %+v", evt)
+		case webhooks.EventApprovalRequired:
+			approval = &received[i]
+		}
+	}
+	if approval == nil {
+		t.Fatalf("missing approval.required: %+v", received)
+	}
+	if approval.AgentID != "gated" || approval.RunID != pending[0].RunID || approval.Data["approval_id"] != pending[0].ID {
+		t.Fatalf("invalid approval.required: %+v", approval)
+	}
+	calls, _ := approval.Data["tool_calls"].([]interface{})
+	if len(calls) != 1 {
+		t.Fatalf("expected one tool call, got %+v", approval.Data["tool_calls"])
+	}
+	if call, _ := calls[0].(map[string]interface{}); call["id"] != "call-1" {
+		t.Fatalf("unexpected tool call: %+v", calls[0])
+	}
+}
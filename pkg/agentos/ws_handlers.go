@@ -0,0 +1,289 @@
+package agentos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/net/websocket"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/agent"
+	"github.com/jholhewres/agent-go/pkg/agentgo/session"
+)
+
+// WSMessageType 是客户端通过 WebSocket 发送的消息类型
+// WSMessageType is the type of a message sent by a WebSocket client
+type WSMessageType string
+
+const (
+	// WSMessageRun 启动一次运行
+	// WSMessageRun starts a run
+	WSMessageRun WSMessageType = "run"
+
+	// WSMessageCancel 取消正在进行的运行
+	// WSMessageCancel cancels the run in progress
+	WSMessageCancel WSMessageType = "cancel"
+)
+
+// WSClientMessage 是客户端通过 WebSocket 发送的消息；run 消息携带与 AgentRunRequest 相同的字段
+// WSClientMessage is a message sent by a WebSocket client; run messages carry the same
+// fields as AgentRunRequest
+type WSClientMessage struct {
+	Type WSMessageType `json:"type"`
+	AgentRunRequest
+
+	// Types 限制此次运行发送的事件类型（为空表示全部）
+	// Types limits the event types sent for the run (empty means all)
+	Types []string `json:"types,omitempty"`
+}
+
+// wsRunTimeout 限制单次 WebSocket 运行的时长，与 SSE 运行一致
+// wsRunTimeout bounds one WebSocket run, like SSE runs
+const wsRunTimeout = 5 * time.Minute
+
+// handleAgentWebSocket 通过 WebSocket 运行代理：客户端发送 run 和 cancel 消息，服务器以 JSON
+// 帧发送与 SSE 相同的事件。每个连接同时只运行一次；连接关闭会取消正在进行的运行。
+// handleAgentWebSocket runs an agent over a WebSocket: the client sends run and cancel
+// messages and the server sends the same events as SSE, one JSON frame each. A connection
+// runs one run at a time; closing it cancels the run in progress.
+// GET /api/v1/agents/:id/ws
+func (s *Server) handleAgentWebSocket(c *gin.Context) {
+	agentID := c.Param("id")
+	ag, err := s.agentRegistry.Get(agentID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "agent not found",
+			Message: err.Error(),
+			Code:    "AGENT_NOT_FOUND",
+		})
+		return
+	}
+
+	// 连接的生命周期不受请求超时限制；每次运行都有自己的超时
+	// The connection outlives the request timeout; every run has its own
+	ctx := context.WithoutCancel(c.Request.Context())
+	server := websocket.Server{
+		Handshake: s.checkWebSocketOrigin,
+		Handler: func(conn *websocket.Conn) {
+			s.serveAgentWebSocket(ctx, conn, agentID, ag)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// checkWebSocketOrigin 在配置了 AllowOrigins 时拒绝来自其他来源的浏览器连接
+// checkWebSocketOrigin rejects browser connections from other origins when AllowOrigins
+// is configured
+func (s *Server) checkWebSocketOrigin(config *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" || s.config == nil || len(s.config.AllowOrigins) == 0 {
+		return nil
+	}
+	for _, allowed := range s.config.AllowOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return nil
+		}
+	}
+	return fmt.Errorf("origin %q is not allowed", origin)
+}
+
+// serveAgentWebSocket 读取客户端消息直到连接关闭
+// serveAgentWebSocket reads client messages until the connection closes
+func (s *Server) serveAgentWebSocket(ctx context.Context, conn *websocket.Conn, agentID string, ag *agent.Agent) {
+	ctx, closeConn := context.WithCancel(ctx)
+	sink := s.newWSStream(conn, closeConn)
+
+	var (
+		mu        sync.Mutex
+		cancelRun context.CancelFunc
+		runs      sync.WaitGroup
+	)
+	defer func() {
+		closeConn()
+		runs.Wait()
+	}()
+
+	for {
+		var msg WSClientMessage
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+				sink.sendError(agentID, "", "", "invalid message: "+err.Error(), "INVALID_REQUEST")
+				continue
+			}
+			return
+		}
+
+		switch msg.Type {
+		case WSMessageRun:
+			mu.Lock()
+			busy := cancelRun != nil
+			mu.Unlock()
+			if busy {
+				sink.sendError(agentID, msg.SessionID, "", "a run is already in progress", "RUN_IN_PROGRESS")
+				continue
+			}
+
+			req := msg.AgentRunRequest
+			attachments, err := normalizeRunRequest(&req)
+			if err != nil {
+				code := "INVALID_REQUEST"
+				if errors.Is(err, errInvalidMediaPayload) {
+					code = "INVALID_MEDIA"
+				}
+				sink.sendError(agentID, req.SessionID, "", err.Error(), code)
+				continue
+			}
+
+			runCtx, baseRunCtx := deriveRunContext(ctx, req.RunContext, req.SessionID)
+			if req.SessionID == "" && baseRunCtx != nil && baseRunCtx.SessionID != "" {
+				req.SessionID = baseRunCtx.SessionID
+			}
+			var sess *session.Session
+			if req.SessionID != "" {
+				sess, err = s.sessionStorage.Get(ctx, req.SessionID)
+				if err != nil {
+					code := "STORAGE_ERROR"
+					if errors.Is(err, session.ErrSessionNotFound) {
+						code = "SESSION_NOT_FOUND"
+					}
+					sink.sendError(agentID, req.SessionID, "", err.Error(), code)
+					continue
+				}
+			}
+
+			runCtxID := ""
+			if baseRunCtx != nil {
+				runCtxID = baseRunCtx.EnsureRunID()
+			}
+			if runCtxID == "" {
+				runCtxID = "rc-" + uuid.NewString()
+			}
+			runCtx, cancel := context.WithTimeout(runCtx, wsRunTimeout)
+			runCtx = agent.WithRunContext(runCtx, runCtxID)
+			if s.config != nil && s.config.StreamOptions != nil {
+				runCtx = agent.WithStreamOptions(runCtx, *s.config.StreamOptions)
+			}
+
+			mu.Lock()
+			cancelRun = cancel
+			mu.Unlock()
+			// The connection accepts the next run before the final event of this one is
+			// written, so a client may start it as soon as it reads that event
+			// 在写入本次运行的最终事件之前连接就接受下一次运行，客户端读到该事件后即可开始下一次运行
+			var finishOnce sync.Once
+			finish := func() {
+				finishOnce.Do(func() {
+					mu.Lock()
+					cancelRun = nil
+					mu.Unlock()
+				})
+			}
+			runs.Add(1)
+			go func() {
+				defer runs.Done()
+				defer cancel()
+				defer finish()
+				filter := NewEventFilter(msg.Types)
+				s.runStream(runCtx, &wsRunStream{wsStream: sink, finish: finish}, filter, agentID, ag, req, attachments, sess, runCtxID, true)
+			}()
+
+		case WSMessageCancel:
+			mu.Lock()
+			if cancelRun != nil {
+				cancelRun()
+			}
+			mu.Unlock()
+
+		default:
+			sink.sendError(agentID, msg.SessionID, "", fmt.Sprintf("unknown message type %q", msg.Type), "INVALID_REQUEST")
+		}
+	}
+}
+
+// wsStream 以 JSON 帧向单个 WebSocket 客户端写入事件，写入串行进行且有超时限制；
+// 写入失败后会调用 onFail（关闭连接），后续事件被丢弃
+// wsStream writes events to one WebSocket client as JSON frames, one write at a time
+// with a timeout; after a failed write it calls onFail (closing the connection) and
+// discards later events
+type wsStream struct {
+	server  *Server
+	conn    *websocket.Conn
+	timeout time.Duration
+	onFail  func()
+
+	mu  sync.Mutex
+	err error
+}
+
+func (s *Server) newWSStream(conn *websocket.Conn, onFail func()) *wsStream {
+	var timeout time.Duration
+	if s.config != nil {
+		timeout = s.config.StreamSendTimeout
+	}
+	return &wsStream{server: s, conn: conn, timeout: timeout, onFail: onFail}
+}
+
+// send 写入单个事件，客户端已失效时返回 false
+// send writes one event, returning false once the client is gone
+func (st *wsStream) send(event *Event) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.err != nil {
+		return false
+	}
+	if st.timeout > 0 {
+		if err := st.conn.SetWriteDeadline(time.Now().Add(st.timeout)); err != nil {
+			st.fail(err)
+			return false
+		}
+	}
+	if err := websocket.JSON.Send(st.conn, event); err != nil {
+		st.fail(err)
+		return false
+	}
+	return true
+}
+
+// sendError 发送不属于任何运行的错误事件
+// sendError sends an error event outside of a run
+func (st *wsStream) sendError(agentID, sessionID, runContextID, message, code string) {
+	evt := NewEvent(EventError, ErrorData{Error: message, Code: code})
+	evt.AgentID = agentID
+	evt.SessionID = sessionID
+	evt.RunContextID = runContextID
+	st.send(evt)
+}
+
+// wsRunStream 是单次运行的 wsStream，在发送最终事件之前结束运行
+// wsRunStream is the wsStream of one run; it finishes the run before sending its final event
+type wsRunStream struct {
+	*wsStream
+	finish func()
+}
+
+func (st *wsRunStream) send(event *Event) bool {
+	if event.Type == EventComplete || event.Type == EventError {
+		st.finish()
+	}
+	return st.wsStream.send(event)
+}
+
+func (st *wsStream) fail(err error) {
+	st.err = err
+	if st.server.logger != nil {
+		st.server.logger.Warn("dropping slow or disconnected websocket client", "error", err)
+	}
+	if st.onFail != nil {
+		st.onFail()
+	}
+	st.conn.Close()
+}
@@ -0,0 +1,187 @@
+package agentos
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/jholhewres/agent-go/pkg/agentgo/agent"
+	"github.com/jholhewres/agent-go/pkg/agentgo/models"
+	"github.com/jholhewres/agent-go/pkg/agentgo/types"
+)
+
+// blockingModel streams one chunk and then waits for the run to be cancelled.
+type blockingModel struct {
+	models.BaseModel
+}
+
+func (m *blockingModel) Invoke(ctx context.Context, req *models.InvokeRequest) (*types.ModelResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (m *blockingModel) InvokeStream(ctx context.Context, req *models.InvokeRequest) (<-chan types.ResponseChunk, error) {
+	ch := make(chan types.ResponseChunk)
+	go func() {
+		defer close(ch)
+		select {
+		case ch <- types.ResponseChunk{Content: "thinking"}:
+		case <-ctx.Done():
+			return
+		}
+		<-ctx.Done()
+	}()
+	return ch, nil
+}
+
+func dialAgentWebSocket(t *testing.T, server *Server, agentID string) *websocket.Conn {
+	t.Helper()
+	ts := httptest.NewServer(server.router)
+	t.Cleanup(ts.Close)
+
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/api/v1/agents/" + agentID + "/ws"
+	conn, err := websocket.Dial(url, "", ts.URL)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// receiveUntil reads events until one of type want arrives
+func receiveUntil(t *testing.T, conn *websocket.Conn, want EventType) []EventType {
+	t.Helper()
+	var got []EventType
+	for {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var evt struct {
+			Type EventType `json:"type"`
+		}
+		if err := websocket.JSON.Receive(conn, &evt); err != nil {
+			t.Fatalf("receive after %v: %v", got, err)
+		}
+		got = append(got, evt.Type)
+		if evt.Type == want {
+			return got
+		}
+	}
+}
+
+func TestAgentWebSocket_Runs(t *testing.T) {
+	server, _ := NewServer(nil)
+	ag, err := agent.New(agent.Config{Name: "ws", Model: &simpleModel{BaseModel: models.BaseModel{ID: "mock-model", Provider: "mock"}}})
+	if err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+	if err := server.RegisterAgent("ws", ag); err != nil {
+		t.Fatalf("failed to register agent: %v", err)
+	}
+	conn := dialAgentWebSocket(t, server, "ws")
+
+	// Two runs on the same connection, the second started as soon as the first completes
+	for i := 0; i < 2; i++ {
+		if err := websocket.JSON.Send(conn, WSClientMessage{Type: WSMessageRun, AgentRunRequest: AgentRunRequest{Input: "hello"}}); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+		got := receiveUntil(t, conn, EventComplete)
+		if got[0] != EventRunStart || !containsEventType(got, EventToken) {
+			t.Fatalf("run %d events = %v", i, got)
+		}
+	}
+
+	if err := websocket.JSON.Send(conn, WSClientMessage{Type: "bogus"}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	receiveUntil(t, conn, EventError)
+}
+
+func TestAgentWebSocket_ToolCallEvents(t *testing.T) {
+	server, _ := NewServer(nil)
+	registerToolAgent(t, server, "tools")
+	conn := dialAgentWebSocket(t, server, "tools")
+
+	if err := websocket.JSON.Send(conn, WSClientMessage{Type: WSMessageRun, AgentRunRequest: AgentRunRequest{Input: "echo hi"}}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	// The tool call is sent when it finishes, before the answer that uses its result
+	var got []EventType
+	var call ToolCallData
+	for len(got) == 0 || got[len(got)-1] != EventComplete {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var evt struct {
+			Type EventType       `json:"type"`
+			Data json.RawMessage `json:"data"`
+		}
+		if err := websocket.JSON.Receive(conn, &evt); err != nil {
+			t.Fatalf("receive after %v: %v", got, err)
+		}
+		if evt.Type == EventToolCall {
+			if err := json.Unmarshal(evt.Data, &call); err != nil {
+				t.Fatalf("decode tool_call: %v", err)
+			}
+		}
+		got = append(got, evt.Type)
+	}
+
+	calls, firstToken := 0, -1
+	for i, typ := range got {
+		switch {
+		case typ == EventToolCall:
+			calls++
+			if firstToken >= 0 {
+				t.Fatalf("tool_call after the answer's tokens: %v", got)
+			}
+		case typ == EventToken && firstToken < 0:
+			firstToken = i
+		}
+	}
+	if calls != 1 || firstToken < 0 {
+		t.Fatalf("events = %v, want one tool_call followed by tokens", got)
+	}
+	if call.ToolName != "echo" || call.Arguments["text"] != "hi" || call.Result != "hi" {
+		t.Errorf("tool_call data = %+v", call)
+	}
+}
+
+func TestAgentWebSocket_Cancel(t *testing.T) {
+	server, _ := NewServer(nil)
+	ag, err := agent.New(agent.Config{Name: "slow", Model: &blockingModel{BaseModel: models.BaseModel{ID: "slow", Provider: "mock"}}})
+	if err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+	if err := server.RegisterAgent("slow", ag); err != nil {
+		t.Fatalf("failed to register agent: %v", err)
+	}
+	conn := dialAgentWebSocket(t, server, "slow")
+
+	if err := websocket.JSON.Send(conn, WSClientMessage{Type: WSMessageRun, AgentRunRequest: AgentRunRequest{Input: "think hard"}}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	receiveUntil(t, conn, EventToken)
+
+	// A second run is rejected while the first is in progress
+	if err := websocket.JSON.Send(conn, WSClientMessage{Type: WSMessageRun, AgentRunRequest: AgentRunRequest{Input: "again"}}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	receiveUntil(t, conn, EventError)
+
+	if err := websocket.JSON.Send(conn, WSClientMessage{Type: WSMessageCancel}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	receiveUntil(t, conn, EventError)
+}
+
+func containsEventType(types []EventType, want EventType) bool {
+	for _, typ := range types {
+		if typ == want {
+			return true
+		}
+	}
+	return false
+}